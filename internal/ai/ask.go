@@ -0,0 +1,158 @@
+package ai
+
+// Natural language search. TranslateQuery asks the model to turn a question
+// into an ArgusQL search — the same filter language as /api/search — plus a
+// time window. The caller runs the search, so what the model produced can
+// be shown next to the results and checked.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// MaxQuestionLength bounds the question text sent to the model.
+const MaxQuestionLength = 500
+
+// defaultAskLookback is the window searched when the question names none.
+const defaultAskLookback = time.Hour
+
+var (
+	// ErrDisabled is returned when no LLM is configured (AI_ENABLED unset or
+	// the client failed to initialise).
+	ErrDisabled = errors.New("AI is not enabled")
+	// ErrUntranslatable wraps a model answer that is not a usable search.
+	ErrUntranslatable = errors.New("question could not be translated into a search")
+)
+
+// QueryTranslation is a question translated into a search over traces or
+// logs in [Start, End].
+type QueryTranslation struct {
+	Signal string    `json:"signal"` // storage.SearchSignalTraces or storage.SearchSignalLogs
+	Query  string    `json:"query"`  // ArgusQL
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// TranslateQuery asks the model to translate question into a search. The
+// query is not validated against the search fields here; running it does
+// that. Returns ErrDisabled without an LLM and ErrUntranslatable when the
+// answer cannot be used.
+func (s *Service) TranslateQuery(ctx context.Context, question string) (*QueryTranslation, error) {
+	if s == nil || !s.enabled {
+		return nil, ErrDisabled
+	}
+	now := s.now().UTC()
+	callCtx, cancel := context.WithTimeout(ctx, analysisTimeout)
+	defer cancel()
+	completion, err := s.llm.Complete(callCtx, buildAskPrompt(question, now))
+	if err != nil {
+		return nil, fmt.Errorf("translate question: %w", err)
+	}
+	return parseTranslation(completion, now)
+}
+
+func buildAskPrompt(question string, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(`Translate the question below into a search over stored telemetry. Reply with only a JSON object:
+{"signal": "traces" or "logs", "query": "<ArgusQL>", "since": "<lookback, e.g. 15m, 1h, 7d>"}
+When the question names absolute times, give "start" and "end" (RFC3339) instead of "since". Without any time in the question, use "since": "1h".
+
+ArgusQL is comparisons joined by AND, OR, NOT and parentheses. Operators: = != > >= < <= ~ (contains) !~ (does not contain). Values: "quoted strings", numbers, durations with a unit (500ms, 1.5s, 2m) or bare words.
+Trace fields (a trace matches when one of its spans matches): service, name (operation), duration, status (ok, error, unset; = and != only), trace_id, span_id, parent_span_id, region, attr.<span attribute key>.
+Log fields: service, severity (trace, debug, info, warn, error, fatal; > and < compare levels), body, trace_id, span_id, region, attr.<log attribute key>.
+Search traces for latency and failed requests, logs for messages and stack traces. Match words from messages or operation names with ~.
+
+Example: "slow checkout calls that failed today" gives {"signal": "traces", "query": "service=\"checkout\" AND duration>1s AND status=error", "since": "24h"}
+
+`)
+	fmt.Fprintf(&b, "Current time: %s\nQuestion: %s\n", now.Format(time.RFC3339), question)
+	return b.String()
+}
+
+// parseTranslation reads the model's JSON answer, tolerating prose or code
+// fences around the object, and resolves its time window against now.
+func parseTranslation(completion string, now time.Time) (*QueryTranslation, error) {
+	i, j := strings.Index(completion, "{"), strings.LastIndex(completion, "}")
+	if i < 0 || j < i {
+		return nil, fmt.Errorf("%w: no JSON object in answer", ErrUntranslatable)
+	}
+	var raw struct {
+		Signal string `json:"signal"`
+		Query  string `json:"query"`
+		Since  string `json:"since"`
+		Start  string `json:"start"`
+		End    string `json:"end"`
+	}
+	if err := json.Unmarshal([]byte(completion[i:j+1]), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUntranslatable, err)
+	}
+
+	t := &QueryTranslation{Signal: strings.ToLower(strings.TrimSpace(raw.Signal)), Query: strings.TrimSpace(raw.Query)}
+	switch t.Signal {
+	case "", "trace", storage.SearchSignalTraces:
+		t.Signal = storage.SearchSignalTraces
+	case "log", storage.SearchSignalLogs:
+		t.Signal = storage.SearchSignalLogs
+	default:
+		return nil, fmt.Errorf("%w: unknown signal %q", ErrUntranslatable, raw.Signal)
+	}
+	if t.Query == "" {
+		return nil, fmt.Errorf("%w: empty query", ErrUntranslatable)
+	}
+
+	t.End = now
+	if raw.End != "" {
+		end, err := time.Parse(time.RFC3339, raw.End)
+		if err != nil {
+			return nil, fmt.Errorf("%w: end: %v", ErrUntranslatable, err)
+		}
+		t.End = end
+	}
+	switch {
+	case raw.Start != "":
+		start, err := time.Parse(time.RFC3339, raw.Start)
+		if err != nil {
+			return nil, fmt.Errorf("%w: start: %v", ErrUntranslatable, err)
+		}
+		t.Start = start
+	case raw.Since != "":
+		d, err := parseLookback(raw.Since)
+		if err != nil {
+			return nil, fmt.Errorf("%w: since: %v", ErrUntranslatable, err)
+		}
+		t.Start = t.End.Add(-d)
+	default:
+		t.Start = t.End.Add(-defaultAskLookback)
+	}
+	if !t.Start.Before(t.End) {
+		return nil, fmt.Errorf("%w: start %s is not before end %s", ErrUntranslatable, t.Start.Format(time.RFC3339), t.End.Format(time.RFC3339))
+	}
+	return t, nil
+}
+
+// parseLookback parses a Go duration, also accepting whole days and weeks
+// ("7d", "2w"), which models reach for.
+func parseLookback(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid lookback %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid lookback %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTranslation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseTranslation("Here you go:\n```json\n{\"signal\": \"traces\", \"query\": \"service=\\\"payment-service\\\" AND duration>1s\", \"since\": \"1h\"}\n```", now)
+	if err != nil {
+		t.Fatalf("parseTranslation: %v", err)
+	}
+	if got.Signal != "traces" || got.Query != `service="payment-service" AND duration>1s` || !got.End.Equal(now) || !got.Start.Equal(now.Add(-time.Hour)) {
+		t.Errorf("translation = %+v", got)
+	}
+
+	got, err = parseTranslation(`{"signal":"log","query":"severity>=error","since":"7d"}`, now)
+	if err != nil || got.Signal != "logs" || !got.Start.Equal(now.Add(-7*24*time.Hour)) {
+		t.Errorf("days lookback = %+v, %v", got, err)
+	}
+
+	got, err = parseTranslation(`{"query":"status=error","start":"2026-03-01T08:00:00Z","end":"2026-03-01T09:00:00Z"}`, now)
+	if err != nil || got.Signal != "traces" || got.Start.Hour() != 8 || got.End.Hour() != 9 {
+		t.Errorf("absolute window = %+v, %v", got, err)
+	}
+
+	got, err = parseTranslation(`{"signal":"traces","query":"status=error"}`, now)
+	if err != nil || !got.Start.Equal(now.Add(-defaultAskLookback)) {
+		t.Errorf("default window = %+v, %v", got, err)
+	}
+
+	for _, bad := range []string{
+		"I cannot help with that.",
+		`{"signal":"metrics","query":"x=1"}`,
+		`{"signal":"traces","query":"  "}`,
+		`{"signal":"traces","query":"status=error","since":"forever"}`,
+		`{"signal":"traces","query":"status=error","start":"2026-03-01T10:00:00Z","end":"2026-03-01T09:00:00Z"}`,
+	} {
+		if _, err := parseTranslation(bad, now); !errors.Is(err, ErrUntranslatable) {
+			t.Errorf("%s: err = %v, want ErrUntranslatable", bad, err)
+		}
+	}
+}
+
+func TestTranslateQuery(t *testing.T) {
+	llm := &fakeAnswerClient{answer: `{"signal":"traces","query":"status=error","since":"15m"}`}
+	svc := NewServiceWithClient(nil, llm)
+	defer svc.Stop()
+
+	got, err := svc.TranslateQuery(context.Background(), "failed requests in the last 15 minutes")
+	if err != nil || got.Query != "status=error" || got.End.Sub(got.Start) != 15*time.Minute {
+		t.Fatalf("TranslateQuery = %+v, %v", got, err)
+	}
+	if !strings.Contains(llm.prompt, "Question: failed requests in the last 15 minutes") {
+		t.Errorf("prompt lacks the question:\n%s", llm.prompt)
+	}
+
+	var disabled *Service
+	if _, err := disabled.TranslateQuery(context.Background(), "q"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("nil service err = %v, want ErrDisabled", err)
+	}
+	if _, err := (&Service{}).TranslateQuery(context.Background(), "q"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("disabled service err = %v, want ErrDisabled", err)
+	}
+}
+
+type fakeAnswerClient struct {
+	answer string
+	prompt string
+}
+
+func (c *fakeAnswerClient) Complete(_ context.Context, prompt string) (string, error) {
+	c.prompt = prompt
+	return c.answer, nil
+}
@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// Client is the LLM backend the Service prompts. Implement it to plug in a
+// provider NewClientFromEnv does not cover (see NewServiceWithClient).
+type Client interface {
+	// Complete returns the model's completion of prompt.
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// AI_PROVIDER values understood by NewClientFromEnv.
+const (
+	ProviderAzure  = "azure"
+	ProviderOpenAI = "openai"
+)
+
+// NewClientFromEnv builds the Client selected by AI_PROVIDER:
+//
+//   - "azure" (default): Azure OpenAI, configured by AZURE_OPENAI_ENDPOINT,
+//     AZURE_OPENAI_KEY, AZURE_OPENAI_MODEL, AZURE_OPENAI_DEPLOYMENT and
+//     AZURE_OPENAI_API_VERSION.
+//   - "openai": any OpenAI-compatible chat completions endpoint (OpenAI,
+//     vLLM, Ollama, LiteLLM, ...), configured by OPENAI_BASE_URL,
+//     OPENAI_API_KEY and OPENAI_MODEL. Servers without auth still need a
+//     placeholder key.
+func NewClientFromEnv() (Client, error) {
+	var opts []openai.Option
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("AI_PROVIDER"))); provider {
+	case "", ProviderAzure:
+		opts = []openai.Option{
+			openai.WithAPIType(openai.APITypeAzure),
+			openai.WithBaseURL(os.Getenv("AZURE_OPENAI_ENDPOINT")),
+			openai.WithToken(os.Getenv("AZURE_OPENAI_KEY")),
+			openai.WithModel(os.Getenv("AZURE_OPENAI_MODEL")),
+		}
+		if deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT"); deployment != "" {
+			opts = append(opts, openai.WithModel(deployment))
+		}
+		if apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION"); apiVersion != "" {
+			opts = append(opts, openai.WithAPIVersion(apiVersion))
+		}
+	case ProviderOpenAI:
+		// langchaingo reads OPENAI_BASE_URL, OPENAI_API_KEY and OPENAI_MODEL.
+		opts = []openai.Option{openai.WithAPIType(openai.APITypeOpenAI)}
+	default:
+		return nil, fmt.Errorf("unknown AI_PROVIDER %q (want %s or %s)", provider, ProviderAzure, ProviderOpenAI)
+	}
+
+	llm, err := openai.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return langchainClient{llm: llm}, nil
+}
+
+// langchainClient adapts a langchaingo model to Client.
+type langchainClient struct {
+	llm llms.Model
+}
+
+func (c langchainClient) Complete(ctx context.Context, prompt string) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, c.llm, prompt)
+}
@@ -0,0 +1,211 @@
+package ai
+
+// Root-cause analysis of error traces. ObserveSpan marks a trace once one of
+// its spans fails; after the trace has been quiet for rcaDelay the trace is
+// loaded with its spans and logs — span events (exceptions) are stored as
+// logs at ingest, so they arrive with them — and the model is asked for a
+// root-cause summary, stored as a storage.TraceInsight.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// rcaMaxPending caps the traces waiting out their delay. Past the cap
+	// new error traces are not analysed rather than growing the map under
+	// an error storm.
+	rcaMaxPending = 10_000
+
+	// Prompt bounds, so one huge trace cannot blow the model's context.
+	rcaMaxSpans    = 40
+	rcaMaxLogs     = 40
+	rcaMaxFieldLen = 500 // bytes per log body or attribute blob
+)
+
+// spanStatusError is the OTLP status code string stored on failed spans.
+const spanStatusError = "STATUS_CODE_ERROR"
+
+// ObserveSpan feeds an ingested span to trace root-cause analysis: a failed
+// span queues its trace, and any span of a queued trace pushes its analysis
+// out by another delay. Wire into TraceServer.SetSpanCallback.
+func (s *Service) ObserveSpan(span storage.Span) {
+	if !s.enabled || s.rcaDelay <= 0 || span.TraceID == "" {
+		return
+	}
+	key := storage.TraceKey{TenantID: span.TenantID, TraceID: span.TraceID}
+	now := s.now()
+	s.rcaMu.Lock()
+	if _, ok := s.rcaPending[key]; ok || (span.Status == spanStatusError && len(s.rcaPending) < rcaMaxPending) {
+		s.rcaPending[key] = now
+	}
+	s.rcaMu.Unlock()
+}
+
+func (s *Service) rcaLoop() {
+	defer close(s.rcaDone)
+	if s.rcaDelay <= 0 {
+		<-s.rcaStop
+		return
+	}
+	tick := time.NewTicker(max(s.rcaDelay/2, time.Second))
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.rcaStop:
+			return
+		case now := <-tick.C:
+			s.sweepRCA(now)
+		}
+	}
+}
+
+// sweepRCA queues every trace quiet since now-rcaDelay for analysis.
+func (s *Service) sweepRCA(now time.Time) {
+	cutoff := now.Add(-s.rcaDelay)
+	var due []storage.TraceKey
+	s.rcaMu.Lock()
+	for k, last := range s.rcaPending {
+		if !last.After(cutoff) {
+			due = append(due, k)
+			delete(s.rcaPending, k)
+		}
+	}
+	s.rcaMu.Unlock()
+
+	for _, k := range due {
+		select {
+		case s.workQueue <- task{trace: k}:
+		default:
+			log.Printf("AI work queue full, dropping root-cause analysis of trace %s", k.TraceID)
+		}
+	}
+}
+
+func (s *Service) analyzeTrace(ctx context.Context, key storage.TraceKey) {
+	// The repository is tenant-scoped; the worker pool is not.
+	ctx = storage.WithTenantContext(ctx, key.TenantID)
+
+	// A late error span can queue a trace again after it was analysed.
+	if _, err := s.repo.GetTraceInsight(ctx, key.TraceID); err == nil {
+		return
+	} else if !errors.Is(err, storage.ErrTraceInsightNotFound) {
+		log.Printf("AI root-cause analysis of trace %s: %v", key.TraceID, err)
+		return
+	}
+
+	trace, err := s.repo.GetTrace(ctx, key.TraceID)
+	if err != nil {
+		log.Printf("AI root-cause analysis of trace %s: %v", key.TraceID, err)
+		return
+	}
+	prompt, service := buildTracePrompt(trace)
+
+	callCtx, cancel := context.WithTimeout(ctx, analysisTimeout)
+	defer cancel()
+	completion, err := s.llm.Complete(callCtx, prompt)
+	if err != nil {
+		log.Printf("AI root-cause analysis failed for trace %s: %v", key.TraceID, err)
+		return
+	}
+	summary := strings.TrimSpace(completion)
+	if summary == "" {
+		return
+	}
+
+	insight := storage.TraceInsight{
+		TraceID:     trace.TraceID,
+		ServiceName: service,
+		Summary:     storage.CompressedText(summary),
+		CreatedAt:   s.now().UTC(),
+	}
+	if err := s.repo.SaveTraceInsight(ctx, &insight); err != nil {
+		log.Printf("Failed to save AI insight for trace %s: %v", key.TraceID, err)
+		return
+	}
+	if s.onTraceInsight != nil {
+		s.onTraceInsight(insight)
+	}
+}
+
+// buildTracePrompt renders t as a root-cause prompt: failing spans first,
+// then the rest by start time, followed by the trace's logs (span events
+// included) with errors first. Also returns the service of the earliest
+// failing span, or the trace's root service when none failed.
+func buildTracePrompt(t *storage.Trace) (prompt, service string) {
+	spans := append([]storage.Span(nil), t.Spans...)
+	sort.SliceStable(spans, func(i, j int) bool {
+		ei, ej := spans[i].Status == spanStatusError, spans[j].Status == spanStatusError
+		if ei != ej {
+			return ei
+		}
+		return spans[i].StartTime.Before(spans[j].StartTime)
+	})
+	service = t.ServiceName
+	if len(spans) > 0 && spans[0].Status == spanStatusError {
+		service = spans[0].ServiceName
+	}
+
+	logs := append([]storage.Log(nil), t.Logs...)
+	sort.SliceStable(logs, func(i, j int) bool {
+		ri, rj := severityRank(logs[i].Severity), severityRank(logs[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return logs[i].Timestamp.Before(logs[j].Timestamp)
+	})
+
+	var b strings.Builder
+	b.WriteString(`Analyze the following failed distributed trace and identify the most likely root cause, using its spans, span events and correlated logs. Answer in at most 4 sentences: the service and operation where the failure originated, what went wrong, and one concrete next step.
+
+`)
+	fmt.Fprintf(&b, "Trace: %s\nRoot service: %s\nDuration: %.1fms\n", t.TraceID, t.ServiceName, float64(t.Duration)/1000)
+
+	fmt.Fprintf(&b, "\nSpans (%d of %d, failing first):\n", min(len(spans), rcaMaxSpans), len(spans))
+	for _, sp := range spans[:min(len(spans), rcaMaxSpans)] {
+		status := "ok"
+		if sp.Status == spanStatusError {
+			status = "ERROR"
+		}
+		fmt.Fprintf(&b, "- [%s] %s %s (%.1fms) span=%s parent=%s attrs=%s\n",
+			status, sp.ServiceName, sp.OperationName, float64(sp.Duration)/1000,
+			sp.SpanID, sp.ParentSpanID, truncate(string(sp.AttributesJSON)))
+	}
+
+	fmt.Fprintf(&b, "\nLogs and span events (%d of %d, errors first):\n", min(len(logs), rcaMaxLogs), len(logs))
+	for _, l := range logs[:min(len(logs), rcaMaxLogs)] {
+		fmt.Fprintf(&b, "- %s %s %s span=%s: %s attrs=%s\n",
+			l.Timestamp.UTC().Format(time.RFC3339Nano), l.Severity, l.ServiceName,
+			l.SpanID, truncate(l.Body), truncate(string(l.AttributesJSON)))
+	}
+
+	b.WriteString("\nRoot cause:")
+	return b.String(), service
+}
+
+// severityRank orders log severities for the prompt; higher is worse.
+func severityRank(severity string) int {
+	switch s := strings.ToUpper(severity); {
+	case strings.Contains(s, "FATAL"), strings.Contains(s, "CRITICAL"):
+		return 3
+	case strings.Contains(s, "ERROR"):
+		return 2
+	case strings.Contains(s, "WARN"):
+		return 1
+	}
+	return 0
+}
+
+func truncate(s string) string {
+	if len(s) <= rcaMaxFieldLen {
+		return s
+	}
+	return s[:rcaMaxFieldLen] + "…"
+}
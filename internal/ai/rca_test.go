@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+type fakeClient struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (c *fakeClient) Complete(_ context.Context, prompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, prompt)
+	return "  payment-service rejected the card: upstream gateway timed out.  ", nil
+}
+
+func (c *fakeClient) calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.prompts)
+}
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	t.Setenv("LOG_FTS_ENABLED", "false")
+	db, err := storage.NewDatabase("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := storage.AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := storage.NewRepositoryFromDB(db, "sqlite")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func TestService_AnalyzesErrorTraceOnceQuiet(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	failed := storage.Span{TenantID: "acme", TraceID: "t1", SpanID: "b", ParentSpanID: "a", ServiceName: "payment-service", OperationName: "charge", StartTime: now, Status: spanStatusError}
+	if err := repo.BatchCreateTraces([]storage.Trace{{TenantID: "acme", TraceID: "t1", ServiceName: "checkout", Timestamp: now, Status: spanStatusError}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BatchCreateSpans([]storage.Span{
+		{TenantID: "acme", TraceID: "t1", SpanID: "a", ServiceName: "checkout", OperationName: "POST /pay", StartTime: now},
+		failed,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BatchCreateLogs([]storage.Log{{TenantID: "acme", TraceID: "t1", SpanID: "b", Severity: "ERROR", ServiceName: "payment-service", Body: "gateway timeout after 5s", Timestamp: now}}); err != nil {
+		t.Fatal(err)
+	}
+
+	llm := &fakeClient{}
+	svc := NewServiceWithClient(repo, llm)
+	svc.now = func() time.Time { return now }
+	got := make(chan storage.TraceInsight, 1)
+	svc.SetTraceInsightCallback(func(in storage.TraceInsight) { got <- in })
+
+	svc.ObserveSpan(storage.Span{TenantID: "acme", TraceID: "t-ok", SpanID: "x", Status: "STATUS_CODE_OK"})
+	svc.ObserveSpan(failed)
+	svc.sweepRCA(now.Add(defaultRCADelay / 2))
+	if llm.calls() != 0 {
+		t.Fatal("trace analysed before its delay")
+	}
+	svc.sweepRCA(now.Add(defaultRCADelay))
+
+	var in storage.TraceInsight
+	select {
+	case in = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trace insight")
+	}
+	if in.TraceID != "t1" || in.ServiceName != "payment-service" || string(in.Summary) != "payment-service rejected the card: upstream gateway timed out." {
+		t.Errorf("insight = %+v", in)
+	}
+	stored, err := repo.GetTraceInsight(storage.WithTenantContext(context.Background(), "acme"), "t1")
+	if err != nil || string(stored.Summary) != string(in.Summary) {
+		t.Fatalf("stored insight = %+v, err = %v", stored, err)
+	}
+	if llm.calls() != 1 || !strings.Contains(llm.prompts[0], "gateway timeout after 5s") {
+		t.Fatalf("prompts = %q", llm.prompts)
+	}
+
+	// A late error span re-queues the trace, but it is not analysed twice.
+	// Stop drains the queue, so the re-queued trace has been handled after it.
+	svc.ObserveSpan(failed)
+	svc.sweepRCA(now.Add(defaultRCADelay))
+	svc.Stop()
+	if llm.calls() != 1 {
+		t.Errorf("LLM calls = %d, want 1", llm.calls())
+	}
+}
+
+func TestBuildTracePrompt(t *testing.T) {
+	now := time.Now()
+	prompt, service := buildTracePrompt(&storage.Trace{
+		TraceID:     "t1",
+		ServiceName: "checkout",
+		Duration:    12_500,
+		Spans: []storage.Span{
+			{SpanID: "a", ServiceName: "checkout", OperationName: "POST /pay", StartTime: now},
+			{SpanID: "b", ServiceName: "payment-service", OperationName: "charge", StartTime: now.Add(time.Millisecond), Status: spanStatusError},
+		},
+		Logs: []storage.Log{
+			{Severity: "INFO", Body: "charging card", Timestamp: now},
+			{Severity: "ERROR", Body: strings.Repeat("x", 2*rcaMaxFieldLen), Timestamp: now.Add(time.Millisecond)},
+		},
+	})
+	if service != "payment-service" {
+		t.Errorf("service = %q, want the failing span's", service)
+	}
+	for _, want := range []string{"Duration: 12.5ms", "[ERROR] payment-service charge", "[ok] checkout POST /pay"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Index(prompt, "[ERROR]") > strings.Index(prompt, "[ok]") {
+		t.Error("failing span not listed first")
+	}
+	if strings.Index(prompt, " ERROR ") > strings.Index(prompt, "charging card") {
+		t.Error("error log not listed first")
+	}
+	if strings.Contains(prompt, strings.Repeat("x", rcaMaxFieldLen+1)) {
+		t.Error("log body not truncated")
+	}
+}
+
+func TestNewClientFromEnv_OpenAICompatible(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer local-key" {
+			http.Error(w, "unexpected request "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1","object":"chat.completion","model":"llama3","choices":[{"index":0,"message":{"role":"assistant","content":"root cause"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("AI_PROVIDER", "openai")
+	t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OPENAI_API_KEY", "local-key")
+	t.Setenv("OPENAI_MODEL", "llama3")
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("NewClientFromEnv: %v", err)
+	}
+	got, err := client.Complete(context.Background(), "why?")
+	if err != nil || got != "root cause" {
+		t.Errorf("Complete = %q, %v", got, err)
+	}
+
+	t.Setenv("AI_PROVIDER", "bedrock")
+	if _, err := NewClientFromEnv(); err == nil {
+		t.Error("unknown provider accepted")
+	}
+}
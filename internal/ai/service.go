@@ -11,15 +11,13 @@ import (
 	"time"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/storage"
-	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/openai"
 )
 
 type Service struct {
 	repo       *storage.Repository
-	llm        llms.Model
+	llm        Client
 	enabled    bool
-	workQueue  chan storage.Log
+	workQueue  chan task
 	workerPool int
 	wg         sync.WaitGroup
 
@@ -30,62 +28,105 @@ type Service struct {
 	// when SetParentContext isn't called (preserves legacy behaviour for
 	// embedded callers).
 	parentCtx context.Context
+
+	// Error-trace root-cause analysis (see rca.go). A trace is analysed once
+	// rcaDelay has passed without new spans after its first error span.
+	// rcaDelay 0 disables.
+	rcaDelay       time.Duration
+	rcaMu          sync.Mutex
+	rcaPending     map[storage.TraceKey]time.Time
+	onTraceInsight func(storage.TraceInsight)
+	rcaStop        chan struct{}
+	rcaDone        chan struct{}
+	now            func() time.Time // injectable clock for tests
+}
+
+// task is one unit of AI work: an error log, or an error trace when log is nil.
+type task struct {
+	log   *storage.Log
+	trace storage.TraceKey
 }
 
+// Service defaults, overridable via AI_QUEUE_SIZE, AI_WORKER_POOL and
+// AI_TRACE_RCA_DELAY.
+const (
+	defaultQueueSize  = 100
+	defaultWorkerPool = 3
+	defaultRCADelay   = 30 * time.Second
+
+	// analysisTimeout bounds one LLM call.
+	analysisTimeout = 30 * time.Second
+)
+
 func NewService(repo *storage.Repository) *Service {
 	enabled := os.Getenv("AI_ENABLED") == "true"
 	if !enabled {
 		return &Service{enabled: false}
 	}
 
-	// Initialize Azure OpenAI
-	opts := []openai.Option{
-		openai.WithAPIType(openai.APITypeAzure),
-		openai.WithBaseURL(os.Getenv("AZURE_OPENAI_ENDPOINT")),
-		openai.WithToken(os.Getenv("AZURE_OPENAI_KEY")),
-		openai.WithModel(os.Getenv("AZURE_OPENAI_MODEL")),
-	}
-
-	if deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT"); deployment != "" {
-		opts = append(opts, openai.WithModel(deployment))
-	}
-
-	if apiVersion := os.Getenv("AZURE_OPENAI_API_VERSION"); apiVersion != "" {
-		opts = append(opts, openai.WithAPIVersion(apiVersion))
-	}
-
-	llm, err := openai.New(opts...)
+	llm, err := NewClientFromEnv()
 	if err != nil {
 		log.Printf("Failed to initialize AI service: %v. AI features disabled.", err)
 		return &Service{enabled: false}
 	}
 
-	queueSize := 100
+	queueSize := defaultQueueSize
 	if qs := os.Getenv("AI_QUEUE_SIZE"); qs != "" {
 		if v, err := strconv.Atoi(qs); err == nil && v > 0 {
 			queueSize = v
 		}
 	}
 
-	workerPool := 3
+	workerPool := defaultWorkerPool
 	if wp := os.Getenv("AI_WORKER_POOL"); wp != "" {
 		if v, err := strconv.Atoi(wp); err == nil && v > 0 {
 			workerPool = v
 		}
 	}
 
+	rcaDelay := defaultRCADelay
+	if os.Getenv("AI_TRACE_RCA_ENABLED") == "false" {
+		rcaDelay = 0
+	} else if d := os.Getenv("AI_TRACE_RCA_DELAY"); d != "" {
+		if v, err := time.ParseDuration(d); err == nil && v > 0 {
+			rcaDelay = v
+		}
+	}
+
+	return newService(repo, llm, queueSize, workerPool, rcaDelay)
+}
+
+// NewServiceWithClient creates an enabled service that prompts client, with
+// the default queue size, worker pool and trace RCA delay. Use it to plug in
+// an LLM backend other than the env-configured ones.
+func NewServiceWithClient(repo *storage.Repository, client Client) *Service {
+	return newService(repo, client, defaultQueueSize, defaultWorkerPool, defaultRCADelay)
+}
+
+func newService(repo *storage.Repository, client Client, queueSize, workerPool int, rcaDelay time.Duration) *Service {
 	s := &Service{
 		repo:       repo,
-		llm:        llm,
+		llm:        client,
 		enabled:    true,
-		workQueue:  make(chan storage.Log, queueSize),
+		workQueue:  make(chan task, queueSize),
 		workerPool: workerPool,
+		rcaDelay:   rcaDelay,
+		rcaPending: make(map[storage.TraceKey]time.Time),
+		rcaStop:    make(chan struct{}),
+		rcaDone:    make(chan struct{}),
+		now:        time.Now,
 	}
 
 	s.startWorkers()
+	go s.rcaLoop()
 	return s
 }
 
+// Enabled reports whether AI_ENABLED turned the service on. Nil-safe.
+func (s *Service) Enabled() bool {
+	return s != nil && s.enabled
+}
+
 // SetParentContext wires the application-level context so worker LLM calls
 // inherit cancellation on shutdown. Call once during boot before
 // EnqueueLog starts taking traffic — the parentCtx is read on every
@@ -94,26 +135,41 @@ func (s *Service) SetParentContext(ctx context.Context) {
 	s.parentCtx = ctx
 }
 
+// SetTraceInsightCallback registers fn to receive every trace insight after
+// it is stored (e.g. to stream it over the WebSocket hub). Call once during
+// boot, like SetParentContext.
+func (s *Service) SetTraceInsightCallback(fn func(storage.TraceInsight)) {
+	s.onTraceInsight = fn
+}
+
 func (s *Service) startWorkers() {
 	for i := 0; i < s.workerPool; i++ {
 		s.wg.Add(1)
 		go func(workerID int) {
 			defer s.wg.Done()
-			for logEntry := range s.workQueue {
+			for t := range s.workQueue {
 				ctx := s.parentCtx
 				if ctx == nil {
 					ctx = context.Background()
 				}
-				s.analyzeLog(ctx, logEntry)
+				if t.log != nil {
+					s.analyzeLog(ctx, *t.log)
+				} else {
+					s.analyzeTrace(ctx, t.trace)
+				}
 			}
 		}(i)
 	}
 }
 
+// Stop halts the trace RCA sweep, dropping traces still waiting out their
+// delay, then drains the work queue.
 func (s *Service) Stop() {
 	if !s.enabled {
 		return
 	}
+	close(s.rcaStop)
+	<-s.rcaDone
 	close(s.workQueue)
 	s.wg.Wait()
 }
@@ -125,7 +181,7 @@ func (s *Service) EnqueueLog(l storage.Log) {
 	severity := strings.ToUpper(l.Severity)
 	if strings.Contains(severity, "ERROR") || strings.Contains(severity, "CRITICAL") || strings.Contains(severity, "FATAL") {
 		select {
-		case s.workQueue <- l:
+		case s.workQueue <- task{log: &l}:
 		default:
 			log.Println("AI work queue full, dropping log analysis")
 		}
@@ -143,10 +199,10 @@ func (s *Service) analyzeLog(ctx context.Context, l storage.Log) {
 	
 	Insight:`, l.ServiceName, l.Timestamp, l.Severity, l.Body, l.AttributesJSON)
 
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, analysisTimeout)
 	defer cancel()
 
-	completion, err := llms.GenerateFromSinglePrompt(ctx, s.llm, prompt)
+	completion, err := s.llm.Complete(ctx, prompt)
 	if err != nil {
 		log.Printf("AI Analysis failed for log %d: %v", l.ID, err)
 		return
@@ -0,0 +1,261 @@
+// Package alerting evaluates user-defined alert rules and SLOs against the
+// repository and notifies Slack, generic webhook and email channels when a
+// rule starts or stops firing.
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+// defaultWindow is the look-back for rules created without window_seconds.
+const defaultWindow = 5 * time.Minute
+
+// Engine periodically evaluates every enabled alert rule. Firing state is
+// persisted as AlertEvent rows, so a restart neither re-notifies an alert
+// that is still firing nor forgets to resolve one.
+type Engine struct {
+	repo     *storage.Repository
+	interval time.Duration
+	metrics  *telemetry.Metrics
+	client   *http.Client
+	smtp     SMTPConfig
+
+	// sendMail is swapped out by tests.
+	sendMail sendMailFunc
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewEngine creates an engine evaluating rules every interval. metrics may
+// be nil.
+func NewEngine(repo *storage.Repository, interval time.Duration, metrics *telemetry.Metrics) *Engine {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &Engine{
+		repo:     repo,
+		interval: interval,
+		metrics:  metrics,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		sendMail: smtpSendMail,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetSMTP configures the relay used by email channels. Must be called before
+// Start.
+func (e *Engine) SetSMTP(cfg SMTPConfig) {
+	e.smtp = cfg
+}
+
+// Start runs the evaluation loop.
+func (e *Engine) Start() {
+	if e.started.CompareAndSwap(false, true) {
+		go e.loop()
+	}
+}
+
+// Stop ends the evaluation loop, waiting for an in-flight pass. Safe without
+// Start.
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stop)
+		if e.started.Load() {
+			<-e.done
+		}
+	})
+}
+
+func (e *Engine) loop() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-e.stop
+		cancel()
+	}()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case now := <-ticker.C:
+			e.Evaluate(ctx, now)
+		}
+	}
+}
+
+// Evaluate refreshes every SLO's status, then runs one pass over every
+// enabled rule at now.
+func (e *Engine) Evaluate(ctx context.Context, now time.Time) {
+	e.evaluateSLOs(ctx, now)
+	rules, err := e.repo.ListEnabledAlertRulesAllTenants(ctx)
+	if err != nil {
+		slog.Error("Alerting: failed to load rules", "error", err)
+		return
+	}
+	states := make([]telemetry.AlertRuleState, 0, len(rules))
+	for i := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+		rule := &rules[i]
+		st := telemetry.AlertRuleState{
+			Tenant:  rule.TenantID,
+			RuleID:  rule.ID,
+			Rule:    rule.Name,
+			Kind:    rule.Kind,
+			Service: rule.ServiceName,
+		}
+		var err error
+		st.Firing, st.Value, err = e.evaluateRule(ctx, rule, now)
+		if err != nil {
+			slog.Warn("Alerting: rule evaluation failed", "rule_id", rule.ID, "rule", rule.Name, "error", err)
+			// Report the persisted state rather than dropping the series.
+			if open, ferr := e.repo.FiringAlertEvent(ctx, rule.ID); ferr == nil && open != nil {
+				st.Firing, st.Value = true, open.Value
+			}
+		}
+		states = append(states, st)
+	}
+	e.metrics.SetAlertRuleStates(states)
+}
+
+// evaluateRule measures rule, opens or resolves its AlertEvent and reports
+// whether it is firing afterwards along with the measured value.
+func (e *Engine) evaluateRule(ctx context.Context, rule *storage.AlertRule, now time.Time) (bool, float64, error) {
+	tctx := storage.WithTenantContext(ctx, rule.TenantID)
+	value, breached, err := e.measure(tctx, rule, now)
+	if err != nil {
+		return false, 0, err
+	}
+	open, err := e.repo.FiringAlertEvent(ctx, rule.ID)
+	if err != nil {
+		return false, value, err
+	}
+
+	switch {
+	case breached && open == nil:
+		ev := &storage.AlertEvent{
+			TenantID: rule.TenantID,
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			State:    storage.AlertStateFiring,
+			Value:    value,
+			Message:  describe(rule, value),
+			FiredAt:  now,
+		}
+		if err := e.repo.SaveAlertEvent(ctx, ev); err != nil {
+			return false, value, err
+		}
+		slog.Info("🚨 Alert firing", "tenant", rule.TenantID, "rule", rule.Name, "value", value)
+		e.notify(ctx, rule, ev)
+	case !breached && open != nil:
+		open.State = storage.AlertStateResolved
+		open.Value = value
+		open.ResolvedAt = &now
+		open.Message = describe(rule, value)
+		if err := e.repo.SaveAlertEvent(ctx, open); err != nil {
+			return true, value, err
+		}
+		slog.Info("✅ Alert resolved", "tenant", rule.TenantID, "rule", rule.Name, "value", value)
+		e.notify(ctx, rule, open)
+	case breached:
+		// Still firing: track the latest value without re-notifying.
+		open.Value = value
+		return true, value, e.repo.SaveAlertEvent(ctx, open)
+	}
+	return breached, value, nil
+}
+
+// measure computes the rule's current value over its window and whether it
+// breaches the threshold.
+func (e *Engine) measure(ctx context.Context, rule *storage.AlertRule, now time.Time) (float64, bool, error) {
+	window := time.Duration(rule.WindowSecs) * time.Second
+	if window <= 0 {
+		window = defaultWindow
+	}
+	start := now.Add(-window)
+	var services []string
+	if rule.ServiceName != "" {
+		services = []string{rule.ServiceName}
+	}
+
+	switch rule.Kind {
+	case storage.AlertKindErrorRate:
+		stats, err := e.repo.GetDashboardStats(ctx, start, now, services)
+		if err != nil {
+			return 0, false, err
+		}
+		return stats.ErrorRate, stats.TotalTraces > 0 && stats.ErrorRate > rule.Threshold, nil
+	case storage.AlertKindP99Latency:
+		stats, err := e.repo.GetDashboardStats(ctx, start, now, services)
+		if err != nil {
+			return 0, false, err
+		}
+		ms := float64(stats.P99Latency) / 1000.0
+		return ms, stats.TotalTraces > 0 && ms > rule.Threshold, nil
+	case storage.AlertKindLogMatch:
+		_, total, err := e.repo.GetLogsV2(ctx, storage.LogFilter{
+			ServiceName: rule.ServiceName,
+			Search:      rule.Keyword,
+			StartTime:   start,
+			EndTime:     now,
+			Limit:       1,
+		})
+		if err != nil {
+			return 0, false, err
+		}
+		n := float64(total)
+		return n, n >= max(rule.Threshold, 1), nil
+	case storage.AlertKindSLOBurnRate:
+		return e.measureBurnRate(ctx, rule, now, window)
+	case storage.AlertKindMonitorDown:
+		// The uptime scheduler keeps the failure streak; the window is unused.
+		m, err := e.repo.GetMonitor(ctx, rule.MonitorID)
+		if err != nil {
+			return 0, false, fmt.Errorf("load monitor %d: %w", rule.MonitorID, err)
+		}
+		if m.Disabled {
+			return 0, false, nil
+		}
+		n := float64(m.Status.ConsecutiveFailures)
+		return n, n >= max(rule.Threshold, 1), nil
+	default:
+		return 0, false, fmt.Errorf("unknown rule kind %q", rule.Kind)
+	}
+}
+
+// describe renders the human-readable alert summary used in notifications.
+func describe(rule *storage.AlertRule, value float64) string {
+	scope := "all services"
+	if rule.ServiceName != "" {
+		scope = rule.ServiceName
+	}
+	switch rule.Kind {
+	case storage.AlertKindErrorRate:
+		return fmt.Sprintf("error rate for %s is %.2f%% (threshold %.2f%%)", scope, value, rule.Threshold)
+	case storage.AlertKindP99Latency:
+		return fmt.Sprintf("p99 latency for %s is %.1fms (threshold %.1fms)", scope, value, rule.Threshold)
+	case storage.AlertKindSLOBurnRate:
+		return fmt.Sprintf("SLO error budget for %s is burning at %.1fx (threshold %.1fx)", scope, value, rule.Threshold)
+	case storage.AlertKindMonitorDown:
+		return fmt.Sprintf("uptime monitor %d for %s failed %d consecutive checks (threshold %d)", rule.MonitorID, scope, int64(value), int64(max(rule.Threshold, 1)))
+	default:
+		return fmt.Sprintf("%d logs from %s matched %q (threshold %d)", int64(value), scope, rule.Keyword, int64(max(rule.Threshold, 1)))
+	}
+}
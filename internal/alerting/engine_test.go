@@ -0,0 +1,257 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	db, err := storage.NewDatabase("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := storage.AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := storage.NewRepositoryFromDB(db, "sqlite")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func seedTraces(t *testing.T, repo *storage.Repository, tenant, service string, ts time.Time, ok, failed int) {
+	t.Helper()
+	var traces []storage.Trace
+	for i := range ok + failed {
+		status := "STATUS_CODE_OK"
+		if i < failed {
+			status = "STATUS_CODE_ERROR"
+		}
+		traces = append(traces, storage.Trace{
+			TenantID:    tenant,
+			TraceID:     fmt.Sprintf("%s-%s-%d-%d", tenant, service, ts.UnixNano(), i),
+			ServiceName: service,
+			Duration:    int64(1000 * (i + 1)),
+			Status:      status,
+			Timestamp:   ts,
+		})
+	}
+	if err := repo.DB().Create(&traces).Error; err != nil {
+		t.Fatalf("seed traces: %v", err)
+	}
+}
+
+// recorder is an httptest server capturing POSTed JSON bodies.
+type recorder struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []map[string]any
+}
+
+func newRecorder(t *testing.T) *recorder {
+	r := &recorder{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, body)
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *recorder) received() []map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]any(nil), r.bodies...)
+}
+
+func TestEngine_ErrorRateFiresAndResolves(t *testing.T) {
+	repo := newTestRepo(t)
+	slack, hook := newRecorder(t), newRecorder(t)
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	rule := &storage.AlertRule{
+		Name:        "checkout errors",
+		Kind:        storage.AlertKindErrorRate,
+		ServiceName: "checkout",
+		Threshold:   10,
+		WindowSecs:  300,
+		Channels: []storage.AlertChannel{
+			{Type: ChannelSlack, URL: slack.URL},
+			{Type: ChannelWebhook, URL: hook.URL},
+		},
+	}
+	if err := repo.CreateAlertRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+
+	now := time.Now()
+	seedTraces(t, repo, "acme", "checkout", now.Add(-time.Minute), 6, 4)
+	seedTraces(t, repo, "other", "checkout", now.Add(-time.Minute), 10, 0) // other tenant ignored
+
+	e := NewEngine(repo, time.Minute, nil)
+	e.Evaluate(context.Background(), now)
+	e.Evaluate(context.Background(), now.Add(time.Second)) // still firing: no re-notify
+
+	firing, err := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10)
+	if err != nil || len(firing) != 1 {
+		t.Fatalf("firing events = %v, %v; want 1", firing, err)
+	}
+	if firing[0].Value != 40 {
+		t.Errorf("value = %v, want 40", firing[0].Value)
+	}
+	if got := slack.received(); len(got) != 1 || !strings.Contains(got[0]["text"].(string), "FIRING") {
+		t.Errorf("slack = %v, want one FIRING message", got)
+	}
+	if got := hook.received(); len(got) != 1 || got[0]["state"] != storage.AlertStateFiring || got[0]["tenant_id"] != "acme" {
+		t.Errorf("webhook = %v", got)
+	}
+
+	// Ten minutes later the window is clean and the alert resolves.
+	e.Evaluate(context.Background(), now.Add(10*time.Minute))
+	resolved, _ := repo.ListAlertEvents(ctx, storage.AlertStateResolved, 10)
+	if len(resolved) != 1 || resolved[0].ResolvedAt == nil {
+		t.Fatalf("resolved events = %+v", resolved)
+	}
+	if got := hook.received(); len(got) != 2 || got[1]["state"] != storage.AlertStateResolved {
+		t.Errorf("webhook = %v, want a resolved notification", got)
+	}
+
+	// Other tenants see nothing.
+	if evs, _ := repo.ListAlertEvents(storage.WithTenantContext(context.Background(), "other"), "", 10); len(evs) != 0 {
+		t.Errorf("other tenant sees %d events", len(evs))
+	}
+}
+
+func TestEngine_P99AndLogMatch(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "default")
+	now := time.Now()
+	seedTraces(t, repo, "default", "api", now.Add(-time.Minute), 100, 0) // p99 ~ 99ms
+	if err := repo.DB().Create(&[]storage.Log{
+		{TenantID: "default", ServiceName: "api", Severity: "ERROR", Body: "OutOfMemoryError in worker", Timestamp: now.Add(-time.Minute)},
+		{TenantID: "default", ServiceName: "api", Severity: "INFO", Body: "all good", Timestamp: now.Add(-time.Minute)},
+	}).Error; err != nil {
+		t.Fatalf("seed logs: %v", err)
+	}
+
+	for _, r := range []*storage.AlertRule{
+		{Name: "slow", Kind: storage.AlertKindP99Latency, ServiceName: "api", Threshold: 50, WindowSecs: 300},
+		{Name: "fast enough", Kind: storage.AlertKindP99Latency, ServiceName: "api", Threshold: 500, WindowSecs: 300},
+		{Name: "oom", Kind: storage.AlertKindLogMatch, Keyword: "OutOfMemoryError", WindowSecs: 300},
+		{Name: "off", Kind: storage.AlertKindLogMatch, Keyword: "OutOfMemoryError", WindowSecs: 300, Disabled: true},
+	} {
+		if err := repo.CreateAlertRule(ctx, r); err != nil {
+			t.Fatalf("CreateAlertRule: %v", err)
+		}
+	}
+
+	NewEngine(repo, time.Minute, nil).Evaluate(context.Background(), now)
+
+	firing, err := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10)
+	if err != nil {
+		t.Fatalf("ListAlertEvents: %v", err)
+	}
+	names := map[string]bool{}
+	for _, ev := range firing {
+		names[ev.RuleName] = true
+	}
+	if len(names) != 2 || !names["slow"] || !names["oom"] {
+		t.Errorf("firing = %v, want slow and oom", names)
+	}
+}
+
+func TestEngine_MonitorDown(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	m := &storage.Monitor{Name: "home", Kind: storage.MonitorKindHTTP, Target: "https://example.com", ServiceName: "web"}
+	if err := repo.CreateMonitor(ctx, m); err != nil {
+		t.Fatalf("CreateMonitor: %v", err)
+	}
+	if err := repo.CreateAlertRule(ctx, &storage.AlertRule{Name: "home down", Kind: storage.AlertKindMonitorDown, MonitorID: m.ID, Threshold: 2}); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	e := NewEngine(repo, time.Minute, nil)
+	check := func(up bool, failures int) {
+		t.Helper()
+		m.Status = storage.MonitorStatus{Up: up, ConsecutiveFailures: failures}
+		if err := repo.SaveMonitorResult(context.Background(), m, &storage.MonitorResult{CheckedAt: time.Now(), Up: up}); err != nil {
+			t.Fatalf("SaveMonitorResult: %v", err)
+		}
+		e.Evaluate(context.Background(), time.Now())
+	}
+
+	check(false, 1)
+	if evs, _ := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10); len(evs) != 0 {
+		t.Fatalf("fired after one failure with threshold 2: %+v", evs)
+	}
+	check(false, 2)
+	evs, _ := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10)
+	if len(evs) != 1 || !strings.Contains(evs[0].Message, "failed 2 consecutive checks") {
+		t.Fatalf("firing = %+v, want one event after two failures", evs)
+	}
+	check(true, 0)
+	if evs, _ := repo.ListAlertEvents(ctx, storage.AlertStateResolved, 10); len(evs) != 1 {
+		t.Errorf("resolved = %+v, want the alert resolved by a passing check", evs)
+	}
+}
+
+func TestEngine_EmailNotifier(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "default")
+	now := time.Now()
+	seedTraces(t, repo, "default", "api", now.Add(-time.Minute), 0, 1)
+	if err := repo.CreateAlertRule(ctx, &storage.AlertRule{
+		Name: "errors", Kind: storage.AlertKindErrorRate, Threshold: 5, WindowSecs: 60,
+		Channels: []storage.AlertChannel{{Type: ChannelEmail, To: []string{"oncall@example.com"}}},
+	}); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+
+	var sent []string
+	e := NewEngine(repo, time.Minute, nil)
+	e.SetSMTP(SMTPConfig{Addr: "smtp.example.com:25", From: "otelcontext@example.com"})
+	e.sendMail = func(_ SMTPConfig, to []string, msg []byte) error {
+		sent = append(sent, strings.Join(to, ",")+"\n"+string(msg))
+		return nil
+	}
+	e.Evaluate(context.Background(), now)
+
+	if len(sent) != 1 || !strings.HasPrefix(sent[0], "oncall@example.com\n") || !strings.Contains(sent[0], "Subject: [🔥 FIRING] errors:") {
+		t.Errorf("sent = %q", sent)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	ok := storage.AlertRule{Name: "x", Kind: storage.AlertKindErrorRate, Threshold: 5}
+	if err := ValidateRule(&ok); err != nil || ok.WindowSecs != 300 {
+		t.Fatalf("valid rule: %v (window %d)", err, ok.WindowSecs)
+	}
+	for name, r := range map[string]storage.AlertRule{
+		"no name":          {Kind: storage.AlertKindErrorRate},
+		"multiline name":   {Name: "a\r\nBcc: x", Kind: storage.AlertKindErrorRate},
+		"bad kind":         {Name: "x", Kind: "cpu"},
+		"no keyword":       {Name: "x", Kind: storage.AlertKindLogMatch},
+		"no monitor":       {Name: "x", Kind: storage.AlertKindMonitorDown},
+		"negative":         {Name: "x", Kind: storage.AlertKindErrorRate, Threshold: -1},
+		"long window":      {Name: "x", Kind: storage.AlertKindErrorRate, WindowSecs: maxWindowSecs + 1},
+		"file url":         {Name: "x", Kind: storage.AlertKindErrorRate, Channels: []storage.AlertChannel{{Type: ChannelWebhook, URL: "file:///etc/passwd"}}},
+		"bad recipient":    {Name: "x", Kind: storage.AlertKindErrorRate, Channels: []storage.AlertChannel{{Type: ChannelEmail, To: []string{"not an address"}}}},
+		"unknown channel":  {Name: "x", Kind: storage.AlertKindErrorRate, Channels: []storage.AlertChannel{{Type: "pager"}}},
+		"empty recipients": {Name: "x", Kind: storage.AlertKindErrorRate, Channels: []storage.AlertChannel{{Type: ChannelEmail}}},
+	} {
+		if err := ValidateRule(&r); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
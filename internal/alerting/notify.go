@@ -0,0 +1,141 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Channel types accepted in AlertRule.Channels.
+const (
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+// SMTPConfig is the relay used by email channels. Username may be empty for
+// an unauthenticated relay.
+type SMTPConfig struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+type sendMailFunc func(cfg SMTPConfig, to []string, msg []byte) error
+
+// Notification is the JSON body POSTed to generic webhook channels.
+type Notification struct {
+	RuleID      uint       `json:"rule_id"`
+	RuleName    string     `json:"rule_name"`
+	Kind        string     `json:"kind"`
+	ServiceName string     `json:"service_name,omitempty"`
+	TenantID    string     `json:"tenant_id"`
+	State       string     `json:"state"`
+	Value       float64    `json:"value"`
+	Threshold   float64    `json:"threshold"`
+	Message     string     `json:"message"`
+	FiredAt     time.Time  `json:"fired_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// notify delivers ev to every channel of rule. Failures are logged and
+// counted; one broken channel does not block the others.
+func (e *Engine) notify(ctx context.Context, rule *storage.AlertRule, ev *storage.AlertEvent) {
+	n := Notification{
+		RuleID:      rule.ID,
+		RuleName:    rule.Name,
+		Kind:        rule.Kind,
+		ServiceName: rule.ServiceName,
+		TenantID:    rule.TenantID,
+		State:       ev.State,
+		Value:       ev.Value,
+		Threshold:   rule.Threshold,
+		Message:     ev.Message,
+		FiredAt:     ev.FiredAt,
+		ResolvedAt:  ev.ResolvedAt,
+	}
+	for _, ch := range rule.Channels {
+		var err error
+		switch ch.Type {
+		case ChannelSlack:
+			err = e.postJSON(ctx, ch.URL, map[string]string{"text": n.title()})
+		case ChannelWebhook:
+			err = e.postJSON(ctx, ch.URL, n)
+		case ChannelEmail:
+			err = e.email(ch.To, n)
+		default:
+			err = fmt.Errorf("unknown channel type %q", ch.Type)
+		}
+		e.metrics.RecordAlertNotification(ch.Type, err)
+		if err != nil {
+			slog.Warn("Alerting: notification failed", "rule", rule.Name, "channel", ch.Type, "error", err)
+		}
+	}
+}
+
+// title is the one-line summary used for Slack text and email subjects.
+func (n Notification) title() string {
+	icon := "🔥 FIRING"
+	if n.State == storage.AlertStateResolved {
+		icon = "✅ RESOLVED"
+	}
+	return fmt.Sprintf("[%s] %s: %s", icon, n.RuleName, n.Message)
+}
+
+func (e *Engine) postJSON(ctx context.Context, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *Engine) email(to []string, n Notification) error {
+	if e.smtp.Addr == "" || e.smtp.From == "" {
+		return fmt.Errorf("email channel requires ALERT_SMTP_ADDR and ALERT_SMTP_FROM")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.smtp.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.title())
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(&b, "Rule: %s\r\nTenant: %s\r\nState: %s\r\n%s\r\nFired at: %s\r\n",
+		n.RuleName, n.TenantID, n.State, n.Message, n.FiredAt.UTC().Format(time.RFC3339))
+	if n.ResolvedAt != nil {
+		fmt.Fprintf(&b, "Resolved at: %s\r\n", n.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+	return e.sendMail(e.smtp, to, []byte(b.String()))
+}
+
+func smtpSendMail(cfg SMTPConfig, to []string, msg []byte) error {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := strings.Cut(cfg.Addr, ":")
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return smtp.SendMail(cfg.Addr, auth, cfg.From, to, msg)
+}
@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxWindowSecs caps a rule's look-back at one day so evaluation queries stay
+// bounded.
+const maxWindowSecs = 24 * 60 * 60
+
+// ValidateRule checks a user-supplied rule and fills defaults.
+func ValidateRule(rule *storage.AlertRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(rule.Name, "\r\n") {
+		return errors.New("name must be a single line")
+	}
+	switch rule.Kind {
+	case storage.AlertKindErrorRate, storage.AlertKindP99Latency:
+	case storage.AlertKindLogMatch:
+		if strings.TrimSpace(rule.Keyword) == "" {
+			return errors.New("keyword is required for log_match rules")
+		}
+	case storage.AlertKindSLOBurnRate:
+		if rule.SLOID == 0 {
+			return errors.New("slo_id is required for slo_burn_rate rules")
+		}
+		if rule.WindowSecs == 0 {
+			rule.WindowSecs = int(defaultBurnRateWindow.Seconds())
+		}
+	case storage.AlertKindMonitorDown:
+		if rule.MonitorID == 0 {
+			return errors.New("monitor_id is required for monitor_down rules")
+		}
+	default:
+		return fmt.Errorf("kind must be one of %s, %s, %s, %s, %s",
+			storage.AlertKindErrorRate, storage.AlertKindP99Latency, storage.AlertKindLogMatch, storage.AlertKindSLOBurnRate,
+			storage.AlertKindMonitorDown)
+	}
+	if rule.Threshold < 0 {
+		return errors.New("threshold must be >= 0")
+	}
+	if rule.WindowSecs == 0 {
+		rule.WindowSecs = int(defaultWindow.Seconds())
+	}
+	if rule.WindowSecs < 0 || rule.WindowSecs > maxWindowSecs {
+		return fmt.Errorf("window_seconds must be between 1 and %d", maxWindowSecs)
+	}
+	for i, ch := range rule.Channels {
+		switch ch.Type {
+		case ChannelSlack, ChannelWebhook:
+			u, err := url.Parse(ch.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("channels[%d]: url must be an http(s) URL", i)
+			}
+		case ChannelEmail:
+			if len(ch.To) == 0 {
+				return fmt.Errorf("channels[%d]: email channel needs at least one recipient", i)
+			}
+			for _, addr := range ch.To {
+				if _, err := mail.ParseAddress(addr); err != nil {
+					return fmt.Errorf("channels[%d]: invalid recipient %q", i, addr)
+				}
+			}
+		default:
+			return fmt.Errorf("channels[%d]: type must be slack, webhook or email", i)
+		}
+	}
+	return nil
+}
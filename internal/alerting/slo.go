@@ -0,0 +1,155 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+const (
+	// defaultSLOWindowDays is the compliance window of SLOs created without
+	// window_days; maxSLOWindowDays bounds the evaluation scan.
+	defaultSLOWindowDays = 30
+	maxSLOWindowDays     = 90
+
+	// defaultBurnRateWindow is the look-back of slo_burn_rate rules created
+	// without window_seconds.
+	defaultBurnRateWindow = time.Hour
+)
+
+// Burn-rate windows reported in every SLO status: the fast and slow
+// windows of the usual multiwindow burn-rate alerts.
+const (
+	burnWindowShort = time.Hour
+	burnWindowLong  = 6 * time.Hour
+)
+
+// ValidateSLO checks a user-supplied SLO and fills defaults.
+func ValidateSLO(slo *storage.SLO) error {
+	slo.Name = strings.TrimSpace(slo.Name)
+	if slo.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(slo.Name, "\r\n") {
+		return errors.New("name must be a single line")
+	}
+	slo.ServiceName = strings.TrimSpace(slo.ServiceName)
+	if slo.ServiceName == "" {
+		return errors.New("service_name is required")
+	}
+	switch slo.Kind {
+	case storage.SLOKindAvailability:
+		slo.LatencyThresholdMs = 0
+	case storage.SLOKindLatency:
+		if slo.LatencyThresholdMs <= 0 {
+			return errors.New("latency_threshold_ms must be > 0 for latency SLOs")
+		}
+	default:
+		return fmt.Errorf("kind must be %s or %s", storage.SLOKindAvailability, storage.SLOKindLatency)
+	}
+	if slo.Objective <= 0 || slo.Objective >= 100 {
+		return errors.New("objective must be a percentage between 0 and 100, exclusive")
+	}
+	if slo.WindowDays == 0 {
+		slo.WindowDays = defaultSLOWindowDays
+	}
+	if slo.WindowDays < 0 || slo.WindowDays > maxSLOWindowDays {
+		return fmt.Errorf("window_days must be between 1 and %d", maxSLOWindowDays)
+	}
+	return nil
+}
+
+// evaluateSLOs refreshes the stored status of every SLO at now and
+// publishes it as the SLO gauges.
+func (e *Engine) evaluateSLOs(ctx context.Context, now time.Time) {
+	slos, err := e.repo.ListSLOsAllTenants(ctx)
+	if err != nil {
+		slog.Error("Alerting: failed to load SLOs", "error", err)
+		return
+	}
+	states := make([]telemetry.SLOState, 0, len(slos))
+	for i := range slos {
+		if ctx.Err() != nil {
+			return
+		}
+		slo := &slos[i]
+		st, err := e.sloStatus(storage.WithTenantContext(ctx, slo.TenantID), slo, now)
+		if err == nil {
+			err = e.repo.SaveSLOStatus(ctx, slo.ID, st)
+		}
+		if err != nil {
+			slog.Warn("Alerting: SLO evaluation failed", "slo_id", slo.ID, "slo", slo.Name, "error", err)
+			// Report the last stored status rather than dropping the series;
+			// an SLO never evaluated has none to report.
+			if slo.Status.EvaluatedAt == nil {
+				continue
+			}
+			st = slo.Status
+		}
+		states = append(states, telemetry.SLOState{
+			Tenant:          slo.TenantID,
+			SLOID:           slo.ID,
+			SLO:             slo.Name,
+			Service:         slo.ServiceName,
+			BudgetRemaining: st.BudgetRemaining,
+			Compliance:      st.Compliance,
+			BurnRate1h:      st.BurnRate1h,
+			BurnRate6h:      st.BurnRate6h,
+		})
+	}
+	e.metrics.SetSLOStates(states)
+}
+
+// sloStatus measures slo over its compliance window and the burn-rate
+// windows.
+func (e *Engine) sloStatus(ctx context.Context, slo *storage.SLO, now time.Time) (storage.SLOStatus, error) {
+	window := time.Duration(slo.WindowDays) * 24 * time.Hour
+	counts, err := e.repo.CountSLOEvents(ctx, slo, now, window, burnWindowLong, burnWindowShort)
+	if err != nil {
+		return storage.SLOStatus{}, err
+	}
+	full := counts[0]
+	st := storage.SLOStatus{
+		EvaluatedAt:     &now,
+		Total:           full.Total,
+		Bad:             full.Bad,
+		Compliance:      100,
+		BudgetRemaining: 1,
+		BurnRate6h:      burnRate(counts[1], slo.Objective),
+		BurnRate1h:      burnRate(counts[2], slo.Objective),
+	}
+	if full.Total > 0 {
+		st.Compliance = 100 * float64(full.Total-full.Bad) / float64(full.Total)
+		st.BudgetRemaining = 1 - burnRate(full, slo.Objective)
+	}
+	return st, nil
+}
+
+// burnRate is the bad-trace ratio of c over the ratio objective allows.
+// Zero without traffic.
+func burnRate(c storage.SLOCount, objective float64) float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Bad) / float64(c.Total) / (1 - objective/100)
+}
+
+// measureBurnRate is the current burn rate of the rule's SLO over window.
+func (e *Engine) measureBurnRate(ctx context.Context, rule *storage.AlertRule, now time.Time, window time.Duration) (float64, bool, error) {
+	slo, err := e.repo.GetSLO(ctx, rule.SLOID)
+	if err != nil {
+		return 0, false, fmt.Errorf("load SLO %d: %w", rule.SLOID, err)
+	}
+	counts, err := e.repo.CountSLOEvents(ctx, slo, now, window)
+	if err != nil {
+		return 0, false, err
+	}
+	rate := burnRate(counts[0], slo.Objective)
+	return rate, counts[0].Total > 0 && rate > rule.Threshold, nil
+}
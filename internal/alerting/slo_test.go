@@ -0,0 +1,96 @@
+package alerting
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestValidateSLO(t *testing.T) {
+	slo := storage.SLO{Name: " checkout ", ServiceName: "checkout", Kind: storage.SLOKindAvailability, Objective: 99.5, LatencyThresholdMs: 300}
+	if err := ValidateSLO(&slo); err != nil {
+		t.Fatalf("valid SLO: %v", err)
+	}
+	if slo.Name != "checkout" || slo.WindowDays != defaultSLOWindowDays || slo.LatencyThresholdMs != 0 {
+		t.Errorf("defaults not applied: %+v", slo)
+	}
+
+	for name, mutate := range map[string]func(*storage.SLO){
+		"no name":           func(s *storage.SLO) { s.Name = "" },
+		"no service":        func(s *storage.SLO) { s.ServiceName = " " },
+		"bad kind":          func(s *storage.SLO) { s.Kind = "throughput" },
+		"objective 100":     func(s *storage.SLO) { s.Objective = 100 },
+		"objective 0":       func(s *storage.SLO) { s.Objective = 0 },
+		"latency threshold": func(s *storage.SLO) { s.Kind = storage.SLOKindLatency },
+		"window too long":   func(s *storage.SLO) { s.WindowDays = maxSLOWindowDays + 1 },
+	} {
+		s := storage.SLO{Name: "x", ServiceName: "checkout", Kind: storage.SLOKindAvailability, Objective: 99}
+		mutate(&s)
+		if err := ValidateSLO(&s); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestEngine_SLOStatusAndBurnRateAlert verifies compliance, budget and
+// burn rates for both SLO kinds, and that a burn-rate rule fires.
+func TestEngine_SLOStatusAndBurnRateAlert(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	avail := &storage.SLO{Name: "checkout availability", ServiceName: "checkout", Kind: storage.SLOKindAvailability, Objective: 99, WindowDays: 30}
+	latency := &storage.SLO{Name: "checkout p90", ServiceName: "checkout", Kind: storage.SLOKindLatency, Objective: 90, LatencyThresholdMs: 5, WindowDays: 30}
+	for _, slo := range []*storage.SLO{avail, latency} {
+		if err := repo.CreateSLO(ctx, slo); err != nil {
+			t.Fatalf("CreateSLO: %v", err)
+		}
+	}
+	rule := &storage.AlertRule{Name: "checkout budget burn", Kind: storage.AlertKindSLOBurnRate, SLOID: avail.ID, Threshold: 14.4}
+	if err := ValidateRule(rule); err != nil {
+		t.Fatalf("ValidateRule: %v", err)
+	}
+	if err := repo.CreateAlertRule(ctx, rule); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+
+	now := time.Now()
+	seedTraces(t, repo, "acme", "checkout", now.Add(-2*time.Hour), 99, 1)    // durations 1..100ms
+	seedTraces(t, repo, "acme", "checkout", now.Add(-10*time.Minute), 5, 5)  // durations 1..10ms
+	seedTraces(t, repo, "other", "checkout", now.Add(-10*time.Minute), 0, 9) // other tenant ignored
+
+	NewEngine(repo, time.Minute, nil).Evaluate(context.Background(), now)
+
+	got, err := repo.GetSLO(ctx, avail.ID)
+	if err != nil {
+		t.Fatalf("GetSLO: %v", err)
+	}
+	st := got.Status
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if st.EvaluatedAt == nil || st.Total != 110 || st.Bad != 6 ||
+		!near(st.Compliance, 100*104.0/110) || !near(st.BudgetRemaining, 1-(6.0/110)/0.01) ||
+		!near(st.BurnRate1h, 50) || !near(st.BurnRate6h, (6.0/110)/0.01) {
+		t.Errorf("availability status = %+v", st)
+	}
+	if got, _ := repo.GetSLO(ctx, latency.ID); got.Status.Total != 110 || got.Status.Bad != 100 {
+		t.Errorf("latency status = %+v, want 100 of 110 traces over 5ms", got.Status)
+	}
+
+	firing, err := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10)
+	if err != nil || len(firing) != 1 || !near(firing[0].Value, 50) || !strings.Contains(firing[0].Message, "burning at 50.0x") {
+		t.Fatalf("firing events = %+v, %v; want the burn-rate rule at 50x", firing, err)
+	}
+
+	// Deleting the SLO takes its burn-rate rule and open alert with it.
+	if err := repo.DeleteSLO(ctx, avail.ID); err != nil {
+		t.Fatalf("DeleteSLO: %v", err)
+	}
+	if rules, _ := repo.ListAlertRules(ctx); len(rules) != 0 {
+		t.Errorf("rules after SLO delete = %+v", rules)
+	}
+	if open, _ := repo.ListAlertEvents(ctx, storage.AlertStateFiring, 10); len(open) != 0 {
+		t.Errorf("firing events after SLO delete = %+v", open)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 )
 
 // handleGetStats handles GET /api/stats
@@ -24,7 +26,8 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(stats)
 }
 
-// handlePurge handles DELETE /api/admin/purge
+// handlePurge handles DELETE /api/admin/purge. Deletions are recorded with
+// reason admin_purge.
 func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
 	// Default: purge data older than 7 days
 	days := 7
@@ -36,14 +39,14 @@ func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
 
 	cutoff := time.Now().AddDate(0, 0, -days)
 
-	logsDeleted, err := s.repo.PurgeLogs(cutoff)
+	logsDeleted, err := s.repo.PurgeLogs(r.Context(), cutoff)
 	if err != nil {
 		slog.Error("Failed to purge logs", "cutoff", cutoff, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	tracesDeleted, err := s.repo.PurgeTraces(cutoff)
+	tracesDeleted, err := s.repo.PurgeTraces(r.Context(), cutoff)
 	if err != nil {
 		slog.Error("Failed to purge traces", "cutoff", cutoff, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -51,6 +54,8 @@ func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
 	}
 
 	slog.Info("Admin purge completed", "days", days, "logs_purged", logsDeleted, "traces_purged", tracesDeleted)
+	s.audit(r, storage.AuditActionDataPurge, "logs,traces",
+		fmt.Sprintf("older than %d days (before %s): %d logs, %d traces", days, cutoff.UTC().Format(time.RFC3339), logsDeleted, tracesDeleted))
 
 	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -61,12 +66,13 @@ func (s *Server) handlePurge(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleVacuum handles POST /api/admin/vacuum
-func (s *Server) handleVacuum(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) handleVacuum(w http.ResponseWriter, r *http.Request) {
 	if err := s.repo.VacuumDB(); err != nil {
 		slog.Error("Failed to vacuum database", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	s.audit(r, storage.AuditActionDBVacuum, "database", "")
 	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "vacuumed"})
 }
@@ -75,6 +81,7 @@ func (s *Server) handleVacuum(w http.ResponseWriter, _ *http.Request) {
 // virtual table + AFTER INSERT/DELETE/UPDATE triggers and runs VACUUM so the
 // freed pages are returned to the OS. One-shot reclaim for existing deploys
 // after LOG_FTS_ENABLED is set to false — typically reclaims 30-40% of DB disk.
+// On Postgres it drops the tsvector GIN index on logs.body instead.
 //
 // Refused (405) when LOG_FTS_ENABLED is currently truthy, because triggers
 // fire on every log INSERT and dropping them mid-flight would silently break
@@ -111,6 +118,7 @@ func (s *Server) handleDropFTS(w http.ResponseWriter, r *http.Request) {
 		reclaimed = 0
 	}
 	slog.Info("drop_fts completed", "elapsed_ms", elapsed.Milliseconds(), "reclaimed_bytes", reclaimed)
+	s.audit(r, storage.AuditActionLogsFTSDrop, "logs_fts", fmt.Sprintf("reclaimed %d bytes in %s", reclaimed, elapsed.Round(time.Millisecond)))
 
 	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
 	_ = json.NewEncoder(w).Encode(map[string]any{
@@ -118,3 +126,18 @@ func (s *Server) handleDropFTS(w http.ResponseWriter, r *http.Request) {
 		"elapsed_ms":      elapsed.Milliseconds(),
 	})
 }
+
+// handleIndexAdvice handles GET /api/admin/index_advice?min_queries=N. It
+// suggests composite indexes and attribute promotions from the query shapes
+// observed since startup; nothing is applied.
+func (s *Server) handleIndexAdvice(w http.ResponseWriter, r *http.Request) {
+	minQueries, _ := strconv.Atoi(r.URL.Query().Get("min_queries"))
+	advice, err := s.repo.AdviseIndexes(r.Context(), minQueries)
+	if err != nil {
+		slog.Error("Failed to build index advice", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(advice)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/alerting"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+// maxAlertRuleBody bounds POST /api/alerts/rules request bodies.
+const maxAlertRuleBody = 64 << 10
+
+// handleListAlertRules handles GET /api/alerts/rules
+func (s *Server) handleListAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := s.repo.ListAlertRules(r.Context())
+	if err != nil {
+		slog.Error("Failed to list alert rules", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rules == nil {
+		rules = []storage.AlertRule{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(rules)
+}
+
+// handleCreateAlertRule handles POST /api/alerts/rules
+func (s *Server) handleCreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var rule storage.AlertRule
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertRuleBody)).Decode(&rule); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := alerting.ValidateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rule.Kind == storage.AlertKindSLOBurnRate {
+		slo, err := s.repo.GetSLO(r.Context(), rule.SLOID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "slo_id does not name an SLO", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to load SLO for alert rule", "slo_id", rule.SLOID, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rule.ServiceName = slo.ServiceName
+	}
+	if rule.Kind == storage.AlertKindMonitorDown {
+		m, err := s.repo.GetMonitor(r.Context(), rule.MonitorID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "monitor_id does not name a monitor", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to load monitor for alert rule", "monitor_id", rule.MonitorID, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rule.ServiceName = m.ServiceName
+	}
+	if err := s.repo.CreateAlertRule(r.Context(), &rule); err != nil {
+		slog.Error("Failed to create alert rule", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionAlertRuleCreate, "alert_rule/"+strconv.FormatUint(uint64(rule.ID), 10),
+		fmt.Sprintf("%s rule %q on %s", rule.Kind, rule.Name, rule.ServiceName))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(rule)
+}
+
+// handleDeleteAlertRule handles DELETE /api/alerts/rules/{id}
+func (s *Server) handleDeleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.DeleteAlertRule(r.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "alert rule not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to delete alert rule", "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionAlertRuleDelete, "alert_rule/"+r.PathValue("id"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetAlerts handles GET /api/alerts?state=firing|resolved&limit=N
+func (s *Server) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	if state != "" && state != storage.AlertStateFiring && state != storage.AlertStateResolved {
+		http.Error(w, "state must be firing or resolved", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	events, err := s.repo.ListAlertEvents(r.Context(), state, limit)
+	if err != nil {
+		slog.Error("Failed to list alerts", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []storage.AlertEvent{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(events)
+}
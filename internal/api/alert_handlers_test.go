@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestAlertHandlers_RuleLifecycle(t *testing.T) {
+	repo := newAPITestRepoWithFTS(t)
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/alerts", srv.handleGetAlerts)
+	mux.HandleFunc("GET /api/alerts/rules", srv.handleListAlertRules)
+	mux.HandleFunc("POST /api/alerts/rules", srv.handleCreateAlertRule)
+	mux.HandleFunc("DELETE /api/alerts/rules/{id}", srv.handleDeleteAlertRule)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/alerts/rules", `{"name":"x","kind":"cpu"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid kind: want 400, got %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/alerts/rules",
+		`{"name":"oom","kind":"log_match","keyword":"OutOfMemoryError","channels":[{"type":"webhook","url":"https://hooks.example.com/x"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	var created storage.AlertRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == 0 || created.WindowSecs != 300 || created.TenantID != storage.DefaultTenantID {
+		t.Errorf("created = %+v", created)
+	}
+
+	var rules []storage.AlertRule
+	_ = json.Unmarshal(do(http.MethodGet, "/api/alerts/rules", "").Body.Bytes(), &rules)
+	if len(rules) != 1 || rules[0].Channels[0].URL != "https://hooks.example.com/x" {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	_ = repo.SaveAlertEvent(t.Context(), &storage.AlertEvent{
+		TenantID: storage.DefaultTenantID, RuleID: created.ID, RuleName: "oom", State: storage.AlertStateFiring, FiredAt: time.Now(),
+	})
+	var events []storage.AlertEvent
+	_ = json.Unmarshal(do(http.MethodGet, "/api/alerts?state=firing", "").Body.Bytes(), &events)
+	if len(events) != 1 {
+		t.Errorf("firing alerts = %d, want 1", len(events))
+	}
+	if rec := do(http.MethodGet, "/api/alerts?state=bogus", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad state: want 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/alerts/rules/999", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("delete missing: want 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/alerts/rules/"+strconv.FormatUint(uint64(created.ID), 10), ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: want 204, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxDimensionLimit caps the values a caller can ask for in one breakdown.
+const maxDimensionLimit = 500
+
+// handleGetDimensionBreakdown handles GET /api/analytics/dimension — span
+// count, traces, errors and latency grouped by an arbitrary span attribute
+// (e.g. ?key=inventory.warehouse). Query params: key (required), start, end
+// (RFC3339; default last 1h), service_name (repeatable), limit, root_only.
+func (s *Server) handleGetDimensionBreakdown(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "missing key", http.StatusBadRequest)
+		return
+	}
+	if len(key) > 255 {
+		http.Error(w, "key too long", http.StatusBadRequest)
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := storage.DimensionQuery{
+		Key:          key,
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+		RootOnly:     r.URL.Query().Get("root_only") == "true",
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(n, maxDimensionLimit)
+	}
+
+	started := time.Now()
+	resp, err := s.repo.GetDimensionBreakdown(r.Context(), q)
+	if err != nil {
+		slog.Error("Failed to get dimension breakdown", "key", key, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if time.Since(started) > 2*time.Second {
+		slog.Warn("Slow dimension breakdown", "key", key, "spans", resp.SpansScanned, "duration", time.Since(started)) // #nosec G706 -- slog uses structured k/v fields
+	}
+
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,395 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// managedKeyPrefix marks keys issued by POST /api/keys so authentication can
+// tell them apart from the shared API_KEY and tenant-file keys without a DB
+// round trip for every legacy credential.
+const managedKeyPrefix = "oc_"
+
+// apiKeyCacheTTL bounds how long a resolved key is trusted without a DB
+// lookup. It is also the granularity of APIKey.LastUsedAt.
+const apiKeyCacheTTL = 30 * time.Second
+
+// maxAPIKeyBody bounds POST /api/keys request bodies.
+const maxAPIKeyBody = 4 << 10
+
+// liveStreamTokenParam carries the key on /ws* upgrades. Browsers cannot set
+// an Authorization header on a WebSocket, so the query string is the only
+// place a UI can put it.
+const liveStreamTokenParam = "access_token"
+
+// APIKeyPrincipal is the identity an API key resolves to. An empty Tenant
+// means the credential is not bound to a tenant (the shared API_KEY), so the
+// X-Tenant-ID header still decides. Services, when set, restricts the live
+// streams to those services. Name identifies the key in the audit log.
+type APIKeyPrincipal struct {
+	Tenant   string
+	Scope    string
+	Services []string
+	Name     string
+}
+
+// Allows reports whether the principal may perform an action needing scope.
+// admin implies every other scope and write implies read.
+func (p APIKeyPrincipal) Allows(scope string) bool {
+	switch p.Scope {
+	case storage.APIKeyScopeAdmin:
+		return true
+	case storage.APIKeyScopeWrite:
+		return scope == storage.APIKeyScopeWrite || scope == storage.APIKeyScopeRead
+	}
+	return p.Scope == scope
+}
+
+type principalCtxKey struct{}
+
+// principalFromContext returns the principal Middleware authenticated the
+// request as, if managed API keys are on.
+func principalFromContext(ctx context.Context) (APIKeyPrincipal, bool) {
+	p, ok := ctx.Value(principalCtxKey{}).(APIKeyPrincipal)
+	return p, ok
+}
+
+type cachedAPIKey struct {
+	key     storage.APIKey
+	expires time.Time
+}
+
+// APIKeyAuth authenticates requests against the managed keys in the api_keys
+// table. The legacy shared API_KEY and API_TENANT_KEYS_FILE credentials keep
+// working alongside it and are treated as admin keys, which is what they
+// effectively were before scopes existed.
+type APIKeyAuth struct {
+	repo       *storage.Repository
+	sharedKey  []byte
+	tenantKeys *TenantKeyAuth
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey // key hash → resolved key
+}
+
+// NewAPIKeyAuth constructs an APIKeyAuth backed by repo.
+func NewAPIKeyAuth(repo *storage.Repository) *APIKeyAuth {
+	return &APIKeyAuth{repo: repo, cache: make(map[string]cachedAPIKey)}
+}
+
+// SetSharedKey accepts the legacy shared API_KEY as an unpinned admin key.
+// Empty disables it.
+func (a *APIKeyAuth) SetSharedKey(key string) {
+	a.sharedKey = []byte(key)
+}
+
+// SetTenantKeys accepts API_TENANT_KEYS_FILE keys as tenant-pinned admin keys.
+func (a *APIKeyAuth) SetTenantKeys(t *TenantKeyAuth) {
+	a.tenantKeys = t
+}
+
+// Authenticate resolves a bearer token to its principal. Lookup errors fail
+// closed.
+func (a *APIKeyAuth) Authenticate(ctx context.Context, token string) (APIKeyPrincipal, bool) {
+	if token == "" {
+		return APIKeyPrincipal{}, false
+	}
+	if strings.HasPrefix(token, managedKeyPrefix) {
+		key, ok := a.lookup(ctx, token)
+		if !ok {
+			return APIKeyPrincipal{}, false
+		}
+		return APIKeyPrincipal{Tenant: key.TenantID, Scope: key.Scope, Services: key.Services, Name: key.Prefix + " (" + key.Name + ")"}, true
+	}
+	if len(a.sharedKey) > 0 && subtle.ConstantTimeCompare([]byte(token), a.sharedKey) == 1 {
+		return APIKeyPrincipal{Scope: storage.APIKeyScopeAdmin, Name: "API_KEY"}, true
+	}
+	if tenant, ok := a.tenantKeys.Lookup(token); ok {
+		return APIKeyPrincipal{Tenant: tenant, Scope: storage.APIKeyScopeAdmin, Name: "tenant key (" + tenant + ")"}, true
+	}
+	return APIKeyPrincipal{}, false
+}
+
+// lookup resolves a managed key through the cache. Only hits are cached, so
+// a key created after a failed attempt works immediately.
+func (a *APIKeyAuth) lookup(ctx context.Context, token string) (storage.APIKey, bool) {
+	hash := hashAPIKey(token)
+	now := time.Now()
+	a.mu.Lock()
+	c, ok := a.cache[hash]
+	a.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.key, true
+	}
+
+	key, err := a.repo.APIKeyByHash(ctx, hash)
+	if err != nil {
+		slog.Warn("API key lookup failed", "error", err)
+		return storage.APIKey{}, false
+	}
+	if key == nil {
+		a.Invalidate(hash)
+		return storage.APIKey{}, false
+	}
+	if err := a.repo.TouchAPIKey(ctx, key.ID, now); err != nil {
+		slog.Debug("API key last_used_at update failed", "id", key.ID, "error", err)
+	}
+	a.mu.Lock()
+	a.cache[hash] = cachedAPIKey{key: *key, expires: now.Add(apiKeyCacheTTL)}
+	a.mu.Unlock()
+	return *key, true
+}
+
+// Invalidate drops a key hash from the cache so a revoked key stops working
+// immediately on this instance. Other replicas stop within apiKeyCacheTTL.
+func (a *APIKeyAuth) Invalidate(hash string) {
+	a.mu.Lock()
+	delete(a.cache, hash)
+	a.mu.Unlock()
+}
+
+// RequiredScope returns the scope a request needs: ingest for OTLP writes,
+// otherwise the scope acting as its RequiredRole — read for queries, write
+// for editor changes and admin for the rest.
+func RequiredScope(r *http.Request, mcpPath string) string {
+	if strings.HasPrefix(r.URL.Path, "/v1/") {
+		return storage.APIKeyScopeIngest
+	}
+	return scopeForRole(RequiredRole(r, mcpPath))
+}
+
+// Middleware enforces API keys on protected paths and on the /ws* live
+// streams, which need the read scope and may pass the key as the
+// access_token query parameter. Missing or unknown keys get 401, keys
+// lacking the required scope get 403, and a tenant-bound key pins its
+// tenant onto the request context, overriding X-Tenant-ID. The key's tenant
+// and services also go on the context as the realtime.Scope the hubs filter
+// by.
+func (a *APIKeyAuth) Middleware(mcpPath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		live := strings.HasPrefix(r.URL.Path, "/ws")
+		if (!live && !IsProtectedPath(r.URL.Path, mcpPath)) || isCORSPreflight(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		const prefix = "Bearer "
+		if token := r.URL.Query().Get(liveStreamTokenParam); live && auth == "" && token != "" {
+			auth = prefix + token
+		}
+		if auth == "" {
+			recordAuthFailure("missing_header")
+			writeUnauthorized(w)
+			return
+		}
+		if !strings.HasPrefix(auth, prefix) {
+			recordAuthFailure("bad_scheme")
+			writeUnauthorized(w)
+			return
+		}
+		p, ok := a.Authenticate(r.Context(), strings.TrimPrefix(auth, prefix))
+		if !ok {
+			recordAuthFailure("bad_key")
+			writeUnauthorized(w)
+			return
+		}
+		if !p.Allows(RequiredScope(r, mcpPath)) {
+			recordAuthFailure("insufficient_scope")
+			writeForbidden(w)
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalCtxKey{}, p)
+		ctx = realtime.WithScope(ctx, realtime.Scope{Tenant: p.Tenant, Services: p.Services})
+		if p.Tenant != "" {
+			ctx = storage.WithTenantContext(ctx, p.Tenant)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UnaryServerInterceptor enforces ingest-scoped API keys on the OTLP gRPC
+// collector services. The key travels in the "authorization" metadata as
+// "Bearer <key>"; other services on the server pass through.
+func (a *APIKeyAuth) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, "/opentelemetry.proto.collector.") {
+			return handler(ctx, req)
+		}
+		var auth string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				auth = v[0]
+			}
+		}
+		token, found := strings.CutPrefix(auth, "Bearer ")
+		if !found {
+			recordAuthFailure("missing_header")
+			return nil, status.Error(codes.Unauthenticated, "missing bearer API key")
+		}
+		p, ok := a.Authenticate(ctx, token)
+		if !ok {
+			recordAuthFailure("bad_key")
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if !p.Allows(storage.APIKeyScopeIngest) {
+			recordAuthFailure("insufficient_scope")
+			return nil, status.Error(codes.PermissionDenied, "API key lacks the ingest scope")
+		}
+		if p.Tenant != "" {
+			ctx = storage.WithTenantContext(ctx, p.Tenant)
+		}
+		return handler(ctx, req)
+	}
+}
+
+func writeForbidden(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`{"error":"forbidden"}`))
+}
+
+// hashAPIKey returns the hex SHA-256 stored in APIKey.KeyHash. Keys carry
+// 256 bits of entropy, so an unsalted fast hash is sufficient.
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAPIKey returns a fresh managed key: "oc_" + 32 random bytes, base64url.
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return managedKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SetAPIKeyAuth enables the /api/keys management endpoints. Revocations
+// invalidate a's cache.
+func (s *Server) SetAPIKeyAuth(a *APIKeyAuth) {
+	s.apiKeys = a
+}
+
+// createdAPIKey is the POST /api/keys response: the stored key plus its
+// plaintext, which is never retrievable again.
+type createdAPIKey struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+// handleListAPIKeys handles GET /api/keys
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.repo.ListAPIKeys(r.Context())
+	if err != nil {
+		slog.Error("Failed to list API keys", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if keys == nil {
+		keys = []storage.APIKey{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(keys)
+}
+
+// handleCreateAPIKey handles POST /api/keys
+// {"name": "...", "scope": "ingest|read|write|admin", "services": ["..."]}
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string   `json:"name"`
+		Scope    string   `json:"scope"`
+		Services []string `json:"services"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIKeyBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > 255 {
+		http.Error(w, "name is required (max 255 chars)", http.StatusBadRequest)
+		return
+	}
+	if !storage.ValidAPIKeyScope(body.Scope) {
+		http.Error(w, "scope must be ingest, read, write or admin", http.StatusBadRequest)
+		return
+	}
+	var services []string
+	for _, svc := range body.Services {
+		svc = strings.TrimSpace(svc)
+		if svc == "" || len(svc) > 255 {
+			http.Error(w, "services must be non-empty names (max 255 chars)", http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(services, svc) {
+			services = append(services, svc)
+		}
+	}
+	plaintext, err := newAPIKey()
+	if err != nil {
+		slog.Error("Failed to generate API key", "error", err)
+		http.Error(w, "failed to generate key", http.StatusInternalServerError)
+		return
+	}
+	key := storage.APIKey{
+		Name:     body.Name,
+		Prefix:   plaintext[:10],
+		KeyHash:  hashAPIKey(plaintext),
+		Scope:    body.Scope,
+		Services: services,
+	}
+	if err := s.repo.CreateAPIKey(r.Context(), &key); err != nil {
+		slog.Error("Failed to create API key", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionAPIKeyCreate, "api_key/"+strconv.FormatUint(uint64(key.ID), 10),
+		fmt.Sprintf("%s key %s (%s)", key.Scope, key.Prefix, key.Name))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(createdAPIKey{APIKey: key, Key: plaintext})
+}
+
+// handleDeleteAPIKey handles DELETE /api/keys/{id}
+func (s *Server) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	hash, err := s.repo.DeleteAPIKey(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "api key not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to delete API key", "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.apiKeys.Invalidate(hash)
+	s.audit(r, storage.AuditActionAPIKeyRevoke, "api_key/"+r.PathValue("id"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newAPIKeyStack wires APIKeyAuth → TenantMiddleware → mux the way main.go
+// does, with /api/keys plus probe routes that echo the resolved tenant.
+func newAPIKeyStack(t *testing.T) (*APIKeyAuth, http.Handler) {
+	t.Helper()
+	repo := newAPITestRepoWithoutFTS(t)
+	auth := NewAPIKeyAuth(repo)
+	auth.SetSharedKey("legacy-secret")
+	s := &Server{repo: repo}
+	s.SetAPIKeyAuth(auth)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/keys", s.handleListAPIKeys)
+	mux.HandleFunc("POST /api/keys", s.handleCreateAPIKey)
+	mux.HandleFunc("DELETE /api/keys/{id}", s.handleDeleteAPIKey)
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(storage.TenantFromContext(r.Context())))
+	}
+	mux.HandleFunc("GET /api/traces", echo)
+	mux.HandleFunc("POST /v1/traces", echo)
+	mux.HandleFunc("POST /api/search", echo)
+	mux.HandleFunc("POST /api/views", echo)
+	mux.HandleFunc("DELETE /api/alerts/rules/{id}", echo)
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		sc := realtime.ScopeFromContext(r.Context())
+		_, _ = w.Write([]byte(sc.Tenant + ":" + strings.Join(sc.Services, ",")))
+	})
+	return auth, auth.Middleware("/mcp", TenantMiddleware(nil)(mux))
+}
+
+func doKeyRequest(h http.Handler, method, path, key, tenant, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	if tenant != "" {
+		req.Header.Set(TenantHeader, tenant)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func createKey(t *testing.T, h http.Handler, tenant, scope string) createdAPIKey {
+	t.Helper()
+	rec := doKeyRequest(h, http.MethodPost, "/api/keys", "legacy-secret", tenant, `{"name":"`+scope+`","scope":"`+scope+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create %s key: status %d (%s)", scope, rec.Code, rec.Body.String())
+	}
+	var out createdAPIKey
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasPrefix(out.Key, managedKeyPrefix) || !strings.HasPrefix(out.Key, out.Prefix) || strings.Contains(rec.Body.String(), "key_hash") {
+		t.Fatalf("created key = %s", rec.Body.String())
+	}
+	return out
+}
+
+func TestAPIKeyAuth_Scopes(t *testing.T) {
+	_, h := newAPIKeyStack(t)
+	ingest := createKey(t, h, "acme", storage.APIKeyScopeIngest)
+	read := createKey(t, h, "acme", storage.APIKeyScopeRead)
+	write := createKey(t, h, "acme", storage.APIKeyScopeWrite)
+	admin := createKey(t, h, "acme", storage.APIKeyScopeAdmin)
+
+	cases := []struct {
+		name, method, path, key string
+		want                    int
+	}{
+		{"no key", http.MethodGet, "/api/traces", "", http.StatusUnauthorized},
+		{"unknown managed key", http.MethodGet, "/api/traces", "oc_nope", http.StatusUnauthorized},
+		{"ingest writes", http.MethodPost, "/v1/traces", ingest.Key, http.StatusOK},
+		{"ingest cannot read", http.MethodGet, "/api/traces", ingest.Key, http.StatusForbidden},
+		{"read reads", http.MethodGet, "/api/traces", read.Key, http.StatusOK},
+		{"read posts searches", http.MethodPost, "/api/search", read.Key, http.StatusOK},
+		{"read cannot ingest", http.MethodPost, "/v1/traces", read.Key, http.StatusForbidden},
+		{"read cannot list keys", http.MethodGet, "/api/keys", read.Key, http.StatusForbidden},
+		{"read cannot save views", http.MethodPost, "/api/views", read.Key, http.StatusForbidden},
+		{"write reads", http.MethodGet, "/api/traces", write.Key, http.StatusOK},
+		{"write saves views", http.MethodPost, "/api/views", write.Key, http.StatusOK},
+		{"write deletes alert rules", http.MethodDelete, "/api/alerts/rules/3", write.Key, http.StatusOK},
+		{"write cannot change settings", http.MethodPatch, "/api/config", write.Key, http.StatusForbidden},
+		{"write cannot list keys", http.MethodGet, "/api/keys", write.Key, http.StatusForbidden},
+		{"admin lists keys", http.MethodGet, "/api/keys", admin.Key, http.StatusOK},
+		{"admin ingests", http.MethodPost, "/v1/traces", admin.Key, http.StatusOK},
+	}
+	for _, tc := range cases {
+		if rec := doKeyRequest(h, tc.method, tc.path, tc.key, "", ""); rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestAPIKeyAuth_TenantPinningAndRevocation(t *testing.T) {
+	_, h := newAPIKeyStack(t)
+	read := createKey(t, h, "acme", storage.APIKeyScopeRead)
+
+	// A tenant-bound key overrides X-Tenant-ID on both read and ingest paths.
+	if rec := doKeyRequest(h, http.MethodGet, "/api/traces", read.Key, "evil", ""); rec.Body.String() != "acme" {
+		t.Errorf("read tenant = %q, want acme", rec.Body.String())
+	}
+	// The unpinned shared key still honours the header.
+	if rec := doKeyRequest(h, http.MethodGet, "/api/traces", "legacy-secret", "beta", ""); rec.Body.String() != "beta" {
+		t.Errorf("shared key tenant = %q, want beta", rec.Body.String())
+	}
+
+	// Keys are listed per tenant.
+	var keys []storage.APIKey
+	rec := doKeyRequest(h, http.MethodGet, "/api/keys", "legacy-secret", "beta", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &keys); err != nil || len(keys) != 0 {
+		t.Errorf("beta keys = %s", rec.Body.String())
+	}
+
+	del := "/api/keys/" + strconv.FormatUint(uint64(read.ID), 10)
+	if rec := doKeyRequest(h, http.MethodDelete, del, "legacy-secret", "beta", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("cross-tenant revoke: status %d, want 404", rec.Code)
+	}
+	if rec := doKeyRequest(h, http.MethodDelete, del, "legacy-secret", "acme", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: status %d (%s)", rec.Code, rec.Body.String())
+	}
+	// The cached entry is invalidated, so the key stops working at once.
+	if rec := doKeyRequest(h, http.MethodGet, "/api/traces", read.Key, "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key: status %d, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyAuth_LiveStreamScope(t *testing.T) {
+	_, h := newAPIKeyStack(t)
+	rec := doKeyRequest(h, http.MethodPost, "/api/keys", "legacy-secret", "acme", `{"name":"team-a","scope":"read","services":["payment-service"," payment-service ","checkout"]}`)
+	var key createdAPIKey
+	if err := json.Unmarshal(rec.Body.Bytes(), &key); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d (%s)", rec.Code, rec.Body.String())
+	}
+	if len(key.Services) != 2 {
+		t.Errorf("services = %v, want trimmed and deduplicated", key.Services)
+	}
+	ingest := createKey(t, h, "acme", storage.APIKeyScopeIngest)
+
+	cases := []struct {
+		name, path, key string
+		want            int
+		body            string
+	}{
+		{"no key", "/ws", "", http.StatusUnauthorized, ""},
+		{"ingest key", "/ws", ingest.Key, http.StatusForbidden, ""},
+		{"header", "/ws", key.Key, http.StatusOK, "acme:payment-service,checkout"},
+		{"query parameter", "/ws?access_token=" + key.Key, "", http.StatusOK, "acme:payment-service,checkout"},
+		{"shared key", "/ws", "legacy-secret", http.StatusOK, ":"},
+	}
+	for _, tc := range cases {
+		rec := doKeyRequest(h, http.MethodGet, tc.path, tc.key, "", "")
+		if rec.Code != tc.want || (tc.body != "" && rec.Body.String() != tc.body) {
+			t.Errorf("%s: status %d body %q, want %d %q", tc.name, rec.Code, rec.Body.String(), tc.want, tc.body)
+		}
+	}
+	// The query parameter is for WebSocket upgrades only.
+	if rec := doKeyRequest(h, http.MethodGet, "/api/traces?access_token="+key.Key, "", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access_token on /api: status %d, want 401", rec.Code)
+	}
+}
+
+func TestCreateAPIKey_Validation(t *testing.T) {
+	_, h := newAPIKeyStack(t)
+	for _, body := range []string{`not json`, `{"name":"","scope":"read"}`, `{"name":"x","scope":"root"}`, `{"name":"x","scope":"read","services":[""]}`} {
+		if rec := doKeyRequest(h, http.MethodPost, "/api/keys", "legacy-secret", "", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestAPIKeyAuth_UnaryServerInterceptor(t *testing.T) {
+	auth, h := newAPIKeyStack(t)
+	ingest := createKey(t, h, "acme", storage.APIKeyScopeIngest)
+	read := createKey(t, h, "acme", storage.APIKeyScopeRead)
+	interceptor := auth.UnaryServerInterceptor()
+	export := &grpc.UnaryServerInfo{FullMethod: "/opentelemetry.proto.collector.trace.v1.TraceService/Export"}
+
+	call := func(info *grpc.UnaryServerInfo, key string) (string, codes.Code) {
+		ctx := context.Background()
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+key))
+		}
+		resp, err := interceptor(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+			return storage.TenantFromContext(ctx), nil
+		})
+		if err != nil {
+			return "", status.Code(err)
+		}
+		return resp.(string), codes.OK
+	}
+
+	if tenant, code := call(export, ingest.Key); code != codes.OK || tenant != "acme" {
+		t.Errorf("ingest key: %v tenant %q, want OK acme", code, tenant)
+	}
+	if _, code := call(export, read.Key); code != codes.PermissionDenied {
+		t.Errorf("read key: %v, want PermissionDenied", code)
+	}
+	if _, code := call(export, ""); code != codes.Unauthenticated {
+		t.Errorf("no key: %v, want Unauthenticated", code)
+	}
+	if _, code := call(&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, ""); code != codes.OK {
+		t.Errorf("non-OTLP method: %v, want pass-through", code)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/ai"
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// askCacheTTL bounds how stale an answer may be. Each miss is an LLM call,
+// so a repeated question is served from cache instead.
+const askCacheTTL = 30 * time.Second
+
+// askResponse is the GET /api/ask payload: the generated filter, and the
+// search it ran unless the filter was unusable.
+type askResponse struct {
+	Question string               `json:"question"`
+	Filter   *ai.QueryTranslation `json:"filter"`
+	Result   *views.SearchResult  `json:"result,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// handleAsk handles GET /api/ask — a natural language question ("payment
+// traces over 1s with gateway timeouts in the last hour") translated by the
+// AI service into an ArgusQL search, which is then run like /api/search.
+// Query params: q (required), limit, region.
+//
+// The response carries the generated filter for transparency. A filter the
+// search rejects is a 422 with the filter and the search's message; 503
+// when AI is disabled. Responses are cached per tenant and query string for
+// 30s.
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	question := r.URL.Query().Get("q")
+	if question == "" {
+		http.Error(w, "missing q", http.StatusBadRequest)
+		return
+	}
+	if len(question) > ai.MaxQuestionLength {
+		http.Error(w, "q too long", http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	ctx := regionFilter(r)
+	cacheKey := "ask:" + storage.TenantFromContext(ctx) + ":" + r.URL.RawQuery
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+		w.Header().Set("X-Cache", "HIT")
+		_ = json.NewEncoder(w).Encode(cached)
+		return
+	}
+
+	filter, err := s.ai.TranslateQuery(ctx, question)
+	switch {
+	case errors.Is(err, ai.ErrDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, ai.ErrUntranslatable):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		slog.Error("Failed to translate question", "error", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp := askResponse{Question: question, Filter: filter}
+	// Same 24h cap on log searches as /api/search.
+	if filter.Signal == storage.SearchSignalLogs {
+		filter.Start, filter.End, err = storage.ClampSearchWindowTo24h(filter.Start, filter.End, time.Now())
+		if err != nil {
+			resp.Error = err.Error()
+			writeAskResponse(w, http.StatusUnprocessableEntity, resp)
+			return
+		}
+	}
+
+	result, err := s.repo.Search(ctx, storage.SearchQuery{
+		Query:  filter.Query,
+		Signal: filter.Signal,
+		Start:  filter.Start,
+		End:    filter.End,
+		Limit:  limit,
+	})
+	if errors.Is(err, storage.ErrInvalidSearchQuery) {
+		resp.Error = err.Error()
+		writeAskResponse(w, http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to run search for question", "signal", filter.Signal, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	view := views.SearchResultFromModel(result)
+	resp.Result = &view
+
+	s.cache.Set(cacheKey, resp, askCacheTTL)
+	w.Header().Set("X-Cache", "MISS")
+	writeAskResponse(w, http.StatusOK, resp)
+}
+
+func writeAskResponse(w http.ResponseWriter, status int, resp askResponse) {
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/ai"
+	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// scriptedLLM answers every prompt with the answer for the question it
+// contains.
+type scriptedLLM map[string]string
+
+func (l scriptedLLM) Complete(_ context.Context, prompt string) (string, error) {
+	for question, answer := range l {
+		if strings.Contains(prompt, "Question: "+question+"\n") {
+			return answer, nil
+		}
+	}
+	return "no idea", nil
+}
+
+func TestHandleAsk(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	if err := repo.BatchCreateSpans([]storage.Span{
+		{TenantID: storage.DefaultTenantID, TraceID: "slow", SpanID: "a", ServiceName: "payment-service", OperationName: "charge", StartTime: now.Add(-time.Minute), Duration: 2_000_000},
+		{TenantID: storage.DefaultTenantID, TraceID: "fast", SpanID: "b", ServiceName: "payment-service", OperationName: "charge", StartTime: now.Add(-time.Minute), Duration: 20_000},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BatchCreateTraces([]storage.Trace{
+		{TenantID: storage.DefaultTenantID, TraceID: "slow", ServiceName: "payment-service", Timestamp: now.Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, TraceID: "fast", ServiceName: "payment-service", Timestamp: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := ai.NewServiceWithClient(repo, scriptedLLM{
+		"slow payments":   `{"signal":"traces","query":"service=\"payment-service\" AND duration>1s","since":"1h"}`,
+		"colourful spans": `{"signal":"traces","query":"colour=red","since":"1h"}`,
+	})
+	t.Cleanup(svc.Stop)
+	c := cache.New()
+	t.Cleanup(c.Stop)
+	srv := &Server{repo: repo, cache: c, ai: svc}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/ask", srv.handleAsk)
+	ask := func(params url.Values) (*httptest.ResponseRecorder, askResponse) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ask?"+params.Encode(), nil))
+		var got askResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec, got
+	}
+
+	rec, got := ask(url.Values{"q": {"slow payments"}})
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("status = %d cache=%q body=%q", rec.Code, rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+	if got.Filter == nil || got.Filter.Query != `service="payment-service" AND duration>1s` || got.Filter.Signal != "traces" {
+		t.Errorf("filter = %+v", got.Filter)
+	}
+	if got.Result == nil || got.Result.Matched != 1 || got.Result.Traces[0].TraceID != "slow" {
+		t.Errorf("result = %+v", got.Result)
+	}
+	if rec, _ := ask(url.Values{"q": {"slow payments"}}); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("second request X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	// A filter the search rejects comes back with the reason.
+	rec, got = ask(url.Values{"q": {"colourful spans"}})
+	if rec.Code != http.StatusUnprocessableEntity || got.Filter == nil || got.Filter.Query != "colour=red" || got.Error == "" {
+		t.Errorf("invalid filter: status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if rec, _ := ask(url.Values{"q": {"what is love"}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("untranslatable: status = %d, want 422", rec.Code)
+	}
+	if rec, _ := ask(url.Values{}); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing q: status = %d, want 400", rec.Code)
+	}
+
+	srv.ai = nil
+	if rec, _ := ask(url.Values{"q": {"slow payments again"}}); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("AI disabled: status = %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestAudit_RecordsAdministrativeActions(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	auth := NewAPIKeyAuth(repo)
+	auth.SetSharedKey("legacy-secret")
+	srv := &Server{repo: repo}
+	srv.SetAPIKeyAuth(auth)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/keys", srv.handleCreateAPIKey)
+	mux.HandleFunc("DELETE /api/keys/{id}", srv.handleDeleteAPIKey)
+	mux.HandleFunc("POST /api/alerts/rules", srv.handleCreateAlertRule)
+	mux.HandleFunc("DELETE /api/alerts/rules/{id}", srv.handleDeleteAlertRule)
+	mux.HandleFunc("GET /api/admin/audit", srv.handleGetAuditEvents)
+	h := auth.Middleware("/mcp", TenantMiddleware(nil)(mux))
+
+	ops := createKey(t, h, "acme", storage.APIKeyScopeAdmin)
+	rec := doKeyRequest(h, http.MethodPost, "/api/alerts/rules", ops.Key, "", `{"name":"errors","kind":"error_rate","service_name":"checkout","threshold":0.1}`)
+	var rule storage.AlertRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create rule = %d %s", rec.Code, rec.Body.String())
+	}
+	ruleID := strconv.FormatUint(uint64(rule.ID), 10)
+	if rec := doKeyRequest(h, http.MethodDelete, "/api/alerts/rules/"+ruleID, ops.Key, "", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete rule = %d", rec.Code)
+	}
+	// A failed action is not recorded.
+	if rec := doKeyRequest(h, http.MethodDelete, "/api/alerts/rules/999", ops.Key, "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing rule = %d", rec.Code)
+	}
+	if rec := doKeyRequest(h, http.MethodDelete, "/api/keys/"+strconv.FormatUint(uint64(ops.ID), 10), "legacy-secret", "acme", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke = %d", rec.Code)
+	}
+
+	var events []storage.AuditEvent
+	rec = doKeyRequest(h, http.MethodGet, "/api/admin/audit", "legacy-secret", "acme", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+		t.Fatalf("audit = %d %s", rec.Code, rec.Body.String())
+	}
+	want := []struct{ action, actor, resource string }{
+		{storage.AuditActionAPIKeyRevoke, "API_KEY", "api_key/" + strconv.FormatUint(uint64(ops.ID), 10)},
+		{storage.AuditActionAlertRuleDelete, ops.Prefix + " (admin)", "alert_rule/" + ruleID},
+		{storage.AuditActionAlertRuleCreate, ops.Prefix + " (admin)", "alert_rule/" + ruleID},
+		{storage.AuditActionAPIKeyCreate, "API_KEY", "api_key/" + strconv.FormatUint(uint64(ops.ID), 10)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d", events, len(want))
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Action != w.action || e.Actor != w.actor || e.Resource != w.resource || e.ActorType != storage.AuditActorAPIKey || e.TenantID != "acme" {
+			t.Errorf("event %d = %+v, want %s by %s on %s", i, e, w.action, w.actor, w.resource)
+		}
+	}
+	if events[2].Details != `error_rate rule "errors" on checkout` {
+		t.Errorf("create details = %q", events[2].Details)
+	}
+
+	rec = doKeyRequest(h, http.MethodGet, "/api/admin/audit?action=api_key.create", "legacy-secret", "acme", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil || len(events) != 1 {
+		t.Errorf("filtered audit = %s", rec.Body.String())
+	}
+	if rec := doKeyRequest(h, http.MethodGet, "/api/admin/audit?limit=x", "legacy-secret", "acme", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad limit = %d, want 400", rec.Code)
+	}
+}
+
+func TestAudit_InstanceWideActionsListedForEveryTenant(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	auth := NewAPIKeyAuth(repo)
+	auth.SetSharedKey("legacy-secret")
+	srv := &Server{repo: repo}
+	srv.SetAPIKeyAuth(auth)
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/admin/purge", srv.handlePurge)
+	mux.HandleFunc("POST /api/admin/vacuum", srv.handleVacuum)
+	mux.HandleFunc("POST /api/admin/drop_fts", srv.handleDropFTS)
+	mux.HandleFunc("GET /api/admin/audit", srv.handleGetAuditEvents)
+	h := auth.Middleware("/mcp", TenantMiddleware(nil)(mux))
+	t.Setenv("LOG_FTS_ENABLED", "false")
+
+	// A purge deletes every tenant's data, whichever tenant asked for it.
+	if rec := doKeyRequest(h, http.MethodDelete, "/api/admin/purge?days=30", "legacy-secret", "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("purge = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := doKeyRequest(h, http.MethodPost, "/api/admin/vacuum", "legacy-secret", "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("vacuum = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := doKeyRequest(h, http.MethodPost, "/api/admin/drop_fts", "legacy-secret", "acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("drop_fts = %d %s", rec.Code, rec.Body.String())
+	}
+	var events []storage.AuditEvent
+	rec := doKeyRequest(h, http.MethodGet, "/api/admin/audit", "legacy-secret", "beta", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+		t.Fatalf("audit = %d %s", rec.Code, rec.Body.String())
+	}
+	want := []string{storage.AuditActionLogsFTSDrop, storage.AuditActionDBVacuum, storage.AuditActionDataPurge}
+	if len(events) != len(want) {
+		t.Fatalf("beta audit = %+v, want acme's %v", events, want)
+	}
+	for i, action := range want {
+		if e := events[i]; e.Action != action || e.TenantID != "acme" || !e.Global {
+			t.Errorf("event %d = %+v, want acme's global %s", i, e, action)
+		}
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/views", nil)
+	if actor, kind := auditActor(req); actor != "anonymous" || kind != storage.AuditActorAnonymous {
+		t.Errorf("no auth = %q %q", actor, kind)
+	}
+	ctx := context.WithValue(req.Context(), sessionUserCtxKey{}, SessionUser{Subject: "u1", Email: "ada@example.com", Role: RoleAdmin})
+	if actor, kind := auditActor(req.WithContext(ctx)); actor != "ada@example.com" || kind != storage.AuditActorUser {
+		t.Errorf("session = %q %q", actor, kind)
+	}
+	req.Header.Set("Authorization", "Bearer shared")
+	if actor, kind := auditActor(req); actor != "API key" || kind != storage.AuditActorAPIKey {
+		t.Errorf("legacy key = %q %q", actor, kind)
+	}
+}
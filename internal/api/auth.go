@@ -11,7 +11,9 @@ import (
 // Left as a package-level function pointer (rather than a DI parameter) to
 // avoid circular imports between api and telemetry. Safe to leave nil.
 //
-// Reasons: "missing_header", "bad_scheme", "bad_key".
+// Reasons: "missing_header", "bad_scheme", "bad_key", "client_cert",
+// "no_session" and the OIDC sign-in failures "oidc_denied", "oidc_state",
+// "oidc_token" and "oidc_domain".
 var AuthFailureHook func(reason string)
 
 func recordAuthFailure(reason string) {
@@ -101,10 +103,34 @@ func APIKeyGate(expectedKey, mcpPath string, next http.Handler) http.Handler {
 	}
 	protected := RequireAPIKey(expectedKey, next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if IsProtectedPath(r.URL.Path, mcpPath) {
+		if IsProtectedPath(r.URL.Path, mcpPath) && !isCORSPreflight(r) {
 			protected.ServeHTTP(w, r)
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireClientCert wraps next so OTLP/HTTP requests (/v1/*) need a client
+// certificate verified in the TLS handshake against OTLP_TLS_CLIENT_CA. The
+// HTTP server only asks for one, so the UI and query API on the same port
+// stay reachable without a certificate.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/") && !isCORSPreflight(r) &&
+			(r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			recordAuthFailure("client_cert")
+			writeUnauthorized(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isCORSPreflight reports whether r is a browser CORS preflight. Browsers
+// never attach credentials to preflights, so gating them on the API key
+// would make every cross-origin export fail before the real (authenticated)
+// request is sent. Preflight handlers only emit headers, never data.
+func isCORSPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
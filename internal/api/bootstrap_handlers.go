@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Auth modes reported by /api/bootstrap, strongest first.
+const (
+	authModeAPIKeys    = "api_keys"    // managed, scoped keys (API_KEYS_ENABLED)
+	authModeTenantKeys = "tenant_keys" // API_TENANT_KEYS_FILE
+	authModeSharedKey  = "shared_key"  // API_KEY
+	authModeNone       = "none"
+)
+
+// bootstrapResponse is everything the UI needs to configure itself, in one
+// call.
+type bootstrapResponse struct {
+	Tenant       string   `json:"tenant"`
+	TenantPinned bool     `json:"tenant_pinned"` // the credential fixes the tenant; X-Tenant-ID is ignored
+	Tenants      []string `json:"tenants"`       // tenants the caller can switch to
+
+	Auth         string   `json:"auth"`
+	Scope        string   `json:"scope"`                   // ingest | read | write | admin
+	Role         Role     `json:"role,omitempty"`          // viewer | editor | admin; empty for ingest keys
+	Permissions  []string `json:"permissions"`             // scopes the caller holds; admin implies the rest
+	LiveServices []string `json:"live_services,omitempty"` // the key's live-stream service restriction
+
+	Features  bootstrapFeatures  `json:"features"`
+	Flags     map[string]bool    `json:"flags"` // featureflag registry values
+	Retention bootstrapRetention `json:"retention"`
+	Services  []string           `json:"services"`
+}
+
+// bootstrapFeatures says which optional subsystems are on. A false flag
+// means the UI should hide the feature: its endpoints 503 or are absent.
+type bootstrapFeatures struct {
+	Alerting       bool `json:"alerting"`
+	AI             bool `json:"ai"`
+	APIKeys        bool `json:"api_keys"`
+	MCP            bool `json:"mcp"`
+	LogFTS         bool `json:"log_fts"`
+	GraphRAG       bool `json:"graphrag"`
+	SemanticSearch bool `json:"semantic_search"`
+	LiveTraces     bool `json:"live_traces"`
+	TopK           bool `json:"top_k"`
+	DLQAdmin       bool `json:"dlq_admin"`
+}
+
+// bootstrapRetention is the caller's tenant's effective retention window
+// per signal, as Go durations.
+type bootstrapRetention struct {
+	Logs           string `json:"logs"`
+	Traces         string `json:"traces"`
+	Metrics        string `json:"metrics"`
+	TenantOverride bool   `json:"tenant_override"` // RETENTION_TENANTS sets this tenant's window
+}
+
+// handleBootstrap handles GET /api/bootstrap — the caller's tenant,
+// permissions, feature flags, retention and services, so the UI does not
+// need a request per setting.
+func (s *Server) handleBootstrap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenant := storage.TenantFromContext(ctx)
+	resp := bootstrapResponse{
+		Tenant:   tenant,
+		Auth:     authModeNone,
+		Scope:    storage.APIKeyScopeAdmin,
+		Role:     RoleAdmin,
+		Services: []string{},
+		Features: bootstrapFeatures{
+			AI:             s.ai.Enabled(),
+			APIKeys:        s.apiKeys != nil,
+			GraphRAG:       s.graphRAG != nil,
+			SemanticSearch: s.vectorIdx != nil,
+			LiveTraces:     s.traceStreams != nil,
+			TopK:           s.topK != nil,
+			DLQAdmin:       s.dlq != nil,
+		},
+		Retention: s.bootstrapRetention(tenant),
+	}
+	if cfg := s.cfg; cfg != nil {
+		resp.Features.Alerting = cfg.AlertingEnabled
+		resp.Features.MCP = cfg.MCPEnabled
+		resp.Features.LogFTS = cfg.LogFTSEnabled
+		switch {
+		case cfg.APIKeysEnabled:
+			resp.Auth = authModeAPIKeys
+		case cfg.APITenantKeysFile != "":
+			resp.Auth = authModeTenantKeys
+			resp.TenantPinned = true
+		case cfg.APIKey != "":
+			resp.Auth = authModeSharedKey
+		}
+	}
+	// Only managed keys and signed-in users carry a scope or role; every
+	// other credential that got this far is effectively admin.
+	if p, ok := principalFromContext(ctx); ok {
+		resp.Scope = p.Scope
+		resp.Role = roleForScope(p.Scope)
+		resp.TenantPinned = p.Tenant != ""
+		resp.LiveServices = p.Services
+	}
+	if u, ok := SessionUserFromContext(ctx); ok {
+		resp.Scope = scopeForRole(u.Role)
+		resp.Role = u.Role
+	}
+	for _, scope := range []string{storage.APIKeyScopeIngest, storage.APIKeyScopeRead, storage.APIKeyScopeWrite, storage.APIKeyScopeAdmin} {
+		if (APIKeyPrincipal{Scope: resp.Scope}).Allows(scope) {
+			resp.Permissions = append(resp.Permissions, scope)
+		}
+	}
+
+	if s.flags != nil {
+		resp.Flags = s.flags.Values()
+	}
+
+	resp.Tenants = []string{tenant}
+	if !resp.TenantPinned && s.graphRAG != nil {
+		resp.Tenants = s.graphRAG.Tenants()
+		if !slices.Contains(resp.Tenants, tenant) {
+			resp.Tenants = append(resp.Tenants, tenant)
+			slices.Sort(resp.Tenants)
+		}
+	}
+	if s.graphRAG != nil {
+		resp.Services = s.graphRAG.ServiceNames(ctx)
+	}
+
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// bootstrapRetention resolves tenant's windows the way the retention
+// scheduler does: a RETENTION_TENANTS entry replaces every signal's window,
+// otherwise RETENTION_<SIGNAL> overrides HOT_RETENTION_DAYS. The values were
+// validated at startup, so parse errors leave the default.
+func (s *Server) bootstrapRetention(tenant string) bootstrapRetention {
+	days := 7
+	var traces, logs, metrics, tenantWindow time.Duration
+	if cfg := s.cfg; cfg != nil {
+		days = cfg.HotRetentionDays
+		traces, _ = config.ParseRetentionWindow(cfg.RetentionTraces)
+		logs, _ = config.ParseRetentionWindow(cfg.RetentionLogs)
+		metrics, _ = config.ParseRetentionWindow(cfg.RetentionMetrics)
+		windows, _ := config.ParseTenantRetention(cfg.RetentionTenants)
+		tenantWindow = windows[tenant]
+	}
+	if tenantWindow > 0 {
+		w := tenantWindow.String()
+		return bootstrapRetention{Logs: w, Traces: w, Metrics: w, TenantOverride: true}
+	}
+	def := time.Duration(days) * 24 * time.Hour
+	or := func(d time.Duration) string {
+		if d == 0 {
+			d = def
+		}
+		return d.String()
+	}
+	return bootstrapRetention{Logs: or(logs), Traces: or(traces), Metrics: or(metrics)}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleBootstrap(t *testing.T) {
+	srv := &Server{cfg: &config.Config{
+		HotRetentionDays: 7,
+		RetentionLogs:    "3d",
+		RetentionTenants: "acme=30d",
+		AlertingEnabled:  true,
+		APIKeysEnabled:   true,
+	}}
+	get := func(ctx context.Context) bootstrapResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/bootstrap", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		srv.handleBootstrap(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+		}
+		var got bootstrapResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return got
+	}
+
+	// A read key pinned to acme, limited to checkout on the live streams.
+	ctx := context.WithValue(context.Background(), principalCtxKey{},
+		APIKeyPrincipal{Tenant: "acme", Scope: storage.APIKeyScopeRead, Services: []string{"checkout"}})
+	got := get(storage.WithTenantContext(ctx, "acme"))
+	if got.Tenant != "acme" || !got.TenantPinned || !slices.Equal(got.Tenants, []string{"acme"}) {
+		t.Errorf("tenant = %q pinned = %v tenants = %v", got.Tenant, got.TenantPinned, got.Tenants)
+	}
+	if got.Auth != authModeAPIKeys || got.Scope != "read" || got.Role != RoleViewer || !slices.Equal(got.Permissions, []string{"read"}) {
+		t.Errorf("auth = %q scope = %q permissions = %v", got.Auth, got.Scope, got.Permissions)
+	}
+	if !slices.Equal(got.LiveServices, []string{"checkout"}) {
+		t.Errorf("live_services = %v", got.LiveServices)
+	}
+	if !got.Features.Alerting || got.Features.APIKeys || got.Features.AI || got.Features.DLQAdmin {
+		t.Errorf("features = %+v", got.Features)
+	}
+	if want := (bootstrapRetention{Logs: "720h0m0s", Traces: "720h0m0s", Metrics: "720h0m0s", TenantOverride: true}); got.Retention != want {
+		t.Errorf("acme retention = %+v, want %+v", got.Retention, want)
+	}
+
+	// No managed key: the default tenant, full access, per-signal windows.
+	got = get(storage.WithTenantContext(context.Background(), storage.DefaultTenantID))
+	if got.TenantPinned || got.Scope != "admin" || !slices.Equal(got.Permissions, []string{"ingest", "read", "write", "admin"}) {
+		t.Errorf("unpinned = %+v", got)
+	}
+
+	// A signed-in editor.
+	got = get(context.WithValue(context.Background(), sessionUserCtxKey{}, SessionUser{Subject: "u1", Role: RoleEditor}))
+	if got.Role != RoleEditor || got.Scope != "write" || !slices.Equal(got.Permissions, []string{"read", "write"}) {
+		t.Errorf("session = role %q scope %q permissions %v", got.Role, got.Scope, got.Permissions)
+	}
+	if want := (bootstrapRetention{Logs: "72h0m0s", Traces: "168h0m0s", Metrics: "168h0m0s"}); got.Retention != want {
+		t.Errorf("default retention = %+v, want %+v", got.Retention, want)
+	}
+	if got.Services == nil || len(got.Services) != 0 {
+		t.Errorf("services = %#v, want empty list without GraphRAG", got.Services)
+	}
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/coldtier"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+// coldQuery reads the time range and limit every /api/cold endpoint takes.
+// ok is false once a 400 has been written.
+func coldQuery(w http.ResponseWriter, r *http.Request) (start, end time.Time, limit int, ok bool) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return start, end, 0, false
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return start, end, 0, false
+		}
+	}
+	return start, end, limit, true
+}
+
+func (s *Server) coldTierReady(w http.ResponseWriter) bool {
+	if s.coldTier == nil {
+		http.Error(w, "cold tier is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+func writeColdJSON(w http.ResponseWriter, v any, err error) {
+	if err != nil {
+		slog.Error("Cold tier query failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleGetColdSegments handles GET /api/cold/segments: the tenant's
+// Parquet segments overlapping start/end, newest first.
+func (s *Server) handleGetColdSegments(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	start, end, limit, ok := coldQuery(w, r)
+	if !ok {
+		return
+	}
+	segs, err := s.repo.ListColdSegments(r.Context(), storage.ColdSegmentQuery{
+		Signal: r.URL.Query().Get("signal"),
+		Start:  start,
+		End:    end,
+		Limit:  limit,
+	})
+	if segs == nil {
+		segs = []storage.ColdSegment{}
+	}
+	writeColdJSON(w, segs, err)
+}
+
+// handleGetColdLogs handles GET /api/cold/logs: a search of the tenant's
+// tiered logs. It reads at most coldtier.MaxScanSegments segments.
+func (s *Server) handleGetColdLogs(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	start, end, limit, ok := coldQuery(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	res, err := s.coldTier.Logs(r.Context(), coldtier.LogQuery{
+		Start:       start,
+		End:         end,
+		ServiceName: q.Get("service_name"),
+		Severity:    q.Get("severity"),
+		TraceID:     q.Get("trace_id"),
+		Search:      q.Get("search"),
+		Limit:       limit,
+	})
+	if res.Data == nil {
+		res.Data = []storage.Log{}
+	}
+	writeColdJSON(w, res, err)
+}
+
+// handleGetColdTraces handles GET /api/cold/traces: a search of the
+// tenant's tiered traces, without spans.
+func (s *Server) handleGetColdTraces(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	start, end, limit, ok := coldQuery(w, r)
+	if !ok {
+		return
+	}
+	res, err := s.coldTier.Traces(r.Context(), coldtier.TraceQuery{
+		Start:       start,
+		End:         end,
+		ServiceName: r.URL.Query().Get("service_name"),
+		Status:      r.URL.Query().Get("status"),
+		Limit:       limit,
+	})
+	if res.Data == nil {
+		res.Data = []storage.Trace{}
+	}
+	writeColdJSON(w, res, err)
+}
+
+// handleGetColdTrace handles GET /api/cold/traces/{id}: one tiered trace
+// with its spans. start/end narrow the segments searched.
+func (s *Server) handleGetColdTrace(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	start, end, _, ok := coldQuery(w, r)
+	if !ok {
+		return
+	}
+	tr, err := s.coldTier.Trace(r.Context(), r.PathValue("id"), start, end)
+	if err == nil && tr == nil {
+		http.Error(w, "trace not found in the cold tier", http.StatusNotFound)
+		return
+	}
+	writeColdJSON(w, tr, err)
+}
+
+// maxRehydrationBody bounds POST /api/cold/rehydrations bodies.
+const maxRehydrationBody = 4 << 10
+
+// rehydrationRequest is the POST /api/cold/rehydrations body. TTL is a
+// duration such as "48h".
+type rehydrationRequest struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	ServiceName string    `json:"service_name"`
+	TTL         string    `json:"ttl"`
+}
+
+// handleCreateRehydration handles POST /api/cold/rehydrations: it loads the
+// tenant's tiered logs in start/end back into the database, queryable
+// through /api/cold/rehydrations/{id}/logs until the job expires. The load
+// runs in the request and reads at most coldtier.MaxScanSegments segments.
+func (s *Server) handleCreateRehydration(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	var body rehydrationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRehydrationBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	q := coldtier.RehydrationRequest{Start: body.Start, End: body.End, ServiceName: body.ServiceName}
+	if body.TTL != "" {
+		ttl, err := time.ParseDuration(body.TTL)
+		if err != nil {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+		q.TTL = ttl
+	}
+	if err := q.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := s.coldTier.RehydrateLogs(r.Context(), q)
+	if err != nil {
+		slog.Error("Cold tier rehydration failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionRehydrationCreate, "rehydration/"+strconv.FormatUint(uint64(job.ID), 10),
+		fmt.Sprintf("%d logs from %d segments, %s to %s", job.RowCount, job.Segments, job.Start.Format(time.RFC3339), job.End.Format(time.RFC3339)))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+// handleListRehydrations handles GET /api/cold/rehydrations: the tenant's
+// unexpired rehydration jobs, newest first.
+func (s *Server) handleListRehydrations(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	jobs, err := s.repo.ListRehydrationJobs(r.Context())
+	if jobs == nil {
+		jobs = []storage.RehydrationJob{}
+	}
+	writeColdJSON(w, jobs, err)
+}
+
+// handleGetRehydration handles GET /api/cold/rehydrations/{id}.
+func (s *Server) handleGetRehydration(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.rehydrationJob(w, r)
+	if !ok {
+		return
+	}
+	writeColdJSON(w, job, nil)
+}
+
+// handleGetRehydratedLogs handles GET /api/cold/rehydrations/{id}/logs: a
+// search of one rehydration's logs, with the filters of /api/cold/logs.
+func (s *Server) handleGetRehydratedLogs(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.rehydrationJob(w, r)
+	if !ok {
+		return
+	}
+	start, end, limit, ok := coldQuery(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	logs, err := s.repo.RehydratedLogs(r.Context(), job, storage.RehydratedLogQuery{
+		Start:       start,
+		End:         end,
+		ServiceName: q.Get("service_name"),
+		Severity:    q.Get("severity"),
+		TraceID:     q.Get("trace_id"),
+		Search:      q.Get("search"),
+		Limit:       limit,
+	})
+	if logs == nil {
+		logs = []storage.Log{}
+	}
+	writeColdJSON(w, logs, err)
+}
+
+// handleDeleteRehydration handles DELETE /api/cold/rehydrations/{id},
+// dropping the job's rows before it expires.
+func (s *Server) handleDeleteRehydration(w http.ResponseWriter, r *http.Request) {
+	if !s.coldTierReady(w) {
+		return
+	}
+	id, ok := rehydrationID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.repo.DeleteRehydrationJob(r.Context(), id); err != nil {
+		writeRehydrationError(w, id, err)
+		return
+	}
+	s.audit(r, storage.AuditActionRehydrationDelete, "rehydration/"+r.PathValue("id"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rehydrationJob loads the {id} job of the tenant, writing the error
+// response when ok is false.
+func (s *Server) rehydrationJob(w http.ResponseWriter, r *http.Request) (*storage.RehydrationJob, bool) {
+	if !s.coldTierReady(w) {
+		return nil, false
+	}
+	id, ok := rehydrationID(w, r)
+	if !ok {
+		return nil, false
+	}
+	job, err := s.repo.GetRehydrationJob(r.Context(), id)
+	if err != nil {
+		writeRehydrationError(w, id, err)
+		return nil, false
+	}
+	return job, true
+}
+
+// rehydrationID parses the {id} path value, writing a 400 when it is invalid.
+func rehydrationID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeRehydrationError maps repository errors to 404 or 500.
+func writeRehydrationError(w http.ResponseWriter, id uint, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "rehydration not found", http.StatusNotFound)
+		return
+	}
+	slog.Error("Rehydration lookup failed", "id", id, "error", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
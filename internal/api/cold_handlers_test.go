@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/coldtier"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// TestColdTier_Endpoints verifies tiered logs and traces read back through
+// /api/cold, and that the endpoints answer 503 without a cold tier.
+func TestColdTier_Endpoints(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	old := time.Now().UTC().Add(-10 * 24 * time.Hour)
+	db := repo.DB()
+	db.Create(&storage.Log{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "ERROR", Body: "card declined", TraceID: "t1", Timestamp: old})
+	db.Create(&storage.Log{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "INFO", Body: "ok", Timestamp: old})
+	db.Create(&storage.Trace{TenantID: storage.DefaultTenantID, TraceID: "t1", ServiceName: "checkout", Timestamp: old})
+	span := storage.Span{TenantID: storage.DefaultTenantID, TraceID: "t1", SpanID: "s1", OperationName: "POST /pay", ServiceName: "checkout"}
+	span.SetTiming(old, old.Add(time.Millisecond))
+	db.Create(&span)
+
+	store, err := coldtier.OpenStore(coldtier.StoreConfig{URL: "file://" + t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tier := coldtier.New(repo, store, coldtier.Options{After: 7 * 24 * time.Hour})
+	if _, err := tier.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/cold/segments", srv.handleGetColdSegments)
+	mux.HandleFunc("GET /api/cold/logs", srv.handleGetColdLogs)
+	mux.HandleFunc("GET /api/cold/traces", srv.handleGetColdTraces)
+	mux.HandleFunc("GET /api/cold/traces/{id}", srv.handleGetColdTrace)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := get("/api/cold/logs"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a tier: want 503, got %d", rec.Code)
+	}
+	srv.SetColdTier(tier)
+
+	var logs coldtier.Result[storage.Log]
+	rec := get("/api/cold/logs?severity=error")
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &logs) != nil {
+		t.Fatalf("logs: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(logs.Data) != 1 || logs.Data[0].Body != "card declined" || logs.SegmentsScanned != 1 {
+		t.Errorf("logs = %+v", logs)
+	}
+
+	var segs []storage.ColdSegment
+	rec = get("/api/cold/segments")
+	if json.Unmarshal(rec.Body.Bytes(), &segs) != nil || len(segs) != 3 {
+		t.Errorf("segments: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var tr storage.Trace
+	rec = get("/api/cold/traces/t1")
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &tr) != nil {
+		t.Fatalf("trace: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(tr.Spans) != 1 || tr.Operation != "POST /pay" {
+		t.Errorf("trace = %+v", tr)
+	}
+	if rec := get("/api/cold/traces/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing trace: want 404, got %d", rec.Code)
+	}
+	if rec := get("/api/cold/traces?limit=-1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad limit: want 400, got %d", rec.Code)
+	}
+}
+
+// TestColdTier_Rehydration verifies tiered logs can be rehydrated, searched
+// and dropped through /api/cold/rehydrations, with both changes audited.
+func TestColdTier_Rehydration(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	old := time.Now().UTC().Truncate(time.Second).Add(-10 * 24 * time.Hour)
+	db := repo.DB()
+	db.Create(&storage.Log{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "ERROR", Body: "card declined", Timestamp: old})
+	db.Create(&storage.Log{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "INFO", Body: "retrying", Timestamp: old.Add(time.Minute)})
+	db.Create(&storage.Log{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "INFO", Body: "ok", Timestamp: old})
+	store, err := coldtier.OpenStore(coldtier.StoreConfig{URL: "file://" + t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tier := coldtier.New(repo, store, coldtier.Options{After: 7 * 24 * time.Hour})
+	if _, err := tier.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/cold/rehydrations", srv.handleListRehydrations)
+	mux.HandleFunc("POST /api/cold/rehydrations", srv.handleCreateRehydration)
+	mux.HandleFunc("GET /api/cold/rehydrations/{id}", srv.handleGetRehydration)
+	mux.HandleFunc("GET /api/cold/rehydrations/{id}/logs", srv.handleGetRehydratedLogs)
+	mux.HandleFunc("DELETE /api/cold/rehydrations/{id}", srv.handleDeleteRehydration)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	window := `"start":"` + old.Add(-time.Hour).Format(time.RFC3339) + `","end":"` + old.Add(time.Hour).Format(time.RFC3339) + `"`
+	if rec := do(http.MethodPost, "/api/cold/rehydrations", "{"+window+"}"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a tier: want 503, got %d", rec.Code)
+	}
+	srv.SetColdTier(tier)
+
+	for _, body := range []string{`{`, `{"start":"2026-01-02T00:00:00Z","end":"2026-01-01T00:00:00Z"}`, "{" + window + `,"ttl":"30d"}`} {
+		if rec := do(http.MethodPost, "/api/cold/rehydrations", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", body, rec.Code)
+		}
+	}
+	var job storage.RehydrationJob
+	rec := do(http.MethodPost, "/api/cold/rehydrations", "{"+window+`,"service_name":"checkout","ttl":"2h"}`)
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &job) != nil {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	if job.Status != storage.RehydrationStatusReady || job.RowCount != 2 || job.Segments != 1 {
+		t.Errorf("job = %+v", job)
+	}
+	path := "/api/cold/rehydrations/" + strconv.FormatUint(uint64(job.ID), 10)
+
+	var jobs []storage.RehydrationJob
+	rec = do(http.MethodGet, "/api/cold/rehydrations", "")
+	if json.Unmarshal(rec.Body.Bytes(), &jobs) != nil || len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("list: %d %s", rec.Code, rec.Body.String())
+	}
+	var logs []storage.Log
+	rec = do(http.MethodGet, path+"/logs?severity=error", "")
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &logs) != nil {
+		t.Fatalf("logs: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(logs) != 1 || logs[0].Body != "card declined" {
+		t.Errorf("logs = %+v", logs)
+	}
+	if rec := do(http.MethodGet, "/api/cold/rehydrations/abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad id: want 400, got %d", rec.Code)
+	}
+
+	if rec := do(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: want 204, got %d", rec.Code)
+	}
+	for _, p := range []string{path, path + "/logs"} {
+		if rec := do(http.MethodGet, p, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s after delete: want 404, got %d", p, rec.Code)
+		}
+	}
+	if rec := do(http.MethodDelete, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete: want 404, got %d", rec.Code)
+	}
+
+	events, err := repo.ListAuditEvents(storage.WithTenantContext(context.Background(), storage.DefaultTenantID), storage.AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Action != storage.AuditActionRehydrationDelete || events[1].Action != storage.AuditActionRehydrationCreate {
+		t.Errorf("audit events = %+v", events)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxRuntimeConfigBody caps a PATCH /api/config body.
+const maxRuntimeConfigBody = 16 << 10
+
+// runtimeSetting is one entry of the /api/config response.
+type runtimeSetting struct {
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Overridden bool   `json:"overridden"` // set through PATCH /api/config
+}
+
+// runtimeConfigResponse is the GET and PATCH /api/config body.
+type runtimeConfigResponse struct {
+	Settings []runtimeSetting `json:"settings"`
+}
+
+// handleGetRuntimeConfig handles GET /api/config — the current value of
+// every setting that can be changed at runtime.
+func (s *Server) handleGetRuntimeConfig(w http.ResponseWriter, _ *http.Request) {
+	if s.live == nil {
+		http.Error(w, "runtime configuration is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	s.writeRuntimeConfig(w, s.live.Current())
+}
+
+// handlePatchRuntimeConfig handles PATCH /api/config with an object of
+// environment variable names to new values, e.g. {"SAMPLING_RATE": 0.5,
+// "INGEST_EXCLUDED_SERVICES": ["load-generator"]}. null clears an override,
+// falling back to the config file or environment. Changes are validated
+// together, persisted and applied at once.
+func (s *Server) handlePatchRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	if s.live == nil {
+		http.Error(w, "runtime configuration is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRuntimeConfigBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(body) == 0 {
+		http.Error(w, "body must set at least one setting", http.StatusBadRequest)
+		return
+	}
+	changes := make(map[string]*string, len(body))
+	for key, raw := range body {
+		if _, ok := s.live.Current().RuntimeValue(key); !ok {
+			http.Error(w, fmt.Sprintf("%s cannot be changed at runtime", key), http.StatusBadRequest)
+			return
+		}
+		v, err := runtimeSettingValue(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", key, err), http.StatusBadRequest)
+			return
+		}
+		changes[key] = v
+	}
+
+	var save func(map[string]string) error
+	if s.repo != nil {
+		save = func(overrides map[string]string) error {
+			return s.repo.ReplaceRuntimeSettings(r.Context(), overrides)
+		}
+	}
+	next, err := s.live.Override(changes, save)
+	if err != nil {
+		if errors.Is(err, config.ErrInvalidOverride) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Error("Runtime config change failed", "error", err)
+		http.Error(w, "failed to apply runtime config", http.StatusInternalServerError)
+		return
+	}
+
+	summary := describeRuntimeChanges(changes)
+	slog.Info("Runtime config changed", "changes", summary)
+	if s.history != nil {
+		s.history.RecordConfigChange(r.Context(), "runtime config: "+summary)
+	}
+	s.audit(r, storage.AuditActionConfigUpdate, "config", summary)
+	s.writeRuntimeConfig(w, next)
+}
+
+// runtimeSettingValue converts a PATCH value to the environment variable
+// format: strings as-is, numbers and booleans as written, string arrays
+// comma-joined and null to nil.
+func runtimeSettingValue(raw json.RawMessage) (*string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case bytes.Equal(raw, []byte("null")):
+		return nil, nil
+	case len(raw) > 0 && raw[0] == '"':
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	case len(raw) > 0 && raw[0] == '[':
+		var list []string
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("lists must hold strings")
+		}
+		v := strings.Join(list, ",")
+		return &v, nil
+	case len(raw) > 0 && raw[0] == '{':
+		return nil, fmt.Errorf("objects are not supported; use the key=value,... string form")
+	}
+	v := string(raw)
+	return &v, nil
+}
+
+func describeRuntimeChanges(changes map[string]*string) string {
+	parts := make([]string, 0, len(changes))
+	for key, v := range changes {
+		if v == nil {
+			parts = append(parts, key+" reset")
+		} else {
+			parts = append(parts, key+"="+*v)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func (s *Server) writeRuntimeConfig(w http.ResponseWriter, cfg *config.Config) {
+	overrides := cfg.RuntimeOverrides()
+	resp := runtimeConfigResponse{Settings: make([]runtimeSetting, 0, len(config.RuntimeKeys()))}
+	for _, key := range config.RuntimeKeys() {
+		v, _ := cfg.RuntimeValue(key)
+		_, overridden := overrides[key]
+		resp.Settings = append(resp.Settings, runtimeSetting{Key: key, Value: v, Overridden: overridden})
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+)
+
+func TestRuntimeConfigHandlers(t *testing.T) {
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var applied *config.Config
+	repo := newAPITestRepoWithoutFTS(t)
+	srv := &Server{repo: repo, live: config.NewLive(cfg, func(_, next *config.Config) error { applied = next; return nil })}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/config", srv.handleGetRuntimeConfig)
+	mux.HandleFunc("PATCH /api/config", srv.handlePatchRuntimeConfig)
+	do := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/api/config", strings.NewReader(body)))
+		return rec
+	}
+	settings := func(rec *httptest.ResponseRecorder) map[string]runtimeSetting {
+		t.Helper()
+		var resp runtimeConfigResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("status %d body %q: %v", rec.Code, rec.Body.String(), err)
+		}
+		out := make(map[string]runtimeSetting, len(resp.Settings))
+		for _, s := range resp.Settings {
+			out[s.Key] = s
+		}
+		return out
+	}
+
+	got := settings(do(http.MethodGet, ""))
+	if s := got["INGEST_MIN_SEVERITY"]; s.Value != cfg.IngestMinSeverity || s.Overridden {
+		t.Fatalf("GET INGEST_MIN_SEVERITY = %+v", s)
+	}
+
+	rec := do(http.MethodPatch, `{"INGEST_MIN_SEVERITY": "warn", "SAMPLING_RATE": 0.5, "INGEST_EXCLUDED_SERVICES": ["noisy", "chatty"]}`)
+	got = settings(rec)
+	if rec.Code != http.StatusOK || got["SAMPLING_RATE"].Value != "0.5" || !got["SAMPLING_RATE"].Overridden || got["INGEST_EXCLUDED_SERVICES"].Value != "noisy,chatty" {
+		t.Fatalf("PATCH status = %d settings = %+v", rec.Code, got)
+	}
+	if applied == nil || applied.IngestMinSeverity != "WARN" || applied.SamplingRate != 0.5 {
+		t.Errorf("applied = %+v", applied)
+	}
+	persisted, err := repo.RuntimeSettings(context.Background())
+	if err != nil || len(persisted) != 3 || persisted["INGEST_EXCLUDED_SERVICES"] != "noisy,chatty" {
+		t.Errorf("persisted = %v, %v", persisted, err)
+	}
+
+	// null clears an override.
+	got = settings(do(http.MethodPatch, `{"SAMPLING_RATE": null}`))
+	if s := got["SAMPLING_RATE"]; s.Overridden || s.Value != "1" {
+		t.Errorf("cleared SAMPLING_RATE = %+v", s)
+	}
+	if persisted, _ := repo.RuntimeSettings(context.Background()); len(persisted) != 2 {
+		t.Errorf("persisted after clear = %v", persisted)
+	}
+
+	for _, body := range []string{`{}`, `not json`, `{"DB_DRIVER": "mysql"}`, `{"SAMPLING_RATE": 3}`, `{"HUB_FLUSH_INTERVAL": "1ms"}`, `{"SAMPLING_SERVICE_RATES": {"a": 1}}`} {
+		if rec := do(http.MethodPatch, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s status = %d, want 400", body, rec.Code)
+		}
+	}
+	if applied.SamplingRate != 1 {
+		t.Errorf("rejected PATCHes were applied: rate %v", applied.SamplingRate)
+	}
+
+	srv.live = nil
+	if rec := do(http.MethodGet, ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no live config status = %d, want 503", rec.Code)
+	}
+}
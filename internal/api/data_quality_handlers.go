@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// dataQualityCacheTTL bounds how stale the data quality report may be. A
+// miss samples spans and self-joins them for clock skew.
+const dataQualityCacheTTL = 30 * time.Second
+
+// dataQualityReport is the GET /api/data-quality payload.
+type dataQualityReport struct {
+	Start    time.Time            `json:"start"`
+	End      time.Time            `json:"end"`
+	Services []serviceDataQuality `json:"services"`
+}
+
+// serviceDataQuality is a service's stored-telemetry hygiene plus the
+// records it lost at ingest. Dropped counts are per instance since startup,
+// not windowed by start/end.
+type serviceDataQuality struct {
+	storage.ServiceDataQuality
+	DroppedRecords int64          `json:"dropped_records"`
+	Dropped        []droppedCount `json:"dropped"`
+}
+
+type droppedCount struct {
+	Signal string `json:"signal"`
+	Reason string `json:"reason"`
+	Count  int64  `json:"count"`
+}
+
+// handleGetDataQuality handles GET /api/data-quality — per-service telemetry
+// hygiene: share of spans without a status, share of logs without trace
+// context, high-cardinality span attributes, clock skew against calling
+// services, and records dropped at ingest.
+// Query params: start, end (RFC3339; default last 1h), service_name
+// (repeatable), region.
+//
+// Responses are cached per tenant and query string for 30s.
+func (s *Server) handleGetDataQuality(w http.ResponseWriter, r *http.Request) {
+	ctx := regionFilter(r)
+	tenant := storage.TenantFromContext(ctx)
+	cacheKey := "data_quality:" + tenant + ":" + r.URL.RawQuery
+
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+		w.Header().Set("X-Cache", "HIT")
+		_ = json.NewEncoder(w).Encode(cached)
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() || !start.Before(end) {
+		start = end.Add(-time.Hour)
+	}
+	serviceNames := r.URL.Query()["service_name"]
+
+	stored, err := s.repo.GetDataQuality(ctx, storage.DataQualityQuery{Start: start, End: end, ServiceNames: serviceNames})
+	if err != nil {
+		slog.Error("Failed to get data quality report", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	byService := make(map[string]*serviceDataQuality, len(stored))
+	services := make([]*serviceDataQuality, 0, len(stored))
+	for _, q := range stored {
+		sq := &serviceDataQuality{ServiceDataQuality: q, Dropped: []droppedCount{}}
+		byService[q.ServiceName] = sq
+		services = append(services, sq)
+	}
+	// Drops are attributed to the service even when none of its records
+	// made it to storage; those services appear with drops only.
+	for _, d := range s.metrics.DroppedRecords(tenant) {
+		if len(serviceNames) > 0 && !slices.Contains(serviceNames, d.ServiceName) {
+			continue
+		}
+		sq, ok := byService[d.ServiceName]
+		if !ok {
+			sq = &serviceDataQuality{
+				ServiceDataQuality: storage.ServiceDataQuality{ServiceName: d.ServiceName, AttributeOffenders: []storage.AttributeCardinality{}},
+				Dropped:            []droppedCount{},
+			}
+			byService[d.ServiceName] = sq
+			services = append(services, sq)
+		}
+		sq.DroppedRecords += d.Count
+		sq.Dropped = append(sq.Dropped, droppedCount{Signal: d.Signal, Reason: d.Reason, Count: d.Count})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].ServiceName < services[j].ServiceName })
+
+	resp := dataQualityReport{Start: start, End: end, Services: make([]serviceDataQuality, len(services))}
+	for i, sq := range services {
+		resp.Services[i] = *sq
+	}
+
+	s.cache.Set(cacheKey, resp, dataQualityCacheTTL)
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.Header().Set("X-Cache", "MISS")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+func TestHandleGetDataQuality(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, Severity: "INFO", ServiceName: "api", Body: "a", TraceID: "t1", Timestamp: now.Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, Severity: "INFO", ServiceName: "api", Body: "b", Timestamp: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+
+	// A zero Metrics avoids registering against the global Prometheus registry.
+	m := &telemetry.Metrics{}
+	m.RecordDroppedRecords(storage.DefaultTenantID, "api", "logs", "severity_filtered", 3)
+	m.RecordDroppedRecords(storage.DefaultTenantID, "noisy", "traces", "health_check", 7)
+	m.RecordDroppedRecords("other", "api", "logs", "severity_filtered", 9)
+
+	c := cache.New()
+	t.Cleanup(c.Stop)
+	srv := &Server{repo: repo, cache: c, metrics: m}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/data-quality", srv.handleGetDataQuality)
+	get := func(query string) (*httptest.ResponseRecorder, dataQualityReport) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/data-quality"+query, nil))
+		var got dataQualityReport
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec, got
+	}
+
+	rec, got := get("")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("status = %d cache=%q body=%q", rec.Code, rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+	if len(got.Services) != 2 {
+		t.Fatalf("services = %+v", got.Services)
+	}
+	api, noisy := got.Services[0], got.Services[1]
+	if api.ServiceName != "api" || api.Logs != 2 || api.LogsWithoutTracePct != 50 || api.DroppedRecords != 3 || len(api.Dropped) != 1 {
+		t.Errorf("api = %+v", api)
+	}
+	if noisy.ServiceName != "noisy" || noisy.Logs != 0 || noisy.DroppedRecords != 7 || noisy.Dropped[0].Reason != "health_check" {
+		t.Errorf("drops-only service = %+v", noisy)
+	}
+	if got.End.Sub(got.Start) != time.Hour {
+		t.Errorf("window = %v..%v, want the last hour", got.Start, got.End)
+	}
+	if rec, _ := get(""); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("second request X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	if _, got := get("?service_name=noisy"); len(got.Services) != 1 || got.Services[0].ServiceName != "noisy" {
+		t.Errorf("service filter = %+v", got.Services)
+	}
+}
@@ -35,6 +35,7 @@ type DBHealth struct {
 	consecutiveFails atomic.Int32
 	failureThreshold int32
 	metrics          *telemetry.Metrics
+	onChange         func(up bool)
 	stopCh           chan struct{}
 	doneCh           chan struct{}
 }
@@ -77,6 +78,12 @@ func (h *DBHealth) SetFailureThreshold(n int) {
 	atomic.StoreInt32(&h.failureThreshold, int32(n)) // #nosec G115 -- n bounded above; in test wiring
 }
 
+// SetOnChange registers fn to be called whenever the gate flips between
+// healthy and unhealthy. Must be called before Start.
+func (h *DBHealth) SetOnChange(fn func(up bool)) {
+	h.onChange = fn
+}
+
 // Start launches the background poller.
 func (h *DBHealth) Start(ctx context.Context) {
 	go h.loop(ctx)
@@ -143,7 +150,9 @@ func (h *DBHealth) ping(parent context.Context) {
 }
 
 func (h *DBHealth) markHealthy(up bool) {
-	h.healthy.Store(up)
+	if was := h.healthy.Swap(up); was != up && h.onChange != nil {
+		h.onChange(up)
+	}
 	if h.metrics == nil || h.metrics.DBUp == nil {
 		return
 	}
@@ -121,6 +121,27 @@ func TestDBHealth_FlipsAtThreshold(t *testing.T) {
 	}
 }
 
+// TestDBHealth_OnChangeFiresOnFlips asserts the change hook sees each flip
+// once, not every ping.
+func TestDBHealth_OnChangeFiresOnFlips(t *testing.T) {
+	p := &stubPinger{}
+	h := NewDBHealth(p, "sqlite", nil)
+	h.SetFailureThreshold(1)
+	var flips []bool
+	h.SetOnChange(func(up bool) { flips = append(flips, up) })
+	ctx := context.Background()
+
+	h.ping(ctx) // healthy already
+	p.fail.Store(true)
+	h.ping(ctx)
+	h.ping(ctx)
+	p.fail.Store(false)
+	h.ping(ctx)
+	if len(flips) != 2 || flips[0] || !flips[1] {
+		t.Errorf("flips = %v, want [false true]", flips)
+	}
+}
+
 // TestDBHealth_SuccessResetsCounter asserts that the streak counter resets
 // on the first success: two failures, one success, two more failures must
 // NOT trip the gate, because there were never 3-in-a-row.
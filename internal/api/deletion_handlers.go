@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// handleGetDeletions handles GET /api/deletions: what retention, admin
+// purges and partition drops deleted for the tenant, newest first.
+func (s *Server) handleGetDeletions(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := storage.DeletionQuery{
+		Start:       start,
+		End:         end,
+		Signal:      r.URL.Query().Get("signal"),
+		ServiceName: r.URL.Query().Get("service_name"),
+		Reason:      r.URL.Query().Get("reason"),
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+
+	records, err := s.repo.ListDeletions(r.Context(), q)
+	if err != nil {
+		slog.Error("Failed to list deletions", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []storage.DeletionRecord{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(records)
+}
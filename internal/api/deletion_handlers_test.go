@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// TestDeletions_AdminPurgeIsRecorded verifies an admin purge leaves
+// per-service deletion records that only the owning tenant can list.
+func TestDeletions_AdminPurgeIsRecorded(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, l := range []storage.Log{
+		{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "INFO", Body: "a", Timestamp: old},
+		{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "INFO", Body: "b", Timestamp: old},
+		{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "INFO", Body: "c", Timestamp: old},
+		{TenantID: "other", ServiceName: "checkout", Severity: "INFO", Body: "d", Timestamp: old},
+	} {
+		if err := repo.DB().Create(&l).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/admin/purge", srv.handlePurge)
+	mux.HandleFunc("GET /api/deletions", srv.handleGetDeletions)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/admin/purge?days=7", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("purge: want 200, got %d body=%q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/deletions?signal=logs&service_name=checkout", nil))
+	var got []storage.DeletionRecord
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil {
+		t.Fatalf("list: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(got) != 1 || got[0].TenantID != storage.DefaultTenantID || got[0].RowCount != 2 || got[0].Reason != storage.DeletionReasonAdminPurge {
+		t.Errorf("deletions = %+v, want the default tenant's 2 checkout logs", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/deletions?limit=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad limit: want 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxDeploymentBody bounds POST /api/deployments bodies.
+	maxDeploymentBody = 16 << 10
+	// defaultDeployImpactWindow is each side of a deployment's impact when
+	// neither ?window= nor SetDeploymentImpactWindow sets one.
+	defaultDeployImpactWindow = 30 * time.Minute
+	maxDeployImpactWindow     = 24 * time.Hour
+)
+
+// deploymentRequest is the body of POST /api/deployments.
+type deploymentRequest struct {
+	ServiceName string    `json:"service_name"`
+	Version     string    `json:"version"`
+	Timestamp   time.Time `json:"timestamp"` // zero = now
+	Environment string    `json:"environment"`
+	Status      string    `json:"status"` // default success
+	ExternalID  string    `json:"external_id"`
+	Ref         string    `json:"ref"`
+	CommitSHA   string    `json:"commit_sha"`
+	URL         string    `json:"url"`
+	Actor       string    `json:"actor"`
+	Description string    `json:"description"`
+}
+
+// validate checks the request against the Deployment column sizes.
+func (req *deploymentRequest) validate() error {
+	switch {
+	case req.ServiceName == "" || req.Version == "":
+		return errors.New("service_name and version are required")
+	case len(req.ServiceName) > 255, len(req.Version) > 255, len(req.Ref) > 255, len(req.Actor) > 255:
+		return errors.New("service_name, version, ref and actor must be at most 255 bytes")
+	case len(req.Environment) > 64, len(req.CommitSHA) > 64:
+		return errors.New("environment and commit_sha must be at most 64 bytes")
+	case len(req.ExternalID) > 128:
+		return errors.New("external_id must be at most 128 bytes")
+	case len(req.URL) > 512:
+		return errors.New("url must be at most 512 bytes")
+	}
+	switch req.Status {
+	case "", storage.DeploymentPending, storage.DeploymentInProgress, storage.DeploymentSuccess, storage.DeploymentFailure:
+		return nil
+	}
+	return errors.New("status must be pending, in_progress, success or failure")
+}
+
+// handleGetDeployments handles GET /api/deployments?service_name=&start=&end=&limit=N
+func (s *Server) handleGetDeployments(w http.ResponseWriter, r *http.Request) {
+	start, end, _ := parseTimeRange(r)
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deployments, err := s.repo.ListDeployments(r.Context(), r.URL.Query().Get("service_name"), start, end, limit)
+	if err != nil {
+		slog.Error("Failed to list deployments", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if deployments == nil {
+		deployments = []storage.Deployment{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(deployments)
+}
+
+// handleGetServiceVersions handles GET /api/deployments/versions
+func (s *Server) handleGetServiceVersions(w http.ResponseWriter, r *http.Request) {
+	versions, err := s.repo.ListServiceVersions(r.Context())
+	if err != nil {
+		slog.Error("Failed to list service versions", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if versions == nil {
+		versions = []storage.Deployment{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(versions)
+}
+
+// handleCreateDeployment handles POST /api/deployments: registers a
+// deployment from a deploy script or CI system without a webhook. Posting
+// the same service, version and environment again (or the same
+// external_id) amends the existing row.
+func (s *Server) handleCreateDeployment(w http.ResponseWriter, r *http.Request) {
+	var req deploymentRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDeploymentBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	d := &storage.Deployment{
+		ExternalID:  req.ExternalID,
+		Source:      storage.DeploymentSourceAPI,
+		ServiceName: req.ServiceName,
+		Version:     req.Version,
+		Environment: req.Environment,
+		Status:      req.Status,
+		Ref:         req.Ref,
+		CommitSHA:   req.CommitSHA,
+		URL:         req.URL,
+		Actor:       req.Actor,
+		Description: req.Description,
+		Timestamp:   req.Timestamp.UTC(),
+	}
+	if d.ExternalID == "" {
+		d.ExternalID = storage.DeploymentExternalID(storage.DeploymentSourceAPI, d.ServiceName, d.Version, d.Environment)
+	}
+	if d.Status == "" {
+		d.Status = storage.DeploymentSuccess
+	}
+	if req.Timestamp.IsZero() {
+		d.Timestamp = time.Now().UTC()
+	}
+	if err := s.repo.UpsertDeployment(r.Context(), d); err != nil {
+		slog.Error("Failed to record deployment", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("🚀 Deployment recorded", "service", d.ServiceName, "version", d.Version, "status", d.Status, "source", d.Source)
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(d)
+}
+
+// handleGetDeploymentImpact handles GET /api/deployments/{id}/impact: the
+// service's latency and error rate before the deployment against after it.
+// ?window= (a duration up to 24h) overrides the configured window.
+func (s *Server) handleGetDeploymentImpact(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid deployment id", http.StatusBadRequest)
+		return
+	}
+	window := s.deployImpactWindow
+	if window <= 0 {
+		window = defaultDeployImpactWindow
+	}
+	if v := r.URL.Query().Get("window"); v != "" {
+		window, err = time.ParseDuration(v)
+		if err != nil || window < time.Minute || window > maxDeployImpactWindow {
+			http.Error(w, "window must be a duration between 1m and 24h", http.StatusBadRequest)
+			return
+		}
+	}
+
+	d, err := s.repo.GetDeployment(r.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "deployment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to load deployment", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	impact, err := s.repo.GetDeploymentImpact(r.Context(), d, window, time.Now())
+	if err != nil {
+		slog.Error("Failed to compute deployment impact", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(struct {
+		Deployment *storage.Deployment       `json:"deployment"`
+		Impact     *storage.DeploymentImpact `json:"impact"`
+	}{d, impact})
+}
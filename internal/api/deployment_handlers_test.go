@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestDeploymentHandlers(t *testing.T) {
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/deployments", srv.handleGetDeployments)
+	mux.HandleFunc("POST /api/deployments", srv.handleCreateDeployment)
+	mux.HandleFunc("GET /api/deployments/{id}/impact", srv.handleGetDeploymentImpact)
+	mux.HandleFunc("GET /api/metrics/traffic", srv.handleGetTrafficMetrics)
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	at := time.Now().UTC().Add(-10 * time.Minute).Truncate(time.Second)
+	body := fmt.Sprintf(`{"service_name":"checkout","version":"v2.1.0","environment":"prod","timestamp":%q}`, at.Format(time.RFC3339))
+	rec := do(http.MethodPost, "/api/deployments", body)
+	var d storage.Deployment
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &d) != nil {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body.String())
+	}
+	if d.Source != storage.DeploymentSourceAPI || d.Status != storage.DeploymentSuccess || !d.Timestamp.Equal(at) {
+		t.Errorf("created = %+v", d)
+	}
+	// Registering the same version again amends the row.
+	if rec := do(http.MethodPost, "/api/deployments", strings.Replace(body, `"prod"`, `"prod","actor":"ci"`, 1)); rec.Code != http.StatusCreated {
+		t.Fatalf("amend: %d %s", rec.Code, rec.Body.String())
+	}
+	var list []storage.Deployment
+	if rec := do(http.MethodGet, "/api/deployments", ""); json.Unmarshal(rec.Body.Bytes(), &list) != nil || len(list) != 1 || list[0].Actor != "ci" {
+		t.Errorf("list = %s", rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, fmt.Sprintf("/api/deployments/%d/impact?window=1h", d.ID), "")
+	var impact struct {
+		Deployment storage.Deployment       `json:"deployment"`
+		Impact     storage.DeploymentImpact `json:"impact"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &impact) != nil {
+		t.Fatalf("impact: %d %s", rec.Code, rec.Body.String())
+	}
+	if impact.Deployment.ID != d.ID || impact.Impact.Complete || impact.Impact.Verdict != storage.DeploymentVerdictInsufficient {
+		t.Errorf("impact = %+v", impact)
+	}
+
+	rec = do(http.MethodGet, "/api/metrics/traffic?deployments=true&start="+at.Add(-time.Minute).Format(time.RFC3339), "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"version":"v2.1.0"`) {
+		t.Errorf("traffic markers: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, c := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/api/deployments", `{"service_name":"checkout"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/deployments", `{"service_name":"checkout","version":"v1","status":"done"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/deployments", `not json`, http.StatusBadRequest},
+		{http.MethodGet, "/api/deployments/999/impact", "", http.StatusNotFound},
+		{http.MethodGet, "/api/deployments/abc/impact", "", http.StatusBadRequest},
+		{http.MethodGet, fmt.Sprintf("/api/deployments/%d/impact?window=2d", d.ID), "", http.StatusBadRequest},
+		{http.MethodGet, fmt.Sprintf("/api/deployments/%d/impact?window=30s", d.ID), "", http.StatusBadRequest},
+	} {
+		if rec := do(c.method, c.target, c.body); rec.Code != c.want {
+			t.Errorf("%s %s %s: want %d, got %d", c.method, c.target, c.body, c.want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/queue"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// dlqFile is one queued batch in GET /api/admin/dlq.
+type dlqFile struct {
+	Name       string  `json:"name"`
+	Bytes      int64   `json:"bytes"`
+	AgeSeconds float64 `json:"age_seconds"`
+	Retries    int     `json:"retries"`
+}
+
+// dlqResponse is the GET /api/admin/dlq body.
+type dlqResponse struct {
+	Files            int       `json:"files"`
+	Bytes            int64     `json:"bytes"`
+	OldestAgeSeconds float64   `json:"oldest_age_seconds"`
+	FailureStreak    int64     `json:"failure_streak"`
+	Batches          []dlqFile `json:"batches"`
+}
+
+// dlqEnabled writes a 503 and reports false when no DLQ is wired.
+func (s *Server) dlqEnabled(w http.ResponseWriter) bool {
+	if s.dlq == nil {
+		http.Error(w, "dead letter queue is not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// handleGetDLQ handles GET /api/admin/dlq — the queued batches, oldest
+// first, with the same totals the DLQ gauges export. The DLQ is shared by
+// all tenants.
+func (s *Server) handleGetDLQ(w http.ResponseWriter, _ *http.Request) {
+	if !s.dlqEnabled(w) {
+		return
+	}
+	files, err := s.dlq.Files()
+	if err != nil {
+		slog.Error("Failed to list DLQ", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	st := s.dlq.Stats()
+	resp := dlqResponse{
+		Files:            len(files),
+		OldestAgeSeconds: st.OldestAge.Seconds(),
+		FailureStreak:    st.FailureStreak,
+		Batches:          make([]dlqFile, 0, len(files)),
+	}
+	for _, f := range files {
+		resp.Bytes += f.Bytes
+		resp.Batches = append(resp.Batches, dlqFile{Name: f.Name, Bytes: f.Bytes, AgeSeconds: f.Age.Seconds(), Retries: f.Retries})
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleReplayDLQ handles POST /api/admin/dlq/replay — replay now, ignoring
+// backoff, and report the outcome.
+func (s *Server) handleReplayDLQ(w http.ResponseWriter, r *http.Request) {
+	if !s.dlqEnabled(w) {
+		return
+	}
+	res := s.dlq.ReplayNow()
+	s.audit(r, storage.AuditActionDLQReplay, "dlq", fmt.Sprintf("replayed %d, failed %d, quarantined %d", res.Replayed, res.Failed, res.Quarantined))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]int{
+		"replayed":    res.Replayed,
+		"coalesced":   res.Coalesced,
+		"failed":      res.Failed,
+		"quarantined": res.Quarantined,
+		"remaining":   s.dlq.Size(),
+	})
+}
+
+// dlqPass is the last replay pass in GET /api/admin/dlq/progress.
+type dlqPass struct {
+	FinishedAt  *time.Time `json:"finished_at"`
+	DurationMs  int64      `json:"duration_ms"`
+	Replayed    int        `json:"replayed"`
+	Coalesced   int        `json:"coalesced"`
+	Failed      int        `json:"failed"`
+	Quarantined int        `json:"quarantined"`
+}
+
+// dlqProgressResponse is the GET /api/admin/dlq/progress body.
+type dlqProgressResponse struct {
+	Replaying      bool       `json:"replaying"`
+	DrainStartedAt *time.Time `json:"drain_started_at"`
+	Replayed       int64      `json:"replayed"`
+	RemainingFiles int        `json:"remaining_files"`
+	RemainingBytes int64      `json:"remaining_bytes"`
+	FilesPerSecond float64    `json:"files_per_second"`
+	ETASeconds     float64    `json:"eta_seconds"`
+	LastPass       dlqPass    `json:"last_pass"`
+}
+
+// handleGetDLQProgress handles GET /api/admin/dlq/progress — how far the
+// replay worker is through the backlog, and when it will be done at the
+// pace so far.
+func (s *Server) handleGetDLQProgress(w http.ResponseWriter, _ *http.Request) {
+	if !s.dlqEnabled(w) {
+		return
+	}
+	p := s.dlq.Progress()
+	resp := dlqProgressResponse{
+		Replaying:      p.Replaying,
+		Replayed:       p.Replayed,
+		RemainingFiles: p.RemainingFiles,
+		RemainingBytes: p.RemainingBytes,
+		FilesPerSecond: p.FilesPerSecond,
+		ETASeconds:     p.ETA.Seconds(),
+		LastPass: dlqPass{
+			DurationMs:  p.LastPassDuration.Milliseconds(),
+			Replayed:    p.LastPass.Replayed,
+			Coalesced:   p.LastPass.Coalesced,
+			Failed:      p.LastPass.Failed,
+			Quarantined: p.LastPass.Quarantined,
+		},
+	}
+	if !p.DrainStart.IsZero() {
+		resp.DrainStartedAt = &p.DrainStart
+	}
+	if !p.LastPassAt.IsZero() {
+		resp.LastPass.FinishedAt = &p.LastPassAt
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleDeleteDLQFile handles DELETE /api/admin/dlq/{name} — drop a poison
+// batch the database will never accept.
+func (s *Server) handleDeleteDLQFile(w http.ResponseWriter, r *http.Request) {
+	if !s.dlqEnabled(w) {
+		return
+	}
+	name := r.PathValue("name")
+	err := s.dlq.Remove(name)
+	if errors.Is(err, queue.ErrDLQFileNotFound) {
+		http.Error(w, "batch not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to delete DLQ batch", "file", name, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionDLQDelete, "dlq/"+name, "")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/queue"
+)
+
+func TestDLQAdminHandlers(t *testing.T) {
+	var replayed int
+	q, err := queue.NewDLQ(t.TempDir(), time.Hour, func([]byte) error { replayed++; return nil })
+	if err != nil {
+		t.Fatalf("NewDLQ: %v", err)
+	}
+	defer q.Stop()
+	for range 2 {
+		if err := q.Enqueue(map[string]string{"type": "logs"}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	srv := &Server{dlq: q}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/admin/dlq", srv.handleGetDLQ)
+	mux.HandleFunc("GET /api/admin/dlq/progress", srv.handleGetDLQProgress)
+	mux.HandleFunc("POST /api/admin/dlq/replay", srv.handleReplayDLQ)
+	mux.HandleFunc("DELETE /api/admin/dlq/{name}", srv.handleDeleteDLQFile)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/admin/dlq")
+	var list dlqResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if list.Files != 2 || len(list.Batches) != 2 || list.Bytes == 0 {
+		t.Fatalf("list = %+v", list)
+	}
+
+	if rec := do(http.MethodDelete, "/api/admin/dlq/"+list.Batches[0].Name); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/admin/dlq/"+list.Batches[0].Name); rec.Code != http.StatusNotFound {
+		t.Errorf("second DELETE status = %d, want 404", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/api/admin/dlq/..%2Fconfig.json"); rec.Code != http.StatusNotFound {
+		t.Errorf("traversal DELETE status = %d, want 404", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/api/admin/dlq/replay")
+	var res map[string]int
+	_ = json.Unmarshal(rec.Body.Bytes(), &res)
+	if rec.Code != http.StatusOK || res["replayed"] != 1 || res["remaining"] != 0 || replayed != 1 {
+		t.Errorf("replay status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodGet, "/api/admin/dlq/progress")
+	var progress dlqProgressResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &progress); err != nil || rec.Code != http.StatusOK ||
+		progress.RemainingFiles != 0 || progress.DrainStartedAt != nil || progress.LastPass.Replayed != 1 || progress.LastPass.FinishedAt == nil {
+		t.Errorf("progress status = %d body = %s", rec.Code, rec.Body.String())
+	}
+
+	srv.dlq = nil
+	if rec := do(http.MethodGet, "/api/admin/dlq"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no DLQ status = %d, want 503", rec.Code)
+	}
+}
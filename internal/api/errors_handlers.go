@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// errorsExplorerCacheTTL bounds how stale the errors overview may be. The
+// grouping pass scans up to errorScanCap rows, so a dashboard polling every
+// few seconds is served from cache instead of re-running it.
+const errorsExplorerCacheTTL = 30 * time.Second
+
+// handleGetErrors handles GET /api/errors — errors grouped by (service,
+// error type, normalized message) with counts, trend, sample trace IDs and
+// first/last seen, fingerprint and triage status. Query params: start, end
+// (RFC3339; default last 24h), service_name (repeatable), status (open |
+// resolved | ignored), limit, buckets, samples.
+//
+// Responses are cached per tenant and query string for 30s; bulk triage
+// drops the tenant's entries.
+func (s *Server) handleGetErrors(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cacheKey := "errors:" + storage.TenantFromContext(ctx) + ":" + r.URL.RawQuery
+
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+		w.Header().Set("X-Cache", "HIT")
+		_ = json.NewEncoder(w).Encode(cached)
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := storage.ErrorGroupQuery{
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+		Status:       r.URL.Query().Get("status"),
+	}
+	switch q.Status {
+	case "", storage.TriageStatusOpen, storage.TriageStatusResolved, storage.TriageStatusIgnored:
+	default:
+		http.Error(w, "status must be open, resolved or ignored", http.StatusBadRequest)
+		return
+	}
+	for param, dst := range map[string]*int{"limit": &q.Limit, "buckets": &q.Buckets, "samples": &q.Samples} {
+		if v := r.URL.Query().Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid "+param, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+	// Clamp the knobs that size the response.
+	q.Limit = min(q.Limit, 500)
+	q.Buckets = min(q.Buckets, 500)
+	q.Samples = min(q.Samples, 50)
+
+	resp, err := s.repo.GetErrorGroups(ctx, q)
+	if err != nil {
+		slog.Error("Failed to get error groups", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.cache.Set(cacheKey, resp, errorsExplorerCacheTTL)
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.Header().Set("X-Cache", "MISS")
+	_ = json.NewEncoder(w).Encode(resp)
+}
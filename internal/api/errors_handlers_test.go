@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleGetErrors_GroupsAndCaches(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, TraceID: "a", Severity: "ERROR", ServiceName: "api", Body: "conn reset by 10.0.0.1", Timestamp: now.Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, TraceID: "b", Severity: "ERROR", ServiceName: "api", Body: "conn reset by 10.0.0.2", Timestamp: now.Add(-2 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+
+	c := cache.New()
+	t.Cleanup(c.Stop)
+	srv := &Server{repo: repo, cache: c}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/errors", srv.handleGetErrors)
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/errors?buckets=6", nil))
+		return rec
+	}
+
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("first call X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+	var body storage.ErrorGroupsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Groups) != 1 || body.Groups[0].Count != 2 || len(body.Groups[0].Trend) != 6 {
+		t.Fatalf("groups = %+v, want one group of 2 with 6 trend buckets", body.Groups)
+	}
+
+	if got := get().Header().Get("X-Cache"); got != "HIT" {
+		t.Errorf("second call X-Cache = %q, want HIT", got)
+	}
+}
+
+func TestHandleGetErrors_RejectsBadParam(t *testing.T) {
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t), cache: cache.New()}
+	t.Cleanup(srv.cache.Stop)
+	rec := httptest.NewRecorder()
+	srv.handleGetErrors(rec, httptest.NewRequest(http.MethodGet, "/api/errors?limit=abc", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
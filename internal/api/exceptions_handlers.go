@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// handleGetExceptionGroups handles GET /api/exceptions
+func (s *Server) handleGetExceptionGroups(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := storage.ExceptionGroupQuery{
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+		Type:         r.URL.Query().Get("type"),
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(n, 500)
+	}
+
+	groups, err := s.repo.GetExceptionGroups(r.Context(), q)
+	if err != nil {
+		slog.Error("Failed to get exception groups", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(groups)
+}
+
+// handleGetExceptions handles GET /api/exceptions/{fingerprint}
+func (s *Server) handleGetExceptions(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	exceptions, err := s.repo.GetExceptions(r.Context(), r.PathValue("fingerprint"), start, end, limit)
+	if err != nil {
+		slog.Error("Failed to list exceptions", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exceptions == nil {
+		exceptions = []storage.SpanException{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(exceptions)
+}
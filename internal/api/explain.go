@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// explainedResponse wraps a query endpoint's usual body when the request
+// asks for ?explain=true.
+type explainedResponse struct {
+	Result  any                   `json:"result"`
+	Explain storage.ExplainReport `json:"explain"`
+}
+
+// withExplain attaches a storage.QueryExplain to ctx when r carries
+// ?explain=true; otherwise it returns ctx and nil.
+func withExplain(ctx context.Context, r *http.Request) (context.Context, *storage.QueryExplain) {
+	if on, _ := strconv.ParseBool(r.URL.Query().Get("explain")); !on {
+		return ctx, nil
+	}
+	return storage.WithExplain(ctx)
+}
+
+// writeQueryJSON writes body as JSON, wrapped with the explain report when
+// ex is non-nil.
+func writeQueryJSON(w http.ResponseWriter, body any, ex *storage.QueryExplain) {
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	if ex != nil {
+		body = explainedResponse{Result: body, Explain: ex.Report()}
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestQueryEndpoints_Explain(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/logs", srv.handleGetLogs)
+	mux.HandleFunc("GET /api/metrics/series", srv.handleGetMetricSeries)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s = %d %s", path, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	// Without explain the body is unchanged.
+	var plain map[string]any
+	if err := json.Unmarshal(get("/api/logs").Body.Bytes(), &plain); err != nil || plain["data"] == nil {
+		t.Fatalf("plain body = %v, %v", plain, err)
+	}
+
+	var resp struct {
+		Result  any
+		Explain storage.ExplainReport
+	}
+	if err := json.Unmarshal(get("/api/logs?explain=true&search=timeout").Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	ex := resp.Explain
+	if ex.Resolution != storage.ResolutionRaw || !strings.Contains(ex.Detail, "LIKE") {
+		t.Errorf("logs resolution = %q / %q", ex.Resolution, ex.Detail)
+	}
+	if len(ex.Queries) != 2 || !strings.Contains(ex.Queries[0].SQL+ex.Queries[1].SQL, "timeout") {
+		t.Errorf("logs queries = %+v, want the COUNT and SELECT with bound values", ex.Queries)
+	}
+
+	resp.Explain = storage.ExplainReport{}
+	start := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	if err := json.Unmarshal(get("/api/metrics/series?explain=1&name=cpu&step=5m&start="+start).Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Explain.Resolution != storage.ResolutionRollup || !strings.Contains(resp.Explain.Detail, "5m0s") {
+		t.Errorf("series resolution = %q / %q", resp.Explain.Resolution, resp.Explain.Detail)
+	}
+}
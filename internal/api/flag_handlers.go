@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxFlagBody caps a PUT /api/admin/flags/{name} body.
+const maxFlagBody = 1 << 10
+
+// handleListFlags handles GET /api/admin/flags — every feature flag with
+// its stage, value and where the value came from.
+func (s *Server) handleListFlags(w http.ResponseWriter, _ *http.Request) {
+	if s.flags == nil {
+		http.Error(w, "feature flags are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(s.flags.List())
+}
+
+// handleSetFlag handles PUT /api/admin/flags/{name} with {"enabled": bool}.
+// Only runtime flags change; startup flags answer 409 and need
+// FEATURE_FLAGS and a restart. Changes are in memory and system-wide.
+func (s *Server) handleSetFlag(w http.ResponseWriter, r *http.Request) {
+	if s.flags == nil {
+		http.Error(w, "feature flags are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFlagBody)).Decode(&body); err != nil || body.Enabled == nil {
+		http.Error(w, `body must be {"enabled": true|false}`, http.StatusBadRequest)
+		return
+	}
+	name := r.PathValue("name")
+	st, err := s.flags.SetRuntime(name, *body.Enabled)
+	switch {
+	case errors.Is(err, featureflag.ErrUnknownFlag):
+		http.Error(w, "unknown flag", http.StatusNotFound)
+		return
+	case errors.Is(err, featureflag.ErrNotRuntime):
+		http.Error(w, err.Error()+"; set FEATURE_FLAGS and restart", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Feature flag changed", "flag", name, "enabled", st.Enabled) // #nosec G706 -- slog uses structured k/v fields
+	if s.history != nil {
+		s.history.RecordConfigChange(r.Context(), fmt.Sprintf("feature flag %s set to %t", name, st.Enabled))
+	}
+	s.audit(r, storage.AuditActionFlagUpdate, "flag/"+name, fmt.Sprintf("enabled set to %t", st.Enabled))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(st)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
+)
+
+func TestFlagHandlers(t *testing.T) {
+	srv := &Server{flags: featureflag.New()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/admin/flags", srv.handleListFlags)
+	mux.HandleFunc("PUT /api/admin/flags/{name}", srv.handleSetFlag)
+	mux.HandleFunc("GET /api/bootstrap", srv.handleBootstrap)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/admin/flags", "")
+	var list []featureflag.State
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 2 {
+		t.Fatalf("list status = %d body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPut, "/api/admin/flags/"+featureflag.AIInsights, `{"enabled": false}`)
+	var st featureflag.State
+	_ = json.Unmarshal(rec.Body.Bytes(), &st)
+	if rec.Code != http.StatusOK || st.Enabled || st.Source != featureflag.SourceAdmin {
+		t.Fatalf("PUT status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if srv.flags.Enabled(featureflag.AIInsights) {
+		t.Error("flag still on after PUT")
+	}
+	for path, want := range map[string]int{
+		"/api/admin/flags/" + featureflag.TailSampling: http.StatusConflict,
+		"/api/admin/flags/clickhouse":                  http.StatusNotFound,
+	} {
+		if rec := do(http.MethodPut, path, `{"enabled": true}`); rec.Code != want {
+			t.Errorf("PUT %s = %d, want %d", path, rec.Code, want)
+		}
+	}
+	if rec := do(http.MethodPut, "/api/admin/flags/"+featureflag.AIInsights, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT without enabled = %d, want 400", rec.Code)
+	}
+
+	var boot bootstrapResponse
+	_ = json.Unmarshal(do(http.MethodGet, "/api/bootstrap", "").Body.Bytes(), &boot)
+	if on, ok := boot.Flags[featureflag.AIInsights]; !ok || on {
+		t.Errorf("bootstrap flags = %v", boot.Flags)
+	}
+
+	srv.flags = nil
+	if rec := do(http.MethodGet, "/api/admin/flags", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no registry = %d, want 503", rec.Code)
+	}
+}
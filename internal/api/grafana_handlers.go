@@ -0,0 +1,388 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// The endpoints under /api/grafana implement the JSON datasource protocol
+// (the simpod-json-datasource plugin, and the older SimpleJSON /search and
+// /annotations calls), so a Grafana panel can chart Argus data with the
+// datasource URL set to <argus>/api/grafana.
+//
+// Targets:
+//   - traffic, errors: traces and error traces per interval
+//   - metric:<name>: a stored metric, aggregated by payload "agg"
+//   - logs: a table of the newest matching logs
+//
+// The payload may narrow any target to a service ("service") and logs by
+// "severity", "search" and "limit".
+
+const (
+	maxGrafanaBody      = 64 << 10
+	defaultGrafanaLogs  = 100
+	maxGrafanaLogs      = 1000
+	grafanaMetricPrefix = "metric:"
+)
+
+// grafanaTargets are the fixed targets listed by /metrics and /search;
+// metric:<name> targets are added per stored metric.
+var grafanaTargets = []string{"traffic", "errors", "logs"}
+
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs int64           `json:"intervalMs"`
+	Targets    []grafanaTarget `json:"targets"`
+}
+
+type grafanaTarget struct {
+	RefID   string         `json:"refId"`
+	Target  string         `json:"target"`
+	Hide    bool           `json:"hide"`
+	Payload grafanaPayload `json:"payload"`
+}
+
+type grafanaPayload struct {
+	Service  string `json:"service"`
+	Agg      string `json:"agg"`
+	Severity string `json:"severity"`
+	Search   string `json:"search"`
+	Limit    int    `json:"limit"`
+}
+
+// UnmarshalJSON accepts the payload as an object or, as the plugin sends
+// an empty editor, a string, which is parsed as JSON when not empty.
+func (p *grafanaPayload) UnmarshalJSON(b []byte) error {
+	type plain grafanaPayload
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if strings.TrimSpace(s) == "" {
+			return nil
+		}
+		b = []byte(s)
+	}
+	return json.Unmarshal(b, (*plain)(p))
+}
+
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	RefID      string       `json:"refId,omitempty"`
+	Datapoints [][2]float64 `json:"datapoints"` // [value, unix ms]
+}
+
+type grafanaTable struct {
+	Type    string          `json:"type"` // always "table"
+	RefID   string          `json:"refId,omitempty"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"` // time | string | number
+}
+
+// handleGrafanaHealth handles GET /api/grafana/, the datasource's
+// "Save & test" call.
+func (s *Server) handleGrafanaHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_, _ = w.Write([]byte(`{"status":"ok"}`))
+}
+
+// handleGrafanaMetrics handles POST /api/grafana/metrics: the targets the
+// query editor offers, with the payload fields each takes.
+func (s *Server) handleGrafanaMetrics(w http.ResponseWriter, r *http.Request) {
+	names, err := s.grafanaTargetNames(r)
+	if err != nil {
+		slog.Error("Failed to list Grafana targets", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	service := map[string]any{"label": "Service", "name": "service", "type": "input"}
+	type option struct {
+		Label    string           `json:"label"`
+		Value    string           `json:"value"`
+		Payloads []map[string]any `json:"payloads"`
+	}
+	aggs := make([]map[string]string, 0, len(storage.MetricAggregations))
+	for _, a := range storage.MetricAggregations {
+		aggs = append(aggs, map[string]string{"label": a, "value": a})
+	}
+	out := make([]option, 0, len(names))
+	for _, name := range names {
+		o := option{Label: name, Value: name, Payloads: []map[string]any{service}}
+		switch {
+		case name == "logs":
+			o.Payloads = append(o.Payloads,
+				map[string]any{"label": "Severity", "name": "severity", "type": "input"},
+				map[string]any{"label": "Search", "name": "search", "type": "input"},
+				map[string]any{"label": "Limit", "name": "limit", "type": "input"})
+		case strings.HasPrefix(name, grafanaMetricPrefix):
+			o.Payloads = append(o.Payloads, map[string]any{"label": "Aggregation", "name": "agg", "type": "select", "options": aggs})
+		}
+		out = append(out, o)
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// handleGrafanaSearch handles POST /api/grafana/search, the SimpleJSON
+// target list.
+func (s *Server) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	names, err := s.grafanaTargetNames(r)
+	if err != nil {
+		slog.Error("Failed to list Grafana targets", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(names)
+}
+
+func (s *Server) grafanaTargetNames(r *http.Request) ([]string, error) {
+	metrics, err := s.repo.GetMetricNames(r.Context(), "")
+	if err != nil {
+		return nil, err
+	}
+	names := append([]string{}, grafanaTargets...)
+	for _, m := range metrics {
+		names = append(names, grafanaMetricPrefix+m)
+	}
+	return names, nil
+}
+
+// handleGrafanaVariable handles POST /api/grafana/variable for template
+// variables: {"payload": {"target": "services"}} lists services and
+// "metrics" the stored metric names.
+func (s *Server) handleGrafanaVariable(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Payload struct {
+			Target string `json:"target"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGrafanaBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var values []string
+	var err error
+	switch body.Payload.Target {
+	case "services", "":
+		values, err = s.repo.GetServices(r.Context())
+	case "metrics":
+		values, err = s.repo.GetMetricNames(r.Context(), "")
+	default:
+		http.Error(w, "target must be services or metrics", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to list Grafana variable values", "target", body.Payload.Target, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := make([]map[string]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, map[string]string{"__text": v, "__value": v})
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// handleGrafanaAnnotations handles POST /api/grafana/annotations:
+// deployments in the range, optionally for the service named by the
+// annotation query.
+func (s *Server) handleGrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Range struct {
+			From time.Time `json:"from"`
+			To   time.Time `json:"to"`
+		} `json:"range"`
+		Annotation struct {
+			Query string `json:"query"`
+		} `json:"annotation"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGrafanaBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	deploys, err := s.repo.ListDeployments(r.Context(), strings.TrimSpace(body.Annotation.Query), body.Range.From, body.Range.To, 0)
+	if err != nil {
+		slog.Error("Failed to list deployments", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type annotation struct {
+		Time  int64    `json:"time"`
+		Title string   `json:"title"`
+		Text  string   `json:"text"`
+		Tags  []string `json:"tags"`
+	}
+	out := make([]annotation, 0, len(deploys))
+	for _, d := range deploys {
+		tags := []string{"deploy", d.ServiceName, d.Status}
+		if d.Verdict == storage.DeploymentVerdictRegressed {
+			tags = append(tags, d.Verdict)
+		}
+		out = append(out, annotation{
+			Time:  d.Timestamp.UnixMilli(),
+			Title: fmt.Sprintf("%s %s", d.ServiceName, d.Version),
+			Text:  d.Description,
+			Tags:  tags,
+		})
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// handleGrafanaQuery handles POST /api/grafana/query: one time series or
+// table per visible target, in target order.
+func (s *Server) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var req grafanaQueryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGrafanaBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	start, end := req.Range.From, req.Range.To
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-time.Hour)
+	}
+	if !start.Before(end) {
+		http.Error(w, "range.from must be before range.to", http.StatusBadRequest)
+		return
+	}
+	interval := time.Duration(req.IntervalMs) * time.Millisecond
+
+	out := make([]any, 0, len(req.Targets))
+	for _, t := range req.Targets {
+		if t.Hide || t.Target == "" {
+			continue
+		}
+		result, err := s.grafanaQueryTarget(r, t, start, end, interval)
+		if err != nil {
+			var bad grafanaBadTarget
+			if errors.As(err, &bad) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slog.Error("Grafana query failed", "target", t.Target, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out = append(out, result...)
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// grafanaBadTarget is a target or payload the client got wrong.
+type grafanaBadTarget string
+
+func (e grafanaBadTarget) Error() string { return string(e) }
+
+func (s *Server) grafanaQueryTarget(r *http.Request, t grafanaTarget, start, end time.Time, interval time.Duration) ([]any, error) {
+	ctx := r.Context()
+	var services []string
+	if t.Payload.Service != "" {
+		services = []string{t.Payload.Service}
+	}
+	switch {
+	case t.Target == "traffic", t.Target == "errors":
+		points, err := s.repo.GetTrafficMetrics(ctx, start, end, services, grafanaTrafficStep(interval))
+		if err != nil {
+			return nil, err
+		}
+		series := grafanaSeries{Target: t.Target, RefID: t.RefID, Datapoints: make([][2]float64, 0, len(points))}
+		for _, p := range points {
+			v := p.Count
+			if t.Target == "errors" {
+				v = p.ErrorCount
+			}
+			series.Datapoints = append(series.Datapoints, [2]float64{float64(v), float64(p.Timestamp.UnixMilli())})
+		}
+		return []any{series}, nil
+
+	case strings.HasPrefix(t.Target, grafanaMetricPrefix):
+		name := strings.TrimPrefix(t.Target, grafanaMetricPrefix)
+		agg := t.Payload.Agg
+		if agg == "" {
+			agg = "avg"
+		}
+		if !slices.Contains(storage.MetricAggregations, agg) {
+			return nil, grafanaBadTarget(fmt.Sprintf("%s: agg must be one of %s", t.Target, strings.Join(storage.MetricAggregations, ", ")))
+		}
+		points, err := s.repo.GetMetricSeries(ctx, start, end, t.Payload.Service, name, interval, agg)
+		if err != nil {
+			return nil, err
+		}
+		series := grafanaSeries{Target: t.Target, RefID: t.RefID, Datapoints: make([][2]float64, 0, len(points))}
+		for _, p := range points {
+			series.Datapoints = append(series.Datapoints, [2]float64{p.Value, float64(p.Timestamp.UnixMilli())})
+		}
+		return []any{series}, nil
+
+	case t.Target == "logs":
+		limit := t.Payload.Limit
+		if limit <= 0 {
+			limit = defaultGrafanaLogs
+		}
+		logs, _, err := s.repo.GetLogsV2(ctx, storage.LogFilter{
+			ServiceName: t.Payload.Service,
+			Severity:    t.Payload.Severity,
+			Search:      t.Payload.Search,
+			StartTime:   start,
+			EndTime:     end,
+			Limit:       min(limit, maxGrafanaLogs),
+		})
+		if err != nil {
+			return nil, err
+		}
+		table := grafanaTable{
+			Type:  "table",
+			RefID: t.RefID,
+			Columns: []grafanaColumn{
+				{Text: "Time", Type: "time"},
+				{Text: "Service", Type: "string"},
+				{Text: "Severity", Type: "string"},
+				{Text: "Body", Type: "string"},
+				{Text: "Trace ID", Type: "string"},
+			},
+			Rows: make([][]any, 0, len(logs)),
+		}
+		for _, l := range logs {
+			table.Rows = append(table.Rows, []any{l.Timestamp.UnixMilli(), l.ServiceName, l.Severity, l.Body, l.TraceID})
+		}
+		return []any{table}, nil
+	}
+	return nil, grafanaBadTarget("unknown target " + strconv.Quote(t.Target) + "; want traffic, errors, logs or metric:<name>")
+}
+
+// grafanaTrafficStep rounds the panel interval up to a bucket
+// GET /api/metrics/traffic accepts, so a wide panel never asks SQL for
+// thousands of tiny buckets.
+func grafanaTrafficStep(interval time.Duration) time.Duration {
+	for _, b := range trafficBuckets {
+		if interval <= b {
+			return b
+		}
+	}
+	return trafficBuckets[len(trafficBuckets)-1]
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestGrafanaDatasource(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now().UTC()
+	if err := repo.BatchCreateTraces([]storage.Trace{
+		{TenantID: storage.DefaultTenantID, TraceID: "t1", ServiceName: "checkout", Status: "STATUS_CODE_ERROR", Timestamp: now.Add(-2 * time.Minute)},
+		{TenantID: storage.DefaultTenantID, TraceID: "t2", ServiceName: "checkout", Status: "STATUS_CODE_OK", Timestamp: now.Add(-2 * time.Minute)},
+		{TenantID: storage.DefaultTenantID, TraceID: "t3", ServiceName: "cart", Status: "STATUS_CODE_OK", Timestamp: now.Add(-2 * time.Minute)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, ServiceName: "checkout", Severity: "ERROR", Body: "card declined", TraceID: "t1", Timestamp: now.Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "INFO", Body: "item added", Timestamp: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/grafana/{$}", srv.handleGrafanaHealth)
+	mux.HandleFunc("POST /api/grafana/search", srv.handleGrafanaSearch)
+	mux.HandleFunc("POST /api/grafana/variable", srv.handleGrafanaVariable)
+	mux.HandleFunc("POST /api/grafana/query", srv.handleGrafanaQuery)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/api/grafana/", ""); rec.Code != http.StatusOK {
+		t.Errorf("health = %d", rec.Code)
+	}
+	var targets []string
+	if rec := do(http.MethodPost, "/api/grafana/search", `{"target":""}`); json.Unmarshal(rec.Body.Bytes(), &targets) != nil || len(targets) < 3 || targets[0] != "traffic" {
+		t.Errorf("search = %d %s", rec.Code, rec.Body.String())
+	}
+	var vars []map[string]string
+	if rec := do(http.MethodPost, "/api/grafana/variable", `{"payload":{"target":"services"}}`); json.Unmarshal(rec.Body.Bytes(), &vars) != nil || len(vars) != 2 {
+		t.Errorf("variable = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rangeJSON := `"range":{"from":"` + now.Add(-time.Hour).Format(time.RFC3339) + `","to":"` + now.Format(time.RFC3339) + `"}`
+	rec := do(http.MethodPost, "/api/grafana/query", `{`+rangeJSON+`,"intervalMs":30000,"targets":[
+		{"refId":"A","target":"traffic"},
+		{"refId":"B","target":"errors","payload":{"service":"checkout"}},
+		{"refId":"C","target":"logs","payload":"{\"service\":\"checkout\"}"},
+		{"refId":"D","target":"traffic","hide":true}]}`)
+	var got []json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 3 {
+		t.Fatalf("query = %d %s", rec.Code, rec.Body.String())
+	}
+	sum := func(raw json.RawMessage) float64 {
+		var s grafanaSeries
+		if err := json.Unmarshal(raw, &s); err != nil {
+			t.Fatal(err)
+		}
+		var n float64
+		for _, p := range s.Datapoints {
+			n += p[0]
+		}
+		return n
+	}
+	if n := sum(got[0]); n != 3 {
+		t.Errorf("traffic = %v, want 3", n)
+	}
+	if n := sum(got[1]); n != 1 {
+		t.Errorf("checkout errors = %v, want 1", n)
+	}
+	var table grafanaTable
+	if err := json.Unmarshal(got[2], &table); err != nil || table.Type != "table" || len(table.Rows) != 1 || table.Rows[0][3] != "card declined" {
+		t.Errorf("logs = %s", got[2])
+	}
+
+	for _, body := range []string{
+		`{` + rangeJSON + `,"targets":[{"target":"spans"}]}`,
+		`{` + rangeJSON + `,"targets":[{"target":"metric:cpu","payload":{"agg":"p99"}}]}`,
+		`{"range":{"from":"` + now.Format(time.RFC3339) + `","to":"` + now.Add(-time.Hour).Format(time.RFC3339) + `"}}`,
+	} {
+		if rec := do(http.MethodPost, "/api/grafana/query", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestGrafanaTrafficStep(t *testing.T) {
+	for in, want := range map[time.Duration]time.Duration{
+		0:                time.Minute,
+		30 * time.Second: time.Minute,
+		2 * time.Minute:  5 * time.Minute,
+		6 * time.Hour:    time.Hour,
+	} {
+		if got := grafanaTrafficStep(in); got != want {
+			t.Errorf("grafanaTrafficStep(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxHubBody caps a PUT /api/admin/hub body.
+const maxHubBody = 1 << 10
+
+// hubTuning is one tuning in the /api/admin/hub body.
+type hubTuning struct {
+	BufferSize    int    `json:"buffer_size"`
+	FlushInterval string `json:"flush_interval"`
+}
+
+// hubTuningResponse is the GET and PUT /api/admin/hub body.
+type hubTuningResponse struct {
+	hubTuning
+	Presets map[string]hubTuning `json:"presets"`
+}
+
+// handleGetHubTuning handles GET /api/admin/hub — the WebSocket hub's
+// current flush tuning and the named presets.
+func (s *Server) handleGetHubTuning(w http.ResponseWriter, _ *http.Request) {
+	if s.hub == nil {
+		http.Error(w, "realtime hub is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	s.writeHubTuning(w)
+}
+
+// handleSetHubTuning handles PUT /api/admin/hub with {"preset"} and/or
+// {"buffer_size", "flush_interval"}; explicit values override the preset
+// and omitted ones keep the current setting. Changes are in memory and
+// last until restart.
+func (s *Server) handleSetHubTuning(w http.ResponseWriter, r *http.Request) {
+	if s.hub == nil {
+		http.Error(w, "realtime hub is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var body struct {
+		Preset        string `json:"preset"`
+		BufferSize    int    `json:"buffer_size"`
+		FlushInterval string `json:"flush_interval"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHubBody)).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.Preset == "" && body.BufferSize == 0 && body.FlushInterval == "" {
+		http.Error(w, "body must set preset, buffer_size or flush_interval", http.StatusBadRequest)
+		return
+	}
+
+	var t config.HubFlushTuning
+	t.BufferSize, t.FlushInterval = s.hub.FlushTuning()
+	if body.Preset != "" {
+		preset, ok := config.HubPresets[body.Preset]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown preset %q", body.Preset), http.StatusBadRequest)
+			return
+		}
+		t = preset
+	}
+	if body.BufferSize != 0 {
+		t.BufferSize = body.BufferSize
+	}
+	if body.FlushInterval != "" {
+		d, err := time.ParseDuration(body.FlushInterval)
+		if err != nil {
+			http.Error(w, "flush_interval must be a duration such as 500ms", http.StatusBadRequest)
+			return
+		}
+		t.FlushInterval = d
+	}
+	if err := t.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.hub.SetFlushTuning(t.BufferSize, t.FlushInterval)
+	slog.Info("Hub flush tuning changed", "buffer_size", t.BufferSize, "flush_interval", t.FlushInterval)
+	if s.history != nil {
+		s.history.RecordConfigChange(r.Context(), fmt.Sprintf("hub flush tuning set to %d entries / %s", t.BufferSize, t.FlushInterval))
+	}
+	s.audit(r, storage.AuditActionHubUpdate, "hub", fmt.Sprintf("flush tuning set to %d entries / %s", t.BufferSize, t.FlushInterval))
+	s.writeHubTuning(w)
+}
+
+func (s *Server) writeHubTuning(w http.ResponseWriter) {
+	size, interval := s.hub.FlushTuning()
+	resp := hubTuningResponse{
+		hubTuning: hubTuning{BufferSize: size, FlushInterval: interval.String()},
+		Presets:   make(map[string]hubTuning, len(config.HubPresets)),
+	}
+	for name, p := range config.HubPresets {
+		resp.Presets[name] = hubTuning{BufferSize: p.BufferSize, FlushInterval: p.FlushInterval.String()}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
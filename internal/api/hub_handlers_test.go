@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+)
+
+func TestHubTuningHandlers(t *testing.T) {
+	hub := realtime.NewHub(nil)
+	srv := &Server{hub: hub}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/admin/hub", srv.handleGetHubTuning)
+	mux.HandleFunc("PUT /api/admin/hub", srv.handleSetHubTuning)
+	do := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/api/admin/hub", strings.NewReader(body)))
+		return rec
+	}
+
+	var got hubTuningResponse
+	rec := do(http.MethodGet, "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.BufferSize != 100 || got.FlushInterval != "500ms" || len(got.Presets) != 3 {
+		t.Fatalf("GET status = %d body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodPut, `{"preset": "high-throughput", "flush_interval": "1s"}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK || got.BufferSize != 1000 || got.FlushInterval != "1s" {
+		t.Fatalf("PUT status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if size, interval := hub.FlushTuning(); size != 1000 || interval != time.Second {
+		t.Errorf("hub tuning = %d, %s", size, interval)
+	}
+
+	// Omitted fields keep the current setting.
+	if rec := do(http.MethodPut, `{"buffer_size": 50}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT buffer_size status = %d", rec.Code)
+	}
+	if size, interval := hub.FlushTuning(); size != 50 || interval != time.Second {
+		t.Errorf("hub tuning = %d, %s; want 50, 1s", size, interval)
+	}
+
+	for _, body := range []string{`{}`, `{"preset": "turbo"}`, `{"flush_interval": "1ms"}`, `{"buffer_size": -1}`, `not json`} {
+		if rec := do(http.MethodPut, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s status = %d, want 400", body, rec.Code)
+		}
+	}
+
+	srv.hub = nil
+	if rec := do(http.MethodGet, ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no hub status = %d, want 503", rec.Code)
+	}
+}
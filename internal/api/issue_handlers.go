@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+// maxIssueBody bounds PATCH /api/issues/{fingerprint} bodies.
+const maxIssueBody = 4 << 10
+
+// issuePatch is the body of PATCH /api/issues/{fingerprint}. Omitted fields
+// are left alone.
+type issuePatch struct {
+	Status   *string `json:"status"`   // open | resolved | ignored
+	Assignee *string `json:"assignee"` // empty unassigns
+}
+
+// handleListIssues handles GET /api/issues — the tracked error groups of
+// the tenant. Query params: service_name (repeatable), status, sort
+// (last_seen, first_seen or occurrences), limit.
+func (s *Server) handleListIssues(w http.ResponseWriter, r *http.Request) {
+	q := storage.IssueQuery{
+		ServiceNames: r.URL.Query()["service_name"],
+		Status:       r.URL.Query().Get("status"),
+		Sort:         r.URL.Query().Get("sort"),
+	}
+	switch q.Status {
+	case "", storage.TriageStatusOpen, storage.TriageStatusResolved, storage.TriageStatusIgnored:
+	default:
+		http.Error(w, "status must be open, resolved or ignored", http.StatusBadRequest)
+		return
+	}
+	if q.Sort != "" && !storage.ValidIssueSort(q.Sort) {
+		http.Error(w, "invalid sort", http.StatusBadRequest)
+		return
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+
+	issues, err := s.repo.ListIssues(r.Context(), q)
+	if err != nil {
+		slog.Error("Failed to list issues", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(issues)
+}
+
+// handleGetIssue handles GET /api/issues/{fingerprint}
+func (s *Server) handleGetIssue(w http.ResponseWriter, r *http.Request) {
+	is, err := s.repo.GetIssue(r.Context(), r.PathValue("fingerprint"))
+	if err != nil {
+		writeIssueError(w, "load", err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(is)
+}
+
+// handleUpdateIssue handles PATCH /api/issues/{fingerprint}: sets the
+// issue's status and/or assignee, the same triage state POST
+// /api/errors/bulk changes.
+func (s *Server) handleUpdateIssue(w http.ResponseWriter, r *http.Request) {
+	var req issuePatch
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIssueBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var actions []storage.TriageAction
+	if req.Status != nil {
+		switch *req.Status {
+		case storage.TriageStatusOpen:
+			actions = append(actions, storage.TriageAction{Action: storage.TriageActionReopen})
+		case storage.TriageStatusResolved:
+			actions = append(actions, storage.TriageAction{Action: storage.TriageActionResolve})
+		case storage.TriageStatusIgnored:
+			actions = append(actions, storage.TriageAction{Action: storage.TriageActionIgnore})
+		default:
+			http.Error(w, "status must be open, resolved or ignored", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Assignee != nil {
+		if len(*req.Assignee) > 255 {
+			http.Error(w, "assignee too long", http.StatusBadRequest)
+			return
+		}
+		actions = append(actions, storage.TriageAction{Action: storage.TriageActionAssign, Assignee: *req.Assignee})
+	}
+	if len(actions) == 0 {
+		http.Error(w, "status or assignee is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	is, err := s.repo.GetIssue(ctx, r.PathValue("fingerprint"))
+	if err != nil {
+		writeIssueError(w, "load", err)
+		return
+	}
+	for _, a := range actions {
+		if _, err := s.repo.ApplyTriage(ctx, storage.TriageKindIssue, []string{is.Fingerprint}, a); err != nil {
+			writeIssueError(w, "update", err)
+			return
+		}
+	}
+	if s.cache != nil {
+		s.cache.DeletePrefix("errors:" + storage.TenantFromContext(ctx) + ":")
+	}
+	if is, err = s.repo.GetIssue(ctx, is.Fingerprint); err != nil {
+		writeIssueError(w, "load", err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(is)
+}
+
+// writeIssueError maps repository errors to 404 or 500.
+func writeIssueError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "issue not found", http.StatusNotFound)
+		return
+	}
+	slog.Error("Failed to "+op+" issue", "error", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestIssueHandlers(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now().UTC()
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, TraceID: "t1", Severity: "ERROR", ServiceName: "checkout", Body: "payment 17 declined", Timestamp: now.Add(-10 * time.Minute)},
+		{TenantID: storage.DefaultTenantID, TraceID: "t2", Severity: "ERROR", ServiceName: "checkout", Body: "payment 18 declined", Timestamp: now.Add(-5 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+	if _, err := repo.AdvanceIssues(context.Background(), now.Add(5*time.Minute), time.Hour); err != nil {
+		t.Fatalf("AdvanceIssues: %v", err)
+	}
+
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/issues", srv.handleListIssues)
+	mux.HandleFunc("GET /api/issues/{fingerprint}", srv.handleGetIssue)
+	mux.HandleFunc("PATCH /api/issues/{fingerprint}", srv.handleUpdateIssue)
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/api/issues?service_name=checkout", "")
+	var list storage.IssueList
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &list) != nil || list.Total != 1 {
+		t.Fatalf("list: %d %s", rec.Code, rec.Body.String())
+	}
+	is := list.Issues[0]
+	if is.Occurrences != 2 || is.Message != "payment <n> declined" || is.Status != storage.TriageStatusOpen {
+		t.Errorf("issue = %+v", is)
+	}
+	path := "/api/issues/" + is.Fingerprint
+
+	rec = do(http.MethodPatch, path, `{"status":"ignored","assignee":"alice"}`)
+	var got storage.Issue
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil || got.Status != storage.TriageStatusIgnored || got.Assignee != "alice" {
+		t.Errorf("patch: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/issues?status=open", ""); !strings.Contains(rec.Body.String(), `"total":0`) {
+		t.Errorf("open issues after ignore = %s", rec.Body.String())
+	}
+
+	for _, c := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodGet, "/api/issues/ffff", "", http.StatusNotFound},
+		{http.MethodPatch, "/api/issues/ffff", `{"status":"resolved"}`, http.StatusNotFound},
+		{http.MethodPatch, path, `{"status":"closed"}`, http.StatusBadRequest},
+		{http.MethodPatch, path, `{}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/issues?status=closed", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/issues?sort=name", "", http.StatusBadRequest},
+	} {
+		if rec := do(c.method, c.target, c.body); rec.Code != c.want {
+			t.Errorf("%s %s %s: want %d, got %d", c.method, c.target, c.body, c.want, rec.Code)
+		}
+	}
+}
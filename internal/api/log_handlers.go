@@ -61,18 +61,28 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 		filter.StartTime, filter.EndTime = cs, ce
 	}
 
-	logs, total, err := s.repo.GetLogsV2(r.Context(), filter)
+	ctx, explain := withExplain(regionFilter(r), r)
+	logs, total, err := s.repo.GetLogsV2(ctx, filter)
 	if err != nil {
 		slog.Error("Failed to get logs", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"data":  views.LogsFromModels(logs),
+	data := views.LogsFromModels(logs)
+	// Logs waiting in the DLQ are only merged into the first page: offsets
+	// count database rows, and region-scoped queries target stored data.
+	if offset == 0 && r.URL.Query().Get("region") == "" {
+		if pending := s.pendingLogs(ctx, filter); len(pending) > 0 {
+			data = mergePendingLogs(logs, pending, limit)
+			total += int64(len(pending))
+		}
+	}
+
+	writeQueryJSON(w, map[string]any{
+		"data":  data,
 		"total": total,
-	})
+	}, explain)
 }
 
 // handleGetLogContext handles GET /api/logs/context
@@ -137,5 +147,6 @@ func (s *Server) BroadcastLog(l storage.Log) {
 		AttributesJSON: string(l.AttributesJSON),
 		AIInsight:      string(l.AIInsight),
 		Timestamp:      l.Timestamp,
+		TenantID:       l.TenantID,
 	})
 }
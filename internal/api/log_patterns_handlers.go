@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// logPatternsCacheTTL bounds how stale the log patterns view may be. Each
+// miss runs Drain twice over up to 20k rows, so a polling dashboard is
+// served from cache instead.
+const logPatternsCacheTTL = 30 * time.Second
+
+// handleGetLogPatterns handles GET /api/logs/patterns — logs grouped into
+// Drain templates per service with counts, share, trend and first/last seen.
+// Query params: start, end (RFC3339; default last 1h), service_name
+// (repeatable), severity, region, limit, buckets.
+//
+// Responses are cached per tenant and query string for 30s.
+func (s *Server) handleGetLogPatterns(w http.ResponseWriter, r *http.Request) {
+	ctx := regionFilter(r)
+	cacheKey := "log_patterns:" + storage.TenantFromContext(ctx) + ":" + r.URL.RawQuery
+
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+		w.Header().Set("X-Cache", "HIT")
+		_ = json.NewEncoder(w).Encode(cached)
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := graphrag.LogPatternQuery{
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+		Severity:     r.URL.Query().Get("severity"),
+	}
+	for param, dst := range map[string]*int{"limit": &q.Limit, "buckets": &q.Buckets} {
+		if v := r.URL.Query().Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "invalid "+param, http.StatusBadRequest)
+				return
+			}
+			*dst = n
+		}
+	}
+	// Clamp the knobs that size the response.
+	q.Limit = min(q.Limit, 500)
+	q.Buckets = min(q.Buckets, 500)
+
+	resp, err := graphrag.MineLogPatterns(ctx, s.repo, q)
+	if err != nil {
+		slog.Error("Failed to mine log patterns", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.cache.Set(cacheKey, resp, logPatternsCacheTTL)
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.Header().Set("X-Cache", "MISS")
+	_ = json.NewEncoder(w).Encode(resp)
+}
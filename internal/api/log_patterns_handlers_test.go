@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleGetLogPatterns(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, Severity: "WARN", ServiceName: "api", Body: "slow query took 812 ms", Timestamp: now.Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, Severity: "WARN", ServiceName: "api", Body: "slow query took 95 ms", Timestamp: now.Add(-2 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+
+	c := cache.New()
+	t.Cleanup(c.Stop)
+	srv := &Server{repo: repo, cache: c}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/logs/patterns", srv.handleGetLogPatterns)
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/logs/patterns"+query, nil))
+		return rec
+	}
+
+	rec := get("?buckets=4")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("status = %d cache=%q body=%q", rec.Code, rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+	var got graphrag.LogPatternsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Patterns) != 1 || got.Patterns[0].Pattern != "slow query took <NUM> ms" || got.Patterns[0].Count != 2 || len(got.Patterns[0].Trend) != 4 {
+		t.Errorf("patterns = %+v", got.Patterns)
+	}
+	if rec := get("?buckets=4"); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("second request X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	for _, q := range []string{"?limit=x", "?buckets=-1"} {
+		if rec := get(q); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, rec.Code)
+		}
+	}
+}
@@ -4,13 +4,21 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
 	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 )
 
-// handleGetTrafficMetrics handles GET /api/metrics/traffic
+// trafficBuckets are the bucket sizes GET /api/metrics/traffic accepts.
+var trafficBuckets = []time.Duration{time.Minute, 5 * time.Minute, time.Hour}
+
+// handleGetTrafficMetrics handles GET /api/metrics/traffic. With
+// exemplars=true every bucket links its slowest trace and an error trace;
+// with deployments=true it lists the deployments that fall in it.
 func (s *Server) handleGetTrafficMetrics(w http.ResponseWriter, r *http.Request) {
 	// Default to last 30 minutes if not specified
 	end := time.Now()
@@ -29,15 +37,39 @@ func (s *Server) handleGetTrafficMetrics(w http.ResponseWriter, r *http.Request)
 
 	serviceNames := r.URL.Query()["service_name"]
 
-	points, err := s.repo.GetTrafficMetrics(r.Context(), start, end, serviceNames)
+	step := time.Minute
+	if v := r.URL.Query().Get("bucket"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || !slices.Contains(trafficBuckets, d) {
+			http.Error(w, "invalid bucket (want 1m, 5m or 1h)", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+
+	ctx, explain := withExplain(r.Context(), r)
+	points, err := s.repo.GetTrafficMetrics(ctx, start, end, serviceNames, step)
 	if err != nil {
 		slog.Error("Failed to get traffic metrics", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if r.URL.Query().Get("exemplars") == "true" {
+		if err := s.repo.AddTrafficExemplars(ctx, points, start, end, serviceNames, step); err != nil {
+			slog.Error("Failed to get traffic exemplars", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if r.URL.Query().Get("deployments") == "true" {
+		if points, err = s.repo.AddTrafficDeployments(ctx, points, start, end, serviceNames, step); err != nil {
+			slog.Error("Failed to get traffic deployment markers", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
-	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
-	_ = json.NewEncoder(w).Encode(points)
+	writeQueryJSON(w, points, explain)
 }
 
 // handleGetLatencyHeatmap handles GET /api/metrics/latency_heatmap
@@ -58,15 +90,88 @@ func (s *Server) handleGetLatencyHeatmap(w http.ResponseWriter, r *http.Request)
 
 	serviceNames := r.URL.Query()["service_name"]
 
-	points, err := s.repo.GetLatencyHeatmap(r.Context(), start, end, serviceNames)
+	ctx, explain := withExplain(r.Context(), r)
+	points, err := s.repo.GetLatencyHeatmap(ctx, start, end, serviceNames)
 	if err != nil {
 		slog.Error("Failed to get latency heatmap", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
-	_ = json.NewEncoder(w).Encode(points)
+	writeQueryJSON(w, points, explain)
+}
+
+// handleGetLatencyPercentiles handles GET /api/metrics/latency_percentiles.
+// With deployments=true each service lists its deployments in the range.
+func (s *Server) handleGetLatencyPercentiles(w http.ResponseWriter, r *http.Request) {
+	end := time.Now()
+	start := end.Add(-30 * time.Minute)
+
+	if startStr := r.URL.Query().Get("start"); startStr != "" {
+		if t, err := time.Parse(time.RFC3339, startStr); err == nil {
+			start = t
+		}
+	}
+	if endStr := r.URL.Query().Get("end"); endStr != "" {
+		if t, err := time.Parse(time.RFC3339, endStr); err == nil {
+			end = t
+		}
+	}
+	serviceNames := r.URL.Query()["service_name"]
+
+	ctx, explain := withExplain(r.Context(), r)
+	services, err := s.repo.GetServiceLatencyPercentiles(ctx, start, end, serviceNames)
+	if err != nil {
+		slog.Error("Failed to get latency percentiles", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("deployments") == "true" {
+		if err := s.repo.AddLatencyDeployments(ctx, services, start, end); err != nil {
+			slog.Error("Failed to get latency deployment markers", "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeQueryJSON(w, services, explain)
+}
+
+// handleGetServiceOperations handles GET /api/services/{name}/operations:
+// request count, error rate and p50/p95/p99 per operation of one service.
+// Query params: start, end (RFC3339; default last 1h), sort (impact, count,
+// error_rate, p99 or name; default impact), limit (100, max 1000).
+func (s *Server) handleGetServiceOperations(w http.ResponseWriter, r *http.Request) {
+	q := storage.OperationQuery{
+		ServiceName: r.PathValue("name"),
+		Sort:        r.URL.Query().Get("sort"),
+	}
+	if q.Sort != "" && !storage.ValidOperationSort(q.Sort) {
+		http.Error(w, "invalid sort", http.StatusBadRequest)
+		return
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	var err error
+	if q.Start, q.End, err = parseTimeRange(r); err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+
+	ctx, explain := withExplain(r.Context(), r)
+	ops, err := s.repo.GetOperationLatency(ctx, q)
+	if err != nil {
+		slog.Error("Failed to get service operations", "service", q.ServiceName, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeQueryJSON(w, ops, explain)
 }
 
 // handleGetDashboardStats handles GET /api/metrics/dashboard
@@ -88,15 +193,15 @@ func (s *Server) handleGetDashboardStats(w http.ResponseWriter, r *http.Request)
 
 	serviceNames := r.URL.Query()["service_name"]
 
-	stats, err := s.repo.GetDashboardStats(r.Context(), start, end, serviceNames)
+	ctx, explain := withExplain(r.Context(), r)
+	stats, err := s.repo.GetDashboardStats(ctx, start, end, serviceNames)
 	if err != nil {
 		slog.Error("Failed to get dashboard stats", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
-	_ = json.NewEncoder(w).Encode(views.DashboardStatsFromModel(stats))
+	writeQueryJSON(w, views.DashboardStatsFromModel(stats), explain)
 }
 
 // handleGetServiceMapMetrics handles GET /api/metrics/service-map
@@ -115,7 +220,7 @@ func (s *Server) handleGetServiceMapMetrics(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
-	metrics, err := s.repo.GetServiceMapMetrics(r.Context(), start, end)
+	metrics, err := s.repo.GetServiceMapMetrics(regionFilter(r), start, end)
 	if err != nil {
 		slog.Error("Failed to get service map metrics", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -143,15 +248,66 @@ func (s *Server) handleGetMetricBuckets(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	buckets, err := s.repo.GetMetricBuckets(r.Context(), start, end, serviceName, name)
+	ctx, explain := withExplain(r.Context(), r)
+	buckets, err := s.repo.GetMetricBuckets(ctx, start, end, serviceName, name)
 	if err != nil {
 		slog.Error("Failed to get metric buckets", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
-	_ = json.NewEncoder(w).Encode(views.MetricBucketsFromModels(buckets))
+	writeQueryJSON(w, views.MetricBucketsFromModels(buckets), explain)
+}
+
+// handleGetMetricSeries handles GET /api/metrics/series — one metric rolled
+// up into step-sized windows (step as a Go duration, default: stored
+// resolution) with agg = avg|min|max|sum|count (default avg).
+func (s *Server) handleGetMetricSeries(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-1 * time.Hour)
+	}
+
+	q := r.URL.Query()
+	name := q.Get("name")
+	if name == "" {
+		http.Error(w, "metric name is required", http.StatusBadRequest)
+		return
+	}
+	var step time.Duration
+	if v := q.Get("step"); v != "" {
+		step, err = time.ParseDuration(v)
+		if err != nil || step < 0 {
+			http.Error(w, "invalid step", http.StatusBadRequest)
+			return
+		}
+	}
+	agg := q.Get("agg")
+	if agg == "" {
+		agg = "avg"
+	}
+	if !slices.Contains(storage.MetricAggregations, agg) {
+		http.Error(w, "invalid agg", http.StatusBadRequest)
+		return
+	}
+
+	ctx, explain := withExplain(r.Context(), r)
+	points, err := s.repo.GetMetricSeries(ctx, start, end, q.Get("service_name"), name, step, agg)
+	if err != nil {
+		slog.Error("Failed to get metric series", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeQueryJSON(w, points, explain)
 }
 
 // handleGetMetricNames handles GET /api/metadata/metrics
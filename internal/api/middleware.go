@@ -30,6 +30,12 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return rw.ResponseWriter.(http.Hijacker).Hijack()
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// streaming handlers can flush through the middleware.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // MetricsMiddleware records OtelContext_http_requests_total and OtelContext_http_request_duration_seconds
 // for every HTTP request.
 func MetricsMiddleware(metrics *telemetry.Metrics, next http.Handler) http.Handler {
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -89,6 +91,52 @@ func TestAPIKeyGate_ProtectedPathsRequireKey(t *testing.T) {
 	}
 }
 
+// TestAPIKeyGate_CORSPreflightSkipsAuth verifies browser preflights reach the
+// handler without credentials; a bare OPTIONS (no preflight header) and the
+// real POST still require the key.
+func TestAPIKeyGate_CORSPreflightSkipsAuth(t *testing.T) {
+	h := APIKeyGate("s3cret", "/mcp", okHandler())
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preflight should skip auth, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bare OPTIONS should require key, got %d", rec.Code)
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	h := RequireClientCert(okHandler())
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	for _, tc := range []struct {
+		path string
+		tls  *tls.ConnectionState
+		want int
+	}{
+		{"/v1/traces", verified, http.StatusOK},
+		{"/v1/traces", &tls.ConnectionState{}, http.StatusUnauthorized},
+		{"/v1/logs", nil, http.StatusUnauthorized},
+		{"/api/traces", &tls.ConnectionState{}, http.StatusOK},
+		{"/", nil, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+		req.TLS = tc.tls
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s (tls=%v): status %d, want %d", tc.path, tc.tls != nil, rec.Code, tc.want)
+		}
+	}
+}
+
 // tenantCapture is a handler that records the tenant stashed on the request
 // context by TenantMiddleware so the test can assert on it.
 type tenantCapture struct{ got string }
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/uptime"
+	"gorm.io/gorm"
+)
+
+// maxMonitorBody bounds monitor request bodies.
+const maxMonitorBody = 16 << 10
+
+// monitorAvailabilityWindows are the windows reported by
+// GET /api/monitors/{id}/results.
+var monitorAvailabilityWindows = []struct {
+	name   string
+	window time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// handleListMonitors handles GET /api/monitors
+func (s *Server) handleListMonitors(w http.ResponseWriter, r *http.Request) {
+	monitors, err := s.repo.ListMonitors(r.Context())
+	if err != nil {
+		slog.Error("Failed to list monitors", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if monitors == nil {
+		monitors = []storage.Monitor{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(monitors)
+}
+
+// handleGetMonitor handles GET /api/monitors/{id}
+func (s *Server) handleGetMonitor(w http.ResponseWriter, r *http.Request) {
+	id, ok := monitorID(w, r)
+	if !ok {
+		return
+	}
+	m, err := s.repo.GetMonitor(r.Context(), id)
+	if err != nil {
+		writeMonitorError(w, "load", id, err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+// handleCreateMonitor handles POST /api/monitors
+func (s *Server) handleCreateMonitor(w http.ResponseWriter, r *http.Request) {
+	var m storage.Monitor
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMonitorBody)).Decode(&m); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := uptime.ValidateMonitor(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.CreateMonitor(r.Context(), &m); err != nil {
+		slog.Error("Failed to create monitor", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+// handleUpdateMonitor handles PUT /api/monitors/{id}, replacing the
+// definition.
+func (s *Server) handleUpdateMonitor(w http.ResponseWriter, r *http.Request) {
+	id, ok := monitorID(w, r)
+	if !ok {
+		return
+	}
+	var m storage.Monitor
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMonitorBody)).Decode(&m); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := uptime.ValidateMonitor(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.UpdateMonitor(r.Context(), id, &m); err != nil {
+		writeMonitorError(w, "update", id, err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+// handleDeleteMonitor handles DELETE /api/monitors/{id}. Its results and
+// monitor_down alert rules are deleted with it.
+func (s *Server) handleDeleteMonitor(w http.ResponseWriter, r *http.Request) {
+	id, ok := monitorID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.repo.DeleteMonitor(r.Context(), id); err != nil {
+		writeMonitorError(w, "delete", id, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetMonitorResults handles GET /api/monitors/{id}/results?limit=N:
+// the latest checks, newest first, and the availability over 24h, 7d and
+// 30d.
+func (s *Server) handleGetMonitorResults(w http.ResponseWriter, r *http.Request) {
+	id, ok := monitorID(w, r)
+	if !ok {
+		return
+	}
+	m, err := s.repo.GetMonitor(r.Context(), id)
+	if err != nil {
+		writeMonitorError(w, "load", id, err)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	results, err := s.repo.ListMonitorResults(r.Context(), m.ID, limit)
+	if err != nil {
+		writeMonitorError(w, "list results of", id, err)
+		return
+	}
+	if results == nil {
+		results = []storage.MonitorResult{}
+	}
+	now := time.Now()
+	availability := make(map[string]storage.MonitorAvailability, len(monitorAvailabilityWindows))
+	for _, aw := range monitorAvailabilityWindows {
+		a, err := s.repo.MonitorAvailabilitySince(r.Context(), m.ID, now.Add(-aw.window))
+		if err != nil {
+			writeMonitorError(w, "measure", id, err)
+			return
+		}
+		availability[aw.name] = a
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"monitor":      m,
+		"availability": availability,
+		"results":      results,
+	})
+}
+
+// monitorID parses the {id} path value, writing a 400 when it is invalid.
+func monitorID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeMonitorError maps repository errors to 404 or 500.
+func writeMonitorError(w http.ResponseWriter, op string, id uint, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "monitor not found", http.StatusNotFound)
+		return
+	}
+	slog.Error("Failed to "+op+" monitor", "id", id, "error", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
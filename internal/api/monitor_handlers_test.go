@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestMonitorHandlers_Lifecycle(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/monitors", srv.handleListMonitors)
+	mux.HandleFunc("POST /api/monitors", srv.handleCreateMonitor)
+	mux.HandleFunc("GET /api/monitors/{id}", srv.handleGetMonitor)
+	mux.HandleFunc("PUT /api/monitors/{id}", srv.handleUpdateMonitor)
+	mux.HandleFunc("DELETE /api/monitors/{id}", srv.handleDeleteMonitor)
+	mux.HandleFunc("GET /api/monitors/{id}/results", srv.handleGetMonitorResults)
+	mux.HandleFunc("POST /api/alerts/rules", srv.handleCreateAlertRule)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/monitors", `{"name":"x","kind":"tcp","target":"db.internal"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("tcp monitor without port: want 400, got %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/monitors", `{"name":"checkout","kind":"http","target":"https://shop.example.com/health","service_name":"checkout"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	var created storage.Monitor
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == 0 || created.IntervalSecs != 60 || created.TenantID != storage.DefaultTenantID {
+		t.Errorf("created = %+v", created)
+	}
+	id := strconv.FormatUint(uint64(created.ID), 10)
+	path := "/api/monitors/" + id
+
+	rec = do(http.MethodPut, path, `{"name":"checkout","kind":"http","target":"https://shop.example.com/health","service_name":"checkout","expected_status":204,"interval_seconds":30}`)
+	var updated storage.Monitor
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &updated) != nil || updated.ExpectedStatus != 204 || updated.IntervalSecs != 30 {
+		t.Errorf("update: %d %s", rec.Code, rec.Body.String())
+	}
+
+	now := time.Now().UTC()
+	for i, up := range []bool{true, false, true, true} {
+		if err := repo.SaveMonitorResult(context.Background(), &created, &storage.MonitorResult{CheckedAt: now.Add(time.Duration(-i) * time.Minute), Up: up}); err != nil {
+			t.Fatalf("SaveMonitorResult: %v", err)
+		}
+	}
+	rec = do(http.MethodGet, path+"/results?limit=2", "")
+	var got struct {
+		Availability map[string]storage.MonitorAvailability `json:"availability"`
+		Results      []storage.MonitorResult                `json:"results"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil {
+		t.Fatalf("results: %d %s", rec.Code, rec.Body.String())
+	}
+	if len(got.Results) != 2 || got.Availability["24h"].Availability != 75 || got.Availability["30d"].Checks != 4 {
+		t.Errorf("results = %+v", got)
+	}
+
+	if rec := do(http.MethodPost, "/api/alerts/rules", `{"name":"down","kind":"monitor_down","monitor_id":999}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("rule on unknown monitor: want 400, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/alerts/rules", `{"name":"down","kind":"monitor_down","monitor_id":`+id+`,"threshold":3}`)
+	var rule storage.AlertRule
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &rule) != nil || rule.ServiceName != "checkout" {
+		t.Errorf("monitor_down rule: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: want 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, path+"/results", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("results after delete: want 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/monitors", ""); strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("list after delete = %s", rec.Body.String())
+	}
+}
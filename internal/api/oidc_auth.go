@@ -0,0 +1,352 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/oidc"
+)
+
+const (
+	sessionCookieName = "argus_session"
+	stateCookieName   = "argus_oidc_state"
+	// stateTTL bounds how long a sign-in may sit at the provider.
+	stateTTL = 10 * time.Minute
+	// loginPath is where unauthenticated browsers are sent.
+	loginPath = "/auth/login"
+)
+
+// SessionUser is the user behind a browser session.
+type SessionUser struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Role    Role   `json:"role"`
+	Expires int64  `json:"exp"` // unix seconds
+}
+
+// devUser is the session user under AUTH_DEV_BYPASS.
+var devUser = SessionUser{Subject: "dev", Email: "dev@localhost", Name: "Development user", Role: RoleAdmin}
+
+type sessionUserCtxKey struct{}
+
+// SessionUserFromContext returns the signed-in browser user on ctx, if the
+// request was authenticated by a session rather than an API key.
+func SessionUserFromContext(ctx context.Context) (SessionUser, bool) {
+	u, ok := ctx.Value(sessionUserCtxKey{}).(SessionUser)
+	return u, ok
+}
+
+// loginState is the state cookie carried across the provider redirect.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect"`
+	Expires  int64  `json:"exp"`
+}
+
+// OIDCOptions tunes an OIDCAuth.
+type OIDCOptions struct {
+	SessionSecret  string
+	SessionTTL     time.Duration
+	AllowedDomains []string // email domains allowed to sign in; empty allows any
+	SecureCookies  bool     // set when the UI is served over HTTPS
+	Roles          RoleMapping
+}
+
+// OIDCAuth signs browser users in with OpenID Connect and gates the UI,
+// /api/*, /ws* and MCP on their session. Sessions are stateless cookies
+// signed with the session secret, so every replica accepts them and
+// signing out only clears the browser's cookie. Requests carrying an API
+// key skip the session check when API keys are accepted.
+type OIDCAuth struct {
+	provider       *oidc.Provider // nil under the development bypass
+	secret         []byte
+	ttl            time.Duration
+	allowedDomains map[string]bool
+	secure         bool
+	devBypass      bool
+	acceptKeys     bool
+	roles          RoleMapping
+
+	now func() time.Time
+}
+
+// NewOIDCAuth returns an OIDCAuth signing users in at p.
+func NewOIDCAuth(p *oidc.Provider, opts OIDCOptions) *OIDCAuth {
+	a := &OIDCAuth{
+		provider: p,
+		secret:   []byte(opts.SessionSecret),
+		ttl:      opts.SessionTTL,
+		secure:   opts.SecureCookies,
+		roles:    opts.Roles,
+		now:      time.Now,
+	}
+	if len(opts.AllowedDomains) > 0 {
+		a.allowedDomains = make(map[string]bool, len(opts.AllowedDomains))
+		for _, d := range opts.AllowedDomains {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				a.allowedDomains[d] = true
+			}
+		}
+	}
+	return a
+}
+
+// NewDevBypassAuth returns an OIDCAuth that treats every request as signed
+// in by a local development user, for running the UI without a provider.
+func NewDevBypassAuth() *OIDCAuth {
+	return &OIDCAuth{devBypass: true, now: time.Now}
+}
+
+// SetAcceptAPIKeys lets requests with an Authorization header (or the /ws
+// access_token parameter) through to API key authentication instead of
+// requiring a session. Leave it off when no API key auth is configured,
+// or such requests would pass unauthenticated.
+func (a *OIDCAuth) SetAcceptAPIKeys(accept bool) {
+	a.acceptKeys = accept
+}
+
+// Gate routes requests on paths it protects to session when they carry a
+// valid session cookie whose role allows the request (403 otherwise), to
+// rest when they carry an API key and keys are accepted, and otherwise
+// rejects them: browsers navigating to the UI are redirected to sign in,
+// everything else gets 401. Paths it does not protect — OTLP, webhooks,
+// probes, metrics and /auth/* — go to rest.
+func (a *OIDCAuth) Gate(mcpPath string, session, rest http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sessionProtectedPath(r.URL.Path) || isCORSPreflight(r) {
+			rest.ServeHTTP(w, r)
+			return
+		}
+		if u, ok := a.sessionUser(r); ok {
+			if !u.Role.Allows(RequiredRole(r, mcpPath)) {
+				recordAuthFailure("insufficient_role")
+				writeForbidden(w)
+				return
+			}
+			session.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionUserCtxKey{}, u)))
+			return
+		}
+		if a.acceptKeys && (r.Header.Get("Authorization") != "" ||
+			(strings.HasPrefix(r.URL.Path, "/ws") && r.URL.Query().Get(liveStreamTokenParam) != "")) {
+			rest.ServeHTTP(w, r)
+			return
+		}
+		recordAuthFailure("no_session")
+		if wantsHTML(r) {
+			http.Redirect(w, r, loginPath+"?redirect="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+		writeUnauthorized(w)
+	})
+}
+
+// sessionProtectedPath reports whether path needs a session or API key.
+func sessionProtectedPath(path string) bool {
+	switch {
+	case strings.HasPrefix(path, "/v1/"), strings.HasPrefix(path, "/ingest/"), strings.HasPrefix(path, "/auth/"):
+		return false
+	case path == "/live", path == "/ready", path == "/api/health", strings.HasPrefix(path, "/metrics"):
+		return false
+	}
+	return true
+}
+
+// wantsHTML reports whether r is a browser page load rather than an API
+// call, so it can be redirected to sign in.
+func wantsHTML(r *http.Request) bool {
+	return r.Method == http.MethodGet &&
+		!strings.HasPrefix(r.URL.Path, "/api/") && !strings.HasPrefix(r.URL.Path, "/ws") &&
+		strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+func (a *OIDCAuth) sessionUser(r *http.Request) (SessionUser, bool) {
+	if a.devBypass {
+		return devUser, true
+	}
+	c, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return SessionUser{}, false
+	}
+	var u SessionUser
+	// Sessions without a valid role predate roles; they sign in again.
+	if !a.open("session", c.Value, &u) || a.now().Unix() >= u.Expires || u.Role.rank() == 0 {
+		return SessionUser{}, false
+	}
+	return u, true
+}
+
+// handleLogin handles GET /auth/login?redirect=<path> — sends the browser
+// to the provider, remembering where to return after sign-in.
+func (a *OIDCAuth) handleLogin(w http.ResponseWriter, r *http.Request) {
+	target := safeRedirect(r.URL.Query().Get("redirect"))
+	if a.devBypass {
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+	st := loginState{Redirect: target, Expires: a.now().Add(stateTTL).Unix()}
+	for _, v := range []*string{&st.State, &st.Nonce, &st.Verifier} {
+		s, err := oidc.RandomString(32)
+		if err != nil {
+			http.Error(w, "failed to start sign-in", http.StatusInternalServerError)
+			return
+		}
+		*v = s
+	}
+	a.setCookie(w, stateCookieName, a.seal("state", st), stateTTL)
+	http.Redirect(w, r, a.provider.AuthCodeURL(st.State, st.Nonce, st.Verifier), http.StatusFound)
+}
+
+// handleCallback handles GET /auth/callback — the provider's redirect back
+// with an authorization code. The code is exchanged, the ID token verified
+// against the nonce and allowed domains, the user's role resolved and a
+// session cookie issued.
+func (a *OIDCAuth) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if a.devBypass {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		recordAuthFailure("oidc_denied")
+		http.Error(w, "sign-in failed: "+e, http.StatusUnauthorized)
+		return
+	}
+	var st loginState
+	c, err := r.Cookie(stateCookieName)
+	if err != nil || !a.open("state", c.Value, &st) || a.now().Unix() >= st.Expires ||
+		subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(st.State)) != 1 {
+		recordAuthFailure("oidc_state")
+		http.Error(w, "sign-in expired or was started elsewhere; try again", http.StatusBadRequest)
+		return
+	}
+	a.setCookie(w, stateCookieName, "", -1)
+
+	claims, err := a.provider.Exchange(r.Context(), q.Get("code"), st.Verifier)
+	if err != nil {
+		recordAuthFailure("oidc_token")
+		slog.Warn("OIDC sign-in failed", "error", err)
+		http.Error(w, "sign-in failed", http.StatusUnauthorized)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(st.Nonce)) != 1 {
+		recordAuthFailure("oidc_token")
+		http.Error(w, "sign-in failed", http.StatusUnauthorized)
+		return
+	}
+	if !a.domainAllowed(claims) {
+		recordAuthFailure("oidc_domain")
+		slog.Warn("OIDC sign-in refused for email domain", "subject", claims.Subject, "email", claims.Email)
+		http.Error(w, "this account is not allowed to sign in", http.StatusForbidden)
+		return
+	}
+
+	// Unverified addresses could be anyone's, so they never match a role.
+	email := ""
+	if claims.EmailVerified {
+		email = claims.Email
+	}
+	role := a.roles.Resolve(email, claims.Groups)
+	if role.rank() == 0 {
+		recordAuthFailure("oidc_role")
+		slog.Warn("OIDC sign-in refused: no role", "subject", claims.Subject, "email", claims.Email)
+		http.Error(w, "this account has no role in Argus", http.StatusForbidden)
+		return
+	}
+
+	u := SessionUser{Subject: claims.Subject, Email: claims.Email, Name: claims.Name, Role: role, Expires: a.now().Add(a.ttl).Unix()}
+	a.setCookie(w, sessionCookieName, a.seal("session", u), a.ttl)
+	slog.Info("OIDC sign-in", "subject", u.Subject, "email", u.Email, "role", u.Role)
+	http.Redirect(w, r, st.Redirect, http.StatusFound)
+}
+
+// handleLogout handles GET and POST /auth/logout — clears the session and
+// continues to the provider's logout endpoint when it has one.
+func (a *OIDCAuth) handleLogout(w http.ResponseWriter, r *http.Request) {
+	a.setCookie(w, sessionCookieName, "", -1)
+	target := "/"
+	if a.provider != nil && a.provider.EndSessionURL() != "" {
+		target = a.provider.EndSessionURL()
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// handleMe handles GET /auth/me — the signed-in user, or 401.
+func (a *OIDCAuth) handleMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := a.sessionUser(r)
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(u)
+}
+
+func (a *OIDCAuth) domainAllowed(c *oidc.Claims) bool {
+	if len(a.allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndexByte(c.Email, '@')
+	return c.EmailVerified && at >= 0 && a.allowedDomains[strings.ToLower(c.Email[at+1:])]
+}
+
+func (a *OIDCAuth) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(ttl / time.Second),
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// seal encodes v and signs it for purpose, so a state cookie can never be
+// replayed as a session.
+func (a *OIDCAuth) seal(purpose string, v any) string {
+	payload, _ := json.Marshal(v)
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	return enc + "." + base64.RawURLEncoding.EncodeToString(a.mac(purpose, enc))
+}
+
+// open verifies a sealed value for purpose and decodes it into v.
+func (a *OIDCAuth) open(purpose, sealed string, v any) bool {
+	enc, sig, ok := strings.Cut(sealed, ".")
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, a.mac(purpose, enc)) {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	return err == nil && json.Unmarshal(payload, v) == nil
+}
+
+func (a *OIDCAuth) mac(purpose, enc string) []byte {
+	m := hmac.New(sha256.New, a.secret)
+	m.Write([]byte(purpose + "." + enc))
+	return m.Sum(nil)
+}
+
+// safeRedirect keeps post-sign-in redirects on this site: only absolute
+// paths are followed, anything else returns to the UI root.
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	return target
+}
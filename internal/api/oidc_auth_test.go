@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/oidc"
+)
+
+// newTestIdP starts an OpenID provider whose token endpoint issues an ID
+// token for email with the nonce of the last authorization request.
+func newTestIdP(t *testing.T, email *string, nonce *string) *httptest.Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer": srv.URL, "authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint": srv.URL + "/token", "jwks_uri": srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss": srv.URL, "aud": "argus", "sub": "user-1", "email": *email, "email_verified": true,
+			"name": "Ada", "groups": []string{"sre"}, "nonce": *nonce, "exp": time.Now().Add(time.Hour).Unix(),
+		})
+		tok.Header["kid"] = "k1"
+		raw, _ := tok.SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": raw})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOIDCAuth_SignInFlow(t *testing.T) {
+	email, nonce := "ada@example.com", ""
+	idp := newTestIdP(t, &email, &nonce)
+	p, err := oidc.Discover(context.Background(), oidc.Config{IssuerURL: idp.URL, ClientID: "argus", RedirectURL: "https://argus.test/auth/callback", GroupsClaim: "groups"})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	a := NewOIDCAuth(p, OIDCOptions{
+		SessionSecret:  strings.Repeat("k", 32),
+		SessionTTL:     time.Hour,
+		AllowedDomains: []string{"Example.com"},
+		Roles:          RoleMapping{Editors: []string{"SRE"}, Default: RoleViewer},
+	})
+
+	var seen SessionUser
+	session := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = SessionUserFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	rest := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	mux := http.NewServeMux()
+	srv := &Server{oidc: a}
+	mux.HandleFunc("GET /auth/login", srv.oidc.handleLogin)
+	mux.HandleFunc("GET /auth/callback", srv.oidc.handleCallback)
+	mux.HandleFunc("GET /auth/me", srv.oidc.handleMe)
+	mux.HandleFunc("POST /auth/logout", srv.oidc.handleLogout)
+	mux.Handle("/", a.Gate("/mcp", session, rest))
+
+	var jar []*http.Cookie
+	do := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		for _, c := range jar {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		for _, c := range rec.Result().Cookies() {
+			kept := jar[:0]
+			for _, old := range jar {
+				if old.Name != c.Name {
+					kept = append(kept, old)
+				}
+			}
+			jar = kept
+			if c.MaxAge >= 0 && c.Value != "" {
+				jar = append(jar, c)
+			}
+		}
+		return rec
+	}
+
+	// Without a session: API calls get 401, page loads are sent to sign in,
+	// OTLP and probes are left to the rest of the chain.
+	if rec := do(http.MethodGet, "/api/traces", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("API without session = %d, want 401", rec.Code)
+	}
+	rec := do(http.MethodGet, "/traces?id=1", http.Header{"Accept": {"text/html"}})
+	if loc := rec.Header().Get("Location"); rec.Code != http.StatusFound || loc != "/auth/login?redirect=%2Ftraces%3Fid%3D1" {
+		t.Errorf("page without session = %d %q", rec.Code, loc)
+	}
+	for _, path := range []string{"/v1/traces", "/live", "/metrics/prometheus"} {
+		if rec := do(http.MethodPost, path, nil); rec.Code != http.StatusTeapot {
+			t.Errorf("%s = %d, want it passed to the rest of the chain", path, rec.Code)
+		}
+	}
+	bearer := http.Header{"Authorization": {"Bearer k"}}
+	if rec := do(http.MethodGet, "/api/traces", bearer); rec.Code != http.StatusUnauthorized {
+		t.Errorf("API key without accepted keys = %d, want 401", rec.Code)
+	}
+	a.SetAcceptAPIKeys(true)
+	if rec := do(http.MethodGet, "/api/traces", bearer); rec.Code != http.StatusTeapot {
+		t.Errorf("API key = %d, want API key auth", rec.Code)
+	}
+
+	// Sign in.
+	rec = do(http.MethodGet, "/auth/login?redirect=/traces", nil)
+	authURL, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || rec.Code != http.StatusFound || !strings.HasPrefix(authURL.String(), idp.URL+"/authorize") {
+		t.Fatalf("login = %d %q", rec.Code, authURL)
+	}
+	nonce = authURL.Query().Get("nonce")
+	state := authURL.Query().Get("state")
+	if rec := do(http.MethodGet, "/auth/callback?code=good-code&state=forged", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("callback with a forged state = %d, want 400", rec.Code)
+	}
+	rec = do(http.MethodGet, "/auth/callback?code=good-code&state="+state, nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/traces" {
+		t.Fatalf("callback = %d %q: %s", rec.Code, rec.Header().Get("Location"), rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/api/traces", nil); rec.Code != http.StatusOK || seen.Email != "ada@example.com" {
+		t.Errorf("API with session = %d, user %+v", rec.Code, seen)
+	}
+	// The sre group makes this user an editor.
+	if rec := do(http.MethodPut, "/api/views/ops", nil); rec.Code != http.StatusOK {
+		t.Errorf("editor saving a view = %d, want 200", rec.Code)
+	}
+	if rec := do(http.MethodPatch, "/api/config", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("editor changing settings = %d, want 403", rec.Code)
+	}
+	var me SessionUser
+	if rec := do(http.MethodGet, "/auth/me", nil); json.Unmarshal(rec.Body.Bytes(), &me) != nil || me.Subject != "user-1" || me.Role != RoleEditor {
+		t.Errorf("/auth/me = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/auth/callback?code=good-code&state="+state, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("replayed callback = %d, want 400", rec.Code)
+	}
+
+	// Sessions expire, and tampered cookies are refused.
+	a.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if rec := do(http.MethodGet, "/api/traces", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expired session = %d, want 401", rec.Code)
+	}
+	a.now = time.Now
+	for _, c := range jar {
+		if c.Name == sessionCookieName {
+			c.Value = strings.Replace(c.Value, ".", "x.", 1)
+		}
+	}
+	if rec := do(http.MethodGet, "/api/traces", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("tampered session = %d, want 401", rec.Code)
+	}
+
+	// Accounts without a role or outside the allowed domains cannot sign in.
+	signIn := func() int {
+		rec := do(http.MethodGet, "/auth/login", nil)
+		authURL, _ := url.Parse(rec.Header().Get("Location"))
+		nonce = authURL.Query().Get("nonce")
+		return do(http.MethodGet, "/auth/callback?code=good-code&state="+authURL.Query().Get("state"), nil).Code
+	}
+	a.roles = RoleMapping{Admins: []string{"ops"}}
+	if code := signIn(); code != http.StatusForbidden {
+		t.Errorf("no role = %d, want 403", code)
+	}
+	a.roles = RoleMapping{Default: RoleViewer}
+	email = "eve@elsewhere.test"
+	if code := signIn(); code != http.StatusForbidden {
+		t.Errorf("disallowed domain = %d, want 403", code)
+	}
+
+	if rec := do(http.MethodPost, "/auth/logout", nil); rec.Code != http.StatusFound {
+		t.Errorf("logout = %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/auth/me", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("/auth/me after logout = %d, want 401", rec.Code)
+	}
+}
+
+func TestOIDCAuth_DevBypass(t *testing.T) {
+	var seen SessionUser
+	h := NewDevBypassAuth().Gate("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = SessionUserFromContext(r.Context())
+	}), okHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/traces", nil))
+	if seen.Subject != devUser.Subject || seen.Role != RoleAdmin {
+		t.Errorf("dev bypass user = %+v", seen)
+	}
+}
+
+func TestSafeRedirect(t *testing.T) {
+	for in, want := range map[string]string{
+		"/traces?id=1":      "/traces?id=1",
+		"":                  "/",
+		"//evil.test/x":     "/",
+		"/\\evil.test":      "/",
+		"https://evil.test": "/",
+	} {
+		if got := safeRedirect(in); got != want {
+			t.Errorf("safeRedirect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
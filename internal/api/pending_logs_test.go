@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/queue"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// TestHandleGetLogs_MergesPendingDLQLogs verifies logs still queued in the
+// DLQ show up in recent log queries marked pending, filtered like stored
+// ones, and only when the merge is enabled.
+func TestHandleGetLogs_MergesPendingDLQLogs(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now().UTC()
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "ERROR", Body: "stored", Timestamp: now.Add(-2 * time.Minute)},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	q, err := queue.NewDLQ(t.TempDir(), time.Hour, func([]byte) error { return nil })
+	if err != nil {
+		t.Fatalf("NewDLQ: %v", err)
+	}
+	defer q.Stop()
+	for _, batch := range []any{
+		map[string]any{"type": "logs", "data": []storage.Log{
+			{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "ERROR", Body: "queued", Timestamp: now.Add(-time.Minute)},
+			{TenantID: storage.DefaultTenantID, ServiceName: "cart", Severity: "INFO", Body: "wrong severity", Timestamp: now},
+			{TenantID: "acme", ServiceName: "cart", Severity: "ERROR", Body: "other tenant", Timestamp: now},
+		}},
+		map[string]any{"type": "batch", "data": map[string]any{"logs": []storage.Log{
+			{ServiceName: "cart", Severity: "ERROR", Body: "spilled", Timestamp: now.Add(-3 * time.Minute)},
+		}}},
+		map[string]any{"type": "spans", "data": []storage.Span{{SpanID: "s1"}}},
+	} {
+		if err := q.Enqueue(batch); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	get := func(srv *Server, query string) (out struct {
+		Data  []views.Log `json:"data"`
+		Total int64       `json:"total"`
+	}) {
+		rec := httptest.NewRecorder()
+		srv.handleGetLogs(rec, httptest.NewRequest(http.MethodGet, "/api/logs?"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return out
+	}
+
+	srv := &Server{repo: repo, dlq: q}
+	if got := get(srv, "severity=ERROR"); got.Total != 1 || len(got.Data) != 1 {
+		t.Fatalf("merge off: %+v", got)
+	}
+
+	srv.SetDLQReadMerge(15 * time.Minute)
+	got := get(srv, "severity=ERROR")
+	var bodies []string
+	for _, l := range got.Data {
+		bodies = append(bodies, l.Body)
+		if l.Pending != (l.Body != "stored") {
+			t.Errorf("%q pending = %v", l.Body, l.Pending)
+		}
+	}
+	if got.Total != 3 || len(bodies) != 3 || bodies[0] != "queued" || bodies[1] != "stored" || bodies[2] != "spilled" {
+		t.Errorf("merged = %v total %d, want [queued stored spilled] total 3", bodies, got.Total)
+	}
+	if got := get(srv, "severity=ERROR&limit=2"); len(got.Data) != 2 || got.Total != 3 {
+		t.Errorf("limit 2: %d rows total %d", len(got.Data), got.Total)
+	}
+	if got := get(srv, "severity=ERROR&offset=1"); got.Total != 1 {
+		t.Errorf("second page total = %d, want stored rows only", got.Total)
+	}
+	old := now.Add(-time.Hour).Format(time.RFC3339)
+	if got := get(srv, "severity=ERROR&end="+old); got.Total != 0 {
+		t.Errorf("query before the window = %+v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Role is what a signed-in user or API key may do outside ingestion. Each
+// role includes the ones before it.
+type Role string
+
+const (
+	// RoleViewer reads traces, logs, metrics and the live streams.
+	RoleViewer Role = "viewer"
+	// RoleEditor also manages saved views, alert rules, SLOs, uptime
+	// monitors and triage, including issues, registers deployments and
+	// rehydrates tiered logs.
+	RoleEditor Role = "editor"
+	// RoleAdmin also manages API keys, retention, runtime settings and
+	// everything under /api/admin/.
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole returns the role named s, or false when s is not one.
+func ParseRole(s string) (Role, bool) {
+	r := Role(strings.ToLower(strings.TrimSpace(s)))
+	return r, r.rank() > 0
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Allows reports whether r may make a request needing need.
+func (r Role) Allows(need Role) bool {
+	return r.rank() > 0 && r.rank() >= need.rank()
+}
+
+// editorPaths are the resources editors may change; other mutations are
+// admin-only.
+var editorPaths = []string{
+	"/api/views",
+	"/api/alerts/rules",
+	"/api/slos",
+	"/api/monitors",
+	"/api/errors/bulk",
+	"/api/issues",
+	"/api/anomalies/bulk",
+	"/api/deployments",
+	"/api/cold/rehydrations",
+}
+
+// RequiredRole returns the least role a request needs: viewer for reads
+// (including POST /api/search, the Grafana datasource, MCP and the live
+// streams), editor for
+// changes to editorPaths, and admin for /api/keys, /api/admin/* and any
+// other mutation, such as PATCH /api/config.
+func RequiredRole(r *http.Request, mcpPath string) Role {
+	path := r.URL.Path
+	switch {
+	case path == "/api/keys", strings.HasPrefix(path, "/api/keys/"), strings.HasPrefix(path, "/api/admin/"):
+		return RoleAdmin
+	case mcpPath != "" && (path == mcpPath || strings.HasPrefix(path, mcpPath+"/")):
+		return RoleViewer
+	case r.Method == http.MethodGet, r.Method == http.MethodHead, path == "/api/search", strings.HasPrefix(path, "/api/grafana/"):
+		return RoleViewer
+	}
+	for _, p := range editorPaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return RoleEditor
+		}
+	}
+	return RoleAdmin
+}
+
+// scopeForRole is the API key scope that acts as role.
+func scopeForRole(role Role) string {
+	switch role {
+	case RoleViewer:
+		return storage.APIKeyScopeRead
+	case RoleEditor:
+		return storage.APIKeyScopeWrite
+	}
+	return storage.APIKeyScopeAdmin
+}
+
+// roleForScope is the role an API key scope acts as; ingest keys have none.
+func roleForScope(scope string) Role {
+	switch scope {
+	case storage.APIKeyScopeRead:
+		return RoleViewer
+	case storage.APIKeyScopeWrite:
+		return RoleEditor
+	case storage.APIKeyScopeAdmin:
+		return RoleAdmin
+	}
+	return ""
+}
+
+// RoleMapping assigns roles to signed-in users from their email address or
+// the groups in their ID token. Entries match case-insensitively; the
+// highest matching role wins and users matching nothing get Default, which
+// may be empty to refuse them.
+type RoleMapping struct {
+	Admins  []string
+	Editors []string
+	Default Role
+}
+
+// Resolve returns the role of a user with email and groups.
+func (m RoleMapping) Resolve(email string, groups []string) Role {
+	matches := func(entries []string) bool {
+		for _, e := range entries {
+			if e = strings.TrimSpace(e); e == "" {
+				continue
+			}
+			if strings.EqualFold(e, email) {
+				return true
+			}
+			for _, g := range groups {
+				if strings.EqualFold(e, g) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch {
+	case matches(m.Admins):
+		return RoleAdmin
+	case matches(m.Editors):
+		return RoleEditor
+	}
+	return m.Default
+}
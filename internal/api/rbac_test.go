@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequiredRole(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         Role
+	}{
+		{http.MethodGet, "/api/traces", RoleViewer},
+		{http.MethodPost, "/api/search", RoleViewer},
+		{http.MethodPost, "/api/grafana/query", RoleViewer},
+		{http.MethodPost, "/mcp", RoleViewer},
+		{http.MethodGet, "/ws/events", RoleViewer},
+		{http.MethodPost, "/api/views", RoleEditor},
+		{http.MethodDelete, "/api/views/ops", RoleEditor},
+		{http.MethodPost, "/api/alerts/rules", RoleEditor},
+		{http.MethodPut, "/api/slos/4", RoleEditor},
+		{http.MethodPost, "/api/errors/bulk", RoleEditor},
+		{http.MethodDelete, "/api/cold/rehydrations/2", RoleEditor},
+		{http.MethodPost, "/api/viewsx", RoleAdmin},
+		{http.MethodPatch, "/api/config", RoleAdmin},
+		{http.MethodGet, "/api/keys", RoleAdmin},
+		{http.MethodGet, "/api/admin/storage", RoleAdmin},
+		{http.MethodDelete, "/api/admin/purge", RoleAdmin},
+	}
+	for _, tc := range cases {
+		if got := RequiredRole(httptest.NewRequest(tc.method, tc.path, nil), "/mcp"); got != tc.want {
+			t.Errorf("%s %s = %q, want %q", tc.method, tc.path, got, tc.want)
+		}
+	}
+	if RoleEditor.Allows(RoleAdmin) || !RoleEditor.Allows(RoleViewer) || Role("").Allows(RoleViewer) {
+		t.Error("role ordering broken")
+	}
+}
+
+func TestRoleMapping_Resolve(t *testing.T) {
+	m := RoleMapping{Admins: []string{"root@example.com", "platform"}, Editors: []string{"SRE"}, Default: RoleViewer}
+	cases := []struct {
+		email  string
+		groups []string
+		want   Role
+	}{
+		{"Root@Example.com", nil, RoleAdmin},
+		{"ada@example.com", []string{"sre", "platform"}, RoleAdmin},
+		{"ada@example.com", []string{"sre"}, RoleEditor},
+		{"ada@example.com", nil, RoleViewer},
+		{"", []string{""}, RoleViewer},
+	}
+	for _, tc := range cases {
+		if got := m.Resolve(tc.email, tc.groups); got != tc.want {
+			t.Errorf("Resolve(%q, %v) = %q, want %q", tc.email, tc.groups, got, tc.want)
+		}
+	}
+	if got := (RoleMapping{}).Resolve("ada@example.com", nil); got != "" {
+		t.Errorf("no default = %q, want no role", got)
+	}
+	if r, ok := ParseRole(" Editor "); !ok || r != RoleEditor {
+		t.Errorf("ParseRole = %q, %v", r, ok)
+	}
+	if _, ok := ParseRole("owner"); ok {
+		t.Error("ParseRole accepted an unknown role")
+	}
+}
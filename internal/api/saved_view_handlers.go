@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+// maxSavedViewBody bounds saved view request bodies; dashboard layouts are
+// the bulk of it.
+const maxSavedViewBody = 256 << 10
+
+var (
+	savedViewSlugRe   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,127}$`)
+	savedViewSlugTrim = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// handleListSavedViews handles GET /api/views?kind=filter|dashboard
+func (s *Server) handleListSavedViews(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != storage.SavedViewKindFilter && kind != storage.SavedViewKindDashboard {
+		http.Error(w, "kind must be filter or dashboard", http.StatusBadRequest)
+		return
+	}
+	views, err := s.repo.ListSavedViews(r.Context(), kind)
+	if err != nil {
+		slog.Error("Failed to list saved views", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if views == nil {
+		views = []storage.SavedView{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(views)
+}
+
+// handleGetSavedView handles GET /api/views/{slug}
+func (s *Server) handleGetSavedView(w http.ResponseWriter, r *http.Request) {
+	v, err := s.repo.GetSavedView(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		writeSavedViewError(w, "load", err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleCreateSavedView handles POST /api/views. Without a slug one is
+// derived from the name, suffixed until it is free.
+func (s *Server) handleCreateSavedView(w http.ResponseWriter, r *http.Request) {
+	var v storage.SavedView
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSavedViewBody)).Decode(&v); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if v.Kind == "" {
+		v.Kind = storage.SavedViewKindFilter
+	}
+	derived := v.Slug == ""
+	if derived {
+		v.Slug = slugify(v.Name)
+	} else if !savedViewSlugRe.MatchString(v.Slug) {
+		http.Error(w, "slug must be lowercase letters, digits and dashes (at most 128)", http.StatusBadRequest)
+		return
+	}
+	if err := validateSavedView(&v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.CreateSavedView(r.Context(), &v, derived); err != nil {
+		writeSavedViewError(w, "create", err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// handleUpdateSavedView handles PUT /api/views/{slug}. The slug and kind are
+// fixed at creation; the body replaces everything else.
+func (s *Server) handleUpdateSavedView(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	var v storage.SavedView
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSavedViewBody)).Decode(&v); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	existing, err := s.repo.GetSavedView(r.Context(), slug)
+	if err != nil {
+		writeSavedViewError(w, "load", err)
+		return
+	}
+	if v.Kind != "" && v.Kind != existing.Kind {
+		http.Error(w, "kind cannot be changed", http.StatusBadRequest)
+		return
+	}
+	v.Kind = existing.Kind
+	if err := validateSavedView(&v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	updated, err := s.repo.UpdateSavedView(r.Context(), slug, &v)
+	if err != nil {
+		writeSavedViewError(w, "update", err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(updated)
+}
+
+// handleDeleteSavedView handles DELETE /api/views/{slug}
+func (s *Server) handleDeleteSavedView(w http.ResponseWriter, r *http.Request) {
+	if err := s.repo.DeleteSavedView(r.Context(), r.PathValue("slug")); err != nil {
+		writeSavedViewError(w, "delete", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSavedViewError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "saved view not found", http.StatusNotFound)
+	case errors.Is(err, storage.ErrSavedViewSlugTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		slog.Error("Failed to "+op+" saved view", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// slugify derives a URL slug from a view name: lowercase ASCII letters and
+// digits joined by dashes.
+func slugify(name string) string {
+	slug := strings.Trim(savedViewSlugTrim.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 120 { // room for a "-NN" suffix
+		slug = strings.TrimRight(slug[:120], "-")
+	}
+	if slug == "" {
+		return "view"
+	}
+	return slug
+}
+
+func validateSavedView(v *storage.SavedView) error {
+	v.Name = strings.TrimSpace(v.Name)
+	switch {
+	case v.Name == "":
+		return fmt.Errorf("name is required")
+	case len(v.Name) > 255:
+		return fmt.Errorf("name must be at most 255 characters")
+	}
+	if string(v.Layout) == "null" {
+		v.Layout = nil
+	}
+	switch v.Kind {
+	case storage.SavedViewKindFilter:
+		if len(v.Layout) > 0 {
+			return fmt.Errorf("layout is only valid for dashboard views")
+		}
+	case storage.SavedViewKindDashboard:
+	default:
+		return fmt.Errorf("kind must be filter or dashboard")
+	}
+
+	f := &v.Filters
+	if f.Range != "" {
+		if d, err := time.ParseDuration(f.Range); err != nil || d <= 0 {
+			return fmt.Errorf("filters.range must be a positive duration such as 15m or 24h")
+		}
+		if f.Start != "" || f.End != "" {
+			return fmt.Errorf("filters.range cannot be combined with filters.start/end")
+		}
+	}
+	var start, end time.Time
+	for _, t := range []struct {
+		name, value string
+		into        *time.Time
+	}{{"start", f.Start, &start}, {"end", f.End, &end}} {
+		if t.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			return fmt.Errorf("filters.%s must be RFC 3339", t.name)
+		}
+		*t.into = parsed
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return fmt.Errorf("filters.start must be before filters.end")
+	}
+	if len(f.Status) > 64 || len(f.Query) > 4096 {
+		return fmt.Errorf("filters.status or filters.query too long")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestSavedViewHandlers_Lifecycle(t *testing.T) {
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/views", srv.handleListSavedViews)
+	mux.HandleFunc("POST /api/views", srv.handleCreateSavedView)
+	mux.HandleFunc("GET /api/views/{slug}", srv.handleGetSavedView)
+	mux.HandleFunc("PUT /api/views/{slug}", srv.handleUpdateSavedView)
+	mux.HandleFunc("DELETE /api/views/{slug}", srv.handleDeleteSavedView)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) storage.SavedView {
+		t.Helper()
+		var v storage.SavedView
+		if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+			t.Fatalf("decode %q: %v", rec.Body.String(), err)
+		}
+		return v
+	}
+
+	for body, want := range map[string]int{
+		`{"name":""}`:                            http.StatusBadRequest,
+		`{"name":"x","kind":"report"}`:           http.StatusBadRequest,
+		`{"name":"x","slug":"Not A Slug"}`:       http.StatusBadRequest,
+		`{"name":"x","filters":{"range":"-5m"}}`: http.StatusBadRequest,
+		`{"name":"x","filters":{"range":"1h","start":"2026-01-01T00:00:00Z"}}`:                 http.StatusBadRequest,
+		`{"name":"x","filters":{"start":"2026-01-02T00:00:00Z","end":"2026-01-01T00:00:00Z"}}`: http.StatusBadRequest,
+		`{"name":"x","layout":{"panels":[]}}`:                                                  http.StatusBadRequest,
+	} {
+		if rec := do(http.MethodPost, "/api/views", body); rec.Code != want {
+			t.Errorf("%s: status %d, want %d", body, rec.Code, want)
+		}
+	}
+
+	rec := do(http.MethodPost, "/api/views", `{"name":"Checkout errors!","filters":{"range":"1h","services":["checkout"],"status":"ERROR","query":"timeout"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d body %q", rec.Code, rec.Body.String())
+	}
+	created := decode(rec)
+	if created.Slug != "checkout-errors" || created.Kind != storage.SavedViewKindFilter || created.Layout != nil {
+		t.Errorf("created = %+v", created)
+	}
+	if again := decode(do(http.MethodPost, "/api/views", `{"name":"checkout errors"}`)); again.Slug != "checkout-errors-2" {
+		t.Errorf("second derived slug = %q, want checkout-errors-2", again.Slug)
+	}
+	if rec := do(http.MethodPost, "/api/views", `{"name":"dup","slug":"checkout-errors"}`); rec.Code != http.StatusConflict {
+		t.Errorf("explicit duplicate slug: status %d, want 409", rec.Code)
+	}
+
+	rec = do(http.MethodPost, "/api/views", `{"name":"SRE board","slug":"sre","kind":"dashboard","layout":{"panels":[{"type":"traffic","w":6}]}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create dashboard: status %d body %q", rec.Code, rec.Body.String())
+	}
+	got := decode(do(http.MethodGet, "/api/views/sre", ""))
+	if got.Kind != storage.SavedViewKindDashboard || string(got.Layout) != `{"panels":[{"type":"traffic","w":6}]}` {
+		t.Errorf("dashboard = %+v layout %s", got, got.Layout)
+	}
+
+	var dashboards []storage.SavedView
+	_ = json.Unmarshal(do(http.MethodGet, "/api/views?kind=dashboard", "").Body.Bytes(), &dashboards)
+	if len(dashboards) != 1 || dashboards[0].Slug != "sre" {
+		t.Errorf("dashboards = %+v", dashboards)
+	}
+
+	if rec := do(http.MethodPut, "/api/views/sre", `{"name":"x","kind":"filter"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("kind change: status %d, want 400", rec.Code)
+	}
+	rec = do(http.MethodPut, "/api/views/checkout-errors", `{"name":"Checkout 5xx","filters":{"range":"24h"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update: status %d body %q", rec.Code, rec.Body.String())
+	}
+	if updated := decode(rec); updated.Name != "Checkout 5xx" || updated.Slug != "checkout-errors" || updated.Filters.Range != "24h" || len(updated.Filters.Services) != 0 {
+		t.Errorf("updated = %+v", updated)
+	}
+	if rec := do(http.MethodPut, "/api/views/missing", `{"name":"x"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("update missing: status %d, want 404", rec.Code)
+	}
+
+	other := httptest.NewRecorder()
+	mux.ServeHTTP(other, httptest.NewRequest(http.MethodGet, "/api/views/sre", nil).WithContext(storage.WithTenantContext(t.Context(), "acme")))
+	if other.Code != http.StatusNotFound {
+		t.Errorf("other tenant: status %d, want 404", other.Code)
+	}
+
+	if rec := do(http.MethodDelete, "/api/views/sre", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: status %d, want 204", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/views/sre", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get deleted: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/argusql"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// maxSearchLimit caps the results a caller can ask for in one search.
+	maxSearchLimit = 500
+	// maxSearchBody caps a POST /api/search body.
+	maxSearchBody = 2 * argusql.MaxFilterLength
+)
+
+// searchRequest is the POST /api/search body; GET takes the same fields
+// as query parameters, with filter as JSON text.
+type searchRequest struct {
+	Q      string          `json:"q"`
+	Filter json.RawMessage `json:"filter"`
+	Signal string          `json:"signal"`
+	Start  time.Time       `json:"start"`
+	End    time.Time       `json:"end"`
+	Limit  int             `json:"limit"`
+}
+
+// handleSearch handles GET /api/search — one ArgusQL query over traces,
+// logs or metrics, e.g. ?q=service="payment-service" AND duration>500ms.
+// Query params: q or filter (the query as an argusql.Filter JSON tree;
+// exactly one is required), signal (traces | logs | metrics, default
+// traces), start, end (RFC3339; default last 1h), limit. Query mistakes are
+// 400s carrying the parser's message.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	req := searchRequest{
+		Q:      r.URL.Query().Get("q"),
+		Signal: r.URL.Query().Get("signal"),
+	}
+	if f := r.URL.Query().Get("filter"); f != "" {
+		req.Filter = json.RawMessage(f)
+	}
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	req.Start, req.End = start, end
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if req.Limit, err = strconv.Atoi(l); err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	s.runSearch(w, r, req)
+}
+
+// handlePostSearch handles POST /api/search — handleSearch with a JSON
+// searchRequest body, so a filter tree needs no URL encoding.
+func (s *Server) handlePostSearch(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSearchBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	s.runSearch(w, r, req)
+}
+
+func (s *Server) runSearch(w http.ResponseWriter, r *http.Request, req searchRequest) {
+	q := storage.SearchQuery{Query: req.Q, Signal: req.Signal, Start: req.Start, End: req.End}
+	switch {
+	case q.Query != "" && len(req.Filter) > 0:
+		http.Error(w, "give q or filter, not both", http.StatusBadRequest)
+		return
+	case len(req.Filter) > 0:
+		expr, err := argusql.ParseFilter(req.Filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Filter = expr
+	case q.Query == "":
+		http.Error(w, "missing q or filter", http.StatusBadRequest)
+		return
+	}
+	// Log searches are keyword scans over bodies: same 24h cap as /api/logs,
+	// keeping the 1h default window.
+	if q.Signal == storage.SearchSignalLogs {
+		now := time.Now()
+		if q.Start.IsZero() {
+			ref := q.End
+			if ref.IsZero() || ref.After(now) {
+				ref = now
+			}
+			q.Start = ref.Add(-1 * time.Hour)
+		}
+		cs, ce, err := storage.ClampSearchWindowTo24h(q.Start, q.End, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Start, q.End = cs, ce
+	}
+	if req.Limit < 0 {
+		http.Error(w, "invalid limit", http.StatusBadRequest)
+		return
+	}
+	q.Limit = min(req.Limit, maxSearchLimit)
+
+	resp, err := s.repo.Search(regionFilter(r), q)
+	if errors.Is(err, storage.ErrInvalidSearchQuery) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to run search", "signal", q.Signal, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(views.SearchResultFromModel(resp))
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleSearch(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	if err := repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, ServiceName: "payment-service", Severity: "ERROR", Body: "card declined", Timestamp: time.Now().Add(-time.Minute)},
+		{TenantID: storage.DefaultTenantID, ServiceName: "payment-service", Severity: "INFO", Body: "card accepted", Timestamp: time.Now().Add(-time.Minute)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/search", srv.handleSearch)
+	mux.HandleFunc("POST /api/search", srv.handlePostSearch)
+	search := func(params url.Values) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?"+params.Encode(), nil))
+		return rec
+	}
+
+	rec := search(url.Values{"q": {`service="payment-service" AND severity>=warn`}, "signal": {"logs"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	var got views.SearchResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Matched != 1 || len(got.Logs) != 1 || got.Logs[0].Body != "card declined" || got.Traces == nil {
+		t.Errorf("result = %+v", got)
+	}
+
+	// The same query as a filter tree, by GET and by POST.
+	filter := `{"and":[{"field":"service","op":"=","value":"payment-service"},{"field":"severity","op":">=","value":"warn"}]}`
+	rec = search(url.Values{"filter": {filter}, "signal": {"logs"}})
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Matched != 1 {
+		t.Errorf("GET filter: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"signal":"logs","limit":5,"filter":`+filter+`}`)))
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Matched != 1 || got.Metrics == nil {
+		t.Errorf("POST filter: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"filter":{"field":"service"}}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "unknown op") {
+		t.Errorf("POST bad filter: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for name, params := range map[string]url.Values{
+		"missing q":     {},
+		"q and filter":  {"q": {`service=x`}, "filter": {filter}},
+		"syntax error":  {"q": {`service=`}},
+		"unknown field": {"q": {`colour=red`}},
+		"bad signal":    {"q": {`service=x`}, "signal": {"events"}},
+		"bad limit":     {"q": {`service=x`}, "limit": {"x"}},
+		"old log window": {"q": {`body~x`}, "signal": {"logs"},
+			"start": {time.Now().Add(-72 * time.Hour).Format(time.RFC3339)},
+			"end":   {time.Now().Add(-48 * time.Hour).Format(time.RFC3339)}},
+	} {
+		if rec := search(params); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, rec.Code)
+		} else if name == "syntax error" && !strings.Contains(rec.Body.String(), "position 8") {
+			t.Errorf("syntax error body = %q, want the position", rec.Body.String())
+		}
+	}
+}
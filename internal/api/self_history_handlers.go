@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// defaultSelfHistoryWindow is how far back GET /api/admin/history looks
+// when no start is given.
+const defaultSelfHistoryWindow = 7 * 24 * time.Hour
+
+// handleGetSelfHistory handles GET /api/admin/history?kind=&start=&end=&limit=N
+// — this instance's runs, downtime, config changes, database outages and
+// ingest gaps overlapping the window (default the last 7 days), newest
+// first. Outages and gaps still in progress lead with a null ended_at.
+// Instance-wide: the same for every tenant.
+func (s *Server) handleGetSelfHistory(w http.ResponseWriter, r *http.Request) {
+	if s.history == nil {
+		http.Error(w, "self history is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if start.IsZero() {
+		start = time.Now().Add(-defaultSelfHistoryWindow)
+	}
+	kind := r.URL.Query().Get("kind")
+	switch kind {
+	case "", storage.SelfEventRun, storage.SelfEventStop, storage.SelfEventDowntime,
+		storage.SelfEventConfig, storage.SelfEventDBOutage, storage.SelfEventIngestGap:
+	default:
+		http.Error(w, "unknown kind", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	events, err := s.history.Events(r.Context(), kind, start, end, limit)
+	if err != nil {
+		slog.Error("Failed to list self history", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []storage.SelfEvent{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(events)
+}
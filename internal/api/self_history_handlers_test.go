@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
+	"github.com/RandomCodeSpace/otelcontext/internal/selfhistory"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleGetSelfHistory(t *testing.T) {
+	repo := newAPITestRepoWithFTS(t)
+	rec := selfhistory.New(repo, "v1", time.Hour)
+	if err := rec.Boot(context.Background(), map[string]string{"A": "1"}); err != nil {
+		t.Fatalf("Boot: %v", err)
+	}
+	srv := &Server{repo: repo, flags: featureflag.New(), history: rec}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/admin/history", srv.handleGetSelfHistory)
+	mux.HandleFunc("PUT /api/admin/flags/{name}", srv.handleSetFlag)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	// A runtime flag flip lands in the history as a config change.
+	if w := do(http.MethodPut, "/api/admin/flags/"+featureflag.AIInsights, `{"enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT flag = %d", w.Code)
+	}
+	w := do(http.MethodGet, "/api/admin/history", "")
+	var events []storage.SelfEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d body = %s", w.Code, w.Body.String())
+	}
+	if len(events) != 2 || events[0].Kind != storage.SelfEventConfig || !strings.Contains(events[0].Detail, featureflag.AIInsights) ||
+		events[1].Kind != storage.SelfEventRun {
+		t.Errorf("events = %+v", events)
+	}
+	w = do(http.MethodGet, "/api/admin/history?kind="+storage.SelfEventRun, "")
+	if err := json.Unmarshal(w.Body.Bytes(), &events); err != nil || len(events) != 1 {
+		t.Errorf("kind=run = %s", w.Body.String())
+	}
+
+	if w := do(http.MethodGet, "/api/admin/history?kind=reboot", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown kind = %d, want 400", w.Code)
+	}
+	srv.history = nil
+	if w := do(http.MethodGet, "/api/admin/history", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("no recorder = %d, want 503", w.Code)
+	}
+}
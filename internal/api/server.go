@@ -4,12 +4,19 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/ai"
 	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/coldtier"
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
 	"github.com/RandomCodeSpace/otelcontext/internal/graph"
 	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
+	"github.com/RandomCodeSpace/otelcontext/internal/queue"
 	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/selfhistory"
 	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	"github.com/RandomCodeSpace/otelcontext/internal/topk"
 	"github.com/RandomCodeSpace/otelcontext/internal/vectordb"
 )
 
@@ -23,6 +30,14 @@ type Server struct {
 	graph     *graph.Graph       // in-memory service dependency graph (may be nil before first build)
 	graphRAG  *graphrag.GraphRAG // layered GraphRAG for advanced queries
 	vectorIdx *vectordb.Index    // TF-IDF semantic log search index
+	ai        *ai.Service        // natural language search; nil or disabled = 503
+
+	traceStreams *realtime.TraceStreams // live trace assembly; nil = 503
+	topK         *topk.Tracker          // heavy hitters behind /api/top; nil = 503
+	dlq          *queue.DeadLetterQueue // behind /api/admin/dlq; nil = 503
+
+	pendingReadWindow  time.Duration // DLQ logs merged into /api/logs; 0 = off
+	deployImpactWindow time.Duration // each side of GET /api/deployments/{id}/impact; 0 = 30m
 
 	// Saturation probes consulted by /ready. Each returns a fullness
 	// fraction in [0.0, 1.0]; nil disables the corresponding check.
@@ -30,6 +45,17 @@ type Server struct {
 	// imports and lets tests inject deterministic values.
 	dlqSaturation      func() float64
 	pipelineSaturation func() float64
+
+	apiKeys *APIKeyAuth // managed API keys; nil leaves /api/keys unregistered
+	oidc    *OIDCAuth   // browser sign-in; nil leaves /auth/* unregistered
+
+	cfg   *config.Config        // feature flags and retention for /api/bootstrap; nil reports defaults
+	flags *featureflag.Registry // behind /api/admin/flags; nil = 503
+	live  *config.Live          // behind /api/config; nil = 503
+
+	history *selfhistory.Recorder // behind /api/admin/history; nil = 503
+
+	coldTier *coldtier.Tier // behind /api/cold; nil = 503
 }
 
 // NewServer creates a new API server.
@@ -43,6 +69,39 @@ func NewServer(repo *storage.Repository, hub *realtime.Hub, eventHub *realtime.E
 	}
 }
 
+// SetConfig wires the running configuration behind /api/bootstrap.
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.cfg = cfg
+}
+
+// SetLiveConfig wires the changeable running configuration behind
+// /api/config.
+func (s *Server) SetLiveConfig(l *config.Live) {
+	s.live = l
+}
+
+// SetOIDCAuth registers the /auth/* sign-in routes of a.
+func (s *Server) SetOIDCAuth(a *OIDCAuth) {
+	s.oidc = a
+}
+
+// SetFeatureFlags wires the feature flag registry behind /api/admin/flags
+// and /api/bootstrap.
+func (s *Server) SetFeatureFlags(r *featureflag.Registry) {
+	s.flags = r
+}
+
+// SetSelfHistory wires the instance's self-history recorder behind
+// /api/admin/history. Runtime feature flag changes are recorded to it.
+func (s *Server) SetSelfHistory(r *selfhistory.Recorder) {
+	s.history = r
+}
+
+// SetColdTier wires the Parquet cold tier behind /api/cold.
+func (s *Server) SetColdTier(t *coldtier.Tier) {
+	s.coldTier = t
+}
+
 // SetGraph wires the in-memory service graph into the API server.
 func (s *Server) SetGraph(g *graph.Graph) {
 	s.graph = g
@@ -58,6 +117,38 @@ func (s *Server) SetVectorIndex(idx *vectordb.Index) {
 	s.vectorIdx = idx
 }
 
+// SetAIService wires the AI service behind /api/ask.
+func (s *Server) SetAIService(svc *ai.Service) {
+	s.ai = svc
+}
+
+// SetTraceStreams wires the span fan-out behind /api/traces/{id}/live.
+func (s *Server) SetTraceStreams(t *realtime.TraceStreams) {
+	s.traceStreams = t
+}
+
+// SetTopK wires the heavy-hitter tracker behind /api/top.
+func (s *Server) SetTopK(t *topk.Tracker) {
+	s.topK = t
+}
+
+// SetDLQ wires the dead letter queue behind /api/admin/dlq.
+func (s *Server) SetDLQ(q *queue.DeadLetterQueue) {
+	s.dlq = q
+}
+
+// SetDLQReadMerge merges logs still queued in the DLQ, enqueued within
+// window, into /api/logs results, marked pending. 0 disables the merge.
+func (s *Server) SetDLQReadMerge(window time.Duration) {
+	s.pendingReadWindow = window
+}
+
+// SetDeploymentImpactWindow sets how long before and after a deployment
+// GET /api/deployments/{id}/impact compares by default.
+func (s *Server) SetDeploymentImpactWindow(window time.Duration) {
+	s.deployImpactWindow = window
+}
+
 // SetDLQSaturationProbe registers a callback returning DLQ disk fullness as
 // a fraction in [0.0, 1.0]. Used by /ready to flip to 503 when DLQ is at
 // risk of FIFO-evicting unflushed batches. Pass nil to disable the check.
@@ -78,13 +169,17 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	// Metadata & Discovery
 	mux.HandleFunc("GET /api/metadata/services", s.handleGetServices)
 	mux.HandleFunc("GET /api/metadata/metrics", s.handleGetMetricNames)
+	mux.HandleFunc("GET /api/bootstrap", s.handleBootstrap)
 
 	// Metrics & Dashboard
 	mux.HandleFunc("GET /api/metrics", s.handleGetMetricBuckets)
+	mux.HandleFunc("GET /api/metrics/series", s.handleGetMetricSeries)
 	mux.HandleFunc("GET /api/metrics/traffic", s.handleGetTrafficMetrics)
 	mux.HandleFunc("GET /api/metrics/latency_heatmap", s.handleGetLatencyHeatmap)
+	mux.HandleFunc("GET /api/metrics/latency_percentiles", s.handleGetLatencyPercentiles)
 	mux.HandleFunc("GET /api/metrics/dashboard", s.handleGetDashboardStats)
 	mux.HandleFunc("GET /api/metrics/service-map", s.handleGetServiceMapMetrics)
+	mux.HandleFunc("GET /api/services/{name}/operations", s.handleGetServiceOperations)
 
 	// System Graph (AI-consumable topology + health)
 	mux.HandleFunc("GET /api/system/graph", s.handleGetSystemGraph)
@@ -92,13 +187,108 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	// Traces
 	mux.HandleFunc("GET /api/traces", s.handleGetTraces)
 	mux.HandleFunc("GET /api/traces/{id}", s.handleGetTraceByID)
+	mux.HandleFunc("GET /api/traces/{id}/tree", s.handleGetTraceTree)
+	mux.HandleFunc("GET /api/traces/{id}/critical-path", s.handleGetCriticalPath)
+	mux.HandleFunc("GET /api/traces/{id}/insight", s.handleGetTraceInsight)
+	mux.HandleFunc("GET /api/traces/{id}/live", s.handleLiveTrace)
+	mux.HandleFunc("GET /api/traces/{id}/export", s.handleExportTrace)
+	mux.HandleFunc("GET /api/traces/{id}/events", s.handleGetTraceEvents)
+	mux.HandleFunc("GET /api/traces/export", s.handleExportTraces)
+	mux.HandleFunc("GET /api/traces/compare", s.handleCompareTraces)
+
+	// ArgusQL search over traces, logs or metrics
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("POST /api/search", s.handlePostSearch)
+	// Natural language search, translated to ArgusQL by the AI service
+	mux.HandleFunc("GET /api/ask", s.handleAsk)
+
+	// Grafana JSON datasource
+	mux.HandleFunc("GET /api/grafana", s.handleGrafanaHealth)
+	mux.HandleFunc("GET /api/grafana/{$}", s.handleGrafanaHealth)
+	mux.HandleFunc("POST /api/grafana/metrics", s.handleGrafanaMetrics)
+	mux.HandleFunc("POST /api/grafana/search", s.handleGrafanaSearch)
+	mux.HandleFunc("POST /api/grafana/variable", s.handleGrafanaVariable)
+	mux.HandleFunc("POST /api/grafana/query", s.handleGrafanaQuery)
+	mux.HandleFunc("POST /api/grafana/annotations", s.handleGrafanaAnnotations)
 
 	// Logs
 	mux.HandleFunc("GET /api/logs", s.handleGetLogs)
 	mux.HandleFunc("GET /api/logs/context", s.handleGetLogContext)
 	mux.HandleFunc("GET /api/logs/similar", s.handleGetSimilarLogs)
+	mux.HandleFunc("GET /api/logs/patterns", s.handleGetLogPatterns)
 	mux.HandleFunc("GET /api/logs/{id}/insight", s.handleGetLogInsight)
 
+	// Errors explorer
+	mux.HandleFunc("GET /api/errors", s.handleGetErrors)
+	mux.HandleFunc("POST /api/errors/bulk", s.handleBulkTriageIssues)
+	mux.HandleFunc("GET /api/exceptions", s.handleGetExceptionGroups)
+	mux.HandleFunc("GET /api/exceptions/{fingerprint}", s.handleGetExceptions)
+	mux.HandleFunc("GET /api/span-events", s.handleSearchSpanEvents)
+	mux.HandleFunc("GET /api/issues", s.handleListIssues)
+	mux.HandleFunc("GET /api/issues/{fingerprint}", s.handleGetIssue)
+	mux.HandleFunc("PATCH /api/issues/{fingerprint}", s.handleUpdateIssue)
+
+	// Telemetry hygiene per service
+	mux.HandleFunc("GET /api/data-quality", s.handleGetDataQuality)
+
+	// Heavy hitters over the last minutes, from memory
+	mux.HandleFunc("GET /api/top", s.handleGetTop)
+
+	// Anomalies (GraphRAG) and their triage
+	mux.HandleFunc("GET /api/anomalies", s.handleGetAnomalies)
+	mux.HandleFunc("POST /api/anomalies/bulk", s.handleBulkTriageAnomalies)
+
+	// Business-dimension analytics
+	mux.HandleFunc("GET /api/analytics/dimension", s.handleGetDimensionBreakdown)
+
+	// Alerting
+	mux.HandleFunc("GET /api/alerts", s.handleGetAlerts)
+	mux.HandleFunc("GET /api/alerts/rules", s.handleListAlertRules)
+	mux.HandleFunc("POST /api/alerts/rules", s.handleCreateAlertRule)
+	mux.HandleFunc("DELETE /api/alerts/rules/{id}", s.handleDeleteAlertRule)
+
+	// SLOs
+	mux.HandleFunc("GET /api/slos", s.handleListSLOs)
+	mux.HandleFunc("POST /api/slos", s.handleCreateSLO)
+	mux.HandleFunc("GET /api/slos/{id}", s.handleGetSLO)
+	mux.HandleFunc("PUT /api/slos/{id}", s.handleUpdateSLO)
+	mux.HandleFunc("DELETE /api/slos/{id}", s.handleDeleteSLO)
+
+	// Uptime monitors
+	mux.HandleFunc("GET /api/monitors", s.handleListMonitors)
+	mux.HandleFunc("POST /api/monitors", s.handleCreateMonitor)
+	mux.HandleFunc("GET /api/monitors/{id}", s.handleGetMonitor)
+	mux.HandleFunc("PUT /api/monitors/{id}", s.handleUpdateMonitor)
+	mux.HandleFunc("DELETE /api/monitors/{id}", s.handleDeleteMonitor)
+	mux.HandleFunc("GET /api/monitors/{id}/results", s.handleGetMonitorResults)
+
+	// Deploy annotations
+	mux.HandleFunc("GET /api/deployments", s.handleGetDeployments)
+	mux.HandleFunc("GET /api/deployments/versions", s.handleGetServiceVersions)
+	mux.HandleFunc("POST /api/deployments", s.handleCreateDeployment)
+	mux.HandleFunc("GET /api/deployments/{id}/impact", s.handleGetDeploymentImpact)
+
+	// Saved views and dashboards
+	mux.HandleFunc("GET /api/views", s.handleListSavedViews)
+	mux.HandleFunc("POST /api/views", s.handleCreateSavedView)
+	mux.HandleFunc("GET /api/views/{slug}", s.handleGetSavedView)
+	mux.HandleFunc("PUT /api/views/{slug}", s.handleUpdateSavedView)
+	mux.HandleFunc("DELETE /api/views/{slug}", s.handleDeleteSavedView)
+
+	// Deletion audit trail (retention, purges, partition drops)
+	mux.HandleFunc("GET /api/deletions", s.handleGetDeletions)
+
+	// Cold tier: traces and logs moved to Parquet segments
+	mux.HandleFunc("GET /api/cold/segments", s.handleGetColdSegments)
+	mux.HandleFunc("GET /api/cold/logs", s.handleGetColdLogs)
+	mux.HandleFunc("GET /api/cold/traces", s.handleGetColdTraces)
+	mux.HandleFunc("GET /api/cold/traces/{id}", s.handleGetColdTrace)
+	mux.HandleFunc("GET /api/cold/rehydrations", s.handleListRehydrations)
+	mux.HandleFunc("POST /api/cold/rehydrations", s.handleCreateRehydration)
+	mux.HandleFunc("GET /api/cold/rehydrations/{id}", s.handleGetRehydration)
+	mux.HandleFunc("GET /api/cold/rehydrations/{id}/logs", s.handleGetRehydratedLogs)
+	mux.HandleFunc("DELETE /api/cold/rehydrations/{id}", s.handleDeleteRehydration)
+
 	// Admin & System
 	mux.HandleFunc("GET /api/stats", s.handleGetStats)
 	mux.HandleFunc("GET /api/health", s.metrics.HealthHandler())
@@ -108,6 +298,36 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("DELETE /api/admin/purge", s.handlePurge)
 	mux.HandleFunc("POST /api/admin/vacuum", s.handleVacuum)
 	mux.HandleFunc("POST /api/admin/drop_fts", s.handleDropFTS)
+	mux.HandleFunc("GET /api/admin/index_advice", s.handleIndexAdvice)
+	mux.HandleFunc("GET /api/admin/storage", s.handleGetStorageUsage)
+	mux.HandleFunc("GET /api/admin/dlq", s.handleGetDLQ)
+	mux.HandleFunc("GET /api/admin/dlq/progress", s.handleGetDLQProgress)
+	mux.HandleFunc("POST /api/admin/dlq/replay", s.handleReplayDLQ)
+	mux.HandleFunc("DELETE /api/admin/dlq/{name}", s.handleDeleteDLQFile)
+	mux.HandleFunc("GET /api/admin/hub", s.handleGetHubTuning)
+	mux.HandleFunc("PUT /api/admin/hub", s.handleSetHubTuning)
+	mux.HandleFunc("GET /api/admin/flags", s.handleListFlags)
+	mux.HandleFunc("PUT /api/admin/flags/{name}", s.handleSetFlag)
+	mux.HandleFunc("GET /api/admin/history", s.handleGetSelfHistory)
+	mux.HandleFunc("GET /api/admin/audit", s.handleGetAuditEvents)
+	mux.HandleFunc("GET /api/config", s.handleGetRuntimeConfig)
+	mux.HandleFunc("PATCH /api/config", s.handlePatchRuntimeConfig)
+
+	// API key management (only when API_KEYS_ENABLED)
+	if s.apiKeys != nil {
+		mux.HandleFunc("GET /api/keys", s.handleListAPIKeys)
+		mux.HandleFunc("POST /api/keys", s.handleCreateAPIKey)
+		mux.HandleFunc("DELETE /api/keys/{id}", s.handleDeleteAPIKey)
+	}
+
+	// Browser sign-in (only when OIDC_ISSUER_URL or AUTH_DEV_BYPASS)
+	if s.oidc != nil {
+		mux.HandleFunc("GET /auth/login", s.oidc.handleLogin)
+		mux.HandleFunc("GET /auth/callback", s.oidc.handleCallback)
+		mux.HandleFunc("GET /auth/logout", s.oidc.handleLogout)
+		mux.HandleFunc("POST /auth/logout", s.oidc.handleLogout)
+		mux.HandleFunc("GET /auth/me", s.oidc.handleMe)
+	}
 
 	// WebSockets
 	mux.HandleFunc("/ws", s.hub.HandleWebSocket)
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleGetServiceOperations(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now().UTC()
+	spans := make([]storage.Span, 0, 3)
+	for i, op := range []string{"GET /cart", "GET /cart", "POST /pay"} {
+		sp := storage.Span{TenantID: storage.DefaultTenantID, TraceID: "t" + op, SpanID: string(rune('a' + i)), OperationName: op, ServiceName: "checkout"}
+		sp.SetTiming(now.Add(-time.Minute), now.Add(-time.Minute).Add(time.Duration(i+1)*time.Millisecond))
+		spans = append(spans, sp)
+	}
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/services/{name}/operations", srv.handleGetServiceOperations)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/services/checkout/operations?sort=count", nil))
+	var got storage.ServiceOperations
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil {
+		t.Fatalf("want 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got.ServiceName != "checkout" || len(got.Operations) != 2 || got.Operations[0].OperationName != "GET /cart" || got.Operations[0].Count != 2 {
+		t.Errorf("got %+v", got)
+	}
+
+	for _, target := range []string{
+		"/api/services/checkout/operations?sort=avg",
+		"/api/services/checkout/operations?limit=-1",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: want 400, got %d", target, rec.Code)
+		}
+	}
+}
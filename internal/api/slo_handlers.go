@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/alerting"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"gorm.io/gorm"
+)
+
+// maxSLOBody bounds SLO request bodies.
+const maxSLOBody = 16 << 10
+
+// handleListSLOs handles GET /api/slos
+func (s *Server) handleListSLOs(w http.ResponseWriter, r *http.Request) {
+	slos, err := s.repo.ListSLOs(r.Context())
+	if err != nil {
+		slog.Error("Failed to list SLOs", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if slos == nil {
+		slos = []storage.SLO{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(slos)
+}
+
+// handleGetSLO handles GET /api/slos/{id}
+func (s *Server) handleGetSLO(w http.ResponseWriter, r *http.Request) {
+	id, ok := sloID(w, r)
+	if !ok {
+		return
+	}
+	slo, err := s.repo.GetSLO(r.Context(), id)
+	if err != nil {
+		writeSLOError(w, "load", id, err)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(slo)
+}
+
+// handleCreateSLO handles POST /api/slos
+func (s *Server) handleCreateSLO(w http.ResponseWriter, r *http.Request) {
+	var slo storage.SLO
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSLOBody)).Decode(&slo); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := alerting.ValidateSLO(&slo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.CreateSLO(r.Context(), &slo); err != nil {
+		slog.Error("Failed to create SLO", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.audit(r, storage.AuditActionSLOCreate, "slo/"+strconv.FormatUint(uint64(slo.ID), 10), sloAuditDetails(&slo))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(slo)
+}
+
+// handleUpdateSLO handles PUT /api/slos/{id}, replacing the definition.
+func (s *Server) handleUpdateSLO(w http.ResponseWriter, r *http.Request) {
+	id, ok := sloID(w, r)
+	if !ok {
+		return
+	}
+	var slo storage.SLO
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSLOBody)).Decode(&slo); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := alerting.ValidateSLO(&slo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.repo.UpdateSLO(r.Context(), id, &slo); err != nil {
+		writeSLOError(w, "update", id, err)
+		return
+	}
+	s.audit(r, storage.AuditActionSLOUpdate, "slo/"+r.PathValue("id"), sloAuditDetails(&slo))
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(slo)
+}
+
+// handleDeleteSLO handles DELETE /api/slos/{id}. Its burn-rate alert rules
+// are deleted with it.
+func (s *Server) handleDeleteSLO(w http.ResponseWriter, r *http.Request) {
+	id, ok := sloID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.repo.DeleteSLO(r.Context(), id); err != nil {
+		writeSLOError(w, "delete", id, err)
+		return
+	}
+	s.audit(r, storage.AuditActionSLODelete, "slo/"+r.PathValue("id"), "")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sloID parses the {id} path value, writing a 400 when it is invalid.
+func sloID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// sloAuditDetails summarizes an SLO definition for its audit event.
+func sloAuditDetails(slo *storage.SLO) string {
+	return fmt.Sprintf("%s SLO %q on %s, objective %g over %d days", slo.Kind, slo.Name, slo.ServiceName, slo.Objective, slo.WindowDays)
+}
+
+// writeSLOError maps repository errors to 404 or 500.
+func writeSLOError(w http.ResponseWriter, op string, id uint, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "SLO not found", http.StatusNotFound)
+		return
+	}
+	slog.Error("Failed to "+op+" SLO", "id", id, "error", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestSLOHandlers_Lifecycle(t *testing.T) {
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/slos", srv.handleListSLOs)
+	mux.HandleFunc("POST /api/slos", srv.handleCreateSLO)
+	mux.HandleFunc("GET /api/slos/{id}", srv.handleGetSLO)
+	mux.HandleFunc("PUT /api/slos/{id}", srv.handleUpdateSLO)
+	mux.HandleFunc("DELETE /api/slos/{id}", srv.handleDeleteSLO)
+	mux.HandleFunc("POST /api/alerts/rules", srv.handleCreateAlertRule)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/slos", `{"name":"x","service_name":"checkout","kind":"latency","objective":95}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("latency SLO without threshold: want 400, got %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/slos", `{"name":"checkout p95","service_name":"checkout","kind":"latency","objective":95,"latency_threshold_ms":300}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: want 201, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	var created storage.SLO
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if created.ID == 0 || created.WindowDays != 30 || created.TenantID != storage.DefaultTenantID {
+		t.Errorf("created = %+v", created)
+	}
+	path := "/api/slos/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	rec = do(http.MethodPut, path, `{"name":"checkout p95","service_name":"checkout","kind":"latency","objective":99,"latency_threshold_ms":250,"window_days":7}`)
+	var updated storage.SLO
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &updated) != nil || updated.Objective != 99 || updated.WindowDays != 7 || updated.ID != created.ID {
+		t.Errorf("update: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, path, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"latency_threshold_ms":250`) {
+		t.Errorf("get: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodPost, "/api/alerts/rules", `{"name":"burn","kind":"slo_burn_rate","slo_id":999,"threshold":14.4}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("burn rule on unknown SLO: want 400, got %d", rec.Code)
+	}
+	rec = do(http.MethodPost, "/api/alerts/rules", `{"name":"burn","kind":"slo_burn_rate","slo_id":`+strconv.FormatUint(uint64(created.ID), 10)+`,"threshold":14.4}`)
+	var rule storage.AlertRule
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &rule) != nil || rule.ServiceName != "checkout" || rule.WindowSecs != 3600 {
+		t.Errorf("burn rule: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: want 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, path, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: want 404, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/slos", ""); strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("list after delete = %s", rec.Body.String())
+	}
+
+	events, err := srv.repo.ListAuditEvents(storage.WithTenantContext(t.Context(), storage.DefaultTenantID), storage.AuditQuery{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	var actions []string
+	for _, e := range events {
+		if strings.HasPrefix(e.Action, "slo.") {
+			if e.Resource != "slo/"+strconv.FormatUint(uint64(created.ID), 10) {
+				t.Errorf("%s resource = %q", e.Action, e.Resource)
+			}
+			actions = append(actions, e.Action)
+		}
+	}
+	if want := []string{storage.AuditActionSLODelete, storage.AuditActionSLOUpdate, storage.AuditActionSLOCreate}; !slices.Equal(actions, want) {
+		t.Errorf("SLO audit actions = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// handleSearchSpanEvents handles GET /api/span-events — the traces that
+// recorded an event, e.g. ?name=database_lock_contention.
+func (s *Server) handleSearchSpanEvents(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, "invalid time range", http.StatusBadRequest)
+		return
+	}
+	q := storage.SpanEventQuery{
+		Name:         name,
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = min(n, 1000)
+	}
+
+	traces, err := s.repo.SearchSpanEventTraces(r.Context(), q)
+	if err != nil {
+		slog.Error("Failed to search span events", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(traces)
+}
+
+// handleGetTraceEvents handles GET /api/traces/{id}/events
+func (s *Server) handleGetTraceEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := s.repo.GetSpanEvents(r.Context(), r.PathValue("id"))
+	if err != nil {
+		slog.Error("Failed to get span events", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []storage.SpanEvent{}
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(events)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// defaultStorageUsageWindow is how much history GET /api/admin/storage
+// returns when no start is given.
+const defaultStorageUsageWindow = 7 * 24 * time.Hour
+
+// storageUsageResponse is the tenant's estimated storage footprint at the
+// latest sample, plus its history over the requested window.
+type storageUsageResponse struct {
+	SampledAt  *time.Time            `json:"sampled_at"` // latest sample; null until the first one
+	TotalBytes int64                 `json:"total_bytes"`
+	Signals    []storageUsageSignal  `json:"signals"`
+	Services   []storageUsageService `json:"services"` // largest first
+	History    []storageUsagePoint   `json:"history"`  // one point per sample, oldest first
+}
+
+type storageUsageSignal struct {
+	Signal string `json:"signal"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+}
+
+type storageUsageService struct {
+	ServiceName string           `json:"service_name"`
+	Rows        int64            `json:"rows"`
+	Bytes       int64            `json:"bytes"`
+	GrowthBytes int64            `json:"growth_bytes"` // change since the window's first sample
+	Signals     map[string]int64 `json:"signals"`      // bytes per signal
+}
+
+type storageUsagePoint struct {
+	SampledAt time.Time        `json:"sampled_at"`
+	Bytes     int64            `json:"bytes"`
+	Signals   map[string]int64 `json:"signals"`
+}
+
+// handleGetStorageUsage handles GET /api/admin/storage — estimated bytes per
+// signal and service for the tenant on the request, from the samples the
+// storage usage sampler records (STORAGE_USAGE_INTERVAL). start (RFC3339,
+// default 7 days ago) bounds the history and the growth baseline.
+func (s *Server) handleGetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	start, _, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if start.IsZero() {
+		start = time.Now().Add(-defaultStorageUsageWindow)
+	}
+	samples, err := s.repo.StorageUsageHistory(r.Context(), start)
+	if err != nil {
+		slog.Error("Failed to load storage usage", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(buildStorageUsage(samples))
+}
+
+// buildStorageUsage folds samples (oldest first) into the response: the
+// breakdown comes from the latest sample, growth from the first.
+func buildStorageUsage(samples []storage.StorageUsageSample) storageUsageResponse {
+	resp := storageUsageResponse{
+		Signals:  []storageUsageSignal{},
+		Services: []storageUsageService{},
+		History:  []storageUsagePoint{},
+	}
+	if len(samples) == 0 {
+		return resp
+	}
+
+	first, latest := samples[0].SampledAt, samples[len(samples)-1].SampledAt
+	baseline := make(map[string]int64)
+	signals := make(map[string]*storageUsageSignal)
+	services := make(map[string]*storageUsageService)
+	for _, sm := range samples {
+		if n := len(resp.History); n == 0 || !resp.History[n-1].SampledAt.Equal(sm.SampledAt) {
+			resp.History = append(resp.History, storageUsagePoint{SampledAt: sm.SampledAt, Signals: map[string]int64{}})
+		}
+		pt := &resp.History[len(resp.History)-1]
+		pt.Bytes += sm.Bytes
+		pt.Signals[sm.Signal] += sm.Bytes
+
+		if sm.SampledAt.Equal(first) {
+			baseline[sm.ServiceName] += sm.Bytes
+		}
+		if !sm.SampledAt.Equal(latest) {
+			continue
+		}
+		resp.TotalBytes += sm.Bytes
+		sig := signals[sm.Signal]
+		if sig == nil {
+			sig = &storageUsageSignal{Signal: sm.Signal}
+			signals[sm.Signal] = sig
+		}
+		sig.Rows += sm.Rows
+		sig.Bytes += sm.Bytes
+		svc := services[sm.ServiceName]
+		if svc == nil {
+			svc = &storageUsageService{ServiceName: sm.ServiceName, Signals: map[string]int64{}}
+			services[sm.ServiceName] = svc
+		}
+		svc.Rows += sm.Rows
+		svc.Bytes += sm.Bytes
+		svc.Signals[sm.Signal] += sm.Bytes
+	}
+	resp.SampledAt = &latest
+
+	for _, sig := range signals {
+		resp.Signals = append(resp.Signals, *sig)
+	}
+	sort.Slice(resp.Signals, func(i, j int) bool { return resp.Signals[i].Signal < resp.Signals[j].Signal })
+	for name, svc := range services {
+		svc.GrowthBytes = svc.Bytes - baseline[name]
+		resp.Services = append(resp.Services, *svc)
+	}
+	sort.Slice(resp.Services, func(i, j int) bool {
+		if resp.Services[i].Bytes != resp.Services[j].Bytes {
+			return resp.Services[i].Bytes > resp.Services[j].Bytes
+		}
+		return resp.Services[i].ServiceName < resp.Services[j].ServiceName
+	})
+	return resp
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestBuildStorageUsage(t *testing.T) {
+	if got := buildStorageUsage(nil); got.SampledAt != nil || len(got.Services) != 0 || got.History == nil {
+		t.Fatalf("empty = %+v", got)
+	}
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	got := buildStorageUsage([]storage.StorageUsageSample{
+		{Signal: storage.UsageSignalLogs, ServiceName: "cart", Rows: 10, Bytes: 100, SampledAt: t0},
+		{Signal: storage.UsageSignalLogs, ServiceName: "cart", Rows: 20, Bytes: 200, SampledAt: t1},
+		{Signal: storage.UsageSignalTraces, ServiceName: "checkout", Rows: 5, Bytes: 500, SampledAt: t1},
+		{Signal: storage.UsageSignalLogs, ServiceName: "checkout", Rows: 1, Bytes: 50, SampledAt: t1},
+	})
+	if got.SampledAt == nil || !got.SampledAt.Equal(t1) || got.TotalBytes != 750 {
+		t.Fatalf("latest = %v total = %d", got.SampledAt, got.TotalBytes)
+	}
+	if len(got.Signals) != 2 || got.Signals[0].Signal != storage.UsageSignalLogs || got.Signals[0].Bytes != 250 {
+		t.Errorf("signals = %+v", got.Signals)
+	}
+	if len(got.Services) != 2 || got.Services[0].ServiceName != "checkout" || got.Services[0].GrowthBytes != 550 ||
+		got.Services[1].GrowthBytes != 100 || got.Services[0].Signals[storage.UsageSignalTraces] != 500 {
+		t.Errorf("services = %+v", got.Services)
+	}
+	if len(got.History) != 2 || got.History[0].Bytes != 100 || got.History[1].Bytes != 750 {
+		t.Errorf("history = %+v", got.History)
+	}
+}
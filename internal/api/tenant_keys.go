@@ -141,7 +141,7 @@ func (a *TenantKeyAuth) Middleware(mcpPath string, next http.Handler) http.Handl
 		return next
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !IsProtectedPath(r.URL.Path, mcpPath) {
+		if !IsProtectedPath(r.URL.Path, mcpPath) || isCORSPreflight(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -31,7 +31,9 @@ func TenantMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !tenantScopedPath(r.URL.Path) {
+			// A tenant pinned by an auth layer (tenant-bound API key) wins
+			// over the client-supplied header.
+			if !tenantScopedPath(r.URL.Path) || storage.HasTenantContext(r.Context()) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/topk"
+)
+
+const (
+	defaultTopWindow = 5 * time.Minute
+	defaultTopK      = 10
+	maxTopK          = 100
+)
+
+// topResponse is the GET /api/top payload. Window is the window actually
+// answered, after rounding to whole minutes and clamping.
+type topResponse struct {
+	Window string                         `json:"window"`
+	Top    map[topk.Dimension][]topk.Item `json:"top"`
+}
+
+// handleGetTop handles GET /api/top — the heaviest hitters of the tenant's
+// ingest over a recent window, from the in-memory top-K tracker:
+//
+//	dimension  services | routes | errors (default: all three)
+//	window     Go duration, 1m..15m (default 5m)
+//	k          items per dimension, 1..100 (default 10)
+//
+// Counts are approximate (Space-Saving; see topk.Item) and per instance.
+func (s *Server) handleGetTop(w http.ResponseWriter, r *http.Request) {
+	if s.topK == nil {
+		http.Error(w, "top-K tracking is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	dims := topk.Dimensions
+	if d := q.Get("dimension"); d != "" {
+		dims = []topk.Dimension{topk.Dimension(d)}
+		if !slices.Contains(topk.Dimensions, dims[0]) {
+			http.Error(w, "dimension must be services, routes or errors", http.StatusBadRequest)
+			return
+		}
+	}
+	window := defaultTopWindow
+	if v := q.Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid window", http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+	// Round up to whole buckets, as the tracker does.
+	window = min((window+topk.BucketWidth-1)/topk.BucketWidth*topk.BucketWidth, topk.MaxWindow)
+	k := defaultTopK
+	if v := q.Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid k", http.StatusBadRequest)
+			return
+		}
+		k = min(n, maxTopK)
+	}
+
+	tenant := storage.TenantFromContext(r.Context())
+	resp := topResponse{Window: window.String(), Top: make(map[topk.Dimension][]topk.Item, len(dims))}
+	for _, d := range dims {
+		resp.Top[d] = s.topK.Top(tenant, d, window, k)
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
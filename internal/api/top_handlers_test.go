@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/topk"
+)
+
+func TestHandleGetTop(t *testing.T) {
+	tracker := topk.New()
+	for range 3 {
+		tracker.RecordService("acme", "checkout")
+	}
+	tracker.RecordService("acme", "payment")
+	tracker.RecordService(storage.DefaultTenantID, "other-tenant")
+	tracker.RecordError("acme", "payment", "card 4242 declined")
+
+	srv := &Server{topK: tracker}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/top", srv.handleGetTop)
+	get := func(query string) (*httptest.ResponseRecorder, topResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/top"+query, nil)
+		req = req.WithContext(storage.WithTenantContext(req.Context(), "acme"))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		var got topResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec, got
+	}
+
+	rec, got := get("")
+	if rec.Code != http.StatusOK || got.Window != "5m0s" || len(got.Top) != 3 {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if svcs := got.Top[topk.Services]; len(svcs) != 2 || svcs[0].Service != "checkout" || svcs[0].Count != 3 {
+		t.Errorf("services = %+v", svcs)
+	}
+	if errs := got.Top[topk.Errors]; len(errs) != 1 || errs[0].Name != "card <n> declined" {
+		t.Errorf("errors = %+v", errs)
+	}
+
+	_, got = get("?dimension=services&k=1&window=90s")
+	if got.Window != "2m0s" || len(got.Top) != 1 || len(got.Top[topk.Services]) != 1 {
+		t.Errorf("k=1 = %+v", got)
+	}
+	if _, got = get("?window=2h"); got.Window != "15m0s" {
+		t.Errorf("window clamp = %q", got.Window)
+	}
+	for _, q := range []string{"?dimension=hosts", "?window=soon", "?k=0"} {
+		if rec, _ := get(q); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", q, rec.Code)
+		}
+	}
+
+	srv.topK = nil
+	if rec, _ := get(""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without tracker: status = %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,446 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Trace export formats accepted by ?format=.
+const (
+	exportFormatOTLP   = "otlp"
+	exportFormatJaeger = "jaeger"
+
+	contentTypeProtobuf = "application/x-protobuf"
+)
+
+// handleExportTrace handles GET /api/traces/{id}/export?format=otlp|jaeger.
+// The trace is served as a download, ready to attach to a bug report or load
+// into another tool.
+func (s *Server) handleExportTrace(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	traceID := r.PathValue("id")
+	trace, err := s.repo.GetTrace(r.Context(), traceID)
+	if err != nil {
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+	tw := newTraceExportWriter(w, format, "trace-"+trace.TraceID)
+	err = tw.begin()
+	if err == nil {
+		err = tw.write(trace)
+	}
+	if err == nil {
+		err = tw.end()
+	}
+	if err != nil {
+		slog.Warn("Trace export failed", "trace_id", trace.TraceID, "format", format, "error", err)
+	}
+}
+
+// handleExportTraces handles GET /api/traces/export?start=&end=&service_name=&limit=&format=.
+// Every trace in the range is streamed page by page, so an export of any size
+// runs in bounded memory. start and end (RFC3339) are required; limit is
+// optional (default: all).
+func (s *Server) handleExportTraces(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(w, r)
+	if !ok {
+		return
+	}
+	start, end, _ := parseTimeRange(r)
+	if start.IsZero() || end.IsZero() {
+		http.Error(w, "start and end (RFC3339) are required", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	filter := storage.TraceExportFilter{
+		Start:        start,
+		End:          end,
+		ServiceNames: r.URL.Query()["service_name"],
+		Limit:        max(limit, 0),
+	}
+
+	tw := newTraceExportWriter(w, format, "traces-"+start.UTC().Format("20060102T150405Z"))
+	if err := tw.begin(); err != nil {
+		return
+	}
+	rc := http.NewResponseController(w)
+	n := 0
+	err := s.repo.ForEachTrace(r.Context(), filter, func(t *storage.Trace) error {
+		if err := tw.write(t); err != nil {
+			return err
+		}
+		n++
+		_ = rc.Flush()
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent; the truncated body is the client's signal.
+		slog.Warn("Trace export aborted", "format", format, "exported", n, "error", err)
+		return
+	}
+	_ = tw.end()
+}
+
+func exportFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch f := r.URL.Query().Get("format"); f {
+	case "", exportFormatOTLP:
+		return exportFormatOTLP, true
+	case exportFormatJaeger:
+		return f, true
+	default:
+		http.Error(w, "format must be otlp or jaeger", http.StatusBadRequest)
+		return "", false
+	}
+}
+
+// traceExportWriter streams traces in one export format.
+type traceExportWriter interface {
+	begin() error
+	write(*storage.Trace) error
+	end() error
+}
+
+func newTraceExportWriter(w http.ResponseWriter, format, filename string) traceExportWriter {
+	if format == exportFormatJaeger {
+		w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.jaeger.json"`)
+		return &jaegerExportWriter{w: w}
+	}
+	w.Header().Set(httpconst.HeaderContentType, contentTypeProtobuf)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.otlp.pb"`)
+	return &otlpExportWriter{w: w}
+}
+
+// otlpExportWriter writes an ExportTraceServiceRequest. Each trace is appended
+// as further occurrences of field 1 (resource_spans) — concatenated
+// occurrences of a repeated field decode as one message, so the stream is a
+// valid request without ever holding it whole.
+type otlpExportWriter struct {
+	w io.Writer
+}
+
+func (o *otlpExportWriter) begin() error { return nil }
+func (o *otlpExportWriter) end() error   { return nil }
+
+func (o *otlpExportWriter) write(t *storage.Trace) error {
+	var buf []byte
+	for _, rs := range traceToOTLP(t) {
+		b, err := proto.Marshal(rs)
+		if err != nil {
+			return fmt.Errorf("marshal resource spans: %w", err)
+		}
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, b)
+	}
+	_, err := o.w.Write(buf)
+	return err
+}
+
+// jaegerExportWriter writes the Jaeger query API envelope
+// ({"data":[trace...]}), the shape the Jaeger UI's JSON upload accepts.
+type jaegerExportWriter struct {
+	w io.Writer
+	n int
+}
+
+func (j *jaegerExportWriter) begin() error {
+	_, err := io.WriteString(j.w, `{"data":[`)
+	return err
+}
+
+func (j *jaegerExportWriter) write(t *storage.Trace) error {
+	b, err := json.Marshal(traceToJaeger(t))
+	if err != nil {
+		return fmt.Errorf("marshal jaeger trace: %w", err)
+	}
+	if j.n > 0 {
+		b = append([]byte{','}, b...)
+	}
+	j.n++
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jaegerExportWriter) end() error {
+	_, err := fmt.Fprintf(j.w, `],"total":%d,"limit":0,"offset":0,"errors":null}`, j.n)
+	return err
+}
+
+// traceToOTLP rebuilds OTLP resource spans for one trace, one ResourceSpans
+// per service. Only what storage keeps survives: names, timing, parentage,
+// status code and attributes. Logs recorded against a span come back as its
+// events.
+func traceToOTLP(t *storage.Trace) []*tracepb.ResourceSpans {
+	events := spanLogs(t)
+	byService := make(map[string]*tracepb.ScopeSpans)
+	var out []*tracepb.ResourceSpans
+	for i := range t.Spans {
+		sp := &t.Spans[i]
+		ss, ok := byService[sp.ServiceName]
+		if !ok {
+			ss = &tracepb.ScopeSpans{}
+			byService[sp.ServiceName] = ss
+			out = append(out, &tracepb.ResourceSpans{
+				Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{{
+					Key:   "service.name",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: sp.ServiceName}},
+				}}},
+				ScopeSpans: []*tracepb.ScopeSpans{ss},
+			})
+		}
+		span := &tracepb.Span{
+			TraceId:           hexID(sp.TraceID, 16),
+			SpanId:            hexID(sp.SpanID, 8),
+			ParentSpanId:      hexID(sp.ParentSpanID, 8),
+			Name:              sp.OperationName,
+			StartTimeUnixNano: unixNano(sp.StartTime),
+			EndTimeUnixNano:   unixNano(sp.EndTime),
+			Attributes:        decodeStoredAttributes(string(sp.AttributesJSON)),
+		}
+		if code := tracepb.Status_StatusCode(tracepb.Status_StatusCode_value[sp.Status]); code != tracepb.Status_STATUS_CODE_UNSET {
+			span.Status = &tracepb.Status{Code: code}
+		}
+		for _, l := range events[sp.SpanID] {
+			span.Events = append(span.Events, &tracepb.Span_Event{
+				Name:         l.Body,
+				TimeUnixNano: unixNano(l.Timestamp),
+				Attributes:   decodeStoredAttributes(string(l.AttributesJSON)),
+			})
+		}
+		ss.Spans = append(ss.Spans, span)
+	}
+	return out
+}
+
+type jaegerTrace struct {
+	TraceID   string                   `json:"traceID"`
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+	Warnings  []string                 `json:"warnings"`
+}
+
+type jaegerProcess struct {
+	ServiceName string     `json:"serviceName"`
+	Tags        []jaegerKV `json:"tags"`
+}
+
+type jaegerSpan struct {
+	TraceID       string      `json:"traceID"`
+	SpanID        string      `json:"spanID"`
+	OperationName string      `json:"operationName"`
+	References    []jaegerRef `json:"references"`
+	Flags         int         `json:"flags"`
+	StartTime     int64       `json:"startTime"` // epoch micros
+	Duration      int64       `json:"duration"`  // micros
+	Tags          []jaegerKV  `json:"tags"`
+	Logs          []jaegerLog `json:"logs"`
+	ProcessID     string      `json:"processID"`
+	Warnings      []string    `json:"warnings"`
+}
+
+type jaegerRef struct {
+	RefType string `json:"refType"`
+	TraceID string `json:"traceID"`
+	SpanID  string `json:"spanID"`
+}
+
+type jaegerKV struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jaegerLog struct {
+	Timestamp int64      `json:"timestamp"`
+	Fields    []jaegerKV `json:"fields"`
+}
+
+// traceToJaeger converts one trace to the Jaeger query API JSON model. The
+// error status becomes the conventional error=true tag.
+func traceToJaeger(t *storage.Trace) jaegerTrace {
+	events := spanLogs(t)
+	jt := jaegerTrace{TraceID: t.TraceID, Processes: map[string]jaegerProcess{}, Spans: []jaegerSpan{}}
+	processIDs := map[string]string{}
+	for i := range t.Spans {
+		sp := &t.Spans[i]
+		pid, ok := processIDs[sp.ServiceName]
+		if !ok {
+			pid = "p" + strconv.Itoa(len(processIDs)+1)
+			processIDs[sp.ServiceName] = pid
+			jt.Processes[pid] = jaegerProcess{ServiceName: sp.ServiceName, Tags: []jaegerKV{}}
+		}
+		js := jaegerSpan{
+			TraceID:       sp.TraceID,
+			SpanID:        sp.SpanID,
+			OperationName: sp.OperationName,
+			References:    []jaegerRef{},
+			Flags:         1,
+			StartTime:     sp.StartTime.UnixMicro(),
+			Duration:      sp.Duration,
+			Tags:          jaegerTags(decodeStoredAttributes(string(sp.AttributesJSON))),
+			Logs:          []jaegerLog{},
+			ProcessID:     pid,
+		}
+		if sp.ParentSpanID != "" {
+			js.References = append(js.References, jaegerRef{RefType: "CHILD_OF", TraceID: sp.TraceID, SpanID: sp.ParentSpanID})
+		}
+		if sp.Status == tracepb.Status_STATUS_CODE_ERROR.String() {
+			js.Tags = append(js.Tags, jaegerKV{Key: "error", Type: "bool", Value: true})
+		}
+		for _, l := range events[sp.SpanID] {
+			fields := append([]jaegerKV{{Key: "event", Type: "string", Value: l.Body}},
+				jaegerTags(decodeStoredAttributes(string(l.AttributesJSON)))...)
+			js.Logs = append(js.Logs, jaegerLog{Timestamp: l.Timestamp.UnixMicro(), Fields: fields})
+		}
+		jt.Spans = append(jt.Spans, js)
+	}
+	return jt
+}
+
+func jaegerTags(kvs []*commonpb.KeyValue) []jaegerKV {
+	tags := make([]jaegerKV, 0, len(kvs))
+	for _, kv := range kvs {
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "string", Value: v.StringValue})
+		case *commonpb.AnyValue_BoolValue:
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "bool", Value: v.BoolValue})
+		case *commonpb.AnyValue_IntValue:
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "int64", Value: v.IntValue})
+		case *commonpb.AnyValue_DoubleValue:
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "float64", Value: v.DoubleValue})
+		case *commonpb.AnyValue_BytesValue:
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "binary", Value: base64.StdEncoding.EncodeToString(v.BytesValue)})
+		case nil:
+		default:
+			// Arrays and maps have no Jaeger tag type; keep them as JSON text.
+			b, _ := protojson.Marshal(kv.Value)
+			tags = append(tags, jaegerKV{Key: kv.Key, Type: "string", Value: string(b)})
+		}
+	}
+	return tags
+}
+
+// spanLogs indexes the trace's logs by span ID.
+func spanLogs(t *storage.Trace) map[string][]storage.Log {
+	m := make(map[string][]storage.Log)
+	for _, l := range t.Logs {
+		if l.SpanID != "" {
+			m[l.SpanID] = append(m[l.SpanID], l)
+		}
+	}
+	return m
+}
+
+// storedAnyValue mirrors the JSON the receiver writes into AttributesJSON:
+// encoding/json over commonpb values, where the oneof wrapper has no json
+// tags and lands under Value.<Kind>Value.
+type storedAnyValue struct {
+	Value struct {
+		StringValue *string
+		BoolValue   *bool
+		IntValue    *int64
+		DoubleValue *float64
+		BytesValue  []byte
+		ArrayValue  *struct {
+			Values []*storedAnyValue `json:"values"`
+		}
+		KvlistValue *struct {
+			Values []storedKeyValue `json:"values"`
+		}
+	}
+}
+
+type storedKeyValue struct {
+	Key   string          `json:"key"`
+	Value *storedAnyValue `json:"value"`
+}
+
+// decodeStoredAttributes turns an AttributesJSON blob back into OTLP
+// attributes. Blobs that are not a KeyValue list (e.g. the "{}" written for
+// synthesized logs) decode to nil.
+func decodeStoredAttributes(attrs string) []*commonpb.KeyValue {
+	if attrs == "" || attrs[0] != '[' {
+		return nil
+	}
+	var kvs []storedKeyValue
+	if err := json.Unmarshal([]byte(attrs), &kvs); err != nil {
+		return nil
+	}
+	return storedKeyValues(kvs)
+}
+
+func storedKeyValues(kvs []storedKeyValue) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		out = append(out, &commonpb.KeyValue{Key: kv.Key, Value: kv.Value.toOTLP()})
+	}
+	return out
+}
+
+func (v *storedAnyValue) toOTLP() *commonpb.AnyValue {
+	if v == nil {
+		return nil
+	}
+	x := v.Value
+	switch {
+	case x.StringValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: *x.StringValue}}
+	case x.BoolValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: *x.BoolValue}}
+	case x.IntValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: *x.IntValue}}
+	case x.DoubleValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: *x.DoubleValue}}
+	case x.BytesValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: x.BytesValue}}
+	case x.ArrayValue != nil:
+		arr := &commonpb.ArrayValue{}
+		for _, e := range x.ArrayValue.Values {
+			arr.Values = append(arr.Values, e.toOTLP())
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: arr}}
+	case x.KvlistValue != nil:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{
+			KvlistValue: &commonpb.KeyValueList{Values: storedKeyValues(x.KvlistValue.Values)}}}
+	}
+	return &commonpb.AnyValue{}
+}
+
+// hexID decodes a stored hex ID into size bytes, left-padding short legacy
+// IDs. Empty or malformed IDs yield nil.
+func hexID(s string, size int) []byte {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) == 0 || len(raw) > size {
+		return nil
+	}
+	out := make([]byte, size)
+	copy(out[size-len(raw):], raw)
+	return out
+}
+
+func unixNano(t time.Time) uint64 {
+	if t.IsZero() || t.UnixNano() < 0 {
+		return 0
+	}
+	return uint64(t.UnixNano()) // #nosec G115 -- checked non-negative
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	exportTraceA = "4bf92f3577b34da6a3ce929d0e0e4736"
+	exportTraceB = "00000000000000000000000000000b0b"
+)
+
+// seedExportTraces stores trace A (web → orders, orders failed with an
+// exception log) and trace B (a lone web span) an hour later.
+func seedExportTraces(t *testing.T, repo *storage.Repository, base time.Time) {
+	t.Helper()
+	attrs, _ := json.Marshal([]*commonpb.KeyValue{
+		{Key: "http.method", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "GET"}}},
+		{Key: "http.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 500}}},
+		{Key: "tags", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{
+			Values: []*commonpb.AnyValue{{Value: &commonpb.AnyValue_BoolValue{BoolValue: true}}}}}}},
+	})
+	db := repo.DB()
+	for _, row := range []any{
+		&storage.Trace{TenantID: "default", TraceID: exportTraceA, ServiceName: "web", Timestamp: base, Duration: 3000},
+		&storage.Trace{TenantID: "default", TraceID: exportTraceB, ServiceName: "web", Timestamp: base.Add(time.Hour), Duration: 1000},
+		&storage.Span{TenantID: "default", TraceID: exportTraceA, SpanID: "00f067aa0ba902b7", OperationName: "GET /orders",
+			ServiceName: "web", StartTime: base, EndTime: base.Add(3 * time.Millisecond), Duration: 3000, Status: "STATUS_CODE_UNSET"},
+		&storage.Span{TenantID: "default", TraceID: exportTraceA, SpanID: "00f067aa0ba902b8", ParentSpanID: "00f067aa0ba902b7",
+			OperationName: "load", ServiceName: "orders", StartTime: base.Add(time.Millisecond), EndTime: base.Add(2 * time.Millisecond),
+			Duration: 1000, Status: "STATUS_CODE_ERROR", AttributesJSON: storage.CompressedText(attrs)},
+		&storage.Log{TenantID: "default", TraceID: exportTraceA, SpanID: "00f067aa0ba902b8", Severity: "ERROR",
+			Body: "db timeout", ServiceName: "orders", AttributesJSON: "{}", Timestamp: base.Add(2 * time.Millisecond)},
+		&storage.Span{TenantID: "default", TraceID: exportTraceB, SpanID: "0000000000000b0b", OperationName: "GET /",
+			ServiceName: "web", StartTime: base.Add(time.Hour), EndTime: base.Add(time.Hour + time.Millisecond), Duration: 1000},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+}
+
+func serveExport(s *Server, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces/{id}/export", s.handleExportTrace)
+	mux.HandleFunc("GET /api/traces/export", s.handleExportTraces)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestExportTrace_OTLP(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	seedExportTraces(t, repo, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	s := &Server{repo: repo}
+
+	rec := serveExport(s, "/api/traces/"+exportTraceA+"/export")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != contentTypeProtobuf {
+		t.Fatalf("status/content-type = %d/%q (%s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(rec.Body.Bytes(), &req); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(req.ResourceSpans) != 2 {
+		t.Fatalf("resource spans = %d, want 2 (web, orders)", len(req.ResourceSpans))
+	}
+	orders := req.ResourceSpans[1]
+	if got := orders.Resource.Attributes[0].Value.GetStringValue(); got != "orders" {
+		t.Errorf("second service = %q, want orders", got)
+	}
+	span := orders.ScopeSpans[0].Spans[0]
+	if len(span.TraceId) != 16 || len(span.ParentSpanId) != 8 || span.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("span ids/status = %x/%x/%v", span.TraceId, span.ParentSpanId, span.Status)
+	}
+	if len(span.Attributes) != 3 || span.Attributes[1].Value.GetIntValue() != 500 ||
+		!span.Attributes[2].Value.GetArrayValue().GetValues()[0].GetBoolValue() {
+		t.Errorf("attributes = %v", span.Attributes)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "db timeout" {
+		t.Errorf("events = %v, want the span's log", span.Events)
+	}
+
+	if rec := serveExport(s, "/api/traces/ffffffffffffffffffffffffffffffff/export"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown trace: status = %d, want 404", rec.Code)
+	}
+	if rec := serveExport(s, "/api/traces/"+exportTraceA+"/export?format=zipkin"); rec.Code != http.StatusBadRequest {
+		t.Errorf("bad format: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestExportTraces_JaegerBulk(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	seedExportTraces(t, repo, base)
+	s := &Server{repo: repo}
+
+	if rec := serveExport(s, "/api/traces/export?format=jaeger"); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing range: status = %d, want 400", rec.Code)
+	}
+
+	rec := serveExport(s, "/api/traces/export?format=jaeger&start=2026-03-01T00:00:00Z&end=2026-03-02T00:00:00Z")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	var out struct {
+		Data  []jaegerTrace `json:"data"`
+		Total int           `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(out.Data) != 2 || out.Total != 2 || out.Data[0].TraceID != exportTraceA {
+		t.Fatalf("data = %+v, want traces A and B in order", out.Data)
+	}
+	a := out.Data[0]
+	if len(a.Spans) != 2 || len(a.Processes) != 2 {
+		t.Fatalf("trace A spans/processes = %d/%d", len(a.Spans), len(a.Processes))
+	}
+	child := a.Spans[1]
+	if len(child.References) != 1 || child.References[0].SpanID != "00f067aa0ba902b7" || child.StartTime != base.Add(time.Millisecond).UnixMicro() {
+		t.Errorf("child refs/start = %+v/%d", child.References, child.StartTime)
+	}
+	tags := map[string]jaegerKV{}
+	for _, kv := range child.Tags {
+		tags[kv.Key] = kv
+	}
+	if tags["error"].Value != true || tags["http.status_code"].Type != "int64" || tags["tags"].Type != "string" {
+		t.Errorf("tags = %+v", child.Tags)
+	}
+	if len(child.Logs) != 1 || child.Logs[0].Fields[0].Value != "db timeout" {
+		t.Errorf("logs = %+v", child.Logs)
+	}
+
+	rec = serveExport(s, "/api/traces/export?format=jaeger&limit=1&start=2026-03-01T00:00:00Z&end=2026-03-02T00:00:00Z")
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || len(out.Data) != 1 {
+		t.Errorf("limit=1: data = %d traces, err %v", len(out.Data), err)
+	}
+}
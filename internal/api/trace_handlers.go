@@ -1,15 +1,49 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 )
 
+// regionFilter returns r's context restricted to the ?region= query
+// parameter, if given. Rows are stamped with the ingesting instance's
+// REGION, so this narrows a shared database to one region's data.
+func regionFilter(r *http.Request) context.Context {
+	return storage.WithRegionFilter(r.Context(), r.URL.Query().Get("region"))
+}
+
+// attributeFilters collects ?attr.<key>=<value> parameters, e.g.
+// ?attr.http.status_code=500, sorted by key so the query is stable.
+func attributeFilters(r *http.Request) []storage.AttributeFilter {
+	var out []storage.AttributeFilter
+	for param, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(param, "attr.")
+		if !ok || key == "" {
+			continue
+		}
+		for _, v := range values {
+			out = append(out, storage.AttributeFilter{Key: key, Value: v})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Key != out[j].Key {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
 // handleGetTraces handles GET /api/traces
 func (s *Server) handleGetTraces(w http.ResponseWriter, r *http.Request) {
 	limit := 20
@@ -37,15 +71,19 @@ func (s *Server) handleGetTraces(w http.ResponseWriter, r *http.Request) {
 	sortBy := r.URL.Query().Get("sort_by")
 	orderBy := r.URL.Query().Get("order_by")
 
-	response, err := s.repo.GetTracesFiltered(r.Context(), start, end, serviceNames, status, search, limit, offset, sortBy, orderBy)
+	ctx, explain := withExplain(regionFilter(r), r)
+	response, err := s.repo.GetTracesFiltered(ctx, start, end, serviceNames, status, search, limit, offset, sortBy, orderBy, attributeFilters(r)...)
+	if errors.Is(err, storage.ErrAttributeNotIndexed) {
+		http.Error(w, err.Error()+" (see SPAN_ATTRIBUTE_INDEX_KEYS)", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		slog.Error("Failed to get filtered traces", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(views.TracesResponseFromModel(response))
+	writeQueryJSON(w, views.TracesResponseFromModel(response), explain)
 }
 
 // handleGetTraceByID handles GET /api/traces/{id}
@@ -66,3 +104,90 @@ func (s *Server) handleGetTraceByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(views.TraceFromModel(*trace))
 }
+
+// handleGetTraceTree handles GET /api/traces/{id}/tree — the trace as a
+// nested span tree with offsets, self time and events, for waterfall and
+// flamegraph rendering.
+func (s *Server) handleGetTraceTree(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("id")
+	if traceID == "" {
+		http.Error(w, "missing trace id", http.StatusBadRequest)
+		return
+	}
+
+	tree, err := s.repo.GetTraceTree(r.Context(), traceID)
+	if err != nil {
+		slog.Error("Trace not found", "trace_id", traceID, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tree)
+}
+
+// handleGetCriticalPath handles GET /api/traces/{id}/critical-path — the
+// spans that determined the trace's duration, with their contribution.
+func (s *Server) handleGetCriticalPath(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("id")
+	if traceID == "" {
+		http.Error(w, "missing trace id", http.StatusBadRequest)
+		return
+	}
+
+	cp, err := s.repo.GetCriticalPath(r.Context(), traceID)
+	if err != nil {
+		slog.Error("Trace not found", "trace_id", traceID, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cp)
+}
+
+// handleCompareTraces handles GET /api/traces/compare?a={id}&b={id} — the
+// two traces aligned by service and operation, with per-span duration
+// deltas, spans present in only one of them and attribute differences.
+func (s *Server) handleCompareTraces(w http.ResponseWriter, r *http.Request) {
+	a, b := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	if a == "" || b == "" {
+		http.Error(w, "a and b trace ids are required", http.StatusBadRequest)
+		return
+	}
+
+	cmp, err := s.repo.GetTraceComparison(r.Context(), a, b)
+	if err != nil {
+		slog.Error("Trace not found", "trace_a", a, "trace_b", b, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, "trace not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(cmp)
+}
+
+// handleGetTraceInsight handles GET /api/traces/{id}/insight — the AI
+// root-cause summary of an error trace. 404 until the AI pipeline has
+// analysed the trace (or when AI is disabled).
+func (s *Server) handleGetTraceInsight(w http.ResponseWriter, r *http.Request) {
+	traceID := r.PathValue("id")
+	if traceID == "" {
+		http.Error(w, "missing trace id", http.StatusBadRequest)
+		return
+	}
+
+	insight, err := s.repo.GetTraceInsight(r.Context(), traceID)
+	if errors.Is(err, storage.ErrTraceInsightNotFound) {
+		http.Error(w, "trace insight not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to get trace insight", "trace_id", traceID, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(views.TraceInsightFromModel(*insight))
+}
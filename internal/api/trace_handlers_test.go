@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestHandleGetTraces_AttributeFilters(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	repo.SetAttributeIndex([]string{"http.status_code"})
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces", srv.handleGetTraces)
+
+	for target, want := range map[string]int{
+		"/api/traces?attr.http.status_code=500": http.StatusOK,
+		"/api/traces?attr.user.id=u1":           http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d (body %q)", target, rec.Code, want, rec.Body.String())
+		}
+	}
+}
+
+func TestAttributeFilters(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/traces?service=a&attr.b=2&attr.a=1&attr.=x&attr.b=1", nil)
+	got := attributeFilters(r)
+	if len(got) != 3 || got[0].Key != "a" || got[1].Value != "1" || got[2].Value != "2" {
+		t.Errorf("attributeFilters = %+v", got)
+	}
+}
+
+func TestHandleGetTraceInsight(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	if err := repo.SaveTraceInsight(context.Background(), &storage.TraceInsight{TraceID: "t1", ServiceName: "payment", Summary: "card gateway timed out"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces/{id}/insight", srv.handleGetTraceInsight)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/t1/insight", nil))
+	var got views.TraceInsight
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Summary != "card gateway timed out" || got.ServiceName != "payment" {
+		t.Errorf("insight = %+v, err = %v", got, err)
+	}
+	if strings.Contains(rec.Body.String(), "tenant_id") {
+		t.Errorf("insight leaks tenant_id: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/t2/insight", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown trace: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestHandleCompareTraces(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	for i, id := range []string{"aaaa", "bbbb"} {
+		end := now.Add(time.Duration(i+1) * 10 * time.Millisecond)
+		rows := []any{
+			&storage.Trace{TenantID: storage.DefaultTenantID, TraceID: id, ServiceName: "api", Timestamp: now},
+			&storage.Span{TenantID: storage.DefaultTenantID, TraceID: id, SpanID: id + "01", ServiceName: "api", OperationName: "GET /", StartTime: now, EndTime: end, DurationNs: end.Sub(now).Nanoseconds()},
+		}
+		for _, row := range rows {
+			if err := repo.DB().Create(row).Error; err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+		}
+	}
+	srv := &Server{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces/{id}", srv.handleGetTraceByID)
+	mux.HandleFunc("GET /api/traces/compare", srv.handleCompareTraces)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/compare?a=aaaa&b=bbbb", nil))
+	var got storage.TraceComparison
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &got) != nil {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if len(got.Matched) != 1 || got.Matched[0].DeltaUs != 10_000 {
+		t.Errorf("comparison = %+v, want the root 10ms slower", got)
+	}
+
+	for target, want := range map[string]int{
+		"/api/traces/compare?a=aaaa":           http.StatusBadRequest,
+		"/api/traces/compare?a=aaaa&b=missing": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
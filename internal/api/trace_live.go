@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
+)
+
+const (
+	// liveTraceMaxDuration ends a live trace stream however busy the trace.
+	liveTraceMaxDuration = 10 * time.Minute
+	// liveTraceIdleTimeout ends a stream when no span arrived for this long
+	// — the end signal when the trace finalizer is off — and marks a trace
+	// whose newest span ended longer ago than this as already complete.
+	liveTraceIdleTimeout = 2 * time.Minute
+	// liveTraceHeartbeat keeps proxies from closing a quiet stream.
+	liveTraceHeartbeat = 15 * time.Second
+)
+
+// handleLiveTrace handles GET /api/traces/{id}/live — a server-sent event
+// stream of a trace as it is assembled. The spans stored so far are sent
+// at once, then every span persisted afterwards, until the trace finalizer
+// recomputes the trace:
+//
+//	event: spans      data: [Span...]  (the first event, possibly [])
+//	event: finalized  data: Trace      (summary without spans; stream ends)
+//	event: end        data: {"reason": "idle" | "timeout"}  (stream ends)
+//
+// A trace whose newest span ended over liveTraceIdleTimeout ago is treated
+// as complete: the stream sends its spans and finalizes immediately. An API
+// key restricted to some services only sees those services' spans.
+func (s *Server) handleLiveTrace(w http.ResponseWriter, r *http.Request) {
+	id, _ := traceid.Parse(r.PathValue("id"))
+	if id == "" {
+		http.Error(w, "missing trace id", http.StatusBadRequest)
+		return
+	}
+	if s.traceStreams == nil {
+		http.Error(w, "live trace streaming is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := r.Context()
+
+	// Subscribe before loading so no span falls between the two; the seen
+	// set drops the overlap.
+	watch, err := s.traceStreams.Watch(storage.TenantFromContext(ctx), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer watch.Close()
+
+	stored, err := s.repo.GetTraceSpans(ctx, id)
+	if err != nil {
+		slog.Error("Failed to load live trace", "trace_id", id, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	rc := http.NewResponseController(w)
+	scope := realtime.ScopeFromContext(ctx)
+	seen := make(map[string]struct{}, len(stored))
+	// send writes the spans not sent yet; the first call always writes.
+	first := true
+	send := func(spans []storage.Span) error {
+		fresh := make([]storage.Span, 0, len(spans))
+		for _, sp := range spans {
+			if !scope.Allows(sp.TenantID, sp.ServiceName) {
+				continue
+			}
+			if _, ok := seen[sp.SpanID]; !ok {
+				seen[sp.SpanID] = struct{}{}
+				fresh = append(fresh, sp)
+			}
+		}
+		if len(fresh) == 0 && !first {
+			return nil
+		}
+		first = false
+		return writeSSEEvent(rc, w, "spans", views.SpansFromModels(fresh))
+	}
+	finalize := func() {
+		// The trace row may be missing (all spans sampled out of the row
+		// path); the summary is then just the ID.
+		summary := views.Trace{TraceID: id}
+		if t, err := s.repo.GetTrace(ctx, id); err == nil {
+			t.Spans, t.Logs = nil, nil
+			summary = views.TraceFromModel(*t)
+		}
+		_ = writeSSEEvent(rc, w, "finalized", summary)
+	}
+
+	if err := send(stored); err != nil {
+		return
+	}
+	if len(stored) > 0 && latestSpanEnd(stored).Before(time.Now().Add(-liveTraceIdleTimeout)) {
+		finalize()
+		return
+	}
+
+	deadline := time.NewTimer(liveTraceMaxDuration)
+	defer deadline.Stop()
+	idle := time.NewTimer(liveTraceIdleTimeout)
+	defer idle.Stop()
+	heartbeat := time.NewTicker(liveTraceHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sp := <-watch.Spans():
+			batch := []storage.Span{sp}
+		drain:
+			for {
+				select {
+				case more := <-watch.Spans():
+					batch = append(batch, more)
+				default:
+					break drain
+				}
+			}
+			if err := send(batch); err != nil {
+				return
+			}
+			idle.Reset(liveTraceIdleTimeout)
+		case <-watch.Resync():
+			spans, err := s.repo.GetTraceSpans(ctx, id)
+			if err != nil {
+				slog.Warn("Failed to resync live trace", "trace_id", id, "error", err) // #nosec G706 -- slog uses structured k/v fields
+				continue
+			}
+			if err := send(spans); err != nil {
+				return
+			}
+		case <-watch.Finalized():
+			// Spans published just before finalization are still queued.
+			spans, err := s.repo.GetTraceSpans(ctx, id)
+			if err == nil {
+				_ = send(spans)
+			}
+			finalize()
+			return
+		case <-idle.C:
+			_ = writeSSEEvent(rc, w, "end", map[string]string{"reason": "idle"})
+			return
+		case <-deadline.C:
+			_ = writeSSEEvent(rc, w, "end", map[string]string{"reason": "timeout"})
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			_ = rc.Flush()
+		}
+	}
+}
+
+// latestSpanEnd returns the latest end time among spans.
+func latestSpanEnd(spans []storage.Span) time.Time {
+	var latest time.Time
+	for _, sp := range spans {
+		if sp.EndTime.After(latest) {
+			latest = sp.EndTime
+		}
+	}
+	return latest
+}
+
+// writeSSEEvent writes v as one JSON server-sent event and flushes it.
+func writeSSEEvent(rc *http.ResponseController, w http.ResponseWriter, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
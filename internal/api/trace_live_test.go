@@ -0,0 +1,110 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const liveTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+// readSSE returns the next event name and data from an event stream.
+func readSSE(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v (event %q)", err, event)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandleLiveTrace(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	now := time.Now()
+	root := storage.Span{TenantID: storage.DefaultTenantID, TraceID: liveTraceID, SpanID: "root", ServiceName: "checkout", OperationName: "POST /pay", StartTime: now, EndTime: now.Add(time.Second)}
+	if err := repo.BatchCreateSpans([]storage.Span{root}); err != nil {
+		t.Fatal(err)
+	}
+
+	streams := realtime.NewTraceStreams()
+	srv := &Server{repo: repo, traceStreams: streams}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces/{id}/live", srv.handleLiveTrace)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/traces/" + liveTraceID + "/live")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	body := bufio.NewReader(resp.Body)
+
+	var spans []views.Span
+	if event, data := readSSE(t, body); event != "spans" || json.Unmarshal([]byte(data), &spans) != nil || len(spans) != 1 || spans[0].SpanID != "root" {
+		t.Fatalf("first event = %s %s", event, data)
+	}
+
+	// A span persisted after connecting is pushed; a republished one is not
+	// sent twice.
+	child := storage.Span{TenantID: storage.DefaultTenantID, TraceID: liveTraceID, SpanID: "child", ParentSpanID: "root", ServiceName: "payment", StartTime: now, EndTime: now.Add(time.Second)}
+	if err := repo.BatchCreateSpans([]storage.Span{child}); err != nil {
+		t.Fatal(err)
+	}
+	streams.PublishSpan(root)
+	streams.PublishSpan(child)
+	if event, data := readSSE(t, body); event != "spans" || json.Unmarshal([]byte(data), &spans) != nil || len(spans) != 1 || spans[0].SpanID != "child" {
+		t.Fatalf("pushed event = %s %s", event, data)
+	}
+
+	streams.PublishFinalized([]storage.TraceKey{{TenantID: storage.DefaultTenantID, TraceID: liveTraceID}})
+	var summary views.Trace
+	if event, data := readSSE(t, body); event != "finalized" || json.Unmarshal([]byte(data), &summary) != nil || summary.TraceID != liveTraceID {
+		t.Fatalf("final event = %s %s", event, data)
+	}
+}
+
+func TestHandleLiveTrace_CompleteTrace(t *testing.T) {
+	repo := newAPITestRepoWithoutFTS(t)
+	old := time.Now().Add(-time.Hour)
+	if err := repo.BatchCreateSpans([]storage.Span{{TenantID: storage.DefaultTenantID, TraceID: liveTraceID, SpanID: "root", StartTime: old, EndTime: old.Add(time.Second)}}); err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{repo: repo, traceStreams: realtime.NewTraceStreams()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/traces/{id}/live", srv.handleLiveTrace)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/"+liveTraceID+"/live", nil))
+	if got := rec.Body.String(); !strings.Contains(got, "event: spans\n") || !strings.Contains(got, "event: finalized\n") {
+		t.Errorf("stream = %q, want spans then finalized", got)
+	}
+
+	srv.traceStreams = nil
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/traces/"+liveTraceID+"/live", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without streams: status = %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleGetTrafficMetrics_Bucket(t *testing.T) {
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t)}
+	for query, want := range map[string]int{
+		"":             http.StatusOK,
+		"?bucket=5m":   http.StatusOK,
+		"?bucket=1h":   http.StatusOK,
+		"?bucket=2m":   http.StatusBadRequest,
+		"?bucket=soon": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		srv.handleGetTrafficMetrics(rec, httptest.NewRequest(http.MethodGet, "/api/metrics/traffic"+query, nil))
+		if rec.Code != want {
+			t.Errorf("%q: status %d, want %d (%s)", query, rec.Code, want, rec.Body.String())
+		}
+	}
+}
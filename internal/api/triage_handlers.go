@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/api/views"
+	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
+	"github.com/RandomCodeSpace/otelcontext/internal/httpconst"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// maxBulkTriageBody bounds POST /api/{errors,anomalies}/bulk bodies.
+	maxBulkTriageBody = 1 << 20
+
+	// maxBulkTriageItems caps the items one bulk request may change, whether
+	// listed by ID or selected by filter.
+	maxBulkTriageItems = 10_000
+
+	// maxTriageKeyLen matches the TriageState.Key column.
+	maxTriageKeyLen = 191
+
+	// bulkTriageIssueLookback is how far back a filter looks for issues.
+	// Error logs past retention are gone anyway.
+	bulkTriageIssueLookback = 90 * 24 * time.Hour
+
+	defaultAnomalyLimit = 100
+)
+
+// bulkTriageRequest is the body of POST /api/errors/bulk and
+// /api/anomalies/bulk. Exactly one of IDs and Filter selects the items.
+type bulkTriageRequest struct {
+	Action   string            `json:"action"`   // resolve | ignore | reopen | assign
+	Assignee string            `json:"assignee"` // assign only; empty unassigns
+	IDs      []string          `json:"ids"`      // issue fingerprints or anomaly IDs
+	Filter   *bulkTriageFilter `json:"filter"`
+	DryRun   bool              `json:"dry_run"` // report the selection without changing it
+}
+
+// bulkTriageFilter selects items by attribute. All set fields must match.
+type bulkTriageFilter struct {
+	ServiceName []string `json:"service_name"`
+	OlderThan   string   `json:"older_than"` // e.g. "7d", "36h": last seen before now minus this
+	Status      string   `json:"status"`     // open | resolved | ignored
+	Type        string   `json:"type"`       // anomalies only: error_spike | latency_spike | metric_zscore
+}
+
+// bulkTriageResponse reports what a bulk request selected and changed.
+type bulkTriageResponse struct {
+	Action    string   `json:"action"`
+	DryRun    bool     `json:"dry_run"`
+	Matched   int      `json:"matched"`
+	Updated   int      `json:"updated"`
+	IDs       []string `json:"ids"`
+	Truncated bool     `json:"truncated"` // the filter matched more than maxBulkTriageItems, or the issue scan was capped
+}
+
+// handleBulkTriageIssues handles POST /api/errors/bulk — resolve, ignore,
+// reopen or assign many errors explorer groups (issues) at once, by
+// fingerprint or by filter, e.g. every issue of one service not seen for 7
+// days: {"action":"resolve","filter":{"service_name":["x"],"older_than":"7d"}}.
+func (s *Server) handleBulkTriageIssues(w http.ResponseWriter, r *http.Request) {
+	s.bulkTriage(w, r, storage.TriageKindIssue, s.selectIssues)
+}
+
+// handleBulkTriageAnomalies handles POST /api/anomalies/bulk — the anomaly
+// counterpart of handleBulkTriageIssues, selecting GraphRAG anomalies by ID
+// or filter.
+func (s *Server) handleBulkTriageAnomalies(w http.ResponseWriter, r *http.Request) {
+	if s.graphRAG == nil {
+		http.Error(w, "GraphRAG not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	s.bulkTriage(w, r, storage.TriageKindAnomaly, s.selectAnomalies)
+}
+
+// triageSelector returns the IDs matching f, and whether the match was cut
+// short.
+type triageSelector func(ctx context.Context, f *bulkTriageFilter, cutoff time.Time) ([]string, bool, error)
+
+func (s *Server) bulkTriage(w http.ResponseWriter, r *http.Request, kind string, selectIDs triageSelector) {
+	var req bulkTriageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBulkTriageBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if err := validateBulkTriage(&req, kind); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cutoff time.Time
+	if req.Filter != nil && req.Filter.OlderThan != "" {
+		age, err := parseAge(req.Filter.OlderThan)
+		if err != nil {
+			http.Error(w, "invalid filter.older_than: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	ctx := r.Context()
+	resp := bulkTriageResponse{Action: req.Action, DryRun: req.DryRun, IDs: req.IDs}
+	if req.Filter != nil {
+		ids, truncated, err := selectIDs(ctx, req.Filter, cutoff)
+		if err != nil {
+			slog.Error("Failed to select items for bulk triage", "kind", kind, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.IDs, resp.Truncated = ids, truncated
+	}
+	if resp.IDs == nil {
+		resp.IDs = []string{}
+	}
+	resp.Matched = len(resp.IDs)
+
+	if !req.DryRun && len(resp.IDs) > 0 {
+		n, err := s.repo.ApplyTriage(ctx, kind, resp.IDs, storage.TriageAction{Action: req.Action, Assignee: req.Assignee})
+		if err != nil {
+			slog.Error("Failed to apply bulk triage", "kind", kind, "action", req.Action, "error", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Updated = n
+		if kind == storage.TriageKindIssue && s.cache != nil {
+			s.cache.DeletePrefix("errors:" + storage.TenantFromContext(ctx) + ":")
+		}
+	}
+
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func validateBulkTriage(req *bulkTriageRequest, kind string) error {
+	switch req.Action {
+	case storage.TriageActionResolve, storage.TriageActionIgnore, storage.TriageActionReopen, storage.TriageActionAssign:
+	default:
+		return fmt.Errorf("action must be resolve, ignore, reopen or assign")
+	}
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		return fmt.Errorf("exactly one of ids and filter is required")
+	}
+	if len(req.IDs) > maxBulkTriageItems {
+		return fmt.Errorf("at most %d ids per request", maxBulkTriageItems)
+	}
+	for _, id := range req.IDs {
+		if id == "" || len(id) > maxTriageKeyLen {
+			return fmt.Errorf("ids must be 1..%d characters", maxTriageKeyLen)
+		}
+	}
+	if f := req.Filter; f != nil {
+		switch f.Status {
+		case "", storage.TriageStatusOpen, storage.TriageStatusResolved, storage.TriageStatusIgnored:
+		default:
+			return fmt.Errorf("filter.status must be open, resolved or ignored")
+		}
+		if f.Type != "" && kind != storage.TriageKindAnomaly {
+			return fmt.Errorf("filter.type applies to anomalies only")
+		}
+	}
+	return nil
+}
+
+// parseAge parses a Go duration or a whole number of days ("7d").
+func parseAge(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return d, nil
+}
+
+// selectIssues returns the fingerprints of the errors explorer groups of the
+// last bulkTriageIssueLookback that match f and were last seen before cutoff.
+func (s *Server) selectIssues(ctx context.Context, f *bulkTriageFilter, cutoff time.Time) ([]string, bool, error) {
+	now := time.Now()
+	resp, err := s.repo.GetErrorGroups(ctx, storage.ErrorGroupQuery{
+		Start:        now.Add(-bulkTriageIssueLookback),
+		End:          now,
+		ServiceNames: f.ServiceName,
+		Status:       f.Status,
+		Limit:        maxBulkTriageItems + 1,
+		Buckets:      1,
+		Samples:      1,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	var ids []string
+	for _, g := range resp.Groups {
+		if !cutoff.IsZero() && !g.LastSeen.Before(cutoff) {
+			continue
+		}
+		ids = append(ids, g.Fingerprint)
+	}
+	truncated := resp.Truncated
+	if len(ids) > maxBulkTriageItems {
+		ids, truncated = ids[:maxBulkTriageItems], true
+	}
+	return ids, truncated, nil
+}
+
+// selectAnomalies returns the IDs of the tenant's anomalies that match f and
+// were detected before cutoff.
+func (s *Server) selectAnomalies(ctx context.Context, f *bulkTriageFilter, cutoff time.Time) ([]string, bool, error) {
+	anomalies, err := s.triagedAnomalies(ctx, f.ServiceName, f.Type, f.Status, time.Time{})
+	if err != nil {
+		return nil, false, err
+	}
+	var ids []string
+	for _, a := range anomalies {
+		if !cutoff.IsZero() && !a.Timestamp.Before(cutoff) {
+			continue
+		}
+		ids = append(ids, a.ID)
+	}
+	if len(ids) > maxBulkTriageItems {
+		return ids[:maxBulkTriageItems], true, nil
+	}
+	return ids, false, nil
+}
+
+// triagedAnomalies returns the tenant's anomalies detected after since,
+// newest first, with their triage status, filtered by service, type and
+// status when set.
+func (s *Server) triagedAnomalies(ctx context.Context, services []string, anomalyType, status string, since time.Time) ([]views.AnomalyNode, error) {
+	var candidates []*graphrag.AnomalyNode
+	for _, a := range s.graphRAG.AnomalyTimeline(ctx, since) {
+		if len(services) > 0 && !slices.Contains(services, a.Service) {
+			continue
+		}
+		if anomalyType != "" && string(a.Type) != anomalyType {
+			continue
+		}
+		candidates = append(candidates, a)
+	}
+	ids := make([]string, len(candidates))
+	for i, a := range candidates {
+		ids[i] = a.ID
+	}
+	states, err := s.repo.TriageStates(ctx, storage.TriageKindAnomaly, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]views.AnomalyNode, 0, len(candidates))
+	for _, a := range candidates {
+		v := views.AnomalyNodeFromModel(*a)
+		v.Status = storage.TriageStatusOpen
+		if st, ok := states[a.ID]; ok {
+			v.Status, v.Assignee = st.EffectiveStatus(a.Timestamp), st.Assignee
+		}
+		if status != "" && v.Status != status {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// handleGetAnomalies handles GET /api/anomalies — the tenant's GraphRAG
+// anomalies, newest first, with triage status and assignee. Query params:
+// service_name (repeatable), type, status, since (RFC3339; default last
+// 24h), limit (default 100, max 1000).
+func (s *Server) handleGetAnomalies(w http.ResponseWriter, r *http.Request) {
+	if s.graphRAG == nil {
+		http.Error(w, "GraphRAG not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	since := time.Now().Add(-24 * time.Hour)
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+	limit := defaultAnomalyLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 1000)
+	}
+
+	anomalies, err := s.triagedAnomalies(r.Context(), q["service_name"], q.Get("type"), q.Get("status"), since)
+	if err != nil {
+		slog.Error("Failed to list anomalies", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	total := len(anomalies)
+	if len(anomalies) > limit {
+		anomalies = anomalies[:limit]
+	}
+	w.Header().Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]any{"anomalies": anomalies, "total": total})
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/cache"
+	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func newTriageTestServer(t *testing.T) (*Server, *http.ServeMux) {
+	t.Helper()
+	c := cache.New()
+	t.Cleanup(c.Stop)
+	srv := &Server{repo: newAPITestRepoWithoutFTS(t), cache: c, graphRAG: graphrag.New(nil, nil, nil, nil, graphrag.DefaultConfig())}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/errors", srv.handleGetErrors)
+	mux.HandleFunc("POST /api/errors/bulk", srv.handleBulkTriageIssues)
+	mux.HandleFunc("GET /api/anomalies", srv.handleGetAnomalies)
+	mux.HandleFunc("POST /api/anomalies/bulk", srv.handleBulkTriageAnomalies)
+	return srv, mux
+}
+
+func postBulk(t *testing.T, mux *http.ServeMux, path, body string) (*httptest.ResponseRecorder, bulkTriageResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	var resp bulkTriageResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec, resp
+}
+
+func TestBulkTriageIssues(t *testing.T) {
+	srv, mux := newTriageTestServer(t)
+	now := time.Now()
+	if err := srv.repo.BatchCreateLogs([]storage.Log{
+		{TenantID: storage.DefaultTenantID, Severity: "ERROR", ServiceName: "checkout", Body: "cart 12 missing", Timestamp: now.Add(-10 * 24 * time.Hour)},
+		{TenantID: storage.DefaultTenantID, Severity: "ERROR", ServiceName: "checkout", Body: "payment timeout", Timestamp: now.Add(-time.Hour)},
+		{TenantID: storage.DefaultTenantID, Severity: "ERROR", ServiceName: "search", Body: "index stale", Timestamp: now.Add(-9 * 24 * time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	getErrors := func(query string) storage.ErrorGroupsResponse {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/errors"+query, nil))
+		var resp storage.ErrorGroupsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: %v (%s)", query, err, rec.Body.String())
+		}
+		return resp
+	}
+	window := "?start=" + now.Add(-30*24*time.Hour).UTC().Format(time.RFC3339)
+	if got := getErrors(window + "&status=open"); got.TotalGroups != 3 { // primes the cache
+		t.Fatalf("open groups before triage = %d", got.TotalGroups)
+	}
+
+	filter := `{"action":"resolve","filter":{"service_name":["checkout"],"older_than":"7d"}%s}`
+	rec, dry := postBulk(t, mux, "/api/errors/bulk", strings.Replace(filter, "%s", `,"dry_run":true`, 1))
+	if rec.Code != http.StatusOK || dry.Matched != 1 || dry.Updated != 0 {
+		t.Fatalf("dry run: %d %+v", rec.Code, dry)
+	}
+	rec, res := postBulk(t, mux, "/api/errors/bulk", strings.Replace(filter, "%s", "", 1))
+	if rec.Code != http.StatusOK || res.Matched != 1 || res.Updated != 1 || res.IDs[0] != dry.IDs[0] {
+		t.Fatalf("resolve: %d %+v", rec.Code, res)
+	}
+
+	open := getErrors(window + "&status=open")
+	if open.TotalGroups != 2 {
+		t.Errorf("open groups after resolve = %d, want 2 (cache must be dropped)", open.TotalGroups)
+	}
+	resolved := getErrors(window + "&status=resolved")
+	if resolved.TotalGroups != 1 || resolved.Groups[0].Message != "cart <n> missing" {
+		t.Errorf("resolved groups = %+v", resolved.Groups)
+	}
+
+	rec, res = postBulk(t, mux, "/api/errors/bulk", `{"action":"assign","assignee":"sam","ids":["`+resolved.Groups[0].Fingerprint+`"]}`)
+	if rec.Code != http.StatusOK || res.Updated != 1 {
+		t.Fatalf("assign: %d %+v", rec.Code, res)
+	}
+	if g := getErrors(window + "&status=resolved").Groups[0]; g.Assignee != "sam" {
+		t.Errorf("assignee = %q", g.Assignee)
+	}
+
+	for name, body := range map[string]string{
+		"bad action":      `{"action":"delete","ids":["x"]}`,
+		"no selector":     `{"action":"resolve"}`,
+		"both selectors":  `{"action":"resolve","ids":["x"],"filter":{}}`,
+		"bad older_than":  `{"action":"resolve","filter":{"older_than":"soon"}}`,
+		"zero older_than": `{"action":"resolve","filter":{"older_than":"0d"}}`,
+		"bad status":      `{"action":"resolve","filter":{"status":"done"}}`,
+		"type on issues":  `{"action":"resolve","filter":{"type":"error_spike"}}`,
+		"long id":         `{"action":"resolve","ids":["` + strings.Repeat("x", 200) + `"]}`,
+		"not json":        `resolve`,
+	} {
+		if rec, _ := postBulk(t, mux, "/api/errors/bulk", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, rec.Code)
+		}
+	}
+}
+
+func TestBulkTriageAnomalies(t *testing.T) {
+	srv, mux := newTriageTestServer(t)
+	now := time.Now()
+	for _, a := range []graphrag.AnomalyNode{
+		{ID: "anom_a", Type: graphrag.AnomalyErrorSpike, Service: "checkout", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "anom_b", Type: graphrag.AnomalyLatencySpike, Service: "checkout", Timestamp: now.Add(-time.Hour)},
+		{ID: "anom_c", Type: graphrag.AnomalyErrorSpike, Service: "search", Timestamp: now.Add(-time.Minute)},
+	} {
+		srv.graphRAG.RegisterAnomaly(storage.DefaultTenantID, a)
+	}
+
+	rec, res := postBulk(t, mux, "/api/anomalies/bulk", `{"action":"ignore","filter":{"type":"error_spike","older_than":"30m"}}`)
+	if rec.Code != http.StatusOK || res.Updated != 1 || res.IDs[0] != "anom_a" {
+		t.Fatalf("ignore: %d %+v", rec.Code, res)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/anomalies?status=open", nil))
+	var list struct {
+		Anomalies []struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"anomalies"`
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body.String())
+	}
+	if list.Total != 2 || list.Anomalies[0].ID != "anom_c" || list.Anomalies[1].ID != "anom_b" || list.Anomalies[0].Status != "open" {
+		t.Errorf("open anomalies = %+v", list)
+	}
+
+	srv.graphRAG = nil
+	if rec, _ := postBulk(t, mux, "/api/anomalies/bulk", `{"action":"ignore","ids":["anom_a"]}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without GraphRAG: status = %d, want 503", rec.Code)
+	}
+}
@@ -22,17 +22,31 @@ import (
 
 // Trace is the wire shape of a distributed trace summary.
 type Trace struct {
-	ID          uint      `json:"id"`
-	TraceID     string    `json:"trace_id"`
-	ServiceName string    `json:"service_name"`
-	Operation   string    `json:"operation"`
-	Status      string    `json:"status"`
-	Duration    int64     `json:"duration"` // microseconds, preserved for legacy consumers
-	DurationMs  float64   `json:"duration_ms"`
-	SpanCount   int       `json:"span_count"`
-	Timestamp   time.Time `json:"timestamp"`
-	Spans       []Span    `json:"spans,omitempty"`
-	Logs        []Log     `json:"logs,omitempty"`
+	ID          uint        `json:"id"`
+	TraceID     string      `json:"trace_id"`
+	ServiceName string      `json:"service_name"`
+	Operation   string      `json:"operation"`
+	Status      string      `json:"status"`
+	Duration    int64       `json:"duration"` // microseconds, preserved for legacy consumers
+	DurationMs  float64     `json:"duration_ms"`
+	SpanCount   int         `json:"span_count"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Region      string      `json:"region,omitempty"`
+	Spans       []Span      `json:"spans,omitempty"`
+	Logs        []Log       `json:"logs,omitempty"`
+	Links       []TraceLink `json:"links,omitempty"`
+}
+
+// TraceLink is the wire shape of a span link between this trace and
+// another, in either direction.
+type TraceLink struct {
+	Direction         string `json:"direction"` // outgoing | incoming
+	SpanID            string `json:"span_id"`   // the span of this trace
+	LinkedTraceID     string `json:"linked_trace_id"`
+	LinkedSpanID      string `json:"linked_span_id"`
+	LinkedServiceName string `json:"linked_service_name,omitempty"` // empty when the linked trace is not stored
+	TraceState        string `json:"trace_state,omitempty"`
+	AttributesJSON    string `json:"attributes_json,omitempty"`
 }
 
 // Span is the wire shape of a single operation inside a trace.
@@ -47,6 +61,7 @@ type Span struct {
 	Duration       int64     `json:"duration"`
 	ServiceName    string    `json:"service_name"`
 	AttributesJSON string    `json:"attributes_json"`
+	Region         string    `json:"region,omitempty"`
 }
 
 // Log is the wire shape of an ingested log record.
@@ -60,6 +75,8 @@ type Log struct {
 	AttributesJSON string    `json:"attributes_json"`
 	AIInsight      string    `json:"ai_insight"`
 	Timestamp      time.Time `json:"timestamp"`
+	Region         string    `json:"region,omitempty"`
+	Pending        bool      `json:"pending,omitempty"` // still queued in the DLQ, not yet stored
 }
 
 // MetricBucket is the wire shape of a pre-aggregated metric window.
@@ -73,6 +90,19 @@ type MetricBucket struct {
 	Sum            float64   `json:"sum"`
 	Count          int64     `json:"count"`
 	AttributesJSON string    `json:"attributes_json"`
+	Region         string    `json:"region,omitempty"`
+	// Exemplar: the sampled trace behind the window's highest value.
+	ExemplarTraceID string  `json:"exemplar_trace_id,omitempty"`
+	ExemplarSpanID  string  `json:"exemplar_span_id,omitempty"`
+	ExemplarValue   float64 `json:"exemplar_value,omitempty"`
+}
+
+// TraceInsight is the wire shape of an AI root-cause summary of a trace.
+type TraceInsight struct {
+	TraceID     string    `json:"trace_id"`
+	ServiceName string    `json:"service_name"`
+	Summary     string    `json:"summary"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // --- Compound response views ---
@@ -85,6 +115,21 @@ type TracesResponse struct {
 	Offset int     `json:"offset"`
 }
 
+// SearchResult is the /api/search response. Traces, Logs or Metrics holds
+// the matches, per Signal; the others are always empty.
+type SearchResult struct {
+	Signal    string         `json:"signal"`
+	Query     string         `json:"query"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Traces    []Trace        `json:"traces"`
+	Logs      []Log          `json:"logs"`
+	Metrics   []MetricBucket `json:"metrics"`
+	Matched   int            `json:"matched"`
+	Scanned   int            `json:"scanned"`
+	Truncated bool           `json:"truncated"`
+}
+
 // ServiceError is the top-failing-service entry on the dashboard.
 type ServiceError struct {
 	ServiceName string  `json:"service_name"`
@@ -101,6 +146,10 @@ type DashboardStats struct {
 	AvgLatencyMs       float64        `json:"avg_latency_ms"`
 	ErrorRate          float64        `json:"error_rate"`
 	ActiveServices     int64          `json:"active_services"`
+	P50Latency         int64          `json:"p50_latency"`
+	P75Latency         int64          `json:"p75_latency"`
+	P90Latency         int64          `json:"p90_latency"`
+	P95Latency         int64          `json:"p95_latency"`
 	P99Latency         int64          `json:"p99_latency"`
 	TopFailingServices []ServiceError `json:"top_failing_services"`
 }
@@ -160,6 +209,8 @@ type AnomalyNode struct {
 	Service   string    `json:"service"`
 	Evidence  string    `json:"evidence"`
 	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status,omitempty"` // triage status; set by GET /api/anomalies
+	Assignee  string    `json:"assignee,omitempty"`
 }
 
 // AffectedEntry is a service affected by an upstream failure.
@@ -224,6 +275,7 @@ func TraceFromModel(m storage.Trace) Trace {
 		DurationMs:  m.DurationMs,
 		SpanCount:   m.SpanCount,
 		Timestamp:   m.Timestamp,
+		Region:      m.Region,
 	}
 	if len(m.Spans) > 0 {
 		out.Spans = SpansFromModels(m.Spans)
@@ -231,6 +283,17 @@ func TraceFromModel(m storage.Trace) Trace {
 	if len(m.Logs) > 0 {
 		out.Logs = LogsFromModels(m.Logs)
 	}
+	for _, l := range m.Links {
+		out.Links = append(out.Links, TraceLink{
+			Direction:         l.Direction,
+			SpanID:            l.SpanID,
+			LinkedTraceID:     l.LinkedTraceID,
+			LinkedSpanID:      l.LinkedSpanID,
+			LinkedServiceName: l.LinkedServiceName,
+			TraceState:        l.TraceState,
+			AttributesJSON:    string(l.AttributesJSON),
+		})
+	}
 	return out
 }
 
@@ -256,6 +319,7 @@ func SpanFromModel(m storage.Span) Span {
 		Duration:       m.Duration,
 		ServiceName:    m.ServiceName,
 		AttributesJSON: string(m.AttributesJSON),
+		Region:         m.Region,
 	}
 }
 
@@ -280,6 +344,7 @@ func LogFromModel(m storage.Log) Log {
 		AttributesJSON: string(m.AttributesJSON),
 		AIInsight:      string(m.AIInsight),
 		Timestamp:      m.Timestamp,
+		Region:         m.Region,
 	}
 }
 
@@ -292,18 +357,32 @@ func LogsFromModels(ms []storage.Log) []Log {
 	return out
 }
 
+// TraceInsightFromModel converts a storage.TraceInsight into its view.
+func TraceInsightFromModel(m storage.TraceInsight) TraceInsight {
+	return TraceInsight{
+		TraceID:     m.TraceID,
+		ServiceName: m.ServiceName,
+		Summary:     string(m.Summary),
+		CreatedAt:   m.CreatedAt,
+	}
+}
+
 // MetricBucketFromModel converts a storage.MetricBucket into its view.
 func MetricBucketFromModel(m storage.MetricBucket) MetricBucket {
 	return MetricBucket{
-		ID:             m.ID,
-		Name:           m.Name,
-		ServiceName:    m.ServiceName,
-		TimeBucket:     m.TimeBucket,
-		Min:            m.Min,
-		Max:            m.Max,
-		Sum:            m.Sum,
-		Count:          m.Count,
-		AttributesJSON: string(m.AttributesJSON),
+		ID:              m.ID,
+		Name:            m.Name,
+		ServiceName:     m.ServiceName,
+		TimeBucket:      m.TimeBucket,
+		Min:             m.Min,
+		Max:             m.Max,
+		Sum:             m.Sum,
+		Count:           m.Count,
+		AttributesJSON:  string(m.AttributesJSON),
+		Region:          m.Region,
+		ExemplarTraceID: m.ExemplarTraceID,
+		ExemplarSpanID:  m.ExemplarSpanID,
+		ExemplarValue:   m.ExemplarValue,
 	}
 }
 
@@ -329,6 +408,25 @@ func TracesResponseFromModel(r *storage.TracesResponse) TracesResponse {
 	}
 }
 
+// SearchResultFromModel wraps a repo SearchResult into the view form.
+func SearchResultFromModel(r *storage.SearchResult) SearchResult {
+	if r == nil {
+		return SearchResult{Traces: []Trace{}, Logs: []Log{}, Metrics: []MetricBucket{}}
+	}
+	return SearchResult{
+		Signal:    r.Signal,
+		Query:     r.Query,
+		Start:     r.Start,
+		End:       r.End,
+		Traces:    TracesFromModels(r.Traces),
+		Logs:      LogsFromModels(r.Logs),
+		Metrics:   MetricBucketsFromModels(r.Metrics),
+		Matched:   r.Matched,
+		Scanned:   r.Scanned,
+		Truncated: r.Truncated,
+	}
+}
+
 // DashboardStatsFromModel converts repo stats into the view form.
 func DashboardStatsFromModel(s *storage.DashboardStats) DashboardStats {
 	if s == nil {
@@ -341,6 +439,10 @@ func DashboardStatsFromModel(s *storage.DashboardStats) DashboardStats {
 		AvgLatencyMs:   s.AvgLatencyMs,
 		ErrorRate:      s.ErrorRate,
 		ActiveServices: s.ActiveServices,
+		P50Latency:     s.P50Latency,
+		P75Latency:     s.P75Latency,
+		P90Latency:     s.P90Latency,
+		P95Latency:     s.P95Latency,
 		P99Latency:     s.P99Latency,
 	}
 	if len(s.TopFailingServices) > 0 {
@@ -0,0 +1,267 @@
+// Package argusql parses ArgusQL, the filter language behind /api/search:
+//
+//	service="payment-service" AND duration>500ms AND attr.payment.provider="stripe"
+//
+// A query is comparisons joined by AND, OR and NOT (case-insensitive, AND
+// binds tighter than OR) with parentheses for grouping. A comparison is
+// field op value:
+//
+//	field  identifier, dots allowed: service, duration, attr.http.route
+//	op     =  !=  >  >=  <  <=  ~ (contains)  !~ (does not contain)
+//	value  "quoted" or 'quoted' string, number, duration (500ms, 1.5s, 2m),
+//	       or a bare word (error, checkout-api)
+//
+// The package only parses; which fields exist and how they map to storage
+// is up to the caller (see storage.Repository.Search).
+package argusql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxQueryLength bounds the query text accepted by Parse.
+	MaxQueryLength = 2048
+	// maxDepth bounds nesting so a hostile query cannot exhaust the stack.
+	maxDepth = 32
+)
+
+// Expr is a node of a parsed query: *And, *Or, *Not or *Compare.
+type Expr interface {
+	String() string
+}
+
+// And matches when both sides match.
+type And struct{ Left, Right Expr }
+
+// Or matches when either side matches.
+type Or struct{ Left, Right Expr }
+
+// Not matches when X does not.
+type Not struct{ X Expr }
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	OpEq          Op = "="
+	OpNe          Op = "!="
+	OpGt          Op = ">"
+	OpGe          Op = ">="
+	OpLt          Op = "<"
+	OpLe          Op = "<="
+	OpContains    Op = "~"
+	OpNotContains Op = "!~"
+)
+
+// Ordered reports whether op is one of > >= < <=.
+func (op Op) Ordered() bool {
+	return op == OpGt || op == OpGe || op == OpLt || op == OpLe
+}
+
+// ValueKind says how a literal was written.
+type ValueKind int
+
+const (
+	KindString   ValueKind = iota // "quoted" or 'quoted'
+	KindWord                      // bare word
+	KindNumber                    // 42, -1.5
+	KindDuration                  // 500ms, 1.5s
+)
+
+// Value is a comparison literal. Text is the literal as written (unquoted);
+// Number and Duration are set for KindNumber and KindDuration.
+type Value struct {
+	Kind     ValueKind
+	Text     string
+	Number   float64
+	Duration time.Duration
+}
+
+// Compare is a single field op value comparison. Pos is the byte offset of
+// the field in the query, for error reporting by callers.
+type Compare struct {
+	Field string
+	Op    Op
+	Value Value
+	Pos   int
+}
+
+func (e *And) String() string { return "(" + e.Left.String() + " AND " + e.Right.String() + ")" }
+func (e *Or) String() string  { return "(" + e.Left.String() + " OR " + e.Right.String() + ")" }
+func (e *Not) String() string { return "NOT " + e.X.String() }
+
+func (e *Compare) String() string {
+	v := e.Value.Text
+	if e.Value.Kind == KindString {
+		v = fmt.Sprintf("%q", v)
+	}
+	return e.Field + string(e.Op) + v
+}
+
+// SyntaxError reports where and why a query failed to parse.
+type SyntaxError struct {
+	Pos int // byte offset into the query
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("argusql: %s at position %d", e.Msg, e.Pos)
+}
+
+// Walk calls fn for every comparison in e, left to right.
+func Walk(e Expr, fn func(*Compare)) {
+	switch n := e.(type) {
+	case *And:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *Or:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *Not:
+		Walk(n.X, fn)
+	case *Compare:
+		fn(n)
+	}
+}
+
+// Parse parses query into an expression tree. Errors are *SyntaxError.
+func Parse(query string) (Expr, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, &SyntaxError{Pos: 0, Msg: "empty query"}
+	}
+	if len(query) > MaxQueryLength {
+		return nil, &SyntaxError{Pos: MaxQueryLength, Msg: fmt.Sprintf("query longer than %d bytes", MaxQueryLength)}
+	}
+	toks, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+	return e, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	if t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// or := and { OR and }
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") {
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// and := unary { AND unary }
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") {
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// unary := NOT unary | "(" or ")" | compare
+func (p *parser) unary(depth int) (Expr, error) {
+	if depth > maxDepth {
+		return nil, &SyntaxError{Pos: p.peek().pos, Msg: "query nested too deeply"}
+	}
+	if p.keyword("NOT") {
+		x, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	if p.peek().kind == tokLParen {
+		open := p.next()
+		e, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("missing ) for ( at position %d", open.pos)}
+		}
+		return e, nil
+	}
+	return p.compare()
+}
+
+// compare := ident op value
+func (p *parser) compare() (Expr, error) {
+	f := p.next()
+	if f.kind != tokIdent || isKeyword(f.text) {
+		return nil, &SyntaxError{Pos: f.pos, Msg: fmt.Sprintf("expected field name, got %s", f)}
+	}
+	o := p.next()
+	if o.kind != tokOp {
+		return nil, &SyntaxError{Pos: o.pos, Msg: fmt.Sprintf("expected operator after %s, got %s", f.text, o)}
+	}
+	v := p.next()
+	c := &Compare{Field: f.text, Op: Op(o.text), Pos: f.pos}
+	switch v.kind {
+	case tokString:
+		c.Value = Value{Kind: KindString, Text: v.text}
+	case tokNumber:
+		c.Value = Value{Kind: KindNumber, Text: v.text, Number: v.num}
+	case tokDuration:
+		c.Value = Value{Kind: KindDuration, Text: v.text, Duration: v.dur}
+	case tokIdent:
+		if isKeyword(v.text) {
+			return nil, &SyntaxError{Pos: v.pos, Msg: fmt.Sprintf("expected value, got keyword %s (quote it to match the word)", v.text)}
+		}
+		c.Value = Value{Kind: KindWord, Text: v.text}
+	default:
+		return nil, &SyntaxError{Pos: v.pos, Msg: fmt.Sprintf("expected value after %s%s, got %s", f.text, o.text, v)}
+	}
+	return c, nil
+}
+
+func isKeyword(s string) bool {
+	return strings.EqualFold(s, "AND") || strings.EqualFold(s, "OR") || strings.EqualFold(s, "NOT")
+}
@@ -0,0 +1,96 @@
+package argusql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Precedence(t *testing.T) {
+	cases := map[string]string{
+		`service="payment-service" AND duration>500ms AND attr.payment.provider="stripe"`: `((service="payment-service" AND duration>500ms) AND attr.payment.provider="stripe")`,
+		`a=1 OR b=2 AND c=3`:            `(a=1 OR (b=2 AND c=3))`,
+		`(a=1 OR b=2) and c=3`:          `((a=1 OR b=2) AND c=3)`,
+		`not status=error or x!~'foo'`:  `(NOT status=error OR x!~"foo")`,
+		`NOT (a>=1.5 AND b<=-2)`:        `NOT (a>=1.5 AND b<=-2)`,
+		`attr.http.status_code = 5xx`:   `attr.http.status_code=5xx`,
+		`service=checkout-api`:          `service=checkout-api`,
+		`body~"said \"hi\""`:            `body~"said \"hi\""`,
+		`date=2024-01-01 AND v = 1.2.3`: `(date=2024-01-01 AND v=1.2.3)`,
+		`severity >= WARN`:              `severity>=WARN`,
+		`duration < 1h30m`:              `duration<1h30m`,
+		`route = /api/v1/orders`:        `route=/api/v1/orders`,
+		`attr.peer:port != "db:5432"`:   `attr.peer:port!="db:5432"`,
+		"a=1\n\tAND\r\nb=2":             `(a=1 AND b=2)`,
+	}
+	for in, want := range cases {
+		e, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", in, err)
+			continue
+		}
+		if got := e.String(); got != want {
+			t.Errorf("Parse(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestParse_Values(t *testing.T) {
+	e, err := Parse(`duration>=1.5s AND n=42 AND s='x' AND w=word`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Value
+	Walk(e, func(c *Compare) { got = append(got, c.Value) })
+	want := []Value{
+		{Kind: KindDuration, Text: "1.5s", Duration: 1500 * time.Millisecond},
+		{Kind: KindNumber, Text: "42", Number: 42},
+		{Kind: KindString, Text: "x"},
+		{Kind: KindWord, Text: "word"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("value %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]struct {
+		pos int
+		msg string
+	}{
+		``:                              {0, "empty query"},
+		`service`:                       {7, "expected operator"},
+		`service=`:                      {8, "expected value"},
+		`service="x`:                    {8, "unterminated string"},
+		`a=1 AND`:                       {7, "expected field name"},
+		`a=1 b=2`:                       {4, "unexpected 'b'"},
+		`(a=1`:                          {4, "missing )"},
+		`a=1)`:                          {3, "unexpected ')'"},
+		`!a=1`:                          {0, "use NOT"},
+		`a=1 & b=2`:                     {4, "unexpected character"},
+		`a=and`:                         {2, "quote it"},
+		`=1`:                            {0, "expected field name"},
+		`a==1`:                          {2, "expected value"},
+		strings.Repeat("(", 40) + "a=1": {33, "nested too deeply"},
+	}
+	for in, want := range cases {
+		_, err := Parse(in)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) error = %v, want *SyntaxError", in, err)
+			continue
+		}
+		if se.Pos != want.pos || !strings.Contains(se.Msg, want.msg) {
+			t.Errorf("Parse(%q) = %v, want %q at %d", in, err, want.msg, want.pos)
+		}
+	}
+	if _, err := Parse("a=" + strings.Repeat("x", MaxQueryLength)); err == nil {
+		t.Error("over-long query parsed")
+	}
+}
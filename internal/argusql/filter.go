@@ -0,0 +1,203 @@
+package argusql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxFilterLength bounds the JSON accepted by ParseFilter.
+const MaxFilterLength = 4 * MaxQueryLength
+
+// Filter is the JSON form of an expression, for clients that build queries
+// rather than type them, and for filters saved to apply to any signal.
+// Exactly one of And, Or, Not or Field is set:
+//
+//	{"and": [{"field": "service", "op": "=", "value": "checkout"},
+//	         {"or": [{"field": "duration", "op": ">", "value": "500ms"},
+//	                 {"not": {"field": "status", "op": "=", "value": "ok"}}]}]}
+//
+// A value is a JSON string, number or boolean. A string that reads as a
+// duration with a unit ("500ms") compares as a duration, a number as a
+// number and anything else, booleans included, as text, so a Filter means
+// what the same comparison typed in ArgusQL means.
+type Filter struct {
+	And   []Filter `json:"and,omitempty"`
+	Or    []Filter `json:"or,omitempty"`
+	Not   *Filter  `json:"not,omitempty"`
+	Field string   `json:"field,omitempty"`
+	Op    Op       `json:"op,omitempty"`
+	Value any      `json:"value,omitempty"`
+}
+
+// FilterError reports where and why a Filter is invalid. Path locates the
+// node, e.g. "and[1].not".
+type FilterError struct {
+	Path string
+	Msg  string
+}
+
+func (e *FilterError) Error() string {
+	if e.Path == "" {
+		return "argusql: filter: " + e.Msg
+	}
+	return fmt.Sprintf("argusql: filter %s: %s", e.Path, e.Msg)
+}
+
+// ParseFilter decodes a JSON Filter into an expression tree. Errors are
+// *FilterError. Comparisons built from a Filter have Pos -1.
+func ParseFilter(data []byte) (Expr, error) {
+	if len(data) > MaxFilterLength {
+		return nil, &FilterError{Msg: fmt.Sprintf("longer than %d bytes", MaxFilterLength)}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	var f Filter
+	if err := dec.Decode(&f); err != nil {
+		return nil, &FilterError{Msg: err.Error()}
+	}
+	return f.expr("", 0)
+}
+
+var filterOps = map[Op]bool{
+	OpEq: true, OpNe: true, OpGt: true, OpGe: true, OpLt: true, OpLe: true,
+	OpContains: true, OpNotContains: true,
+}
+
+func (f *Filter) expr(path string, depth int) (Expr, error) {
+	invalid := func(format string, a ...any) (Expr, error) {
+		return nil, &FilterError{Path: path, Msg: fmt.Sprintf(format, a...)}
+	}
+	if depth > maxDepth {
+		return invalid("nested too deeply")
+	}
+	set := 0
+	for _, ok := range []bool{f.And != nil, f.Or != nil, f.Not != nil, f.Field != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return invalid("needs exactly one of and, or, not or field")
+	}
+	join := func(name string, list []Filter, mk func(l, r Expr) Expr) (Expr, error) {
+		if len(list) == 0 {
+			return invalid("%s is empty", name)
+		}
+		var out Expr
+		for i := range list {
+			e, err := list[i].expr(fmt.Sprintf("%s%s[%d]", dot(path), name, i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil {
+				out = e
+			} else {
+				out = mk(out, e)
+			}
+		}
+		return out, nil
+	}
+	switch {
+	case f.And != nil:
+		return join("and", f.And, func(l, r Expr) Expr { return &And{Left: l, Right: r} })
+	case f.Or != nil:
+		return join("or", f.Or, func(l, r Expr) Expr { return &Or{Left: l, Right: r} })
+	case f.Not != nil:
+		x, err := f.Not.expr(dot(path)+"not", depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	if isKeyword(f.Field) {
+		return invalid("field cannot be %s", f.Field)
+	}
+	if !filterOps[f.Op] {
+		return invalid("unknown op %q (=, !=, >, >=, <, <=, ~, !~)", f.Op)
+	}
+	v, err := filterValue(f.Value)
+	if err != nil {
+		return invalid("%v", err)
+	}
+	return &Compare{Field: f.Field, Op: f.Op, Value: v, Pos: -1}, nil
+}
+
+func dot(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "."
+}
+
+func filterValue(v any) (Value, error) {
+	switch v := v.(type) {
+	case string:
+		if v != "" && (v[0] == '-' || v[0] >= '0' && v[0] <= '9') {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				if d, err := time.ParseDuration(v); err == nil {
+					return Value{Kind: KindDuration, Text: v, Duration: d}, nil
+				}
+			}
+		}
+		return Value{Kind: KindString, Text: v}, nil
+	case json.Number:
+		n, err := v.Float64()
+		if err != nil {
+			return Value{}, fmt.Errorf("bad number %s", v)
+		}
+		return Value{Kind: KindNumber, Text: v.String(), Number: n}, nil
+	case float64: // a Filter built in Go rather than decoded
+		return Value{Kind: KindNumber, Text: strconv.FormatFloat(v, 'g', -1, 64), Number: v}, nil
+	case int:
+		return Value{Kind: KindNumber, Text: strconv.Itoa(v), Number: float64(v)}, nil
+	case bool:
+		return Value{Kind: KindString, Text: strconv.FormatBool(v)}, nil
+	case nil:
+		return Value{}, fmt.Errorf("missing value")
+	}
+	return Value{}, fmt.Errorf("value must be a string, number or boolean")
+}
+
+// FilterOf returns the JSON form of e. Runs of the same operator are
+// flattened into one list, so FilterOf(ParseFilter(f)) reproduces f.
+func FilterOf(e Expr) Filter {
+	switch n := e.(type) {
+	case *And:
+		return Filter{And: flatten(e, func(x Expr) (Expr, Expr, bool) {
+			a, ok := x.(*And)
+			if !ok {
+				return nil, nil, false
+			}
+			return a.Left, a.Right, true
+		})}
+	case *Or:
+		return Filter{Or: flatten(e, func(x Expr) (Expr, Expr, bool) {
+			o, ok := x.(*Or)
+			if !ok {
+				return nil, nil, false
+			}
+			return o.Left, o.Right, true
+		})}
+	case *Not:
+		x := FilterOf(n.X)
+		return Filter{Not: &x}
+	case *Compare:
+		f := Filter{Field: n.Field, Op: n.Op, Value: n.Value.Text}
+		if n.Value.Kind == KindNumber {
+			f.Value = n.Value.Number
+		}
+		return f
+	}
+	return Filter{}
+}
+
+func flatten(e Expr, split func(Expr) (Expr, Expr, bool)) []Filter {
+	if l, r, ok := split(e); ok {
+		return append(flatten(l, split), flatten(r, split)...)
+	}
+	return []Filter{FilterOf(e)}
+}
@@ -0,0 +1,65 @@
+package argusql
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	e, err := ParseFilter([]byte(`{"and":[
+		{"field":"service","op":"=","value":"checkout"},
+		{"or":[{"field":"duration","op":">","value":"500ms"},{"not":{"field":"status","op":"=","value":"error"}}]},
+		{"field":"attr.http.status_code","op":">=","value":500},
+		{"field":"attr.cached","op":"=","value":true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(((service="checkout" AND (duration>500ms OR NOT status="error")) AND attr.http.status_code>=500) AND attr.cached="true")`
+	if got := e.String(); got != want {
+		t.Errorf("ParseFilter = %s, want %s", got, want)
+	}
+	var vals []Value
+	Walk(e, func(c *Compare) { vals = append(vals, c.Value) })
+	if vals[1].Kind != KindDuration || vals[1].Duration != 500*time.Millisecond || vals[3].Kind != KindNumber || vals[3].Number != 500 {
+		t.Errorf("values = %+v", vals)
+	}
+
+	// FilterOf is the inverse, flattening AND/OR chains.
+	data, err := json.Marshal(FilterOf(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := ParseFilter(data)
+	if err != nil || back.String() != want {
+		t.Errorf("round trip %s = %v, %v", data, back, err)
+	}
+	// Text queries convert too, so a typed query can be saved as a filter.
+	q, _ := Parse(`service=checkout AND (a=1 OR b~x OR NOT c!=2)`)
+	if data, _ := json.Marshal(FilterOf(q)); string(data) != `{"and":[{"field":"service","op":"=","value":"checkout"},{"or":[{"field":"a","op":"=","value":1},{"field":"b","op":"~","value":"x"},{"not":{"field":"c","op":"!=","value":2}}]}]}` {
+		t.Errorf("FilterOf(%s) = %s", q, data)
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	for in, path := range map[string]string{
+		`{"field":"a","op":"==","value":1}`:                    "",
+		`{"field":"a","op":"="}`:                               "",
+		`{"field":"a","op":"=","value":[1]}`:                   "",
+		`{"and":[]}`:                                           "",
+		`{"field":"a","op":"=","value":1,"not":{"field":"b"}}`: "",
+		`{"or":[{"field":"a","op":"=","value":1},{"not":{}}]}`: "or[1].not",
+		`{"field":"AND","op":"=","value":1}`:                   "",
+		`{"field":"a","op":"=","value":1,"colour":"red"}`:      "",
+		`not json`: "",
+	} {
+		_, err := ParseFilter([]byte(in))
+		var fe *FilterError
+		if !errors.As(err, &fe) {
+			t.Errorf("ParseFilter(%s) error = %v, want *FilterError", in, err)
+		} else if path != "" && fe.Path != path {
+			t.Errorf("ParseFilter(%s) path = %q, want %q", in, fe.Path, path)
+		}
+	}
+}
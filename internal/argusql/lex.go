@@ -0,0 +1,147 @@
+package argusql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokDuration
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+	num  float64
+	dur  time.Duration
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// isWordByte reports bytes allowed in identifiers and bare words after the
+// first: letters, digits and the separators that appear in service names,
+// attribute keys and routes.
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '/' || c == ':' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '=' || c == '~':
+			toks = append(toks, token{kind: tokOp, text: string(c), pos: i})
+			i++
+		case c == '!' || c == '>' || c == '<':
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '!' && src[i+1] == '~')) {
+				op += string(src[i+1])
+			}
+			if op == "!" {
+				return nil, &SyntaxError{Pos: i, Msg: "expected != or !~ (use NOT to negate)"}
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		case c == '"' || c == '\'':
+			t, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, t)
+			i += n
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			t, n := lexNumber(src, i)
+			toks = append(toks, t)
+			i += n
+		case isWordByte(c):
+			j := i
+			for j < len(src) && isWordByte(src[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: i})
+			i = j
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// lexString reads a quoted string starting at src[start]. Backslash escapes
+// the next byte, so \" and \\ work inside either quote style.
+func lexString(src string, start int) (token, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src):
+			i++
+			b.WriteByte(src[i])
+		case c == quote:
+			return token{kind: tokString, text: b.String(), pos: start}, i - start + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{}, 0, &SyntaxError{Pos: start, Msg: "unterminated string"}
+}
+
+// lexNumber reads a word starting with a digit (or -digit): a number, a
+// duration (500ms, 1.5s, 1h30m; units ns, us, µs, ms, s, m, h), or anything
+// else — 5xx, 2024-01-01, 1.2.3 — as a bare word.
+func lexNumber(src string, start int) (token, int) {
+	j := start + 1
+	for j < len(src) {
+		if isWordByte(src[j]) {
+			j++
+		} else if strings.HasPrefix(src[j:], "µ") {
+			j += len("µ")
+		} else {
+			break
+		}
+	}
+	text := src[start:j]
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return token{kind: tokNumber, text: text, pos: start, num: f}, j - start
+	}
+	if d, err := time.ParseDuration(text); err == nil {
+		return token{kind: tokDuration, text: text, pos: start, dur: d}, j - start
+	}
+	return token{kind: tokIdent, text: text, pos: start}, j - start
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strings"
 	"sync"
 	"time"
 )
@@ -62,6 +63,18 @@ func (c *TTLCache) Delete(key string) {
 	c.mu.Unlock()
 }
 
+// DeletePrefix removes every key starting with prefix, e.g. all cached
+// responses of one endpoint for one tenant after a write.
+func (c *TTLCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	for k := range c.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.items, k)
+		}
+	}
+	c.mu.Unlock()
+}
+
 // evictLoop removes expired entries every 30 seconds.
 func (c *TTLCache) evictLoop() {
 	defer c.wg.Done()
@@ -0,0 +1,192 @@
+package coldtier
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// MaxScanSegments bounds the segments one query downloads. Queries
+	// over more of the cold tier report Truncated; narrow the time range.
+	MaxScanSegments = 20
+
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// LogQuery filters a cold tier log search. Zero fields match everything.
+type LogQuery struct {
+	Start, End  time.Time
+	ServiceName string
+	Severity    string
+	TraceID     string
+	Search      string // case-insensitive substring of the body
+	Limit       int    // default 100, max 1000
+}
+
+// TraceQuery filters a cold tier trace search.
+type TraceQuery struct {
+	Start, End  time.Time
+	ServiceName string
+	Status      string
+	Limit       int // default 100, max 1000
+}
+
+// Result is one page of cold tier rows, newest first.
+type Result[T any] struct {
+	Data []T `json:"data"`
+	// SegmentsScanned is the number of segments read. Truncated is set
+	// when more segments overlapped the range than MaxScanSegments, in
+	// which case only the newest were read.
+	SegmentsScanned int  `json:"segments_scanned"`
+	Truncated       bool `json:"truncated"`
+}
+
+func queryLimit(n int) int {
+	if n <= 0 {
+		return defaultQueryLimit
+	}
+	return min(n, maxQueryLimit)
+}
+
+// segments returns the newest MaxScanSegments of the ctx tenant's segments
+// of signal overlapping [start, end], and whether there were more.
+func (t *Tier) segments(ctx context.Context, signal string, start, end time.Time) ([]storage.ColdSegment, bool, error) {
+	segs, err := t.repo.ListColdSegments(ctx, storage.ColdSegmentQuery{Signal: signal, Start: start, End: end, Limit: MaxScanSegments + 1})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(segs) > MaxScanSegments {
+		return segs[:MaxScanSegments], true, nil
+	}
+	return segs, false, nil
+}
+
+func inRange(ts, start, end time.Time) bool {
+	return (start.IsZero() || !ts.Before(start)) && (end.IsZero() || !ts.After(end))
+}
+
+// Logs searches the tenant on ctx's tiered logs.
+func (t *Tier) Logs(ctx context.Context, q LogQuery) (Result[storage.Log], error) {
+	segs, truncated, err := t.segments(ctx, storage.ColdSignalLogs, q.Start, q.End)
+	if err != nil {
+		return Result[storage.Log]{}, err
+	}
+	tenant := storage.TenantFromContext(ctx)
+	search := strings.ToLower(q.Search)
+	var out []storage.Log
+	for _, seg := range segs {
+		index, rows, err := t.readSegment(ctx, seg)
+		if err != nil {
+			return Result[storage.Log]{}, err
+		}
+		for _, vals := range rows {
+			l := logFromRow(row{index: index, vals: vals})
+			if l.TenantID != tenant || !inRange(l.Timestamp, q.Start, q.End) ||
+				(q.ServiceName != "" && l.ServiceName != q.ServiceName) ||
+				(q.Severity != "" && !strings.EqualFold(l.Severity, q.Severity)) ||
+				(q.TraceID != "" && l.TraceID != q.TraceID) ||
+				(search != "" && !strings.Contains(strings.ToLower(l.Body), search)) {
+				continue
+			}
+			out = append(out, l)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if limit := queryLimit(q.Limit); len(out) > limit {
+		out = out[:limit]
+	}
+	return Result[storage.Log]{Data: out, SegmentsScanned: len(segs), Truncated: truncated}, nil
+}
+
+// Traces searches the tenant on ctx's tiered traces. Spans are not loaded;
+// use Trace for one trace's spans.
+func (t *Tier) Traces(ctx context.Context, q TraceQuery) (Result[storage.Trace], error) {
+	segs, truncated, err := t.segments(ctx, storage.ColdSignalTraces, q.Start, q.End)
+	if err != nil {
+		return Result[storage.Trace]{}, err
+	}
+	tenant := storage.TenantFromContext(ctx)
+	var out []storage.Trace
+	for _, seg := range segs {
+		index, rows, err := t.readSegment(ctx, seg)
+		if err != nil {
+			return Result[storage.Trace]{}, err
+		}
+		for _, vals := range rows {
+			tr := traceFromRow(row{index: index, vals: vals})
+			if tr.TenantID != tenant || !inRange(tr.Timestamp, q.Start, q.End) ||
+				(q.ServiceName != "" && tr.ServiceName != q.ServiceName) ||
+				(q.Status != "" && tr.Status != q.Status) {
+				continue
+			}
+			out = append(out, tr)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if limit := queryLimit(q.Limit); len(out) > limit {
+		out = out[:limit]
+	}
+	return Result[storage.Trace]{Data: out, SegmentsScanned: len(segs), Truncated: truncated}, nil
+}
+
+// Trace finds one tiered trace, with its spans, among the trace segments
+// overlapping [start, end]. It returns nil when no scanned segment holds it;
+// pass the range the trace was seen in to search past MaxScanSegments.
+func (t *Tier) Trace(ctx context.Context, traceID string, start, end time.Time) (*storage.Trace, error) {
+	segs, _, err := t.segments(ctx, storage.ColdSignalTraces, start, end)
+	if err != nil {
+		return nil, err
+	}
+	tenant := storage.TenantFromContext(ctx)
+	for _, seg := range segs {
+		index, rows, err := t.readSegment(ctx, seg)
+		if err != nil {
+			return nil, err
+		}
+		for _, vals := range rows {
+			r := row{index: index, vals: vals}
+			if r.str("trace_id") != traceID || r.str("tenant_id") != tenant {
+				continue
+			}
+			tr := traceFromRow(r)
+			spans, err := t.spansOf(ctx, seg, tenant, traceID)
+			if err != nil {
+				return nil, err
+			}
+			tr.Spans = spans
+			tr.SpanCount = len(spans)
+			for _, s := range spans {
+				if s.ParentSpanID == "" {
+					tr.Operation = s.OperationName
+					break
+				}
+			}
+			return &tr, nil
+		}
+	}
+	return nil, nil
+}
+
+// spansOf reads traceID's spans from the spans segment exported with the
+// traces segment seg.
+func (t *Tier) spansOf(ctx context.Context, seg storage.ColdSegment, tenant, traceID string) ([]storage.Span, error) {
+	spanSeg := storage.ColdSegment{ObjectKey: storage.ColdSignalSpans + strings.TrimPrefix(seg.ObjectKey, storage.ColdSignalTraces)}
+	index, rows, err := t.readSegment(ctx, spanSeg)
+	if err != nil {
+		return nil, err
+	}
+	var spans []storage.Span
+	for _, vals := range rows {
+		r := row{index: index, vals: vals}
+		if r.str("trace_id") == traceID && r.str("tenant_id") == tenant {
+			spans = append(spans, spanFromRow(r))
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].StartTimeNs < spans[j].StartTimeNs })
+	return spans, nil
+}
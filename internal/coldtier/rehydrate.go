@@ -0,0 +1,95 @@
+package coldtier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// DefaultRehydrationTTL is how long rehydrated logs stay queryable
+	// when the request does not say.
+	DefaultRehydrationTTL = 24 * time.Hour
+	// MaxRehydrationTTL bounds how long rehydrated logs stay queryable.
+	MaxRehydrationTTL = 7 * 24 * time.Hour
+)
+
+// RehydrationRequest selects the tiered logs a rehydration loads.
+type RehydrationRequest struct {
+	Start, End  time.Time
+	ServiceName string        // optional
+	TTL         time.Duration // default DefaultRehydrationTTL, max MaxRehydrationTTL
+}
+
+// Validate checks the range and applies the TTL default.
+func (q *RehydrationRequest) Validate() error {
+	switch {
+	case q.Start.IsZero() || q.End.IsZero():
+		return errors.New("start and end are required")
+	case !q.End.After(q.Start):
+		return errors.New("end must be after start")
+	case q.TTL < 0 || q.TTL > MaxRehydrationTTL:
+		return fmt.Errorf("ttl must be between 0 and %s", MaxRehydrationTTL)
+	}
+	if q.TTL == 0 {
+		q.TTL = DefaultRehydrationTTL
+	}
+	return nil
+}
+
+// RehydrateLogs loads the tenant on ctx's tiered logs in q's range back
+// into the database as a RehydrationJob, queryable with
+// Repository.RehydratedLogs until the job expires. Like Logs it reads at
+// most MaxScanSegments segments, the newest, and reports Truncated. A load
+// that fails leaves the job failed with no rows, and its error is returned
+// with the job.
+func (t *Tier) RehydrateLogs(ctx context.Context, q RehydrationRequest) (*storage.RehydrationJob, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	job := &storage.RehydrationJob{
+		Start:       q.Start.UTC(),
+		End:         q.End.UTC(),
+		ServiceName: q.ServiceName,
+		ExpiresAt:   time.Now().UTC().Add(q.TTL),
+	}
+	if err := t.repo.CreateRehydrationJob(ctx, job); err != nil {
+		return nil, err
+	}
+	loadErr := t.loadRehydration(ctx, job)
+	// Recorded even when the client has gone away mid-load.
+	if err := t.repo.FinishRehydrationJob(context.WithoutCancel(ctx), job, loadErr); err != nil {
+		return nil, err
+	}
+	return job, loadErr
+}
+
+func (t *Tier) loadRehydration(ctx context.Context, job *storage.RehydrationJob) error {
+	segs, truncated, err := t.segments(ctx, storage.ColdSignalLogs, job.Start, job.End)
+	if err != nil {
+		return err
+	}
+	job.Segments, job.Truncated = len(segs), truncated
+	for _, seg := range segs {
+		index, rows, err := t.readSegment(ctx, seg)
+		if err != nil {
+			return err
+		}
+		var logs []storage.Log
+		for _, vals := range rows {
+			l := logFromRow(row{index: index, vals: vals})
+			if l.TenantID != job.TenantID || !inRange(l.Timestamp, job.Start, job.End) ||
+				(job.ServiceName != "" && l.ServiceName != job.ServiceName) {
+				continue
+			}
+			logs = append(logs, l)
+		}
+		if err := t.repo.AppendRehydratedLogs(ctx, job, logs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
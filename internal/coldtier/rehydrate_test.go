@@ -0,0 +1,70 @@
+package coldtier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestTier_RehydrateLogs(t *testing.T) {
+	tier, repo := newTestTier(t)
+	db := repo.DB()
+	old := time.Now().UTC().Truncate(time.Microsecond).Add(-72 * time.Hour)
+	for i := range 25 {
+		service := "checkout"
+		if i%5 == 0 {
+			service = "cart"
+		}
+		db.Create(&storage.Log{TenantID: "acme", ServiceName: service, Severity: "INFO", Body: "payment declined",
+			Timestamp: old.Add(time.Duration(i) * time.Minute), AttributesJSON: `{"k":"v"}`})
+	}
+	db.Create(&storage.Log{TenantID: "other", ServiceName: "checkout", Severity: "INFO", Body: "payment", Timestamp: old})
+	ctx := context.Background()
+	if _, err := tier.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	acme := storage.WithTenantContext(ctx, "acme")
+	if _, err := tier.RehydrateLogs(acme, RehydrationRequest{Start: old, End: old.Add(-time.Minute)}); err == nil {
+		t.Error("an inverted range was accepted")
+	}
+	job, err := tier.RehydrateLogs(acme, RehydrationRequest{Start: old, End: old.Add(time.Hour), ServiceName: "checkout"})
+	if err != nil {
+		t.Fatalf("RehydrateLogs: %v", err)
+	}
+	if job.Status != storage.RehydrationStatusReady || job.RowCount != 20 || job.Segments != 2 || job.Truncated ||
+		job.ExpiresAt.Sub(time.Now()) < DefaultRehydrationTTL-time.Minute {
+		t.Fatalf("job = %+v", job)
+	}
+
+	logs, err := repo.RehydratedLogs(acme, job, storage.RehydratedLogQuery{Search: "DECLINED", Limit: 5})
+	if err != nil {
+		t.Fatalf("RehydratedLogs: %v", err)
+	}
+	if len(logs) != 5 || !logs[0].Timestamp.Equal(old.Add(24*time.Minute)) || logs[0].AttributesJSON != `{"k":"v"}` || logs[0].ID == 0 {
+		t.Errorf("newest rehydrated logs = %+v", logs)
+	}
+	if got, _ := repo.ListRehydrationJobs(storage.WithTenantContext(ctx, "other")); len(got) != 0 {
+		t.Errorf("other tenant sees %+v", got)
+	}
+	if _, err := repo.GetRehydrationJob(storage.WithTenantContext(ctx, "other"), job.ID); err == nil {
+		t.Error("other tenant loads acme's job")
+	}
+
+	// Expired jobs are hidden, then dropped with their rows by the next pass.
+	db.Model(&storage.RehydrationJob{}).Where("id = ?", job.ID).Update("expires_at", time.Now().UTC().Add(-time.Minute))
+	if got, _ := repo.ListRehydrationJobs(acme); len(got) != 0 {
+		t.Errorf("expired jobs listed: %+v", got)
+	}
+	if _, err := tier.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	var jobs, rows int64
+	db.Model(&storage.RehydrationJob{}).Count(&jobs)
+	db.Model(&storage.RehydratedLog{}).Count(&rows)
+	if jobs != 0 || rows != 0 {
+		t.Errorf("after expiry: %d jobs, %d rows left", jobs, rows)
+	}
+}
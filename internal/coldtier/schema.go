@@ -0,0 +1,151 @@
+package coldtier
+
+import (
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/parquet"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Segment schemas. Columns are looked up by name on read, so a column can
+// be added without breaking segments already in the bucket.
+var (
+	logSchema = []parquet.Column{
+		{Name: "id", Type: parquet.Int64},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "timestamp", Type: parquet.Timestamp},
+		{Name: "trace_id", Type: parquet.String},
+		{Name: "span_id", Type: parquet.String},
+		{Name: "severity", Type: parquet.String},
+		{Name: "service_name", Type: parquet.String},
+		{Name: "body", Type: parquet.String},
+		{Name: "attributes_json", Type: parquet.String},
+		{Name: "ai_insight", Type: parquet.String},
+		{Name: "region", Type: parquet.String},
+	}
+	traceSchema = []parquet.Column{
+		{Name: "id", Type: parquet.Int64},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "trace_id", Type: parquet.String},
+		{Name: "service_name", Type: parquet.String},
+		{Name: "timestamp", Type: parquet.Timestamp},
+		{Name: "duration_ns", Type: parquet.Int64},
+		{Name: "status", Type: parquet.String},
+		{Name: "region", Type: parquet.String},
+	}
+	spanSchema = []parquet.Column{
+		{Name: "id", Type: parquet.Int64},
+		{Name: "tenant_id", Type: parquet.String},
+		{Name: "trace_id", Type: parquet.String},
+		{Name: "span_id", Type: parquet.String},
+		{Name: "parent_span_id", Type: parquet.String},
+		{Name: "operation_name", Type: parquet.String},
+		{Name: "service_name", Type: parquet.String},
+		{Name: "start_time_unix_nano", Type: parquet.Int64},
+		{Name: "duration_ns", Type: parquet.Int64},
+		{Name: "status", Type: parquet.String},
+		{Name: "attributes_json", Type: parquet.String},
+		{Name: "region", Type: parquet.String},
+	}
+)
+
+func logRow(l *storage.Log) []any {
+	return []any{int64(l.ID), l.TenantID, l.Timestamp, l.TraceID, l.SpanID, l.Severity, l.ServiceName,
+		l.Body, string(l.AttributesJSON), string(l.AIInsight), l.Region}
+}
+
+func traceRow(t *storage.Trace) []any {
+	return []any{int64(t.ID), t.TenantID, t.TraceID, t.ServiceName, t.Timestamp, t.DurationNs, t.Status, t.Region}
+}
+
+func spanRow(s *storage.Span) []any {
+	return []any{int64(s.ID), s.TenantID, s.TraceID, s.SpanID, s.ParentSpanID, s.OperationName, s.ServiceName,
+		s.StartTimeNs, s.DurationNs, s.Status, string(s.AttributesJSON), s.Region}
+}
+
+// row reads the columns of a decoded segment row by name; a column the
+// segment lacks reads as its zero value.
+type row struct {
+	index map[string]int
+	vals  []any
+}
+
+func columnIndex(schema []parquet.Column) map[string]int {
+	idx := make(map[string]int, len(schema))
+	for i, c := range schema {
+		idx[c.Name] = i
+	}
+	return idx
+}
+
+func (r row) get(name string) any {
+	if i, ok := r.index[name]; ok && i < len(r.vals) {
+		return r.vals[i]
+	}
+	return nil
+}
+
+func (r row) str(name string) string {
+	s, _ := r.get(name).(string)
+	return s
+}
+
+func (r row) i64(name string) int64 {
+	n, _ := r.get(name).(int64)
+	return n
+}
+
+func (r row) time(name string) time.Time {
+	t, _ := r.get(name).(time.Time)
+	return t
+}
+
+func logFromRow(r row) storage.Log {
+	return storage.Log{
+		ID:             uint(r.i64("id")), // #nosec G115 -- ids were exported from uint
+		TenantID:       r.str("tenant_id"),
+		Timestamp:      r.time("timestamp"),
+		TraceID:        r.str("trace_id"),
+		SpanID:         r.str("span_id"),
+		Severity:       r.str("severity"),
+		ServiceName:    r.str("service_name"),
+		Body:           r.str("body"),
+		AttributesJSON: storage.CompressedText(r.str("attributes_json")),
+		AIInsight:      storage.CompressedText(r.str("ai_insight")),
+		Region:         r.str("region"),
+	}
+}
+
+func traceFromRow(r row) storage.Trace {
+	t := storage.Trace{
+		ID:          uint(r.i64("id")), // #nosec G115 -- ids were exported from uint
+		TenantID:    r.str("tenant_id"),
+		TraceID:     r.str("trace_id"),
+		ServiceName: r.str("service_name"),
+		Timestamp:   r.time("timestamp"),
+		DurationNs:  r.i64("duration_ns"),
+		Status:      r.str("status"),
+		Region:      r.str("region"),
+	}
+	t.Duration = t.DurationNs / int64(time.Microsecond)
+	t.DurationMs = float64(t.DurationNs) / float64(time.Millisecond)
+	return t
+}
+
+func spanFromRow(r row) storage.Span {
+	s := storage.Span{
+		ID:             uint(r.i64("id")), // #nosec G115 -- ids were exported from uint
+		TenantID:       r.str("tenant_id"),
+		TraceID:        r.str("trace_id"),
+		SpanID:         r.str("span_id"),
+		ParentSpanID:   r.str("parent_span_id"),
+		OperationName:  r.str("operation_name"),
+		ServiceName:    r.str("service_name"),
+		Status:         r.str("status"),
+		AttributesJSON: storage.CompressedText(r.str("attributes_json")),
+		Region:         r.str("region"),
+	}
+	start := time.Unix(0, r.i64("start_time_unix_nano")).UTC()
+	s.SetTiming(start, start.Add(time.Duration(r.i64("duration_ns"))))
+	return s
+}
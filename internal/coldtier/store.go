@@ -0,0 +1,111 @@
+// Package coldtier moves traces and logs older than COLD_TIER_AFTER out of
+// the hot database into Parquet objects on local disk, S3 or GCS, and
+// reads them back on demand for historical investigations.
+package coldtier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by Store.Get for a missing object.
+var ErrNotFound = errors.New("cold tier object not found")
+
+// Store holds cold segment objects under slash-separated keys.
+type Store interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// StoreConfig selects and authenticates a Store.
+type StoreConfig struct {
+	// URL is file:///dir, s3://bucket/prefix or gs://bucket/prefix.
+	URL string
+	// Endpoint overrides the S3 endpoint, e.g. a MinIO URL. gs:// defaults
+	// to https://storage.googleapis.com, s3:// to AWS in Region.
+	Endpoint string
+	Region   string
+	// PathStyle addresses buckets as endpoint/bucket rather than
+	// bucket.endpoint.
+	PathStyle       bool
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// OpenStore returns the Store cfg.URL names. GCS is reached through its
+// S3-compatible XML API and needs an HMAC key.
+func OpenStore(cfg StoreConfig) (Store, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cold tier URL: %w", err)
+	}
+	switch u.Scheme {
+	case "file":
+		dir := u.Path
+		if u.Host != "" {
+			// file://relative/dir
+			dir = filepath.Join(u.Host, u.Path)
+		}
+		if dir == "" {
+			return nil, errors.New("cold tier file URL needs a directory")
+		}
+		return &localStore{dir: filepath.Clean(dir)}, nil
+	case "s3", "gs":
+		if u.Host == "" {
+			return nil, fmt.Errorf("cold tier URL %q needs a bucket", cfg.URL)
+		}
+		if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+			return nil, errors.New("cold tier object storage needs an access key and secret")
+		}
+		return newS3Store(u.Scheme, u.Host, strings.Trim(u.Path, "/"), cfg)
+	}
+	return nil, fmt.Errorf("cold tier URL %q: scheme must be file, s3 or gs", cfg.URL)
+}
+
+// localStore keeps objects as files under dir.
+type localStore struct {
+	dir string
+}
+
+func (s *localStore) path(key string) (string, error) {
+	p := filepath.Join(s.dir, filepath.FromSlash(key))
+	if !strings.HasPrefix(p, s.dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return p, nil
+}
+
+// Put writes body through a temporary file, so readers never see a partial
+// object.
+func (s *localStore) Put(_ context.Context, key string, body []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (s *localStore) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p) // #nosec G304 -- key is checked to stay under dir
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	return b, err
+}
@@ -0,0 +1,316 @@
+package coldtier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/parquet"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+const (
+	defaultInterval  = time.Hour
+	defaultBatchRows = 10_000
+)
+
+// Options configures a Tier.
+type Options struct {
+	// After is the age past which rows leave the hot database.
+	After time.Duration
+	// Interval between passes; default 1h.
+	Interval time.Duration
+	// BatchRows is the log rows per segment; default 10,000. Trace
+	// segments hold a tenth as many traces, with all their spans.
+	BatchRows int
+	Metrics   *telemetry.Metrics
+}
+
+// Tier periodically exports traces and logs older than Options.After to
+// Parquet segments in a Store, then deletes them from the hot database.
+// Each batch is uploaded before the transaction that records its segment
+// and deletes its rows, so a crash in between leaves the rows hot and, at
+// worst, an unreferenced object the next pass overwrites.
+type Tier struct {
+	repo  *storage.Repository
+	store Store
+	opts  Options
+
+	started atomic.Bool
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	running atomic.Bool
+}
+
+// New constructs a Tier but does not start it.
+func New(repo *storage.Repository, store Store, opts Options) *Tier {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.BatchRows <= 0 {
+		opts.BatchRows = defaultBatchRows
+	}
+	return &Tier{repo: repo, store: store, opts: opts, done: make(chan struct{})}
+}
+
+// Start runs a pass immediately and then every Interval until Stop.
+func (t *Tier) Start(parent context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started.Load() {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	t.cancel = cancel
+	go t.loop(ctx)
+	t.started.Store(true)
+}
+
+// Stop cancels the running pass and waits for the loop to return. Safe to
+// call repeatedly, or without Start.
+func (t *Tier) Stop() {
+	if !t.started.Load() {
+		return
+	}
+	t.mu.Lock()
+	cancel, done := t.cancel, t.done
+	t.mu.Unlock()
+	cancel()
+	<-done
+}
+
+func (t *Tier) loop(ctx context.Context) {
+	defer close(t.done)
+	tick := time.NewTicker(t.opts.Interval)
+	defer tick.Stop()
+	t.run(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			t.run(ctx)
+		}
+	}
+}
+
+func (t *Tier) run(ctx context.Context) {
+	if !t.running.CompareAndSwap(false, true) {
+		slog.Warn("cold tier: previous pass still in progress, skipping this tick")
+		return
+	}
+	defer t.running.Store(false)
+	start := time.Now()
+	exported, err := t.RunOnce(ctx)
+	t.opts.Metrics.RecordColdTierRun(exported, err)
+	if err != nil && ctx.Err() == nil {
+		slog.Error("cold tier pass failed", "error", err)
+		return
+	}
+	slog.Info("cold tier pass complete",
+		"logs", exported[storage.ColdSignalLogs],
+		"traces", exported[storage.ColdSignalTraces],
+		"spans", exported[storage.ColdSignalSpans],
+		"duration", time.Since(start).Round(time.Millisecond),
+	)
+}
+
+// RunOnce drops expired rehydrations, then exports every tenant's traces
+// and logs older than After and returns the rows exported per signal. A
+// failed tenant does not stop the others; the first error is returned.
+func (t *Tier) RunOnce(ctx context.Context) (map[string]int64, error) {
+	exported := map[string]int64{}
+	if n, err := t.repo.PurgeExpiredRehydrations(ctx, time.Now().UTC()); err != nil {
+		slog.Error("cold tier: dropping expired rehydrations failed", "error", err)
+	} else if n > 0 {
+		slog.Info("cold tier: expired rehydrations dropped", "jobs", n)
+	}
+	cutoff := time.Now().UTC().Add(-t.opts.After)
+	signals := []string{storage.ColdSignalTraces}
+	if t.repo.LogsSharded() {
+		// Shard files are dropped whole by retention; rows cannot be
+		// deleted from them one batch at a time.
+		slog.Debug("cold tier: logs are in SQLite shards, tiering traces only")
+	} else {
+		signals = append(signals, storage.ColdSignalLogs)
+	}
+	var firstErr error
+	for _, signal := range signals {
+		tenants, err := t.repo.ColdTierTenants(ctx, signal, cutoff)
+		if err != nil {
+			return exported, err
+		}
+		for _, tenant := range tenants {
+			if signal == storage.ColdSignalLogs {
+				err = t.exportLogs(ctx, tenant, cutoff, exported)
+			} else {
+				err = t.exportTraces(ctx, tenant, cutoff, exported)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return exported, ctx.Err()
+				}
+				slog.Error("cold tier export failed", "signal", signal, "tenant", tenant, "error", err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s for tenant %s: %w", signal, tenant, err)
+				}
+			}
+		}
+	}
+	return exported, firstErr
+}
+
+func (t *Tier) exportLogs(ctx context.Context, tenant string, cutoff time.Time, exported map[string]int64) error {
+	for ctx.Err() == nil {
+		logs, err := t.repo.LogsForColdTier(ctx, tenant, cutoff, t.opts.BatchRows)
+		if err != nil || len(logs) == 0 {
+			return err
+		}
+		minT, maxT := logs[0].Timestamp, logs[0].Timestamp
+		for _, l := range logs {
+			minT, maxT = earliest(minT, l.Timestamp), latest(maxT, l.Timestamp)
+		}
+		first, last := logs[0].ID, logs[len(logs)-1].ID
+		seg, err := t.upload(ctx, storage.ColdSignalLogs, tenant, minT, maxT, first, last, logSchema, len(logs), func(i int) []any {
+			return logRow(&logs[i])
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := t.repo.CommitColdLogs(ctx, tenant, cutoff, last, []storage.ColdSegment{seg}); err != nil {
+			return err
+		}
+		exported[storage.ColdSignalLogs] += int64(len(logs))
+		if len(logs) < t.opts.BatchRows {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+func (t *Tier) exportTraces(ctx context.Context, tenant string, cutoff time.Time, exported map[string]int64) error {
+	batch := max(t.opts.BatchRows/10, 1)
+	for ctx.Err() == nil {
+		traces, spans, err := t.repo.TracesForColdTier(ctx, tenant, cutoff, batch)
+		if err != nil || len(traces) == 0 {
+			return err
+		}
+		minT, maxT := traces[0].Timestamp, traces[0].Timestamp
+		ids := make([]string, len(traces))
+		for i, tr := range traces {
+			minT, maxT = earliest(minT, tr.Timestamp), latest(maxT, tr.Timestamp)
+			ids[i] = tr.TraceID
+		}
+		first, last := traces[0].ID, traces[len(traces)-1].ID
+		seg, err := t.upload(ctx, storage.ColdSignalTraces, tenant, minT, maxT, first, last, traceSchema, len(traces), func(i int) []any {
+			return traceRow(&traces[i])
+		})
+		if err != nil {
+			return err
+		}
+		segments := []storage.ColdSegment{seg}
+		var lastSpan uint
+		if len(spans) > 0 {
+			lastSpan = spans[len(spans)-1].ID
+			sMin, sMax := spans[0].StartTime, spans[0].StartTime
+			for _, s := range spans {
+				sMin, sMax = earliest(sMin, s.StartTime), latest(sMax, s.StartTime)
+			}
+			// Keyed by the trace batch, so the spans segment sits beside
+			// its traces segment.
+			spanSeg, err := t.upload(ctx, storage.ColdSignalSpans, tenant, minT, maxT, first, last, spanSchema, len(spans), func(i int) []any {
+				return spanRow(&spans[i])
+			})
+			if err != nil {
+				return err
+			}
+			spanSeg.MinTime, spanSeg.MaxTime = earliest(sMin, minT), latest(sMax, maxT)
+			segments = append(segments, spanSeg)
+		}
+		if _, err := t.repo.CommitColdTraces(ctx, tenant, cutoff, last, lastSpan, ids, segments); err != nil {
+			return err
+		}
+		exported[storage.ColdSignalTraces] += int64(len(traces))
+		exported[storage.ColdSignalSpans] += int64(len(spans))
+		if len(traces) < batch {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// upload encodes n rows as a Parquet segment, puts it in the store and
+// returns its unsaved ColdSegment row.
+func (t *Tier) upload(ctx context.Context, signal, tenant string, minT, maxT time.Time, first, last uint, schema []parquet.Column, n int, rowAt func(int) []any) (storage.ColdSegment, error) {
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(&buf, schema)
+	if err != nil {
+		return storage.ColdSegment{}, err
+	}
+	for i := range n {
+		if err := w.Write(rowAt(i)...); err != nil {
+			return storage.ColdSegment{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return storage.ColdSegment{}, err
+	}
+	key := segmentKey(signal, tenant, minT, first, last)
+	if err := t.store.Put(ctx, key, buf.Bytes()); err != nil {
+		return storage.ColdSegment{}, fmt.Errorf("upload %s: %w", key, err)
+	}
+	return storage.ColdSegment{
+		Signal:    signal,
+		ObjectKey: key,
+		MinTime:   minT,
+		MaxTime:   maxT,
+		RowCount:  int64(n),
+		SizeBytes: int64(buf.Len()),
+	}, nil
+}
+
+// segmentKey is <signal>/<tenant>/YYYY/MM/DD/<first id>-<last id>.parquet,
+// dated by the segment's oldest row.
+func segmentKey(signal, tenant string, minT time.Time, first, last uint) string {
+	return fmt.Sprintf("%s/%s/%s/%d-%d.parquet", signal, url.PathEscape(tenant), minT.UTC().Format("2006/01/02"), first, last)
+}
+
+func earliest(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// readSegment fetches and decodes one segment. A segment whose object is
+// gone, e.g. expired by a bucket lifecycle rule, reads as empty.
+func (t *Tier) readSegment(ctx context.Context, seg storage.ColdSegment) (map[string]int, [][]any, error) {
+	data, err := t.store.Get(ctx, seg.ObjectKey)
+	if errors.Is(err, ErrNotFound) {
+		slog.Warn("cold tier: segment object missing", "key", seg.ObjectKey)
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, rows, err := parquet.Read(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode %s: %w", seg.ObjectKey, err)
+	}
+	return columnIndex(schema), rows, nil
+}
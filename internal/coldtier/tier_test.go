@@ -0,0 +1,117 @@
+package coldtier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func newTestTier(t *testing.T) (*Tier, *storage.Repository) {
+	t.Helper()
+	db, err := storage.NewDatabase("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := storage.AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := storage.NewRepositoryFromDB(db, "sqlite")
+	t.Cleanup(func() { _ = repo.Close() })
+	store, err := OpenStore(StoreConfig{URL: "file://" + t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(repo, store, Options{After: 24 * time.Hour, BatchRows: 20}), repo
+}
+
+func TestTier_ExportAndQuery(t *testing.T) {
+	tier, repo := newTestTier(t)
+	db := repo.DB()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	old := now.Add(-72 * time.Hour)
+
+	// 25 old logs span two segments of 20; one recent log stays hot.
+	for i := range 25 {
+		sev := "INFO"
+		if i == 3 {
+			sev = "ERROR"
+		}
+		db.Create(&storage.Log{TenantID: "acme", ServiceName: "checkout", Severity: sev, Body: "payment declined",
+			TraceID: "t1", Timestamp: old.Add(time.Duration(i) * time.Minute), AttributesJSON: `{"k":"v"}`})
+	}
+	db.Create(&storage.Log{TenantID: "acme", ServiceName: "checkout", Severity: "INFO", Body: "recent", Timestamp: now})
+	db.Create(&storage.Log{TenantID: "other", ServiceName: "checkout", Severity: "INFO", Body: "payment", Timestamp: old})
+	db.Create(&storage.Trace{TenantID: "acme", TraceID: "t1", ServiceName: "checkout", Status: "STATUS_CODE_ERROR", Timestamp: old, DurationNs: 5e6})
+	root := storage.Span{TenantID: "acme", TraceID: "t1", SpanID: "s1", OperationName: "POST /pay", ServiceName: "checkout", AttributesJSON: `{"http.status_code":500}`}
+	root.SetTiming(old, old.Add(5*time.Millisecond))
+	child := storage.Span{TenantID: "acme", TraceID: "t1", SpanID: "s2", ParentSpanID: "s1", OperationName: "db", ServiceName: "checkout"}
+	child.SetTiming(old.Add(time.Millisecond), old.Add(3*time.Millisecond))
+	db.Create(&root)
+	db.Create(&child)
+
+	ctx := context.Background()
+	exported, err := tier.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if exported[storage.ColdSignalLogs] != 26 || exported[storage.ColdSignalTraces] != 1 || exported[storage.ColdSignalSpans] != 2 {
+		t.Errorf("exported = %v", exported)
+	}
+	var hotLogs, hotSpans int64
+	db.Model(&storage.Log{}).Count(&hotLogs)
+	db.Model(&storage.Span{}).Count(&hotSpans)
+	if hotLogs != 1 || hotSpans != 0 {
+		t.Errorf("hot rows left: %d logs, %d spans", hotLogs, hotSpans)
+	}
+	// A second pass finds nothing to do.
+	if again, err := tier.RunOnce(ctx); err != nil || len(again) != 0 {
+		t.Errorf("second pass = %v, %v", again, err)
+	}
+
+	acme := storage.WithTenantContext(ctx, "acme")
+	logs, err := tier.Logs(acme, LogQuery{Search: "DECLINED", Limit: 10})
+	if err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if len(logs.Data) != 10 || logs.SegmentsScanned != 2 || logs.Truncated {
+		t.Fatalf("logs = %d rows from %d segments", len(logs.Data), logs.SegmentsScanned)
+	}
+	newest := logs.Data[0]
+	if !newest.Timestamp.Equal(old.Add(24*time.Minute)) || newest.AttributesJSON != `{"k":"v"}` || newest.TenantID != "acme" {
+		t.Errorf("newest log = %+v", newest)
+	}
+	if errs, _ := tier.Logs(acme, LogQuery{Severity: "error"}); len(errs.Data) != 1 {
+		t.Errorf("error logs = %d, want 1", len(errs.Data))
+	}
+	if none, _ := tier.Logs(acme, LogQuery{Start: old.Add(time.Hour)}); len(none.Data) != 0 || none.SegmentsScanned != 0 {
+		t.Errorf("logs after the range = %+v", none)
+	}
+	if other, _ := tier.Logs(storage.WithTenantContext(ctx, "other"), LogQuery{}); len(other.Data) != 1 || other.Data[0].TenantID != "other" {
+		t.Errorf("other tenant logs = %+v", other.Data)
+	}
+
+	traces, err := tier.Traces(acme, TraceQuery{Status: "STATUS_CODE_ERROR"})
+	if err != nil || len(traces.Data) != 1 || traces.Data[0].DurationNs != 5e6 {
+		t.Fatalf("Traces = %+v, %v", traces, err)
+	}
+	tr, err := tier.Trace(acme, "t1", time.Time{}, time.Time{})
+	if err != nil || tr == nil {
+		t.Fatalf("Trace = %v, %v", tr, err)
+	}
+	if len(tr.Spans) != 2 || tr.Operation != "POST /pay" || tr.Spans[1].DurationNs != (2*time.Millisecond).Nanoseconds() ||
+		!tr.Spans[0].StartTime.Equal(old) || tr.Spans[0].AttributesJSON != `{"http.status_code":500}` {
+		t.Errorf("trace = %+v", tr)
+	}
+	if tr, err := tier.Trace(storage.WithTenantContext(ctx, "other"), "t1", time.Time{}, time.Time{}); err != nil || tr != nil {
+		t.Errorf("other tenant reads acme's trace: %v, %v", tr, err)
+	}
+}
+
+func TestSegmentKey(t *testing.T) {
+	ts := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+	if got := segmentKey("logs", "acme/eu", ts, 7, 42); got != "logs/acme%2Feu/2026/02/03/7-42.parquet" {
+		t.Errorf("segmentKey = %s", got)
+	}
+}
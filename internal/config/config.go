@@ -1,12 +1,18 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
 	"github.com/joho/godotenv"
 )
 
@@ -33,6 +39,57 @@ type Config struct {
 	// behavior change vs. the single-threshold semantics.
 	StoreMinSeverity string
 
+	// Strict validation. When enabled, spans and log records with impossible
+	// data (end before start, timestamps beyond IngestMaxFutureSkew, missing
+	// or all-zero IDs) are rejected at the receiver and reported to the
+	// client via OTLP partial_success. Off by default — legacy behavior
+	// ingests everything that parses.
+	IngestStrictValidation bool
+	IngestMaxFutureSkew    string // e.g. "10m"
+
+	// Health-check noise. When IngestDropHealthChecks is on, spans and log
+	// records whose route (http.route / url.path / http.target) is one of
+	// IngestHealthCheckRoutes, or whose user agent contains one of
+	// IngestHealthCheckUserAgents, are dropped at the receiver. Failing
+	// probes are kept. Both lists are comma-separated.
+	IngestDropHealthChecks      bool
+	IngestHealthCheckRoutes     string
+	IngestHealthCheckUserAgents string
+	// IngestRewriteRulesFile, when set, points at a JSON array of per-service
+	// span rewrite rules (status overrides, attribute sets) applied at
+	// ingest. See ingest.RewriteRule for the format.
+	IngestRewriteRulesFile string
+	// IngestSpanNameRulesFile, when set, points at a JSON array of span name
+	// normalization rules (regex replace, path templates) applied at
+	// ingest. See ingest.SpanNameRule for the format.
+	IngestSpanNameRulesFile string
+	// IngestRedactionRulesFile, when set, points at a JSON array of
+	// redaction rules (attribute keys, credit card and email patterns,
+	// custom regexes) applied to traces and logs before anything is
+	// stored. See ingest.RedactionRule for the format. Rules with action
+	// "hash" key their HMAC with IngestRedactionHashKey.
+	IngestRedactionRulesFile string
+	IngestRedactionHashKey   string
+	// IngestTransformFile, when set, points at a YAML chain of attribute
+	// processors (rename, set, drop, derive) applied to span and log record
+	// attributes at ingest. See ingest.TransformConfig for the format.
+	IngestTransformFile string
+	// IngestRecordFixturesDir, when set, writes incoming OTLP requests to
+	// that directory as OTLP/JSON golden fixtures (see ingest.FixtureRecorder),
+	// at most IngestRecordFixturesMax files. A debugging aid — payloads are
+	// stored verbatim.
+	IngestRecordFixturesDir string
+	IngestRecordFixturesMax int
+	// IngestAuthFile, when set, requires OTLP exports (gRPC and /v1/*) to
+	// carry a bearer token or basic auth credential listed in that file,
+	// each optionally pinned to a tenant and a service allowlist. See
+	// ingest.LoadAuthFile for the format.
+	IngestAuthFile string
+	// TraceIDAccept64Bit accepts legacy 8-byte trace IDs and zero-pads them
+	// to the canonical 128-bit form (see internal/traceid). When false they
+	// are stored unpadded and rejected under strict validation.
+	TraceIDAccept64Bit bool
+
 	// DB Connection Pool
 	DBMaxOpenConns    int
 	DBMaxIdleConns    int
@@ -51,6 +108,15 @@ type Config struct {
 	// policy is short and ingest spikes around a daily boundary.
 	DBPartitionLookaheadDays int
 
+	// SQLite-only opt-in: shard the logs table into one database file per
+	// UTC day under DBSQLiteShardDir. Today's logs stay in the main file;
+	// the SQLiteShardScheduler moves closed days into their shard and
+	// deletes shard files past the logs retention window, so backups copy
+	// small immutable files and retention is an unlink. Empty / "none" =
+	// single file.
+	DBSQLiteSharding string
+	DBSQLiteShardDir string
+
 	// Retention
 	HotRetentionDays int
 
@@ -60,13 +126,107 @@ type Config struct {
 	RetentionBatchSize    int
 	RetentionBatchSleepMs int
 
+	// Per-signal retention windows, e.g. "72h" or "30d". Empty falls back to
+	// HotRetentionDays. Parsed with ParseRetentionWindow.
+	RetentionTraces  string
+	RetentionLogs    string
+	RetentionMetrics string
+
+	// RetentionTenants gives individual tenants their own window for every
+	// signal, e.g. "team-a=3d,team-b=30d". Listed tenants skip the global
+	// windows above. Parsed with ParseTenantRetention.
+	RetentionTenants string
+
+	// Cold tier: traces and logs older than ColdTierAfter (a
+	// ParseRetentionWindow window, shorter than their retention) move to
+	// Parquet segments at ColdTierURL — file:///dir, s3://bucket/prefix or
+	// gs://bucket/prefix. Empty URL = off. GCS is reached through its S3
+	// API with an HMAC key. The key pair falls back to AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+	ColdTierURL             string
+	ColdTierAfter           string // default "3d"
+	ColdTierInterval        string // default "1h"
+	ColdTierS3Endpoint      string // e.g. a MinIO URL; default AWS or GCS
+	ColdTierS3Region        string
+	ColdTierS3PathStyle     bool
+	ColdTierAccessKeyID     string
+	ColdTierSecretAccessKey string // never logged
+	ColdTierSessionToken    string // never logged
+
+	// Export sink: ingested spans, logs and (optionally) metric points are
+	// republished as JSON to ExportURL — kafka://host:9092[,host2:9092] or
+	// nats://host:4222, "+tls" on the scheme for TLS, userinfo for
+	// SASL/PLAIN or NATS credentials. Empty URL = off; an empty topic skips
+	// that signal. Delivery is best effort and never slows ingestion.
+	ExportURL           string // never logged: may carry credentials
+	ExportTracesTopic   string // default "argus.spans"
+	ExportLogsTopic     string // default "argus.logs"
+	ExportMetricsTopic  string // default "" (off)
+	ExportBatchSize     int    // default 500
+	ExportFlushInterval string // default "1s"
+	ExportQueueSize     int    // default 10000
+	ExportTLSCAFile     string
+
 	// TSDB
 	TSDBRingBufferDuration string // e.g. "1h"
 
+	// FeatureFlags overrides featureflag registry values, e.g.
+	// "tail_sampling,ai_insights=off" (see featureflag.ParseSpec). An entry
+	// wins over the subsystem's own switch such as TAIL_SAMPLING_ENABLED.
+	FeatureFlags string
+
 	// Smart Observability — Adaptive Sampling
 	SamplingRate               float64
 	SamplingAlwaysOnErrors     bool
 	SamplingLatencyThresholdMs int
+	// SamplingServiceRates overrides SamplingRate for some services as
+	// "service=rate" pairs. Those services are sampled by trace ID ratio,
+	// so every kept trace is kept whole. Parsed with ParseServiceRates.
+	SamplingServiceRates string
+
+	// IngestSpanRateLimits and IngestLogRateLimits cap spans and log
+	// records per second per service as "service=limit" pairs; "*" sets
+	// the limit for every unlisted service. Excess records are dropped and
+	// reported via OTLP partial_success. Parsed with ParseServiceRateLimits.
+	IngestSpanRateLimits string
+	IngestLogRateLimits  string
+
+	// Tail sampling — buffer each trace for TailSamplingDecisionWait, then
+	// keep it if any policy matches: an error span, a span slower than
+	// TailSamplingLatencyThresholdMs (0 disables), a span from one of
+	// TailSamplingServices (comma-separated), or the probabilistic
+	// TailSamplingRate fallback.
+	TailSamplingEnabled            bool
+	TailSamplingDecisionWait       string // e.g. "10s"
+	TailSamplingKeepErrors         bool
+	TailSamplingLatencyThresholdMs int
+	TailSamplingServices           string
+	TailSamplingRate               float64
+	TailSamplingMaxTraces          int
+
+	// Span metrics — RED metrics (otelcontext_span_calls_total and
+	// otelcontext_span_duration_seconds) generated from ingested spans and
+	// served on /metrics/prometheus. SpanMetricsMaxSeries caps the
+	// tenant/service/span name combinations; later ones count as
+	// span_name="(other)".
+	SpanMetricsEnabled   bool // default true
+	SpanMetricsMaxSeries int  // default 10000
+
+	// Alerting — evaluate user-defined rules (created via /api/alerts/rules)
+	// every AlertEvalInterval. Email channels deliver through the
+	// AlertSMTP* relay; Slack and webhook channels need no server config.
+	AlertingEnabled   bool
+	AlertEvalInterval string // e.g. "1m"
+	AlertSMTPAddr     string // host:port
+	AlertSMTPFrom     string
+	AlertSMTPUsername string
+	AlertSMTPPassword string
+
+	// Uptime monitors (created via /api/monitors) are checked from this
+	// instance; results older than UptimeResultRetention (a
+	// ParseRetentionWindow window; empty keeps everything) are pruned.
+	UptimeEnabled         bool   // default true
+	UptimeResultRetention string // default "30d"
 
 	// Smart Observability — Metric Cardinality
 	MetricAttributeKeys  string // comma-separated allowlist
@@ -90,6 +250,33 @@ type Config struct {
 	// hammering the (just-restarted) DB and exhausting connections.
 	// 0 = unlimited (legacy default).
 	DLQMaxReplayPerTick int
+	// DLQReplayConcurrency is how many replay calls a tick runs at once
+	// (0 or 1 = one at a time). DLQCoalesceFiles groups up to that many
+	// batch files of at most DLQCoalesceMaxBytes each on disk into one
+	// transactional insert, counted as one call against
+	// DLQMaxReplayPerTick (0 or 1 = off).
+	DLQReplayConcurrency int
+	DLQCoalesceFiles     int
+	DLQCoalesceMaxBytes  int
+	// DLQMaxBytes caps the DLQ directory in bytes, overriding DLQMaxDiskMB
+	// when > 0. DLQMaxAge ("" = off, else a ParseRetentionWindow window)
+	// expires batches older than it on every replay tick. DLQOverflowPolicy
+	// is what a full DLQ does with a new batch: drop_oldest evicts the
+	// oldest files to make room, reject_new refuses the new batch.
+	DLQMaxBytes       int
+	DLQMaxAge         string
+	DLQOverflowPolicy string
+	// DLQReadMergeWindow ("" or "0" = off, else a duration such as "15m")
+	// merges logs still queued in the DLQ into GET /api/logs results whose
+	// time range ends within the window, marked pending.
+	DLQReadMergeWindow string
+	// DLQ growth alerts (built-in conditions on /api/health, MCP get_alerts
+	// and otelcontext_dlq_alert_active). 0 / "0" disables a condition.
+	// DLQAlertDiskPct is a percentage of DLQByteLimit (ignored when that is 0).
+	DLQAlertFiles         int
+	DLQAlertDiskPct       int
+	DLQAlertMaxAge        string // e.g. "15m"
+	DLQAlertFailureStreak int
 
 	// API Protection
 	APIRateLimitRPS int
@@ -129,12 +316,13 @@ type Config struct {
 	// final snapshot still fires on graceful shutdown). Default "5m".
 	VectorIndexSnapshotInterval string
 
-	// LogFTSEnabled toggles SQLite FTS5 provisioning + querying. The FTS5
+	// LogFTSEnabled toggles full-text log search: the FTS5 virtual table on
+	// SQLite, a tsvector GIN index on logs.body on Postgres. The FTS5
 	// inverted index typically consumes 30-40% of SQLite DB disk for
 	// log-heavy workloads, while the LIKE fallback (log_repo.go:105) keeps
 	// search_logs functional without it. Default false; opt in with
-	// LOG_FTS_ENABLED=true. Only meaningful on SQLite; Postgres uses pg_trgm
-	// independently of this flag.
+	// LOG_FTS_ENABLED=true. Postgres' pg_trgm indexes back the ILIKE path
+	// independently of this flag; MySQL and SQL Server always use LIKE.
 	LogFTSEnabled bool
 
 	// GraphRAG worker count (background consumers of the ingestion event channel).
@@ -163,6 +351,69 @@ type Config struct {
 	// N is the expected number of concurrently-active tenants, with some
 	// headroom (e.g. 2× the fair-share value) for short bursts.
 	IngestPipelinePerTenantCap int
+	// Write batching. A worker coalesces queued Export batches into one
+	// insert transaction of up to IngestPipelineBatchSize records (spans or
+	// log records; 0 writes each batch on its own), waiting at most
+	// IngestPipelineFlushInterval for more to arrive. A failed write is
+	// retried IngestPipelineWriteRetries times with backoff, then each batch
+	// is written alone and the ones that still fail spill to the DLQ.
+	IngestPipelineBatchSize     int    // default 1000
+	IngestPipelineFlushInterval string // default "50ms"
+	IngestPipelineWriteRetries  int    // default 3
+
+	// Trace finalization. Trace rows are written from the first span seen;
+	// once a trace has received no spans for TraceFinalizeQuietPeriod its
+	// Duration/Status/Timestamp are recomputed from the persisted spans so
+	// late-arriving spans are reflected. TraceBackfillOnStart runs the same
+	// recomputation once over every historical trace row in the background
+	// at boot — enable it for one restart after upgrading, then turn it off.
+	TraceFinalizeEnabled     bool   // default true
+	TraceFinalizeQuietPeriod string // e.g. "30s"
+	TraceBackfillOnStart     bool   // default false
+
+	// Storage usage sampling behind GET /api/admin/storage. Every
+	// StorageUsageInterval the estimated bytes per tenant, signal and service
+	// are recorded; samples older than StorageUsageHistory are pruned. Both
+	// take ParseRetentionWindow windows; an interval of "0" disables sampling
+	// and an empty history keeps every sample.
+	StorageUsageInterval string // default "1h"
+	StorageUsageHistory  string // default "30d"
+
+	// Dashboard rollups. When enabled, a background aggregator rolls settled
+	// traces and log counts into per-service minute and hour buckets, and
+	// GetDashboardStats reads them instead of scanning raw rows. The first
+	// run backfills DashboardRollupBackfill; hour buckets older than
+	// DashboardRollupRetention are pruned (empty keeps them). Both take
+	// ParseRetentionWindow windows.
+	DashboardRollupsEnabled  bool   // default true
+	DashboardRollupBackfill  string // default "24h"
+	DashboardRollupRetention string // default "90d"
+
+	// Issue tracking. When enabled, a background pass folds settled error
+	// logs and failed spans into Issue rows (GET /api/issues) with first
+	// seen, last seen and occurrence counts. The first run backfills
+	// IssueBackfill, a ParseRetentionWindow window.
+	IssuesEnabled bool   // default true
+	IssueBackfill string // default "7d"
+
+	// Deployment tracking. DeploymentsFromServiceVersion records a
+	// deployment the first time a service reports a new service.version
+	// resource attribute over OTLP. Every successful deployment is judged
+	// once DeploymentImpactWindow has passed: the service's latency and
+	// error rate over that window after it are compared with the window
+	// before, and regressions flagged.
+	DeploymentsFromServiceVersion bool   // default true
+	DeploymentImpactWindow        string // default "30m"
+
+	// Self-history behind GET /api/admin/history: restarts, config changes,
+	// database outages and ingest gaps of this instance. An ingest gap is
+	// SelfHistoryIngestGap (a Go duration; "0" disables gap detection) or
+	// more without a span or log after ingest had started. Events older than
+	// SelfHistoryRetention (a ParseRetentionWindow window; empty keeps
+	// everything) are pruned.
+	SelfHistoryEnabled   bool   // default true
+	SelfHistoryIngestGap string // default "5m"
+	SelfHistoryRetention string // default "90d"
 
 	// TLS (HTTP + gRPC). When both paths are set, TLS is enabled on both servers.
 	// Empty values (default) keep plaintext behavior.
@@ -175,6 +426,15 @@ type Config struct {
 	TLSAutoSelfsigned bool
 	TLSCacheDir       string
 
+	// OTLP receiver TLS. OTLPTLSCert/OTLPTLSKey give the gRPC receiver its
+	// own certificate instead of the TLS_CERT_FILE one; the HTTP port, which
+	// also serves OTLP/HTTP, uses it when no other TLS is configured.
+	// OTLPTLSClientCA turns on mutual TLS: gRPC clients and /v1/* requests
+	// must present a certificate signed by one of its CAs.
+	OTLPTLSCert     string
+	OTLPTLSKey      string
+	OTLPTLSClientCA string
+
 	// API key authentication. When empty, auth middleware is a pass-through.
 	// Loaded from API_KEY env var — never logged.
 	APIKey string
@@ -188,6 +448,19 @@ type Config struct {
 	// X-Tenant-ID header (HTTP) / x-tenant-id gRPC metadata.
 	DefaultTenant string
 
+	// Region identifies this instance's region. Stamped on every trace,
+	// span, log and metric bucket it stores, so instances of an
+	// active-active deployment sharing one database can be told apart and
+	// queried per region (?region=). Empty = rows are not stamped.
+	Region string
+
+	// SpanAttributeIndexKeys is a comma-separated list of span attribute
+	// keys copied into the span_attributes table at write time, so
+	// /api/traces can filter on them (?attr.http.status_code=500). "*"
+	// indexes every scalar attribute (one row each — size accordingly).
+	// Empty = attribute filtering disabled.
+	SpanAttributeIndexKeys string
+
 	// OTLPTrustResourceTenant enables resolving the tenant from the OTLP
 	// `tenant.id` resource attribute when no transport-level tenant header
 	// was provided. Disabled by default because resource attributes are
@@ -196,6 +469,66 @@ type Config struct {
 	// all OTLP producers are trusted.
 	OTLPTrustResourceTenant bool
 
+	// OTLPHTTPCORSOrigins is a comma-separated list of origins allowed to
+	// post to the OTLP/HTTP receiver (/v1/*) from a browser. Empty
+	// (default) disables CORS; "*" allows any origin.
+	OTLPHTTPCORSOrigins string
+
+	// JaegerAgentAddr, when non-empty, starts a UDP receiver speaking the
+	// legacy Jaeger agent protocol (emitBatch, Thrift compact) on that
+	// address — e.g. ":6831". For services not yet re-instrumented with
+	// OTLP. Empty (default) disables it.
+	JaegerAgentAddr string
+
+	// JaegerCollectorHTTPAddr and JaegerCollectorGRPCAddr, when non-empty,
+	// start Jaeger collector-compatible listeners: Thrift binary over HTTP
+	// (POST /api/traces, conventionally ":14268") and the api_v2 gRPC
+	// CollectorService (conventionally ":14250"). Empty (default) disables
+	// each.
+	JaegerCollectorHTTPAddr string
+	JaegerCollectorGRPCAddr string
+
+	// ZipkinAddr, when non-empty, starts a Zipkin-compatible HTTP listener
+	// accepting v2 JSON spans on POST /api/v2/spans — e.g. ":9411". Empty
+	// (default) disables it.
+	ZipkinAddr string
+
+	// LokiAddr, when non-empty, starts a Loki-compatible HTTP listener
+	// accepting POST /loki/api/v1/push from Promtail / Grafana Agent — e.g.
+	// ":3100". Empty (default) disables it.
+	LokiAddr string
+
+	// StatsDAddr, when non-empty, starts a UDP StatsD/DogStatsD listener on
+	// that address — e.g. ":8125". Counters, gauges and timers feed the
+	// metrics pipeline. Empty (default) disables it.
+	StatsDAddr string
+
+	// FirehoseAccessKey, when non-empty, enables POST /ingest/firehose for
+	// Kinesis Data Firehose HTTP endpoint delivery (CloudWatch Logs
+	// subscription data). Firehose must be configured with the same access
+	// key. Deliveries land in FirehoseTenant (empty = DEFAULT_TENANT).
+	// FirehoseLogGroupServices maps log groups to service names as
+	// "group=service,prefix*=service".
+	FirehoseAccessKey        string
+	FirehoseTenant           string
+	FirehoseLogGroupServices string
+
+	// LogDrains enables the Heroku (/ingest/drains/heroku) and Vercel
+	// (/ingest/drains/vercel) log drain endpoints. Comma-separated
+	// "name:token[:tenant]"; each drain authenticates with its own token and
+	// name is the default service name. Empty (default) disables both.
+	LogDrains string
+
+	// GitHubWebhookSecret, when non-empty, enables POST /ingest/github for
+	// GitHub deployment, deployment_status and workflow_run webhooks, which
+	// become deploy annotations. GitHub must sign with the same secret.
+	// Events land in GitHubWebhookTenant (empty = DEFAULT_TENANT).
+	// GitHubDeployWorkflows limits workflow_run events to the listed
+	// comma-separated workflow names; empty accepts every workflow.
+	GitHubWebhookSecret   string
+	GitHubWebhookTenant   string
+	GitHubDeployWorkflows string
+
 	// APITenantKeysFile, when non-empty, switches API auth from a single
 	// shared API_KEY into per-tenant bearer tokens. The file contains one
 	// `key=tenant` pair per line; the matched key's tenant OVERRIDES any
@@ -203,6 +536,36 @@ type Config struct {
 	// (legacy shared-key mode remains available for single-tenant dev).
 	APITenantKeysFile string
 
+	// APIKeysEnabled turns on managed API keys: hashed keys in the api_keys
+	// table, issued via /api/keys with an ingest, read or admin scope, and
+	// enforced on the HTTP API and the OTLP gRPC receiver. API_KEY and
+	// API_TENANT_KEYS_FILE keys keep working as admin keys alongside them.
+	APIKeysEnabled bool
+
+	// OIDC sign-in for the UI, API and /ws, enabled by OIDCIssuerURL.
+	// Browser sessions are cookies signed with OIDCSessionSecret; API keys
+	// keep working for automation alongside them.
+	OIDCIssuerURL      string
+	OIDCClientID       string
+	OIDCClientSecret   string // never logged
+	OIDCRedirectURL    string // e.g. https://argus.example.com/auth/callback
+	OIDCScopes         string // space- or comma-separated; "openid" is always requested
+	OIDCSessionSecret  string // at least 32 bytes; never logged
+	OIDCSessionTTL     string // e.g. "12h"
+	OIDCAllowedDomains string // comma-separated email domains; empty allows any
+	OIDCGroupsClaim    string // ID token claim listing the user's groups
+
+	// Roles of signed-in users: RBACAdmins and RBACEditors list emails or
+	// groups (comma-separated); everyone else gets RBACDefaultRole
+	// (viewer | editor | admin | none, which refuses sign-in).
+	RBACAdmins      string
+	RBACEditors     string
+	RBACDefaultRole string
+
+	// AuthDevBypass signs every browser and API request in as a local
+	// development user instead of running OIDC. Refused in production.
+	AuthDevBypass bool
+
 	// DevMode disables origin checks for WebSocket and enables dev-friendly defaults.
 	// Derived from APP_ENV == "development".
 	DevMode bool
@@ -221,6 +584,49 @@ type Config struct {
 	// the cap receive HTTP 503. Sized for the operator's expected dashboard
 	// audience — small for ops dashboards, larger for read-heavy public UIs.
 	WSMaxClients int
+
+	// WebSocket keepalive for /ws: each client is pinged every
+	// WSPingInterval ("0" disables) and closed when a ping goes unanswered
+	// for WSIdleTimeout, so half-open connections behind a load balancer
+	// are reaped. WSReadLimitBytes caps one client message.
+	WSPingInterval   string // e.g. "30s"
+	WSIdleTimeout    string // e.g. "60s"
+	WSReadLimitBytes int
+
+	// WSCompression lists the /ws payload compressions offered to clients,
+	// comma-separated: "zstd" (opt-in per client via an
+	// "otelcontext.vN+zstd" subprotocol) and "deflate" (permessage-deflate,
+	// negotiated by the browser). "none" disables both.
+	WSCompression string // e.g. "zstd"
+
+	// WebSocket hub flushing: buffered logs, metrics and traces go out when
+	// HubBufferSize of one kind are queued or every HubFlushInterval.
+	// HubPreset picks both from HubPresets ("" = default); the other two
+	// override it when set. Adjustable at runtime via /api/admin/hub.
+	HubPreset        string
+	HubBufferSize    int
+	HubFlushInterval string // e.g. "500ms"
+
+	// ConfigFile, when set, names a YAML file (conventionally argus.yaml)
+	// whose keys override the environment for ingestion filters, sampling,
+	// retention, redaction and alert rules. It is watched for file system
+	// events, re-read every ConfigReloadInterval as a fallback, and changes
+	// apply without a restart. See File and WatchFile.
+	ConfigFile           string
+	ConfigReloadInterval string // e.g. "30s"; "0" disables reloading
+
+	// RedactionRules and AlertRules come from the config file only.
+	// Non-nil RedactionRules replace IngestRedactionRulesFile.
+	RedactionRules []RedactionRuleSpec
+	AlertRules     []AlertRuleSpec
+
+	// env is the configuration as read from the environment, before the
+	// config file was applied; Reload starts from it. file is the config
+	// file last applied and runtime the overrides set through /api/config,
+	// applied last.
+	env     *Config
+	file    *File
+	runtime map[string]string
 }
 
 func Load(customPath string) (*Config, error) {
@@ -240,7 +646,7 @@ func Load(customPath string) (*Config, error) {
 	}
 
 	env := getEnv("APP_ENV", "development")
-	return &Config{
+	c := &Config{
 		Env:               env,
 		DevMode:           env == "development",
 		LogLevel:          getEnv("LOG_LEVEL", "INFO"),
@@ -255,6 +661,21 @@ func Load(customPath string) (*Config, error) {
 		StoreMinSeverity:       getEnv("STORE_MIN_SEVERITY", ""),
 		IngestAllowedServices:  getEnv("INGEST_ALLOWED_SERVICES", ""),
 		IngestExcludedServices: getEnv("INGEST_EXCLUDED_SERVICES", ""),
+		IngestStrictValidation: parseTruthy(getEnv("INGEST_STRICT_VALIDATION", "")),
+		IngestMaxFutureSkew:    getEnv("INGEST_MAX_FUTURE_SKEW", "10m"),
+
+		IngestDropHealthChecks:      getEnvBool("INGEST_DROP_HEALTH_CHECKS", false),
+		IngestHealthCheckRoutes:     getEnv("INGEST_HEALTH_CHECK_ROUTES", "/healthz,/health,/livez,/readyz,/ready,/live,/ping"),
+		IngestHealthCheckUserAgents: getEnv("INGEST_HEALTH_CHECK_USER_AGENTS", "kube-probe/,ELB-HealthChecker/,GoogleHC/"),
+		IngestRewriteRulesFile:      getEnv("INGEST_REWRITE_RULES_FILE", ""),
+		IngestSpanNameRulesFile:     getEnv("INGEST_SPAN_NAME_RULES_FILE", ""),
+		IngestRedactionRulesFile:    getEnv("INGEST_REDACTION_RULES_FILE", ""),
+		IngestRedactionHashKey:      getEnv("INGEST_REDACTION_HASH_KEY", ""),
+		IngestTransformFile:         getEnv("INGEST_TRANSFORM_FILE", ""),
+		IngestRecordFixturesDir:     getEnv("INGEST_RECORD_FIXTURES_DIR", ""),
+		IngestRecordFixturesMax:     getEnvInt("INGEST_RECORD_FIXTURES_MAX", 100),
+		IngestAuthFile:              getEnv("INGEST_AUTH_FILE", ""),
+		TraceIDAccept64Bit:          getEnvBool("TRACE_ID_ACCEPT_64BIT", true),
 
 		// DB Connection Pool
 		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 50),
@@ -265,10 +686,39 @@ func Load(customPath string) (*Config, error) {
 		DBPostgresPartitioning:   strings.ToLower(strings.TrimSpace(getEnv("DB_POSTGRES_PARTITIONING", ""))),
 		DBPartitionLookaheadDays: getEnvInt("DB_PARTITION_LOOKAHEAD_DAYS", 3),
 
+		// SQLite sharding (opt-in). Default empty = single database file.
+		DBSQLiteSharding: strings.ToLower(strings.TrimSpace(getEnv("DB_SQLITE_SHARDING", ""))),
+		DBSQLiteShardDir: getEnv("DB_SQLITE_SHARD_DIR", "shards"),
+
 		// Retention
 		HotRetentionDays:      getEnvInt("HOT_RETENTION_DAYS", 7),
 		RetentionBatchSize:    getEnvInt("RETENTION_BATCH_SIZE", 50000),
 		RetentionBatchSleepMs: getEnvInt("RETENTION_BATCH_SLEEP_MS", 1),
+		RetentionTraces:       getEnv("RETENTION_TRACES", ""),
+		RetentionLogs:         getEnv("RETENTION_LOGS", ""),
+		RetentionMetrics:      getEnv("RETENTION_METRICS", ""),
+		RetentionTenants:      getEnv("RETENTION_TENANTS", ""),
+
+		// Cold tier (opt-in)
+		ColdTierURL:             getEnv("COLD_TIER_URL", ""),
+		ColdTierAfter:           getEnv("COLD_TIER_AFTER", "3d"),
+		ColdTierInterval:        getEnv("COLD_TIER_INTERVAL", "1h"),
+		ColdTierS3Endpoint:      getEnv("COLD_TIER_S3_ENDPOINT", ""),
+		ColdTierS3Region:        getEnv("COLD_TIER_S3_REGION", getEnv("AWS_REGION", "")),
+		ColdTierS3PathStyle:     getEnvBool("COLD_TIER_S3_PATH_STYLE", false),
+		ColdTierAccessKeyID:     getEnv("COLD_TIER_ACCESS_KEY_ID", getEnv("AWS_ACCESS_KEY_ID", "")),
+		ColdTierSecretAccessKey: getEnv("COLD_TIER_SECRET_ACCESS_KEY", getEnv("AWS_SECRET_ACCESS_KEY", "")),
+		ColdTierSessionToken:    getEnv("COLD_TIER_SESSION_TOKEN", getEnv("AWS_SESSION_TOKEN", "")),
+
+		// Export sink (opt-in)
+		ExportURL:           getEnv("EXPORT_URL", ""),
+		ExportTracesTopic:   getEnv("EXPORT_TRACES_TOPIC", "argus.spans"),
+		ExportLogsTopic:     getEnv("EXPORT_LOGS_TOPIC", "argus.logs"),
+		ExportMetricsTopic:  getEnv("EXPORT_METRICS_TOPIC", ""),
+		ExportBatchSize:     getEnvInt("EXPORT_BATCH_SIZE", 500),
+		ExportFlushInterval: getEnv("EXPORT_FLUSH_INTERVAL", "1s"),
+		ExportQueueSize:     getEnvInt("EXPORT_QUEUE_SIZE", 10000),
+		ExportTLSCAFile:     getEnv("EXPORT_TLS_CA_FILE", ""),
 
 		// TSDB
 		TSDBRingBufferDuration: getEnv("TSDB_RING_BUFFER_DURATION", "1h"),
@@ -277,6 +727,36 @@ func Load(customPath string) (*Config, error) {
 		SamplingRate:               getEnvFloat("SAMPLING_RATE", 1.0), // default: keep all
 		SamplingAlwaysOnErrors:     getEnvBool("SAMPLING_ALWAYS_ON_ERRORS", true),
 		SamplingLatencyThresholdMs: getEnvInt("SAMPLING_LATENCY_THRESHOLD_MS", 500),
+		SamplingServiceRates:       getEnv("SAMPLING_SERVICE_RATES", ""),
+		IngestSpanRateLimits:       getEnv("INGEST_SPAN_RATE_LIMITS", ""),
+		IngestLogRateLimits:        getEnv("INGEST_LOG_RATE_LIMITS", ""),
+
+		FeatureFlags: getEnv("FEATURE_FLAGS", ""),
+
+		// Tail Sampling
+		TailSamplingEnabled:            getEnvBool("TAIL_SAMPLING_ENABLED", false),
+		TailSamplingDecisionWait:       getEnv("TAIL_SAMPLING_DECISION_WAIT", "10s"),
+		TailSamplingKeepErrors:         getEnvBool("TAIL_SAMPLING_KEEP_ERRORS", true),
+		TailSamplingLatencyThresholdMs: getEnvInt("TAIL_SAMPLING_LATENCY_THRESHOLD_MS", 1000),
+		TailSamplingServices:           getEnv("TAIL_SAMPLING_SERVICES", ""),
+		TailSamplingRate:               getEnvFloat("TAIL_SAMPLING_RATE", 0.1),
+		TailSamplingMaxTraces:          getEnvInt("TAIL_SAMPLING_MAX_TRACES", 50000),
+
+		// Span metrics
+		SpanMetricsEnabled:   getEnvBool("SPAN_METRICS_ENABLED", true),
+		SpanMetricsMaxSeries: getEnvInt("SPAN_METRICS_MAX_SERIES", 10000),
+
+		// Alerting
+		AlertingEnabled:   getEnvBool("ALERTING_ENABLED", true),
+		AlertEvalInterval: getEnv("ALERT_EVAL_INTERVAL", "1m"),
+		AlertSMTPAddr:     getEnv("ALERT_SMTP_ADDR", ""),
+		AlertSMTPFrom:     getEnv("ALERT_SMTP_FROM", ""),
+		AlertSMTPUsername: getEnv("ALERT_SMTP_USERNAME", ""),
+		AlertSMTPPassword: getEnv("ALERT_SMTP_PASSWORD", ""),
+
+		// Uptime monitors
+		UptimeEnabled:         getEnvBool("UPTIME_ENABLED", true),
+		UptimeResultRetention: getEnv("UPTIME_RESULT_RETENTION", "30d"),
 
 		// Cardinality
 		MetricAttributeKeys:           getEnv("METRIC_ATTRIBUTE_KEYS", ""),
@@ -284,10 +764,22 @@ func Load(customPath string) (*Config, error) {
 		MetricMaxCardinalityPerTenant: getEnvInt("METRIC_MAX_CARDINALITY_PER_TENANT", 0),
 
 		// DLQ
-		DLQMaxFiles:         getEnvInt("DLQ_MAX_FILES", 1000),
-		DLQMaxDiskMB:        getEnvInt("DLQ_MAX_DISK_MB", 500),
-		DLQMaxRetries:       getEnvInt("DLQ_MAX_RETRIES", 10),
-		DLQMaxReplayPerTick: getEnvInt("DLQ_MAX_REPLAY_PER_TICK", 100),
+		DLQMaxFiles:          getEnvInt("DLQ_MAX_FILES", 1000),
+		DLQMaxDiskMB:         getEnvInt("DLQ_MAX_DISK_MB", 500),
+		DLQMaxRetries:        getEnvInt("DLQ_MAX_RETRIES", 10),
+		DLQMaxReplayPerTick:  getEnvInt("DLQ_MAX_REPLAY_PER_TICK", 100),
+		DLQReplayConcurrency: getEnvInt("DLQ_REPLAY_CONCURRENCY", 4),
+		DLQCoalesceFiles:     getEnvInt("DLQ_COALESCE_FILES", 50),
+		DLQCoalesceMaxBytes:  getEnvInt("DLQ_COALESCE_MAX_BYTES", 256<<10),
+		DLQMaxBytes:          getEnvInt("DLQ_MAX_BYTES", 0),
+		DLQMaxAge:            getEnv("DLQ_MAX_AGE", ""),
+		DLQOverflowPolicy:    getEnv("DLQ_OVERFLOW_POLICY", "drop_oldest"),
+		DLQReadMergeWindow:   getEnv("DLQ_READ_MERGE_WINDOW", ""),
+
+		DLQAlertFiles:         getEnvInt("DLQ_ALERT_FILES", 100),
+		DLQAlertDiskPct:       getEnvInt("DLQ_ALERT_DISK_PCT", 80),
+		DLQAlertMaxAge:        getEnv("DLQ_ALERT_MAX_AGE", "15m"),
+		DLQAlertFailureStreak: getEnvInt("DLQ_ALERT_FAILURE_STREAK", 5),
 
 		// API
 		APIRateLimitRPS: getEnvInt("API_RATE_LIMIT_RPS", 100),
@@ -307,7 +799,7 @@ func Load(customPath string) (*Config, error) {
 		VectorIndexSnapshotPath:     getEnv("VECTOR_INDEX_SNAPSHOT_PATH", "data/vectordb.snapshot"),
 		VectorIndexSnapshotInterval: getEnv("VECTOR_INDEX_SNAPSHOT_INTERVAL", "5m"),
 
-		// Log search FTS5 toggle (SQLite only). Default off — see field comment.
+		// Log full-text search toggle (SQLite FTS5, Postgres tsvector). Default off — see field comment.
 		LogFTSEnabled: parseTruthy(getEnv("LOG_FTS_ENABLED", "")),
 
 		// GraphRAG
@@ -320,11 +812,45 @@ func Load(customPath string) (*Config, error) {
 		IngestPipelineWorkers:      getEnvInt("INGEST_PIPELINE_WORKERS", 8),
 		IngestPipelinePerTenantCap: getEnvInt("INGEST_PIPELINE_PER_TENANT_CAP", 0),
 
+		IngestPipelineBatchSize:     getEnvInt("INGEST_PIPELINE_BATCH_SIZE", 1000),
+		IngestPipelineFlushInterval: getEnv("INGEST_PIPELINE_FLUSH_INTERVAL", "50ms"),
+		IngestPipelineWriteRetries:  getEnvInt("INGEST_PIPELINE_WRITE_RETRIES", 3),
+
+		// Trace finalization
+		TraceFinalizeEnabled:     getEnvBool("TRACE_FINALIZE_ENABLED", true),
+		TraceFinalizeQuietPeriod: getEnv("TRACE_FINALIZE_QUIET_PERIOD", "30s"),
+		TraceBackfillOnStart:     parseTruthy(getEnv("TRACE_BACKFILL_ON_START", "")),
+
+		// Storage usage sampling
+		StorageUsageInterval: getEnv("STORAGE_USAGE_INTERVAL", "1h"),
+		StorageUsageHistory:  getEnv("STORAGE_USAGE_HISTORY", "30d"),
+
+		// Dashboard rollups
+		DashboardRollupsEnabled:  getEnvBool("DASHBOARD_ROLLUPS_ENABLED", true),
+		DashboardRollupBackfill:  getEnv("DASHBOARD_ROLLUP_BACKFILL", "24h"),
+		DashboardRollupRetention: getEnv("DASHBOARD_ROLLUP_RETENTION", "90d"),
+
+		// Issue tracking
+		IssuesEnabled: getEnvBool("ISSUES_ENABLED", true),
+		IssueBackfill: getEnv("ISSUE_BACKFILL", "7d"),
+
+		// Deployment tracking
+		DeploymentsFromServiceVersion: getEnvBool("DEPLOYMENTS_FROM_SERVICE_VERSION", true),
+		DeploymentImpactWindow:        getEnv("DEPLOYMENT_IMPACT_WINDOW", "30m"),
+
+		// Self-history
+		SelfHistoryEnabled:   getEnvBool("SELF_HISTORY_ENABLED", true),
+		SelfHistoryIngestGap: getEnv("SELF_HISTORY_INGEST_GAP", "5m"),
+		SelfHistoryRetention: getEnv("SELF_HISTORY_RETENTION", "90d"),
+
 		// TLS
 		TLSCertFile:       getEnv("TLS_CERT_FILE", ""),
 		TLSKeyFile:        getEnv("TLS_KEY_FILE", ""),
 		TLSAutoSelfsigned: parseTruthy(getEnv("TLS_AUTO_SELFSIGNED", "")),
 		TLSCacheDir:       getEnv("TLS_CACHE_DIR", "./data/tls"),
+		OTLPTLSCert:       getEnv("OTLP_TLS_CERT", ""),
+		OTLPTLSKey:        getEnv("OTLP_TLS_KEY", ""),
+		OTLPTLSClientCA:   getEnv("OTLP_TLS_CLIENT_CA", ""),
 
 		// Auth
 		APIKey: getEnv("API_KEY", ""),
@@ -333,12 +859,57 @@ func Load(customPath string) (*Config, error) {
 		OTelExporterEndpoint: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
 
 		// WebSocket admission cap
-		WSMaxClients: getEnvInt("WS_MAX_CLIENTS", 0),
+		WSMaxClients:     getEnvInt("WS_MAX_CLIENTS", 0),
+		WSPingInterval:   getEnv("WS_PING_INTERVAL", "30s"),
+		WSIdleTimeout:    getEnv("WS_IDLE_TIMEOUT", "60s"),
+		WSReadLimitBytes: getEnvInt("WS_READ_LIMIT_BYTES", 4096),
+		WSCompression:    getEnv("WS_COMPRESSION", "zstd"),
+
+		HubPreset:        getEnv("HUB_PRESET", ""),
+		HubBufferSize:    getEnvInt("HUB_BUFFER_SIZE", 0),
+		HubFlushInterval: getEnv("HUB_FLUSH_INTERVAL", ""),
 
 		// Multi-tenancy
 		DefaultTenant:           getEnv("DEFAULT_TENANT", "default"),
+		Region:                  getEnv("REGION", ""),
+		SpanAttributeIndexKeys:  getEnv("SPAN_ATTRIBUTE_INDEX_KEYS", ""),
 		OTLPTrustResourceTenant: parseTruthy(getEnv("OTLP_TRUST_RESOURCE_TENANT", "")),
+		OTLPHTTPCORSOrigins:     getEnv("OTLP_HTTP_CORS_ORIGINS", ""),
+		JaegerAgentAddr:         getEnv("JAEGER_AGENT_ADDR", ""),
+		JaegerCollectorHTTPAddr: getEnv("JAEGER_COLLECTOR_HTTP_ADDR", ""),
+		JaegerCollectorGRPCAddr: getEnv("JAEGER_COLLECTOR_GRPC_ADDR", ""),
+		ZipkinAddr:              getEnv("ZIPKIN_ADDR", ""),
+		LokiAddr:                getEnv("LOKI_ADDR", ""),
+		StatsDAddr:              getEnv("STATSD_ADDR", ""),
 		APITenantKeysFile:       getEnv("API_TENANT_KEYS_FILE", ""),
+		APIKeysEnabled:          getEnvBool("API_KEYS_ENABLED", false),
+
+		OIDCIssuerURL:      getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:         getEnv("OIDC_SCOPES", "openid profile email"),
+		OIDCSessionSecret:  getEnv("OIDC_SESSION_SECRET", ""),
+		OIDCSessionTTL:     getEnv("OIDC_SESSION_TTL", "12h"),
+		OIDCAllowedDomains: getEnv("OIDC_ALLOWED_DOMAINS", ""),
+		OIDCGroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		RBACAdmins:         getEnv("RBAC_ADMINS", ""),
+		RBACEditors:        getEnv("RBAC_EDITORS", ""),
+		RBACDefaultRole:    getEnv("RBAC_DEFAULT_ROLE", "viewer"),
+		AuthDevBypass:      getEnvBool("AUTH_DEV_BYPASS", false),
+
+		// Kinesis Firehose ingestion
+		FirehoseAccessKey:        getEnv("FIREHOSE_ACCESS_KEY", ""),
+		FirehoseTenant:           getEnv("FIREHOSE_TENANT", ""),
+		FirehoseLogGroupServices: getEnv("FIREHOSE_LOG_GROUP_SERVICES", ""),
+
+		// PaaS log drains
+		LogDrains: getEnv("LOG_DRAINS", ""),
+
+		// CI/CD deploy annotations
+		GitHubWebhookSecret:   getEnv("GITHUB_WEBHOOK_SECRET", ""),
+		GitHubWebhookTenant:   getEnv("GITHUB_WEBHOOK_TENANT", ""),
+		GitHubDeployWorkflows: getEnv("GITHUB_DEPLOY_WORKFLOWS", ""),
 
 		// gRPC server tuning
 		GRPCMaxRecvMB:            getEnvInt("GRPC_MAX_RECV_MB", 16),
@@ -346,7 +917,22 @@ func Load(customPath string) (*Config, error) {
 
 		// Production safety guard for SQLite
 		AllowSqliteProd: parseTruthy(getEnv("OTELCONTEXT_ALLOW_SQLITE_PROD", "")),
-	}, nil
+
+		ConfigFile:           getEnv("CONFIG_FILE", ""),
+		ConfigReloadInterval: getEnv("CONFIG_RELOAD_INTERVAL", "30s"),
+	}
+	fromEnv := *c
+	c.env = &fromEnv
+	if c.ConfigFile != "" {
+		f, err := ReadFile(c.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		f.Apply(c)
+		c.file = f
+		log.Printf("✅ Loaded configuration file %s", c.ConfigFile)
+	}
+	return c, nil
 }
 
 func getEnv(key, fallback string) string {
@@ -396,6 +982,94 @@ func getEnvBool(key string, fallback bool) bool {
 
 // Validate checks that all configuration values are within valid ranges.
 // Call this once after Load() during startup to catch misconfiguration early.
+// maxRetentionWindow mirrors the HOT_RETENTION_DAYS upper bound.
+const maxRetentionWindow = 36500 * 24 * time.Hour
+
+// ParseRetentionWindow parses a retention window. It accepts Go durations
+// ("72h", "90m") plus a whole-day suffix ("7d"), and rejects windows outside
+// 1m..36500d — a zero or overflowed window would purge everything.
+func ParseRetentionWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 || n > 36500 {
+			return 0, fmt.Errorf("invalid retention window %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid retention window %q", s)
+		}
+	}
+	if d < time.Minute || d > maxRetentionWindow {
+		return 0, fmt.Errorf("retention window %q must be between 1m and 36500d", s)
+	}
+	return d, nil
+}
+
+// ParseTenantRetention parses "tenant=window" pairs separated by commas,
+// each window in ParseRetentionWindow syntax. Empty input yields nil.
+func ParseTenantRetention(s string) (map[string]time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	out := make(map[string]time.Duration)
+	for _, pair := range strings.Split(s, ",") {
+		tenant, window, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		tenant = strings.TrimSpace(tenant)
+		if !ok || tenant == "" || len(tenant) > 64 {
+			return nil, fmt.Errorf("invalid tenant retention %q: want tenant=window", pair)
+		}
+		if _, dup := out[tenant]; dup {
+			return nil, fmt.Errorf("tenant %q listed twice", tenant)
+		}
+		d, err := ParseRetentionWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", tenant, err)
+		}
+		out[tenant] = d
+	}
+	return out, nil
+}
+
+// ParseServiceRates parses "service=rate" pairs separated by commas, each
+// rate between 0 and 1. Empty input yields nil.
+func ParseServiceRates(s string) (map[string]float64, error) {
+	return parseServiceValues(s, "rate", func(v float64) bool { return v >= 0 && v <= 1 }, "between 0 and 1")
+}
+
+// ParseServiceRateLimits parses "service=limit" pairs separated by commas,
+// each limit a positive number of records per second. The service "*"
+// stands for every unlisted service. Empty input yields nil.
+func ParseServiceRateLimits(s string) (map[string]float64, error) {
+	return parseServiceValues(s, "limit", func(v float64) bool { return v > 0 }, "positive")
+}
+
+func parseServiceValues(s, what string, ok func(float64) bool, want string) (map[string]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	out := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		service, raw, found := strings.Cut(strings.TrimSpace(pair), "=")
+		service = strings.TrimSpace(service)
+		if !found || service == "" {
+			return nil, fmt.Errorf("invalid service %s %q: want service=%s", what, pair, what)
+		}
+		if _, dup := out[service]; dup {
+			return nil, fmt.Errorf("service %q listed twice", service)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || !ok(v) {
+			return nil, fmt.Errorf("service %q: %s %q must be %s", service, what, raw, want)
+		}
+		out[service] = v
+	}
+	return out, nil
+}
+
 func (c *Config) Validate() error {
 	// Port validation
 	httpPort, err := strconv.Atoi(c.HTTPPort)
@@ -407,6 +1081,16 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid GRPC_PORT %q: must be 1-65535", c.GRPCPort)
 	}
 
+	if len(c.Region) > 64 || strings.ContainsAny(c.Region, " \t\r\n") {
+		return fmt.Errorf("invalid REGION %q: must be at most 64 characters without whitespace", c.Region)
+	}
+
+	for _, key := range strings.Split(c.SpanAttributeIndexKeys, ",") {
+		if len(strings.TrimSpace(key)) > 128 {
+			return fmt.Errorf("invalid SPAN_ATTRIBUTE_INDEX_KEYS: key %q longer than 128 characters", key)
+		}
+	}
+
 	// DB driver
 	validDrivers := map[string]bool{
 		"sqlite": true, "postgres": true, "postgresql": true,
@@ -431,6 +1115,20 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("DB_POSTGRES_PARTITIONING=daily requires DB_DRIVER=postgres, got %q", c.DBDriver)
 		}
 	}
+	switch c.DBSQLiteSharding {
+	case "", "none", "daily":
+		// ok
+	default:
+		return fmt.Errorf("invalid DB_SQLITE_SHARDING %q: must be one of \"\", \"none\", \"daily\"", c.DBSQLiteSharding)
+	}
+	if c.DBSQLiteSharding == "daily" {
+		if drv := strings.ToLower(c.DBDriver); drv != "sqlite" && drv != "" {
+			return fmt.Errorf("DB_SQLITE_SHARDING=daily requires DB_DRIVER=sqlite, got %q", c.DBDriver)
+		}
+		if strings.TrimSpace(c.DBSQLiteShardDir) == "" {
+			return fmt.Errorf("DB_SQLITE_SHARD_DIR is required when DB_SQLITE_SHARDING=daily")
+		}
+	}
 	// 0 == "use default at the storage layer" so direct struct construction
 	// (tests, embedded callers) doesn't have to set it.
 	if c.DBPartitionLookaheadDays < 0 || c.DBPartitionLookaheadDays > 365 {
@@ -463,6 +1161,113 @@ func (c *Config) Validate() error {
 	if c.RetentionBatchSleepMs < 0 || c.RetentionBatchSleepMs > 60_000 {
 		return fmt.Errorf("RETENTION_BATCH_SLEEP_MS must be between 0 and 60000, got %d", c.RetentionBatchSleepMs)
 	}
+	for _, rw := range []struct{ key, val string }{
+		{"RETENTION_TRACES", c.RetentionTraces},
+		{"RETENTION_LOGS", c.RetentionLogs},
+		{"RETENTION_METRICS", c.RetentionMetrics},
+	} {
+		if rw.val == "" {
+			continue
+		}
+		if _, err := ParseRetentionWindow(rw.val); err != nil {
+			return fmt.Errorf("%s: %w", rw.key, err)
+		}
+	}
+	if _, err := ParseTenantRetention(c.RetentionTenants); err != nil {
+		return fmt.Errorf("RETENTION_TENANTS: %w", err)
+	}
+	if err := c.validateSQLiteSharding(); err != nil {
+		return err
+	}
+	if err := c.validateColdTier(); err != nil {
+		return err
+	}
+	if err := c.validateExport(); err != nil {
+		return err
+	}
+	if c.DLQMaxBytes < 0 {
+		return fmt.Errorf("DLQ_MAX_BYTES must be >= 0, got %d", c.DLQMaxBytes)
+	}
+	if c.DLQReplayConcurrency < 0 || c.DLQReplayConcurrency > 64 {
+		return fmt.Errorf("DLQ_REPLAY_CONCURRENCY must be between 0 and 64, got %d", c.DLQReplayConcurrency)
+	}
+	if c.DLQCoalesceFiles < 0 {
+		return fmt.Errorf("DLQ_COALESCE_FILES must be >= 0, got %d", c.DLQCoalesceFiles)
+	}
+	if c.DLQCoalesceMaxBytes < 0 {
+		return fmt.Errorf("DLQ_COALESCE_MAX_BYTES must be >= 0, got %d", c.DLQCoalesceMaxBytes)
+	}
+	if c.DLQMaxAge != "" {
+		if _, err := ParseRetentionWindow(c.DLQMaxAge); err != nil {
+			return fmt.Errorf("DLQ_MAX_AGE: %w", err)
+		}
+	}
+	if c.DLQReadMergeWindow != "" {
+		if d, err := time.ParseDuration(c.DLQReadMergeWindow); err != nil || d < 0 {
+			return fmt.Errorf("DLQ_READ_MERGE_WINDOW must be a non-negative duration, got %q", c.DLQReadMergeWindow)
+		}
+	}
+	if c.StorageUsageInterval != "" && c.StorageUsageInterval != "0" {
+		if _, err := ParseRetentionWindow(c.StorageUsageInterval); err != nil {
+			return fmt.Errorf("STORAGE_USAGE_INTERVAL: %w", err)
+		}
+	}
+	if c.StorageUsageHistory != "" {
+		if _, err := ParseRetentionWindow(c.StorageUsageHistory); err != nil {
+			return fmt.Errorf("STORAGE_USAGE_HISTORY: %w", err)
+		}
+	}
+	if c.DashboardRollupBackfill != "" {
+		if _, err := ParseRetentionWindow(c.DashboardRollupBackfill); err != nil {
+			return fmt.Errorf("DASHBOARD_ROLLUP_BACKFILL: %w", err)
+		}
+	}
+	if c.DashboardRollupRetention != "" {
+		if _, err := ParseRetentionWindow(c.DashboardRollupRetention); err != nil {
+			return fmt.Errorf("DASHBOARD_ROLLUP_RETENTION: %w", err)
+		}
+	}
+	if c.IssueBackfill != "" {
+		if _, err := ParseRetentionWindow(c.IssueBackfill); err != nil {
+			return fmt.Errorf("ISSUE_BACKFILL: %w", err)
+		}
+	}
+	if c.DeploymentImpactWindow != "" {
+		if d, err := time.ParseDuration(c.DeploymentImpactWindow); err != nil || d < time.Minute || d > 24*time.Hour {
+			return fmt.Errorf("DEPLOYMENT_IMPACT_WINDOW must be a duration between 1m and 24h, got %q", c.DeploymentImpactWindow)
+		}
+	}
+	if c.SelfHistoryIngestGap != "" {
+		if d, err := time.ParseDuration(c.SelfHistoryIngestGap); err != nil || d < 0 {
+			return fmt.Errorf("SELF_HISTORY_INGEST_GAP must be a non-negative duration, got %q", c.SelfHistoryIngestGap)
+		}
+	}
+	if c.SelfHistoryRetention != "" {
+		if _, err := ParseRetentionWindow(c.SelfHistoryRetention); err != nil {
+			return fmt.Errorf("SELF_HISTORY_RETENTION: %w", err)
+		}
+	}
+	if c.UptimeResultRetention != "" {
+		if _, err := ParseRetentionWindow(c.UptimeResultRetention); err != nil {
+			return fmt.Errorf("UPTIME_RESULT_RETENTION: %w", err)
+		}
+	}
+	switch c.DLQOverflowPolicy {
+	case "", "drop_oldest", "reject_new":
+	default:
+		return fmt.Errorf("DLQ_OVERFLOW_POLICY must be drop_oldest or reject_new, got %q", c.DLQOverflowPolicy)
+	}
+	if c.IngestPipelineBatchSize < 0 || c.IngestPipelineBatchSize > 100000 {
+		return fmt.Errorf("INGEST_PIPELINE_BATCH_SIZE must be in [0, 100000], got %d", c.IngestPipelineBatchSize)
+	}
+	if c.IngestPipelineFlushInterval != "" {
+		if d, err := time.ParseDuration(c.IngestPipelineFlushInterval); err != nil || d < 0 || d > 10*time.Second {
+			return fmt.Errorf("INGEST_PIPELINE_FLUSH_INTERVAL must be a duration in [0, 10s], got %q", c.IngestPipelineFlushInterval)
+		}
+	}
+	if c.IngestPipelineWriteRetries < 0 || c.IngestPipelineWriteRetries > 10 {
+		return fmt.Errorf("INGEST_PIPELINE_WRITE_RETRIES must be in [0, 10], got %d", c.IngestPipelineWriteRetries)
+	}
 	if c.MetricMaxCardinality < 0 {
 		return fmt.Errorf("METRIC_MAX_CARDINALITY must be >= 0, got %d", c.MetricMaxCardinality)
 	}
@@ -472,6 +1277,72 @@ func (c *Config) Validate() error {
 	if c.SamplingRate < 0 || c.SamplingRate > 1.0 {
 		return fmt.Errorf("SAMPLING_RATE must be between 0 and 1, got %f", c.SamplingRate)
 	}
+	if c.ConfigReloadInterval != "" && c.ConfigReloadInterval != "0" {
+		if d, err := time.ParseDuration(c.ConfigReloadInterval); err != nil || d < 100*time.Millisecond {
+			return fmt.Errorf("CONFIG_RELOAD_INTERVAL must be a duration of at least 100ms or 0, got %q", c.ConfigReloadInterval)
+		}
+	}
+	if err := validateAlertRules(c.AlertRules); err != nil {
+		return fmt.Errorf("config file alerts: %w", err)
+	}
+	if _, err := ParseServiceRates(c.SamplingServiceRates); err != nil {
+		return fmt.Errorf("SAMPLING_SERVICE_RATES: %w", err)
+	}
+	if _, err := ParseServiceRateLimits(c.IngestSpanRateLimits); err != nil {
+		return fmt.Errorf("INGEST_SPAN_RATE_LIMITS: %w", err)
+	}
+	if _, err := ParseServiceRateLimits(c.IngestLogRateLimits); err != nil {
+		return fmt.Errorf("INGEST_LOG_RATE_LIMITS: %w", err)
+	}
+	flagOverrides, err := featureflag.ParseSpec(c.FeatureFlags)
+	if err != nil {
+		return fmt.Errorf("FEATURE_FLAGS: %w", err)
+	}
+	tailSampling := c.TailSamplingEnabled
+	if on, ok := flagOverrides[featureflag.TailSampling]; ok {
+		tailSampling = on
+	}
+	if tailSampling {
+		if c.TailSamplingRate < 0 || c.TailSamplingRate > 1.0 {
+			return fmt.Errorf("TAIL_SAMPLING_RATE must be between 0 and 1, got %f", c.TailSamplingRate)
+		}
+		if d, err := time.ParseDuration(c.TailSamplingDecisionWait); err != nil || d <= 0 {
+			return fmt.Errorf("TAIL_SAMPLING_DECISION_WAIT must be a positive duration, got %q", c.TailSamplingDecisionWait)
+		}
+		if c.TailSamplingMaxTraces < 1 {
+			return fmt.Errorf("TAIL_SAMPLING_MAX_TRACES must be >= 1, got %d", c.TailSamplingMaxTraces)
+		}
+		if c.TailSamplingLatencyThresholdMs < 0 {
+			return fmt.Errorf("TAIL_SAMPLING_LATENCY_THRESHOLD_MS must be >= 0, got %d", c.TailSamplingLatencyThresholdMs)
+		}
+	}
+	if c.SpanMetricsEnabled && (c.SpanMetricsMaxSeries < 1 || c.SpanMetricsMaxSeries > 1_000_000) {
+		return fmt.Errorf("SPAN_METRICS_MAX_SERIES must be between 1 and 1000000, got %d", c.SpanMetricsMaxSeries)
+	}
+	if c.AlertingEnabled {
+		if d, err := time.ParseDuration(c.AlertEvalInterval); err != nil || d < 10*time.Second {
+			return fmt.Errorf("ALERT_EVAL_INTERVAL must be a duration of at least 10s, got %q", c.AlertEvalInterval)
+		}
+	}
+	if d, err := time.ParseDuration(c.WSPingInterval); err != nil || d < 0 {
+		return fmt.Errorf("WS_PING_INTERVAL must be a non-negative duration, got %q", c.WSPingInterval)
+	}
+	if d, err := time.ParseDuration(c.WSIdleTimeout); err != nil || d <= 0 {
+		return fmt.Errorf("WS_IDLE_TIMEOUT must be a positive duration, got %q", c.WSIdleTimeout)
+	}
+	if c.WSReadLimitBytes < 1 {
+		return fmt.Errorf("WS_READ_LIMIT_BYTES must be >= 1, got %d", c.WSReadLimitBytes)
+	}
+	for _, mode := range strings.Split(c.WSCompression, ",") {
+		switch strings.ToLower(strings.TrimSpace(mode)) {
+		case "zstd", "deflate", "none", "":
+		default:
+			return fmt.Errorf("invalid WS_COMPRESSION %q: must be zstd, deflate, both comma-separated, or none", c.WSCompression)
+		}
+	}
+	if _, err := c.HubTuning(); err != nil {
+		return err
+	}
 	if c.APIRateLimitRPS < 0 {
 		return fmt.Errorf("API_RATE_LIMIT_RPS must be >= 0, got %d", c.APIRateLimitRPS)
 	}
@@ -508,6 +1379,10 @@ func (c *Config) Validate() error {
 		log.Printf("⚠️  API_TENANT_KEYS_FILE is empty but DEFAULT_TENANT=%q — shared API_KEY permits any holder to read any tenant's data. Set API_TENANT_KEYS_FILE to enforce per-tenant auth.", c.DefaultTenant)
 	}
 
+	if c.IngestRecordFixturesDir != "" && c.IngestRecordFixturesMax < 1 {
+		return fmt.Errorf("INGEST_RECORD_FIXTURES_MAX must be >= 1, got %d", c.IngestRecordFixturesMax)
+	}
+
 	// TLS: both paths must be set together, and both files must exist & be readable.
 	certSet := c.TLSCertFile != ""
 	keySet := c.TLSKeyFile != ""
@@ -527,9 +1402,123 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if err := c.validateOIDC(); err != nil {
+		return err
+	}
+
+	if (c.OTLPTLSCert != "") != (c.OTLPTLSKey != "") {
+		return fmt.Errorf("OTLP_TLS_CERT and OTLP_TLS_KEY must both be set or both empty")
+	}
+	for _, f := range []struct{ name, path string }{
+		{"OTLP_TLS_CERT", c.OTLPTLSCert},
+		{"OTLP_TLS_KEY", c.OTLPTLSKey},
+		{"OTLP_TLS_CLIENT_CA", c.OTLPTLSClientCA},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if err := checkReadable(f.path); err != nil {
+			return fmt.Errorf("%s %q: %w", f.name, f.path, err)
+		}
+	}
+	if c.OTLPTLSClientCA != "" && c.OTLPTLSCert == "" && !c.TLSEnabled() {
+		return fmt.Errorf("OTLP_TLS_CLIENT_CA needs TLS: set OTLP_TLS_CERT/OTLP_TLS_KEY, TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTO_SELFSIGNED")
+	}
+
+	return nil
+}
+
+// Fingerprint returns a short hash of every setting, keyed by field name,
+// so two runs' configurations can be compared without storing their values.
+// Hashes are truncated to 32 bits: enough to spot a change, too short to
+// confirm a guessed secret.
+func (c *Config) Fingerprint() map[string]string {
+	v := reflect.ValueOf(*c)
+	out := make(map[string]string, v.NumField())
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		sum := sha256.Sum256([]byte(fmt.Sprint(v.Field(i).Interface())))
+		out[f.Name] = hex.EncodeToString(sum[:4])
+	}
+	return out
+}
+
+// DLQByteLimit returns the DLQ size cap in bytes: DLQMaxBytes when set,
+// else DLQMaxDiskMB. 0 means unlimited.
+func (c *Config) DLQByteLimit() int64 {
+	if c.DLQMaxBytes > 0 {
+		return int64(c.DLQMaxBytes)
+	}
+	return int64(c.DLQMaxDiskMB) * 1024 * 1024
+}
+
+// HubFlushTuning is how the WebSocket hub batches broadcasts.
+type HubFlushTuning struct {
+	BufferSize    int           `json:"buffer_size"`
+	FlushInterval time.Duration `json:"flush_interval"`
+}
+
+// HubPresets are the named tunings HUB_PRESET and /api/admin/hub accept.
+// low-latency suits a handful of viewers on a quiet deployment;
+// high-throughput keeps browsers responsive at tens of thousands of
+// entries per second.
+var HubPresets = map[string]HubFlushTuning{
+	"default":         {BufferSize: 100, FlushInterval: 500 * time.Millisecond},
+	"low-latency":     {BufferSize: 20, FlushInterval: 100 * time.Millisecond},
+	"high-throughput": {BufferSize: 1000, FlushInterval: 2 * time.Second},
+}
+
+// Validate checks the tuning is within 1..10000 entries and 10ms..10s.
+func (t HubFlushTuning) Validate() error {
+	if t.BufferSize < 1 || t.BufferSize > 10000 {
+		return fmt.Errorf("hub buffer size must be between 1 and 10000, got %d", t.BufferSize)
+	}
+	if t.FlushInterval < 10*time.Millisecond || t.FlushInterval > 10*time.Second {
+		return fmt.Errorf("hub flush interval must be between 10ms and 10s, got %s", t.FlushInterval)
+	}
 	return nil
 }
 
+// HubTuning resolves HUB_PRESET, HUB_BUFFER_SIZE and HUB_FLUSH_INTERVAL.
+func (c *Config) HubTuning() (HubFlushTuning, error) {
+	name := c.HubPreset
+	if name == "" {
+		name = "default"
+	}
+	t, ok := HubPresets[name]
+	if !ok {
+		return t, fmt.Errorf("HUB_PRESET must be default, low-latency or high-throughput, got %q", c.HubPreset)
+	}
+	if c.HubBufferSize != 0 {
+		t.BufferSize = c.HubBufferSize
+	}
+	if c.HubFlushInterval != "" {
+		d, err := time.ParseDuration(c.HubFlushInterval)
+		if err != nil {
+			return t, fmt.Errorf("HUB_FLUSH_INTERVAL must be a duration, got %q", c.HubFlushInterval)
+		}
+		t.FlushInterval = d
+	}
+	if err := t.Validate(); err != nil {
+		return t, fmt.Errorf("HUB_BUFFER_SIZE/HUB_FLUSH_INTERVAL: %w", err)
+	}
+	return t, nil
+}
+
+// WSCompressionEnabled reports whether mode ("zstd" or "deflate") is listed
+// in WSCompression.
+func (c *Config) WSCompressionEnabled(mode string) bool {
+	for _, m := range strings.Split(c.WSCompression, ",") {
+		if strings.EqualFold(strings.TrimSpace(m), mode) {
+			return true
+		}
+	}
+	return false
+}
+
 // TLSEnabled reports whether HTTPS + gRPC-TLS should be served using any
 // mode (explicit files or auto self-signed).
 func (c *Config) TLSEnabled() bool {
@@ -551,6 +1540,214 @@ func (c *Config) TLSSelfsignedMode() bool {
 	return c.TLSAutoSelfsigned
 }
 
+// OIDCEnabled reports whether OIDC sign-in is configured.
+func (c *Config) OIDCEnabled() bool {
+	return c.OIDCIssuerURL != ""
+}
+
+// OIDCScopeList returns OIDC_SCOPES split on spaces and commas.
+func (c *Config) OIDCScopeList() []string {
+	return splitFieldList(c.OIDCScopes)
+}
+
+// OIDCAllowedDomainList returns OIDC_ALLOWED_DOMAINS as a list.
+func (c *Config) OIDCAllowedDomainList() []string {
+	return splitFieldList(c.OIDCAllowedDomains)
+}
+
+// RBACAdminList returns RBAC_ADMINS as a list.
+func (c *Config) RBACAdminList() []string {
+	return splitFieldList(c.RBACAdmins)
+}
+
+// RBACEditorList returns RBAC_EDITORS as a list.
+func (c *Config) RBACEditorList() []string {
+	return splitFieldList(c.RBACEditors)
+}
+
+func splitFieldList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// OIDCSessionDuration returns OIDC_SESSION_TTL, validated in Validate.
+func (c *Config) OIDCSessionDuration() time.Duration {
+	d, _ := time.ParseDuration(c.OIDCSessionTTL)
+	return d
+}
+
+func (c *Config) validateOIDC() error {
+	if c.AuthDevBypass && strings.EqualFold(c.Env, "production") {
+		return fmt.Errorf("AUTH_DEV_BYPASS must not be set with APP_ENV=production")
+	}
+	if !c.OIDCEnabled() {
+		return nil
+	}
+	for _, u := range []struct{ name, value string }{
+		{"OIDC_ISSUER_URL", c.OIDCIssuerURL},
+		{"OIDC_REDIRECT_URL", c.OIDCRedirectURL},
+	} {
+		parsed, err := url.Parse(u.value)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("%s must be an absolute http(s) URL, got %q", u.name, u.value)
+		}
+	}
+	if c.OIDCClientID == "" {
+		return fmt.Errorf("OIDC_CLIENT_ID is required with OIDC_ISSUER_URL")
+	}
+	if len(c.OIDCSessionSecret) < 32 {
+		return fmt.Errorf("OIDC_SESSION_SECRET must be at least 32 bytes with OIDC_ISSUER_URL")
+	}
+	if d, err := time.ParseDuration(c.OIDCSessionTTL); err != nil || d < time.Minute {
+		return fmt.Errorf("OIDC_SESSION_TTL must be a duration of at least 1m, got %q", c.OIDCSessionTTL)
+	}
+	switch strings.ToLower(c.RBACDefaultRole) {
+	case "viewer", "editor", "admin", "none":
+	default:
+		return fmt.Errorf("RBAC_DEFAULT_ROLE must be viewer, editor, admin or none, got %q", c.RBACDefaultRole)
+	}
+	return nil
+}
+
+// ColdTierWindows returns COLD_TIER_AFTER and COLD_TIER_INTERVAL,
+// validated in Validate.
+func (c *Config) ColdTierWindows() (after, interval time.Duration) {
+	after, _ = ParseRetentionWindow(c.ColdTierAfter)
+	interval, _ = time.ParseDuration(c.ColdTierInterval)
+	return after, interval
+}
+
+// sqliteShardMaxRetentionDays is how many closed days of SQLite log shards
+// stay queryable: storage attaches at most 8 shard files behind the logs
+// view (maxAttachedLogShards, under SQLite's limit of 10 attachments).
+const sqliteShardMaxRetentionDays = 8
+
+// validateSQLiteSharding refuses retention settings the daily shards cannot
+// honour. A longer logs window would keep shards on disk that no query can
+// read, and RetentionScheduler only purges main.logs per tenant, so
+// RETENTION_TENANTS windows would never reach the shards.
+func (c *Config) validateSQLiteSharding() error {
+	if c.DBSQLiteSharding != "daily" {
+		return nil
+	}
+	if strings.TrimSpace(c.RetentionTenants) != "" {
+		return fmt.Errorf("RETENTION_TENANTS is not supported with DB_SQLITE_SHARDING=daily: shard files are dropped by day for every tenant")
+	}
+	window := time.Duration(c.HotRetentionDays) * 24 * time.Hour
+	key := "HOT_RETENTION_DAYS"
+	if c.RetentionLogs != "" {
+		window, _ = ParseRetentionWindow(c.RetentionLogs)
+		key = "RETENTION_LOGS"
+	}
+	if window > sqliteShardMaxRetentionDays*24*time.Hour {
+		return fmt.Errorf("%s (%s) must be at most %dd with DB_SQLITE_SHARDING=daily: only the newest %d shards are queryable",
+			key, window, sqliteShardMaxRetentionDays, sqliteShardMaxRetentionDays)
+	}
+	return nil
+}
+
+func (c *Config) validateColdTier() error {
+	if c.ColdTierURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.ColdTierURL)
+	if err != nil {
+		return fmt.Errorf("invalid COLD_TIER_URL %q: %w", c.ColdTierURL, err)
+	}
+	switch u.Scheme {
+	case "file":
+	case "s3", "gs":
+		if u.Host == "" {
+			return fmt.Errorf("COLD_TIER_URL %q needs a bucket", c.ColdTierURL)
+		}
+		if c.ColdTierAccessKeyID == "" || c.ColdTierSecretAccessKey == "" {
+			return fmt.Errorf("COLD_TIER_ACCESS_KEY_ID and COLD_TIER_SECRET_ACCESS_KEY are required with an %s:// COLD_TIER_URL", u.Scheme)
+		}
+	default:
+		return fmt.Errorf("COLD_TIER_URL must start with file://, s3:// or gs://, got %q", c.ColdTierURL)
+	}
+	if c.ColdTierS3Endpoint != "" {
+		e, err := url.Parse(c.ColdTierS3Endpoint)
+		if err != nil || (e.Scheme != "https" && e.Scheme != "http") || e.Host == "" {
+			return fmt.Errorf("COLD_TIER_S3_ENDPOINT must be an absolute http(s) URL, got %q", c.ColdTierS3Endpoint)
+		}
+	}
+	after, err := ParseRetentionWindow(c.ColdTierAfter)
+	if err != nil {
+		return fmt.Errorf("COLD_TIER_AFTER: %w", err)
+	}
+	// Rows must reach the cold tier before retention deletes them.
+	for _, rw := range []struct{ key, val string }{
+		{"RETENTION_TRACES", c.RetentionTraces},
+		{"RETENTION_LOGS", c.RetentionLogs},
+	} {
+		window := time.Duration(c.HotRetentionDays) * 24 * time.Hour
+		if rw.val != "" {
+			window, _ = ParseRetentionWindow(rw.val)
+		}
+		if after >= window {
+			return fmt.Errorf("COLD_TIER_AFTER (%s) must be shorter than the %s window (%s)", c.ColdTierAfter, rw.key, window)
+		}
+	}
+	if d, err := time.ParseDuration(c.ColdTierInterval); err != nil || d < time.Minute {
+		return fmt.Errorf("COLD_TIER_INTERVAL must be a duration of at least 1m, got %q", c.ColdTierInterval)
+	}
+	return nil
+}
+
+// ExportFlushEvery returns EXPORT_FLUSH_INTERVAL, validated in Validate.
+func (c *Config) ExportFlushEvery() time.Duration {
+	d, _ := time.ParseDuration(c.ExportFlushInterval)
+	return d
+}
+
+func (c *Config) validateExport() error {
+	if c.ExportURL == "" {
+		return nil
+	}
+	// The URL may hold credentials, so errors name the scheme only.
+	scheme, hosts, ok := strings.Cut(c.ExportURL, "://")
+	switch scheme {
+	case "kafka", "kafka+tls", "nats", "nats+tls":
+	default:
+		return fmt.Errorf("EXPORT_URL must start with kafka://, kafka+tls://, nats:// or nats+tls://, got scheme %q", scheme)
+	}
+	if hosts, _, _ = strings.Cut(hosts, "/"); !ok || hosts[strings.LastIndex(hosts, "@")+1:] == "" {
+		return fmt.Errorf("EXPORT_URL needs a host")
+	}
+	topics := 0
+	for _, t := range []struct{ key, val string }{
+		{"EXPORT_TRACES_TOPIC", c.ExportTracesTopic},
+		{"EXPORT_LOGS_TOPIC", c.ExportLogsTopic},
+		{"EXPORT_METRICS_TOPIC", c.ExportMetricsTopic},
+	} {
+		if t.val == "" {
+			continue
+		}
+		if strings.ContainsAny(t.val, " \t\r\n") {
+			return fmt.Errorf("%s must not contain whitespace, got %q", t.key, t.val)
+		}
+		topics++
+	}
+	if topics == 0 {
+		return fmt.Errorf("EXPORT_URL is set but EXPORT_TRACES_TOPIC, EXPORT_LOGS_TOPIC and EXPORT_METRICS_TOPIC are all empty")
+	}
+	if c.ExportBatchSize < 1 || c.ExportBatchSize > 10000 {
+		return fmt.Errorf("EXPORT_BATCH_SIZE must be between 1 and 10000, got %d", c.ExportBatchSize)
+	}
+	if d, err := time.ParseDuration(c.ExportFlushInterval); err != nil || d < 10*time.Millisecond {
+		return fmt.Errorf("EXPORT_FLUSH_INTERVAL must be a duration of at least 10ms, got %q", c.ExportFlushInterval)
+	}
+	if c.ExportQueueSize < 1 {
+		return fmt.Errorf("EXPORT_QUEUE_SIZE must be >= 1, got %d", c.ExportQueueSize)
+	}
+	if c.ExportTLSCAFile != "" {
+		if err := checkReadable(c.ExportTLSCAFile); err != nil {
+			return fmt.Errorf("EXPORT_TLS_CA_FILE: %w", err)
+		}
+	}
+	return nil
+}
+
 // checkReadable verifies the file exists and can be opened for reading.
 func checkReadable(path string) error {
 	f, err := os.Open(path) // #nosec G304 -- operator-supplied TLS material path
@@ -25,6 +25,9 @@ func baseValid() *Config {
 		GRPCMaxConcurrentStreams: 1000,
 		RetentionBatchSize:    50000,
 		RetentionBatchSleepMs: 1,
+		WSPingInterval:        "30s",
+		WSIdleTimeout:         "60s",
+		WSReadLimitBytes:      4096,
 	}
 }
 
@@ -76,6 +79,339 @@ func TestValidate_HotRetentionDays_UpperBound_OverflowGuard(t *testing.T) {
 	}
 }
 
+func TestParseRetentionWindow(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "72h", want: 72 * time.Hour},
+		{in: "7d", want: 7 * 24 * time.Hour},
+		{in: " 30d ", want: 30 * 24 * time.Hour},
+		{in: "36500d", want: 36500 * 24 * time.Hour},
+		{in: "0d", wantErr: true},
+		{in: "30s", wantErr: true},
+		{in: "36501d", wantErr: true},
+		{in: "-1d", wantErr: true},
+		{in: "1.5d", wantErr: true},
+		{in: "week", wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := ParseRetentionWindow(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want error, got %v", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%q = %v, %v; want %v", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseTenantRetention(t *testing.T) {
+	got, err := ParseTenantRetention(" team-a=3d, team-b=72h ")
+	if err != nil || len(got) != 2 || got["team-a"] != 3*24*time.Hour || got["team-b"] != 72*time.Hour {
+		t.Fatalf("ParseTenantRetention = %v, %v", got, err)
+	}
+	if got, err := ParseTenantRetention(""); got != nil || err != nil {
+		t.Errorf("empty = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"team-a", "=3d", "team-a=0d", "team-a=3d,team-a=4d"} {
+		if _, err := ParseTenantRetention(bad); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestValidate_PerSignalRetention(t *testing.T) {
+	c := baseValid()
+	c.RetentionTraces, c.RetentionLogs, c.RetentionMetrics = "72h", "7d", ""
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid per-signal retention rejected: %v", err)
+	}
+	c.RetentionLogs = "forever"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "RETENTION_LOGS") {
+		t.Fatalf("expected RETENTION_LOGS error, got %v", err)
+	}
+}
+
+func TestValidate_Region(t *testing.T) {
+	c := baseValid()
+	c.Region = "eu-west-1"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid REGION rejected: %v", err)
+	}
+	c.Region = "eu west"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "REGION") {
+		t.Fatalf("expected REGION error, got %v", err)
+	}
+}
+
+func TestValidate_SQLiteSharding(t *testing.T) {
+	c := baseValid()
+	c.DBDriver = "sqlite"
+	c.DBSQLiteSharding = "daily"
+	c.DBSQLiteShardDir = "shards"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid sharding config rejected: %v", err)
+	}
+	c.DBDriver = "postgres"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "DB_SQLITE_SHARDING") {
+		t.Fatalf("expected driver mismatch error, got %v", err)
+	}
+	c.DBDriver = "sqlite"
+	c.DBSQLiteSharding = "hourly"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "DB_SQLITE_SHARDING") {
+		t.Fatalf("expected invalid mode error, got %v", err)
+	}
+
+	// Retention must fit in the queryable shards and apply to every tenant.
+	c.DBSQLiteSharding = "daily"
+	c.RetentionLogs = "8d"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("8d logs window rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"RETENTION_LOGS":     func(c *Config) { c.RetentionLogs = "9d" },
+		"HOT_RETENTION_DAYS": func(c *Config) { c.RetentionLogs, c.HotRetentionDays = "", 30 },
+		"RETENTION_TENANTS":  func(c *Config) { c.RetentionTenants = "team-a=3d" },
+	} {
+		c := *c
+		mutate(&c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected sharding retention error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_SpanAttributeIndexKeys(t *testing.T) {
+	c := baseValid()
+	c.SpanAttributeIndexKeys = "http.status_code, customer.id"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid keys rejected: %v", err)
+	}
+	c.SpanAttributeIndexKeys = "ok," + strings.Repeat("k", 129)
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "SPAN_ATTRIBUTE_INDEX_KEYS") {
+		t.Fatalf("expected key length error, got %v", err)
+	}
+}
+
+func TestValidate_WebSocketKeepalive(t *testing.T) {
+	c := baseValid()
+	c.WSPingInterval = "0"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("disabled pings rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"WS_PING_INTERVAL":    func(c *Config) { c.WSPingInterval = "soon" },
+		"WS_IDLE_TIMEOUT":     func(c *Config) { c.WSIdleTimeout = "0s" },
+		"WS_READ_LIMIT_BYTES": func(c *Config) { c.WSReadLimitBytes = 0 },
+		"WS_COMPRESSION":      func(c *Config) { c.WSCompression = "zstd,gzip" },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestConfig_WSCompressionEnabled(t *testing.T) {
+	c := baseValid()
+	c.WSCompression = "Zstd, deflate"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid WS_COMPRESSION rejected: %v", err)
+	}
+	if !c.WSCompressionEnabled("zstd") || !c.WSCompressionEnabled("deflate") {
+		t.Error("listed modes not enabled")
+	}
+	c.WSCompression = "none"
+	if c.WSCompressionEnabled("zstd") || c.WSCompressionEnabled("deflate") {
+		t.Error("none enabled a mode")
+	}
+}
+
+func TestConfig_DLQLimits(t *testing.T) {
+	c := baseValid()
+	c.DLQMaxDiskMB = 2
+	if got := c.DLQByteLimit(); got != 2<<20 {
+		t.Errorf("DLQByteLimit from MB = %d", got)
+	}
+	c.DLQMaxBytes = 4096
+	c.DLQMaxAge = "2d"
+	c.DLQOverflowPolicy = "reject_new"
+	c.DLQReadMergeWindow = "15m"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid DLQ limits rejected: %v", err)
+	}
+	if got := c.DLQByteLimit(); got != 4096 {
+		t.Errorf("DLQByteLimit = %d, want DLQ_MAX_BYTES", got)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"DLQ_MAX_BYTES":          func(c *Config) { c.DLQMaxBytes = -1 },
+		"DLQ_MAX_AGE":            func(c *Config) { c.DLQMaxAge = "forever" },
+		"DLQ_OVERFLOW_POLICY":    func(c *Config) { c.DLQOverflowPolicy = "block" },
+		"DLQ_READ_MERGE_WINDOW":  func(c *Config) { c.DLQReadMergeWindow = "-1m" },
+		"DLQ_REPLAY_CONCURRENCY": func(c *Config) { c.DLQReplayConcurrency = 65 },
+		"DLQ_COALESCE_FILES":     func(c *Config) { c.DLQCoalesceFiles = -1 },
+		"DLQ_COALESCE_MAX_BYTES": func(c *Config) { c.DLQCoalesceMaxBytes = -1 },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_IngestPipelineBatching(t *testing.T) {
+	c := baseValid()
+	c.IngestPipelineBatchSize = 500
+	c.IngestPipelineFlushInterval = "0s"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid batching settings rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"INGEST_PIPELINE_BATCH_SIZE":     func(c *Config) { c.IngestPipelineBatchSize = -1 },
+		"INGEST_PIPELINE_FLUSH_INTERVAL": func(c *Config) { c.IngestPipelineFlushInterval = "1m" },
+		"INGEST_PIPELINE_WRITE_RETRIES":  func(c *Config) { c.IngestPipelineWriteRetries = 11 },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_StorageUsage(t *testing.T) {
+	c := baseValid()
+	c.StorageUsageInterval = "0"
+	c.StorageUsageHistory = "90d"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid storage usage settings rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"STORAGE_USAGE_INTERVAL": func(c *Config) { c.StorageUsageInterval = "10s" },
+		"STORAGE_USAGE_HISTORY":  func(c *Config) { c.StorageUsageHistory = "forever" },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_DashboardRollups(t *testing.T) {
+	c := baseValid()
+	c.DashboardRollupBackfill = "7d"
+	c.DashboardRollupRetention = ""
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid rollup settings rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"DASHBOARD_ROLLUP_BACKFILL":  func(c *Config) { c.DashboardRollupBackfill = "yesterday" },
+		"DASHBOARD_ROLLUP_RETENTION": func(c *Config) { c.DashboardRollupRetention = "0" },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_IssueBackfill(t *testing.T) {
+	c := baseValid()
+	c.IssueBackfill = "30d"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid ISSUE_BACKFILL rejected: %v", err)
+	}
+	c.IssueBackfill = "forever"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "ISSUE_BACKFILL") {
+		t.Errorf("expected ISSUE_BACKFILL validation error, got %v", err)
+	}
+}
+
+func TestValidate_DeploymentImpactWindow(t *testing.T) {
+	c := baseValid()
+	c.DeploymentImpactWindow = "1h"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid DEPLOYMENT_IMPACT_WINDOW rejected: %v", err)
+	}
+	for _, v := range []string{"30s", "48h", "soon"} {
+		c.DeploymentImpactWindow = v
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "DEPLOYMENT_IMPACT_WINDOW") {
+			t.Errorf("%q: expected DEPLOYMENT_IMPACT_WINDOW validation error, got %v", v, err)
+		}
+	}
+}
+
+func TestValidate_SelfHistory(t *testing.T) {
+	c := baseValid()
+	c.SelfHistoryIngestGap = "0"
+	c.SelfHistoryRetention = ""
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid self-history settings rejected: %v", err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"SELF_HISTORY_INGEST_GAP": func(c *Config) { c.SelfHistoryIngestGap = "-1m" },
+		"SELF_HISTORY_RETENTION":  func(c *Config) { c.SelfHistoryRetention = "forever" },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestValidate_UptimeResultRetention(t *testing.T) {
+	c := baseValid()
+	c.UptimeResultRetention = "30d"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid uptime retention rejected: %v", err)
+	}
+	c.UptimeResultRetention = "forever"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "UPTIME_RESULT_RETENTION") {
+		t.Errorf("expected UPTIME_RESULT_RETENTION error, got %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a, b := baseValid(), baseValid()
+	b.HotRetentionDays = a.HotRetentionDays + 1
+	fa, fb := a.Fingerprint(), b.Fingerprint()
+	for key, h := range fa {
+		if changed := fb[key] != h; changed != (key == "HotRetentionDays") {
+			t.Errorf("%s: changed = %v", key, changed)
+		}
+	}
+	if len(fa) < 50 {
+		t.Errorf("fingerprint covers %d fields", len(fa))
+	}
+}
+
+func TestValidate_FeatureFlags(t *testing.T) {
+	c := baseValid()
+	c.FeatureFlags = "ai_insights=off"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid FEATURE_FLAGS rejected: %v", err)
+	}
+	c.FeatureFlags = "clickhouse"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "FEATURE_FLAGS") {
+		t.Fatalf("expected FEATURE_FLAGS error, got %v", err)
+	}
+	// Turning tail sampling on through the flag validates its settings.
+	c.FeatureFlags = "tail_sampling"
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "TAIL_SAMPLING") {
+		t.Fatalf("expected TAIL_SAMPLING error, got %v", err)
+	}
+}
+
 func TestValidate_InvalidDBDriver(t *testing.T) {
 	c := baseValid()
 	c.DBDriver = "mongodb"
@@ -137,6 +473,175 @@ func TestValidate_TLS_ReadableFilesOK(t *testing.T) {
 	}
 }
 
+func TestValidate_OTLPTLS(t *testing.T) {
+	dir := t.TempDir()
+	pem := filepath.Join(dir, "otlp.pem")
+	if err := os.WriteFile(pem, []byte("pem"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]struct {
+		mutate  func(*Config)
+		wantErr string
+	}{
+		"cert without key": {func(c *Config) { c.OTLPTLSCert = pem }, "OTLP_TLS_CERT and OTLP_TLS_KEY"},
+		"missing key file": {func(c *Config) { c.OTLPTLSCert, c.OTLPTLSKey = pem, "/does/not/exist.key" }, "OTLP_TLS_KEY"},
+		"client CA without TLS": {func(c *Config) { c.OTLPTLSClientCA = pem }, "OTLP_TLS_CLIENT_CA needs TLS"},
+		"mTLS on the OTLP cert": {func(c *Config) { c.OTLPTLSCert, c.OTLPTLSKey, c.OTLPTLSClientCA = pem, pem, pem }, ""},
+		"mTLS on self-signed": {func(c *Config) { c.TLSAutoSelfsigned, c.OTLPTLSClientCA = true, pem }, ""},
+	}
+	for name, tc := range cases {
+		c := baseValid()
+		tc.mutate(c)
+		err := c.Validate()
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_OIDC(t *testing.T) {
+	oidc := func(c *Config) {
+		c.OIDCIssuerURL = "https://login.example.com"
+		c.OIDCClientID = "argus"
+		c.OIDCRedirectURL = "https://argus.example.com/auth/callback"
+		c.OIDCSessionSecret = strings.Repeat("s", 32)
+		c.OIDCSessionTTL = "12h"
+		c.RBACDefaultRole = "viewer"
+	}
+	cases := map[string]struct {
+		mutate  func(*Config)
+		wantErr string
+	}{
+		"valid":               {func(c *Config) {}, ""},
+		"no client":           {func(c *Config) { c.OIDCClientID = "" }, "OIDC_CLIENT_ID"},
+		"relative redirect":   {func(c *Config) { c.OIDCRedirectURL = "/auth/callback" }, "OIDC_REDIRECT_URL"},
+		"bad issuer":          {func(c *Config) { c.OIDCIssuerURL = "login.example.com" }, "OIDC_ISSUER_URL"},
+		"short secret":        {func(c *Config) { c.OIDCSessionSecret = "short" }, "OIDC_SESSION_SECRET"},
+		"bad ttl":             {func(c *Config) { c.OIDCSessionTTL = "10s" }, "OIDC_SESSION_TTL"},
+		"no default role":     {func(c *Config) { c.RBACDefaultRole = "None" }, ""},
+		"bad default role":    {func(c *Config) { c.RBACDefaultRole = "owner" }, "RBAC_DEFAULT_ROLE"},
+		"bypass in prod":      {func(c *Config) { c.Env, c.AuthDevBypass = "production", true }, "AUTH_DEV_BYPASS"},
+		"bypass without oidc": {func(c *Config) { c.OIDCIssuerURL, c.AuthDevBypass = "", true }, ""},
+	}
+	for name, tc := range cases {
+		c := baseValid()
+		oidc(c)
+		tc.mutate(c)
+		err := c.Validate()
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestValidate_ColdTier(t *testing.T) {
+	cold := func(c *Config) {
+		c.ColdTierURL = "s3://archive/argus"
+		c.ColdTierAfter = "3d"
+		c.ColdTierInterval = "1h"
+		c.ColdTierAccessKeyID = "key"
+		c.ColdTierSecretAccessKey = "secret"
+	}
+	cases := map[string]struct {
+		mutate  func(*Config)
+		wantErr string
+	}{
+		"valid":             {func(c *Config) {}, ""},
+		"off":               {func(c *Config) { c.ColdTierURL, c.ColdTierAfter = "", "" }, ""},
+		"local disk":        {func(c *Config) { c.ColdTierURL, c.ColdTierAccessKeyID = "file:///var/lib/argus/cold", "" }, ""},
+		"bad scheme":        {func(c *Config) { c.ColdTierURL = "ftp://archive" }, "COLD_TIER_URL"},
+		"no bucket":         {func(c *Config) { c.ColdTierURL = "gs://" }, "needs a bucket"},
+		"no credentials":    {func(c *Config) { c.ColdTierSecretAccessKey = "" }, "COLD_TIER_SECRET_ACCESS_KEY"},
+		"bad endpoint":      {func(c *Config) { c.ColdTierS3Endpoint = "minio:9000" }, "COLD_TIER_S3_ENDPOINT"},
+		"bad after":         {func(c *Config) { c.ColdTierAfter = "soon" }, "COLD_TIER_AFTER"},
+		"after retention":   {func(c *Config) { c.ColdTierAfter = "7d" }, "RETENTION_TRACES"},
+		"after logs window": {func(c *Config) { c.RetentionLogs = "2d" }, "RETENTION_LOGS"},
+		"longer retention":  {func(c *Config) { c.ColdTierAfter, c.RetentionTraces, c.RetentionLogs = "10d", "30d", "30d" }, ""},
+		"short interval":    {func(c *Config) { c.ColdTierInterval = "10s" }, "COLD_TIER_INTERVAL"},
+	}
+	for name, tc := range cases {
+		c := baseValid()
+		cold(c)
+		tc.mutate(c)
+		err := c.Validate()
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestLoad_ColdTierFallsBackToAWSCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	t.Setenv("COLD_TIER_ACCESS_KEY_ID", "")
+	os.Unsetenv("COLD_TIER_ACCESS_KEY_ID") // restored by t.Setenv
+	t.Setenv("COLD_TIER_SECRET_ACCESS_KEY", "tier-secret")
+
+	cfg, err := Load("__no_such_env_file__")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ColdTierAccessKeyID != "aws-key" || cfg.ColdTierSecretAccessKey != "tier-secret" {
+		t.Errorf("cold tier credentials = %q / %q", cfg.ColdTierAccessKeyID, cfg.ColdTierSecretAccessKey)
+	}
+	if after, interval := cfg.ColdTierWindows(); after != 72*time.Hour || interval != time.Hour {
+		t.Errorf("ColdTierWindows = %v, %v", after, interval)
+	}
+}
+
+func TestValidate_Export(t *testing.T) {
+	export := func(c *Config) {
+		c.ExportURL = "kafka://user:pass@b1:9092,b2:9092"
+		c.ExportTracesTopic = "argus.spans"
+		c.ExportLogsTopic = "argus.logs"
+		c.ExportBatchSize = 500
+		c.ExportFlushInterval = "1s"
+		c.ExportQueueSize = 10000
+	}
+	cases := map[string]struct {
+		mutate  func(*Config)
+		wantErr string
+	}{
+		"valid":         {func(c *Config) {}, ""},
+		"off":           {func(c *Config) { c.ExportURL, c.ExportBatchSize = "", 0 }, ""},
+		"nats tls":      {func(c *Config) { c.ExportURL = "nats+tls://token@nats:4222" }, ""},
+		"bad scheme":    {func(c *Config) { c.ExportURL = "amqp://rabbit" }, "EXPORT_URL"},
+		"no host":       {func(c *Config) { c.ExportURL = "kafka://user:pass@" }, "needs a host"},
+		"secret hidden": {func(c *Config) { c.ExportURL = "http://user:hunter2@x" }, "\"http\""},
+		"no topics":     {func(c *Config) { c.ExportTracesTopic, c.ExportLogsTopic = "", "" }, "all empty"},
+		"metrics only":  {func(c *Config) { c.ExportTracesTopic, c.ExportLogsTopic, c.ExportMetricsTopic = "", "", "m" }, ""},
+		"topic spaces":  {func(c *Config) { c.ExportLogsTopic = "argus logs" }, "EXPORT_LOGS_TOPIC"},
+		"batch too big": {func(c *Config) { c.ExportBatchSize = 20000 }, "EXPORT_BATCH_SIZE"},
+		"bad flush":     {func(c *Config) { c.ExportFlushInterval = "1ms" }, "EXPORT_FLUSH_INTERVAL"},
+		"no queue":      {func(c *Config) { c.ExportQueueSize = 0 }, "EXPORT_QUEUE_SIZE"},
+		"missing CA":    {func(c *Config) { c.ExportTLSCAFile = "/nonexistent/ca.pem" }, "EXPORT_TLS_CA_FILE"},
+	}
+	for name, tc := range cases {
+		c := baseValid()
+		export(c)
+		tc.mutate(c)
+		err := c.Validate()
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.wantErr)
+		}
+		if err != nil && strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("%s: error leaks the URL password: %v", name, err)
+		}
+	}
+}
+
 func TestLoad_EnvVars_TLS_APIKey_OTel_Tenant(t *testing.T) {
 	t.Setenv("TLS_CERT_FILE", "")
 	t.Setenv("TLS_KEY_FILE", "")
@@ -307,3 +812,92 @@ func TestLoad_DefaultTenant_FallsBackToDefault(t *testing.T) {
 		t.Errorf("expected default tenant to be 'default', got %q", cfg.DefaultTenant)
 	}
 }
+
+func TestConfig_HubTuning(t *testing.T) {
+	c := baseValid()
+	if got, err := c.HubTuning(); err != nil || got != HubPresets["default"] {
+		t.Errorf("default tuning = %+v, %v", got, err)
+	}
+	c.HubPreset = "high-throughput"
+	c.HubFlushInterval = "1s"
+	if got, err := c.HubTuning(); err != nil || got.BufferSize != 1000 || got.FlushInterval != time.Second {
+		t.Errorf("preset with override = %+v, %v", got, err)
+	}
+	for env, mutate := range map[string]func(*Config){
+		"HUB_PRESET":         func(c *Config) { c.HubPreset = "turbo" },
+		"HUB_FLUSH_INTERVAL": func(c *Config) { c.HubFlushInterval = "soon" },
+		"HUB_BUFFER_SIZE":    func(c *Config) { c.HubBufferSize = 20000 },
+	} {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), env) {
+			t.Errorf("%s: expected validation error, got %v", env, err)
+		}
+	}
+}
+
+func TestParseServiceRates(t *testing.T) {
+	got, err := ParseServiceRates(" checkout=0.1, search=1 ")
+	if err != nil || len(got) != 2 || got["checkout"] != 0.1 || got["search"] != 1 {
+		t.Fatalf("ParseServiceRates = %v, %v", got, err)
+	}
+	if got, err := ParseServiceRates(""); got != nil || err != nil {
+		t.Errorf("empty = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"checkout", "=0.1", "checkout=1.5", "checkout=-0.1", "checkout=half", "a=0.1,a=0.2"} {
+		if _, err := ParseServiceRates(bad); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestParseServiceRateLimits(t *testing.T) {
+	got, err := ParseServiceRateLimits("checkout=500,*=2000")
+	if err != nil || len(got) != 2 || got["checkout"] != 500 || got["*"] != 2000 {
+		t.Fatalf("ParseServiceRateLimits = %v, %v", got, err)
+	}
+	for _, bad := range []string{"checkout=0", "checkout=-5", "*"} {
+		if _, err := ParseServiceRateLimits(bad); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestValidate_ServiceSamplingAndRateLimits(t *testing.T) {
+	cases := map[string]func(*Config){
+		"SAMPLING_SERVICE_RATES":  func(c *Config) { c.SamplingServiceRates = "checkout=2" },
+		"INGEST_SPAN_RATE_LIMITS": func(c *Config) { c.IngestSpanRateLimits = "checkout" },
+		"INGEST_LOG_RATE_LIMITS":  func(c *Config) { c.IngestLogRateLimits = "*=0" },
+	}
+	for want, mutate := range cases {
+		c := baseValid()
+		mutate(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %s error, got %v", want, err)
+		}
+	}
+	c := baseValid()
+	c.SamplingServiceRates, c.IngestSpanRateLimits, c.IngestLogRateLimits = "checkout=0.1", "checkout=500", "*=2000"
+	if err := c.Validate(); err != nil {
+		t.Errorf("valid limits rejected: %v", err)
+	}
+}
+
+func TestValidate_SpanMetricsMaxSeries(t *testing.T) {
+	c := baseValid()
+	c.SpanMetricsEnabled = true
+	c.SpanMetricsMaxSeries = 5000
+	if err := c.Validate(); err != nil {
+		t.Fatalf("valid SPAN_METRICS_MAX_SERIES rejected: %v", err)
+	}
+	for _, v := range []int{0, 2_000_000} {
+		c.SpanMetricsMaxSeries = v
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "SPAN_METRICS_MAX_SERIES") {
+			t.Errorf("%d: expected SPAN_METRICS_MAX_SERIES validation error, got %v", v, err)
+		}
+	}
+	c.SpanMetricsEnabled = false
+	if err := c.Validate(); err != nil {
+		t.Errorf("cap checked while span metrics are off: %v", err)
+	}
+}
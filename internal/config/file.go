@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the YAML configuration file named by CONFIG_FILE, conventionally
+// argus.yaml. It covers the settings that can change while the process
+// runs; every key it sets overrides the matching environment variable, and
+// keys it leaves out keep the environment value. The file is re-read when
+// it changes (see WatchFile), so all of it applies without a restart.
+//
+//	ingest:
+//	  min_severity: WARN
+//	  excluded_services: [load-generator]
+//	  span_rate_limits: {checkout: 1000, "*": 5000}
+//	sampling:
+//	  rate: 0.5
+//	  service_rates: {search: 0.1}
+//	retention:
+//	  days: 14
+//	  logs: 72h
+//	  tenants: {team-a: 30d}
+//	redaction:
+//	  hash_key: change-me
+//	  rules:
+//	    - builtin: credit_card
+//	    - attribute: user.email
+//	      action: hash
+//	alerts:
+//	  - name: checkout errors
+//	    kind: error_rate
+//	    service: checkout
+//	    threshold: 5
+//	    window: 5m
+//	    channels: [{type: slack, url: "https://hooks.slack.com/..."}]
+type File struct {
+	Ingest    *FileIngest     `yaml:"ingest"`
+	Sampling  *FileSampling   `yaml:"sampling"`
+	Retention *FileRetention  `yaml:"retention"`
+	Redaction *FileRedaction  `yaml:"redaction"`
+	Alerts    []AlertRuleSpec `yaml:"alerts"`
+}
+
+// FileIngest overrides the ingestion filters and rate limits.
+type FileIngest struct {
+	MinSeverity      *string            `yaml:"min_severity"`      // INGEST_MIN_SEVERITY
+	AllowedServices  []string           `yaml:"allowed_services"`  // INGEST_ALLOWED_SERVICES
+	ExcludedServices []string           `yaml:"excluded_services"` // INGEST_EXCLUDED_SERVICES
+	SpanRateLimits   map[string]float64 `yaml:"span_rate_limits"`  // INGEST_SPAN_RATE_LIMITS
+	LogRateLimits    map[string]float64 `yaml:"log_rate_limits"`   // INGEST_LOG_RATE_LIMITS
+}
+
+// FileSampling overrides head sampling.
+type FileSampling struct {
+	Rate               *float64           `yaml:"rate"`                 // SAMPLING_RATE
+	AlwaysOnErrors     *bool              `yaml:"always_on_errors"`     // SAMPLING_ALWAYS_ON_ERRORS
+	LatencyThresholdMs *int               `yaml:"latency_threshold_ms"` // SAMPLING_LATENCY_THRESHOLD_MS
+	ServiceRates       map[string]float64 `yaml:"service_rates"`        // SAMPLING_SERVICE_RATES
+}
+
+// FileRetention overrides the retention windows.
+type FileRetention struct {
+	Days    *int              `yaml:"days"`    // HOT_RETENTION_DAYS
+	Traces  *string           `yaml:"traces"`  // RETENTION_TRACES
+	Logs    *string           `yaml:"logs"`    // RETENTION_LOGS
+	Metrics *string           `yaml:"metrics"` // RETENTION_METRICS
+	Tenants map[string]string `yaml:"tenants"` // RETENTION_TENANTS
+}
+
+// FileRedaction sets the redaction rules inline. Rules replace
+// INGEST_REDACTION_RULES_FILE.
+type FileRedaction struct {
+	HashKey *string             `yaml:"hash_key"` // INGEST_REDACTION_HASH_KEY
+	Rules   []RedactionRuleSpec `yaml:"rules"`
+}
+
+// RedactionRuleSpec is one redaction rule, with the fields and meaning of
+// ingest.RedactionRule.
+type RedactionRuleSpec struct {
+	Name      string `yaml:"name"`
+	Service   string `yaml:"service"`
+	Attribute string `yaml:"attribute"`
+	Builtin   string `yaml:"builtin"`
+	Pattern   string `yaml:"pattern"`
+	Action    string `yaml:"action"`
+}
+
+// AlertRuleSpec is an alert rule managed by the config file. The rules are
+// synced into the alert rule table, matched by tenant and name; see
+// storage.AlertRule for the kinds and thresholds.
+type AlertRuleSpec struct {
+	Tenant    string             `yaml:"tenant"` // "" = DEFAULT_TENANT
+	Name      string             `yaml:"name"`
+	Kind      string             `yaml:"kind"`
+	Service   string             `yaml:"service"`
+	Keyword   string             `yaml:"keyword"`
+	SLOID     uint               `yaml:"slo_id"`
+	MonitorID uint               `yaml:"monitor_id"`
+	Threshold float64            `yaml:"threshold"`
+	Window    string             `yaml:"window"` // Go duration; "" = the evaluator default
+	Disabled  bool               `yaml:"disabled"`
+	Channels  []AlertChannelSpec `yaml:"channels"`
+}
+
+// AlertChannelSpec is a notification target of an AlertRuleSpec.
+type AlertChannelSpec struct {
+	Type string   `yaml:"type"` // slack | webhook | email
+	URL  string   `yaml:"url"`
+	To   []string `yaml:"to"`
+}
+
+// ReadFile parses the config file at path. Unknown keys are rejected so a
+// typo cannot silently leave a setting at its old value. An empty file is
+// valid and overrides nothing.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("config file %q: %w", path, err)
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("config file %q: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Apply overlays the keys set in f onto c. Values keep the environment
+// variables' string formats, so Validate and the Parse helpers cover both
+// sources.
+func (f *File) Apply(c *Config) {
+	if in := f.Ingest; in != nil {
+		if in.MinSeverity != nil {
+			c.IngestMinSeverity = *in.MinSeverity
+		}
+		if in.AllowedServices != nil {
+			c.IngestAllowedServices = strings.Join(in.AllowedServices, ",")
+		}
+		if in.ExcludedServices != nil {
+			c.IngestExcludedServices = strings.Join(in.ExcludedServices, ",")
+		}
+		if in.SpanRateLimits != nil {
+			c.IngestSpanRateLimits = formatServiceValues(in.SpanRateLimits)
+		}
+		if in.LogRateLimits != nil {
+			c.IngestLogRateLimits = formatServiceValues(in.LogRateLimits)
+		}
+	}
+	if s := f.Sampling; s != nil {
+		if s.Rate != nil {
+			c.SamplingRate = *s.Rate
+		}
+		if s.AlwaysOnErrors != nil {
+			c.SamplingAlwaysOnErrors = *s.AlwaysOnErrors
+		}
+		if s.LatencyThresholdMs != nil {
+			c.SamplingLatencyThresholdMs = *s.LatencyThresholdMs
+		}
+		if s.ServiceRates != nil {
+			c.SamplingServiceRates = formatServiceValues(s.ServiceRates)
+		}
+	}
+	if r := f.Retention; r != nil {
+		if r.Days != nil {
+			c.HotRetentionDays = *r.Days
+		}
+		if r.Traces != nil {
+			c.RetentionTraces = *r.Traces
+		}
+		if r.Logs != nil {
+			c.RetentionLogs = *r.Logs
+		}
+		if r.Metrics != nil {
+			c.RetentionMetrics = *r.Metrics
+		}
+		if r.Tenants != nil {
+			pairs := make([]string, 0, len(r.Tenants))
+			for tenant, window := range r.Tenants {
+				pairs = append(pairs, tenant+"="+window)
+			}
+			sort.Strings(pairs)
+			c.RetentionTenants = strings.Join(pairs, ",")
+		}
+	}
+	if rd := f.Redaction; rd != nil {
+		if rd.HashKey != nil {
+			c.IngestRedactionHashKey = *rd.HashKey
+		}
+		if rd.Rules != nil {
+			c.RedactionRules = rd.Rules
+		}
+	}
+	if f.Alerts != nil {
+		c.AlertRules = f.Alerts
+	}
+}
+
+func formatServiceValues(m map[string]float64) string {
+	pairs := make([]string, 0, len(m))
+	for svc, v := range m {
+		pairs = append(pairs, svc+"="+strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// validateAlertRules checks what can be checked without the alerting
+// package: names, uniqueness per tenant and windows. Kinds and thresholds
+// are validated when the rules are synced.
+func validateAlertRules(rules []AlertRuleSpec) error {
+	seen := make(map[[2]string]bool, len(rules))
+	for i, r := range rules {
+		if r.Name == "" {
+			return fmt.Errorf("alert rule %d: name is required", i)
+		}
+		k := [2]string{r.Tenant, r.Name}
+		if seen[k] {
+			return fmt.Errorf("alert rule %q listed twice", r.Name)
+		}
+		seen[k] = true
+		if r.Window != "" {
+			if d, err := time.ParseDuration(r.Window); err != nil || d <= 0 {
+				return fmt.Errorf("alert rule %q: invalid window %q", r.Name, r.Window)
+			}
+		}
+	}
+	return nil
+}
+
+// Reload returns a fresh copy of the configuration: the environment values
+// read at startup with the config file re-read on top and the runtime
+// overrides kept, validated. c is left untouched, so a bad edit keeps the
+// running configuration.
+func (c *Config) Reload() (*Config, error) {
+	var f *File
+	if c.ConfigFile != "" {
+		var err error
+		if f, err = ReadFile(c.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	next, err := c.rebuild(f, c.runtime)
+	if err != nil {
+		return nil, fmt.Errorf("config file %q: %w", c.ConfigFile, err)
+	}
+	return next, nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFile_Apply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "argus.yaml")
+	writeConfigFile(t, path, `
+ingest:
+  min_severity: WARN
+  excluded_services: [load-gen, canary]
+  span_rate_limits: {checkout: 1000, "*": 5000}
+sampling:
+  rate: 0.5
+  service_rates: {search: 0.1}
+retention:
+  days: 14
+  logs: 72h
+  tenants: {team-b: 30d, team-a: 3d}
+redaction:
+  hash_key: k1
+  rules:
+    - builtin: credit_card
+alerts:
+  - name: checkout errors
+    kind: error_rate
+    threshold: 5
+    window: 5m
+`)
+	f, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	c := baseValid()
+	c.IngestAllowedServices = "from-env"
+	f.Apply(c)
+
+	got := map[string]string{
+		"IngestMinSeverity":      c.IngestMinSeverity,
+		"IngestAllowedServices":  c.IngestAllowedServices,
+		"IngestExcludedServices": c.IngestExcludedServices,
+		"IngestSpanRateLimits":   c.IngestSpanRateLimits,
+		"SamplingServiceRates":   c.SamplingServiceRates,
+		"RetentionLogs":          c.RetentionLogs,
+		"RetentionTenants":       c.RetentionTenants,
+		"IngestRedactionHashKey": c.IngestRedactionHashKey,
+	}
+	want := map[string]string{
+		"IngestMinSeverity":      "WARN",
+		"IngestAllowedServices":  "from-env",
+		"IngestExcludedServices": "load-gen,canary",
+		"IngestSpanRateLimits":   "*=5000,checkout=1000",
+		"SamplingServiceRates":   "search=0.1",
+		"RetentionLogs":          "72h",
+		"RetentionTenants":       "team-a=3d,team-b=30d",
+		"IngestRedactionHashKey": "k1",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if c.SamplingRate != 0.5 || c.HotRetentionDays != 14 || len(c.RedactionRules) != 1 || len(c.AlertRules) != 1 {
+		t.Errorf("rate=%v days=%d redaction=%v alerts=%v", c.SamplingRate, c.HotRetentionDays, c.RedactionRules, c.AlertRules)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestReadFile_RejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "argus.yaml")
+	writeConfigFile(t, path, "sampling:\n  rat: 0.5\n")
+	if _, err := ReadFile(path); err == nil {
+		t.Error("unknown key should fail")
+	}
+	writeConfigFile(t, path, "")
+	if f, err := ReadFile(path); err != nil || f.Sampling != nil {
+		t.Errorf("empty file = %+v, %v; want no overrides", f, err)
+	}
+}
+
+// TestConfig_Reload verifies a reload starts from the environment values,
+// so a key removed from the file falls back instead of sticking.
+func TestConfig_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "argus.yaml")
+	env := baseValid()
+	env.ConfigFile = path
+	env.IngestMinSeverity = "INFO"
+	c := *env
+	c.env = env
+
+	writeConfigFile(t, path, "ingest: {min_severity: ERROR}\n")
+	next, err := c.Reload()
+	if err != nil || next.IngestMinSeverity != "ERROR" {
+		t.Fatalf("Reload = %v, %v; want ERROR", next, err)
+	}
+	writeConfigFile(t, path, "sampling: {rate: 0.5}\n")
+	next, err = next.Reload()
+	if err != nil || next.IngestMinSeverity != "INFO" || next.SamplingRate != 0.5 {
+		t.Fatalf("Reload = %+v, %v; want INFO from the environment", next, err)
+	}
+
+	writeConfigFile(t, path, "sampling: {rate: 2}\n")
+	if _, err := next.Reload(); err == nil || !strings.Contains(err.Error(), "SAMPLING_RATE") {
+		t.Errorf("invalid file err = %v, want SAMPLING_RATE", err)
+	}
+	writeConfigFile(t, path, "alerts: [{name: a, kind: error_rate}, {name: a, kind: error_rate}]\n")
+	if _, err := next.Reload(); err == nil || !strings.Contains(err.Error(), "listed twice") {
+		t.Errorf("duplicate alert err = %v", err)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "argus.yaml")
+	writeConfigFile(t, path, "a: 1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var changes atomic.Int32
+	go WatchFile(ctx, path, 10*time.Millisecond, func() { changes.Add(1) })
+
+	time.Sleep(50 * time.Millisecond)
+	if n := changes.Load(); n != 0 {
+		t.Fatalf("changes = %d before any edit", n)
+	}
+	writeConfigFile(t, path, "a: 2\n")
+	deadline := time.Now().Add(2 * time.Second)
+	for changes.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := changes.Load(); n != 1 {
+		t.Errorf("changes = %d after one edit, want 1", n)
+	}
+}
+
+// TestWatchFile_Events verifies edits are picked up from file system events
+// long before the polling fallback, including an editor-style replace.
+func TestWatchFile_Events(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "argus.yaml")
+	writeConfigFile(t, path, "a: 1\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var changes atomic.Int32
+	go WatchFile(ctx, path, time.Hour, func() { changes.Add(1) })
+	time.Sleep(50 * time.Millisecond) // let the watcher subscribe
+
+	waitFor := func(want int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for changes.Load() < want && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := changes.Load(); n != want {
+			t.Fatalf("changes = %d, want %d", n, want)
+		}
+	}
+	writeConfigFile(t, path, "a: 2\n")
+	waitFor(1)
+
+	tmp := filepath.Join(dir, ".argus.yaml.swp")
+	writeConfigFile(t, tmp, "a: 3\n")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(2)
+
+	// Touching the file without changing it is not a change.
+	writeConfigFile(t, path, "a: 3\n")
+	time.Sleep(300 * time.Millisecond)
+	if n := changes.Load(); n != 2 {
+		t.Errorf("changes = %d after an identical write, want 2", n)
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidOverride wraps the error Live.Override returns when the changed
+// settings do not validate.
+var ErrInvalidOverride = errors.New("invalid runtime setting")
+
+// runtimeField reads and writes one runtime-adjustable setting in its
+// environment variable's string format.
+type runtimeField struct {
+	get func(c *Config) string
+	set func(c *Config, v string) error
+}
+
+func stringField(field func(c *Config) *string) runtimeField {
+	return runtimeField{
+		get: func(c *Config) string { return *field(c) },
+		set: func(c *Config, v string) error { *field(c) = v; return nil },
+	}
+}
+
+func intField(key string, field func(c *Config) *int) runtimeField {
+	return runtimeField{
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", key, v)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// runtimeFields are the settings PATCH /api/config can change, keyed by
+// environment variable name. Ranges and formats are left to Validate.
+var runtimeFields = map[string]runtimeField{
+	"INGEST_MIN_SEVERITY": {
+		get: func(c *Config) string { return c.IngestMinSeverity },
+		set: func(c *Config, v string) error {
+			switch strings.ToUpper(v) {
+			case "DEBUG", "INFO", "WARN", "WARNING", "ERROR", "FATAL":
+				c.IngestMinSeverity = strings.ToUpper(v)
+				return nil
+			}
+			return fmt.Errorf("INGEST_MIN_SEVERITY must be DEBUG, INFO, WARN, ERROR or FATAL, got %q", v)
+		},
+	},
+	"INGEST_ALLOWED_SERVICES":  stringField(func(c *Config) *string { return &c.IngestAllowedServices }),
+	"INGEST_EXCLUDED_SERVICES": stringField(func(c *Config) *string { return &c.IngestExcludedServices }),
+	"SAMPLING_RATE": {
+		get: func(c *Config) string { return strconv.FormatFloat(c.SamplingRate, 'f', -1, 64) },
+		set: func(c *Config, v string) error {
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return fmt.Errorf("SAMPLING_RATE must be a number, got %q", v)
+			}
+			c.SamplingRate = f
+			return nil
+		},
+	},
+	"SAMPLING_SERVICE_RATES": stringField(func(c *Config) *string { return &c.SamplingServiceRates }),
+	"HUB_BUFFER_SIZE":        intField("HUB_BUFFER_SIZE", func(c *Config) *int { return &c.HubBufferSize }),
+	"HUB_FLUSH_INTERVAL":     stringField(func(c *Config) *string { return &c.HubFlushInterval }),
+	"HOT_RETENTION_DAYS":     intField("HOT_RETENTION_DAYS", func(c *Config) *int { return &c.HotRetentionDays }),
+	"RETENTION_TRACES":       stringField(func(c *Config) *string { return &c.RetentionTraces }),
+	"RETENTION_LOGS":         stringField(func(c *Config) *string { return &c.RetentionLogs }),
+	"RETENTION_METRICS":      stringField(func(c *Config) *string { return &c.RetentionMetrics }),
+}
+
+// RuntimeKeys returns the settings that can be changed at runtime, sorted.
+func RuntimeKeys() []string {
+	keys := make([]string, 0, len(runtimeFields))
+	for k := range runtimeFields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RuntimeValue returns the current value of the runtime setting key in its
+// environment variable format, and whether key is a runtime setting.
+func (c *Config) RuntimeValue(key string) (string, bool) {
+	f, ok := runtimeFields[key]
+	if !ok {
+		return "", false
+	}
+	return f.get(c), true
+}
+
+// RuntimeOverrides returns a copy of the runtime settings applied on top of
+// the environment and config file.
+func (c *Config) RuntimeOverrides() map[string]string {
+	out := make(map[string]string, len(c.runtime))
+	for k, v := range c.runtime {
+		out[k] = v
+	}
+	return out
+}
+
+// WithRuntimeOverrides returns a copy of c with overrides as its runtime
+// settings, replacing the previous ones, validated. Settings not in
+// overrides fall back to the config file and environment values.
+func (c *Config) WithRuntimeOverrides(overrides map[string]string) (*Config, error) {
+	return c.rebuild(c.file, overrides)
+}
+
+// rebuild layers the environment values, f and the runtime overrides, in
+// that order, and validates the result.
+func (c *Config) rebuild(f *File, overrides map[string]string) (*Config, error) {
+	next := *c
+	if c.env != nil {
+		next = *c.env
+	}
+	next.env, next.file, next.runtime = c.env, f, nil
+	if f != nil {
+		f.Apply(&next)
+	}
+	if len(overrides) > 0 {
+		next.runtime = make(map[string]string, len(overrides))
+		for k, v := range overrides {
+			field, ok := runtimeFields[k]
+			if !ok {
+				return nil, fmt.Errorf("%s cannot be changed at runtime", k)
+			}
+			if err := field.set(&next, v); err != nil {
+				return nil, err
+			}
+			next.runtime[k] = v
+		}
+	}
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// Live holds the running configuration when it can change: config file
+// reloads and runtime overrides both go through it, so neither loses the
+// other's changes.
+type Live struct {
+	mu    sync.Mutex
+	cur   *Config
+	apply func(prev, next *Config) error
+}
+
+// NewLive returns a Live running cfg. apply pushes a changed configuration
+// to the running subsystems; when it fails the previous one is kept.
+func NewLive(cfg *Config, apply func(prev, next *Config) error) *Live {
+	return &Live{cur: cfg, apply: apply}
+}
+
+// Current returns the running configuration. It must not be modified.
+func (l *Live) Current() *Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cur
+}
+
+// Reload re-reads the config file and applies it, keeping the runtime
+// overrides.
+func (l *Live) Reload() (*Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next, err := l.cur.Reload()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.swap(next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// Override merges changes into the runtime overrides — a nil value clears
+// the override — and applies the result. save, when non-nil, persists the
+// merged overrides once they are applied, so overrides the running
+// subsystems rejected never reach the next start; if it fails the previous
+// configuration is applied again and nothing changes.
+func (l *Live) Override(changes map[string]*string, save func(overrides map[string]string) error) (*Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	overrides := l.cur.RuntimeOverrides()
+	for k, v := range changes {
+		if v == nil {
+			delete(overrides, k)
+		} else {
+			overrides[k] = *v
+		}
+	}
+	next, err := l.cur.WithRuntimeOverrides(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOverride, err)
+	}
+	prev := l.cur
+	if err := l.swap(next); err != nil {
+		return nil, err
+	}
+	if save != nil {
+		if err := save(next.RuntimeOverrides()); err != nil {
+			if rerr := l.swap(prev); rerr != nil {
+				return nil, errors.Join(err, fmt.Errorf("restore previous config: %w", rerr))
+			}
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+func (l *Live) swap(next *Config) error {
+	if l.apply != nil {
+		if err := l.apply(l.cur, next); err != nil {
+			return err
+		}
+	}
+	l.cur = next
+	return nil
+}
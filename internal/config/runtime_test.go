@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLive_OverridesAndReload verifies runtime overrides sit on top of the
+// config file, survive a reload and fall back when cleared.
+func TestLive_OverridesAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "argus.yaml")
+	env := baseValid()
+	env.ConfigFile = path
+	env.IngestMinSeverity = "INFO"
+	writeConfigFile(t, path, "ingest: {min_severity: WARN}\nsampling: {rate: 0.5}\n")
+	c, err := env.rebuild(nil, nil)
+	if err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	c.env = env
+
+	var applied int
+	live := NewLive(c, func(_, _ *Config) error { applied++; return nil })
+	if _, err := live.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	str := func(s string) *string { return &s }
+	var saved map[string]string
+	save := func(o map[string]string) error { saved = o; return nil }
+	next, err := live.Override(map[string]*string{"INGEST_MIN_SEVERITY": str("error"), "HOT_RETENTION_DAYS": str("3")}, save)
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	if next.IngestMinSeverity != "ERROR" || next.HotRetentionDays != 3 || next.SamplingRate != 0.5 {
+		t.Errorf("override = %s/%d/%v; want ERROR/3 over the file's 0.5", next.IngestMinSeverity, next.HotRetentionDays, next.SamplingRate)
+	}
+	if len(saved) != 2 || saved["INGEST_MIN_SEVERITY"] != "error" {
+		t.Errorf("saved = %v", saved)
+	}
+
+	writeConfigFile(t, path, "sampling: {rate: 0.25}\n")
+	if next, err = live.Reload(); err != nil || next.IngestMinSeverity != "ERROR" || next.SamplingRate != 0.25 {
+		t.Fatalf("Reload = %+v, %v; want the override kept and the new file", next, err)
+	}
+
+	next, err = live.Override(map[string]*string{"INGEST_MIN_SEVERITY": nil}, save)
+	if err != nil || next.IngestMinSeverity != "INFO" || len(saved) != 1 {
+		t.Errorf("clear = %v, %v, saved %v; want INFO from the environment", next, err, saved)
+	}
+	if applied != 4 {
+		t.Errorf("apply called %d times, want 4", applied)
+	}
+
+	for key, value := range map[string]string{
+		"SAMPLING_RATE":       "2",
+		"HOT_RETENTION_DAYS":  "many",
+		"INGEST_MIN_SEVERITY": "LOUD",
+		"DB_DRIVER":           "mysql",
+	} {
+		if _, err := live.Override(map[string]*string{key: str(value)}, save); !errors.Is(err, ErrInvalidOverride) || !strings.Contains(err.Error(), key) {
+			t.Errorf("%s=%s err = %v", key, value, err)
+		}
+	}
+	if _, err := live.Override(map[string]*string{"SAMPLING_RATE": str("0")}, func(map[string]string) error { return errors.New("db down") }); err == nil || errors.Is(err, ErrInvalidOverride) {
+		t.Error("a failed save should fail the override")
+	}
+	if live.Current().SamplingRate != 0.25 {
+		t.Errorf("failed overrides changed the running config: rate %v", live.Current().SamplingRate)
+	}
+}
+
+// TestLive_OverrideNotSavedWhenApplyFails verifies overrides the running
+// subsystems reject are not persisted for the next start.
+func TestLive_OverrideNotSavedWhenApplyFails(t *testing.T) {
+	c, err := baseValid().rebuild(nil, nil)
+	if err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	live := NewLive(c, func(_, next *Config) error {
+		if next.IngestMinSeverity == "ERROR" {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+	saved := map[string]string{"HOT_RETENTION_DAYS": "3"}
+	save := func(o map[string]string) error { saved = o; return nil }
+	sev := "ERROR"
+	if _, err := live.Override(map[string]*string{"INGEST_MIN_SEVERITY": &sev}, save); err == nil {
+		t.Fatal("Override succeeded although apply failed")
+	}
+	if len(saved) != 1 || saved["HOT_RETENTION_DAYS"] != "3" {
+		t.Errorf("saved = %v, want the previous overrides untouched", saved)
+	}
+	if live.Current() != c {
+		t.Error("a failed apply changed the running config")
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchSettle is how long WatchFile waits after a file system event before
+// reading the file, so an editor's truncate-then-write or a ConfigMap's
+// several renames are read once, complete.
+const watchSettle = 100 * time.Millisecond
+
+// WatchFile calls onChange whenever the content of path changes, until ctx
+// is done. It subscribes to file system events on path's directory rather
+// than the file itself, which follows editors that replace the file and
+// Kubernetes ConfigMap symlink swaps. The content is also checked every
+// interval, as a fallback for events the platform does not deliver (network
+// and some container file systems) or a watcher that cannot be created. A
+// missing or unreadable file is logged and retried; onChange only runs once
+// the content is readable and different from the last version seen.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last, _ := fileDigest(path)
+	failing := false
+	check := func() {
+		sum, err := fileDigest(path)
+		if err != nil {
+			if !failing {
+				slog.Warn("Config file unreadable, keeping the running configuration", "path", path, "error", err)
+			}
+			failing = true
+			return
+		}
+		failing = false
+		if bytes.Equal(sum, last) {
+			return
+		}
+		last = sum
+		onChange()
+	}
+
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+	if w, err := fsnotify.NewWatcher(); err != nil {
+		slog.Warn("Config file events unavailable, polling only", "path", path, "interval", interval, "error", err)
+	} else {
+		defer func() { _ = w.Close() }()
+		if err := w.Add(filepath.Dir(path)); err != nil {
+			slog.Warn("Config file events unavailable, polling only", "path", path, "interval", interval, "error", err)
+		} else {
+			events, watchErrs = w.Events, w.Errors
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	settle := time.NewTimer(watchSettle)
+	settle.Stop()
+	defer settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			// Any event in the directory may be the file changing under a
+			// symlink; the digest comparison filters the rest out.
+			settle.Reset(watchSettle)
+		case err, ok := <-watchErrs:
+			if !ok {
+				watchErrs = nil
+				continue
+			}
+			slog.Warn("Config file watch error, relying on polling", "path", path, "error", err)
+		case <-settle.C:
+			check()
+		}
+	}
+}
+
+func fileDigest(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
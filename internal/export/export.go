@@ -0,0 +1,359 @@
+// Package export republishes ingested telemetry to Kafka or NATS so the
+// service can sit as a processing hop in a larger pipeline while still
+// storing everything locally.
+package export
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+)
+
+// Signal names used as the metrics label.
+const (
+	SignalTraces  = "traces"
+	SignalLogs    = "logs"
+	SignalMetrics = "metrics"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10_000
+	publishTimeout       = 30 * time.Second
+)
+
+// Publisher delivers one batch of messages, all with the same key, to a
+// topic (a Kafka topic or a NATS subject).
+type Publisher interface {
+	Publish(ctx context.Context, topic string, key []byte, values [][]byte) error
+	Close() error
+}
+
+// SinkConfig selects and authenticates a Publisher.
+type SinkConfig struct {
+	// URL is kafka://broker1:9092,broker2:9092 or nats://host:4222, with a
+	// +tls suffix on the scheme for TLS. Userinfo carries SASL/PLAIN
+	// credentials for Kafka, and user/password or a bare token for NATS.
+	URL string
+	// TLSCAFile, when set, holds the PEM CAs that verify the brokers.
+	TLSCAFile string
+}
+
+// Open returns the Publisher cfg.URL names. It does not connect; the
+// first Publish does.
+func Open(cfg SinkConfig) (Publisher, error) {
+	// url.Parse refuses the comma-separated broker list, so the authority
+	// is split by hand.
+	rawScheme, rest, ok := strings.Cut(cfg.URL, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid export URL %q: want scheme://host:port", cfg.URL)
+	}
+	scheme, useTLS := strings.CutSuffix(rawScheme, "+tls")
+	hosts, _, _ := strings.Cut(rest, "/")
+	var user, pass string
+	if i := strings.LastIndex(hosts, "@"); i >= 0 {
+		userinfo := hosts[:i]
+		hosts = hosts[i+1:]
+		rawUser, rawPass, _ := strings.Cut(userinfo, ":")
+		var err1, err2 error
+		user, err1 = url.PathUnescape(rawUser)
+		pass, err2 = url.PathUnescape(rawPass)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("invalid export URL credentials: %w", err)
+		}
+	}
+	if hosts == "" {
+		return nil, errors.New("export URL has no host")
+	}
+	var tlsConf *tls.Config
+	if useTLS || cfg.TLSCAFile != "" {
+		var err error
+		if tlsConf, err = clientTLS(cfg.TLSCAFile); err != nil {
+			return nil, err
+		}
+	}
+	switch scheme {
+	case "kafka":
+		var brokers []string
+		for b := range strings.SplitSeq(hosts, ",") {
+			if b = strings.TrimSpace(b); b != "" {
+				brokers = append(brokers, withDefaultPort(b, "9092"))
+			}
+		}
+		return newKafkaPublisher(brokers, tlsConf, user, pass), nil
+	case "nats":
+		if strings.Contains(hosts, ",") {
+			return nil, errors.New("export URL: NATS takes a single server")
+		}
+		return newNATSPublisher(withDefaultPort(hosts, "4222"), tlsConf, user, pass), nil
+	default:
+		return nil, fmt.Errorf("unsupported export URL scheme %q (want kafka, kafka+tls, nats or nats+tls)", rawScheme)
+	}
+}
+
+func clientTLS(caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return cfg, nil
+	}
+	pemBytes, err := os.ReadFile(caFile) // #nosec G304 -- operator-supplied TLS material path
+	if err != nil {
+		return nil, fmt.Errorf("read export CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("export CA %q holds no PEM certificates", caFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+func withDefaultPort(host, port string) string {
+	if strings.LastIndex(host, ":") > strings.LastIndex(host, "]") {
+		return host
+	}
+	return host + ":" + port
+}
+
+// Options configures an Exporter. An empty topic leaves that signal
+// unexported.
+type Options struct {
+	TracesTopic  string
+	LogsTopic    string
+	MetricsTopic string
+	// BatchSize caps the messages per Publish; default 500.
+	BatchSize int
+	// FlushInterval bounds how long a record waits for a full batch;
+	// default 1s.
+	FlushInterval time.Duration
+	// QueueSize is the records buffered ahead of the publisher; default
+	// 10,000. Records arriving while it is full are dropped.
+	QueueSize int
+	Metrics   *telemetry.Metrics
+}
+
+type record struct {
+	signal string
+	tenant string
+	value  any
+}
+
+type batchKey struct{ signal, tenant string }
+
+// Exporter queues spans, logs and metric points and publishes them as
+// JSON, batched per signal and tenant with the tenant as the message key.
+// Delivery is best effort: the ingest path never waits on it, and a
+// failed batch is logged, counted and dropped.
+type Exporter struct {
+	pub   Publisher
+	opts  Options
+	queue chan record
+
+	started atomic.Bool
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New constructs an Exporter but does not start it.
+func New(pub Publisher, opts Options) *Exporter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	return &Exporter{pub: pub, opts: opts, queue: make(chan record, opts.QueueSize), done: make(chan struct{})}
+}
+
+// Start launches the publishing worker.
+func (e *Exporter) Start(parent context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.started.Load() {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	e.cancel = cancel
+	go e.loop(ctx)
+	e.started.Store(true)
+}
+
+// Stop publishes what is still queued, waits for the worker and closes
+// the publisher. Records queued after Stop are never published. Safe to call
+// repeatedly, without Start, or on a nil Exporter.
+func (e *Exporter) Stop() {
+	if e == nil || !e.started.Load() {
+		return
+	}
+	e.mu.Lock()
+	cancel, done := e.cancel, e.done
+	e.mu.Unlock()
+	cancel()
+	<-done
+}
+
+// Span queues a span for TracesTopic.
+func (e *Exporter) Span(s storage.Span) {
+	if e == nil || e.opts.TracesTopic == "" {
+		return
+	}
+	e.enqueue(record{signal: SignalTraces, tenant: s.TenantID, value: s})
+}
+
+// Log queues a log for LogsTopic.
+func (e *Exporter) Log(l storage.Log) {
+	if e == nil || e.opts.LogsTopic == "" {
+		return
+	}
+	e.enqueue(record{signal: SignalLogs, tenant: l.TenantID, value: l})
+}
+
+// Metric queues a raw metric point for MetricsTopic.
+func (e *Exporter) Metric(m tsdb.RawMetric) {
+	if e == nil || e.opts.MetricsTopic == "" {
+		return
+	}
+	e.enqueue(record{signal: SignalMetrics, tenant: m.TenantID, value: m})
+}
+
+func (e *Exporter) enqueue(r record) {
+	if r.tenant == "" {
+		r.tenant = storage.DefaultTenantID
+	}
+	select {
+	case e.queue <- r:
+	default:
+		e.opts.Metrics.RecordExport(r.signal, "dropped", 1)
+	}
+}
+
+func (e *Exporter) loop(ctx context.Context) {
+	defer close(e.done)
+	defer func() {
+		if err := e.pub.Close(); err != nil {
+			slog.Warn("export: closing publisher", "error", err)
+		}
+	}()
+	tick := time.NewTicker(e.opts.FlushInterval)
+	defer tick.Stop()
+	pending := map[batchKey][][]byte{}
+	for {
+		select {
+		case r := <-e.queue:
+			e.add(pending, r)
+		case <-tick.C:
+			e.flush(pending)
+		case <-ctx.Done():
+			// Drain what was queued before Stop, then flush once more.
+			for {
+				select {
+				case r := <-e.queue:
+					e.add(pending, r)
+				default:
+					e.flush(pending)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *Exporter) add(pending map[batchKey][][]byte, r record) {
+	v, err := encode(r)
+	if err != nil {
+		slog.Warn("export: encoding record", "signal", r.signal, "error", err)
+		e.opts.Metrics.RecordExport(r.signal, "error", 1)
+		return
+	}
+	k := batchKey{r.signal, r.tenant}
+	pending[k] = append(pending[k], v)
+	if len(pending[k]) >= e.opts.BatchSize {
+		e.publish(k, pending[k])
+		delete(pending, k)
+	}
+}
+
+func (e *Exporter) flush(pending map[batchKey][][]byte) {
+	for k, values := range pending {
+		e.publish(k, values)
+		delete(pending, k)
+	}
+}
+
+func (e *Exporter) publish(k batchKey, values [][]byte) {
+	topic := e.topic(k.signal)
+	// Not the loop context: the final flush runs after it is cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	if err := e.pub.Publish(ctx, topic, []byte(k.tenant), values); err != nil {
+		slog.Warn("export: publish failed", "topic", topic, "tenant", k.tenant, "records", len(values), "error", err)
+		e.opts.Metrics.RecordExport(k.signal, "error", len(values))
+		return
+	}
+	e.opts.Metrics.RecordExport(k.signal, "ok", len(values))
+}
+
+func (e *Exporter) topic(signal string) string {
+	switch signal {
+	case SignalTraces:
+		return e.opts.TracesTopic
+	case SignalLogs:
+		return e.opts.LogsTopic
+	default:
+		return e.opts.MetricsTopic
+	}
+}
+
+// metricMessage is the wire form of a tsdb.RawMetric, which has no JSON
+// tags of its own.
+type metricMessage struct {
+	TenantID        string         `json:"tenant_id"`
+	Name            string         `json:"name"`
+	ServiceName     string         `json:"service_name"`
+	Value           float64        `json:"value"`
+	Timestamp       time.Time      `json:"timestamp"`
+	Attributes      map[string]any `json:"attributes,omitempty"`
+	ExemplarTraceID string         `json:"exemplar_trace_id,omitempty"`
+	ExemplarSpanID  string         `json:"exemplar_span_id,omitempty"`
+	ExemplarValue   *float64       `json:"exemplar_value,omitempty"`
+}
+
+func encode(r record) ([]byte, error) {
+	m, ok := r.value.(tsdb.RawMetric)
+	if !ok {
+		return json.Marshal(r.value)
+	}
+	msg := metricMessage{
+		TenantID:    r.tenant,
+		Name:        m.Name,
+		ServiceName: m.ServiceName,
+		Value:       m.Value,
+		Timestamp:   m.Timestamp,
+		Attributes:  m.Attributes,
+	}
+	if ex := m.Exemplar; ex != nil {
+		msg.ExemplarTraceID = ex.TraceID
+		msg.ExemplarSpanID = ex.SpanID
+		msg.ExemplarValue = &ex.Value
+	}
+	return json.Marshal(msg)
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+)
+
+type published struct {
+	topic  string
+	key    string
+	values [][]byte
+}
+
+type fakePublisher struct {
+	mu     sync.Mutex
+	got    []published
+	fail   bool
+	closed bool
+}
+
+func (f *fakePublisher) Publish(_ context.Context, topic string, key []byte, values [][]byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("broker down")
+	}
+	f.got = append(f.got, published{topic, string(key), values})
+	return nil
+}
+
+func (f *fakePublisher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func TestExporter_BatchesPerSignalAndTenant(t *testing.T) {
+	pub := &fakePublisher{}
+	e := New(pub, Options{TracesTopic: "spans", LogsTopic: "logs", MetricsTopic: "metrics", BatchSize: 2, FlushInterval: time.Hour})
+	e.Start(context.Background())
+
+	e.Log(storage.Log{TenantID: "acme", Body: "a"})
+	e.Log(storage.Log{TenantID: "acme", Body: "b"}) // fills a batch
+	e.Log(storage.Log{TenantID: "other", Body: "c"})
+	e.Span(storage.Span{SpanID: "s1"})
+	e.Metric(tsdb.RawMetric{TenantID: "acme", Name: "cpu", Value: 0.5, Exemplar: &tsdb.Exemplar{TraceID: "t1", SpanID: "s1", Value: 0.9}})
+	e.Stop()
+	e.Stop()
+
+	if !pub.closed {
+		t.Error("publisher not closed on Stop")
+	}
+	byTopicKey := map[string]int{}
+	var metric map[string]any
+	for _, p := range pub.got {
+		byTopicKey[p.topic+"/"+p.key] += len(p.values)
+		if p.topic == "metrics" {
+			_ = json.Unmarshal(p.values[0], &metric)
+		}
+	}
+	want := map[string]int{"logs/acme": 2, "logs/other": 1, "spans/default": 1, "metrics/acme": 1}
+	for k, n := range want {
+		if byTopicKey[k] != n {
+			t.Errorf("%s: %d records, want %d (all: %v)", k, byTopicKey[k], n, byTopicKey)
+		}
+	}
+	if metric["name"] != "cpu" || metric["tenant_id"] != "acme" || metric["exemplar_trace_id"] != "t1" {
+		t.Errorf("metric message = %v", metric)
+	}
+	if pub.got[0].topic != "logs" || len(pub.got[0].values) != 2 {
+		t.Errorf("first publish = %s with %d values, want the full logs batch", pub.got[0].topic, len(pub.got[0].values))
+	}
+	var l storage.Log
+	if err := json.Unmarshal(pub.got[0].values[0], &l); err != nil || l.Body != "a" {
+		t.Errorf("log message = %s, %v", pub.got[0].values[0], err)
+	}
+}
+
+func TestExporter_DropsWhenQueueFullAndSkipsUnsetTopics(t *testing.T) {
+	pub := &fakePublisher{}
+	e := New(pub, Options{LogsTopic: "logs", QueueSize: 2})
+	// Not started: the queue fills and further records are dropped.
+	for range 5 {
+		e.Log(storage.Log{Body: "x"})
+	}
+	e.Span(storage.Span{SpanID: "s1"}) // no traces topic
+	if n := len(e.queue); n != 2 {
+		t.Errorf("queued = %d, want 2", n)
+	}
+	var nilExporter *Exporter
+	nilExporter.Log(storage.Log{})
+	nilExporter.Stop()
+}
+
+func TestExporter_FailedPublishIsDropped(t *testing.T) {
+	pub := &fakePublisher{fail: true}
+	e := New(pub, Options{LogsTopic: "logs"})
+	e.Start(context.Background())
+	e.Log(storage.Log{Body: "x"})
+	e.Stop()
+	if len(pub.got) != 0 || !pub.closed {
+		t.Errorf("got %v, closed %v", pub.got, pub.closed)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	cases := []struct {
+		url  string
+		ok   bool
+		kind string
+	}{
+		{"kafka://b1:9092,b2", true, "kafka"},
+		{"kafka+tls://user:secret@b1:9093", true, "kafka"},
+		{"nats://token@localhost", true, "nats"},
+		{"nats+tls://n1:4222", true, "nats"},
+		{"nats://a:4222,b:4222", false, ""},
+		{"amqp://rabbit", false, ""},
+		{"kafka://", false, ""},
+	}
+	for _, c := range cases {
+		pub, err := Open(SinkConfig{URL: c.url})
+		if (err == nil) != c.ok {
+			t.Errorf("Open(%q) error = %v, want ok=%v", c.url, err, c.ok)
+			continue
+		}
+		switch p := pub.(type) {
+		case *kafkaPublisher:
+			if c.kind != "kafka" {
+				t.Errorf("Open(%q) = kafka", c.url)
+			}
+			if c.url == "kafka://b1:9092,b2" && (len(p.bootstrap) != 2 || p.bootstrap[1] != "b2:9092") {
+				t.Errorf("bootstrap = %v", p.bootstrap)
+			}
+		case *natsPublisher:
+			if c.kind != "nats" {
+				t.Errorf("Open(%q) = nats", c.url)
+			}
+			if c.url == "nats://token@localhost" && (p.addr != "localhost:4222" || p.user != "token" || p.tls != nil) {
+				t.Errorf("nats publisher = %+v", p)
+			}
+		}
+	}
+	if _, err := Open(SinkConfig{URL: "kafka://b1", TLSCAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("missing CA file accepted")
+	}
+}
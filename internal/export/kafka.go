@@ -0,0 +1,472 @@
+package export
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Kafka API keys and the versions spoken here. Produce v3 with record
+// batch v2 is understood by every broker since 0.11, including 4.x.
+const (
+	kafkaAPIProduce          = 0
+	kafkaAPIMetadata         = 3
+	kafkaAPISaslHandshake    = 17
+	kafkaAPISaslAuthenticate = 36
+
+	kafkaClientID     = "argus"
+	kafkaAcksAll      = -1
+	kafkaProduceWait  = 10 * time.Second
+	kafkaMaxResponse  = 64 << 20
+	kafkaDialTimeout  = 10 * time.Second
+	kafkaErrNone      = 0
+	kafkaRecordMagic  = 2
+	kafkaNoProducerID = -1
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaPublisher produces to the partition leaders found through Metadata
+// requests to the bootstrap brokers. It is not safe for concurrent use;
+// the Exporter publishes from one goroutine.
+type kafkaPublisher struct {
+	bootstrap []string
+	tls       *tls.Config // nil = plaintext
+	user      string      // SASL/PLAIN when non-empty
+	pass      string
+
+	mu      sync.Mutex
+	conns   map[string]*kafkaConn // by broker address
+	brokers map[int32]string      // node id -> address
+	leaders map[string][]int32    // topic -> leader node per partition
+}
+
+func newKafkaPublisher(bootstrap []string, tlsConf *tls.Config, user, pass string) *kafkaPublisher {
+	return &kafkaPublisher{
+		bootstrap: bootstrap,
+		tls:       tlsConf,
+		user:      user,
+		pass:      pass,
+		conns:     map[string]*kafkaConn{},
+	}
+}
+
+// Publish appends values to one partition of topic, chosen by key, as a
+// single record batch. A failure drops the cached metadata and
+// connection and is retried once, which covers leader changes.
+func (p *kafkaPublisher) Publish(ctx context.Context, topic string, key []byte, values [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	err := p.produce(ctx, topic, key, values)
+	if err != nil && ctx.Err() == nil {
+		p.reset()
+		err = p.produce(ctx, topic, key, values)
+	}
+	return err
+}
+
+func (p *kafkaPublisher) produce(ctx context.Context, topic string, key []byte, values [][]byte) error {
+	leaders, err := p.partitions(ctx, topic)
+	if err != nil {
+		return err
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	partition := int32(h.Sum32() % uint32(len(leaders))) // #nosec G115 -- bounded by the partition count
+	addr, ok := p.brokers[leaders[partition]]
+	if !ok {
+		return fmt.Errorf("kafka: no leader for %s/%d", topic, partition)
+	}
+	conn, err := p.conn(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	var b kafkaWriter
+	b.i16(-1) // transactional_id: null
+	b.i16(kafkaAcksAll)
+	b.i32(int32(kafkaProduceWait / time.Millisecond))
+	b.i32(1)
+	b.str(topic)
+	b.i32(1)
+	b.i32(partition)
+	b.bytes(recordBatch(key, values, time.Now()))
+	resp, err := conn.request(ctx, kafkaAPIProduce, 3, b.buf)
+	if err != nil {
+		return err
+	}
+
+	r := kafkaReader{b: resp}
+	for range r.arrayLen() {
+		r.str()
+		for range r.arrayLen() {
+			r.i32()
+			code := r.i16()
+			r.i64()
+			r.i64()
+			if r.err == nil && code != kafkaErrNone {
+				return fmt.Errorf("kafka: produce to %s/%d: error code %d", topic, partition, code)
+			}
+		}
+	}
+	return r.err
+}
+
+// partitions returns topic's partition leaders, fetching metadata when
+// they are not cached.
+func (p *kafkaPublisher) partitions(ctx context.Context, topic string) ([]int32, error) {
+	if l := p.leaders[topic]; len(l) > 0 {
+		return l, nil
+	}
+	var lastErr error
+	for _, addr := range p.bootstrap {
+		conn, err := p.conn(ctx, addr)
+		if err == nil {
+			err = p.metadata(ctx, conn, topic)
+		}
+		if err == nil {
+			if l := p.leaders[topic]; len(l) > 0 {
+				return l, nil
+			}
+			err = fmt.Errorf("kafka: topic %q has no partitions with a leader", topic)
+		}
+		lastErr = err
+		p.dropConn(addr)
+	}
+	return nil, lastErr
+}
+
+func (p *kafkaPublisher) metadata(ctx context.Context, conn *kafkaConn, topic string) error {
+	var b kafkaWriter
+	b.i32(1)
+	b.str(topic)
+	resp, err := conn.request(ctx, kafkaAPIMetadata, 1, b.buf)
+	if err != nil {
+		return err
+	}
+	r := kafkaReader{b: resp}
+	brokers := map[int32]string{}
+	for range r.arrayLen() {
+		id := r.i32()
+		host := r.str()
+		port := r.i32()
+		r.nullableStr() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.i32() // controller
+	leaders := map[string][]int32{}
+	for range r.arrayLen() {
+		code := r.i16()
+		name := r.str()
+		r.i8() // is_internal
+		n := r.arrayLen()
+		parts := make([]int32, n)
+		for i := range n {
+			r.i16()
+			idx := r.i32()
+			leader := r.i32()
+			for range r.arrayLen() {
+				r.i32()
+			}
+			for range r.arrayLen() {
+				r.i32()
+			}
+			if idx >= 0 && int(idx) < n {
+				parts[idx] = leader
+			} else {
+				parts[i] = -1
+			}
+		}
+		if r.err == nil && code != kafkaErrNone {
+			return fmt.Errorf("kafka: metadata for %s: error code %d", name, code)
+		}
+		leaders[name] = parts
+	}
+	if r.err != nil {
+		return r.err
+	}
+	for _, l := range leaders[topic] {
+		if l < 0 {
+			return fmt.Errorf("kafka: topic %q has a partition without a leader", topic)
+		}
+	}
+	p.brokers = brokers
+	if p.leaders == nil {
+		p.leaders = map[string][]int32{}
+	}
+	p.leaders[topic] = leaders[topic]
+	return nil
+}
+
+func (p *kafkaPublisher) conn(ctx context.Context, addr string) (*kafkaConn, error) {
+	if c := p.conns[addr]; c != nil {
+		return c, nil
+	}
+	c, err := dialKafka(ctx, addr, p.tls)
+	if err != nil {
+		return nil, err
+	}
+	if p.user != "" {
+		if err := c.saslPlain(ctx, p.user, p.pass); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	p.conns[addr] = c
+	return c, nil
+}
+
+func (p *kafkaPublisher) dropConn(addr string) {
+	if c := p.conns[addr]; c != nil {
+		_ = c.Close()
+		delete(p.conns, addr)
+	}
+}
+
+func (p *kafkaPublisher) reset() {
+	for addr := range p.conns {
+		p.dropConn(addr)
+	}
+	p.leaders = nil
+}
+
+// Close closes every broker connection.
+func (p *kafkaPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
+
+// kafkaConn is one broker connection. Requests are sent one at a time.
+type kafkaConn struct {
+	net.Conn
+	corr int32
+}
+
+func dialKafka(ctx context.Context, addr string, tlsConf *tls.Config) (*kafkaConn, error) {
+	d := &net.Dialer{Timeout: kafkaDialTimeout}
+	var conn net.Conn
+	var err error
+	if tlsConf != nil {
+		td := &tls.Dialer{NetDialer: d, Config: tlsConf}
+		conn, err = td.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kafka: dial %s: %w", addr, err)
+	}
+	return &kafkaConn{Conn: conn}, nil
+}
+
+// request sends one request (header v1) and returns the response body
+// after its correlation id.
+func (c *kafkaConn) request(ctx context.Context, apiKey, version int16, body []byte) ([]byte, error) {
+	deadline := time.Now().Add(kafkaProduceWait + 5*time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.SetDeadline(deadline)
+
+	c.corr++
+	var h kafkaWriter
+	h.i32(0) // size, patched below
+	h.i16(apiKey)
+	h.i16(version)
+	h.i32(c.corr)
+	h.str(kafkaClientID)
+	h.buf = append(h.buf, body...)
+	binary.BigEndian.PutUint32(h.buf, uint32(len(h.buf)-4)) // #nosec G115 -- request sizes are far below 2^31
+	if _, err := c.Write(h.buf); err != nil {
+		return nil, fmt.Errorf("kafka: write: %w", err)
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c, size[:]); err != nil {
+		return nil, fmt.Errorf("kafka: read: %w", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < 4 || n > kafkaMaxResponse {
+		return nil, fmt.Errorf("kafka: bad response size %d", n)
+	}
+	resp := make([]byte, n)
+	if _, err := io.ReadFull(c, resp); err != nil {
+		return nil, fmt.Errorf("kafka: read: %w", err)
+	}
+	if corr := int32(binary.BigEndian.Uint32(resp)); corr != c.corr { // #nosec G115 -- wire format
+		return nil, fmt.Errorf("kafka: correlation id %d, want %d", corr, c.corr)
+	}
+	return resp[4:], nil
+}
+
+func (c *kafkaConn) saslPlain(ctx context.Context, user, pass string) error {
+	var b kafkaWriter
+	b.str("PLAIN")
+	resp, err := c.request(ctx, kafkaAPISaslHandshake, 1, b.buf)
+	if err != nil {
+		return err
+	}
+	r := kafkaReader{b: resp}
+	if code := r.i16(); r.err == nil && code != kafkaErrNone {
+		return fmt.Errorf("kafka: broker refused SASL/PLAIN (error code %d)", code)
+	}
+
+	b = kafkaWriter{}
+	b.bytes([]byte("\x00" + user + "\x00" + pass))
+	if resp, err = c.request(ctx, kafkaAPISaslAuthenticate, 0, b.buf); err != nil {
+		return err
+	}
+	r = kafkaReader{b: resp}
+	code := r.i16()
+	msg := r.nullableStr()
+	if r.err != nil {
+		return r.err
+	}
+	if code != kafkaErrNone {
+		return fmt.Errorf("kafka: SASL authentication failed: %s (error code %d)", msg, code)
+	}
+	return nil
+}
+
+// recordBatch encodes values as one uncompressed v2 record batch, every
+// record carrying key.
+func recordBatch(key []byte, values [][]byte, now time.Time) []byte {
+	ts := now.UnixMilli()
+	var recs kafkaWriter
+	for i, v := range values {
+		var rec kafkaWriter
+		rec.i8(0)     // attributes
+		rec.varint(0) // timestamp delta
+		rec.varint(int64(i))
+		rec.varint(int64(len(key)))
+		rec.buf = append(rec.buf, key...)
+		rec.varint(int64(len(v)))
+		rec.buf = append(rec.buf, v...)
+		rec.varint(0) // headers
+		recs.varint(int64(len(rec.buf)))
+		recs.buf = append(recs.buf, rec.buf...)
+	}
+
+	// The CRC covers attributes through the records.
+	var tail kafkaWriter
+	tail.i16(0)                      // attributes: no compression
+	tail.i32(int32(len(values) - 1)) // #nosec G115 -- batch sizes are small
+	tail.i64(ts)
+	tail.i64(ts)
+	tail.i64(kafkaNoProducerID)
+	tail.i16(-1)                 // producer epoch
+	tail.i32(-1)                 // base sequence
+	tail.i32(int32(len(values))) // #nosec G115 -- batch sizes are small
+	tail.buf = append(tail.buf, recs.buf...)
+
+	var b kafkaWriter
+	b.i64(0)                                // base offset
+	b.i32(int32(4 + 1 + 4 + len(tail.buf))) // #nosec G115 -- batch length from the leader epoch on
+	b.i32(-1)                               // partition leader epoch
+	b.i8(kafkaRecordMagic)
+	b.i32(int32(crc32.Checksum(tail.buf, crc32c))) // #nosec G115 -- CRC bits
+	b.buf = append(b.buf, tail.buf...)
+	return b.buf
+}
+
+// kafkaWriter appends big-endian Kafka primitives.
+type kafkaWriter struct{ buf []byte }
+
+func (w *kafkaWriter) i8(v int8)      { w.buf = append(w.buf, byte(v)) }
+func (w *kafkaWriter) i16(v int16)    { w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(v)) } // #nosec G115 -- two's complement on the wire
+func (w *kafkaWriter) i32(v int32)    { w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v)) } // #nosec G115 -- two's complement on the wire
+func (w *kafkaWriter) i64(v int64)    { w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v)) } // #nosec G115 -- two's complement on the wire
+func (w *kafkaWriter) varint(v int64) { w.buf = binary.AppendVarint(w.buf, v) }
+
+func (w *kafkaWriter) str(s string) {
+	w.i16(int16(len(s))) // #nosec G115 -- topic names and mechanisms are short
+	w.buf = append(w.buf, s...)
+}
+
+func (w *kafkaWriter) bytes(b []byte) {
+	w.i32(int32(len(b))) // #nosec G115 -- batches are far below 2^31
+	w.buf = append(w.buf, b...)
+}
+
+// kafkaReader decodes big-endian Kafka primitives. The first short read
+// sets err and every later read returns zero.
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+var errKafkaShort = errors.New("kafka: truncated response")
+
+func (r *kafkaReader) take(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = errKafkaShort
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *kafkaReader) i8() int8 {
+	if b := r.take(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (r *kafkaReader) i16() int16 {
+	if b := r.take(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b)) // #nosec G115 -- two's complement on the wire
+	}
+	return 0
+}
+
+func (r *kafkaReader) i32() int32 {
+	if b := r.take(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b)) // #nosec G115 -- two's complement on the wire
+	}
+	return 0
+}
+
+func (r *kafkaReader) i64() int64 {
+	if b := r.take(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b)) // #nosec G115 -- two's complement on the wire
+	}
+	return 0
+}
+
+func (r *kafkaReader) str() string {
+	return string(r.take(int(r.i16())))
+}
+
+func (r *kafkaReader) nullableStr() string {
+	n := r.i16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.take(int(n)))
+}
+
+// arrayLen reads an array length, treating null as empty and refusing
+// lengths the remaining bytes cannot hold.
+func (r *kafkaReader) arrayLen() int {
+	n := int(r.i32())
+	if n < 0 || r.err != nil {
+		return 0
+	}
+	if n > len(r.b) {
+		r.err = errKafkaShort
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,255 @@
+package export
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker answers Metadata v1, SaslHandshake, SaslAuthenticate and
+// Produce v3 for one single-partition topic it leads itself, and records
+// the values of every produced batch.
+type fakeBroker struct {
+	t        *testing.T
+	ln       net.Listener
+	topic    string
+	user     string // SASL/PLAIN credentials required when non-empty
+	pass     string
+	failOnce bool // answer the first Produce with NOT_LEADER_OR_FOLLOWER
+
+	mu       sync.Mutex
+	keys     []string
+	values   []string
+	produces int
+}
+
+func newFakeBroker(t *testing.T, topic, user, pass string) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{t: t, ln: ln, topic: topic, user: user, pass: pass}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(c)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(c net.Conn) {
+	defer c.Close()
+	authed := b.user == ""
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(c, req); err != nil {
+			return
+		}
+		r := kafkaReader{b: req}
+		api, _, corr := r.i16(), r.i16(), r.i32()
+		r.str() // client id
+
+		var w kafkaWriter
+		w.i32(corr)
+		switch api {
+		case kafkaAPIMetadata:
+			if !authed {
+				return
+			}
+			host, portStr, _ := net.SplitHostPort(b.ln.Addr().String())
+			port, _ := strconv.Atoi(portStr)
+			w.i32(1) // brokers
+			w.i32(7)
+			w.str(host)
+			w.i32(int32(port))
+			w.i16(-1) // rack
+			w.i32(7)  // controller
+			w.i32(1)  // topics
+			w.i16(0)
+			w.str(b.topic)
+			w.i8(0)
+			w.i32(1) // partitions
+			w.i16(0)
+			w.i32(0)
+			w.i32(7)
+			w.i32(0) // replicas
+			w.i32(0) // isr
+		case kafkaAPISaslHandshake:
+			w.i16(0)
+			w.i32(1)
+			w.str("PLAIN")
+		case kafkaAPISaslAuthenticate:
+			token := r.take(int(r.i32()))
+			if string(token) == "\x00"+b.user+"\x00"+b.pass {
+				authed = true
+				w.i16(0)
+				w.i16(-1)
+			} else {
+				w.i16(58) // SASL_AUTHENTICATION_FAILED
+				w.str("bad credentials")
+			}
+			w.i32(0) // auth bytes
+		case kafkaAPIProduce:
+			if !authed {
+				return
+			}
+			code := b.produce(&r)
+			w.i32(1)
+			w.str(b.topic)
+			w.i32(1)
+			w.i32(0)
+			w.i16(code)
+			w.i64(0)
+			w.i64(-1)
+			w.i32(0) // throttle
+		default:
+			b.t.Errorf("unexpected api key %d", api)
+			return
+		}
+		out := binary.BigEndian.AppendUint32(nil, uint32(len(w.buf)))
+		if _, err := c.Write(append(out, w.buf...)); err != nil {
+			return
+		}
+	}
+}
+
+// produce decodes one Produce v3 request and returns its error code.
+func (b *fakeBroker) produce(r *kafkaReader) int16 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.produces++
+	if b.failOnce {
+		b.failOnce = false
+		return 6 // NOT_LEADER_OR_FOLLOWER
+	}
+	r.i16() // transactional id
+	if acks := r.i16(); acks != -1 {
+		b.t.Errorf("acks = %d, want -1", acks)
+	}
+	r.i32()
+	r.arrayLen()
+	if topic := r.str(); topic != b.topic {
+		b.t.Errorf("topic = %q", topic)
+	}
+	r.arrayLen()
+	r.i32()
+	batch := kafkaReader{b: r.take(int(r.i32()))}
+	batch.i64()
+	length := int(batch.i32())
+	if length != len(batch.b) {
+		b.t.Errorf("batch length %d, %d bytes follow", length, len(batch.b))
+	}
+	batch.i32()
+	if magic := batch.i8(); magic != 2 {
+		b.t.Errorf("magic = %d", magic)
+	}
+	crc := uint32(batch.i32())
+	if got := crc32.Checksum(batch.b, crc32.MakeTable(crc32.Castagnoli)); got != crc {
+		b.t.Errorf("crc = %x, computed %x", crc, got)
+	}
+	batch.i16()
+	batch.i32()
+	batch.i64()
+	batch.i64()
+	batch.i64()
+	batch.i16()
+	batch.i32()
+	n := int(batch.i32())
+	for range n {
+		recLen, k := binary.Varint(batch.b)
+		rec := batch.b[k : k+int(recLen)]
+		batch.b = batch.b[k+int(recLen):]
+		rec = rec[1:] // attributes
+		for range 2 {
+			_, k = binary.Varint(rec)
+			rec = rec[k:]
+		}
+		keyLen, k := binary.Varint(rec)
+		b.keys = append(b.keys, string(rec[k:k+int(keyLen)]))
+		rec = rec[k+int(keyLen):]
+		valLen, k := binary.Varint(rec)
+		b.values = append(b.values, string(rec[k:k+int(valLen)]))
+	}
+	if batch.err != nil {
+		b.t.Errorf("batch: %v", batch.err)
+	}
+	return 0
+}
+
+func TestKafkaPublisher_Produce(t *testing.T) {
+	b := newFakeBroker(t, "argus.logs", "", "")
+	p := newKafkaPublisher([]string{b.ln.Addr().String()}, nil, "", "")
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, "argus.logs", []byte("acme"), [][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := p.Publish(ctx, "argus.logs", []byte("acme"), [][]byte{[]byte(`{"c":3}`)}); err != nil {
+		t.Fatalf("second Publish: %v", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.values) != 3 || b.values[0] != `{"a":1}` || b.values[2] != `{"c":3}` || b.keys[1] != "acme" {
+		t.Errorf("broker got keys %q values %q", b.keys, b.values)
+	}
+}
+
+func TestKafkaPublisher_RetriesAfterProduceError(t *testing.T) {
+	b := newFakeBroker(t, "argus.spans", "", "")
+	b.mu.Lock()
+	b.failOnce = true
+	b.mu.Unlock()
+	p := newKafkaPublisher([]string{b.ln.Addr().String()}, nil, "", "")
+	defer p.Close()
+	if err := p.Publish(context.Background(), "argus.spans", []byte("acme"), [][]byte{[]byte("x")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.produces != 2 || len(b.values) != 1 {
+		t.Errorf("produces = %d, values = %q", b.produces, b.values)
+	}
+}
+
+func TestKafkaPublisher_SASLPlain(t *testing.T) {
+	b := newFakeBroker(t, "argus.logs", "svc", "s3cret")
+
+	bad := newKafkaPublisher([]string{b.ln.Addr().String()}, nil, "svc", "wrong")
+	defer bad.Close()
+	if err := bad.Publish(context.Background(), "argus.logs", nil, [][]byte{[]byte("x")}); err == nil {
+		t.Error("wrong password accepted")
+	}
+
+	good := newKafkaPublisher([]string{b.ln.Addr().String()}, nil, "svc", "s3cret")
+	defer good.Close()
+	if err := good.Publish(context.Background(), "argus.logs", nil, [][]byte{[]byte("x")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func TestKafkaPublisher_UnknownTopic(t *testing.T) {
+	b := newFakeBroker(t, "argus.logs", "", "")
+	p := newKafkaPublisher([]string{b.ln.Addr().String()}, nil, "", "")
+	defer p.Close()
+	if err := p.Publish(context.Background(), "missing", nil, [][]byte{[]byte("x")}); err == nil {
+		t.Error("publish to a topic absent from metadata succeeded")
+	}
+}
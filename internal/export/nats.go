@@ -0,0 +1,215 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	natsDialTimeout = 10 * time.Second
+	natsMaxLine     = 64 << 10
+)
+
+// natsServerInfo is the part of the server's INFO line used here.
+type natsServerInfo struct {
+	TLSRequired bool  `json:"tls_required"`
+	MaxPayload  int64 `json:"max_payload"`
+}
+
+// natsPublisher speaks the NATS text protocol to one server. Each batch is
+// followed by a PING and acknowledged by the matching PONG, so a returned
+// nil means the server accepted every message.
+type natsPublisher struct {
+	addr string
+	tls  *tls.Config // non-nil forces TLS; otherwise used only if the server requires it
+	user string
+	pass string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+	info natsServerInfo
+}
+
+func newNATSPublisher(addr string, tlsConf *tls.Config, user, pass string) *natsPublisher {
+	return &natsPublisher{addr: addr, tls: tlsConf, user: user, pass: pass}
+}
+
+// Publish sends values to the subject topic. NATS has no message keys;
+// key is ignored, and the tenant travels inside each message.
+func (p *natsPublisher) Publish(ctx context.Context, topic string, _ []byte, values [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	err := p.publish(ctx, topic, values)
+	if err != nil {
+		// Redial on the next batch.
+		p.closeConn()
+	}
+	return err
+}
+
+func (p *natsPublisher) publish(ctx context.Context, topic string, values [][]byte) error {
+	p.setDeadline(ctx)
+	w := bufio.NewWriter(p.conn)
+	for _, v := range values {
+		if p.info.MaxPayload > 0 && int64(len(v)) > p.info.MaxPayload {
+			return fmt.Errorf("nats: message of %d bytes exceeds max_payload %d", len(v), p.info.MaxPayload)
+		}
+		fmt.Fprintf(w, "PUB %s %d\r\n", topic, len(v))
+		_, _ = w.Write(v)
+		_, _ = w.WriteString("\r\n")
+	}
+	_, _ = w.WriteString("PING\r\n")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("nats: write: %w", err)
+	}
+	return p.awaitPong()
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	d := &net.Dialer{Timeout: natsDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("nats: dial %s: %w", p.addr, err)
+	}
+	p.conn, p.r = conn, bufio.NewReader(conn)
+	p.setDeadline(ctx)
+
+	line, err := p.readLine()
+	if err != nil {
+		p.closeConn()
+		return err
+	}
+	payload, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		p.closeConn()
+		return fmt.Errorf("nats: expected INFO, got %q", line)
+	}
+	p.info = natsServerInfo{}
+	if err := json.Unmarshal([]byte(payload), &p.info); err != nil {
+		p.closeConn()
+		return fmt.Errorf("nats: parse INFO: %w", err)
+	}
+
+	useTLS := p.tls != nil || p.info.TLSRequired
+	if useTLS {
+		cfg := p.tls
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		cfg = cfg.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(p.addr)
+		}
+		tc := tls.Client(conn, cfg)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			p.closeConn()
+			return fmt.Errorf("nats: TLS handshake: %w", err)
+		}
+		p.conn, p.r = tc, bufio.NewReader(tc)
+	}
+
+	opts := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "argus",
+		"lang":     "go",
+		"version":  "1",
+		"protocol": 1,
+	}
+	if useTLS {
+		opts["tls_required"] = true
+	}
+	switch {
+	case p.user != "" && p.pass != "":
+		opts["user"], opts["pass"] = p.user, p.pass
+	case p.user != "":
+		opts["auth_token"] = p.user
+	}
+	connect, _ := json.Marshal(opts)
+	if _, err := fmt.Fprintf(p.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		p.closeConn()
+		return fmt.Errorf("nats: write: %w", err)
+	}
+	if err := p.awaitPong(); err != nil {
+		p.closeConn()
+		return err
+	}
+	return nil
+}
+
+// awaitPong reads until the PONG answering our PING, answering server
+// PINGs and surfacing -ERR along the way.
+func (p *natsPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("nats: write: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			msg := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'")
+			return fmt.Errorf("nats: server error: %s", msg)
+		}
+		// +OK and INFO updates need no reply.
+	}
+}
+
+func (p *natsPublisher) readLine() (string, error) {
+	var sb strings.Builder
+	for {
+		chunk, isPrefix, err := p.r.ReadLine()
+		if err != nil {
+			return "", fmt.Errorf("nats: read: %w", err)
+		}
+		sb.Write(chunk)
+		if sb.Len() > natsMaxLine {
+			return "", errors.New("nats: protocol line too long")
+		}
+		if !isPrefix {
+			return sb.String(), nil
+		}
+	}
+}
+
+func (p *natsPublisher) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(publishTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = p.conn.SetDeadline(deadline)
+}
+
+func (p *natsPublisher) closeConn() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+		p.conn, p.r = nil, nil
+	}
+}
+
+// Close closes the server connection.
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closeConn()
+	return nil
+}
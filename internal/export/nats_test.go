@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNATS is a minimal NATS server: it sends INFO, checks CONNECT
+// credentials, answers PINGs and records PUB payloads by subject.
+type fakeNATS struct {
+	ln    net.Listener
+	token string
+
+	mu       sync.Mutex
+	msgs     map[string][]string
+	connects int
+}
+
+func newFakeNATS(t *testing.T, token string) *fakeNATS {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNATS{ln: ln, token: token, msgs: map[string][]string{}}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeNATS) serve(c net.Conn) {
+	defer c.Close()
+	_, _ = io.WriteString(c, `INFO {"server_id":"fake","max_payload":1024}`+"\r\n")
+	r := bufio.NewReader(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, rest, _ := strings.Cut(line, " ")
+		switch verb {
+		case "CONNECT":
+			var opts struct {
+				AuthToken string `json:"auth_token"`
+			}
+			_ = json.Unmarshal([]byte(rest), &opts)
+			if opts.AuthToken != s.token {
+				_, _ = io.WriteString(c, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+			s.mu.Lock()
+			s.connects++
+			s.mu.Unlock()
+		case "PING":
+			// Interleave a server PING to exercise the client's reply.
+			_, _ = io.WriteString(c, "PING\r\nPONG\r\n")
+		case "PONG":
+		case "PUB":
+			fields := strings.Fields(rest)
+			n, _ := strconv.Atoi(fields[len(fields)-1])
+			buf := make([]byte, n+2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.msgs[fields[0]] = append(s.msgs[fields[0]], string(buf[:n]))
+			s.mu.Unlock()
+		default:
+			_, _ = io.WriteString(c, "-ERR 'Unknown Protocol Operation'\r\n")
+			return
+		}
+	}
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	s := newFakeNATS(t, "tok")
+	p := newNATSPublisher(s.ln.Addr().String(), nil, "tok", "")
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Publish(ctx, "argus.logs", []byte("acme"), [][]byte{[]byte(`{"a":1}`), []byte("two\r\nlines")}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := p.Publish(ctx, "argus.spans", nil, [][]byte{[]byte("x")}); err != nil {
+		t.Fatalf("second Publish: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if got := s.msgs["argus.logs"]; len(got) != 2 || got[1] != "two\r\nlines" {
+		t.Errorf("argus.logs = %q", got)
+	}
+	if len(s.msgs["argus.spans"]) != 1 || s.connects != 1 {
+		t.Errorf("spans = %q, connects = %d", s.msgs["argus.spans"], s.connects)
+	}
+}
+
+func TestNATSPublisher_Errors(t *testing.T) {
+	s := newFakeNATS(t, "tok")
+
+	bad := newNATSPublisher(s.ln.Addr().String(), nil, "wrong", "")
+	defer bad.Close()
+	err := bad.Publish(context.Background(), "argus.logs", nil, [][]byte{[]byte("x")})
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("bad token: %v", err)
+	}
+
+	p := newNATSPublisher(s.ln.Addr().String(), nil, "tok", "")
+	defer p.Close()
+	if err := p.Publish(context.Background(), "argus.logs", nil, [][]byte{make([]byte, 2048)}); err == nil {
+		t.Error("message over max_payload accepted")
+	}
+	// The connection was dropped; the next batch redials.
+	if err := p.Publish(context.Background(), "argus.logs", nil, [][]byte{[]byte("ok")}); err != nil {
+		t.Errorf("after redial: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.connects != 2 {
+		t.Errorf("connects = %d, want 2", s.connects)
+	}
+}
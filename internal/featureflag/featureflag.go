@@ -0,0 +1,202 @@
+// Package featureflag is the server-side registry of flags that gate
+// experimental subsystems, so they can ship dark and be turned on per
+// deployment through FEATURE_FLAGS, and — for flags checked on every use —
+// flipped at runtime through the admin API.
+package featureflag
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Built-in flags.
+const (
+	// TailSampling buffers spans per trace and keeps errors, slow traces and
+	// a sample of the rest. Read once at startup.
+	TailSampling = "tail_sampling"
+	// AIInsights feeds ingested logs and spans to the AI service for log
+	// insights and trace root-cause analysis. Needs AI_ENABLED too.
+	AIInsights = "ai_insights"
+)
+
+// Stages of a flag's subsystem.
+const (
+	StageExperimental = "experimental"
+	StageBeta         = "beta"
+)
+
+// Sources of a flag's current value.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config" // a subsystem's own env var, e.g. TAIL_SAMPLING_ENABLED
+	SourceEnv     = "env"    // FEATURE_FLAGS
+	SourceAdmin   = "admin"  // PUT /api/admin/flags/{name}
+)
+
+var (
+	// ErrUnknownFlag is returned for a name the registry does not define.
+	ErrUnknownFlag = errors.New("featureflag: unknown flag")
+	// ErrNotRuntime is returned by SetRuntime for a flag read only at
+	// startup.
+	ErrNotRuntime = errors.New("featureflag: flag can only change at startup")
+)
+
+// Flag defines a feature flag.
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Stage       string `json:"stage"`
+	Default     bool   `json:"default"`
+	// Runtime flags are checked on every use, so the admin API may flip
+	// them while the process runs.
+	Runtime bool `json:"runtime"`
+}
+
+// Builtin returns the flags every registry starts with.
+func Builtin() []Flag {
+	return []Flag{
+		{Name: TailSampling, Stage: StageBeta, Description: "Tail-based trace sampling (TAIL_SAMPLING_* settings)."},
+		{Name: AIInsights, Stage: StageExperimental, Default: true, Runtime: true, Description: "LLM log insights and trace root-cause analysis; needs AI_ENABLED."},
+	}
+}
+
+// State is a flag with its current value.
+type State struct {
+	Flag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// Registry holds the current value of every flag. A nil *Registry reports
+// every flag at its built-in default.
+type Registry struct {
+	mu    sync.RWMutex
+	flags map[string]*State
+}
+
+// New returns a registry of the Builtin flags at their defaults.
+func New() *Registry {
+	r := &Registry{flags: make(map[string]*State)}
+	for _, f := range Builtin() {
+		r.flags[f.Name] = &State{Flag: f, Enabled: f.Default, Source: SourceDefault}
+	}
+	return r
+}
+
+// Enabled reports whether name is on. Unknown flags are off.
+func (r *Registry) Enabled(name string) bool {
+	if r == nil {
+		for _, f := range Builtin() {
+			if f.Name == name {
+				return f.Default
+			}
+		}
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st, ok := r.flags[name]
+	return ok && st.Enabled
+}
+
+// Set changes name at startup, recording where the value came from.
+func (r *Registry) Set(name string, on bool, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.flags[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	}
+	st.Enabled, st.Source = on, source
+	return nil
+}
+
+// SetRuntime changes a Runtime flag on a running process.
+func (r *Registry) SetRuntime(name string, on bool) (State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.flags[name]
+	switch {
+	case !ok:
+		return State{}, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+	case !st.Runtime:
+		return *st, fmt.Errorf("%w: %s", ErrNotRuntime, name)
+	}
+	st.Enabled, st.Source = on, SourceAdmin
+	return *st, nil
+}
+
+// List returns every flag sorted by name.
+func (r *Registry) List() []State {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]State, 0, len(r.flags))
+	for _, st := range r.flags {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Values returns every flag's on/off value by name.
+func (r *Registry) Values() map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]bool, len(r.flags))
+	for name, st := range r.flags {
+		out[name] = st.Enabled
+	}
+	return out
+}
+
+// ParseSpec parses FEATURE_FLAGS: comma-separated name=value pairs, value
+// a boolean ("true", "1", "on" …), or a bare name for true:
+//
+//	tail_sampling,ai_insights=off
+//
+// Names must be Builtin flags.
+func ParseSpec(spec string) (map[string]bool, error) {
+	known := make(map[string]bool)
+	for _, f := range Builtin() {
+		known[f.Name] = true
+	}
+	out := make(map[string]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, fmt.Errorf("%w %q", ErrUnknownFlag, name)
+		}
+		on := true
+		if hasValue {
+			var err error
+			if on, err = parseBool(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("featureflag: %s: %w", name, err)
+			}
+		}
+		out[name] = on
+	}
+	return out, nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "yes", "enabled":
+		return true, nil
+	case "off", "no", "disabled":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q", s)
+	}
+	return b, nil
+}
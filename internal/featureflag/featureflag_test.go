@@ -0,0 +1,47 @@
+package featureflag
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistry_SetAndRuntime(t *testing.T) {
+	r := New()
+	if r.Enabled(TailSampling) || !r.Enabled(AIInsights) || r.Enabled("clickhouse") {
+		t.Fatalf("defaults = %v", r.Values())
+	}
+	if err := r.Set(TailSampling, true, SourceEnv); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := r.Set("clickhouse", true, SourceEnv); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Set unknown = %v", err)
+	}
+	if _, err := r.SetRuntime(TailSampling, false); !errors.Is(err, ErrNotRuntime) {
+		t.Errorf("SetRuntime startup-only flag = %v", err)
+	}
+	st, err := r.SetRuntime(AIInsights, false)
+	if err != nil || st.Enabled || st.Source != SourceAdmin {
+		t.Fatalf("SetRuntime = %+v, %v", st, err)
+	}
+	list := r.List()
+	if len(list) != 2 || list[0].Name != AIInsights || list[1].Source != SourceEnv || !list[1].Enabled {
+		t.Errorf("List = %+v", list)
+	}
+
+	var nilRegistry *Registry
+	if !nilRegistry.Enabled(AIInsights) || nilRegistry.Enabled(TailSampling) {
+		t.Error("nil registry should report defaults")
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	got, err := ParseSpec(" tail_sampling , ai_insights=off,")
+	if err != nil || len(got) != 2 || !got[TailSampling] || got[AIInsights] {
+		t.Fatalf("ParseSpec = %v, %v", got, err)
+	}
+	for _, bad := range []string{"clickhouse", "ai_insights=maybe"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) accepted", bad)
+		}
+	}
+}
@@ -35,8 +35,7 @@ func (g *GraphRAG) detectAnomaliesForTenant(ctx context.Context, tenant string,
 				Evidence:  fmt.Sprintf("error rate %.1f%% (baseline ~%.1f%%)", svc.ErrorRate*100, baselineErrorRate*100),
 				Timestamp: now,
 			}
-			stores.anomalies.AddAnomaly(anomaly)
-			correlateWithRecent(stores, anomaly)
+			g.addAnomaly(tenant, stores, anomaly)
 
 			// Trigger investigation (scoped to this tenant).
 			chains := g.ErrorChain(ctx, svc.Name, now.Add(-5*time.Minute), 5)
@@ -56,8 +55,7 @@ func (g *GraphRAG) detectAnomaliesForTenant(ctx context.Context, tenant string,
 				Evidence:  fmt.Sprintf("avg latency %.0fms", svc.AvgLatency),
 				Timestamp: now,
 			}
-			stores.anomalies.AddAnomaly(anomaly)
-			correlateWithRecent(stores, anomaly)
+			g.addAnomaly(tenant, stores, anomaly)
 		}
 	}
 
@@ -86,13 +84,20 @@ func (g *GraphRAG) detectAnomaliesForTenant(ctx context.Context, tenant string,
 					Evidence:  fmt.Sprintf("metric %s z-score %.1f (avg=%.2f, range=[%.2f, %.2f])", m.MetricName, deviation, m.RollingAvg, m.RollingMin, m.RollingMax),
 					Timestamp: now,
 				}
-				stores.anomalies.AddAnomaly(anomaly)
-				correlateWithRecent(stores, anomaly)
+				g.addAnomaly(tenant, stores, anomaly)
 			}
 		}
 	}
 }
 
+// addAnomaly stores anomaly in the tenant's AnomalyStore, links it to
+// recent neighbours and counts it in otelcontext_graphrag_anomalies_total.
+func (g *GraphRAG) addAnomaly(tenant string, stores *tenantStores, anomaly AnomalyNode) {
+	stores.anomalies.AddAnomaly(anomaly)
+	correlateWithRecent(stores, anomaly)
+	g.metrics.RecordAnomaly(tenant, string(anomaly.Type), string(anomaly.Severity))
+}
+
 // correlateWithRecent links an anomaly to other anomalies within ±30s in the
 // same tenant's AnomalyStore.
 func correlateWithRecent(stores *tenantStores, anomaly AnomalyNode) {
@@ -424,7 +424,7 @@ func (g *GraphRAG) eventWorker(ctx context.Context) {
 
 func (g *GraphRAG) processSpan(ev *spanEvent) {
 	span := ev.Span
-	durationMs := float64(span.Duration) / 1000.0 // microseconds → ms
+	durationMs := float64(span.DurationNanos()) / 1e6 // nanoseconds → ms
 	isError := span.OperationName != "" && ev.Status == "STATUS_CODE_ERROR"
 
 	// Check for error status from the span data
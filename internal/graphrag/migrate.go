@@ -22,6 +22,7 @@ package graphrag
 // operator can apply the equivalent DDL by hand.
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -56,6 +57,19 @@ func AutoMigrateGraphRAG(db *gorm.DB) error {
 	return nil
 }
 
+// MigrateStorage copies the GraphRAG tables from src to dst for
+// -migrate-storage, after storage.MigrateStorage has copied the rest. dst
+// must already be migrated with AutoMigrateGraphRAG.
+func MigrateStorage(ctx context.Context, src, dst *gorm.DB, opts storage.StorageMigrationOptions) error {
+	if err := storage.CopyTable[Investigation](ctx, src, dst, opts); err != nil {
+		return err
+	}
+	if err := storage.CopyTable[GraphSnapshot](ctx, src, dst, opts); err != nil {
+		return err
+	}
+	return storage.CopyTable[DrainTemplateRow](ctx, src, dst, opts)
+}
+
 // backfillTenantIDs sets tenant_id = DefaultTenantID for any row in the three
 // GraphRAG tables that ended up with NULL or empty tenant_id. AutoMigrate
 // already supplies a column default for new inserts; this pass covers the
@@ -2,6 +2,7 @@ package graphrag
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -295,3 +296,45 @@ func TestGraphRAG_GetGraphSnapshot_TenantScoped(t *testing.T) {
 		}
 	}
 }
+
+// TestMigrateStorage_CopiesGraphRAGTables copies all three GraphRAG tables
+// into a fresh database in several batches, then again to check a rerun
+// overwrites instead of failing on the existing keys.
+func TestMigrateStorage_CopiesGraphRAGTables(t *testing.T) {
+	src, dst := newTestGraphRAGDB(t), newTestGraphRAGDB(t)
+	for _, db := range []*gorm.DB{src, dst} {
+		if err := AutoMigrateGraphRAG(db); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+	now := time.Now().UTC()
+	for i, tenant := range []string{"acme", "acme", "globex"} {
+		id := fmt.Sprintf("%s-%d", tenant, i)
+		if err := src.Create(&Investigation{TenantID: tenant, ID: "inv-" + id, CreatedAt: now, Status: "detected"}).Error; err != nil {
+			t.Fatalf("seed investigation: %v", err)
+		}
+		if err := src.Create(&GraphSnapshot{TenantID: tenant, ID: "snap-" + id, CreatedAt: now}).Error; err != nil {
+			t.Fatalf("seed snapshot: %v", err)
+		}
+	}
+	// The same template ID under two tenants: the composite key must survive.
+	for _, tenant := range []string{"acme", "globex"} {
+		if err := src.Create(&DrainTemplateRow{TenantID: tenant, ID: 7, Tokens: `["a"]`, Count: 1, FirstSeen: now, LastSeen: now}).Error; err != nil {
+			t.Fatalf("seed template: %v", err)
+		}
+	}
+
+	for run := 0; run < 2; run++ {
+		if err := MigrateStorage(context.Background(), src, dst, storage.StorageMigrationOptions{BatchSize: 2}); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+	for _, model := range []any{&Investigation{}, &GraphSnapshot{}, &DrainTemplateRow{}} {
+		var s, d int64
+		src.Model(model).Count(&s)
+		dst.Model(model).Count(&d)
+		if s == 0 || s != d {
+			t.Errorf("%T: source %d rows, destination %d", model, s, d)
+		}
+	}
+}
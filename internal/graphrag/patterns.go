@@ -0,0 +1,173 @@
+package graphrag
+
+// On-demand log pattern mining. The ingest-time Drain miner (clusterLog)
+// keeps running totals per cluster but no history, so it cannot say how a
+// pattern moved within a time window. MineLogPatterns instead runs a fresh
+// Drain over the stored logs of the requested window and buckets every line
+// under its final template.
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// logPatternScanCap bounds how many log rows one mining pass folds in
+	// memory. Newest rows win; LogPatternsResponse.Truncated reports a hit.
+	logPatternScanCap = 20_000
+
+	defaultLogPatternLimit   = 20
+	defaultLogPatternBuckets = 24
+
+	// logPatternLineMaxLen caps the line fed to Drain so a multi-KB body
+	// cannot dominate the pass.
+	logPatternLineMaxLen = 512
+)
+
+// LogPatternQuery selects and shapes a log pattern mining pass.
+type LogPatternQuery struct {
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string
+	Severity     string // exact stored severity; empty = all
+	Limit        int    // max patterns returned (default 20)
+	Buckets      int    // trend resolution (default 24)
+}
+
+// LogPattern is one mined template within one service.
+type LogPattern struct {
+	ServiceName   string           `json:"service_name"`
+	Pattern       string           `json:"pattern"` // variable tokens rendered as <*>, <NUM>, <IP>, ...
+	Count         int64            `json:"count"`
+	Share         float64          `json:"share"` // Count / TotalLogs
+	FirstSeen     time.Time        `json:"first_seen"`
+	LastSeen      time.Time        `json:"last_seen"`
+	Trend         []int64          `json:"trend"` // counts per bucket, oldest first
+	Severities    map[string]int64 `json:"severities"`
+	SampleMessage string           `json:"sample_message"` // most recent raw line
+}
+
+// LogPatternsResponse is the log pattern mining payload.
+type LogPatternsResponse struct {
+	Patterns      []LogPattern `json:"patterns"`
+	TotalPatterns int          `json:"total_patterns"`
+	TotalLogs     int64        `json:"total_logs"`
+	Start         time.Time    `json:"start"`
+	End           time.Time    `json:"end"`
+	BucketSeconds int64        `json:"bucket_seconds"`
+	Truncated     bool         `json:"truncated"` // true when logPatternScanCap was hit
+}
+
+// MineLogPatterns groups the logs in [Start, End] into Drain templates per
+// service, scoped to the tenant and region on ctx, with counts, a trend per
+// bucket and first/last seen. Patterns are ordered by count descending.
+//
+// Drain runs twice over the rows: the first pass lets templates generalize,
+// the second assigns each line to the template that now covers it, so an
+// early line is not left under a template that was later widened.
+func MineLogPatterns(ctx context.Context, repo *storage.Repository, q LogPatternQuery) (*LogPatternsResponse, error) {
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-time.Hour)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultLogPatternLimit
+	}
+	if q.Buckets <= 0 {
+		q.Buckets = defaultLogPatternBuckets
+	}
+
+	rows, err := repo.LogsForPatternMining(ctx, q.Start, q.End, q.ServiceNames, q.Severity, logPatternScanCap)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketWidth := q.End.Sub(q.Start) / time.Duration(q.Buckets)
+	if bucketWidth <= 0 {
+		bucketWidth = time.Second
+	}
+	resp := &LogPatternsResponse{
+		Start:         q.Start,
+		End:           q.End,
+		BucketSeconds: int64(bucketWidth / time.Second),
+		Truncated:     len(rows) == logPatternScanCap,
+	}
+
+	lines := make([]string, len(rows))
+	d := NewDrain(WithMaxTemplates(logPatternScanCap))
+	for i := range rows {
+		lines[i] = patternLine(rows[i].Body)
+		d.Match(lines[i], rows[i].Timestamp)
+	}
+
+	// Keyed by the rendered template rather than *Template: a widened
+	// template can fold into an identical one mid-pass.
+	type groupKey struct{ service, pattern string }
+	groups := make(map[groupKey]*LogPattern)
+	// Rows arrive newest-first, so the first row seen per group is LastSeen
+	// and supplies the sample message.
+	for i, row := range rows {
+		tpl := d.Match(lines[i], row.Timestamp)
+		if tpl == nil {
+			continue
+		}
+		k := groupKey{row.ServiceName, tpl.TemplateString()}
+		g, ok := groups[k]
+		if !ok {
+			g = &LogPattern{
+				ServiceName:   row.ServiceName,
+				Pattern:       k.pattern,
+				SampleMessage: lines[i],
+				LastSeen:      row.Timestamp,
+				Trend:         make([]int64, q.Buckets),
+				Severities:    make(map[string]int64),
+			}
+			groups[k] = g
+		}
+		g.Count++
+		g.FirstSeen = row.Timestamp
+		idx := min(max(int(row.Timestamp.Sub(q.Start)/bucketWidth), 0), q.Buckets-1)
+		g.Trend[idx]++
+		g.Severities[row.Severity]++
+		resp.TotalLogs++
+	}
+
+	resp.Patterns = make([]LogPattern, 0, len(groups))
+	for _, g := range groups {
+		g.Share = float64(g.Count) / float64(resp.TotalLogs)
+		resp.Patterns = append(resp.Patterns, *g)
+	}
+	sort.Slice(resp.Patterns, func(i, j int) bool {
+		a, b := resp.Patterns[i], resp.Patterns[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.After(b.LastSeen)
+		}
+		return a.Pattern < b.Pattern
+	})
+	resp.TotalPatterns = len(resp.Patterns)
+	if len(resp.Patterns) > q.Limit {
+		resp.Patterns = resp.Patterns[:q.Limit]
+	}
+	return resp, nil
+}
+
+// patternLine is the part of a log body that is mined: the first line
+// (stack traces follow it), capped at logPatternLineMaxLen bytes.
+func patternLine(body string) string {
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[:i]
+	}
+	if len(body) > logPatternLineMaxLen {
+		body = body[:logPatternLineMaxLen]
+	}
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,66 @@
+package graphrag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestMineLogPatterns(t *testing.T) {
+	repo := newTestRepo(t)
+	end := time.Now().Truncate(time.Minute)
+	start := end.Add(-time.Hour)
+
+	var logs []storage.Log
+	add := func(service, severity, body string, ago time.Duration) {
+		logs = append(logs, storage.Log{TenantID: storage.DefaultTenantID, ServiceName: service, Severity: severity, Body: body, Timestamp: end.Add(-ago)})
+	}
+	users := []string{"alice", "bob", "carol", "dave", "erin", "frank"}
+	for i, u := range users {
+		add("auth", "INFO", fmt.Sprintf("login succeeded for user %s from 10.0.0.%d", u, i), time.Duration(50-i)*time.Minute)
+	}
+	for i := range 3 {
+		add("payment", "ERROR", fmt.Sprintf("charge %d failed: card declined\n\tat Pay.charge()", 1000+i), time.Duration(5+i)*time.Minute)
+	}
+	add("payment", "INFO", "cache warmed", 30*time.Minute)
+	add("auth", "INFO", "login succeeded for user zed from 10.0.0.9", 2*time.Hour) // outside the window
+	if err := repo.BatchCreateLogs(logs); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := MineLogPatterns(context.Background(), repo, LogPatternQuery{Start: start, End: end, Buckets: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalLogs != 10 || resp.TotalPatterns != 3 || resp.BucketSeconds != 600 || resp.Truncated {
+		t.Fatalf("totals = %d logs, %d patterns, %ds buckets, truncated=%v", resp.TotalLogs, resp.TotalPatterns, resp.BucketSeconds, resp.Truncated)
+	}
+	login := resp.Patterns[0]
+	if login.ServiceName != "auth" || login.Pattern != "login succeeded for user <*> from <IP>" || login.Count != 6 {
+		t.Errorf("top pattern = %+v", login)
+	}
+	if login.Share != 0.6 || login.SampleMessage != "login succeeded for user frank from 10.0.0.5" || login.Severities["INFO"] != 6 {
+		t.Errorf("top pattern details = %+v", login)
+	}
+	if fmt.Sprint(login.Trend) != "[0 6 0 0 0 0]" {
+		t.Errorf("trend = %v", login.Trend)
+	}
+	charge := resp.Patterns[1]
+	if charge.Pattern != "charge <NUM> failed: card declined" || charge.Count != 3 || charge.Trend[5] != 3 {
+		t.Errorf("second pattern = %+v", charge)
+	}
+	if !charge.FirstSeen.Equal(end.Add(-7*time.Minute)) || !charge.LastSeen.Equal(end.Add(-5*time.Minute)) {
+		t.Errorf("first/last seen = %v/%v", charge.FirstSeen, charge.LastSeen)
+	}
+
+	resp, err = MineLogPatterns(context.Background(), repo, LogPatternQuery{Start: start, End: end, ServiceNames: []string{"payment"}, Severity: "ERROR", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalLogs != 3 || resp.TotalPatterns != 1 || len(resp.Patterns) != 1 || resp.Patterns[0].Share != 1 {
+		t.Errorf("filtered = %+v", resp)
+	}
+}
@@ -369,6 +369,18 @@ func (g *GraphRAG) ServiceNames(ctx context.Context) []string {
 	return names
 }
 
+// Tenants returns the tenants with an in-memory graph, sorted: those that
+// ingested since startup or had spans in the last hour at the last rebuild.
+func (g *GraphRAG) Tenants() []string {
+	tenants := g.snapshotTenants()
+	names := make([]string, 0, len(tenants))
+	for t := range tenants {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ServiceMap returns the service topology with health scores for the API.
 type ServiceMapEntry struct {
 	Service    *ServiceNode     `json:"service"`
@@ -136,7 +136,7 @@ func (g *GraphRAG) rebuildFromDBForTenant(_ context.Context, tenant string, sinc
 		ParentSpanID  string
 		ServiceName   string
 		OperationName string
-		Duration      int64 // microseconds
+		DurationNs    int64
 		TraceID       string
 		Status        string
 		StartTime     time.Time
@@ -147,9 +147,9 @@ func (g *GraphRAG) rebuildFromDBForTenant(_ context.Context, tenant string, sinc
 	var rows []spanRow
 	err := g.repo.DB().
 		Table("spans").
-		Select("span_id, parent_span_id, service_name, operation_name, duration, trace_id, status, start_time").
+		Select("span_id, parent_span_id, service_name, operation_name, duration_ns, trace_id, status, start_time").
 		Where("start_time > ? AND tenant_id = ?", since, tenant).
-		Order("start_time ASC").
+		Order("start_time_unix_nano ASC").
 		Limit(50000).
 		Find(&rows).Error
 	if err != nil {
@@ -168,7 +168,7 @@ func (g *GraphRAG) rebuildFromDBForTenant(_ context.Context, tenant string, sinc
 	}
 
 	for _, r := range rows {
-		durationMs := float64(r.Duration) / 1000.0
+		durationMs := float64(r.DurationNs) / 1e6
 		isError := r.Status == "STATUS_CODE_ERROR"
 
 		stores.service.UpsertService(r.ServiceName, durationMs, isError, r.StartTime)
@@ -0,0 +1,307 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Ingest credential kinds in an INGEST_AUTH_FILE.
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+)
+
+// AuthEntry is one accepted ingest credential. For bearer entries Secret is
+// the token; for basic entries User and Secret are the username and
+// password. A non-empty Tenant pins everything written with the credential
+// to that tenant, overriding X-Tenant-ID. A non-empty Services restricts it
+// to resources with those service.name values.
+type AuthEntry struct {
+	Kind     string
+	User     string
+	Secret   string
+	Tenant   string
+	Services []string
+}
+
+// LoadAuthFile parses an ingest credentials file. Each non-empty,
+// non-comment line is a kind, a credential and optional settings:
+//
+//	# bearer <token> | basic <user>:<password>, then tenant=… services=a,b
+//	bearer 7c9e6679f0a94c2b services=checkout,cart
+//	basic edge-collector:s3cret tenant=acme
+//
+// Duplicate credentials, unknown settings and empty values are errors, so
+// misconfiguration fails loud at startup.
+func LoadAuthFile(path string) ([]AuthEntry, error) {
+	f, err := os.Open(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("ingest auth file %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var out []AuthEntry
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("ingest auth file %q line %d: expected `bearer <token>` or `basic <user>:<password>`", path, line)
+		}
+		e := AuthEntry{Kind: strings.ToLower(fields[0]), Secret: fields[1]}
+		switch e.Kind {
+		case AuthBearer:
+		case AuthBasic:
+			var ok bool
+			if e.User, e.Secret, ok = strings.Cut(fields[1], ":"); !ok || e.User == "" || e.Secret == "" {
+				return nil, fmt.Errorf("ingest auth file %q line %d: basic credentials must be <user>:<password>", path, line)
+			}
+		default:
+			return nil, fmt.Errorf("ingest auth file %q line %d: unknown kind %q (want bearer or basic)", path, line, fields[0])
+		}
+		for _, opt := range fields[2:] {
+			k, v, _ := strings.Cut(opt, "=")
+			switch {
+			case v == "":
+				return nil, fmt.Errorf("ingest auth file %q line %d: empty value in %q", path, line, opt)
+			case k == "tenant":
+				if e.Tenant = storage.SanitizeTenantID(v); e.Tenant == "" {
+					return nil, fmt.Errorf("ingest auth file %q line %d: invalid tenant %q", path, line, v)
+				}
+			case k == "services":
+				for _, svc := range strings.Split(v, ",") {
+					if svc = strings.TrimSpace(svc); svc != "" {
+						e.Services = append(e.Services, svc)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("ingest auth file %q line %d: unknown setting %q (want tenant= or services=)", path, line, k)
+			}
+		}
+		id := e.Kind + " " + e.User
+		if e.Kind == AuthBearer {
+			id += e.Secret
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("ingest auth file %q line %d: duplicate credential", path, line)
+		}
+		seen[id] = true
+		out = append(out, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ingest auth file %q: %w", path, err)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("ingest auth file %q: no entries found", path)
+	}
+	return out, nil
+}
+
+// ingestPrincipal is what an ingest credential resolves to.
+type ingestPrincipal struct {
+	tenant   string
+	services map[string]bool // nil = any service
+}
+
+type ingestPrincipalKey struct{}
+
+// serviceAllowed reports whether the credential the request authenticated
+// with may write service's telemetry. Requests without one (auth off) may
+// write anything.
+func serviceAllowed(ctx context.Context, service string) bool {
+	p, ok := ctx.Value(ingestPrincipalKey{}).(*ingestPrincipal)
+	return !ok || p.services == nil || p.services[service]
+}
+
+type basicCredential struct {
+	passwordHash [sha256.Size]byte
+	principal    *ingestPrincipal
+}
+
+// BearerFallback resolves a bearer token the auth file does not list, e.g.
+// a managed API key with the ingest scope. It returns the token's tenant
+// ("" = unpinned) and allowed services (nil = any).
+type BearerFallback func(ctx context.Context, token string) (tenant string, services []string, ok bool)
+
+// Authenticator checks OTLP exports against ingest credentials, on the gRPC
+// "authorization" metadata and the OTLP/HTTP Authorization header alike:
+// "Bearer <token>" or "Basic <base64 user:password>". A credential bound to
+// a tenant pins it onto the context; one bound to services has the other
+// services' resources rejected through partial_success
+// (reason service_not_allowed).
+type Authenticator struct {
+	bearer   map[[sha256.Size]byte]*ingestPrincipal // token hash → principal
+	basic    map[string]basicCredential             // user → credential
+	fallback BearerFallback
+	onFail   func(transport, reason string)
+}
+
+// NewAuthenticator builds an Authenticator accepting entries.
+func NewAuthenticator(entries []AuthEntry) *Authenticator {
+	a := &Authenticator{
+		bearer: make(map[[sha256.Size]byte]*ingestPrincipal),
+		basic:  make(map[string]basicCredential),
+	}
+	for _, e := range entries {
+		p := &ingestPrincipal{tenant: e.Tenant, services: serviceSet(e.Services)}
+		switch e.Kind {
+		case AuthBearer:
+			a.bearer[sha256.Sum256([]byte(e.Secret))] = p
+		case AuthBasic:
+			a.basic[e.User] = basicCredential{passwordHash: sha256.Sum256([]byte(e.Secret)), principal: p}
+		}
+	}
+	return a
+}
+
+func serviceSet(services []string) map[string]bool {
+	if len(services) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(services))
+	for _, s := range services {
+		set[s] = true
+	}
+	return set
+}
+
+// SetBearerFallback consults fn for bearer tokens not in the auth file.
+func (a *Authenticator) SetBearerFallback(fn BearerFallback) {
+	a.fallback = fn
+}
+
+// SetFailureHook is called with the transport (grpc|http) and reason
+// (missing_credentials|bad_scheme|bad_credentials) of every rejected export.
+func (a *Authenticator) SetFailureHook(fn func(transport, reason string)) {
+	a.onFail = fn
+}
+
+// authenticate resolves an Authorization value, returning the failure
+// reason when it is not accepted.
+func (a *Authenticator) authenticate(ctx context.Context, auth string) (*ingestPrincipal, string) {
+	if auth == "" {
+		return nil, "missing_credentials"
+	}
+	scheme, cred, _ := strings.Cut(auth, " ")
+	cred = strings.TrimSpace(cred)
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		if p := a.bearer[sha256.Sum256([]byte(cred))]; p != nil {
+			return p, ""
+		}
+		if a.fallback != nil {
+			if tenant, services, ok := a.fallback(ctx, cred); ok {
+				return &ingestPrincipal{tenant: tenant, services: serviceSet(services)}, ""
+			}
+		}
+	case "basic":
+		raw, err := base64.StdEncoding.DecodeString(cred)
+		if err != nil {
+			return nil, "bad_credentials"
+		}
+		user, password, _ := strings.Cut(string(raw), ":")
+		c, ok := a.basic[user]
+		hash := sha256.Sum256([]byte(password))
+		if ok && subtle.ConstantTimeCompare(hash[:], c.passwordHash[:]) == 1 {
+			return c.principal, ""
+		}
+	default:
+		return nil, "bad_scheme"
+	}
+	return nil, "bad_credentials"
+}
+
+func (a *Authenticator) fail(transport, reason string) {
+	if a.onFail != nil {
+		a.onFail(transport, reason)
+	}
+}
+
+// withPrincipal puts p on ctx, pinning its tenant when it has one.
+func withPrincipal(ctx context.Context, p *ingestPrincipal) context.Context {
+	ctx = context.WithValue(ctx, ingestPrincipalKey{}, p)
+	if p.tenant != "" {
+		ctx = storage.WithTenantContext(ctx, p.tenant)
+	}
+	return ctx
+}
+
+// UnaryServerInterceptor enforces ingest credentials on the OTLP gRPC
+// collector services; other services on the server pass through.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, "/opentelemetry.proto.collector.") {
+			return handler(ctx, req)
+		}
+		var auth string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if v := md.Get("authorization"); len(v) > 0 {
+				auth = v[0]
+			}
+		}
+		p, reason := a.authenticate(ctx, auth)
+		if p == nil {
+			a.fail("grpc", reason)
+			return nil, grpcstatus.Error(codes.Unauthenticated, "ingest authentication failed: "+reason)
+		}
+		return handler(withPrincipal(ctx, p), req)
+	}
+}
+
+// Require authenticates every request to next, for receivers that have a
+// listener of their own (e.g. Loki). transport labels failures.
+func (a *Authenticator) Require(transport string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, reason := a.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if p == nil {
+			a.fail(transport, reason)
+			w.Header().Set("WWW-Authenticate", `Basic realm="ingest", charset="UTF-8"`)
+			http.Error(w, "ingest authentication failed: "+reason, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+	})
+}
+
+// Gate sends OTLP/HTTP requests (/v1/*) through ingest authentication to
+// otlp, and everything else to rest. This lets /v1/* use ingest credentials
+// instead of whatever API authentication wraps rest. CORS preflights pass
+// unauthenticated, as browsers never attach credentials to them.
+func (a *Authenticator) Gate(otlp, rest http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			rest.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			otlp.ServeHTTP(w, r)
+			return
+		}
+		p, reason := a.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if p == nil {
+			a.fail("http", reason)
+			w.Header().Set("WWW-Authenticate", `Basic realm="otlp", charset="UTF-8"`)
+			writeOTLPError(w, r, http.StatusUnauthorized, "ingest authentication failed: "+reason)
+			return
+		}
+		otlp.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+	})
+}
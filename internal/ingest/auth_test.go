@@ -0,0 +1,160 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func writeAuthFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ingest-auth")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestLoadAuthFile(t *testing.T) {
+	entries, err := LoadAuthFile(writeAuthFile(t, `
+# collectors
+bearer tok-1 services=checkout,cart
+BASIC edge:pa:ss tenant=acme
+`))
+	if err != nil {
+		t.Fatalf("LoadAuthFile: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Secret != "tok-1" || len(entries[0].Services) != 2 ||
+		entries[1].Kind != AuthBasic || entries[1].User != "edge" || entries[1].Secret != "pa:ss" || entries[1].Tenant != "acme" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	for name, content := range map[string]string{
+		"empty":         "# nothing\n",
+		"no credential": "bearer\n",
+		"unknown kind":  "digest abc\n",
+		"basic no pass": "basic edge\n",
+		"bad setting":   "bearer t region=eu\n",
+		"empty value":   "bearer t tenant=\n",
+		"duplicate":     "basic a:1\nbasic a:2\n",
+	} {
+		if _, err := LoadAuthFile(writeAuthFile(t, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestAuthenticator_GRPC verifies bearer and basic credentials on gRPC
+// metadata, tenant pinning, and the bearer fallback.
+func TestAuthenticator_GRPC(t *testing.T) {
+	a := NewAuthenticator([]AuthEntry{
+		{Kind: AuthBearer, Secret: "tok-1"},
+		{Kind: AuthBasic, User: "edge", Secret: "s3cret", Tenant: "acme"},
+	})
+	a.SetBearerFallback(func(_ context.Context, token string) (string, []string, bool) {
+		return "managed", nil, token == "oc_managed"
+	})
+	var failures []string
+	a.SetFailureHook(func(transport, reason string) { failures = append(failures, transport+":"+reason) })
+
+	interceptor := a.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/opentelemetry.proto.collector.trace.v1.TraceService/Export"}
+	call := func(auth string) (string, error) {
+		ctx := context.Background()
+		if auth != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", auth))
+		}
+		var tenant string
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+			tenant = storage.TenantFromContext(ctx)
+			return nil, nil
+		})
+		return tenant, err
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("edge:s3cret"))
+	for auth, want := range map[string]string{"Bearer tok-1": storage.DefaultTenantID, basic: "acme", "Bearer oc_managed": "managed"} {
+		if tenant, err := call(auth); err != nil || tenant != want {
+			t.Errorf("%q: tenant %q, err %v; want %q", auth, tenant, err, want)
+		}
+	}
+	wrongPass := "Basic " + base64.StdEncoding.EncodeToString([]byte("edge:nope"))
+	for _, auth := range []string{"", "Bearer nope", wrongPass, "Token tok-1"} {
+		if _, err := call(auth); grpcstatus.Code(err) != codes.Unauthenticated {
+			t.Errorf("%q: err %v, want Unauthenticated", auth, err)
+		}
+	}
+	if got := strings.Join(failures, ","); got != "grpc:missing_credentials,grpc:bad_credentials,grpc:bad_credentials,grpc:bad_scheme" {
+		t.Errorf("failures = %s", got)
+	}
+
+	health := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	if _, err := interceptor(context.Background(), nil, health, func(context.Context, any) (any, error) { return nil, nil }); err != nil {
+		t.Errorf("non-OTLP service rejected: %v", err)
+	}
+}
+
+// TestAuthenticator_HTTPServiceAllowlist verifies /v1/* goes through ingest
+// auth, other paths to the API handler, and a service-bound token has other
+// services' spans rejected via partial_success.
+func TestAuthenticator_HTTPServiceAllowlist(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+	h := NewHTTPHandler(NewTraceServer(repo, nil, cfg), NewLogsServer(repo, nil, cfg), NewMetricsServer(repo, nil, nil, cfg))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rest := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	gate := NewAuthenticator([]AuthEntry{{Kind: AuthBearer, Secret: "tok-1", Services: []string{"checkout"}}}).Gate(mux, rest)
+
+	req := buildTracesRequest("checkout", 2)
+	req.ResourceSpans = append(req.ResourceSpans, buildTracesRequest("intruder", 3).ResourceSpans...)
+	body, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	post := func(auth string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentTypeProtobuf)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		gate.ServeHTTP(rec, r)
+		return rec
+	}
+
+	if rec := post(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no credentials: status %d, want 401", rec.Code)
+	}
+	rec := post("Bearer tok-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %q", rec.Code, rec.Body.String())
+	}
+	var resp coltracepb.ExportTraceServiceResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if ps := resp.GetPartialSuccess(); ps.GetRejectedSpans() != 3 || !strings.Contains(ps.GetErrorMessage(), "service_not_allowed=3") {
+		t.Errorf("partial success = %+v, want 3 service_not_allowed", ps)
+	}
+
+	other := httptest.NewRecorder()
+	gate.ServeHTTP(other, httptest.NewRequest(http.MethodGet, "/api/services", nil))
+	if other.Code != http.StatusTeapot {
+		t.Errorf("non-OTLP path status %d, want the API handler", other.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+const (
+	// maxTrackedVersions bounds the versions a VersionTracker remembers;
+	// past it the set is cleared and versions are looked up again.
+	maxTrackedVersions = 10_000
+	// versionRecordTimeout bounds one deployment write.
+	versionRecordTimeout = 10 * time.Second
+)
+
+// Resource attributes naming the environment, current semantic conventions
+// first.
+var deployEnvironmentKeys = []string{"deployment.environment.name", "deployment.environment"}
+
+type versionKey struct{ tenant, service, environment, version string }
+
+// VersionTracker turns the service.version resource attribute into deploy
+// annotations: the first time a service reports a version it has no
+// deployment for, one is recorded at the time it was seen. Enabled via
+// DEPLOYMENTS_FROM_SERVICE_VERSION; nil VersionTracker = off.
+//
+// Versions already handled are remembered in memory, so the database is
+// consulted once per service version per process. Writes run off the
+// ingest path. Safe for concurrent use.
+type VersionTracker struct {
+	repo *storage.Repository
+	now  func() time.Time
+
+	mu   sync.Mutex
+	seen map[versionKey]struct{}
+	wg   sync.WaitGroup // in-flight writes; tests wait on it
+}
+
+// NewVersionTracker returns a tracker recording into repo.
+func NewVersionTracker(repo *storage.Repository) *VersionTracker {
+	return &VersionTracker{repo: repo, now: time.Now, seen: make(map[versionKey]struct{})}
+}
+
+// observe records a deployment when attrs carry a service.version not yet
+// handled for the tenant's service.
+func (t *VersionTracker) observe(tenant, service string, attrs []*commonpb.KeyValue) {
+	if t == nil {
+		return
+	}
+	k := versionKey{tenant: tenant, service: service}
+	for _, kv := range attrs {
+		switch kv.Key {
+		case "service.version":
+			k.version = kv.Value.GetStringValue()
+		case deployEnvironmentKeys[0]:
+			k.environment = kv.Value.GetStringValue()
+		case deployEnvironmentKeys[1]:
+			if k.environment == "" {
+				k.environment = kv.Value.GetStringValue()
+			}
+		}
+	}
+	if k.version == "" || len(k.version) > 255 || len(k.environment) > 64 {
+		return
+	}
+
+	t.mu.Lock()
+	if _, ok := t.seen[k]; ok {
+		t.mu.Unlock()
+		return
+	}
+	if len(t.seen) >= maxTrackedVersions {
+		clear(t.seen)
+	}
+	t.seen[k] = struct{}{}
+	t.mu.Unlock()
+
+	at := t.now().UTC()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ctx, cancel := context.WithTimeout(storage.WithTenantContext(context.Background(), k.tenant), versionRecordTimeout)
+		defer cancel()
+		created, err := t.repo.RecordServiceVersion(ctx, k.service, k.version, k.environment, at)
+		if err != nil {
+			// Forget it so a later batch retries.
+			t.mu.Lock()
+			delete(t.seen, k)
+			t.mu.Unlock()
+			slog.Warn("Failed to record service version deployment", "service", k.service, "version", k.version, "error", err)
+			return
+		}
+		if created {
+			slog.Info("🚀 Deployment recorded", "service", k.service, "version", k.version, "environment", k.environment, "source", storage.DeploymentSourceServiceVersion)
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+func TestVersionTracker_RecordsNewVersions(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	vt := NewVersionTracker(repo)
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	vt.now = func() time.Time { return t0 }
+	traces.SetVersionTracker(vt)
+
+	export := func(svc string, attrs ...string) {
+		t.Helper()
+		req := buildTracesRequest(svc, 1)
+		res := req.ResourceSpans[0].Resource
+		for i := 0; i+1 < len(attrs); i += 2 {
+			res.Attributes = append(res.Attributes, &commonpb.KeyValue{
+				Key: attrs[i], Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: attrs[i+1]}},
+			})
+		}
+		if _, err := traces.Export(context.Background(), req); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		vt.wg.Wait()
+	}
+	export("checkout", "service.version", "v1", "deployment.environment.name", "prod")
+	export("checkout", "service.version", "v1", "deployment.environment.name", "prod")
+	export("checkout")
+	vt.now = func() time.Time { return t0.Add(time.Hour) }
+	export("checkout", "service.version", "v2", "deployment.environment", "prod")
+
+	got, err := repo.ListDeployments(context.Background(), "checkout", time.Time{}, time.Time{}, 0)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("deployments = %+v, %v; want v2 and v1", got, err)
+	}
+	if got[0].Version != "v2" || got[0].Environment != "prod" || !got[0].Timestamp.Equal(t0.Add(time.Hour)) ||
+		got[0].Source != storage.DeploymentSourceServiceVersion || got[0].Status != storage.DeploymentSuccess {
+		t.Errorf("latest = %+v", got[0])
+	}
+
+	// A restart forgets the seen set; the stored row keeps v1 from repeating.
+	traces.SetVersionTracker(NewVersionTracker(repo))
+	traces.versions.now = func() time.Time { return t0.Add(2 * time.Hour) }
+	vt = traces.versions
+	export("checkout", "service.version", "v1", "deployment.environment.name", "prod")
+	if got, _ := repo.ListDeployments(context.Background(), "checkout", time.Time{}, time.Time{}, 0); len(got) != 2 {
+		t.Errorf("after restart: %d deployments, want 2", len(got))
+	}
+}
@@ -0,0 +1,334 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Dry-run actions.
+const (
+	DryRunDrop   = "drop"   // the record is not stored
+	DryRunModify = "modify" // the record is stored in changed form
+	DryRunSample = "sample" // the record is stored only if the sampler lets it through
+)
+
+// DryRunReport is what the ingest pipeline would do with one OTLP payload:
+// every processor decision that dropped or changed a record, the
+// partial_success rejections the client would see, and the rows that
+// would be stored. Building it persists nothing and touches no metrics,
+// sampler state or callbacks.
+type DryRunReport struct {
+	Signal    string           `json:"signal"`
+	Received  int              `json:"received"`
+	Rejected  map[string]int64 `json:"rejected,omitempty"`
+	Decisions []DryRunDecision `json:"decisions"`
+	Stored    DryRunStored     `json:"stored"`
+
+	mu sync.Mutex
+}
+
+// DryRunDecision is one processor acting on one record. Kind is resource
+// (a whole resource filtered out), span, log, event (a log synthesized from
+// a span event) or trace (a tail-sampling decision).
+type DryRunDecision struct {
+	Service   string `json:"service"`
+	Kind      string `json:"kind"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Processor string `json:"processor"`
+	Action    string `json:"action"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// DryRunStored is the final form of the records that would be written.
+// Metrics are the raw points handed to the TSDB aggregator, before
+// windowing.
+type DryRunStored struct {
+	Traces  []storage.Trace `json:"traces"`
+	Spans   []storage.Span  `json:"spans"`
+	Logs    []storage.Log   `json:"logs"`
+	Metrics []DryRunMetric  `json:"metrics"`
+}
+
+// DryRunMetric is a tsdb.RawMetric with JSON field names.
+type DryRunMetric struct {
+	TenantID    string         `json:"tenant_id"`
+	Name        string         `json:"name"`
+	ServiceName string         `json:"service_name"`
+	Value       float64        `json:"value"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+type dryRunKey struct{}
+
+// withDryRun marks ctx so the Export methods report into rep instead of
+// persisting.
+func withDryRun(ctx context.Context, rep *DryRunReport) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, rep)
+}
+
+// dryRunFrom returns the report on ctx, or nil for a real export. Every
+// DryRunReport method is nil-safe, so Export can report unconditionally.
+func dryRunFrom(ctx context.Context) *DryRunReport {
+	rep, _ := ctx.Value(dryRunKey{}).(*DryRunReport)
+	return rep
+}
+
+func (r *DryRunReport) add(d DryRunDecision) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.Decisions = append(r.Decisions, d)
+	r.mu.Unlock()
+}
+
+// dropResource records a whole resource of n records filtered out.
+func (r *DryRunReport) dropResource(service, processor, reason string, n int) {
+	r.add(DryRunDecision{Service: service, Kind: "resource", Processor: processor, Action: DryRunDrop, Detail: fmt.Sprintf("%s: %d records", reason, n)})
+}
+
+// span records processor acting on span.
+func (r *DryRunReport) span(service string, span *tracepb.Span, processor, action, detail string) {
+	r.add(DryRunDecision{Service: service, Kind: "span", ID: fmt.Sprintf("%x", span.SpanId), Name: span.Name, Processor: processor, Action: action, Detail: detail})
+}
+
+// logModified records processor changing a log record.
+func (r *DryRunReport) logModified(service string, l *logspb.LogRecord, processor, detail string) {
+	r.add(DryRunDecision{Service: service, Kind: "log", ID: fmt.Sprintf("%x", l.SpanId), Name: truncateDryRunName(l.Body.GetStringValue()), Processor: processor, Action: DryRunModify, Detail: detail})
+}
+
+// logRecord records processor dropping a log record.
+func (r *DryRunReport) logRecord(service string, l *logspb.LogRecord, processor, detail string) {
+	r.add(DryRunDecision{Service: service, Kind: "log", ID: fmt.Sprintf("%x", l.SpanId), Name: truncateDryRunName(l.Body.GetStringValue()), Processor: processor, Action: DryRunDrop, Detail: detail})
+}
+
+// spanRewrite records how the rewrite rules changed span, given a copy
+// taken before they ran.
+func (r *DryRunReport) spanRewrite(service string, before, after *tracepb.Span) {
+	if r == nil || proto.Equal(before, after) {
+		return
+	}
+	detail := "attributes rewritten"
+	if from, to := before.GetStatus().GetCode(), after.GetStatus().GetCode(); from != to {
+		detail = fmt.Sprintf("status %s -> %s", from, to)
+		if !proto.Equal(&tracepb.Span{Attributes: before.Attributes}, &tracepb.Span{Attributes: after.Attributes}) {
+			detail += ", attributes rewritten"
+		}
+	}
+	r.span(service, after, "rewrite", DryRunModify, detail)
+}
+
+// headSample reports the head sampler's verdict on a span without drawing
+// from its token buckets, so a dry run cannot starve real traffic. Spans
+// the sampler always keeps pass silently. Services with their own rate get
+// the real trace ID decision; the rest are dropped at rate 0 and otherwise
+// stored subject to sampling.
+func (r *DryRunReport) headSample(sm *Sampler, service string, span *tracepb.Span, isError bool, durationMs float64) bool {
+	rate, override := sm.serviceRates[service]
+	if !override {
+		rate = sm.rate
+	}
+	switch {
+	case (sm.alwaysOnErrors && isError) || durationMs >= sm.latencyThresholdMs || rate >= 1:
+		return true
+	case rate <= 0:
+		r.span(service, span, "sampler", DryRunDrop, "sampling rate 0")
+		return false
+	case override:
+		if !traceIDSampled(span.TraceId, rate) {
+			r.span(service, span, "sampler", DryRunDrop, fmt.Sprintf("trace not sampled at service rate %.2f", rate))
+			return false
+		}
+		r.span(service, span, "sampler", DryRunSample, fmt.Sprintf("trace sampled at service rate %.2f", rate))
+		return true
+	default:
+		r.span(service, span, "sampler", DryRunSample, fmt.Sprintf("kept at sampling rate %.2f", rate))
+		return true
+	}
+}
+
+// tailSample applies the tail sampler's policies to the payload's traces
+// as if their decision windows closed now, and returns the kept records.
+// Spans of a trace already decided follow the earlier decision in a real
+// export; a dry run cannot see that state and evaluates the payload alone.
+func (r *DryRunReport) tailSample(t *TailSampler, traces []storage.Trace, spans []storage.Span, logs []storage.Log) ([]storage.Trace, []storage.Span, []storage.Log) {
+	batches := make(map[string]*tailBatch)
+	var order []string
+	get := func(tenant, traceID string) *tailBatch {
+		k := tenant + "/" + traceID
+		b := batches[k]
+		if b == nil {
+			b = &tailBatch{}
+			batches[k] = b
+			order = append(order, k)
+		}
+		return b
+	}
+	for _, tr := range traces {
+		b := get(tr.TenantID, tr.TraceID)
+		b.traces = append(b.traces, tr)
+	}
+	for _, sp := range spans {
+		b := get(sp.TenantID, sp.TraceID)
+		b.spans = append(b.spans, sp)
+	}
+	for _, l := range logs {
+		b := get(l.TenantID, l.TraceID)
+		b.logs = append(b.logs, l)
+	}
+
+	kept := &tailBatch{}
+	for _, k := range order {
+		b := batches[k]
+		service, traceID := "", ""
+		if len(b.spans) > 0 {
+			service, traceID = b.spans[0].ServiceName, b.spans[0].TraceID
+		} else if len(b.logs) > 0 {
+			service, traceID = b.logs[0].ServiceName, b.logs[0].TraceID
+		}
+		if policy := t.evaluate(k, b); policy != tailPolicyNone {
+			kept.append(b)
+		} else {
+			r.add(DryRunDecision{Service: service, Kind: "trace", ID: traceID, Processor: "tail_sampler", Action: DryRunDrop, Detail: "no tail-sampling policy matched"})
+		}
+	}
+	return kept.traces, kept.spans, kept.logs
+}
+
+// storeSeverity applies the pipeline's STORE_MIN_SEVERITY gate to logs.
+func (r *DryRunReport) storeSeverity(p *Pipeline, logs []storage.Log) []storage.Log {
+	if p == nil || p.storeMinSeverity <= 0 {
+		return logs
+	}
+	kept := make([]storage.Log, 0, len(logs))
+	for _, l := range logs {
+		if shouldIngestSeverity(l.Severity, p.storeMinSeverity) {
+			kept = append(kept, l)
+			continue
+		}
+		r.add(DryRunDecision{Service: l.ServiceName, Kind: "log", ID: l.SpanID, Name: truncateDryRunName(l.Body), Processor: "store_severity", Action: DryRunDrop, Detail: "severity " + l.Severity})
+	}
+	return kept
+}
+
+// storeMetric records a point that would be handed to the aggregator.
+func (r *DryRunReport) storeMetric(m tsdb.RawMetric) {
+	r.mu.Lock()
+	r.Stored.Metrics = append(r.Stored.Metrics, DryRunMetric{
+		TenantID:    m.TenantID,
+		Name:        m.Name,
+		ServiceName: m.ServiceName,
+		Value:       m.Value,
+		Timestamp:   m.Timestamp,
+		Attributes:  m.Attributes,
+	})
+	r.mu.Unlock()
+}
+
+// finish records the client-visible rejections and fills nil slices so
+// the JSON always has arrays.
+func (r *DryRunReport) finish(rejected *rejectTally) {
+	if counts := rejected.snapshot(); len(counts) > 0 {
+		r.Rejected = counts
+	}
+	if r.Decisions == nil {
+		r.Decisions = []DryRunDecision{}
+	}
+	if r.Stored.Traces == nil {
+		r.Stored.Traces = []storage.Trace{}
+	}
+	if r.Stored.Spans == nil {
+		r.Stored.Spans = []storage.Span{}
+	}
+	if r.Stored.Logs == nil {
+		r.Stored.Logs = []storage.Log{}
+	}
+	if r.Stored.Metrics == nil {
+		r.Stored.Metrics = []DryRunMetric{}
+	}
+}
+
+// truncateDryRunName shortens a log body for a decision's name field.
+func truncateDryRunName(s string) string {
+	const maxName = 120
+	if len(s) <= maxName {
+		return s
+	}
+	return s[:maxName] + "…"
+}
+
+// handleDryRun decodes an OTLP payload exactly like POST /v1/{signal} and
+// returns the DryRunReport for it as JSON. Runs under the same ingest
+// credentials, tenant header and service allowlist as a real export.
+func (h *HTTPHandler) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	signal := r.PathValue("signal")
+	var req proto.Message
+	switch signal {
+	case "traces":
+		req = &coltracepb.ExportTraceServiceRequest{}
+	case "logs":
+		req = &collogspb.ExportLogsServiceRequest{}
+	case "metrics":
+		req = &colmetricspb.ExportMetricsServiceRequest{}
+	default:
+		writeOTLPError(w, r, http.StatusNotFound, "unknown signal "+signal+"; want traces, logs or metrics")
+		return
+	}
+
+	body, err := h.readBody(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errDecompressedTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeOTLPError(w, r, status, err.Error())
+		return
+	}
+	if err := h.unmarshal(r, body, req); err != nil {
+		writeOTLPError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rep := &DryRunReport{Signal: signal}
+	ctx := withDryRun(withTenantFromHTTP(r), rep)
+	switch req := req.(type) {
+	case *coltracepb.ExportTraceServiceRequest:
+		for _, rs := range req.ResourceSpans {
+			rep.Received += countResourceSpans(rs.ScopeSpans)
+		}
+		_, err = h.traces.Export(ctx, req)
+	case *collogspb.ExportLogsServiceRequest:
+		for _, rl := range req.ResourceLogs {
+			rep.Received += countResourceLogs(rl.ScopeLogs)
+		}
+		_, err = h.logs.Export(ctx, req)
+	case *colmetricspb.ExportMetricsServiceRequest:
+		for _, rm := range req.ResourceMetrics {
+			rep.Received += countResourceDataPoints(rm.ScopeMetrics)
+		}
+		_, err = h.metrics.Export(ctx, req)
+	}
+	if err != nil {
+		writeOTLPError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestDryRun_Traces posts a payload that exercises every trace processor
+// and verifies the reported decisions, the stored form, and that nothing
+// reached the database.
+func TestDryRun_Traces(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG", IngestExcludedServices: "noisy"}
+	traces := NewTraceServer(repo, nil, cfg)
+	traces.SetValidator(NewValidator(time.Hour))
+	traces.SetHealthCheckFilter(NewHealthCheckFilter("/healthz", ""))
+	rw, err := NewSpanRewriter([]RewriteRule{{Service: "checkout", Match: map[string]string{"http.response.status_code": "404"}, SetStatus: "unset"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	traces.SetSpanRewriter(rw)
+	names, err := NewSpanNameNormalizer([]SpanNameRule{{Template: "/users/{id}"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traces.SetSpanNameNormalizer(names)
+	traces.SetSampler(NewSampler(0.5, true, 60_000))
+	h := NewHTTPHandler(traces, NewLogsServer(repo, nil, cfg), NewMetricsServer(repo, nil, nil, cfg))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	req := buildTracesRequest("checkout", 4)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	spans[0].Name = "GET /users/42"
+	spans[1].Attributes = []*commonpb.KeyValue{strAttr("http.route", "/healthz")}
+	spans[2].SpanId = nil
+	spans[3].Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+	spans[3].Attributes = []*commonpb.KeyValue{strAttr("http.response.status_code", "404")}
+	spans[3].Events = []*tracepb.Span_Event{{Name: "exception", TimeUnixNano: spans[3].StartTimeUnixNano, Attributes: []*commonpb.KeyValue{strAttr("exception.message", "not found")}}}
+	req.ResourceSpans = append(req.ResourceSpans, buildTracesRequest("noisy", 2).ResourceSpans...)
+	body, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/dry-run/traces", bytes.NewReader(body))
+	r.Header.Set("Content-Type", contentTypeProtobuf)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d body %q", rec.Code, rec.Body.String())
+	}
+	var rep DryRunReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if rep.Received != 6 || rep.Rejected[rejectServiceFiltered] != 2 || rep.Rejected[rejectHealthCheck] != 1 || rep.Rejected[rejectInvalidSpanID] != 1 {
+		t.Errorf("received %d, rejected %v", rep.Received, rep.Rejected)
+	}
+	seen := make(map[string]string)
+	for _, d := range rep.Decisions {
+		seen[d.Processor+"/"+d.Action] = d.Detail
+	}
+	for key, detail := range map[string]string{
+		"service_filter/drop": "service_filtered: 2 records",
+		"validator/drop":      rejectInvalidSpanID,
+		"health_check/drop":   rejectHealthCheck,
+		"rewrite/modify":      "status STATUS_CODE_ERROR -> STATUS_CODE_UNSET",
+		"span_names/modify":   "GET /users/42 -> GET /users/{id}",
+		"sampler/sample":      "kept at sampling rate 0.50",
+	} {
+		if got, ok := seen[key]; !ok || got != detail {
+			t.Errorf("decision %s = %q (present %v), want %q; all %+v", key, got, ok, detail, rep.Decisions)
+		}
+	}
+
+	if len(rep.Stored.Spans) != 2 || rep.Stored.Spans[0].OperationName != "GET /users/{id}" || rep.Stored.Spans[1].Status != "STATUS_CODE_UNSET" {
+		t.Errorf("stored spans = %+v", rep.Stored.Spans)
+	}
+	if len(rep.Stored.Traces) != 2 || len(rep.Stored.Logs) != 1 || rep.Stored.Logs[0].Body != "not found" {
+		t.Errorf("stored traces %d, logs %+v", len(rep.Stored.Traces), rep.Stored.Logs)
+	}
+
+	if recent, err := repo.RecentTraces(context.Background(), 10); err != nil || len(recent) != 0 {
+		t.Errorf("dry run persisted traces: %+v, %v", recent, err)
+	}
+	if logs, err := repo.GetRecentLogs(context.Background(), 10); err != nil || len(logs) != 0 {
+		t.Errorf("dry run persisted logs: %+v, %v", logs, err)
+	}
+	if seen, dropped := traces.sampler.Load().Stats(); seen != 0 || dropped != 0 {
+		t.Errorf("dry run touched sampler: seen %d dropped %d", seen, dropped)
+	}
+}
+
+func TestDryRun_UnknownSignal(t *testing.T) {
+	h := NewHTTPHandler(nil, nil, nil)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/dry-run/profiles", bytes.NewReader(nil)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404", rec.Code)
+	}
+}
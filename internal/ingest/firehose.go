@@ -0,0 +1,341 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const (
+	// firehoseMaxBody caps the wire body. Firehose HTTP delivery buffers at
+	// most 64 MiB per request; base64 adds a third on top.
+	firehoseMaxBody = 96 << 20
+
+	// firehoseAccessKeyHeader carries the access key configured on the
+	// Firehose HTTP endpoint destination.
+	firehoseAccessKeyHeader = "X-Amz-Firehose-Access-Key"
+	firehoseRequestIDHeader = "X-Amz-Firehose-Request-Id"
+
+	cwlDataMessage = "DATA_MESSAGE"
+)
+
+// firehoseRequest is the Kinesis Data Firehose HTTP endpoint delivery body.
+type firehoseRequest struct {
+	RequestID string `json:"requestId"`
+	Timestamp int64  `json:"timestamp"`
+	Records   []struct {
+		Data string `json:"data"`
+	} `json:"records"`
+}
+
+// firehoseResponse is the acknowledgement Firehose expects; any non-200
+// response (or one without the matching requestId) is retried.
+type firehoseResponse struct {
+	RequestID    string `json:"requestId"`
+	Timestamp    int64  `json:"timestamp"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// cwlSubscription is one CloudWatch Logs subscription-filter payload.
+type cwlSubscription struct {
+	MessageType string `json:"messageType"`
+	Owner       string `json:"owner"`
+	LogGroup    string `json:"logGroup"`
+	LogStream   string `json:"logStream"`
+	LogEvents   []struct {
+		ID        string `json:"id"`
+		Timestamp int64  `json:"timestamp"` // epoch millis
+		Message   string `json:"message"`
+	} `json:"logEvents"`
+}
+
+// LogGroupMapping maps a CloudWatch log group (exact, or a prefix ending in
+// "*") to a service name.
+type LogGroupMapping struct {
+	Pattern string
+	Service string
+}
+
+// ParseLogGroupServices parses "group=service,prefix*=service" as used by
+// FIREHOSE_LOG_GROUP_SERVICES.
+func ParseLogGroupServices(spec string) ([]LogGroupMapping, error) {
+	var out []LogGroupMapping
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, service, ok := strings.Cut(entry, "=")
+		group, service = strings.TrimSpace(group), strings.TrimSpace(service)
+		if !ok || group == "" || service == "" {
+			return nil, fmt.Errorf("invalid log group mapping %q, want group=service", entry)
+		}
+		out = append(out, LogGroupMapping{Pattern: group, Service: service})
+	}
+	return out, nil
+}
+
+// FirehoseHandler accepts Kinesis Data Firehose HTTP endpoint deliveries
+// carrying CloudWatch Logs subscription data (gzipped JSON per record), so
+// Lambda and other CloudWatch log groups can be shipped without a custom
+// forwarder. Records that are not subscription payloads are stored as one
+// log line each.
+//
+// The endpoint authenticates with the Firehose access key rather than the
+// API key: Firehose cannot send an Authorization header.
+type FirehoseHandler struct {
+	logs      *LogsServer
+	accessKey []byte
+	tenant    string
+	groups    []LogGroupMapping
+	metrics   *telemetry.Metrics
+}
+
+// NewFirehoseHandler creates a handler forwarding into logs. accessKey must
+// be non-empty. metrics may be nil.
+func NewFirehoseHandler(logs *LogsServer, accessKey string, metrics *telemetry.Metrics) *FirehoseHandler {
+	return &FirehoseHandler{logs: logs, accessKey: []byte(accessKey), metrics: metrics}
+}
+
+// SetTenant pins every delivery to tenant. Empty uses the default tenant.
+func (h *FirehoseHandler) SetTenant(tenant string) {
+	h.tenant = storage.SanitizeTenantID(tenant)
+}
+
+// SetLogGroupServices installs log group → service name mappings, checked
+// in order. Unmapped groups fall back to the Lambda function name for
+// "/aws/lambda/<fn>" groups and to the log group itself otherwise.
+func (h *FirehoseHandler) SetLogGroupServices(m []LogGroupMapping) {
+	h.groups = m
+}
+
+// RegisterRoutes registers POST /ingest/firehose.
+func (h *FirehoseHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /ingest/firehose", h.handle)
+}
+
+func (h *FirehoseHandler) handle(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Header.Get(firehoseRequestIDHeader)
+	key := []byte(r.Header.Get(firehoseAccessKeyHeader))
+	if len(key) != len(h.accessKey) || subtle.ConstantTimeCompare(key, h.accessKey) != 1 {
+		slog.Warn("Firehose: rejected delivery with invalid access key", "remote", r.RemoteAddr)
+		writeFirehoseResponse(w, http.StatusUnauthorized, reqID, "invalid access key")
+		return
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, firehoseMaxBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			writeFirehoseResponse(w, http.StatusBadRequest, reqID, "invalid gzip body")
+			return
+		}
+		defer func() { _ = gz.Close() }()
+		body = io.LimitReader(gz, maxDecompressedBody)
+	}
+	var req firehoseRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		writeFirehoseResponse(w, http.StatusBadRequest, reqID, "invalid Firehose request body")
+		return
+	}
+	if req.RequestID != "" {
+		reqID = req.RequestID
+	}
+
+	export, stats := h.convert(&req)
+	h.metrics.RecordFirehoseRecords("ok", stats.ok)
+	h.metrics.RecordFirehoseRecords("decode_error", stats.decodeErrors)
+	h.metrics.RecordFirehoseRecords("control", stats.control)
+
+	if len(export.ResourceLogs) > 0 {
+		ctx := r.Context()
+		if h.tenant != "" {
+			ctx = storage.WithTenantContext(ctx, h.tenant)
+		}
+		if _, err := h.logs.Export(ctx, export); err != nil {
+			if isQueueFull(err) {
+				// Firehose retries non-200s with backoff.
+				writeFirehoseResponse(w, http.StatusServiceUnavailable, reqID, "ingest pipeline at capacity")
+				return
+			}
+			slog.Error("Firehose logs export failed", "error", err)
+			writeFirehoseResponse(w, http.StatusInternalServerError, reqID, "export failed")
+			return
+		}
+	}
+	writeFirehoseResponse(w, http.StatusOK, reqID, "")
+}
+
+type firehoseStats struct {
+	ok, decodeErrors, control int
+}
+
+// convert decodes every record into one OTLP resource per log group and
+// stream. Undecodable records are counted and skipped so one bad record
+// does not make Firehose retry (and duplicate) the whole batch.
+func (h *FirehoseHandler) convert(req *firehoseRequest) (*collogspb.ExportLogsServiceRequest, firehoseStats) {
+	var stats firehoseStats
+	resources := make(map[string]*logspb.ScopeLogs)
+	out := &collogspb.ExportLogsServiceRequest{}
+	scopeFor := func(service string, attrs ...*commonpb.KeyValue) *logspb.ScopeLogs {
+		key := service
+		for _, kv := range attrs {
+			key += "|" + kv.Value.GetStringValue()
+		}
+		if sl, ok := resources[key]; ok {
+			return sl
+		}
+		sl := &logspb.ScopeLogs{Scope: &commonpb.InstrumentationScope{Name: "firehose"}}
+		resources[key] = sl
+		out.ResourceLogs = append(out.ResourceLogs, &logspb.ResourceLogs{
+			Resource:  &resourcepb.Resource{Attributes: append([]*commonpb.KeyValue{stringKV("service.name", service), stringKV("cloud.provider", "aws")}, attrs...)},
+			ScopeLogs: []*logspb.ScopeLogs{sl},
+		})
+		return sl
+	}
+	fallbackTS := time.UnixMilli(req.Timestamp)
+	if req.Timestamp <= 0 {
+		fallbackTS = time.Now()
+	}
+
+	for _, rec := range req.Records {
+		data, err := base64.StdEncoding.DecodeString(rec.Data)
+		if err != nil {
+			stats.decodeErrors++
+			continue
+		}
+		if sub, ok, err := decodeCWLSubscription(data); ok {
+			if err != nil {
+				stats.decodeErrors++
+				continue
+			}
+			if sub.MessageType != cwlDataMessage {
+				stats.control++ // CONTROL_MESSAGE reachability probes
+				continue
+			}
+			sl := scopeFor(h.serviceFor(sub.LogGroup),
+				stringKV("aws.log.group.names", sub.LogGroup),
+				stringKV("aws.log.stream.names", sub.LogStream),
+				stringKV("cloud.account.id", sub.Owner))
+			for _, ev := range sub.LogEvents {
+				sl.LogRecords = append(sl.LogRecords, cwlLogRecord(time.UnixMilli(ev.Timestamp), ev.Message))
+			}
+			stats.ok++
+			continue
+		}
+		line := strings.TrimRight(string(data), "\n")
+		if line == "" {
+			continue
+		}
+		sl := scopeFor("firehose")
+		sl.LogRecords = append(sl.LogRecords, cwlLogRecord(fallbackTS, line))
+		stats.ok++
+	}
+	return out, stats
+}
+
+// decodeCWLSubscription decodes a gzipped CloudWatch Logs subscription
+// payload. ok is false when data is not gzip at all (a plain record).
+func decodeCWLSubscription(data []byte) (sub cwlSubscription, ok bool, err error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return sub, false, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return sub, true, err
+	}
+	defer func() { _ = gz.Close() }()
+	if err := json.NewDecoder(io.LimitReader(gz, maxDecompressedBody)).Decode(&sub); err != nil {
+		return sub, true, err
+	}
+	if sub.MessageType == "" {
+		return sub, true, errors.New("missing messageType")
+	}
+	return sub, true, nil
+}
+
+// serviceFor maps a log group to a service name.
+func (h *FirehoseHandler) serviceFor(group string) string {
+	for _, m := range h.groups {
+		if prefix, ok := strings.CutSuffix(m.Pattern, "*"); ok {
+			if strings.HasPrefix(group, prefix) {
+				return m.Service
+			}
+		} else if group == m.Pattern {
+			return m.Service
+		}
+	}
+	if fn, ok := strings.CutPrefix(group, "/aws/lambda/"); ok && fn != "" {
+		return fn
+	}
+	return group
+}
+
+func cwlLogRecord(ts time.Time, msg string) *logspb.LogRecord {
+	msg = strings.TrimRight(msg, "\n")
+	return &logspb.LogRecord{
+		TimeUnixNano:         uint64(ts.UnixNano()),         // #nosec G115 -- epoch millis from AWS are positive
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()), // #nosec G115 -- wall clock is positive
+		SeverityText:         lambdaSeverity(msg),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: msg}},
+	}
+}
+
+// lambdaSeverity extracts the level from the Lambda runtime log formats:
+// Node.js "<ts>\t<request-id>\tERROR\t…", Python "[ERROR]\t…", and the
+// JSON log format {"level":"ERROR",…}. Anything else is INFO.
+func lambdaSeverity(msg string) string {
+	level := ""
+	switch {
+	case strings.HasPrefix(msg, "{"):
+		var v struct {
+			Level string `json:"level"`
+		}
+		if json.Unmarshal([]byte(msg), &v) == nil {
+			level = v.Level
+		}
+	case strings.HasPrefix(msg, "["):
+		if end := strings.IndexByte(msg, ']'); end > 0 {
+			level = msg[1:end]
+		}
+	default:
+		if fields := strings.SplitN(msg, "\t", 4); len(fields) == 4 {
+			level = fields[2]
+		}
+	}
+	switch lvl := strings.ToUpper(level); lvl {
+	case "TRACE", "DEBUG", "INFO", "ERROR", "FATAL":
+		return lvl
+	case "WARN", "WARNING":
+		return "WARN"
+	case "CRITICAL":
+		return "FATAL"
+	}
+	return "INFO"
+}
+
+func writeFirehoseResponse(w http.ResponseWriter, status int, reqID, errMsg string) {
+	w.Header().Set(headerContentType, contentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(firehoseResponse{
+		RequestID:    reqID,
+		Timestamp:    time.Now().UnixMilli(),
+		ErrorMessage: errMsg,
+	})
+}
@@ -0,0 +1,138 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func gzipJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(v); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+func firehoseBody(t *testing.T, records ...[]byte) []byte {
+	t.Helper()
+	req := map[string]any{"requestId": "req-1", "timestamp": 1_700_000_000_000}
+	var recs []map[string]string
+	for _, r := range records {
+		recs = append(recs, map[string]string{"data": base64.StdEncoding.EncodeToString(r)})
+	}
+	req["records"] = recs
+	b, _ := json.Marshal(req)
+	return b
+}
+
+func TestFirehoseHandler_CloudWatchLogs(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	groups, err := ParseLogGroupServices("/aws/ecs/web*=web-frontend")
+	if err != nil {
+		t.Fatalf("ParseLogGroupServices: %v", err)
+	}
+	h := NewFirehoseHandler(logs, "s3cret", nil)
+	h.SetTenant("acme")
+	h.SetLogGroupServices(groups)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	body := firehoseBody(t,
+		gzipJSON(t, map[string]any{
+			"messageType": "DATA_MESSAGE", "owner": "123456789012",
+			"logGroup": "/aws/lambda/checkout-fn", "logStream": "2024/01/01/[$LATEST]abc",
+			"logEvents": []map[string]any{
+				{"id": "1", "timestamp": 1_700_000_000_000, "message": "2023-11-14T22:13:20.000Z\tabc-123\tERROR\tpayment declined\n"},
+				{"id": "2", "timestamp": 1_700_000_000_001, "message": "[WARNING]\t2023-11-14T22:13:20.001Z\tabc-123\tretrying"},
+			},
+		}),
+		gzipJSON(t, map[string]any{
+			"messageType": "DATA_MESSAGE", "logGroup": "/aws/ecs/web-prod", "logStream": "task-1",
+			"logEvents": []map[string]any{{"id": "3", "timestamp": 1_700_000_000_002, "message": `{"level":"debug","msg":"hi"}`}},
+		}),
+		gzipJSON(t, map[string]any{"messageType": "CONTROL_MESSAGE", "logGroup": "", "logEvents": []any{}}),
+		[]byte("plain record\n"),
+		[]byte{0x1f, 0x8b, 0x00}, // truncated gzip: counted, skipped
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/ingest/firehose", bytes.NewReader(body))
+	req.Header.Set(firehoseAccessKeyHeader, "s3cret")
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body)
+	}
+	var resp firehoseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.RequestID != "req-1" {
+		t.Errorf("response = %s", rec.Body)
+	}
+
+	var stored []storage.Log
+	if err := repo.DB().Order("id").Find(&stored).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := []struct{ service, severity, body string }{
+		{"checkout-fn", "ERROR", "2023-11-14T22:13:20.000Z\tabc-123\tERROR\tpayment declined"},
+		{"checkout-fn", "WARN", "[WARNING]\t2023-11-14T22:13:20.001Z\tabc-123\tretrying"},
+		{"web-frontend", "DEBUG", `{"level":"debug","msg":"hi"}`},
+		{"firehose", "INFO", "plain record"},
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("stored %d logs, want %d: %+v", len(stored), len(want), stored)
+	}
+	for i, w := range want {
+		got := stored[i]
+		if got.ServiceName != w.service || got.Severity != w.severity || got.Body != w.body || got.TenantID != "acme" {
+			t.Errorf("log[%d] = {%s %s %q %s}, want %+v", i, got.ServiceName, got.Severity, got.Body, got.TenantID, w)
+		}
+	}
+}
+
+func TestFirehoseHandler_RejectsBadAccessKey(t *testing.T) {
+	h := NewFirehoseHandler(nil, "s3cret", nil)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/ingest/firehose", bytes.NewReader(firehoseBody(t)))
+	req.Header.Set(firehoseAccessKeyHeader, "wrong")
+	req.Header.Set(firehoseRequestIDHeader, "req-9")
+	h.handle(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+	var resp firehoseResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.RequestID != "req-9" || resp.ErrorMessage == "" {
+		t.Errorf("response = %s", rec.Body)
+	}
+}
+
+func TestParseLogGroupServices(t *testing.T) {
+	m, err := ParseLogGroupServices(" /aws/lambda/a = svc-a , /ecs/*=ecs ")
+	if err != nil || len(m) != 2 || m[0] != (LogGroupMapping{"/aws/lambda/a", "svc-a"}) {
+		t.Fatalf("got %v, %v", m, err)
+	}
+	h := &FirehoseHandler{groups: m}
+	for group, want := range map[string]string{
+		"/aws/lambda/a":   "svc-a",
+		"/aws/lambda/b":   "b",
+		"/ecs/web":        "ecs",
+		"/custom/group-1": "/custom/group-1",
+	} {
+		if got := h.serviceFor(group); got != want {
+			t.Errorf("serviceFor(%q) = %q, want %q", group, got, want)
+		}
+	}
+	if _, err := ParseLogGroupServices("no-equals"); err == nil {
+		t.Error("want error for entry without '='")
+	}
+}
@@ -0,0 +1,82 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// FixtureRecorder captures live OTLP export requests as golden test fixtures:
+// one OTLP/JSON file per request, named <prefix>.<signal>.json, the layout
+// TestGoldenFixtures reads from internal/ingest/testdata/golden. Requests are
+// written as received — before service filters, validation and sampling —
+// so a recorded fixture replays the whole ingest path. Enabled via
+// INGEST_RECORD_FIXTURES_DIR; stops after INGEST_RECORD_FIXTURES_MAX files
+// so a forgotten setting cannot fill the disk.
+//
+// Payloads are written verbatim: review and scrub them before committing.
+// Safe for concurrent use.
+type FixtureRecorder struct {
+	dir    string
+	max    int64
+	prefix string
+	n      atomic.Int64
+}
+
+// NewFixtureRecorder creates dir and returns a recorder writing at most
+// limit files into it (limit < 1 = 100). Returns nil for an empty dir.
+func NewFixtureRecorder(dir string, limit int) (*FixtureRecorder, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if limit < 1 {
+		limit = 100
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("fixture recorder: %w", err)
+	}
+	return &FixtureRecorder{
+		dir:    dir,
+		max:    int64(limit),
+		prefix: "recorded-" + time.Now().UTC().Format("20060102T150405Z"),
+	}, nil
+}
+
+// record writes msg as the next fixture for signal (traces, logs or
+// metrics). Failures are logged, never returned — recording must not
+// affect ingest.
+func (r *FixtureRecorder) record(signal string, msg proto.Message) {
+	seq := r.n.Add(1)
+	if seq > r.max {
+		return
+	}
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		slog.Warn("fixture recorder: marshal failed", "signal", signal, "error", err)
+		return
+	}
+	// protojson deliberately varies its whitespace; re-indent so fixtures
+	// are stable and reviewable.
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		slog.Warn("fixture recorder: marshal failed", "signal", signal, "error", err)
+		return
+	}
+	buf.WriteByte('\n')
+	path := filepath.Join(r.dir, fmt.Sprintf("%s-%04d.%s.json", r.prefix, seq, signal))
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		slog.Warn("fixture recorder: write failed", "path", path, "error", err)
+		return
+	}
+	if seq == r.max {
+		slog.Info("fixture recorder: limit reached, recording stopped", "dir", r.dir, "files", seq)
+	}
+}
@@ -0,0 +1,347 @@
+package ingest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+const (
+	// githubWebhookMaxBody matches GitHub's own 25 MB payload cap.
+	githubWebhookMaxBody = 25 << 20
+
+	githubEventHeader     = "X-GitHub-Event"
+	githubSignatureHeader = "X-Hub-Signature-256"
+)
+
+// Deployment sources recorded on storage.Deployment.Source.
+const (
+	deploySourceGitHubDeployment = "github_deployment"
+	deploySourceGitHubWorkflow   = "github_workflow"
+)
+
+type githubRepository struct {
+	Name          string `json:"name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubDeployment struct {
+	ID          int64           `json:"id"`
+	SHA         string          `json:"sha"`
+	Ref         string          `json:"ref"`
+	Environment string          `json:"environment"`
+	Description string          `json:"description"`
+	Payload     json.RawMessage `json:"payload"`
+	Creator     githubUser      `json:"creator"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// githubEvent holds the fields used from deployment, deployment_status and
+// workflow_run payloads; each event fills its own subset.
+type githubEvent struct {
+	Action           string            `json:"action"`
+	Repository       githubRepository  `json:"repository"`
+	Deployment       *githubDeployment `json:"deployment"`
+	DeploymentStatus *struct {
+		State       string     `json:"state"`
+		Environment string     `json:"environment"`
+		Description string     `json:"description"`
+		TargetURL   string     `json:"target_url"`
+		LogURL      string     `json:"log_url"`
+		Creator     githubUser `json:"creator"`
+		CreatedAt   time.Time  `json:"created_at"`
+	} `json:"deployment_status"`
+	WorkflowRun *struct {
+		ID           int64      `json:"id"`
+		Name         string     `json:"name"`
+		DisplayTitle string     `json:"display_title"`
+		HeadBranch   string     `json:"head_branch"`
+		HeadSHA      string     `json:"head_sha"`
+		Conclusion   string     `json:"conclusion"`
+		HTMLURL      string     `json:"html_url"`
+		Actor        githubUser `json:"actor"`
+		UpdatedAt    time.Time  `json:"updated_at"`
+	} `json:"workflow_run"`
+}
+
+// GitHubWebhookHandler turns GitHub deployment, deployment_status and
+// workflow_run webhooks into deploy annotations (storage.Deployment), so
+// pipelines need no explicit deploy-marker step. A service's current version
+// is its latest successful deployment.
+//
+// The service is the deployment payload's "service" key, else the repository
+// name; the version is the payload's "version" key, else a tag ref, else the
+// short commit SHA. Workflow runs count as deployments only on the default
+// branch, and only for the configured workflow names when any are set.
+//
+// The endpoint authenticates with the webhook secret (X-Hub-Signature-256)
+// rather than the API key: GitHub cannot send a bearer token.
+type GitHubWebhookHandler struct {
+	repo      *storage.Repository
+	secret    []byte
+	tenant    string
+	workflows map[string]bool
+	metrics   *telemetry.Metrics
+}
+
+// NewGitHubWebhookHandler creates a handler recording into repo. secret must
+// be non-empty. metrics may be nil.
+func NewGitHubWebhookHandler(repo *storage.Repository, secret string, metrics *telemetry.Metrics) *GitHubWebhookHandler {
+	return &GitHubWebhookHandler{repo: repo, secret: []byte(secret), metrics: metrics}
+}
+
+// SetTenant pins every event to tenant. Empty uses the default tenant.
+func (h *GitHubWebhookHandler) SetTenant(tenant string) {
+	h.tenant = storage.SanitizeTenantID(tenant)
+}
+
+// SetDeployWorkflows limits workflow_run events to the comma-separated
+// workflow names. Empty accepts every workflow.
+func (h *GitHubWebhookHandler) SetDeployWorkflows(names string) {
+	h.workflows = parseServiceList(names)
+}
+
+// RegisterRoutes registers POST /ingest/github.
+func (h *GitHubWebhookHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /ingest/github", h.handle)
+}
+
+func (h *GitHubWebhookHandler) handle(w http.ResponseWriter, r *http.Request) {
+	event := r.Header.Get(githubEventHeader)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, githubWebhookMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !h.validSignature(r.Header.Get(githubSignatureHeader), body) {
+		h.metrics.RecordGitHubWebhookEvent(event, "unauthorized")
+		slog.Warn("GitHub webhook: rejected delivery with invalid signature", "remote", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if event == "ping" {
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "pong")
+		return
+	}
+
+	var ev githubEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		h.metrics.RecordGitHubWebhookEvent(event, "invalid")
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	var d *storage.Deployment
+	switch event {
+	case "deployment":
+		d = deploymentFromGitHub(&ev)
+	case "deployment_status":
+		d = deploymentStatusFromGitHub(&ev)
+	case "workflow_run":
+		d = h.workflowRunFromGitHub(&ev)
+	}
+	if d == nil {
+		h.metrics.RecordGitHubWebhookEvent(event, "ignored")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(w, "ignored")
+		return
+	}
+
+	ctx := r.Context()
+	if h.tenant != "" {
+		ctx = storage.WithTenantContext(ctx, h.tenant)
+	}
+	if err := h.repo.UpsertDeployment(ctx, d); err != nil {
+		h.metrics.RecordGitHubWebhookEvent(event, "error")
+		slog.Error("GitHub webhook: failed to record deployment", "event", event, "service", d.ServiceName, "error", err)
+		http.Error(w, "failed to record deployment", http.StatusInternalServerError)
+		return
+	}
+	h.metrics.RecordGitHubWebhookEvent(event, "ok")
+	slog.Info("🚀 Deployment recorded", "service", d.ServiceName, "version", d.Version, "status", d.Status, "source", d.Source)
+	w.WriteHeader(http.StatusOK)
+}
+
+// validSignature checks "sha256=<hex HMAC of body>" in constant time.
+func (h *GitHubWebhookHandler) validSignature(header string, body []byte) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// deploymentFromGitHub records a newly created deployment as pending.
+func deploymentFromGitHub(ev *githubEvent) *storage.Deployment {
+	if ev.Deployment == nil {
+		return nil
+	}
+	d := githubDeploymentBase(ev)
+	d.Status = storage.DeploymentPending
+	d.Timestamp = ev.Deployment.CreatedAt
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now()
+	}
+	return d
+}
+
+// deploymentStatusFromGitHub amends the deployment with its new state.
+// "inactive" (superseded by a newer deployment) is ignored so it does not
+// overwrite the success it replaces.
+func deploymentStatusFromGitHub(ev *githubEvent) *storage.Deployment {
+	st := ev.DeploymentStatus
+	if ev.Deployment == nil || st == nil {
+		return nil
+	}
+	var status string
+	switch st.State {
+	case "success":
+		status = storage.DeploymentSuccess
+	case "failure", "error":
+		status = storage.DeploymentFailure
+	case "in_progress", "queued":
+		status = storage.DeploymentInProgress
+	case "pending":
+		status = storage.DeploymentPending
+	default:
+		return nil
+	}
+	d := githubDeploymentBase(ev)
+	d.Status = status
+	if st.Environment != "" {
+		d.Environment = st.Environment
+	}
+	if st.Description != "" {
+		d.Description = st.Description
+	}
+	d.URL = st.LogURL
+	if d.URL == "" {
+		d.URL = st.TargetURL
+	}
+	if st.Creator.Login != "" {
+		d.Actor = st.Creator.Login
+	}
+	d.Timestamp = st.CreatedAt
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now()
+	}
+	return d
+}
+
+func githubDeploymentBase(ev *githubEvent) *storage.Deployment {
+	dep := ev.Deployment
+	service, version := githubDeployPayload(dep.Payload)
+	if service == "" {
+		service = ev.Repository.Name
+	}
+	if version == "" {
+		version = githubRefVersion(dep.Ref, dep.SHA, ev.Repository.DefaultBranch)
+	}
+	return &storage.Deployment{
+		ExternalID:  "github:deployment:" + strconv.FormatInt(dep.ID, 10),
+		Source:      deploySourceGitHubDeployment,
+		ServiceName: service,
+		Version:     version,
+		Environment: dep.Environment,
+		Ref:         dep.Ref,
+		CommitSHA:   dep.SHA,
+		Actor:       dep.Creator.Login,
+		Description: dep.Description,
+	}
+}
+
+// workflowRunFromGitHub records completed runs of deploy workflows on the
+// default branch. Cancelled and skipped runs deployed nothing.
+func (h *GitHubWebhookHandler) workflowRunFromGitHub(ev *githubEvent) *storage.Deployment {
+	run := ev.WorkflowRun
+	if run == nil || ev.Action != "completed" {
+		return nil
+	}
+	if run.HeadBranch != ev.Repository.DefaultBranch {
+		return nil
+	}
+	if len(h.workflows) > 0 && !h.workflows[run.Name] {
+		return nil
+	}
+	var status string
+	switch run.Conclusion {
+	case "success":
+		status = storage.DeploymentSuccess
+	case "failure", "timed_out":
+		status = storage.DeploymentFailure
+	default:
+		return nil
+	}
+	ts := run.UpdatedAt
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	return &storage.Deployment{
+		ExternalID:  fmt.Sprintf("github:workflow_run:%d", run.ID),
+		Source:      deploySourceGitHubWorkflow,
+		ServiceName: ev.Repository.Name,
+		Version:     shortSHA(run.HeadSHA),
+		Status:      status,
+		Ref:         run.HeadBranch,
+		CommitSHA:   run.HeadSHA,
+		URL:         run.HTMLURL,
+		Actor:       run.Actor.Login,
+		Description: strings.TrimSpace(run.Name + ": " + run.DisplayTitle),
+		Timestamp:   ts,
+	}
+}
+
+// githubDeployPayload reads "service" and "version" from a deployment's
+// custom payload, which GitHub passes through as an object or a JSON string.
+func githubDeployPayload(raw json.RawMessage) (service, version string) {
+	var p struct {
+		Service string `json:"service"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		var s string
+		if json.Unmarshal(raw, &s) != nil || json.Unmarshal([]byte(s), &p) != nil {
+			return "", ""
+		}
+	}
+	return strings.TrimSpace(p.Service), strings.TrimSpace(p.Version)
+}
+
+// githubRefVersion uses the ref as the version when it names a tag or
+// release branch, and the short SHA when it is the default branch or the
+// SHA itself.
+func githubRefVersion(ref, sha, defaultBranch string) string {
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/tags/"), "refs/heads/")
+	if ref == "" || ref == defaultBranch || ref == sha {
+		return shortSHA(sha)
+	}
+	return ref
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
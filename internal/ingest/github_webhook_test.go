@@ -0,0 +1,129 @@
+package ingest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const testGitHubSecret = "s3cret"
+
+func postGitHub(t *testing.T, h *GitHubWebhookHandler, event, body, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/ingest/github", strings.NewReader(body))
+	req.Header.Set(githubEventHeader, event)
+	req.Header.Set(githubSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGitHubWebhook_DeploymentLifecycle(t *testing.T) {
+	repo := newTestRepo(t)
+	h := NewGitHubWebhookHandler(repo, testGitHubSecret, nil)
+	h.SetTenant("acme")
+
+	const deployment = `"deployment":{"id":42,"sha":"0123456789abcdef","ref":"v2.3.0","environment":"production",
+		"payload":{"service":"checkout-api"},"creator":{"login":"octocat"},"created_at":"2026-03-01T12:00:00Z"},
+		"repository":{"name":"checkout","default_branch":"main"}`
+
+	if rec := postGitHub(t, h, "deployment", `{"action":"created",`+deployment+`}`, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad signature: status = %d, want 401", rec.Code)
+	}
+	if rec := postGitHub(t, h, "ping", `{"zen":"hi"}`, testGitHubSecret); rec.Code != http.StatusOK {
+		t.Fatalf("ping: status = %d", rec.Code)
+	}
+	if rec := postGitHub(t, h, "deployment", `{"action":"created",`+deployment+`}`, testGitHubSecret); rec.Code != http.StatusOK {
+		t.Fatalf("deployment: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	status := `{"action":"created","deployment_status":{"state":"success","log_url":"https://github.com/acme/checkout/actions/runs/1",
+		"created_at":"2026-03-01T12:05:00Z"},` + deployment + `}`
+	if rec := postGitHub(t, h, "deployment_status", status, testGitHubSecret); rec.Code != http.StatusOK {
+		t.Fatalf("deployment_status: status = %d (%s)", rec.Code, rec.Body.String())
+	}
+	inactive := strings.Replace(status, `"success"`, `"inactive"`, 1)
+	if rec := postGitHub(t, h, "deployment_status", inactive, testGitHubSecret); rec.Code != http.StatusAccepted {
+		t.Fatalf("inactive: status = %d, want 202 ignored", rec.Code)
+	}
+
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	got, err := repo.ListDeployments(ctx, "", time.Time{}, time.Time{}, 0)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("deployments = %+v, %v", got, err)
+	}
+	d := got[0]
+	if d.ServiceName != "checkout-api" || d.Version != "v2.3.0" || d.Status != storage.DeploymentSuccess ||
+		d.Environment != "production" || d.Actor != "octocat" || !strings.HasSuffix(d.URL, "/runs/1") {
+		t.Errorf("deployment = %+v", d)
+	}
+	if !d.Timestamp.Equal(time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)) {
+		t.Errorf("timestamp = %v, want the success time", d.Timestamp)
+	}
+}
+
+func TestGitHubWebhook_WorkflowRunFilters(t *testing.T) {
+	repo := newTestRepo(t)
+	h := NewGitHubWebhookHandler(repo, testGitHubSecret, nil)
+	h.SetDeployWorkflows("Deploy")
+
+	run := func(id, name, branch, conclusion string) string {
+		return `{"action":"completed","workflow_run":{"id":` + id + `,"name":"` + name + `","head_branch":"` + branch +
+			`","head_sha":"feedfacecafebeef","conclusion":"` + conclusion + `","updated_at":"2026-03-02T08:00:00Z"},
+			"repository":{"name":"billing","default_branch":"main"}}`
+	}
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"deploy on main":     {run("1", "Deploy", "main", "success"), http.StatusOK},
+		"feature branch":     {run("2", "Deploy", "feature/x", "success"), http.StatusAccepted},
+		"other workflow":     {run("3", "Lint", "main", "success"), http.StatusAccepted},
+		"cancelled":          {run("4", "Deploy", "main", "cancelled"), http.StatusAccepted},
+		"not completed":      {strings.Replace(run("5", "Deploy", "main", ""), "completed", "in_progress", 1), http.StatusAccepted},
+		"failed deploy":      {run("6", "Deploy", "main", "failure"), http.StatusOK},
+		"unsupported event":  {`{"action":"opened"}`, http.StatusAccepted},
+		"invalid JSON (400)": {`{`, http.StatusBadRequest},
+	} {
+		event := "workflow_run"
+		if strings.HasPrefix(name, "unsupported") {
+			event = "issues"
+		}
+		if rec := postGitHub(t, h, event, tc.body, testGitHubSecret); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+
+	versions, err := repo.ListServiceVersions(context.Background())
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("versions = %+v, %v", versions, err)
+	}
+	if v := versions[0]; v.ServiceName != "billing" || v.Version != "feedfac" || v.Source != deploySourceGitHubWorkflow {
+		t.Errorf("version = %+v", v)
+	}
+}
+
+func TestGitHubRefVersion(t *testing.T) {
+	for _, tc := range []struct{ ref, want string }{
+		{"refs/tags/v1.0.0", "v1.0.0"},
+		{"release/2026-03", "release/2026-03"},
+		{"main", "abcdef1"},
+		{"abcdef1234567", "abcdef1"},
+		{"", "abcdef1"},
+	} {
+		if got := githubRefVersion(tc.ref, "abcdef1234567", "main"); got != tc.want {
+			t.Errorf("githubRefVersion(%q) = %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Golden fixtures live in testdata/golden as pairs:
+//
+//	<name>.<signal>.json         OTLP/JSON export request (signal = traces | logs | metrics)
+//	<name>.<signal>.golden.json  what ingest stored for it
+//
+// To add one, drop a request file in (hand-written, or captured from live
+// traffic with INGEST_RECORD_FIXTURES_DIR) and run
+//
+//	go test ./internal/ingest -run TestGoldenFixtures -update
+//
+// then review the generated snapshot like any other code change.
+var updateGolden = flag.Bool("update", false, "rewrite testdata/golden snapshots from current ingest output")
+
+const goldenDir = "testdata/golden"
+
+// goldenConfig is the ingest configuration every fixture is replayed with.
+// Changing it changes every snapshot.
+func goldenConfig() *config.Config {
+	return &config.Config{
+		IngestMinSeverity:      "INFO",
+		IngestExcludedServices: "excluded-service",
+		DefaultTenant:          storage.DefaultTenantID,
+	}
+}
+
+// goldenSnapshot is the stored state for one fixture, normalised so it is
+// stable across runs: row IDs dropped, times in UTC, rows sorted.
+type goldenSnapshot struct {
+	Rejected *goldenRejected `json:"rejected,omitempty"`
+	Traces   []goldenTrace   `json:"traces,omitempty"`
+	Spans    []goldenSpan    `json:"spans,omitempty"`
+	Logs     []goldenLog     `json:"logs,omitempty"`
+	Metrics  []goldenMetric  `json:"metrics,omitempty"`
+}
+
+type goldenRejected struct {
+	Count   int64  `json:"count"`
+	Message string `json:"message"`
+}
+
+type goldenTrace struct {
+	TraceID     string    `json:"trace_id"`
+	ServiceName string    `json:"service_name"`
+	Status      string    `json:"status"`
+	DurationUs  int64     `json:"duration_us"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type goldenSpan struct {
+	TraceID       string          `json:"trace_id"`
+	SpanID        string          `json:"span_id"`
+	ParentSpanID  string          `json:"parent_span_id,omitempty"`
+	ServiceName   string          `json:"service_name"`
+	OperationName string          `json:"operation_name"`
+	Status        string          `json:"status"`
+	StartTime     time.Time       `json:"start_time"`
+	DurationUs    int64           `json:"duration_us"`
+	Attributes    json.RawMessage `json:"attributes,omitempty"`
+}
+
+type goldenLog struct {
+	TraceID     string          `json:"trace_id,omitempty"`
+	SpanID      string          `json:"span_id,omitempty"`
+	ServiceName string          `json:"service_name"`
+	Severity    string          `json:"severity"`
+	Body        string          `json:"body"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Attributes  json.RawMessage `json:"attributes,omitempty"`
+}
+
+type goldenMetric struct {
+	Name        string         `json:"name"`
+	ServiceName string         `json:"service_name"`
+	Value       float64        `json:"value"`
+	Timestamp   time.Time      `json:"timestamp"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+func TestGoldenFixtures(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join(goldenDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".golden.json") {
+			continue
+		}
+		n++
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".json"), func(t *testing.T) {
+			assertGolden(t, strings.TrimSuffix(path, ".json")+".golden.json", replayFixture(t, path))
+		})
+	}
+	if n == 0 {
+		t.Fatalf("no fixtures found in %s", goldenDir)
+	}
+}
+
+// replayFixture exports the request in path through a fresh in-memory
+// repository and snapshots what was stored.
+func replayFixture(t *testing.T, path string) goldenSnapshot {
+	t.Helper()
+	data, err := os.ReadFile(path) // #nosec G304 -- test fixture
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestRepo(t)
+	cfg := goldenConfig()
+	ctx := storage.WithTenantContext(context.Background(), cfg.DefaultTenant)
+
+	var snap goldenSnapshot
+	var rejected *goldenRejected
+	traceIDs := map[string]bool{}
+	switch signal := filepath.Ext(strings.TrimSuffix(path, ".json")); signal {
+	case ".traces":
+		req := &coltracepb.ExportTraceServiceRequest{}
+		if err := protojson.Unmarshal(data, req); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		srv := NewTraceServer(repo, nil, cfg)
+		var mu sync.Mutex
+		srv.SetSpanCallback(func(s storage.Span) {
+			mu.Lock()
+			traceIDs[s.TraceID] = true
+			mu.Unlock()
+		})
+		resp, err := srv.Export(ctx, req)
+		if err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		if ps := resp.GetPartialSuccess(); ps != nil {
+			rejected = &goldenRejected{Count: ps.GetRejectedSpans(), Message: ps.GetErrorMessage()}
+		}
+	case ".logs":
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := protojson.Unmarshal(data, req); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		resp, err := NewLogsServer(repo, nil, cfg).Export(ctx, req)
+		if err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		if ps := resp.GetPartialSuccess(); ps != nil {
+			rejected = &goldenRejected{Count: ps.GetRejectedLogRecords(), Message: ps.GetErrorMessage()}
+		}
+	case ".metrics":
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		if err := protojson.Unmarshal(data, req); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		// Metric points are aggregated in memory and flushed later, so the
+		// snapshot is the flattened points handed to the aggregator.
+		srv := NewMetricsServer(repo, nil, nil, cfg)
+		var mu sync.Mutex
+		srv.SetMetricCallback(func(m tsdb.RawMetric) {
+			mu.Lock()
+			snap.Metrics = append(snap.Metrics, goldenMetric{
+				Name: m.Name, ServiceName: m.ServiceName, Value: m.Value,
+				Timestamp: m.Timestamp.UTC(), Attributes: m.Attributes,
+			})
+			mu.Unlock()
+		})
+		resp, err := srv.Export(ctx, req)
+		if err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		if ps := resp.GetPartialSuccess(); ps != nil {
+			rejected = &goldenRejected{Count: ps.GetRejectedDataPoints(), Message: ps.GetErrorMessage()}
+		}
+	default:
+		t.Fatalf("%s: name must end in .traces.json, .logs.json or .metrics.json", path)
+	}
+	snap.Rejected = rejected
+
+	for id := range traceIDs {
+		tr, err := repo.GetTrace(ctx, id)
+		if err != nil {
+			t.Fatalf("GetTrace(%s): %v", id, err)
+		}
+		snap.Traces = append(snap.Traces, goldenTrace{
+			TraceID: tr.TraceID, ServiceName: tr.ServiceName, Status: tr.Status,
+			DurationUs: tr.Duration, Timestamp: tr.Timestamp.UTC(),
+		})
+		for _, s := range tr.Spans {
+			snap.Spans = append(snap.Spans, goldenSpan{
+				TraceID: s.TraceID, SpanID: s.SpanID, ParentSpanID: s.ParentSpanID,
+				ServiceName: s.ServiceName, OperationName: s.OperationName, Status: s.Status,
+				StartTime: s.StartTime.UTC(), DurationUs: s.Duration,
+				Attributes: goldenJSON(string(s.AttributesJSON)),
+			})
+		}
+	}
+	logs, _, err := repo.GetLogsV2(ctx, storage.LogFilter{Limit: 10000})
+	if err != nil {
+		t.Fatalf("GetLogsV2: %v", err)
+	}
+	for _, l := range logs {
+		snap.Logs = append(snap.Logs, goldenLog{
+			TraceID: l.TraceID, SpanID: l.SpanID, ServiceName: l.ServiceName,
+			Severity: l.Severity, Body: l.Body, Timestamp: l.Timestamp.UTC(),
+			Attributes: goldenJSON(string(l.AttributesJSON)),
+		})
+	}
+
+	sort.Slice(snap.Traces, func(i, j int) bool { return snap.Traces[i].TraceID < snap.Traces[j].TraceID })
+	sort.Slice(snap.Spans, func(i, j int) bool {
+		a, b := snap.Spans[i], snap.Spans[j]
+		if a.TraceID != b.TraceID {
+			return a.TraceID < b.TraceID
+		}
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.SpanID < b.SpanID
+	})
+	sort.Slice(snap.Logs, func(i, j int) bool {
+		a, b := snap.Logs[i], snap.Logs[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return a.ServiceName+a.Body < b.ServiceName+b.Body
+	})
+	sort.Slice(snap.Metrics, func(i, j int) bool {
+		return fmt.Sprint(snap.Metrics[i]) < fmt.Sprint(snap.Metrics[j])
+	})
+	return snap
+}
+
+// goldenJSON embeds a stored JSON string as JSON so snapshots diff cleanly.
+func goldenJSON(s string) json.RawMessage {
+	if s == "" || s == "{}" || s == "null" || !json.Valid([]byte(s)) {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// assertGolden compares got, as indented JSON, with the file at path, or
+// rewrites the file under -update. Usable by any receiver test that wants a
+// snapshot instead of hand-written assertions.
+func assertGolden(t *testing.T, path string, got any) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, '\n')
+	if *updateGolden {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path) // #nosec G304 -- test fixture
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s is out of date (run with -update and review the diff)\ngot:\n%s", path, data)
+	}
+}
+
+func TestFixtureRecorder_WritesReplayableRequests(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewFixtureRecorder(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewTraceServer(newTestRepo(t), nil, goldenConfig())
+	srv.SetFixtureRecorder(rec)
+	for range 3 {
+		if _, err := srv.Export(context.Background(), buildTracesRequest("svc", 2)); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.traces.json"))
+	if len(files) != 2 {
+		t.Fatalf("recorded %d files, want 2 (the limit)", len(files))
+	}
+	snap := replayFixture(t, files[0])
+	if len(snap.Spans) != 2 {
+		t.Errorf("replayed %d spans, want 2", len(snap.Spans))
+	}
+}
@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// rejectHealthCheck is the reject reason for spans and log records produced
+// by health-check probes. Reported through partial_success and
+// otelcontext_ingest_rejected_total{reason} like every other drop.
+const rejectHealthCheck = "health_check"
+
+// Attribute keys that carry the request path and user agent, current
+// semantic conventions first, then the legacy names older SDKs still send.
+var (
+	healthCheckRouteKeys     = []string{"http.route", "url.path", "http.target"}
+	healthCheckUserAgentKeys = []string{"user_agent.original", "http.user_agent"}
+)
+
+// HealthCheckFilter drops telemetry generated by liveness/readiness probes
+// before storage. Probes fire every few seconds per pod, so left alone they
+// dominate the stored trace count and drag latency percentiles towards the
+// cost of a no-op handler. Enabled via INGEST_DROP_HEALTH_CHECKS=true; nil
+// HealthCheckFilter = keep everything.
+//
+// A record matches when its route is one of the configured paths (exact,
+// query string ignored) or its user agent contains one of the configured
+// substrings. Failing probes (error status / ERROR severity and above) are
+// always kept — they are the signal, not the noise.
+//
+// Immutable after construction; safe for concurrent use.
+type HealthCheckFilter struct {
+	routes     map[string]bool
+	userAgents []string
+}
+
+// NewHealthCheckFilter builds a filter from comma-separated route paths and
+// user-agent substrings. Returns nil when both lists are empty.
+func NewHealthCheckFilter(routes, userAgents string) *HealthCheckFilter {
+	f := &HealthCheckFilter{routes: make(map[string]bool)}
+	for _, r := range strings.Split(routes, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			f.routes[r] = true
+		}
+	}
+	for _, ua := range strings.Split(userAgents, ",") {
+		if ua = strings.TrimSpace(ua); ua != "" {
+			f.userAgents = append(f.userAgents, strings.ToLower(ua))
+		}
+	}
+	if len(f.routes) == 0 && len(f.userAgents) == 0 {
+		return nil
+	}
+	return f
+}
+
+// matchSpan reports whether span is a successful health-check request.
+func (f *HealthCheckFilter) matchSpan(span *tracepb.Span) bool {
+	if span.Status != nil && span.Status.Code == tracepb.Status_STATUS_CODE_ERROR {
+		return false
+	}
+	return f.matchAttributes(span.Attributes)
+}
+
+// matchLog reports whether record was logged for a successful health check.
+func (f *HealthCheckFilter) matchLog(record *logspb.LogRecord) bool {
+	if record.SeverityNumber >= logspb.SeverityNumber_SEVERITY_NUMBER_ERROR {
+		return false
+	}
+	return f.matchAttributes(record.Attributes)
+}
+
+func (f *HealthCheckFilter) matchAttributes(attrs []*commonpb.KeyValue) bool {
+	for _, kv := range attrs {
+		for _, key := range healthCheckRouteKeys {
+			if kv.Key != key {
+				continue
+			}
+			path, _, _ := strings.Cut(kv.Value.GetStringValue(), "?")
+			if f.routes[path] {
+				return true
+			}
+		}
+		for _, key := range healthCheckUserAgentKeys {
+			if kv.Key != key {
+				continue
+			}
+			ua := strings.ToLower(kv.Value.GetStringValue())
+			for _, probe := range f.userAgents {
+				if strings.Contains(ua, probe) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func strAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func TestHealthCheckFilter_Match(t *testing.T) {
+	f := NewHealthCheckFilter("/healthz, /ready", "kube-probe/")
+	cases := []struct {
+		name string
+		span *tracepb.Span
+		want bool
+	}{
+		{"route", &tracepb.Span{Attributes: []*commonpb.KeyValue{strAttr("http.route", "/healthz")}}, true},
+		{"legacy target with query", &tracepb.Span{Attributes: []*commonpb.KeyValue{strAttr("http.target", "/ready?full=1")}}, true},
+		{"user agent", &tracepb.Span{Attributes: []*commonpb.KeyValue{strAttr("user_agent.original", "Kube-Probe/1.29")}}, true},
+		{"other route", &tracepb.Span{Attributes: []*commonpb.KeyValue{strAttr("http.route", "/healthz/deep")}}, false},
+		{"failing probe kept", &tracepb.Span{
+			Attributes: []*commonpb.KeyValue{strAttr("http.route", "/healthz")},
+			Status:     &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR},
+		}, false},
+	}
+	for _, tc := range cases {
+		if got := f.matchSpan(tc.span); got != tc.want {
+			t.Errorf("%s: matchSpan = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	probeLog := &logspb.LogRecord{Attributes: []*commonpb.KeyValue{strAttr("url.path", "/healthz")}}
+	if !f.matchLog(probeLog) {
+		t.Error("probe access log not matched")
+	}
+	probeLog.SeverityNumber = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	if f.matchLog(probeLog) {
+		t.Error("ERROR probe log should be kept")
+	}
+
+	if NewHealthCheckFilter(" , ", "") != nil {
+		t.Error("empty lists should disable the filter")
+	}
+}
+
+// TestHealthCheckFilter_ExportDropsProbes verifies probe spans and logs
+// never reach the DB and are reported under the health_check reason.
+func TestHealthCheckFilter_ExportDropsProbes(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+	hc := NewHealthCheckFilter("/healthz", "kube-probe/")
+	traces := NewTraceServer(repo, nil, cfg)
+	traces.SetHealthCheckFilter(hc)
+	logs := NewLogsServer(repo, nil, cfg)
+	logs.SetHealthCheckFilter(hc)
+
+	req := buildTracesRequest("svc", 4)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	spans[0].Attributes = append(spans[0].Attributes, strAttr("http.route", "/healthz"))
+	spans[1].Attributes = append(spans[1].Attributes, strAttr("http.user_agent", "kube-probe/1.30"))
+
+	resp, err := traces.Export(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	ps := resp.GetPartialSuccess()
+	if ps.GetRejectedSpans() != 2 || !strings.Contains(ps.GetErrorMessage(), rejectHealthCheck+"=2") {
+		t.Errorf("partial success = %+v, want 2 health_check rejects", ps)
+	}
+	if got := countSpans(t, repo); got != 2 {
+		t.Errorf("persisted spans = %d, want 2", got)
+	}
+
+	logResp, err := logs.Export(context.Background(), &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: req.ResourceSpans[0].Resource,
+			ScopeLogs: []*logspb.ScopeLogs{{LogRecords: []*logspb.LogRecord{
+				{Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "GET /healthz 200"}},
+					Attributes: []*commonpb.KeyValue{strAttr("url.path", "/healthz")}},
+				{Body: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "order placed"}}},
+			}}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("logs Export: %v", err)
+	}
+	if got := logResp.GetPartialSuccess().GetRejectedLogRecords(); got != 1 {
+		t.Errorf("RejectedLogRecords = %d, want 1", got)
+	}
+}
@@ -0,0 +1,451 @@
+package ingest
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// jaegerAgentMaxPacket is the largest datagram a Jaeger client emits (the
+// agent's own UDP buffer size).
+const jaegerAgentMaxPacket = 65000
+
+// Jaeger thrift TagType values.
+const (
+	jaegerTagString = 0
+	jaegerTagDouble = 1
+	jaegerTagBool   = 2
+	jaegerTagLong   = 3
+	jaegerTagBinary = 4
+)
+
+// jaegerRefChildOf is SpanRefType.CHILD_OF.
+const jaegerRefChildOf = 0
+
+// JaegerAgentReceiver accepts spans from legacy Jaeger clients speaking the
+// agent protocol (emitBatch over UDP, Thrift compact encoding — the default
+// port 6831). Each batch is converted to OTLP and handed to TraceServer.Export,
+// so filtering, validation, sampling, tenancy and the async pipeline apply
+// exactly as for OTLP traffic. The binary-protocol port (6832) and the
+// deprecated zipkin.thrift endpoint are not supported.
+type JaegerAgentReceiver struct {
+	traces  *TraceServer
+	metrics *telemetry.Metrics
+
+	conn    net.PacketConn
+	wg      sync.WaitGroup
+	stopped atomic.Bool
+}
+
+// NewJaegerAgentReceiver creates a receiver that forwards into traces.
+// metrics may be nil.
+func NewJaegerAgentReceiver(traces *TraceServer, metrics *telemetry.Metrics) *JaegerAgentReceiver {
+	return &JaegerAgentReceiver{traces: traces, metrics: metrics}
+}
+
+// Start binds addr (e.g. ":6831") and begins reading datagrams.
+func (j *JaegerAgentReceiver) Start(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("jaeger agent: listen %s: %w", addr, err)
+	}
+	j.conn = conn
+	j.wg.Add(1)
+	go j.serve()
+	slog.Info("📡 Jaeger agent receiver started (compact thrift)", "addr", conn.LocalAddr().String())
+	return nil
+}
+
+// Addr returns the bound address, or nil before Start.
+func (j *JaegerAgentReceiver) Addr() net.Addr {
+	if j.conn == nil {
+		return nil
+	}
+	return j.conn.LocalAddr()
+}
+
+// Stop closes the socket and waits for the read loop to exit.
+func (j *JaegerAgentReceiver) Stop() {
+	if j.conn == nil || !j.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	_ = j.conn.Close()
+	j.wg.Wait()
+}
+
+func (j *JaegerAgentReceiver) serve() {
+	defer j.wg.Done()
+	buf := make([]byte, jaegerAgentMaxPacket)
+	for {
+		n, _, err := j.conn.ReadFrom(buf)
+		if err != nil {
+			if j.stopped.Load() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("Jaeger agent: read failed", "error", err)
+			continue
+		}
+		j.handlePacket(buf[:n])
+	}
+}
+
+// handlePacket decodes one datagram and exports it. Errors are counted and
+// logged; UDP has no way to report them to the client.
+func (j *JaegerAgentReceiver) handlePacket(pkt []byte) {
+	req, err := decodeJaegerEmitBatch(pkt)
+	if err != nil {
+		j.metrics.RecordJaegerAgentPacket("decode_error")
+		slog.Debug("Jaeger agent: dropped undecodable packet", "bytes", len(pkt), "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := j.traces.Export(ctx, req); err != nil {
+		j.metrics.RecordJaegerAgentPacket("export_error")
+		slog.Warn("Jaeger agent: export failed", "error", err)
+		return
+	}
+	j.metrics.RecordJaegerAgentPacket("ok")
+}
+
+// decodeJaegerEmitBatch parses an Agent.emitBatch compact-thrift message into
+// an OTLP export request.
+func decodeJaegerEmitBatch(pkt []byte) (*coltracepb.ExportTraceServiceRequest, error) {
+	r := newCompactReader(pkt)
+	name, err := r.readMessageBegin()
+	if err != nil {
+		return nil, err
+	}
+	if name != "emitBatch" {
+		return nil, fmt.Errorf("jaeger agent: unsupported method %q", name)
+	}
+
+	var rs *tracepb.ResourceSpans
+	// emitBatch_args { 1: Batch batch }
+	err = r.readStruct(func(typ byte, id int16) (bool, error) {
+		if id != 1 || typ != ctStruct {
+			return false, nil
+		}
+		var err error
+		rs, err = readJaegerBatch(r, "jaeger-agent")
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rs == nil {
+		return nil, errors.New("jaeger agent: emitBatch without batch")
+	}
+	return &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{rs}}, nil
+}
+
+// readJaegerBatch decodes Batch { 1: Process process, 2: list<Span> spans }.
+// scopeName records which receiver the spans arrived through.
+func readJaegerBatch(r thriftReader, scopeName string) (*tracepb.ResourceSpans, error) {
+	resource := &resourcepb.Resource{}
+	scope := &tracepb.ScopeSpans{Scope: &commonpb.InstrumentationScope{Name: scopeName}}
+	err := r.readStruct(func(typ byte, id int16) (bool, error) {
+		switch {
+		case id == 1 && typ == ctStruct:
+			return true, readJaegerProcess(r, resource)
+		case id == 2 && typ == ctList:
+			_, n, err := r.readListBegin()
+			if err != nil {
+				return true, err
+			}
+			scope.Spans = make([]*tracepb.Span, 0, n)
+			for range n {
+				sp, err := readJaegerSpan(r)
+				if err != nil {
+					return true, err
+				}
+				scope.Spans = append(scope.Spans, sp)
+			}
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tracepb.ResourceSpans{Resource: resource, ScopeSpans: []*tracepb.ScopeSpans{scope}}, nil
+}
+
+// readJaegerProcess decodes Process { 1: string serviceName, 2: list<Tag> tags }
+// into resource attributes.
+func readJaegerProcess(r thriftReader, res *resourcepb.Resource) error {
+	return r.readStruct(func(typ byte, id int16) (bool, error) {
+		switch {
+		case id == 1 && typ == ctBinary:
+			svc, err := r.readString()
+			res.Attributes = append(res.Attributes, stringKV("service.name", svc))
+			return true, err
+		case id == 2 && typ == ctList:
+			tags, err := readJaegerTags(r)
+			res.Attributes = append(res.Attributes, tags...)
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+// readJaegerSpan decodes a Jaeger Span. Jaeger times are microseconds; the
+// "error" and "span.kind" tags map onto OTLP status and kind.
+func readJaegerSpan(r thriftReader) (*tracepb.Span, error) {
+	var (
+		traceLow, traceHigh, spanID, parentID int64
+		startUs, durationUs                   int64
+		refParent                             int64
+	)
+	span := &tracepb.Span{}
+	err := r.readStruct(func(typ byte, id int16) (bool, error) {
+		var err error
+		switch {
+		case id == 1 && typ == ctI64:
+			traceLow, err = r.readI64()
+		case id == 2 && typ == ctI64:
+			traceHigh, err = r.readI64()
+		case id == 3 && typ == ctI64:
+			spanID, err = r.readI64()
+		case id == 4 && typ == ctI64:
+			parentID, err = r.readI64()
+		case id == 5 && typ == ctBinary:
+			span.Name, err = r.readString()
+		case id == 6 && typ == ctList:
+			refParent, err = readJaegerRefs(r)
+		case id == 8 && typ == ctI64:
+			startUs, err = r.readI64()
+		case id == 9 && typ == ctI64:
+			durationUs, err = r.readI64()
+		case id == 10 && typ == ctList:
+			span.Attributes, err = readJaegerTags(r)
+		case id == 11 && typ == ctList:
+			span.Events, err = readJaegerLogs(r)
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.TraceId = make([]byte, 16)
+	binary.BigEndian.PutUint64(span.TraceId[:8], uint64(traceHigh)) // #nosec G115 -- bit-for-bit ID reinterpretation
+	binary.BigEndian.PutUint64(span.TraceId[8:], uint64(traceLow))  // #nosec G115 -- bit-for-bit ID reinterpretation
+	span.SpanId = jaegerID(spanID)
+	if parentID == 0 {
+		parentID = refParent
+	}
+	if parentID != 0 {
+		span.ParentSpanId = jaegerID(parentID)
+	}
+	span.StartTimeUnixNano = uint64(startUs) * 1000                         // #nosec G115 -- epoch micros are positive
+	span.EndTimeUnixNano = span.StartTimeUnixNano + uint64(durationUs)*1000 // #nosec G115 -- durations are positive
+	applyJaegerSpanTags(span)
+	return span, nil
+}
+
+// applyJaegerSpanTags moves the "error" and "span.kind" tags onto the OTLP
+// status and kind.
+func applyJaegerSpanTags(span *tracepb.Span) {
+	attrs := span.Attributes[:0]
+	for _, kv := range span.Attributes {
+		switch kv.Key {
+		case "error":
+			if kv.Value.GetBoolValue() || kv.Value.GetStringValue() == "true" {
+				span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+			}
+			continue
+		case "span.kind":
+			span.Kind = jaegerSpanKind(kv.Value.GetStringValue())
+			continue
+		}
+		attrs = append(attrs, kv)
+	}
+	span.Attributes = attrs
+}
+
+// readJaegerRefs returns the CHILD_OF parent from a list<SpanRef>, if any.
+// SpanRef { 1: refType, 2: traceIdLow, 3: traceIdHigh, 4: spanId }.
+func readJaegerRefs(r thriftReader) (int64, error) {
+	_, n, err := r.readListBegin()
+	if err != nil {
+		return 0, err
+	}
+	var parent int64
+	for range n {
+		var refType int32
+		var refSpan int64
+		err := r.readStruct(func(typ byte, id int16) (bool, error) {
+			var err error
+			switch {
+			case id == 1 && typ == ctI32:
+				refType, err = r.readI32()
+			case id == 4 && typ == ctI64:
+				refSpan, err = r.readI64()
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+		if err != nil {
+			return 0, err
+		}
+		if refType == jaegerRefChildOf && parent == 0 {
+			parent = refSpan
+		}
+	}
+	return parent, nil
+}
+
+// readJaegerLogs decodes list<Log> into span events. Log { 1: i64 timestamp,
+// 2: list<Tag> fields }. See nameJaegerEvent; the "exception" name makes the
+// receiver synthesize an ERROR log for Jaeger error events.
+func readJaegerLogs(r thriftReader) ([]*tracepb.Span_Event, error) {
+	_, n, err := r.readListBegin()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*tracepb.Span_Event, 0, n)
+	for range n {
+		ev := &tracepb.Span_Event{Name: "log"}
+		err := r.readStruct(func(typ byte, id int16) (bool, error) {
+			var err error
+			switch {
+			case id == 1 && typ == ctI64:
+				var us int64
+				us, err = r.readI64()
+				ev.TimeUnixNano = uint64(us) * 1000 // #nosec G115 -- epoch micros are positive
+			case id == 2 && typ == ctList:
+				ev.Attributes, err = readJaegerTags(r)
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		nameJaegerEvent(ev)
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// nameJaegerEvent names a span event after its "event" field. Jaeger's
+// conventional "error" event becomes "exception", with "message" copied to
+// exception.message.
+func nameJaegerEvent(ev *tracepb.Span_Event) {
+	for _, kv := range ev.Attributes {
+		if kv.Key == "event" {
+			ev.Name = kv.Value.GetStringValue()
+		}
+	}
+	if ev.Name == "error" {
+		ev.Name = "exception"
+		for _, kv := range ev.Attributes {
+			if kv.Key == "message" {
+				ev.Attributes = append(ev.Attributes, stringKV("exception.message", kv.Value.GetStringValue()))
+				break
+			}
+		}
+	}
+}
+
+// readJaegerTags decodes list<Tag>. Tag { 1: key, 2: vType, 3: vStr,
+// 4: vDouble, 5: vBool, 6: vLong, 7: vBinary }.
+func readJaegerTags(r thriftReader) ([]*commonpb.KeyValue, error) {
+	_, n, err := r.readListBegin()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*commonpb.KeyValue, 0, n)
+	for range n {
+		var (
+			key   string
+			vType int32
+			vStr  string
+			vDbl  float64
+			vBool bool
+			vLong int64
+			vBin  []byte
+		)
+		err := r.readStruct(func(typ byte, id int16) (bool, error) {
+			var err error
+			switch {
+			case id == 1 && typ == ctBinary:
+				key, err = r.readString()
+			case id == 2 && typ == ctI32:
+				vType, err = r.readI32()
+			case id == 3 && typ == ctBinary:
+				vStr, err = r.readString()
+			case id == 4 && typ == ctDouble:
+				vDbl, err = r.readDouble()
+			case id == 5 && (typ == ctBoolTrue || typ == ctBoolFalse):
+				vBool, err = r.readBool()
+			case id == 6 && typ == ctI64:
+				vLong, err = r.readI64()
+			case id == 7 && typ == ctBinary:
+				vBin, err = r.readBinary()
+			default:
+				return false, nil
+			}
+			return true, err
+		})
+		if err != nil {
+			return nil, err
+		}
+		kv := &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{}}
+		switch vType {
+		case jaegerTagDouble:
+			kv.Value.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: vDbl}
+		case jaegerTagBool:
+			kv.Value.Value = &commonpb.AnyValue_BoolValue{BoolValue: vBool}
+		case jaegerTagLong:
+			kv.Value.Value = &commonpb.AnyValue_IntValue{IntValue: vLong}
+		case jaegerTagBinary:
+			kv.Value.Value = &commonpb.AnyValue_BytesValue{BytesValue: append([]byte(nil), vBin...)}
+		default:
+			kv.Value.Value = &commonpb.AnyValue_StringValue{StringValue: vStr}
+		}
+		out = append(out, kv)
+	}
+	return out, nil
+}
+
+func jaegerID(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id)) // #nosec G115 -- bit-for-bit ID reinterpretation
+	return b
+}
+
+func jaegerSpanKind(kind string) tracepb.Span_SpanKind {
+	switch kind {
+	case "server":
+		return tracepb.Span_SPAN_KIND_SERVER
+	case "client":
+		return tracepb.Span_SPAN_KIND_CLIENT
+	case "producer":
+		return tracepb.Span_SPAN_KIND_PRODUCER
+	case "consumer":
+		return tracepb.Span_SPAN_KIND_CONSUMER
+	}
+	return tracepb.Span_SPAN_KIND_INTERNAL
+}
+
+func stringKV(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
@@ -0,0 +1,254 @@
+package ingest
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// compactWriter is a test-only Thrift compact encoder, the mirror of
+// compactReader, used to build emitBatch packets the way jaeger-client-go does.
+type compactWriter struct {
+	buf  []byte
+	last []int16
+}
+
+func (w *compactWriter) uvarint(v uint64) { w.buf = binary.AppendUvarint(w.buf, v) }
+func (w *compactWriter) varint(v int64)   { w.uvarint(uint64((v << 1) ^ (v >> 63))) }
+func (w *compactWriter) str(s string)     { w.uvarint(uint64(len(s))); w.buf = append(w.buf, s...) }
+func (w *compactWriter) begin()           { w.last = append(w.last, 0) }
+func (w *compactWriter) end() {
+	w.buf = append(w.buf, ctStop)
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *compactWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		w.buf = append(w.buf, byte(d)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *compactWriter) list(elem byte, n int) {
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+		return
+	}
+	w.buf = append(w.buf, 0xf0|elem)
+	w.uvarint(uint64(n))
+}
+
+type testTag struct {
+	key   string
+	str   string
+	isDbl bool
+	dbl   float64
+	isB   bool
+	b     bool
+}
+
+func (w *compactWriter) tags(tags []testTag) {
+	w.list(ctStruct, len(tags))
+	for _, tg := range tags {
+		w.begin()
+		w.field(1, ctBinary)
+		w.str(tg.key)
+		w.field(2, ctI32)
+		switch {
+		case tg.isDbl:
+			w.varint(jaegerTagDouble)
+			w.field(4, ctDouble)
+			w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(tg.dbl))
+		case tg.isB:
+			w.varint(jaegerTagBool)
+			if tg.b {
+				w.field(5, ctBoolTrue)
+			} else {
+				w.field(5, ctBoolFalse)
+			}
+		default:
+			w.varint(jaegerTagString)
+			w.field(3, ctBinary)
+			w.str(tg.str)
+		}
+		w.end()
+	}
+}
+
+type testJaegerSpan struct {
+	traceHigh, traceLow, spanID, parentID int64
+	name                                  string
+	startUs, durUs                        int64
+	tags                                  []testTag
+	logs                                  [][]testTag
+}
+
+func buildEmitBatch(service string, spans []testJaegerSpan) []byte {
+	w := &compactWriter{}
+	w.buf = append(w.buf, compactProtocolID, 4<<5|compactVersion) // ONEWAY
+	w.uvarint(1)
+	w.str("emitBatch")
+
+	w.begin() // args
+	w.field(1, ctStruct)
+	w.begin() // Batch
+	w.field(1, ctStruct)
+	w.begin() // Process
+	w.field(1, ctBinary)
+	w.str(service)
+	w.field(2, ctList)
+	w.tags([]testTag{{key: "hostname", str: "legacy-host"}})
+	w.end()
+	w.field(2, ctList)
+	w.list(ctStruct, len(spans))
+	for _, sp := range spans {
+		w.begin()
+		w.field(1, ctI64)
+		w.varint(sp.traceLow)
+		w.field(2, ctI64)
+		w.varint(sp.traceHigh)
+		w.field(3, ctI64)
+		w.varint(sp.spanID)
+		w.field(4, ctI64)
+		w.varint(sp.parentID)
+		w.field(5, ctBinary)
+		w.str(sp.name)
+		w.field(7, ctI32)
+		w.varint(1)
+		w.field(8, ctI64)
+		w.varint(sp.startUs)
+		w.field(9, ctI64)
+		w.varint(sp.durUs)
+		w.field(10, ctList)
+		w.tags(sp.tags)
+		if len(sp.logs) > 0 {
+			w.field(11, ctList)
+			w.list(ctStruct, len(sp.logs))
+			for _, fields := range sp.logs {
+				w.begin()
+				w.field(1, ctI64)
+				w.varint(sp.startUs + 10)
+				w.field(2, ctList)
+				w.tags(fields)
+				w.end()
+			}
+		}
+		// Unknown trailing field must be skipped.
+		w.field(42, ctBinary)
+		w.str("future")
+		w.end()
+	}
+	w.field(3, ctI64) // seqNo
+	w.varint(7)
+	w.end() // Batch
+	w.end() // args
+	return w.buf
+}
+
+func TestDecodeJaegerEmitBatch(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixMicro()
+	pkt := buildEmitBatch("legacy-billing", []testJaegerSpan{
+		{traceLow: 0x4bf92f3577b34da6, spanID: 0x11, name: "GET /invoice", startUs: start, durUs: 2500,
+			tags: []testTag{{key: "span.kind", str: "server"}, {key: "http.status_code", isDbl: true, dbl: 500}, {key: "error", isB: true, b: true}},
+			logs: [][]testTag{{{key: "event", str: "error"}, {key: "message", str: "db timeout"}}}},
+		{traceLow: 0x4bf92f3577b34da6, spanID: 0x22, parentID: 0x11, name: "SELECT", startUs: start + 100, durUs: 2000},
+	})
+
+	req, err := decodeJaegerEmitBatch(pkt)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	rs := req.ResourceSpans[0]
+	if got := rs.Resource.Attributes[0]; got.Key != "service.name" || got.Value.GetStringValue() != "legacy-billing" {
+		t.Errorf("resource service.name = %v", got)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	root, child := spans[0], spans[1]
+	if root.Name != "GET /invoice" || root.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("root name/kind = %q/%v", root.Name, root.Kind)
+	}
+	if root.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("root status = %v, want error", root.Status)
+	}
+	if got := root.EndTimeUnixNano - root.StartTimeUnixNano; got != 2_500_000 {
+		t.Errorf("root duration = %dns, want 2.5ms", got)
+	}
+	if len(root.Attributes) != 1 || root.Attributes[0].Value.GetDoubleValue() != 500 {
+		t.Errorf("root attributes = %v, want only http.status_code", root.Attributes)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != "exception" {
+		t.Fatalf("root events = %v, want one exception", root.Events)
+	}
+	if len(root.ParentSpanId) != 0 {
+		t.Errorf("root parent = %x, want none", root.ParentSpanId)
+	}
+	if want := jaegerID(0x11); string(child.ParentSpanId) != string(want) {
+		t.Errorf("child parent = %x, want %x", child.ParentSpanId, want)
+	}
+}
+
+func TestDecodeJaegerEmitBatch_RejectsGarbage(t *testing.T) {
+	good := buildEmitBatch("svc", []testJaegerSpan{{traceLow: 1, spanID: 1, name: "op", startUs: 1, durUs: 1}})
+	for name, pkt := range map[string][]byte{
+		"empty":      nil,
+		"not thrift": []byte("hello"),
+		"truncated":  good[:len(good)/2],
+	} {
+		if _, err := decodeJaegerEmitBatch(pkt); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+// TestJaegerAgentReceiver_UDPEndToEnd sends a real datagram and reads the
+// trace back through the repository, by its 64-bit spelling.
+func TestJaegerAgentReceiver_UDPEndToEnd(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Span, 4)
+	traces.SetSpanCallback(func(sp storage.Span) { stored <- sp })
+
+	recv := NewJaegerAgentReceiver(traces, nil)
+	if err := recv.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer recv.Stop()
+
+	conn, err := net.Dial("udp", recv.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	pkt := buildEmitBatch("legacy-billing", []testJaegerSpan{
+		{traceLow: 0x4bf92f3577b34da6, spanID: 0x11, name: "GET /invoice", startUs: time.Now().UnixMicro(), durUs: 1000},
+	})
+	if _, err := conn.Write(pkt); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	select {
+	case sp := <-stored:
+		if sp.ServiceName != "legacy-billing" || sp.TraceID != "00000000000000004bf92f3577b34da6" {
+			t.Errorf("stored span = %+v", sp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("span not ingested")
+	}
+	if _, err := repo.GetTrace(context.Background(), "4bf92f3577b34da6"); err != nil {
+		t.Errorf("GetTrace: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// jaegerCollectorMaxBody caps a POST /api/traces body. Jaeger clients flush
+// far smaller batches; the cap only bounds hostile uploads.
+const jaegerCollectorMaxBody = 4 << 20
+
+// JaegerCollector accepts spans from Jaeger clients and agents that report
+// straight to a collector: Thrift binary over HTTP (POST /api/traces, the
+// default port 14268) and the api_v2 CollectorService over gRPC (PostSpans,
+// the default port 14250). Like JaegerAgentReceiver, batches are converted to
+// OTLP and handed to TraceServer.Export. Sampling-strategy and Zipkin
+// endpoints are not served.
+type JaegerCollector struct {
+	traces  *TraceServer
+	metrics *telemetry.Metrics
+
+	httpSrv *http.Server
+	httpLis net.Listener
+	grpcSrv *grpc.Server
+	grpcLis net.Listener
+	stopped atomic.Bool
+}
+
+// NewJaegerCollector creates a collector that forwards into traces. metrics
+// may be nil.
+func NewJaegerCollector(traces *TraceServer, metrics *telemetry.Metrics) *JaegerCollector {
+	return &JaegerCollector{traces: traces, metrics: metrics}
+}
+
+// StartHTTP binds addr (e.g. ":14268") and serves POST /api/traces.
+func (j *JaegerCollector) StartHTTP(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("jaeger collector: listen %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/traces", j.handleThrift)
+	j.httpLis = lis
+	j.httpSrv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	go func() {
+		if err := j.httpSrv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Jaeger collector: HTTP server failed", "error", err)
+		}
+	}()
+	slog.Info("📡 Jaeger collector receiver started (HTTP thrift)", "addr", lis.Addr().String())
+	return nil
+}
+
+// StartGRPC binds addr (e.g. ":14250") and serves the api_v2 CollectorService.
+// opts are applied to the dedicated gRPC server (interceptors, message limits,
+// TLS); the server always decodes requests with its own raw codec.
+func (j *JaegerCollector) StartGRPC(addr string, opts ...grpc.ServerOption) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("jaeger collector: listen %s: %w", addr, err)
+	}
+	opts = append(opts[:len(opts):len(opts)], grpc.ForceServerCodec(rawCodec{}))
+	j.grpcLis = lis
+	j.grpcSrv = grpc.NewServer(opts...)
+	j.grpcSrv.RegisterService(&jaegerCollectorServiceDesc, j)
+	go func() {
+		if err := j.grpcSrv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.Error("Jaeger collector: gRPC server failed", "error", err)
+		}
+	}()
+	slog.Info("📡 Jaeger collector receiver started (gRPC api_v2)", "addr", lis.Addr().String())
+	return nil
+}
+
+// HTTPAddr returns the bound HTTP address, or nil before StartHTTP.
+func (j *JaegerCollector) HTTPAddr() net.Addr {
+	if j.httpLis == nil {
+		return nil
+	}
+	return j.httpLis.Addr()
+}
+
+// GRPCAddr returns the bound gRPC address, or nil before StartGRPC.
+func (j *JaegerCollector) GRPCAddr() net.Addr {
+	if j.grpcLis == nil {
+		return nil
+	}
+	return j.grpcLis.Addr()
+}
+
+// Stop drains in-flight requests on both listeners.
+func (j *JaegerCollector) Stop(ctx context.Context) {
+	if !j.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	if j.httpSrv != nil {
+		if err := j.httpSrv.Shutdown(ctx); err != nil {
+			slog.Warn("Jaeger collector: HTTP shutdown", "error", err)
+		}
+	}
+	if j.grpcSrv != nil {
+		done := make(chan struct{})
+		go func() {
+			j.grpcSrv.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			j.grpcSrv.Stop()
+		}
+	}
+}
+
+// handleThrift serves POST /api/traces: one Thrift-binary Batch per body,
+// without a message envelope, as sent by jaeger-client's HTTP sender.
+func (j *JaegerCollector) handleThrift(w http.ResponseWriter, r *http.Request) {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	if mt != "application/x-thrift" && mt != "application/vnd.apache.thrift.binary" {
+		j.metrics.RecordJaegerCollectorBatch("http", "decode_error")
+		http.Error(w, "unsupported content type: want application/x-thrift", http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, jaegerCollectorMaxBody))
+	if err != nil {
+		j.metrics.RecordJaegerCollectorBatch("http", "decode_error")
+		http.Error(w, "request body too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	rs, err := readJaegerBatch(newBinaryReader(body), "jaeger-collector")
+	if err != nil {
+		j.metrics.RecordJaegerCollectorBatch("http", "decode_error")
+		http.Error(w, "invalid thrift batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &coltracepb.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{rs}}
+	if _, err := j.traces.Export(r.Context(), req); err != nil {
+		j.metrics.RecordJaegerCollectorBatch("http", "export_error")
+		if isQueueFull(err) {
+			w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfterSeconds))
+			http.Error(w, "ingest pipeline at capacity", http.StatusTooManyRequests)
+			return
+		}
+		slog.Warn("Jaeger collector: export failed", "transport", "http", "error", err)
+		http.Error(w, "export failed", http.StatusInternalServerError)
+		return
+	}
+	j.metrics.RecordJaegerCollectorBatch("http", "ok")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// postSpans implements CollectorService.PostSpans. The request is decoded by
+// hand (see decodeJaegerPostSpans) so the Jaeger IDL is not a dependency;
+// PostSpansResponse has no fields, so the reply is an empty message.
+func (j *JaegerCollector) postSpans(ctx context.Context, raw []byte) ([]byte, error) {
+	req, err := decodeJaegerPostSpans(raw)
+	if err != nil {
+		j.metrics.RecordJaegerCollectorBatch("grpc", "decode_error")
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "jaeger collector: %v", err)
+	}
+	if _, err := j.traces.Export(ctx, req); err != nil {
+		j.metrics.RecordJaegerCollectorBatch("grpc", "export_error")
+		return nil, err
+	}
+	j.metrics.RecordJaegerCollectorBatch("grpc", "ok")
+	return []byte{}, nil
+}
+
+// jaegerCollectorServiceDesc describes jaeger.api_v2.CollectorService without
+// generated stubs. Only the unary PostSpans method exists upstream.
+var jaegerCollectorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.api_v2.CollectorService",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "PostSpans",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			var raw []byte
+			if err := dec(&raw); err != nil {
+				return nil, err
+			}
+			j := srv.(*JaegerCollector)
+			if interceptor == nil {
+				return j.postSpans(ctx, raw)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/jaeger.api_v2.CollectorService/PostSpans"}
+			return interceptor(ctx, raw, info, func(ctx context.Context, req any) (any, error) {
+				return j.postSpans(ctx, req.([]byte))
+			})
+		},
+	}},
+	Metadata: "model.proto",
+}
+
+// rawCodec passes message bytes through untouched. It registers under the
+// "proto" name so standard gRPC clients negotiate it.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec: cannot marshal %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	p, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec: cannot unmarshal into %T", v)
+	}
+	*p = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
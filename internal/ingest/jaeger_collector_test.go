@@ -0,0 +1,281 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// binaryWriter is a test-only Thrift binary encoder, the mirror of
+// binaryReader.
+type binaryWriter struct{ buf []byte }
+
+func (w *binaryWriter) field(typ byte, id int16) {
+	w.buf = append(w.buf, typ)
+	w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(id))
+}
+func (w *binaryWriter) i32(v int32) { w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v)) }
+func (w *binaryWriter) i64(v int64) { w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v)) }
+func (w *binaryWriter) str(s string) {
+	w.i32(int32(len(s)))
+	w.buf = append(w.buf, s...)
+}
+func (w *binaryWriter) list(elem byte, n int) {
+	w.buf = append(w.buf, elem)
+	w.i32(int32(n))
+}
+func (w *binaryWriter) stop() { w.buf = append(w.buf, btStop) }
+
+// buildThriftBatch encodes a Batch the way jaeger-client's HTTP sender does:
+// a bare struct, no message envelope.
+func buildThriftBatch(service string, sp testJaegerSpan) []byte {
+	w := &binaryWriter{}
+	w.field(btStruct, 1) // Process
+	w.field(btString, 1)
+	w.str(service)
+	w.stop()
+	w.field(btList, 2)
+	w.list(btStruct, 1)
+	w.field(btI64, 1)
+	w.i64(sp.traceLow)
+	w.field(btI64, 2)
+	w.i64(sp.traceHigh)
+	w.field(btI64, 3)
+	w.i64(sp.spanID)
+	w.field(btI64, 4)
+	w.i64(sp.parentID)
+	w.field(btString, 5)
+	w.str(sp.name)
+	w.field(btI64, 8)
+	w.i64(sp.startUs)
+	w.field(btI64, 9)
+	w.i64(sp.durUs)
+	w.field(btList, 10)
+	w.list(btStruct, len(sp.tags))
+	for _, tg := range sp.tags {
+		w.field(btString, 1)
+		w.str(tg.key)
+		w.field(btI32, 2)
+		if tg.isB {
+			w.i32(jaegerTagBool)
+			w.field(btBool, 5)
+			w.buf = append(w.buf, 1)
+		} else {
+			w.i32(jaegerTagString)
+			w.field(btString, 3)
+			w.str(tg.str)
+		}
+		w.stop()
+	}
+	// Unknown map field must be skipped.
+	w.field(btMap, 42)
+	w.buf = append(w.buf, btString, btI32)
+	w.i32(1)
+	w.str("k")
+	w.i32(1)
+	w.stop() // Span
+	w.stop() // Batch
+	return w.buf
+}
+
+func TestJaegerCollector_HTTPThrift(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Span, 4)
+	traces.SetSpanCallback(func(sp storage.Span) { stored <- sp })
+	jc := NewJaegerCollector(traces, nil)
+
+	body := buildThriftBatch("legacy-orders", testJaegerSpan{
+		traceHigh: 1, traceLow: 2, spanID: 3, name: "POST /orders",
+		startUs: time.Now().UnixMicro(), durUs: 1500,
+		tags: []testTag{{key: "error", isB: true, b: true}, {key: "span.kind", str: "server"}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/traces", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec := httptest.NewRecorder()
+	jc.handleThrift(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d (%s), want 202", rec.Code, rec.Body.String())
+	}
+	select {
+	case sp := <-stored:
+		if sp.ServiceName != "legacy-orders" || sp.OperationName != "POST /orders" || sp.Status != "STATUS_CODE_ERROR" {
+			t.Errorf("stored span = %+v", sp)
+		}
+		if sp.TraceID != "00000000000000010000000000000002" {
+			t.Errorf("trace id = %s", sp.TraceID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("span never reached the pipeline")
+	}
+
+	for name, tc := range map[string]struct {
+		ct   string
+		body []byte
+		want int
+	}{
+		"wrong content type": {"application/json", body, http.StatusUnsupportedMediaType},
+		"truncated":          {"application/x-thrift", body[:len(body)/2], http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/traces", bytes.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.ct)
+		rec := httptest.NewRecorder()
+		jc.handleThrift(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+}
+
+// protoMsg appends field num as a length-delimited sub-message.
+func protoMsg(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func protoTime(t time.Duration) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(t/time.Second))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(t%time.Second))
+}
+
+func protoKV(key, val string) []byte {
+	b := protoMsg(nil, 1, []byte(key))
+	return protoMsg(b, 3, []byte(val))
+}
+
+// buildPostSpans encodes a PostSpansRequest with a batch-level process and
+// two spans, the second a CHILD_OF the first.
+func buildPostSpans(service string, start time.Time) []byte {
+	traceID := bytes.Repeat([]byte{0xab}, 16)
+	rootID := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+	root := protoMsg(nil, 1, traceID)
+	root = protoMsg(root, 2, rootID)
+	root = protoMsg(root, 3, []byte("GET /cart"))
+	root = protoMsg(root, 6, protoTime(time.Duration(start.UnixNano())))
+	root = protoMsg(root, 7, protoTime(3*time.Millisecond))
+	root = protoMsg(root, 8, protoKV("span.kind", "server"))
+	errTag := protoMsg(nil, 1, []byte("error"))
+	errTag = protowire.AppendTag(errTag, 2, protowire.VarintType)
+	errTag = protowire.AppendVarint(errTag, jaegerProtoBool)
+	errTag = protowire.AppendTag(errTag, 4, protowire.VarintType)
+	errTag = protowire.AppendVarint(errTag, 1)
+	root = protoMsg(root, 8, errTag)
+	log := protoMsg(nil, 1, protoTime(time.Duration(start.UnixNano())))
+	log = protoMsg(log, 2, protoKV("event", "error"))
+	log = protoMsg(log, 2, protoKV("message", "cache miss storm"))
+	root = protoMsg(root, 9, log)
+
+	ref := protoMsg(nil, 1, traceID)
+	ref = protoMsg(ref, 2, rootID)
+	child := protoMsg(nil, 1, traceID)
+	child = protoMsg(child, 2, []byte{0, 0, 0, 0, 0, 0, 0, 2})
+	child = protoMsg(child, 3, []byte("redis GET"))
+	child = protoMsg(child, 4, ref)
+	child = protoMsg(child, 6, protoTime(time.Duration(start.UnixNano())))
+	child = protoMsg(child, 99, []byte("future field"))
+
+	process := protoMsg(nil, 1, []byte(service))
+	process = protoMsg(process, 2, protoKV("hostname", "legacy-host"))
+
+	batch := protoMsg(nil, 1, root)
+	batch = protoMsg(batch, 1, child)
+	batch = protoMsg(batch, 2, process)
+	return protoMsg(nil, 1, batch)
+}
+
+func TestDecodeJaegerPostSpans(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	req, err := decodeJaegerPostSpans(buildPostSpans("legacy-cart", start))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("resource spans = %d, want 1", len(req.ResourceSpans))
+	}
+	rs := req.ResourceSpans[0]
+	if got := rs.Resource.Attributes[0]; got.Key != "service.name" || got.Value.GetStringValue() != "legacy-cart" {
+		t.Errorf("resource service.name = %v", got)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	root, child := spans[0], spans[1]
+	if root.Kind != tracepb.Span_SPAN_KIND_SERVER || root.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("root kind/status = %v/%v", root.Kind, root.Status)
+	}
+	if root.StartTimeUnixNano != uint64(start.UnixNano()) || root.EndTimeUnixNano-root.StartTimeUnixNano != 3_000_000 {
+		t.Errorf("root times = %d..%d", root.StartTimeUnixNano, root.EndTimeUnixNano)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != "exception" {
+		t.Errorf("root events = %v, want one exception", root.Events)
+	}
+	if !bytes.Equal(child.ParentSpanId, root.SpanId) {
+		t.Errorf("child parent = %x, want %x", child.ParentSpanId, root.SpanId)
+	}
+
+	for name, raw := range map[string][]byte{
+		"empty":     nil,
+		"truncated": buildPostSpans("svc", start)[:40],
+		"bad ids":   protoMsg(nil, 1, protoMsg(nil, 1, protoMsg(nil, 1, []byte{1, 2}))),
+	} {
+		if _, err := decodeJaegerPostSpans(raw); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+// TestJaegerCollector_GRPCEndToEnd calls PostSpans over a real connection
+// with the standard "proto" content subtype.
+func TestJaegerCollector_GRPCEndToEnd(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Span, 4)
+	traces.SetSpanCallback(func(sp storage.Span) { stored <- sp })
+
+	jc := NewJaegerCollector(traces, nil)
+	if err := jc.StartGRPC("127.0.0.1:0"); err != nil {
+		t.Fatalf("StartGRPC: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	defer jc.Stop(ctx)
+
+	conn, err := grpc.NewClient(jc.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	var resp []byte
+	err = conn.Invoke(ctx, "/jaeger.api_v2.CollectorService/PostSpans",
+		buildPostSpans("legacy-cart", time.Now()), &resp, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		t.Fatalf("PostSpans: %v", err)
+	}
+	for range 2 {
+		select {
+		case sp := <-stored:
+			if sp.ServiceName != "legacy-cart" {
+				t.Errorf("service = %q, want legacy-cart", sp.ServiceName)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("spans never reached the pipeline")
+		}
+	}
+}
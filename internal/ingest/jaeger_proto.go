@@ -0,0 +1,353 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Minimal decoder for the Jaeger api_v2 protobuf model (model.proto), enough
+// for CollectorService.PostSpans. Field numbers follow model.proto; unknown
+// fields are skipped so newer clients still decode.
+
+// api_v2 ValueType values.
+const (
+	jaegerProtoString  = 0
+	jaegerProtoBool    = 1
+	jaegerProtoInt64   = 2
+	jaegerProtoFloat64 = 3
+	jaegerProtoBinary  = 4
+)
+
+// protoFields calls fn for every field in msg. fn receives the raw value:
+// the varint, the fixed bits, or the length-delimited bytes.
+func protoFields(msg []byte, fn func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error) error {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		msg = msg[n:]
+		var (
+			v uint64
+			b []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(msg)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(msg)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(msg)
+			v = uint64(v32)
+		case protowire.BytesType:
+			b, n = protowire.ConsumeBytes(msg)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, msg)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		msg = msg[n:]
+		if err := fn(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeJaegerPostSpans parses PostSpansRequest { Batch batch = 1 }. Batch is
+// { repeated Span spans = 1; Process process = 2 }; spans carrying their own
+// Process get a ResourceSpans of their own.
+func decodeJaegerPostSpans(raw []byte) (*coltracepb.ExportTraceServiceRequest, error) {
+	var batch []byte
+	err := protoFields(raw, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if num == 1 && typ == protowire.BytesType {
+			batch = b
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, errors.New("PostSpans without batch")
+	}
+
+	var (
+		spanMsgs [][]byte
+		process  *resourcepb.Resource
+	)
+	err = protoFields(batch, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			spanMsgs = append(spanMsgs, b)
+		case 2:
+			var err error
+			process, err = decodeJaegerProtoProcess(b)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newRS := func(res *resourcepb.Resource) *tracepb.ResourceSpans {
+		return &tracepb.ResourceSpans{
+			Resource:   res,
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: &commonpb.InstrumentationScope{Name: "jaeger-collector"}}},
+		}
+	}
+	req := &coltracepb.ExportTraceServiceRequest{}
+	var batchRS *tracepb.ResourceSpans
+	for _, msg := range spanMsgs {
+		span, own, err := decodeJaegerProtoSpan(msg)
+		if err != nil {
+			return nil, err
+		}
+		rs := batchRS
+		switch {
+		case own != nil:
+			rs = newRS(own)
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		case rs == nil:
+			if process == nil {
+				process = &resourcepb.Resource{}
+			}
+			batchRS = newRS(process)
+			rs = batchRS
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+		rs.ScopeSpans[0].Spans = append(rs.ScopeSpans[0].Spans, span)
+	}
+	return req, nil
+}
+
+// decodeJaegerProtoProcess decodes Process { string service_name = 1;
+// repeated KeyValue tags = 2 } into resource attributes.
+func decodeJaegerProtoProcess(msg []byte) (*resourcepb.Resource, error) {
+	res := &resourcepb.Resource{}
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			res.Attributes = append(res.Attributes, stringKV("service.name", string(b)))
+		case 2:
+			kv, err := decodeJaegerProtoKV(b)
+			if err != nil {
+				return err
+			}
+			res.Attributes = append(res.Attributes, kv)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// decodeJaegerProtoSpan decodes an api_v2 Span. IDs are raw big-endian bytes
+// (16-byte trace, 8-byte span), so they map onto OTLP unchanged. The span's
+// own Process, if present, is returned separately.
+func decodeJaegerProtoSpan(msg []byte) (*tracepb.Span, *resourcepb.Resource, error) {
+	span := &tracepb.Span{}
+	var (
+		process     *resourcepb.Resource
+		refParent   []byte
+		start, dur  []byte
+		hasDuration bool
+	)
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		var err error
+		switch num {
+		case 1:
+			span.TraceId = append([]byte(nil), b...)
+		case 2:
+			span.SpanId = append([]byte(nil), b...)
+		case 3:
+			span.Name = string(b)
+		case 4:
+			if refParent == nil {
+				refParent, err = decodeJaegerProtoRef(b)
+			}
+		case 6:
+			start = b
+		case 7:
+			dur, hasDuration = b, true
+		case 8:
+			var kv *commonpb.KeyValue
+			if kv, err = decodeJaegerProtoKV(b); err == nil {
+				span.Attributes = append(span.Attributes, kv)
+			}
+		case 9:
+			var ev *tracepb.Span_Event
+			if ev, err = decodeJaegerProtoLog(b); err == nil {
+				span.Events = append(span.Events, ev)
+			}
+		case 10:
+			process, err = decodeJaegerProtoProcess(b)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(span.TraceId) != 16 || len(span.SpanId) != 8 {
+		return nil, nil, fmt.Errorf("span %q: bad trace/span id length %d/%d", span.Name, len(span.TraceId), len(span.SpanId))
+	}
+	if len(refParent) == 8 {
+		span.ParentSpanId = refParent
+	}
+	if span.StartTimeUnixNano, err = decodeProtoTime(start); err != nil {
+		return nil, nil, err
+	}
+	span.EndTimeUnixNano = span.StartTimeUnixNano
+	if hasDuration {
+		d, err := decodeProtoTime(dur)
+		if err != nil {
+			return nil, nil, err
+		}
+		span.EndTimeUnixNano += d
+	}
+	applyJaegerSpanTags(span)
+	return span, process, nil
+}
+
+// decodeJaegerProtoRef returns the parent span ID of a CHILD_OF SpanRef
+// { bytes trace_id = 1; bytes span_id = 2; SpanRefType ref_type = 3 }, or nil
+// for FOLLOWS_FROM.
+func decodeJaegerProtoRef(msg []byte) ([]byte, error) {
+	var (
+		spanID  []byte
+		refType uint64
+	)
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch {
+		case num == 2 && typ == protowire.BytesType:
+			spanID = b
+		case num == 3 && typ == protowire.VarintType:
+			refType = v
+		}
+		return nil
+	})
+	if err != nil || refType != jaegerRefChildOf {
+		return nil, err
+	}
+	return append([]byte(nil), spanID...), nil
+}
+
+// decodeJaegerProtoLog decodes Log { Timestamp timestamp = 1; repeated
+// KeyValue fields = 2 } into a span event named by nameJaegerEvent.
+func decodeJaegerProtoLog(msg []byte) (*tracepb.Span_Event, error) {
+	ev := &tracepb.Span_Event{Name: "log"}
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		var err error
+		switch num {
+		case 1:
+			ev.TimeUnixNano, err = decodeProtoTime(b)
+		case 2:
+			var kv *commonpb.KeyValue
+			if kv, err = decodeJaegerProtoKV(b); err == nil {
+				ev.Attributes = append(ev.Attributes, kv)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	nameJaegerEvent(ev)
+	return ev, nil
+}
+
+// decodeJaegerProtoKV decodes KeyValue { key = 1; v_type = 2; v_str = 3;
+// v_bool = 4; v_int64 = 5; v_float64 = 6; v_binary = 7 }.
+func decodeJaegerProtoKV(msg []byte) (*commonpb.KeyValue, error) {
+	var (
+		key   string
+		vType uint64
+		vStr  string
+		vBool bool
+		vInt  int64
+		vDbl  float64
+		vBin  []byte
+	)
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, v uint64, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			key = string(b)
+		case num == 2 && typ == protowire.VarintType:
+			vType = v
+		case num == 3 && typ == protowire.BytesType:
+			vStr = string(b)
+		case num == 4 && typ == protowire.VarintType:
+			vBool = v != 0
+		case num == 5 && typ == protowire.VarintType:
+			vInt = int64(v) // #nosec G115 -- proto int64 is two's-complement on the wire
+		case num == 6 && typ == protowire.Fixed64Type:
+			vDbl = math.Float64frombits(v)
+		case num == 7 && typ == protowire.BytesType:
+			vBin = append([]byte(nil), b...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	kv := &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{}}
+	switch vType {
+	case jaegerProtoBool:
+		kv.Value.Value = &commonpb.AnyValue_BoolValue{BoolValue: vBool}
+	case jaegerProtoInt64:
+		kv.Value.Value = &commonpb.AnyValue_IntValue{IntValue: vInt}
+	case jaegerProtoFloat64:
+		kv.Value.Value = &commonpb.AnyValue_DoubleValue{DoubleValue: vDbl}
+	case jaegerProtoBinary:
+		kv.Value.Value = &commonpb.AnyValue_BytesValue{BytesValue: vBin}
+	default:
+		kv.Value.Value = &commonpb.AnyValue_StringValue{StringValue: vStr}
+	}
+	return kv, nil
+}
+
+// decodeProtoTime decodes a google.protobuf.Timestamp or Duration (both
+// { int64 seconds = 1; int32 nanos = 2 }) into nanoseconds.
+func decodeProtoTime(msg []byte) (uint64, error) {
+	var sec, nanos int64
+	err := protoFields(msg, func(num protowire.Number, typ protowire.Type, v uint64, _ []byte) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			sec = int64(v) // #nosec G115 -- proto int64 is two's-complement on the wire
+		case 2:
+			nanos = int64(int32(v)) // #nosec G115 -- proto int32 is sign-extended on the wire
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if sec < 0 || nanos < 0 {
+		return 0, nil
+	}
+	return uint64(sec)*1e9 + uint64(nanos), nil // #nosec G115 -- checked non-negative
+}
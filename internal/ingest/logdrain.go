@@ -0,0 +1,374 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// logDrainMaxBody caps a drain POST. Heroku batches a few hundred frames and
+// Vercel a few MB of JSON at most.
+const logDrainMaxBody = 4 << 20
+
+// LogDrain is one configured PaaS log drain. Its token authenticates the
+// drain URL; Name is the default service name and Tenant (empty = default
+// tenant) scopes everything the drain sends.
+type LogDrain struct {
+	Name   string
+	Token  string
+	Tenant string
+}
+
+// ParseLogDrains parses LOG_DRAINS: comma-separated "name:token[:tenant]".
+func ParseLogDrains(spec string) ([]LogDrain, error) {
+	var drains []LogDrain
+	seen := make(map[string]bool)
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid log drain %q, want name:token[:tenant]", entry)
+		}
+		if seen[parts[1]] {
+			return nil, fmt.Errorf("log drain %q reuses another drain's token", parts[0])
+		}
+		seen[parts[1]] = true
+		d := LogDrain{Name: parts[0], Token: parts[1]}
+		if len(parts) == 3 {
+			d.Tenant = storage.SanitizeTenantID(parts[2])
+			if d.Tenant == "" {
+				return nil, fmt.Errorf("log drain %q has an invalid tenant", parts[0])
+			}
+		}
+		drains = append(drains, d)
+	}
+	return drains, nil
+}
+
+// LogDrainHandler accepts HTTPS log drains from PaaS platforms and forwards
+// them to LogsServer.Export:
+//
+//   - POST /ingest/drains/heroku — Logplex frames (application/logplex-1):
+//     octet-counted RFC 5424 syslog lines
+//   - POST /ingest/drains/vercel — Vercel log drain JSON (array or NDJSON)
+//
+// Drains authenticate with their own token rather than the API key, since
+// neither platform can send a bearer token: put it in the drain URL as
+// basic-auth credentials (https://drain:<token>@host/...) or "?token=".
+type LogDrainHandler struct {
+	logs    *LogsServer
+	drains  []LogDrain
+	metrics *telemetry.Metrics
+}
+
+// NewLogDrainHandler creates a handler for the given drains. metrics may be
+// nil.
+func NewLogDrainHandler(logs *LogsServer, drains []LogDrain, metrics *telemetry.Metrics) *LogDrainHandler {
+	return &LogDrainHandler{logs: logs, drains: drains, metrics: metrics}
+}
+
+// RegisterRoutes registers the drain endpoints.
+func (h *LogDrainHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /ingest/drains/heroku", h.handleHeroku)
+	mux.HandleFunc("POST /ingest/drains/vercel", h.handleVercel)
+}
+
+// drainFor resolves the request's token to a drain, comparing every entry
+// in constant time.
+func (h *LogDrainHandler) drainFor(r *http.Request) (LogDrain, bool) {
+	token := r.URL.Query().Get("token")
+	if _, pw, ok := r.BasicAuth(); ok {
+		token = pw
+	} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	var match LogDrain
+	found := false
+	for _, d := range h.drains {
+		if len(token) == len(d.Token) && subtle.ConstantTimeCompare([]byte(token), []byte(d.Token)) == 1 {
+			match, found = d, true
+		}
+	}
+	return match, found && token != ""
+}
+
+func (h *LogDrainHandler) handleHeroku(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, "heroku", parseLogplex)
+}
+
+func (h *LogDrainHandler) handleVercel(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, "vercel", parseVercelDrain)
+}
+
+// drainParser turns a drain body into log records grouped by service name.
+// drain is the default service. invalid counts skipped entries.
+type drainParser func(body []byte, drain string) (records map[string][]*logspb.LogRecord, order []string, invalid int)
+
+func (h *LogDrainHandler) handle(w http.ResponseWriter, r *http.Request, format string, parse drainParser) {
+	drain, ok := h.drainFor(r)
+	if !ok {
+		slog.Warn("Log drain: rejected request with unknown token", "format", format, "remote", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, logDrainMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	records, order, invalid := parse(body, drain.Name)
+	h.metrics.RecordLogDrainLines(format, "invalid", invalid)
+	req := &collogspb.ExportLogsServiceRequest{}
+	total := 0
+	for _, service := range order {
+		req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+				stringKV("service.name", service),
+				stringKV("log.drain.name", drain.Name),
+				stringKV("log.drain.format", format),
+			}},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: "logdrain"},
+				LogRecords: records[service],
+			}},
+		})
+		total += len(records[service])
+	}
+	if total > 0 {
+		ctx := r.Context()
+		if drain.Tenant != "" {
+			ctx = storage.WithTenantContext(ctx, drain.Tenant)
+		}
+		if _, err := h.logs.Export(ctx, req); err != nil {
+			h.metrics.RecordLogDrainLines(format, "export_error", total)
+			if isQueueFull(err) {
+				w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfterSeconds))
+				http.Error(w, "ingest pipeline at capacity", http.StatusTooManyRequests)
+				return
+			}
+			slog.Error("Log drain export failed", "format", format, "drain", drain.Name, "error", err)
+			http.Error(w, "export failed", http.StatusInternalServerError)
+			return
+		}
+		h.metrics.RecordLogDrainLines(format, "ok", total)
+	}
+	// Heroku treats any 2xx as delivered; Vercel expects 200.
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseLogplex parses Logplex octet-counted frames:
+//
+//	83 <40>1 2012-11-30T06:45:29+00:00 host app web.3 - State changed from starting to up
+//
+// Logplex omits RFC 5424 structured data, so everything after MSGID is the
+// message. All frames go to the drain's service; the Heroku source (app,
+// heroku) and dyno are kept as attributes.
+func parseLogplex(body []byte, drain string) (map[string][]*logspb.LogRecord, []string, int) {
+	var recs []*logspb.LogRecord
+	invalid := 0
+	for len(body) > 0 {
+		body = bytes.TrimLeft(body, " \r\n")
+		sp := bytes.IndexByte(body, ' ')
+		if sp <= 0 {
+			break
+		}
+		n, err := strconv.Atoi(string(body[:sp]))
+		if err != nil || n <= 0 || sp+1+n > len(body) {
+			invalid++ // unframed or truncated: nothing after it can be trusted
+			break
+		}
+		frame := string(body[sp+1 : sp+1+n])
+		body = body[sp+1+n:]
+		rec, err := parseSyslogFrame(frame)
+		if err != nil {
+			invalid++
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) == 0 {
+		return nil, nil, invalid
+	}
+	return map[string][]*logspb.LogRecord{drain: recs}, []string{drain}, invalid
+}
+
+var errSyslogFrame = errors.New("logdrain: malformed syslog frame")
+
+// parseSyslogFrame parses "<PRI>1 TIMESTAMP HOST APP PROCID MSGID MSG".
+func parseSyslogFrame(frame string) (*logspb.LogRecord, error) {
+	frame = strings.TrimRight(frame, "\r\n")
+	if !strings.HasPrefix(frame, "<") {
+		return nil, errSyslogFrame
+	}
+	end := strings.IndexByte(frame, '>')
+	if end < 2 {
+		return nil, errSyslogFrame
+	}
+	pri, err := strconv.Atoi(frame[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, errSyslogFrame
+	}
+	// VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID MSG
+	fields := strings.SplitN(frame[end+1:], " ", 7)
+	if len(fields) < 6 {
+		return nil, errSyslogFrame
+	}
+	ts, err := time.Parse(time.RFC3339Nano, fields[1])
+	if err != nil {
+		return nil, errSyslogFrame
+	}
+	msg := ""
+	if len(fields) == 7 {
+		msg = fields[6]
+	}
+	severity := syslogSeverity(pri % 8)
+	if severity == "INFO" && strings.Contains(msg, "at=error") {
+		severity = "ERROR" // Heroku router errors (H10, H12, …) log at info
+	}
+	return &logspb.LogRecord{
+		TimeUnixNano:         uint64(ts.UnixNano()),         // #nosec G115 -- RFC 3339 times after 1970
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()), // #nosec G115 -- wall clock is positive
+		SeverityText:         severity,
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: msg}},
+		Attributes: []*commonpb.KeyValue{
+			stringKV("heroku.source", fields[3]),
+			stringKV("heroku.dyno", fields[4]),
+		},
+	}, nil
+}
+
+// syslogSeverity maps an RFC 5424 severity (PRI % 8) to a level name.
+func syslogSeverity(sev int) string {
+	switch {
+	case sev <= 2: // emerg, alert, crit
+		return "FATAL"
+	case sev == 3:
+		return "ERROR"
+	case sev == 4:
+		return "WARN"
+	case sev == 7:
+		return "DEBUG"
+	default: // notice, info
+		return "INFO"
+	}
+}
+
+// vercelLog is one Vercel log drain entry. Only the fields stored are
+// decoded.
+type vercelLog struct {
+	ID           string `json:"id"`
+	Message      string `json:"message"`
+	Timestamp    int64  `json:"timestamp"` // epoch millis
+	Source       string `json:"source"`    // build | static | external | lambda | edge
+	Type         string `json:"type"`      // stdout | stderr | …
+	Level        string `json:"level"`
+	ProjectName  string `json:"projectName"`
+	DeploymentID string `json:"deploymentId"`
+	RequestID    string `json:"requestId"`
+	Path         string `json:"path"`
+	StatusCode   int    `json:"statusCode"`
+}
+
+// parseVercelDrain parses a JSON array or NDJSON stream of Vercel logs,
+// grouping by projectName (falling back to the drain name).
+func parseVercelDrain(body []byte, drain string) (map[string][]*logspb.LogRecord, []string, int) {
+	var entries []vercelLog
+	invalid := 0
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, nil, 1
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(body))
+		sc.Buffer(make([]byte, 64<<10), logDrainMaxBody)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var e vercelLog
+			if err := json.Unmarshal(line, &e); err != nil {
+				invalid++
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	records := make(map[string][]*logspb.LogRecord)
+	var order []string
+	now := time.Now()
+	for _, e := range entries {
+		service := e.ProjectName
+		if service == "" {
+			service = drain
+		}
+		if _, ok := records[service]; !ok {
+			order = append(order, service)
+		}
+		ts := now
+		if e.Timestamp > 0 {
+			ts = time.UnixMilli(e.Timestamp)
+		}
+		attrs := []*commonpb.KeyValue{stringKV("vercel.source", e.Source)}
+		for _, kv := range [][2]string{
+			{"vercel.deployment_id", e.DeploymentID},
+			{"vercel.request_id", e.RequestID},
+			{"url.path", e.Path},
+		} {
+			if kv[1] != "" {
+				attrs = append(attrs, stringKV(kv[0], kv[1]))
+			}
+		}
+		if e.StatusCode != 0 {
+			attrs = append(attrs, &commonpb.KeyValue{Key: "http.response.status_code", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(e.StatusCode)}}})
+		}
+		records[service] = append(records[service], &logspb.LogRecord{
+			TimeUnixNano:         uint64(ts.UnixNano()),  // #nosec G115 -- epoch millis are positive
+			ObservedTimeUnixNano: uint64(now.UnixNano()), // #nosec G115 -- wall clock is positive
+			SeverityText:         vercelSeverity(e),
+			Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: strings.TrimRight(e.Message, "\n")}},
+			Attributes:           attrs,
+		})
+	}
+	return records, order, invalid
+}
+
+func vercelSeverity(e vercelLog) string {
+	switch strings.ToLower(e.Level) {
+	case "debug":
+		return "DEBUG"
+	case "info":
+		return "INFO"
+	case "warning", "warn":
+		return "WARN"
+	case "error":
+		return "ERROR"
+	case "fatal":
+		return "FATAL"
+	}
+	if e.Type == "stderr" || e.StatusCode >= 500 {
+		return "ERROR"
+	}
+	return "INFO"
+}
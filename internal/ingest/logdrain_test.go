@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func logplexBody(frames ...string) string {
+	var b strings.Builder
+	for _, f := range frames {
+		fmt.Fprintf(&b, "%d %s", len(f), f)
+	}
+	return b.String()
+}
+
+func newLogDrainMux(t *testing.T) (*http.ServeMux, *storage.Repository) {
+	t.Helper()
+	repo := newTestRepo(t)
+	drains, err := ParseLogDrains("storefront:tok-heroku:acme, marketing:tok-vercel")
+	if err != nil {
+		t.Fatalf("ParseLogDrains: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewLogDrainHandler(NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"}), drains, nil).RegisterRoutes(mux)
+	return mux, repo
+}
+
+func TestLogDrain_Heroku(t *testing.T) {
+	mux, repo := newLogDrainMux(t)
+	body := logplexBody(
+		"<190>1 2024-03-01T10:00:00.123456+00:00 host app web.1 - Started GET \"/cart\"\n",
+		"<158>1 2024-03-01T10:00:01+00:00 host heroku router - at=error code=H12 desc=\"Request timeout\"\n",
+		"<187>1 2024-03-01T10:00:02+00:00 host app worker.2 - boom",
+		"not syslog at all",
+	)
+	req := httptest.NewRequest(http.MethodPost, "/ingest/drains/heroku", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/logplex-1")
+	req.SetBasicAuth("drain", "tok-heroku")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%s", rec.Code, rec.Body)
+	}
+
+	var stored []storage.Log
+	if err := repo.DB().Order("id").Find(&stored).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := []struct{ severity, body string }{
+		{"INFO", `Started GET "/cart"`},
+		{"ERROR", `at=error code=H12 desc="Request timeout"`},
+		{"ERROR", "boom"},
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("stored %d logs, want %d: %+v", len(stored), len(want), stored)
+	}
+	for i, w := range want {
+		got := stored[i]
+		if got.Severity != w.severity || got.Body != w.body || got.ServiceName != "storefront" || got.TenantID != "acme" {
+			t.Errorf("log[%d] = {%s %q %s %s}, want %+v", i, got.Severity, got.Body, got.ServiceName, got.TenantID, w)
+		}
+	}
+	if !strings.Contains(string(stored[1].AttributesJSON), "router") {
+		t.Errorf("router frame attributes = %s, want heroku.dyno=router", stored[1].AttributesJSON)
+	}
+}
+
+func TestLogDrain_Vercel(t *testing.T) {
+	mux, repo := newLogDrainMux(t)
+	ndjson := `{"id":"1","message":"GET /api/items 200","timestamp":1709287200000,"source":"lambda","projectName":"shop-web","deploymentId":"dpl_1","path":"/api/items","statusCode":200}
+{"id":"2","message":"Unhandled rejection","timestamp":1709287200001,"source":"lambda","type":"stderr"}
+{broken json
+`
+	for _, tc := range []struct{ name, body string }{
+		{"ndjson", ndjson},
+		{"array", `[{"id":"3","message":"build done","timestamp":1709287200002,"source":"build","level":"warning","projectName":"shop-web"}]`},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/ingest/drains/vercel?token=tok-vercel", strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", tc.name, rec.Code)
+		}
+	}
+
+	var stored []storage.Log
+	if err := repo.DB().Order("id").Find(&stored).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	want := []struct{ service, severity string }{
+		{"shop-web", "INFO"},
+		{"marketing", "ERROR"}, // no projectName: the drain name; stderr → ERROR
+		{"shop-web", "WARN"},
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("stored %d logs, want %d", len(stored), len(want))
+	}
+	for i, w := range want {
+		if stored[i].ServiceName != w.service || stored[i].Severity != w.severity || stored[i].TenantID != storage.DefaultTenantID {
+			t.Errorf("log[%d] = {%s %s %s}, want %+v", i, stored[i].ServiceName, stored[i].Severity, stored[i].TenantID, w)
+		}
+	}
+}
+
+func TestLogDrain_RejectsUnknownToken(t *testing.T) {
+	mux, _ := newLogDrainMux(t)
+	for _, target := range []string{"/ingest/drains/heroku", "/ingest/drains/vercel?token=nope", "/ingest/drains/vercel"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader("[]")))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", target, rec.Code)
+		}
+	}
+}
+
+func TestParseLogDrains_Rejects(t *testing.T) {
+	for _, spec := range []string{"name-only", "a:tok,b:tok", ":tok", "a:tok:ten:extra"} {
+		if _, err := ParseLogDrains(spec); err == nil {
+			t.Errorf("%q: want error", spec)
+		}
+	}
+}
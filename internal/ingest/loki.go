@@ -0,0 +1,533 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	"github.com/klauspost/compress/snappy"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// lokiMaxBody caps a push body on the wire. Promtail and Grafana Agent send
+// batches of 1 MiB by default; bodies are additionally capped at
+// maxDecompressedBody once inflated.
+const lokiMaxBody = 4 << 20
+
+// lokiTenantHeader is the header Loki clients send their tenant_id in.
+const lokiTenantHeader = "X-Scope-OrgID"
+
+// lokiServiceLabels are the stream labels that name the service, in the
+// order Loki's own service_name discovery checks them.
+var lokiServiceLabels = []string{
+	"service_name", "service", "app", "application", "name",
+	"app_kubernetes_io_name", "container", "container_name",
+	"component", "workload", "job",
+}
+
+// lokiLevelKeys are the label and structured metadata names read as the
+// log level.
+var lokiLevelKeys = []string{"level", "severity", "detected_level", "lvl"}
+
+type lokiLabel struct{ name, value string }
+
+type lokiEntry struct {
+	ts       time.Time
+	line     string
+	metadata []lokiLabel
+}
+
+type lokiStream struct {
+	labels  []lokiLabel
+	entries []lokiEntry
+}
+
+// LokiReceiver accepts the Loki push API (POST /loki/api/v1/push,
+// conventionally on :3100), so Promtail, Grafana Agent / Alloy and other
+// Loki clients can ship logs unchanged. Both encodings are served:
+// snappy-compressed protobuf (the clients' default) and JSON, optionally
+// gzipped. Each stream becomes one OTLP resource and is handed to
+// LogsServer.Export.
+type LokiReceiver struct {
+	logs    *LogsServer
+	metrics *telemetry.Metrics
+	auth    *Authenticator // nil = unauthenticated
+
+	srv     *http.Server
+	lis     net.Listener
+	stopped atomic.Bool
+}
+
+// NewLokiReceiver creates a receiver that forwards into logs. metrics may
+// be nil.
+func NewLokiReceiver(logs *LogsServer, metrics *telemetry.Metrics) *LokiReceiver {
+	return &LokiReceiver{logs: logs, metrics: metrics}
+}
+
+// SetAuthenticator makes pushes present INGEST_AUTH_FILE credentials (Loki
+// clients send them as basic_auth or bearer_token). A credential's tenant
+// wins over X-Scope-OrgID. Call before Start.
+func (l *LokiReceiver) SetAuthenticator(a *Authenticator) {
+	l.auth = a
+}
+
+// Start binds addr (e.g. ":3100") and serves POST /loki/api/v1/push.
+func (l *LokiReceiver) Start(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("loki: listen %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	var push http.Handler = http.HandlerFunc(l.handlePush)
+	if l.auth != nil {
+		push = l.auth.Require("loki", push)
+	}
+	mux.Handle("POST /loki/api/v1/push", push)
+	// Some clients probe readiness before pushing.
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ready\n")
+	})
+	l.lis = lis
+	l.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	go func() {
+		if err := l.srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Loki: HTTP server failed", "error", err)
+		}
+	}()
+	slog.Info("📡 Loki push receiver started", "addr", lis.Addr().String())
+	return nil
+}
+
+// Addr returns the bound address, or nil before Start.
+func (l *LokiReceiver) Addr() net.Addr {
+	if l.lis == nil {
+		return nil
+	}
+	return l.lis.Addr()
+}
+
+// Stop drains in-flight requests.
+func (l *LokiReceiver) Stop(ctx context.Context) {
+	if l.srv == nil || !l.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	if err := l.srv.Shutdown(ctx); err != nil {
+		slog.Warn("Loki: HTTP shutdown", "error", err)
+	}
+}
+
+func (l *LokiReceiver) handlePush(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, lokiMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var streams []lokiStream
+	mt, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType))
+	switch mt {
+	case contentTypeJSON:
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			if raw, err = gunzipLimited(raw); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		streams, err = decodeLokiJSON(raw)
+	case "", contentTypeProtobuf:
+		var body []byte
+		if n, lenErr := snappy.DecodedLen(raw); lenErr != nil {
+			err = fmt.Errorf("invalid snappy body: %w", lenErr)
+		} else if n > maxDecompressedBody {
+			http.Error(w, "decompressed body too large", http.StatusRequestEntityTooLarge)
+			return
+		} else if body, err = snappy.Decode(nil, raw); err == nil {
+			streams, err = decodeLokiProto(body)
+		}
+	default:
+		http.Error(w, "unsupported content type: want application/x-protobuf or application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		l.metrics.RecordLokiEntries("invalid", 1)
+		http.Error(w, "invalid Loki push request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, n := convertLokiStreams(streams, time.Now())
+	if n > 0 {
+		ctx := r.Context()
+		// An invalid X-Scope-OrgID sanitizes to "" and falls back to the
+		// default tenant, as X-Tenant-ID does.
+		if tenant := storage.SanitizeTenantID(r.Header.Get(lokiTenantHeader)); tenant != "" && !storage.HasTenantContext(ctx) {
+			ctx = storage.WithTenantContext(ctx, tenant)
+		}
+		if _, err := l.logs.Export(ctx, req); err != nil {
+			l.metrics.RecordLokiEntries("export_error", n)
+			if isQueueFull(err) {
+				w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfterSeconds))
+				http.Error(w, "ingest pipeline at capacity", http.StatusTooManyRequests)
+				return
+			}
+			slog.Warn("Loki: export failed", "error", err)
+			http.Error(w, "export failed", http.StatusInternalServerError)
+			return
+		}
+		l.metrics.RecordLokiEntries("ok", n)
+	}
+	// Loki answers a successful push with 204.
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func gunzipLimited(raw []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("invalid gzip body")
+	}
+	defer func() { _ = gz.Close() }()
+	body, err := io.ReadAll(io.LimitReader(gz, maxDecompressedBody+1))
+	if err != nil {
+		return nil, errors.New("invalid gzip body")
+	}
+	if len(body) > maxDecompressedBody {
+		return nil, fmt.Errorf("%w: decompressed body > %d bytes", errDecompressedTooLarge, maxDecompressedBody)
+	}
+	return body, nil
+}
+
+// decodeLokiJSON parses the JSON push format:
+//
+//	{"streams":[{"stream":{"app":"web"},"values":[["<unix ns>","line",{"trace_id":"…"}]]}]}
+//
+// The third value element, structured metadata, is optional.
+func decodeLokiJSON(body []byte) ([]lokiStream, error) {
+	var req struct {
+		Streams []struct {
+			Stream map[string]string   `json:"stream"`
+			Values [][]json.RawMessage `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	streams := make([]lokiStream, 0, len(req.Streams))
+	for _, s := range req.Streams {
+		st := lokiStream{labels: make([]lokiLabel, 0, len(s.Stream))}
+		for k, v := range s.Stream {
+			st.labels = append(st.labels, lokiLabel{k, v})
+		}
+		sortLokiLabels(st.labels)
+		for _, v := range s.Values {
+			if len(v) < 2 || len(v) > 3 {
+				return nil, fmt.Errorf("value has %d elements, want [timestamp, line] or [timestamp, line, metadata]", len(v))
+			}
+			var tsStr, line string
+			if err := json.Unmarshal(v[0], &tsStr); err != nil {
+				return nil, fmt.Errorf("timestamp must be a string of unix nanoseconds: %w", err)
+			}
+			ns, err := strconv.ParseInt(tsStr, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %q", tsStr)
+			}
+			if err := json.Unmarshal(v[1], &line); err != nil {
+				return nil, fmt.Errorf("line must be a string: %w", err)
+			}
+			e := lokiEntry{ts: time.Unix(0, ns), line: line}
+			if len(v) == 3 {
+				var md map[string]string
+				if err := json.Unmarshal(v[2], &md); err != nil {
+					return nil, fmt.Errorf("structured metadata must be an object of strings: %w", err)
+				}
+				for k, val := range md {
+					e.metadata = append(e.metadata, lokiLabel{k, val})
+				}
+				sortLokiLabels(e.metadata)
+			}
+			st.entries = append(st.entries, e)
+		}
+		streams = append(streams, st)
+	}
+	return streams, nil
+}
+
+func sortLokiLabels(ls []lokiLabel) {
+	// Insertion sort: label sets are small.
+	for i := 1; i < len(ls); i++ {
+		for j := i; j > 0 && ls[j].name < ls[j-1].name; j-- {
+			ls[j], ls[j-1] = ls[j-1], ls[j]
+		}
+	}
+}
+
+// decodeLokiProto parses logproto.PushRequest { repeated StreamAdapter
+// streams = 1 }. StreamAdapter is { string labels = 1; repeated
+// EntryAdapter entries = 2 }, EntryAdapter { Timestamp timestamp = 1;
+// string line = 2; repeated LabelPairAdapter structuredMetadata = 3 }.
+func decodeLokiProto(body []byte) ([]lokiStream, error) {
+	var streams []lokiStream
+	err := protoFields(body, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		var st lokiStream
+		err := protoFields(b, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+			if typ != protowire.BytesType {
+				return nil
+			}
+			switch num {
+			case 1:
+				labels, err := parseLokiLabels(string(b))
+				st.labels = labels
+				return err
+			case 2:
+				e, err := decodeLokiProtoEntry(b)
+				st.entries = append(st.entries, e)
+				return err
+			}
+			return nil
+		})
+		streams = append(streams, st)
+		return err
+	})
+	return streams, err
+}
+
+func decodeLokiProtoEntry(b []byte) (lokiEntry, error) {
+	var e lokiEntry
+	var secs, nanos int64
+	err := protoFields(b, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1: // google.protobuf.Timestamp { int64 seconds = 1; int32 nanos = 2 }
+			return protoFields(b, func(num protowire.Number, typ protowire.Type, v uint64, _ []byte) error {
+				if typ != protowire.VarintType {
+					return nil
+				}
+				switch num {
+				case 1:
+					secs = int64(v) // #nosec G115 -- protobuf int64 wire encoding
+				case 2:
+					nanos = int64(int32(v)) // #nosec G115 -- protobuf int32 wire encoding
+				}
+				return nil
+			})
+		case 2:
+			e.line = string(b)
+		case 3:
+			var p lokiLabel
+			err := protoFields(b, func(num protowire.Number, typ protowire.Type, _ uint64, b []byte) error {
+				if typ == protowire.BytesType && num == 1 {
+					p.name = string(b)
+				} else if typ == protowire.BytesType && num == 2 {
+					p.value = string(b)
+				}
+				return nil
+			})
+			e.metadata = append(e.metadata, p)
+			return err
+		}
+		return nil
+	})
+	e.ts = time.Unix(secs, nanos)
+	return e, err
+}
+
+// parseLokiLabels parses a Prometheus label set: {app="web", env="prod"}.
+// Values are Go-style double-quoted strings.
+func parseLokiLabels(s string) ([]lokiLabel, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid stream labels %q", s)
+	}
+	s = s[1 : len(s)-1]
+	var labels []lokiLabel
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if s == "" {
+			break
+		}
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 || eq+1 >= len(s) || s[eq+1] != '"' {
+			return nil, fmt.Errorf("invalid stream labels near %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		rest := s[eq+1:]
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("unterminated label value for %q", name)
+		}
+		value, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid label value for %q: %w", name, err)
+		}
+		labels = append(labels, lokiLabel{name, value})
+		s = rest[end+1:]
+	}
+	sortLokiLabels(labels)
+	return labels, nil
+}
+
+// convertLokiStreams maps streams to OTLP, one resource per stream named
+// by its service label. Stream labels and structured metadata become log
+// attributes; trace_id / span_id metadata link the line to its trace. n
+// is the number of entries.
+func convertLokiStreams(streams []lokiStream, now time.Time) (req *collogspb.ExportLogsServiceRequest, n int) {
+	req = &collogspb.ExportLogsServiceRequest{}
+	for _, st := range streams {
+		if len(st.entries) == 0 {
+			continue
+		}
+		streamAttrs := make([]*commonpb.KeyValue, 0, len(st.labels))
+		for _, lb := range st.labels {
+			streamAttrs = append(streamAttrs, stringKV(lb.name, lb.value))
+		}
+		streamLevel := lokiLevel(st.labels)
+		recs := make([]*logspb.LogRecord, 0, len(st.entries))
+		for _, e := range st.entries {
+			rec := &logspb.LogRecord{
+				TimeUnixNano:         uint64(e.ts.UnixNano()), // #nosec G115 -- client timestamps after 1970
+				ObservedTimeUnixNano: uint64(now.UnixNano()),  // #nosec G115 -- wall clock is positive
+				Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: e.line}},
+				Attributes:           append([]*commonpb.KeyValue(nil), streamAttrs...),
+			}
+			for _, md := range e.metadata {
+				switch strings.ToLower(md.name) {
+				case "trace_id", "traceid":
+					if id, err := hex.DecodeString(md.value); err == nil && len(id) == 16 {
+						rec.TraceId = id
+						continue
+					}
+				case "span_id", "spanid":
+					if id, err := hex.DecodeString(md.value); err == nil && len(id) == 8 {
+						rec.SpanId = id
+						continue
+					}
+				}
+				rec.Attributes = append(rec.Attributes, stringKV(md.name, md.value))
+			}
+			level := streamLevel
+			if level == "" {
+				level = lokiLevel(e.metadata)
+			}
+			if level == "" {
+				level = lineLevel(e.line)
+			}
+			if level == "" {
+				level = "INFO"
+			}
+			rec.SeverityText = level
+			recs = append(recs, rec)
+		}
+		req.ResourceLogs = append(req.ResourceLogs, &logspb.ResourceLogs{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringKV("service.name", lokiService(st.labels))}},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      &commonpb.InstrumentationScope{Name: "loki"},
+				LogRecords: recs,
+			}},
+		})
+		n += len(recs)
+	}
+	return req, n
+}
+
+func lokiService(labels []lokiLabel) string {
+	for _, key := range lokiServiceLabels {
+		for _, lb := range labels {
+			if lb.name == key && lb.value != "" {
+				return lb.value
+			}
+		}
+	}
+	return "unknown_service"
+}
+
+// lokiLevel returns the normalized level from a level-like label, or "".
+func lokiLevel(labels []lokiLabel) string {
+	for _, key := range lokiLevelKeys {
+		for _, lb := range labels {
+			if lb.name == key {
+				if lvl := normalizeLokiLevel(lb.value); lvl != "" {
+					return lvl
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// lineLevel reads the level from a JSON line's "level" field or a logfmt
+// level= pair, or returns "".
+func lineLevel(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var v struct {
+			Level    string `json:"level"`
+			Severity string `json:"severity"`
+		}
+		if json.Unmarshal([]byte(line), &v) == nil {
+			if v.Level == "" {
+				v.Level = v.Severity
+			}
+			return normalizeLokiLevel(v.Level)
+		}
+		return ""
+	}
+	for _, key := range []string{"level=", "lvl="} {
+		i := strings.Index(line, key)
+		if i < 0 || (i > 0 && line[i-1] != ' ') {
+			continue
+		}
+		v := strings.TrimLeft(line[i+len(key):], `"`)
+		if end := strings.IndexAny(v, "\" \t"); end >= 0 {
+			v = v[:end]
+		}
+		return normalizeLokiLevel(v)
+	}
+	return ""
+}
+
+func normalizeLokiLevel(v string) string {
+	switch strings.ToUpper(strings.TrimSpace(v)) {
+	case "TRACE", "DEBUG", "DBG":
+		return "DEBUG"
+	case "INFO", "INFORMATION", "NOTICE", "INF":
+		return "INFO"
+	case "WARN", "WARNING", "WRN":
+		return "WARN"
+	case "ERROR", "ERR", "EROR":
+		return "ERROR"
+	case "FATAL", "CRITICAL", "CRIT", "PANIC", "EMERG", "ALERT":
+		return "FATAL"
+	}
+	return ""
+}
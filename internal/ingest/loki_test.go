@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseLokiLabels(t *testing.T) {
+	got, err := parseLokiLabels(`{job="varlogs", app="web", msg="say \"hi\", ok"}`)
+	if err != nil {
+		t.Fatalf("parseLokiLabels: %v", err)
+	}
+	want := []lokiLabel{{"app", "web"}, {"job", "varlogs"}, {"msg", `say "hi", ok`}}
+	if len(got) != len(want) {
+		t.Fatalf("labels = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("label %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if got, err := parseLokiLabels("{}"); err != nil || len(got) != 0 {
+		t.Errorf("empty set = %v, %v", got, err)
+	}
+	for _, bad := range []string{`app="web"`, `{app=web}`, `{app="web}`, `{="x"}`} {
+		if _, err := parseLokiLabels(bad); err == nil {
+			t.Errorf("parseLokiLabels(%q) accepted", bad)
+		}
+	}
+}
+
+func TestConvertLokiStreams(t *testing.T) {
+	ts := time.Unix(1767323045, 0)
+	req, n := convertLokiStreams([]lokiStream{
+		{
+			labels: []lokiLabel{{"container", "sidecar"}, {"job", "varlogs"}, {"level", "warning"}},
+			entries: []lokiEntry{{ts: ts, line: "disk low", metadata: []lokiLabel{
+				{"trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"}, {"span_id", "00f067aa0ba902b7"}, {"pod", "web-1"},
+			}}},
+		},
+		{
+			labels: []lokiLabel{{"job", "api"}},
+			entries: []lokiEntry{
+				{ts: ts, line: `{"level":"error","msg":"boom"}`},
+				{ts: ts, line: `ts=1 level=debug msg="cache miss"`},
+				{ts: ts, line: "plain"},
+			},
+		},
+		{labels: []lokiLabel{{"job", "idle"}}},
+	}, ts)
+	if n != 4 || len(req.ResourceLogs) != 2 {
+		t.Fatalf("entries = %d, resources = %d, want 4 and 2", n, len(req.ResourceLogs))
+	}
+	first := req.ResourceLogs[0]
+	if got := first.Resource.Attributes[0].Value.GetStringValue(); got != "sidecar" {
+		t.Errorf("service = %q, want container label over job", got)
+	}
+	rec := first.ScopeLogs[0].LogRecords[0]
+	if rec.SeverityText != "WARN" || len(rec.TraceId) != 16 || len(rec.SpanId) != 8 {
+		t.Errorf("record severity/trace/span = %s/%x/%x", rec.SeverityText, rec.TraceId, rec.SpanId)
+	}
+	attrs := map[string]string{}
+	for _, kv := range rec.Attributes {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	if attrs["job"] != "varlogs" || attrs["pod"] != "web-1" || attrs["trace_id"] != "" {
+		t.Errorf("attributes = %v", attrs)
+	}
+	var levels []string
+	for _, r := range req.ResourceLogs[1].ScopeLogs[0].LogRecords {
+		levels = append(levels, r.SeverityText)
+	}
+	if strings.Join(levels, ",") != "ERROR,DEBUG,INFO" {
+		t.Errorf("line levels = %v, want ERROR,DEBUG,INFO", levels)
+	}
+}
+
+// lokiPushProto builds a snappy-compressed logproto.PushRequest with one
+// stream and one entry.
+func lokiPushProto(labels, line string, secs int64, md map[string]string) []byte {
+	var tsMsg []byte
+	tsMsg = protowire.AppendTag(tsMsg, 1, protowire.VarintType)
+	tsMsg = protowire.AppendVarint(tsMsg, uint64(secs))
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, tsMsg)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendString(entry, line)
+	for k, v := range md {
+		var pair []byte
+		pair = protowire.AppendTag(pair, 1, protowire.BytesType)
+		pair = protowire.AppendString(pair, k)
+		pair = protowire.AppendTag(pair, 2, protowire.BytesType)
+		pair = protowire.AppendString(pair, v)
+		entry = protowire.AppendTag(entry, 3, protowire.BytesType)
+		entry = protowire.AppendBytes(entry, pair)
+	}
+	var stream []byte
+	stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+	stream = protowire.AppendString(stream, labels)
+	stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+	stream = protowire.AppendBytes(stream, entry)
+	var push []byte
+	push = protowire.AppendTag(push, 1, protowire.BytesType)
+	push = protowire.AppendBytes(push, stream)
+	return snappy.Encode(nil, push)
+}
+
+func TestLokiReceiver_HTTP(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Log, 4)
+	logs.SetLogCallback(func(l storage.Log) { stored <- l })
+	lr := NewLokiReceiver(logs, nil)
+
+	push := func(ct string, body []byte, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/push", bytes.NewReader(body))
+		req.Header.Set("Content-Type", ct)
+		if tenant != "" {
+			req.Header.Set("X-Scope-OrgID", tenant)
+		}
+		rec := httptest.NewRecorder()
+		lr.handlePush(rec, req)
+		return rec
+	}
+	next := func() storage.Log {
+		t.Helper()
+		select {
+		case l := <-stored:
+			return l
+		case <-time.After(5 * time.Second):
+			t.Fatal("log never reached the pipeline")
+		}
+		return storage.Log{}
+	}
+
+	// Promtail's default: snappy-compressed protobuf.
+	rec := push("application/x-protobuf", lokiPushProto(`{app="checkout", level="error"}`, "payment failed", 1767323045,
+		map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"}), "acme")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("protobuf status = %d (%s), want 204", rec.Code, rec.Body.String())
+	}
+	l := next()
+	if l.ServiceName != "checkout" || l.Severity != "ERROR" || l.Body != "payment failed" ||
+		l.TenantID != "acme" || l.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || l.Timestamp.Unix() != 1767323045 {
+		t.Errorf("protobuf log = %+v", l)
+	}
+
+	rec = push("application/json", []byte(`{"streams":[{"stream":{"service_name":"billing"},"values":[["1767323045000000000","level=warn msg=slow",{"pod":"b-1"}]]}]}`), "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("JSON status = %d (%s), want 204", rec.Code, rec.Body.String())
+	}
+	l = next()
+	if l.ServiceName != "billing" || l.Severity != "WARN" || !strings.Contains(string(l.AttributesJSON), "b-1") {
+		t.Errorf("JSON log = %+v", l)
+	}
+
+	// A tenant ID with control characters falls back to the default.
+	rec = push("application/json", []byte(`{"streams":[{"stream":{"app":"web"},"values":[["1767323045000000000","x"]]}]}`), "acme\x00evil")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("bad tenant status = %d, want 204", rec.Code)
+	}
+	if l = next(); l.TenantID != storage.DefaultTenantID {
+		t.Errorf("tenant = %q, want %q", l.TenantID, storage.DefaultTenantID)
+	}
+
+	for name, tc := range map[string]struct {
+		ct   string
+		body []byte
+		want int
+	}{
+		"text":          {"text/plain", []byte("x"), http.StatusUnsupportedMediaType},
+		"invalid JSON":  {"application/json", []byte("{"), http.StatusBadRequest},
+		"bad timestamp": {"application/json", []byte(`{"streams":[{"stream":{},"values":[["soon","x"]]}]}`), http.StatusBadRequest},
+		"not snappy":    {"application/x-protobuf", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, http.StatusBadRequest},
+		"bad labels":    {"application/x-protobuf", lokiPushProto(`app=web`, "x", 1, nil), http.StatusBadRequest},
+		"empty push":    {"application/json", []byte(`{"streams":[]}`), http.StatusNoContent},
+	} {
+		if rec := push(tc.ct, tc.body, ""); rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+}
+
+// TestLokiReceiver_Auth verifies INGEST_AUTH_FILE credentials guard the
+// push endpoint and a credential's tenant wins over X-Scope-OrgID.
+func TestLokiReceiver_Auth(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Log, 1)
+	logs.SetLogCallback(func(l storage.Log) { stored <- l })
+	lr := NewLokiReceiver(logs, nil)
+	lr.SetAuthenticator(NewAuthenticator([]AuthEntry{{Kind: AuthBasic, User: "promtail", Secret: "s3cret", Tenant: "acme"}}))
+	if err := lr.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer lr.Stop(context.Background())
+
+	push := func(user, password string) int {
+		body := `{"streams":[{"stream":{"app":"web"},"values":[["1767323045000000000","hello"]]}]}`
+		req, _ := http.NewRequest(http.MethodPost, "http://"+lr.Addr().String()+"/loki/api/v1/push", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Scope-OrgID", "other")
+		if user != "" {
+			req.SetBasicAuth(user, password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("push: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := push("", ""); code != http.StatusUnauthorized {
+		t.Errorf("anonymous push = %d, want 401", code)
+	}
+	if code := push("promtail", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("bad password = %d, want 401", code)
+	}
+	if code := push("promtail", "s3cret"); code != http.StatusNoContent {
+		t.Fatalf("authenticated push = %d, want 204", code)
+	}
+	select {
+	case l := <-stored:
+		if l.TenantID != "acme" {
+			t.Errorf("tenant = %q, want the credential's acme", l.TenantID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("log never reached the pipeline")
+	}
+}
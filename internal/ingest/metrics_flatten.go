@@ -0,0 +1,193 @@
+package ingest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// histogramQuantiles are the quantiles derived from histogram and summary
+// points. Each becomes its own series (<name>_p50, …) so the existing
+// MetricBucket min/max/avg rollups chart them without a new storage model.
+var histogramQuantiles = []struct {
+	q      float64
+	suffix string
+}{
+	{0.50, "_p50"},
+	{0.95, "_p95"},
+	{0.99, "_p99"},
+}
+
+// flattenMetric converts one OTLP metric into the scalar RawMetric points
+// the TSDB aggregator stores. Gauges and sums map 1:1. Distribution types
+// have no single value, so they fan out Prometheus-style into derived
+// series:
+//
+//   - Histogram:            <name>_count, <name>_sum, <name>_p50/_p95/_p99
+//     (quantiles linearly interpolated from the explicit bucket bounds)
+//   - ExponentialHistogram: <name>_count, <name>_sum
+//   - Summary:              <name>_count, <name>_sum, and the client-side
+//     quantiles it carries for 0.5/0.95/0.99
+//
+// Every point derived from a data point carries that point's
+// highest-value exemplar, if any, so a latency spike links to the slowest
+// trace the SDK sampled for it. Summaries have no exemplars.
+func flattenMetric(m *metricspb.Metric, serviceName, tenantID string) []tsdb.RawMetric {
+	var out []tsdb.RawMetric
+	emit := func(name string, value float64, tsNano uint64, attrs []*commonpb.KeyValue, ex *tsdb.Exemplar) {
+		raw := tsdb.RawMetric{
+			Name:        name,
+			ServiceName: serviceName,
+			Value:       value,
+			Timestamp:   time.Unix(0, int64(tsNano)), // #nosec G115 -- OTLP time in nanos: uint64 source fits int64 until year 2262
+			Attributes:  make(map[string]any, len(attrs)),
+			TenantID:    tenantID,
+			Exemplar:    ex,
+		}
+		// Convert attributes to map for TSDB grouping
+		for _, kv := range attrs {
+			raw.Attributes[kv.Key] = kv.Value.String()
+		}
+		out = append(out, raw)
+	}
+
+	switch data := m.Data.(type) {
+	case *metricspb.Metric_Gauge:
+		for _, p := range data.Gauge.DataPoints {
+			emit(m.Name, numberValue(p), p.TimeUnixNano, p.Attributes, topExemplar(p.Exemplars))
+		}
+	case *metricspb.Metric_Sum:
+		for _, p := range data.Sum.DataPoints {
+			emit(m.Name, numberValue(p), p.TimeUnixNano, p.Attributes, topExemplar(p.Exemplars))
+		}
+	case *metricspb.Metric_Histogram:
+		for _, p := range data.Histogram.DataPoints {
+			ex := topExemplar(p.Exemplars)
+			emit(m.Name+"_count", float64(p.Count), p.TimeUnixNano, p.Attributes, ex)
+			emit(m.Name+"_sum", p.GetSum(), p.TimeUnixNano, p.Attributes, ex)
+			if p.Count == 0 {
+				continue
+			}
+			for _, hq := range histogramQuantiles {
+				emit(m.Name+hq.suffix, histogramQuantile(p, hq.q), p.TimeUnixNano, p.Attributes, ex)
+			}
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		for _, p := range data.ExponentialHistogram.DataPoints {
+			ex := topExemplar(p.Exemplars)
+			emit(m.Name+"_count", float64(p.Count), p.TimeUnixNano, p.Attributes, ex)
+			emit(m.Name+"_sum", p.GetSum(), p.TimeUnixNano, p.Attributes, ex)
+		}
+	case *metricspb.Metric_Summary:
+		for _, p := range data.Summary.DataPoints {
+			emit(m.Name+"_count", float64(p.Count), p.TimeUnixNano, p.Attributes, nil)
+			emit(m.Name+"_sum", p.Sum, p.TimeUnixNano, p.Attributes, nil)
+			for _, qv := range p.QuantileValues {
+				for _, hq := range histogramQuantiles {
+					if qv.Quantile == hq.q {
+						emit(m.Name+hq.suffix, qv.Value, p.TimeUnixNano, p.Attributes, nil)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// topExemplar returns the highest-value exemplar that names a trace, or
+// nil when none does.
+func topExemplar(exemplars []*metricspb.Exemplar) *tsdb.Exemplar {
+	var top *tsdb.Exemplar
+	for _, e := range exemplars {
+		id, ok := traceid.Encode(e.TraceId)
+		if !ok {
+			continue
+		}
+		v := exemplarValue(e)
+		if top == nil || v > top.Value {
+			top = &tsdb.Exemplar{TraceID: id, SpanID: fmt.Sprintf("%x", e.SpanId), Value: v}
+		}
+	}
+	return top
+}
+
+func exemplarValue(e *metricspb.Exemplar) float64 {
+	switch v := e.Value.(type) {
+	case *metricspb.Exemplar_AsDouble:
+		return v.AsDouble
+	case *metricspb.Exemplar_AsInt:
+		return float64(v.AsInt)
+	}
+	return 0
+}
+
+func numberValue(p *metricspb.NumberDataPoint) float64 {
+	switch v := p.Value.(type) {
+	case *metricspb.NumberDataPoint_AsDouble:
+		return v.AsDouble
+	case *metricspb.NumberDataPoint_AsInt:
+		return float64(v.AsInt)
+	}
+	return 0
+}
+
+// histogramQuantile estimates quantile q from an explicit-bucket histogram
+// point by locating the bucket holding rank q·count and interpolating
+// linearly inside it. The open-ended first and last buckets are bounded by
+// the point's min/max when the SDK reports them, otherwise by the nearest
+// explicit bound.
+func histogramQuantile(p *metricspb.HistogramDataPoint, q float64) float64 {
+	bounds := p.ExplicitBounds
+	counts := p.BucketCounts
+	if len(counts) == 0 || len(counts) != len(bounds)+1 {
+		if p.Count > 0 {
+			return p.GetSum() / float64(p.Count)
+		}
+		return 0
+	}
+
+	rank := q * float64(p.Count)
+	var cum float64
+	for i, c := range counts {
+		prev := cum
+		cum += float64(c)
+		if cum < rank || c == 0 {
+			continue
+		}
+		lower, upper := bucketRange(p, i)
+		frac := (rank - prev) / float64(c)
+		return lower + (upper-lower)*frac
+	}
+	// Rank past the last bucket (counts/Count mismatch) — fall back to max.
+	_, upper := bucketRange(p, len(counts)-1)
+	return upper
+}
+
+// bucketRange returns the [lower, upper] value range for bucket i.
+func bucketRange(p *metricspb.HistogramDataPoint, i int) (float64, float64) {
+	bounds := p.ExplicitBounds
+	var lower, upper float64
+	switch {
+	case len(bounds) == 0:
+		lower, upper = p.GetMin(), p.GetMax()
+	case i == 0:
+		upper = bounds[0]
+		lower = min(upper, 0)
+		if p.Min != nil {
+			lower = min(p.GetMin(), upper)
+		}
+	case i == len(bounds):
+		lower = bounds[i-1]
+		upper = lower
+		if p.Max != nil {
+			upper = max(p.GetMax(), lower)
+		}
+	default:
+		lower, upper = bounds[i-1], bounds[i]
+	}
+	return lower, upper
+}
@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"math"
+	"testing"
+
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func flattenByName(t *testing.T, m *metricspb.Metric) map[string]float64 {
+	t.Helper()
+	out := make(map[string]float64)
+	for _, raw := range flattenMetric(m, "svc", "default") {
+		if raw.ServiceName != "svc" || raw.TenantID != "default" {
+			t.Fatalf("raw metric lost service/tenant: %+v", raw)
+		}
+		out[raw.Name] = raw.Value
+	}
+	return out
+}
+
+func TestFlattenMetric_Gauge(t *testing.T) {
+	got := flattenByName(t, &metricspb.Metric{
+		Name: "temp",
+		Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+			{TimeUnixNano: 1, Value: &metricspb.NumberDataPoint_AsInt{AsInt: 42}},
+		}}},
+	})
+	if len(got) != 1 || got["temp"] != 42 {
+		t.Errorf("gauge = %v", got)
+	}
+}
+
+func TestFlattenMetric_Histogram(t *testing.T) {
+	// 100 observations: 50 in (0,10], 40 in (10,100], 10 in (100,+inf) capped by max 200.
+	maxV := 200.0
+	got := flattenByName(t, &metricspb.Metric{
+		Name: "latency",
+		Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{DataPoints: []*metricspb.HistogramDataPoint{{
+			TimeUnixNano:   1,
+			Count:          100,
+			Sum:            ptrFloat(3000),
+			Max:            &maxV,
+			ExplicitBounds: []float64{10, 100},
+			BucketCounts:   []uint64{50, 40, 10},
+		}}}},
+	})
+
+	want := map[string]float64{
+		"latency_count": 100,
+		"latency_sum":   3000,
+		"latency_p50":   10,  // rank 50 is the top of the first bucket
+		"latency_p95":   150, // halfway through (100,200]
+		"latency_p99":   190, // 90% through (100,200]
+	}
+	for name, w := range want {
+		if math.Abs(got[name]-w) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, got[name], w)
+		}
+	}
+}
+
+func TestFlattenMetric_EmptyHistogramSkipsQuantiles(t *testing.T) {
+	got := flattenByName(t, &metricspb.Metric{
+		Name: "latency",
+		Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{DataPoints: []*metricspb.HistogramDataPoint{{TimeUnixNano: 1}}}},
+	})
+	if _, ok := got["latency_p50"]; ok || len(got) != 2 {
+		t.Errorf("empty histogram should emit only _count/_sum, got %v", got)
+	}
+}
+
+func TestFlattenMetric_Summary(t *testing.T) {
+	got := flattenByName(t, &metricspb.Metric{
+		Name: "rpc",
+		Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{DataPoints: []*metricspb.SummaryDataPoint{{
+			TimeUnixNano: 1,
+			Count:        10,
+			Sum:          55,
+			QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{
+				{Quantile: 0.5, Value: 5},
+				{Quantile: 0.9, Value: 9}, // not a derived quantile; dropped
+				{Quantile: 0.99, Value: 10},
+			},
+		}}}},
+	})
+	want := map[string]float64{"rpc_count": 10, "rpc_sum": 55, "rpc_p50": 5, "rpc_p99": 10}
+	if len(got) != len(want) {
+		t.Fatalf("summary = %v, want %v", got, want)
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %v, want %v", name, got[name], w)
+		}
+	}
+}
+
+func ptrFloat(v float64) *float64 { return &v }
+
+func TestFlattenMetric_HistogramExemplar(t *testing.T) {
+	traceID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	got := flattenMetric(&metricspb.Metric{
+		Name: "latency",
+		Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{DataPoints: []*metricspb.HistogramDataPoint{{
+			TimeUnixNano: 1,
+			Count:        2,
+			Exemplars: []*metricspb.Exemplar{
+				{TraceId: traceID, SpanId: []byte{1, 1, 1, 1, 1, 1, 1, 1}, Value: &metricspb.Exemplar_AsDouble{AsDouble: 12}},
+				{TraceId: traceID, SpanId: []byte{2, 2, 2, 2, 2, 2, 2, 2}, Value: &metricspb.Exemplar_AsDouble{AsDouble: 480}},
+				{SpanId: []byte{3, 3, 3, 3, 3, 3, 3, 3}, Value: &metricspb.Exemplar_AsDouble{AsDouble: 900}}, // no trace: skipped
+			},
+		}}}},
+	}, "svc", "default")
+
+	if len(got) == 0 {
+		t.Fatal("no points")
+	}
+	for _, raw := range got {
+		ex := raw.Exemplar
+		if ex == nil || ex.TraceID != "0102030405060708090a0b0c0d0e0f10" || ex.SpanID != "0202020202020202" || ex.Value != 480 {
+			t.Errorf("%s exemplar = %+v, want the 480 one", raw.Name, ex)
+		}
+	}
+}
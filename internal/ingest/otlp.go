@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"runtime"
@@ -14,17 +15,18 @@ import (
 	"github.com/RandomCodeSpace/otelcontext/internal/config"
 	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
 	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
 	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
 	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
-	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
 	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // tenantHeader is the canonical HTTP / gRPC metadata key used to override the
@@ -106,12 +108,20 @@ type TraceServer struct {
 	metrics             *telemetry.Metrics
 	logCallback         func(storage.Log)
 	spanCallback        func(storage.Span) // called for each span after persistence
-	minSeverity         int
-	allowedServices     map[string]bool
-	excludedServices    map[string]bool
-	sampler             *Sampler  // nil = no sampling (keep all)
-	pipeline            *Pipeline // nil = synchronous DB writes (legacy path)
-	latencyThresholdMs  float64   // spans slower than this are flagged HasSlow for the pipeline
+	filters             atomic.Pointer[ingestFilters]
+	sampler             atomic.Pointer[Sampler]            // nil = no sampling (keep all)
+	rateLimiter         atomic.Pointer[ServiceRateLimiter] // nil = no per-service span rate limits
+	tailSampler         *TailSampler                       // nil = persist every parsed span
+	validator           *Validator                         // nil = no strict validation (legacy)
+	healthChecks        *HealthCheckFilter                 // nil = keep probe traffic
+	rewriter            *SpanRewriter                      // nil = store spans as sent
+	spanNames           *SpanNameNormalizer                // nil = store span names as sent
+	redactor            atomic.Pointer[Redactor]           // nil = store attributes as sent
+	transformer         *Transformer                       // nil = store attributes as sent
+	pipeline            *Pipeline                          // nil = synchronous DB writes (legacy path)
+	recorder            *FixtureRecorder                   // nil = no fixture capture
+	versions            *VersionTracker                    // nil = no deployments from service.version
+	latencyThresholdMs  float64                            // spans slower than this are flagged HasSlow for the pipeline
 	defaultTenant       string
 	trustResourceTenant bool
 	coltracepb.UnimplementedTraceServiceServer
@@ -121,10 +131,14 @@ type LogsServer struct {
 	repo                *storage.Repository
 	metrics             *telemetry.Metrics
 	logCallback         func(storage.Log)
-	minSeverity         int
-	allowedServices     map[string]bool
-	excludedServices    map[string]bool
-	pipeline            *Pipeline // nil = synchronous DB writes (legacy path)
+	filters             atomic.Pointer[ingestFilters]
+	validator           *Validator                         // nil = no strict validation (legacy)
+	healthChecks        *HealthCheckFilter                 // nil = keep probe traffic
+	redactor            atomic.Pointer[Redactor]           // nil = store attributes and bodies as sent
+	transformer         *Transformer                       // nil = store attributes as sent
+	rateLimiter         atomic.Pointer[ServiceRateLimiter] // nil = no per-service log rate limits
+	pipeline            *Pipeline                          // nil = synchronous DB writes (legacy path)
+	recorder            *FixtureRecorder                   // nil = no fixture capture
 	defaultTenant       string
 	trustResourceTenant bool
 	collogspb.UnimplementedLogsServiceServer
@@ -135,24 +149,23 @@ type MetricsServer struct {
 	metrics             *telemetry.Metrics
 	aggregator          *tsdb.Aggregator
 	metricCallback      func(tsdb.RawMetric)
-	allowedServices     map[string]bool
-	excludedServices    map[string]bool
+	filters             atomic.Pointer[ingestFilters]
+	recorder            *FixtureRecorder // nil = no fixture capture
 	defaultTenant       string
 	trustResourceTenant bool
 	colmetricspb.UnimplementedMetricsServiceServer
 }
 
 func NewTraceServer(repo *storage.Repository, metrics *telemetry.Metrics, cfg *config.Config) *TraceServer {
-	return &TraceServer{
+	s := &TraceServer{
 		repo:                repo,
 		metrics:             metrics,
-		minSeverity:         parseSeverity(cfg.IngestMinSeverity),
-		allowedServices:     parseServiceList(cfg.IngestAllowedServices),
-		excludedServices:    parseServiceList(cfg.IngestExcludedServices),
 		latencyThresholdMs:  float64(cfg.SamplingLatencyThresholdMs),
 		defaultTenant:       cfg.DefaultTenant,
 		trustResourceTenant: cfg.OTLPTrustResourceTenant,
 	}
+	s.SetFilters(cfg)
+	return s
 }
 
 // SetLogCallback sets the function to call when a new log is synthesized from a trace.
@@ -165,9 +178,71 @@ func (s *TraceServer) SetSpanCallback(cb func(storage.Span)) {
 	s.spanCallback = cb
 }
 
-// SetSampler enables adaptive trace sampling. Pass nil to disable.
+// SetSampler enables adaptive trace sampling. Pass nil to disable. Safe
+// while Export runs; in-flight requests finish with the previous sampler.
 func (s *TraceServer) SetSampler(sm *Sampler) {
-	s.sampler = sm
+	s.sampler.Store(sm)
+}
+
+// SetRateLimiter caps spans per second per service, applied after head
+// sampling. Spans over the limit are rejected as rate_limited. Pass nil to
+// disable. Safe while Export runs.
+func (s *TraceServer) SetRateLimiter(rl *ServiceRateLimiter) {
+	s.rateLimiter.Store(rl)
+}
+
+// SetTailSampler routes parsed spans through a tail-sampling buffer; kept
+// traces are persisted when their decision window closes. Pass nil to
+// disable. Must be called before Export traffic starts.
+func (s *TraceServer) SetTailSampler(ts *TailSampler) {
+	s.tailSampler = ts
+	if ts != nil {
+		ts.flush = s.flushSampled
+	}
+}
+
+// SetValidator enables strict validation of incoming spans. Spans with
+// impossible data are rejected and reported via partial_success. Pass nil
+// to disable.
+func (s *TraceServer) SetValidator(v *Validator) {
+	s.validator = v
+}
+
+// SetHealthCheckFilter drops spans from health-check probes before
+// sampling and storage. Pass nil to disable.
+func (s *TraceServer) SetHealthCheckFilter(f *HealthCheckFilter) {
+	s.healthChecks = f
+}
+
+// SetSpanRewriter enables per-service span status/attribute rewrite rules,
+// applied before sampling and storage. Pass nil to disable.
+func (s *TraceServer) SetSpanRewriter(rw *SpanRewriter) {
+	s.rewriter = rw
+}
+
+// SetSpanNameNormalizer enables span name normalization rules, applied
+// after the rewrite rules and before sampling. Pass nil to disable.
+func (s *TraceServer) SetSpanNameNormalizer(n *SpanNameNormalizer) {
+	s.spanNames = n
+}
+
+// SetVersionTracker records a deployment whenever a service reports a new
+// service.version resource attribute. Pass nil to disable.
+func (s *TraceServer) SetVersionTracker(vt *VersionTracker) {
+	s.versions = vt
+}
+
+// SetRedactor enables redaction rules, applied to each request before
+// fixture capture and every other ingest step. Pass nil to disable. Safe
+// while Export runs.
+func (s *TraceServer) SetRedactor(rd *Redactor) {
+	s.redactor.Store(rd)
+}
+
+// SetTransformer enables the attribute transform chain, applied after the
+// health-check filter and before the rewrite rules. Pass nil to disable.
+func (s *TraceServer) SetTransformer(t *Transformer) {
+	s.transformer = t
 }
 
 // SetPipeline enables the async ingest pipeline. When set, Export()
@@ -178,6 +253,54 @@ func (s *TraceServer) SetPipeline(p *Pipeline) {
 	s.pipeline = p
 }
 
+// SetFixtureRecorder captures incoming requests as golden test fixtures.
+// Pass nil to disable.
+func (s *TraceServer) SetFixtureRecorder(r *FixtureRecorder) {
+	s.recorder = r
+}
+
+// SetFixtureRecorder captures incoming requests as golden test fixtures.
+// Pass nil to disable.
+func (s *LogsServer) SetFixtureRecorder(r *FixtureRecorder) {
+	s.recorder = r
+}
+
+// SetFixtureRecorder captures incoming requests as golden test fixtures.
+// Pass nil to disable.
+func (s *MetricsServer) SetFixtureRecorder(r *FixtureRecorder) {
+	s.recorder = r
+}
+
+// SetValidator enables strict validation of incoming log records. Same
+// semantics as TraceServer.SetValidator.
+func (s *LogsServer) SetValidator(v *Validator) {
+	s.validator = v
+}
+
+// SetHealthCheckFilter drops log records from health-check probes. Same
+// semantics as TraceServer.SetHealthCheckFilter.
+func (s *LogsServer) SetHealthCheckFilter(f *HealthCheckFilter) {
+	s.healthChecks = f
+}
+
+// SetRedactor scrubs sensitive values from incoming log records. Same
+// semantics as TraceServer.SetRedactor.
+func (s *LogsServer) SetRedactor(rd *Redactor) {
+	s.redactor.Store(rd)
+}
+
+// SetTransformer enables the attribute transform chain for log records.
+// Same semantics as TraceServer.SetTransformer.
+func (s *LogsServer) SetTransformer(t *Transformer) {
+	s.transformer = t
+}
+
+// SetRateLimiter caps log records per second per service, applied after
+// the severity filter. Same semantics as TraceServer.SetRateLimiter.
+func (s *LogsServer) SetRateLimiter(rl *ServiceRateLimiter) {
+	s.rateLimiter.Store(rl)
+}
+
 // SetPipeline enables the async ingest pipeline for log export. Same
 // semantics as TraceServer.SetPipeline.
 func (s *LogsServer) SetPipeline(p *Pipeline) {
@@ -185,15 +308,14 @@ func (s *LogsServer) SetPipeline(p *Pipeline) {
 }
 
 func NewLogsServer(repo *storage.Repository, metrics *telemetry.Metrics, cfg *config.Config) *LogsServer {
-	return &LogsServer{
+	s := &LogsServer{
 		repo:                repo,
 		metrics:             metrics,
-		minSeverity:         parseSeverity(cfg.IngestMinSeverity),
-		allowedServices:     parseServiceList(cfg.IngestAllowedServices),
-		excludedServices:    parseServiceList(cfg.IngestExcludedServices),
 		defaultTenant:       cfg.DefaultTenant,
 		trustResourceTenant: cfg.OTLPTrustResourceTenant,
 	}
+	s.SetFilters(cfg)
+	return s
 }
 
 // SetLogCallback sets the function to call when a new log is received.
@@ -202,15 +324,15 @@ func (s *LogsServer) SetLogCallback(cb func(storage.Log)) {
 }
 
 func NewMetricsServer(repo *storage.Repository, metrics *telemetry.Metrics, aggregator *tsdb.Aggregator, cfg *config.Config) *MetricsServer {
-	return &MetricsServer{
+	s := &MetricsServer{
 		repo:                repo,
 		metrics:             metrics,
 		aggregator:          aggregator,
-		allowedServices:     parseServiceList(cfg.IngestAllowedServices),
-		excludedServices:    parseServiceList(cfg.IngestExcludedServices),
 		defaultTenant:       cfg.DefaultTenant,
 		trustResourceTenant: cfg.OTLPTrustResourceTenant,
 	}
+	s.SetFilters(cfg)
+	return s
 }
 
 // SetMetricCallback sets the function to call when a new metric point is received.
@@ -220,52 +342,40 @@ func (s *MetricsServer) SetMetricCallback(cb func(tsdb.RawMetric)) {
 
 // Export handles incoming OTLP metrics data.
 func (s *MetricsServer) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	// A dry run reports into dry and leaves metrics and storage untouched.
+	dry := dryRunFrom(ctx)
+	metrics := s.metrics
+	if dry != nil {
+		metrics = nil
+	}
 	start := time.Now()
-	defer func() { s.metrics.ObserveIngestDuration("metrics", time.Since(start)) }()
+	defer func() { metrics.ObserveIngestDuration("metrics", time.Since(start)) }()
+	if s.recorder != nil && dry == nil {
+		s.recorder.record("metrics", req)
+	}
+	filters := s.filters.Load()
+	var rejected rejectTally
 	for _, resourceMetrics := range req.ResourceMetrics {
 		serviceName := getServiceName(resourceMetrics.Resource.Attributes)
+		tenantID := resolveTenant(ctx, resourceMetrics.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
 
-		if !shouldIngestService(serviceName, s.allowedServices, s.excludedServices) {
+		if !filters.service(serviceName) {
+			rejected.add(tenantID, serviceName, rejectServiceFiltered, countResourceDataPoints(resourceMetrics.ScopeMetrics))
+			dry.dropResource(serviceName, "service_filter", rejectServiceFiltered, countResourceDataPoints(resourceMetrics.ScopeMetrics))
+			continue
+		}
+		if !serviceAllowed(ctx, serviceName) {
+			rejected.add(tenantID, serviceName, rejectServiceNotAllowed, countResourceDataPoints(resourceMetrics.ScopeMetrics))
+			dry.dropResource(serviceName, "ingest_auth", rejectServiceNotAllowed, countResourceDataPoints(resourceMetrics.ScopeMetrics))
 			continue
 		}
-
-		tenantID := resolveTenant(ctx, resourceMetrics.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
 
 		for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
 			for _, m := range scopeMetrics.Metrics {
-				var points []*metricspb.NumberDataPoint
-
-				// Extract points based on metric type
-				switch m.Data.(type) {
-				case *metricspb.Metric_Gauge:
-					points = m.GetGauge().DataPoints
-				case *metricspb.Metric_Sum:
-					points = m.GetSum().DataPoints
-				}
-
-				for _, p := range points {
-					var val float64
-					if p.Value != nil {
-						switch v := p.Value.(type) {
-						case *metricspb.NumberDataPoint_AsDouble:
-							val = v.AsDouble
-						case *metricspb.NumberDataPoint_AsInt:
-							val = float64(v.AsInt)
-						}
-					}
-
-					raw := tsdb.RawMetric{
-						Name:        m.Name,
-						ServiceName: serviceName,
-						Value:       val,
-						Timestamp:   time.Unix(0, int64(p.TimeUnixNano)), // #nosec G115 -- OTLP time in nanos: uint64 source fits int64 until year 2262
-						Attributes:  make(map[string]any),
-						TenantID:    tenantID,
-					}
-
-					// Convert attributes to map for TSDB grouping
-					for _, kv := range p.Attributes {
-						raw.Attributes[kv.Key] = kv.Value.String()
+				for _, raw := range flattenMetric(m, serviceName, tenantID) {
+					if dry != nil {
+						dry.storeMetric(raw)
+						continue
 					}
 
 					// 1. Process via TSDB Aggregator (for storage)
@@ -282,18 +392,39 @@ func (s *MetricsServer) Export(ctx context.Context, req *colmetricspb.ExportMetr
 		}
 	}
 
+	if dry != nil {
+		dry.finish(&rejected)
+		return rejected.metricsResponse(), nil
+	}
+
 	if s.metrics != nil {
 		// Just a marker for Prometheus that metrics were received
 		s.metrics.RecordIngestion(1)
 	}
 
-	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+	rejected.observe(s.metrics, "metrics")
+	return rejected.metricsResponse(), nil
 }
 
 // Export handles incoming OTLP trace data.
 func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	// A dry run reports into dry and leaves metrics, sampler state and
+	// storage untouched.
+	dry := dryRunFrom(ctx)
+	metrics := s.metrics
+	if dry != nil {
+		metrics = nil
+	}
 	start := time.Now()
-	defer func() { s.metrics.ObserveIngestDuration("traces", time.Since(start)) }()
+	defer func() { metrics.ObserveIngestDuration("traces", time.Since(start)) }()
+	// Load the reloadable settings once so a request sees one consistent set.
+	filters, sampler, limiter := s.filters.Load(), s.sampler.Load(), s.rateLimiter.Load()
+	if rd := s.redactor.Load(); rd != nil {
+		rd.redactTraces(req, dry == nil)
+	}
+	if s.recorder != nil && dry == nil {
+		s.recorder.record("traces", req)
+	}
 	slog.Debug("📥 [TRACES] Received Request", "resource_spans", len(req.ResourceSpans))
 
 	type batchResult struct {
@@ -305,6 +436,7 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 	}
 
 	results := make([]batchResult, len(req.ResourceSpans))
+	var rejected rejectTally
 
 	g, _ := errgroup.WithContext(ctx)
 	g.SetLimit(runtime.GOMAXPROCS(0) * 4)
@@ -312,13 +444,22 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 	for idx, resourceSpans := range req.ResourceSpans {
 		g.Go(func() error {
 			serviceName := getServiceName(resourceSpans.Resource.Attributes)
+			tenantID := resolveTenant(ctx, resourceSpans.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
 
-			if !shouldIngestService(serviceName, s.allowedServices, s.excludedServices) {
+			if !filters.service(serviceName) {
 				slog.Debug("🚫 [TRACES] Dropped service", "service", serviceName)
+				rejected.add(tenantID, serviceName, rejectServiceFiltered, countResourceSpans(resourceSpans.ScopeSpans))
+				dry.dropResource(serviceName, "service_filter", rejectServiceFiltered, countResourceSpans(resourceSpans.ScopeSpans))
 				return nil
 			}
-
-			tenantID := resolveTenant(ctx, resourceSpans.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
+			if !serviceAllowed(ctx, serviceName) {
+				rejected.add(tenantID, serviceName, rejectServiceNotAllowed, countResourceSpans(resourceSpans.ScopeSpans))
+				dry.dropResource(serviceName, "ingest_auth", rejectServiceNotAllowed, countResourceSpans(resourceSpans.ScopeSpans))
+				return nil
+			}
+			if dry == nil {
+				s.versions.observe(tenantID, serviceName, resourceSpans.Resource.Attributes)
+			}
 
 			localSpans := make([]storage.Span, 0)
 			localTraces := make([]storage.Trace, 0)
@@ -327,39 +468,94 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 
 			for _, scopeSpans := range resourceSpans.ScopeSpans {
 				for _, span := range scopeSpans.Spans {
+					if s.validator != nil {
+						if reason := s.validator.checkSpan(span); reason != "" {
+							rejected.add(tenantID, serviceName, reason, 1)
+							if dry != nil {
+								dry.span(serviceName, span, "validator", DryRunDrop, reason)
+							} else {
+								s.validator.logReject("traces", reason, serviceName, span.TraceId)
+							}
+							continue
+						}
+					}
+					if s.healthChecks != nil && s.healthChecks.matchSpan(span) {
+						rejected.add(tenantID, serviceName, rejectHealthCheck, 1)
+						dry.span(serviceName, span, "health_check", DryRunDrop, rejectHealthCheck)
+						continue
+					}
+					if s.transformer != nil {
+						var changed []string
+						span.Attributes, changed = s.transformer.apply("traces", serviceName, span.Attributes, dry == nil)
+						if len(changed) > 0 {
+							dry.span(serviceName, span, "transform", DryRunModify, strings.Join(changed, ", "))
+						}
+					}
+					if s.rewriter != nil {
+						if dry != nil {
+							before := proto.Clone(span).(*tracepb.Span)
+							s.rewriter.rewriteSpan(serviceName, span)
+							dry.spanRewrite(serviceName, before, span)
+						} else {
+							s.rewriter.rewriteSpan(serviceName, span)
+						}
+					}
+					if s.spanNames != nil {
+						if dry != nil {
+							if name := s.spanNames.rename(serviceName, span.Name, false); name != span.Name {
+								dry.span(serviceName, span, "span_names", DryRunModify, span.Name+" -> "+name)
+								span.Name = name
+							}
+						} else {
+							span.Name = s.spanNames.normalize(serviceName, span.Name)
+						}
+					}
+
 					startTime := time.Unix(0, int64(span.StartTimeUnixNano)) // #nosec G115 -- OTLP time in nanos: uint64 source fits int64 until year 2262
 					endTime := time.Unix(0, int64(span.EndTimeUnixNano))     // #nosec G115 -- OTLP time in nanos: uint64 source fits int64 until year 2262
-					duration := endTime.Sub(startTime).Microseconds()
+					durationNs := endTime.Sub(startTime).Nanoseconds()
 
 					// Adaptive sampling: evaluate before any allocations.
 					statusStr := "STATUS_CODE_UNSET"
 					if span.Status != nil {
 						statusStr = span.Status.Code.String()
 					}
-					if s.sampler != nil {
+					if sampler != nil {
 						isError := statusStr == "STATUS_CODE_ERROR"
-						durationMs := float64(duration) / 1000.0
-						if !s.sampler.ShouldSample(serviceName, isError, durationMs) {
+						durationMs := float64(durationNs) / 1e6
+						if dry != nil {
+							if !dry.headSample(sampler, serviceName, span, isError, durationMs) {
+								rejected.add(tenantID, serviceName, rejectSampled, 1)
+								continue
+							}
+						} else if !sampler.ShouldSampleTrace(serviceName, span.TraceId, isError, durationMs) {
+							rejected.add(tenantID, serviceName, rejectSampled, 1)
 							continue
 						}
 					}
+					// Rate limits draw tokens, so a dry run leaves them alone.
+					if dry == nil && limiter != nil && !limiter.allow(tenantID, serviceName) {
+						rejected.add(tenantID, serviceName, rejectRateLimited, 1)
+						continue
+					}
 
 					attrs, _ := json.Marshal(span.Attributes)
+					traceID, _ := traceid.Encode(span.TraceId)
 
 					// Create Span Model
 					sModel := storage.Span{
 						TenantID:       tenantID,
-						TraceID:        fmt.Sprintf("%x", span.TraceId),
+						TraceID:        traceID,
 						SpanID:         fmt.Sprintf("%x", span.SpanId),
 						ParentSpanID:   fmt.Sprintf("%x", span.ParentSpanId),
 						OperationName:  span.Name,
-						StartTime:      startTime,
-						EndTime:        endTime,
-						Duration:       duration,
 						ServiceName:    serviceName,
 						Status:         statusStr,
 						AttributesJSON: storage.CompressedText(attrs),
+						Links:          spanLinks(span),
+						Events:         spanEvents(span),
 					}
+					sModel.SetTiming(startTime, endTime)
 					localSpans = append(localSpans, sModel)
 
 					// Flag the batch for the async pipeline's priority lane.
@@ -368,16 +564,17 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 					if statusStr == "STATUS_CODE_ERROR" {
 						localHasErr = true
 					}
-					if s.latencyThresholdMs > 0 && float64(duration)/1000.0 >= s.latencyThresholdMs {
+					if s.latencyThresholdMs > 0 && float64(durationNs)/1e6 >= s.latencyThresholdMs {
 						localHasSlow = true
 					}
 
 					tModel := storage.Trace{
 						TenantID:    tenantID,
-						TraceID:     fmt.Sprintf("%x", span.TraceId),
+						TraceID:     traceID,
 						ServiceName: serviceName,
 						Timestamp:   startTime,
-						Duration:    duration,
+						Duration:    sModel.Duration,
+						DurationNs:  durationNs,
 						Status:      statusStr,
 					}
 					localTraces = append(localTraces, tModel)
@@ -389,7 +586,8 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 							severity = "ERROR"
 						}
 
-						if !shouldIngestSeverity(severity, s.minSeverity) {
+						if !shouldIngestSeverity(severity, filters.minSeverity) {
+							dry.add(DryRunDecision{Service: serviceName, Kind: "event", ID: fmt.Sprintf("%x", span.SpanId), Name: event.Name, Processor: "severity_filter", Action: DryRunDrop, Detail: "severity " + severity})
 							continue
 						}
 
@@ -405,7 +603,7 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 
 						l := storage.Log{
 							TenantID:       tenantID,
-							TraceID:        fmt.Sprintf("%x", span.TraceId),
+							TraceID:        traceID,
 							SpanID:         fmt.Sprintf("%x", span.SpanId),
 							Severity:       severity,
 							Body:           body,
@@ -425,7 +623,7 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 					}
 
 					if !hasErrorLog && span.Status != nil && span.Status.Code == tracepb.Status_STATUS_CODE_ERROR {
-						if shouldIngestSeverity("ERROR", s.minSeverity) {
+						if shouldIngestSeverity("ERROR", filters.minSeverity) {
 							msg := span.Status.Message
 							if msg == "" {
 								msg = fmt.Sprintf("Span '%s' failed", span.Name)
@@ -433,7 +631,7 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 
 							l := storage.Log{
 								TenantID:       tenantID,
-								TraceID:        fmt.Sprintf("%x", span.TraceId),
+								TraceID:        traceID,
 								SpanID:         fmt.Sprintf("%x", span.SpanId),
 								Severity:       "ERROR",
 								Body:           msg,
@@ -482,33 +680,65 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 	// Intake metrics fire before the persist decision so operators see
 	// what was received regardless of async drops/rejections. Net
 	// persisted = ingestion_total - ingest_pipeline_dropped_total.
+	if dry != nil {
+		if s.tailSampler != nil {
+			tracesToUpsert, spansToInsert, synthesizedLogs = dry.tailSample(s.tailSampler, tracesToUpsert, spansToInsert, synthesizedLogs)
+		}
+		dry.Stored.Traces, dry.Stored.Spans = tracesToUpsert, spansToInsert
+		dry.Stored.Logs = dry.storeSeverity(s.pipeline, synthesizedLogs)
+		dry.finish(&rejected)
+		return rejected.traceResponse(), nil
+	}
 	if s.metrics != nil && len(spansToInsert) > 0 {
 		s.metrics.GRPCBatchSize.Observe(float64(len(spansToInsert)))
 		s.metrics.RecordIngestion(len(spansToInsert))
 	}
 
-	// Async path: hand off to the pipeline. ErrQueueFull is the only
-	// signal we need to surface to the OTLP client — translates to
-	// gRPC RESOURCE_EXHAUSTED so the client backs off rather than
-	// retrying tighter. Soft backpressure drops are silent.
+	// Tail sampling: buffer until each trace's decision window closes; the
+	// sampler persists kept traces through s.persist.
+	if s.tailSampler != nil {
+		s.tailSampler.Add(tracesToUpsert, spansToInsert, synthesizedLogs)
+		rejected.observe(s.metrics, "traces")
+		return rejected.traceResponse(), nil
+	}
+
+	// Async path: ErrQueueFull is the only signal we need to surface to
+	// the OTLP client — translates to gRPC RESOURCE_EXHAUSTED so the
+	// client backs off rather than retrying tighter. Soft backpressure
+	// drops are reported via partial_success.
+	dropReason, err := s.persist(tracesToUpsert, spansToInsert, synthesizedLogs, batchHasErr, batchHasSlow)
+	if err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			return nil, grpcstatus.Errorf(codes.ResourceExhausted, "ingest pipeline at capacity")
+		}
+		return nil, err
+	}
+	if dropReason != "" {
+		rejected.addSpans(dropReason, spansToInsert)
+	}
+	rejected.observe(s.metrics, "traces")
+	return rejected.traceResponse(), nil
+}
+
+// persist hands parsed trace data to the async pipeline or, when it is
+// disabled, writes it inline. dropReason reports a batch the pipeline
+// accepted but shed under soft backpressure; every span in it was dropped.
+func (s *TraceServer) persist(tracesToUpsert []storage.Trace, spansToInsert []storage.Span, synthesizedLogs []storage.Log, hasErr, hasSlow bool) (dropReason string, err error) {
 	if s.pipeline != nil {
 		batch := &Batch{
 			Type:         SignalTraces,
 			Traces:       tracesToUpsert,
 			Spans:        spansToInsert,
 			Logs:         synthesizedLogs,
-			HasError:     batchHasErr,
-			HasSlow:      batchHasSlow,
+			HasError:     hasErr,
+			HasSlow:      hasSlow,
 			SpanCallback: s.spanCallback,
 			LogCallback:  s.logCallback,
 		}
 		if err := s.pipeline.Submit(batch); err != nil {
-			if errors.Is(err, ErrQueueFull) {
-				return nil, grpcstatus.Errorf(codes.ResourceExhausted, "ingest pipeline at capacity")
-			}
-			return nil, err
+			return "", err
 		}
-		return &coltracepb.ExportTraceServiceResponse{}, nil
+		return batch.DropReason(), nil
 	}
 
 	// Synchronous fallback (s.pipeline == nil). Preserves the original
@@ -526,7 +756,7 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 	if len(spansToInsert) > 0 {
 		if err := s.repo.BatchCreateSpans(spansToInsert); err != nil {
 			slog.Error("❌ Failed to insert spans", "error", err)
-			return nil, err
+			return "", err
 		}
 		// Notify GraphRAG of persisted spans
 		if s.spanCallback != nil {
@@ -548,41 +778,91 @@ func (s *TraceServer) Export(ctx context.Context, req *coltracepb.ExportTraceSer
 			}
 		}
 	}
-
-	return &coltracepb.ExportTraceServiceResponse{}, nil
+	return "", nil
 }
 
 // Export handles incoming OTLP log data.
 func (s *LogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	// A dry run reports into dry and leaves metrics and storage untouched.
+	dry := dryRunFrom(ctx)
+	metrics := s.metrics
+	if dry != nil {
+		metrics = nil
+	}
 	start := time.Now()
-	defer func() { s.metrics.ObserveIngestDuration("logs", time.Since(start)) }()
+	defer func() { metrics.ObserveIngestDuration("logs", time.Since(start)) }()
+	filters, limiter := s.filters.Load(), s.rateLimiter.Load()
+	if rd := s.redactor.Load(); rd != nil {
+		rd.redactLogs(req, dry == nil)
+	}
+	if s.recorder != nil && dry == nil {
+		s.recorder.record("logs", req)
+	}
 	// slog.Debug("📥 [LOGS] Received Request", "resource_logs", len(req.ResourceLogs))
 
 	logResults := make([][]storage.Log, len(req.ResourceLogs))
+	var rejected rejectTally
 
 	g, _ := errgroup.WithContext(ctx)
 
 	for idx, resourceLogs := range req.ResourceLogs {
 		g.Go(func() error {
 			serviceName := getServiceName(resourceLogs.Resource.Attributes)
+			tenantID := resolveTenant(ctx, resourceLogs.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
 
-			if !shouldIngestService(serviceName, s.allowedServices, s.excludedServices) {
+			if !filters.service(serviceName) {
 				slog.Debug("🚫 [LOGS] Dropped service", "service", serviceName)
+				rejected.add(tenantID, serviceName, rejectServiceFiltered, countResourceLogs(resourceLogs.ScopeLogs))
+				dry.dropResource(serviceName, "service_filter", rejectServiceFiltered, countResourceLogs(resourceLogs.ScopeLogs))
+				return nil
+			}
+			if !serviceAllowed(ctx, serviceName) {
+				rejected.add(tenantID, serviceName, rejectServiceNotAllowed, countResourceLogs(resourceLogs.ScopeLogs))
+				dry.dropResource(serviceName, "ingest_auth", rejectServiceNotAllowed, countResourceLogs(resourceLogs.ScopeLogs))
 				return nil
 			}
-
-			tenantID := resolveTenant(ctx, resourceLogs.Resource.Attributes, s.defaultTenant, s.trustResourceTenant)
 
 			localLogs := make([]storage.Log, 0)
 
 			for _, scopeLogs := range resourceLogs.ScopeLogs {
 				for _, l := range scopeLogs.LogRecords {
+					if s.validator != nil {
+						if reason := s.validator.checkLog(l); reason != "" {
+							rejected.add(tenantID, serviceName, reason, 1)
+							if dry != nil {
+								dry.logRecord(serviceName, l, "validator", reason)
+							} else {
+								s.validator.logReject("logs", reason, serviceName, l.TraceId)
+							}
+							continue
+						}
+					}
+					if s.healthChecks != nil && s.healthChecks.matchLog(l) {
+						rejected.add(tenantID, serviceName, rejectHealthCheck, 1)
+						dry.logRecord(serviceName, l, "health_check", rejectHealthCheck)
+						continue
+					}
+
+					if s.transformer != nil {
+						var changed []string
+						l.Attributes, changed = s.transformer.apply("logs", serviceName, l.Attributes, dry == nil)
+						if len(changed) > 0 {
+							dry.logModified(serviceName, l, "transform", strings.Join(changed, ", "))
+						}
+					}
+
 					severity := l.SeverityText
 					if severity == "" {
 						severity = l.SeverityNumber.String()
 					}
 
-					if !shouldIngestSeverity(severity, s.minSeverity) {
+					if !shouldIngestSeverity(severity, filters.minSeverity) {
+						rejected.add(tenantID, serviceName, rejectSeverityFiltered, 1)
+						dry.logRecord(serviceName, l, "severity_filter", "severity "+severity)
+						continue
+					}
+					if dry == nil && limiter != nil && !limiter.allow(tenantID, serviceName) {
+						rejected.add(tenantID, serviceName, rejectRateLimited, 1)
 						continue
 					}
 
@@ -593,10 +873,11 @@ func (s *LogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServic
 
 					bodyStr := l.Body.GetStringValue()
 					attrs, _ := json.Marshal(l.Attributes)
+					traceID, _ := traceid.Encode(l.TraceId)
 
 					logEntry := storage.Log{
 						TenantID:       tenantID,
-						TraceID:        fmt.Sprintf("%x", l.TraceId),
+						TraceID:        traceID,
 						SpanID:         fmt.Sprintf("%x", l.SpanId),
 						Severity:       severity,
 						Body:           bodyStr,
@@ -622,8 +903,15 @@ func (s *LogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServic
 		logsToInsert = append(logsToInsert, lr...)
 	}
 
+	if dry != nil {
+		dry.Stored.Logs = dry.storeSeverity(s.pipeline, logsToInsert)
+		dry.finish(&rejected)
+		return rejected.logsResponse(), nil
+	}
+
 	if len(logsToInsert) == 0 {
-		return &collogspb.ExportLogsServiceResponse{}, nil
+		rejected.observe(s.metrics, "logs")
+		return rejected.logsResponse(), nil
 	}
 
 	// Intake metric fires before the persist decision (see TraceServer.Export
@@ -655,7 +943,11 @@ func (s *LogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServic
 			}
 			return nil, err
 		}
-		return &collogspb.ExportLogsServiceResponse{}, nil
+		if reason := batch.DropReason(); reason != "" {
+			rejected.addLogs(reason, logsToInsert)
+		}
+		rejected.observe(s.metrics, "logs")
+		return rejected.logsResponse(), nil
 	}
 
 	// Synchronous fallback (preserves original behavior when async is disabled).
@@ -669,7 +961,8 @@ func (s *LogsServer) Export(ctx context.Context, req *collogspb.ExportLogsServic
 		}
 	}
 
-	return &collogspb.ExportLogsServiceResponse{}, nil
+	rejected.observe(s.metrics, "logs")
+	return rejected.logsResponse(), nil
 }
 
 // Helper to extract service.name from attributes
@@ -682,11 +975,94 @@ func getServiceName(attrs []*commonpb.KeyValue) string {
 	return "unknown-service"
 }
 
+// spanLinks converts the OTLP links of span, dropping links without a
+// valid trace ID and tracestates over storage.MaxSpanLinkTraceState.
+func spanLinks(span *tracepb.Span) []storage.SpanLink {
+	if len(span.Links) == 0 {
+		return nil
+	}
+	links := make([]storage.SpanLink, 0, len(span.Links))
+	for _, l := range span.Links {
+		traceID, ok := traceid.Encode(l.TraceId)
+		if !ok {
+			continue
+		}
+		link := storage.SpanLink{
+			LinkedTraceID: traceID,
+			LinkedSpanID:  fmt.Sprintf("%x", l.SpanId),
+		}
+		if len(l.TraceState) <= storage.MaxSpanLinkTraceState {
+			link.TraceState = l.TraceState
+		}
+		if len(l.Attributes) > 0 {
+			attrs, _ := json.Marshal(l.Attributes)
+			link.AttributesJSON = storage.CompressedText(attrs)
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// spanEvents converts span's OTLP events for the span_events table. They
+// are kept whatever INGEST_MIN_SEVERITY says about the logs synthesized from
+// the same events.
+func spanEvents(span *tracepb.Span) []storage.SpanEvent {
+	if len(span.Events) == 0 {
+		return nil
+	}
+	events := make([]storage.SpanEvent, 0, len(span.Events))
+	for _, e := range span.Events {
+		ev := storage.SpanEvent{Name: e.Name}
+		if e.TimeUnixNano > 0 {
+			ev.Timestamp = time.Unix(0, int64(e.TimeUnixNano)) // #nosec G115 -- OTLP time in nanos: uint64 source fits int64 until year 2262
+		}
+		if len(e.Attributes) > 0 {
+			attrs, _ := json.Marshal(e.Attributes)
+			ev.AttributesJSON = storage.CompressedText(attrs)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
 // ParseSeverity is the exported wrapper for parseSeverity. Used by main.go
 // to translate the STORE_MIN_SEVERITY env value into the integer rank the
 // pipeline's second-tier filter expects.
 func ParseSeverity(level string) int { return parseSeverity(level) }
 
+// ingestFilters are a server's service and severity filters, swapped
+// whole by SetFilters.
+type ingestFilters struct {
+	minSeverity int
+	allowed     map[string]bool
+	excluded    map[string]bool
+}
+
+func newIngestFilters(cfg *config.Config) *ingestFilters {
+	return &ingestFilters{
+		minSeverity: parseSeverity(cfg.IngestMinSeverity),
+		allowed:     parseServiceList(cfg.IngestAllowedServices),
+		excluded:    parseServiceList(cfg.IngestExcludedServices),
+	}
+}
+
+func (f *ingestFilters) service(name string) bool {
+	return shouldIngestService(name, f.allowed, f.excluded)
+}
+
+// SetFilters replaces the service and severity filters with those of cfg
+// (INGEST_ALLOWED_SERVICES, INGEST_EXCLUDED_SERVICES, INGEST_MIN_SEVERITY).
+// Safe while Export runs; in-flight requests finish with the old filters.
+func (s *TraceServer) SetFilters(cfg *config.Config) { s.filters.Store(newIngestFilters(cfg)) }
+
+// SetFilters replaces the service and severity filters. Same semantics as
+// TraceServer.SetFilters.
+func (s *LogsServer) SetFilters(cfg *config.Config) { s.filters.Store(newIngestFilters(cfg)) }
+
+// SetFilters replaces the service filters; metrics have no severity. Same
+// semantics as TraceServer.SetFilters.
+func (s *MetricsServer) SetFilters(cfg *config.Config) { s.filters.Store(newIngestFilters(cfg)) }
+
 // Filtering Helpers
 func parseSeverity(level string) int {
 	switch strings.ToUpper(level) {
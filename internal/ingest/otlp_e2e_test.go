@@ -458,3 +458,41 @@ func countSpans(t *testing.T, repo *storage.Repository) int {
 	}
 	return len(traces)
 }
+
+// TestTraceExport_MaterializesExceptionEvents verifies a span's exception
+// event reaches the exceptions API with its type, message and stack trace.
+func TestTraceExport_MaterializesExceptionEvents(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	str := func(k, v string) *commonpb.KeyValue {
+		return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+	}
+	req := buildTracesRequest("checkout", 2)
+	for i, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+		span.Events = []*tracepb.Span_Event{{
+			Name:         "exception",
+			TimeUnixNano: span.StartTimeUnixNano,
+			Attributes: []*commonpb.KeyValue{
+				str("exception.type", "java.net.SocketTimeoutException"),
+				str("exception.message", []string{"Read timed out after 3000ms", "Read timed out after 500ms"}[i]),
+				str("exception.stacktrace", "java.net.SocketTimeoutException\n\tat Pay.call(Pay.java:42)"),
+			},
+		}}
+	}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	groups, err := repo.GetExceptionGroups(context.Background(), storage.ExceptionGroupQuery{})
+	if err != nil {
+		t.Fatalf("GetExceptionGroups: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("groups = %+v, want 1", groups)
+	}
+	g := groups[0]
+	if g.Count != 2 || g.ServiceName != "checkout" || g.Type != "java.net.SocketTimeoutException" ||
+		g.Message != "Read timed out after <n>ms" || !strings.Contains(g.SampleStacktrace, "Pay.java:42") {
+		t.Errorf("group = %+v", g)
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
@@ -38,6 +41,10 @@ const headerContentType = "Content-Type" //nolint:goconst // single literal; Son
 // Uses the shared storage.WithTenantContext helper so ingest and read paths
 // agree on the context key.
 func withTenantFromHTTP(r *http.Request) context.Context {
+	// A tenant pinned by a tenant-bound API key is authoritative.
+	if storage.HasTenantContext(r.Context()) {
+		return r.Context()
+	}
 	if v := r.Header.Get("X-Tenant-ID"); v != "" {
 		return storage.WithTenantContext(r.Context(), v)
 	}
@@ -67,6 +74,11 @@ type HTTPHandler struct {
 	// time the async ingest pipeline returns RESOURCE_EXHAUSTED and the HTTP
 	// handler maps it to 429. nil-safe.
 	onThrottle func(signal string)
+
+	// corsOrigins lists the origins allowed to export from a browser. Empty
+	// (default) disables CORS entirely: no preflight routes, no headers.
+	// "*" allows any origin.
+	corsOrigins []string
 }
 
 // NewHTTPHandler creates an HTTP OTLP handler wrapping the existing gRPC servers.
@@ -102,6 +114,13 @@ func (h *HTTPHandler) SetThrottleCallback(fn func(signal string)) {
 	h.onThrottle = fn
 }
 
+// SetCORSAllowedOrigins enables CORS on /v1/* for the given origins so
+// browser SDKs (OTLP/HTTP JSON or protobuf exporters) can post directly.
+// Must be called before RegisterRoutes. Pass nil to disable.
+func (h *HTTPHandler) SetCORSAllowedOrigins(origins []string) {
+	h.corsOrigins = origins
+}
+
 // isQueueFull reports whether the error returned by an Export() method is
 // the gRPC RESOURCE_EXHAUSTED status used by the async pipeline to signal
 // "queue at capacity". Used by the HTTP handlers to map back to 429.
@@ -121,19 +140,50 @@ func isQueueFull(err error) bool {
 // writeThrottled emits an OTLP-shaped 429 with a Retry-After header. The
 // Retry-After value is duplicated in the protobuf Status message so clients
 // that don't read headers (some custom OTLP shims) still see it.
-func (h *HTTPHandler) writeThrottled(w http.ResponseWriter, signal string) {
+func (h *HTTPHandler) writeThrottled(w http.ResponseWriter, r *http.Request, signal string) {
 	if h.onThrottle != nil {
 		h.onThrottle(signal)
 	}
 	w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfterSeconds))
-	writeOTLPError(w, http.StatusTooManyRequests, fmt.Sprintf("ingest pipeline at capacity, retry after %ds", defaultRetryAfterSeconds))
+	writeOTLPError(w, r, http.StatusTooManyRequests, fmt.Sprintf("ingest pipeline at capacity, retry after %ds", defaultRetryAfterSeconds))
 }
 
 // RegisterRoutes registers the HTTP OTLP endpoints on the given mux.
 func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /v1/traces", h.handleTraces)
-	mux.HandleFunc("POST /v1/logs", h.handleLogs)
-	mux.HandleFunc("POST /v1/metrics", h.handleMetrics)
+	mux.HandleFunc("POST /v1/traces", h.withCORS(h.handleTraces))
+	mux.HandleFunc("POST /v1/logs", h.withCORS(h.handleLogs))
+	mux.HandleFunc("POST /v1/metrics", h.withCORS(h.handleMetrics))
+	mux.HandleFunc("POST /v1/dry-run/{signal}", h.handleDryRun)
+	if len(h.corsOrigins) > 0 {
+		preflight := h.withCORS(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		mux.HandleFunc("OPTIONS /v1/traces", preflight)
+		mux.HandleFunc("OPTIONS /v1/logs", preflight)
+		mux.HandleFunc("OPTIONS /v1/metrics", preflight)
+	}
+}
+
+// withCORS sets CORS response headers when the request Origin is allowed.
+// A disallowed origin gets no CORS headers, so the browser blocks the
+// response — the request itself is still processed like any other POST.
+func (h *HTTPHandler) withCORS(next http.HandlerFunc) http.HandlerFunc {
+	if len(h.corsOrigins) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && (slices.Contains(h.corsOrigins, "*") || slices.Contains(h.corsOrigins, origin)) {
+			hdr := w.Header()
+			hdr.Set("Access-Control-Allow-Origin", origin)
+			hdr.Add("Vary", "Origin")
+			hdr.Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			hdr.Set("Access-Control-Allow-Headers", "Content-Type, Content-Encoding, Authorization, X-API-Key, X-Tenant-ID")
+			hdr.Set("Access-Control-Expose-Headers", "Retry-After")
+			hdr.Set("Access-Control-Max-Age", "86400")
+		}
+		next(w, r)
+	}
 }
 
 func (h *HTTPHandler) handleTraces(w http.ResponseWriter, r *http.Request) {
@@ -143,24 +193,24 @@ func (h *HTTPHandler) handleTraces(w http.ResponseWriter, r *http.Request) {
 		if errors.Is(err, errDecompressedTooLarge) {
 			status = http.StatusRequestEntityTooLarge
 		}
-		writeOTLPError(w, status, err.Error())
+		writeOTLPError(w, r, status, err.Error())
 		return
 	}
 
 	req := &coltracepb.ExportTraceServiceRequest{}
 	if err := h.unmarshal(r, body, req); err != nil {
-		writeOTLPError(w, http.StatusBadRequest, err.Error())
+		writeOTLPError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	resp, err := h.traces.Export(withTenantFromHTTP(r), req)
 	if err != nil {
 		if isQueueFull(err) {
-			h.writeThrottled(w, "traces")
+			h.writeThrottled(w, r, "traces")
 			return
 		}
 		slog.Error("HTTP OTLP traces export failed", "error", err)
-		writeOTLPError(w, http.StatusInternalServerError, err.Error())
+		writeOTLPError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -174,24 +224,24 @@ func (h *HTTPHandler) handleLogs(w http.ResponseWriter, r *http.Request) {
 		if errors.Is(err, errDecompressedTooLarge) {
 			status = http.StatusRequestEntityTooLarge
 		}
-		writeOTLPError(w, status, err.Error())
+		writeOTLPError(w, r, status, err.Error())
 		return
 	}
 
 	req := &collogspb.ExportLogsServiceRequest{}
 	if err := h.unmarshal(r, body, req); err != nil {
-		writeOTLPError(w, http.StatusBadRequest, err.Error())
+		writeOTLPError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	resp, err := h.logs.Export(withTenantFromHTTP(r), req)
 	if err != nil {
 		if isQueueFull(err) {
-			h.writeThrottled(w, "logs")
+			h.writeThrottled(w, r, "logs")
 			return
 		}
 		slog.Error("HTTP OTLP logs export failed", "error", err)
-		writeOTLPError(w, http.StatusInternalServerError, err.Error())
+		writeOTLPError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -205,24 +255,24 @@ func (h *HTTPHandler) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		if errors.Is(err, errDecompressedTooLarge) {
 			status = http.StatusRequestEntityTooLarge
 		}
-		writeOTLPError(w, status, err.Error())
+		writeOTLPError(w, r, status, err.Error())
 		return
 	}
 
 	req := &colmetricspb.ExportMetricsServiceRequest{}
 	if err := h.unmarshal(r, body, req); err != nil {
-		writeOTLPError(w, http.StatusBadRequest, err.Error())
+		writeOTLPError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	resp, err := h.metrics.Export(withTenantFromHTTP(r), req)
 	if err != nil {
 		if isQueueFull(err) {
-			h.writeThrottled(w, "metrics")
+			h.writeThrottled(w, r, "metrics")
 			return
 		}
 		slog.Error("HTTP OTLP metrics export failed", "error", err)
-		writeOTLPError(w, http.StatusInternalServerError, err.Error())
+		writeOTLPError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -272,9 +322,20 @@ func (h *HTTPHandler) readBody(r *http.Request) ([]byte, error) {
 	return body, nil
 }
 
+// requestMediaType returns the request's Content-Type without parameters,
+// so "application/json; charset=utf-8" (what browser fetch sends) matches
+// contentTypeJSON.
+func requestMediaType(r *http.Request) string {
+	ct := r.Header.Get(headerContentType)
+	if mt, _, err := mime.ParseMediaType(ct); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
 // unmarshal decodes the body based on Content-Type header.
 func (h *HTTPHandler) unmarshal(r *http.Request, body []byte, msg proto.Message) error {
-	ct := r.Header.Get(headerContentType)
+	ct := requestMediaType(r)
 	switch ct {
 	case contentTypeProtobuf, "":
 		if err := proto.Unmarshal(body, msg); err != nil {
@@ -292,12 +353,11 @@ func (h *HTTPHandler) unmarshal(r *http.Request, body []byte, msg proto.Message)
 
 // writeResponse marshals and writes the OTLP response.
 func (h *HTTPHandler) writeResponse(w http.ResponseWriter, r *http.Request, msg proto.Message) {
-	ct := r.Header.Get(headerContentType)
-	if ct == contentTypeJSON {
+	if requestMediaType(r) == contentTypeJSON {
 		w.Header().Set(headerContentType, contentTypeJSON)
 		data, err := protojson.Marshal(msg)
 		if err != nil {
-			writeOTLPError(w, http.StatusInternalServerError, "failed to marshal response")
+			writeOTLPError(w, r, http.StatusInternalServerError, "failed to marshal response")
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -306,7 +366,7 @@ func (h *HTTPHandler) writeResponse(w http.ResponseWriter, r *http.Request, msg
 		w.Header().Set(headerContentType, contentTypeProtobuf)
 		data, err := proto.Marshal(msg)
 		if err != nil {
-			writeOTLPError(w, http.StatusInternalServerError, "failed to marshal response")
+			writeOTLPError(w, r, http.StatusInternalServerError, "failed to marshal response")
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -314,19 +374,26 @@ func (h *HTTPHandler) writeResponse(w http.ResponseWriter, r *http.Request, msg
 	}
 }
 
-// writeOTLPError writes an OTLP-compliant error response.
-func writeOTLPError(w http.ResponseWriter, statusCode int, msg string) {
-	// OTLP HTTP spec: errors are returned as Status protobuf
+// writeOTLPError writes an OTLP-compliant error response. Per the OTLP/HTTP
+// spec the Status message uses the same encoding as the request, so JSON
+// clients (browsers) get a JSON Status they can actually parse.
+func writeOTLPError(w http.ResponseWriter, r *http.Request, statusCode int, msg string) {
 	status := &spb.Status{
 		Code:    int32(statusCode), // #nosec G115 -- HTTP status code always fits int32
 		Message: msg,
 	}
-	data, err := proto.Marshal(status)
+	ct := contentTypeProtobuf
+	marshal := proto.Marshal
+	if requestMediaType(r) == contentTypeJSON {
+		ct = contentTypeJSON
+		marshal = protojson.Marshal
+	}
+	data, err := marshal(status)
 	if err != nil {
 		http.Error(w, msg, statusCode)
 		return
 	}
-	w.Header().Set(headerContentType, contentTypeProtobuf)
+	w.Header().Set(headerContentType, ct)
 	w.WriteHeader(statusCode)
 	_, _ = w.Write(data)
 }
@@ -0,0 +1,106 @@
+package ingest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func newCORSTestMux(t *testing.T, origins []string) *http.ServeMux {
+	t.Helper()
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+	h := NewHTTPHandler(NewTraceServer(repo, nil, cfg), NewLogsServer(repo, nil, cfg), NewMetricsServer(repo, nil, nil, cfg))
+	h.SetCORSAllowedOrigins(origins)
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux
+}
+
+// TestOTLPHTTP_JSONWithCharset covers browser fetch(), which sends
+// "application/json; charset=utf-8" rather than the bare media type.
+func TestOTLPHTTP_JSONWithCharset(t *testing.T) {
+	mux := newCORSTestMux(t, nil)
+	body, err := protojson.Marshal(buildTracesRequest("browser", 1))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/traces", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body=%q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != contentTypeJSON {
+		t.Errorf("response Content-Type = %q, want %q", ct, contentTypeJSON)
+	}
+}
+
+// TestOTLPHTTP_JSONErrorBody verifies error Status bodies follow the request
+// encoding so JSON clients can decode them.
+func TestOTLPHTTP_JSONErrorBody(t *testing.T) {
+	mux := newCORSTestMux(t, nil)
+	req := httptest.NewRequest(http.MethodPost, "/v1/logs", strings.NewReader("{not json"))
+	req.Header.Set("Content-Type", contentTypeJSON)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	var st spb.Status
+	if err := protojson.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("error body is not a JSON Status: %v (%q)", err, rec.Body.String())
+	}
+	if st.GetCode() != http.StatusBadRequest {
+		t.Errorf("Status.Code = %d, want 400", st.GetCode())
+	}
+}
+
+func TestOTLPHTTP_CORS(t *testing.T) {
+	mux := newCORSTestMux(t, []string{"https://app.example.com"})
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+	preflight.Header.Set("Origin", "https://app.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+
+	other := httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+	other.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, other)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin got Allow-Origin %q", got)
+	}
+}
+
+// TestOTLPHTTP_CORSDisabledByDefault guards the legacy surface: no preflight
+// route and no CORS headers unless origins are configured.
+func TestOTLPHTTP_CORSDisabledByDefault(t *testing.T) {
+	mux := newCORSTestMux(t, nil)
+	req := httptest.NewRequest(http.MethodOptions, "/v1/traces", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("OPTIONS status = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Allow-Origin = %q, want none", got)
+	}
+}
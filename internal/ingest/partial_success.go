@@ -0,0 +1,223 @@
+package ingest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Rejection reasons reported in OTLP partial_success messages and on the
+// otelcontext_ingest_rejected_total{reason=…} counter. Keep the set small and
+// stable — operators alert on these labels.
+const (
+	rejectServiceFiltered   = "service_filtered"    // INGEST_ALLOWED_SERVICES / INGEST_EXCLUDED_SERVICES
+	rejectSeverityFiltered  = "severity_filtered"   // INGEST_MIN_SEVERITY
+	rejectBackpressure      = "soft_backpressure"   // pipeline >= soft threshold, healthy batch shed
+	rejectTenantQuota       = "tenant_backpressure" // per-tenant in-flight cap reached
+	rejectServiceNotAllowed = "service_not_allowed" // ingest credential's services= allowlist
+	rejectSampled           = "sampled"             // head sampler: SAMPLING_RATE / SAMPLING_SERVICE_RATES
+)
+
+// rejectTally accumulates the records refused during a single Export call,
+// keyed by tenant, service and reason. The OTLP spec lets a server accept a
+// request while telling the client how many items it dropped and why;
+// surfacing that instead of a bare success keeps SDK-side "exported"
+// counters honest. The per-service split feeds the data quality report.
+//
+// Safe for concurrent use — TraceServer.Export fans resources out across an
+// errgroup and every goroutine reports into the same tally.
+type rejectTally struct {
+	mu     sync.Mutex
+	counts map[rejectKey]int64
+}
+
+// rejectKey is the origin of rejected items.
+type rejectKey struct {
+	tenant, service, reason string
+}
+
+// add records n items of tenant's service rejected under reason. n <= 0 is
+// a no-op.
+func (t *rejectTally) add(tenant, service, reason string, n int) {
+	if n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	if t.counts == nil {
+		t.counts = make(map[rejectKey]int64)
+	}
+	t.counts[rejectKey{tenant, service, reason}] += int64(n)
+	t.mu.Unlock()
+}
+
+// addSpans records spans rejected under reason, attributed to their own
+// tenant and service.
+func (t *rejectTally) addSpans(reason string, spans []storage.Span) {
+	for i := range spans {
+		t.add(spans[i].TenantID, spans[i].ServiceName, reason, 1)
+	}
+}
+
+// addLogs records logs rejected under reason, attributed to their own
+// tenant and service.
+func (t *rejectTally) addLogs(reason string, logs []storage.Log) {
+	for i := range logs {
+		t.add(logs[i].TenantID, logs[i].ServiceName, reason, 1)
+	}
+}
+
+// total returns the number of rejected items across all reasons.
+func (t *rejectTally) total() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int64
+	for _, c := range t.counts {
+		n += c
+	}
+	return n
+}
+
+// snapshot returns the per-reason counts.
+func (t *rejectTally) snapshot() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]int64, len(t.counts))
+	for k, v := range t.counts {
+		out[k.reason] += v
+	}
+	return out
+}
+
+// message renders the per-reason breakdown as a stable, human-readable
+// string, e.g. "rejected 7: service_filtered=5, severity_filtered=2".
+// Reasons are sorted so the message is deterministic across calls.
+func (t *rejectTally) message() string {
+	counts := t.snapshot()
+	if len(counts) == 0 {
+		return ""
+	}
+	reasons := make([]string, 0, len(counts))
+	var total int64
+	for r, c := range counts {
+		reasons = append(reasons, r)
+		total += c
+	}
+	sort.Strings(reasons)
+	parts := make([]string, len(reasons))
+	for i, r := range reasons {
+		parts[i] = fmt.Sprintf("%s=%d", r, counts[r])
+	}
+	return fmt.Sprintf("rejected %d: %s", total, strings.Join(parts, ", "))
+}
+
+// observe feeds every reason into the rejected-records counter for signal,
+// and every origin into the per-service dropped-records ledger. Nil-safe on
+// the Metrics side so tests can run without a registry.
+func (t *rejectTally) observe(m *telemetry.Metrics, signal string) {
+	for reason, n := range t.snapshot() {
+		m.RecordIngestRejected(signal, reason, n)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, n := range t.counts {
+		m.RecordDroppedRecords(k.tenant, k.service, signal, k.reason, n)
+	}
+}
+
+// traceResponse builds the OTLP trace response. PartialSuccess is only set
+// when something was rejected — the spec reserves an unset field for full
+// acceptance.
+func (t *rejectTally) traceResponse() *coltracepb.ExportTraceServiceResponse {
+	n := t.total()
+	if n == 0 {
+		return &coltracepb.ExportTraceServiceResponse{}
+	}
+	return &coltracepb.ExportTraceServiceResponse{
+		PartialSuccess: &coltracepb.ExportTracePartialSuccess{
+			RejectedSpans: n,
+			ErrorMessage:  t.message(),
+		},
+	}
+}
+
+// logsResponse builds the OTLP logs response. See traceResponse.
+func (t *rejectTally) logsResponse() *collogspb.ExportLogsServiceResponse {
+	n := t.total()
+	if n == 0 {
+		return &collogspb.ExportLogsServiceResponse{}
+	}
+	return &collogspb.ExportLogsServiceResponse{
+		PartialSuccess: &collogspb.ExportLogsPartialSuccess{
+			RejectedLogRecords: n,
+			ErrorMessage:       t.message(),
+		},
+	}
+}
+
+// metricsResponse builds the OTLP metrics response. See traceResponse.
+func (t *rejectTally) metricsResponse() *colmetricspb.ExportMetricsServiceResponse {
+	n := t.total()
+	if n == 0 {
+		return &colmetricspb.ExportMetricsServiceResponse{}
+	}
+	return &colmetricspb.ExportMetricsServiceResponse{
+		PartialSuccess: &colmetricspb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: n,
+			ErrorMessage:       t.message(),
+		},
+	}
+}
+
+// countResourceSpans returns the number of spans across a resource's scopes.
+// Used to size a rejection when an entire resource is filtered out.
+func countResourceSpans(scopes []*tracepb.ScopeSpans) int {
+	n := 0
+	for _, ss := range scopes {
+		n += len(ss.Spans)
+	}
+	return n
+}
+
+// countResourceLogs returns the number of log records across a resource's scopes.
+func countResourceLogs(scopes []*logspb.ScopeLogs) int {
+	n := 0
+	for _, sl := range scopes {
+		n += len(sl.LogRecords)
+	}
+	return n
+}
+
+// countResourceDataPoints returns the number of data points across a
+// resource's scopes, covering every OTLP metric shape — not just the gauge/sum points
+// the TSDB currently ingests — so the reported rejection matches what the
+// client counted as exported.
+func countResourceDataPoints(scopes []*metricspb.ScopeMetrics) int {
+	n := 0
+	for _, sm := range scopes {
+		for _, m := range sm.Metrics {
+			switch d := m.Data.(type) {
+			case *metricspb.Metric_Gauge:
+				n += len(d.Gauge.GetDataPoints())
+			case *metricspb.Metric_Sum:
+				n += len(d.Sum.GetDataPoints())
+			case *metricspb.Metric_Histogram:
+				n += len(d.Histogram.GetDataPoints())
+			case *metricspb.Metric_ExponentialHistogram:
+				n += len(d.ExponentialHistogram.GetDataPoints())
+			case *metricspb.Metric_Summary:
+				n += len(d.Summary.GetDataPoints())
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// TestPartialSuccess_FullAcceptanceLeavesFieldUnset guards the OTLP spec
+// requirement that partial_success stays unset when nothing was rejected.
+func TestPartialSuccess_FullAcceptanceLeavesFieldUnset(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	resp, err := traces.Export(context.Background(), buildTracesRequest("svc", 3))
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if resp.GetPartialSuccess() != nil {
+		t.Fatalf("PartialSuccess = %+v, want nil on full acceptance", resp.GetPartialSuccess())
+	}
+}
+
+// TestPartialSuccess_ServiceFilterReportsRejectedSpans verifies that a
+// resource dropped by INGEST_EXCLUDED_SERVICES is reported back to the client
+// instead of being silently acknowledged.
+func TestPartialSuccess_ServiceFilterReportsRejectedSpans(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{
+		IngestMinSeverity:      "DEBUG",
+		IngestExcludedServices: "noisy",
+	})
+
+	req := buildTracesRequest("noisy", 4)
+	req.ResourceSpans = append(req.ResourceSpans, buildTracesRequest("kept", 2).ResourceSpans...)
+
+	resp, err := traces.Export(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	ps := resp.GetPartialSuccess()
+	if ps == nil {
+		t.Fatal("PartialSuccess = nil, want rejected spans reported")
+	}
+	if ps.GetRejectedSpans() != 4 {
+		t.Errorf("RejectedSpans = %d, want 4", ps.GetRejectedSpans())
+	}
+	if !strings.Contains(ps.GetErrorMessage(), "service_filtered=4") {
+		t.Errorf("ErrorMessage = %q, want service_filtered=4", ps.GetErrorMessage())
+	}
+}
+
+// TestPartialSuccess_SetFiltersAppliesToNextExport verifies filters
+// replaced at runtime (config file reload) take effect on the next request.
+func TestPartialSuccess_SetFiltersAppliesToNextExport(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	resp, err := logs.Export(context.Background(), buildLogsRequest("svc", 2))
+	if err != nil || resp.GetPartialSuccess() != nil {
+		t.Fatalf("Export = %+v, %v; want full acceptance", resp, err)
+	}
+	logs.SetFilters(&config.Config{IngestMinSeverity: "WARN", IngestExcludedServices: "noisy"})
+	if resp, _ = logs.Export(context.Background(), buildLogsRequest("svc", 2)); resp.GetPartialSuccess().GetRejectedLogRecords() != 2 {
+		t.Errorf("after SetFilters PartialSuccess = %+v, want 2 severity_filtered", resp.GetPartialSuccess())
+	}
+	if resp, _ = logs.Export(context.Background(), buildLogsRequest("noisy", 1)); !strings.Contains(resp.GetPartialSuccess().GetErrorMessage(), "service_filtered=1") {
+		t.Errorf("after SetFilters PartialSuccess = %+v, want service_filtered=1", resp.GetPartialSuccess())
+	}
+}
+
+// TestPartialSuccess_SeverityFilterReportsRejectedLogs covers the per-record
+// rejection path: only the records below INGEST_MIN_SEVERITY are counted.
+func TestPartialSuccess_SeverityFilterReportsRejectedLogs(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "WARN"})
+
+	req := buildLogsRequest("svc", 3)
+	req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].SeverityText = "ERROR"
+	req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].SeverityNumber = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+
+	resp, err := logs.Export(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if got := resp.GetPartialSuccess().GetRejectedLogRecords(); got != 2 {
+		t.Errorf("RejectedLogRecords = %d, want 2", got)
+	}
+}
+
+// TestPartialSuccess_SoftBackpressureReported verifies that a healthy batch
+// shed by the pipeline at the soft threshold surfaces as rejected records
+// even though Submit returned nil.
+func TestPartialSuccess_SoftBackpressureReported(t *testing.T) {
+	repo := newTestRepo(t)
+	logs := NewLogsServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	// Capacity 2, threshold 0.5, no workers: one priority pre-fill puts the
+	// queue at 50% so the next healthy batch is soft-dropped.
+	pl := NewPipeline(repo, nil, PipelineConfig{Capacity: 2, SoftThreshold: 0.5})
+	t.Cleanup(pl.Stop)
+	if err := pl.Submit(errorBatch()); err != nil {
+		t.Fatalf("pre-fill Submit: %v", err)
+	}
+	logs.SetPipeline(pl)
+
+	resp, err := logs.Export(context.Background(), buildLogsRequest("svc", 5))
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	ps := resp.GetPartialSuccess()
+	if ps.GetRejectedLogRecords() != 5 {
+		t.Errorf("RejectedLogRecords = %d, want 5", ps.GetRejectedLogRecords())
+	}
+	if !strings.Contains(ps.GetErrorMessage(), rejectBackpressure) {
+		t.Errorf("ErrorMessage = %q, want reason %q", ps.GetErrorMessage(), rejectBackpressure)
+	}
+}
+
+func TestRejectTally_MessageIsSorted(t *testing.T) {
+	var r rejectTally
+	r.add("default", "api", rejectSeverityFiltered, 2)
+	r.add("default", "api", rejectServiceFiltered, 3)
+	r.add("default", "worker", rejectServiceFiltered, 2)
+	r.add("default", "worker", rejectServiceFiltered, 0) // no-op
+	if got, want := r.message(), "rejected 7: service_filtered=5, severity_filtered=2"; got != want {
+		t.Errorf("message() = %q, want %q", got, want)
+	}
+
+	m := &telemetry.Metrics{}
+	r.observe(m, "logs")
+	if got, want := fmt.Sprint(m.DroppedRecords("default")), "[{api logs service_filtered 3} {api logs severity_filtered 2} {worker logs service_filtered 2}]"; got != want {
+		t.Errorf("DroppedRecords = %s, want %s", got, want)
+	}
+}
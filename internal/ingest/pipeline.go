@@ -60,6 +60,27 @@ type Batch struct {
 	LogCallback  func(storage.Log)
 
 	enqueuedAt time.Time
+
+	// dropReason is set by Submit when the batch is accepted-but-shed
+	// (soft or per-tenant backpressure). Submit still returns nil in that
+	// case; Export reads DropReason() to report the loss to the client via
+	// OTLP partial_success instead of claiming full acceptance.
+	dropReason string
+}
+
+// DropReason returns the reason Submit shed this batch without enqueuing
+// it ("soft_backpressure" or "tenant_backpressure"), or "" when the batch
+// was enqueued or rejected with ErrQueueFull.
+func (b *Batch) DropReason() string { return b.dropReason }
+
+// Len returns the number of records the batch carries for its signal —
+// spans for trace batches, log records for log batches. Synthesized logs
+// on a trace batch are not counted; the client never sent them.
+func (b *Batch) Len() int {
+	if b.Type == SignalTraces {
+		return len(b.Spans)
+	}
+	return len(b.Logs)
 }
 
 // Priority reports whether the batch is protected from soft-backpressure
@@ -76,8 +97,23 @@ type PipelineConfig struct {
 	Capacity      int     // total queue depth across all signal types
 	Workers       int     // worker goroutines draining the queue
 	SoftThreshold float64 // fullness fraction above which healthy batches are dropped (0.0–1.0)
+
+	// BatchSize is how many records (Batch.Len) a worker coalesces into one
+	// write. 0 or 1 writes every batch in its own transaction. A worker
+	// waits at most FlushInterval after its first batch for more to arrive;
+	// 0 coalesces only what is already queued.
+	BatchSize     int
+	FlushInterval time.Duration
+	// WriteRetries is how many times a failed write is retried, with
+	// backoff from writeRetryBackoff, before its batches are written one
+	// by one and the failures are spilled (see SetSpill).
+	WriteRetries int
 }
 
+// writeRetryBackoff is the first retry delay of a failed write; each later
+// retry doubles it.
+const writeRetryBackoff = 100 * time.Millisecond
+
 // Defensive upper bounds on operator-supplied capacity/workers. Env-var
 // inputs go directly into a make(chan ...) and into goroutine launches;
 // without a sanity cap a typo like INGEST_PIPELINE_QUEUE_SIZE=10_000_000_000
@@ -85,8 +121,9 @@ type PipelineConfig struct {
 // production deployment (50k is the default queue, 8 the default workers)
 // while still keeping the allocation finite.
 const (
-	maxPipelineCapacity = 1_000_000
-	maxPipelineWorkers  = 256
+	maxPipelineCapacity  = 1_000_000
+	maxPipelineWorkers   = 256
+	maxPipelineBatchSize = 100_000
 )
 
 // DefaultPipelineConfig returns production-sized defaults.
@@ -161,6 +198,11 @@ type Pipeline struct {
 	storeMinSeverity int
 	storeFiltered    atomic.Int64
 
+	// spill receives batches whose write still failed after retries,
+	// typically DLQ.Enqueue. Nil drops them.
+	spill        func(*Batch) error
+	spilledTotal atomic.Int64
+
 	stopCh chan struct{}
 	once   sync.Once
 	wg     sync.WaitGroup
@@ -201,6 +243,9 @@ func NewPipeline(writer pipelineWriter, metrics *telemetry.Metrics, cfg Pipeline
 	}
 	cfg.Capacity = capacity
 	cfg.Workers = workers
+	cfg.BatchSize = min(max(cfg.BatchSize, 0), maxPipelineBatchSize)
+	cfg.FlushInterval = max(cfg.FlushInterval, 0)
+	cfg.WriteRetries = max(cfg.WriteRetries, 0)
 	// Zero-value config falls back to defaults — the field is internal
 	// (no env-var surface) and TestPipeline_DefaultsApplied enforces this.
 	// Priority-only mode (always-soft-drop) is not a supported configuration
@@ -250,6 +295,14 @@ func (p *Pipeline) SetStoreMinSeverity(level int) {
 	p.storeMinSeverity = level
 }
 
+// SetSpill registers where batches go when their write keeps failing —
+// main wires the DLQ here so a database outage is replayed later instead of
+// lost. fn returning an error (e.g. the DLQ is full) drops the batch.
+// Startup-only — call before Start().
+func (p *Pipeline) SetSpill(fn func(*Batch) error) {
+	p.spill = fn
+}
+
 // TenantDropped reports the cumulative number of healthy submissions
 // rejected because the submitting tenant was at the per-tenant cap.
 // Distinct from RejectedFull (queue at hard capacity) and
@@ -304,7 +357,8 @@ func (p *Pipeline) Submit(b *Batch) error {
 	fullness := float64(len(p.queue)) / float64(p.cfg.Capacity)
 	if fullness >= p.cfg.SoftThreshold && !b.Priority() {
 		p.droppedHealthy.Add(1)
-		p.observeDrop(b.Type, "soft_backpressure")
+		b.dropReason = rejectBackpressure
+		p.observeDrop(b.Type, rejectBackpressure)
 		return nil
 	}
 
@@ -318,7 +372,8 @@ func (p *Pipeline) Submit(b *Batch) error {
 		if p.tenantInFlight[b.Tenant] >= p.perTenantCap {
 			p.tenantMu.Unlock()
 			p.tenantDropped.Add(1)
-			p.observeDrop(b.Type, "tenant_backpressure")
+			b.dropReason = rejectTenantQuota
+			p.observeDrop(b.Type, rejectTenantQuota)
 			return nil
 		}
 		p.tenantInFlight[b.Tenant]++
@@ -379,6 +434,7 @@ func (p *Pipeline) Stats() PipelineStats {
 		RejectedFull:    p.rejectedFull.Load(),
 		ProcessFailures: p.processFailures.Load(),
 		StoreFiltered:   p.storeFiltered.Load(),
+		Spilled:         p.spilledTotal.Load(),
 		QueueDepth:      len(p.queue),
 		Capacity:        p.cfg.Capacity,
 	}
@@ -392,6 +448,7 @@ type PipelineStats struct {
 	RejectedFull    int64
 	ProcessFailures int64
 	StoreFiltered   int64 // logs dropped by STORE_MIN_SEVERITY at persist time
+	Spilled         int64 // batches handed to the spill target after failed writes
 	QueueDepth      int
 	Capacity        int
 }
@@ -404,14 +461,14 @@ func (p *Pipeline) worker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case b := <-p.queue:
-			p.process(b)
+			p.processGroup(p.collect(ctx, b, true))
 		case <-p.stopCh:
 			// Drain remaining buffered batches synchronously so a
 			// graceful shutdown doesn't lose in-flight ingest.
 			for {
 				select {
 				case b := <-p.queue:
-					p.process(b)
+					p.processGroup(p.collect(ctx, b, false))
 				default:
 					return
 				}
@@ -420,74 +477,173 @@ func (p *Pipeline) worker(ctx context.Context) {
 	}
 }
 
-// process persists a single batch in a single DB transaction. Trace→Span→Log
-// ordering inside the transaction mirrors the FK invariant of the synchronous
-// Export() path; atomicity prevents the orphan-row class of bugs where a
-// panic between two BatchCreate* calls left a parent row with no children
-// (or vice versa). Any failure rolls the entire batch back; the worker logs,
-// increments processFailures, and drops the batch (DLQ is the redundancy
-// story for sustained failures).
-//
-// Behavior change vs. the pre-tx implementation: trace insert errors are no
-// longer "tolerated" with downstream spans/logs continuing — the whole batch
-// is now atomic. This is intentional. Traces are idempotent (ON CONFLICT
-// DO NOTHING), so a DLQ retry of the same envelope re-attempts cleanly.
-func (p *Pipeline) process(b *Batch) {
-	if b == nil {
-		return
+// collect coalesces first with further queued batches until BatchSize
+// records are gathered, FlushInterval has passed since first arrived, or the
+// queue is empty (when FlushInterval is 0 or wait is false, as when draining
+// on Stop). Without a BatchSize every batch is written alone.
+func (p *Pipeline) collect(ctx context.Context, first *Batch, wait bool) []*Batch {
+	group := []*Batch{first}
+	if p.cfg.BatchSize <= 1 {
+		return group
+	}
+	records := first.Len()
+	var flush <-chan time.Time
+	if wait && p.cfg.FlushInterval > 0 {
+		timer := time.NewTimer(p.cfg.FlushInterval)
+		defer timer.Stop()
+		flush = timer.C
 	}
-	// Release the per-tenant slot reserved at Submit time. Registered as
-	// a defer so it runs even if the batch panics. Priority batches don't
+	for records < p.cfg.BatchSize {
+		if flush == nil {
+			select {
+			case b := <-p.queue:
+				group = append(group, b)
+				records += b.Len()
+				continue
+			default:
+				return group
+			}
+		}
+		select {
+		case b := <-p.queue:
+			group = append(group, b)
+			records += b.Len()
+		case <-flush:
+			return group
+		case <-p.stopCh:
+			return group
+		case <-ctx.Done():
+			return group
+		}
+	}
+	return group
+}
+
+// processGroup persists the batches of group in a single DB transaction.
+// Trace→Span→Log ordering inside the transaction mirrors the FK invariant of
+// the synchronous Export() path; atomicity prevents the orphan-row class of
+// bugs where a panic between two BatchCreate* calls left a parent row with no
+// children (or vice versa).
+//
+// A failed write is retried WriteRetries times with exponential backoff,
+// which holds the worker and so backs the queue up into Submit's
+// backpressure. If it still fails, each batch is written on its own so one
+// poison batch cannot sink its neighbours, and batches that fail alone go
+// to the spill target (the DLQ) for replay. Traces and spans are idempotent
+// (ON CONFLICT DO NOTHING), so a replay of the same envelope re-attempts
+// cleanly.
+func (p *Pipeline) processGroup(group []*Batch) {
+	// Release the per-tenant slots reserved at Submit time. Registered as
+	// a defer so it runs even if the write panics. Priority batches don't
 	// reserve at submit, so they don't release here either — the conditions
 	// must mirror exactly to keep the in-flight count balanced.
-	if !b.Priority() {
-		defer p.releaseTenantSlot(b.Tenant)
-	}
 	defer func() {
-		if r := recover(); r != nil {
-			slog.Error("ingest pipeline process panic",
-				"panic", r,
-				"stack", string(debug.Stack()),
-			)
-			p.processFailures.Add(1)
-			if p.metrics != nil && p.metrics.PanicsRecoveredTotal != nil {
-				p.metrics.PanicsRecoveredTotal.WithLabelValues("ingest_pipeline").Inc()
+		for _, b := range group {
+			if !b.Priority() {
+				p.releaseTenantSlot(b.Tenant)
 			}
 		}
 	}()
-	p.processedTotal.Add(1)
-
-	if len(b.Traces) == 0 && len(b.Spans) == 0 && len(b.Logs) == 0 {
-		return
-	}
+	defer p.recoverPanic()
+	p.processedTotal.Add(int64(len(group)))
 
 	// Apply the second-tier store-severity gate. Logs below the threshold
 	// are dropped from the persist set but still flow through the callback
 	// so in-memory enrichers (vectordb, GraphRAG Drain) keep seeing them.
-	logsToPersist := b.Logs
-	if p.storeMinSeverity > 0 && len(b.Logs) > 0 {
-		kept := make([]storage.Log, 0, len(b.Logs))
-		for _, l := range b.Logs {
-			if shouldIngestSeverity(l.Severity, p.storeMinSeverity) {
-				kept = append(kept, l)
-			} else {
-				p.storeFiltered.Add(1)
+	persist := make([][]storage.Log, len(group))
+	var traces []storage.Trace
+	var spans []storage.Span
+	var logs []storage.Log
+	for i, b := range group {
+		persist[i] = p.logsToPersist(b)
+		traces = append(traces, b.Traces...)
+		spans = append(spans, b.Spans...)
+		logs = append(logs, persist[i]...)
+	}
+	if len(traces) == 0 && len(spans) == 0 && len(logs) == 0 {
+		return
+	}
+
+	if err := p.writeWithRetry(traces, spans, logs); err != nil {
+		slog.Error("ingest pipeline: BatchCreateAll failed", "batches", len(group), "error", err)
+		for i, b := range group {
+			if len(group) > 1 {
+				err = p.writer.BatchCreateAll(b.Traces, b.Spans, persist[i])
 			}
+			if err != nil {
+				p.processFailures.Add(1)
+				p.spillBatch(b, persist[i])
+				continue
+			}
+			p.runCallbacks(b)
 		}
-		logsToPersist = kept
+		return
 	}
+	for _, b := range group {
+		p.runCallbacks(b)
+	}
+}
 
-	if err := p.writer.BatchCreateAll(b.Traces, b.Spans, logsToPersist); err != nil {
-		slog.Error("ingest pipeline: BatchCreateAll failed", "error", err)
-		p.processFailures.Add(1)
-		return
+// logsToPersist returns b's logs that pass the store-severity gate.
+func (p *Pipeline) logsToPersist(b *Batch) []storage.Log {
+	if p.storeMinSeverity <= 0 || len(b.Logs) == 0 {
+		return b.Logs
+	}
+	kept := make([]storage.Log, 0, len(b.Logs))
+	for _, l := range b.Logs {
+		if shouldIngestSeverity(l.Severity, p.storeMinSeverity) {
+			kept = append(kept, l)
+		} else {
+			p.storeFiltered.Add(1)
+		}
 	}
+	return kept
+}
 
-	// Callbacks fire only after the transaction commits successfully — a
-	// rolled-back batch must not feed downstream consumers (GraphRAG etc.)
-	// data that no longer exists in the DB. The LogCallback intentionally
-	// iterates over the FULL b.Logs slice, not logsToPersist — even logs
-	// dropped by the store-severity gate must reach in-memory enrichers.
+// writeWithRetry runs BatchCreateAll, retrying WriteRetries times with
+// exponential backoff. Stop cuts the backoff short.
+func (p *Pipeline) writeWithRetry(traces []storage.Trace, spans []storage.Span, logs []storage.Log) error {
+	err := p.writer.BatchCreateAll(traces, spans, logs)
+	backoff := writeRetryBackoff
+	for attempt := 0; err != nil && attempt < p.cfg.WriteRetries; attempt++ {
+		select {
+		case <-time.After(backoff):
+		case <-p.stopCh:
+		}
+		backoff *= 2
+		err = p.writer.BatchCreateAll(traces, spans, logs)
+	}
+	return err
+}
+
+// spillBatch hands a batch that could not be written to the spill target,
+// with its logs already filtered by the store-severity gate.
+func (p *Pipeline) spillBatch(b *Batch, logs []storage.Log) {
+	if p.spill != nil {
+		spilled := *b
+		spilled.Logs = logs
+		err := p.spill(&spilled)
+		if err == nil {
+			p.spilledTotal.Add(1)
+			if p.metrics != nil && p.metrics.IngestPipelineSpilledTotal != nil {
+				p.metrics.IngestPipelineSpilledTotal.WithLabelValues(signalLabel(b.Type)).Inc()
+			}
+			return
+		}
+		slog.Error("ingest pipeline: spill failed, batch dropped", "signal", signalLabel(b.Type), "error", err)
+	}
+	p.observeDrop(b.Type, "write_failed")
+}
+
+// runCallbacks feeds a persisted batch to its callbacks. They fire only
+// after the transaction commits — a rolled-back batch must not feed
+// downstream consumers (GraphRAG etc.) data that no longer exists in the
+// DB. The LogCallback intentionally iterates over the FULL b.Logs slice,
+// not the persisted subset — even logs dropped by the store-severity gate
+// must reach in-memory enrichers. A panicking callback only loses the rest
+// of its own batch.
+func (p *Pipeline) runCallbacks(b *Batch) {
+	defer p.recoverPanic()
 	if b.SpanCallback != nil {
 		for _, s := range b.Spans {
 			b.SpanCallback(s)
@@ -500,6 +656,21 @@ func (p *Pipeline) process(b *Batch) {
 	}
 }
 
+// recoverPanic is deferred around writes and callbacks so a panic counts as
+// a process failure instead of killing the worker.
+func (p *Pipeline) recoverPanic() {
+	if r := recover(); r != nil {
+		slog.Error("ingest pipeline process panic",
+			"panic", r,
+			"stack", string(debug.Stack()),
+		)
+		p.processFailures.Add(1)
+		if p.metrics != nil && p.metrics.PanicsRecoveredTotal != nil {
+			p.metrics.PanicsRecoveredTotal.WithLabelValues("ingest_pipeline").Inc()
+		}
+	}
+}
+
 func (p *Pipeline) observeQueueDepth(t SignalType) {
 	if p.metrics == nil || p.metrics.IngestPipelineQueueDepth == nil {
 		return
@@ -0,0 +1,116 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// flakyWriter fails the first failures BatchCreateAll calls, and any call
+// carrying the poison trace ID, recording the span count of each success.
+type flakyWriter struct {
+	fakeWriter
+	failures int
+	poison   string
+
+	mu      sync.Mutex
+	writes  []int
+	attempt int
+}
+
+func (f *flakyWriter) BatchCreateAll(t []storage.Trace, s []storage.Span, _ []storage.Log) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempt++
+	if f.attempt <= f.failures {
+		return errors.New("db down")
+	}
+	for _, tr := range t {
+		if tr.TraceID == f.poison {
+			return errors.New("poison")
+		}
+	}
+	f.writes = append(f.writes, len(s))
+	return nil
+}
+
+func (f *flakyWriter) snapshot() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.writes...)
+}
+
+func batchFor(traceID string) *Batch {
+	b := healthyBatch()
+	b.Traces[0].TraceID = traceID
+	b.Spans[0].TraceID = traceID
+	return b
+}
+
+func TestPipeline_CoalescesQueuedBatches(t *testing.T) {
+	w := &flakyWriter{}
+	p := NewPipeline(w, nil, PipelineConfig{Capacity: 10, Workers: 1, BatchSize: 3})
+	var called int
+	for i := range 4 {
+		b := batchFor(string(rune('a' + i)))
+		b.SpanCallback = func(storage.Span) { called++ }
+		if err := p.Submit(b); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+	p.Start(context.Background())
+	p.Stop()
+
+	// Three batches reach BatchSize in one write; the fourth goes alone.
+	if got := w.snapshot(); len(got) != 2 || got[0] != 3 || got[1] != 1 {
+		t.Errorf("writes = %v, want [3 1]", got)
+	}
+	if called != 4 || p.Stats().Processed != 4 {
+		t.Errorf("callbacks = %d, processed = %d; want 4, 4", called, p.Stats().Processed)
+	}
+}
+
+func TestPipeline_FlushIntervalWaitsForMore(t *testing.T) {
+	w := &flakyWriter{}
+	p := NewPipeline(w, nil, PipelineConfig{Capacity: 10, Workers: 1, BatchSize: 100, FlushInterval: 200 * time.Millisecond})
+	p.Start(context.Background())
+	t.Cleanup(p.Stop)
+
+	_ = p.Submit(batchFor("a"))
+	time.Sleep(20 * time.Millisecond)
+	_ = p.Submit(batchFor("b"))
+	if !waitFor(t, 5*time.Second, func() bool { return len(w.snapshot()) > 0 }) {
+		t.Fatal("flush interval never flushed")
+	}
+	if got := w.snapshot(); len(got) != 1 || got[0] != 2 {
+		t.Errorf("writes = %v, want one write of 2 spans", got)
+	}
+}
+
+func TestPipeline_RetriesThenSpillsPoisonBatch(t *testing.T) {
+	w := &flakyWriter{failures: 1, poison: "bad"}
+	p := NewPipeline(w, nil, PipelineConfig{Capacity: 10, Workers: 1, BatchSize: 10, WriteRetries: 1})
+	var spilled []string
+	p.SetSpill(func(b *Batch) error {
+		spilled = append(spilled, b.Traces[0].TraceID)
+		return nil
+	})
+	_ = p.Submit(batchFor("good"))
+	_ = p.Submit(batchFor("bad"))
+	p.Start(context.Background())
+	p.Stop()
+
+	// The first attempt fails (db down), the retry hits the poison batch,
+	// then the good batch is written alone and the poison one spills.
+	if got := w.snapshot(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("writes = %v, want the good batch alone", got)
+	}
+	st := p.Stats()
+	if len(spilled) != 1 || spilled[0] != "bad" || st.Spilled != 1 || st.ProcessFailures != 1 {
+		t.Errorf("spilled = %v, stats = %+v", spilled, st)
+	}
+}
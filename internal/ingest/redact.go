@@ -0,0 +1,350 @@
+package ingest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// RedactionRule is one entry of the redaction rules file. A rule applies
+// to telemetry of its service and either scrubs every value of an
+// attribute key (Attribute) or scrubs matches of a pattern inside string
+// attribute values, log bodies and span status messages (Builtin or
+// Pattern). Exactly one of the three must be set.
+//
+//	[
+//	  {"attribute": "payment.card.number"},
+//	  {"attribute": "payment.*", "service": "payment-service"},
+//	  {"attribute": "user.email", "action": "hash"},
+//	  {"builtin": "credit_card"},
+//	  {"builtin": "email", "action": "hash"},
+//	  {"name": "iban", "pattern": "\\b[A-Z]{2}\\d{2}[A-Z0-9]{11,30}\\b"}
+//	]
+//
+// Attribute is an exact key, or a prefix when it ends in "*". Builtin is
+// credit_card (13–19 digits, optionally space- or dash-separated, passing
+// the Luhn check) or email. Action "redact" (the default) replaces the
+// value or match with "[REDACTED]"; "hash" replaces it with "sha256:"
+// and the first 16 hex digits of its HMAC-SHA256 under the hash key, so
+// equal values stay correlatable without being recoverable.
+type RedactionRule struct {
+	Name      string `json:"name,omitempty"`      // metric label; defaults to the attribute, builtin or rule index
+	Service   string `json:"service,omitempty"`   // exact service.name; "" or "*" = every service
+	Attribute string `json:"attribute,omitempty"` // attribute key, or key prefix ending in "*"
+	Builtin   string `json:"builtin,omitempty"`   // credit_card | email
+	Pattern   string `json:"pattern,omitempty"`   // Go regular expression
+	Action    string `json:"action,omitempty"`    // redact (default) | hash
+}
+
+// redactedValue replaces redacted attribute values and pattern matches.
+const redactedValue = "[REDACTED]"
+
+var (
+	creditCardRE = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	emailRE      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+type redactionRule struct {
+	label   string
+	service string // "" = every service
+	key     string
+	prefix  bool           // key is a prefix
+	pattern *regexp.Regexp // nil for attribute rules
+	luhn    bool           // pattern matches must pass the Luhn check
+	hash    bool
+}
+
+// Redactor scrubs sensitive attribute values and log body patterns from
+// trace and log requests as they arrive, before fixture capture, the span
+// rewrite rules and anything else sees them, so raw values never reach
+// storage, the DLQ or the live streams. Rules run in file order; a later
+// rule sees the output of earlier ones. The service.name resource
+// attribute is never scrubbed. Nil Redactor = telemetry stored as sent.
+//
+// Immutable after construction; safe for concurrent use.
+type Redactor struct {
+	rules   []redactionRule
+	hashKey []byte
+	metrics *telemetry.Metrics
+}
+
+// NewRedactor compiles rules. Returns an error naming the first invalid
+// rule (unknown builtin or action, bad pattern, or not exactly one of
+// attribute, builtin and pattern), or when a rule hashes and hashKey is
+// empty. Returns nil for no rules. metrics may be nil.
+func NewRedactor(rules []RedactionRule, hashKey string, metrics *telemetry.Metrics) (*Redactor, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	rd := &Redactor{rules: make([]redactionRule, 0, len(rules)), hashKey: []byte(hashKey), metrics: metrics}
+	for i, r := range rules {
+		c := redactionRule{label: r.Name, service: r.Service}
+		if c.service == "*" {
+			c.service = ""
+		}
+		set := 0
+		for _, v := range []string{r.Attribute, r.Builtin, r.Pattern} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("redaction rule %d: set exactly one of attribute, builtin and pattern", i)
+		}
+		switch {
+		case r.Attribute != "":
+			c.key, c.prefix = strings.CutSuffix(r.Attribute, "*")
+			if c.label == "" {
+				c.label = r.Attribute
+			}
+		case r.Builtin != "":
+			switch r.Builtin {
+			case "credit_card":
+				c.pattern, c.luhn = creditCardRE, true
+			case "email":
+				c.pattern = emailRE
+			default:
+				return nil, fmt.Errorf("redaction rule %d: unknown builtin %q (want credit_card or email)", i, r.Builtin)
+			}
+			if c.label == "" {
+				c.label = r.Builtin
+			}
+		default:
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %d: %w", i, err)
+			}
+			c.pattern = re
+		}
+		if c.label == "" {
+			c.label = strconv.Itoa(i)
+		}
+		switch strings.ToLower(r.Action) {
+		case "", "redact":
+		case "hash":
+			if hashKey == "" {
+				return nil, fmt.Errorf("redaction rule %d: action hash needs INGEST_REDACTION_HASH_KEY", i)
+			}
+			c.hash = true
+		default:
+			return nil, fmt.Errorf("redaction rule %d: unknown action %q (want redact or hash)", i, r.Action)
+		}
+		rd.rules = append(rd.rules, c)
+	}
+	return rd, nil
+}
+
+// LoadRedactor reads a JSON array of RedactionRule from path. An empty
+// path disables redaction (nil, nil).
+func LoadRedactor(path, hashKey string, metrics *telemetry.Metrics) (*Redactor, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("redaction rules file %q: %w", path, err)
+	}
+	var rules []RedactionRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("redaction rules file %q: %w", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("redaction rules file %q: no rules found", path)
+	}
+	rd, err := NewRedactor(rules, hashKey, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("redaction rules file %q: %w", path, err)
+	}
+	return rd, nil
+}
+
+// Rules returns the number of compiled rules.
+func (rd *Redactor) Rules() int { return len(rd.rules) }
+
+// redactTraces scrubs req in place: resource, span, event and link
+// attributes, and span status messages. count is false for dry runs.
+func (rd *Redactor) redactTraces(req *coltracepb.ExportTraceServiceRequest, count bool) {
+	for _, rs := range req.ResourceSpans {
+		service := getServiceName(rs.GetResource().GetAttributes())
+		sc := rd.scope(service, "traces", count)
+		if rs.Resource != nil {
+			sc.attrs(rs.Resource.Attributes, true)
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				sc.attrs(span.Attributes, false)
+				for _, ev := range span.Events {
+					sc.attrs(ev.Attributes, false)
+				}
+				for _, l := range span.Links {
+					sc.attrs(l.Attributes, false)
+				}
+				if span.Status != nil && span.Status.Message != "" {
+					span.Status.Message = sc.text(span.Status.Message)
+				}
+			}
+		}
+	}
+}
+
+// redactLogs scrubs req in place: resource and record attributes, and
+// record bodies. count is false for dry runs.
+func (rd *Redactor) redactLogs(req *collogspb.ExportLogsServiceRequest, count bool) {
+	for _, rl := range req.ResourceLogs {
+		service := getServiceName(rl.GetResource().GetAttributes())
+		sc := rd.scope(service, "logs", count)
+		if rl.Resource != nil {
+			sc.attrs(rl.Resource.Attributes, true)
+		}
+		for _, sl := range rl.ScopeLogs {
+			for _, l := range sl.LogRecords {
+				sc.attrs(l.Attributes, false)
+				if l.Body != nil {
+					sc.value(l.Body)
+				}
+			}
+		}
+	}
+}
+
+// redactScope is the rule set for one resource's service.
+type redactScope struct {
+	rd     *Redactor
+	rules  []*redactionRule
+	signal string
+	count  bool
+}
+
+func (rd *Redactor) scope(service, signal string, count bool) redactScope {
+	sc := redactScope{rd: rd, signal: signal, count: count}
+	for i := range rd.rules {
+		if r := &rd.rules[i]; r.service == "" || r.service == service {
+			sc.rules = append(sc.rules, r)
+		}
+	}
+	return sc
+}
+
+func (sc redactScope) attrs(attrs []*commonpb.KeyValue, resource bool) {
+	if len(sc.rules) == 0 {
+		return
+	}
+	for _, kv := range attrs {
+		if resource && kv.Key == "service.name" {
+			continue
+		}
+		scrubbed := false
+		for _, r := range sc.rules {
+			if r.pattern != nil || !r.matchKey(kv.Key) {
+				continue
+			}
+			kv.Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: sc.replace(r, kv.Value)}}
+			sc.record(r)
+			scrubbed = true
+			break
+		}
+		if !scrubbed && kv.Value != nil {
+			sc.value(kv.Value)
+		}
+	}
+}
+
+// value runs the pattern rules over every string inside v.
+func (sc redactScope) value(v *commonpb.AnyValue) {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		x.StringValue = sc.text(x.StringValue)
+	case *commonpb.AnyValue_ArrayValue:
+		for _, e := range x.ArrayValue.GetValues() {
+			sc.value(e)
+		}
+	case *commonpb.AnyValue_KvlistValue:
+		sc.attrs(x.KvlistValue.GetValues(), false)
+	}
+}
+
+// text runs the pattern rules over s.
+func (sc redactScope) text(s string) string {
+	for _, r := range sc.rules {
+		if r.pattern == nil {
+			continue
+		}
+		s = r.pattern.ReplaceAllStringFunc(s, func(m string) string {
+			if r.luhn && !luhnValid(m) {
+				return m
+			}
+			sc.record(r)
+			if r.hash {
+				return sc.rd.hashString(m)
+			}
+			return redactedValue
+		})
+	}
+	return s
+}
+
+// replace returns the scrubbed form of an attribute value. Hashing uses
+// the scalar's string form; non-scalar values are redacted.
+func (sc redactScope) replace(r *redactionRule, v *commonpb.AnyValue) string {
+	if !r.hash {
+		return redactedValue
+	}
+	s, ok := scalarString(v)
+	if !ok {
+		return redactedValue
+	}
+	return sc.rd.hashString(s)
+}
+
+func (sc redactScope) record(r *redactionRule) {
+	if sc.count {
+		sc.rd.metrics.RecordRedaction(sc.signal, r.label)
+	}
+}
+
+func (r *redactionRule) matchKey(key string) bool {
+	if r.prefix {
+		return strings.HasPrefix(key, r.key)
+	}
+	return key == r.key
+}
+
+func (rd *Redactor) hashString(s string) string {
+	mac := hmac.New(sha256.New, rd.hashKey)
+	mac.Write([]byte(s))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum, which
+// keeps the credit_card builtin off order numbers and other long digit
+// runs.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,145 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestRedactor_Traces(t *testing.T) {
+	rd, err := NewRedactor([]RedactionRule{
+		{Attribute: "payment.card.number"},
+		{Attribute: "payment.*", Service: "payment-service"},
+		{Attribute: "user.email", Action: "hash"},
+		{Builtin: "credit_card"},
+		{Name: "ssn", Pattern: `\b\d{3}-\d{2}-\d{4}\b`},
+	}, "k1", nil)
+	if err != nil {
+		t.Fatalf("NewRedactor: %v", err)
+	}
+
+	req := buildTracesRequest("payment-service", 1)
+	span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	span.Attributes = []*commonpb.KeyValue{
+		intAttr("payment.card.number", 4111111111111111),
+		strAttr("payment.cvv", "123"),
+		strAttr("user.email", "ana@example.com"),
+		strAttr("note", "card 4111-1111-1111-1111 declined, order 1234567890123"),
+		strAttr("customer.ssn", "ssn 123-45-6789"),
+	}
+	span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "charge 4242 4242 4242 4242 failed"}
+	rd.redactTraces(req, false)
+
+	got := map[string]string{}
+	for _, kv := range span.Attributes {
+		got[kv.Key], _ = scalarString(kv.Value)
+	}
+	want := map[string]string{
+		"payment.card.number": redactedValue,
+		"payment.cvv":         redactedValue,
+		"user.email":          rd.hashString("ana@example.com"),
+		"note":                "card " + redactedValue + " declined, order 1234567890123", // order number fails Luhn
+		"customer.ssn":        "ssn " + redactedValue,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.HasPrefix(got["user.email"], "sha256:") || len(got["user.email"]) != len("sha256:")+16 {
+		t.Errorf("hashed email = %q", got["user.email"])
+	}
+	if span.Status.Message != "charge "+redactedValue+" failed" {
+		t.Errorf("status message = %q", span.Status.Message)
+	}
+	if svc := getServiceName(req.ResourceSpans[0].Resource.Attributes); svc != "payment-service" {
+		t.Errorf("service.name = %q, must never be scrubbed", svc)
+	}
+
+	// The payment.* rule is scoped to payment-service.
+	other := buildTracesRequest("checkout", 1)
+	other.ResourceSpans[0].ScopeSpans[0].Spans[0].Attributes = []*commonpb.KeyValue{strAttr("payment.cvv", "123")}
+	rd.redactTraces(other, false)
+	if v, _ := spanAttribute(other.ResourceSpans[0].ScopeSpans[0].Spans[0].Attributes, "payment.cvv"); v != "123" {
+		t.Errorf("checkout payment.cvv = %q, want untouched", v)
+	}
+}
+
+func TestNewRedactor_Invalid(t *testing.T) {
+	cases := map[string]RedactionRule{
+		"no selector":      {Service: "svc"},
+		"two selectors":    {Attribute: "a", Builtin: "email"},
+		"unknown builtin":  {Builtin: "phone"},
+		"bad pattern":      {Pattern: "("},
+		"unknown action":   {Attribute: "a", Action: "mask"},
+		"hash without key": {Attribute: "a", Action: "hash"},
+	}
+	for name, r := range cases {
+		if _, err := NewRedactor([]RedactionRule{r}, "", nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadRedactor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "redact.json")
+	if err := os.WriteFile(path, []byte(`[{"builtin":"email"},{"attribute":"payment.*"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := LoadRedactor(path, "", nil)
+	if err != nil || rd.Rules() != 2 {
+		t.Fatalf("LoadRedactor = %v, %v; want 2 rules", rd, err)
+	}
+	if rd, err := LoadRedactor("", "", nil); rd != nil || err != nil {
+		t.Errorf("empty path = %v, %v; want disabled", rd, err)
+	}
+}
+
+// TestRedactor_ExportStoresScrubbedValues verifies neither stored spans
+// nor stored logs carry the raw values.
+func TestRedactor_ExportStoresScrubbedValues(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+	rd, err := NewRedactor([]RedactionRule{{Attribute: "payment.card.number"}, {Builtin: "email"}}, "", nil)
+	if err != nil {
+		t.Fatalf("NewRedactor: %v", err)
+	}
+	traces := NewTraceServer(repo, nil, cfg)
+	traces.SetRedactor(rd)
+	logs := NewLogsServer(repo, nil, cfg)
+	logs.SetRedactor(rd)
+
+	var mu sync.Mutex
+	var spans []storage.Span
+	var logRows []storage.Log
+	traces.SetSpanCallback(func(s storage.Span) { mu.Lock(); spans = append(spans, s); mu.Unlock() })
+	logs.SetLogCallback(func(l storage.Log) { mu.Lock(); logRows = append(logRows, l); mu.Unlock() })
+
+	treq := buildTracesRequest("payment-service", 1)
+	treq.ResourceSpans[0].ScopeSpans[0].Spans[0].Attributes = []*commonpb.KeyValue{strAttr("payment.card.number", "4111111111111111")}
+	if _, err := traces.Export(context.Background(), treq); err != nil {
+		t.Fatalf("trace Export: %v", err)
+	}
+	lreq := buildLogsRequest("payment-service", 1)
+	lreq.ResourceLogs[0].ScopeLogs[0].LogRecords[0].Body = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "receipt sent to ana@example.com"}}
+	if _, err := logs.Export(context.Background(), lreq); err != nil {
+		t.Fatalf("logs Export: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spans) != 1 || strings.Contains(string(spans[0].AttributesJSON), "4111") {
+		t.Errorf("stored spans = %+v, want the card number scrubbed", spans)
+	}
+	if len(logRows) != 1 || logRows[0].Body != "receipt sent to "+redactedValue {
+		t.Errorf("stored logs = %+v, want the email scrubbed", logRows)
+	}
+}
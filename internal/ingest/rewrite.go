@@ -0,0 +1,190 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// RewriteRule is one entry of the span rewrite rules file. A rule applies
+// to a span when its service matches, its status matches (if Status is
+// set) and every Match attribute holds. It then overrides the span status
+// and/or sets attributes.
+//
+//	[
+//	  {"service": "search-service", "status": "error",
+//	   "match": {"http.response.status_code": "404"}, "set_status": "unset"},
+//	  {"service": "payments", "match": {"vendor.code": "E1042"},
+//	   "set_attributes": {"error.type": "card_declined"}}
+//	]
+type RewriteRule struct {
+	Service       string            `json:"service"`                  // exact service.name; "" or "*" = every service
+	Status        string            `json:"status,omitempty"`         // ok | error | unset; "" = any
+	Match         map[string]string `json:"match,omitempty"`          // attribute key → value, all must hold
+	SetStatus     string            `json:"set_status,omitempty"`     // ok | error | unset
+	SetAttributes map[string]string `json:"set_attributes,omitempty"` // upserted as string attributes
+}
+
+// rewriteStatusCodes maps the rule file's status names to OTLP codes.
+var rewriteStatusCodes = map[string]tracepb.Status_StatusCode{
+	"unset": tracepb.Status_STATUS_CODE_UNSET,
+	"ok":    tracepb.Status_STATUS_CODE_OK,
+	"error": tracepb.Status_STATUS_CODE_ERROR,
+}
+
+type rewriteRule struct {
+	service       string // "" = every service
+	match         map[string]string
+	status        tracepb.Status_StatusCode
+	hasStatus     bool
+	setStatus     tracepb.Status_StatusCode
+	hasSetStatus  bool
+	setAttributes map[string]string
+}
+
+// SpanRewriter applies per-service status and attribute rewrite rules to
+// spans at ingest, so stored status — and every error rate computed from
+// it — reflects what the operator considers a failure rather than SDK
+// defaults (e.g. a search service's 404s). Rules run in file order, after
+// validation and the health-check filter and before sampling; a later rule
+// sees the edits of earlier ones. Nil SpanRewriter = spans stored as sent.
+//
+// Immutable after construction; safe for concurrent use.
+type SpanRewriter struct {
+	rules []rewriteRule
+}
+
+// NewSpanRewriter compiles rules. Returns an error naming the first
+// invalid rule (unknown status, or no action). Returns nil for no rules.
+func NewSpanRewriter(rules []RewriteRule) (*SpanRewriter, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	rw := &SpanRewriter{rules: make([]rewriteRule, 0, len(rules))}
+	for i, r := range rules {
+		c := rewriteRule{service: r.Service, match: r.Match, setAttributes: r.SetAttributes}
+		if c.service == "*" {
+			c.service = ""
+		}
+		if r.Status != "" {
+			code, ok := rewriteStatusCodes[strings.ToLower(r.Status)]
+			if !ok {
+				return nil, fmt.Errorf("rewrite rule %d: unknown status %q (want ok, error or unset)", i, r.Status)
+			}
+			c.status, c.hasStatus = code, true
+		}
+		if r.SetStatus != "" {
+			code, ok := rewriteStatusCodes[strings.ToLower(r.SetStatus)]
+			if !ok {
+				return nil, fmt.Errorf("rewrite rule %d: unknown set_status %q (want ok, error or unset)", i, r.SetStatus)
+			}
+			c.setStatus, c.hasSetStatus = code, true
+		}
+		if !c.hasSetStatus && len(c.setAttributes) == 0 {
+			return nil, fmt.Errorf("rewrite rule %d: needs set_status or set_attributes", i)
+		}
+		rw.rules = append(rw.rules, c)
+	}
+	return rw, nil
+}
+
+// LoadSpanRewriter reads a JSON array of RewriteRule from path. An empty
+// path disables rewriting (nil, nil).
+func LoadSpanRewriter(path string) (*SpanRewriter, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("rewrite rules file %q: %w", path, err)
+	}
+	var rules []RewriteRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("rewrite rules file %q: %w", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("rewrite rules file %q: no rules found", path)
+	}
+	rw, err := NewSpanRewriter(rules)
+	if err != nil {
+		return nil, fmt.Errorf("rewrite rules file %q: %w", path, err)
+	}
+	return rw, nil
+}
+
+// Rules returns the number of compiled rules.
+func (rw *SpanRewriter) Rules() int { return len(rw.rules) }
+
+// rewriteSpan applies every rule for service to span in place.
+func (rw *SpanRewriter) rewriteSpan(service string, span *tracepb.Span) {
+	for i := range rw.rules {
+		rw.rules[i].apply(service, span)
+	}
+}
+
+func (r *rewriteRule) apply(service string, span *tracepb.Span) {
+	if r.service != "" && r.service != service {
+		return
+	}
+	if r.hasStatus && span.GetStatus().GetCode() != r.status {
+		return
+	}
+	for key, want := range r.match {
+		got, ok := spanAttribute(span.Attributes, key)
+		if !ok || got != want {
+			return
+		}
+	}
+	if r.hasSetStatus {
+		if span.Status == nil {
+			span.Status = &tracepb.Status{}
+		}
+		span.Status.Code = r.setStatus
+	}
+	for key, value := range r.setAttributes {
+		setSpanAttribute(span, key, value)
+	}
+}
+
+// spanAttribute returns the string form of a scalar attribute, so rules
+// can match int status codes ("404") as well as strings.
+func spanAttribute(attrs []*commonpb.KeyValue, key string) (string, bool) {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return scalarString(kv.Value)
+		}
+	}
+	return "", false
+}
+
+// scalarString returns the string form of a scalar value; false for
+// arrays, maps, bytes and unset values.
+func scalarString(v *commonpb.AnyValue) (string, bool) {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue, true
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10), true
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue), true
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(x.DoubleValue, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+func setSpanAttribute(span *tracepb.Span, key, value string) {
+	v := &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			kv.Value = v
+			return
+		}
+	}
+	span.Attributes = append(span.Attributes, &commonpb.KeyValue{Key: key, Value: v})
+}
@@ -0,0 +1,137 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func intAttr(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}}
+}
+
+func TestSpanRewriter_Rules(t *testing.T) {
+	rw, err := NewSpanRewriter([]RewriteRule{
+		{Service: "search", Status: "error", Match: map[string]string{"http.response.status_code": "404"}, SetStatus: "unset"},
+		{Service: "payments", Match: map[string]string{"vendor.code": "E1042"}, SetAttributes: map[string]string{"error.type": "card_declined"}},
+	})
+	if err != nil {
+		t.Fatalf("NewSpanRewriter: %v", err)
+	}
+
+	notFound := &tracepb.Span{
+		Attributes: []*commonpb.KeyValue{intAttr("http.response.status_code", 404)},
+		Status:     &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR},
+	}
+	rw.rewriteSpan("search", notFound)
+	if notFound.Status.Code != tracepb.Status_STATUS_CODE_UNSET {
+		t.Errorf("search 404 status = %v, want UNSET", notFound.Status.Code)
+	}
+
+	otherService := &tracepb.Span{
+		Attributes: []*commonpb.KeyValue{intAttr("http.response.status_code", 404)},
+		Status:     &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR},
+	}
+	rw.rewriteSpan("checkout", otherService)
+	if otherService.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+		t.Error("rule for search must not touch checkout spans")
+	}
+
+	serverError := &tracepb.Span{
+		Attributes: []*commonpb.KeyValue{intAttr("http.response.status_code", 500)},
+		Status:     &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR},
+	}
+	rw.rewriteSpan("search", serverError)
+	if serverError.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+		t.Error("search 500 must stay an error")
+	}
+
+	vendor := &tracepb.Span{Attributes: []*commonpb.KeyValue{strAttr("vendor.code", "E1042"), strAttr("error.type", "E1042")}}
+	rw.rewriteSpan("payments", vendor)
+	if got, _ := spanAttribute(vendor.Attributes, "error.type"); got != "card_declined" || len(vendor.Attributes) != 2 {
+		t.Errorf("error.type = %q (attrs %d), want card_declined replaced in place", got, len(vendor.Attributes))
+	}
+}
+
+func TestNewSpanRewriter_Invalid(t *testing.T) {
+	cases := map[string]RewriteRule{
+		"unknown status":     {Status: "failed", SetStatus: "ok"},
+		"unknown set_status": {SetStatus: "broken"},
+		"no action":          {Service: "svc", Match: map[string]string{"a": "b"}},
+	}
+	for name, r := range cases {
+		if _, err := NewSpanRewriter([]RewriteRule{r}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadSpanRewriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(`[{"service":"*","set_attributes":{"env":"prod"}}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rw, err := LoadSpanRewriter(path)
+	if err != nil || rw.Rules() != 1 {
+		t.Fatalf("LoadSpanRewriter = %v, %v; want 1 rule", rw, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`[]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpanRewriter(path); err == nil {
+		t.Error("empty rules file should fail")
+	}
+	if rw, err := LoadSpanRewriter(""); rw != nil || err != nil {
+		t.Errorf("empty path = %v, %v; want disabled", rw, err)
+	}
+}
+
+// TestSpanRewriter_ExportStoresRewrittenStatus verifies a rewritten status
+// is what gets persisted, and no ERROR log is synthesized for it.
+func TestSpanRewriter_ExportStoresRewrittenStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	rw, err := NewSpanRewriter([]RewriteRule{
+		{Service: "search", Match: map[string]string{"http.response.status_code": "404"}, SetStatus: "unset"},
+	})
+	if err != nil {
+		t.Fatalf("NewSpanRewriter: %v", err)
+	}
+	traces.SetSpanRewriter(rw)
+
+	var mu sync.Mutex
+	var stored []storage.Span
+	var logs []storage.Log
+	traces.SetSpanCallback(func(s storage.Span) { mu.Lock(); stored = append(stored, s); mu.Unlock() })
+	traces.SetLogCallback(func(l storage.Log) { mu.Lock(); logs = append(logs, l); mu.Unlock() })
+
+	req := buildTracesRequest("search", 2)
+	for i, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+		span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+		span.Attributes = append(span.Attributes, intAttr("http.response.status_code", []int64{404, 500}[i]))
+	}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	statuses := map[string]int{}
+	for _, s := range stored {
+		statuses[s.Status]++
+	}
+	if statuses["STATUS_CODE_UNSET"] != 1 || statuses["STATUS_CODE_ERROR"] != 1 {
+		t.Errorf("stored statuses = %v, want one UNSET (404) and one ERROR (500)", statuses)
+	}
+	if len(logs) != 1 {
+		t.Errorf("synthesized logs = %d, want 1 (for the 500 only)", len(logs))
+	}
+}
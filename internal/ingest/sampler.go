@@ -1,6 +1,7 @@
 package ingest
 
 import (
+	"hash/fnv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,8 +10,11 @@ import (
 // Sampler decides whether a trace/span should be ingested.
 // Always keeps: error traces, slow traces (duration > latencyThresholdMs), new services.
 // Samples healthy traces at the configured rate using a per-service token bucket.
+// Services with their own rate (SetServiceRates) are sampled by trace ID hash
+// instead, so every span of a kept trace is kept.
 type Sampler struct {
 	rate               float64 // 0.0–1.0, fraction to keep
+	serviceRates       map[string]float64
 	alwaysOnErrors     bool
 	latencyThresholdMs float64 // always keep traces slower than this
 	mu                 sync.Mutex
@@ -35,6 +39,45 @@ func NewSampler(rate float64, alwaysOnErrors bool, latencyThresholdMs float64) *
 	}
 }
 
+// SetServiceRates overrides the sampling rate per service.name, each rate
+// 0.0–1.0. Must be called before Export traffic starts.
+func (s *Sampler) SetServiceRates(rates map[string]float64) {
+	s.serviceRates = rates
+}
+
+// ShouldSampleTrace is ShouldSample for a span of traceID. Services with a
+// rate from SetServiceRates keep a deterministic fraction of trace IDs, so
+// the decision is the same for every span of a trace and on every replica;
+// other services fall back to ShouldSample.
+func (s *Sampler) ShouldSampleTrace(serviceName string, traceID []byte, isError bool, durationMs float64) bool {
+	rate, ok := s.serviceRates[serviceName]
+	if !ok {
+		return s.ShouldSample(serviceName, isError, durationMs)
+	}
+	s.totalSeen.Add(1)
+	if (s.alwaysOnErrors && isError) || durationMs >= s.latencyThresholdMs {
+		return true
+	}
+	if !traceIDSampled(traceID, rate) {
+		s.totalDropped.Add(1)
+		return false
+	}
+	return true
+}
+
+// traceIDSampled reports whether traceID falls in the kept fraction rate.
+func traceIDSampled(traceID []byte, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(traceID)
+	return float64(h.Sum32()%10_000) < rate*10_000
+}
+
 // ShouldSample returns true if the trace should be ingested.
 // isError: whether the trace/span has error status.
 // durationMs: trace duration in milliseconds.
@@ -0,0 +1,109 @@
+package ingest
+
+import (
+	"sync"
+	"time"
+)
+
+// rejectRateLimited is the reject reason for spans and log records over
+// their service's INGEST_SPAN_RATE_LIMITS / INGEST_LOG_RATE_LIMITS limit.
+const rejectRateLimited = "rate_limited"
+
+// maxServiceBuckets bounds the limiter's bucket map; past it, idle buckets
+// are pruned before a new one is added.
+const maxServiceBuckets = 4096
+
+// ServiceRateLimiter caps records per second per tenant and service with a
+// token bucket each. A bucket holds one second's worth of records, so a
+// service may burst up to its limit after being idle. Records over the
+// limit are dropped and reported via partial_success.
+//
+// Safe for concurrent use.
+type ServiceRateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]float64 // service.name → records/sec
+	deflt   float64            // "*" limit; 0 = unlisted services unlimited
+	buckets map[serviceKey]*rateBucket
+	now     func() time.Time
+}
+
+type serviceKey struct {
+	tenant, service string
+}
+
+// NewServiceRateLimiter returns a limiter for limits as parsed by
+// config.ParseServiceRateLimits. Returns nil for no limits.
+func NewServiceRateLimiter(limits map[string]float64) *ServiceRateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	rl := &ServiceRateLimiter{
+		limits:  make(map[string]float64, len(limits)),
+		buckets: make(map[serviceKey]*rateBucket),
+		now:     time.Now,
+	}
+	for svc, v := range limits {
+		if svc == "*" {
+			rl.deflt = v
+			continue
+		}
+		rl.limits[svc] = v
+	}
+	return rl
+}
+
+// allow reports whether one more record of tenant's service fits its
+// limit, and takes a token if so.
+func (rl *ServiceRateLimiter) allow(tenant, service string) bool {
+	limit, ok := rl.limits[service]
+	if !ok {
+		limit = rl.deflt
+	}
+	if limit <= 0 {
+		return true
+	}
+	now := rl.now()
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	k := serviceKey{tenant, service}
+	b, ok := rl.buckets[k]
+	if !ok {
+		if len(rl.buckets) >= maxServiceBuckets {
+			rl.prune(now)
+		}
+		b = &rateBucket{tokens: max(limit, 1), lastSeen: now}
+		rl.buckets[k] = b
+	}
+	b.refill(now, limit)
+	return b.take()
+}
+
+// prune drops buckets idle long enough to have refilled; recreating them
+// later starts them full, so nothing changes. Caller holds rl.mu.
+func (rl *ServiceRateLimiter) prune(now time.Time) {
+	for k, b := range rl.buckets {
+		if now.Sub(b.lastSeen) >= time.Second {
+			delete(rl.buckets, k)
+		}
+	}
+}
+
+// rateBucket is a token bucket refilled at the limit per second and capped
+// at one second's worth, or one token for limits below 1/sec.
+type rateBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+func (b *rateBucket) refill(now time.Time, limit float64) {
+	b.tokens = min(b.tokens+now.Sub(b.lastSeen).Seconds()*limit, max(limit, 1))
+	b.lastSeen = now
+}
+
+func (b *rateBucket) take() bool {
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
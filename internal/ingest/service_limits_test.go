@@ -0,0 +1,120 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+)
+
+func TestServiceRateLimiter_Allow(t *testing.T) {
+	rl := NewServiceRateLimiter(map[string]float64{"chatty": 2, "*": 5})
+	now := time.Unix(1000, 0)
+	rl.now = func() time.Time { return now }
+
+	allowed := func(tenant, service string, n int) int {
+		kept := 0
+		for range n {
+			if rl.allow(tenant, service) {
+				kept++
+			}
+		}
+		return kept
+	}
+	if got := allowed("t1", "chatty", 10); got != 2 {
+		t.Errorf("chatty burst kept %d, want 2", got)
+	}
+	if got := allowed("t1", "other", 10); got != 5 {
+		t.Errorf("default burst kept %d, want 5", got)
+	}
+	if got := allowed("t2", "chatty", 10); got != 2 {
+		t.Errorf("chatty of another tenant kept %d, want its own 2", got)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if got := allowed("t1", "chatty", 10); got != 1 {
+		t.Errorf("chatty after 0.5s kept %d, want 1", got)
+	}
+	now = now.Add(time.Hour)
+	if got := allowed("t1", "chatty", 10); got != 2 {
+		t.Errorf("chatty after idle kept %d, want a full bucket of 2", got)
+	}
+
+	if NewServiceRateLimiter(nil) != nil {
+		t.Error("no limits should disable the limiter")
+	}
+	rl = NewServiceRateLimiter(map[string]float64{"chatty": 1})
+	if got := allowed("t1", "quiet", 100); got != 100 {
+		t.Errorf("unlisted service without a default kept %d, want all", got)
+	}
+}
+
+func TestSampler_ServiceRatesByTraceID(t *testing.T) {
+	sm := NewSampler(1.0, true, 60_000)
+	sm.SetServiceRates(map[string]float64{"noisy": 0.25, "muted": 0})
+
+	kept := 0
+	for i := range 4000 {
+		id := []byte(fmt.Sprintf("trace-%016d", i))
+		first := sm.ShouldSampleTrace("noisy", id, false, 1)
+		if again := sm.ShouldSampleTrace("noisy", id, false, 1); again != first {
+			t.Fatalf("trace %d: decisions differ across spans", i)
+		}
+		if first {
+			kept++
+		}
+	}
+	if kept < 800 || kept > 1200 {
+		t.Errorf("kept %d of 4000 traces at rate 0.25", kept)
+	}
+	if !sm.ShouldSampleTrace("muted", []byte("t"), true, 1) || !sm.ShouldSampleTrace("muted", []byte("t"), false, 60_000) {
+		t.Error("errors and slow spans must be kept at any service rate")
+	}
+	if sm.ShouldSampleTrace("muted", []byte("t"), false, 1) {
+		t.Error("muted service kept a healthy span at rate 0")
+	}
+	if !sm.ShouldSampleTrace("other", []byte("t"), false, 1) {
+		t.Error("unlisted service should follow SAMPLING_RATE 1.0")
+	}
+}
+
+// TestServiceLimits_PartialSuccess verifies sampled-out and rate-limited
+// records are reported per reason in partial_success.
+func TestServiceLimits_PartialSuccess(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+
+	traces := NewTraceServer(repo, nil, cfg)
+	sm := NewSampler(1.0, true, 60_000)
+	sm.SetServiceRates(map[string]float64{"muted": 0})
+	traces.SetSampler(sm)
+	traces.SetRateLimiter(NewServiceRateLimiter(map[string]float64{"chatty": 2}))
+
+	resp, err := traces.Export(context.Background(), buildTracesRequest("muted", 3))
+	if err != nil {
+		t.Fatalf("trace Export: %v", err)
+	}
+	if ps := resp.GetPartialSuccess(); ps.GetRejectedSpans() != 3 || !strings.Contains(ps.GetErrorMessage(), rejectSampled+"=3") {
+		t.Errorf("muted PartialSuccess = %+v, want 3 sampled", ps)
+	}
+	resp, err = traces.Export(context.Background(), buildTracesRequest("chatty", 5))
+	if err != nil {
+		t.Fatalf("trace Export: %v", err)
+	}
+	if ps := resp.GetPartialSuccess(); ps.GetRejectedSpans() != 3 || !strings.Contains(ps.GetErrorMessage(), rejectRateLimited+"=3") {
+		t.Errorf("chatty PartialSuccess = %+v, want 3 rate_limited", ps)
+	}
+
+	logs := NewLogsServer(repo, nil, cfg)
+	logs.SetRateLimiter(NewServiceRateLimiter(map[string]float64{"*": 4}))
+	lresp, err := logs.Export(context.Background(), buildLogsRequest("chatty", 10))
+	if err != nil {
+		t.Fatalf("logs Export: %v", err)
+	}
+	if ps := lresp.GetPartialSuccess(); ps.GetRejectedLogRecords() != 6 || !strings.Contains(ps.GetErrorMessage(), rejectRateLimited+"=6") {
+		t.Errorf("logs PartialSuccess = %+v, want 6 rate_limited", ps)
+	}
+}
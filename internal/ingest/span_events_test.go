@@ -0,0 +1,31 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestExport_StoresSpanEventsBelowMinSeverity(t *testing.T) {
+	repo := newTestRepo(t)
+	// INFO events are not kept as logs at ERROR, but stay searchable.
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "ERROR"})
+
+	req := buildTracesRequest("inventory", 1)
+	span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	span.Events = []*tracepb.Span_Event{{Name: "database_lock_contention", TimeUnixNano: span.StartTimeUnixNano + 1000}}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	got, err := repo.SearchSpanEventTraces(context.Background(), storage.SpanEventQuery{Name: "database_lock_contention"})
+	if err != nil {
+		t.Fatalf("SearchSpanEventTraces: %v", err)
+	}
+	if len(got) != 1 || got[0].TraceID != "a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0" || got[0].SampleService != "inventory" {
+		t.Errorf("traces = %+v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestExport_StoresSpanLinks(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	producer := bytes.Repeat([]byte{0x42}, 16)
+	req := buildTracesRequest("worker", 1)
+	req.ResourceSpans[0].ScopeSpans[0].Spans[0].Links = []*tracepb.Span_Link{
+		{
+			TraceId: producer, SpanId: bytes.Repeat([]byte{0x07}, 8), TraceState: "vendor=1",
+			Attributes: []*commonpb.KeyValue{{Key: "messaging.operation", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "process"}}}},
+		},
+		{TraceId: []byte{0x01}, SpanId: bytes.Repeat([]byte{0x08}, 8)}, // malformed, dropped
+	}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	tr, err := repo.GetTrace(context.Background(), "a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0a0")
+	if err != nil {
+		t.Fatalf("GetTrace: %v", err)
+	}
+	if len(tr.Links) != 1 {
+		t.Fatalf("links = %+v, want the valid one", tr.Links)
+	}
+	l := tr.Links[0]
+	if l.Direction != storage.TraceLinkOutgoing || l.LinkedTraceID != "42424242424242424242424242424242" ||
+		l.LinkedSpanID != "0707070707070707" || l.TraceState != "vendor=1" || !bytes.Contains([]byte(l.AttributesJSON), []byte("messaging.operation")) {
+		t.Errorf("link = %+v", l)
+	}
+}
+
+func TestSpanLinks_DropsOversizedTraceState(t *testing.T) {
+	span := &tracepb.Span{Links: []*tracepb.Span_Link{{
+		TraceId:    bytes.Repeat([]byte{0x42}, 16),
+		SpanId:     bytes.Repeat([]byte{0x07}, 8),
+		TraceState: "vendor=" + strings.Repeat("é", storage.MaxSpanLinkTraceState),
+	}}}
+	links := spanLinks(span)
+	if len(links) != 1 || links[0].TraceState != "" {
+		t.Errorf("links = %+v, want the link without its tracestate", links)
+	}
+}
@@ -0,0 +1,242 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+// SpanNameRule is one entry of the span name rules file. A rule applies to
+// spans of its service and rewrites the span name either by regular
+// expression or by path template; exactly one of Pattern and Template must
+// be set.
+//
+//	[
+//	  {"service": "web", "pattern": "^GET /assets/.*", "replacement": "GET /assets/*"},
+//	  {"template": "/users/{id}/orders/{order_id}"}
+//	]
+//
+// Pattern is a Go regular expression; every match is replaced with
+// Replacement, which may reference groups ($1, ${name}). Template matches
+// span names whose path — after an optional "GET "-style method prefix,
+// without the query string — has the same segments, each {placeholder}
+// standing for any one non-empty segment; the path is then replaced by the
+// template: "GET /users/42/orders/7?x=1" → "GET /users/{id}/orders/{order_id}".
+type SpanNameRule struct {
+	Name        string `json:"name,omitempty"`        // metric label; defaults to the rule's index
+	Service     string `json:"service"`               // exact service.name; "" or "*" = every service
+	Pattern     string `json:"pattern,omitempty"`     // regular expression
+	Replacement string `json:"replacement,omitempty"` // used with Pattern
+	Template    string `json:"template,omitempty"`    // path template with {placeholders}
+}
+
+// spanNameDistinctCap bounds each distinct-name set the normalizer keeps
+// for its before/after gauges. Past it the gauges stop growing, which is
+// itself the signal that names are still exploding.
+const spanNameDistinctCap = 10_000
+
+type spanNameRule struct {
+	label    string
+	service  string // "" = every service
+	pattern  *regexp.Regexp
+	replace  string
+	template []string // path segments; "" marks a placeholder
+	rendered string   // the template as written
+}
+
+// SpanNameNormalizer collapses high-cardinality span names — raw URLs with
+// IDs, as some SDKs emit — into templates at ingest, so the operations
+// dimension and every per-operation aggregate stay meaningful. Rules run in
+// file order after the span rewrite rules and before sampling; a later rule
+// sees the name left by earlier ones. Nil SpanNameNormalizer = names stored
+// as sent.
+//
+// Rules are immutable after construction; safe for concurrent use.
+type SpanNameNormalizer struct {
+	rules   []spanNameRule
+	metrics *telemetry.Metrics
+
+	mu     sync.Mutex
+	before map[string]struct{} // distinct service/name pairs as received
+	after  map[string]struct{} // ... and as stored
+}
+
+// NewSpanNameNormalizer compiles rules. Returns an error naming the first
+// invalid rule (bad pattern or template, or not exactly one of them).
+// Returns nil for no rules. metrics may be nil.
+func NewSpanNameNormalizer(rules []SpanNameRule, metrics *telemetry.Metrics) (*SpanNameNormalizer, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	n := &SpanNameNormalizer{
+		rules:   make([]spanNameRule, 0, len(rules)),
+		metrics: metrics,
+		before:  make(map[string]struct{}),
+		after:   make(map[string]struct{}),
+	}
+	for i, r := range rules {
+		c := spanNameRule{label: r.Name, service: r.Service, replace: r.Replacement}
+		if c.label == "" {
+			c.label = strconv.Itoa(i)
+		}
+		if c.service == "*" {
+			c.service = ""
+		}
+		switch {
+		case r.Pattern != "" && r.Template != "":
+			return nil, fmt.Errorf("span name rule %d: set pattern or template, not both", i)
+		case r.Pattern != "":
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("span name rule %d: %w", i, err)
+			}
+			c.pattern = re
+		case r.Template != "":
+			segs, err := parsePathTemplate(r.Template)
+			if err != nil {
+				return nil, fmt.Errorf("span name rule %d: %w", i, err)
+			}
+			c.template, c.rendered = segs, r.Template
+		default:
+			return nil, fmt.Errorf("span name rule %d: needs pattern or template", i)
+		}
+		n.rules = append(n.rules, c)
+	}
+	return n, nil
+}
+
+// LoadSpanNameNormalizer reads a JSON array of SpanNameRule from path. An
+// empty path disables normalization (nil, nil).
+func LoadSpanNameNormalizer(path string, metrics *telemetry.Metrics) (*SpanNameNormalizer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("span name rules file %q: %w", path, err)
+	}
+	var rules []SpanNameRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("span name rules file %q: %w", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("span name rules file %q: no rules found", path)
+	}
+	n, err := NewSpanNameNormalizer(rules, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("span name rules file %q: %w", path, err)
+	}
+	return n, nil
+}
+
+// Rules returns the number of compiled rules.
+func (n *SpanNameNormalizer) Rules() int { return len(n.rules) }
+
+// normalize returns name after every rule for service has run, counting
+// each rule that changed it and the distinct names before and after.
+func (n *SpanNameNormalizer) normalize(service, name string) string {
+	out := n.rename(service, name, true)
+	n.track(service, name, out)
+	return out
+}
+
+// rename runs every rule for service over name, counting the rules that
+// changed it when count is set. Dry runs rename without counting.
+func (n *SpanNameNormalizer) rename(service, name string, count bool) string {
+	out := name
+	for i := range n.rules {
+		r := &n.rules[i]
+		if r.service != "" && r.service != service {
+			continue
+		}
+		if next := r.apply(out); next != out {
+			out = next
+			if count {
+				n.metrics.RecordSpanNameNormalized(r.label)
+			}
+		}
+	}
+	return out
+}
+
+func (n *SpanNameNormalizer) track(service, before, after string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if addDistinct(n.before, service+"\x00"+before) {
+		n.metrics.SetSpanNamesDistinct("before", len(n.before))
+	}
+	if addDistinct(n.after, service+"\x00"+after) {
+		n.metrics.SetSpanNamesDistinct("after", len(n.after))
+	}
+}
+
+// addDistinct adds key to set unless the set is full. Reports whether the
+// set grew.
+func addDistinct(set map[string]struct{}, key string) bool {
+	if _, ok := set[key]; ok || len(set) >= spanNameDistinctCap {
+		return false
+	}
+	set[key] = struct{}{}
+	return true
+}
+
+func (r *spanNameRule) apply(name string) string {
+	if r.pattern != nil {
+		return r.pattern.ReplaceAllString(name, r.replace)
+	}
+	method, path := splitSpanName(name)
+	if path == "" {
+		return name
+	}
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segs) != len(r.template) {
+		return name
+	}
+	for i, want := range r.template {
+		if segs[i] == "" || (want != "" && segs[i] != want) {
+			return name
+		}
+	}
+	return method + r.rendered
+}
+
+// splitSpanName splits a span name such as "GET /users/42?x=1" into its
+// method prefix ("GET ", kept verbatim) and path ("/users/42"). path is ""
+// when the name has no "/"-rooted path.
+func splitSpanName(name string) (method, path string) {
+	path = name
+	if i := strings.IndexByte(name, ' '); i > 0 && !strings.Contains(name[:i], "/") {
+		method, path = name[:i+1], name[i+1:]
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", ""
+	}
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return method, path
+}
+
+// parsePathTemplate splits a "/users/{id}" template into segments, with ""
+// for each placeholder.
+func parsePathTemplate(tmpl string) ([]string, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("template %q must start with /", tmpl)
+	}
+	segs := strings.Split(strings.Trim(tmpl, "/"), "/")
+	for i, s := range segs {
+		switch {
+		case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") && len(s) > 2:
+			segs[i] = ""
+		case s == "" || strings.ContainsAny(s, "{}"):
+			return nil, fmt.Errorf("template %q: invalid segment %q", tmpl, s)
+		}
+	}
+	return segs, nil
+}
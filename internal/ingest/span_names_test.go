@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestSpanNameNormalizer_Rules(t *testing.T) {
+	n, err := NewSpanNameNormalizer([]SpanNameRule{
+		{Service: "web", Pattern: `^GET /assets/.*`, Replacement: "GET /assets/*"},
+		{Template: "/users/{id}/orders/{order_id}"},
+		{Pattern: `/v(\d+)/items/[0-9a-f-]{36}`, Replacement: "/v$1/items/{uuid}"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewSpanNameNormalizer: %v", err)
+	}
+
+	cases := []struct{ service, name, want string }{
+		{"web", "GET /assets/app.3f9a1c.js", "GET /assets/*"},
+		{"api", "GET /assets/app.3f9a1c.js", "GET /assets/app.3f9a1c.js"}, // rule is web-only
+		{"api", "GET /users/42/orders/7?expand=items", "GET /users/{id}/orders/{order_id}"},
+		{"api", "/users/42/orders/7/", "/users/{id}/orders/{order_id}"},
+		{"api", "GET /users/42/orders", "GET /users/42/orders"},           // segment count differs
+		{"api", "GET /accounts/42/orders/7", "GET /accounts/42/orders/7"}, // literal differs
+		{"api", "SELECT orders", "SELECT orders"},
+		{"api", "POST /v2/items/0d7e9c4e-5b1c-4a8e-9f3a-2c6b7d8e9f01", "POST /v2/items/{uuid}"},
+	}
+	for _, c := range cases {
+		if got := n.normalize(c.service, c.name); got != c.want {
+			t.Errorf("normalize(%q, %q) = %q, want %q", c.service, c.name, got, c.want)
+		}
+	}
+
+	n.before, n.after = map[string]struct{}{}, map[string]struct{}{}
+	for _, id := range []string{"1", "2", "3"} {
+		n.normalize("api", "GET /users/"+id+"/orders/9")
+	}
+	if len(n.before) != 3 || len(n.after) != 1 {
+		t.Errorf("distinct names before/after = %d/%d, want 3/1", len(n.before), len(n.after))
+	}
+}
+
+func TestNewSpanNameNormalizer_Invalid(t *testing.T) {
+	cases := map[string]SpanNameRule{
+		"no action":         {Service: "svc"},
+		"both":              {Pattern: "x", Template: "/x"},
+		"bad pattern":       {Pattern: "("},
+		"relative template": {Template: "users/{id}"},
+		"empty segment":     {Template: "/users//{id}"},
+		"half placeholder":  {Template: "/users/{id"},
+	}
+	for name, r := range cases {
+		if _, err := NewSpanNameNormalizer([]SpanNameRule{r}, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadSpanNameNormalizer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "span_names.json")
+	if err := os.WriteFile(path, []byte(`[{"name":"users","template":"/users/{id}"}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	n, err := LoadSpanNameNormalizer(path, nil)
+	if err != nil || n.Rules() != 1 {
+		t.Fatalf("LoadSpanNameNormalizer = %v, %v; want 1 rule", n, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`[]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpanNameNormalizer(path, nil); err == nil {
+		t.Error("empty rules file should fail")
+	}
+	if n, err := LoadSpanNameNormalizer("", nil); n != nil || err != nil {
+		t.Errorf("empty path = %v, %v; want disabled", n, err)
+	}
+}
+
+// TestSpanNameNormalizer_ExportStoresTemplate verifies the normalized name
+// is what gets persisted as the span's operation.
+func TestSpanNameNormalizer_ExportStoresTemplate(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	n, err := NewSpanNameNormalizer([]SpanNameRule{{Template: "/users/{id}"}}, nil)
+	if err != nil {
+		t.Fatalf("NewSpanNameNormalizer: %v", err)
+	}
+	traces.SetSpanNameNormalizer(n)
+
+	var mu sync.Mutex
+	var stored []storage.Span
+	traces.SetSpanCallback(func(s storage.Span) { mu.Lock(); stored = append(stored, s); mu.Unlock() })
+
+	req := buildTracesRequest("web", 2)
+	for i, span := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+		span.Name = []string{"GET /users/17", "GET /users/23"}[i]
+	}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stored) != 2 || stored[0].OperationName != "GET /users/{id}" || stored[1].OperationName != "GET /users/{id}" {
+		t.Errorf("stored spans = %+v, want both named GET /users/{id}", stored)
+	}
+}
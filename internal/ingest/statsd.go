@@ -0,0 +1,324 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const (
+	// statsdMaxPacket is the largest UDP payload; DogStatsD clients buffer
+	// up to 8KB, plain StatsD clients far less.
+	statsdMaxPacket = 65535
+
+	// statsdDefaultService is the service.name for lines without a
+	// DogStatsD "service" tag.
+	statsdDefaultService = "statsd"
+
+	// statsdMaxGaugeKeys bounds the last-value state kept for relative
+	// gauges ("+3|g"). Past the cap, deltas for new series are applied to 0.
+	statsdMaxGaugeKeys = 10_000
+)
+
+var errStatsDUnsupported = errors.New("statsd: unsupported metric type")
+
+// statsdSample is one parsed StatsD line.
+type statsdSample struct {
+	name     string
+	typ      string // c | g | ms | h | d
+	values   []float64
+	rate     float64
+	relative bool // gauge with an explicit +/- sign
+	service  string
+	tags     []*commonpb.KeyValue
+	ts       time.Time
+}
+
+// StatsDReceiver accepts StatsD and DogStatsD lines over UDP and forwards
+// them to MetricsServer.Export, so service filters and tenancy apply as for
+// OTLP metrics:
+//
+//   - counters (c) become delta sums, scaled by 1/sample-rate
+//   - gauges (g) become gauges; "+N"/"-N" adjust the last value seen
+//   - timers (ms), histograms (h) and distributions (d) become one gauge
+//     point per sample, so the TSDB min/max/avg rollups chart them
+//
+// DogStatsD "#k:v" tags become point attributes, except "service", which
+// sets service.name. Sets, events and service checks are counted and dropped.
+type StatsDReceiver struct {
+	metricsSrv *MetricsServer
+	metrics    *telemetry.Metrics
+
+	conn    net.PacketConn
+	wg      sync.WaitGroup
+	stopped atomic.Bool
+
+	gaugeMu sync.Mutex
+	gauges  map[string]float64
+}
+
+// NewStatsDReceiver creates a receiver that forwards into metricsSrv.
+// metrics may be nil.
+func NewStatsDReceiver(metricsSrv *MetricsServer, metrics *telemetry.Metrics) *StatsDReceiver {
+	return &StatsDReceiver{metricsSrv: metricsSrv, metrics: metrics, gauges: make(map[string]float64)}
+}
+
+// Start binds addr (e.g. ":8125") and begins reading datagrams.
+func (s *StatsDReceiver) Start(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("statsd: listen %s: %w", addr, err)
+	}
+	s.conn = conn
+	s.wg.Add(1)
+	go s.serve()
+	slog.Info("📡 StatsD receiver started", "addr", conn.LocalAddr().String())
+	return nil
+}
+
+// Addr returns the bound address, or nil before Start.
+func (s *StatsDReceiver) Addr() net.Addr {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+// Stop closes the socket and waits for the read loop to exit.
+func (s *StatsDReceiver) Stop() {
+	if s.conn == nil || !s.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	_ = s.conn.Close()
+	s.wg.Wait()
+}
+
+func (s *StatsDReceiver) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, statsdMaxPacket)
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			if s.stopped.Load() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("StatsD: read failed", "error", err)
+			continue
+		}
+		s.handlePacket(string(buf[:n]))
+	}
+}
+
+// handlePacket parses every newline-separated line in a datagram and exports
+// the valid ones as a single request. Bad lines are counted and skipped.
+func (s *StatsDReceiver) handlePacket(pkt string) {
+	now := time.Now()
+	var samples []statsdSample
+	for line := range strings.SplitSeq(pkt, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sm, err := parseStatsDLine(line, now)
+		switch {
+		case errors.Is(err, errStatsDUnsupported):
+			s.metrics.RecordStatsDLines("unsupported", 1)
+		case err != nil:
+			s.metrics.RecordStatsDLines("parse_error", 1)
+			slog.Debug("StatsD: dropped malformed line", "line", line, "error", err)
+		default:
+			samples = append(samples, sm)
+		}
+	}
+	if len(samples) == 0 {
+		return
+	}
+	s.resolveRelativeGauges(samples)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := s.metricsSrv.Export(ctx, statsdRequest(samples)); err != nil {
+		s.metrics.RecordStatsDLines("export_error", len(samples))
+		slog.Warn("StatsD: export failed", "error", err)
+		return
+	}
+	s.metrics.RecordStatsDLines("ok", len(samples))
+}
+
+// resolveRelativeGauges turns "+N"/"-N" gauge deltas into absolute values
+// and remembers the last value of every gauge series.
+func (s *StatsDReceiver) resolveRelativeGauges(samples []statsdSample) {
+	s.gaugeMu.Lock()
+	defer s.gaugeMu.Unlock()
+	for i := range samples {
+		sm := &samples[i]
+		if sm.typ != "g" {
+			continue
+		}
+		key := statsdSeriesKey(sm)
+		last, known := s.gauges[key]
+		if sm.relative {
+			sm.values[0] += last
+		}
+		if known || len(s.gauges) < statsdMaxGaugeKeys {
+			s.gauges[key] = sm.values[0]
+		}
+	}
+}
+
+// statsdSeriesKey identifies a gauge series by service, name and tags.
+func statsdSeriesKey(sm *statsdSample) string {
+	var b strings.Builder
+	b.WriteString(sm.service)
+	b.WriteByte('|')
+	b.WriteString(sm.name)
+	for _, kv := range sm.tags {
+		b.WriteByte('|')
+		b.WriteString(kv.Key)
+		b.WriteByte('=')
+		b.WriteString(kv.Value.GetStringValue())
+	}
+	return b.String()
+}
+
+// parseStatsDLine parses "name:value[:value…]|type[|@rate][|#tags][|T<unix>]".
+// Unknown trailing sections (e.g. DogStatsD "|c:<container-id>") are ignored.
+func parseStatsDLine(line string, now time.Time) (statsdSample, error) {
+	if strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|") {
+		return statsdSample{}, errStatsDUnsupported // event / service check
+	}
+	sections := strings.Split(line, "|")
+	if len(sections) < 2 {
+		return statsdSample{}, fmt.Errorf("statsd: missing type in %q", line)
+	}
+	name, rawValues, ok := strings.Cut(sections[0], ":")
+	if !ok || name == "" || rawValues == "" {
+		return statsdSample{}, fmt.Errorf("statsd: malformed metric %q", sections[0])
+	}
+	sm := statsdSample{name: name, typ: sections[1], rate: 1, service: statsdDefaultService, ts: now}
+	switch sm.typ {
+	case "c", "g", "ms", "h", "d":
+	case "s":
+		return statsdSample{}, errStatsDUnsupported
+	default:
+		return statsdSample{}, fmt.Errorf("statsd: unknown type %q", sm.typ)
+	}
+
+	for _, sec := range sections[2:] {
+		switch {
+		case strings.HasPrefix(sec, "@"):
+			rate, err := strconv.ParseFloat(sec[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return statsdSample{}, fmt.Errorf("statsd: invalid sample rate %q", sec)
+			}
+			sm.rate = rate
+		case strings.HasPrefix(sec, "#"):
+			sm.tags = parseDogStatsDTags(sec[1:], &sm.service)
+		case strings.HasPrefix(sec, "T"):
+			if unix, err := strconv.ParseInt(sec[1:], 10, 64); err == nil && unix > 0 {
+				sm.ts = time.Unix(unix, 0)
+			}
+		}
+	}
+
+	// Multi-value packing ("a:1:2:3|d") is a DogStatsD extension; gauges and
+	// counters carry exactly one value.
+	parts := strings.Split(rawValues, ":")
+	if len(parts) > 1 && (sm.typ == "c" || sm.typ == "g") {
+		return statsdSample{}, fmt.Errorf("statsd: multiple values for %s", sm.typ)
+	}
+	sm.values = make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return statsdSample{}, fmt.Errorf("statsd: invalid value %q", p)
+		}
+		sm.values = append(sm.values, v)
+	}
+	if sm.typ == "g" && (parts[0][0] == '+' || parts[0][0] == '-') {
+		sm.relative = true
+	}
+	return sm, nil
+}
+
+// parseDogStatsDTags parses "k:v,flag,…" into sorted attributes. A "service"
+// tag is lifted into *service rather than kept as an attribute.
+func parseDogStatsDTags(raw string, service *string) []*commonpb.KeyValue {
+	var tags []*commonpb.KeyValue
+	for t := range strings.SplitSeq(raw, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(t), ":")
+		if k == "" {
+			continue
+		}
+		if k == "service" && v != "" {
+			*service = v
+			continue
+		}
+		tags = append(tags, stringKV(k, v))
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+// statsdRequest groups samples by service into an OTLP metrics request.
+func statsdRequest(samples []statsdSample) *colmetricspb.ExportMetricsServiceRequest {
+	scopes := make(map[string]*metricspb.ScopeMetrics)
+	req := &colmetricspb.ExportMetricsServiceRequest{}
+	for _, sm := range samples {
+		scope, ok := scopes[sm.service]
+		if !ok {
+			scope = &metricspb.ScopeMetrics{Scope: &commonpb.InstrumentationScope{Name: "statsd"}}
+			scopes[sm.service] = scope
+			req.ResourceMetrics = append(req.ResourceMetrics, &metricspb.ResourceMetrics{
+				Resource:     &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringKV("service.name", sm.service)}},
+				ScopeMetrics: []*metricspb.ScopeMetrics{scope},
+			})
+		}
+		scope.Metrics = append(scope.Metrics, statsdMetric(sm))
+	}
+	return req
+}
+
+func statsdMetric(sm statsdSample) *metricspb.Metric {
+	ts := uint64(sm.ts.UnixNano()) // #nosec G115 -- wall-clock nanos are positive
+	point := func(v float64) *metricspb.NumberDataPoint {
+		return &metricspb.NumberDataPoint{
+			TimeUnixNano: ts,
+			Attributes:   sm.tags,
+			Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: v},
+		}
+	}
+	m := &metricspb.Metric{Name: sm.name}
+	switch sm.typ {
+	case "c":
+		m.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			IsMonotonic:            true,
+			DataPoints:             []*metricspb.NumberDataPoint{point(sm.values[0] / sm.rate)},
+		}}
+	default: // g, ms, h, d
+		if sm.typ == "ms" {
+			m.Unit = "ms"
+		}
+		points := make([]*metricspb.NumberDataPoint, 0, len(sm.values))
+		for _, v := range sm.values {
+			points = append(points, point(v))
+		}
+		m.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: points}}
+	}
+	return m
+}
@@ -0,0 +1,153 @@
+package ingest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
+)
+
+func TestParseStatsDLine(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	sm, err := parseStatsDLine("jobs.processed:3|c|@0.5|#service:billing-batch,env:prod,canary", now)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if sm.name != "jobs.processed" || sm.typ != "c" || sm.rate != 0.5 || sm.values[0] != 3 {
+		t.Errorf("sample = %+v", sm)
+	}
+	if sm.service != "billing-batch" {
+		t.Errorf("service = %q, want billing-batch", sm.service)
+	}
+	if len(sm.tags) != 2 || sm.tags[0].Key != "canary" || sm.tags[1].Key != "env" || sm.tags[1].Value.GetStringValue() != "prod" {
+		t.Errorf("tags = %v, want sorted canary, env=prod", sm.tags)
+	}
+
+	sm, err = parseStatsDLine("req.latency:12:15.5:9|d|T1600000000", now)
+	if err != nil {
+		t.Fatalf("parse packed: %v", err)
+	}
+	if len(sm.values) != 3 || sm.service != statsdDefaultService || sm.ts.Unix() != 1_600_000_000 {
+		t.Errorf("packed sample = %+v", sm)
+	}
+
+	sm, err = parseStatsDLine("queue.depth:-4|g", now)
+	if err != nil || !sm.relative || sm.values[0] != -4 {
+		t.Errorf("relative gauge = %+v, %v", sm, err)
+	}
+}
+
+func TestParseStatsDLine_Rejects(t *testing.T) {
+	for line, unsupported := range map[string]bool{
+		"users.unique:alice|s": true,
+		"_e{5,4}:title|text":   true,
+		"_sc|db.check|0":       true,
+		"no.type:1":            false,
+		"bad.value:abc|c":      false,
+		"bad.type:1|x":         false,
+		"bad.rate:1|c|@2":      false,
+		"counter.packed:1:2|c": false,
+		":1|c":                 false,
+		"missing.value:|g":     false,
+	} {
+		_, err := parseStatsDLine(line, time.Now())
+		if err == nil {
+			t.Errorf("%q: want error", line)
+			continue
+		}
+		if got := err == errStatsDUnsupported; got != unsupported {
+			t.Errorf("%q: unsupported = %v, want %v (%v)", line, got, unsupported, err)
+		}
+	}
+}
+
+func TestStatsDRequest_Mapping(t *testing.T) {
+	now := time.Now()
+	var samples []statsdSample
+	for _, line := range []string{
+		"jobs.processed:3|c|@0.5|#service:billing-batch",
+		"job.duration:250|ms|#service:billing-batch",
+		"queue.depth:7|g",
+	} {
+		sm, err := parseStatsDLine(line, now)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		samples = append(samples, sm)
+	}
+
+	req := statsdRequest(samples)
+	if len(req.ResourceMetrics) != 2 {
+		t.Fatalf("resources = %d, want 2 (billing-batch, statsd)", len(req.ResourceMetrics))
+	}
+	billing := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if sum := billing[0].GetSum(); sum == nil || !sum.IsMonotonic || sum.DataPoints[0].GetAsDouble() != 6 {
+		t.Errorf("counter = %v, want monotonic sum of 6 (3 @ 0.5)", billing[0])
+	}
+	if g := billing[1].GetGauge(); g == nil || billing[1].Unit != "ms" || g.DataPoints[0].GetAsDouble() != 250 {
+		t.Errorf("timer = %v, want ms gauge of 250", billing[1])
+	}
+}
+
+func TestStatsDReceiver_RelativeGauges(t *testing.T) {
+	s := NewStatsDReceiver(nil, nil)
+	now := time.Now()
+	var out []float64
+	for _, line := range []string{"depth:10|g", "depth:+5|g", "depth:-3|g", "depth:1|g|#shard:b"} {
+		sm, err := parseStatsDLine(line, now)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		batch := []statsdSample{sm}
+		s.resolveRelativeGauges(batch)
+		out = append(out, batch[0].values[0])
+	}
+	want := []float64{10, 15, 12, 1}
+	for i := range want {
+		if out[i] != want[i] {
+			t.Errorf("gauge values = %v, want %v", out, want)
+			break
+		}
+	}
+}
+
+// TestStatsDReceiver_UDPEndToEnd sends a real multi-line datagram and checks
+// the points reach the metrics pipeline.
+func TestStatsDReceiver_UDPEndToEnd(t *testing.T) {
+	srv := NewMetricsServer(newTestRepo(t), nil, nil, &config.Config{})
+	got := make(chan tsdb.RawMetric, 8)
+	srv.SetMetricCallback(func(m tsdb.RawMetric) { got <- m })
+
+	recv := NewStatsDReceiver(srv, nil)
+	if err := recv.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer recv.Stop()
+
+	conn, err := net.Dial("udp", recv.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("jobs.processed:1|c|#service:nightly-export,env:prod\ngarbage\njob.duration:42|ms|#service:nightly-export\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	seen := map[string]tsdb.RawMetric{}
+	for len(seen) < 2 {
+		select {
+		case m := <-got:
+			seen[m.Name] = m
+		case <-time.After(5 * time.Second):
+			t.Fatalf("metrics not ingested; got %v", seen)
+		}
+	}
+	if m := seen["jobs.processed"]; m.ServiceName != "nightly-export" || m.Value != 1 || m.Attributes["env"] == nil {
+		t.Errorf("counter = %+v", m)
+	}
+	if m := seen["job.duration"]; m.Value != 42 {
+		t.Errorf("timer = %+v", m)
+	}
+}
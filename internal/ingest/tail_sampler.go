@@ -0,0 +1,305 @@
+package ingest
+
+import (
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+// Tail-sampling policy names, used as the otelcontext_tail_sampling_traces_total
+// policy label.
+const (
+	tailPolicyError         = "error"
+	tailPolicyLatency       = "latency"
+	tailPolicyService       = "service"
+	tailPolicyProbabilistic = "probabilistic"
+	tailPolicyNone          = "none"
+)
+
+// TailSamplingPolicy decides which complete traces are kept. A trace is kept
+// when ANY policy matches, checked in field order.
+type TailSamplingPolicy struct {
+	KeepErrors       bool          // any span with STATUS_CODE_ERROR
+	LatencyThreshold time.Duration // any span at least this slow; 0 disables
+	Services         []string      // any span from one of these services
+	Rate             float64       // probabilistic fallback, 0..1, by trace ID hash
+}
+
+// tailBatch is the buffered data for one or more traces.
+type tailBatch struct {
+	traces []storage.Trace
+	spans  []storage.Span
+	logs   []storage.Log
+}
+
+func (b *tailBatch) append(o *tailBatch) {
+	b.traces = append(b.traces, o.traces...)
+	b.spans = append(b.spans, o.spans...)
+	b.logs = append(b.logs, o.logs...)
+}
+
+type pendingTrace struct {
+	tailBatch
+	firstSeen time.Time
+}
+
+type tailDecision struct {
+	keep      bool
+	decidedAt time.Time
+}
+
+// TailSampler buffers spans per trace for a decision window and then keeps
+// or drops the whole trace by TailSamplingPolicy. Unlike the head Sampler it
+// sees every span of the trace, so an error deep in a downstream service
+// keeps the full request.
+//
+// Spans arriving after a decision follow it for decisionTTL. Buffering is
+// bounded by maxTraces; once full, new traces are decided on arrival with
+// the spans at hand rather than growing memory without limit.
+type TailSampler struct {
+	policy      TailSamplingPolicy
+	services    map[string]bool
+	wait        time.Duration
+	maxTraces   int
+	decisionTTL time.Duration
+	metrics     *telemetry.Metrics
+
+	// flush persists kept traces; installed by TraceServer.SetTailSampler.
+	flush func(*tailBatch)
+
+	mu      sync.Mutex
+	pending map[string]*pendingTrace
+	decided map[string]tailDecision
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewTailSampler creates a sampler that decides each trace wait after its
+// first span. maxTraces bounds the buffer. metrics may be nil.
+func NewTailSampler(policy TailSamplingPolicy, wait time.Duration, maxTraces int, metrics *telemetry.Metrics) *TailSampler {
+	if wait <= 0 {
+		wait = 10 * time.Second
+	}
+	if maxTraces <= 0 {
+		maxTraces = 50_000
+	}
+	policy.Rate = min(max(policy.Rate, 0), 1)
+	return &TailSampler{
+		policy:      policy,
+		services:    parseServiceList(strings.Join(policy.Services, ",")),
+		wait:        wait,
+		maxTraces:   maxTraces,
+		decisionTTL: max(5*wait, time.Minute),
+		metrics:     metrics,
+		pending:     make(map[string]*pendingTrace),
+		decided:     make(map[string]tailDecision),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start runs the decision loop.
+func (t *TailSampler) Start() {
+	if t.started.CompareAndSwap(false, true) {
+		go t.loop()
+	}
+}
+
+// Stop decides every buffered trace immediately, flushes the kept ones and
+// stops the loop. Safe without Start. Call before the ingest pipeline is
+// drained.
+func (t *TailSampler) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+		if t.started.Load() {
+			<-t.done
+		}
+		t.decideDue(time.Time{}) // zero cutoff: everything is due
+	})
+}
+
+func (t *TailSampler) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(max(t.wait/4, 100*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case now := <-ticker.C:
+			t.decideDue(now)
+		}
+	}
+}
+
+// Add buffers parsed trace data. Data for traces already decided is kept or
+// dropped straight away.
+func (t *TailSampler) Add(traces []storage.Trace, spans []storage.Span, logs []storage.Log) {
+	incoming := make(map[string]*tailBatch)
+	get := func(tenant, traceID string) *tailBatch {
+		k := tenant + "/" + traceID
+		b := incoming[k]
+		if b == nil {
+			b = &tailBatch{}
+			incoming[k] = b
+		}
+		return b
+	}
+	for _, tr := range traces {
+		b := get(tr.TenantID, tr.TraceID)
+		b.traces = append(b.traces, tr)
+	}
+	for _, sp := range spans {
+		b := get(sp.TenantID, sp.TraceID)
+		b.spans = append(b.spans, sp)
+	}
+	for _, l := range logs {
+		b := get(l.TenantID, l.TraceID)
+		b.logs = append(b.logs, l)
+	}
+
+	now := time.Now()
+	kept := &tailBatch{}
+	t.mu.Lock()
+	for k, b := range incoming {
+		if d, ok := t.decided[k]; ok {
+			if d.keep {
+				kept.append(b)
+			}
+			continue
+		}
+		if p, ok := t.pending[k]; ok {
+			p.append(b)
+			continue
+		}
+		if len(t.pending) >= t.maxTraces {
+			// Buffer full: decide now on the spans at hand.
+			if t.decideLocked(k, b, now) {
+				kept.append(b)
+			}
+			continue
+		}
+		t.pending[k] = &pendingTrace{tailBatch: *b, firstSeen: now}
+	}
+	buffered := len(t.pending)
+	t.mu.Unlock()
+
+	t.metrics.SetTailSamplingBuffered(buffered)
+	t.flushKept(kept)
+}
+
+// decideDue decides every pending trace first seen at or before now-wait
+// (all of them when now is zero) and expires stale decisions.
+func (t *TailSampler) decideDue(now time.Time) {
+	cutoff := now.Add(-t.wait)
+	kept := &tailBatch{}
+	t.mu.Lock()
+	for k, p := range t.pending {
+		if !now.IsZero() && p.firstSeen.After(cutoff) {
+			continue
+		}
+		delete(t.pending, k)
+		if t.decideLocked(k, &p.tailBatch, now) {
+			kept.append(&p.tailBatch)
+		}
+	}
+	for k, d := range t.decided {
+		if now.Sub(d.decidedAt) > t.decisionTTL {
+			delete(t.decided, k)
+		}
+	}
+	buffered := len(t.pending)
+	t.mu.Unlock()
+
+	t.metrics.SetTailSamplingBuffered(buffered)
+	t.flushKept(kept)
+}
+
+// decideLocked evaluates the policies for one trace and records the
+// decision for late spans. Callers hold t.mu.
+func (t *TailSampler) decideLocked(key string, b *tailBatch, now time.Time) bool {
+	policy := t.evaluate(key, b)
+	keep := policy != tailPolicyNone
+	if len(t.decided) < t.maxTraces*2 {
+		t.decided[key] = tailDecision{keep: keep, decidedAt: now}
+	}
+	t.metrics.RecordTailSamplingDecision(keep, policy)
+	return keep
+}
+
+// evaluate returns the first matching policy name, or tailPolicyNone.
+func (t *TailSampler) evaluate(key string, b *tailBatch) string {
+	p := t.policy
+	for _, sp := range b.spans {
+		if p.KeepErrors && sp.Status == "STATUS_CODE_ERROR" {
+			return tailPolicyError
+		}
+	}
+	if p.LatencyThreshold > 0 {
+		for _, sp := range b.spans {
+			if time.Duration(sp.DurationNanos()) >= p.LatencyThreshold {
+				return tailPolicyLatency
+			}
+		}
+	}
+	if len(t.services) > 0 {
+		for _, sp := range b.spans {
+			if t.services[sp.ServiceName] {
+				return tailPolicyService
+			}
+		}
+	}
+	// Hash the tenant/trace key so every replica makes the same call.
+	if p.Rate > 0 {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		if float64(h.Sum32()%10_000) < p.Rate*10_000 {
+			return tailPolicyProbabilistic
+		}
+	}
+	return tailPolicyNone
+}
+
+func (t *TailSampler) flushKept(b *tailBatch) {
+	if len(b.spans) == 0 && len(b.traces) == 0 && len(b.logs) == 0 {
+		return
+	}
+	if t.flush != nil {
+		t.flush(b)
+	}
+}
+
+// flushSampled persists traces the tail sampler kept. There is no client
+// waiting, so pipeline rejections are logged rather than returned.
+func (s *TraceServer) flushSampled(b *tailBatch) {
+	var hasErr, hasSlow bool
+	for _, sp := range b.spans {
+		if sp.Status == "STATUS_CODE_ERROR" {
+			hasErr = true
+		}
+		if s.latencyThresholdMs > 0 && float64(sp.DurationNanos())/1e6 >= s.latencyThresholdMs {
+			hasSlow = true
+		}
+	}
+	reason, err := s.persist(b.traces, b.spans, b.logs, hasErr, hasSlow)
+	switch {
+	case errors.Is(err, ErrQueueFull):
+		slog.Warn("Tail sampling: pipeline full, kept traces dropped", "spans", len(b.spans))
+	case err != nil:
+		slog.Error("Tail sampling: failed to persist kept traces", "error", err)
+	case reason != "":
+		var rejected rejectTally
+		rejected.addSpans(reason, b.spans)
+		rejected.observe(s.metrics, "traces")
+	}
+}
@@ -0,0 +1,159 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// collectFlushes installs a flush func recording every kept trace ID.
+func collectFlushes(ts *TailSampler) func() []string {
+	var mu sync.Mutex
+	var kept []string
+	ts.flush = func(b *tailBatch) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, sp := range b.spans {
+			kept = append(kept, sp.TraceID)
+		}
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), kept...)
+	}
+}
+
+func tailSpan(traceID, service, status string, durUs int64) storage.Span {
+	return storage.Span{TenantID: "default", TraceID: traceID, ServiceName: service, Status: status, Duration: durUs}
+}
+
+func TestTailSampler_Policies(t *testing.T) {
+	ts := NewTailSampler(TailSamplingPolicy{
+		KeepErrors:       true,
+		LatencyThreshold: 500 * time.Millisecond,
+		Services:         []string{"payments"},
+	}, time.Hour, 100, nil)
+	kept := collectFlushes(ts)
+
+	ts.Add(nil, []storage.Span{
+		tailSpan("err", "web", "STATUS_CODE_OK", 100),
+		tailSpan("err", "db", "STATUS_CODE_ERROR", 100), // error deep in the trace
+		tailSpan("slow", "web", "STATUS_CODE_OK", 750_000),
+		tailSpan("pay", "payments", "STATUS_CODE_OK", 100),
+		tailSpan("boring", "web", "STATUS_CODE_OK", 100),
+	}, nil)
+	if got := kept(); len(got) != 0 {
+		t.Fatalf("flushed before the decision window: %v", got)
+	}
+	ts.Stop()
+
+	got := map[string]int{}
+	for _, id := range kept() {
+		got[id]++
+	}
+	want := map[string]int{"err": 2, "slow": 1, "pay": 1}
+	if len(got) != len(want) {
+		t.Fatalf("kept = %v, want %v", got, want)
+	}
+	for id, n := range want {
+		if got[id] != n {
+			t.Errorf("kept[%s] = %d spans, want %d", id, got[id], n)
+		}
+	}
+}
+
+func TestTailSampler_ProbabilisticIsDeterministic(t *testing.T) {
+	ts := NewTailSampler(TailSamplingPolicy{Rate: 0.5}, time.Hour, 100, nil)
+	keptCount := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("default/trace-%d", i)
+		first := ts.evaluate(key, &tailBatch{})
+		if again := ts.evaluate(key, &tailBatch{}); again != first {
+			t.Fatalf("%s: decision flipped %s -> %s", key, first, again)
+		}
+		if first == tailPolicyProbabilistic {
+			keptCount++
+		}
+	}
+	if keptCount < 400 || keptCount > 600 {
+		t.Errorf("kept %d/1000 at rate 0.5", keptCount)
+	}
+}
+
+func TestTailSampler_DecisionWindowAndLateSpans(t *testing.T) {
+	ts := NewTailSampler(TailSamplingPolicy{KeepErrors: true}, 50*time.Millisecond, 100, nil)
+	kept := collectFlushes(ts)
+
+	ts.Add(nil, []storage.Span{tailSpan("t1", "web", "STATUS_CODE_ERROR", 1)}, nil)
+	ts.Add(nil, []storage.Span{tailSpan("t2", "web", "STATUS_CODE_OK", 1)}, nil)
+	ts.decideDue(time.Now().Add(time.Second))
+	if got := kept(); len(got) != 1 || got[0] != "t1" {
+		t.Fatalf("kept after window = %v, want [t1]", got)
+	}
+
+	// Late spans follow the earlier decision instead of starting a new window.
+	ts.Add(nil, []storage.Span{
+		tailSpan("t1", "db", "STATUS_CODE_OK", 1),
+		tailSpan("t2", "db", "STATUS_CODE_OK", 1),
+	}, nil)
+	if got := kept(); len(got) != 2 || got[1] != "t1" {
+		t.Errorf("kept after late spans = %v, want [t1 t1]", got)
+	}
+	ts.mu.Lock()
+	pending := len(ts.pending)
+	ts.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("late spans re-buffered: pending = %d", pending)
+	}
+}
+
+func TestTailSampler_FullBufferDecidesOnArrival(t *testing.T) {
+	ts := NewTailSampler(TailSamplingPolicy{KeepErrors: true}, time.Hour, 1, nil)
+	kept := collectFlushes(ts)
+
+	ts.Add(nil, []storage.Span{tailSpan("a", "web", "STATUS_CODE_OK", 1)}, nil)
+	ts.Add(nil, []storage.Span{tailSpan("b", "web", "STATUS_CODE_ERROR", 1)}, nil)
+	if got := kept(); len(got) != 1 || got[0] != "b" {
+		t.Errorf("kept = %v, want [b] decided on arrival", got)
+	}
+}
+
+// TestTailSampler_TraceServerPersistsKeptTraces runs Export through the tail
+// sampler on the synchronous write path.
+func TestTailSampler_TraceServerPersistsKeptTraces(t *testing.T) {
+	repo := newTestRepo(t)
+	srv := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	ts := NewTailSampler(TailSamplingPolicy{KeepErrors: true}, time.Hour, 100, nil)
+	srv.SetTailSampler(ts)
+
+	req := buildTracesRequest("checkout", 3)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	spans[1].Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR}
+	if _, err := srv.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	var n int64
+	if err := repo.DB().Model(&storage.Span{}).Count(&n).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("spans persisted before the decision: %d", n)
+	}
+
+	ts.Stop()
+	var stored []storage.Span
+	if err := repo.DB().Find(&stored).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(stored) != 1 || stored[0].Status != "STATUS_CODE_ERROR" {
+		t.Errorf("stored = %+v, want only the error trace", stored)
+	}
+}
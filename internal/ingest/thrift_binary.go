@@ -0,0 +1,264 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Minimal reader for the Thrift binary protocol, used by the Jaeger
+// collector's HTTP endpoint (POST /api/traces, application/x-thrift). Wire
+// types are translated to their compact equivalents so the Jaeger decoders
+// are shared with the agent receiver.
+
+// Binary protocol wire types.
+const (
+	btStop   = 0
+	btBool   = 2
+	btByte   = 3
+	btDouble = 4
+	btI16    = 6
+	btI32    = 8
+	btI64    = 10
+	btString = 11
+	btStruct = 12
+	btMap    = 13
+	btSet    = 14
+	btList   = 15
+)
+
+type binaryReader struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+func newBinaryReader(b []byte) *binaryReader {
+	return &binaryReader{buf: b}
+}
+
+// binaryToCompact maps a binary wire type to the compact constant the
+// decoders switch on.
+func binaryToCompact(t byte) (byte, error) {
+	switch t {
+	case btStop:
+		return ctStop, nil
+	case btBool:
+		return ctBoolTrue, nil
+	case btByte:
+		return ctByte, nil
+	case btDouble:
+		return ctDouble, nil
+	case btI16:
+		return ctI16, nil
+	case btI32:
+		return ctI32, nil
+	case btI64:
+		return ctI64, nil
+	case btString:
+		return ctBinary, nil
+	case btStruct:
+		return ctStruct, nil
+	case btMap:
+		return ctMap, nil
+	case btSet:
+		return ctSet, nil
+	case btList:
+		return ctList, nil
+	}
+	return 0, fmt.Errorf("thrift: unknown binary type %d", t)
+}
+
+func (r *binaryReader) next(n int) ([]byte, error) {
+	if n < 0 || n > len(r.buf)-r.pos {
+		return nil, errThriftTruncated
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *binaryReader) readByte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *binaryReader) readI16() (int16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil // #nosec G115 -- bit-for-bit i16
+}
+
+func (r *binaryReader) readI32() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil // #nosec G115 -- bit-for-bit i32
+}
+
+func (r *binaryReader) readI64() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil // #nosec G115 -- bit-for-bit i64
+}
+
+func (r *binaryReader) readDouble() (float64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+func (r *binaryReader) readBinary() ([]byte, error) {
+	n, err := r.readI32()
+	if err != nil {
+		return nil, err
+	}
+	return r.next(int(n))
+}
+
+func (r *binaryReader) readString() (string, error) {
+	b, err := r.readBinary()
+	return string(b), err
+}
+
+func (r *binaryReader) readBool() (bool, error) {
+	b, err := r.readByte()
+	return b != 0, err
+}
+
+// readListBegin returns the element type (as a compact type) and size of a
+// list or set.
+func (r *binaryReader) readListBegin() (elem byte, size int, err error) {
+	t, err := r.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	if elem, err = binaryToCompact(t); err != nil {
+		return 0, 0, err
+	}
+	n, err := r.readI32()
+	if err != nil {
+		return 0, 0, err
+	}
+	// Every element takes at least one byte.
+	if n < 0 || int(n) > len(r.buf)-r.pos {
+		return 0, 0, errThriftTruncated
+	}
+	return elem, int(n), nil
+}
+
+// readStruct iterates a struct's fields, calling fn for each with the field
+// type translated to its compact constant. Unhandled fields are skipped.
+func (r *binaryReader) readStruct(fn func(typ byte, id int16) (handled bool, err error)) error {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		return errors.New("thrift: nesting too deep")
+	}
+	for {
+		t, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if t == btStop {
+			return nil
+		}
+		typ, err := binaryToCompact(t)
+		if err != nil {
+			return err
+		}
+		id, err := r.readI16()
+		if err != nil {
+			return err
+		}
+		handled, err := fn(typ, id)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			if err := r.skip(typ); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skip discards one value of compact type typ.
+func (r *binaryReader) skip(typ byte) error {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		return errors.New("thrift: nesting too deep")
+	}
+	var err error
+	switch typ {
+	case ctBoolTrue, ctBoolFalse, ctByte:
+		_, err = r.next(1)
+	case ctI16:
+		_, err = r.next(2)
+	case ctI32:
+		_, err = r.next(4)
+	case ctI64, ctDouble:
+		_, err = r.next(8)
+	case ctBinary:
+		_, err = r.readBinary()
+	case ctList, ctSet:
+		elem, n, lerr := r.readListBegin()
+		if lerr != nil {
+			return lerr
+		}
+		for range n {
+			if err = r.skip(elem); err != nil {
+				return err
+			}
+		}
+	case ctMap:
+		kt, kerr := r.readByte()
+		if kerr != nil {
+			return kerr
+		}
+		vt, verr := r.readByte()
+		if verr != nil {
+			return verr
+		}
+		k, kerr := binaryToCompact(kt)
+		if kerr != nil {
+			return kerr
+		}
+		v, verr := binaryToCompact(vt)
+		if verr != nil {
+			return verr
+		}
+		n, nerr := r.readI32()
+		if nerr != nil {
+			return nerr
+		}
+		if n < 0 || int(n) > len(r.buf)-r.pos {
+			return errThriftTruncated
+		}
+		for range n {
+			if err = r.skip(k); err != nil {
+				return err
+			}
+			if err = r.skip(v); err != nil {
+				return err
+			}
+		}
+	case ctStruct:
+		err = r.readStruct(func(byte, int16) (bool, error) { return false, nil })
+	default:
+		return fmt.Errorf("thrift: unknown type %d", typ)
+	}
+	return err
+}
@@ -0,0 +1,325 @@
+package ingest
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Minimal reader for the Thrift compact protocol — just enough to decode the
+// Jaeger agent's emitBatch message without pulling in the Apache Thrift
+// runtime. Unknown fields are skipped, so newer agent payloads still decode.
+
+// Compact protocol wire types.
+const (
+	ctStop      = 0x0
+	ctBoolTrue  = 0x1
+	ctBoolFalse = 0x2
+	ctByte      = 0x3
+	ctI16       = 0x4
+	ctI32       = 0x5
+	ctI64       = 0x6
+	ctDouble    = 0x7
+	ctBinary    = 0x8
+	ctList      = 0x9
+	ctSet       = 0xA
+	ctMap       = 0xB
+	ctStruct    = 0xC
+)
+
+const (
+	compactProtocolID = 0x82
+	compactVersion    = 1
+
+	// thriftMaxDepth bounds struct nesting when skipping unknown fields so a
+	// hostile packet cannot recurse the decoder off the stack.
+	thriftMaxDepth = 32
+)
+
+var errThriftTruncated = errors.New("thrift: truncated input")
+
+// thriftReader is what the Jaeger decoders need from a Thrift protocol.
+// Both the compact and binary readers report field and element types as
+// compact wire types (ct*), so one decoder serves both protocols.
+type thriftReader interface {
+	readI32() (int32, error)
+	readI64() (int64, error)
+	readDouble() (float64, error)
+	readBinary() ([]byte, error)
+	readString() (string, error)
+	readBool() (bool, error)
+	readListBegin() (elem byte, size int, err error)
+	readStruct(fn func(typ byte, id int16) (handled bool, err error)) error
+}
+
+type compactReader struct {
+	buf []byte
+	pos int
+
+	lastFieldID []int16 // per-struct stack for delta-encoded field ids
+	boolValue   *bool   // bool carried in the field header
+}
+
+func newCompactReader(b []byte) *compactReader {
+	return &compactReader{buf: b}
+}
+
+func (r *compactReader) readByte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errThriftTruncated
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *compactReader) readVarint() (int64, error) {
+	u, err := r.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil // #nosec G115 -- zigzag decode
+}
+
+func (r *compactReader) readI32() (int32, error) {
+	v, err := r.readVarint()
+	return int32(v), err // #nosec G115 -- compact i32 is a zigzag varint of an i32
+}
+
+func (r *compactReader) readI64() (int64, error) { return r.readVarint() }
+
+func (r *compactReader) readDouble() (float64, error) {
+	if r.pos+8 > len(r.buf) {
+		return 0, errThriftTruncated
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos:]))
+	r.pos += 8
+	return v, nil
+}
+
+func (r *compactReader) readBinary() ([]byte, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, errThriftTruncated
+	}
+	b := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *compactReader) readString() (string, error) {
+	b, err := r.readBinary()
+	return string(b), err
+}
+
+// readBool reads a bool field value (carried in the field header) or, inside
+// a list, a standalone byte.
+func (r *compactReader) readBool() (bool, error) {
+	if r.boolValue != nil {
+		v := *r.boolValue
+		r.boolValue = nil
+		return v, nil
+	}
+	b, err := r.readByte()
+	return b == ctBoolTrue, err
+}
+
+// readMessageBegin consumes the message envelope and returns the method name.
+func (r *compactReader) readMessageBegin() (string, error) {
+	id, err := r.readByte()
+	if err != nil {
+		return "", err
+	}
+	if id != compactProtocolID {
+		return "", fmt.Errorf("thrift: not a compact message (protocol id 0x%x)", id)
+	}
+	vt, err := r.readByte()
+	if err != nil {
+		return "", err
+	}
+	if vt&0x1f != compactVersion {
+		return "", fmt.Errorf("thrift: unsupported compact version %d", vt&0x1f)
+	}
+	if _, err := r.readUvarint(); err != nil { // seqid
+		return "", err
+	}
+	return r.readString()
+}
+
+func (r *compactReader) structBegin() {
+	r.lastFieldID = append(r.lastFieldID, 0)
+}
+
+func (r *compactReader) structEnd() {
+	r.lastFieldID = r.lastFieldID[:len(r.lastFieldID)-1]
+}
+
+// readFieldBegin returns the next field's type and id; typ == ctStop ends the
+// struct.
+func (r *compactReader) readFieldBegin() (typ byte, id int16, err error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	typ = b & 0x0f
+	if typ == ctStop {
+		return ctStop, 0, nil
+	}
+	last := &r.lastFieldID[len(r.lastFieldID)-1]
+	if delta := int16(b >> 4); delta != 0 {
+		id = *last + delta
+	} else {
+		v, err := r.readVarint()
+		if err != nil {
+			return 0, 0, err
+		}
+		id = int16(v) // #nosec G115 -- compact field ids are i16
+	}
+	*last = id
+	switch typ {
+	case ctBoolTrue, ctBoolFalse:
+		v := typ == ctBoolTrue
+		r.boolValue = &v
+	}
+	return typ, id, nil
+}
+
+// readListBegin returns the element type and size of a list or set.
+func (r *compactReader) readListBegin() (elem byte, size int, err error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	elem = b & 0x0f
+	n := uint64(b >> 4)
+	if n == 15 {
+		if n, err = r.readUvarint(); err != nil {
+			return 0, 0, err
+		}
+	}
+	// Every element takes at least one byte; reject sizes the rest of the
+	// buffer cannot hold before the caller allocates for them.
+	if n > uint64(len(r.buf)-r.pos) {
+		return 0, 0, errThriftTruncated
+	}
+	return elem, int(n), nil
+}
+
+// skip discards one value of type typ.
+func (r *compactReader) skip(typ byte, depth int) error {
+	if depth > thriftMaxDepth {
+		return errors.New("thrift: nesting too deep")
+	}
+	var err error
+	switch typ {
+	case ctBoolTrue, ctBoolFalse:
+		_, err = r.readBool()
+	case ctByte:
+		_, err = r.readByte()
+	case ctI16, ctI32, ctI64:
+		_, err = r.readVarint()
+	case ctDouble:
+		_, err = r.readDouble()
+	case ctBinary:
+		_, err = r.readBinary()
+	case ctList, ctSet:
+		elem, n, lerr := r.readListBegin()
+		if lerr != nil {
+			return lerr
+		}
+		for range n {
+			if err = r.skipElem(elem, depth+1); err != nil {
+				return err
+			}
+		}
+	case ctMap:
+		n, merr := r.readUvarint()
+		if merr != nil {
+			return merr
+		}
+		if n == 0 {
+			return nil
+		}
+		kv, kerr := r.readByte()
+		if kerr != nil {
+			return kerr
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return errThriftTruncated
+		}
+		for range n {
+			if err = r.skipElem(kv>>4, depth+1); err != nil {
+				return err
+			}
+			if err = r.skipElem(kv&0x0f, depth+1); err != nil {
+				return err
+			}
+		}
+	case ctStruct:
+		r.structBegin()
+		for {
+			ft, _, ferr := r.readFieldBegin()
+			if ferr != nil {
+				return ferr
+			}
+			if ft == ctStop {
+				break
+			}
+			if err = r.skip(ft, depth+1); err != nil {
+				return err
+			}
+		}
+		r.structEnd()
+	default:
+		return fmt.Errorf("thrift: unknown compact type %d", typ)
+	}
+	return err
+}
+
+// skipElem skips a list/map element. Bools inside containers are a full
+// byte rather than folded into a field header.
+func (r *compactReader) skipElem(typ byte, depth int) error {
+	if typ == ctBoolTrue || typ == ctBoolFalse {
+		_, err := r.readByte()
+		return err
+	}
+	return r.skip(typ, depth)
+}
+
+// readStruct iterates a struct's fields, calling fn for each. fn must consume
+// the field value (or return handled=false to have it skipped).
+func (r *compactReader) readStruct(fn func(typ byte, id int16) (handled bool, err error)) error {
+	r.structBegin()
+	defer r.structEnd()
+	for {
+		typ, id, err := r.readFieldBegin()
+		if err != nil {
+			return err
+		}
+		if typ == ctStop {
+			return nil
+		}
+		handled, err := fn(typ, id)
+		if err != nil {
+			return err
+		}
+		if !handled {
+			if err := r.skip(typ, 0); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+// defaultFinalizeMaxPending caps how many distinct traces the finalizer
+// tracks at once. Past the cap new traces are not tracked (their rows keep
+// the first-span summary until the next backfill) rather than growing the
+// map without bound under a trace-ID storm.
+const defaultFinalizeMaxPending = 200_000
+
+// TraceFinalizer recomputes a trace's row-level Duration/Status/Timestamp
+// from its spans once the trace has gone quiet. The receiver writes the
+// trace row from the first span it sees and later inserts collapse via
+// OnConflict.DoNothing, so a late child that extends the trace — or is the
+// only span to fail — would otherwise never be reflected in trace lists,
+// latency sorts or the service map.
+//
+// Wire Observe into TraceServer.SetSpanCallback so traces are tracked only
+// after their spans have been persisted (sync path or pipeline worker).
+type TraceFinalizer struct {
+	repo        *storage.Repository
+	metrics     *telemetry.Metrics
+	quietPeriod time.Duration
+	interval    time.Duration
+	maxPending  int
+	now         func() time.Time // injectable clock for tests
+
+	mu      sync.Mutex
+	pending map[storage.TraceKey]time.Time // last span seen per trace
+
+	onFinalized func([]storage.TraceKey) // optional, see SetFinalizeCallback
+
+	started atomic.Bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewTraceFinalizer creates a finalizer that recomputes a trace once no span
+// for it has arrived for quietPeriod. The sweep runs every quietPeriod/2
+// (min 1s). metrics may be nil.
+func NewTraceFinalizer(repo *storage.Repository, metrics *telemetry.Metrics, quietPeriod time.Duration) *TraceFinalizer {
+	if quietPeriod <= 0 {
+		quietPeriod = 30 * time.Second
+	}
+	interval := max(quietPeriod/2, time.Second)
+	return &TraceFinalizer{
+		repo:        repo,
+		metrics:     metrics,
+		quietPeriod: quietPeriod,
+		interval:    interval,
+		maxPending:  defaultFinalizeMaxPending,
+		now:         time.Now,
+		pending:     make(map[storage.TraceKey]time.Time),
+		done:        make(chan struct{}),
+	}
+}
+
+// Observe records activity on a trace, pushing its finalization out by
+// another quietPeriod.
+func (f *TraceFinalizer) Observe(tenantID, traceID string) {
+	if traceID == "" {
+		return
+	}
+	key := storage.TraceKey{TenantID: tenantID, TraceID: traceID}
+	now := f.now()
+	f.mu.Lock()
+	if _, ok := f.pending[key]; ok || len(f.pending) < f.maxPending {
+		f.pending[key] = now
+	}
+	f.mu.Unlock()
+}
+
+// SetFinalizeCallback registers fn to receive each batch of traces once they
+// have been recomputed (e.g. to end live trace streams). Call before Start.
+func (f *TraceFinalizer) SetFinalizeCallback(fn func([]storage.TraceKey)) {
+	f.onFinalized = fn
+}
+
+// Pending returns the number of traces awaiting finalization.
+func (f *TraceFinalizer) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.pending)
+}
+
+// Start launches the sweep loop. One-shot lifecycle, same as the storage
+// schedulers: a second Start is a no-op and Start-Stop-Start is unsupported.
+func (f *TraceFinalizer) Start(parent context.Context) {
+	if !f.started.CompareAndSwap(false, true) {
+		return
+	}
+	ctx, cancel := context.WithCancel(parent)
+	f.cancel = cancel
+	go f.loop(ctx)
+}
+
+// Stop cancels the loop and runs a final sweep over every pending trace,
+// quiet or not, so a graceful shutdown doesn't strand corrections. Must be
+// called before the repository is closed.
+func (f *TraceFinalizer) Stop() {
+	if !f.started.Load() {
+		return
+	}
+	f.cancel()
+	<-f.done
+	f.flush(context.Background(), true)
+}
+
+func (f *TraceFinalizer) loop(ctx context.Context) {
+	defer close(f.done)
+	tick := time.NewTicker(f.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			f.flush(ctx, false)
+		}
+	}
+}
+
+// flush recomputes every trace that has been quiet for quietPeriod (or every
+// pending trace when all is set) and returns how many rows were corrected.
+// On a DB error the batch is re-queued so the next sweep retries it.
+func (f *TraceFinalizer) flush(ctx context.Context, all bool) int {
+	cutoff := f.now().Add(-f.quietPeriod)
+	var keys []storage.TraceKey
+	f.mu.Lock()
+	for k, last := range f.pending {
+		if all || !last.After(cutoff) {
+			keys = append(keys, k)
+			delete(f.pending, k)
+		}
+	}
+	f.mu.Unlock()
+	if len(keys) == 0 {
+		return 0
+	}
+
+	n, err := f.repo.RecomputeTraceSummaries(ctx, keys)
+	f.metrics.RecordTracesRecomputed("finalize", n)
+	if err != nil {
+		slog.Warn("⚠️ [TRACES] Finalization failed, will retry", "traces", len(keys), "error", err)
+		if !all {
+			now := f.now()
+			f.mu.Lock()
+			for _, k := range keys {
+				if _, ok := f.pending[k]; !ok {
+					f.pending[k] = now.Add(-f.quietPeriod)
+				}
+			}
+			f.mu.Unlock()
+		}
+		return n
+	}
+	if n > 0 {
+		slog.Debug("🧮 [TRACES] Finalized traces", "checked", len(keys), "corrected", n)
+	}
+	if f.onFinalized != nil {
+		f.onFinalized(keys)
+	}
+	return n
+}
@@ -0,0 +1,82 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+)
+
+// TestTraceFinalizer_LateSpanCorrectsDuration drives two Exports for the same
+// trace — the second carrying a child that outlives the root — and verifies
+// the finalizer widens the trace row once the trace goes quiet.
+func TestTraceFinalizer_LateSpanCorrectsDuration(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	now := time.Now()
+	fin := NewTraceFinalizer(repo, nil, 30*time.Second)
+	fin.now = func() time.Time { return now }
+	var finalized []storage.TraceKey
+	fin.SetFinalizeCallback(func(keys []storage.TraceKey) { finalized = append(finalized, keys...) })
+	traces.SetSpanCallback(func(sp storage.Span) { fin.Observe(sp.TenantID, sp.TraceID) })
+
+	first := buildTracesRequest("svc", 1)
+	late := buildTracesRequest("svc", 1)
+	lateSpan := late.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	lateSpan.SpanId = []byte{9, 9, 9, 9, 9, 9, 9, 9}
+	lateSpan.StartTimeUnixNano = first.ResourceSpans[0].ScopeSpans[0].Spans[0].StartTimeUnixNano
+	lateSpan.EndTimeUnixNano = lateSpan.StartTimeUnixNano + uint64(time.Second)
+
+	for _, req := range []*coltracepb.ExportTraceServiceRequest{first, late} {
+		if _, err := traces.Export(context.Background(), req); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+	}
+	if got := fin.Pending(); got != 1 {
+		t.Fatalf("Pending = %d, want 1", got)
+	}
+
+	// Not yet quiet: nothing is recomputed.
+	if n := fin.flush(context.Background(), false); n != 0 || len(finalized) != 0 {
+		t.Fatalf("flush before quiet period corrected %d rows, finalized %v", n, finalized)
+	}
+
+	now = now.Add(31 * time.Second)
+	if n := fin.flush(context.Background(), false); n != 1 {
+		t.Fatalf("flush corrected %d rows, want 1", n)
+	}
+	if got := fin.Pending(); got != 0 {
+		t.Errorf("Pending after flush = %d, want 0", got)
+	}
+	if len(finalized) != 1 || finalized[0].TenantID != storage.DefaultTenantID {
+		t.Errorf("finalize callback got %v, want the one trace", finalized)
+	}
+
+	resp, err := repo.GetTracesFiltered(context.Background(), time.Time{}, time.Time{}, nil, "", "", 10, 0, "", "")
+	if err != nil {
+		t.Fatalf("GetTracesFiltered: %v", err)
+	}
+	if len(resp.Traces) != 1 {
+		t.Fatalf("traces = %d, want 1", len(resp.Traces))
+	}
+	if want := time.Second.Microseconds(); resp.Traces[0].Duration != want {
+		t.Errorf("Duration = %d, want %d", resp.Traces[0].Duration, want)
+	}
+}
+
+func TestTraceFinalizer_MaxPendingCap(t *testing.T) {
+	fin := NewTraceFinalizer(nil, nil, time.Second)
+	fin.maxPending = 2
+	fin.Observe("t", "a")
+	fin.Observe("t", "b")
+	fin.Observe("t", "c") // over cap: dropped
+	fin.Observe("t", "a") // already tracked: refreshed
+	fin.Observe("t", "")  // ignored
+	if got := fin.Pending(); got != 2 {
+		t.Errorf("Pending = %d, want 2", got)
+	}
+}
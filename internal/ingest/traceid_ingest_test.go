@@ -0,0 +1,38 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// TestTraceServer_Pads64BitTraceIDs verifies a legacy 8-byte trace ID is
+// stored in the canonical 128-bit form on the span and trace rows, and is
+// reachable by its short spelling.
+func TestTraceServer_Pads64BitTraceIDs(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+
+	var stored []storage.Span
+	traces.SetSpanCallback(func(sp storage.Span) { stored = append(stored, sp) })
+
+	req := buildTracesRequest("legacy-svc", 1)
+	req.ResourceSpans[0].ScopeSpans[0].Spans[0].TraceId = []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6}
+	if _, err := traces.Export(context.Background(), req); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	const canonical = "00000000000000004bf92f3577b34da6"
+	if len(stored) != 1 || stored[0].TraceID != canonical {
+		t.Fatalf("stored spans = %+v, want trace id %s", stored, canonical)
+	}
+	tr, err := repo.GetTrace(context.Background(), "4bf92f3577b34da6")
+	if err != nil {
+		t.Fatalf("GetTrace by short id: %v", err)
+	}
+	if tr.TraceID != canonical {
+		t.Errorf("trace row id = %q, want %q", tr.TraceID, canonical)
+	}
+}
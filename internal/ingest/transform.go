@@ -0,0 +1,351 @@
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// TransformConfig is the transform file: an ordered chain of attribute
+// processors.
+//
+//	processors:
+//	  - service: legacy-api
+//	    rename: {http.status: http.response.status_code}
+//	  - set: {deployment.environment: prod, cloud.region: eu-west-1}
+//	  - drop: [http.user_agent, "debug.*"]
+//	  - derive: {from: http.response.status_code, to: http.status_class, function: status_class}
+//	  - signals: [traces]
+//	    derive: {from: url.full, to: url.path, pattern: "^https?://[^/]+([^?#]*)"}
+type TransformConfig struct {
+	Processors []TransformProcessor `yaml:"processors"`
+}
+
+// TransformProcessor is one step of the chain. It applies to span and log
+// record attributes of its service and signals, and does exactly one of:
+// Rename (old key → new key; the new key's previous value is replaced),
+// Set (upsert static values), Drop (keys, or prefixes ending in "*") or
+// Derive.
+type TransformProcessor struct {
+	Name    string            `yaml:"name"`    // metric label; defaults to the operation and index ("rename_0")
+	Service string            `yaml:"service"` // exact service.name; "" or "*" = every service
+	Signals []string          `yaml:"signals"` // traces | logs; empty = both
+	Rename  map[string]string `yaml:"rename"`
+	Set     map[string]string `yaml:"set"`
+	Drop    []string          `yaml:"drop"`
+	Derive  *TransformDerive  `yaml:"derive"`
+}
+
+// TransformDerive sets To from the scalar value of From, through exactly
+// one of Function and Pattern. Function is status_class ("404" → "4xx"),
+// lower or upper. Pattern is a Go regular expression; its first capture
+// group, or the whole match when it has none, becomes the value. Nothing
+// is set when From is absent or does not yield a value.
+type TransformDerive struct {
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	Function string `yaml:"function"`
+	Pattern  string `yaml:"pattern"`
+}
+
+type transformOp int
+
+const (
+	opRename transformOp = iota
+	opSet
+	opDrop
+	opDerive
+)
+
+type transformStep struct {
+	label   string
+	service string // "" = every service
+	traces  bool
+	logs    bool
+	op      transformOp
+	pairs   [][2]string // rename: from/to; set: key/value — sorted for a stable order
+	drop    []string
+	derive  func(string) (string, bool)
+	from    string
+	to      string
+}
+
+// Transformer normalizes span and log record attributes from
+// heterogeneous services at ingest: renames, static tags, dropped noise
+// and derived fields. Steps run in file order after validation and the
+// health-check filter, and before the span rewrite rules, so those rules
+// and everything stored see the normalized attributes; a later step sees
+// the output of earlier ones. Resource attributes are not touched — only
+// service.name is stored from them. Nil Transformer = attributes stored
+// as sent.
+//
+// Immutable after construction; safe for concurrent use.
+type Transformer struct {
+	steps   []transformStep
+	metrics *telemetry.Metrics
+}
+
+// NewTransformer compiles cfg. Returns an error naming the first invalid
+// processor (not exactly one operation, unknown signal or function, bad
+// pattern). Returns nil for no processors. metrics may be nil.
+func NewTransformer(cfg TransformConfig, metrics *telemetry.Metrics) (*Transformer, error) {
+	if len(cfg.Processors) == 0 {
+		return nil, nil
+	}
+	t := &Transformer{steps: make([]transformStep, 0, len(cfg.Processors)), metrics: metrics}
+	for i, p := range cfg.Processors {
+		st := transformStep{service: p.Service}
+		if st.service == "*" {
+			st.service = ""
+		}
+		if len(p.Signals) == 0 {
+			st.traces, st.logs = true, true
+		}
+		for _, sig := range p.Signals {
+			switch sig {
+			case "traces":
+				st.traces = true
+			case "logs":
+				st.logs = true
+			default:
+				return nil, fmt.Errorf("transform processor %d: unknown signal %q (want traces or logs)", i, sig)
+			}
+		}
+
+		ops := 0
+		var name string
+		if len(p.Rename) > 0 {
+			ops++
+			st.op, name, st.pairs = opRename, "rename", sortedPairs(p.Rename)
+		}
+		if len(p.Set) > 0 {
+			ops++
+			st.op, name, st.pairs = opSet, "set", sortedPairs(p.Set)
+		}
+		if len(p.Drop) > 0 {
+			ops++
+			st.op, name, st.drop = opDrop, "drop", p.Drop
+		}
+		if p.Derive != nil {
+			ops++
+			st.op, name = opDerive, "derive"
+			fn, err := compileDerive(*p.Derive)
+			if err != nil {
+				return nil, fmt.Errorf("transform processor %d: %w", i, err)
+			}
+			st.derive, st.from, st.to = fn, p.Derive.From, p.Derive.To
+		}
+		if ops != 1 {
+			return nil, fmt.Errorf("transform processor %d: set exactly one of rename, set, drop and derive", i)
+		}
+		st.label = p.Name
+		if st.label == "" {
+			st.label = name + "_" + strconv.Itoa(i)
+		}
+		t.steps = append(t.steps, st)
+	}
+	return t, nil
+}
+
+func compileDerive(d TransformDerive) (func(string) (string, bool), error) {
+	if d.From == "" || d.To == "" {
+		return nil, errors.New("derive needs from and to")
+	}
+	switch {
+	case d.Function != "" && d.Pattern != "":
+		return nil, errors.New("derive: set function or pattern, not both")
+	case d.Pattern != "":
+		re, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("derive: %w", err)
+		}
+		return func(v string) (string, bool) {
+			m := re.FindStringSubmatch(v)
+			switch {
+			case m == nil:
+				return "", false
+			case len(m) > 1:
+				return m[1], m[1] != ""
+			}
+			return m[0], m[0] != ""
+		}, nil
+	}
+	switch d.Function {
+	case "status_class":
+		return statusClass, nil
+	case "lower":
+		return func(v string) (string, bool) { return strings.ToLower(v), true }, nil
+	case "upper":
+		return func(v string) (string, bool) { return strings.ToUpper(v), true }, nil
+	case "":
+		return nil, errors.New("derive needs function or pattern")
+	}
+	return nil, fmt.Errorf("derive: unknown function %q (want status_class, lower or upper)", d.Function)
+}
+
+// statusClass maps an HTTP status code to its class: "503" → "5xx".
+func statusClass(v string) (string, bool) {
+	code, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || code < 100 || code > 599 {
+		return "", false
+	}
+	return strconv.Itoa(code/100) + "xx", true
+}
+
+// LoadTransformer reads a YAML TransformConfig from path. Unknown fields
+// are rejected so a typo cannot silently disable a step. An empty path
+// disables transformation (nil, nil).
+func LoadTransformer(path string, metrics *telemetry.Metrics) (*Transformer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied config path
+	if err != nil {
+		return nil, fmt.Errorf("transform file %q: %w", path, err)
+	}
+	var cfg TransformConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("transform file %q: %w", path, err)
+	}
+	if len(cfg.Processors) == 0 {
+		return nil, fmt.Errorf("transform file %q: no processors found", path)
+	}
+	t, err := NewTransformer(cfg, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("transform file %q: %w", path, err)
+	}
+	return t, nil
+}
+
+// Processors returns the number of compiled steps.
+func (t *Transformer) Processors() int { return len(t.steps) }
+
+// apply runs every step for signal ("traces" or "logs") and service over
+// attrs and returns the result with the labels of the steps that changed
+// it. Changes are counted unless count is false (dry runs).
+func (t *Transformer) apply(signal, service string, attrs []*commonpb.KeyValue, count bool) ([]*commonpb.KeyValue, []string) {
+	var changed []string
+	for i := range t.steps {
+		st := &t.steps[i]
+		if st.service != "" && st.service != service {
+			continue
+		}
+		if (signal == "traces" && !st.traces) || (signal == "logs" && !st.logs) {
+			continue
+		}
+		var hit bool
+		attrs, hit = st.run(attrs)
+		if hit {
+			changed = append(changed, st.label)
+			if count {
+				t.metrics.RecordTransform(signal, st.label)
+			}
+		}
+	}
+	return attrs, changed
+}
+
+func (st *transformStep) run(attrs []*commonpb.KeyValue) ([]*commonpb.KeyValue, bool) {
+	hit := false
+	switch st.op {
+	case opRename:
+		for _, p := range st.pairs {
+			i := attrIndex(attrs, p[0])
+			if i < 0 {
+				continue
+			}
+			if j := attrIndex(attrs, p[1]); j >= 0 && j != i {
+				attrs = append(attrs[:j], attrs[j+1:]...)
+				i = attrIndex(attrs, p[0])
+			}
+			attrs[i].Key = p[1]
+			hit = true
+		}
+	case opSet:
+		for _, p := range st.pairs {
+			var ok bool
+			if attrs, ok = upsertAttr(attrs, p[0], p[1]); ok {
+				hit = true
+			}
+		}
+	case opDrop:
+		kept := attrs[:0]
+		for _, kv := range attrs {
+			if st.drops(kv.Key) {
+				hit = true
+				continue
+			}
+			kept = append(kept, kv)
+		}
+		attrs = kept
+	case opDerive:
+		i := attrIndex(attrs, st.from)
+		if i < 0 {
+			break
+		}
+		src, ok := scalarString(attrs[i].Value)
+		if !ok {
+			break
+		}
+		if v, ok := st.derive(src); ok {
+			attrs, hit = upsertAttr(attrs, st.to, v)
+		}
+	}
+	return attrs, hit
+}
+
+func (st *transformStep) drops(key string) bool {
+	for _, d := range st.drop {
+		if p, ok := strings.CutSuffix(d, "*"); ok {
+			if strings.HasPrefix(key, p) {
+				return true
+			}
+		} else if key == d {
+			return true
+		}
+	}
+	return false
+}
+
+func attrIndex(attrs []*commonpb.KeyValue, key string) int {
+	for i, kv := range attrs {
+		if kv.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// upsertAttr sets key to the string value, reporting whether that changed
+// anything.
+func upsertAttr(attrs []*commonpb.KeyValue, key, value string) ([]*commonpb.KeyValue, bool) {
+	if i := attrIndex(attrs, key); i >= 0 {
+		if cur, ok := attrs[i].Value.GetValue().(*commonpb.AnyValue_StringValue); ok && cur.StringValue == value {
+			return attrs, false
+		}
+		attrs[i].Value = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}
+		return attrs, true
+	}
+	return append(attrs, &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}), true
+}
+
+func sortedPairs(m map[string]string) [][2]string {
+	out := make([][2]string, 0, len(m))
+	for k, v := range m {
+		out = append(out, [2]string{k, v})
+	}
+	slices.SortFunc(out, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+	return out
+}
@@ -0,0 +1,167 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+func attrMap(attrs []*commonpb.KeyValue) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for _, kv := range attrs {
+		out[kv.Key], _ = scalarString(kv.Value)
+	}
+	return out
+}
+
+func TestTransformer_Chain(t *testing.T) {
+	tf, err := NewTransformer(TransformConfig{Processors: []TransformProcessor{
+		{Service: "legacy-api", Rename: map[string]string{"http.status": "http.response.status_code"}},
+		{Set: map[string]string{"deployment.environment": "prod"}},
+		{Drop: []string{"http.user_agent", "debug.*"}},
+		{Name: "status_class", Derive: &TransformDerive{From: "http.response.status_code", To: "http.status_class", Function: "status_class"}},
+		{Signals: []string{"logs"}, Derive: &TransformDerive{From: "url.full", To: "url.path", Pattern: `^https?://[^/]+([^?#]*)`}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("NewTransformer: %v", err)
+	}
+
+	attrs := []*commonpb.KeyValue{
+		intAttr("http.status", 503),
+		strAttr("http.response.status_code", "stale"),
+		strAttr("http.user_agent", "curl/8"),
+		strAttr("debug.trace", "x"),
+		strAttr("url.full", "https://shop.example/cart/42?coupon=1"),
+	}
+	attrs, changed := tf.apply("traces", "legacy-api", attrs, false)
+	want := map[string]string{
+		"http.response.status_code": "503",
+		"deployment.environment":    "prod",
+		"http.status_class":         "5xx",
+		"url.full":                  "https://shop.example/cart/42?coupon=1",
+	}
+	got := attrMap(attrs)
+	if len(got) != len(want) {
+		t.Errorf("attributes = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(changed) != 4 || changed[3] != "status_class" {
+		t.Errorf("changed = %v, want rename_0, set_1, drop_2, status_class", changed)
+	}
+
+	// Other services skip the rename; the logs-only derive runs for logs.
+	attrs, _ = tf.apply("logs", "checkout", []*commonpb.KeyValue{
+		intAttr("http.status", 404),
+		strAttr("url.full", "http://api/v1/items?id=3"),
+	}, false)
+	got = attrMap(attrs)
+	if got["http.status"] != "404" || got["http.status_class"] != "" || got["url.path"] != "/v1/items" {
+		t.Errorf("checkout log attributes = %v", got)
+	}
+
+	// A second pass over normalized attributes changes nothing.
+	if _, changed := tf.apply("traces", "legacy-api", []*commonpb.KeyValue{strAttr("deployment.environment", "prod")}, false); len(changed) != 0 {
+		t.Errorf("no-op pass changed = %v", changed)
+	}
+}
+
+func TestNewTransformer_Invalid(t *testing.T) {
+	cases := map[string]TransformProcessor{
+		"no operation":         {Service: "svc"},
+		"two operations":       {Set: map[string]string{"a": "b"}, Drop: []string{"c"}},
+		"unknown signal":       {Signals: []string{"metrics"}, Drop: []string{"c"}},
+		"derive without to":    {Derive: &TransformDerive{From: "a", Function: "lower"}},
+		"unknown function":     {Derive: &TransformDerive{From: "a", To: "b", Function: "reverse"}},
+		"bad pattern":          {Derive: &TransformDerive{From: "a", To: "b", Pattern: "("}},
+		"function and pattern": {Derive: &TransformDerive{From: "a", To: "b", Function: "lower", Pattern: "x"}},
+	}
+	for name, p := range cases {
+		if _, err := NewTransformer(TransformConfig{Processors: []TransformProcessor{p}}, nil); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadTransformer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transform.yaml")
+	write := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("processors:\n  - set: {cloud.region: eu-west-1}\n  - drop: [http.user_agent]\n")
+	tf, err := LoadTransformer(path, nil)
+	if err != nil || tf.Processors() != 2 {
+		t.Fatalf("LoadTransformer = %v, %v; want 2 processors", tf, err)
+	}
+
+	write("processors:\n  - sett: {cloud.region: eu-west-1}\n")
+	if _, err := LoadTransformer(path, nil); err == nil {
+		t.Error("unknown field should fail")
+	}
+	write("")
+	if _, err := LoadTransformer(path, nil); err == nil {
+		t.Error("empty file should fail")
+	}
+	if tf, err := LoadTransformer("", nil); tf != nil || err != nil {
+		t.Errorf("empty path = %v, %v; want disabled", tf, err)
+	}
+}
+
+// TestTransformer_ExportStoresTransformedAttributes verifies stored spans
+// and logs carry the transformed attributes.
+func TestTransformer_ExportStoresTransformedAttributes(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &config.Config{IngestMinSeverity: "DEBUG"}
+	tf, err := NewTransformer(TransformConfig{Processors: []TransformProcessor{
+		{Set: map[string]string{"cloud.region": "eu-west-1"}},
+		{Drop: []string{"http.user_agent"}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("NewTransformer: %v", err)
+	}
+	traces := NewTraceServer(repo, nil, cfg)
+	traces.SetTransformer(tf)
+	logs := NewLogsServer(repo, nil, cfg)
+	logs.SetTransformer(tf)
+
+	var mu sync.Mutex
+	var spans []storage.Span
+	var logRows []storage.Log
+	traces.SetSpanCallback(func(s storage.Span) { mu.Lock(); spans = append(spans, s); mu.Unlock() })
+	logs.SetLogCallback(func(l storage.Log) { mu.Lock(); logRows = append(logRows, l); mu.Unlock() })
+
+	treq := buildTracesRequest("web", 1)
+	treq.ResourceSpans[0].ScopeSpans[0].Spans[0].Attributes = []*commonpb.KeyValue{strAttr("http.user_agent", "curl/8")}
+	if _, err := traces.Export(context.Background(), treq); err != nil {
+		t.Fatalf("trace Export: %v", err)
+	}
+	if _, err := logs.Export(context.Background(), buildLogsRequest("web", 1)); err != nil {
+		t.Fatalf("logs Export: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	transformed := func(attrs storage.CompressedText) bool {
+		s := string(attrs)
+		return strings.Contains(s, "cloud.region") && strings.Contains(s, "eu-west-1") && !strings.Contains(s, "curl")
+	}
+	if len(spans) != 1 || !transformed(spans[0].AttributesJSON) {
+		t.Errorf("stored span attributes = %v", spans)
+	}
+	if len(logRows) != 1 || !transformed(logRows[0].AttributesJSON) {
+		t.Errorf("stored log attributes = %v", logRows)
+	}
+}
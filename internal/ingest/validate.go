@@ -0,0 +1,152 @@
+package ingest
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Validation reject reasons. Reported through the same partial_success /
+// otelcontext_ingest_rejected_total{reason} channel as filter and
+// backpressure rejections, so one dashboard covers every way a record can
+// be refused.
+const (
+	rejectInvalidTraceID   = "invalid_trace_id"
+	rejectInvalidSpanID    = "invalid_span_id"
+	rejectMissingTimestamp = "missing_timestamp"
+	rejectEndBeforeStart   = "end_before_start"
+	rejectFutureTimestamp  = "future_timestamp"
+)
+
+// defaultMaxFutureSkew bounds how far ahead of the receiver's clock a record
+// may be stamped before it is treated as garbage. Generous enough to absorb
+// ordinary NTP drift between producers and the receiver.
+const defaultMaxFutureSkew = 10 * time.Minute
+
+// rejectLogInterval is the minimum gap between two "rejected record" log
+// lines for the same (signal, reason). A broken SDK can emit thousands of
+// bad spans a second; one line per interval plus a suppressed count is
+// enough to find the producer without flooding the process log.
+const rejectLogInterval = 10 * time.Second
+
+// Validator is the optional strict-validation stage for incoming telemetry.
+// It rejects spans and log records whose data is impossible — end before
+// start, timestamps far in the future, missing or malformed IDs — so they
+// never reach the DB or skew latency aggregates. Enabled via
+// INGEST_STRICT_VALIDATION=true; nil Validator = no validation (legacy).
+//
+// Safe for concurrent use by the Export goroutines.
+type Validator struct {
+	maxFutureSkew time.Duration
+	now           func() time.Time // injectable clock for tests
+
+	mu         sync.Mutex
+	lastLogged map[string]time.Time
+	suppressed map[string]int64
+}
+
+// NewValidator creates a Validator. maxFutureSkew <= 0 falls back to
+// defaultMaxFutureSkew.
+func NewValidator(maxFutureSkew time.Duration) *Validator {
+	if maxFutureSkew <= 0 {
+		maxFutureSkew = defaultMaxFutureSkew
+	}
+	return &Validator{
+		maxFutureSkew: maxFutureSkew,
+		now:           time.Now,
+		lastLogged:    make(map[string]time.Time),
+		suppressed:    make(map[string]int64),
+	}
+}
+
+// checkSpan returns the reject reason for span, or "" when it is valid.
+// Checks run cheapest-first and stop at the first failure. Trace ID length
+// rules (128-bit, or 64-bit when allowed) come from the traceid codec.
+func (v *Validator) checkSpan(span *tracepb.Span) string {
+	if _, ok := traceid.Encode(span.TraceId); !ok {
+		return rejectInvalidTraceID
+	}
+	if !validID(span.SpanId, 8) {
+		return rejectInvalidSpanID
+	}
+	if span.StartTimeUnixNano == 0 || span.EndTimeUnixNano == 0 {
+		return rejectMissingTimestamp
+	}
+	if span.EndTimeUnixNano < span.StartTimeUnixNano {
+		return rejectEndBeforeStart
+	}
+	if v.inFuture(span.StartTimeUnixNano) {
+		return rejectFutureTimestamp
+	}
+	return ""
+}
+
+// checkLog returns the reject reason for a log record, or "" when valid.
+// Trace/span IDs are optional on logs (uncorrelated logs are normal), so
+// they are only rejected when present but malformed. A zero timestamp is
+// also allowed — LogsServer substitutes the receive time.
+func (v *Validator) checkLog(l *logspb.LogRecord) string {
+	if _, ok := traceid.Encode(l.TraceId); len(l.TraceId) > 0 && !ok {
+		return rejectInvalidTraceID
+	}
+	if len(l.SpanId) > 0 && !validID(l.SpanId, 8) {
+		return rejectInvalidSpanID
+	}
+	if l.TimeUnixNano != 0 && v.inFuture(l.TimeUnixNano) {
+		return rejectFutureTimestamp
+	}
+	return ""
+}
+
+// inFuture reports whether a nanosecond OTLP timestamp lies beyond the
+// allowed skew from the validator's clock.
+func (v *Validator) inFuture(unixNano uint64) bool {
+	limit := v.now().Add(v.maxFutureSkew).UnixNano()
+	return unixNano > uint64(limit) // #nosec G115 -- limit is a positive wall-clock nanosecond value
+}
+
+// logReject emits a sampled warning for a rejected record: at most one line
+// per (signal, reason) every rejectLogInterval, carrying the number of
+// rejects suppressed since the previous line.
+func (v *Validator) logReject(signal, reason, service string, traceID []byte) {
+	key := signal + "/" + reason
+	now := v.now()
+
+	v.mu.Lock()
+	if last, ok := v.lastLogged[key]; ok && now.Sub(last) < rejectLogInterval {
+		v.suppressed[key]++
+		v.mu.Unlock()
+		return
+	}
+	suppressed := v.suppressed[key]
+	v.lastLogged[key] = now
+	v.suppressed[key] = 0
+	v.mu.Unlock()
+
+	slog.Warn("🚫 [VALIDATION] Rejected record",
+		"signal", signal,
+		"reason", reason,
+		"service", service,
+		"trace_id", fmt.Sprintf("%x", traceID),
+		"suppressed_since_last", suppressed,
+	)
+}
+
+// validID reports whether id has the OTLP-mandated byte length and is not
+// all zeroes (the spec's "invalid" sentinel).
+func validID(id []byte, size int) bool {
+	if len(id) != size {
+		return false
+	}
+	for _, b := range id {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
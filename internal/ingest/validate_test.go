@@ -0,0 +1,126 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func fixedValidator(now time.Time) *Validator {
+	v := NewValidator(10 * time.Minute)
+	v.now = func() time.Time { return now }
+	return v
+}
+
+func TestValidator_CheckSpan(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := fixedValidator(now)
+	start := uint64(now.UnixNano())
+	good := func() *tracepb.Span {
+		return &tracepb.Span{
+			TraceId:           bytes.Repeat([]byte{0xAB}, 16),
+			SpanId:            bytes.Repeat([]byte{0xCD}, 8),
+			StartTimeUnixNano: start,
+			EndTimeUnixNano:   start + uint64(time.Millisecond),
+		}
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(*tracepb.Span)
+		want   string
+	}{
+		{"valid", func(*tracepb.Span) {}, ""},
+		{"empty trace id", func(s *tracepb.Span) { s.TraceId = nil }, rejectInvalidTraceID},
+		{"zero trace id", func(s *tracepb.Span) { s.TraceId = make([]byte, 16) }, rejectInvalidTraceID},
+		{"legacy 64-bit trace id", func(s *tracepb.Span) { s.TraceId = bytes.Repeat([]byte{0xCD}, 8) }, ""},
+		{"odd-length trace id", func(s *tracepb.Span) { s.TraceId = bytes.Repeat([]byte{0xCD}, 12) }, rejectInvalidTraceID},
+		{"short span id", func(s *tracepb.Span) { s.SpanId = []byte{1, 2, 3} }, rejectInvalidSpanID},
+		{"missing end", func(s *tracepb.Span) { s.EndTimeUnixNano = 0 }, rejectMissingTimestamp},
+		{"end before start", func(s *tracepb.Span) { s.EndTimeUnixNano = start - 1 }, rejectEndBeforeStart},
+		{"within skew", func(s *tracepb.Span) {
+			s.StartTimeUnixNano = start + uint64(5*time.Minute)
+			s.EndTimeUnixNano = s.StartTimeUnixNano
+		}, ""},
+		{"far future", func(s *tracepb.Span) {
+			s.StartTimeUnixNano = start + uint64(time.Hour)
+			s.EndTimeUnixNano = s.StartTimeUnixNano
+		}, rejectFutureTimestamp},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			span := good()
+			tc.mutate(span)
+			if got := v.checkSpan(span); got != tc.want {
+				t.Errorf("checkSpan = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestValidator_CheckLogAllowsUncorrelated guards against rejecting the
+// common case of logs emitted outside any span.
+func TestValidator_CheckLogAllowsUncorrelated(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := fixedValidator(now)
+
+	if got := v.checkLog(&logspb.LogRecord{}); got != "" {
+		t.Errorf("uncorrelated log rejected: %q", got)
+	}
+	if got := v.checkLog(&logspb.LogRecord{TraceId: make([]byte, 16)}); got != rejectInvalidTraceID {
+		t.Errorf("zero trace id: got %q, want %q", got, rejectInvalidTraceID)
+	}
+	future := uint64(now.Add(time.Hour).UnixNano())
+	if got := v.checkLog(&logspb.LogRecord{TimeUnixNano: future}); got != rejectFutureTimestamp {
+		t.Errorf("future log: got %q, want %q", got, rejectFutureTimestamp)
+	}
+}
+
+// TestValidator_ExportReportsRejectedSpans verifies invalid spans are
+// dropped before persistence and surfaced through partial_success.
+func TestValidator_ExportReportsRejectedSpans(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	traces.SetValidator(NewValidator(0))
+
+	req := buildTracesRequest("svc", 4)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	spans[0].EndTimeUnixNano = spans[0].StartTimeUnixNano - 1
+	spans[1].TraceId = nil
+
+	resp, err := traces.Export(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if got := resp.GetPartialSuccess().GetRejectedSpans(); got != 2 {
+		t.Errorf("RejectedSpans = %d, want 2", got)
+	}
+	if got := countSpans(t, repo); got != 2 {
+		t.Errorf("persisted spans = %d, want 2", got)
+	}
+}
+
+func TestValidator_LogRejectIsSampled(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	v := NewValidator(0)
+	v.now = func() time.Time { return now }
+	key := "traces/" + rejectEndBeforeStart
+
+	for i := 0; i < 5; i++ {
+		v.logReject("traces", rejectEndBeforeStart, "svc", nil)
+	}
+	if got := v.suppressed[key]; got != 4 {
+		t.Fatalf("suppressed = %d, want 4", got)
+	}
+
+	now = now.Add(rejectLogInterval)
+	v.logReject("traces", rejectEndBeforeStart, "svc", nil)
+	if got := v.suppressed[key]; got != 0 {
+		t.Errorf("suppressed after interval = %d, want 0 (reset on emit)", got)
+	}
+}
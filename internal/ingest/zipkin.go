@@ -0,0 +1,288 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// zipkinMaxBody caps a POST /api/v2/spans body on the wire; gzipped bodies
+// are additionally capped at maxDecompressedBody once inflated.
+const zipkinMaxBody = 4 << 20
+
+// zipkinEndpoint is a Zipkin v2 Endpoint.
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+// zipkinSpan is a Zipkin v2 JSON span (zipkin2.Span as reported by Brave).
+type zipkinSpan struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	Timestamp      int64             `json:"timestamp"` // epoch micros
+	Duration       int64             `json:"duration"`  // micros
+	Shared         bool              `json:"shared"`
+	LocalEndpoint  *zipkinEndpoint   `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint   `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnot     `json:"annotations"`
+	Tags           map[string]string `json:"tags"`
+}
+
+type zipkinAnnot struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// ZipkinReceiver accepts Zipkin v2 JSON spans (POST /api/v2/spans, the
+// default port 9411), so Brave / Spring Cloud Sleuth services can report
+// directly. Spans are converted to OTLP, grouped by local service, and handed
+// to TraceServer.Export. The protobuf encoding and the v1 API are not served.
+type ZipkinReceiver struct {
+	traces  *TraceServer
+	metrics *telemetry.Metrics
+
+	srv     *http.Server
+	lis     net.Listener
+	stopped atomic.Bool
+}
+
+// NewZipkinReceiver creates a receiver that forwards into traces. metrics
+// may be nil.
+func NewZipkinReceiver(traces *TraceServer, metrics *telemetry.Metrics) *ZipkinReceiver {
+	return &ZipkinReceiver{traces: traces, metrics: metrics}
+}
+
+// Start binds addr (e.g. ":9411") and serves POST /api/v2/spans.
+func (z *ZipkinReceiver) Start(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("zipkin: listen %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v2/spans", z.handleSpans)
+	z.lis = lis
+	z.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	go func() {
+		if err := z.srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Zipkin: HTTP server failed", "error", err)
+		}
+	}()
+	slog.Info("📡 Zipkin receiver started (v2 JSON)", "addr", lis.Addr().String())
+	return nil
+}
+
+// Addr returns the bound address, or nil before Start.
+func (z *ZipkinReceiver) Addr() net.Addr {
+	if z.lis == nil {
+		return nil
+	}
+	return z.lis.Addr()
+}
+
+// Stop drains in-flight requests.
+func (z *ZipkinReceiver) Stop(ctx context.Context) {
+	if z.srv == nil || !z.stopped.CompareAndSwap(false, true) {
+		return
+	}
+	if err := z.srv.Shutdown(ctx); err != nil {
+		slog.Warn("Zipkin: HTTP shutdown", "error", err)
+	}
+}
+
+func (z *ZipkinReceiver) handleSpans(w http.ResponseWriter, r *http.Request) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get(headerContentType)); mt != "" && mt != contentTypeJSON {
+		http.Error(w, "unsupported content type: want application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var body io.Reader = http.MaxBytesReader(w, r.Body, zipkinMaxBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "invalid gzip body", http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = gz.Close() }()
+		body = io.LimitReader(gz, maxDecompressedBody)
+	}
+	var spans []zipkinSpan
+	if err := json.NewDecoder(body).Decode(&spans); err != nil {
+		z.metrics.RecordZipkinSpans("invalid", 1)
+		http.Error(w, "invalid Zipkin v2 JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, invalid := convertZipkinSpans(spans)
+	z.metrics.RecordZipkinSpans("invalid", invalid)
+	n := len(spans) - invalid
+	if n > 0 {
+		if _, err := z.traces.Export(r.Context(), req); err != nil {
+			z.metrics.RecordZipkinSpans("export_error", n)
+			if isQueueFull(err) {
+				w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfterSeconds))
+				http.Error(w, "ingest pipeline at capacity", http.StatusTooManyRequests)
+				return
+			}
+			slog.Warn("Zipkin: export failed", "error", err)
+			http.Error(w, "export failed", http.StatusInternalServerError)
+			return
+		}
+		z.metrics.RecordZipkinSpans("ok", n)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// convertZipkinSpans maps Zipkin spans to OTLP, one ResourceSpans per local
+// service. Spans with malformed IDs are skipped and counted.
+func convertZipkinSpans(spans []zipkinSpan) (*coltracepb.ExportTraceServiceRequest, int) {
+	byService := make(map[string]*tracepb.ScopeSpans)
+	var order []string
+	invalid := 0
+	for i := range spans {
+		zs := &spans[i]
+		span, err := zipkinToOTLP(zs)
+		if err != nil {
+			invalid++
+			continue
+		}
+		service := "unknown"
+		if zs.LocalEndpoint != nil && zs.LocalEndpoint.ServiceName != "" {
+			service = zs.LocalEndpoint.ServiceName
+		}
+		ss, ok := byService[service]
+		if !ok {
+			ss = &tracepb.ScopeSpans{Scope: &commonpb.InstrumentationScope{Name: "zipkin"}}
+			byService[service] = ss
+			order = append(order, service)
+		}
+		ss.Spans = append(ss.Spans, span)
+	}
+	req := &coltracepb.ExportTraceServiceRequest{}
+	for _, service := range order {
+		req.ResourceSpans = append(req.ResourceSpans, &tracepb.ResourceSpans{
+			Resource:   &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringKV("service.name", service)}},
+			ScopeSpans: []*tracepb.ScopeSpans{byService[service]},
+		})
+	}
+	return req, invalid
+}
+
+// zipkinToOTLP converts one span. 64-bit trace IDs are zero-padded like
+// Jaeger's. The "error" tag sets the error status (its value becomes the
+// status message); annotations become events.
+//
+// A shared SERVER span (Zipkin's join of client and server halves under one
+// span ID) gets a derived span ID parented to the client half, since span IDs
+// are unique per trace in storage.
+func zipkinToOTLP(zs *zipkinSpan) (*tracepb.Span, error) {
+	traceID, err := zipkinID(zs.TraceID, 16)
+	if err != nil {
+		return nil, fmt.Errorf("traceId: %w", err)
+	}
+	spanID, err := zipkinID(zs.ID, 8)
+	if err != nil {
+		return nil, fmt.Errorf("id: %w", err)
+	}
+	span := &tracepb.Span{
+		TraceId:           traceID,
+		SpanId:            spanID,
+		Name:              zs.Name,
+		Kind:              jaegerSpanKind(strings.ToLower(zs.Kind)),
+		StartTimeUnixNano: uint64(max(zs.Timestamp, 0)) * 1000, // #nosec G115 -- clamped non-negative
+	}
+	span.EndTimeUnixNano = span.StartTimeUnixNano + uint64(max(zs.Duration, 0))*1000 // #nosec G115 -- clamped non-negative
+	if zs.ParentID != "" {
+		if span.ParentSpanId, err = zipkinID(zs.ParentID, 8); err != nil {
+			return nil, fmt.Errorf("parentId: %w", err)
+		}
+	}
+	if zs.Shared && span.Kind == tracepb.Span_SPAN_KIND_SERVER {
+		span.ParentSpanId = span.SpanId
+		h := fnv.New64a()
+		_, _ = h.Write(span.SpanId)
+		_, _ = h.Write([]byte("shared"))
+		span.SpanId = binary.BigEndian.AppendUint64(nil, h.Sum64())
+	}
+
+	keys := make([]string, 0, len(zs.Tags))
+	for k := range zs.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := zs.Tags[k]
+		if k == "error" {
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: v}
+			continue
+		}
+		span.Attributes = append(span.Attributes, stringKV(k, v))
+	}
+	if re := zs.RemoteEndpoint; re != nil {
+		if re.ServiceName != "" {
+			span.Attributes = append(span.Attributes, stringKV("peer.service", re.ServiceName))
+		}
+		if ip := re.IPv4 + re.IPv6; ip != "" {
+			span.Attributes = append(span.Attributes, stringKV("network.peer.address", ip))
+		}
+		if re.Port > 0 {
+			span.Attributes = append(span.Attributes, &commonpb.KeyValue{Key: "network.peer.port",
+				Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(re.Port)}}})
+		}
+	}
+	for _, a := range zs.Annotations {
+		span.Events = append(span.Events, &tracepb.Span_Event{
+			Name:         a.Value,
+			TimeUnixNano: uint64(max(a.Timestamp, 0)) * 1000, // #nosec G115 -- clamped non-negative
+		})
+	}
+	return span, nil
+}
+
+// zipkinID decodes a lower-hex ID into size bytes, left-padding shorter IDs
+// (64-bit trace IDs, IDs with leading zeros dropped).
+func zipkinID(s string, size int) ([]byte, error) {
+	if s == "" || len(s) > size*2 {
+		return nil, fmt.Errorf("invalid length %d", len(s))
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, size)
+	copy(out[size-len(raw):], raw)
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// braveSpans is a client/server pair as Brave reports it: the server half
+// shares the client's span ID.
+const braveSpans = `[
+ {"traceId":"4bf92f3577b34da6","id":"00f067aa0ba902b7","name":"get /orders","kind":"CLIENT",
+  "timestamp":1767323045000000,"duration":2500,
+  "localEndpoint":{"serviceName":"web","ipv4":"10.0.0.1"},
+  "remoteEndpoint":{"serviceName":"orders","ipv4":"10.0.0.2","port":8080},
+  "tags":{"http.method":"GET","http.path":"/orders","error":"500"},
+  "annotations":[{"timestamp":1767323045001000,"value":"ws"}]},
+ {"traceId":"4bf92f3577b34da6","id":"00f067aa0ba902b7","name":"get /orders","kind":"SERVER","shared":true,
+  "timestamp":1767323045000500,"duration":1500,"localEndpoint":{"serviceName":"orders"}},
+ {"traceId":"xyz","id":"1","name":"bad"}
+]`
+
+func TestConvertZipkinSpans(t *testing.T) {
+	var spans []zipkinSpan
+	if err := json.Unmarshal([]byte(braveSpans), &spans); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	req, invalid := convertZipkinSpans(spans)
+	if invalid != 1 {
+		t.Errorf("invalid = %d, want 1", invalid)
+	}
+	rss := req.ResourceSpans
+	if len(rss) != 2 {
+		t.Fatalf("resource spans = %d, want 2 (web, orders)", len(rss))
+	}
+	client := rss[0].ScopeSpans[0].Spans[0]
+	server := rss[1].ScopeSpans[0].Spans[0]
+
+	if got := rss[0].Resource.Attributes[0].Value.GetStringValue(); got != "web" {
+		t.Errorf("first service = %q, want web", got)
+	}
+	if len(client.TraceId) != 16 || client.TraceId[0] != 0 || client.TraceId[8] != 0x4b {
+		t.Errorf("trace id = %x, want zero-padded 64-bit id", client.TraceId)
+	}
+	if client.Kind != tracepb.Span_SPAN_KIND_CLIENT || client.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || client.Status.GetMessage() != "500" {
+		t.Errorf("client kind/status = %v/%v", client.Kind, client.Status)
+	}
+	if got := client.EndTimeUnixNano - client.StartTimeUnixNano; got != 2_500_000 {
+		t.Errorf("client duration = %dns, want 2.5ms", got)
+	}
+	attrs := map[string]bool{}
+	for _, kv := range client.Attributes {
+		attrs[kv.Key] = true
+	}
+	for _, k := range []string{"http.method", "peer.service", "network.peer.address", "network.peer.port"} {
+		if !attrs[k] {
+			t.Errorf("client attributes missing %s: %v", k, client.Attributes)
+		}
+	}
+	if attrs["error"] {
+		t.Error("error tag kept as attribute")
+	}
+	if len(client.Events) != 1 || client.Events[0].Name != "ws" {
+		t.Errorf("client events = %v", client.Events)
+	}
+
+	if server.Kind != tracepb.Span_SPAN_KIND_SERVER {
+		t.Errorf("server kind = %v", server.Kind)
+	}
+	if bytes.Equal(server.SpanId, client.SpanId) || !bytes.Equal(server.ParentSpanId, client.SpanId) {
+		t.Errorf("shared server span id/parent = %x/%x, want a new id parented to %x", server.SpanId, server.ParentSpanId, client.SpanId)
+	}
+}
+
+func TestZipkinReceiver_HTTP(t *testing.T) {
+	repo := newTestRepo(t)
+	traces := NewTraceServer(repo, nil, &config.Config{IngestMinSeverity: "DEBUG"})
+	stored := make(chan storage.Span, 4)
+	traces.SetSpanCallback(func(sp storage.Span) { stored <- sp })
+	z := NewZipkinReceiver(traces, nil)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(braveSpans))
+	_ = zw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/spans", &gz)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	z.handleSpans(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d (%s), want 202", rec.Code, rec.Body.String())
+	}
+	services := map[string]bool{}
+	for range 2 {
+		select {
+		case sp := <-stored:
+			services[sp.ServiceName] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("spans never reached the pipeline")
+		}
+	}
+	if !services["web"] || !services["orders"] {
+		t.Errorf("stored services = %v, want web and orders", services)
+	}
+
+	for name, tc := range map[string]struct {
+		ct, body string
+		want     int
+	}{
+		"protobuf":     {"application/x-protobuf", "x", http.StatusUnsupportedMediaType},
+		"invalid JSON": {"application/json", "{", http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/spans", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.ct)
+		rec := httptest.NewRecorder()
+		z.handleSpans(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+}
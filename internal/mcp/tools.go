@@ -138,7 +138,7 @@ var toolDefs = []Tool{
 	},
 	{
 		Name:        "get_alerts",
-		Description: "Returns active alerts and anomalies: services with high error rates, p99 latency spikes, and degraded health scores.",
+		Description: "Returns active alerts and anomalies: services with high error rates, p99 latency spikes, degraded health scores, and DLQ growth (dead-letter backlog, oldest batch age, replay failure streak).",
 		InputSchema: InputSchema{Type: "object"},
 	},
 	{
@@ -663,6 +663,15 @@ func (s *Server) toolGetAlerts() ToolCallResult {
 		Alerts  []string `json:"alerts"`
 	}
 	var entries []alertEntry
+	// Built-in system conditions (DLQ growth) come first: a dying DB
+	// explains every downstream service alert below it.
+	if dlqAlerts := s.metrics.DLQAlerts(); len(dlqAlerts) > 0 {
+		entries = append(entries, alertEntry{
+			Service: serverName,
+			Status:  "degraded",
+			Alerts:  dlqAlerts,
+		})
+	}
 	for _, n := range snap.Nodes {
 		if len(n.Alerts) > 0 || n.Status != "healthy" {
 			entries = append(entries, alertEntry{
@@ -0,0 +1,312 @@
+// Package oidc implements the OpenID Connect authorization code flow used
+// for browser sign-in: provider discovery, the authorization redirect with
+// PKCE, the token exchange and ID token verification against the
+// provider's published signing keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// maxResponseBody caps discovery, JWKS and token responses.
+	maxResponseBody = 1 << 20
+	// keyRefreshInterval bounds how often an unknown key ID refetches the
+	// JWKS, so tokens with made-up key IDs cannot hammer the provider.
+	keyRefreshInterval = time.Minute
+	// clockSkew is the leeway allowed on ID token times.
+	clockSkew = time.Minute
+)
+
+// Config describes the OIDC client registration.
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string // empty for public clients, which rely on PKCE alone
+	RedirectURL  string
+	Scopes       []string // "openid" is added when missing
+	GroupsClaim  string   // ID token claim listing the user's groups; empty reads none
+	HTTPClient   *http.Client
+}
+
+// Claims are the verified ID token claims used for a session.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+	Nonce         string
+	Expiry        time.Time
+}
+
+// Provider is an OpenID Connect provider discovered from its issuer URL.
+type Provider struct {
+	cfg           Config
+	client        *http.Client
+	authURL       string
+	tokenURL      string
+	jwksURL       string
+	endSessionURL string
+
+	mu          sync.Mutex
+	keys        map[string]any // key ID → *rsa.PublicKey | *ecdsa.PublicKey
+	keysFetched time.Time
+
+	now func() time.Time
+}
+
+// discovery is the part of the provider metadata document in use.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// Discover fetches the provider metadata from
+// <issuer>/.well-known/openid-configuration and returns a Provider for it.
+// The metadata must name the same issuer, as the spec requires.
+func Discover(ctx context.Context, cfg Config) (*Provider, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	issuer := strings.TrimSuffix(cfg.IssuerURL, "/")
+	var d discovery
+	if err := getJSON(ctx, client, issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", d.Issuer, cfg.IssuerURL)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: metadata lacks authorization, token or jwks endpoint")
+	}
+	cfg.IssuerURL = d.Issuer
+	if !containsScope(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &Provider{
+		cfg:           cfg,
+		client:        client,
+		authURL:       d.AuthorizationEndpoint,
+		tokenURL:      d.TokenEndpoint,
+		jwksURL:       d.JWKSURI,
+		endSessionURL: d.EndSessionEndpoint,
+		keys:          make(map[string]any),
+		now:           time.Now,
+	}, nil
+}
+
+// AuthCodeURL returns the provider URL that starts a sign-in. state and
+// nonce come back in the callback and the ID token; verifier is the PKCE
+// code verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.authURL, "?") {
+		sep = "&"
+	}
+	return p.authURL + sep + q.Encode()
+}
+
+// EndSessionURL returns the provider's logout endpoint, or "" when it does
+// not publish one.
+func (p *Provider) EndSessionURL() string {
+	return p.endSessionURL
+}
+
+// Exchange trades an authorization code for tokens and returns the verified
+// claims of the ID token. The caller checks the nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Claims, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc token exchange: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("oidc token exchange: response has no id_token")
+	}
+	return p.Verify(ctx, tok.IDToken)
+}
+
+// Verify checks an ID token's signature, issuer, audience and expiry and
+// returns its claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken string) (*Claims, error) {
+	token, err := jwt.Parse(rawIDToken,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.cfg.IssuerURL),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(p.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc id token: %w", err)
+	}
+	mc, _ := token.Claims.(jwt.MapClaims)
+	c := &Claims{}
+	c.Subject, _ = mc["sub"].(string)
+	c.Email, _ = mc["email"].(string)
+	c.EmailVerified, _ = mc["email_verified"].(bool)
+	c.Name, _ = mc["name"].(string)
+	c.Nonce, _ = mc["nonce"].(string)
+	if p.cfg.GroupsClaim != "" {
+		c.Groups = stringList(mc[p.cfg.GroupsClaim])
+	}
+	if exp, err := mc.GetExpirationTime(); err == nil && exp != nil {
+		c.Expiry = exp.Time
+	}
+	if c.Subject == "" {
+		return nil, errors.New("oidc id token: no subject")
+	}
+	return c, nil
+}
+
+// key returns the signing key kid, refetching the JWKS when it is unknown
+// (the provider may have rotated keys) at most once per keyRefreshInterval.
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	if !p.keysFetched.IsZero() && p.now().Sub(p.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	keys, err := fetchJWKS(ctx, p.client, p.jwksURL)
+	p.keysFetched = p.now()
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds kid; a token without a key ID matches a JWKS holding a
+// single key.
+func (p *Provider) lookupKey(kid string) (any, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// RandomString returns n random bytes, base64url-encoded, for states,
+// nonces and PKCE verifiers.
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBody)).Decode(v)
+}
+
+// stringList reads a claim holding a string array or, as some providers
+// send a single group, one string.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsScope(scopes []string, s string) bool {
+	for _, v := range scopes {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
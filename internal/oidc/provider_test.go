@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIdP is a minimal OpenID provider issuing RS256 ID tokens.
+type fakeIdP struct {
+	srv      *httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	claims   jwt.MapClaims // next ID token's claims
+	verifier string        // code_verifier seen by the token endpoint
+	jwksHit  int
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key, kid: "k1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, _ *http.Request) {
+		idp.jwksHit++
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "use": "sig", "kid": idp.kid,
+			"n": base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good-code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "argus" || pass != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		idp.verifier = r.PostForm.Get("code_verifier")
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, idp.claims), "access_token": "at"})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (idp *fakeIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = idp.kid
+	s, err := tok.SignedString(idp.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (idp *fakeIdP) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": idp.srv.URL, "aud": "argus", "sub": "user-1", "email": "ada@example.com",
+		"email_verified": true, "name": "Ada", "nonce": "n1", "groups": []string{"sre", "oncall"},
+		"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestProvider_CodeFlow(t *testing.T) {
+	idp := newFakeIdP(t)
+	ctx := context.Background()
+	p, err := Discover(ctx, Config{IssuerURL: idp.srv.URL + "/", ClientID: "argus", ClientSecret: "s3cret", RedirectURL: "https://argus.test/auth/callback", Scopes: []string{"email"}, GroupsClaim: "groups"})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	u, err := url.Parse(p.AuthCodeURL("st", "n1", "verifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/authorize" || q.Get("state") != "st" || q.Get("nonce") != "n1" || q.Get("scope") != "openid email" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("client_id") != "argus" {
+		t.Errorf("AuthCodeURL = %s", u)
+	}
+
+	idp.claims = idp.validClaims()
+	c, err := p.Exchange(ctx, "good-code", "verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if c.Subject != "user-1" || c.Email != "ada@example.com" || !c.EmailVerified || c.Nonce != "n1" || c.Expiry.IsZero() ||
+		len(c.Groups) != 2 || c.Groups[1] != "oncall" {
+		t.Errorf("claims = %+v", c)
+	}
+	if idp.verifier != "verifier" {
+		t.Errorf("token endpoint saw code_verifier %q", idp.verifier)
+	}
+	if _, err := p.Exchange(ctx, "bad-code", "verifier"); err == nil {
+		t.Error("a rejected code should fail the exchange")
+	}
+
+	for name, mutate := range map[string]func(jwt.MapClaims){
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+	} {
+		claims := idp.validClaims()
+		mutate(claims)
+		if _, err := p.Verify(ctx, idp.sign(t, claims)); err == nil {
+			t.Errorf("%s: token verified", name)
+		}
+	}
+
+	// A token signed by another key is rejected; an unknown key ID refetches
+	// the JWKS at most once per interval.
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.validClaims())
+	forged.Header["kid"] = idp.kid
+	raw, _ := forged.SignedString(other)
+	if _, err := p.Verify(ctx, raw); err == nil {
+		t.Error("forged token verified")
+	}
+	hits := idp.jwksHit
+	idp.kid = "k2"
+	if _, err := p.Verify(ctx, idp.sign(t, idp.validClaims())); err == nil || !strings.Contains(err.Error(), "k2") {
+		t.Errorf("rotated key within the refresh interval: %v", err)
+	}
+	p.now = func() time.Time { return time.Now().Add(2 * keyRefreshInterval) }
+	claims := idp.validClaims()
+	claims["exp"] = time.Now().Add(3 * time.Hour).Unix()
+	if _, err := p.Verify(ctx, idp.sign(t, claims)); err != nil {
+		t.Errorf("rotated key after the interval: %v", err)
+	}
+	if idp.jwksHit != hits+1 {
+		t.Errorf("JWKS fetched %d more times, want 1", idp.jwksHit-hits)
+	}
+}
+
+func TestDiscover_IssuerMismatch(t *testing.T) {
+	idp := newFakeIdP(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idp.srv.Config.Handler.ServeHTTP(w, r) // metadata names idp.srv.URL, not this server
+	}))
+	defer srv.Close()
+	if _, err := Discover(context.Background(), Config{IssuerURL: srv.URL, ClientID: "argus"}); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Discover err = %v, want issuer mismatch", err)
+	}
+}
@@ -0,0 +1,564 @@
+// Package parquet writes and reads the subset of Apache Parquet the cold
+// storage tier needs: flat schemas of required INT64, DOUBLE, UTF8 string
+// and microsecond timestamp columns, PLAIN encoded in ZSTD-compressed data
+// pages. Files written here open in any Parquet reader (DuckDB, Spark,
+// pandas); Read only accepts files of the same shape.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/compress"
+)
+
+// Type is a column's value type.
+type Type int
+
+const (
+	// Int64 columns hold int64 values.
+	Int64 Type = iota
+	// Float64 columns hold float64 values.
+	Float64
+	// String columns hold UTF-8 strings.
+	String
+	// Timestamp columns hold time.Time values, stored as UTC microseconds.
+	Timestamp
+)
+
+// Column is one field of a flat schema.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Parquet enum values (parquet.thrift).
+const (
+	physInt64     = 2
+	physDouble    = 5
+	physByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+
+	repetitionRequired = 0
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecZstd = 6
+
+	pageData = 0
+)
+
+const (
+	magic     = "PAR1"
+	createdBy = "otelcontext"
+
+	// A row group is flushed at rowGroupRows rows or once its values reach
+	// rowGroupBytes, which keeps every page well under compress's
+	// decompression cap.
+	rowGroupRows  = 10_000
+	rowGroupBytes = 32 << 20
+)
+
+func (t Type) physical() int32 {
+	switch t {
+	case Float64:
+		return physDouble
+	case String:
+		return physByteArray
+	}
+	return physInt64
+}
+
+// Writer streams rows into a Parquet file.
+type Writer struct {
+	w      io.Writer
+	schema []Column
+	offset int64
+
+	pages     [][]byte // PLAIN values of the open row group, per column
+	groupRows int
+	groupSize int
+
+	rowGroups []rowGroupMeta
+	numRows   int64
+	closed    bool
+}
+
+type rowGroupMeta struct {
+	columns   []columnMeta
+	numRows   int64
+	totalSize int64
+}
+
+type columnMeta struct {
+	offset       int64
+	uncompressed int64
+	compressed   int64
+	numValues    int64
+}
+
+// NewWriter writes the file header to w and returns a Writer for schema.
+func NewWriter(w io.Writer, schema []Column) (*Writer, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("parquet: empty schema")
+	}
+	seen := make(map[string]bool, len(schema))
+	for _, c := range schema {
+		if c.Name == "" || seen[c.Name] {
+			return nil, fmt.Errorf("parquet: column name %q is empty or repeated", c.Name)
+		}
+		seen[c.Name] = true
+	}
+	pw := &Writer{w: w, schema: schema, pages: make([][]byte, len(schema))}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// Write appends a row: one value per column, in schema order, of the
+// column's Go type (int64, float64, string or time.Time).
+func (w *Writer) Write(row ...any) error {
+	if w.closed {
+		return errors.New("parquet: write after Close")
+	}
+	if len(row) != len(w.schema) {
+		return fmt.Errorf("parquet: row has %d values, schema %d columns", len(row), len(w.schema))
+	}
+	for i, c := range w.schema {
+		before := len(w.pages[i])
+		switch v := row[i].(type) {
+		case int64:
+			if c.Type != Int64 {
+				return w.typeError(c, v)
+			}
+			w.pages[i] = binary.LittleEndian.AppendUint64(w.pages[i], uint64(v))
+		case float64:
+			if c.Type != Float64 {
+				return w.typeError(c, v)
+			}
+			w.pages[i] = binary.LittleEndian.AppendUint64(w.pages[i], math.Float64bits(v))
+		case string:
+			if c.Type != String {
+				return w.typeError(c, v)
+			}
+			w.pages[i] = binary.LittleEndian.AppendUint32(w.pages[i], uint32(len(v)))
+			w.pages[i] = append(w.pages[i], v...)
+		case time.Time:
+			if c.Type != Timestamp {
+				return w.typeError(c, v)
+			}
+			w.pages[i] = binary.LittleEndian.AppendUint64(w.pages[i], uint64(v.UnixMicro()))
+		default:
+			return w.typeError(c, v)
+		}
+		w.groupSize += len(w.pages[i]) - before
+	}
+	w.groupRows++
+	w.numRows++
+	if w.groupRows >= rowGroupRows || w.groupSize >= rowGroupBytes {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *Writer) typeError(c Column, v any) error {
+	// The row is partly written, so the file can no longer be completed.
+	w.closed = true
+	return fmt.Errorf("parquet: column %q: unexpected %T", c.Name, v)
+}
+
+// flush writes the open row group, one data page per column.
+func (w *Writer) flush() error {
+	if w.groupRows == 0 {
+		return nil
+	}
+	g := rowGroupMeta{numRows: int64(w.groupRows)}
+	for i := range w.schema {
+		values := w.pages[i]
+		compressed := compress.Compress(values)
+		h := newThriftWriter()
+		h.i32(1, pageData)
+		h.i32(2, int32(len(values)))
+		h.i32(3, int32(len(compressed)))
+		h.beginStruct(5)
+		h.i32(1, int32(w.groupRows))
+		h.i32(2, encodingPlain)
+		h.i32(3, encodingRLE)
+		h.i32(4, encodingRLE)
+		h.endStruct()
+		h.endStruct()
+
+		c := columnMeta{
+			offset:       w.offset,
+			uncompressed: int64(len(h.buf) + len(values)),
+			compressed:   int64(len(h.buf) + len(compressed)),
+			numValues:    int64(w.groupRows),
+		}
+		if err := w.write(h.buf); err != nil {
+			return err
+		}
+		if err := w.write(compressed); err != nil {
+			return err
+		}
+		g.columns = append(g.columns, c)
+		g.totalSize += c.uncompressed
+		w.pages[i] = values[:0]
+	}
+	w.rowGroups = append(w.rowGroups, g)
+	w.groupRows, w.groupSize = 0, 0
+	return nil
+}
+
+// Close flushes the last row group and writes the footer. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return errors.New("parquet: writer already closed or failed")
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	m := newThriftWriter()
+	m.i32(1, 1)
+	m.list(2, ctStruct, len(w.schema)+1)
+	m.beginStruct(-1)
+	m.str(4, "schema")
+	m.i32(5, int32(len(w.schema)))
+	m.endStruct()
+	for _, c := range w.schema {
+		m.beginStruct(-1)
+		m.i32(1, c.Type.physical())
+		m.i32(3, repetitionRequired)
+		m.str(4, c.Name)
+		switch c.Type {
+		case String:
+			m.i32(6, convertedUTF8)
+		case Timestamp:
+			m.i32(6, convertedTimestampMicros)
+		}
+		m.endStruct()
+	}
+	m.i64(3, w.numRows)
+	m.list(4, ctStruct, len(w.rowGroups))
+	for _, g := range w.rowGroups {
+		m.beginStruct(-1)
+		m.list(1, ctStruct, len(g.columns))
+		for i, c := range g.columns {
+			m.beginStruct(-1)
+			m.i64(2, c.offset)
+			m.beginStruct(3)
+			m.i32(1, w.schema[i].Type.physical())
+			m.list(2, ctI32, 2)
+			m.i32Value(encodingPlain)
+			m.i32Value(encodingRLE)
+			m.list(3, ctBinary, 1)
+			m.binaryValue([]byte(w.schema[i].Name))
+			m.i32(4, codecZstd)
+			m.i64(5, c.numValues)
+			m.i64(6, c.uncompressed)
+			m.i64(7, c.compressed)
+			m.i64(9, c.offset)
+			m.endStruct()
+			m.endStruct()
+		}
+		m.i64(2, g.totalSize)
+		m.i64(3, g.numRows)
+		m.endStruct()
+	}
+	m.str(6, createdBy)
+	m.endStruct()
+
+	if err := w.write(m.buf); err != nil {
+		return err
+	}
+	if err := w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(m.buf)))); err != nil {
+		return err
+	}
+	return w.write([]byte(magic))
+}
+
+// NumRows is the number of rows written so far.
+func (w *Writer) NumRows() int64 { return w.numRows }
+
+// Read decodes a whole file written by Writer. Rows hold int64, float64,
+// string or time.Time (UTC) values in schema order.
+func Read(data []byte) ([]Column, [][]any, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], []byte(magic)) || !bytes.Equal(data[len(data)-4:], []byte(magic)) {
+		return nil, nil, errors.New("parquet: not a parquet file")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerLen <= 0 || footerLen > len(data)-12 {
+		return nil, nil, errors.New("parquet: invalid footer length")
+	}
+	meta, err := readFileMeta(data[len(data)-8-footerLen : len(data)-8])
+	if err != nil {
+		return nil, nil, err
+	}
+	if meta.numRows < 0 || meta.numRows > int64(len(data)) {
+		return nil, nil, fmt.Errorf("parquet: implausible row count %d", meta.numRows)
+	}
+
+	rows := make([][]any, 0, meta.numRows)
+	for _, g := range meta.rowGroups {
+		if len(g.columns) != len(meta.schema) {
+			return nil, nil, errors.New("parquet: row group does not match schema")
+		}
+		base := len(rows)
+		for range g.numRows {
+			rows = append(rows, make([]any, len(meta.schema)))
+		}
+		for ci, c := range g.columns {
+			if c.codec != codecZstd {
+				return nil, nil, fmt.Errorf("parquet: column %q: unsupported codec %d", meta.schema[ci].Name, c.codec)
+			}
+			if err := readColumn(data, c, meta.schema[ci], rows[base:], ci); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return meta.schema, rows, nil
+}
+
+type fileMeta struct {
+	schema    []Column
+	numRows   int64
+	rowGroups []readRowGroup
+}
+
+type readRowGroup struct {
+	numRows int64
+	columns []readColumnMeta
+}
+
+type readColumnMeta struct {
+	codec      int32
+	numValues  int64
+	dataOffset int64
+}
+
+type schemaElement struct {
+	name      string
+	physical  int32
+	converted int32
+	children  int32
+	repeated  int32
+}
+
+func readFileMeta(b []byte) (fileMeta, error) {
+	r := &thriftReader{b: b}
+	var m fileMeta
+	var elems []schemaElement
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 2 && typ == ctList:
+			_, n := r.list()
+			for i := 0; i < n && r.err == nil; i++ {
+				e := schemaElement{physical: -1, converted: -1}
+				r.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == ctI32:
+						e.physical = r.i32()
+					case id == 3 && typ == ctI32:
+						e.repeated = r.i32()
+					case id == 4 && typ == ctBinary:
+						e.name = r.str()
+					case id == 5 && typ == ctI32:
+						e.children = r.i32()
+					case id == 6 && typ == ctI32:
+						e.converted = r.i32()
+					default:
+						r.skip(typ)
+					}
+				})
+				elems = append(elems, e)
+			}
+		case id == 3 && typ == ctI64:
+			m.numRows = r.i64()
+		case id == 4 && typ == ctList:
+			_, n := r.list()
+			for i := 0; i < n && r.err == nil; i++ {
+				m.rowGroups = append(m.rowGroups, readRowGroupMeta(r))
+			}
+		default:
+			r.skip(typ)
+		}
+	})
+	if r.err != nil {
+		return m, r.err
+	}
+	if len(elems) < 2 || int(elems[0].children) != len(elems)-1 {
+		return m, errors.New("parquet: unsupported schema (want a flat list of columns)")
+	}
+	for _, e := range elems[1:] {
+		if e.children != 0 || e.repeated != repetitionRequired {
+			return m, fmt.Errorf("parquet: column %q: only required flat columns are supported", e.name)
+		}
+		var t Type
+		switch {
+		case e.physical == physInt64 && e.converted == convertedTimestampMicros:
+			t = Timestamp
+		case e.physical == physInt64:
+			t = Int64
+		case e.physical == physDouble:
+			t = Float64
+		case e.physical == physByteArray:
+			t = String
+		default:
+			return m, fmt.Errorf("parquet: column %q: unsupported type %d", e.name, e.physical)
+		}
+		m.schema = append(m.schema, Column{Name: e.name, Type: t})
+	}
+	return m, nil
+}
+
+func readRowGroupMeta(r *thriftReader) readRowGroup {
+	var g readRowGroup
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == ctList:
+			_, n := r.list()
+			for i := 0; i < n && r.err == nil; i++ {
+				var c readColumnMeta
+				r.readStruct(func(id int16, typ byte) {
+					if id != 3 || typ != ctStruct {
+						r.skip(typ)
+						return
+					}
+					r.readStruct(func(id int16, typ byte) {
+						switch {
+						case id == 4 && typ == ctI32:
+							c.codec = r.i32()
+						case id == 5 && typ == ctI64:
+							c.numValues = r.i64()
+						case id == 9 && typ == ctI64:
+							c.dataOffset = r.i64()
+						default:
+							r.skip(typ)
+						}
+					})
+				})
+				g.columns = append(g.columns, c)
+			}
+		case id == 3 && typ == ctI64:
+			g.numRows = r.i64()
+		default:
+			r.skip(typ)
+		}
+	})
+	return g
+}
+
+// readColumn decodes the data pages of one column chunk into column ci of
+// rows.
+func readColumn(data []byte, c readColumnMeta, col Column, rows [][]any, ci int) error {
+	if c.numValues != int64(len(rows)) {
+		return fmt.Errorf("parquet: column %q has %d values for %d rows", col.Name, c.numValues, len(rows))
+	}
+	pos := c.dataOffset
+	next := 0
+	for next < len(rows) {
+		if pos < 4 || pos >= int64(len(data)) {
+			return fmt.Errorf("parquet: column %q: page offset out of range", col.Name)
+		}
+		r := &thriftReader{b: data[pos:]}
+		var pageType, uncompressed, compressed, numValues, encoding int32 = -1, 0, 0, 0, -1
+		r.readStruct(func(id int16, typ byte) {
+			switch {
+			case id == 1 && typ == ctI32:
+				pageType = r.i32()
+			case id == 2 && typ == ctI32:
+				uncompressed = r.i32()
+			case id == 3 && typ == ctI32:
+				compressed = r.i32()
+			case id == 5 && typ == ctStruct:
+				r.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == ctI32:
+						numValues = r.i32()
+					case id == 2 && typ == ctI32:
+						encoding = r.i32()
+					default:
+						r.skip(typ)
+					}
+				})
+			default:
+				r.skip(typ)
+			}
+		})
+		if r.err != nil {
+			return fmt.Errorf("parquet: column %q: page header: %w", col.Name, r.err)
+		}
+		if pageType != pageData || encoding != encodingPlain {
+			return fmt.Errorf("parquet: column %q: unsupported page (type %d, encoding %d)", col.Name, pageType, encoding)
+		}
+		start := pos + int64(r.pos)
+		if compressed < 0 || start+int64(compressed) > int64(len(data)) || numValues < 0 || int(numValues) > len(rows)-next {
+			return fmt.Errorf("parquet: column %q: page out of range", col.Name)
+		}
+		values, err := compress.Decompress(data[start : start+int64(compressed)])
+		if err != nil {
+			return fmt.Errorf("parquet: column %q: %w", col.Name, err)
+		}
+		if len(values) != int(uncompressed) {
+			return fmt.Errorf("parquet: column %q: page size mismatch", col.Name)
+		}
+		if err := decodePlain(values, col, rows[next:next+int(numValues)], ci); err != nil {
+			return err
+		}
+		next += int(numValues)
+		pos = start + int64(compressed)
+	}
+	return nil
+}
+
+func decodePlain(b []byte, col Column, rows [][]any, ci int) error {
+	for _, row := range rows {
+		switch col.Type {
+		case String:
+			if len(b) < 4 {
+				return fmt.Errorf("parquet: column %q: truncated page", col.Name)
+			}
+			n := binary.LittleEndian.Uint32(b)
+			if uint64(n) > uint64(len(b)-4) {
+				return fmt.Errorf("parquet: column %q: truncated page", col.Name)
+			}
+			row[ci] = string(b[4 : 4+n])
+			b = b[4+n:]
+		default:
+			if len(b) < 8 {
+				return fmt.Errorf("parquet: column %q: truncated page", col.Name)
+			}
+			v := binary.LittleEndian.Uint64(b)
+			b = b[8:]
+			switch col.Type {
+			case Int64:
+				row[ci] = int64(v)
+			case Float64:
+				row[ci] = math.Float64frombits(v)
+			case Timestamp:
+				row[ci] = time.UnixMicro(int64(v)).UTC()
+			}
+		}
+	}
+	if len(b) != 0 {
+		return fmt.Errorf("parquet: column %q: trailing page bytes", col.Name)
+	}
+	return nil
+}
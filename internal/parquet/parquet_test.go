@@ -0,0 +1,127 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = []Column{
+	{Name: "id", Type: Int64},
+	{Name: "ts", Type: Timestamp},
+	{Name: "body", Type: String},
+	{Name: "value", Type: Float64},
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	// More than one row group.
+	const n = rowGroupRows + 17
+	for i := range n {
+		if err := w.Write(int64(i), base.Add(time.Duration(i)*time.Second), fmt.Sprintf("héllo %d", i), float64(i)/2); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, rows, err := Read(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !reflect.DeepEqual(schema, testSchema) {
+		t.Errorf("schema = %+v", schema)
+	}
+	if len(rows) != n {
+		t.Fatalf("rows = %d, want %d", len(rows), n)
+	}
+	last := rows[n-1]
+	if last[0] != int64(n-1) || !last[1].(time.Time).Equal(base.Add((n-1)*time.Second)) || last[2] != fmt.Sprintf("héllo %d", n-1) || last[3] != float64(n-1)/2 {
+		t.Errorf("last row = %v", last)
+	}
+}
+
+func TestWriteRead_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, testSchema)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, rows, err := Read(buf.Bytes()); err != nil || len(rows) != 0 {
+		t.Errorf("Read = %d rows, %v", len(rows), err)
+	}
+}
+
+func TestWriter_RejectsBadRows(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "a"}, {Name: "a"}}); err == nil {
+		t.Error("repeated column name accepted")
+	}
+	w, _ := NewWriter(&bytes.Buffer{}, testSchema)
+	if err := w.Write(int64(1)); err == nil {
+		t.Error("short row accepted")
+	}
+	if err := w.Write("1", time.Now(), "x", 1.0); err == nil || !strings.Contains(err.Error(), `"id"`) {
+		t.Errorf("wrong type = %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Error("Close after a failed row succeeded")
+	}
+}
+
+func TestRead_RejectsCorruptFiles(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, testSchema)
+	for i := range 100 {
+		_ = w.Write(int64(i), time.Now(), "x", 1.0)
+	}
+	_ = w.Close()
+	good := buf.Bytes()
+
+	if _, _, err := Read([]byte("not parquet")); err == nil {
+		t.Error("garbage accepted")
+	}
+	// Truncations and flipped bytes must fail cleanly, never panic.
+	for i := 4; i < len(good)-4; i += 7 {
+		_, _, _ = Read(append(append([]byte{}, good[:i]...), good[len(good)-8:]...))
+		bad := append([]byte{}, good...)
+		bad[i] ^= 0xff
+		_, _, _ = Read(bad)
+	}
+}
+
+func TestThriftCompact(t *testing.T) {
+	w := newThriftWriter()
+	w.i32(1, -1)
+	w.str(4, "ab")
+	w.i64(20, 300) // long-form field header
+	w.endStruct()
+	want := []byte{0x15, 0x01, 0x38, 0x02, 'a', 'b', 0x06, 0x28, 0xd8, 0x04, 0x00}
+	if !bytes.Equal(w.buf, want) {
+		t.Fatalf("encoded % x, want % x", w.buf, want)
+	}
+
+	r := &thriftReader{b: w.buf}
+	got := map[int16]any{}
+	r.readStruct(func(id int16, typ byte) {
+		switch typ {
+		case ctI32:
+			got[id] = r.i32()
+		case ctI64:
+			got[id] = r.i64()
+		case ctBinary:
+			got[id] = r.str()
+		}
+	})
+	if r.err != nil || got[1] != int32(-1) || got[4] != "ab" || got[20] != int64(300) {
+		t.Errorf("decoded %v, %v", got, r.err)
+	}
+}
@@ -0,0 +1,250 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Thrift compact protocol type ids, as used in field headers and lists.
+const (
+	ctStop   = 0
+	ctTrue   = 1
+	ctFalse  = 2
+	ctByte   = 3
+	ctI16    = 4
+	ctI32    = 5
+	ctI64    = 6
+	ctDouble = 7
+	ctBinary = 8
+	ctList   = 9
+	ctSet    = 10
+	ctMap    = 11
+	ctStruct = 12
+)
+
+// maxThriftDepth bounds struct nesting while skipping unknown fields, so a
+// corrupt footer cannot recurse without end.
+const maxThriftDepth = 64
+
+var errThriftTruncated = errors.New("parquet: truncated thrift data")
+
+// thriftWriter encodes the Thrift compact protocol. Structs are written
+// field by field; last tracks the previous field id of each open struct
+// for the delta-encoded field headers.
+type thriftWriter struct {
+	buf  []byte
+	last []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{last: []int16{0}}
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		w.buf = append(w.buf, byte(d)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.uvarint(uint64(uint16((id << 1) ^ (id >> 15))))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32Value(v int32) { w.uvarint(uint64(uint32((v << 1) ^ (v >> 31)))) }
+func (w *thriftWriter) i64Value(v int64) { w.uvarint(uint64((v << 1) ^ (v >> 63))) }
+
+func (w *thriftWriter) binaryValue(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, ctI32)
+	w.i32Value(v)
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, ctI64)
+	w.i64Value(v)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, ctBinary)
+	w.binaryValue([]byte(s))
+}
+
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, ctList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+		return
+	}
+	w.buf = append(w.buf, 0xf0|elem)
+	w.uvarint(uint64(n))
+}
+
+// beginStruct opens a struct field; id < 0 opens a list element instead.
+func (w *thriftWriter) beginStruct(id int16) {
+	if id >= 0 {
+		w.field(id, ctStruct)
+	}
+	w.last = append(w.last, 0)
+}
+
+func (w *thriftWriter) endStruct() {
+	w.buf = append(w.buf, ctStop)
+	w.last = w.last[:len(w.last)-1]
+}
+
+// thriftReader decodes the Thrift compact protocol. The first error is
+// kept in err; later reads return zero values.
+type thriftReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *thriftReader) byte1() byte {
+	if r.err != nil || r.pos >= len(r.b) {
+		r.fail(errThriftTruncated)
+		return 0
+	}
+	c := r.b[r.pos]
+	r.pos++
+	return c
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 {
+		r.fail(errThriftTruncated)
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) i32() int32 {
+	v := uint32(r.uvarint())
+	return int32(v>>1) ^ -int32(v&1)
+}
+
+func (r *thriftReader) i64() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) binary() []byte {
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.b)-r.pos) {
+		r.fail(errThriftTruncated)
+		return nil
+	}
+	b := r.b[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b
+}
+
+func (r *thriftReader) str() string { return string(r.binary()) }
+
+// list reads a list header.
+func (r *thriftReader) list() (elem byte, n int) {
+	h := r.byte1()
+	elem = h & 0x0f
+	size := uint64(h >> 4)
+	if size == 15 {
+		size = r.uvarint()
+	}
+	// Every element takes at least one byte.
+	if r.err == nil && size > uint64(len(r.b)-r.pos) {
+		r.fail(errThriftTruncated)
+		return elem, 0
+	}
+	return elem, int(size)
+}
+
+// readStruct calls fn for each field of a struct. fn must read the value
+// or call skip.
+func (r *thriftReader) readStruct(fn func(id int16, typ byte)) {
+	var last int16
+	for r.err == nil {
+		h := r.byte1()
+		if h == ctStop {
+			return
+		}
+		typ := h & 0x0f
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			v := uint16(r.uvarint())
+			id = int16(v>>1) ^ -int16(v&1)
+		}
+		last = id
+		fn(id, typ)
+	}
+}
+
+// skip discards a value of typ.
+func (r *thriftReader) skip(typ byte) { r.skipDepth(typ, 0, false) }
+
+func (r *thriftReader) skipDepth(typ byte, depth int, inList bool) {
+	if depth > maxThriftDepth {
+		r.fail(errors.New("parquet: thrift data nested too deeply"))
+		return
+	}
+	switch typ {
+	case ctTrue, ctFalse:
+		// In a field header the value is the type; list elements are a byte.
+		if inList {
+			r.byte1()
+		}
+	case ctByte:
+		r.byte1()
+	case ctI16, ctI32, ctI64:
+		r.uvarint()
+	case ctDouble:
+		if len(r.b)-r.pos < 8 {
+			r.fail(errThriftTruncated)
+			return
+		}
+		r.pos += 8
+	case ctBinary:
+		r.binary()
+	case ctList, ctSet:
+		elem, n := r.list()
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skipDepth(elem, depth+1, true)
+		}
+	case ctMap:
+		n := r.uvarint()
+		if n == 0 {
+			return
+		}
+		kv := r.byte1()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			r.skipDepth(kv>>4, depth+1, true)
+			r.skipDepth(kv&0x0f, depth+1, true)
+		}
+	case ctStruct:
+		r.readStruct(func(_ int16, t byte) { r.skipDepth(t, depth+1, false) })
+	default:
+		r.fail(fmt.Errorf("parquet: unknown thrift type %d", typ))
+	}
+}
@@ -2,15 +2,19 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/compress"
 	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
 )
 
@@ -26,10 +30,14 @@ type DeadLetterQueue struct {
 	wg       sync.WaitGroup
 	mu       sync.Mutex
 
+	// replayMu serializes replay passes so a ReplayNow racing the ticker
+	// cannot hand the same file to replayFn twice.
+	replayMu sync.Mutex
+
 	// Bounds
 	maxFiles   int   // 0 = unlimited
-	maxDiskMB  int64 // 0 = unlimited
-	maxRetries int   // 0 = unlimited
+	maxBytes   int64 // 0 = unlimited
+	maxRetries int   // 0 = unlimited; past it a file moves to QuarantineDir
 
 	// maxReplayPerTick caps the number of files replayed per tick. Without
 	// this, an outage that filled the DLQ with 10k files would replay all
@@ -38,6 +46,31 @@ type DeadLetterQueue struct {
 	// the DLQ_MAX_REPLAY_PER_TICK env var.
 	maxReplayPerTick int
 
+	// replayConcurrency is how many replay calls one pass runs at once
+	// (default 1). coalesceFn, when set, replays up to coalesceMaxFiles
+	// batch files of at most coalesceMaxBytes each in one call; see
+	// SetCoalesce.
+	replayConcurrency int
+	coalesceFn        func(batches [][]byte) error
+	coalesceMaxFiles  int
+	coalesceMaxBytes  int64
+
+	// Drain progress behind Progress: when the current backlog started
+	// draining (zero while the DLQ is empty), files replayed since, and the
+	// last pass.
+	drainStart       time.Time
+	drainReplayed    int64
+	lastPass         DLQReplayResult
+	lastPassAt       time.Time
+	lastPassDuration time.Duration
+	replaying        atomic.Bool
+
+	// maxAge expires batches older than it on every replay tick (0 = keep
+	// until replayed or evicted). rejectNew makes a full DLQ refuse new
+	// batches with ErrDLQFull instead of evicting the oldest.
+	maxAge    time.Duration
+	rejectNew bool
+
 	// Per-file retry tracking (in-memory; resets on restart)
 	retries map[string]int
 
@@ -51,6 +84,22 @@ type DeadLetterQueue struct {
 	evicted      atomic.Int64
 	evictedBytes atomic.Int64
 	metricsTel   *telemetry.Metrics // nil-safe; enables otelcontext_dlq_evicted_* counters
+	quarantined  atomic.Int64
+	expired      atomic.Int64
+	rejected     atomic.Int64
+
+	// failureStreak counts consecutive failed replay attempts across all
+	// files, reset by any successful replay. A climbing streak means the DB
+	// is still rejecting writes, not just one poisoned batch.
+	failureStreak atomic.Int64
+}
+
+// DLQStats is a point-in-time view of the DLQ backlog.
+type DLQStats struct {
+	Files         int
+	Bytes         int64
+	OldestAge     time.Duration // age of the oldest batch file; 0 when empty
+	FailureStreak int64         // consecutive failed replays since the last success
 }
 
 // NewDLQ creates a new Dead Letter Queue.
@@ -72,9 +121,11 @@ func NewDLQWithLimits(dir string, interval time.Duration, replayFn func(data []b
 		replayFn:   replayFn,
 		stopCh:     make(chan struct{}),
 		maxFiles:   maxFiles,
-		maxDiskMB:  maxDiskMB,
+		maxBytes:   maxDiskMB * 1024 * 1024,
 		maxRetries: maxRetries,
 		retries:    make(map[string]int),
+
+		replayConcurrency: 1,
 	}
 
 	dlq.wg.Add(1)
@@ -113,10 +164,86 @@ func (d *DeadLetterQueue) SetMaxReplayPerTick(n int) {
 	d.maxReplayPerTick = n
 }
 
+// SetReplayConcurrency sets how many replay calls a pass runs at once.
+// n <= 0 keeps one. DLQ_MAX_REPLAY_PER_TICK still bounds the calls per pass.
+func (d *DeadLetterQueue) SetReplayConcurrency(n int) {
+	d.mu.Lock()
+	d.replayConcurrency = max(n, 1)
+	d.mu.Unlock()
+}
+
+// SetCoalesce lets a pass replay small batch files together: up to
+// maxFiles files of at most maxBytes each on disk go to fn in one call,
+// which counts as one call against the per-tick cap. fn must write all of
+// them or none. When it fails every file in the group is marked as retried
+// once, so each backs off and then replays alone through replayFn; a group
+// failure never quarantines. Only files that have not failed yet are
+// grouped. A nil fn or maxFiles < 2 disables coalescing.
+func (d *DeadLetterQueue) SetCoalesce(fn func(batches [][]byte) error, maxFiles int, maxBytes int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if fn == nil || maxFiles < 2 {
+		d.coalesceFn = nil
+		return
+	}
+	d.coalesceFn, d.coalesceMaxFiles, d.coalesceMaxBytes = fn, maxFiles, maxBytes
+}
+
+// Overflow policies for SetOverflowPolicy.
+const (
+	OverflowDropOldest = "drop_oldest"
+	OverflowRejectNew  = "reject_new"
+)
+
+// ErrDLQFull is returned by Enqueue when the reject_new overflow policy is
+// on and the batch would break the file or byte cap.
+var ErrDLQFull = errors.New("dlq: full")
+
+// SetMaxBytes replaces the disk cap given to NewDLQWithLimits with one in
+// bytes. n <= 0 keeps the existing cap.
+func (d *DeadLetterQueue) SetMaxBytes(n int64) {
+	if n <= 0 {
+		return
+	}
+	d.mu.Lock()
+	d.maxBytes = n
+	d.mu.Unlock()
+}
+
+// SetMaxAge expires batches older than age, by their enqueue time, at the
+// start of every replay pass. age <= 0 disables expiry.
+func (d *DeadLetterQueue) SetMaxAge(age time.Duration) {
+	d.mu.Lock()
+	d.maxAge = max(age, 0)
+	d.mu.Unlock()
+}
+
+// SetOverflowPolicy chooses what a full DLQ does with a new batch:
+// OverflowDropOldest (the default) evicts the oldest files to make room,
+// OverflowRejectNew refuses the batch with ErrDLQFull so the backlog
+// already on disk survives.
+func (d *DeadLetterQueue) SetOverflowPolicy(policy string) {
+	d.mu.Lock()
+	d.rejectNew = policy == OverflowRejectNew
+	d.mu.Unlock()
+}
+
+// ExpiredCount reports the cumulative number of files removed for being
+// older than the max age.
+func (d *DeadLetterQueue) ExpiredCount() int64 { return d.expired.Load() }
+
+// RejectedCount reports the cumulative number of batches refused under
+// OverflowRejectNew.
+func (d *DeadLetterQueue) RejectedCount() int64 { return d.rejected.Load() }
+
 // EvictedCount reports the cumulative number of DLQ files dropped due to
 // MaxFiles/MaxDiskMB caps. Exposed for tests; see otelcontext_dlq_evicted_total.
 func (d *DeadLetterQueue) EvictedCount() int64 { return d.evicted.Load() }
 
+// QuarantinedCount reports the cumulative number of files moved to
+// QuarantineDir after maxRetries failed replays.
+func (d *DeadLetterQueue) QuarantinedCount() int64 { return d.quarantined.Load() }
+
 // EvictedBytesCount reports the byte volume dropped alongside EvictedCount.
 func (d *DeadLetterQueue) EvictedBytesCount() int64 { return d.evictedBytes.Load() }
 
@@ -127,7 +254,7 @@ func (d *DeadLetterQueue) DiskBytes() int64 {
 	entries, _ := os.ReadDir(d.dir)
 	var total int64
 	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+		if !e.IsDir() && isBatchFile(e.Name()) {
 			if info, err := e.Info(); err == nil {
 				total += info.Size()
 			}
@@ -136,6 +263,81 @@ func (d *DeadLetterQueue) DiskBytes() int64 {
 	return total
 }
 
+// Stats reports file count, total bytes, oldest-file age and the replay
+// failure streak in a single directory scan. The age is taken from the
+// batch_<nanos>_ filename prefix because replay failures touch the file's
+// mtime to reset backoff; files without the prefix fall back to mtime.
+func (d *DeadLetterQueue) Stats() DLQStats {
+	st := DLQStats{FailureStreak: d.failureStreak.Load()}
+	d.mu.Lock()
+	entries, err := os.ReadDir(d.dir)
+	d.mu.Unlock()
+	if err != nil {
+		slog.Error("DLQ: failed to read directory", "error", err)
+		return st
+	}
+
+	now := time.Now()
+	var oldest time.Time
+	for _, e := range entries {
+		if e.IsDir() || !isBatchFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		st.Files++
+		st.Bytes += info.Size()
+		created, ok := batchFileTime(e.Name())
+		if !ok {
+			created = info.ModTime()
+		}
+		if oldest.IsZero() || created.Before(oldest) {
+			oldest = created
+		}
+	}
+	if !oldest.IsZero() {
+		st.OldestAge = max(now.Sub(oldest), 0)
+	}
+	return st
+}
+
+// Batch file extensions. Enqueue writes zstd-compressed batches; plain
+// JSON files from earlier versions are still replayed.
+const (
+	batchExt     = ".json"
+	zstdBatchExt = ".json.zst"
+)
+
+// isBatchFile reports whether name is a DLQ batch file, compressed or not.
+func isBatchFile(name string) bool {
+	return strings.HasSuffix(name, zstdBatchExt) || filepath.Ext(name) == batchExt
+}
+
+// readBatch returns a batch file's JSON, decompressing .json.zst files.
+func readBatch(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from d.dir (operator-controlled) + files we previously wrote
+	if err != nil || !strings.HasSuffix(path, zstdBatchExt) {
+		return data, err
+	}
+	return compress.Decompress(data)
+}
+
+// batchFileTime parses the enqueue time from a batch_<nanos>_* name.
+func batchFileTime(name string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(name, "batch_")
+	if !ok {
+		return time.Time{}, false
+	}
+	nanos, _, _ := strings.Cut(rest, "_")
+	n, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSuffix(nanos, zstdBatchExt), batchExt), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, n), true
+}
+
 // Enqueue serializes the given batch to JSON and writes it to disk.
 // Enforces file count and disk size limits (FIFO eviction when exceeded).
 //
@@ -148,17 +350,23 @@ func (d *DeadLetterQueue) Enqueue(batch any) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	data, err := json.Marshal(batch)
+	raw, err := json.Marshal(batch)
 	if err != nil {
 		return fmt.Errorf("DLQ: failed to marshal batch: %w", err)
 	}
+	// Batches are repetitive JSON; zstd shrinks them several-fold, which is
+	// what keeps a long outage inside the disk cap.
+	data := compress.Compress(raw)
 
 	// Enforce limits before writing a new file.
-	d.enforceLimits(int64(len(data)))
+	if err := d.enforceLimits(int64(len(data))); err != nil {
+		return err
+	}
 
-	// batch_<nanos>_*.json — CreateTemp replaces `*` with a unique suffix so
-	// two goroutines in the same nanosecond still get distinct files.
-	pattern := fmt.Sprintf("batch_%d_*.json", time.Now().UnixNano())
+	// batch_<nanos>_*.json.zst — CreateTemp replaces `*` with a unique
+	// suffix so two goroutines in the same nanosecond still get distinct
+	// files.
+	pattern := fmt.Sprintf("batch_%d_*%s", time.Now().UnixNano(), zstdBatchExt)
 	f, err := os.CreateTemp(d.dir, pattern)
 	if err != nil {
 		return fmt.Errorf("DLQ: failed to create file: %w", err)
@@ -193,23 +401,24 @@ func (d *DeadLetterQueue) Enqueue(batch any) error {
 		return fmt.Errorf("DLQ: failed to close %s: %w", path, err)
 	}
 
-	slog.Warn("📦 Batch written to DLQ", "file", filename, "bytes", len(data))
+	slog.Warn("📦 Batch written to DLQ", "file", filename, "bytes", len(data), "json_bytes", len(raw))
 	if d.onEnqueue != nil {
 		d.onEnqueue()
 	}
 	return nil
 }
 
-// enforceLimits removes oldest files to stay within maxFiles and maxDiskMB.
-// Must be called with d.mu held.
-func (d *DeadLetterQueue) enforceLimits(incomingBytes int64) {
-	if d.maxFiles == 0 && d.maxDiskMB == 0 {
-		return
+// enforceLimits removes oldest files to stay within maxFiles and maxBytes,
+// or under the reject_new policy returns ErrDLQFull instead. Must be called
+// with d.mu held.
+func (d *DeadLetterQueue) enforceLimits(incomingBytes int64) error {
+	if d.maxFiles == 0 && d.maxBytes == 0 {
+		return nil
 	}
 
 	entries, err := os.ReadDir(d.dir)
 	if err != nil {
-		return
+		return nil
 	}
 
 	// Collect JSON files sorted by name (timestamp-prefixed → chronological).
@@ -220,7 +429,7 @@ func (d *DeadLetterQueue) enforceLimits(incomingBytes int64) {
 	var files []fileInfo
 	var totalBytes int64
 	for _, e := range entries {
-		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+		if e.IsDir() || !isBatchFile(e.Name()) {
 			continue
 		}
 		if info, err := e.Info(); err == nil {
@@ -229,7 +438,21 @@ func (d *DeadLetterQueue) enforceLimits(incomingBytes int64) {
 		}
 	}
 
-	maxBytes := d.maxDiskMB * 1024 * 1024
+	maxBytes := d.maxBytes
+	if d.rejectNew {
+		if (d.maxFiles > 0 && len(files) >= d.maxFiles) || (maxBytes > 0 && totalBytes+incomingBytes > maxBytes) {
+			d.rejected.Add(1)
+			if d.metricsTel != nil && d.metricsTel.DLQRejectedTotal != nil {
+				d.metricsTel.DLQRejectedTotal.Inc()
+			}
+			slog.Warn("dlq: full, rejecting new batch",
+				"files", len(files), "bytes", totalBytes, "incoming_bytes", incomingBytes,
+				"max_files", d.maxFiles, "max_bytes", maxBytes)
+			return ErrDLQFull
+		}
+		return nil
+	}
+
 	var evictedThisCall int
 	var evictedBytesThisCall int64
 	i := 0
@@ -267,9 +490,100 @@ func (d *DeadLetterQueue) enforceLimits(incomingBytes int64) {
 			"files", evictedThisCall,
 			"bytes", evictedBytesThisCall,
 			"max_files", d.maxFiles,
-			"max_disk_mb", d.maxDiskMB,
+			"max_bytes", maxBytes,
 		)
 	}
+	return nil
+}
+
+// expireOld removes batches enqueued more than maxAge ago. Files without
+// the batch_<nanos>_ prefix age by mtime.
+func (d *DeadLetterQueue) expireOld() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.maxAge <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-d.maxAge)
+	var expired int
+	for _, e := range entries {
+		if e.IsDir() || !isBatchFile(e.Name()) {
+			continue
+		}
+		created, ok := batchFileTime(e.Name())
+		if !ok {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			created = info.ModTime()
+		}
+		if !created.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.dir, e.Name())); err != nil {
+			continue
+		}
+		delete(d.retries, e.Name())
+		expired++
+		if d.metricsTel != nil && d.metricsTel.DLQExpiredTotal != nil {
+			d.metricsTel.DLQExpiredTotal.Inc()
+		}
+	}
+	if expired > 0 {
+		d.expired.Add(int64(expired))
+		slog.Warn("dlq: expired batches older than max age", "files", expired, "max_age", d.maxAge)
+	}
+}
+
+// QuarantineDir is the subdirectory of the DLQ directory that holds batches
+// that failed maxRetries replays. Nothing replays or evicts from it except
+// the maxFiles cap; operators inspect and delete the files by hand.
+const QuarantineDir = "quarantine"
+
+// quarantine moves a poison file out of the replay set into QuarantineDir,
+// evicting the oldest quarantined files beyond maxFiles. Must be called
+// with d.mu held.
+func (d *DeadLetterQueue) quarantine(name string) {
+	delete(d.retries, name)
+	qdir := filepath.Join(d.dir, QuarantineDir)
+	src := filepath.Join(d.dir, name)
+	if err := os.MkdirAll(qdir, 0o750); err != nil {
+		slog.Error("DLQ: failed to create quarantine directory, dropping file", "file", name, "error", err)
+		_ = os.Remove(src)
+		return
+	}
+	if err := os.Rename(src, filepath.Join(qdir, name)); err != nil {
+		slog.Error("DLQ: failed to quarantine file, dropping it", "file", name, "error", err)
+		_ = os.Remove(src)
+		return
+	}
+	d.quarantined.Add(1)
+	if d.metricsTel != nil && d.metricsTel.DLQQuarantinedTotal != nil {
+		d.metricsTel.DLQQuarantinedTotal.Inc()
+	}
+
+	if d.maxFiles == 0 {
+		return
+	}
+	entries, err := os.ReadDir(qdir) // sorted by name, so oldest first
+	if err != nil {
+		return
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && isBatchFile(e.Name()) {
+			files = append(files, e.Name())
+		}
+	}
+	for i := 0; i < len(files)-d.maxFiles; i++ {
+		_ = os.Remove(filepath.Join(qdir, files[i]))
+		slog.Warn("🗑️  DLQ quarantine eviction", "file", files[i])
+	}
 }
 
 // Size returns the number of files currently in the DLQ directory.
@@ -285,7 +599,7 @@ func (d *DeadLetterQueue) Size() int {
 
 	count := 0
 	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+		if !e.IsDir() && isBatchFile(e.Name()) {
 			count++
 		}
 	}
@@ -319,106 +633,262 @@ func (d *DeadLetterQueue) replayWorker() {
 // processFiles reads all JSON files in the DLQ directory and attempts to replay them
 // with exponential backoff based on per-file retry count.
 func (d *DeadLetterQueue) processFiles() {
+	d.replay(false)
+}
+
+// replay runs one replay pass. force skips the per-file backoff; the
+// per-tick cap and max retries still apply.
+func (d *DeadLetterQueue) replay(force bool) DLQReplayResult {
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+
+	d.expireOld()
+
+	started := time.Now()
+	var res DLQReplayResult
 	d.mu.Lock()
 	entries, err := os.ReadDir(d.dir)
 	d.mu.Unlock()
 
 	if err != nil {
 		slog.Error("DLQ: failed to read directory for replay", "error", err)
-		return
+		return res
+	}
+
+	units := d.replayUnits(entries, force)
+	if len(units) > 0 {
+		d.replaying.Store(true)
+		d.mu.Lock()
+		if d.drainStart.IsZero() {
+			d.drainStart = started
+		}
+		workers := min(d.replayConcurrency, len(units))
+		d.mu.Unlock()
+
+		work := make(chan []string)
+		var resMu sync.Mutex
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Go(func() {
+				for names := range work {
+					r := d.replayUnit(names)
+					resMu.Lock()
+					res.Replayed += r.Replayed
+					res.Coalesced += r.Coalesced
+					res.Failed += r.Failed
+					res.Quarantined += r.Quarantined
+					resMu.Unlock()
+				}
+			})
+		}
+		for _, names := range units {
+			work <- names
+		}
+		close(work)
+		wg.Wait()
+		d.replaying.Store(false)
 	}
 
+	if res.Replayed > 0 {
+		slog.Info("🔁 DLQ replay cycle complete", "replayed", res.Replayed, "coalesced", res.Coalesced)
+	}
+	remaining := d.Size()
 	d.mu.Lock()
-	replayCap := d.maxReplayPerTick
+	d.drainReplayed += int64(res.Replayed)
+	if remaining == 0 {
+		d.drainStart, d.drainReplayed = time.Time{}, 0
+	}
+	d.lastPass, d.lastPassAt, d.lastPassDuration = res, time.Now(), time.Since(started)
 	d.mu.Unlock()
+	return res
+}
 
-	replayed := 0
-	attempts := 0
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
+// replayUnits picks the files due this pass, oldest first, in units of one
+// replay call each: a file alone, or a group of small never-failed files
+// when coalescing is on. At most maxReplayPerTick units are returned.
+// Backoff-skipped files don't count against the cap — they cost nothing.
+func (d *DeadLetterQueue) replayUnits(entries []os.DirEntry, force bool) [][]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	replayCap := d.maxReplayPerTick
+	coalesce := d.coalesceFn != nil
+
+	var units [][]string
+	var group []string
+	flush := func() {
+		if len(group) > 0 {
+			units = append(units, group)
+			group = nil
 		}
-		// Cap actual replayFn calls per tick so a 10k-file backlog after an
-		// outage doesn't hammer the just-restarted DB. Backoff-skipped files
-		// don't count — they cost nothing.
-		if replayCap > 0 && attempts >= replayCap {
-			slog.Debug("DLQ: max replay-per-tick cap reached", "cap", replayCap)
+	}
+	for _, entry := range entries {
+		if replayCap > 0 && len(units) >= replayCap {
 			break
 		}
-
+		if entry.IsDir() || !isBatchFile(entry.Name()) {
+			continue
+		}
 		name := entry.Name()
-
-		// Check max retries — permanently drop if exceeded.
-		d.mu.Lock()
-		retries := d.retries[name]
-		if d.maxRetries > 0 && retries >= d.maxRetries {
-			path := filepath.Join(d.dir, name)
-			_ = os.Remove(path)
-			delete(d.retries, name)
-			d.mu.Unlock()
-			slog.Error("DLQ: max retries exceeded, dropping file", "file", name, "retries", retries)
+		info, err := entry.Info()
+		if err != nil {
 			continue
 		}
-		d.mu.Unlock()
+		retries := d.retries[name]
 
 		// Exponential backoff: wait 2^retries × base interval before retrying.
-		if retries > 0 {
+		if retries > 0 && !force {
 			backoff := time.Duration(math.Pow(2, float64(retries-1))) * d.interval
 			const maxBackoff = 30 * time.Minute
 			if backoff > maxBackoff {
 				backoff = maxBackoff
 			}
 			// Skip this file until enough time has elapsed.
-			if info, err := entry.Info(); err == nil {
-				if time.Since(info.ModTime()) < backoff {
-					continue
-				}
+			if time.Since(info.ModTime()) < backoff {
+				continue
 			}
 		}
 
-		path := filepath.Join(d.dir, name)
-		data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from d.dir (operator-controlled) + files we previously wrote
+		if !coalesce || retries > 0 || info.Size() > d.coalesceMaxBytes {
+			units = append(units, []string{name})
+			continue
+		}
+		group = append(group, name)
+		if len(group) >= d.coalesceMaxFiles {
+			flush()
+		}
+	}
+	if replayCap == 0 || len(units) < replayCap {
+		flush()
+	}
+	if replayCap > 0 && len(units) >= replayCap {
+		slog.Debug("DLQ: max replay-per-tick cap reached", "cap", replayCap)
+	}
+	return units
+}
 
+// replayUnit replays one unit from replayUnits: a single file through
+// replayFn, or a group through coalesceFn.
+func (d *DeadLetterQueue) replayUnit(names []string) DLQReplayResult {
+	var res DLQReplayResult
+	read := names[:0:0]
+	var batches [][]byte
+	for _, name := range names {
+		data, err := readBatch(filepath.Join(d.dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue // evicted or replayed since the directory was listed
+		}
 		if err != nil {
-			slog.Error("DLQ: failed to read file", "file", name, "error", err)
+			// A torn or corrupt file backs off and is quarantined like a
+			// batch the sink rejects, rather than failing every tick.
+			res.Failed++
+			if d.replayFailed(name, fmt.Errorf("read: %w", err)) {
+				res.Quarantined++
+			}
 			continue
 		}
+		read = append(read, name)
+		batches = append(batches, data)
+	}
 
-		attempts++
-		if err := d.replayFn(data); err != nil {
-			d.mu.Lock()
-			d.retries[name]++
-			newRetries := d.retries[name]
-			cb := d.onFailure
-			d.mu.Unlock()
-			slog.Warn("DLQ: replay failed, backing off", "file", name, "retries", newRetries, "error", err)
-			if cb != nil {
-				cb()
+	switch len(read) {
+	case 0:
+		return res
+	case 1:
+		if err := d.replayFn(batches[0]); err != nil {
+			res.Failed++
+			if d.replayFailed(read[0], err) {
+				res.Quarantined++
 			}
-			// Touch the file to reset the backoff timer.
-			now := time.Now()
-			_ = os.Chtimes(path, now, now)
-			continue
+			return res
 		}
+		if d.replaySucceeded(read[0]) {
+			res.Replayed++
+		}
+		return res
+	}
 
-		// Success — remove the file and clear retry counter.
-		d.mu.Lock()
-		var successCb func()
-		if err := os.Remove(path); err != nil {
-			slog.Error("DLQ: failed to remove replayed file", "file", name, "error", err)
-		} else {
-			delete(d.retries, name)
-			replayed++
-			successCb = d.onSuccess
-			slog.Info("✅ DLQ file replayed and removed", "file", name)
+	d.mu.Lock()
+	fn := d.coalesceFn
+	d.mu.Unlock()
+	if err := fn(batches); err != nil {
+		d.groupFailed(read, err)
+		res.Failed += len(read)
+		return res
+	}
+	for _, name := range read {
+		if d.replaySucceeded(name) {
+			res.Replayed++
+			res.Coalesced++
 		}
-		d.mu.Unlock()
-		if successCb != nil {
-			successCb()
+	}
+	return res
+}
+
+// replayFailed records a failed replay of one file, quarantining it at
+// maxRetries, and reports whether it was quarantined.
+func (d *DeadLetterQueue) replayFailed(name string, err error) bool {
+	d.failureStreak.Add(1)
+	d.mu.Lock()
+	d.retries[name]++
+	newRetries := d.retries[name]
+	cb := d.onFailure
+	poison := d.maxRetries > 0 && newRetries >= d.maxRetries
+	if poison {
+		d.quarantine(name)
+	}
+	d.mu.Unlock()
+	if cb != nil {
+		cb()
+	}
+	if poison {
+		slog.Error("DLQ: max retries exceeded, file quarantined", "file", name, "retries", newRetries, "error", err)
+		return true
+	}
+	slog.Warn("DLQ: replay failed, backing off", "file", name, "retries", newRetries, "error", err)
+	// Touch the file to reset the backoff timer.
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(d.dir, name), now, now)
+	return false
+}
+
+// groupFailed marks every file of a failed coalesced group as retried once
+// so they back off and then replay alone.
+func (d *DeadLetterQueue) groupFailed(names []string, err error) {
+	d.failureStreak.Add(1)
+	now := time.Now()
+	d.mu.Lock()
+	cb := d.onFailure
+	for _, name := range names {
+		d.retries[name] = max(d.retries[name], 1)
+		_ = os.Chtimes(filepath.Join(d.dir, name), now, now)
+	}
+	d.mu.Unlock()
+	if cb != nil {
+		for range names {
+			cb()
 		}
 	}
+	slog.Warn("DLQ: coalesced replay failed, files will replay alone", "files", len(names), "error", err)
+}
 
-	if replayed > 0 {
-		slog.Info("🔁 DLQ replay cycle complete", "replayed", replayed)
+// replaySucceeded removes a replayed file and clears its retry counter,
+// reporting whether it was removed.
+func (d *DeadLetterQueue) replaySucceeded(name string) bool {
+	d.failureStreak.Store(0)
+	d.mu.Lock()
+	var successCb func()
+	removed := false
+	if err := os.Remove(filepath.Join(d.dir, name)); err != nil {
+		slog.Error("DLQ: failed to remove replayed file", "file", name, "error", err)
+	} else {
+		delete(d.retries, name)
+		removed = true
+		successCb = d.onSuccess
+		slog.Info("✅ DLQ file replayed and removed", "file", name)
+	}
+	d.mu.Unlock()
+	if successCb != nil {
+		successCb()
 	}
+	return removed
 }
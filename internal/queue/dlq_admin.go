@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrDLQFileNotFound is returned by Remove for a name that is not a queued
+// batch file.
+var ErrDLQFileNotFound = errors.New("dlq: batch file not found")
+
+// DLQFile describes one queued batch.
+type DLQFile struct {
+	Name    string
+	Bytes   int64
+	Age     time.Duration // since enqueue, from the batch_<nanos>_ prefix
+	Retries int           // failed replays since startup
+}
+
+// DLQReplayResult counts what one replay pass did.
+type DLQReplayResult struct {
+	Replayed    int
+	Coalesced   int // of Replayed, written as part of a coalesced group
+	Failed      int
+	Quarantined int // of Failed, moved to QuarantineDir at DLQ_MAX_RETRIES
+}
+
+// DLQProgress reports how far the DLQ is through draining its backlog.
+type DLQProgress struct {
+	Replaying      bool      // a replay pass is running now
+	DrainStart     time.Time // when the current backlog started replaying; zero when the DLQ is empty
+	Replayed       int64     // files replayed since DrainStart
+	RemainingFiles int
+	RemainingBytes int64
+	// FilesPerSecond is Replayed over the time since DrainStart, pauses
+	// between passes included, so ETA is wall-clock time to empty at the
+	// pace so far. Both are zero until a file has been replayed.
+	FilesPerSecond   float64
+	ETA              time.Duration
+	LastPass         DLQReplayResult
+	LastPassAt       time.Time // zero before the first pass
+	LastPassDuration time.Duration
+}
+
+// Files lists the queued batches, oldest first.
+func (d *DeadLetterQueue) Files() ([]DLQFile, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("DLQ: failed to read directory: %w", err)
+	}
+
+	now := time.Now()
+	files := []DLQFile{}
+	for _, e := range entries {
+		if e.IsDir() || !isBatchFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		created, ok := batchFileTime(e.Name())
+		if !ok {
+			created = info.ModTime()
+		}
+		files = append(files, DLQFile{
+			Name:    e.Name(),
+			Bytes:   info.Size(),
+			Age:     max(now.Sub(created), 0),
+			Retries: d.retries[e.Name()],
+		})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Age > files[j].Age })
+	return files, nil
+}
+
+// ReplayNow runs a replay pass immediately, ignoring per-file backoff, and
+// waits for it. The per-tick cap still applies, so a large backlog drains
+// over several calls rather than in one burst against the database.
+func (d *DeadLetterQueue) ReplayNow() DLQReplayResult {
+	res := d.replay(true)
+	slog.Info("DLQ: manual replay", "replayed", res.Replayed, "failed", res.Failed, "quarantined", res.Quarantined)
+	return res
+}
+
+// Progress reports the drain progress of the current backlog.
+func (d *DeadLetterQueue) Progress() DLQProgress {
+	st := d.Stats()
+	d.mu.Lock()
+	p := DLQProgress{
+		Replaying:      d.replaying.Load(),
+		DrainStart:     d.drainStart,
+		Replayed:       d.drainReplayed,
+		RemainingFiles: st.Files,
+		RemainingBytes: st.Bytes,
+		LastPass:       d.lastPass,
+		LastPassAt:     d.lastPassAt,
+
+		LastPassDuration: d.lastPassDuration,
+	}
+	d.mu.Unlock()
+	if p.DrainStart.IsZero() || p.Replayed == 0 {
+		return p
+	}
+	if elapsed := time.Since(p.DrainStart).Seconds(); elapsed > 0 {
+		p.FilesPerSecond = float64(p.Replayed) / elapsed
+		p.ETA = time.Duration(float64(p.RemainingFiles) / p.FilesPerSecond * float64(time.Second))
+	}
+	return p
+}
+
+// Remove deletes one queued batch, e.g. a poison batch the database will
+// never accept. name must be a bare file name as reported by Files.
+func (d *DeadLetterQueue) Remove(name string) error {
+	if name == "" || filepath.Base(name) != name || !isBatchFile(name) || strings.HasPrefix(name, ".") {
+		return ErrDLQFileNotFound
+	}
+	// Wait out an in-flight replay so the file is not deleted under replayFn.
+	d.replayMu.Lock()
+	defer d.replayMu.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := os.Remove(filepath.Join(d.dir, name)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrDLQFileNotFound
+		}
+		return fmt.Errorf("DLQ: failed to remove %s: %w", name, err)
+	}
+	delete(d.retries, name)
+	slog.Warn("🗑️  DLQ batch removed by operator", "file", name)
+	return nil
+}
@@ -0,0 +1,66 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDLQ_AdminFilesReplayRemove covers the operator surface: listing
+// oldest first, a forced replay that skips backoff, and removing a poison
+// batch without escaping the DLQ directory.
+func TestDLQ_AdminFilesReplayRemove(t *testing.T) {
+	dir := t.TempDir()
+	poison := fmt.Sprintf("batch_%d_1.json", time.Now().Add(-time.Hour).UnixNano())
+	q, err := NewDLQWithLimits(dir, time.Hour, func(data []byte) error {
+		if string(data) == `"poison"` {
+			return errors.New("rejected")
+		}
+		return nil
+	}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+
+	if err := os.WriteFile(filepath.Join(dir, poison), []byte(`"poison"`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := q.Enqueue("ok"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	files, err := q.Files()
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Files = %+v, %v; want 2", files, err)
+	}
+	if files[0].Name != poison || files[0].Age < 59*time.Minute || files[0].Bytes != 8 {
+		t.Errorf("oldest = %+v, want the poison batch ~1h old", files[0])
+	}
+
+	if res := q.ReplayNow(); res.Replayed != 1 || res.Failed != 1 {
+		t.Errorf("first ReplayNow = %+v, want 1 replayed, 1 failed", res)
+	}
+	// The poison batch is now in backoff; a forced replay tries it anyway.
+	if res := q.ReplayNow(); res.Failed != 1 {
+		t.Errorf("second ReplayNow = %+v, want backoff skipped", res)
+	}
+	if files, _ := q.Files(); len(files) != 1 || files[0].Retries != 2 {
+		t.Errorf("after replay Files = %+v, want poison with 2 retries", files)
+	}
+
+	for _, bad := range []string{"", "../" + poison, "x/" + poison, "missing.json", "notes.txt"} {
+		if err := q.Remove(bad); !errors.Is(err, ErrDLQFileNotFound) {
+			t.Errorf("Remove(%q) = %v, want ErrDLQFileNotFound", bad, err)
+		}
+	}
+	if err := q.Remove(poison); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if q.Size() != 0 {
+		t.Errorf("Size = %d after Remove, want 0", q.Size())
+	}
+}
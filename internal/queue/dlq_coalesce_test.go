@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDLQ_CoalescedReplay verifies small files replay in groups that count
+// as one call against the per-tick cap, and that a failed group falls back
+// to replaying its files alone.
+func TestDLQ_CoalescedReplay(t *testing.T) {
+	var single, grouped atomic.Int64
+	var failGroups atomic.Bool
+	var sizes []int
+	var mu sync.Mutex
+	q, err := NewDLQWithLimits(t.TempDir(), time.Hour, func([]byte) error {
+		single.Add(1)
+		return nil
+	}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+	q.SetCoalesce(func(batches [][]byte) error {
+		grouped.Add(1)
+		if failGroups.Load() {
+			return errReplayFailed
+		}
+		mu.Lock()
+		sizes = append(sizes, len(batches))
+		mu.Unlock()
+		return nil
+	}, 4, 1<<10)
+	q.SetMaxReplayPerTick(2)
+
+	for i := range 10 {
+		if err := q.Enqueue(map[string]int{"i": i}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	res := q.ReplayNow()
+	if res.Replayed != 8 || res.Coalesced != 8 || grouped.Load() != 2 || single.Load() != 0 {
+		t.Fatalf("first pass = %+v, groups %d, singles %d; want 2 groups of 4", res, grouped.Load(), single.Load())
+	}
+	if q.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", q.Size())
+	}
+
+	failGroups.Store(true)
+	if res := q.ReplayNow(); res.Failed != 2 || res.Quarantined != 0 {
+		t.Fatalf("failed group pass = %+v, want 2 failed", res)
+	}
+	files, _ := q.Files()
+	for _, f := range files {
+		if f.Retries != 1 {
+			t.Errorf("%s retries = %d, want 1", f.Name, f.Retries)
+		}
+	}
+
+	// Retried files replay alone, so the group function is not called again.
+	if res := q.ReplayNow(); res.Replayed != 2 || res.Coalesced != 0 || single.Load() != 2 || grouped.Load() != 3 {
+		t.Fatalf("fallback pass = %+v, singles %d, groups %d", res, single.Load(), grouped.Load())
+	}
+	if p := q.Progress(); p.RemainingFiles != 0 || !p.DrainStart.IsZero() || p.Replayed != 0 || p.LastPass.Replayed != 2 {
+		t.Errorf("progress after drain = %+v", p)
+	}
+}
+
+// TestDLQ_ReplayConcurrency verifies a pass runs up to the configured
+// number of replay calls at once.
+func TestDLQ_ReplayConcurrency(t *testing.T) {
+	var running, peak atomic.Int64
+	q, err := NewDLQWithLimits(t.TempDir(), time.Hour, func([]byte) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+	q.SetReplayConcurrency(4)
+
+	for i := range 12 {
+		if err := q.Enqueue(map[string]int{"i": i}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if res := q.ReplayNow(); res.Replayed != 12 {
+		t.Fatalf("ReplayNow = %+v, want 12 replayed", res)
+	}
+	if got := peak.Load(); got < 2 || got > 4 {
+		t.Errorf("peak concurrent replays = %d, want 2..4", got)
+	}
+}
+
+// TestDLQ_ProgressETA verifies the drain rate and ETA while a backlog
+// remains.
+func TestDLQ_ProgressETA(t *testing.T) {
+	q, err := NewDLQWithLimits(t.TempDir(), time.Hour, func([]byte) error { return nil }, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+	q.SetMaxReplayPerTick(3)
+	for i := range 9 {
+		if err := q.Enqueue(map[string]int{"i": i}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	if p := q.Progress(); p.RemainingFiles != 9 || p.FilesPerSecond != 0 || p.ETA != 0 || !p.LastPassAt.IsZero() {
+		t.Errorf("progress before replay = %+v", p)
+	}
+
+	q.ReplayNow()
+	time.Sleep(10 * time.Millisecond)
+	p := q.Progress()
+	if p.Replayed != 3 || p.RemainingFiles != 6 || p.DrainStart.IsZero() || p.LastPassAt.IsZero() {
+		t.Fatalf("progress = %+v", p)
+	}
+	if p.FilesPerSecond <= 0 || p.ETA <= 0 {
+		t.Errorf("rate = %v, eta = %v; want both positive", p.FilesPerSecond, p.ETA)
+	}
+}
@@ -0,0 +1,99 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDLQ_CompressedAndLegacyFilesReplay verifies Enqueue writes
+// zstd-compressed .json.zst files and replay hands the JSON to replayFn for
+// both those and plain .json files left by earlier versions.
+func TestDLQ_CompressedAndLegacyFilesReplay(t *testing.T) {
+	dir := t.TempDir()
+	var got []string
+	q, err := NewDLQ(dir, time.Hour, func(data []byte) error {
+		var v struct{ Body string }
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Errorf("replayFn got non-JSON %q: %v", data, err)
+		}
+		got = append(got, v.Body[:6])
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewDLQ: %v", err)
+	}
+	defer q.Stop()
+
+	body := strings.Repeat("compressed log line ", 500)
+	if err := q.Enqueue(struct{ Body string }{body}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	legacy := "batch_1_1.json"
+	if err := os.WriteFile(filepath.Join(dir, legacy), []byte(`{"Body":"legacy plain"}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	files, _ := q.Files()
+	if len(files) != 2 {
+		t.Fatalf("Files = %+v, want 2", files)
+	}
+	for _, f := range files {
+		if f.Name != legacy && (!strings.HasSuffix(f.Name, zstdBatchExt) || f.Bytes >= int64(len(body))/10) {
+			t.Errorf("enqueued file = %+v, want a .json.zst under a tenth of the JSON", f)
+		}
+	}
+
+	q.processFiles()
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "compre" || got[1] != "legacy" {
+		t.Errorf("replayed = %v, want both batches", got)
+	}
+	if q.Size() != 0 {
+		t.Errorf("Size = %d after replay, want 0", q.Size())
+	}
+}
+
+// TestDLQ_CorruptCompressedFileQuarantined verifies a .json.zst batch that
+// cannot be decompressed backs off and is quarantined like a rejected one,
+// and does not stop ReadPending from reaching the batches next to it.
+func TestDLQ_CorruptCompressedFileQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	replayed := 0
+	q, err := NewDLQWithLimits(dir, time.Hour, func([]byte) error { replayed++; return nil }, 0, 0, 2)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+
+	torn := fmt.Sprintf("batch_%d_1%s", time.Now().UnixNano(), zstdBatchExt)
+	if err := os.WriteFile(filepath.Join(dir, torn), []byte("not zstd at all"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := q.Enqueue(map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	var pending int
+	if err := q.ReadPending(time.Time{}, 0, func([]byte) error { pending++; return nil }); err != nil || pending != 1 {
+		t.Errorf("ReadPending = %d batches, %v; want the readable one", pending, err)
+	}
+
+	if res := q.ReplayNow(); res.Failed != 1 || res.Replayed != 1 || res.Quarantined != 0 {
+		t.Fatalf("first ReplayNow = %+v, want the torn file failed once", res)
+	}
+	if res := q.ReplayNow(); res.Failed != 1 || res.Quarantined != 1 {
+		t.Fatalf("second ReplayNow = %+v, want the torn file quarantined", res)
+	}
+	if replayed != 1 || q.Size() != 0 || q.QuarantinedCount() != 1 {
+		t.Errorf("replayed = %d, Size = %d, QuarantinedCount = %d; want 1, 0, 1", replayed, q.Size(), q.QuarantinedCount())
+	}
+	if _, err := os.Stat(filepath.Join(dir, QuarantineDir, torn)); err != nil {
+		t.Errorf("quarantined file missing: %v", err)
+	}
+}
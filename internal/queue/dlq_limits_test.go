@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDLQ_RejectNewPolicy verifies reject_new keeps the backlog already on
+// disk and refuses the batch that would break the byte cap.
+func TestDLQ_RejectNewPolicy(t *testing.T) {
+	q, err := NewDLQWithLimits(t.TempDir(), time.Hour, func([]byte) error { return nil }, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+	q.SetOverflowPolicy(OverflowRejectNew)
+	if err := q.Enqueue("0123456789"); err != nil {
+		t.Fatalf("first Enqueue: %v", err)
+	}
+	// Room for one batch, not two.
+	q.SetMaxBytes(q.DiskBytes() + 1)
+	if err := q.Enqueue("0123456789"); !errors.Is(err, ErrDLQFull) {
+		t.Fatalf("Enqueue past cap = %v, want ErrDLQFull", err)
+	}
+	if q.Size() != 1 || q.RejectedCount() != 1 || q.EvictedCount() != 0 {
+		t.Errorf("Size = %d, rejected = %d, evicted = %d; want 1, 1, 0", q.Size(), q.RejectedCount(), q.EvictedCount())
+	}
+
+	// drop_oldest makes room instead.
+	q.SetOverflowPolicy(OverflowDropOldest)
+	if err := q.Enqueue("0123456789"); err != nil {
+		t.Fatalf("drop_oldest Enqueue: %v", err)
+	}
+	if q.Size() != 1 || q.EvictedCount() != 1 {
+		t.Errorf("Size = %d, evicted = %d; want 1, 1", q.Size(), q.EvictedCount())
+	}
+}
+
+// TestDLQ_MaxAgeExpiry verifies batches past the max age are removed at the
+// start of a replay pass, before they are replayed, judged by enqueue time.
+func TestDLQ_MaxAgeExpiry(t *testing.T) {
+	dir := t.TempDir()
+	var replays int
+	q, err := NewDLQ(dir, time.Hour, func([]byte) error { replays++; return errors.New("db down") })
+	if err != nil {
+		t.Fatalf("NewDLQ: %v", err)
+	}
+	defer q.Stop()
+	q.SetMaxAge(30 * time.Minute)
+
+	stale := fmt.Sprintf("batch_%d_1.json", time.Now().Add(-time.Hour).UnixNano())
+	if err := os.WriteFile(filepath.Join(dir, stale), []byte(`[]`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := q.Enqueue([]int{1}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	q.processFiles()
+	if replays != 1 || q.ExpiredCount() != 1 || q.Size() != 1 {
+		t.Errorf("replays = %d, expired = %d, Size = %d; want 1, 1, 1", replays, q.ExpiredCount(), q.Size())
+	}
+	if _, err := os.Stat(filepath.Join(dir, stale)); !os.IsNotExist(err) {
+		t.Errorf("stale batch still on disk: %v", err)
+	}
+}
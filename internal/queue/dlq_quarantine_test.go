@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDLQ_PoisonFileQuarantined verifies a batch that fails maxRetries
+// replays leaves the replay set for quarantine/ instead of being retried
+// forever, and that the quarantine is capped at maxFiles.
+func TestDLQ_PoisonFileQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewDLQWithLimits(dir, time.Hour, func([]byte) error { return errors.New("malformed") }, 1, 0, 3)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+
+	if err := q.Enqueue("poison"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	files, _ := q.Files()
+	if len(files) != 1 {
+		t.Fatalf("Files = %+v", files)
+	}
+	name := files[0].Name
+
+	// The regular tick honours backoff: a second pass right after the first
+	// failure skips the file.
+	q.processFiles()
+	if res := q.replay(false); res.Failed != 0 {
+		t.Errorf("replay inside backoff = %+v, want skipped", res)
+	}
+	for i, want := range []int{0, 1} {
+		if res := q.ReplayNow(); res.Failed != 1 || res.Quarantined != want {
+			t.Fatalf("ReplayNow %d = %+v, want quarantined %d", i, res, want)
+		}
+	}
+	if q.Size() != 0 || q.QuarantinedCount() != 1 {
+		t.Errorf("Size = %d, QuarantinedCount = %d; want 0, 1", q.Size(), q.QuarantinedCount())
+	}
+	if _, err := os.Stat(filepath.Join(dir, QuarantineDir, name)); err != nil {
+		t.Errorf("quarantined file missing: %v", err)
+	}
+	if res := q.ReplayNow(); res != (DLQReplayResult{}) {
+		t.Errorf("quarantined file replayed again: %+v", res)
+	}
+
+	// A second poison batch pushes the first out of the capped quarantine.
+	if err := q.Enqueue("poison-2"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	for range 3 {
+		q.ReplayNow()
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, QuarantineDir))
+	if len(entries) != 1 || entries[0].Name() == name {
+		t.Errorf("quarantine = %v, want only the newer batch", entries)
+	}
+}
@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ReadPending hands fn the JSON of up to maxFiles queued batches enqueued
+// at or after since, newest first, without replaying or removing them. It
+// lets queries show data that is waiting for the database. A batch replayed
+// or evicted while being read, or one that cannot be read or decompressed,
+// is skipped; an error from fn stops the walk and is returned. maxFiles <= 0
+// reads every match.
+func (d *DeadLetterQueue) ReadPending(since time.Time, maxFiles int, fn func(data []byte) error) error {
+	d.mu.Lock()
+	entries, err := os.ReadDir(d.dir)
+	d.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("DLQ: failed to read directory: %w", err)
+	}
+	type pending struct {
+		name    string
+		created time.Time
+	}
+	var files []pending
+	for _, e := range entries {
+		if e.IsDir() || !isBatchFile(e.Name()) {
+			continue
+		}
+		created, ok := batchFileTime(e.Name())
+		if !ok {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			created = info.ModTime()
+		}
+		if !created.Before(since) {
+			files = append(files, pending{e.Name(), created})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].created.After(files[j].created) })
+	if maxFiles > 0 && len(files) > maxFiles {
+		files = files[:maxFiles]
+	}
+	for _, f := range files {
+		data, err := readBatch(filepath.Join(d.dir, f.name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			// One unreadable batch must not hide the others; replay
+			// quarantines it.
+			slog.Warn("DLQ: skipping unreadable file", "file", f.name, "error", err)
+			continue
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
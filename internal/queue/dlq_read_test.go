@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDLQ_ReadPending verifies reads go newest first, honour since and
+// maxFiles, and leave the batches queued.
+func TestDLQ_ReadPending(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewDLQ(dir, time.Hour, func([]byte) error { return nil })
+	if err != nil {
+		t.Fatalf("NewDLQ: %v", err)
+	}
+	defer q.Stop()
+	old := fmt.Sprintf("batch_%d_1.json", time.Now().Add(-time.Hour).UnixNano())
+	if err := os.WriteFile(filepath.Join(dir, old), []byte(`{"n":0}`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for n := 1; n <= 3; n++ {
+		if err := q.Enqueue(map[string]int{"n": n}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		time.Sleep(time.Millisecond) // distinct enqueue timestamps
+	}
+
+	read := func(since time.Time, maxFiles int) []int {
+		var got []int
+		if err := q.ReadPending(since, maxFiles, func(data []byte) error {
+			var v struct{ N int }
+			if err := json.Unmarshal(data, &v); err != nil {
+				return err
+			}
+			got = append(got, v.N)
+			return nil
+		}); err != nil {
+			t.Fatalf("ReadPending: %v", err)
+		}
+		return got
+	}
+	if got := read(time.Now().Add(-time.Minute), 0); fmt.Sprint(got) != "[3 2 1]" {
+		t.Errorf("recent = %v, want [3 2 1]", got)
+	}
+	if got := read(time.Time{}, 2); fmt.Sprint(got) != "[3 2]" {
+		t.Errorf("capped = %v, want [3 2]", got)
+	}
+	if q.Size() != 4 {
+		t.Errorf("Size = %d after reads, want 4", q.Size())
+	}
+}
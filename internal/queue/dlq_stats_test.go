@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDLQ_Stats_CountsBytesAndOldestAge checks the single-scan snapshot used
+// for DLQ growth metrics. The oldest age must come from the filename, not
+// mtime, because replay failures touch files to reset their backoff.
+func TestDLQ_Stats_CountsBytesAndOldestAge(t *testing.T) {
+	dir := t.TempDir()
+	q, err := NewDLQWithLimits(dir, time.Hour, func([]byte) error { return nil }, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+
+	if st := q.Stats(); st.Files != 0 || st.Bytes != 0 || st.OldestAge != 0 {
+		t.Fatalf("empty DLQ stats = %+v", st)
+	}
+
+	// A batch enqueued an hour ago whose mtime was just touched.
+	old := fmt.Sprintf("batch_%d_1.json", time.Now().Add(-time.Hour).UnixNano())
+	if err := os.WriteFile(filepath.Join(dir, old), []byte(`[]`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := q.Enqueue([]int{1, 2, 3}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	st := q.Stats()
+	if st.Files != 2 {
+		t.Errorf("Files = %d, want 2", st.Files)
+	}
+	if st.Bytes != q.DiskBytes() {
+		t.Errorf("Bytes = %d, want %d", st.Bytes, q.DiskBytes())
+	}
+	if st.OldestAge < 59*time.Minute || st.OldestAge > 61*time.Minute {
+		t.Errorf("OldestAge = %s, want ~1h", st.OldestAge)
+	}
+}
+
+// TestDLQ_Stats_FailureStreak verifies the streak climbs across files and
+// resets on the first successful replay.
+func TestDLQ_Stats_FailureStreak(t *testing.T) {
+	dir := t.TempDir()
+	var healthy atomic.Bool
+	replay := func([]byte) error {
+		if healthy.Load() {
+			return nil
+		}
+		return errReplayFailed
+	}
+	q, err := NewDLQWithLimits(dir, time.Hour, replay, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("NewDLQWithLimits: %v", err)
+	}
+	defer q.Stop()
+
+	for i := range 3 {
+		if err := q.Enqueue(map[string]int{"i": i}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	q.processFiles()
+	if got := q.Stats().FailureStreak; got != 3 {
+		t.Fatalf("FailureStreak after failed tick = %d, want 3", got)
+	}
+
+	// Files are now in backoff; a fresh batch is attempted immediately.
+	healthy.Store(true)
+	if err := q.Enqueue(map[string]int{"i": 99}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	q.processFiles()
+	if got := q.Stats().FailureStreak; got != 0 {
+		t.Fatalf("FailureStreak after success = %d, want 0", got)
+	}
+}
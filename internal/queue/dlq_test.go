@@ -2,7 +2,6 @@ package queue
 
 import (
 	"os"
-	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -50,7 +49,7 @@ func TestDLQ_ConcurrentEnqueue_NoFilenameCollision(t *testing.T) {
 	}
 	var count int
 	for _, e := range entries {
-		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+		if !e.IsDir() && isBatchFile(e.Name()) {
 			count++
 		}
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,10 +23,18 @@ type LiveSnapshot struct {
 	ServiceMap *storage.ServiceMapMetrics `json:"service_map"`
 }
 
-// clientFilter tracks a client's active service filter.
-// Empty string = all services (no filter).
+// clientFilter tracks a client's active service filter and the Scope of
+// its API key. Empty service = all services the scope allows.
 type clientFilter struct {
 	service string
+	scope   Scope
+}
+
+// snapshotKey groups clients that see the same snapshot.
+type snapshotKey struct {
+	tenant   string
+	services string // scope-narrowed services, comma-joined; "" = all
+	noMap    bool   // the scope restricts services; omit the service map
 }
 
 // EventHub manages WebSocket clients and pushes live data snapshots
@@ -137,10 +146,11 @@ func (h *EventHub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Check for initial service filter from query params
 	initialService := r.URL.Query().Get("service")
-	h.addClient(conn, initialService)
+	scope := ScopeFromContext(r.Context())
+	h.addClient(conn, initialService, scope)
 
 	// Send immediate snapshot so the client has data right away
-	h.sendSnapshotTo(conn, initialService)
+	h.sendSnapshotTo(conn, scope, initialService)
 
 	// Read loop: client can send {"service":"xxx"} to change filter
 	for {
@@ -160,9 +170,9 @@ func (h *EventHub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	_ = conn.Close(websocket.StatusNormalClosure, "bye")
 }
 
-func (h *EventHub) addClient(c *websocket.Conn, service string) {
+func (h *EventHub) addClient(c *websocket.Conn, service string, scope Scope) {
 	h.mu.Lock()
-	h.clients[c] = &clientFilter{service: service}
+	h.clients[c] = &clientFilter{service: service, scope: scope}
 	h.mu.Unlock()
 	if h.onConn != nil {
 		h.onConn()
@@ -200,25 +210,34 @@ func (h *EventHub) flushSnapshots() {
 		return
 	}
 
-	// Group clients by service filter
-	groups := make(map[string][]*websocket.Conn)
+	// Group clients by the tenant and services their snapshot covers
+	groups := make(map[snapshotKey][]*websocket.Conn)
 	for c, cf := range h.clients {
-		groups[cf.service] = append(groups[cf.service], c)
+		k := snapshotKey{
+			tenant:   cf.scope.Tenant,
+			services: strings.Join(cf.scope.narrow(cf.service), ","),
+			noMap:    len(cf.scope.Services) > 0,
+		}
+		groups[k] = append(groups[k], c)
 	}
 	h.mu.Unlock()
 
 	// Compute snapshots in parallel using errgroup
 	g, ctx := errgroup.WithContext(context.Background())
-	snapshotMap := make(map[string]*LiveSnapshot)
+	snapshotMap := make(map[snapshotKey]*LiveSnapshot)
 	var snapMu sync.Mutex
 
-	for service := range groups {
+	for k := range groups {
 		// Capture
 		g.Go(func() error {
-			snap := h.computeSnapshot(service)
+			var services []string
+			if k.services != "" {
+				services = strings.Split(k.services, ",")
+			}
+			snap := h.computeSnapshot(k.tenant, services, !k.noMap)
 			if snap != nil {
 				snapMu.Lock()
-				snapshotMap[service] = snap
+				snapshotMap[k] = snap
 				snapMu.Unlock()
 			}
 			return nil
@@ -230,8 +249,8 @@ func (h *EventHub) flushSnapshots() {
 	}
 
 	// Broadcast memoized snapshots to matching clients
-	for service, clients := range groups {
-		snap, ok := snapshotMap[service]
+	for k, clients := range groups {
+		snap, ok := snapshotMap[k]
 		if !ok {
 			continue
 		}
@@ -275,7 +294,7 @@ func (h *EventHub) flushBatches() {
 		// 1. Filter Logs
 		clientLogs := make([]LogEntry, 0)
 		for _, l := range logs {
-			if filter.service == "" || filter.service == l.ServiceName {
+			if filter.scope.Allows(l.TenantID, l.ServiceName) && (filter.service == "" || filter.service == l.ServiceName) {
 				clientLogs = append(clientLogs, l)
 			}
 		}
@@ -283,7 +302,7 @@ func (h *EventHub) flushBatches() {
 		// 2. Filter Metrics
 		clientMetrics := make([]MetricEntry, 0)
 		for _, m := range metrics {
-			if filter.service == "" || filter.service == m.ServiceName {
+			if filter.scope.Allows(m.TenantID, m.ServiceName) && (filter.service == "" || filter.service == m.ServiceName) {
 				clientMetrics = append(clientMetrics, m)
 			}
 		}
@@ -309,8 +328,8 @@ func (h *EventHub) sendBatch(conn *websocket.Conn, batchType string, data any) {
 }
 
 // sendSnapshotTo sends a snapshot to a single client.
-func (h *EventHub) sendSnapshotTo(conn *websocket.Conn, service string) {
-	snapshot := h.computeSnapshot(service)
+func (h *EventHub) sendSnapshotTo(conn *websocket.Conn, scope Scope, service string) {
+	snapshot := h.computeSnapshot(scope.Tenant, scope.narrow(service), len(scope.Services) == 0)
 	if snapshot == nil {
 		return
 	}
@@ -324,28 +343,26 @@ func (h *EventHub) sendSnapshotTo(conn *websocket.Conn, service string) {
 }
 
 // computeSnapshot queries the DB for the last 15 minutes of data,
-// optionally filtered by a single service name.
-func (h *EventHub) computeSnapshot(service string) *LiveSnapshot {
+// optionally filtered to serviceNames. tenant is the client's Scope tenant;
+// clients without one get the default tenant, as a single-tenant install
+// would. The service map spans every service, so it is only included with
+// withMap.
+func (h *EventHub) computeSnapshot(tenant string, serviceNames []string, withMap bool) *LiveSnapshot {
 	now := time.Now()
 	start := now.Add(-15 * time.Minute)
 
-	var serviceNames []string
-	if service != "" {
-		serviceNames = []string{service}
-	}
-
 	snapshot := &LiveSnapshot{Type: "live_snapshot"}
 
-	// WebSocket snapshots are not tenant-scoped in the current protocol;
-	// use the default-tenant context so repo query helpers behave the same as
-	// a single-tenant install.
 	ctx := context.Background()
+	if tenant != "" {
+		ctx = storage.WithTenantContext(ctx, tenant)
+	}
 
 	if stats, err := h.repo.GetDashboardStats(ctx, start, now, serviceNames); err == nil {
 		snapshot.Dashboard = stats
 	}
 
-	if traffic, err := h.repo.GetTrafficMetrics(ctx, start, now, serviceNames); err == nil {
+	if traffic, err := h.repo.GetTrafficMetrics(ctx, start, now, serviceNames, time.Minute); err == nil {
 		snapshot.Traffic = traffic
 	}
 
@@ -353,8 +370,10 @@ func (h *EventHub) computeSnapshot(service string) *LiveSnapshot {
 		snapshot.Traces = traces
 	}
 
-	if smap, err := h.repo.GetServiceMapMetrics(ctx, start, now); err == nil {
-		snapshot.ServiceMap = smap
+	if withMap {
+		if smap, err := h.repo.GetServiceMapMetrics(ctx, start, now); err == nil {
+			snapshot.ServiceMap = smap
+		}
 	}
 
 	return snapshot
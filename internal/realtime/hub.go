@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/compress"
 	"github.com/coder/websocket"
 )
 
@@ -23,6 +26,7 @@ type LogEntry struct {
 	AttributesJSON string    `json:"attributes_json"`
 	AIInsight      string    `json:"ai_insight,omitempty"`
 	Timestamp      time.Time `json:"timestamp"`
+	TenantID       string    `json:"-"` // for Scope filtering; never sent
 }
 
 // MetricEntry represents a raw metric point for real-time visualization.
@@ -32,32 +36,160 @@ type MetricEntry struct {
 	Value       float64        `json:"value"`
 	Timestamp   time.Time      `json:"timestamp"`
 	Attributes  map[string]any `json:"attributes"`
+	TenantID    string         `json:"-"` // for Scope filtering; never sent
+}
+
+// LiveStats is the ingest rollup broadcast once per second as a "stats"
+// batch, so dashboard tickers need not poll the stats endpoint. Rates are
+// per second over the elapsed interval, across all tenants.
+type LiveStats struct {
+	Timestamp      time.Time `json:"timestamp"`
+	SpansPerSec    float64   `json:"spans_per_sec"`
+	LogsPerSec     float64   `json:"logs_per_sec"`
+	MetricsPerSec  float64   `json:"metrics_per_sec"` // data points
+	ErrorsPerSec   float64   `json:"errors_per_sec"`  // error spans + ERROR/FATAL logs
+	ErrorRate      float64   `json:"error_rate"`      // errors / (spans + logs), 0..1
+	ActiveServices int       `json:"active_services"` // seen within activeServiceWindow
+}
+
+// TraceInsightEntry is an AI root-cause summary of an error trace, broadcast
+// as a "trace_insights" batch when the AI pipeline stores it.
+type TraceInsightEntry struct {
+	TraceID     string    `json:"trace_id"`
+	ServiceName string    `json:"service_name"`
+	Summary     string    `json:"summary"`
+	CreatedAt   time.Time `json:"created_at"`
+	TenantID    string    `json:"-"` // for Scope filtering; never sent
+}
+
+// TraceEntry is a completed trace, broadcast in a "traces" batch when its
+// root span is persisted. Duration and status are the root span's; the
+// trace finalizer may later correct the stored row from late children.
+type TraceEntry struct {
+	TraceID       string    `json:"trace_id"`
+	ServiceName   string    `json:"service_name"`
+	RootOperation string    `json:"root_operation"`
+	DurationMs    float64   `json:"duration_ms"`
+	Status        string    `json:"status"`
+	Timestamp     time.Time `json:"timestamp"`
+	TenantID      string    `json:"-"` // for Scope filtering; never sent
 }
 
 // HubBatch is a unified payload for WebSocket broadcasts.
 type HubBatch struct {
-	Type string `json:"type"` // "logs" or "metrics"
-	Data any    `json:"data"` // Slice of entries
+	Type    string `json:"type"`        // "logs", "metrics", "traces", "stats" or "trace_insights"
+	Version int    `json:"v,omitempty"` // schema version; omitted for v1 clients
+	Data    any    `json:"data"`        // Slice of entries; LiveStats for "stats"
+}
+
+// Hub payload schema versions. A client picks one at connect by offering
+// WebSocket subprotocols ("otelcontext.v2", "otelcontext.v1", ...); the
+// hub accepts the highest it supports. A client that offers none — every UI
+// build before versioning — gets v1: the bare {type, data} envelope and
+// only the stream types v1 had. From v2 on, every message carries "v".
+//
+// When zstd is enabled (SetCompression), v2+ are also offered with a
+// "+zstd" suffix ("otelcontext.v2+zstd"), preferred over the plain form. A
+// client that negotiates one receives every batch as a binary message
+// holding the zstd-compressed JSON.
+const (
+	HubProtocolV1 = 1
+	HubProtocolV2 = 2
+
+	// HubProtocolLatest is the newest version this hub speaks.
+	HubProtocolLatest = HubProtocolV2
+
+	hubSubprotocolPrefix = "otelcontext.v"
+	hubZstdSuffix        = "+zstd"
+)
+
+// hubStreamMinVersion is the first protocol version that receives each
+// stream type. Clients on older versions are skipped rather than sent a
+// type they may not handle. A type missing here is sent to every version.
+var hubStreamMinVersion = map[string]int{
+	"logs":           HubProtocolV1,
+	"metrics":        HubProtocolV1,
+	"traces":         HubProtocolV2,
+	"stats":          HubProtocolV2,
+	"trace_insights": HubProtocolV2,
+}
+
+// hubSubprotocols lists the subprotocols offered to Accept, newest first,
+// so a client offering several is matched to the highest.
+var hubSubprotocols = func() []string {
+	out := make([]string, 0, HubProtocolLatest)
+	for v := HubProtocolLatest; v >= HubProtocolV1; v-- {
+		out = append(out, hubSubprotocolPrefix+strconv.Itoa(v))
+	}
+	return out
+}()
+
+// hubZstdSubprotocols is hubSubprotocols with a "+zstd" form ahead of each
+// v2+ entry, offered when zstd is enabled.
+var hubZstdSubprotocols = func() []string {
+	out := make([]string, 0, 2*HubProtocolLatest-1)
+	for _, p := range hubSubprotocols {
+		if protocolVersion(p) >= HubProtocolV2 {
+			out = append(out, p+hubZstdSuffix)
+		}
+		out = append(out, p)
+	}
+	return out
+}()
+
+// protocolVersion maps the negotiated subprotocol to a schema version.
+func protocolVersion(subprotocol string) int {
+	sub := strings.TrimSuffix(strings.ToLower(subprotocol), hubZstdSuffix)
+	if v, err := strconv.Atoi(strings.TrimPrefix(sub, hubSubprotocolPrefix)); err == nil && v >= HubProtocolV1 && v <= HubProtocolLatest {
+		return v
+	}
+	return HubProtocolV1
+}
+
+// protocolZstd reports whether the negotiated subprotocol asks for
+// zstd-compressed batches.
+func protocolZstd(subprotocol string) bool {
+	return strings.HasSuffix(strings.ToLower(subprotocol), hubZstdSuffix)
 }
 
+// activeServiceWindow is how long a service counts as active after its last
+// span, log or metric point.
+const activeServiceWindow = time.Minute
+
+// Keepalive defaults; see SetKeepalive and SetReadLimit.
+const (
+	defaultMaxBufferSize = 100
+	defaultFlushInterval = 500 * time.Millisecond
+
+	defaultPingInterval = 30 * time.Second
+	defaultIdleTimeout  = 60 * time.Second
+	defaultReadLimit    = 4096
+)
+
 // Hub is a buffered WebSocket broadcast hub.
 //
 // Instead of broadcasting each log individually (which would freeze the UI at high throughput),
 // it buffers logs and flushes them as a JSON array when either:
 //   - Buffer size >= maxBufferSize (default: 100)
 //   - Flush ticker fires (default: every 500ms)
+//
+// Both can be changed while running; see SetFlushTuning.
 type Hub struct {
 	clients    map[*client]struct{}
 	register   chan *client
 	unregister chan *client
 	broadcast  chan LogEntry
 	metricsCh  chan MetricEntry
+	insightsCh chan TraceInsightEntry
+	tracesCh   chan TraceEntry
 
 	logBuffer     []LogEntry
 	metricBuffer  []MetricEntry
+	traceBuffer   []TraceEntry // not pooled: traces are far rarer than logs
 	bufferMu      sync.Mutex
-	maxBufferSize int
-	flushInterval time.Duration
+	maxBufferSize atomic.Int64
+	flushInterval atomic.Int64  // nanoseconds
+	retune        chan struct{} // wakes Run to reset its flush ticker
 
 	// maxClients caps simultaneous WebSocket connections. 0 = unlimited
 	// (legacy). When set, HandleWebSocket rejects new connects past the cap
@@ -75,19 +207,50 @@ type Hub struct {
 	// onConnectionChange is called when the number of active connections changes.
 	onConnectionChange func(count int)
 
+	// Keepalive: HandleWebSocket pings each client every pingInterval and
+	// closes it when a ping goes unanswered for idleTimeout, so half-open
+	// connections (peer gone, load balancer never sent a FIN) are reaped
+	// instead of holding a slot until restart. pingInterval 0 disables.
+	// readLimit caps one client message in bytes; clients only send small
+	// application pings and Subscription messages.
+	pingInterval time.Duration
+	idleTimeout  time.Duration
+	readLimit    int64
+
+	// Compression offered at connect; see SetCompression.
+	zstd    bool
+	deflate bool
+
 	// Metric callbacks (optional)
 	onMessageSent    func(msgType string) // WSMessagesSent.WithLabelValues(type).Inc()
 	onSlowClientDrop func()               // WSSlowClientsRemoved.Inc()
+	onStaleClient    func()               // WSStaleClientsReaped.Inc()
 
 	logPool    sync.Pool
 	metricPool sync.Pool
+
+	// Live stats, rolled up every statsInterval by Run. The counters and
+	// recentServices are written from ingest callbacks; lastStats and
+	// serviceSeen belong to the Run goroutine.
+	statsInterval  time.Duration
+	spanCount      atomic.Int64
+	logCount       atomic.Int64
+	metricCount    atomic.Int64
+	errorCount     atomic.Int64
+	recentServices sync.Map // service → struct{}, since the last rollup
+	serviceSeen    map[string]time.Time
+	lastStats      time.Time
 }
 
 // client represents a single WebSocket connection.
 type client struct {
-	conn   *websocket.Conn
-	send   chan []byte
-	closed atomic.Bool // guards against double-close of send channel
+	conn    *websocket.Conn
+	send    chan []byte
+	version int                       // negotiated HubProtocol version; 0 is treated as v1
+	zstd    bool                      // negotiated "+zstd": send compressed binary messages
+	closed  atomic.Bool               // guards against double-close of send channel
+	filter  atomic.Pointer[logFilter] // nil = no Subscription
+	scope   Scope                     // from the API key; fixed at connect
 }
 
 // NewHub creates a new buffered WebSocket hub.
@@ -98,17 +261,25 @@ func NewHub(onConnectionChange func(count int)) *Hub {
 		unregister:         make(chan *client),
 		broadcast:          make(chan LogEntry, 5000),
 		metricsCh:          make(chan MetricEntry, 5000),
-		maxBufferSize:      100,
-		flushInterval:      500 * time.Millisecond,
+		insightsCh:         make(chan TraceInsightEntry, 100),
+		tracesCh:           make(chan TraceEntry, 1000),
+		retune:             make(chan struct{}, 1),
 		stopCh:             make(chan struct{}),
 		onConnectionChange: onConnectionChange,
+		statsInterval:      time.Second,
+		pingInterval:       defaultPingInterval,
+		idleTimeout:        defaultIdleTimeout,
+		readLimit:          defaultReadLimit,
+		serviceSeen:        make(map[string]time.Time),
 	}
+	h.maxBufferSize.Store(defaultMaxBufferSize)
+	h.flushInterval.Store(int64(defaultFlushInterval))
 
 	h.logPool.New = func() any {
-		return make([]LogEntry, 0, h.maxBufferSize)
+		return make([]LogEntry, 0, h.maxBufferSize.Load())
 	}
 	h.metricPool.New = func() any {
-		return make([]MetricEntry, 0, h.maxBufferSize)
+		return make([]MetricEntry, 0, h.maxBufferSize.Load())
 	}
 
 	h.logBuffer = h.logPool.Get().([]LogEntry)
@@ -122,8 +293,11 @@ func (h *Hub) Run() {
 	h.wg.Add(1)
 	defer h.wg.Done()
 
-	flushTicker := time.NewTicker(h.flushInterval)
+	flushTicker := time.NewTicker(time.Duration(h.flushInterval.Load()))
 	defer flushTicker.Stop()
+	statsTicker := time.NewTicker(h.statsInterval)
+	defer statsTicker.Stop()
+	h.lastStats = time.Now()
 
 	for {
 		select {
@@ -163,7 +337,7 @@ func (h *Hub) Run() {
 		case entry := <-h.broadcast:
 			h.bufferMu.Lock()
 			h.logBuffer = append(h.logBuffer, entry)
-			shouldFlush := len(h.logBuffer) >= h.maxBufferSize
+			shouldFlush := int64(len(h.logBuffer)) >= h.maxBufferSize.Load()
 			h.bufferMu.Unlock()
 
 			if shouldFlush {
@@ -173,23 +347,81 @@ func (h *Hub) Run() {
 		case metric := <-h.metricsCh:
 			h.bufferMu.Lock()
 			h.metricBuffer = append(h.metricBuffer, metric)
-			shouldFlush := len(h.metricBuffer) >= h.maxBufferSize
+			shouldFlush := int64(len(h.metricBuffer)) >= h.maxBufferSize.Load()
 			h.bufferMu.Unlock()
 
 			if shouldFlush {
 				h.flush()
 			}
 
+		case entry := <-h.tracesCh:
+			h.bufferMu.Lock()
+			h.traceBuffer = append(h.traceBuffer, entry)
+			shouldFlush := int64(len(h.traceBuffer)) >= h.maxBufferSize.Load()
+			h.bufferMu.Unlock()
+
+			if shouldFlush {
+				h.flush()
+			}
+
+		case entry := <-h.insightsCh:
+			// Rare and wanted promptly: sent unbuffered.
+			h.broadcastBatch(HubBatch{Type: "trace_insights", Data: []TraceInsightEntry{entry}})
+
 		case <-flushTicker.C:
 			h.flush()
+
+		case <-h.retune:
+			flushTicker.Reset(time.Duration(h.flushInterval.Load()))
+
+		case now := <-statsTicker.C:
+			h.flushStats(now)
 		}
 	}
 }
 
-// flush sends the buffered logs and metrics as JSON batches to all connected clients.
+// flushStats rolls the counters up into a LiveStats and broadcasts it.
+// Counters are reset even with no clients connected, so the first message a
+// new client sees covers one interval, not the time since startup.
+func (h *Hub) flushStats(now time.Time) {
+	elapsed := now.Sub(h.lastStats).Seconds()
+	h.lastStats = now
+	spans, logs := h.spanCount.Swap(0), h.logCount.Swap(0)
+	metrics, errs := h.metricCount.Swap(0), h.errorCount.Swap(0)
+
+	h.recentServices.Range(func(k, _ any) bool {
+		h.serviceSeen[k.(string)] = now
+		return true
+	})
+	h.recentServices.Clear()
+	for svc, seen := range h.serviceSeen {
+		if now.Sub(seen) > activeServiceWindow {
+			delete(h.serviceSeen, svc)
+		}
+	}
+
+	if len(h.clients) == 0 || elapsed <= 0 {
+		return
+	}
+	stats := LiveStats{
+		Timestamp:      now.UTC(),
+		SpansPerSec:    float64(spans) / elapsed,
+		LogsPerSec:     float64(logs) / elapsed,
+		MetricsPerSec:  float64(metrics) / elapsed,
+		ErrorsPerSec:   float64(errs) / elapsed,
+		ActiveServices: len(h.serviceSeen),
+	}
+	if spans+logs > 0 {
+		stats.ErrorRate = float64(errs) / float64(spans+logs)
+	}
+	h.broadcastBatch(HubBatch{Type: "stats", Data: stats})
+}
+
+// flush sends the buffered logs, metrics and traces as JSON batches to all
+// connected clients.
 func (h *Hub) flush() {
 	h.bufferMu.Lock()
-	if len(h.logBuffer) == 0 && len(h.metricBuffer) == 0 {
+	if len(h.logBuffer) == 0 && len(h.metricBuffer) == 0 && len(h.traceBuffer) == 0 {
 		h.bufferMu.Unlock()
 		return
 	}
@@ -200,6 +432,9 @@ func (h *Hub) flush() {
 
 	metricBatch := h.metricBuffer
 	h.metricBuffer = h.metricPool.Get().([]MetricEntry)
+
+	traceBatch := h.traceBuffer
+	h.traceBuffer = nil
 	h.bufferMu.Unlock()
 
 	// Broadcast Logs if any
@@ -217,18 +452,51 @@ func (h *Hub) flush() {
 		metricBatch = metricBatch[:0]
 		h.metricPool.Put(metricBatch) //nolint:staticcheck // SA6002: []T pool; pointer wrap would require broader refactor
 	}
+
+	if len(traceBatch) > 0 {
+		h.broadcastBatch(HubBatch{Type: "traces", Data: traceBatch})
+	}
 }
 
 func (h *Hub) broadcastBatch(batch HubBatch) {
-	data, err := json.Marshal(batch)
-	if err != nil {
-		slog.Error("Hub: failed to marshal batch", "error", err, "type", batch.Type)
-		return
-	}
+	// One encoding per protocol version and compression in use, marshalled
+	// on first need; clients with a Subscription or a restricted Scope get
+	// their own.
+	var encoded [2][HubProtocolLatest + 1][]byte
+	minVersion := max(hubStreamMinVersion[batch.Type], HubProtocolV1)
 
 	sent := 0
 	var slow []*client
 	for c := range h.clients {
+		v := max(c.version, HubProtocolV1)
+		if v < minVersion {
+			continue
+		}
+		var data []byte
+		if f := c.filter.Load(); f != nil || !c.scope.Unrestricted() {
+			filtered, ok := c.scope.apply(f, batch)
+			if !ok {
+				continue
+			}
+			var err error
+			if data, err = encodeBatch(filtered, v, c.zstd); err != nil {
+				slog.Error("Hub: failed to marshal batch", "error", err, "type", batch.Type)
+				return
+			}
+		} else {
+			z := 0
+			if c.zstd {
+				z = 1
+			}
+			if encoded[z][v] == nil {
+				var err error
+				if encoded[z][v], err = encodeBatch(batch, v, c.zstd); err != nil {
+					slog.Error("Hub: failed to marshal batch", "error", err, "type", batch.Type)
+					return
+				}
+			}
+			data = encoded[z][v]
+		}
 		select {
 		case c.send <- data:
 			sent++
@@ -254,6 +522,19 @@ func (h *Hub) broadcastBatch(batch HubBatch) {
 	}
 }
 
+// encodeBatch marshals batch for a client on protocol version v,
+// zstd-compressing it for clients that negotiated "+zstd".
+func encodeBatch(batch HubBatch, v int, zstd bool) ([]byte, error) {
+	if v > HubProtocolV1 {
+		batch.Version = v
+	}
+	data, err := json.Marshal(batch)
+	if err != nil || !zstd {
+		return data, err
+	}
+	return compress.Compress(data), nil
+}
+
 // SetDevMode controls whether cross-origin WebSocket connections are accepted.
 // Should be true only in development environments.
 func (h *Hub) SetDevMode(devMode bool) {
@@ -270,14 +551,70 @@ func (h *Hub) SetMaxClients(n int) {
 	h.maxClients = n
 }
 
+// SetFlushTuning sets how many buffered entries of one kind trigger a
+// flush and how often buffers are flushed regardless. Zero or negative
+// values keep the current setting. Safe to call while Run is running; the
+// flush ticker restarts with the new interval.
+func (h *Hub) SetFlushTuning(bufferSize int, interval time.Duration) {
+	if bufferSize > 0 {
+		h.maxBufferSize.Store(int64(bufferSize))
+	}
+	if interval > 0 {
+		h.flushInterval.Store(int64(interval))
+	}
+	select {
+	case h.retune <- struct{}{}:
+	default:
+	}
+}
+
+// FlushTuning reports the current buffer size and flush interval.
+func (h *Hub) FlushTuning() (bufferSize int, interval time.Duration) {
+	return int(h.maxBufferSize.Load()), time.Duration(h.flushInterval.Load())
+}
+
 // ActiveClients reports the count of currently-connected WebSocket clients.
 // Updated atomically as connections are accepted and torn down.
 func (h *Hub) ActiveClients() int64 { return h.clientCount.Load() }
 
+// SetKeepalive sets how often clients are pinged and how long a ping may go
+// unanswered before the connection is closed. pingInterval 0 disables
+// pings; idleTimeout <= 0 keeps the current timeout. Configure once at
+// startup, like SetMaxClients.
+func (h *Hub) SetKeepalive(pingInterval, idleTimeout time.Duration) {
+	if pingInterval < 0 {
+		pingInterval = 0
+	}
+	h.pingInterval = pingInterval
+	if idleTimeout > 0 {
+		h.idleTimeout = idleTimeout
+	}
+}
+
+// SetReadLimit caps the size in bytes of a message read from a client; a
+// larger message closes the connection. n <= 0 keeps the current limit.
+func (h *Hub) SetReadLimit(n int64) {
+	if n > 0 {
+		h.readLimit = n
+	}
+}
+
+// SetCompression sets the payload compression offered to new clients. With
+// zstd, v2+ subprotocols are also offered with a "+zstd" suffix and clients
+// that pick one get zstd-compressed binary batches. With deflate, the
+// permessage-deflate extension is accepted when the client offers it (every
+// browser does), compressing messages of 512 bytes or more transparently.
+// Both default to off. Configure once at startup, like SetMaxClients.
+func (h *Hub) SetCompression(zstd, deflate bool) {
+	h.zstd = zstd
+	h.deflate = deflate
+}
+
 // SetWSMetrics wires WebSocket metric callbacks.
-func (h *Hub) SetWSMetrics(onMessageSent func(string), onSlowClientDrop func()) {
+func (h *Hub) SetWSMetrics(onMessageSent func(string), onSlowClientDrop, onStaleClient func()) {
 	h.onMessageSent = onMessageSent
 	h.onSlowClientDrop = onSlowClientDrop
+	h.onStaleClient = onStaleClient
 }
 
 // Broadcast adds a log entry to the broadcast buffer.
@@ -298,6 +635,62 @@ func (h *Hub) BroadcastMetric(entry MetricEntry) {
 	}
 }
 
+// BroadcastTrace adds a completed trace to the traces buffer, sent to v2+
+// clients.
+func (h *Hub) BroadcastTrace(entry TraceEntry) {
+	select {
+	case h.tracesCh <- entry:
+	default:
+		// Drop if internal channel is full
+	}
+}
+
+// BroadcastTraceInsight sends an AI trace insight to v2+ clients.
+func (h *Hub) BroadcastTraceInsight(entry TraceInsightEntry) {
+	select {
+	case h.insightsCh <- entry:
+	default:
+		// Drop if internal channel is full
+	}
+}
+
+// RecordSpan counts one ingested span toward the live stats.
+func (h *Hub) RecordSpan(service string, isError bool) {
+	h.spanCount.Add(1)
+	if isError {
+		h.errorCount.Add(1)
+	}
+	h.markService(service)
+}
+
+// RecordLog counts one ingested log toward the live stats; ERROR and FATAL
+// severities (in any spelling containing ERR or FATAL) count as errors.
+func (h *Hub) RecordLog(service, severity string) {
+	h.logCount.Add(1)
+	if upper := strings.ToUpper(severity); strings.Contains(upper, "ERR") || strings.Contains(upper, "FATAL") {
+		h.errorCount.Add(1)
+	}
+	h.markService(service)
+}
+
+// RecordMetricPoint counts one ingested metric data point toward the live
+// stats.
+func (h *Hub) RecordMetricPoint(service string) {
+	h.metricCount.Add(1)
+	h.markService(service)
+}
+
+func (h *Hub) markService(service string) {
+	if service == "" {
+		return
+	}
+	// Load first: the common case, a service already seen this interval,
+	// stays lock-free.
+	if _, ok := h.recentServices.Load(service); !ok {
+		h.recentServices.Store(service, struct{}{})
+	}
+}
+
 // Stop gracefully shuts down the hub.
 func (h *Hub) Stop() {
 	h.stopped.Store(true)
@@ -336,22 +729,44 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+	opts := &websocket.AcceptOptions{
 		InsecureSkipVerify: h.devMode, // Allow cross-origin in dev mode only
-	})
+		Subprotocols:       hubSubprotocols,
+	}
+	if h.zstd {
+		opts.Subprotocols = hubZstdSubprotocols
+	}
+	if h.deflate {
+		// No context takeover: no per-connection compressor state to keep
+		// for every idle dashboard.
+		opts.CompressionMode = websocket.CompressionNoContextTakeover
+	}
+	conn, err := websocket.Accept(w, r, opts)
 	if err != nil {
 		releaseSlot()
 		slog.Error("WebSocket upgrade failed", "error", err)
 		return
 	}
 
+	conn.SetReadLimit(h.readLimit)
+
 	c := &client{
-		conn: conn,
-		send: make(chan []byte, 256),
+		conn:    conn,
+		send:    make(chan []byte, 256),
+		version: protocolVersion(conn.Subprotocol()),
+		zstd:    protocolZstd(conn.Subprotocol()),
+		scope:   ScopeFromContext(r.Context()),
 	}
 
 	h.register <- c
 
+	// Cancelled when the reader loop exits, which stops the pinger.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	if h.pingInterval > 0 {
+		go h.keepalive(ctx, conn, r.RemoteAddr)
+	}
+
 	// Writer goroutine
 	h.writerWg.Add(1)
 	go func() { // #nosec G118 -- long-lived WS writer goroutine outlives HTTP request intentionally
@@ -370,9 +785,13 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			_ = conn.Close(websocket.StatusNormalClosure, "closing")
 		}()
 
+		msgType := websocket.MessageText
+		if c.zstd {
+			msgType = websocket.MessageBinary
+		}
 		for msg := range c.send {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			err := conn.Write(ctx, websocket.MessageText, msg)
+			err := conn.Write(ctx, msgType, msg)
 			cancel()
 			if err != nil {
 				slog.Debug("WebSocket write failed", "error", err)
@@ -381,13 +800,20 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	// Reader goroutine — keeps connection alive, handles close.
-	// Use request context so the read unblocks when the connection drops.
+	// Reader goroutine — keeps connection alive, handles close, answers
+	// the pongs keepalive waits on and takes Subscription messages. Use the
+	// request context so the read unblocks when the connection drops.
 	for {
-		_, _, err := conn.Read(r.Context())
+		typ, msg, err := conn.Read(ctx)
 		if err != nil {
 			break
 		}
+		if typ != websocket.MessageText {
+			continue
+		}
+		if f, ok := parseSubscription(msg); ok {
+			c.filter.Store(f)
+		}
 	}
 	// Force the writer goroutine to exit once the conn is dead, otherwise
 	// it stays blocked on `for msg := range c.send` until the next broadcast
@@ -398,3 +824,34 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		close(c.send)
 	}
 }
+
+// keepalive pings conn every pingInterval until ctx is cancelled. A ping
+// not answered within idleTimeout marks the peer dead: the connection is
+// closed without a close handshake (nobody would answer it), which fails
+// the reader loop and releases the client through the usual path.
+func (h *Hub) keepalive(ctx context.Context, conn *websocket.Conn, remote string) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, h.idleTimeout)
+		err := conn.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Info("🔌 WebSocket client unresponsive, closing", "remote", remote, "idle_timeout", h.idleTimeout, "error", err) // #nosec G706 -- slog uses structured k/v fields
+		if h.onStaleClient != nil {
+			h.onStaleClient()
+		}
+		_ = conn.CloseNow()
+		return
+	}
+}
@@ -0,0 +1,154 @@
+package realtime
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Subscription is the message a /ws client sends to narrow the logs,
+// metrics and traces it receives:
+//
+//	{"services":["payment-service"],"minSeverity":"ERROR","search":"timeout"}
+//
+// Services applies to logs, metrics and traces (by root service);
+// minSeverity and search to logs only. Every field is optional, and each
+// new message replaces the last — {} goes back to receiving everything.
+// Other stream types are not filtered. A Subscription only narrows what
+// the client's Scope already allows.
+type Subscription struct {
+	Services    []string `json:"services"`
+	MinSeverity string   `json:"minSeverity"`
+	Search      string   `json:"search"`
+}
+
+// logSeverityWords maps a severity rank (1 = TRACE … 6 = FATAL) to the words
+// that identify it in free-form severity text, as in ArgusQL.
+var logSeverityWords = [...][]string{
+	1: {"TRACE"},
+	2: {"DEBUG"},
+	3: {"INFO"},
+	4: {"WARN"},
+	5: {"ERR"},
+	6: {"FATAL", "CRIT"},
+}
+
+// logSeverityRank classifies severity text — INFO, Information, warn, err —
+// most severe word first; 0 if unrecognised.
+func logSeverityRank(s string) int {
+	upper := strings.ToUpper(s)
+	for rank := len(logSeverityWords) - 1; rank > 0; rank-- {
+		for _, w := range logSeverityWords[rank] {
+			if strings.Contains(upper, w) {
+				return rank
+			}
+		}
+	}
+	return 0
+}
+
+// logFilter is a parsed Subscription, read by the Run goroutine on every
+// flush.
+type logFilter struct {
+	services    map[string]struct{} // nil = all services
+	minSeverity int                 // 0 = any severity
+	search      string              // lowercased body substring; "" = any
+}
+
+// parseSubscription parses a client message. ok is false for anything that
+// is not a subscription — a message with a "type", such as the UI's
+// {"type":"ping"}, malformed JSON or an unknown severity; f is nil for a
+// subscription that filters nothing.
+func parseSubscription(msg []byte) (f *logFilter, ok bool) {
+	var probe struct {
+		Subscription
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal(msg, &probe); err != nil || probe.Type != nil {
+		return nil, false
+	}
+	sub := probe.Subscription
+	f = &logFilter{search: strings.ToLower(strings.TrimSpace(sub.Search))}
+	if sev := strings.TrimSpace(sub.MinSeverity); sev != "" {
+		if f.minSeverity = logSeverityRank(sev); f.minSeverity == 0 {
+			return nil, false
+		}
+	}
+	for _, svc := range sub.Services {
+		if svc = strings.TrimSpace(svc); svc == "" {
+			continue
+		}
+		if f.services == nil {
+			f.services = make(map[string]struct{}, len(sub.Services))
+		}
+		f.services[svc] = struct{}{}
+	}
+	if f.services == nil && f.minSeverity == 0 && f.search == "" {
+		return nil, true
+	}
+	return f, true
+}
+
+func (f *logFilter) matchService(service string) bool {
+	if f.services == nil {
+		return true
+	}
+	_, ok := f.services[service]
+	return ok
+}
+
+func (f *logFilter) matchLog(e *LogEntry) bool {
+	if !f.matchService(e.ServiceName) {
+		return false
+	}
+	if f.minSeverity > 0 && logSeverityRank(e.Severity) < f.minSeverity {
+		return false
+	}
+	return f.search == "" || strings.Contains(strings.ToLower(e.Body), f.search)
+}
+
+// apply narrows a batch to the entries a client with scope s and
+// subscription f (nil = none) may see. ok is false when none are left, so
+// the client is sent nothing. Stats cover every tenant and are withheld
+// from restricted scopes.
+func (s Scope) apply(f *logFilter, batch HubBatch) (filtered HubBatch, ok bool) {
+	var n int
+	switch data := batch.Data.(type) {
+	case []LogEntry:
+		out := keepEntries(data, func(e *LogEntry) bool {
+			return s.Allows(e.TenantID, e.ServiceName) && (f == nil || f.matchLog(e))
+		})
+		filtered.Data, n = out, len(out)
+	case []MetricEntry:
+		out := keepEntries(data, func(m *MetricEntry) bool {
+			return s.Allows(m.TenantID, m.ServiceName) && (f == nil || f.matchService(m.ServiceName))
+		})
+		filtered.Data, n = out, len(out)
+	case []TraceEntry:
+		out := keepEntries(data, func(t *TraceEntry) bool {
+			return s.Allows(t.TenantID, t.ServiceName) && (f == nil || f.matchService(t.ServiceName))
+		})
+		filtered.Data, n = out, len(out)
+	case []TraceInsightEntry:
+		out := keepEntries(data, func(t *TraceInsightEntry) bool {
+			return s.Allows(t.TenantID, t.ServiceName)
+		})
+		filtered.Data, n = out, len(out)
+	case LiveStats:
+		return batch, s.Unrestricted()
+	default:
+		return batch, true
+	}
+	filtered.Type, filtered.Version = batch.Type, batch.Version
+	return filtered, n > 0
+}
+
+// keepEntries returns the entries of data keep accepts, in a new slice.
+func keepEntries[T any](data []T, keep func(*T) bool) []T {
+	out := make([]T, 0, len(data))
+	for i := range data {
+		if keep(&data[i]) {
+			out = append(out, data[i])
+		}
+	}
+	return out
+}
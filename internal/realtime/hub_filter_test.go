@@ -0,0 +1,170 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseSubscription(t *testing.T) {
+	f, ok := parseSubscription([]byte(`{"services":["payment-service"," "],"minSeverity":"error","search":" Timeout "}`))
+	if !ok || f == nil || len(f.services) != 1 || f.minSeverity != 5 || f.search != "timeout" {
+		t.Fatalf("parse = %+v, %v", f, ok)
+	}
+	if f, ok := parseSubscription([]byte(`{}`)); !ok || f != nil {
+		t.Errorf("{} = %+v, %v; want a cleared filter", f, ok)
+	}
+	for _, msg := range []string{`{"type":"ping"}`, `not json`, `{"minSeverity":"loud"}`} {
+		if _, ok := parseSubscription([]byte(msg)); ok {
+			t.Errorf("%s parsed as a subscription", msg)
+		}
+	}
+}
+
+func TestHub_BroadcastBatchFiltered(t *testing.T) {
+	h := NewHub(nil)
+	all := &client{send: make(chan []byte, 4)}
+	tailing := &client{send: make(chan []byte, 4)}
+	quiet := &client{send: make(chan []byte, 4)}
+	f, _ := parseSubscription([]byte(`{"services":["payment-service"],"minSeverity":"ERROR","search":"timeout"}`))
+	tailing.filter.Store(f)
+	f, _ = parseSubscription([]byte(`{"services":["search"]}`))
+	quiet.filter.Store(f)
+	for _, c := range []*client{all, tailing, quiet} {
+		h.clients[c] = struct{}{}
+	}
+
+	h.broadcastBatch(HubBatch{Type: "logs", Data: []LogEntry{
+		{ID: 1, ServiceName: "payment-service", Severity: "ERROR", Body: "upstream Timeout"},
+		{ID: 2, ServiceName: "payment-service", Severity: "INFO", Body: "timeout retried"},
+		{ID: 3, ServiceName: "payment-service", Severity: "FATAL", Body: "out of memory"},
+		{ID: 4, ServiceName: "checkout", Severity: "ERROR", Body: "timeout"},
+	}})
+	logIDs := func(c *client) []uint {
+		var batch struct{ Data []LogEntry }
+		if err := json.Unmarshal(<-c.send, &batch); err != nil {
+			t.Fatal(err)
+		}
+		var ids []uint
+		for _, e := range batch.Data {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	if ids := logIDs(all); len(ids) != 4 {
+		t.Errorf("unfiltered client got %v", ids)
+	}
+	if ids := logIDs(tailing); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("filtered client got %v, want [1]", ids)
+	}
+	if len(quiet.send) != 0 {
+		t.Error("client whose filter matched nothing was sent a batch")
+	}
+
+	// Metrics are filtered by service only.
+	h.broadcastBatch(HubBatch{Type: "metrics", Data: []MetricEntry{{Name: "a", ServiceName: "search"}, {Name: "b", ServiceName: "checkout"}}})
+	var batch struct{ Data []MetricEntry }
+	if err := json.Unmarshal(<-quiet.send, &batch); err != nil || len(batch.Data) != 1 || batch.Data[0].Name != "a" {
+		t.Errorf("metrics = %+v, %v", batch.Data, err)
+	}
+	if len(tailing.send) != 0 {
+		t.Error("metric sent to a client subscribed to another service")
+	}
+}
+
+func TestHub_FlushTraces(t *testing.T) {
+	h := NewHub(nil)
+	legacy := &client{send: make(chan []byte, 4)}
+	all := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	tailing := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	f, _ := parseSubscription([]byte(`{"services":["payment-service"],"minSeverity":"ERROR"}`))
+	tailing.filter.Store(f)
+	for _, c := range []*client{legacy, all, tailing} {
+		h.clients[c] = struct{}{}
+	}
+
+	h.traceBuffer = []TraceEntry{
+		{TraceID: "t1", ServiceName: "payment-service", RootOperation: "POST /pay", DurationMs: 12.5, Status: "STATUS_CODE_ERROR"},
+		{TraceID: "t2", ServiceName: "checkout", RootOperation: "GET /cart"},
+	}
+	h.flush()
+	if len(legacy.send) != 0 {
+		t.Errorf("v1 client got a traces message: %s", <-legacy.send)
+	}
+	traceIDs := func(c *client) []string {
+		var batch struct {
+			Type string
+			Data []TraceEntry
+		}
+		if err := json.Unmarshal(<-c.send, &batch); err != nil || batch.Type != "traces" {
+			t.Fatalf("batch = %+v, %v", batch, err)
+		}
+		var ids []string
+		for _, e := range batch.Data {
+			ids = append(ids, e.TraceID)
+		}
+		return ids
+	}
+	if ids := traceIDs(all); len(ids) != 2 {
+		t.Errorf("unfiltered client got %v", ids)
+	}
+	// Traces are filtered by service; the log-only fields don't apply.
+	if ids := traceIDs(tailing); len(ids) != 1 || ids[0] != "t1" {
+		t.Errorf("filtered client got %v, want [t1]", ids)
+	}
+	if h.traceBuffer != nil {
+		t.Error("trace buffer not reset by flush")
+	}
+}
+
+func TestHub_BroadcastBatchScoped(t *testing.T) {
+	h := NewHub(nil)
+	admin := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	acme := &client{send: make(chan []byte, 4), version: HubProtocolV2, scope: Scope{Tenant: "acme"}}
+	payments := &client{send: make(chan []byte, 4), version: HubProtocolV2, scope: Scope{Tenant: "acme", Services: []string{"payment-service"}}}
+	// A subscription cannot widen the scope.
+	f, _ := parseSubscription([]byte(`{"services":["checkout","payment-service"]}`))
+	payments.filter.Store(f)
+	for _, c := range []*client{admin, acme, payments} {
+		h.clients[c] = struct{}{}
+	}
+
+	h.broadcastBatch(HubBatch{Type: "logs", Data: []LogEntry{
+		{ID: 1, TenantID: "acme", ServiceName: "payment-service"},
+		{ID: 2, TenantID: "acme", ServiceName: "checkout"},
+		{ID: 3, TenantID: "globex", ServiceName: "payment-service"},
+	}})
+	for c, want := range map[*client]int{admin: 3, acme: 2, payments: 1} {
+		var batch struct{ Data []LogEntry }
+		if err := json.Unmarshal(<-c.send, &batch); err != nil || len(batch.Data) != want {
+			t.Errorf("scope %+v got %+v, want %d logs", c.scope, batch.Data, want)
+		}
+	}
+
+	h.broadcastBatch(HubBatch{Type: "trace_insights", Data: []TraceInsightEntry{{TraceID: "t", TenantID: "globex"}}})
+	if len(admin.send) != 1 || len(acme.send) != 0 || len(payments.send) != 0 {
+		t.Error("trace insight of another tenant sent to a scoped client")
+	}
+	<-admin.send
+
+	// Stats cover every tenant, so scoped clients go without.
+	h.broadcastBatch(HubBatch{Type: "stats", Data: LiveStats{}})
+	if len(admin.send) != 1 || len(acme.send) != 0 || len(payments.send) != 0 {
+		t.Error("stats sent to a scoped client")
+	}
+}
+
+func TestScope(t *testing.T) {
+	s := Scope{Tenant: "default", Services: []string{"a", "b"}}
+	if !s.Allows("", "a") || s.Allows("default", "c") || s.Allows("acme", "a") {
+		t.Error("Allows")
+	}
+	if got := s.narrow("a"); len(got) != 1 || got[0] != "a" {
+		t.Errorf("narrow(a) = %v", got)
+	}
+	if got := s.narrow(""); len(got) != 2 {
+		t.Errorf("narrow(\"\") = %v, want the scope's services", got)
+	}
+	if got := (Scope{}).narrow(""); got != nil {
+		t.Errorf("unrestricted narrow = %v, want nil", got)
+	}
+}
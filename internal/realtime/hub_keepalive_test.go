@@ -0,0 +1,93 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func startKeepaliveHub(t *testing.T) (*Hub, string, *atomic.Int64) {
+	t.Helper()
+	hub := NewHub(nil)
+	hub.SetKeepalive(20*time.Millisecond, 50*time.Millisecond)
+	hub.SetReadLimit(64)
+	var reaped atomic.Int64
+	hub.SetWSMetrics(nil, nil, func() { reaped.Add(1) })
+	go hub.Run()
+	t.Cleanup(hub.Stop)
+
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	t.Cleanup(srv.Close)
+	return hub, "ws" + strings.TrimPrefix(srv.URL, "http"), &reaped
+}
+
+func waitForClients(t *testing.T, hub *Hub, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.ActiveClients() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("ActiveClients = %d, want %d", hub.ActiveClients(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestHub_KeepaliveReapsUnresponsiveClient: a client that never reads never
+// answers pings, like a peer behind a half-open connection.
+func TestHub_KeepaliveReapsUnresponsiveClient(t *testing.T) {
+	hub, url, reaped := startKeepaliveHub(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	live, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.CloseNow()
+	liveCtx := live.CloseRead(context.Background()) // reads, so answers pings
+
+	stale, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stale.CloseNow()
+
+	waitForClients(t, hub, 1)
+	if reaped.Load() != 1 {
+		t.Errorf("stale reaps = %d, want 1", reaped.Load())
+	}
+	time.Sleep(100 * time.Millisecond) // several more ping rounds
+	if hub.ActiveClients() != 1 || liveCtx.Err() != nil {
+		t.Error("responsive client was closed")
+	}
+}
+
+func TestHub_ReadLimitClosesClient(t *testing.T) {
+	hub, url, _ := startKeepaliveHub(t)
+	hub.SetKeepalive(0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c, _, err := websocket.Dial(ctx, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.CloseNow()
+	if err := c.Write(ctx, websocket.MessageText, []byte(`{"type":"ping"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write(ctx, websocket.MessageText, []byte(strings.Repeat("x", 65))); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.Read(ctx)
+	if websocket.CloseStatus(err) != websocket.StatusMessageTooBig {
+		t.Errorf("read after oversized message: %v, want StatusMessageTooBig", err)
+	}
+	waitForClients(t, hub, 0)
+}
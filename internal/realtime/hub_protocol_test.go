@@ -0,0 +1,120 @@
+package realtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/compress"
+	"github.com/coder/websocket"
+)
+
+func TestHub_BroadcastBatchPerVersion(t *testing.T) {
+	h := NewHub(nil)
+	legacy := &client{send: make(chan []byte, 4)} // pre-versioning UI build
+	v2 := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	h.clients[legacy] = struct{}{}
+	h.clients[v2] = struct{}{}
+
+	h.broadcastBatch(HubBatch{Type: "logs", Data: []LogEntry{}})
+	if got := string(<-legacy.send); got != `{"type":"logs","data":[]}` {
+		t.Errorf("v1 logs = %s", got)
+	}
+	if got := string(<-v2.send); got != `{"type":"logs","v":2,"data":[]}` {
+		t.Errorf("v2 logs = %s", got)
+	}
+
+	// Stream types newer than a client's version are not sent to it.
+	for typ, data := range map[string]any{"stats": LiveStats{}, "traces": []TraceEntry{}, "trace_insights": []TraceInsightEntry{}} {
+		h.broadcastBatch(HubBatch{Type: typ, Data: data})
+		if len(legacy.send) != 0 {
+			t.Errorf("v1 client got a %s message: %s", typ, <-legacy.send)
+		}
+		if got := string(<-v2.send); !strings.HasPrefix(got, `{"type":"`+typ+`","v":2,`) {
+			t.Errorf("v2 %s = %s", typ, got)
+		}
+	}
+}
+
+func TestHub_NegotiatesProtocolVersion(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+	defer hub.Stop()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for name, tc := range map[string]struct {
+		offer []string
+		want  string
+	}{
+		"none":        {nil, ""},
+		"highest":     {[]string{"otelcontext.v1", "otelcontext.v2"}, "otelcontext.v2"},
+		"only v1":     {[]string{"otelcontext.v1"}, "otelcontext.v1"},
+		"unsupported": {[]string{"otelcontext.v9"}, ""},
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		conn, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: tc.offer})
+		cancel()
+		if err != nil {
+			t.Fatalf("%s: dial: %v", name, err)
+		}
+		if got := conn.Subprotocol(); got != tc.want {
+			t.Errorf("%s: subprotocol = %q, want %q", name, got, tc.want)
+		}
+		conn.CloseNow()
+	}
+	waitForClients(t, hub, 0)
+
+	for sub, want := range map[string]int{"": 1, "otelcontext.v1": 1, "otelcontext.v2": 2, "OtelContext.V2": 2, "otelcontext.v2+zstd": 2, "otelcontext.v9": 1} {
+		if got := protocolVersion(sub); got != want {
+			t.Errorf("protocolVersion(%q) = %d, want %d", sub, got, want)
+		}
+	}
+}
+
+func TestHub_ZstdCompression(t *testing.T) {
+	registered := make(chan int, 4)
+	hub := NewHub(func(n int) { registered <- n })
+	hub.SetCompression(true, true)
+	go hub.Run()
+	defer hub.Stop()
+	srv := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Clients that don't ask for zstd keep plain text messages.
+	plain, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{"otelcontext.v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.CloseNow()
+	zc, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{Subprotocols: []string{"otelcontext.v2+zstd", "otelcontext.v2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zc.CloseNow()
+	if got := zc.Subprotocol(); got != "otelcontext.v2+zstd" {
+		t.Fatalf("subprotocol = %q", got)
+	}
+	for n := 0; n < 2; n = <-registered {
+	}
+
+	hub.Broadcast(LogEntry{ID: 7, Body: strings.Repeat("compressible ", 100)})
+	typ, msg, err := zc.Read(ctx)
+	if err != nil || typ != websocket.MessageBinary {
+		t.Fatalf("zstd client read = %v, %v", typ, err)
+	}
+	raw, err := compress.Decompress(msg)
+	if err != nil || !strings.HasPrefix(string(raw), `{"type":"logs","v":2,`) || len(msg) >= len(raw) {
+		t.Errorf("decompressed %d -> %d bytes: %.40s, %v", len(msg), len(raw), raw, err)
+	}
+	if typ, msg, err := plain.Read(ctx); err != nil || typ != websocket.MessageText || !strings.HasPrefix(string(msg), `{"type":"logs"`) {
+		t.Errorf("plain client read = %v, %.40s, %v", typ, msg, err)
+	}
+}
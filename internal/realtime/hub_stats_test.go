@@ -0,0 +1,70 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHub_FlushStatsBroadcastsRates(t *testing.T) {
+	h := NewHub(nil)
+	c := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	h.clients[c] = struct{}{}
+
+	now := time.Now()
+	h.lastStats = now.Add(-2 * time.Second)
+	for range 6 {
+		h.RecordSpan("checkout", false)
+	}
+	h.RecordSpan("payment", true)
+	h.RecordSpan("payment", true)
+	h.RecordLog("payment", "Error")
+	h.RecordLog("inventory", "INFO")
+	h.RecordMetricPoint("inventory")
+	h.serviceSeen["stale"] = now.Add(-2 * activeServiceWindow)
+	h.flushStats(now)
+
+	var got struct {
+		Type string    `json:"type"`
+		Data LiveStats `json:"data"`
+	}
+	if err := json.Unmarshal(<-c.send, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := LiveStats{
+		Timestamp:      now.UTC(),
+		SpansPerSec:    4,
+		LogsPerSec:     1,
+		MetricsPerSec:  0.5,
+		ErrorsPerSec:   1.5,
+		ErrorRate:      0.3,
+		ActiveServices: 3,
+	}
+	if got.Type != "stats" || !got.Data.Timestamp.Equal(want.Timestamp) {
+		t.Fatalf("batch = %+v", got)
+	}
+	got.Data.Timestamp = want.Timestamp
+	if got.Data != want {
+		t.Errorf("stats = %+v, want %+v", got.Data, want)
+	}
+
+	// Counters reset; services stay active for the window.
+	h.flushStats(now.Add(time.Second))
+	if err := json.Unmarshal(<-c.send, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Data.SpansPerSec != 0 || got.Data.ErrorRate != 0 || got.Data.ActiveServices != 3 {
+		t.Errorf("second rollup = %+v", got.Data)
+	}
+}
+
+func TestHub_FlushStatsWithoutClientsResetsCounters(t *testing.T) {
+	h := NewHub(nil)
+	now := time.Now()
+	h.lastStats = now.Add(-time.Second)
+	h.RecordSpan("checkout", true)
+	h.flushStats(now)
+	if h.spanCount.Load() != 0 || h.errorCount.Load() != 0 {
+		t.Error("counters not reset without clients")
+	}
+}
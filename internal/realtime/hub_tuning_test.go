@@ -0,0 +1,46 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestHub_SetFlushTuning verifies a running hub picks up a new buffer size
+// and flush interval.
+func TestHub_SetFlushTuning(t *testing.T) {
+	h := NewHub(nil)
+	if size, interval := h.FlushTuning(); size != defaultMaxBufferSize || interval != defaultFlushInterval {
+		t.Fatalf("defaults = %d, %s", size, interval)
+	}
+	go h.Run()
+	defer h.Stop()
+	c := &client{send: make(chan []byte, 4), version: HubProtocolV2}
+	h.register <- c
+
+	h.SetFlushTuning(2, time.Hour)
+	h.SetFlushTuning(0, -1) // keeps both
+	if size, interval := h.FlushTuning(); size != 2 || interval != time.Hour {
+		t.Fatalf("tuning = %d, %s; want 2, 1h", size, interval)
+	}
+
+	h.Broadcast(LogEntry{Body: "one"})
+	select {
+	case msg := <-c.send:
+		t.Fatalf("flushed before the buffer filled: %s", msg)
+	case <-time.After(700 * time.Millisecond): // past the old 500ms interval
+	}
+	h.Broadcast(LogEntry{Body: "two"})
+	select {
+	case msg := <-c.send:
+		var got struct {
+			Type string     `json:"type"`
+			Data []LogEntry `json:"data"`
+		}
+		if err := json.Unmarshal(msg, &got); err != nil || got.Type != "logs" || len(got.Data) != 2 {
+			t.Errorf("batch = %s, %v", msg, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("full buffer not flushed")
+	}
+}
@@ -0,0 +1,60 @@
+package realtime
+
+import (
+	"context"
+	"slices"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Scope limits what a live-stream client may see: the tenant its API key is
+// pinned to and, optionally, the services the key is restricted to. The
+// auth middleware attaches it to the upgrade request; a request without one
+// (authentication disabled, or the unpinned shared key) sees everything.
+type Scope struct {
+	Tenant   string   // "" = every tenant
+	Services []string // empty = every service
+}
+
+type scopeKey struct{}
+
+// WithScope returns a copy of ctx carrying s.
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// ScopeFromContext returns the Scope on ctx; the zero Scope if none.
+func ScopeFromContext(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Unrestricted reports whether s lets everything through.
+func (s Scope) Unrestricted() bool {
+	return s.Tenant == "" && len(s.Services) == 0
+}
+
+// Allows reports whether an entry of tenant and service is visible in s.
+// An empty tenant is the default tenant, as it is once persisted.
+func (s Scope) Allows(tenant, service string) bool {
+	if tenant == "" {
+		tenant = storage.DefaultTenantID
+	}
+	if s.Tenant != "" && tenant != s.Tenant {
+		return false
+	}
+	return len(s.Services) == 0 || slices.Contains(s.Services, service)
+}
+
+// narrow returns the services a snapshot for the selected service should
+// cover: the selection if s allows it, otherwise every service s allows
+// (nil = all).
+func (s Scope) narrow(service string) []string {
+	if len(s.Services) == 0 || slices.Contains(s.Services, service) {
+		if service == "" {
+			return nil
+		}
+		return []string{service}
+	}
+	return s.Services
+}
@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+const (
+	// traceWatchBuffer is the spans a watcher may fall behind by before it
+	// is told to resync from storage instead.
+	traceWatchBuffer = 256
+	// maxTraceWatches caps simultaneous trace watches across all clients.
+	maxTraceWatches = 1000
+)
+
+// ErrTooManyTraceWatches is returned by Watch once maxTraceWatches watches
+// are open.
+var ErrTooManyTraceWatches = errors.New("too many live trace streams")
+
+// TraceStreams fans freshly persisted spans out to clients watching
+// individual traces, so a waterfall can fill in live, and tells them when
+// the trace is finalized. Wire PublishSpan into the span callback and
+// PublishFinalized into the trace finalizer.
+type TraceStreams struct {
+	mu      sync.RWMutex
+	watches map[storage.TraceKey]map[*TraceWatch]struct{}
+	count   atomic.Int64 // open watches; lets PublishSpan skip the lock when idle
+}
+
+// TraceWatch is one client's subscription to a trace. Spans delivers spans
+// persisted after Watch; Resync fires when the watcher fell behind and
+// spans were skipped; Finalized closes once the trace is finalized.
+type TraceWatch struct {
+	key       storage.TraceKey
+	streams   *TraceStreams
+	spans     chan storage.Span
+	resync    chan struct{}
+	finalized chan struct{}
+	finalOnce sync.Once
+	closeOnce sync.Once
+}
+
+// NewTraceStreams creates an empty TraceStreams.
+func NewTraceStreams() *TraceStreams {
+	return &TraceStreams{watches: make(map[storage.TraceKey]map[*TraceWatch]struct{})}
+}
+
+// Watch subscribes to a tenant's trace. Close the watch when done.
+func (t *TraceStreams) Watch(tenantID, traceID string) (*TraceWatch, error) {
+	key := storage.TraceKey{TenantID: tenantID, TraceID: traceID}
+	w := &TraceWatch{
+		key:       key,
+		streams:   t,
+		spans:     make(chan storage.Span, traceWatchBuffer),
+		resync:    make(chan struct{}, 1),
+		finalized: make(chan struct{}),
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.count.Load() >= maxTraceWatches {
+		return nil, ErrTooManyTraceWatches
+	}
+	set := t.watches[key]
+	if set == nil {
+		set = make(map[*TraceWatch]struct{})
+		t.watches[key] = set
+	}
+	set[w] = struct{}{}
+	t.count.Add(1)
+	return w, nil
+}
+
+// PublishSpan delivers span to the watchers of its trace without blocking.
+func (t *TraceStreams) PublishSpan(span storage.Span) {
+	if t == nil || t.count.Load() == 0 {
+		return
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for w := range t.watches[storage.TraceKey{TenantID: span.TenantID, TraceID: span.TraceID}] {
+		select {
+		case w.spans <- span:
+		default:
+			select {
+			case w.resync <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// PublishFinalized tells the watchers of keys that their traces are final.
+func (t *TraceStreams) PublishFinalized(keys []storage.TraceKey) {
+	if t == nil || t.count.Load() == 0 {
+		return
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, k := range keys {
+		for w := range t.watches[k] {
+			w.finalOnce.Do(func() { close(w.finalized) })
+		}
+	}
+}
+
+// Spans delivers spans persisted after the watch was opened.
+func (w *TraceWatch) Spans() <-chan storage.Span { return w.spans }
+
+// Resync fires when spans were skipped because the watcher fell behind;
+// reload the trace from storage.
+func (w *TraceWatch) Resync() <-chan struct{} { return w.resync }
+
+// Finalized is closed once the trace finalizer has recomputed the trace.
+func (w *TraceWatch) Finalized() <-chan struct{} { return w.finalized }
+
+// Close unsubscribes the watch. Safe to call more than once.
+func (w *TraceWatch) Close() {
+	w.closeOnce.Do(func() {
+		t := w.streams
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if set := t.watches[w.key]; set != nil {
+			delete(set, w)
+			if len(set) == 0 {
+				delete(t.watches, w.key)
+			}
+		}
+		t.count.Add(-1)
+	})
+}
@@ -0,0 +1,71 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func TestTraceStreams(t *testing.T) {
+	ts := NewTraceStreams()
+	ts.PublishSpan(storage.Span{TenantID: "acme", TraceID: "t1"}) // no watchers: no-op
+
+	w, err := ts.Watch("acme", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ts.Watch("globex", "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.PublishSpan(storage.Span{TenantID: "acme", TraceID: "t1", SpanID: "a"})
+	ts.PublishSpan(storage.Span{TenantID: "acme", TraceID: "t2", SpanID: "b"})
+	if sp := <-w.Spans(); sp.SpanID != "a" {
+		t.Errorf("span = %+v", sp)
+	}
+	if len(w.Spans()) != 0 || len(other.Spans()) != 0 {
+		t.Error("span delivered to another trace or tenant")
+	}
+
+	// A watcher that falls behind is told to resync instead of blocking.
+	for range traceWatchBuffer + 1 {
+		ts.PublishSpan(storage.Span{TenantID: "acme", TraceID: "t1"})
+	}
+	select {
+	case <-w.Resync():
+	default:
+		t.Error("no resync after overflow")
+	}
+
+	ts.PublishFinalized([]storage.TraceKey{{TenantID: "acme", TraceID: "t1"}})
+	ts.PublishFinalized([]storage.TraceKey{{TenantID: "acme", TraceID: "t1"}}) // closes once
+	select {
+	case <-w.Finalized():
+	default:
+		t.Error("watch not finalized")
+	}
+	select {
+	case <-other.Finalized():
+		t.Error("other tenant's watch finalized")
+	default:
+	}
+
+	w.Close()
+	w.Close()
+	other.Close()
+	if n := ts.count.Load(); n != 0 || len(ts.watches) != 0 {
+		t.Errorf("after Close: count = %d, watches = %v", n, ts.watches)
+	}
+}
+
+func TestTraceStreams_MaxWatches(t *testing.T) {
+	ts := NewTraceStreams()
+	for range maxTraceWatches {
+		if _, err := ts.Watch("acme", "t1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ts.Watch("acme", "t2"); err != ErrTooManyTraceWatches {
+		t.Errorf("err = %v, want ErrTooManyTraceWatches", err)
+	}
+}
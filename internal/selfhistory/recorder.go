@@ -0,0 +1,347 @@
+// Package selfhistory records what happened to this OtelContext instance —
+// restarts, config changes, database outages and ingest gaps — so a hole in
+// a user's data can be explained from GET /api/admin/history.
+package selfhistory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// maxPending caps events held in memory while the database cannot take them.
+const maxPending = 100
+
+// Recorder writes self-history events. Boot records the downtime since the
+// previous run and any config change; the loop then heartbeats the current
+// run every interval, so after a crash the next boot knows when the process
+// was last alive, and watches for ingest gaps. Events that fail to write
+// (the database is down) are retried on later ticks.
+type Recorder struct {
+	repo     *storage.Repository
+	version  string
+	interval time.Duration
+
+	gap      time.Duration
+	ingested func() int64
+	keep     time.Duration
+
+	// now is swapped out by tests.
+	now func() time.Time
+
+	mu           sync.Mutex
+	run          *storage.SelfEvent
+	pending      []storage.SelfEvent
+	dbDownSince  time.Time
+	lastIngested int64
+	lastActivity time.Time // zero until ingest is first seen
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates a recorder for a process running version, heartbeating every
+// interval.
+func New(repo *storage.Repository, version string, interval time.Duration) *Recorder {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Recorder{
+		repo:     repo,
+		version:  version,
+		interval: interval,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetIngestGap enables ingest gap detection: once ingest has started, a
+// stretch of at least threshold in which ingested (a running total of spans
+// and logs) does not move is recorded. Gaps are measured at heartbeat
+// resolution. Must be called before Start.
+func (r *Recorder) SetIngestGap(threshold time.Duration, ingested func() int64) {
+	r.gap = threshold
+	r.ingested = ingested
+}
+
+// SetRetention prunes events older than keep on every boot and daily after.
+// 0 keeps everything. Must be called before Boot.
+func (r *Recorder) SetRetention(keep time.Duration) {
+	r.keep = keep
+}
+
+// Boot records the downtime since the previous run (graceful or not, and
+// any version change), a config change when fingerprint differs from the
+// previous run's, and opens the current run.
+func (r *Recorder) Boot(ctx context.Context, fingerprint map[string]string) error {
+	now := r.now().UTC()
+	prev, err := r.repo.LatestSelfEvent(ctx, storage.SelfEventRun)
+	if err != nil {
+		return err
+	}
+	cfg, err := json.Marshal(fingerprint)
+	if err != nil {
+		return fmt.Errorf("self history: encode config fingerprint: %w", err)
+	}
+	if prev != nil {
+		if err := r.recordDowntime(ctx, prev, now); err != nil {
+			return err
+		}
+		var old map[string]string
+		if prev.Config != "" && json.Unmarshal([]byte(prev.Config), &old) == nil {
+			if diff := diffFingerprints(old, fingerprint); diff != "" {
+				if err := r.repo.CreateSelfEvent(ctx, &storage.SelfEvent{Kind: storage.SelfEventConfig, StartedAt: now, Version: r.version, Detail: diff}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	run := &storage.SelfEvent{Kind: storage.SelfEventRun, StartedAt: now, EndedAt: &now, Version: r.version, Config: string(cfg)}
+	if err := r.repo.CreateSelfEvent(ctx, run); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.run = run
+	r.mu.Unlock()
+	r.prune(ctx)
+	return nil
+}
+
+// recordDowntime records the hole between prev's last heartbeat and now.
+func (r *Recorder) recordDowntime(ctx context.Context, prev *storage.SelfEvent, now time.Time) error {
+	lastSeen := prev.StartedAt
+	if prev.EndedAt != nil {
+		lastSeen = *prev.EndedAt
+	}
+	stop, err := r.repo.LatestSelfEvent(ctx, storage.SelfEventStop)
+	if err != nil {
+		return err
+	}
+	detail := "unclean exit (crash, kill or host loss); time of death is the last heartbeat"
+	if stop != nil && !stop.StartedAt.Before(prev.StartedAt) {
+		detail = "graceful stop"
+	}
+	if prev.Version != r.version {
+		detail += fmt.Sprintf("; upgraded from %s to %s", prev.Version, r.version)
+	}
+	return r.repo.CreateSelfEvent(ctx, &storage.SelfEvent{Kind: storage.SelfEventDowntime, StartedAt: lastSeen, EndedAt: &now, Version: r.version, Detail: detail})
+}
+
+// diffFingerprints names the settings that changed, appeared or went away.
+func diffFingerprints(old, cur map[string]string) string {
+	var changed, added, removed []string
+	for k, v := range cur {
+		switch ov, ok := old[k]; {
+		case !ok:
+			added = append(added, k)
+		case ov != v:
+			changed = append(changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	var parts []string
+	for _, p := range []struct {
+		label string
+		keys  []string
+	}{{"changed", changed}, {"added", added}, {"removed", removed}} {
+		if len(p.keys) > 0 {
+			sort.Strings(p.keys)
+			parts = append(parts, p.label+": "+strings.Join(p.keys, ", "))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Start runs the heartbeat loop. Call after Boot.
+func (r *Recorder) Start() {
+	if r.started.CompareAndSwap(false, true) {
+		go r.loop()
+	}
+}
+
+// Stop ends the loop and records a graceful stop. Safe without Start.
+func (r *Recorder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		if r.started.Load() {
+			<-r.done
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		r.tick(ctx)
+		r.add(storage.SelfEvent{Kind: storage.SelfEventStop, StartedAt: r.now().UTC(), Version: r.version})
+		r.flush(ctx)
+	})
+}
+
+func (r *Recorder) loop() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	lastPrune := r.now()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+		r.tick(ctx)
+		if r.now().Sub(lastPrune) >= 24*time.Hour {
+			r.prune(ctx)
+			lastPrune = r.now()
+		}
+		cancel()
+	}
+}
+
+// tick heartbeats the run, checks for an ingest gap and retries pending
+// events.
+func (r *Recorder) tick(ctx context.Context) {
+	now := r.now().UTC()
+	r.mu.Lock()
+	run := r.run
+	if r.ingested != nil && r.gap > 0 {
+		if n := r.ingested(); n != r.lastIngested {
+			if !r.lastActivity.IsZero() && now.Sub(r.lastActivity) >= r.gap {
+				r.addLocked(r.gapEvent(now))
+			}
+			r.lastIngested, r.lastActivity = n, now
+		}
+	}
+	r.mu.Unlock()
+	if run != nil {
+		if err := r.repo.EndSelfEvent(ctx, run.ID, now); err != nil {
+			slog.Debug("Self history heartbeat failed", "error", err)
+		}
+	}
+	r.flush(ctx)
+}
+
+// gapEvent is the ingest gap ending at end; the caller holds mu.
+func (r *Recorder) gapEvent(end time.Time) storage.SelfEvent {
+	return storage.SelfEvent{
+		Kind:      storage.SelfEventIngestGap,
+		StartedAt: r.lastActivity,
+		EndedAt:   &end,
+		Version:   r.version,
+		Detail:    fmt.Sprintf("no spans or logs ingested for %s", end.Sub(r.lastActivity).Round(time.Second)),
+	}
+}
+
+// SetDBHealthy is the database health hook: an outage is recorded once the
+// database is back, since it cannot be written while down.
+func (r *Recorder) SetDBHealthy(up bool) {
+	now := r.now().UTC()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case !up && r.dbDownSince.IsZero():
+		r.dbDownSince = now
+	case up && !r.dbDownSince.IsZero():
+		r.addLocked(storage.SelfEvent{
+			Kind:      storage.SelfEventDBOutage,
+			StartedAt: r.dbDownSince,
+			EndedAt:   &now,
+			Version:   r.version,
+			Detail:    fmt.Sprintf("database health checks failed for %s", now.Sub(r.dbDownSince).Round(time.Second)),
+		})
+		r.dbDownSince = time.Time{}
+	}
+}
+
+// RecordConfigChange records a setting changed at runtime, such as a
+// feature flag flipped through the admin API.
+func (r *Recorder) RecordConfigChange(ctx context.Context, detail string) {
+	r.add(storage.SelfEvent{Kind: storage.SelfEventConfig, StartedAt: r.now().UTC(), Version: r.version, Detail: detail})
+	r.flush(ctx)
+}
+
+// Events returns stored events overlapping [start, end] newest first, led by
+// any database outage or ingest gap still in progress (EndedAt nil).
+func (r *Recorder) Events(ctx context.Context, kind string, start, end time.Time, limit int) ([]storage.SelfEvent, error) {
+	stored, err := r.repo.ListSelfEvents(ctx, kind, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	now := r.now().UTC()
+	var open []storage.SelfEvent
+	r.mu.Lock()
+	if !r.dbDownSince.IsZero() {
+		open = append(open, storage.SelfEvent{Kind: storage.SelfEventDBOutage, StartedAt: r.dbDownSince, Version: r.version, Detail: "database health checks failing"})
+	}
+	if r.gap > 0 && !r.lastActivity.IsZero() && now.Sub(r.lastActivity) >= r.gap {
+		gap := r.gapEvent(now)
+		gap.EndedAt = nil
+		open = append(open, gap)
+	}
+	r.mu.Unlock()
+	out := make([]storage.SelfEvent, 0, len(open)+len(stored))
+	for _, e := range open {
+		if (kind == "" || e.Kind == kind) && (end.IsZero() || !e.StartedAt.After(end)) {
+			out = append(out, e)
+		}
+	}
+	return append(out, stored...), nil
+}
+
+func (r *Recorder) add(e storage.SelfEvent) {
+	r.mu.Lock()
+	r.addLocked(e)
+	r.mu.Unlock()
+}
+
+// addLocked queues e for the next flush, dropping the oldest past maxPending.
+func (r *Recorder) addLocked(e storage.SelfEvent) {
+	if len(r.pending) >= maxPending {
+		r.pending = r.pending[1:]
+	}
+	r.pending = append(r.pending, e)
+}
+
+// flush writes pending events, keeping the rest for the next tick after the
+// first failure.
+func (r *Recorder) flush(ctx context.Context) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = nil
+	r.mu.Unlock()
+	for i := range pending {
+		if err := r.repo.CreateSelfEvent(ctx, &pending[i]); err != nil {
+			slog.Debug("Self history write deferred", "error", err)
+			r.mu.Lock()
+			r.pending = append(pending[i:], r.pending...)
+			if n := len(r.pending); n > maxPending {
+				r.pending = r.pending[n-maxPending:]
+			}
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (r *Recorder) prune(ctx context.Context) {
+	if r.keep <= 0 {
+		return
+	}
+	if n, err := r.repo.PruneSelfEvents(ctx, r.now().Add(-r.keep)); err != nil {
+		slog.Warn("Self history prune failed", "error", err)
+	} else if n > 0 {
+		slog.Debug("Self history pruned", "events", n)
+	}
+}
@@ -0,0 +1,135 @@
+package selfhistory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func newTestRecorder(t *testing.T, repo *storage.Repository, version string, clock *time.Time) *Recorder {
+	t.Helper()
+	r := New(repo, version, time.Hour)
+	r.now = func() time.Time { return *clock }
+	return r
+}
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	db, err := storage.NewDatabase("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := storage.AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := storage.NewRepositoryFromDB(db, "sqlite")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func eventsByKind(t *testing.T, r *Recorder) map[string][]storage.SelfEvent {
+	t.Helper()
+	events, err := r.Events(context.Background(), "", time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	out := make(map[string][]storage.SelfEvent)
+	for _, e := range events {
+		out[e.Kind] = append(out[e.Kind], e)
+	}
+	return out
+}
+
+// TestRecorder_RestartsAndConfigChanges verifies a crash, an upgrade with a
+// config change and a graceful restart each leave the right trail.
+func TestRecorder_RestartsAndConfigChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first := newTestRecorder(t, repo, "v1", &clock)
+	if err := first.Boot(ctx, map[string]string{"HotRetentionDays": "aa", "DBDriver": "bb"}); err != nil {
+		t.Fatalf("Boot: %v", err)
+	}
+	clock = clock.Add(10 * time.Minute)
+	first.tick(ctx) // last heartbeat, then the process dies without Stop
+
+	clock = clock.Add(20 * time.Minute)
+	second := newTestRecorder(t, repo, "v2", &clock)
+	if err := second.Boot(ctx, map[string]string{"HotRetentionDays": "cc", "DBDriver": "bb"}); err != nil {
+		t.Fatalf("second Boot: %v", err)
+	}
+	got := eventsByKind(t, second)
+	down := got[storage.SelfEventDowntime]
+	if len(down) != 1 || down[0].EndedAt == nil || down[0].EndedAt.Sub(down[0].StartedAt) != 20*time.Minute ||
+		!strings.Contains(down[0].Detail, "unclean exit") || !strings.Contains(down[0].Detail, "upgraded from v1 to v2") {
+		t.Fatalf("downtime = %+v", down)
+	}
+	if cfg := got[storage.SelfEventConfig]; len(cfg) != 1 || cfg[0].Detail != "changed: HotRetentionDays" {
+		t.Errorf("config changes = %+v", cfg)
+	}
+
+	second.Stop()
+	clock = clock.Add(time.Minute)
+	third := newTestRecorder(t, repo, "v2", &clock)
+	if err := third.Boot(ctx, map[string]string{"HotRetentionDays": "cc", "DBDriver": "bb"}); err != nil {
+		t.Fatalf("third Boot: %v", err)
+	}
+	got = eventsByKind(t, third)
+	if len(got[storage.SelfEventRun]) != 3 || len(got[storage.SelfEventStop]) != 1 || len(got[storage.SelfEventConfig]) != 1 {
+		t.Errorf("events = %+v", got)
+	}
+	if down := got[storage.SelfEventDowntime]; len(down) != 2 || down[0].Detail != "graceful stop" {
+		t.Errorf("latest downtime = %+v", down[0])
+	}
+}
+
+// TestRecorder_IngestGapsAndDBOutages verifies gaps and outages show as open
+// events while they last and are stored once they end.
+func TestRecorder_IngestGapsAndDBOutages(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, repo, "v1", &clock)
+	var ingested int64
+	r.SetIngestGap(5*time.Minute, func() int64 { return ingested })
+	if err := r.Boot(ctx, nil); err != nil {
+		t.Fatalf("Boot: %v", err)
+	}
+
+	r.tick(ctx) // idle since boot: not a gap
+	clock = clock.Add(10 * time.Minute)
+	r.tick(ctx)
+	if got := eventsByKind(t, r)[storage.SelfEventIngestGap]; len(got) != 0 {
+		t.Fatalf("gap before any ingest = %+v", got)
+	}
+
+	ingested = 10
+	r.tick(ctx)
+	start := clock
+	clock = clock.Add(7 * time.Minute)
+	r.SetDBHealthy(false)
+	r.tick(ctx)
+	got := eventsByKind(t, r)
+	if gap := got[storage.SelfEventIngestGap]; len(gap) != 1 || gap[0].EndedAt != nil || !gap[0].StartedAt.Equal(start) {
+		t.Fatalf("open gap = %+v", gap)
+	}
+	if out := got[storage.SelfEventDBOutage]; len(out) != 1 || out[0].EndedAt != nil {
+		t.Fatalf("open outage = %+v", out)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	r.SetDBHealthy(true)
+	ingested = 20
+	r.tick(ctx)
+	got = eventsByKind(t, r)
+	if gap := got[storage.SelfEventIngestGap]; len(gap) != 1 || gap[0].EndedAt == nil || gap[0].Detail != "no spans or logs ingested for 9m0s" {
+		t.Errorf("stored gap = %+v", gap)
+	}
+	if out := got[storage.SelfEventDBOutage]; len(out) != 1 || out[0].EndedAt == nil || out[0].EndedAt.Sub(out[0].StartedAt) != 2*time.Minute {
+		t.Errorf("stored outage = %+v", out)
+	}
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Alert rule kinds and event states.
+const (
+	AlertKindErrorRate   = "error_rate"
+	AlertKindP99Latency  = "p99_latency"
+	AlertKindLogMatch    = "log_match"
+	AlertKindSLOBurnRate = "slo_burn_rate"
+	AlertKindMonitorDown = "monitor_down"
+
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved"
+)
+
+// ListAlertRules returns the alert rules of the tenant on ctx, oldest first.
+func (r *Repository) ListAlertRules(ctx context.Context) ([]AlertRule, error) {
+	var rules []AlertRule
+	if err := r.db.WithContext(ctx).
+		Where(sqlWhereTenantID, TenantFromContext(ctx)).
+		Order("id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ListEnabledAlertRulesAllTenants returns every enabled rule across tenants.
+//
+// Tenant scope: SYSTEM-WIDE, for the alert evaluator only. Never expose this
+// on a tenant-scoped API surface.
+func (r *Repository) ListEnabledAlertRulesAllTenants(ctx context.Context) ([]AlertRule, error) {
+	var rules []AlertRule
+	if err := r.db.WithContext(ctx).Where("disabled = ?", false).Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list enabled alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateAlertRule inserts rule under the tenant on ctx.
+func (r *Repository) CreateAlertRule(ctx context.Context, rule *AlertRule) error {
+	rule.ID = 0
+	rule.TenantID = TenantFromContext(ctx)
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlertRule removes a rule of the tenant on ctx and resolves its open
+// event. Returns gorm.ErrRecordNotFound when the tenant has no such rule.
+func (r *Repository) DeleteAlertRule(ctx context.Context, id uint) error {
+	tenant := TenantFromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND tenant_id = ?", id, tenant).Delete(&AlertRule{})
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete alert rule: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		now := time.Now()
+		return tx.Model(&AlertEvent{}).
+			Where("rule_id = ? AND tenant_id = ? AND state = ?", id, tenant, AlertStateFiring).
+			Updates(map[string]any{"state": AlertStateResolved, "resolved_at": now}).Error
+	})
+}
+
+// ListAlertEvents returns the tenant's alert events, newest first. state
+// filters to "firing" or "resolved"; empty returns both.
+func (r *Repository) ListAlertEvents(ctx context.Context, state string, limit int) ([]AlertEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	q := r.db.WithContext(ctx).Where(sqlWhereTenantID, TenantFromContext(ctx))
+	if state != "" {
+		q = q.Where("state = ?", state)
+	}
+	var events []AlertEvent
+	if err := q.Order("fired_at DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to list alert events: %w", err)
+	}
+	return events, nil
+}
+
+// FiringAlertEvent returns the open event of a rule, or nil when the rule is
+// not firing.
+//
+// Tenant scope: SYSTEM-WIDE (keyed by rule ID), for the alert evaluator.
+func (r *Repository) FiringAlertEvent(ctx context.Context, ruleID uint) (*AlertEvent, error) {
+	var ev AlertEvent
+	err := r.db.WithContext(ctx).
+		Where("rule_id = ? AND state = ?", ruleID, AlertStateFiring).
+		Order("id DESC").
+		First(&ev).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load firing alert: %w", err)
+	}
+	return &ev, nil
+}
+
+// SaveAlertEvent inserts or updates ev.
+func (r *Repository) SaveAlertEvent(ctx context.Context, ev *AlertEvent) error {
+	if err := r.db.WithContext(ctx).Save(ev).Error; err != nil {
+		return fmt.Errorf("failed to save alert event: %w", err)
+	}
+	return nil
+}
+
+// SyncManagedAlertRules makes the managed rules (Managed=true, defined in
+// the config file) equal rules, matching by tenant and name: new rules are
+// created, changed ones updated in place so their firing state survives,
+// and managed rules no longer listed are deleted with their open events
+// resolved. An empty TenantID means DefaultTenantID. Rules created
+// through the API are never touched. Returns the
+// number of rules created, updated and deleted.
+//
+// Tenant scope: SYSTEM-WIDE — each rule carries its own TenantID.
+func (r *Repository) SyncManagedAlertRules(ctx context.Context, rules []AlertRule) (created, updated, deleted int, err error) {
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []AlertRule
+		if err := tx.Where("managed = ?", true).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to list managed alert rules: %w", err)
+		}
+		type key struct{ tenant, name string }
+		current := make(map[key]*AlertRule, len(existing))
+		for i := range existing {
+			current[key{existing[i].TenantID, existing[i].Name}] = &existing[i]
+		}
+		for i := range rules {
+			want := rules[i]
+			want.Managed = true
+			if want.TenantID == "" {
+				want.TenantID = DefaultTenantID
+			}
+			k := key{want.TenantID, want.Name}
+			cur, ok := current[k]
+			delete(current, k)
+			if !ok {
+				want.ID = 0
+				if err := tx.Create(&want).Error; err != nil {
+					return fmt.Errorf("failed to create alert rule %q: %w", want.Name, err)
+				}
+				created++
+				continue
+			}
+			if managedRuleEqual(cur, &want) {
+				continue
+			}
+			want.ID, want.CreatedAt = cur.ID, cur.CreatedAt
+			if err := tx.Save(&want).Error; err != nil {
+				return fmt.Errorf("failed to update alert rule %q: %w", want.Name, err)
+			}
+			updated++
+		}
+		now := time.Now()
+		for _, stale := range current {
+			if err := tx.Delete(&AlertRule{}, stale.ID).Error; err != nil {
+				return fmt.Errorf("failed to delete alert rule %q: %w", stale.Name, err)
+			}
+			if err := tx.Model(&AlertEvent{}).
+				Where("rule_id = ? AND state = ?", stale.ID, AlertStateFiring).
+				Updates(map[string]any{"state": AlertStateResolved, "resolved_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to resolve alerts of rule %q: %w", stale.Name, err)
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return created, updated, deleted, nil
+}
+
+func managedRuleEqual(a, b *AlertRule) bool {
+	if a.Kind != b.Kind || a.ServiceName != b.ServiceName || a.Keyword != b.Keyword || a.SLOID != b.SLOID || a.MonitorID != b.MonitorID ||
+		a.Threshold != b.Threshold || a.WindowSecs != b.WindowSecs || a.Disabled != b.Disabled || len(a.Channels) != len(b.Channels) {
+		return false
+	}
+	for i := range a.Channels {
+		if a.Channels[i].Type != b.Channels[i].Type || a.Channels[i].URL != b.Channels[i].URL || !slices.Equal(a.Channels[i].To, b.Channels[i].To) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestAlertRules_TenantScopedCRUD(t *testing.T) {
+	repo := newTestRepo(t)
+	acme := WithTenantContext(context.Background(), "acme")
+	other := WithTenantContext(context.Background(), "other")
+
+	rule := &AlertRule{
+		TenantID: "spoofed",
+		Name:     "errors",
+		Kind:     AlertKindErrorRate,
+		Channels: []AlertChannel{{Type: "email", To: []string{"a@example.com"}}},
+	}
+	if err := repo.CreateAlertRule(acme, rule); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	if rule.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme from context", rule.TenantID)
+	}
+
+	got, err := repo.ListAlertRules(acme)
+	if err != nil || len(got) != 1 || len(got[0].Channels) != 1 || got[0].Channels[0].To[0] != "a@example.com" {
+		t.Fatalf("ListAlertRules = %+v, %v", got, err)
+	}
+	if got, _ := repo.ListAlertRules(other); len(got) != 0 {
+		t.Errorf("other tenant sees %d rules", len(got))
+	}
+
+	if err := repo.SaveAlertEvent(context.Background(), &AlertEvent{
+		TenantID: "acme", RuleID: rule.ID, RuleName: rule.Name, State: AlertStateFiring, FiredAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("SaveAlertEvent: %v", err)
+	}
+
+	if err := repo.DeleteAlertRule(other, rule.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("cross-tenant delete err = %v, want ErrRecordNotFound", err)
+	}
+	if err := repo.DeleteAlertRule(acme, rule.ID); err != nil {
+		t.Fatalf("DeleteAlertRule: %v", err)
+	}
+	if ev, _ := repo.FiringAlertEvent(context.Background(), rule.ID); ev != nil {
+		t.Errorf("deleting the rule left event %+v firing", ev)
+	}
+	if evs, _ := repo.ListAlertEvents(acme, AlertStateResolved, 0); len(evs) != 1 {
+		t.Errorf("resolved events = %d, want 1", len(evs))
+	}
+}
+
+func TestSyncManagedAlertRules(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	acme := WithTenantContext(ctx, "acme")
+
+	manual := &AlertRule{Name: "errors", Kind: AlertKindErrorRate, Threshold: 5}
+	if err := repo.CreateAlertRule(acme, manual); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	rules := []AlertRule{
+		{TenantID: "acme", Name: "errors", Kind: AlertKindErrorRate, Threshold: 10},
+		{TenantID: "acme", Name: "slow", Kind: AlertKindP99Latency, Threshold: 500},
+	}
+	if c, u, d, err := repo.SyncManagedAlertRules(ctx, rules); err != nil || c != 2 || u != 0 || d != 0 {
+		t.Fatalf("first sync = %d/%d/%d, %v; want 2 created", c, u, d, err)
+	}
+	if c, u, d, err := repo.SyncManagedAlertRules(ctx, rules); err != nil || c+u+d != 0 {
+		t.Fatalf("unchanged sync = %d/%d/%d, %v; want no-op", c, u, d, err)
+	}
+
+	got, _ := repo.ListAlertRules(acme)
+	var slowID uint
+	for _, r := range got {
+		if r.Name == "slow" {
+			slowID = r.ID
+		}
+	}
+	if err := repo.SaveAlertEvent(ctx, &AlertEvent{TenantID: "acme", RuleID: slowID, RuleName: "slow", State: AlertStateFiring, FiredAt: time.Now()}); err != nil {
+		t.Fatalf("SaveAlertEvent: %v", err)
+	}
+
+	rules = []AlertRule{{TenantID: "acme", Name: "errors", Kind: AlertKindErrorRate, Threshold: 20}}
+	if c, u, d, err := repo.SyncManagedAlertRules(ctx, rules); err != nil || c != 0 || u != 1 || d != 1 {
+		t.Fatalf("second sync = %d/%d/%d, %v; want 1 updated, 1 deleted", c, u, d, err)
+	}
+	if ev, _ := repo.FiringAlertEvent(ctx, slowID); ev != nil {
+		t.Errorf("removed rule left event %+v firing", ev)
+	}
+	got, _ = repo.ListAlertRules(acme)
+	if len(got) != 2 {
+		t.Fatalf("rules = %+v, want the manual rule and one managed rule", got)
+	}
+	for _, r := range got {
+		switch {
+		case r.ID == manual.ID && (r.Managed || r.Threshold != 5):
+			t.Errorf("manual rule changed: %+v", r)
+		case r.ID != manual.ID && (!r.Managed || r.Threshold != 20):
+			t.Errorf("managed rule = %+v, want threshold 20", r)
+		}
+	}
+}
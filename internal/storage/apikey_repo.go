@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API key scopes, from least to most privileged. write implies read; admin
+// implies the others.
+const (
+	APIKeyScopeIngest = "ingest"
+	APIKeyScopeRead   = "read"
+	APIKeyScopeWrite  = "write"
+	APIKeyScopeAdmin  = "admin"
+)
+
+// ValidAPIKeyScope reports whether scope is one of the APIKeyScope* values.
+func ValidAPIKeyScope(scope string) bool {
+	switch scope {
+	case APIKeyScopeIngest, APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// CreateAPIKey inserts key under the tenant on ctx. The caller hashes the
+// plaintext into key.KeyHash; it is never stored.
+func (r *Repository) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	key.ID = 0
+	key.TenantID = TenantFromContext(ctx)
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns the API keys of the tenant on ctx, oldest first.
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	if err := r.db.WithContext(ctx).
+		Where(sqlWhereTenantID, TenantFromContext(ctx)).
+		Order("id ASC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey revokes a key of the tenant on ctx and returns its hash so
+// the caller can evict it from any cache. Returns gorm.ErrRecordNotFound when
+// the tenant has no such key.
+func (r *Repository) DeleteAPIKey(ctx context.Context, id uint) (string, error) {
+	var key APIKey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).First(&key).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&key).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to delete api key: %w", err)
+	}
+	return key.KeyHash, nil
+}
+
+// APIKeyByHash returns the key with the given hash, or nil when none exists.
+//
+// Tenant scope: SYSTEM-WIDE — authentication runs before a tenant is known.
+// Never expose this on a tenant-scoped API surface.
+func (r *Repository) APIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	return &key, nil
+}
+
+// TouchAPIKey records that a key was used at t.
+func (r *Repository) TouchAPIKey(ctx context.Context, id uint, t time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", t).Error
+}
+
+// HasAdminAPIKey reports whether any tenant has an admin-scoped key.
+//
+// Tenant scope: SYSTEM-WIDE, for the startup bootstrap check.
+func (r *Repository) HasAdminAPIKey(ctx context.Context) (bool, error) {
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&APIKey{}).Where("scope = ?", APIKeyScopeAdmin).Count(&n).Error; err != nil {
+		return false, fmt.Errorf("failed to count admin api keys: %w", err)
+	}
+	return n > 0, nil
+}
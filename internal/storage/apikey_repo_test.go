@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestAPIKeys_TenantScopedCRUD(t *testing.T) {
+	repo := newTestRepo(t)
+	acme := WithTenantContext(context.Background(), "acme")
+	other := WithTenantContext(context.Background(), "other")
+
+	if ok, err := repo.HasAdminAPIKey(acme); err != nil || ok {
+		t.Fatalf("HasAdminAPIKey on empty table = %v, %v", ok, err)
+	}
+	key := &APIKey{TenantID: "spoofed", Name: "ci", Prefix: "oc_abc", KeyHash: "h1", Scope: APIKeyScopeAdmin}
+	if err := repo.CreateAPIKey(acme, key); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if key.TenantID != "acme" || key.ID == 0 {
+		t.Fatalf("created key = %+v, want tenant acme and an id", key)
+	}
+	if ok, _ := repo.HasAdminAPIKey(other); !ok {
+		t.Error("HasAdminAPIKey should see admin keys of any tenant")
+	}
+
+	if keys, _ := repo.ListAPIKeys(other); len(keys) != 0 {
+		t.Errorf("other tenant sees %d keys", len(keys))
+	}
+	got, err := repo.APIKeyByHash(context.Background(), "h1")
+	if err != nil || got == nil || got.TenantID != "acme" {
+		t.Fatalf("APIKeyByHash = %+v, %v", got, err)
+	}
+	if miss, err := repo.APIKeyByHash(context.Background(), "nope"); miss != nil || err != nil {
+		t.Errorf("unknown hash = %+v, %v; want nil, nil", miss, err)
+	}
+
+	used := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := repo.TouchAPIKey(acme, key.ID, used); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+	keys, _ := repo.ListAPIKeys(acme)
+	if len(keys) != 1 || keys[0].LastUsedAt == nil || !keys[0].LastUsedAt.Equal(used) {
+		t.Fatalf("listed keys = %+v", keys)
+	}
+
+	if _, err := repo.DeleteAPIKey(other, key.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("cross-tenant delete err = %v, want ErrRecordNotFound", err)
+	}
+	hash, err := repo.DeleteAPIKey(acme, key.ID)
+	if err != nil || hash != "h1" {
+		t.Fatalf("DeleteAPIKey = %q, %v", hash, err)
+	}
+	if got, _ := repo.APIKeyByHash(context.Background(), "h1"); got != nil {
+		t.Error("deleted key still resolves")
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// otlpKeyValue mirrors the JSON shape the receiver writes into
+// AttributesJSON: encoding/json over []*commonpb.KeyValue, where the AnyValue
+// oneof wrapper has no json tags and lands under value.Value.<Kind>Value.
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		Value struct {
+			StringValue *string
+			IntValue    *int64
+			DoubleValue *float64
+			BoolValue   *bool
+		}
+	} `json:"value"`
+}
+
+// scalar renders a string, int, double or bool value as a string; other
+// kinds report ok=false.
+func (kv otlpKeyValue) scalar() (string, bool) {
+	v := kv.Value.Value
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue, true
+	case v.IntValue != nil:
+		return strconv.FormatInt(*v.IntValue, 10), true
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64), true
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue), true
+	}
+	return "", false
+}
+
+// parseAttributes decodes an AttributesJSON blob; nil for anything that is
+// not a KeyValue list (e.g. the "{}" written for synthesized logs).
+func parseAttributes(attrs string) []otlpKeyValue {
+	if attrs == "" || attrs[0] != '[' {
+		return nil
+	}
+	var kvs []otlpKeyValue
+	if err := json.Unmarshal([]byte(attrs), &kvs); err != nil {
+		return nil
+	}
+	return kvs
+}
+
+// attributeValue returns the scalar value of the first attribute in attrs
+// whose key matches one of keys, rendered as a string. Array, map and bytes
+// values — and blobs that are not a KeyValue list at all — report ok=false.
+func attributeValue(attrs string, keys ...string) (string, bool) {
+	kvs := parseAttributes(attrs)
+	for _, want := range keys {
+		for _, kv := range kvs {
+			if kv.Key != want {
+				continue
+			}
+			if v, ok := kv.scalar(); ok {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Actions recorded on AuditEvent.Action.
+const (
+	AuditActionAlertRuleCreate   = "alert_rule.create"
+	AuditActionAlertRuleDelete   = "alert_rule.delete"
+	AuditActionSLOCreate         = "slo.create"
+	AuditActionSLOUpdate         = "slo.update"
+	AuditActionSLODelete         = "slo.delete"    // also deletes its burn-rate alert rules
+	AuditActionConfigUpdate      = "config.update" // PATCH /api/config, including retention
+	AuditActionHubUpdate         = "hub.update"    // PUT /api/admin/hub
+	AuditActionFlagUpdate        = "flag.update"   // PUT /api/admin/flags/{name}
+	AuditActionAPIKeyCreate      = "api_key.create"
+	AuditActionAPIKeyRevoke      = "api_key.revoke"
+	AuditActionDataPurge         = "data.purge" // DELETE /api/admin/purge
+	AuditActionDLQReplay         = "dlq.replay" // POST /api/admin/dlq/replay
+	AuditActionDLQDelete         = "dlq.delete"
+	AuditActionDBVacuum          = "db.vacuum"          // POST /api/admin/vacuum
+	AuditActionLogsFTSDrop       = "logs_fts.drop"      // POST /api/admin/drop_fts
+	AuditActionRehydrationCreate = "rehydration.create" // POST /api/cold/rehydrations
+	AuditActionRehydrationDelete = "rehydration.delete"
+)
+
+// globalAuditActions act on the whole instance rather than the caller's
+// tenant: their events are marked Global and listed for every tenant.
+var globalAuditActions = map[string]bool{
+	AuditActionConfigUpdate: true,
+	AuditActionHubUpdate:    true,
+	AuditActionFlagUpdate:   true,
+	AuditActionDataPurge:    true,
+	AuditActionDLQReplay:    true,
+	AuditActionDLQDelete:    true,
+	AuditActionDBVacuum:     true,
+	AuditActionLogsFTSDrop:  true,
+}
+
+// Actor types recorded on AuditEvent.ActorType.
+const (
+	AuditActorUser      = "user"
+	AuditActorAPIKey    = "api_key"
+	AuditActorAnonymous = "anonymous" // API authentication is off
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditQuery filters ListAuditEvents. Zero fields match everything.
+type AuditQuery struct {
+	Start  time.Time
+	End    time.Time
+	Actor  string
+	Action string
+	Limit  int // default 100, max 1000
+}
+
+// RecordAuditEvent stores e under the tenant on ctx, marked Global when its
+// action is instance-wide.
+func (r *Repository) RecordAuditEvent(ctx context.Context, e *AuditEvent) error {
+	e.TenantID = TenantFromContext(ctx)
+	e.Global = globalAuditActions[e.Action]
+	if err := r.db.WithContext(ctx).Create(e).Error; err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEvents returns the audit events of the tenant on ctx and the
+// instance-wide events of every tenant, newest first.
+func (r *Repository) ListAuditEvents(ctx context.Context, q AuditQuery) ([]AuditEvent, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditLimit
+	}
+	q.Limit = min(q.Limit, maxAuditLimit)
+	query := r.db.WithContext(ctx).Where("tenant_id = ? OR global = ?", TenantFromContext(ctx), true)
+	if !q.Start.IsZero() {
+		query = query.Where("created_at >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		query = query.Where("created_at <= ?", q.End)
+	}
+	query = query.Where(&AuditEvent{Actor: q.Actor, Action: q.Action})
+	var out []AuditEvent
+	if err := query.Order("created_at DESC, id DESC").Limit(q.Limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAuditEvents_RecordAndList(t *testing.T) {
+	repo := newTestRepo(t)
+	acme := WithTenantContext(context.Background(), "acme")
+	events := []AuditEvent{
+		{Actor: "ada@example.com", ActorType: AuditActorUser, Action: AuditActionAlertRuleCreate, Resource: "alert_rule/1"},
+		{Actor: "oc_abcdefg (ci)", ActorType: AuditActorAPIKey, Action: AuditActionAPIKeyRevoke, Resource: "api_key/2"},
+		{Actor: "ada@example.com", ActorType: AuditActorUser, Action: AuditActionConfigUpdate, Resource: "config", Details: "HOT_RETENTION_DAYS=14"},
+	}
+	for i := range events {
+		if err := repo.RecordAuditEvent(acme, &events[i]); err != nil {
+			t.Fatalf("RecordAuditEvent: %v", err)
+		}
+	}
+	other := AuditEvent{Actor: "eve", ActorType: AuditActorUser, Action: AuditActionAlertRuleDelete}
+	if err := repo.RecordAuditEvent(WithTenantContext(context.Background(), "beta"), &other); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := repo.ListAuditEvents(acme, AuditQuery{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(all) != 3 || all[0].Action != AuditActionConfigUpdate || all[0].TenantID != "acme" {
+		t.Fatalf("acme events = %+v, want 3 newest first", all)
+	}
+	if got, _ := repo.ListAuditEvents(acme, AuditQuery{Actor: "ada@example.com", Action: AuditActionAlertRuleCreate}); len(got) != 1 || got[0].Resource != "alert_rule/1" {
+		t.Errorf("filtered = %+v", got)
+	}
+	if got, _ := repo.ListAuditEvents(acme, AuditQuery{Limit: 2}); len(got) != 2 {
+		t.Errorf("limit 2 returned %d events", len(got))
+	}
+	if got, _ := repo.ListAuditEvents(acme, AuditQuery{Start: time.Now().Add(time.Hour)}); len(got) != 0 {
+		t.Errorf("future start returned %+v", got)
+	}
+}
+
+func TestAuditEvents_GlobalActionsListedForEveryTenant(t *testing.T) {
+	repo := newTestRepo(t)
+	acme := WithTenantContext(context.Background(), "acme")
+	beta := WithTenantContext(context.Background(), "beta")
+	purge := AuditEvent{Actor: "eve", ActorType: AuditActorUser, Action: AuditActionDataPurge, Resource: "logs,traces"}
+	if err := repo.RecordAuditEvent(acme, &purge); err != nil {
+		t.Fatal(err)
+	}
+	local := AuditEvent{Actor: "eve", ActorType: AuditActorUser, Action: AuditActionAlertRuleCreate}
+	if err := repo.RecordAuditEvent(acme, &local); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.ListAuditEvents(beta, AuditQuery{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(got) != 1 || got[0].Action != AuditActionDataPurge || !got[0].Global || got[0].TenantID != "acme" {
+		t.Errorf("beta events = %+v, want only acme's purge", got)
+	}
+	if got, _ := repo.ListAuditEvents(beta, AuditQuery{Action: AuditActionAlertRuleCreate}); len(got) != 0 {
+		t.Errorf("beta sees acme's tenant events: %+v", got)
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Signals recorded on ColdSegment.Signal. Traces are exported as a traces
+// segment and a spans segment committed together.
+const (
+	ColdSignalLogs   = "logs"
+	ColdSignalTraces = "traces"
+	ColdSignalSpans  = "spans"
+)
+
+const (
+	defaultColdSegmentLimit = 100
+	maxColdSegmentLimit     = 1000
+)
+
+// ColdSegmentQuery filters ListColdSegments. Zero fields match everything.
+type ColdSegmentQuery struct {
+	Signal string
+	Start  time.Time // segments with rows at or after Start
+	End    time.Time // segments with rows at or before End
+	Limit  int       // default 100, max 1000
+}
+
+// ColdTierTenants returns the tenants with rows of signal (logs or traces)
+// older than cutoff.
+func (r *Repository) ColdTierTenants(ctx context.Context, signal string, cutoff time.Time) ([]string, error) {
+	table, tsColumn, err := r.coldTierTable(signal)
+	if err != nil {
+		return nil, err
+	}
+	var tenants []string
+	if err := r.db.WithContext(ctx).Table(table).Where(tsColumn+" < ?", cutoff).Distinct("tenant_id").Pluck("tenant_id", &tenants).Error; err != nil {
+		return nil, fmt.Errorf("failed to list tenants with %s to tier: %w", signal, err)
+	}
+	return tenants, nil
+}
+
+func (r *Repository) coldTierTable(signal string) (table, tsColumn string, err error) {
+	switch signal {
+	case ColdSignalLogs:
+		return r.logsTable(), "timestamp", nil
+	case ColdSignalTraces:
+		return "traces", "timestamp", nil
+	}
+	return "", "", fmt.Errorf("signal %q cannot be tiered", signal)
+}
+
+// LogsForColdTier returns up to limit of the tenant's logs older than
+// cutoff, oldest id first.
+func (r *Repository) LogsForColdTier(ctx context.Context, tenant string, cutoff time.Time, limit int) ([]Log, error) {
+	var logs []Log
+	err := r.db.WithContext(ctx).Table(r.logsTable()).
+		Where("tenant_id = ? AND timestamp < ?", tenant, cutoff).
+		Order("id").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs to tier: %w", err)
+	}
+	return logs, nil
+}
+
+// TracesForColdTier returns up to limit of the tenant's traces older than
+// cutoff, oldest id first, with all of their spans.
+func (r *Repository) TracesForColdTier(ctx context.Context, tenant string, cutoff time.Time, limit int) ([]Trace, []Span, error) {
+	var traces []Trace
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND timestamp < ?", tenant, cutoff).
+		Order("id").Limit(limit).Find(&traces).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read traces to tier: %w", err)
+	}
+	if len(traces) == 0 {
+		return nil, nil, nil
+	}
+	var spans []Span
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND trace_id IN ?", tenant, traceIDsOf(traces)).
+		Order("id").Find(&spans).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to read spans to tier: %w", err)
+	}
+	return traces, spans, nil
+}
+
+func traceIDsOf(traces []Trace) []string {
+	ids := make([]string, len(traces))
+	for i, t := range traces {
+		ids[i] = t.TraceID
+	}
+	return ids
+}
+
+// CommitColdLogs records segments and, in the same transaction, deletes
+// the tenant's logs older than cutoff up to maxID, which the segments
+// hold. The deletion is recorded with reason cold_tier.
+func (r *Repository) CommitColdLogs(ctx context.Context, tenant string, cutoff time.Time, maxID uint, segments []ColdSegment) (int64, error) {
+	return r.commitColdSegments(ctx, DeletionSignalLogs, r.logsTable(), tenant, cutoff, maxID, segments, nil, 0)
+}
+
+// CommitColdTraces is CommitColdLogs for traces: it also deletes the
+// traces' spans up to maxSpanID, the highest span id exported, with the
+// span attributes, exceptions, links and events derived from them, and the
+// traces' AI insights. A span that arrived for one of the traces after
+// TracesForColdTier read them is kept.
+func (r *Repository) CommitColdTraces(ctx context.Context, tenant string, cutoff time.Time, maxID, maxSpanID uint, traceIDs []string, segments []ColdSegment) (int64, error) {
+	return r.commitColdSegments(ctx, DeletionSignalTraces, "traces", tenant, cutoff, maxID, segments, traceIDs, maxSpanID)
+}
+
+func (r *Repository) commitColdSegments(ctx context.Context, signal, table, tenant string, cutoff time.Time, maxID uint, segments []ColdSegment, traceIDs []string, maxSpanID uint) (int64, error) {
+	if len(segments) == 0 {
+		return 0, errors.New("no cold segments to commit")
+	}
+	where := "tenant_id = ? AND timestamp < ? AND id <= ?"
+	args := []any{tenant, cutoff, maxID}
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range segments {
+			segments[i].TenantID = tenant
+		}
+		if err := tx.Create(&segments).Error; err != nil {
+			return fmt.Errorf("record cold segments: %w", err)
+		}
+		groups, err := countDeletionGroups(tx, table, "timestamp", where, args...)
+		if err != nil {
+			return fmt.Errorf("count %s to delete: %w", signal, err)
+		}
+		res := tx.Exec("DELETE FROM "+table+" WHERE "+where, args...)
+		if res.Error != nil {
+			return fmt.Errorf("delete tiered %s: %w", signal, res.Error)
+		}
+		deleted = res.RowsAffected
+		if len(traceIDs) > 0 {
+			// Derived rows go first, while the exported spans still name them.
+			exported := "SELECT span_id FROM spans WHERE tenant_id = ? AND trace_id IN ? AND id <= ?"
+			for _, t := range []string{"span_attributes", "span_exceptions", "span_links", "span_events"} {
+				if err := tx.Exec("DELETE FROM "+t+" WHERE tenant_id = ? AND trace_id IN ? AND span_id IN ("+exported+")",
+					tenant, traceIDs, tenant, traceIDs, maxSpanID).Error; err != nil {
+					return fmt.Errorf("delete tiered %s: %w", t, err)
+				}
+			}
+			if err := tx.Exec("DELETE FROM spans WHERE tenant_id = ? AND trace_id IN ? AND id <= ?", tenant, traceIDs, maxSpanID).Error; err != nil {
+				return fmt.Errorf("delete tiered spans: %w", err)
+			}
+			if err := tx.Exec("DELETE FROM trace_insights WHERE tenant_id = ? AND trace_id IN ?", tenant, traceIDs).Error; err != nil {
+				return fmt.Errorf("delete tiered trace_insights: %w", err)
+			}
+		}
+		return recordDeletions(tx, signal, DeletionReasonColdTier, cutoff, groups)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// ListColdSegments returns the cold segments of the tenant on ctx whose
+// rows overlap the query range, newest first.
+func (r *Repository) ListColdSegments(ctx context.Context, q ColdSegmentQuery) ([]ColdSegment, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultColdSegmentLimit
+	}
+	q.Limit = min(q.Limit, maxColdSegmentLimit)
+	query := r.db.WithContext(ctx).Where(sqlWhereTenantID, TenantFromContext(ctx))
+	if q.Signal != "" {
+		query = query.Where(&ColdSegment{Signal: q.Signal})
+	}
+	if !q.Start.IsZero() {
+		query = query.Where("max_time >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		query = query.Where("min_time <= ?", q.End)
+	}
+	var out []ColdSegment
+	if err := query.Order("max_time DESC, id DESC").Limit(q.Limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list cold segments: %w", err)
+	}
+	return out, nil
+}
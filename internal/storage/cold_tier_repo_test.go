@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCommitColdTraces_DeletesBatchAndRecordsSegments(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	rows := []any{
+		&Trace{TenantID: "acme", TraceID: "t1", ServiceName: "checkout", Timestamp: old},
+		&Trace{TenantID: "acme", TraceID: "t2", ServiceName: "checkout", Timestamp: old},
+		&Trace{TenantID: "acme", TraceID: "t3", ServiceName: "checkout", Timestamp: now},
+		&Trace{TenantID: "other", TraceID: "t4", ServiceName: "checkout", Timestamp: old},
+		&Span{TenantID: "acme", TraceID: "t1", SpanID: "s1", StartTime: old},
+		&Span{TenantID: "acme", TraceID: "t2", SpanID: "s2", StartTime: old},
+		&Span{TenantID: "acme", TraceID: "t3", SpanID: "s3", StartTime: now},
+	}
+	for _, row := range rows {
+		if err := repo.db.Create(row).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	cutoff := now.Add(-24 * time.Hour)
+
+	tenants, err := repo.ColdTierTenants(ctx, ColdSignalTraces, cutoff)
+	if err != nil || len(tenants) != 2 {
+		t.Fatalf("ColdTierTenants = %v, %v", tenants, err)
+	}
+	// A batch of one: only t1 goes.
+	traces, spans, err := repo.TracesForColdTier(ctx, "acme", cutoff, 1)
+	if err != nil || len(traces) != 1 || traces[0].TraceID != "t1" || len(spans) != 1 {
+		t.Fatalf("TracesForColdTier = %+v, %+v, %v", traces, spans, err)
+	}
+	segs := []ColdSegment{
+		{Signal: ColdSignalTraces, ObjectKey: "traces/acme/1-1.parquet", MinTime: old, MaxTime: old, RowCount: 1},
+		{Signal: ColdSignalSpans, ObjectKey: "spans/acme/1-1.parquet", MinTime: old, MaxTime: old, RowCount: 1},
+	}
+	// A span of t1 that lands between the export and the commit is not in
+	// the segment, so it must stay.
+	late := Span{TenantID: "acme", TraceID: "t1", SpanID: "s1b", StartTime: old, Events: []SpanEvent{{Name: "retry", Timestamp: old}}}
+	if err := repo.db.Create(&SpanEvent{TenantID: "acme", TraceID: "t1", SpanID: "s1", Name: "retry", Timestamp: old}).Error; err != nil {
+		t.Fatalf("seed event: %v", err)
+	}
+	if err := repo.BatchCreateSpans([]Span{late}); err != nil {
+		t.Fatalf("late span: %v", err)
+	}
+	n, err := repo.CommitColdTraces(ctx, "acme", cutoff, traces[0].ID, spans[len(spans)-1].ID, []string{"t1"}, segs)
+	if err != nil || n != 1 {
+		t.Fatalf("CommitColdTraces = %d, %v", n, err)
+	}
+
+	var left []string
+	repo.db.Model(&Trace{}).Order("trace_id").Pluck("trace_id", &left)
+	if len(left) != 3 || left[0] != "t2" {
+		t.Errorf("traces left = %v, want t2 t3 t4", left)
+	}
+	var spansLeft []string
+	repo.db.Model(&Span{}).Order("span_id").Pluck("span_id", &spansLeft)
+	if len(spansLeft) != 3 || spansLeft[0] != "s1b" {
+		t.Errorf("spans left = %v, want the late s1b, s2 and s3", spansLeft)
+	}
+	var eventsLeft []string
+	repo.db.Model(&SpanEvent{}).Pluck("span_id", &eventsLeft)
+	if len(eventsLeft) != 1 || eventsLeft[0] != "s1b" {
+		t.Errorf("span events left = %v, want only the late span's", eventsLeft)
+	}
+
+	acme := WithTenantContext(ctx, "acme")
+	got, err := repo.ListColdSegments(acme, ColdSegmentQuery{Signal: ColdSignalTraces})
+	if err != nil || len(got) != 1 || got[0].TenantID != "acme" || got[0].ObjectKey != "traces/acme/1-1.parquet" {
+		t.Errorf("ListColdSegments = %+v, %v", got, err)
+	}
+	if got, _ := repo.ListColdSegments(acme, ColdSegmentQuery{End: old.Add(-time.Hour)}); len(got) != 0 {
+		t.Errorf("segments before their rows = %+v", got)
+	}
+	if got, _ := repo.ListColdSegments(WithTenantContext(ctx, "other"), ColdSegmentQuery{}); len(got) != 0 {
+		t.Errorf("other tenant sees %+v", got)
+	}
+	dels, _ := repo.ListDeletions(acme, DeletionQuery{Signal: DeletionSignalTraces})
+	if len(dels) != 1 || dels[0].Reason != DeletionReasonColdTier || dels[0].RowCount != 1 {
+		t.Errorf("deletions = %+v", dels)
+	}
+}
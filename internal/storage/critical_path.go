@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// CriticalPath is the chain of spans that determines a trace's duration:
+// at every instant, the span the trace was actually waiting on. Speeding up
+// anything off the path does not shorten the trace.
+type CriticalPath struct {
+	TraceID    string                `json:"trace_id"`
+	DurationUs int64                 `json:"duration_us"`
+	Spans      []CriticalPathSpan    `json:"spans"`    // path order
+	Services   []CriticalPathService `json:"services"` // largest contribution first
+}
+
+// CriticalPathSpan is one span on the critical path. PathTimeUs is the time
+// it spends on the path itself — its duration minus the stretches where the
+// path runs through one of its children.
+type CriticalPathSpan struct {
+	SpanID          string  `json:"span_id"`
+	ParentSpanID    string  `json:"parent_span_id,omitempty"`
+	OperationName   string  `json:"operation_name"`
+	ServiceName     string  `json:"service_name"`
+	Status          string  `json:"status"`
+	StartOffsetUs   int64   `json:"start_offset_us"` // first moment on the path
+	DurationUs      int64   `json:"duration_us"`
+	PathTimeUs      int64   `json:"path_time_us"`
+	ContributionPct float64 `json:"contribution_pct"`
+}
+
+// CriticalPathService sums critical path time by service.
+type CriticalPathService struct {
+	ServiceName     string  `json:"service_name"`
+	PathTimeUs      int64   `json:"path_time_us"`
+	ContributionPct float64 `json:"contribution_pct"`
+}
+
+// GetCriticalPath loads a trace of the tenant on ctx and computes its
+// critical path. Errors like GetTrace when the trace does not exist.
+func (r *Repository) GetCriticalPath(ctx context.Context, traceID string) (*CriticalPath, error) {
+	trace, err := r.GetTrace(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCriticalPath(BuildTraceTree(trace)), nil
+}
+
+// BuildCriticalPath walks tree backwards from the end of the trace. Within
+// a span, the child that finished last is what the span waited on; the
+// path descends into it, then continues from that child's start with the
+// child that finished last before it, and so on. Stretches where no child
+// was running belong to the span itself. Children are clipped to their
+// parent's interval so clock skew and async children that outlive their
+// parent cannot inflate the path.
+func BuildCriticalPath(tree *TraceTree) *CriticalPath {
+	cp := &CriticalPath{
+		TraceID:    tree.TraceID,
+		DurationUs: tree.DurationUs,
+		Spans:      []CriticalPathSpan{},
+		Services:   []CriticalPathService{},
+	}
+	byID := make(map[string]*CriticalPathSpan)
+	add := func(n *TraceTreeNode, start, end int64) {
+		if end <= start {
+			return
+		}
+		s, ok := byID[n.SpanID]
+		if !ok {
+			s = &CriticalPathSpan{
+				SpanID:        n.SpanID,
+				ParentSpanID:  n.ParentSpanID,
+				OperationName: n.OperationName,
+				ServiceName:   n.ServiceName,
+				Status:        n.Status,
+				StartOffsetUs: start,
+				DurationUs:    n.DurationUs,
+			}
+			byID[n.SpanID] = s
+		}
+		s.StartOffsetUs = min(s.StartOffsetUs, start)
+		s.PathTimeUs += end - start
+	}
+
+	// The roots hang off a virtual span covering the whole trace; time
+	// where no root was running is not attributed to anything.
+	walkCriticalPath(tree.Roots, 0, tree.DurationUs, nil, add)
+
+	services := make(map[string]*CriticalPathService)
+	for _, s := range byID {
+		s.ContributionPct = pct(s.PathTimeUs, cp.DurationUs)
+		cp.Spans = append(cp.Spans, *s)
+		svc, ok := services[s.ServiceName]
+		if !ok {
+			svc = &CriticalPathService{ServiceName: s.ServiceName}
+			services[s.ServiceName] = svc
+		}
+		svc.PathTimeUs += s.PathTimeUs
+	}
+	sort.Slice(cp.Spans, func(i, j int) bool { return cp.Spans[i].StartOffsetUs < cp.Spans[j].StartOffsetUs })
+	for _, svc := range services {
+		svc.ContributionPct = pct(svc.PathTimeUs, cp.DurationUs)
+		cp.Services = append(cp.Services, *svc)
+	}
+	sort.Slice(cp.Services, func(i, j int) bool {
+		if cp.Services[i].PathTimeUs != cp.Services[j].PathTimeUs {
+			return cp.Services[i].PathTimeUs > cp.Services[j].PathTimeUs
+		}
+		return cp.Services[i].ServiceName < cp.Services[j].ServiceName
+	})
+	return cp
+}
+
+// walkCriticalPath attributes the window [lo, hi] of owner (nil for the
+// virtual trace root) to owner or, where the path runs through them, to its
+// children, recursing into each child on the path.
+func walkCriticalPath(children []*TraceTreeNode, lo, hi int64, owner *TraceTreeNode, add func(*TraceTreeNode, int64, int64)) {
+	byEnd := make([]*TraceTreeNode, len(children))
+	copy(byEnd, children)
+	sort.SliceStable(byEnd, func(i, j int) bool {
+		return byEnd[i].StartOffsetUs+byEnd[i].DurationUs > byEnd[j].StartOffsetUs+byEnd[j].DurationUs
+	})
+
+	cursor := hi
+	for _, c := range byEnd {
+		if cursor <= lo {
+			break
+		}
+		cStart := max(c.StartOffsetUs, lo)
+		cEnd := min(c.StartOffsetUs+c.DurationUs, cursor)
+		if cEnd <= cStart {
+			continue // started after the path had already moved past it
+		}
+		if owner != nil {
+			add(owner, cEnd, cursor)
+		}
+		walkCriticalPath(c.Children, cStart, cEnd, c, add)
+		cursor = cStart
+	}
+	if owner != nil {
+		add(owner, lo, cursor)
+	}
+}
+
+func pct(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildCriticalPath(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0).UTC()
+	span := func(id, parent, svc string, startMs, durMs int) Span {
+		st := t0.Add(time.Duration(startMs) * time.Millisecond)
+		return Span{
+			TraceID:      "t1",
+			SpanID:       id,
+			ParentSpanID: parent,
+			ServiceName:  svc,
+			StartTime:    st,
+			EndTime:      st.Add(time.Duration(durMs) * time.Millisecond),
+			Duration:     int64(durMs) * 1000,
+		}
+	}
+	// gateway 0–100 calls auth 5–15, then fans out to orders 20–60 and
+	// cache 20–30 in parallel, then db 60–90. cache is off the path: orders
+	// was still running when it finished.
+	trace := &Trace{TraceID: "t1", Spans: []Span{
+		span("gw", "", "gateway", 0, 100),
+		span("auth", "gw", "auth", 5, 10),
+		span("orders", "gw", "orders", 20, 40),
+		span("cache", "gw", "cache", 20, 10),
+		span("db", "gw", "db", 60, 30),
+	}}
+
+	cp := BuildCriticalPath(BuildTraceTree(trace))
+	got := make(map[string]CriticalPathSpan)
+	for _, s := range cp.Spans {
+		got[s.SpanID] = s
+	}
+	if _, ok := got["cache"]; ok {
+		t.Error("cache ran in parallel with orders and must not be on the path")
+	}
+	want := map[string]int64{"gw": 20_000, "auth": 10_000, "orders": 40_000, "db": 30_000}
+	for id, us := range want {
+		if got[id].PathTimeUs != us {
+			t.Errorf("%s path time = %d, want %d", id, got[id].PathTimeUs, us)
+		}
+	}
+	if got["orders"].ContributionPct != 40 {
+		t.Errorf("orders contribution = %v, want 40", got["orders"].ContributionPct)
+	}
+	if cp.Spans[0].SpanID != "gw" || cp.Spans[1].SpanID != "auth" || cp.Spans[len(cp.Spans)-1].SpanID != "db" {
+		t.Errorf("spans not in path order: %+v", cp.Spans)
+	}
+	if cp.Services[0].ServiceName != "orders" {
+		t.Errorf("top service = %q, want orders", cp.Services[0].ServiceName)
+	}
+
+	var total int64
+	for _, s := range cp.Spans {
+		total += s.PathTimeUs
+	}
+	if total != cp.DurationUs {
+		t.Errorf("path time sums to %d, want trace duration %d", total, cp.DurationUs)
+	}
+}
+
+func TestBuildCriticalPath_ClipsChildOutlivingParent(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0).UTC()
+	trace := &Trace{Spans: []Span{
+		{SpanID: "p", StartTime: t0, EndTime: t0.Add(10 * time.Millisecond), Duration: 10_000},
+		{SpanID: "c", ParentSpanID: "p", StartTime: t0.Add(5 * time.Millisecond), EndTime: t0.Add(50 * time.Millisecond), Duration: 45_000},
+	}}
+	cp := BuildCriticalPath(BuildTraceTree(trace))
+	for _, s := range cp.Spans {
+		if s.SpanID == "c" && s.PathTimeUs != 5_000 {
+			t.Errorf("async child path time = %d, want 5000 (clipped to parent)", s.PathTimeUs)
+		}
+	}
+}
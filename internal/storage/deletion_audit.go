@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Signals recorded on DeletionRecord.Signal.
+const (
+	DeletionSignalLogs    = "logs"
+	DeletionSignalTraces  = "traces"
+	DeletionSignalMetrics = "metrics"
+)
+
+// Reasons recorded on DeletionRecord.Reason.
+const (
+	DeletionReasonRetention       = "retention"        // the global retention window
+	DeletionReasonTenantRetention = "tenant_retention" // a per-tenant retention window
+	DeletionReasonAdminPurge      = "admin_purge"      // DELETE /api/admin/purge
+	DeletionReasonPartitionDrop   = "partition_drop"   // an expired logs partition or SQLite shard
+	DeletionReasonColdTier        = "cold_tier"        // moved to the cold tier (ColdSegment)
+)
+
+const (
+	defaultDeletionLimit = 100
+	maxDeletionLimit     = 1000
+
+	// deletionAuditTimeout bounds the recount and write after a purge. They
+	// run detached from the purge's context so a purge cut short by
+	// shutdown still leaves its record.
+	deletionAuditTimeout = 10 * time.Second
+)
+
+// DeletionQuery filters ListDeletions. Zero fields match everything.
+type DeletionQuery struct {
+	Start       time.Time
+	End         time.Time
+	Signal      string
+	ServiceName string
+	Reason      string
+	Limit       int // default 100, max 1000
+}
+
+// deletionGroup is the rows of one tenant and service a purge deletes.
+type deletionGroup struct {
+	TenantID    string
+	ServiceName string
+	RowCount    int64
+	Oldest      aggregateTime
+}
+
+// ListDeletions returns the deletion records of the tenant on ctx, newest
+// first.
+func (r *Repository) ListDeletions(ctx context.Context, q DeletionQuery) ([]DeletionRecord, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultDeletionLimit
+	}
+	q.Limit = min(q.Limit, maxDeletionLimit)
+	query := r.db.WithContext(ctx).Where(sqlWhereTenantID, TenantFromContext(ctx))
+	if !q.Start.IsZero() {
+		query = query.Where("created_at >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		query = query.Where("created_at <= ?", q.End)
+	}
+	// A struct condition skips zero fields and quotes the column names;
+	// SIGNAL is a reserved word on MySQL.
+	query = query.Where(&DeletionRecord{Signal: q.Signal, ServiceName: q.ServiceName, Reason: q.Reason})
+	var out []DeletionRecord
+	if err := query.Order("created_at DESC, id DESC").Limit(q.Limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list deletions: %w", err)
+	}
+	return out, nil
+}
+
+// signalTable is the table and timestamp column a signal is purged from.
+func (r *Repository) signalTable(signal string) (table, tsColumn string) {
+	switch signal {
+	case DeletionSignalLogs:
+		return r.logsTable(), "timestamp"
+	case DeletionSignalTraces:
+		return "traces", "timestamp"
+	}
+	return "metric_buckets", "time_bucket"
+}
+
+// auditedPurge runs purge, which deletes the rows of signal older than
+// cutoff within scope, and records what it deleted per tenant and service.
+// The rows are counted just before the purge; when it fails or deletes a
+// different number they are recounted afterwards and the difference is
+// recorded. A purge whose rows cannot be counted does not run, so nothing
+// is ever deleted without a record.
+func (r *Repository) auditedPurge(ctx context.Context, signal, reason string, cutoff time.Time, scope purgeScope, purge func() (int64, error)) (int64, error) {
+	table, tsColumn := r.signalTable(signal)
+	tenantSQL, tenantArgs := scope.clause()
+	where := tsColumn + " < ?" + tenantSQL
+	args := append([]any{cutoff}, tenantArgs...)
+
+	before, err := countDeletionGroups(r.db.WithContext(ctx), table, tsColumn, where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("count %s to purge: %w", signal, err)
+	}
+	n, purgeErr := purge()
+	if len(before) == 0 {
+		return n, purgeErr
+	}
+
+	actx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deletionAuditTimeout)
+	defer cancel()
+	deleted := before
+	if purgeErr != nil || n != sumDeletionGroups(before) {
+		after, err := countDeletionGroups(r.db.WithContext(actx), table, tsColumn, where, args...)
+		if err != nil {
+			slog.Error("deletion audit: recount failed, purge not recorded", "signal", signal, "reason", reason, "rows_deleted", n, "error", err)
+			return n, purgeErr
+		}
+		deleted = subtractDeletionGroups(before, after)
+	}
+	if err := recordDeletions(r.db.WithContext(actx), signal, reason, cutoff, deleted); err != nil {
+		slog.Error("deletion audit: write failed", "signal", signal, "reason", reason, "rows_deleted", n, "error", err)
+	}
+	return n, purgeErr
+}
+
+// countDeletionGroups counts the rows of table matching where per tenant
+// and service, with the oldest tsColumn of each.
+func countDeletionGroups(db *gorm.DB, table, tsColumn, where string, args ...any) ([]deletionGroup, error) {
+	q := db.Table(table).
+		Select("tenant_id, service_name, COUNT(*) AS row_count, MIN(" + tsColumn + ") AS oldest")
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+	var groups []deletionGroup
+	if err := q.Group("tenant_id, service_name").Scan(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func sumDeletionGroups(groups []deletionGroup) int64 {
+	var n int64
+	for _, g := range groups {
+		n += g.RowCount
+	}
+	return n
+}
+
+// subtractDeletionGroups is the rows of before no longer present in after.
+// Rows written behind the cutoff in between can make a group grow; it then
+// counts as nothing deleted.
+func subtractDeletionGroups(before, after []deletionGroup) []deletionGroup {
+	left := make(map[[2]string]int64, len(after))
+	for _, g := range after {
+		left[[2]string{g.TenantID, g.ServiceName}] = g.RowCount
+	}
+	out := make([]deletionGroup, 0, len(before))
+	for _, g := range before {
+		g.RowCount -= left[[2]string{g.TenantID, g.ServiceName}]
+		if g.RowCount > 0 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// recordDeletions writes one DeletionRecord per group with rows deleted.
+func recordDeletions(db *gorm.DB, signal, reason string, end time.Time, groups []deletionGroup) error {
+	records := make([]DeletionRecord, 0, len(groups))
+	for _, g := range groups {
+		if g.RowCount <= 0 {
+			continue
+		}
+		records = append(records, DeletionRecord{
+			TenantID:    g.TenantID,
+			Signal:      signal,
+			ServiceName: g.ServiceName,
+			Reason:      reason,
+			RangeStart:  g.Oldest.t.UTC(),
+			RangeEnd:    end.UTC(),
+			RowCount:    g.RowCount,
+		})
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(records, 500).Error
+}
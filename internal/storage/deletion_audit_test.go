@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunPurge_RecordsDeletions verifies a retention pass records what it
+// deleted per tenant, service and signal, with the reason and time range.
+func TestRunPurge_RecordsDeletions(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	old := now.Add(-10 * 24 * time.Hour)
+	older := now.Add(-12 * 24 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	rows := []any{
+		&Log{TenantID: "acme", ServiceName: "checkout", Body: "b", Severity: "INFO", Timestamp: older},
+		&Log{TenantID: "acme", ServiceName: "checkout", Body: "b", Severity: "INFO", Timestamp: old},
+		&Log{TenantID: "acme", ServiceName: "cart", Body: "b", Severity: "INFO", Timestamp: old},
+		&Log{TenantID: "acme", ServiceName: "cart", Body: "b", Severity: "INFO", Timestamp: recent},
+		&Trace{TenantID: "acme", TraceID: "t1", ServiceName: "checkout", Timestamp: old},
+		&Log{TenantID: "short", ServiceName: "checkout", Body: "b", Severity: "INFO", Timestamp: now.Add(-2 * 24 * time.Hour)},
+	}
+	for _, row := range rows {
+		if err := repo.db.Create(row).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	sched := NewRetentionScheduler(repo, 7, 100, 0)
+	sched.SetTenantWindows(map[string]time.Duration{"short": 24 * time.Hour})
+	sched.runPurge(context.Background())
+
+	acme := WithTenantContext(context.Background(), "acme")
+	logs, err := repo.ListDeletions(acme, DeletionQuery{Signal: DeletionSignalLogs})
+	if err != nil {
+		t.Fatalf("ListDeletions: %v", err)
+	}
+	got := map[string]DeletionRecord{}
+	for _, d := range logs {
+		got[d.ServiceName] = d
+	}
+	if len(logs) != 2 || got["checkout"].RowCount != 2 || got["cart"].RowCount != 1 {
+		t.Fatalf("acme log deletions = %+v, want checkout 2 and cart 1", logs)
+	}
+	d := got["checkout"]
+	cutoff := now.Add(-7 * 24 * time.Hour)
+	if d.Reason != DeletionReasonRetention || d.RangeStart.Sub(older).Abs() > time.Millisecond ||
+		d.RangeEnd.Before(cutoff) || d.RangeEnd.After(cutoff.Add(time.Minute)) {
+		t.Errorf("checkout record = %+v, want retention over [%v, %v)", d, older, cutoff)
+	}
+
+	if traces, _ := repo.ListDeletions(acme, DeletionQuery{Signal: DeletionSignalTraces}); len(traces) != 1 || traces[0].RowCount != 1 {
+		t.Errorf("acme trace deletions = %+v", traces)
+	}
+	if metrics, _ := repo.ListDeletions(acme, DeletionQuery{Signal: DeletionSignalMetrics}); len(metrics) != 0 {
+		t.Errorf("acme metric deletions = %+v, want none without rows", metrics)
+	}
+
+	short, err := repo.ListDeletions(WithTenantContext(context.Background(), "short"), DeletionQuery{})
+	if err != nil || len(short) != 1 || short[0].Reason != DeletionReasonTenantRetention || short[0].RowCount != 1 {
+		t.Errorf("short deletions = %+v, %v; want one tenant_retention record", short, err)
+	}
+}
+
+func TestSubtractDeletionGroups(t *testing.T) {
+	before := []deletionGroup{
+		{TenantID: "a", ServiceName: "x", RowCount: 10},
+		{TenantID: "a", ServiceName: "y", RowCount: 5},
+		{TenantID: "b", ServiceName: "x", RowCount: 3},
+	}
+	after := []deletionGroup{
+		{TenantID: "a", ServiceName: "x", RowCount: 4},
+		{TenantID: "b", ServiceName: "x", RowCount: 7}, // grew behind the cutoff
+	}
+	got := subtractDeletionGroups(before, after)
+	if len(got) != 2 || got[0].RowCount != 6 || got[1].ServiceName != "y" || got[1].RowCount != 5 {
+		t.Errorf("subtractDeletionGroups = %+v", got)
+	}
+}
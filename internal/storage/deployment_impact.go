@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Deployment verdicts, set by EvaluateDeployments.
+const (
+	DeploymentVerdictOK           = "ok"
+	DeploymentVerdictRegressed    = "regressed"
+	DeploymentVerdictInsufficient = "insufficient_data"
+)
+
+const (
+	// deploymentSettleDelay is how long after a deployment's window ends
+	// EvaluateDeployments waits for late spans.
+	deploymentSettleDelay = 2 * time.Minute
+	// deploymentEvalMaxAge bounds how far back EvaluateDeployments looks, so
+	// a first boot does not evaluate the whole history.
+	deploymentEvalMaxAge = 7 * 24 * time.Hour
+	// deploymentEvalBatch bounds the deployments evaluated per call.
+	deploymentEvalBatch = 100
+	// maxDeploymentMarkers bounds the deployments overlaid on one response.
+	maxDeploymentMarkers = 1000
+
+	// A window with fewer spans than this is not compared.
+	deploymentMinSamples = 20
+	// Latency regressed when the after-window P95 is at least
+	// deploymentLatencyRegressPct percent and deploymentLatencyRegressMinUs
+	// microseconds above the before-window P95.
+	deploymentLatencyRegressPct   = 20.0
+	deploymentLatencyRegressMinUs = 5000
+	// Errors regressed when the error rate rose by at least
+	// deploymentErrorRegressPoints percentage points and at least doubled.
+	deploymentErrorRegressPoints = 1.0
+)
+
+// DeploymentWindowStats is a service's span volume, error rate and latency
+// over one side of a deployment.
+type DeploymentWindowStats struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Count      int64     `json:"count"`
+	ErrorCount int64     `json:"error_count"`
+	ErrorRate  float64   `json:"error_rate"` // percent
+	LatencyPercentiles
+}
+
+// DeploymentImpact compares a service's spans before a deployment with
+// those after it. Before starts no earlier than the service's previous
+// deployment and After ends no later than its next one, so each side
+// covers one version.
+type DeploymentImpact struct {
+	Before         DeploymentWindowStats `json:"before"`
+	After          DeploymentWindowStats `json:"after"`
+	P50DeltaPct    float64               `json:"p50_delta_pct"`
+	P95DeltaPct    float64               `json:"p95_delta_pct"`
+	P99DeltaPct    float64               `json:"p99_delta_pct"`
+	ErrorRateDelta float64               `json:"error_rate_delta"` // percentage points
+	Complete       bool                  `json:"complete"`         // the after window has ended
+	Verdict        string                `json:"verdict"`
+	Reasons        []string              `json:"reasons,omitempty"`
+}
+
+// DeploymentMarker is a deployment overlaid on a time series.
+type DeploymentMarker struct {
+	ID          uint      `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Version     string    `json:"version"`
+	Environment string    `json:"environment,omitempty"`
+	Source      string    `json:"source"`
+	Status      string    `json:"status"`
+	Verdict     string    `json:"verdict,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func markerOf(d Deployment) DeploymentMarker {
+	return DeploymentMarker{
+		ID:          d.ID,
+		ServiceName: d.ServiceName,
+		Version:     d.Version,
+		Environment: d.Environment,
+		Source:      d.Source,
+		Status:      d.Status,
+		Verdict:     d.Verdict,
+		Timestamp:   d.Timestamp,
+	}
+}
+
+// deploymentsIn returns the tenant's deployments in [start, end], oldest
+// first, of serviceNames when set.
+func (r *Repository) deploymentsIn(ctx context.Context, start, end time.Time, serviceNames []string) ([]Deployment, error) {
+	q := r.db.WithContext(ctx).Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", TenantFromContext(ctx), start, end)
+	if len(serviceNames) > 0 {
+		q = q.Where(sqlWhereServiceIn, serviceNames)
+	}
+	var out []Deployment
+	if err := q.Order("timestamp ASC").Limit(maxDeploymentMarkers).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list deployment markers: %w", err)
+	}
+	return out, nil
+}
+
+// AddTrafficDeployments overlays the deployments in [start, end] on points
+// returned by GetTrafficMetrics for the same range, services and step: each
+// is listed on the bucket it falls in. A bucket without traffic is added
+// with zero counts, so no marker is lost.
+func (r *Repository) AddTrafficDeployments(ctx context.Context, points []TrafficPoint, start, end time.Time, serviceNames []string, step time.Duration) ([]TrafficPoint, error) {
+	deploys, err := r.deploymentsIn(ctx, start, end, serviceNames)
+	if err != nil || len(deploys) == 0 {
+		return points, err
+	}
+	if step < time.Second {
+		step = time.Minute
+	}
+	secs := int64(step / time.Second)
+	index := make(map[int64]int, len(points))
+	for i, p := range points {
+		index[p.Timestamp.Unix()] = i
+	}
+	added := false
+	for _, d := range deploys {
+		bucket := d.Timestamp.Unix() / secs * secs
+		i, ok := index[bucket]
+		if !ok {
+			points = append(points, TrafficPoint{Timestamp: time.Unix(bucket, 0)})
+			i = len(points) - 1
+			index[bucket] = i
+			added = true
+		}
+		points[i].Deployments = append(points[i].Deployments, markerOf(d))
+	}
+	if added {
+		slices.SortFunc(points, func(a, b TrafficPoint) int { return a.Timestamp.Compare(b.Timestamp) })
+	}
+	return points, nil
+}
+
+// AddLatencyDeployments lists on each of services, returned by
+// GetServiceLatencyPercentiles, its deployments in [start, end].
+func (r *Repository) AddLatencyDeployments(ctx context.Context, services []ServiceLatency, start, end time.Time) error {
+	if len(services) == 0 {
+		return nil
+	}
+	names := make([]string, len(services))
+	index := make(map[string]int, len(services))
+	for i, s := range services {
+		names[i] = s.ServiceName
+		index[s.ServiceName] = i
+	}
+	deploys, err := r.deploymentsIn(ctx, start, end, names)
+	if err != nil {
+		return err
+	}
+	for _, d := range deploys {
+		if i, ok := index[d.ServiceName]; ok {
+			services[i].Deployments = append(services[i].Deployments, markerOf(d))
+		}
+	}
+	return nil
+}
+
+// GetDeploymentImpact compares d's service over window before d with
+// window after it, up to now. The tenant is d's.
+func (r *Repository) GetDeploymentImpact(ctx context.Context, d *Deployment, window time.Duration, now time.Time) (*DeploymentImpact, error) {
+	ctx = WithTenantContext(ctx, d.TenantID)
+	at := d.Timestamp
+	before := DeploymentWindowStats{Start: at.Add(-window), End: at}
+	after := DeploymentWindowStats{Start: at, End: at.Add(window)}
+
+	// Keep each side to one version.
+	db := r.db.WithContext(ctx).Model(&Deployment{}).Limit(1)
+	scope := "tenant_id = ? AND service_name = ? AND id <> ? AND status = ?"
+	var prev, next []time.Time
+	if err := db.Session(&gorm.Session{}).Where(scope, d.TenantID, d.ServiceName, d.ID, DeploymentSuccess).
+		Where("timestamp > ? AND timestamp < ?", before.Start, at).Order("timestamp DESC").Pluck("timestamp", &prev).Error; err != nil {
+		return nil, fmt.Errorf("failed to find previous deployment: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Where(scope, d.TenantID, d.ServiceName, d.ID, DeploymentSuccess).
+		Where("timestamp > ? AND timestamp < ?", at, after.End).Order("timestamp ASC").Pluck("timestamp", &next).Error; err != nil {
+		return nil, fmt.Errorf("failed to find next deployment: %w", err)
+	}
+	if len(prev) > 0 {
+		before.Start = prev[0]
+	}
+	if len(next) > 0 {
+		after.End = next[0]
+	}
+	out := &DeploymentImpact{Complete: !now.Before(after.End)}
+	if !out.Complete {
+		after.End = now
+	}
+
+	var err error
+	if out.Before, err = r.deploymentWindowStats(ctx, d.ServiceName, before); err != nil {
+		return nil, err
+	}
+	if out.After, err = r.deploymentWindowStats(ctx, d.ServiceName, after); err != nil {
+		return nil, err
+	}
+	judgeDeployment(out)
+	return out, nil
+}
+
+// deploymentWindowStats fills w from the service's spans in [w.Start, w.End).
+func (r *Repository) deploymentWindowStats(ctx context.Context, service string, w DeploymentWindowStats) (DeploymentWindowStats, error) {
+	base := r.db.WithContext(ctx).Model(&Span{}).
+		Where("tenant_id = ? AND service_name = ? AND start_time >= ? AND start_time < ?", TenantFromContext(ctx), service, w.Start, w.End)
+	var totals struct {
+		Count      int64
+		ErrorCount int64
+	}
+	if err := base.Session(&gorm.Session{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS error_count", spanStatusError).
+		Scan(&totals).Error; err != nil {
+		return w, fmt.Errorf("failed to count deployment window spans: %w", err)
+	}
+	w.Count, w.ErrorCount = totals.Count, totals.ErrorCount
+	if w.Count == 0 {
+		return w, nil
+	}
+	w.ErrorRate = float64(w.ErrorCount) / float64(w.Count) * 100
+	pcts, err := r.durationPercentiles(ctx, base.Session(&gorm.Session{}), "")
+	if err != nil {
+		return w, fmt.Errorf("failed to compute deployment window latency: %w", err)
+	}
+	if len(pcts) > 0 {
+		w.LatencyPercentiles = pcts[0].LatencyPercentiles
+	}
+	return w, nil
+}
+
+// judgeDeployment fills the deltas, verdict and reasons of im.
+func judgeDeployment(im *DeploymentImpact) {
+	b, a := im.Before, im.After
+	im.P50DeltaPct = pctChange(b.P50, a.P50)
+	im.P95DeltaPct = pctChange(b.P95, a.P95)
+	im.P99DeltaPct = pctChange(b.P99, a.P99)
+	im.ErrorRateDelta = a.ErrorRate - b.ErrorRate
+
+	if b.Count < deploymentMinSamples || a.Count < deploymentMinSamples {
+		im.Verdict = DeploymentVerdictInsufficient
+		return
+	}
+	im.Reasons = nil
+	if im.P95DeltaPct >= deploymentLatencyRegressPct && a.P95-b.P95 >= deploymentLatencyRegressMinUs {
+		im.Reasons = append(im.Reasons, fmt.Sprintf("p95 latency up %.0f%% (%dµs → %dµs)", im.P95DeltaPct, b.P95, a.P95))
+	}
+	if im.ErrorRateDelta >= deploymentErrorRegressPoints && a.ErrorRate >= 2*b.ErrorRate {
+		im.Reasons = append(im.Reasons, fmt.Sprintf("error rate up %.1f points (%.1f%% → %.1f%%)", im.ErrorRateDelta, b.ErrorRate, a.ErrorRate))
+	}
+	im.Verdict = DeploymentVerdictOK
+	if len(im.Reasons) > 0 {
+		im.Verdict = DeploymentVerdictRegressed
+	}
+}
+
+// pctChange is the percent change from before to after; 0 when before is.
+func pctChange(before, after int64) float64 {
+	if before <= 0 {
+		return 0
+	}
+	return float64(after-before) / float64(before) * 100
+}
+
+// EvaluateDeployments judges the successful deployments, across all
+// tenants, whose after window ended by now and that have no verdict yet,
+// oldest first and at most deploymentEvalBatch per call. Each gets its
+// Verdict, Impact and EvaluatedAt; the evaluated deployments are returned.
+func (r *Repository) EvaluateDeployments(ctx context.Context, now time.Time, window time.Duration) ([]Deployment, error) {
+	var due []Deployment
+	if err := r.db.WithContext(ctx).
+		Where("COALESCE(verdict, '') = '' AND status = ? AND timestamp <= ? AND timestamp >= ?",
+			DeploymentSuccess, now.Add(-window-deploymentSettleDelay), now.Add(-deploymentEvalMaxAge)).
+		Order("timestamp ASC").Limit(deploymentEvalBatch).Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("failed to list deployments to evaluate: %w", err)
+	}
+	evaluated := make([]Deployment, 0, len(due))
+	for _, d := range due {
+		im, err := r.GetDeploymentImpact(ctx, &d, window, now)
+		if err != nil {
+			return evaluated, err
+		}
+		at := now
+		if err := r.db.WithContext(ctx).Model(&d).Select("verdict", "impact", "evaluated_at").
+			Updates(Deployment{Verdict: im.Verdict, Impact: im, EvaluatedAt: &at}).Error; err != nil {
+			return evaluated, fmt.Errorf("failed to save deployment verdict: %w", err)
+		}
+		d.Verdict, d.Impact, d.EvaluatedAt = im.Verdict, im, &at
+		evaluated = append(evaluated, d)
+	}
+	return evaluated, nil
+}
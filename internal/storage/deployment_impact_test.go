@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecordServiceVersion_OncePerVersion(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		version, env string
+		want         bool
+	}{
+		{"v1", "prod", true},
+		{"v1", "prod", false},
+		{"v1", "staging", true},
+		{"v2", "prod", true},
+	} {
+		if got, err := repo.RecordServiceVersion(ctx, "checkout", c.version, c.env, t0); err != nil || got != c.want {
+			t.Errorf("%s/%s: created = %v, %v; want %v", c.version, c.env, got, err, c.want)
+		}
+	}
+	// A version registered through the API is not recorded again.
+	if err := repo.UpsertDeployment(ctx, &Deployment{
+		ExternalID: DeploymentExternalID(DeploymentSourceAPI, "checkout", "v3", ""), Source: DeploymentSourceAPI,
+		ServiceName: "checkout", Version: "v3", Status: DeploymentSuccess, Timestamp: t0,
+	}); err != nil {
+		t.Fatalf("UpsertDeployment: %v", err)
+	}
+	if got, err := repo.RecordServiceVersion(ctx, "checkout", "v3", "", t0.Add(time.Minute)); err != nil || got {
+		t.Errorf("registered version recorded again: %v, %v", got, err)
+	}
+	list, _ := repo.ListDeployments(ctx, "checkout", time.Time{}, time.Time{}, 0)
+	if len(list) != 4 || list[len(list)-1].Source != DeploymentSourceServiceVersion {
+		t.Errorf("deployments = %+v", list)
+	}
+}
+
+func TestEvaluateDeployments_FlagsRegressions(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	acme := WithTenantContext(ctx, "acme")
+	now := time.Now().UTC().Truncate(time.Minute)
+	at := now.Add(-time.Hour)
+	window := 30 * time.Minute
+
+	var spans []Span
+	add := func(service string, n int, from time.Time, dur time.Duration, failEvery int) {
+		for i := range n {
+			sp := Span{TenantID: "acme", TraceID: fmt.Sprintf("%s-%d-%d", service, from.Unix(), i), SpanID: fmt.Sprintf("s%d", i), ServiceName: service, OperationName: "GET /"}
+			if failEvery > 0 && i%failEvery == 0 {
+				sp.Status = spanStatusError
+			}
+			start := from.Add(time.Duration(i) * time.Second)
+			sp.SetTiming(start, start.Add(dur))
+			spans = append(spans, sp)
+		}
+	}
+	add("api", 40, at.Add(-20*time.Minute), 10*time.Millisecond, 0)
+	add("api", 40, at.Add(5*time.Minute), 60*time.Millisecond, 5)
+	add("web", 40, at.Add(-20*time.Minute), 10*time.Millisecond, 0)
+	add("web", 40, at.Add(5*time.Minute), 10*time.Millisecond, 0)
+	add("db", 3, at.Add(5*time.Minute), 10*time.Millisecond, 0)
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+	for _, svc := range []string{"api", "web", "db"} {
+		if _, err := repo.RecordServiceVersion(acme, svc, "v2", "", at); err != nil {
+			t.Fatalf("RecordServiceVersion: %v", err)
+		}
+	}
+	// Too recent to judge.
+	if _, err := repo.RecordServiceVersion(acme, "api", "v3", "", now.Add(-10*time.Minute)); err != nil {
+		t.Fatalf("RecordServiceVersion: %v", err)
+	}
+
+	got, err := repo.EvaluateDeployments(ctx, now, window)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("EvaluateDeployments = %d, %v; want 3", len(got), err)
+	}
+	verdicts := make(map[string]Deployment)
+	for _, d := range got {
+		verdicts[d.ServiceName] = d
+	}
+	api := verdicts["api"]
+	if api.Verdict != DeploymentVerdictRegressed || len(api.Impact.Reasons) != 2 || api.Impact.P95DeltaPct <= 0 ||
+		api.Impact.Before.Count != 40 || api.Impact.After.ErrorRate != 20 || !api.Impact.Complete {
+		t.Errorf("api = %s %+v", api.Verdict, api.Impact)
+	}
+	if v := verdicts["web"].Verdict; v != DeploymentVerdictOK {
+		t.Errorf("web verdict = %q", v)
+	}
+	if v := verdicts["db"].Verdict; v != DeploymentVerdictInsufficient {
+		t.Errorf("db verdict = %q", v)
+	}
+	if again, err := repo.EvaluateDeployments(ctx, now, window); err != nil || len(again) != 0 {
+		t.Errorf("second pass = %d, %v; want nothing", len(again), err)
+	}
+
+	stored, err := repo.GetDeployment(acme, api.ID)
+	if err != nil || stored.Verdict != DeploymentVerdictRegressed || stored.Impact == nil || stored.EvaluatedAt == nil {
+		t.Fatalf("stored = %+v, %v", stored, err)
+	}
+	// The previous deployment bounds the before window to one version.
+	latest, _ := repo.ListDeployments(acme, "api", now.Add(-15*time.Minute), time.Time{}, 0)
+	im, err := repo.GetDeploymentImpact(ctx, &latest[0], time.Hour, now)
+	if err != nil || im.Complete || !im.Before.Start.Equal(at) {
+		t.Errorf("v3 impact = %+v, %v", im, err)
+	}
+	if _, err := repo.GetDeployment(WithTenantContext(ctx, "other"), api.ID); err == nil {
+		t.Error("other tenant sees an acme deployment")
+	}
+}
+
+func TestAddTrafficDeployments_MarksBuckets(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, svc := range []string{"api", "web"} {
+		if _, err := repo.RecordServiceVersion(ctx, svc, "v1", "", t0.Add(time.Duration(i)*5*time.Minute+30*time.Second)); err != nil {
+			t.Fatalf("RecordServiceVersion: %v", err)
+		}
+	}
+
+	points := []TrafficPoint{{Timestamp: t0, Count: 3}, {Timestamp: t0.Add(10 * time.Minute), Count: 1}}
+	got, err := repo.AddTrafficDeployments(ctx, points, t0, t0.Add(time.Hour), nil, time.Minute)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("AddTrafficDeployments = %+v, %v", got, err)
+	}
+	if len(got[0].Deployments) != 1 || got[0].Deployments[0].ServiceName != "api" || got[0].Count != 3 {
+		t.Errorf("first bucket = %+v", got[0])
+	}
+	if !got[1].Timestamp.Equal(t0.Add(5*time.Minute)) || got[1].Count != 0 || got[1].Deployments[0].ServiceName != "web" {
+		t.Errorf("added bucket = %+v", got[1])
+	}
+
+	services := []ServiceLatency{{ServiceName: "web"}, {ServiceName: "db"}}
+	if err := repo.AddLatencyDeployments(ctx, services, t0, t0.Add(time.Hour)); err != nil {
+		t.Fatalf("AddLatencyDeployments: %v", err)
+	}
+	if len(services[0].Deployments) != 1 || len(services[1].Deployments) != 0 {
+		t.Errorf("latency markers = %+v", services)
+	}
+}
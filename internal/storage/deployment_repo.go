@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Deployment statuses.
+const (
+	DeploymentPending    = "pending"
+	DeploymentInProgress = "in_progress"
+	DeploymentSuccess    = "success"
+	DeploymentFailure    = "failure"
+)
+
+// Deployment sources recorded outside the ingest webhooks.
+const (
+	DeploymentSourceAPI            = "api"             // POST /api/deployments
+	DeploymentSourceServiceVersion = "service_version" // a new service.version seen at ingest
+)
+
+// DeploymentExternalID is the ExternalID of a deployment from source that
+// has no upstream ID of its own: one row per service, version and
+// environment.
+func DeploymentExternalID(source, service, version, environment string) string {
+	sum := sha256.Sum256([]byte(service + "\x00" + version + "\x00" + environment))
+	return source + ":" + hex.EncodeToString(sum[:16])
+}
+
+// UpsertDeployment records d under the tenant on ctx. A row with the same
+// ExternalID is updated in place, so a deployment's status changes amend one
+// annotation rather than stacking new ones. Empty fields of d keep the
+// stored value; d is reloaded with the merged row.
+func (r *Repository) UpsertDeployment(ctx context.Context, d *Deployment) error {
+	d.TenantID = TenantFromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing Deployment
+		err := tx.Where("tenant_id = ? AND external_id = ?", d.TenantID, d.ExternalID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			d.ID = 0
+			if err := tx.Create(d).Error; err != nil {
+				return fmt.Errorf("failed to create deployment: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load deployment: %w", err)
+		}
+		d.ID = existing.ID
+		// Updates with a struct skips zero fields, which is the merge we want.
+		if err := tx.Model(&existing).Updates(d).Error; err != nil {
+			return fmt.Errorf("failed to update deployment: %w", err)
+		}
+		return tx.First(d, existing.ID).Error
+	})
+}
+
+// ListDeployments returns the tenant's deploy annotations in [start, end],
+// newest first. Zero times leave that side open; service filters when set.
+func (r *Repository) ListDeployments(ctx context.Context, service string, start, end time.Time, limit int) ([]Deployment, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	q := r.db.WithContext(ctx).Where(sqlWhereTenantID, TenantFromContext(ctx))
+	if service != "" {
+		q = q.Where("service_name = ?", service)
+	}
+	if !start.IsZero() {
+		q = q.Where("timestamp >= ?", start)
+	}
+	if !end.IsZero() {
+		q = q.Where("timestamp <= ?", end)
+	}
+	var out []Deployment
+	if err := q.Order("timestamp DESC").Limit(limit).Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	return out, nil
+}
+
+// ListServiceVersions returns the latest successful deployment of each of
+// the tenant's services, ordered by service name.
+func (r *Repository) ListServiceVersions(ctx context.Context) ([]Deployment, error) {
+	tenant := TenantFromContext(ctx)
+	latest := r.db.WithContext(ctx).Model(&Deployment{}).
+		Select("service_name, MAX(timestamp) AS ts").
+		Where("tenant_id = ? AND status = ?", tenant, DeploymentSuccess).
+		Group("service_name")
+	var rows []Deployment
+	err := r.db.WithContext(ctx).
+		Joins("JOIN (?) AS latest ON deployments.service_name = latest.service_name AND deployments.timestamp = latest.ts", latest).
+		Where("deployments.tenant_id = ? AND deployments.status = ?", tenant, DeploymentSuccess).
+		Order("deployments.service_name ASC, deployments.id DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service versions: %w", err)
+	}
+	// Two deployments of a service may share a timestamp; keep the newest row.
+	out := rows[:0]
+	for _, d := range rows {
+		if len(out) > 0 && out[len(out)-1].ServiceName == d.ServiceName {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// GetDeployment returns one deployment of the tenant on ctx.
+// gorm.ErrRecordNotFound when there is none.
+func (r *Repository) GetDeployment(ctx context.Context, id uint) (*Deployment, error) {
+	var d Deployment
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", TenantFromContext(ctx), id).Take(&d).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// RecordServiceVersion records a successful deployment of version for the
+// tenant's service at at, unless the service already has a deployment of
+// that version in environment. It reports whether a row was created.
+func (r *Repository) RecordServiceVersion(ctx context.Context, service, version, environment string, at time.Time) (bool, error) {
+	tenant := TenantFromContext(ctx)
+	var n int64
+	if err := r.db.WithContext(ctx).Model(&Deployment{}).
+		Where("tenant_id = ? AND service_name = ? AND version = ? AND environment = ?", tenant, service, version, environment).
+		Limit(1).Count(&n).Error; err != nil {
+		return false, fmt.Errorf("failed to look up service version: %w", err)
+	}
+	if n > 0 {
+		return false, nil
+	}
+	d := Deployment{
+		TenantID:    tenant,
+		ExternalID:  DeploymentExternalID(DeploymentSourceServiceVersion, service, version, environment),
+		Source:      DeploymentSourceServiceVersion,
+		ServiceName: service,
+		Version:     version,
+		Environment: environment,
+		Status:      DeploymentSuccess,
+		Timestamp:   at,
+	}
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&d)
+	if res.Error != nil {
+		return false, fmt.Errorf("failed to record service version: %w", res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
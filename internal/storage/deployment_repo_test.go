@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUpsertDeployment_MergesStatusUpdates(t *testing.T) {
+	repo := newTestRepo(t)
+	acme := WithTenantContext(context.Background(), "acme")
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	d := &Deployment{
+		ExternalID: "github:deployment:1", Source: "github_deployment",
+		ServiceName: "checkout", Version: "v1.4.0", Environment: "production",
+		Status: DeploymentPending, Timestamp: t0,
+	}
+	if err := repo.UpsertDeployment(acme, d); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	update := &Deployment{
+		ExternalID: "github:deployment:1", Source: "github_deployment",
+		ServiceName: "checkout", Status: DeploymentSuccess, URL: "https://ci/1", Timestamp: t0.Add(time.Minute),
+	}
+	if err := repo.UpsertDeployment(acme, update); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if update.ID != d.ID || update.Version != "v1.4.0" || update.Status != DeploymentSuccess || update.URL != "https://ci/1" {
+		t.Errorf("merged row = %+v", update)
+	}
+
+	got, err := repo.ListDeployments(acme, "", time.Time{}, time.Time{}, 0)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("ListDeployments = %+v, %v", got, err)
+	}
+	if got, _ := repo.ListDeployments(WithTenantContext(context.Background(), "other"), "", time.Time{}, time.Time{}, 0); len(got) != 0 {
+		t.Errorf("other tenant sees %d deployments", len(got))
+	}
+	if got, _ := repo.ListDeployments(acme, "", t0.Add(2*time.Minute), time.Time{}, 0); len(got) != 0 {
+		t.Errorf("time filter kept %d deployments", len(got))
+	}
+}
+
+func TestListServiceVersions_LatestSuccessPerService(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, d := range []Deployment{
+		{ServiceName: "checkout", Version: "v1", Status: DeploymentSuccess, Timestamp: t0},
+		{ServiceName: "checkout", Version: "v2", Status: DeploymentSuccess, Timestamp: t0.Add(time.Hour)},
+		{ServiceName: "checkout", Version: "v3", Status: DeploymentFailure, Timestamp: t0.Add(2 * time.Hour)},
+		{ServiceName: "billing", Version: "b7", Status: DeploymentSuccess, Timestamp: t0},
+		{ServiceName: "search", Version: "s1", Status: DeploymentInProgress, Timestamp: t0},
+	} {
+		d.ExternalID = "test:" + string(rune('a'+i))
+		d.Source = "github_deployment"
+		if err := repo.UpsertDeployment(ctx, &d); err != nil {
+			t.Fatalf("upsert %d: %v", i, err)
+		}
+	}
+
+	got, err := repo.ListServiceVersions(ctx)
+	if err != nil {
+		t.Fatalf("ListServiceVersions: %v", err)
+	}
+	if len(got) != 2 || got[0].ServiceName != "billing" || got[0].Version != "b7" ||
+		got[1].ServiceName != "checkout" || got[1].Version != "v2" {
+		t.Errorf("versions = %+v, want billing=b7, checkout=v2", got)
+	}
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// dimensionScanCap bounds the span rows folded per query. Attributes are
+	// zstd-compressed blobs, so grouping cannot be pushed into SQL; newest
+	// rows win and DimensionBreakdown.Truncated reports a hit.
+	dimensionScanCap = 100_000
+
+	// dimensionMaxTracked caps distinct attribute values held in memory per
+	// query. Values first seen after the cap fold into DimensionOtherValue,
+	// so a high-cardinality key (user.id, request.id) cannot blow up the
+	// response or the heap.
+	dimensionMaxTracked = 1_000
+
+	defaultDimensionLimit = 50
+
+	// DimensionOtherValue collects values beyond the cardinality caps.
+	DimensionOtherValue = "__other__"
+	// DimensionMissingValue collects spans that do not carry the key.
+	DimensionMissingValue = "__missing__"
+)
+
+// DimensionQuery selects a business-dimension breakdown over spans.
+type DimensionQuery struct {
+	Key          string // span attribute key, e.g. "inventory.warehouse"
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string
+	Limit        int  // values returned before folding into __other__ (default 50)
+	RootOnly     bool // only root spans (one row per request)
+}
+
+// DimensionValueStats aggregates the spans sharing one attribute value.
+type DimensionValueStats struct {
+	Value        string  `json:"value"`
+	SpanCount    int64   `json:"span_count"`
+	TraceCount   int64   `json:"trace_count"`
+	ErrorCount   int64   `json:"error_count"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	P50LatencyMs float64 `json:"p50_latency_ms"`
+	P95LatencyMs float64 `json:"p95_latency_ms"`
+	P99LatencyMs float64 `json:"p99_latency_ms"`
+}
+
+// DimensionBreakdown is the analytics response for one attribute key.
+type DimensionBreakdown struct {
+	Key            string                `json:"key"`
+	Start          time.Time             `json:"start"`
+	End            time.Time             `json:"end"`
+	Values         []DimensionValueStats `json:"values"`
+	DistinctValues int                   `json:"distinct_values"` // tracked before __other__ folding
+	SpansScanned   int                   `json:"spans_scanned"`
+	Truncated      bool                  `json:"truncated"`       // dimensionScanCap hit
+	CardinalityCap bool                  `json:"cardinality_cap"` // dimensionMaxTracked hit
+}
+
+// dimensionAcc is the per-value accumulator behind DimensionValueStats.
+type dimensionAcc struct {
+	spans     int64
+	errors    int64
+	totalNs   int64
+	durations []float64 // ms
+	traces    map[string]struct{}
+}
+
+func (a *dimensionAcc) add(sp Span) {
+	a.spans++
+	if sp.Status == spanStatusError {
+		a.errors++
+	}
+	a.totalNs += sp.DurationNanos()
+	a.durations = append(a.durations, float64(sp.DurationNanos())/1e6)
+	a.traces[sp.TraceID] = struct{}{}
+}
+
+func (a *dimensionAcc) merge(b *dimensionAcc) {
+	a.spans += b.spans
+	a.errors += b.errors
+	a.totalNs += b.totalNs
+	a.durations = append(a.durations, b.durations...)
+	for t := range b.traces {
+		a.traces[t] = struct{}{}
+	}
+}
+
+func (a *dimensionAcc) stats(value string) DimensionValueStats {
+	st := DimensionValueStats{
+		Value:      value,
+		SpanCount:  a.spans,
+		TraceCount: int64(len(a.traces)),
+		ErrorCount: a.errors,
+	}
+	if a.spans > 0 {
+		st.ErrorRate = float64(a.errors) / float64(a.spans)
+		st.AvgLatencyMs = float64(a.totalNs) / float64(a.spans) / 1e6
+	}
+	sort.Float64s(a.durations)
+	st.P50LatencyMs = sortedPercentile(a.durations, 50)
+	st.P95LatencyMs = sortedPercentile(a.durations, 95)
+	st.P99LatencyMs = sortedPercentile(a.durations, 99)
+	return st
+}
+
+func newDimensionAcc() *dimensionAcc {
+	return &dimensionAcc{traces: make(map[string]struct{})}
+}
+
+// GetDimensionBreakdown aggregates span count, distinct traces, errors and
+// latency percentiles by the value of an arbitrary span attribute key,
+// scoped to the tenant on ctx. No schema change is needed per dimension —
+// the key is read from each span's stored AttributesJSON.
+//
+// Cardinality is capped twice: at most dimensionMaxTracked distinct values
+// are tracked, and only the top Limit by span count are returned; both
+// overflows fold into DimensionOtherValue. Spans without the key are
+// reported under DimensionMissingValue.
+func (r *Repository) GetDimensionBreakdown(ctx context.Context, q DimensionQuery) (*DimensionBreakdown, error) {
+	if q.Key == "" {
+		return nil, fmt.Errorf("dimension key is required")
+	}
+	tenant := TenantFromContext(ctx)
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-1 * time.Hour)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultDimensionLimit
+	}
+
+	var eq []string
+	if len(q.ServiceNames) > 0 {
+		eq = append(eq, "service_name")
+	}
+	defer func(started time.Time) {
+		r.filters.observe(newFilterPattern("spans", eq, "start_time", q.Key), time.Since(started))
+	}(time.Now())
+
+	query := r.db.WithContext(ctx).Model(&Span{}).
+		Select("trace_id, duration, duration_ns, status, attributes_json").
+		Where("tenant_id = ? AND start_time BETWEEN ? AND ?", tenant, q.Start, q.End)
+	if len(q.ServiceNames) > 0 {
+		query = query.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+	if q.RootOnly {
+		query = query.Where("parent_span_id = ''")
+	}
+	var rows []Span
+	if err := query.Order("start_time DESC").Limit(dimensionScanCap).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch spans for dimension breakdown: %w", err)
+	}
+
+	out := &DimensionBreakdown{
+		Key:          q.Key,
+		Start:        q.Start,
+		End:          q.End,
+		SpansScanned: len(rows),
+		Truncated:    len(rows) == dimensionScanCap,
+	}
+	accs := make(map[string]*dimensionAcc)
+	other := newDimensionAcc()
+	for _, sp := range rows {
+		value, ok := attributeValue(string(sp.AttributesJSON), q.Key)
+		if !ok {
+			value = DimensionMissingValue
+		}
+		acc, tracked := accs[value]
+		if !tracked {
+			if len(accs) >= dimensionMaxTracked {
+				out.CardinalityCap = true
+				other.add(sp)
+				continue
+			}
+			acc = newDimensionAcc()
+			accs[value] = acc
+		}
+		acc.add(sp)
+	}
+	out.DistinctValues = len(accs)
+
+	values := make([]string, 0, len(accs))
+	for v := range accs {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, b := accs[values[i]], accs[values[j]]
+		if a.spans != b.spans {
+			return a.spans > b.spans
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > q.Limit {
+		for _, v := range values[q.Limit:] {
+			other.merge(accs[v])
+		}
+		values = values[:q.Limit]
+	}
+
+	out.Values = make([]DimensionValueStats, 0, len(values)+1)
+	for _, v := range values {
+		out.Values = append(out.Values, accs[v].stats(v))
+	}
+	if other.spans > 0 {
+		out.Values = append(out.Values, other.stats(DimensionOtherValue))
+	}
+	return out, nil
+}
+
+// sortedPercentile returns the p-th percentile (nearest-rank) of an
+// ascending slice.
+func sortedPercentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
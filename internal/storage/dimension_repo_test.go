@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func warehouseAttrs(v string) CompressedText {
+	return CompressedText(fmt.Sprintf(`[{"key":"inventory.warehouse","value":{"Value":{"StringValue":%q}}}]`, v))
+}
+
+func TestAttributeValue_ScalarKinds(t *testing.T) {
+	attrs := `[{"key":"s","value":{"Value":{"StringValue":"x"}}},{"key":"i","value":{"Value":{"IntValue":42}}},` +
+		`{"key":"d","value":{"Value":{"DoubleValue":1.5}}},{"key":"b","value":{"Value":{"BoolValue":true}}}]`
+	for key, want := range map[string]string{"s": "x", "i": "42", "d": "1.5", "b": "true"} {
+		if got, ok := attributeValue(attrs, key); !ok || got != want {
+			t.Errorf("attributeValue(%q) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := attributeValue(attrs, "absent"); ok {
+		t.Error("absent key reported ok")
+	}
+}
+
+func TestGetDimensionBreakdown(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now()
+	spans := []Span{
+		{TraceID: "t1", SpanID: "1", Duration: 10_000, Status: spanStatusOK, AttributesJSON: warehouseAttrs("east")},
+		{TraceID: "t2", SpanID: "1", Duration: 30_000, Status: spanStatusError, AttributesJSON: warehouseAttrs("east")},
+		{TraceID: "t2", SpanID: "2", ParentSpanID: "1", Duration: 20_000, Status: spanStatusOK, AttributesJSON: warehouseAttrs("east")},
+		{TraceID: "t3", SpanID: "1", Duration: 5_000, Status: spanStatusOK, AttributesJSON: warehouseAttrs("west")},
+		{TraceID: "t4", SpanID: "1", Duration: 1_000, Status: spanStatusOK},
+	}
+	for i := range spans {
+		spans[i].TenantID = DefaultTenantID
+		spans[i].StartTime = now.Add(-time.Minute)
+		spans[i].ServiceName = "inventory"
+	}
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+
+	ctx := context.Background()
+	got, err := repo.GetDimensionBreakdown(ctx, DimensionQuery{Key: "inventory.warehouse"})
+	if err != nil {
+		t.Fatalf("GetDimensionBreakdown: %v", err)
+	}
+	if got.DistinctValues != 3 || len(got.Values) != 3 {
+		t.Fatalf("values = %+v, want east, west, missing", got.Values)
+	}
+	east := got.Values[0]
+	if east.Value != "east" || east.SpanCount != 3 || east.TraceCount != 2 || east.ErrorCount != 1 {
+		t.Errorf("east = %+v", east)
+	}
+	if east.AvgLatencyMs != 20 || east.P50LatencyMs != 20 || east.P99LatencyMs != 30 {
+		t.Errorf("east latency = avg %.1f p50 %.1f p99 %.1f", east.AvgLatencyMs, east.P50LatencyMs, east.P99LatencyMs)
+	}
+
+	// Limit folds the tail into __other__.
+	limited, err := repo.GetDimensionBreakdown(ctx, DimensionQuery{Key: "inventory.warehouse", Limit: 1})
+	if err != nil {
+		t.Fatalf("GetDimensionBreakdown limit: %v", err)
+	}
+	if len(limited.Values) != 2 || limited.Values[1].Value != DimensionOtherValue || limited.Values[1].SpanCount != 2 {
+		t.Errorf("limited values = %+v, want east + __other__(2)", limited.Values)
+	}
+
+	// RootOnly drops the child span.
+	roots, err := repo.GetDimensionBreakdown(ctx, DimensionQuery{Key: "inventory.warehouse", RootOnly: true})
+	if err != nil {
+		t.Fatalf("GetDimensionBreakdown root_only: %v", err)
+	}
+	if roots.Values[0].SpanCount != 2 {
+		t.Errorf("root-only east spans = %d, want 2", roots.Values[0].SpanCount)
+	}
+
+	// Other tenants see nothing.
+	other, err := repo.GetDimensionBreakdown(WithTenantContext(ctx, "other"), DimensionQuery{Key: "inventory.warehouse"})
+	if err != nil {
+		t.Fatalf("GetDimensionBreakdown other tenant: %v", err)
+	}
+	if other.SpansScanned != 0 {
+		t.Errorf("other tenant scanned %d spans", other.SpansScanned)
+	}
+}
+
+func TestGetDimensionBreakdown_RequiresKey(t *testing.T) {
+	repo := newTestRepo(t)
+	if _, err := repo.GetDimensionBreakdown(context.Background(), DimensionQuery{}); err == nil {
+		t.Fatal("want error for empty key")
+	}
+}
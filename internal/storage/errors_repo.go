@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// errorSeverities lists the stored severity strings treated as errors. The
+// receiver keeps SeverityText verbatim and falls back to the OTLP
+// SeverityNumber enum name, so both spellings appear in the logs table.
+var errorSeverities = []string{
+	"ERROR", "FATAL", "CRITICAL", "error", "fatal", "critical",
+	"SEVERITY_NUMBER_ERROR", "SEVERITY_NUMBER_ERROR2", "SEVERITY_NUMBER_ERROR3", "SEVERITY_NUMBER_ERROR4",
+	"SEVERITY_NUMBER_FATAL", "SEVERITY_NUMBER_FATAL2", "SEVERITY_NUMBER_FATAL3", "SEVERITY_NUMBER_FATAL4",
+}
+
+const (
+	// errorScanCap bounds how many error rows one explorer query folds in
+	// memory. Newest rows win; ErrorGroupsResponse.Truncated reports a hit.
+	errorScanCap = 50_000
+
+	defaultErrorGroupLimit   = 50
+	defaultErrorTrendBuckets = 24
+	defaultErrorSampleTraces = 5
+
+	// errorMessageMaxLen caps the normalized message so a multi-KB stack
+	// trace body cannot become a group key.
+	errorMessageMaxLen = 256
+
+	unknownErrorType = "unknown"
+)
+
+// ErrorGroupQuery selects and shapes the errors explorer result.
+type ErrorGroupQuery struct {
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string
+	Limit        int    // max groups returned (default 50)
+	Buckets      int    // trend sparkline resolution (default 24)
+	Samples      int    // sample trace IDs per group (default 5)
+	Status       string // open | resolved | ignored; empty = all
+}
+
+// ErrorGroup is one (service, error type, normalized message) bucket — an
+// issue. Fingerprint identifies it across queries for triage.
+type ErrorGroup struct {
+	Fingerprint    string    `json:"fingerprint"`
+	ServiceName    string    `json:"service_name"`
+	ErrorType      string    `json:"error_type"`
+	Message        string    `json:"message"`        // normalized template
+	SampleMessage  string    `json:"sample_message"` // most recent raw body
+	Count          int64     `json:"count"`
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+	Trend          []int64   `json:"trend"` // counts per bucket, oldest first
+	SampleTraceIDs []string  `json:"sample_trace_ids"`
+	Status         string    `json:"status"` // see TriageState.EffectiveStatus
+	Assignee       string    `json:"assignee,omitempty"`
+}
+
+// ErrorGroupsResponse is the errors explorer payload.
+type ErrorGroupsResponse struct {
+	Groups        []ErrorGroup `json:"groups"`
+	TotalGroups   int          `json:"total_groups"`
+	TotalErrors   int64        `json:"total_errors"`
+	Start         time.Time    `json:"start"`
+	End           time.Time    `json:"end"`
+	BucketSeconds int64        `json:"bucket_seconds"`
+	Truncated     bool         `json:"truncated"` // true when errorScanCap was hit
+}
+
+// GetErrorGroups returns error logs in [Start, End] grouped by service,
+// error type (exception.type / error.type attribute) and normalized message,
+// with counts, a trend sparkline, sample trace IDs and first/last seen,
+// scoped to the tenant on ctx. Groups are ordered by count descending.
+//
+// This fetches the narrow row set and folds in Go — normalization is
+// regex-based and not expressible portably in SQL.
+func (r *Repository) GetErrorGroups(ctx context.Context, q ErrorGroupQuery) (*ErrorGroupsResponse, error) {
+	tenant := TenantFromContext(ctx)
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-24 * time.Hour)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultErrorGroupLimit
+	}
+	if q.Buckets <= 0 {
+		q.Buckets = defaultErrorTrendBuckets
+	}
+	if q.Samples <= 0 {
+		q.Samples = defaultErrorSampleTraces
+	}
+
+	query := r.db.WithContext(ctx).Model(&Log{}).
+		Select("trace_id, body, service_name, attributes_json, timestamp").
+		Where(sqlWhereTenantTimeBetween, tenant, q.Start, q.End).
+		Where("severity IN ?", errorSeverities)
+	if len(q.ServiceNames) > 0 {
+		query = query.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+	var rows []Log
+	if err := query.Order(sqlOrderTimestampDesc).Limit(errorScanCap).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch error logs: %w", err)
+	}
+
+	bucketWidth := q.End.Sub(q.Start) / time.Duration(q.Buckets)
+	if bucketWidth <= 0 {
+		bucketWidth = time.Second
+	}
+
+	type groupKey struct{ service, errType, message string }
+	groups := make(map[groupKey]*ErrorGroup)
+	resp := &ErrorGroupsResponse{
+		Start:         q.Start,
+		End:           q.End,
+		BucketSeconds: int64(bucketWidth / time.Second),
+		Truncated:     len(rows) == errorScanCap,
+	}
+
+	// Rows arrive newest-first, so the first row seen per group is LastSeen
+	// and supplies the sample message.
+	for _, row := range rows {
+		errType := errorTypeFromAttributes(string(row.AttributesJSON))
+		msg := NormalizeErrorMessage(row.Body)
+		k := groupKey{row.ServiceName, errType, msg}
+		g, ok := groups[k]
+		if !ok {
+			g = &ErrorGroup{
+				Fingerprint:   IssueFingerprint(row.ServiceName, errType, msg),
+				ServiceName:   row.ServiceName,
+				ErrorType:     errType,
+				Message:       msg,
+				SampleMessage: row.Body,
+				LastSeen:      row.Timestamp,
+				Trend:         make([]int64, q.Buckets),
+			}
+			groups[k] = g
+		}
+		g.Count++
+		g.FirstSeen = row.Timestamp
+		idx := min(max(int(row.Timestamp.Sub(q.Start)/bucketWidth), 0), q.Buckets-1)
+		g.Trend[idx]++
+		if row.TraceID != "" && len(g.SampleTraceIDs) < q.Samples && !slices.Contains(g.SampleTraceIDs, row.TraceID) {
+			g.SampleTraceIDs = append(g.SampleTraceIDs, row.TraceID)
+		}
+	}
+
+	fingerprints := make([]string, 0, len(groups))
+	for _, g := range groups {
+		fingerprints = append(fingerprints, g.Fingerprint)
+	}
+	states, err := r.TriageStates(ctx, TriageKindIssue, fingerprints)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Groups = make([]ErrorGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.SampleTraceIDs == nil {
+			g.SampleTraceIDs = []string{}
+		}
+		st, ok := states[g.Fingerprint]
+		if ok {
+			g.Status, g.Assignee = st.EffectiveStatus(g.LastSeen), st.Assignee
+		} else {
+			g.Status = TriageStatusOpen
+		}
+		if q.Status != "" && g.Status != q.Status {
+			continue
+		}
+		resp.Groups = append(resp.Groups, *g)
+		resp.TotalErrors += g.Count
+	}
+	sort.Slice(resp.Groups, func(i, j int) bool {
+		a, b := resp.Groups[i], resp.Groups[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.LastSeen.After(b.LastSeen)
+	})
+	resp.TotalGroups = len(resp.Groups)
+	if len(resp.Groups) > q.Limit {
+		resp.Groups = resp.Groups[:q.Limit]
+	}
+	return resp, nil
+}
+
+var (
+	reErrUUID   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	reErrHex    = regexp.MustCompile(`\b(?:0x[0-9a-fA-F]+|[0-9a-fA-F]{8,})\b`)
+	reErrQuoted = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+	reErrNumber = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	reErrSpace  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeErrorMessage collapses the variable parts of an error message —
+// UUIDs, hex IDs, quoted values, numbers — into placeholders so that
+// "timeout after 3012ms on order 8812" and "timeout after 998ms on order 17"
+// group together. Only the first line is kept (stack traces follow it).
+func NormalizeErrorMessage(msg string) string {
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	msg = reErrUUID.ReplaceAllString(msg, "<uuid>")
+	msg = reErrQuoted.ReplaceAllString(msg, "<str>")
+	msg = reErrHex.ReplaceAllString(msg, "<hex>")
+	msg = reErrNumber.ReplaceAllString(msg, "<n>")
+	msg = strings.TrimSpace(reErrSpace.ReplaceAllString(msg, " "))
+	// The template is stored in Issue.Message.
+	return TruncateUTF8(msg, errorMessageMaxLen)
+}
+
+// TruncateUTF8 returns s cut to at most n bytes on a rune boundary.
+// Postgres and MySQL reject invalid UTF-8, so strings bound for a sized
+// column must not be byte-sliced mid-rune.
+func TruncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// IssueFingerprint is the stable identifier of an error group: a hash of
+// its service, error type and normalized message.
+func IssueFingerprint(service, errType, message string) string {
+	h := fnv.New64a()
+	for _, part := range []string{service, errType, message} {
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// errorTypeFromAttributes extracts exception.type (or error.type) from an
+// attributes blob as written by the receiver.
+func errorTypeFromAttributes(attrs string) string {
+	if v, ok := attributeValue(attrs, "exception.type", "error.type"); ok && v != "" {
+		return v
+	}
+	return unknownErrorType
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"timeout after 3012ms on order 8812", "timeout after <n>ms on order <n>"},
+		{"user 'alice' not found", "user <str> not found"},
+		{"request 3fa85f64-5717-4562-b3fc-2c963f66afa6 failed", "request <uuid> failed"},
+		{"bad pointer 0xc000a1b2", "bad pointer <hex>"},
+		{"panic: boom\n\tat main.go:12\n\tat runtime.go:99", "panic: boom"},
+		{"  lots   of\tspace  ", "lots of space"},
+	}
+	for _, tc := range cases {
+		if got := NormalizeErrorMessage(tc.in); got != tc.want {
+			t.Errorf("NormalizeErrorMessage(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	// Long multi-byte messages are cut on a rune boundary.
+	long := strings.Repeat("ошибка ", 100) // byte 256 is mid-rune
+	if got := NormalizeErrorMessage(long); !utf8.ValidString(got) || len(got) > errorMessageMaxLen || len(got) < errorMessageMaxLen-3 {
+		t.Errorf("truncated to %d bytes, valid UTF-8 = %v", len(got), utf8.ValidString(got))
+	}
+}
+
+func TestErrorTypeFromAttributes(t *testing.T) {
+	attrs := `[{"key":"exception.message","value":{"Value":{"StringValue":"x"}}},{"key":"exception.type","value":{"Value":{"StringValue":"IOError"}}}]`
+	if got := errorTypeFromAttributes(attrs); got != "IOError" {
+		t.Errorf("exception.type = %q, want IOError", got)
+	}
+	for _, in := range []string{"", "{}", "[not json"} {
+		if got := errorTypeFromAttributes(in); got != unknownErrorType {
+			t.Errorf("errorTypeFromAttributes(%q) = %q, want %q", in, got, unknownErrorType)
+		}
+	}
+}
+
+func TestGetErrorGroups_GroupsAndTrends(t *testing.T) {
+	repo := newTestRepo(t)
+	end := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	start := end.Add(-4 * time.Hour)
+	ioErr := CompressedText(`[{"key":"exception.type","value":{"Value":{"StringValue":"IOError"}}}]`)
+
+	logs := []Log{
+		// Same group: numbers differ, type and service match.
+		{TenantID: "t1", TraceID: "tr1", Severity: "ERROR", ServiceName: "api", Body: "read failed after 10ms", AttributesJSON: ioErr, Timestamp: start.Add(30 * time.Minute)},
+		{TenantID: "t1", TraceID: "tr2", Severity: "ERROR", ServiceName: "api", Body: "read failed after 250ms", AttributesJSON: ioErr, Timestamp: start.Add(3 * time.Hour)},
+		{TenantID: "t1", TraceID: "tr2", Severity: "SEVERITY_NUMBER_ERROR", ServiceName: "api", Body: "read failed after 7ms", AttributesJSON: ioErr, Timestamp: start.Add(3*time.Hour + time.Minute)},
+		// Different service → separate group.
+		{TenantID: "t1", TraceID: "tr3", Severity: "FATAL", ServiceName: "db", Body: "disk full", Timestamp: start.Add(time.Hour)},
+		// Excluded: not an error, other tenant, out of range.
+		{TenantID: "t1", Severity: "INFO", ServiceName: "api", Body: "ok", Timestamp: start.Add(time.Hour)},
+		{TenantID: "t2", Severity: "ERROR", ServiceName: "api", Body: "read failed after 1ms", Timestamp: start.Add(time.Hour)},
+		{TenantID: "t1", Severity: "ERROR", ServiceName: "api", Body: "old", Timestamp: start.Add(-time.Hour)},
+	}
+	if err := repo.BatchCreateLogs(logs); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+
+	ctx := WithTenantContext(context.Background(), "t1")
+	resp, err := repo.GetErrorGroups(ctx, ErrorGroupQuery{Start: start, End: end, Buckets: 4})
+	if err != nil {
+		t.Fatalf("GetErrorGroups: %v", err)
+	}
+	if resp.TotalErrors != 4 || resp.TotalGroups != 2 {
+		t.Fatalf("TotalErrors=%d TotalGroups=%d, want 4 and 2", resp.TotalErrors, resp.TotalGroups)
+	}
+	g := resp.Groups[0]
+	if g.ServiceName != "api" || g.ErrorType != "IOError" || g.Message != "read failed after <n>ms" {
+		t.Fatalf("top group = %+v", g)
+	}
+	if g.Count != 3 {
+		t.Errorf("Count = %d, want 3", g.Count)
+	}
+	if want := []int64{1, 0, 0, 2}; !slices.Equal(g.Trend, want) {
+		t.Errorf("Trend = %v, want %v", g.Trend, want)
+	}
+	if !g.FirstSeen.Equal(start.Add(30*time.Minute)) || !g.LastSeen.Equal(start.Add(3*time.Hour+time.Minute)) {
+		t.Errorf("FirstSeen=%v LastSeen=%v", g.FirstSeen, g.LastSeen)
+	}
+	if g.SampleMessage != "read failed after 7ms" {
+		t.Errorf("SampleMessage = %q, want most recent body", g.SampleMessage)
+	}
+	if len(g.SampleTraceIDs) != 2 {
+		t.Errorf("SampleTraceIDs = %v, want 2 distinct", g.SampleTraceIDs)
+	}
+	if resp.Groups[1].ErrorType != unknownErrorType {
+		t.Errorf("db group ErrorType = %q, want %q", resp.Groups[1].ErrorType, unknownErrorType)
+	}
+
+	limited, err := repo.GetErrorGroups(ctx, ErrorGroupQuery{Start: start, End: end, Limit: 1})
+	if err != nil {
+		t.Fatalf("GetErrorGroups limit: %v", err)
+	}
+	if len(limited.Groups) != 1 || limited.TotalGroups != 2 {
+		t.Errorf("limit=1: groups=%d total=%d, want 1 and 2", len(limited.Groups), limited.TotalGroups)
+	}
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Query resolutions reported by QueryExplain.
+const (
+	// ResolutionRaw means the answer was computed from individual spans,
+	// traces or logs.
+	ResolutionRaw = "raw"
+	// ResolutionRollup means the answer was computed from pre-aggregated
+	// metric_buckets windows or dashboard_rollups buckets.
+	ResolutionRollup = "rollup"
+)
+
+const (
+	// maxExplainQueries caps the statements one QueryExplain records.
+	maxExplainQueries = 100
+	// maxExplainSQLLen truncates recorded SQL; IN lists can be long.
+	maxExplainSQLLen = 8192
+
+	cacheKeyExplainStart = "explain:start"
+)
+
+// ExplainedQuery is one SQL statement run while a QueryExplain was on the
+// context. Rows is the number of rows it returned (-1 for row-streaming
+// reads), not the number the database engine examined.
+type ExplainedQuery struct {
+	SQL        string  `json:"sql"`
+	Rows       int64   `json:"rows"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// ExplainReport is the ?explain=true payload of the query endpoints.
+type ExplainReport struct {
+	Resolution string           `json:"resolution,omitempty"` // ResolutionRaw or ResolutionRollup
+	Detail     string           `json:"detail,omitempty"`     // e.g. the search path or step used
+	Queries    []ExplainedQuery `json:"queries"`
+	Truncated  bool             `json:"truncated,omitempty"` // more than maxExplainQueries ran
+	RowsRead   int64            `json:"rows_read"`           // sum of Queries' Rows
+	DBMs       float64          `json:"db_ms"`               // sum of Queries' durations
+	TotalMs    float64          `json:"total_ms"`            // since WithExplain
+}
+
+// QueryExplain collects the SQL a request runs and the resolution the
+// repository chose. Attach one with WithExplain; it is safe for the
+// concurrent COUNT and SELECT the list queries run.
+type QueryExplain struct {
+	started time.Time
+
+	mu         sync.Mutex
+	resolution string
+	detail     string
+	queries    []ExplainedQuery
+	truncated  bool
+}
+
+type explainCtxKey struct{}
+
+// WithExplain returns a copy of ctx that records every statement the
+// repository runs with it, and the QueryExplain to read them from.
+func WithExplain(ctx context.Context) (context.Context, *QueryExplain) {
+	e := &QueryExplain{started: time.Now()}
+	return context.WithValue(ctx, explainCtxKey{}, e), e
+}
+
+func explainFromContext(ctx context.Context) *QueryExplain {
+	if ctx == nil {
+		return nil
+	}
+	e, _ := ctx.Value(explainCtxKey{}).(*QueryExplain)
+	return e
+}
+
+// explainResolution notes the resolution a query path chose, and a detail
+// such as the search path, on the QueryExplain on ctx, if any.
+func explainResolution(ctx context.Context, resolution, detail string) {
+	if e := explainFromContext(ctx); e != nil {
+		e.mu.Lock()
+		e.resolution, e.detail = resolution, detail
+		e.mu.Unlock()
+	}
+}
+
+func (e *QueryExplain) record(sql string, rows int64, elapsed time.Duration, err error) {
+	if len(sql) > maxExplainSQLLen {
+		sql = sql[:maxExplainSQLLen] + "…"
+	}
+	q := ExplainedQuery{SQL: sql, Rows: rows, DurationMs: float64(elapsed.Microseconds()) / 1000}
+	if err != nil {
+		q.Error = err.Error()
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queries) >= maxExplainQueries {
+		e.truncated = true
+		return
+	}
+	e.queries = append(e.queries, q)
+}
+
+// Report summarizes what has been recorded so far.
+func (e *QueryExplain) Report() ExplainReport {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rep := ExplainReport{
+		Resolution: e.resolution,
+		Detail:     e.detail,
+		Queries:    append([]ExplainedQuery{}, e.queries...),
+		Truncated:  e.truncated,
+		TotalMs:    float64(time.Since(e.started).Microseconds()) / 1000,
+	}
+	for _, q := range e.queries {
+		if q.Rows > 0 {
+			rep.RowsRead += q.Rows
+		}
+		rep.DBMs += q.DurationMs
+	}
+	return rep
+}
+
+// registerExplainCallbacks records every read statement whose context
+// carries a QueryExplain. Row-streaming reads (Scan, Rows) are recorded
+// before their rows are consumed, so their Rows is -1.
+func registerExplainCallbacks(db *gorm.DB) {
+	before := func(d *gorm.DB) {
+		if explainFromContext(d.Statement.Context) != nil {
+			d.Set(cacheKeyExplainStart, time.Now())
+		}
+	}
+	after := func(rows func(*gorm.DB) int64) func(*gorm.DB) {
+		return func(d *gorm.DB) {
+			e := explainFromContext(d.Statement.Context)
+			start, ok := d.Get(cacheKeyExplainStart)
+			if e == nil || !ok || d.Statement.SQL.Len() == 0 {
+				return
+			}
+			sql := d.Dialector.Explain(d.Statement.SQL.String(), d.Statement.Vars...)
+			e.record(sql, rows(d), time.Since(start.(time.Time)), d.Error)
+		}
+	}
+	_ = db.Callback().Query().Before("gorm:query").Register("explain:before_query", before)
+	_ = db.Callback().Query().After("gorm:query").Register("explain:after_query", after(func(d *gorm.DB) int64 { return d.RowsAffected }))
+	_ = db.Callback().Row().Before("gorm:row").Register("explain:before_row", before)
+	_ = db.Callback().Row().After("gorm:row").Register("explain:after_row", after(func(*gorm.DB) int64 { return -1 }))
+}
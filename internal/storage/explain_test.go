@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithExplain_RecordsStatements(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now()
+	seedLogs(t, repo.db, 3, now, "checkout")
+
+	// Statements on a context without a QueryExplain are not recorded.
+	if _, _, err := repo.GetLogsV2(context.Background(), LogFilter{Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, ex := WithExplain(WithTenantContext(context.Background(), DefaultTenantID))
+	logs, _, err := repo.GetLogsV2(ctx, LogFilter{ServiceName: "checkout", Limit: 2})
+	if err != nil || len(logs) != 2 {
+		t.Fatalf("GetLogsV2 = %d logs, %v", len(logs), err)
+	}
+	rep := ex.Report()
+	if rep.Resolution != ResolutionRaw || len(rep.Queries) != 2 {
+		t.Fatalf("report = %+v", rep)
+	}
+	// The SELECT returned 2 rows and the COUNT 1.
+	if rep.RowsRead != 3 || rep.TotalMs < rep.DBMs {
+		t.Errorf("rows_read = %d, total %v ms, db %v ms", rep.RowsRead, rep.TotalMs, rep.DBMs)
+	}
+	for _, q := range rep.Queries {
+		if !strings.Contains(q.SQL, "checkout") {
+			t.Errorf("SQL without bound values: %s", q.SQL)
+		}
+	}
+
+	for range maxExplainQueries {
+		ex.record("SELECT 1", 1, 0, nil)
+	}
+	if rep := ex.Report(); len(rep.Queries) != maxExplainQueries || !rep.Truncated {
+		t.Errorf("cap: %d queries, truncated=%v", len(rep.Queries), rep.Truncated)
+	}
+}
@@ -134,6 +134,8 @@ func NewDatabase(driver, dsn string) (*gorm.DB, error) {
 		}
 	}
 
+	registerExplainCallbacks(db)
+
 	return db, nil
 }
 
@@ -255,7 +257,7 @@ func AutoMigrateModelsWithOptions(db *gorm.DB, driver string, opts MigrateOption
 		log.Printf("⚠️  span dedupe before unique index failed: %v", err)
 	}
 
-	migrateModels := []any{&Trace{}, &Span{}, &MetricBucket{}}
+	migrateModels := []any{&Trace{}, &Span{}, &SpanAttribute{}, &SpanException{}, &SpanLink{}, &SpanEvent{}, &MetricBucket{}, &AlertRule{}, &AlertEvent{}, &SLO{}, &Monitor{}, &MonitorResult{}, &Deployment{}, &APIKey{}, &TriageState{}, &Issue{}, &TraceInsight{}, &StorageUsageSample{}, &SelfEvent{}, &DashboardRollup{}, &RollupWatermark{}, &SavedView{}, &DeletionRecord{}, &RuntimeSetting{}, &AuditEvent{}, &ColdSegment{}, &RehydrationJob{}, &RehydratedLog{}}
 	if !logsPartitioned {
 		migrateModels = append(migrateModels, &Log{})
 	}
@@ -282,6 +284,12 @@ func AutoMigrateModelsWithOptions(db *gorm.DB, driver string, opts MigrateOption
 		log.Printf("⚠️  legacy trace_id unique index drop failed: %v", err)
 	}
 
+	// Populate the nanosecond timing columns on rows written before they
+	// existed. Idempotent and a no-op on fresh databases.
+	if err := backfillNanosecondTiming(db, driver); err != nil {
+		log.Printf("⚠️  nanosecond timing backfill failed: %v", err)
+	}
+
 	// Legacy MySQL cleanup: drop FKs that pre-RAN-49 migrations created. Fresh
 	// MySQL DBs after RAN-49 won't have these (FK creation is now disabled at
 	// the gorm.Config layer), but pre-existing deployments still need this
@@ -300,7 +308,7 @@ func AutoMigrateModelsWithOptions(db *gorm.DB, driver string, opts MigrateOption
 	// Gated on LOG_FTS_ENABLED (default false) — FTS5's inverted index typically
 	// consumes 30-40% of SQLite DB disk for log-heavy workloads. When disabled,
 	// search_logs falls back transparently to LIKE via the existing branch in
-	// log_repo.go:105.
+	// log_repo.go:105. Postgres gets the equivalent tsvector index below.
 	if driver == "sqlite" || driver == "" {
 		if logFTSEnabledFromEnv() {
 			if err := setupSQLiteFTS5(db); err != nil {
@@ -348,6 +356,17 @@ func AutoMigrateModelsWithOptions(db *gorm.DB, driver string, opts MigrateOption
 				log.Println("🔎 Postgres: pg_trgm extension verified; GIN indexes ready on logs.body and logs.service_name")
 			}
 		}
+
+		// Full-text search: tsvector GIN expression index, gated on
+		// LOG_FTS_ENABLED like SQLite's FTS5 table. Covers partitioned logs
+		// too — the index is declared on the parent.
+		if logFTSEnabledFromEnv() {
+			if err := setupPostgresLogFTS(db); err != nil {
+				log.Printf("⚠️  Postgres full-text index setup failed (%v) — log search will fall back to ILIKE", err)
+			} else {
+				log.Println("🔎 Postgres: tsvector GIN index ready on logs.body")
+			}
+		}
 	}
 
 	return nil
@@ -148,17 +148,28 @@ func logFTSEnabledFromEnv() bool {
 
 // DropLogsFTS removes the FTS5 virtual table and its sync triggers, then runs
 // VACUUM to reclaim freed pages. Used by /api/admin/drop_fts on existing
-// SQLite deployments after LOG_FTS_ENABLED has been set to false, to recover
+// deployments after LOG_FTS_ENABLED has been set to false, to recover
 // the 30-40% of DB disk the inverted index occupied.
 //
 // VACUUM blocks writes for ~10-60 minutes on a multi-GB DB and cannot run
 // inside a transaction. Idempotent — safe to call when the FTS5 table or
 // triggers are already absent.
+//
+// On Postgres it drops the tsvector GIN index instead; the space returns to
+// the filesystem immediately, no VACUUM needed.
 func (r *Repository) DropLogsFTS(ctx context.Context) error {
-	if !strings.EqualFold(r.driver, "sqlite") {
-		return fmt.Errorf("DropLogsFTS only supported on SQLite, got driver=%q", r.driver)
-	}
 	db := r.db.WithContext(ctx)
+	switch strings.ToLower(r.driver) {
+	case "sqlite":
+	case "postgres", "postgresql":
+		if err := db.Exec("DROP INDEX IF EXISTS " + pgLogsFTSIndex).Error; err != nil {
+			return fmt.Errorf("drop index %s: %w", pgLogsFTSIndex, err)
+		}
+		slog.Info("Postgres logs full-text index dropped")
+		return nil
+	default:
+		return fmt.Errorf("DropLogsFTS only supported on SQLite and Postgres, got driver=%q", r.driver)
+	}
 	for _, name := range []string{"logs_au", "logs_ad", "logs_ai"} {
 		if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
 			return fmt.Errorf("drop trigger %s: %w", name, err)
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// pgLogsFTSIndex is the GIN expression index backing Postgres full-text log
+// search. Postgres maintains it on every INSERT/UPDATE, so — unlike the
+// SQLite FTS5 table — no triggers or shadow table are needed.
+const pgLogsFTSIndex = "idx_logs_body_fts"
+
+// pgLogsTSVector is the indexed expression. Queries must repeat it verbatim
+// for the planner to match the index. The "english" configuration stems like
+// FTS5's porter tokenizer, so both drivers match "panic" → "panicked".
+const pgLogsTSVector = "to_tsvector('english', logs.body)"
+
+// setupPostgresLogFTS creates the tsvector GIN index on logs.body. On a
+// partitioned logs table the index is declared on the parent and propagates
+// to every partition. Idempotent.
+//
+// Building the index on an existing large table blocks writes to logs for
+// the duration; operators with hot multi-GB tables can pre-create it with
+// CREATE INDEX CONCURRENTLY (on an unpartitioned table) before enabling
+// LOG_FTS_ENABLED.
+func setupPostgresLogFTS(db *gorm.DB) error {
+	ddl := "CREATE INDEX IF NOT EXISTS " + pgLogsFTSIndex + " ON logs USING GIN (to_tsvector('english', body))"
+	if err := db.Exec(ddl).Error; err != nil {
+		return fmt.Errorf("create %s: %w", pgLogsFTSIndex, err)
+	}
+	return nil
+}
+
+// pgFTSAvailable reports whether the given driver should use the tsvector
+// path: Postgres with LOG_FTS_ENABLED on.
+func pgFTSAvailable(driver string) bool {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql":
+		return logFTSEnabledFromEnv()
+	}
+	return false
+}
+
+// pgTSQueryExpr translates free-form user input into a to_tsquery expression:
+// every word becomes a prefix term ("term:*") and terms are AND-ed, matching
+// fts5MatchExpr's semantics. Input is split on anything that is not a letter
+// or digit, so tsquery operators and quotes in user input can never reach
+// the parser.
+//
+// Returns "" when the input has no searchable words.
+func pgTSQueryExpr(input string) string {
+	words := strings.FieldsFunc(input, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	for i, w := range words {
+		words[i] = strings.ToLower(w) + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// logFTSQuery is the indexed full-text clause set for one search string.
+type logFTSQuery struct {
+	join  string // extra JOIN, if the index lives in a side table
+	where string // WHERE clause with one placeholder
+	arg   string // its argument
+	order string // relevance ordering
+}
+
+// logFTS returns the indexed full-text search clauses for search on this
+// Repository's driver — FTS5 on SQLite, tsvector on Postgres — or ok=false
+// when search must use LIKE (FTS disabled, other drivers, or no searchable
+// words).
+func (r *Repository) logFTS(search string) (logFTSQuery, bool) {
+	switch {
+	case fts5Available(r.driver):
+		expr := fts5MatchExpr(search)
+		if expr == "" {
+			return logFTSQuery{}, false
+		}
+		return logFTSQuery{
+			join:  "JOIN " + fts5LogsTable + " ON logs.id = " + fts5LogsTable + ".rowid",
+			where: fts5LogsTable + " MATCH ?",
+			arg:   expr,
+			order: "bm25(" + fts5LogsTable + ") ASC",
+		}, true
+	case pgFTSAvailable(r.driver):
+		expr := pgTSQueryExpr(search)
+		if expr == "" {
+			return logFTSQuery{}, false
+		}
+		return logFTSQuery{
+			where: pgLogsTSVector + " @@ to_tsquery('english', ?)",
+			arg:   expr,
+			order: "ts_rank(" + pgLogsTSVector + ", to_tsquery('english', " + quoteSQLString(expr) + ")) DESC",
+		}, true
+	}
+	return logFTSQuery{}, false
+}
+
+// quoteSQLString renders s as a SQL string literal. Only used for tsquery
+// expressions built by pgTSQueryExpr, which contain letters, digits and the
+// fixed ":*" / " & " separators, but quoting keeps it safe regardless.
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
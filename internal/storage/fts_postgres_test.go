@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPgTSQueryExpr verifies that user input becomes AND-ed prefix terms and
+// that tsquery operators never survive into the expression.
+func TestPgTSQueryExpr(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"  \t", ""},
+		{"& | ! ( ) : * '", ""},
+		{"connection", "connection:*"},
+		{"Connection Refused", "connection:* & refused:*"},
+		{"db-primary:5432", "db:* & primary:* & 5432:*"},
+		{`he said "hi" & !bye`, "he:* & said:* & hi:* & bye:*"},
+		{"naïve café", "naïve:* & café:*"},
+	}
+	for _, c := range cases {
+		if got := pgTSQueryExpr(c.in); got != c.want {
+			t.Errorf("pgTSQueryExpr(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+// TestPgFTSAvailable_Gates verifies that only Postgres uses the tsvector path
+// and only with LOG_FTS_ENABLED on.
+func TestPgFTSAvailable_Gates(t *testing.T) {
+	t.Setenv("LOG_FTS_ENABLED", "true")
+	for driver, want := range map[string]bool{
+		"postgres":   true,
+		"PostgreSQL": true,
+		"sqlite":     false,
+		"mysql":      false,
+		"":           false,
+	} {
+		if got := pgFTSAvailable(driver); got != want {
+			t.Errorf("pgFTSAvailable(%q) = %v, want %v", driver, got, want)
+		}
+	}
+	t.Setenv("LOG_FTS_ENABLED", "false")
+	if pgFTSAvailable("postgres") {
+		t.Error("pgFTSAvailable(postgres) with LOG_FTS_ENABLED=false = true, want false")
+	}
+}
+
+// TestLogFTS_PicksIndexPerDriver verifies the repository picks FTS5 on
+// SQLite, tsvector on Postgres, and LIKE elsewhere or for unsearchable input.
+func TestLogFTS_PicksIndexPerDriver(t *testing.T) {
+	t.Setenv("LOG_FTS_ENABLED", "true")
+
+	q, ok := (&Repository{driver: "sqlite"}).logFTS("panic")
+	if !ok || !strings.Contains(q.where, "MATCH") || q.join == "" || !strings.HasPrefix(q.order, "bm25(") {
+		t.Errorf("sqlite: got %+v, %v; want FTS5 MATCH + bm25", q, ok)
+	}
+
+	q, ok = (&Repository{driver: "postgres"}).logFTS("panic")
+	if !ok || !strings.Contains(q.where, "@@ to_tsquery") || q.join != "" || q.arg != "panic:*" ||
+		!strings.HasPrefix(q.order, "ts_rank(") {
+		t.Errorf("postgres: got %+v, %v; want tsvector @@ + ts_rank", q, ok)
+	}
+
+	if _, ok := (&Repository{driver: "postgres"}).logFTS("!&|"); ok {
+		t.Error("postgres: operator-only input used the index, want LIKE fallback")
+	}
+	if _, ok := (&Repository{driver: "mysql"}).logFTS("panic"); ok {
+		t.Error("mysql: used a full-text index, want LIKE")
+	}
+}
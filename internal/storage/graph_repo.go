@@ -18,7 +18,7 @@ type SpanGraphRow struct {
 // GetSpansForGraph returns a lightweight projection of recent spans used to
 // rebuild the in-memory service dependency graph.
 //
-// Duration is stored in microseconds; we convert to milliseconds here so the
+// Duration is stored in nanoseconds; we convert to milliseconds here so the
 // graph layer doesn't need to know the storage unit.
 func (r *Repository) GetSpansForGraph(since time.Time) ([]SpanGraphRow, error) {
 	type raw struct {
@@ -26,7 +26,7 @@ func (r *Repository) GetSpansForGraph(since time.Time) ([]SpanGraphRow, error) {
 		ParentSpanID  string
 		ServiceName   string
 		OperationName string
-		Duration      int64
+		DurationNs    int64
 		TraceStatus   string
 		StartTime     time.Time
 	}
@@ -34,7 +34,7 @@ func (r *Repository) GetSpansForGraph(since time.Time) ([]SpanGraphRow, error) {
 	var rows []raw
 	err := r.db.
 		Table("spans").
-		Select("spans.span_id, spans.parent_span_id, spans.service_name, spans.operation_name, spans.duration, traces.status AS trace_status, spans.start_time").
+		Select("spans.span_id, spans.parent_span_id, spans.service_name, spans.operation_name, spans.duration_ns, traces.status AS trace_status, spans.start_time").
 		Joins("LEFT JOIN traces ON traces.trace_id = spans.trace_id").
 		Where("spans.start_time >= ?", since).
 		Scan(&rows).Error
@@ -49,7 +49,7 @@ func (r *Repository) GetSpansForGraph(since time.Time) ([]SpanGraphRow, error) {
 			ParentSpanID:  raw.ParentSpanID,
 			ServiceName:   raw.ServiceName,
 			OperationName: raw.OperationName,
-			DurationMs:    float64(raw.Duration) / 1000.0, // µs → ms
+			DurationMs:    float64(raw.DurationNs) / 1e6, // ns → ms
 			IsError:       raw.TraceStatus == "ERROR",
 			Timestamp:     raw.StartTime,
 		}
@@ -0,0 +1,306 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxFilterPatterns caps distinct query shapes held in memory. Shapes
+	// are code-defined (a handful of filter columns per table), so the cap
+	// is only a guard against an unexpected blow-up.
+	maxFilterPatterns = 256
+
+	// defaultAdviceMinQueries is the sample size below which a shape is
+	// reported but never turned into a suggestion.
+	defaultAdviceMinQueries = 20
+
+	// Suggestion kinds.
+	AdviceCompositeIndex     = "composite_index"
+	AdviceAttributePromotion = "attribute_promotion"
+)
+
+// selectiveColumns are filter columns selective enough that any index led
+// by them serves the query on its own, whatever else it filters on.
+var selectiveColumns = map[string]bool{"trace_id": true}
+
+// filterPattern is one observed query shape. Equality columns are sorted so
+// the same filters in any order collapse into one shape.
+type filterPattern struct {
+	table     string
+	eq        string // comma-joined equality columns
+	rng       string // range column, "" when unbounded
+	attribute string // span attribute grouped in Go, "" for SQL-only shapes
+}
+
+type filterPatternAcc struct {
+	count int64
+	total time.Duration
+}
+
+// filterStats records query shapes since process start. The zero value is
+// ready to use.
+type filterStats struct {
+	mu       sync.Mutex
+	patterns map[filterPattern]*filterPatternAcc
+	since    time.Time
+}
+
+func (s *filterStats) observe(p filterPattern, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.patterns == nil {
+		s.patterns = make(map[filterPattern]*filterPatternAcc)
+		s.since = time.Now()
+	}
+	acc, ok := s.patterns[p]
+	if !ok {
+		if len(s.patterns) >= maxFilterPatterns {
+			return
+		}
+		acc = &filterPatternAcc{}
+		s.patterns[p] = acc
+	}
+	acc.count++
+	acc.total += elapsed
+}
+
+// observeFilter records a query on table filtering eq columns by equality
+// and rng by range. Called by the query paths after they run.
+func (r *Repository) observeFilter(table string, eq []string, rng string, elapsed time.Duration) {
+	r.filters.observe(newFilterPattern(table, eq, rng, ""), elapsed)
+}
+
+func newFilterPattern(table string, eq []string, rng, attribute string) filterPattern {
+	cols := slices.Clone(eq)
+	sort.Strings(cols)
+	return filterPattern{table: table, eq: strings.Join(cols, ","), rng: rng, attribute: attribute}
+}
+
+// FilterPatternStats reports one observed query shape.
+type FilterPatternStats struct {
+	Table        string   `json:"table"`
+	Equality     []string `json:"equality,omitempty"`
+	Range        string   `json:"range,omitempty"`
+	Attribute    string   `json:"attribute,omitempty"`
+	Queries      int64    `json:"queries"`
+	AvgLatencyMs float64  `json:"avg_latency_ms"`
+	TotalMs      float64  `json:"total_ms"`
+	Covered      bool     `json:"covered"` // an existing index serves the SQL filter
+}
+
+// IndexSuggestion is one advisor recommendation.
+type IndexSuggestion struct {
+	Kind      string   `json:"kind"` // composite_index | attribute_promotion
+	Table     string   `json:"table"`
+	Columns   []string `json:"columns,omitempty"`
+	Attribute string   `json:"attribute,omitempty"`
+	DDL       string   `json:"ddl,omitempty"`
+	Queries   int64    `json:"queries"`
+	// EstimatedBenefitMs is the time spent on the shapes this suggestion
+	// serves since Since — an upper bound on what it can save.
+	EstimatedBenefitMs float64 `json:"estimated_benefit_ms"`
+	// Share is the fraction of the table's observed query time it covers.
+	Share  float64 `json:"share"`
+	Reason string  `json:"reason"`
+}
+
+// IndexAdvice is the advisor response.
+type IndexAdvice struct {
+	Driver      string               `json:"driver"`
+	Since       time.Time            `json:"since"`
+	Patterns    []FilterPatternStats `json:"patterns"`
+	Suggestions []IndexSuggestion    `json:"suggestions"`
+}
+
+// AdviseIndexes turns the query shapes observed since startup into composite
+// index and attribute promotion suggestions, skipping shapes an existing
+// index already serves and shapes seen fewer than minQueries times (<= 0
+// means defaultAdviceMinQueries). Suggestions are ordered by estimated
+// benefit. Nothing is created; DDL is returned for the operator to review.
+//
+// Tenant scope: SYSTEM-WIDE. Indexes are shared by every tenant, so shapes
+// are recorded without tenant attribution.
+func (r *Repository) AdviseIndexes(ctx context.Context, minQueries int) (*IndexAdvice, error) {
+	if minQueries <= 0 {
+		minQueries = defaultAdviceMinQueries
+	}
+	r.filters.mu.Lock()
+	since := r.filters.since
+	observed := make(map[filterPattern]filterPatternAcc, len(r.filters.patterns))
+	for p, acc := range r.filters.patterns {
+		observed[p] = *acc
+	}
+	r.filters.mu.Unlock()
+
+	advice := &IndexAdvice{Driver: r.driver, Since: since, Patterns: []FilterPatternStats{}, Suggestions: []IndexSuggestion{}}
+	indexes := make(map[string][][]string)
+	tableTotal := make(map[string]time.Duration)
+	for p, acc := range observed {
+		tableTotal[p.table] += acc.total
+		if _, ok := indexes[p.table]; ok {
+			continue
+		}
+		cols, err := r.tableIndexColumns(ctx, p.table)
+		if err != nil {
+			return nil, err
+		}
+		indexes[p.table] = cols
+	}
+
+	// Uncovered shapes needing the same index are merged into one
+	// suggestion keyed by its column list.
+	composite := make(map[string]*IndexSuggestion)
+	promotions := make(map[string]*IndexSuggestion)
+	for p, acc := range observed {
+		eq := splitColumns(p.eq)
+		covered := indexCovers(indexes[p.table], eq, p.rng)
+		advice.Patterns = append(advice.Patterns, FilterPatternStats{
+			Table:        p.table,
+			Equality:     eq,
+			Range:        p.rng,
+			Attribute:    p.attribute,
+			Queries:      acc.count,
+			AvgLatencyMs: durationMs(acc.total) / float64(acc.count),
+			TotalMs:      durationMs(acc.total),
+			Covered:      covered,
+		})
+		if acc.count < int64(minQueries) {
+			continue
+		}
+		if !covered {
+			cols := append(append([]string{"tenant_id"}, eq...), nonEmpty(p.rng)...)
+			key := p.table + ":" + strings.Join(cols, ",")
+			s, ok := composite[key]
+			if !ok {
+				s = &IndexSuggestion{
+					Kind:    AdviceCompositeIndex,
+					Table:   p.table,
+					Columns: cols,
+					DDL:     r.createIndexDDL(p.table, cols),
+					Reason:  "no index leads with tenant_id, the equality filters and then the range column",
+				}
+				composite[key] = s
+			}
+			s.Queries += acc.count
+			s.EstimatedBenefitMs += durationMs(acc.total)
+		}
+		if p.attribute != "" {
+			key := p.table + ":" + p.attribute
+			s, ok := promotions[key]
+			if !ok {
+				s = &IndexSuggestion{
+					Kind:      AdviceAttributePromotion,
+					Table:     p.table,
+					Attribute: p.attribute,
+					Reason:    "grouped by decompressing and parsing attributes_json per row; a dedicated indexed column lets SQL filter and group it",
+				}
+				promotions[key] = s
+			}
+			s.Queries += acc.count
+			s.EstimatedBenefitMs += durationMs(acc.total)
+		}
+	}
+
+	for _, group := range []map[string]*IndexSuggestion{composite, promotions} {
+		for _, s := range group {
+			if total := durationMs(tableTotal[s.Table]); total > 0 {
+				s.Share = s.EstimatedBenefitMs / total
+			}
+			advice.Suggestions = append(advice.Suggestions, *s)
+		}
+	}
+	sort.Slice(advice.Suggestions, func(i, j int) bool {
+		a, b := advice.Suggestions[i], advice.Suggestions[j]
+		if a.EstimatedBenefitMs != b.EstimatedBenefitMs {
+			return a.EstimatedBenefitMs > b.EstimatedBenefitMs
+		}
+		return a.Table+a.Attribute+strings.Join(a.Columns, ",") < b.Table+b.Attribute+strings.Join(b.Columns, ",")
+	})
+	sort.Slice(advice.Patterns, func(i, j int) bool {
+		if advice.Patterns[i].TotalMs != advice.Patterns[j].TotalMs {
+			return advice.Patterns[i].TotalMs > advice.Patterns[j].TotalMs
+		}
+		return advice.Patterns[i].Queries > advice.Patterns[j].Queries
+	})
+	return advice, nil
+}
+
+// tableIndexColumns returns the column list of every index on table.
+func (r *Repository) tableIndexColumns(ctx context.Context, table string) ([][]string, error) {
+	idx, err := r.db.WithContext(ctx).Migrator().GetIndexes(table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes on %s: %w", table, err)
+	}
+	out := make([][]string, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, i.Columns())
+	}
+	return out, nil
+}
+
+// indexCovers reports whether one of indexes serves a filter on the eq
+// columns plus the rng range: it leads with tenant_id, then every eq column
+// in any order, then rng. An index led by a selective eq column also counts.
+func indexCovers(indexes [][]string, eq []string, rng string) bool {
+	for _, cols := range indexes {
+		if len(cols) > 0 && selectiveColumns[cols[0]] && slices.Contains(eq, cols[0]) {
+			return true
+		}
+		need := 1 + len(eq)
+		if rng != "" {
+			need++
+		}
+		if len(cols) < need || cols[0] != "tenant_id" {
+			continue
+		}
+		prefix := slices.Clone(cols[1 : 1+len(eq)])
+		sort.Strings(prefix)
+		if !slices.Equal(prefix, eq) {
+			continue
+		}
+		if rng == "" || cols[1+len(eq)] == rng {
+			return true
+		}
+	}
+	return false
+}
+
+// createIndexDDL renders the statement for a suggested index. Postgres
+// builds it CONCURRENTLY so ingest is not blocked, except on the
+// partitioned logs parent, which does not support it.
+func (r *Repository) createIndexDDL(table string, cols []string) string {
+	name := "idx_" + table + "_" + strings.Join(cols, "_")
+	if len(name) > 63 { // Postgres identifier limit
+		name = name[:63]
+	}
+	create := "CREATE INDEX IF NOT EXISTS"
+	isPG := r.driver == "postgres" || r.driver == "postgresql"
+	if isPG && !(table == "logs" && r.LogsPartitioned()) {
+		create = "CREATE INDEX CONCURRENTLY IF NOT EXISTS"
+	}
+	return fmt.Sprintf("%s %s ON %s (%s)", create, name, table, strings.Join(cols, ", "))
+}
+
+func splitColumns(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIndexCovers(t *testing.T) {
+	indexes := [][]string{
+		{"tenant_id", "timestamp"},
+		{"tenant_id", "service_name"},
+		{"trace_id"},
+	}
+	cases := []struct {
+		eq   []string
+		rng  string
+		want bool
+	}{
+		{nil, "timestamp", true},
+		{[]string{"service_name"}, "", true},
+		{[]string{"service_name"}, "timestamp", false},
+		{[]string{"service_name", "trace_id"}, "timestamp", true}, // selective column
+		{[]string{"severity"}, "", false},
+	}
+	for _, tc := range cases {
+		if got := indexCovers(indexes, tc.eq, tc.rng); got != tc.want {
+			t.Errorf("indexCovers(%v, %q) = %v, want %v", tc.eq, tc.rng, got, tc.want)
+		}
+	}
+}
+
+func TestAdviseIndexes_SuggestsUncoveredShapes(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	// One real query proves GetLogsV2 records its shape.
+	if _, _, err := repo.GetLogsV2(ctx, LogFilter{ServiceName: "web", Severity: "ERROR", StartTime: time.Now().Add(-time.Hour), Limit: 10}); err != nil {
+		t.Fatalf("GetLogsV2: %v", err)
+	}
+	for range 24 {
+		repo.observeFilter("logs", []string{"severity", "service_name"}, "timestamp", 10*time.Millisecond)
+		repo.observeFilter("logs", nil, "timestamp", 5*time.Millisecond) // idx_logs_tenant_ts
+	}
+	for range 30 {
+		repo.filters.observe(newFilterPattern("spans", []string{"service_name"}, "start_time", "region"), 40*time.Millisecond)
+	}
+	repo.observeFilter("traces", []string{"service_name"}, "timestamp", time.Second) // below min_queries
+
+	advice, err := repo.AdviseIndexes(ctx, 0)
+	if err != nil {
+		t.Fatalf("AdviseIndexes: %v", err)
+	}
+	if len(advice.Patterns) != 4 {
+		t.Errorf("patterns = %+v, want 4 shapes", advice.Patterns)
+	}
+	if len(advice.Suggestions) != 2 {
+		t.Fatalf("suggestions = %+v, want an attribute promotion and a logs index", advice.Suggestions)
+	}
+
+	promo, idx := advice.Suggestions[0], advice.Suggestions[1]
+	if promo.Kind != AdviceAttributePromotion || promo.Attribute != "region" || promo.Queries != 30 || promo.Share != 1 {
+		t.Errorf("promotion = %+v", promo)
+	}
+	if idx.Kind != AdviceCompositeIndex || idx.Queries != 25 ||
+		strings.Join(idx.Columns, ",") != "tenant_id,service_name,severity,timestamp" {
+		t.Errorf("index = %+v", idx)
+	}
+	if idx.DDL != "CREATE INDEX IF NOT EXISTS idx_logs_tenant_id_service_name_severity_timestamp ON logs (tenant_id, service_name, severity, timestamp)" {
+		t.Errorf("ddl = %q", idx.DDL)
+	}
+	if idx.Share <= 0.5 || idx.Share >= 1 {
+		t.Errorf("share = %v, want most of the logs time", idx.Share)
+	}
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// issueSettleDelay is how long errors are left for late batches before
+	// they are folded into issues. Errors stored later than this, with an
+	// older timestamp, are in the explorer but not counted on their issue.
+	issueSettleDelay = 2 * time.Minute
+	// issueStep is the range folded per transaction; maxIssueCatchUp bounds
+	// one AdvanceIssues call, so a first-boot backfill proceeds in steps.
+	issueStep       = time.Hour
+	maxIssueCatchUp = 6 * time.Hour
+	// issueSampleMaxLen caps the stored sample message.
+	issueSampleMaxLen = 4096
+	// issueListScanCap bounds the issues ListIssues folds per request.
+	issueListScanCap = 10_000
+
+	defaultIssueLimit = 100
+	maxIssueLimit     = 1000
+
+	issueWatermarkName = "issues"
+)
+
+// Issue sort orders accepted by ListIssues.
+const (
+	IssueSortLastSeen    = "last_seen"   // most recent first (default)
+	IssueSortFirstSeen   = "first_seen"  // newest issues first
+	IssueSortOccurrences = "occurrences" // most frequent first
+)
+
+// IssueQuery selects issues for the tenant on ctx.
+type IssueQuery struct {
+	ServiceNames []string
+	Status       string // open | resolved | ignored; empty = all
+	Sort         string // IssueSort*; default last_seen
+	Limit        int    // default 100, max 1000
+}
+
+// IssueList is a page of issues.
+type IssueList struct {
+	Issues    []Issue `json:"issues"`
+	Total     int     `json:"total"`     // matching issues before Limit
+	Truncated bool    `json:"truncated"` // issueListScanCap was hit
+}
+
+// ValidIssueSort reports whether s is an IssueSort* value.
+func ValidIssueSort(s string) bool {
+	switch s {
+	case IssueSortLastSeen, IssueSortFirstSeen, IssueSortOccurrences:
+		return true
+	}
+	return false
+}
+
+// issueAgg accumulates one fingerprint's occurrences within a step.
+type issueAgg struct {
+	Issue
+}
+
+func (a *issueAgg) add(ts time.Time, traceID, body string) {
+	a.Occurrences++
+	if a.FirstSeen.IsZero() || ts.Before(a.FirstSeen) {
+		a.FirstSeen = ts
+	}
+	if !ts.Before(a.LastSeen) {
+		a.LastSeen = ts
+		a.SampleMessage = TruncateUTF8(body, issueSampleMaxLen)
+		a.SampleTraceID = traceID
+	}
+}
+
+// AdvanceIssues folds settled error logs, and failed spans without an error
+// log of their own, into Issue rows across all tenants: occurrences since
+// the last call are added, first/last seen and the latest sample updated.
+// The first call starts backfill before now. At most maxIssueCatchUp is
+// folded per call, each step in one transaction with its watermark, so a
+// crash does not count errors twice. It returns the occurrences folded.
+func (r *Repository) AdvanceIssues(ctx context.Context, now time.Time, backfill time.Duration) (int64, error) {
+	now = now.UTC()
+	var wms []RollupWatermark
+	if err := r.db.WithContext(ctx).Where("name = ?", issueWatermarkName).Find(&wms).Error; err != nil {
+		return 0, fmt.Errorf("issues: read watermark: %w", err)
+	}
+	wm := RollupWatermark{Name: issueWatermarkName}
+	if len(wms) > 0 {
+		wm.Since, wm.Until = wms[0].Since.UTC(), wms[0].Until.UTC()
+	} else {
+		from := now.Add(-backfill).Truncate(time.Minute)
+		wm.Since, wm.Until = from, from
+	}
+	limit := now.Add(-issueSettleDelay).Truncate(time.Minute)
+	limit = minTime(limit, wm.Until.Add(maxIssueCatchUp))
+
+	var total int64
+	for wm.Until.Before(limit) {
+		next := minTime(wm.Until.Add(issueStep), limit)
+		n, err := r.foldIssues(ctx, wm.Until, next, RollupWatermark{Name: wm.Name, Since: wm.Since, Until: next})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		wm.Until = next
+	}
+	return total, nil
+}
+
+// foldIssues adds the errors in [from, to) to their issues and saves wm in
+// the same transaction.
+func (r *Repository) foldIssues(ctx context.Context, from, to time.Time, wm RollupWatermark) (int64, error) {
+	type key struct{ tenant, fingerprint string }
+	aggs := make(map[key]*issueAgg)
+	fold := func(tenant, service, errType, body string, ts time.Time, traceID string) {
+		msg := NormalizeErrorMessage(body)
+		fp := IssueFingerprint(service, errType, msg)
+		a := aggs[key{tenant, fp}]
+		if a == nil {
+			a = &issueAgg{Issue{TenantID: tenant, Fingerprint: fp, ServiceName: service, ErrorType: errType, Message: msg}}
+			aggs[key{tenant, fp}] = a
+		}
+		a.add(ts.UTC(), traceID, body)
+	}
+
+	db := r.db.WithContext(ctx)
+	// Each cursor is drained and closed before the next statement: SQLite
+	// runs on a single connection.
+	scan := func(what string, q *gorm.DB, row func(tenant, traceID, text, svc string, attrs CompressedText, ts time.Time)) error {
+		rows, err := q.Rows()
+		if err != nil {
+			return fmt.Errorf("issues: read %s: %w", what, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var tenant, traceID, text, svc string
+			var attrs CompressedText
+			var ts time.Time
+			if err := rows.Scan(&tenant, &traceID, &text, &svc, &attrs, &ts); err != nil {
+				return fmt.Errorf("issues: scan %s: %w", what, err)
+			}
+			row(tenant, traceID, text, svc, attrs, ts)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("issues: read %s: %w", what, err)
+		}
+		return nil
+	}
+
+	logs := db.Model(&Log{}).Select("tenant_id, trace_id, body, service_name, attributes_json, timestamp").
+		Where("timestamp >= ? AND timestamp < ? AND severity IN ?", from, to, errorSeverities)
+	if err := scan("logs", logs, func(tenant, traceID, body, svc string, attrs CompressedText, ts time.Time) {
+		fold(tenant, svc, errorTypeFromAttributes(string(attrs)), body, ts, traceID)
+	}); err != nil {
+		return 0, err
+	}
+
+	// The OTLP receiver logs every failed span; spans from receivers that do
+	// not (and spans whose log a severity filter dropped) are counted under
+	// their operation.
+	spans := db.Model(&Span{}).Select("tenant_id, trace_id, operation_name, service_name, attributes_json, start_time").
+		Where("start_time >= ? AND start_time < ? AND status = ?", from, to, spanStatusError).
+		Where("NOT EXISTS (SELECT 1 FROM logs WHERE logs.tenant_id = spans.tenant_id AND logs.trace_id = spans.trace_id AND logs.span_id = spans.span_id AND logs.severity IN ?)", errorSeverities)
+	if err := scan("spans", spans, func(tenant, traceID, op, svc string, attrs CompressedText, ts time.Time) {
+		fold(tenant, svc, errorTypeFromAttributes(string(attrs)), op+" failed", ts, traceID)
+	}); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, a := range aggs {
+			n += a.Occurrences
+			var found []Issue
+			if err := tx.Where("tenant_id = ? AND fingerprint = ?", a.TenantID, a.Fingerprint).Limit(1).Find(&found).Error; err != nil {
+				return err
+			}
+			if len(found) == 0 {
+				if err := tx.Create(&a.Issue).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			existing := found[0]
+			updates := map[string]any{"occurrences": gorm.Expr("occurrences + ?", a.Occurrences)}
+			if a.FirstSeen.Before(existing.FirstSeen) {
+				updates["first_seen"] = a.FirstSeen
+			}
+			if !a.LastSeen.Before(existing.LastSeen) {
+				updates["last_seen"] = a.LastSeen
+				updates["sample_message"] = a.SampleMessage
+				updates["sample_trace_id"] = a.SampleTraceID
+			}
+			if err := tx.Model(&Issue{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&wm).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("issues: fold [%s, %s): %w", from.Format(time.RFC3339), to.Format(time.RFC3339), err)
+	}
+	return n, nil
+}
+
+// ListIssues returns the issues of the tenant on ctx with their triage
+// status, ordered by q.Sort.
+func (r *Repository) ListIssues(ctx context.Context, q IssueQuery) (*IssueList, error) {
+	if q.Sort == "" {
+		q.Sort = IssueSortLastSeen
+	}
+	if !ValidIssueSort(q.Sort) {
+		return nil, fmt.Errorf("unknown issue sort %q", q.Sort)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultIssueLimit
+	}
+	q.Limit = min(q.Limit, maxIssueLimit)
+
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", TenantFromContext(ctx))
+	if len(q.ServiceNames) > 0 {
+		query = query.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+	order := "last_seen DESC"
+	switch q.Sort {
+	case IssueSortFirstSeen:
+		order = "first_seen DESC"
+	case IssueSortOccurrences:
+		order = "occurrences DESC, last_seen DESC"
+	}
+	var rows []Issue
+	if err := query.Order(order).Limit(issueListScanCap).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+	if err := r.applyIssueTriage(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	out := &IssueList{Issues: make([]Issue, 0, min(len(rows), q.Limit)), Truncated: len(rows) == issueListScanCap}
+	for _, is := range rows {
+		if q.Status != "" && is.Status != q.Status {
+			continue
+		}
+		out.Total++
+		if len(out.Issues) < q.Limit {
+			out.Issues = append(out.Issues, is)
+		}
+	}
+	return out, nil
+}
+
+// GetIssue returns one issue of the tenant on ctx by fingerprint, with its
+// triage status. gorm.ErrRecordNotFound when there is none.
+func (r *Repository) GetIssue(ctx context.Context, fingerprint string) (*Issue, error) {
+	var is Issue
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND fingerprint = ?", TenantFromContext(ctx), fingerprint).
+		Take(&is).Error; err != nil {
+		return nil, err
+	}
+	rows := []Issue{is}
+	if err := r.applyIssueTriage(ctx, rows); err != nil {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// applyIssueTriage fills Status and Assignee from the issues' triage states.
+func (r *Repository) applyIssueTriage(ctx context.Context, issues []Issue) error {
+	fingerprints := make([]string, len(issues))
+	for i, is := range issues {
+		fingerprints[i] = is.Fingerprint
+	}
+	states, err := r.TriageStates(ctx, TriageKindIssue, fingerprints)
+	if err != nil {
+		return err
+	}
+	for i := range issues {
+		is := &issues[i]
+		if st, ok := states[is.Fingerprint]; ok {
+			is.Status, is.Assignee = st.EffectiveStatus(is.LastSeen), st.Assignee
+		} else {
+			is.Status = TriageStatusOpen
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAdvanceIssues_FoldsLogsAndSpans(t *testing.T) {
+	repo := newTestRepo(t)
+	// Resolving stamps the wall clock, so the recurrence must be after it.
+	now := time.Now().UTC().Truncate(time.Minute)
+	ioErr := CompressedText(`[{"key":"exception.type","value":{"Value":{"StringValue":"IOError"}}}]`)
+
+	if err := repo.BatchCreateLogs([]Log{
+		{TenantID: "t1", TraceID: "tr1", SpanID: "s1", Severity: "ERROR", ServiceName: "api", Body: "read failed after 10ms", AttributesJSON: ioErr, Timestamp: now.Add(-50 * time.Minute)},
+		{TenantID: "t1", TraceID: "tr2", SpanID: "s2", Severity: "ERROR", ServiceName: "api", Body: "read failed after 250ms", AttributesJSON: ioErr, Timestamp: now.Add(-20 * time.Minute)},
+		{TenantID: "t1", TraceID: "tr3", Severity: "FATAL", ServiceName: "db", Body: "disk full", Timestamp: now.Add(-30 * time.Minute)},
+		{TenantID: "t2", TraceID: "tr4", Severity: "ERROR", ServiceName: "api", Body: "read failed after 1ms", AttributesJSON: ioErr, Timestamp: now.Add(-30 * time.Minute)},
+		{TenantID: "t1", Severity: "INFO", ServiceName: "api", Body: "ok", Timestamp: now.Add(-30 * time.Minute)},
+		// Not settled yet.
+		{TenantID: "t1", TraceID: "tr5", Severity: "ERROR", ServiceName: "api", Body: "read failed after 3ms", AttributesJSON: ioErr, Timestamp: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+	span := func(trace, id, op string, at time.Time) Span {
+		sp := Span{TenantID: "t1", TraceID: trace, SpanID: id, OperationName: op, ServiceName: "api", Status: spanStatusError}
+		sp.SetTiming(at, at.Add(time.Millisecond))
+		return sp
+	}
+	if err := repo.BatchCreateSpans([]Span{
+		span("tr1", "s1", "GET /read", now.Add(-50*time.Minute)), // has an error log
+		span("tr6", "s6", "GET /orders/17", now.Add(-40*time.Minute)),
+		span("tr7", "s7", "GET /orders/18", now.Add(-10*time.Minute)),
+	}); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+
+	ctx := context.Background()
+	n, err := repo.AdvanceIssues(ctx, now, time.Hour)
+	if err != nil || n != 6 {
+		t.Fatalf("AdvanceIssues = %d, %v; want 6 folded", n, err)
+	}
+	if n, err := repo.AdvanceIssues(ctx, now, time.Hour); err != nil || n != 0 {
+		t.Fatalf("second pass = %d, %v; want nothing new", n, err)
+	}
+
+	t1 := WithTenantContext(ctx, "t1")
+	list, err := repo.ListIssues(t1, IssueQuery{})
+	if err != nil {
+		t.Fatalf("ListIssues: %v", err)
+	}
+	if list.Total != 3 || list.Issues[0].Message != "GET /orders/<n> failed" {
+		t.Fatalf("issues = %+v, want orders, read and disk, latest first", list.Issues)
+	}
+	byMessage := make(map[string]Issue)
+	for _, is := range list.Issues {
+		byMessage[is.Message] = is
+	}
+	read := byMessage["read failed after <n>ms"]
+	if read.Occurrences != 2 || read.ErrorType != "IOError" ||
+		read.SampleMessage != "read failed after 250ms" || read.SampleTraceID != "tr2" ||
+		!read.FirstSeen.Equal(now.Add(-50*time.Minute)) || !read.LastSeen.Equal(now.Add(-20*time.Minute)) || read.Status != TriageStatusOpen {
+		t.Errorf("read issue = %+v", read)
+	}
+	orders := byMessage["GET /orders/<n> failed"]
+	if orders.Occurrences != 2 || orders.ErrorType != unknownErrorType || orders.SampleTraceID != "tr7" {
+		t.Errorf("span issue = %+v", orders)
+	}
+
+	// New occurrences extend the issue; a resolved issue that recurs reopens.
+	if _, err := repo.ApplyTriage(t1, TriageKindIssue, []string{read.Fingerprint}, TriageAction{Action: TriageActionResolve}); err != nil {
+		t.Fatalf("ApplyTriage: %v", err)
+	}
+	if got, err := repo.ListIssues(t1, IssueQuery{Status: TriageStatusResolved}); err != nil || got.Total != 1 || got.Issues[0].Fingerprint != read.Fingerprint {
+		t.Fatalf("resolved filter = %+v, %v", got, err)
+	}
+	later := now.Add(30 * time.Minute)
+	if err := repo.BatchCreateLogs([]Log{{TenantID: "t1", TraceID: "tr8", Severity: "ERROR", ServiceName: "api", Body: "read failed after 99ms", AttributesJSON: ioErr, Timestamp: later}}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+	if n, err := repo.AdvanceIssues(ctx, later.Add(5*time.Minute), time.Hour); err != nil || n != 2 {
+		t.Fatalf("AdvanceIssues = %d, %v; want the settled tr5 and tr8", n, err)
+	}
+	got, err := repo.GetIssue(t1, read.Fingerprint)
+	if err != nil {
+		t.Fatalf("GetIssue: %v", err)
+	}
+	if got.Occurrences != 4 || !got.LastSeen.Equal(later) || got.SampleTraceID != "tr8" || got.Status != TriageStatusOpen {
+		t.Errorf("after recurrence = %+v", got)
+	}
+
+	if _, err := repo.GetIssue(WithTenantContext(ctx, "t2"), orders.Fingerprint); err == nil {
+		t.Error("t2 sees a t1 issue")
+	}
+}
+
+func TestIssueAgg_SampleMessageRuneBoundary(t *testing.T) {
+	var a issueAgg
+	body := strings.Repeat("ошибка ", 400) // byte 4096 is mid-rune
+	a.add(time.Now(), "tr1", body)
+	if got := a.SampleMessage; !utf8.ValidString(got) || len(got) > issueSampleMaxLen || len(got) < issueSampleMaxLen-3 {
+		t.Errorf("sample truncated to %d bytes, valid UTF-8 = %v", len(got), utf8.ValidString(got))
+	}
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// latencyQuantiles are the trace duration percentiles the dashboard reports,
+// in LatencyPercentiles field order.
+var latencyQuantiles = [...]float64{0.50, 0.75, 0.90, 0.95, 0.99}
+
+// LatencyPercentiles are trace duration percentiles in microseconds.
+type LatencyPercentiles struct {
+	P50 int64 `json:"p50"`
+	P75 int64 `json:"p75"`
+	P90 int64 `json:"p90"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+}
+
+func latencyPercentilesOf(v [len(latencyQuantiles)]int64) LatencyPercentiles {
+	return LatencyPercentiles{P50: v[0], P75: v[1], P90: v[2], P95: v[3], P99: v[4]}
+}
+
+// ServiceLatency is one service's trace count and duration percentiles.
+type ServiceLatency struct {
+	ServiceName string `json:"service_name"`
+	Count       int64  `json:"count"`
+	LatencyPercentiles
+	// Deployments in the range, filled by AddLatencyDeployments.
+	Deployments []DeploymentMarker `json:"deployments,omitempty"`
+}
+
+// GetServiceLatencyPercentiles returns trace duration percentiles per
+// service for the tenant on ctx, slowest P99 first. Accuracy follows
+// durationPercentiles.
+func (r *Repository) GetServiceLatencyPercentiles(ctx context.Context, start, end time.Time, serviceNames []string) ([]ServiceLatency, error) {
+	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRaw, "")
+	q := r.db.WithContext(ctx).Model(&Trace{}).Where(sqlWhereTenantTimeBetween, tenant, start, end)
+	if len(serviceNames) > 0 {
+		q = q.Where(sqlWhereServiceIn, serviceNames)
+	}
+	out, err := r.durationPercentiles(ctx, q, "service_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute service latency percentiles: %w", err)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].P99 != out[j].P99 {
+			return out[i].P99 > out[j].P99
+		}
+		return out[i].ServiceName < out[j].ServiceName
+	})
+	return out, nil
+}
+
+// durationPercentiles computes the latencyQuantiles of duration over the
+// rows matched by session (a Model(&Trace{}) or Model(&Span{}) query), one
+// entry per value of the groupBy column, carried in ServiceName, or a single
+// unnamed entry when groupBy is empty (Count 0 when nothing matched). The
+// work stays in the database where it can:
+//
+//   - postgres: exact, interpolated percentile_cont.
+//   - mysql, sqlserver, sqlite: the database groups durations into the
+//     rollups' log-scale buckets and the percentile is the largest duration
+//     in the bucket holding its rank, at most 10% above the exact value.
+//   - if that query fails (a build without math functions, an unknown
+//     driver), durations are streamed through a t-digest, bounded in memory
+//     however many rows match.
+func (r *Repository) durationPercentiles(ctx context.Context, session *gorm.DB, groupBy string) ([]ServiceLatency, error) {
+	driver := strings.ToLower(r.driver)
+	if driver == "postgres" || driver == "postgresql" {
+		return percentileCont(ctx, session, groupBy)
+	}
+	out, err := percentileHistogram(ctx, session, driver, groupBy)
+	if err == nil || ctx.Err() != nil {
+		return out, err
+	}
+	slog.Debug("Histogram percentiles failed, streaming durations through a t-digest", "driver", r.driver, "error", err)
+	return percentileTDigest(ctx, session, groupBy)
+}
+
+// percentileCont is durationPercentiles on Postgres.
+func percentileCont(ctx context.Context, session *gorm.DB, groupBy string) ([]ServiceLatency, error) {
+	cols := []string{"COUNT(*) AS count"}
+	for i, q := range latencyQuantiles {
+		cols = append(cols, fmt.Sprintf("COALESCE(percentile_cont(%g) WITHIN GROUP (ORDER BY duration), 0) AS q%d", q, i))
+	}
+	q := session.Session(&gorm.Session{Context: ctx})
+	if groupBy != "" {
+		q = q.Select(groupBy + " AS service_name, " + strings.Join(cols, ", ")).Group(groupBy)
+	} else {
+		q = q.Select(strings.Join(cols, ", "))
+	}
+	var rows []struct {
+		ServiceName        string
+		Count              int64
+		Q0, Q1, Q2, Q3, Q4 float64
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make([]ServiceLatency, 0, len(rows))
+	for _, row := range rows {
+		var v [len(latencyQuantiles)]int64
+		for i, f := range []float64{row.Q0, row.Q1, row.Q2, row.Q3, row.Q4} {
+			v[i] = int64(math.Round(f))
+		}
+		out = append(out, ServiceLatency{ServiceName: row.ServiceName, Count: row.Count, LatencyPercentiles: latencyPercentilesOf(v)})
+	}
+	if groupBy == "" && len(out) == 0 {
+		out = append(out, ServiceLatency{})
+	}
+	return out, nil
+}
+
+// percentileHistogram is durationPercentiles on the databases without an
+// ordered-set percentile aggregate.
+func percentileHistogram(ctx context.Context, session *gorm.DB, driver, groupBy string) ([]ServiceLatency, error) {
+	ln, ceil := "LN", "CEIL"
+	if driver == "sqlserver" {
+		ln, ceil = "LOG", "CEILING"
+	}
+	bucket := fmt.Sprintf("CASE WHEN duration <= 1 THEN 0 ELSE %s(%s(duration) / %s(%g)) END", ceil, ln, ln, rollupHistogramBase)
+	q := session.Session(&gorm.Session{Context: ctx})
+	if groupBy != "" {
+		q = q.Select(groupBy + " AS service_name, " + bucket + " AS bucket, COUNT(*) AS n, MAX(duration) AS max_duration").Group(groupBy + ", " + bucket)
+	} else {
+		q = q.Select(bucket + " AS bucket, COUNT(*) AS n, MAX(duration) AS max_duration").Group(bucket)
+	}
+	var rows []struct {
+		ServiceName string
+		Bucket      float64
+		N           int64
+		MaxDuration int64
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	type bucketCount struct {
+		bucket   float64
+		n, maxUs int64
+	}
+	byName := make(map[string][]bucketCount)
+	var names []string
+	for _, row := range rows {
+		if _, ok := byName[row.ServiceName]; !ok {
+			names = append(names, row.ServiceName)
+		}
+		byName[row.ServiceName] = append(byName[row.ServiceName], bucketCount{row.Bucket, row.N, row.MaxDuration})
+	}
+	out := make([]ServiceLatency, 0, len(names))
+	for _, name := range names {
+		buckets := byName[name]
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].bucket < buckets[j].bucket })
+		var total int64
+		for _, b := range buckets {
+			total += b.n
+		}
+		var v [len(latencyQuantiles)]int64
+		for i, q := range latencyQuantiles {
+			rank := max(int64(math.Ceil(float64(total)*q)), 1)
+			var seen int64
+			for _, b := range buckets {
+				if seen += b.n; seen >= rank {
+					v[i] = b.maxUs
+					break
+				}
+			}
+		}
+		out = append(out, ServiceLatency{ServiceName: name, Count: total, LatencyPercentiles: latencyPercentilesOf(v)})
+	}
+	if groupBy == "" && len(out) == 0 {
+		out = append(out, ServiceLatency{})
+	}
+	return out, nil
+}
+
+// percentileTDigest is the durationPercentiles fallback.
+func percentileTDigest(ctx context.Context, session *gorm.DB, groupBy string) ([]ServiceLatency, error) {
+	cols := "duration"
+	if groupBy != "" {
+		cols = groupBy + ", duration"
+	}
+	rows, err := session.Session(&gorm.Session{Context: ctx}).Select(cols).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	digests := make(map[string]*tdigest)
+	var names []string
+	for rows.Next() {
+		var name string
+		var us int64
+		if groupBy != "" {
+			err = rows.Scan(&name, &us)
+		} else {
+			err = rows.Scan(&us)
+		}
+		if err != nil {
+			return nil, err
+		}
+		d := digests[name]
+		if d == nil {
+			d = newTDigest(tdigestCompression)
+			digests[name] = d
+			names = append(names, name)
+		}
+		d.add(float64(us))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]ServiceLatency, 0, len(names))
+	for _, name := range names {
+		d := digests[name]
+		var v [len(latencyQuantiles)]int64
+		for i, q := range latencyQuantiles {
+			v[i] = int64(math.Round(d.quantile(q)))
+		}
+		out = append(out, ServiceLatency{ServiceName: name, Count: int64(d.count), LatencyPercentiles: latencyPercentilesOf(v)})
+	}
+	if groupBy == "" && len(out) == 0 {
+		out = append(out, ServiceLatency{})
+	}
+	return out, nil
+}
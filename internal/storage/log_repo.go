@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
@@ -34,9 +36,13 @@ func (r *Repository) BatchCreateLogs(logs []Log) error {
 	if len(logs) == 0 {
 		return nil
 	}
-	if err := r.db.CreateInBatches(logs, 500).Error; err != nil {
+	stampRegion(logs, r.region, func(l *Log) *string { return &l.Region })
+	if err := r.logsInsert(r.db).CreateInBatches(logs, 500).Error; err != nil {
 		return fmt.Errorf("failed to batch create logs: %w", err)
 	}
+	if err := createSpanExceptionsIdempotent(r.db, r.driver, spanExceptionRows(logs)); err != nil {
+		return fmt.Errorf("failed to materialize span exceptions: %w", err)
+	}
 	return nil
 }
 
@@ -63,40 +69,48 @@ func (r *Repository) GetRecentLogs(ctx context.Context, limit int) ([]Log, error
 // GetLogsV2 performs advanced filtering and search on logs scoped to the
 // tenant on ctx. COUNT and SELECT run in parallel via errgroup for reduced latency.
 //
-// When `filter.Search` is set and the driver is SQLite, the query routes
-// through the FTS5 virtual table (`logs_fts`) and results are ordered by BM25
-// relevance. Other drivers continue to use LIKE/ILIKE against logs.body and
-// logs.trace_id.
+// When `filter.Search` is set and LOG_FTS_ENABLED is on, the query uses the
+// driver's full-text index — the FTS5 virtual table (`logs_fts`) on SQLite,
+// the tsvector GIN index on Postgres — and results are ordered by relevance.
+// Otherwise search uses LIKE/ILIKE against logs.body and logs.trace_id.
 func (r *Repository) GetLogsV2(ctx context.Context, filter LogFilter) ([]Log, int64, error) {
 	tenant := TenantFromContext(ctx)
+	defer r.observeLogFilter(filter, time.Now())
 	var logs []Log
 	var total int64
 
-	useFTS5 := filter.Search != "" && fts5Available(r.driver)
-	matchExpr := ""
-	if useFTS5 {
-		matchExpr = fts5MatchExpr(filter.Search)
-		if matchExpr == "" {
-			useFTS5 = false
-		}
+	var fts logFTSQuery
+	useFTS := false
+	if filter.Search != "" {
+		fts, useFTS = r.logFTS(filter.Search)
+	}
+	switch {
+	case useFTS:
+		explainResolution(ctx, ResolutionRaw, "full-text search")
+	case filter.Search != "":
+		explainResolution(ctx, ResolutionRaw, "substring search (LIKE)")
+	default:
+		explainResolution(ctx, ResolutionRaw, "")
 	}
 
-	base := r.db.WithContext(ctx).Model(&Log{}).Where(sqlWhereTenantID, tenant)
-	if useFTS5 {
-		base = base.Joins("JOIN "+fts5LogsTable+" ON logs.id = "+fts5LogsTable+".rowid").
-			Where(fts5LogsTable+" MATCH ?", matchExpr)
+	base := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Log{}).Where(sqlWhereTenantID, tenant))
+	if useFTS {
+		if fts.join != "" {
+			base = base.Joins(fts.join)
+		}
+		base = base.Where(fts.where, fts.arg)
 	}
 
 	base = applyLogFilterCriteria(base, filter)
-	if filter.Search != "" && !useFTS5 {
+	if filter.Search != "" && !useFTS {
 		search := "%" + filter.Search + "%"
 		op := r.likeOp()
 		base = base.Where(fmt.Sprintf("body %s ? OR trace_id %s ?", op, op), search, search)
 	}
 
 	orderBy := sqlOrderTimestampDesc
-	if useFTS5 {
-		orderBy = "bm25(" + fts5LogsTable + ") ASC"
+	if useFTS {
+		orderBy = fts.order
 	}
 
 	// Run COUNT and SELECT in parallel using independent sessions.
@@ -112,11 +126,11 @@ func (r *Repository) GetLogsV2(ctx context.Context, filter LogFilter) ([]Log, in
 			Find(&logs).Error
 	})
 	if err := g.Wait(); err != nil {
-		if useFTS5 {
-			// Same rationale as searchLogsFTS5: FTS5 query error keeps the
-			// API available via LIKE, but we log loudly so the operator
+		if useFTS {
+			// Same rationale as searchLogsFTS: an index query error keeps
+			// the API available via LIKE, but we log loudly so the operator
 			// can rebuild the index instead of leaving the seatbelt on.
-			slog.Warn("FTS5 GetLogsV2 failed, falling back to LIKE", "tenant", tenant, "search", filter.Search, "error", err)
+			slog.Warn("full-text GetLogsV2 failed, falling back to LIKE", "driver", r.driver, "tenant", tenant, "search", filter.Search, "error", err)
 			return r.getLogsV2LikeFallback(ctx, filter, tenant)
 		}
 		return nil, 0, fmt.Errorf("failed to fetch logs: %w", err)
@@ -125,9 +139,29 @@ func (r *Repository) GetLogsV2(ctx context.Context, filter LogFilter) ([]Log, in
 	return logs, total, nil
 }
 
+// observeLogFilter records the index-relevant shape of a GetLogsV2 query.
+// Search is left out: it is served by the full-text index, not a B-tree.
+func (r *Repository) observeLogFilter(filter LogFilter, started time.Time) {
+	var eq []string
+	if filter.ServiceName != "" {
+		eq = append(eq, "service_name")
+	}
+	if filter.Severity != "" {
+		eq = append(eq, "severity")
+	}
+	if filter.TraceID != "" {
+		eq = append(eq, "trace_id")
+	}
+	rng := ""
+	if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+		rng = "timestamp"
+	}
+	r.observeFilter("logs", eq, rng, time.Since(started))
+}
+
 // applyLogFilterCriteria appends the non-search WHERE clauses that are common
 // to GetLogsV2 and its LIKE fallback. The Search clause is intentionally NOT
-// applied here — the two callers handle it differently (FTS5 MATCH vs LIKE).
+// applied here — the two callers handle it differently (full-text vs LIKE).
 func applyLogFilterCriteria(base *gorm.DB, filter LogFilter) *gorm.DB {
 	if filter.ServiceName != "" {
 		base = base.Where("service_name = ?", filter.ServiceName)
@@ -136,7 +170,7 @@ func applyLogFilterCriteria(base *gorm.DB, filter LogFilter) *gorm.DB {
 		base = base.Where(sqlWhereSeverity, filter.Severity)
 	}
 	if filter.TraceID != "" {
-		base = base.Where("trace_id = ?", filter.TraceID)
+		base = base.Where("trace_id IN ?", traceid.Variants(filter.TraceID))
 	}
 	if !filter.StartTime.IsZero() {
 		base = base.Where(sqlWhereTimestampGTE, filter.StartTime)
@@ -147,13 +181,42 @@ func applyLogFilterCriteria(base *gorm.DB, filter LogFilter) *gorm.DB {
 	return base
 }
 
+// Matches reports whether l passes filter in memory, with the same rules as
+// the SQL path (search is a case-insensitive substring of body or trace ID).
+// It is used for logs not yet in the database, such as ones queued in the DLQ.
+func (f LogFilter) Matches(l *Log) bool {
+	if f.ServiceName != "" && l.ServiceName != f.ServiceName {
+		return false
+	}
+	if f.Severity != "" && l.Severity != f.Severity {
+		return false
+	}
+	if f.TraceID != "" && !slices.Contains(traceid.Variants(f.TraceID), l.TraceID) {
+		return false
+	}
+	if !f.StartTime.IsZero() && l.Timestamp.Before(f.StartTime) {
+		return false
+	}
+	if !f.EndTime.IsZero() && l.Timestamp.After(f.EndTime) {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(l.Body), search) && !strings.Contains(strings.ToLower(l.TraceID), search) {
+			return false
+		}
+	}
+	return true
+}
+
 // getLogsV2LikeFallback re-runs the query using LIKE against body/trace_id —
-// used when the FTS5 path errors out so the API never serves a 500 because of
+// used when the full-text path errors out so the API never serves a 500 because of
 // an index-layer hiccup.
 func (r *Repository) getLogsV2LikeFallback(ctx context.Context, filter LogFilter, tenant string) ([]Log, int64, error) {
 	var logs []Log
 	var total int64
-	base := r.db.WithContext(ctx).Model(&Log{}).Where(sqlWhereTenantID, tenant)
+	explainResolution(ctx, ResolutionRaw, "substring search (LIKE) after full-text search failed")
+	base := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Log{}).Where(sqlWhereTenantID, tenant))
 	base = applyLogFilterCriteria(base, filter)
 	if filter.Search != "" {
 		search := "%" + filter.Search + "%"
@@ -193,17 +256,30 @@ func (r *Repository) GetLogContext(ctx context.Context, targetTime time.Time) ([
 // log belonging to another tenant gets ErrLogNotFoundOrWrongTenant (IDOR fix).
 func (r *Repository) UpdateLogInsight(ctx context.Context, logID uint, insight string) error {
 	tenant := TenantFromContext(ctx)
-	result := r.db.WithContext(ctx).
-		Model(&Log{}).
-		Where("id = ? AND tenant_id = ?", logID, tenant).
-		Update("ai_insight", insight)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update log insight: %w", result.Error)
+	tables := []string{r.logsTable()}
+	if r.LogsSharded() {
+		// The view is read-only; the row may live in any attached shard.
+		shards, err := attachedLogShards(r.db.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to update log insight: %w", err)
+		}
+		for schema := range shards {
+			tables = append(tables, schema+".logs")
+		}
 	}
-	if result.RowsAffected == 0 {
-		return ErrLogNotFoundOrWrongTenant
+	for _, table := range tables {
+		result := r.db.WithContext(ctx).
+			Table(table).
+			Where("id = ? AND tenant_id = ?", logID, tenant).
+			Update("ai_insight", insight)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update log insight: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
 	}
-	return nil
+	return ErrLogNotFoundOrWrongTenant
 }
 
 // LogsForVectorReplay returns ERROR/WARN-family logs with id > sinceID,
@@ -234,6 +310,27 @@ func (r *Repository) LogsForVectorReplay(ctx context.Context, sinceID uint, limi
 	return logs, nil
 }
 
+// LogsForPatternMining returns up to limit logs in [start, end], newest
+// first, scoped to the tenant and region on ctx. Only the columns the log
+// pattern miner reads are selected. serviceNames and severity are optional.
+func (r *Repository) LogsForPatternMining(ctx context.Context, start, end time.Time, serviceNames []string, severity string, limit int) ([]Log, error) {
+	tenant := TenantFromContext(ctx)
+	q := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Log{}).
+		Select("service_name, severity, body, timestamp").
+		Where(sqlWhereTenantTimeBetween, tenant, start, end))
+	if len(serviceNames) > 0 {
+		q = q.Where(sqlWhereServiceIn, serviceNames)
+	}
+	if severity != "" {
+		q = q.Where(sqlWhereSeverity, severity)
+	}
+	var logs []Log
+	if err := q.Order(sqlOrderTimestampDesc).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch logs for pattern mining: %w", err)
+	}
+	return logs, nil
+}
+
 // ListRecentHighSeverityLogsAllTenants returns recent logs of the given
 // severity across EVERY tenant, each row carrying its own TenantID. This is an
 // administrative read used exclusively by the vector index's startup
@@ -261,15 +358,19 @@ func (r *Repository) ListRecentHighSeverityLogsAllTenants(ctx context.Context, s
 	return logs, nil
 }
 
-// PurgeLogs deletes logs older than the given timestamp in a single statement.
-// Suitable for SQLite; for Postgres at large retention volumes prefer PurgeLogsBatched.
-func (r *Repository) PurgeLogs(olderThan time.Time) (int64, error) {
-	result := r.db.Where("timestamp < ?", olderThan).Delete(&Log{})
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to purge logs: %w", result.Error)
-	}
-	slog.Info("Logs purged", "count", result.RowsAffected, "cutoff", olderThan)
-	return result.RowsAffected, nil
+// PurgeLogs deletes logs older than the given timestamp in a single statement,
+// recording the deletion as an admin purge. Suitable for SQLite; for
+// Postgres at large retention volumes prefer PurgeLogsBatched.
+func (r *Repository) PurgeLogs(ctx context.Context, olderThan time.Time) (int64, error) {
+	n, err := r.auditedPurge(ctx, DeletionSignalLogs, DeletionReasonAdminPurge, olderThan, purgeScope{}, func() (int64, error) {
+		result := r.db.WithContext(ctx).Table(r.logsTable()).Where("timestamp < ?", olderThan).Delete(&Log{})
+		return result.RowsAffected, result.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge logs: %w", err)
+	}
+	slog.Info("Logs purged", "count", n, "cutoff", olderThan)
+	return n, nil
 }
 
 // PurgeLogsBatched deletes logs in bounded chunks to avoid long locks and bloat on Postgres/MySQL.
@@ -279,28 +380,37 @@ func (r *Repository) PurgeLogs(olderThan time.Time) (int64, error) {
 // does NOT filter by tenant. All rows older than olderThan are purged across
 // every tenant. Never expose this on a tenant-scoped API surface.
 func (r *Repository) PurgeLogsBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration) (int64, error) {
+	return r.purgeLogsBatched(ctx, olderThan, batchSize, sleep, purgeScope{})
+}
+
+// purgeLogsBatched is PurgeLogsBatched narrowed to scope.
+func (r *Repository) purgeLogsBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration, scope purgeScope) (int64, error) {
 	if batchSize <= 0 {
 		batchSize = 10_000
 	}
+	tenantSQL, tenantArgs := scope.clause()
 	driver := strings.ToLower(r.driver)
 	if driver == "sqlite" || driver == "" {
-		result := r.db.WithContext(ctx).Where("timestamp < ?", olderThan).Delete(&Log{})
+		result := r.db.WithContext(ctx).Table(r.logsTable()).Where("timestamp < ?"+tenantSQL, append([]any{olderThan}, tenantArgs...)...).Delete(&Log{})
 		return result.RowsAffected, result.Error
 	}
 
 	var total int64
+	var batches int
 	for {
 		if err := ctx.Err(); err != nil {
 			return total, err
 		}
 		result := r.db.WithContext(ctx).Exec(
-			"DELETE FROM logs WHERE id IN (SELECT id FROM logs WHERE timestamp < ? ORDER BY id LIMIT ?)",
-			olderThan, batchSize,
+			"DELETE FROM logs WHERE id IN (SELECT id FROM logs WHERE timestamp < ?"+tenantSQL+" ORDER BY id LIMIT ?)",
+			scope.args(olderThan, tenantArgs, batchSize)...,
 		)
 		if result.Error != nil {
 			return total, fmt.Errorf("batched purge logs: %w", result.Error)
 		}
 		total += result.RowsAffected
+		batches++
+		logPurgeProgress("logs", batches, total)
 		if result.RowsAffected < int64(batchSize) {
 			return total, nil
 		}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -12,36 +13,24 @@ import (
 // Step 1: dialect-dispatch tests (DryRun + real SQLite)
 // ---------------------------------------------------------------------------
 
-// TestP99_SQLite_DispatchLimit verifies that the SQLite path issues a query
-// with Limit(sqliteP99RowCap+1). We use a real in-memory SQLite DB with a
-// DryRun session to capture the generated SQL.
-func TestP99_SQLite_DispatchLimit(t *testing.T) {
+// TestP99_SQLite_Empty verifies the histogram path returns a zero entry
+// when nothing matches.
+func TestP99_SQLite_Empty(t *testing.T) {
 	repo := newTestRepo(t)
-
-	// Build a session identical to how GetDashboardStats passes it:
-	// Model(&Trace{}) + Where clause + Session(&gorm.Session{}).
 	baseQuery := repo.db.Model(&Trace{}).Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", "default", time.Now().Add(-time.Hour), time.Now())
-	session := baseQuery.Session(&gorm.Session{DryRun: true})
-
-	// We need a variant of the helper that returns the statement instead of executing.
-	// Since we can't easily intercept a live query without executing it, we verify
-	// the cap constant and the helper runs without error on a real (empty) DB.
-	//
-	// The real cap-and-limit behaviour is covered by the large-data test below.
-	// Here we verify the helper is callable and returns (0, nil) for an empty DB.
-	p99, err := repo.p99DurationForQuery(context.Background(), session)
+
+	got, err := repo.durationPercentiles(context.Background(), baseQuery.Session(&gorm.Session{}), "")
 	if err != nil {
-		t.Fatalf("p99DurationForQuery (sqlite, empty): %v", err)
+		t.Fatalf("durationPercentiles (sqlite, empty): %v", err)
 	}
-	if p99 != 0 {
-		t.Fatalf("want 0 for empty DB, got %d", p99)
+	if len(got) != 1 || !reflect.DeepEqual(got[0], ServiceLatency{}) {
+		t.Fatalf("want one zero entry for empty DB, got %+v", got)
 	}
 }
 
-// TestP99_MySQL_Dispatch verifies that swapping r.driver to "mysql" causes the
-// helper to take the MySQL two-query path. We use the SQLite engine underneath
-// (same SQL is compatible for COUNT+OFFSET) to verify it doesn't panic and
-// returns a sane value.
+// TestP99_MySQL_Dispatch verifies that swapping r.driver to "mysql" takes the
+// SQL histogram path. We use the SQLite engine underneath (LN and CEIL are
+// available in both) to verify it returns a sane value.
 func TestP99_MySQL_Dispatch(t *testing.T) {
 	repo := newTestRepo(t)
 	repo.driver = "mysql" // force MySQL path on SQLite engine (SQL is compatible)
@@ -55,18 +44,18 @@ func TestP99_MySQL_Dispatch(t *testing.T) {
 	baseQuery := repo.db.Model(&Trace{}).Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", "default", now.Add(-time.Hour), now.Add(time.Hour))
 	session := baseQuery.Session(&gorm.Session{})
 
-	p99, err := repo.p99DurationForQuery(context.Background(), session)
+	got, err := repo.durationPercentiles(context.Background(), session, "")
 	if err != nil {
-		t.Fatalf("p99DurationForQuery (mysql path): %v", err)
+		t.Fatalf("durationPercentiles (mysql path): %v", err)
 	}
-	// With 10 rows of duration 1000..10000 (step 1000), p99 index = ceil(10*0.99)-1 = 9
-	// → duration = 10000.
-	if p99 != 10000 {
-		t.Fatalf("want 10000 (p99 of 10 sorted rows), got %d", p99)
+	// With 10 rows of duration 1000..10000 (step 1000), the p99 rank is
+	// ceil(10*0.99) = 10 → the bucket holding 10000, whose max is 10000.
+	if got[0].Count != 10 || got[0].P99 != 10000 {
+		t.Fatalf("want p99 10000 of 10 rows, got %+v", got[0])
 	}
 }
 
-// TestP99_MySQL_EmptyTable ensures the MySQL path returns (0, nil) on empty.
+// TestP99_MySQL_EmptyTable ensures the MySQL path returns zeros on empty.
 func TestP99_MySQL_EmptyTable(t *testing.T) {
 	repo := newTestRepo(t)
 	repo.driver = "mysql"
@@ -74,12 +63,12 @@ func TestP99_MySQL_EmptyTable(t *testing.T) {
 	baseQuery := repo.db.Model(&Trace{}).Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", "default", time.Now().Add(-time.Hour), time.Now())
 	session := baseQuery.Session(&gorm.Session{})
 
-	p99, err := repo.p99DurationForQuery(context.Background(), session)
+	got, err := repo.durationPercentiles(context.Background(), session, "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if p99 != 0 {
-		t.Fatalf("want 0 for empty, got %d", p99)
+	if got[0].P99 != 0 {
+		t.Fatalf("want 0 for empty, got %d", got[0].P99)
 	}
 }
 
@@ -104,11 +93,21 @@ func TestP99_SQLite_SmallData(t *testing.T) {
 		t.Fatalf("GetDashboardStats: %v", err)
 	}
 
-	// ceil(50*0.99) - 1 = 49 → durations[49] = 50*1000 = 50000
+	// ceil(50*0.99) = rank 50 → 50*1000 = 50000, the max of its bucket
 	want := int64(50 * 1000)
 	if stats.P99Latency != want {
 		t.Fatalf("P99Latency: want %d, got %d", want, stats.P99Latency)
 	}
+	// Lower percentiles are the max of their bucket: at most 10% above the
+	// nearest-rank value (p50 = 25000, p90 = 45000).
+	for name, c := range map[string]struct{ got, exact int64 }{
+		"p50": {stats.P50Latency, 25000},
+		"p90": {stats.P90Latency, 45000},
+	} {
+		if c.got < c.exact || float64(c.got) > float64(c.exact)*1.1 {
+			t.Errorf("%s = %d, want within 10%% above %d", name, c.got, c.exact)
+		}
+	}
 }
 
 // TestP99_SQLite_SingleRow ensures p99 of a single row is that row's value.
@@ -131,49 +130,73 @@ func TestP99_SQLite_SingleRow(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// Step 3b: Large-data / cap test
+// Step 3b: t-digest fallback and per-service breakdown
 // ---------------------------------------------------------------------------
 
-// TestP99_SQLite_CapTriggers temporarily shrinks sqliteP99RowCap and inserts
-// just over that amount, verifying the cap path is exercised:
-//  1. GetDashboardStats returns without error.
-//  2. P99Latency is non-zero.
-//  3. The call completes quickly (small dataset).
-//
-// The cap is a var specifically so this test can run under -race without
-// seeding 200k rows. Default cap remains 200_000 in production.
-func TestP99_SQLite_CapTriggers(t *testing.T) {
-	orig := sqliteP99RowCap
-	sqliteP99RowCap = 200
-	t.Cleanup(func() { sqliteP99RowCap = orig })
-
+// TestP99_TDigestFallback checks the t-digest used when the histogram SQL
+// fails stays close to the exact percentiles of 10k rows.
+func TestP99_TDigestFallback(t *testing.T) {
 	repo := newTestRepo(t)
 	now := time.Now().UTC()
-
-	insertCount := sqliteP99RowCap + 50 // just over the (shrunk) cap
-
-	batch := make([]Trace, 0, insertCount)
-	for i := 0; i < insertCount; i++ {
+	batch := make([]Trace, 0, 10_000)
+	for i := 0; i < 10_000; i++ {
 		batch = append(batch, Trace{
 			TraceID:     "t" + p99Itoa(i),
 			ServiceName: "svc",
-			Duration:    int64(i + 1),
+			Duration:    int64((i*7919)%10_000 + 1), // 1..10000, shuffled
 			Status:      "OK",
 			Timestamp:   now,
 			TenantID:    "default",
 		})
 	}
-	if err := repo.db.CreateInBatches(batch, 100).Error; err != nil {
+	if err := repo.db.CreateInBatches(batch, 500).Error; err != nil {
 		t.Fatalf("seed batch: %v", err)
 	}
+	session := repo.db.Model(&Trace{}).Where("tenant_id = ?", "default").Session(&gorm.Session{})
 
-	ctx := context.Background()
-	stats, err := repo.GetDashboardStats(ctx, now.Add(-time.Hour), now.Add(time.Hour), nil)
+	got, err := percentileTDigest(context.Background(), session, "")
 	if err != nil {
-		t.Fatalf("GetDashboardStats: %v", err)
+		t.Fatalf("percentileTDigest: %v", err)
+	}
+	p := got[0]
+	for name, c := range map[string]struct{ got, exact int64 }{
+		"p50": {p.P50, 5000}, "p90": {p.P90, 9000}, "p99": {p.P99, 9900},
+	} {
+		if d := c.got - c.exact; d < -50 || d > 50 {
+			t.Errorf("%s = %d, want %d ± 50", name, c.got, c.exact)
+		}
+	}
+	if p.Count != 10_000 {
+		t.Errorf("count = %d, want 10000", p.Count)
+	}
+}
+
+// TestGetServiceLatencyPercentiles verifies the breakdown is per service,
+// slowest first.
+func TestGetServiceLatencyPercentiles(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	var traces []Trace
+	for i := 0; i < 20; i++ {
+		svc, dur := "fast", int64(i+1)*100
+		if i%2 == 0 {
+			svc, dur = "slow", int64(i+1)*10_000
+		}
+		traces = append(traces, Trace{TraceID: "s" + p99Itoa(i), ServiceName: svc, Duration: dur, Status: "OK", Timestamp: now, TenantID: "default"})
+	}
+	if err := repo.db.Create(&traces).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	got, err := repo.GetServiceLatencyPercentiles(context.Background(), now.Add(-time.Hour), now.Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("GetServiceLatencyPercentiles: %v", err)
+	}
+	if len(got) != 2 || got[0].ServiceName != "slow" || got[1].ServiceName != "fast" {
+		t.Fatalf("breakdown = %+v, want slow then fast", got)
 	}
-	if stats.P99Latency <= 0 {
-		t.Fatalf("P99Latency should be positive, got %d", stats.P99Latency)
+	if got[0].Count != 10 || got[0].P99 != 190_000 || got[1].P99 != 2000 {
+		t.Errorf("breakdown = %+v", got)
 	}
 }
 
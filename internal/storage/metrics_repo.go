@@ -5,25 +5,23 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"sort"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// sqliteP99RowCap is the maximum number of duration rows fetched for the
-// in-memory p99 sort on SQLite. Queries returning more rows than this are
-// capped with a warning; accuracy degrades gracefully at the tail.
-// Declared as var (not const) so tests can temporarily shrink it to exercise
-// the cap path without seeding 200k rows under -race.
-var sqliteP99RowCap = 200_000
-
 // TrafficPoint represents a data point for the traffic chart.
 type TrafficPoint struct {
 	Timestamp  time.Time `json:"timestamp"`
 	Count      int64     `json:"count"`
 	ErrorCount int64     `json:"error_count"`
+	// Exemplars, filled by AddTrafficExemplars: the bucket's slowest trace
+	// and one of its error traces.
+	SlowTraceID  string `json:"slow_trace_id,omitempty"`
+	ErrorTraceID string `json:"error_trace_id,omitempty"`
+	// Deployments in the bucket, filled by AddTrafficDeployments.
+	Deployments []DeploymentMarker `json:"deployments,omitempty"`
 }
 
 // LatencyPoint represents a data point for the latency heatmap.
@@ -48,15 +46,24 @@ type DashboardStats struct {
 	AvgLatencyMs       float64        `json:"avg_latency_ms"`
 	ErrorRate          float64        `json:"error_rate"`
 	ActiveServices     int64          `json:"active_services"`
+	P50Latency         int64          `json:"p50_latency"` // trace duration percentiles, µs
+	P75Latency         int64          `json:"p75_latency"`
+	P90Latency         int64          `json:"p90_latency"`
+	P95Latency         int64          `json:"p95_latency"`
 	P99Latency         int64          `json:"p99_latency"`
 	TopFailingServices []ServiceError `json:"top_failing_services"`
 }
 
+func (s *DashboardStats) setLatencyPercentiles(p LatencyPercentiles) {
+	s.P50Latency, s.P75Latency, s.P90Latency, s.P95Latency, s.P99Latency = p.P50, p.P75, p.P90, p.P95, p.P99
+}
+
 // BatchCreateMetrics inserts aggregated metrics in batches.
 func (r *Repository) BatchCreateMetrics(buckets []MetricBucket) error {
 	if len(buckets) == 0 {
 		return nil
 	}
+	stampRegion(buckets, r.region, func(b *MetricBucket) *string { return &b.Region })
 	if err := r.db.CreateInBatches(buckets, 500).Error; err != nil {
 		return fmt.Errorf("failed to batch create metrics: %w", err)
 	}
@@ -67,6 +74,7 @@ func (r *Repository) BatchCreateMetrics(buckets []MetricBucket) error {
 // scoped to the tenant on ctx.
 func (r *Repository) GetMetricBuckets(ctx context.Context, start, end time.Time, serviceName string, metricName string) ([]MetricBucket, error) {
 	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRollup, "stored metric_buckets windows")
 	var buckets []MetricBucket
 	query := r.db.WithContext(ctx).Where("tenant_id = ? AND time_bucket BETWEEN ? AND ?", tenant, start, end)
 	if serviceName != "" {
@@ -81,6 +89,84 @@ func (r *Repository) GetMetricBuckets(ctx context.Context, start, end time.Time,
 	return buckets, nil
 }
 
+// MetricSeriesPoint is one step of a windowed metric series.
+type MetricSeriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"` // the requested aggregation
+	Min       float64   `json:"min"`
+	Max       float64   `json:"max"`
+	Sum       float64   `json:"sum"`
+	Count     int64     `json:"count"`
+	// Exemplar: the highest-value exemplar of the merged windows.
+	ExemplarTraceID string  `json:"exemplar_trace_id,omitempty"`
+	ExemplarSpanID  string  `json:"exemplar_span_id,omitempty"`
+	ExemplarValue   float64 `json:"exemplar_value,omitempty"`
+}
+
+// MetricAggregations lists the values accepted for GetMetricSeries' agg.
+var MetricAggregations = []string{"avg", "min", "max", "sum", "count"}
+
+// GetMetricSeries rolls the stored MetricBucket windows for one metric into
+// step-sized points, scoped to the tenant on ctx. Buckets across all
+// attribute sets (and services, when serviceName is empty) are merged, so
+// the result is one chartable series. agg selects Value: avg (Sum/Count,
+// the default), min, max, sum or count. step <= 0 returns the stored
+// window resolution unchanged (one point per TimeBucket).
+func (r *Repository) GetMetricSeries(ctx context.Context, start, end time.Time, serviceName, metricName string, step time.Duration, agg string) ([]MetricSeriesPoint, error) {
+	buckets, err := r.GetMetricBuckets(ctx, start, end, serviceName, metricName)
+	if err != nil {
+		return nil, err
+	}
+	if step > 0 {
+		explainResolution(ctx, ResolutionRollup, "metric_buckets windows merged into "+step.String()+" steps")
+	}
+
+	byStep := make(map[int64]*MetricSeriesPoint)
+	var order []int64
+	for _, b := range buckets {
+		ts := b.TimeBucket
+		if step > 0 {
+			ts = ts.Truncate(step)
+		}
+		key := ts.UnixNano()
+		p, ok := byStep[key]
+		if !ok {
+			p = &MetricSeriesPoint{Timestamp: ts, Min: b.Min, Max: b.Max}
+			byStep[key] = p
+			order = append(order, key)
+		}
+		p.Min = math.Min(p.Min, b.Min)
+		p.Max = math.Max(p.Max, b.Max)
+		p.Sum += b.Sum
+		p.Count += b.Count
+		if b.ExemplarTraceID != "" && (p.ExemplarTraceID == "" || b.ExemplarValue > p.ExemplarValue) {
+			p.ExemplarTraceID, p.ExemplarSpanID, p.ExemplarValue = b.ExemplarTraceID, b.ExemplarSpanID, b.ExemplarValue
+		}
+	}
+
+	points := make([]MetricSeriesPoint, 0, len(order))
+	for _, key := range order {
+		p := byStep[key]
+		switch agg {
+		case "min":
+			p.Value = p.Min
+		case "max":
+			p.Value = p.Max
+		case "sum":
+			p.Value = p.Sum
+		case "count":
+			p.Value = float64(p.Count)
+		default:
+			if p.Count > 0 {
+				p.Value = p.Sum / float64(p.Count)
+			}
+		}
+		points = append(points, *p)
+	}
+	// Buckets arrive time-ordered, so order is already ascending.
+	return points, nil
+}
+
 // GetMetricNames returns a list of distinct metric names for the tenant on ctx,
 // optionally filtered by service.
 func (r *Repository) GetMetricNames(ctx context.Context, serviceName string) ([]string, error) {
@@ -96,90 +182,20 @@ func (r *Repository) GetMetricNames(ctx context.Context, serviceName string) ([]
 	return names, nil
 }
 
-// p99DurationForQuery computes the p99 latency (in microseconds) from the
-// matching rows of session. It dispatches on r.driver:
-//
-//   - postgres / postgresql: native percentile_disc aggregate (single query).
-//   - mysql: two-query COUNT + ORDER BY … OFFSET approach.
-//   - default (sqlite + unknown): in-memory sort capped at sqliteP99RowCap rows.
-//
-// The caller must pass a fresh Session so nothing leaks across subsequent calls.
-// ctx is threaded into every sub-session so client cancellation (disconnect/timeout)
-// is honoured at the driver level.
-func (r *Repository) p99DurationForQuery(ctx context.Context, session *gorm.DB) (int64, error) {
-	switch strings.ToLower(r.driver) {
-	case "postgres", "postgresql":
-		// Use Rows() (not Row()) so we can explicitly Close the underlying
-		// *sql.Rows — otherwise the connection leaks on sustained traffic.
-		rows, err := session.Session(&gorm.Session{Context: ctx}).Select("COALESCE(percentile_disc(0.99) WITHIN GROUP (ORDER BY duration), 0)::bigint").Rows()
-		if err != nil {
-			return 0, err
-		}
-		defer rows.Close()
-		var p int64
-		if rows.Next() {
-			if err := rows.Scan(&p); err != nil {
-				return 0, err
-			}
-		}
-		if err := rows.Err(); err != nil {
-			return 0, err
-		}
-		return p, nil
-
-	case "mysql":
-		var n int64
-		if err := session.Session(&gorm.Session{Context: ctx}).Model(&Trace{}).Count(&n).Error; err != nil {
-			return 0, err
-		}
-		if n == 0 {
-			return 0, nil
-		}
-		offset := int64(math.Ceil(float64(n)*0.99)) - 1
-		if offset < 0 {
-			offset = 0
-		} else if offset >= n {
-			offset = n - 1
-		}
-		var p int64
-		if err := session.Session(&gorm.Session{Context: ctx}).Select("duration").Order("duration ASC").Offset(int(offset)).Limit(1).Scan(&p).Error; err != nil {
-			return 0, err
-		}
-		return p, nil
-
-	default: // sqlite and any unknown driver
-		var durations []int64
-		q := session.Session(&gorm.Session{Context: ctx}).Select("duration").Order("duration ASC").Limit(sqliteP99RowCap + 1)
-		if err := q.Find(&durations).Error; err != nil {
-			return 0, err
-		}
-		if len(durations) == 0 {
-			return 0, nil
-		}
-		if len(durations) > sqliteP99RowCap {
-			// Truncate to cap — accuracy degrades gracefully. Operators alert on
-			// the counter (dataset is too large for in-memory p99 — migrate to
-			// Postgres). Keep a low-volume debug log for dev observability.
-			if r.metrics != nil {
-				r.metrics.DashboardP99RowCapHitsTotal.Inc()
-			}
-			slog.Debug("p99 SQLite fallback capped rows", "cap", sqliteP99RowCap)
-			durations = durations[:sqliteP99RowCap]
-		}
-		idx := int(math.Ceil(float64(len(durations))*0.99)) - 1
-		if idx < 0 {
-			idx = 0
-		} else if idx >= len(durations) {
-			idx = len(durations) - 1
-		}
-		return durations[idx], nil
-	}
-}
-
 // GetDashboardStats calculates high-level metrics for the dashboard, scoped to
 // the tenant on ctx.
 func (r *Repository) GetDashboardStats(ctx context.Context, start, end time.Time, serviceNames []string) (*DashboardStats, error) {
+	// Whole minutes and hours the aggregator has rolled up are read from
+	// dashboard_rollups; only the edges and the unsettled recent window
+	// touch raw rows.
+	if minute, hour, err := r.rollupCoverage(ctx); err != nil {
+		slog.Debug("Dashboard rollups unavailable, reading raw rows", "error", err)
+	} else if segs := planRollupSegments(start.UTC(), end.UTC(), minute, hour); segs != nil {
+		return r.dashboardStatsFromRollups(ctx, segs, serviceNames)
+	}
+
 	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRaw, "")
 	var stats DashboardStats
 
 	baseQuery := r.db.WithContext(ctx).Model(&Trace{}).Where(sqlWhereTenantTimeBetween, tenant, start, end)
@@ -233,12 +249,12 @@ func (r *Repository) GetDashboardStats(ctx context.Context, start, end time.Time
 		return nil, fmt.Errorf("failed to count active services: %w", err)
 	}
 
-	// 6. P99 Latency
-	p99, err := r.p99DurationForQuery(ctx, baseQuery.Session(&gorm.Session{}))
+	// 6. Latency percentiles
+	pcts, err := r.durationPercentiles(ctx, baseQuery.Session(&gorm.Session{}), "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to compute p99 latency: %w", err)
+		return nil, fmt.Errorf("failed to compute latency percentiles: %w", err)
 	}
-	stats.P99Latency = p99
+	stats.setLatencyPercentiles(pcts[0].LatencyPercentiles)
 
 	// 7. Top Failing Services
 	type svcCount struct {
@@ -273,67 +289,140 @@ func (r *Repository) GetDashboardStats(ctx context.Context, start, end time.Time
 	return &stats, nil
 }
 
-// GetTrafficMetrics returns request counts bucketed by minute (including error
-// counts), scoped to the tenant on ctx.
-func (r *Repository) GetTrafficMetrics(ctx context.Context, start, end time.Time, serviceNames []string) ([]TrafficPoint, error) {
+// GetTrafficMetrics returns request counts (including error counts) in
+// step-sized buckets aligned to the Unix epoch, default one minute, scoped to
+// the tenant on ctx. Bucketing happens in SQL, so memory is O(buckets)
+// however many traces the range holds.
+func (r *Repository) GetTrafficMetrics(ctx context.Context, start, end time.Time, serviceNames []string, step time.Duration) ([]TrafficPoint, error) {
 	tenant := TenantFromContext(ctx)
-	var points []TrafficPoint
-
-	type traceRow struct {
-		Timestamp time.Time
-		Status    string
+	if step < time.Second {
+		step = time.Minute
 	}
-	var rows []traceRow
+	explainResolution(ctx, ResolutionRaw, "traces counted per "+step.String()+" in SQL")
 
+	bucket := epochBucketExpr(r.driver, "timestamp", int64(step/time.Second))
 	query := r.db.WithContext(ctx).Model(&Trace{}).
-		Select("timestamp, status").
-		Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", tenant, start, end)
+		Select(bucket+" AS bucket, COUNT(*) AS count, "+
+			"SUM(CASE WHEN UPPER(status) LIKE '%ERROR%' THEN 1 ELSE 0 END) AS error_count").
+		Where(sqlWhereTenantTimeBetween, tenant, start, end).
+		Group(bucket).
+		Order("bucket")
 
 	if len(serviceNames) > 0 {
 		query = query.Where(sqlWhereServiceIn, serviceNames)
 	}
 
-	if err := query.Find(&rows).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch traffic rows: %w", err)
+	var rows []struct {
+		Bucket     int64
+		Count      int64
+		ErrorCount int64
 	}
-
-	type bucket struct {
-		count      int64
-		errorCount int64
-	}
-	buckets := make(map[int64]*bucket)
-	for _, r := range rows {
-		ts := r.Timestamp.Truncate(time.Minute).Unix()
-		b, ok := buckets[ts]
-		if !ok {
-			b = &bucket{}
-			buckets[ts] = b
-		}
-		b.count++
-		if strings.Contains(strings.ToUpper(r.Status), "ERROR") {
-			b.errorCount++
-		}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch traffic buckets: %w", err)
 	}
 
-	for ts, b := range buckets {
+	points := make([]TrafficPoint, 0, len(rows))
+	for _, row := range rows {
 		points = append(points, TrafficPoint{
-			Timestamp:  time.Unix(ts, 0),
-			Count:      b.count,
-			ErrorCount: b.errorCount,
+			Timestamp:  time.Unix(row.Bucket, 0),
+			Count:      row.Count,
+			ErrorCount: row.ErrorCount,
 		})
 	}
+	return points, nil
+}
 
-	sort.Slice(points, func(i, j int) bool {
-		return points[i].Timestamp.Before(points[j].Timestamp)
-	})
+// AddTrafficExemplars fills the exemplar trace IDs of points returned by
+// GetTrafficMetrics for the same range, services and step: the slowest
+// trace of each bucket and, for buckets with errors, one error trace. Two
+// more grouped scans over the range, so callers ask for it explicitly.
+func (r *Repository) AddTrafficExemplars(ctx context.Context, points []TrafficPoint, start, end time.Time, serviceNames []string, step time.Duration) error {
+	if len(points) == 0 {
+		return nil
+	}
+	tenant := TenantFromContext(ctx)
+	if step < time.Second {
+		step = time.Minute
+	}
+	secs := int64(step / time.Second)
+	scoped := func(q *gorm.DB, table string) *gorm.DB {
+		q = q.Where(table+".tenant_id = ? AND "+table+".timestamp BETWEEN ? AND ?", tenant, start, end)
+		if len(serviceNames) > 0 {
+			q = q.Where(table+".service_name IN ?", serviceNames)
+		}
+		return q
+	}
+
+	// Slowest: join each bucket's MAX(duration) back to its trace. Ties
+	// keep the first row.
+	bucket := epochBucketExpr(r.driver, "timestamp", secs)
+	maxes := scoped(r.db.WithContext(ctx).Model(&Trace{}), "traces").
+		Select(bucket + " AS bucket, MAX(duration) AS max_duration").
+		Group(bucket)
+	outer := epochBucketExpr(r.driver, "traces.timestamp", secs)
+	var slow []struct {
+		Bucket  int64
+		TraceID string
+	}
+	if err := scoped(r.db.WithContext(ctx).Model(&Trace{}), "traces").
+		Select(outer+" AS bucket, traces.trace_id").
+		Joins("JOIN (?) AS slowest ON "+outer+" = slowest.bucket AND traces.duration = slowest.max_duration", maxes).
+		Scan(&slow).Error; err != nil {
+		return fmt.Errorf("failed to fetch slow trace exemplars: %w", err)
+	}
+
+	var failed []struct {
+		Bucket  int64
+		TraceID string
+	}
+	if err := scoped(r.db.WithContext(ctx).Model(&Trace{}), "traces").
+		Select(bucket + " AS bucket, MAX(trace_id) AS trace_id").
+		Where("UPPER(status) LIKE '%ERROR%'").
+		Group(bucket).
+		Scan(&failed).Error; err != nil {
+		return fmt.Errorf("failed to fetch error trace exemplars: %w", err)
+	}
+
+	index := make(map[int64]int, len(points))
+	for i, p := range points {
+		index[p.Timestamp.Unix()] = i
+	}
+	for _, row := range slow {
+		if i, ok := index[row.Bucket]; ok && points[i].SlowTraceID == "" {
+			points[i].SlowTraceID = row.TraceID
+		}
+	}
+	for _, row := range failed {
+		if i, ok := index[row.Bucket]; ok {
+			points[i].ErrorTraceID = row.TraceID
+		}
+	}
+	return nil
+}
 
-	return points, nil
+// epochBucketExpr is a SQL expression flooring column to a multiple of
+// seconds since the Unix epoch, as an integer. Floor division keeps buckets
+// of any size aligned, which date_trunc alone cannot do for 5m.
+func epochBucketExpr(driver, column string, seconds int64) string {
+	switch strings.ToLower(driver) {
+	case "postgres", "postgresql":
+		return fmt.Sprintf("(FLOOR(EXTRACT(EPOCH FROM %s) / %d) * %d)::bigint", column, seconds, seconds)
+	case "mysql":
+		return fmt.Sprintf("(FLOOR(UNIX_TIMESTAMP(%s) / %d) * %d)", column, seconds, seconds)
+	case "sqlserver", "mssql":
+		return fmt.Sprintf("(DATEDIFF_BIG(SECOND, '1970-01-01', %s) / %d * %d)", column, seconds, seconds)
+	default:
+		// SQLite stores timestamps as text; strftime('%s') honours the
+		// offset suffix.
+		return fmt.Sprintf("(CAST(strftime('%%s', %s) AS INTEGER) / %d * %d)", column, seconds, seconds)
+	}
 }
 
 // GetLatencyHeatmap returns trace duration and timestamps for heatmap rendering,
 // scoped to the tenant on ctx.
 func (r *Repository) GetLatencyHeatmap(ctx context.Context, start, end time.Time, serviceNames []string) ([]LatencyPoint, error) {
 	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRaw, "")
 	var points []LatencyPoint
 	query := r.db.WithContext(ctx).Model(&Trace{}).
 		Select("timestamp, duration").
@@ -355,28 +444,37 @@ func (r *Repository) GetLatencyHeatmap(ctx context.Context, start, end time.Time
 // does NOT filter by tenant. Rows are deleted across every tenant. Never expose
 // this on a tenant-scoped API surface.
 func (r *Repository) PurgeMetricBucketsBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration) (int64, error) {
+	return r.purgeMetricBucketsBatched(ctx, olderThan, batchSize, sleep, purgeScope{})
+}
+
+// purgeMetricBucketsBatched is PurgeMetricBucketsBatched narrowed to scope.
+func (r *Repository) purgeMetricBucketsBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration, scope purgeScope) (int64, error) {
 	if batchSize <= 0 {
 		batchSize = 10_000
 	}
+	tenantSQL, tenantArgs := scope.clause()
 	driver := strings.ToLower(r.driver)
 	if driver == "sqlite" || driver == "" {
-		result := r.db.WithContext(ctx).Where("time_bucket < ?", olderThan).Delete(&MetricBucket{})
+		result := r.db.WithContext(ctx).Where("time_bucket < ?"+tenantSQL, append([]any{olderThan}, tenantArgs...)...).Delete(&MetricBucket{})
 		return result.RowsAffected, result.Error
 	}
 
 	var total int64
+	var batches int
 	for {
 		if err := ctx.Err(); err != nil {
 			return total, err
 		}
 		result := r.db.WithContext(ctx).Exec(
-			"DELETE FROM metric_buckets WHERE id IN (SELECT id FROM metric_buckets WHERE time_bucket < ? ORDER BY id LIMIT ?)",
-			olderThan, batchSize,
+			"DELETE FROM metric_buckets WHERE id IN (SELECT id FROM metric_buckets WHERE time_bucket < ?"+tenantSQL+" ORDER BY id LIMIT ?)",
+			scope.args(olderThan, tenantArgs, batchSize)...,
 		)
 		if result.Error != nil {
 			return total, fmt.Errorf("batched purge metric_buckets: %w", result.Error)
 		}
 		total += result.RowsAffected
+		batches++
+		logPurgeProgress("metric_buckets", batches, total)
 		if result.RowsAffected < int64(batchSize) {
 			return total, nil
 		}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetMetricSeries_RollsUpSteps(t *testing.T) {
+	repo := newTestRepo(t)
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	buckets := []MetricBucket{
+		// Two attribute sets in the first minute merge into one point.
+		{TenantID: "default", Name: "cpu", ServiceName: "api", TimeBucket: base, Min: 1, Max: 4, Sum: 10, Count: 4, AttributesJSON: `{"core":"0"}`, ExemplarTraceID: "slow", ExemplarSpanID: "s1", ExemplarValue: 4},
+		{TenantID: "default", Name: "cpu", ServiceName: "api", TimeBucket: base.Add(30 * time.Second), Min: 0.5, Max: 2, Sum: 2, Count: 2, AttributesJSON: `{"core":"1"}`, ExemplarTraceID: "fast", ExemplarSpanID: "s2", ExemplarValue: 2},
+		{TenantID: "default", Name: "cpu", ServiceName: "api", TimeBucket: base.Add(90 * time.Second), Min: 3, Max: 3, Sum: 3, Count: 1},
+		// Other tenant and other metric are excluded.
+		{TenantID: "acme", Name: "cpu", ServiceName: "api", TimeBucket: base, Min: 99, Max: 99, Sum: 99, Count: 1},
+		{TenantID: "default", Name: "mem", ServiceName: "api", TimeBucket: base, Min: 99, Max: 99, Sum: 99, Count: 1},
+	}
+	if err := repo.db.Create(&buckets).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	ctx := context.Background()
+	start, end := base.Add(-time.Minute), base.Add(5*time.Minute)
+
+	points, err := repo.GetMetricSeries(ctx, start, end, "", "cpu", time.Minute, "avg")
+	if err != nil {
+		t.Fatalf("GetMetricSeries: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("want 2 points, got %d: %+v", len(points), points)
+	}
+	p := points[0]
+	if !p.Timestamp.Equal(base) || p.Count != 6 || p.Sum != 12 || p.Min != 0.5 || p.Max != 4 || p.Value != 2 {
+		t.Errorf("first point = %+v", p)
+	}
+	if p.ExemplarTraceID != "slow" || p.ExemplarSpanID != "s1" || p.ExemplarValue != 4 {
+		t.Errorf("first point exemplar = %+v, want the highest-value one", p)
+	}
+	if points[1].ExemplarTraceID != "" {
+		t.Errorf("second point exemplar = %q, want none", points[1].ExemplarTraceID)
+	}
+	if !points[1].Timestamp.Equal(base.Add(time.Minute)) || points[1].Value != 3 {
+		t.Errorf("second point = %+v", points[1])
+	}
+
+	for agg, want := range map[string]float64{"min": 0.5, "max": 4, "sum": 12, "count": 6} {
+		points, err := repo.GetMetricSeries(ctx, start, end, "api", "cpu", time.Minute, agg)
+		if err != nil {
+			t.Fatalf("%s: %v", agg, err)
+		}
+		if points[0].Value != want {
+			t.Errorf("%s: value = %v, want %v", agg, points[0].Value, want)
+		}
+	}
+
+	// step 0 keeps the stored resolution.
+	points, err = repo.GetMetricSeries(ctx, start, end, "", "cpu", 0, "avg")
+	if err != nil {
+		t.Fatalf("GetMetricSeries step=0: %v", err)
+	}
+	if len(points) != 3 {
+		t.Errorf("step=0: want 3 points, got %d", len(points))
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// nanosBackfillBatch bounds each SQLite backfill round trip.
+const nanosBackfillBatch = 1000
+
+// nanosBackfillRange is the primary-key span of each server-side backfill
+// UPDATE, so no single statement rewrites a whole table while holding its
+// locks and growing the transaction log.
+const nanosBackfillRange = 10000
+
+// backfillNanosecondTiming populates spans.start_time_unix_nano,
+// spans.duration_ns and traces.duration_ns on rows written before those
+// columns existed, from the legacy start_time and microsecond duration
+// columns. Sub-microsecond detail those rows never had cannot be recovered;
+// everything ingested afterwards carries the OTLP nanoseconds.
+//
+// Must run AFTER db.AutoMigrate(...) adds the columns. Idempotent: a span is
+// pending while start_time_unix_nano is 0, a trace while duration_ns is 0 and
+// duration is not, so re-runs and fresh databases touch nothing. Rows are
+// updated in primary-key ranges, each its own statement, so an interrupted
+// backfill resumes where it stopped on the next start.
+func backfillNanosecondTiming(db *gorm.DB, driver string) error {
+	if db == nil {
+		return nil
+	}
+	driver = strings.ToLower(driver)
+
+	traces, err := backfillByIDRange(db, "traces", "duration_ns = duration * 1000",
+		"duration_ns = 0 AND duration <> 0", nanosBackfillRange)
+	if err != nil {
+		return fmt.Errorf("backfill traces.duration_ns: %w", err)
+	}
+
+	var startExpr string
+	switch driver {
+	case "sqlite", "":
+		// SQLite keeps timestamps as text; parse them in Go so whatever
+		// precision the text holds survives.
+		spans, err := backfillSpanNanosSQLite(db)
+		if err != nil {
+			return err
+		}
+		logNanosBackfill(spans, traces)
+		return nil
+	case "postgres", "postgresql":
+		// timestamptz is microsecond precision.
+		startExpr = "(EXTRACT(EPOCH FROM start_time) * 1000000)::bigint * 1000"
+	case "mysql":
+		// datetime(3) is millisecond precision and zone-less; counting from
+		// a literal epoch keeps the session time_zone out of it, which
+		// UNIX_TIMESTAMP would apply.
+		startExpr = "TIMESTAMPDIFF(MICROSECOND, '1970-01-01 00:00:00', start_time) * 1000"
+	case "sqlserver", "mssql":
+		startExpr = "DATEDIFF_BIG(MICROSECOND, '1970-01-01', start_time) * 1000"
+	default:
+		return nil
+	}
+	spans, err := backfillByIDRange(db, "spans", "start_time_unix_nano = "+startExpr+", duration_ns = duration * 1000",
+		"start_time_unix_nano = 0", nanosBackfillRange)
+	if err != nil {
+		return fmt.Errorf("backfill spans nanosecond timing (%s): %w", driver, err)
+	}
+	logNanosBackfill(spans, traces)
+	return nil
+}
+
+// backfillByIDRange applies set to the pending rows of table, one UPDATE per
+// step ids, from the lowest pending id to the highest. Returns the rows
+// changed.
+func backfillByIDRange(db *gorm.DB, table, set, pending string, step uint) (int64, error) {
+	var bounds struct {
+		Lo, Hi uint
+	}
+	if err := db.Table(table).Select("COALESCE(MIN(id), 0) AS lo, COALESCE(MAX(id), 0) AS hi").
+		Where(pending).Scan(&bounds).Error; err != nil {
+		return 0, err
+	}
+	if bounds.Hi == 0 {
+		return 0, nil
+	}
+	var total int64
+	for lo := bounds.Lo; lo <= bounds.Hi; lo += step {
+		res := db.Exec("UPDATE "+table+" SET "+set+" WHERE id >= ? AND id < ? AND "+pending, lo, lo+step)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+	}
+	return total, nil
+}
+
+// backfillSpanNanosSQLite walks pending spans in primary-key order, so rows
+// whose start really is the epoch cannot loop forever.
+func backfillSpanNanosSQLite(db *gorm.DB) (int64, error) {
+	type pending struct {
+		ID        uint
+		StartTime time.Time
+		Duration  int64
+	}
+	var lastID uint
+	var total int64
+	for {
+		var rows []pending
+		if err := db.Table("spans").Select("id, start_time, duration").
+			Where("start_time_unix_nano = 0 AND id > ?", lastID).
+			Order("id").Limit(nanosBackfillBatch).Scan(&rows).Error; err != nil {
+			return total, fmt.Errorf("backfill spans nanosecond timing (sqlite): %w", err)
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				if err := tx.Table("spans").Where("id = ?", row.ID).Updates(map[string]any{
+					"start_time_unix_nano": row.StartTime.UnixNano(),
+					"duration_ns":          row.Duration * int64(time.Microsecond),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("backfill spans nanosecond timing (sqlite): %w", err)
+		}
+		total += int64(len(rows))
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+func logNanosBackfill(spans, traces int64) {
+	if spans > 0 || traces > 0 {
+		log.Printf("⏱️  Backfilled nanosecond timing on %d span(s) and %d trace(s)", spans, traces)
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSpanNanosecondOrdering verifies spans that start within the same
+// microsecond keep their order and full precision through a round trip.
+func TestSpanNanosecondOrdering(t *testing.T) {
+	repo := newTestRepo(t)
+	base := time.Unix(1_700_000_000, 123_456_000)
+	late, early := Span{TraceID: "nano", SpanID: "b"}, Span{TraceID: "nano", SpanID: "a"}
+	late.SetTiming(base.Add(700), base.Add(1_250))
+	early.SetTiming(base.Add(300), base.Add(900))
+	if err := repo.db.Create([]Span{late, early}).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	spans, err := repo.GetTraceSpans(context.Background(), "nano")
+	if err != nil || len(spans) != 2 {
+		t.Fatalf("GetTraceSpans = %+v, %v", spans, err)
+	}
+	if spans[0].SpanID != "a" || !spans[0].StartTime.Equal(base.Add(300)) || !spans[0].EndTime.Equal(base.Add(900)) {
+		t.Errorf("first span = %s %v→%v, want a with nanosecond bounds", spans[0].SpanID, spans[0].StartTime, spans[0].EndTime)
+	}
+	if spans[1].DurationNs != 550 || spans[1].Duration != 0 {
+		t.Errorf("second span duration = %dns / %dµs, want 550ns / 0µs", spans[1].DurationNs, spans[1].Duration)
+	}
+}
+
+// TestBackfillNanosecondTiming verifies rows written before the nanosecond
+// columns existed are populated from start_time and the microsecond duration.
+func TestBackfillNanosecondTiming(t *testing.T) {
+	repo := newTestRepo(t)
+	start := time.Unix(1_700_000_000, 5_000).UTC()
+	seedTrace(t, repo.db, "legacy", start, []time.Time{start})
+	// Simulate pre-migration rows.
+	repo.db.Exec("UPDATE spans SET start_time_unix_nano = 0, duration_ns = 0")
+	repo.db.Exec("UPDATE traces SET duration_ns = 0")
+
+	if err := backfillNanosecondTiming(repo.db, "sqlite"); err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	var sp struct {
+		StartTimeUnixNano int64
+		DurationNs        int64
+	}
+	repo.db.Table("spans").Select("start_time_unix_nano, duration_ns").Where("trace_id = ?", "legacy").Scan(&sp)
+	if sp.StartTimeUnixNano != start.UnixNano() || sp.DurationNs != 1_000_000 {
+		t.Errorf("span = %+v, want start %d and 1ms", sp, start.UnixNano())
+	}
+	var traceNs int64
+	repo.db.Table("traces").Select("duration_ns").Where("trace_id = ?", "legacy").Scan(&traceNs)
+	if traceNs != 1_000_000 {
+		t.Errorf("trace duration_ns = %d, want 1000000", traceNs)
+	}
+
+	// Re-running is a no-op.
+	if err := backfillNanosecondTiming(repo.db, "sqlite"); err != nil {
+		t.Fatalf("second backfill: %v", err)
+	}
+}
+
+// TestBackfillByIDRange verifies the server-side backfill covers every
+// pending row when they span several primary-key ranges.
+func TestBackfillByIDRange(t *testing.T) {
+	repo := newTestRepo(t)
+	start := time.Unix(1_700_000_000, 0).UTC()
+	for _, id := range []string{"r1", "r2", "r3", "r4", "r5"} {
+		seedTrace(t, repo.db, id, start, []time.Time{start})
+	}
+	repo.db.Exec("UPDATE traces SET duration_ns = 0")
+	repo.db.Exec("UPDATE traces SET duration = 0 WHERE trace_id = ?", "r3") // never pending
+
+	n, err := backfillByIDRange(repo.db, "traces", "duration_ns = duration * 1000", "duration_ns = 0 AND duration <> 0", 2)
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("backfilled %d traces, want 4", n)
+	}
+	var left int64
+	repo.db.Table("traces").Where("duration_ns = 0 AND duration <> 0").Count(&left)
+	if left != 0 {
+		t.Errorf("%d traces still pending", left)
+	}
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -93,17 +94,20 @@ type Trace struct {
 	TenantID    string  `gorm:"size:64;default:'default';not null;index:idx_traces_tenant_ts,priority:1;index:idx_traces_tenant_service,priority:1;uniqueIndex:idx_traces_tenant_trace_id,priority:1" json:"tenant_id"`
 	TraceID     string  `gorm:"size:32;not null;uniqueIndex:idx_traces_tenant_trace_id,priority:2" json:"trace_id"`
 	ServiceName string  `gorm:"size:255;index:idx_traces_tenant_service,priority:2" json:"service_name"`
-	Duration    int64   `gorm:"index" json:"duration"` // Microseconds
+	Duration    int64   `gorm:"index" json:"duration"`    // Microseconds; kept for API compatibility
+	DurationNs  int64   `gorm:"index" json:"duration_ns"` // Nanoseconds; authoritative
 	DurationMs  float64 `gorm:"-" json:"duration_ms"`
 	SpanCount   int     `gorm:"-" json:"span_count"`
 	Operation   string  `gorm:"-" json:"operation"`
 	Status      string  `gorm:"size:50" json:"status"`
+	Region      string  `gorm:"size:64" json:"region,omitempty"` // ingesting instance's REGION
 	// Timestamp is both part of idx_traces_tenant_ts (composite) and retains a
 	// standalone index so range scans on traces across all tenants (e.g.
 	// retention sweeps) still use an index.
 	Timestamp time.Time      `gorm:"index;index:idx_traces_tenant_ts,priority:2" json:"timestamp"`
 	Spans     []Span         `gorm:"foreignKey:TraceID;references:TraceID;constraint:false" json:"spans,omitempty"`
 	Logs      []Log          `gorm:"foreignKey:TraceID;references:TraceID;constraint:false" json:"logs,omitempty"`
+	Links     []TraceLink    `gorm:"-" json:"links,omitempty"` // filled by GetTrace
 	CreatedAt time.Time      `json:"-"`
 	UpdatedAt time.Time      `json:"-"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -118,6 +122,11 @@ type Trace struct {
 // double-counting in downstream metrics or GraphRAG. The composite covers
 // the legacy idx_spans_tenant_trace as a left-prefix; the legacy index
 // is retained for query-plan stability across upgrades.
+//
+// Precision: start_time/end_time columns keep whatever the driver stores
+// (microseconds on Postgres, milliseconds on MySQL's default datetime), so
+// StartTimeNs and DurationNs carry the OTLP nanoseconds as INT64 and AfterFind
+// rebuilds StartTime/EndTime from them. Order spans by start_time_unix_nano.
 type Span struct {
 	ID             uint           `gorm:"primaryKey" json:"id"`
 	TenantID       string         `gorm:"size:64;default:'default';not null;index:idx_spans_tenant_trace,priority:1;index:idx_spans_tenant_service_start,priority:1;uniqueIndex:idx_spans_tenant_trace_span,priority:1" json:"tenant_id"`
@@ -127,10 +136,79 @@ type Span struct {
 	OperationName  string         `gorm:"size:255;index" json:"operation_name"`
 	StartTime      time.Time      `gorm:"index:idx_spans_tenant_service_start,priority:3" json:"start_time"`
 	EndTime        time.Time      `json:"end_time"`
-	Duration       int64          `json:"duration"`                                                                     // Microseconds
+	Duration       int64          `json:"duration"`                                                                     // Microseconds; kept for API compatibility
+	StartTimeNs    int64          `gorm:"column:start_time_unix_nano;index" json:"start_time_unix_nano"`                // Epoch nanoseconds; authoritative
+	DurationNs     int64          `json:"duration_ns"`                                                                  // Nanoseconds; authoritative
 	ServiceName    string         `gorm:"size:255;index:idx_spans_tenant_service_start,priority:2" json:"service_name"` // Originating service
 	Status         string         `gorm:"size:50;default:'STATUS_CODE_UNSET';index" json:"status"`                      // OTLP status code (e.g. STATUS_CODE_ERROR); drives GraphRAG error signal
 	AttributesJSON CompressedText `json:"attributes_json"`                                                              // Compressed JSON string
+	Region         string         `gorm:"size:64" json:"region,omitempty"`                                              // ingesting instance's REGION
+	// Links are the span's OTLP links, set at ingest and stored in
+	// span_links by the batch writers.
+	Links []SpanLink `gorm:"-" json:"links,omitempty"`
+	// Events are the span's OTLP events, set at ingest and stored in
+	// span_events by the batch writers.
+	Events []SpanEvent `gorm:"-" json:"events,omitempty"`
+}
+
+// SetTiming fills the nanosecond and legacy microsecond timing fields
+// from an OTLP start/end pair.
+func (s *Span) SetTiming(start, end time.Time) {
+	s.StartTime, s.EndTime = start, end
+	s.StartTimeNs = start.UnixNano()
+	s.DurationNs = end.Sub(start).Nanoseconds()
+	s.Duration = s.DurationNs / int64(time.Microsecond)
+}
+
+// DurationNanos returns the span's duration in nanoseconds, falling back to
+// the microsecond column for rows written before duration_ns existed.
+func (s *Span) DurationNanos() int64 {
+	if s.DurationNs != 0 {
+		return s.DurationNs
+	}
+	return s.Duration * int64(time.Microsecond)
+}
+
+// BeforeCreate fills the nanosecond columns from the legacy fields for
+// writers that set only StartTime and Duration.
+func (s *Span) BeforeCreate(*gorm.DB) error {
+	if s.StartTimeNs == 0 && !s.StartTime.IsZero() {
+		s.StartTimeNs = s.StartTime.UnixNano()
+	}
+	if s.DurationNs == 0 {
+		s.DurationNs = s.Duration * int64(time.Microsecond)
+	}
+	return nil
+}
+
+// AfterFind restores full precision on StartTime/EndTime, which the driver
+// may have truncated, from the nanosecond columns.
+func (s *Span) AfterFind(*gorm.DB) error {
+	if s.StartTimeNs != 0 {
+		s.StartTime = time.Unix(0, s.StartTimeNs)
+	}
+	if s.StartTimeNs != 0 && s.DurationNs != 0 {
+		s.EndTime = s.StartTime.Add(time.Duration(s.DurationNs))
+	}
+	return nil
+}
+
+// BeforeCreate fills duration_ns from Duration for writers that set only
+// the microsecond field.
+func (t *Trace) BeforeCreate(*gorm.DB) error {
+	if t.DurationNs == 0 {
+		t.DurationNs = t.Duration * int64(time.Microsecond)
+	}
+	return nil
+}
+
+// DurationNanos returns the trace's duration in nanoseconds, falling back to
+// the microsecond column for rows written before duration_ns existed.
+func (t *Trace) DurationNanos() int64 {
+	if t.DurationNs != 0 {
+		return t.DurationNs
+	}
+	return t.Duration * int64(time.Microsecond)
 }
 
 // Log represents a log entry associated with a trace.
@@ -145,6 +223,7 @@ type Log struct {
 	AttributesJSON CompressedText `json:"attributes_json"`
 	AIInsight      CompressedText `json:"ai_insight"`                                                 // Populated by AI analysis
 	Timestamp      time.Time      `gorm:"index;index:idx_logs_tenant_ts,priority:2" json:"timestamp"` // standalone index for global retention sweeps
+	Region         string         `gorm:"size:64" json:"region,omitempty"`                            // ingesting instance's REGION
 }
 
 // MetricBucket represents aggregated metric data over a time window (e.g., 10s).
@@ -158,5 +237,340 @@ type MetricBucket struct {
 	Max            float64        `json:"max"`
 	Sum            float64        `json:"sum"`
 	Count          int64          `json:"count"`
-	AttributesJSON CompressedText `json:"attributes_json"` // Grouped attributes
+	AttributesJSON CompressedText `json:"attributes_json"`                 // Grouped attributes
+	Region         string         `gorm:"size:64" json:"region,omitempty"` // ingesting instance's REGION
+	// The window's highest-value exemplar: a sampled trace behind it.
+	ExemplarTraceID string  `gorm:"size:32" json:"exemplar_trace_id,omitempty"`
+	ExemplarSpanID  string  `gorm:"size:16" json:"exemplar_span_id,omitempty"`
+	ExemplarValue   float64 `json:"exemplar_value,omitempty"`
+}
+
+// AlertChannel is one notification target of an AlertRule.
+type AlertChannel struct {
+	Type string   `json:"type"`          // slack | webhook | email
+	URL  string   `json:"url,omitempty"` // slack / webhook endpoint
+	To   []string `json:"to,omitempty"`  // email recipients
+}
+
+// AlertRule is a user-defined condition evaluated periodically by
+// internal/alerting against the tenant's recent data.
+type AlertRule struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	TenantID    string         `gorm:"size:64;default:'default';not null;index" json:"tenant_id"`
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	Kind        string         `gorm:"size:32;not null" json:"kind"`                // error_rate | p99_latency | log_match | slo_burn_rate | monitor_down
+	ServiceName string         `gorm:"size:255" json:"service_name"`                // empty = all services
+	Keyword     string         `gorm:"size:255" json:"keyword,omitempty"`           // log_match only
+	SLOID       uint           `gorm:"column:slo_id;index" json:"slo_id,omitempty"` // slo_burn_rate only
+	MonitorID   uint           `gorm:"index" json:"monitor_id,omitempty"`           // monitor_down only
+	Threshold   float64        `json:"threshold"`                                   // error_rate: percent; p99_latency: ms; log_match: matching lines; slo_burn_rate: burn rate; monitor_down: consecutive failed checks
+	WindowSecs  int            `json:"window_seconds"`                              // look-back window per evaluation
+	Disabled    bool           `json:"disabled"`
+	Channels    []AlertChannel `gorm:"serializer:json" json:"channels"`
+	Managed     bool           `gorm:"not null;default:false" json:"managed,omitempty"` // defined in the config file; replaced on reload
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// AlertEvent is one firing→resolved incident of an AlertRule. A rule has at
+// most one event in state "firing" at a time.
+type AlertEvent struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   string     `gorm:"size:64;default:'default';not null;index:idx_alert_events_tenant_state,priority:1" json:"tenant_id"`
+	RuleID     uint       `gorm:"not null;index" json:"rule_id"`
+	RuleName   string     `gorm:"size:255" json:"rule_name"`
+	State      string     `gorm:"size:16;not null;index:idx_alert_events_tenant_state,priority:2" json:"state"` // firing | resolved
+	Value      float64    `json:"value"`                                                                        // last observed value
+	Message    string     `gorm:"type:text" json:"message"`
+	FiredAt    time.Time  `json:"fired_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// SLO is a service level objective on one service's traces, evaluated by
+// internal/alerting. An availability SLO counts error traces as bad; a
+// latency SLO counts traces slower than LatencyThresholdMs as bad, so
+// "p95 < 300ms" is a latency SLO with Objective 95 and a 300ms threshold.
+type SLO struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	TenantID           string    `gorm:"size:64;default:'default';not null;index" json:"tenant_id"`
+	Name               string    `gorm:"size:255;not null" json:"name"`
+	ServiceName        string    `gorm:"size:255;not null" json:"service_name"`
+	Kind               string    `gorm:"size:16;not null" json:"kind"`                  // availability | latency
+	Objective          float64   `json:"objective"`                                     // percent of traces that must be good, e.g. 99.5
+	LatencyThresholdMs float64   `json:"latency_threshold_ms,omitempty"`                // latency only
+	WindowDays         int       `json:"window_days"`                                   // rolling compliance window
+	Status             SLOStatus `gorm:"embedded;embeddedPrefix:status_" json:"status"` // written by the evaluator
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// SLOStatus is an SLO's compliance as of its last evaluation. Burn rates
+// are the bad-trace ratio over the window divided by the allowed ratio
+// (1 - objective): 1 spends the budget exactly over WindowDays.
+type SLOStatus struct {
+	EvaluatedAt     *time.Time `json:"evaluated_at,omitempty"`
+	Total           int64      `json:"total"`
+	Bad             int64      `json:"bad"`
+	Compliance      float64    `json:"compliance"`             // percent good; 100 without traffic
+	BudgetRemaining float64    `json:"error_budget_remaining"` // fraction of the budget left; negative when overspent
+	BurnRate1h      float64    `gorm:"column:burn_rate_1h" json:"burn_rate_1h"`
+	BurnRate6h      float64    `gorm:"column:burn_rate_6h" json:"burn_rate_6h"`
+}
+
+// Monitor is a synthetic check run against a service from the outside by
+// internal/uptime: an HTTP request, a TCP connect or an ICMP echo to Target
+// every IntervalSecs. ServiceName optionally ties it to a service for
+// display and alerting.
+type Monitor struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	TenantID       string        `gorm:"size:64;default:'default';not null;index" json:"tenant_id"`
+	Name           string        `gorm:"size:255;not null" json:"name"`
+	Kind           string        `gorm:"size:16;not null" json:"kind"`     // http | tcp | icmp
+	Target         string        `gorm:"size:2048;not null" json:"target"` // http: URL; tcp: host:port; icmp: host
+	ServiceName    string        `gorm:"size:255" json:"service_name,omitempty"`
+	IntervalSecs   int           `json:"interval_seconds"`
+	TimeoutMs      int           `json:"timeout_ms"`
+	ExpectedStatus int           `json:"expected_status,omitempty"` // http only; 0 = any 2xx or 3xx
+	Disabled       bool          `json:"disabled"`
+	Status         MonitorStatus `gorm:"embedded;embeddedPrefix:status_" json:"status"` // written by the scheduler
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// MonitorStatus is a monitor's outcome as of its last check.
+type MonitorStatus struct {
+	CheckedAt           *time.Time `json:"checked_at,omitempty"`
+	Up                  bool       `json:"up"`
+	LatencyMs           float64    `json:"latency_ms"`
+	StatusCode          int        `json:"status_code,omitempty"` // http only
+	Error               string     `gorm:"size:512" json:"error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Availability24h     float64    `gorm:"column:availability_24h" json:"availability_24h"` // percent of checks up over the last 24h
+}
+
+// MonitorResult is one check of a Monitor. Pruned after
+// UPTIME_RESULT_RETENTION.
+type MonitorResult struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TenantID   string    `gorm:"size:64;default:'default';not null" json:"tenant_id"`
+	MonitorID  uint      `gorm:"not null;index:idx_monitor_results_monitor_time,priority:1" json:"monitor_id"`
+	CheckedAt  time.Time `gorm:"not null;index;index:idx_monitor_results_monitor_time,priority:2" json:"checked_at"`
+	Up         bool      `json:"up"`
+	LatencyMs  float64   `json:"latency_ms"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `gorm:"size:512" json:"error,omitempty"`
+}
+
+// TriageState is the triage status of one issue (an errors explorer group,
+// keyed by its fingerprint) or one GraphRAG anomaly (keyed by its ID). No
+// row means open and unassigned.
+type TriageState struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	TenantID   string     `gorm:"size:64;default:'default';not null;uniqueIndex:idx_triage_states_key,priority:1" json:"tenant_id"`
+	Kind       string     `gorm:"size:16;not null;uniqueIndex:idx_triage_states_key,priority:2" json:"kind"` // issue | anomaly
+	Key        string     `gorm:"column:item_key;size:191;not null;uniqueIndex:idx_triage_states_key,priority:3" json:"key"`
+	Status     string     `gorm:"size:16;not null" json:"status"` // open | resolved | ignored
+	Assignee   string     `gorm:"size:255" json:"assignee,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Issue is one error group tracked over time: the error logs and failed
+// spans of a service that share an error type and message template, keyed
+// by IssueFingerprint like the errors explorer groups. AdvanceIssues keeps
+// the counts; rows outlive the raw data. Status and Assignee come from the
+// issue's TriageState and are not stored here.
+type Issue struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	TenantID      string    `gorm:"size:64;default:'default';not null;uniqueIndex:idx_issues_fingerprint,priority:1;index:idx_issues_tenant_last_seen,priority:1" json:"tenant_id"`
+	Fingerprint   string    `gorm:"size:16;not null;uniqueIndex:idx_issues_fingerprint,priority:2" json:"fingerprint"`
+	ServiceName   string    `gorm:"size:255;not null" json:"service_name"`
+	ErrorType     string    `gorm:"size:255;not null" json:"error_type"`
+	Message       string    `gorm:"size:256" json:"message"`         // normalized template
+	SampleMessage string    `gorm:"type:text" json:"sample_message"` // latest raw message
+	SampleTraceID string    `gorm:"size:32" json:"sample_trace_id,omitempty"`
+	Occurrences   int64     `gorm:"not null" json:"occurrences"`
+	FirstSeen     time.Time `gorm:"not null" json:"first_seen"`
+	LastSeen      time.Time `gorm:"not null;index;index:idx_issues_tenant_last_seen,priority:2" json:"last_seen"`
+	Status        string    `gorm:"-" json:"status"` // see TriageState.EffectiveStatus
+	Assignee      string    `gorm:"-" json:"assignee,omitempty"`
+}
+
+// TraceInsight is the AI root-cause summary of one error trace, written by
+// the internal/ai pipeline once the trace has gone quiet. Swept with its
+// trace by retention.
+type TraceInsight struct {
+	ID          uint           `gorm:"primaryKey" json:"-"`
+	TenantID    string         `gorm:"size:64;default:'default';not null;uniqueIndex:idx_trace_insights_trace,priority:1" json:"tenant_id"`
+	TraceID     string         `gorm:"size:32;not null;uniqueIndex:idx_trace_insights_trace,priority:2" json:"trace_id"`
+	ServiceName string         `gorm:"size:255" json:"service_name"` // service of the first failing span
+	Summary     CompressedText `json:"summary"`
+	CreatedAt   time.Time      `gorm:"index" json:"created_at"`
+}
+
+// Deployment is a deploy annotation: a release of one service, recorded from
+// a CI/CD webhook, POST /api/deployments or a new service.version seen at
+// ingest. ExternalID identifies the upstream event (e.g.
+// "github:deployment:123") so later status updates amend the same row.
+// Verdict and Impact are set once the post-deploy window has been compared
+// with the one before (EvaluateDeployments).
+type Deployment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    string    `gorm:"size:64;default:'default';not null;uniqueIndex:idx_deployments_tenant_external,priority:1;index:idx_deployments_tenant_time,priority:1" json:"tenant_id"`
+	ExternalID  string    `gorm:"size:128;not null;uniqueIndex:idx_deployments_tenant_external,priority:2" json:"external_id"`
+	Source      string    `gorm:"size:32;not null" json:"source"` // github_deployment | github_workflow | api | service_version
+	ServiceName string    `gorm:"size:255;not null;index" json:"service_name"`
+	Version     string    `gorm:"size:255" json:"version"`
+	Environment string    `gorm:"size:64" json:"environment,omitempty"`
+	Status      string    `gorm:"size:32;not null" json:"status"` // pending | in_progress | success | failure
+	Ref         string    `gorm:"size:255" json:"ref,omitempty"`
+	CommitSHA   string    `gorm:"size:64" json:"commit_sha,omitempty"`
+	URL         string    `gorm:"size:512" json:"url,omitempty"`
+	Actor       string    `gorm:"size:255" json:"actor,omitempty"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	Timestamp   time.Time `gorm:"not null;index:idx_deployments_tenant_time,priority:2" json:"timestamp"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Verdict     string            `gorm:"size:24;default:''" json:"verdict,omitempty"` // ok | regressed | insufficient_data; empty until evaluated
+	Impact      *DeploymentImpact `gorm:"serializer:json" json:"impact,omitempty"`
+	EvaluatedAt *time.Time        `json:"evaluated_at,omitempty"`
+}
+
+// SavedView is a named filter set or dashboard layout, shared across its
+// tenant and addressed by Slug (/views/<slug> in the UI). Layout is the
+// UI's own JSON; the backend stores it verbatim.
+type SavedView struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	TenantID    string           `gorm:"size:64;default:'default';not null;uniqueIndex:idx_saved_views_tenant_slug,priority:1" json:"tenant_id"`
+	Slug        string           `gorm:"size:128;not null;uniqueIndex:idx_saved_views_tenant_slug,priority:2" json:"slug"`
+	Name        string           `gorm:"size:255;not null" json:"name"`
+	Kind        string           `gorm:"size:16;not null" json:"kind"` // filter | dashboard
+	Description string           `gorm:"type:text" json:"description,omitempty"`
+	Filters     SavedViewFilters `gorm:"serializer:json" json:"filters"`
+	Layout      json.RawMessage  `gorm:"serializer:json" json:"layout,omitempty"` // dashboard only
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// SavedViewFilters is the filter set of a SavedView. The time range is
+// either relative (Range, a Go duration back from now) or absolute (Start
+// and End, RFC 3339).
+type SavedViewFilters struct {
+	Range    string   `json:"range,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	Services []string `json:"services,omitempty"`
+	Status   string   `json:"status,omitempty"`
+	Query    string   `json:"query,omitempty"`
+}
+
+// APIKey is a managed bearer credential. Only the SHA-256 of the key is
+// stored; Prefix keeps enough of the plaintext to recognise a key in a list.
+// Requests authenticated with a key are pinned to its TenantID; Services
+// further restricts what the key can tail on the live streams.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   string     `gorm:"size:64;default:'default';not null;index" json:"tenant_id"`
+	Name       string     `gorm:"size:255;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scope      string     `gorm:"size:16;not null" json:"scope"`             // ingest | read | write | admin
+	Services   []string   `gorm:"serializer:json" json:"services,omitempty"` // live streams only; empty = all
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// DeletionRecord is the audit trail of one purge: how many rows of one
+// signal a tenant's service lost, the time range they covered and why they
+// were deleted. Records are never purged themselves.
+type DeletionRecord struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    string    `gorm:"size:64;default:'default';not null;index:idx_deletions_tenant_created,priority:1" json:"tenant_id"`
+	Signal      string    `gorm:"size:16;not null" json:"signal"` // logs | traces | metrics
+	ServiceName string    `gorm:"size:255" json:"service_name"`
+	Reason      string    `gorm:"size:32;not null" json:"reason"` // retention | tenant_retention | admin_purge | partition_drop | cold_tier
+	RangeStart  time.Time `json:"range_start"`                    // oldest deleted row
+	RangeEnd    time.Time `json:"range_end"`                      // every row before it was deleted
+	RowCount    int64     `json:"row_count"`
+	CreatedAt   time.Time `gorm:"index:idx_deletions_tenant_created,priority:2" json:"created_at"`
+}
+
+// AuditEvent records one administrative action: who changed an alert rule,
+// a runtime setting or an API key, or deleted data, and when. TenantID is
+// the caller's; Global events act on every tenant. Events are never purged.
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	TenantID   string    `gorm:"size:64;default:'default';not null;index:idx_audit_tenant_created,priority:1" json:"tenant_id"`
+	Actor      string    `gorm:"size:255;not null" json:"actor"`     // email, subject or API key
+	ActorType  string    `gorm:"size:16;not null" json:"actor_type"` // user | api_key | anonymous
+	Action     string    `gorm:"size:64;not null" json:"action"`     // e.g. alert_rule.create
+	Resource   string    `gorm:"size:255" json:"resource"`           // the rule, key, setting or data acted on
+	Details    string    `gorm:"type:text" json:"details,omitempty"` // what changed, in words
+	RemoteAddr string    `gorm:"size:64" json:"remote_addr,omitempty"`
+	Global     bool      `gorm:"not null;default:false;index" json:"global"` // instance-wide action, listed for every tenant
+	CreatedAt  time.Time `gorm:"index:idx_audit_tenant_created,priority:2" json:"created_at"`
+}
+
+// ColdSegment is one Parquet object the cold tier exported: the rows of
+// one signal and tenant, moved out of the hot database. MinTime and MaxTime
+// bound the rows' timestamps so queries only fetch overlapping objects.
+type ColdSegment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  string    `gorm:"size:64;default:'default';not null;index:idx_cold_segments_lookup,priority:1" json:"tenant_id"`
+	Signal    string    `gorm:"size:16;not null;index:idx_cold_segments_lookup,priority:2" json:"signal"` // logs | traces | spans
+	ObjectKey string    `gorm:"size:512;not null" json:"object_key"`
+	MinTime   time.Time `gorm:"not null" json:"min_time"`
+	MaxTime   time.Time `gorm:"not null;index:idx_cold_segments_lookup,priority:3" json:"max_time"`
+	RowCount  int64     `json:"row_count"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RehydrationJob is one on-demand load of tiered logs back into the
+// database: the tenant's log segments overlapping [Start, End] are read and
+// their rows copied into rehydrated_logs, queryable until ExpiresAt. Expired
+// jobs are dropped with their rows by the cold tier pass.
+type RehydrationJob struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    string    `gorm:"size:64;default:'default';not null;index:idx_rehydration_jobs_tenant,priority:1" json:"tenant_id"`
+	Start       time.Time `gorm:"column:range_start;not null" json:"start"`
+	End         time.Time `gorm:"column:range_end;not null" json:"end"`
+	ServiceName string    `gorm:"size:255" json:"service_name,omitempty"`
+	Status      string    `gorm:"size:16;not null" json:"status"` // loading | ready | failed
+	Error       string    `gorm:"size:512" json:"error,omitempty"`
+	Segments    int       `json:"segments"`  // segments read
+	Truncated   bool      `json:"truncated"` // more segments overlapped than were read
+	RowCount    int64     `json:"row_count"`
+	ExpiresAt   time.Time `gorm:"not null;index;index:idx_rehydration_jobs_tenant,priority:2" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RehydratedLog is a log row a RehydrationJob loaded from the cold tier.
+// OriginalID is the row's id in the hot logs table before it was tiered;
+// queries return the row as a Log with that id.
+type RehydratedLog struct {
+	ID             uint `gorm:"primaryKey"`
+	JobID          uint `gorm:"not null;index:idx_rehydrated_logs_job_ts,priority:1"`
+	OriginalID     uint
+	TenantID       string `gorm:"size:64;default:'default';not null"`
+	TraceID        string `gorm:"size:32"`
+	SpanID         string `gorm:"size:16"`
+	Severity       string `gorm:"size:50"`
+	Body           string `gorm:"type:text"`
+	ServiceName    string `gorm:"size:255"`
+	AttributesJSON CompressedText
+	AIInsight      CompressedText
+	Timestamp      time.Time `gorm:"index:idx_rehydrated_logs_job_ts,priority:2"`
+	Region         string    `gorm:"size:64"`
+}
+
+// RuntimeSetting is one setting changed through PATCH /api/config, keyed by
+// its environment variable name and stored in that variable's string
+// format. The rows are applied over the environment and config file at
+// startup, so the changes survive restarts.
+type RuntimeSetting struct {
+	Key       string    `gorm:"primaryKey;size:64" json:"key"`
+	Value     string    `gorm:"type:text;not null" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Monitor kinds.
+const (
+	MonitorKindHTTP = "http"
+	MonitorKindTCP  = "tcp"
+	MonitorKindICMP = "icmp"
+)
+
+// MonitorAvailability is the share of a monitor's checks that were up over
+// one window.
+type MonitorAvailability struct {
+	Checks       int64   `json:"checks"`
+	Up           int64   `json:"up"`
+	Availability float64 `json:"availability"` // percent; 100 without checks
+}
+
+// ListMonitors returns the monitors of the tenant on ctx, oldest first.
+func (r *Repository) ListMonitors(ctx context.Context) ([]Monitor, error) {
+	var monitors []Monitor
+	if err := r.db.WithContext(ctx).
+		Where(sqlWhereTenantID, TenantFromContext(ctx)).
+		Order("id ASC").
+		Find(&monitors).Error; err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %w", err)
+	}
+	return monitors, nil
+}
+
+// ListEnabledMonitorsAllTenants returns every enabled monitor across
+// tenants.
+//
+// Tenant scope: SYSTEM-WIDE, for the uptime scheduler only. Never expose
+// this on a tenant-scoped API surface.
+func (r *Repository) ListEnabledMonitorsAllTenants(ctx context.Context) ([]Monitor, error) {
+	var monitors []Monitor
+	if err := r.db.WithContext(ctx).Where("disabled = ?", false).Order("id ASC").Find(&monitors).Error; err != nil {
+		return nil, fmt.Errorf("failed to list enabled monitors: %w", err)
+	}
+	return monitors, nil
+}
+
+// GetMonitor returns one monitor of the tenant on ctx. Returns
+// gorm.ErrRecordNotFound when the tenant has no such monitor.
+func (r *Repository) GetMonitor(ctx context.Context, id uint) (*Monitor, error) {
+	var m Monitor
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).
+		First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateMonitor inserts m under the tenant on ctx. Its status starts empty
+// until the first check.
+func (r *Repository) CreateMonitor(ctx context.Context, m *Monitor) error {
+	m.ID = 0
+	m.TenantID = TenantFromContext(ctx)
+	m.Status = MonitorStatus{}
+	if err := r.db.WithContext(ctx).Create(m).Error; err != nil {
+		return fmt.Errorf("failed to create monitor: %w", err)
+	}
+	return nil
+}
+
+// UpdateMonitor replaces the definition of a monitor of the tenant on ctx
+// and reloads m from the stored row. The last status and the results are
+// kept. Returns gorm.ErrRecordNotFound when the tenant has no such monitor.
+func (r *Repository) UpdateMonitor(ctx context.Context, id uint, m *Monitor) error {
+	m.UpdatedAt = time.Now()
+	res := r.db.WithContext(ctx).Model(&Monitor{}).
+		Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).
+		Select("name", "kind", "target", "service_name", "interval_secs", "timeout_ms", "expected_status", "disabled", "updated_at").
+		Updates(m)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update monitor: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	stored, err := r.GetMonitor(ctx, id)
+	if err != nil {
+		return err
+	}
+	*m = *stored
+	return nil
+}
+
+// DeleteMonitor removes a monitor of the tenant on ctx together with its
+// results and monitor_down alert rules, resolving their open events.
+// Returns gorm.ErrRecordNotFound when the tenant has no such monitor.
+func (r *Repository) DeleteMonitor(ctx context.Context, id uint) error {
+	tenant := TenantFromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND tenant_id = ?", id, tenant).Delete(&Monitor{})
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete monitor: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("monitor_id = ?", id).Delete(&MonitorResult{}).Error; err != nil {
+			return fmt.Errorf("failed to delete monitor results: %w", err)
+		}
+		var ruleIDs []uint
+		if err := tx.Model(&AlertRule{}).
+			Where("monitor_id = ? AND tenant_id = ?", id, tenant).
+			Pluck("id", &ruleIDs).Error; err != nil {
+			return fmt.Errorf("failed to find monitor alert rules: %w", err)
+		}
+		if len(ruleIDs) == 0 {
+			return nil
+		}
+		if err := tx.Where("id IN ?", ruleIDs).Delete(&AlertRule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete monitor alert rules: %w", err)
+		}
+		return tx.Model(&AlertEvent{}).
+			Where("rule_id IN ? AND state = ?", ruleIDs, AlertStateFiring).
+			Updates(map[string]any{"state": AlertStateResolved, "resolved_at": time.Now()}).Error
+	})
+}
+
+// SaveMonitorResult stores one check of monitor m and its updated status.
+// The status of a monitor deleted meanwhile is silently not written.
+//
+// Tenant scope: SYSTEM-WIDE (the result carries the monitor's tenant), for
+// the uptime scheduler.
+func (r *Repository) SaveMonitorResult(ctx context.Context, m *Monitor, res *MonitorResult) error {
+	res.ID = 0
+	res.TenantID = m.TenantID
+	res.MonitorID = m.ID
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(res).Error; err != nil {
+			return fmt.Errorf("failed to save monitor result: %w", err)
+		}
+		if err := tx.Model(&Monitor{}).Where("id = ?", m.ID).
+			Select("status_checked_at", "status_up", "status_latency_ms", "status_status_code", "status_error",
+				"status_consecutive_failures", "status_availability_24h").
+			Updates(&Monitor{Status: m.Status}).Error; err != nil {
+			return fmt.Errorf("failed to save monitor status: %w", err)
+		}
+		return nil
+	})
+}
+
+// MonitorAvailabilitySince counts the checks of a monitor since since.
+//
+// Tenant scope: keyed by monitor ID; callers must have resolved the
+// monitor under the caller's tenant first.
+func (r *Repository) MonitorAvailabilitySince(ctx context.Context, monitorID uint, since time.Time) (MonitorAvailability, error) {
+	var row struct {
+		Checks int64
+		Up     int64
+	}
+	if err := r.db.WithContext(ctx).Model(&MonitorResult{}).
+		Select("COUNT(*) AS checks, COALESCE(SUM(CASE WHEN up = ? THEN 1 ELSE 0 END), 0) AS up", true).
+		Where("monitor_id = ? AND checked_at >= ?", monitorID, since).
+		Scan(&row).Error; err != nil {
+		return MonitorAvailability{}, fmt.Errorf("failed to count monitor checks: %w", err)
+	}
+	a := MonitorAvailability{Checks: row.Checks, Up: row.Up, Availability: 100}
+	if row.Checks > 0 {
+		a.Availability = 100 * float64(row.Up) / float64(row.Checks)
+	}
+	return a, nil
+}
+
+// ListMonitorResults returns the latest checks of a monitor of the tenant
+// on ctx, newest first.
+func (r *Repository) ListMonitorResults(ctx context.Context, monitorID uint, limit int) ([]MonitorResult, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	var results []MonitorResult
+	if err := r.db.WithContext(ctx).
+		Where("monitor_id = ? AND tenant_id = ?", monitorID, TenantFromContext(ctx)).
+		Order("checked_at DESC").
+		Limit(limit).
+		Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to list monitor results: %w", err)
+	}
+	return results, nil
+}
+
+// PruneMonitorResults deletes checks older than cutoff, returning how many
+// were removed.
+func (r *Repository) PruneMonitorResults(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("checked_at < ?", cutoff).Delete(&MonitorResult{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to prune monitor results: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestMonitors_CRUDResultsAndAvailability(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	acme := WithTenantContext(ctx, "acme")
+	other := WithTenantContext(ctx, "other")
+
+	m := &Monitor{TenantID: "spoofed", Name: "home", Kind: MonitorKindHTTP, Target: "https://example.com", IntervalSecs: 60, TimeoutMs: 5000}
+	if err := repo.CreateMonitor(acme, m); err != nil {
+		t.Fatalf("CreateMonitor: %v", err)
+	}
+	if m.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme from context", m.TenantID)
+	}
+	if got, _ := repo.ListMonitors(other); len(got) != 0 {
+		t.Errorf("other tenant sees %d monitors", len(got))
+	}
+
+	now := time.Now().UTC()
+	for i, up := range []bool{true, true, false, true} {
+		m.Status = MonitorStatus{CheckedAt: &now, Up: up}
+		if !up {
+			m.Status.ConsecutiveFailures = 1
+		}
+		if err := repo.SaveMonitorResult(ctx, m, &MonitorResult{CheckedAt: now.Add(time.Duration(i-4) * time.Minute), Up: up}); err != nil {
+			t.Fatalf("SaveMonitorResult: %v", err)
+		}
+	}
+	old := &MonitorResult{CheckedAt: now.Add(-48 * time.Hour)}
+	if err := repo.SaveMonitorResult(ctx, m, old); err != nil {
+		t.Fatalf("SaveMonitorResult: %v", err)
+	}
+	a, err := repo.MonitorAvailabilitySince(ctx, m.ID, now.Add(-24*time.Hour))
+	if err != nil || a.Checks != 4 || a.Up != 3 || a.Availability != 75 {
+		t.Errorf("availability = %+v, %v; want 3 of 4 up", a, err)
+	}
+	if res, _ := repo.ListMonitorResults(other, m.ID, 0); len(res) != 0 {
+		t.Errorf("other tenant sees %d results", len(res))
+	}
+	if res, _ := repo.ListMonitorResults(acme, m.ID, 2); len(res) != 2 || !res[0].CheckedAt.After(res[1].CheckedAt) {
+		t.Errorf("results = %+v, want the newest 2, newest first", res)
+	}
+	if n, err := repo.PruneMonitorResults(ctx, now.Add(-24*time.Hour)); err != nil || n != 1 {
+		t.Errorf("PruneMonitorResults = %d, %v; want 1", n, err)
+	}
+
+	m.Name, m.Status = "renamed", MonitorStatus{}
+	if err := repo.UpdateMonitor(acme, m.ID, m); err != nil {
+		t.Fatalf("UpdateMonitor: %v", err)
+	}
+	if m.Name != "renamed" || m.Status.CheckedAt == nil {
+		t.Errorf("after update = %+v, want the new name and the last status kept", m)
+	}
+
+	rule := &AlertRule{Name: "home down", Kind: AlertKindMonitorDown, MonitorID: m.ID}
+	if err := repo.CreateAlertRule(acme, rule); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	if err := repo.DeleteMonitor(other, m.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("cross-tenant delete err = %v, want ErrRecordNotFound", err)
+	}
+	if err := repo.DeleteMonitor(acme, m.ID); err != nil {
+		t.Fatalf("DeleteMonitor: %v", err)
+	}
+	if rules, _ := repo.ListAlertRules(acme); len(rules) != 0 {
+		t.Errorf("monitor_down rules left after delete: %+v", rules)
+	}
+	if a, _ := repo.MonitorAvailabilitySince(ctx, m.ID, time.Time{}); a.Checks != 0 {
+		t.Errorf("%d results left after delete", a.Checks)
+	}
+}
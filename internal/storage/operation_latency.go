@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Operation sort orders accepted by GetOperationLatency.
+const (
+	OperationSortImpact    = "impact"     // total time spent, count × average latency
+	OperationSortCount     = "count"      // most calls
+	OperationSortErrorRate = "error_rate" // highest error percentage
+	OperationSortP99       = "p99"        // slowest P99
+	OperationSortName      = "name"       // operation name, ascending
+)
+
+const (
+	defaultOperationLimit = 100
+	maxOperationLimit     = 1000
+)
+
+// OperationQuery selects the operations of one service.
+type OperationQuery struct {
+	ServiceName string
+	Start       time.Time // default End - 1h
+	End         time.Time // default now
+	Sort        string    // OperationSort*; default impact
+	Limit       int       // operations returned (default 100, max 1000)
+}
+
+// OperationLatency is one operation's span count, errors and duration
+// percentiles (microseconds) within a service.
+type OperationLatency struct {
+	OperationName string  `json:"operation_name"`
+	Count         int64   `json:"count"`
+	ErrorCount    int64   `json:"error_count"`
+	ErrorRate     float64 `json:"error_rate"`     // percent
+	TotalDuration int64   `json:"total_duration"` // microseconds; the impact sort key
+	LatencyPercentiles
+}
+
+// ServiceOperations is the per-operation breakdown of one service.
+type ServiceOperations struct {
+	ServiceName     string             `json:"service_name"`
+	Start           time.Time          `json:"start"`
+	End             time.Time          `json:"end"`
+	Sort            string             `json:"sort"`
+	Operations      []OperationLatency `json:"operations"`
+	TotalOperations int                `json:"total_operations"` // before Limit
+}
+
+// ValidOperationSort reports whether s is an OperationSort* value.
+func ValidOperationSort(s string) bool {
+	switch s {
+	case OperationSortImpact, OperationSortCount, OperationSortErrorRate, OperationSortP99, OperationSortName:
+		return true
+	}
+	return false
+}
+
+// GetOperationLatency returns request count, error rate and duration
+// percentiles per span operation of q.ServiceName for the tenant on ctx.
+// Every span of the service counts, not only roots, so an operation is
+// measured wherever it runs in a trace. Accuracy follows
+// durationPercentiles.
+func (r *Repository) GetOperationLatency(ctx context.Context, q OperationQuery) (*ServiceOperations, error) {
+	if q.ServiceName == "" {
+		return nil, fmt.Errorf("service name is required")
+	}
+	if q.Sort == "" {
+		q.Sort = OperationSortImpact
+	}
+	if !ValidOperationSort(q.Sort) {
+		return nil, fmt.Errorf("unknown operation sort %q", q.Sort)
+	}
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-1 * time.Hour)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultOperationLimit
+	}
+	q.Limit = min(q.Limit, maxOperationLimit)
+
+	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRaw, "")
+	base := r.db.WithContext(ctx).Model(&Span{}).
+		Where("tenant_id = ? AND service_name = ? AND start_time BETWEEN ? AND ?", tenant, q.ServiceName, q.Start, q.End)
+
+	var totals []struct {
+		OperationName string
+		Count         int64
+		ErrorCount    int64
+		TotalDuration int64
+	}
+	if err := base.Session(&gorm.Session{}).
+		Select("operation_name, COUNT(*) AS count, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS error_count, COALESCE(SUM(duration), 0) AS total_duration", spanStatusError).
+		Group("operation_name").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to count operations: %w", err)
+	}
+	pcts, err := r.durationPercentiles(ctx, base.Session(&gorm.Session{}), "operation_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute operation latency percentiles: %w", err)
+	}
+	byName := make(map[string]LatencyPercentiles, len(pcts))
+	for _, p := range pcts {
+		byName[p.ServiceName] = p.LatencyPercentiles
+	}
+
+	ops := make([]OperationLatency, 0, len(totals))
+	for _, t := range totals {
+		op := OperationLatency{
+			OperationName:      t.OperationName,
+			Count:              t.Count,
+			ErrorCount:         t.ErrorCount,
+			TotalDuration:      t.TotalDuration,
+			LatencyPercentiles: byName[t.OperationName],
+		}
+		if t.Count > 0 {
+			op.ErrorRate = float64(t.ErrorCount) / float64(t.Count) * 100
+		}
+		ops = append(ops, op)
+	}
+	sortOperations(ops, q.Sort)
+
+	out := &ServiceOperations{
+		ServiceName:     q.ServiceName,
+		Start:           q.Start,
+		End:             q.End,
+		Sort:            q.Sort,
+		TotalOperations: len(ops),
+	}
+	if len(ops) > q.Limit {
+		ops = ops[:q.Limit]
+	}
+	out.Operations = ops
+	return out, nil
+}
+
+// sortOperations orders ops by by, breaking ties on the operation name.
+func sortOperations(ops []OperationLatency, by string) {
+	sort.Slice(ops, func(i, j int) bool {
+		a, b := ops[i], ops[j]
+		switch by {
+		case OperationSortImpact:
+			if a.TotalDuration != b.TotalDuration {
+				return a.TotalDuration > b.TotalDuration
+			}
+		case OperationSortCount:
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+		case OperationSortErrorRate:
+			if a.ErrorRate != b.ErrorRate {
+				return a.ErrorRate > b.ErrorRate
+			}
+		case OperationSortP99:
+			if a.P99 != b.P99 {
+				return a.P99 > b.P99
+			}
+		}
+		return a.OperationName < b.OperationName
+	})
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetOperationLatency(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	var spans []Span
+	add := func(svc, op string, n int, dur time.Duration, errors int) {
+		for i := 0; i < n; i++ {
+			sp := Span{
+				TenantID:      DefaultTenantID,
+				TraceID:       fmt.Sprintf("%s-%s-%d", svc, op, i),
+				SpanID:        fmt.Sprintf("%016d", len(spans)),
+				OperationName: op,
+				ServiceName:   svc,
+				Status:        "STATUS_CODE_OK",
+			}
+			if i < errors {
+				sp.Status = spanStatusError
+			}
+			sp.SetTiming(now.Add(-time.Minute), now.Add(-time.Minute).Add(dur))
+			spans = append(spans, sp)
+		}
+	}
+	add("checkout", "GET /cart", 100, 10*time.Millisecond, 0)    // 1s total
+	add("checkout", "POST /pay", 10, 500*time.Millisecond, 5)    // 5s total
+	add("checkout", "SELECT orders", 40, 50*time.Millisecond, 0) // 2s total
+	add("billing", "POST /pay", 50, time.Second, 0)
+	if err := repo.db.Create(&spans).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	other := Span{TenantID: "acme", TraceID: "acme-1", SpanID: "a", OperationName: "GET /cart", ServiceName: "checkout"}
+	other.SetTiming(now.Add(-time.Minute), now)
+	if err := repo.db.Create(&other).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	got, err := repo.GetOperationLatency(ctx, OperationQuery{ServiceName: "checkout"})
+	if err != nil {
+		t.Fatalf("GetOperationLatency: %v", err)
+	}
+	if got.TotalOperations != 3 || len(got.Operations) != 3 || got.Sort != OperationSortImpact {
+		t.Fatalf("got %+v", got)
+	}
+	pay := got.Operations[0]
+	if pay.OperationName != "POST /pay" || pay.Count != 10 || pay.ErrorCount != 5 || pay.ErrorRate != 50 || pay.TotalDuration != 5_000_000 {
+		t.Errorf("impact first = %+v", pay)
+	}
+	if pay.P99 < 500_000 || pay.P99 > 550_000 {
+		t.Errorf("POST /pay p99 = %d µs, want about 500ms", pay.P99)
+	}
+	if got.Operations[1].OperationName != "SELECT orders" || got.Operations[2].OperationName != "GET /cart" {
+		t.Errorf("impact order = %s, %s", got.Operations[1].OperationName, got.Operations[2].OperationName)
+	}
+
+	got, err = repo.GetOperationLatency(ctx, OperationQuery{ServiceName: "checkout", Sort: OperationSortCount, Limit: 1})
+	if err != nil {
+		t.Fatalf("GetOperationLatency: %v", err)
+	}
+	if len(got.Operations) != 1 || got.TotalOperations != 3 || got.Operations[0].OperationName != "GET /cart" || got.Operations[0].Count != 100 {
+		t.Errorf("count sort, limit 1 = %+v", got)
+	}
+
+	got, err = repo.GetOperationLatency(ctx, OperationQuery{ServiceName: "checkout", Start: now.Add(-time.Hour), End: now.Add(-30 * time.Minute)})
+	if err != nil || len(got.Operations) != 0 {
+		t.Errorf("empty window = %+v, %v", got, err)
+	}
+	if _, err := repo.GetOperationLatency(ctx, OperationQuery{ServiceName: "checkout", Sort: "avg"}); err == nil {
+		t.Error("unknown sort: want error")
+	}
+}
@@ -91,6 +91,7 @@ func setupPostgresPartitionedLogs(db *gorm.DB, lookaheadDays int) error {
 				attributes_json BYTEA,
 				ai_insight BYTEA,
 				timestamp TIMESTAMPTZ NOT NULL,
+				region VARCHAR(64),
 				PRIMARY KEY (id, timestamp)
 			) PARTITION BY RANGE (timestamp)`).Error; err != nil {
 			return fmt.Errorf("create partitioned logs: %w", err)
@@ -104,6 +105,12 @@ func setupPostgresPartitionedLogs(db *gorm.DB, lookaheadDays int) error {
 		return fmt.Errorf("logs table has unexpected relkind=%q", relkind)
 	}
 
+	// AutoMigrate skips the partitioned parent, so columns added to Log
+	// after the table was created are added here. Cascades to partitions.
+	if err := db.Exec(`ALTER TABLE logs ADD COLUMN IF NOT EXISTS region VARCHAR(64)`).Error; err != nil {
+		return fmt.Errorf("add logs.region: %w", err)
+	}
+
 	// Indexes on the parent — auto-cascade to children.
 	parentIndexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_logs_tenant_ts        ON logs (tenant_id, timestamp DESC)`,
@@ -190,8 +197,8 @@ func EnsureLogsLookahead(db *gorm.DB, lookaheadDays int) (int, error) {
 }
 
 // DropExpiredLogsPartitions drops every daily logs partition whose entire
-// upper bound is older than `cutoff`. Returns the number of partitions
-// dropped. Safe to call repeatedly (no-op when nothing matches).
+// upper bound is older than `cutoff`, recording each drop as DeletionRecords.
+// Returns the number of partitions dropped. Safe to call repeatedly (no-op when nothing matches).
 //
 // We use the partition catalog (pg_partitioned_table + pg_inherits) instead
 // of guessing names, so partitions created by earlier code paths or operator
@@ -228,12 +235,21 @@ func DropExpiredLogsPartitions(ctx context.Context, db *gorm.DB, cutoff time.Tim
 		}
 		if !upper.After(cutoffUTC) {
 			// Entire partition range ends at or before the cutoff — safe
-			// to drop. Use IF EXISTS so a concurrent drop from another
-			// scheduler instance doesn't error.
+			// to drop. Count what it holds first so the drop is recorded;
+			// a partition that cannot be counted is kept for the next run.
+			groups, err := countDeletionGroups(db.WithContext(ctx), quoteIdent(r.Name), "timestamp", "")
+			if err != nil {
+				return dropped, fmt.Errorf("count partition %s: %w", r.Name, err)
+			}
+			// Use IF EXISTS so a concurrent drop from another scheduler
+			// instance doesn't error.
 			if err := db.WithContext(ctx).Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, quoteIdent(r.Name))).Error; err != nil {
 				return dropped, fmt.Errorf("drop partition %s: %w", r.Name, err)
 			}
 			slog.Info("🗑️  dropped expired logs partition", "name", r.Name, "upper", upper.Format(time.RFC3339))
+			if err := recordDeletions(db.WithContext(ctx), DeletionSignalLogs, DeletionReasonPartitionDrop, upper, groups); err != nil {
+				slog.Error("deletion audit: write failed", "partition", r.Name, "error", err)
+			}
 			dropped++
 		}
 	}
@@ -219,6 +219,48 @@ func TestPG_PgTrgm_SubstringQueryUsesGIN(t *testing.T) {
 	}
 }
 
+// TestPG_LogFTS_TSVectorSearch verifies that with LOG_FTS_ENABLED the
+// tsvector GIN index is created and both search entry points match through
+// it: stemming ("panicked" for "panic"), prefix terms, and ts_rank order.
+func TestPG_LogFTS_TSVectorSearch(t *testing.T) {
+	t.Setenv("LOG_FTS_ENABLED", "true")
+	repo, teardown := setupPGContainer(t)
+	defer teardown()
+
+	var present int
+	if err := repo.db.Raw("SELECT 1 FROM pg_indexes WHERE tablename = 'logs' AND indexname = ?", pgLogsFTSIndex).
+		Row().Scan(&present); err != nil || present != 1 {
+		t.Fatalf("index %s not created: %v", pgLogsFTSIndex, err)
+	}
+
+	now := time.Now().UTC()
+	for _, body := range []string{
+		"worker panicked: nil map",
+		"panic panic panic in scheduler",
+		"connection refused",
+	} {
+		if err := repo.db.Create(&Log{Severity: "ERROR", Body: body, ServiceName: "api", Timestamp: now}).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	logs, err := repo.SearchLogs(context.Background(), "panic", 10)
+	if err != nil {
+		t.Fatalf("SearchLogs: %v", err)
+	}
+	if len(logs) != 2 || !strings.HasPrefix(logs[0].Body, "panic panic") {
+		t.Fatalf("SearchLogs(panic) = %+v, want 2 matches ranked by ts_rank", logs)
+	}
+
+	got, total, err := repo.GetLogsV2(context.Background(), LogFilter{Search: "conn", Limit: 10})
+	if err != nil {
+		t.Fatalf("GetLogsV2: %v", err)
+	}
+	if total != 1 || len(got) != 1 || got[0].Body != "connection refused" {
+		t.Fatalf("GetLogsV2(conn) = %+v (total %d), want the prefix match", got, total)
+	}
+}
+
 // TestPG_VacuumAnalyze_OutsideTx proves that runMaintenance uses the raw
 // *sql.DB escape hatch and that VACUUM ANALYZE succeeds on Postgres. GORM's
 // default path would wrap the statement in a transaction and Postgres would
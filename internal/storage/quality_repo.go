@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// qualityAttrSampleCap bounds how many recent spans are parsed for
+	// attribute cardinality.
+	qualityAttrSampleCap = 5_000
+	// qualitySkewPairCap bounds how many cross-service parent/child span
+	// pairs are compared for clock skew.
+	qualitySkewPairCap = 20_000
+
+	// qualityCardinalityThreshold is the distinct value count at which an
+	// attribute key is reported as a cardinality offender.
+	qualityCardinalityThreshold = 100
+	// qualityMaxOffenders caps the offenders reported per service.
+	qualityMaxOffenders = 10
+
+	// qualitySkewTolerance absorbs timestamp rounding: a child that starts
+	// or ends within it outside its parent's bounds is not counted as
+	// skewed.
+	qualitySkewTolerance = time.Millisecond
+)
+
+// DataQualityQuery selects a data quality report.
+type DataQualityQuery struct {
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string
+}
+
+// AttributeCardinality is a span attribute key with many distinct values
+// in one service — typically an ID, timestamp or URL with parameters that
+// belongs in a span event or should be templated.
+type AttributeCardinality struct {
+	Key            string `json:"key"`
+	DistinctValues int    `json:"distinct_values"`
+	SampledSpans   int    `json:"sampled_spans"` // spans of the service that carried the key
+}
+
+// ClockSkewEstimate compares a service's spans with their parents in other
+// services. A child that starts before its parent or ends after it implies
+// the two hosts' clocks disagree.
+type ClockSkewEstimate struct {
+	PairsChecked int     `json:"pairs_checked"`
+	SkewedPairs  int     `json:"skewed_pairs"`
+	SkewedPct    float64 `json:"skewed_pct"`
+	// MedianSkewMs is the median offset of the skewed pairs: negative when
+	// the service's clock runs behind its callers', positive when ahead.
+	MedianSkewMs float64 `json:"median_skew_ms"`
+	MaxSkewMs    float64 `json:"max_skew_ms"` // largest absolute offset
+}
+
+// ServiceDataQuality is the telemetry hygiene of one service in a window.
+type ServiceDataQuality struct {
+	ServiceName           string                 `json:"service_name"`
+	Spans                 int64                  `json:"spans"`
+	SpansMissingStatus    int64                  `json:"spans_missing_status"` // STATUS_CODE_UNSET
+	SpansMissingStatusPct float64                `json:"spans_missing_status_pct"`
+	Logs                  int64                  `json:"logs"`
+	LogsWithoutTrace      int64                  `json:"logs_without_trace"`
+	LogsWithoutTracePct   float64                `json:"logs_without_trace_pct"`
+	AttributeOffenders    []AttributeCardinality `json:"attribute_offenders"`
+	ClockSkew             *ClockSkewEstimate     `json:"clock_skew,omitempty"` // nil without cross-service parents
+}
+
+// GetDataQuality reports per-service telemetry hygiene in [Start, End],
+// scoped to the tenant and region on ctx: how many spans lack a status, how
+// many logs lack trace context, which span attributes explode in
+// cardinality, and how far each service's clock appears to drift from its
+// callers'. Services are ordered by name.
+//
+// Status and trace-context shares are exact; cardinality is estimated from
+// the newest qualityAttrSampleCap spans and skew from at most
+// qualitySkewPairCap parent/child pairs.
+func (r *Repository) GetDataQuality(ctx context.Context, q DataQualityQuery) ([]ServiceDataQuality, error) {
+	tenant := TenantFromContext(ctx)
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-time.Hour)
+	}
+	services := make(map[string]*ServiceDataQuality)
+	get := func(name string) *ServiceDataQuality {
+		s, ok := services[name]
+		if !ok {
+			s = &ServiceDataQuality{ServiceName: name, AttributeOffenders: []AttributeCardinality{}}
+			services[name] = s
+		}
+		return s
+	}
+
+	spans := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Span{}).
+		Where("tenant_id = ? AND start_time BETWEEN ? AND ?", tenant, q.Start, q.End))
+	logs := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Log{}).
+		Where(sqlWhereTenantTimeBetween, tenant, q.Start, q.End))
+	if len(q.ServiceNames) > 0 {
+		spans = spans.Where(sqlWhereServiceIn, q.ServiceNames)
+		logs = logs.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+
+	var spanCounts []struct {
+		ServiceName string
+		Total       int64
+		Unset       int64
+	}
+	if err := spans.Session(&gorm.Session{}).
+		Select("service_name, COUNT(*) AS total, SUM(CASE WHEN status = ? OR status = '' OR status IS NULL THEN 1 ELSE 0 END) AS unset", spanStatusUnset).
+		Group("service_name").Scan(&spanCounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count spans for data quality: %w", err)
+	}
+	for _, c := range spanCounts {
+		s := get(c.ServiceName)
+		s.Spans, s.SpansMissingStatus = c.Total, c.Unset
+		s.SpansMissingStatusPct = pct(c.Unset, c.Total)
+	}
+
+	var logCounts []struct {
+		ServiceName string
+		Total       int64
+		Untraced    int64
+	}
+	if err := logs.Session(&gorm.Session{}).
+		Select("service_name, COUNT(*) AS total, SUM(CASE WHEN trace_id = '' OR trace_id IS NULL THEN 1 ELSE 0 END) AS untraced").
+		Group("service_name").Scan(&logCounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count logs for data quality: %w", err)
+	}
+	for _, c := range logCounts {
+		s := get(c.ServiceName)
+		s.Logs, s.LogsWithoutTrace = c.Total, c.Untraced
+		s.LogsWithoutTracePct = pct(c.Untraced, c.Total)
+	}
+
+	var sample []Span
+	if err := spans.Session(&gorm.Session{}).
+		Select("service_name, attributes_json").
+		Order("start_time desc").Limit(qualityAttrSampleCap).Find(&sample).Error; err != nil {
+		return nil, fmt.Errorf("failed to sample spans for data quality: %w", err)
+	}
+	for name, offenders := range attributeOffenders(sample) {
+		get(name).AttributeOffenders = offenders
+	}
+
+	skew, err := r.clockSkew(ctx, tenant, q)
+	if err != nil {
+		return nil, err
+	}
+	for name, est := range skew {
+		get(name).ClockSkew = est
+	}
+
+	out := make([]ServiceDataQuality, 0, len(services))
+	for _, s := range services {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ServiceName < out[j].ServiceName })
+	return out, nil
+}
+
+// qualityDistinctCap stops counting an attribute's distinct values past this
+// many, bounding memory; DistinctValues is then a lower bound.
+const qualityDistinctCap = 10 * qualityCardinalityThreshold
+
+// attributeOffenders returns, per service, the scalar span attribute keys
+// with at least qualityCardinalityThreshold distinct values in sample,
+// highest first.
+func attributeOffenders(sample []Span) map[string][]AttributeCardinality {
+	type keyStats struct {
+		values map[string]struct{}
+		spans  int
+	}
+	byService := make(map[string]map[string]*keyStats)
+	for i := range sample {
+		keys := byService[sample[i].ServiceName]
+		if keys == nil {
+			keys = make(map[string]*keyStats)
+			byService[sample[i].ServiceName] = keys
+		}
+		for _, kv := range parseAttributes(string(sample[i].AttributesJSON)) {
+			v, ok := kv.scalar()
+			if !ok {
+				continue
+			}
+			ks := keys[kv.Key]
+			if ks == nil {
+				ks = &keyStats{values: make(map[string]struct{})}
+				keys[kv.Key] = ks
+			}
+			ks.spans++
+			if len(ks.values) < qualityDistinctCap {
+				ks.values[v] = struct{}{}
+			}
+		}
+	}
+
+	out := make(map[string][]AttributeCardinality)
+	for service, keys := range byService {
+		var offenders []AttributeCardinality
+		for key, ks := range keys {
+			if len(ks.values) >= qualityCardinalityThreshold {
+				offenders = append(offenders, AttributeCardinality{Key: key, DistinctValues: len(ks.values), SampledSpans: ks.spans})
+			}
+		}
+		if len(offenders) == 0 {
+			continue
+		}
+		sort.Slice(offenders, func(i, j int) bool {
+			if offenders[i].DistinctValues != offenders[j].DistinctValues {
+				return offenders[i].DistinctValues > offenders[j].DistinctValues
+			}
+			return offenders[i].Key < offenders[j].Key
+		})
+		out[service] = offenders[:min(len(offenders), qualityMaxOffenders)]
+	}
+	return out
+}
+
+// clockSkew estimates each child service's clock offset from its parent
+// spans in other services. A child starting before its parent puts its
+// clock behind by the gap; one ending after its parent puts it ahead.
+// Asynchronous children (fire-and-forget producers, consumers) can
+// legitimately outlive their parent, so a positive estimate on a messaging
+// service deserves a second look before blaming NTP.
+func (r *Repository) clockSkew(ctx context.Context, tenant string, q DataQualityQuery) (map[string]*ClockSkewEstimate, error) {
+	query := r.db.WithContext(ctx).Table("spans AS c").
+		Select("c.service_name AS service_name, c.start_time AS child_start, c.end_time AS child_end, p.start_time AS parent_start, p.end_time AS parent_end").
+		Joins("JOIN spans p ON p.tenant_id = c.tenant_id AND p.trace_id = c.trace_id AND p.span_id = c.parent_span_id").
+		Where("c.tenant_id = ? AND c.start_time BETWEEN ? AND ? AND c.service_name <> p.service_name", tenant, q.Start, q.End)
+	if region := RegionFilterFromContext(ctx); region != "" {
+		query = query.Where("c.region = ?", region)
+	}
+	if len(q.ServiceNames) > 0 {
+		query = query.Where("c.service_name IN ?", q.ServiceNames)
+	}
+	var pairs []struct {
+		ServiceName string
+		ChildStart  time.Time
+		ChildEnd    time.Time
+		ParentStart time.Time
+		ParentEnd   time.Time
+	}
+	if err := query.Limit(qualitySkewPairCap).Scan(&pairs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch span pairs for clock skew: %w", err)
+	}
+
+	offsets := make(map[string][]time.Duration)
+	out := make(map[string]*ClockSkewEstimate)
+	for _, p := range pairs {
+		est := out[p.ServiceName]
+		if est == nil {
+			est = &ClockSkewEstimate{}
+			out[p.ServiceName] = est
+		}
+		est.PairsChecked++
+		var off time.Duration
+		switch {
+		case p.ChildStart.Before(p.ParentStart.Add(-qualitySkewTolerance)):
+			off = p.ChildStart.Sub(p.ParentStart)
+		case p.ChildEnd.After(p.ParentEnd.Add(qualitySkewTolerance)):
+			off = p.ChildEnd.Sub(p.ParentEnd)
+		default:
+			continue
+		}
+		est.SkewedPairs++
+		offsets[p.ServiceName] = append(offsets[p.ServiceName], off)
+	}
+	for service, est := range out {
+		est.SkewedPct = pct(int64(est.SkewedPairs), int64(est.PairsChecked))
+		offs := offsets[service]
+		if len(offs) == 0 {
+			continue
+		}
+		sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+		est.MedianSkewMs = durationMs(offs[len(offs)/2])
+		est.MaxSkewMs = max(durationMs(-offs[0]), durationMs(offs[len(offs)-1]))
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetDataQuality(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	var spans []Span
+	for i := range 120 {
+		status := spanStatusOK
+		if i%4 == 0 {
+			status = spanStatusUnset
+		}
+		spans = append(spans, Span{
+			TenantID: DefaultTenantID, TraceID: fmt.Sprintf("t%d", i), SpanID: "root", ServiceName: "api",
+			StartTime: now.Add(-time.Minute), EndTime: now.Add(-time.Minute + 100*time.Millisecond), Status: status,
+			AttributesJSON: CompressedText(fmt.Sprintf(`[{"key":"user.id","value":{"Value":{"StringValue":"u%d"}}},{"key":"http.route","value":{"Value":{"StringValue":"/pay"}}}]`, i)),
+		})
+	}
+	// db's clock runs 40ms behind api's: its child spans start before their parent.
+	for i := range 4 {
+		spans = append(spans, Span{
+			TenantID: DefaultTenantID, TraceID: fmt.Sprintf("t%d", i), SpanID: "child", ParentSpanID: "root", ServiceName: "db",
+			StartTime: now.Add(-time.Minute - 40*time.Millisecond), EndTime: now.Add(-time.Minute), Status: spanStatusOK,
+		})
+	}
+	// A consistent child of db's own service is ignored for skew.
+	spans = append(spans, Span{TenantID: DefaultTenantID, TraceID: "t9", SpanID: "c2", ParentSpanID: "root", ServiceName: "api",
+		StartTime: now.Add(-time.Minute), EndTime: now.Add(-time.Minute + time.Millisecond), Status: spanStatusOK})
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.BatchCreateLogs([]Log{
+		{TenantID: DefaultTenantID, ServiceName: "api", Severity: "INFO", Body: "a", TraceID: "t1", Timestamp: now.Add(-time.Minute)},
+		{TenantID: DefaultTenantID, ServiceName: "api", Severity: "INFO", Body: "b", Timestamp: now.Add(-time.Minute)},
+		{TenantID: DefaultTenantID, ServiceName: "worker", Severity: "INFO", Body: "c", Timestamp: now.Add(-time.Minute)},
+		{TenantID: "other", ServiceName: "api", Severity: "INFO", Body: "d", Timestamp: now.Add(-time.Minute)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.GetDataQuality(ctx, DataQualityQuery{Start: now.Add(-time.Hour), End: now})
+	if err != nil {
+		t.Fatalf("GetDataQuality: %v", err)
+	}
+	if len(got) != 3 || got[0].ServiceName != "api" || got[1].ServiceName != "db" || got[2].ServiceName != "worker" {
+		t.Fatalf("services = %+v", got)
+	}
+	api, db, worker := got[0], got[1], got[2]
+	if api.Spans != 121 || api.SpansMissingStatus != 30 || api.Logs != 2 || api.LogsWithoutTrace != 1 || api.LogsWithoutTracePct != 50 {
+		t.Errorf("api = %+v", api)
+	}
+	if len(api.AttributeOffenders) != 1 || api.AttributeOffenders[0] != (AttributeCardinality{Key: "user.id", DistinctValues: 120, SampledSpans: 120}) {
+		t.Errorf("api offenders = %+v", api.AttributeOffenders)
+	}
+	if api.ClockSkew != nil {
+		t.Errorf("api skew = %+v, want nil (no cross-service parent)", api.ClockSkew)
+	}
+	if db.ClockSkew == nil || db.ClockSkew.PairsChecked != 4 || db.ClockSkew.SkewedPct != 100 || db.ClockSkew.MedianSkewMs != -40 || db.ClockSkew.MaxSkewMs != 40 {
+		t.Errorf("db skew = %+v", db.ClockSkew)
+	}
+	if worker.Logs != 1 || worker.LogsWithoutTracePct != 100 || worker.Spans != 0 {
+		t.Errorf("worker = %+v", worker)
+	}
+
+	got, err = repo.GetDataQuality(ctx, DataQualityQuery{Start: now.Add(-time.Hour), End: now, ServiceNames: []string{"db"}})
+	if err != nil || len(got) != 1 || got[0].ServiceName != "db" {
+		t.Errorf("service filter = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// SetRegion sets the region identity stamped on every trace, span, log and
+// metric bucket this repository writes, unless the row already carries one
+// (e.g. a DLQ batch written by another region). Must be called before
+// ingest starts; "" leaves rows unstamped.
+func (r *Repository) SetRegion(region string) { r.region = region }
+
+// Region returns the configured region identity, "" when unset.
+func (r *Repository) Region() string { return r.region }
+
+// stampRegion fills the region column of rows that have none.
+func stampRegion[T any](rows []T, region string, field func(*T) *string) {
+	if region == "" {
+		return
+	}
+	for i := range rows {
+		if f := field(&rows[i]); *f == "" {
+			*f = region
+		}
+	}
+}
+
+// regionCtxKey carries an optional region filter for read queries.
+type regionCtxKey struct{}
+
+// WithRegionFilter returns a copy of ctx that restricts trace, log and
+// service map queries to rows stamped with region. "" leaves ctx as-is
+// (all regions). Unlike the tenant, the region is a filter, not a scope:
+// every region's data stays visible when no filter is set.
+func WithRegionFilter(ctx context.Context, region string) context.Context {
+	if region == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, regionCtxKey{}, region)
+}
+
+// RegionFilterFromContext returns the region filter set by
+// WithRegionFilter, or "".
+func RegionFilterFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	region, _ := ctx.Value(regionCtxKey{}).(string)
+	return region
+}
+
+// scopeRegion applies the region filter on ctx, if any, to q.
+func scopeRegion(ctx context.Context, q *gorm.DB) *gorm.DB {
+	if region := RegionFilterFromContext(ctx); region != "" {
+		return q.Where("region = ?", region)
+	}
+	return q
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegion_StampedAndFiltered(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	repo.SetRegion("eu-west-1")
+	if err := repo.BatchCreateAll(
+		[]Trace{{TraceID: "eu", ServiceName: "api", Timestamp: now}},
+		[]Span{{TraceID: "eu", SpanID: "s1", ServiceName: "api", StartTime: now, EndTime: now}},
+		[]Log{{TraceID: "eu", Body: "from eu", ServiceName: "api", Timestamp: now}},
+	); err != nil {
+		t.Fatalf("BatchCreateAll: %v", err)
+	}
+	// A row that already carries a region (e.g. a DLQ batch from the other
+	// instance) keeps it.
+	if err := repo.BatchCreateLogs([]Log{{Body: "from us", ServiceName: "api", Timestamp: now, Region: "us-east-1"}}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+	if err := repo.BatchCreateMetrics([]MetricBucket{{Name: "m", ServiceName: "api", TimeBucket: now}}); err != nil {
+		t.Fatalf("BatchCreateMetrics: %v", err)
+	}
+
+	var region string
+	repo.db.Model(&MetricBucket{}).Select("region").Scan(&region)
+	if region != "eu-west-1" {
+		t.Errorf("metric bucket region = %q, want eu-west-1", region)
+	}
+
+	logs, total, err := repo.GetLogsV2(WithRegionFilter(ctx, "us-east-1"), LogFilter{Limit: 10})
+	if err != nil || total != 1 || logs[0].Body != "from us" {
+		t.Fatalf("us-east-1 logs = %+v (%d), %v", logs, total, err)
+	}
+	if _, total, _ = repo.GetLogsV2(ctx, LogFilter{Limit: 10}); total != 2 {
+		t.Errorf("unfiltered logs = %d, want 2", total)
+	}
+
+	resp, err := repo.GetTracesFiltered(WithRegionFilter(ctx, "eu-west-1"), time.Time{}, time.Time{}, nil, "", "", 10, 0, "", "")
+	if err != nil || resp.Total != 1 || resp.Traces[0].Region != "eu-west-1" {
+		t.Fatalf("eu-west-1 traces = %+v, %v", resp, err)
+	}
+	resp, _ = repo.GetTracesFiltered(WithRegionFilter(ctx, "us-east-1"), time.Time{}, time.Time{}, nil, "", "", 10, 0, "", "")
+	if resp.Total != 0 {
+		t.Errorf("us-east-1 traces = %d, want 0", resp.Total)
+	}
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rehydration job states, on RehydrationJob.Status.
+const (
+	RehydrationStatusLoading = "loading"
+	RehydrationStatusReady   = "ready"
+	RehydrationStatusFailed  = "failed"
+)
+
+// maxRehydrationError caps RehydrationJob.Error, matching the column.
+const maxRehydrationError = 512
+
+// RehydratedLogQuery filters RehydratedLogs. Zero fields match everything.
+type RehydratedLogQuery struct {
+	Start, End  time.Time
+	ServiceName string
+	Severity    string
+	TraceID     string
+	Search      string // case-insensitive substring of the body
+	Limit       int    // default 100, max 1000
+}
+
+// CreateRehydrationJob inserts job under the tenant on ctx, loading.
+func (r *Repository) CreateRehydrationJob(ctx context.Context, job *RehydrationJob) error {
+	job.ID = 0
+	job.TenantID = TenantFromContext(ctx)
+	job.Status = RehydrationStatusLoading
+	if err := r.db.WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create rehydration job: %w", err)
+	}
+	return nil
+}
+
+// AppendRehydratedLogs copies logs into job's rehydrated_logs rows.
+func (r *Repository) AppendRehydratedLogs(ctx context.Context, job *RehydrationJob, logs []Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	rows := make([]RehydratedLog, len(logs))
+	for i, l := range logs {
+		rows[i] = RehydratedLog{
+			JobID: job.ID, OriginalID: l.ID, TenantID: job.TenantID,
+			TraceID: l.TraceID, SpanID: l.SpanID, Severity: l.Severity, Body: l.Body, ServiceName: l.ServiceName,
+			AttributesJSON: l.AttributesJSON, AIInsight: l.AIInsight, Timestamp: l.Timestamp, Region: l.Region,
+		}
+	}
+	if err := r.db.WithContext(ctx).CreateInBatches(rows, 500).Error; err != nil {
+		return fmt.Errorf("failed to store rehydrated logs: %w", err)
+	}
+	job.RowCount += int64(len(rows))
+	return nil
+}
+
+// FinishRehydrationJob marks job ready, or failed with loadErr, in which
+// case the rows it loaded so far are deleted.
+func (r *Repository) FinishRehydrationJob(ctx context.Context, job *RehydrationJob, loadErr error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		job.Status = RehydrationStatusReady
+		if loadErr != nil {
+			job.Status, job.Error, job.RowCount = RehydrationStatusFailed, TruncateUTF8(loadErr.Error(), maxRehydrationError), 0
+			if err := tx.Where("job_id = ?", job.ID).Delete(&RehydratedLog{}).Error; err != nil {
+				return fmt.Errorf("failed to drop rows of failed rehydration: %w", err)
+			}
+		}
+		err := tx.Model(&RehydrationJob{}).Where("id = ?", job.ID).Updates(map[string]any{
+			"status": job.Status, "error": job.Error, "segments": job.Segments, "truncated": job.Truncated, "row_count": job.RowCount,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to finish rehydration job: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListRehydrationJobs returns the unexpired rehydration jobs of the tenant
+// on ctx, newest first.
+func (r *Repository) ListRehydrationJobs(ctx context.Context) ([]RehydrationJob, error) {
+	var jobs []RehydrationJob
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND expires_at > ?", TenantFromContext(ctx), time.Now().UTC()).
+		Order("id DESC").Find(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rehydration jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// GetRehydrationJob returns the tenant's unexpired job id. Returns
+// gorm.ErrRecordNotFound when there is none.
+func (r *Repository) GetRehydrationJob(ctx context.Context, id uint) (*RehydrationJob, error) {
+	var job RehydrationJob
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ? AND expires_at > ?", id, TenantFromContext(ctx), time.Now().UTC()).
+		First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to load rehydration job: %w", err)
+	}
+	return &job, nil
+}
+
+// DeleteRehydrationJob drops the tenant's job id and its rows before it
+// expires. Returns gorm.ErrRecordNotFound when there is no such job.
+func (r *Repository) DeleteRehydrationJob(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).Delete(&RehydrationJob{})
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete rehydration job: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("job_id = ?", id).Delete(&RehydratedLog{}).Error; err != nil {
+			return fmt.Errorf("failed to delete rehydrated logs: %w", err)
+		}
+		return nil
+	})
+}
+
+// PurgeExpiredRehydrations drops the jobs of every tenant that expired
+// before now, with their rows, and returns the jobs dropped.
+func (r *Repository) PurgeExpiredRehydrations(ctx context.Context, now time.Time) (int64, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&RehydrationJob{}).Where("expires_at <= ?", now).Pluck("id", &ids).Error; err != nil {
+		return 0, fmt.Errorf("failed to list expired rehydrations: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_id IN ?", ids).Delete(&RehydratedLog{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&RehydrationJob{}).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired rehydrations: %w", err)
+	}
+	return int64(len(ids)), nil
+}
+
+// RehydratedLogs searches the rows of job, newest first, returned as the
+// Logs they were before tiering.
+func (r *Repository) RehydratedLogs(ctx context.Context, job *RehydrationJob, q RehydratedLogQuery) ([]Log, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	q.Limit = min(q.Limit, 1000)
+	query := r.db.WithContext(ctx).Where("job_id = ? AND tenant_id = ?", job.ID, job.TenantID)
+	if !q.Start.IsZero() {
+		query = query.Where("timestamp >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		query = query.Where("timestamp <= ?", q.End)
+	}
+	if q.ServiceName != "" {
+		query = query.Where("service_name = ?", q.ServiceName)
+	}
+	if q.Severity != "" {
+		query = query.Where("UPPER(severity) = ?", strings.ToUpper(q.Severity))
+	}
+	if q.TraceID != "" {
+		query = query.Where("trace_id = ?", q.TraceID)
+	}
+	if q.Search != "" {
+		query = query.Where("LOWER(body) LIKE ?", "%"+strings.ToLower(q.Search)+"%")
+	}
+	var rows []RehydratedLog
+	if err := query.Order("timestamp DESC, id DESC").Limit(q.Limit).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search rehydrated logs: %w", err)
+	}
+	out := make([]Log, len(rows))
+	for i, row := range rows {
+		out[i] = Log{
+			ID: row.OriginalID, TenantID: row.TenantID, TraceID: row.TraceID, SpanID: row.SpanID,
+			Severity: row.Severity, Body: row.Body, ServiceName: row.ServiceName,
+			AttributesJSON: row.AttributesJSON, AIInsight: row.AIInsight, Timestamp: row.Timestamp, Region: row.Region,
+		}
+	}
+	return out, nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // partitionLookaheadFromEnv reads DB_PARTITION_LOOKAHEAD_DAYS, defaulting to
@@ -110,6 +111,21 @@ type Repository struct {
 	// bool that "works because the writer ran first" — no test catches a
 	// torn read on amd64, but the contract is brittle.
 	logsPartitioned atomic.Bool
+
+	// logsSharded is set by SQLiteShardScheduler.Start: `logs` is then a
+	// TEMP VIEW over main.logs and the day shards, and writes must name
+	// main.logs. See logsTable.
+	logsSharded atomic.Bool
+
+	// filters records the query shapes behind AdviseIndexes.
+	filters filterStats
+
+	// region is stamped on ingested rows; see SetRegion.
+	region string
+
+	// attrIndex selects the span attributes copied into span_attributes;
+	// see SetAttributeIndex.
+	attrIndex attributeIndex
 }
 
 // LogsPartitioned reports whether the `logs` table is provisioned as a
@@ -121,6 +137,27 @@ func (r *Repository) LogsPartitioned() bool { return r.logsPartitioned.Load() }
 // setup path (factory.go) once the partitioned schema is in place.
 func (r *Repository) MarkLogsPartitioned() { r.logsPartitioned.Store(true) }
 
+// LogsSharded reports whether DB_SQLITE_SHARDING=daily moved logs retention
+// to SQLiteShardScheduler.
+func (r *Repository) LogsSharded() bool { return r.logsSharded.Load() }
+
+func (r *Repository) markLogsSharded() { r.logsSharded.Store(true) }
+
+// logsTable is the table log writes target: main.logs once sharding has
+// shadowed `logs` with a read-only view, plain logs otherwise.
+func (r *Repository) logsTable() string {
+	if r.LogsSharded() {
+		return "main.logs"
+	}
+	return "logs"
+}
+
+// logsInsert scopes db's INSERTs to logsTable. GORM builds the INSERT target
+// from the model, ignoring Table(), so the clause has to be explicit.
+func (r *Repository) logsInsert(db *gorm.DB) *gorm.DB {
+	return db.Clauses(clause.Insert{Table: clause.Table{Name: r.logsTable()}})
+}
+
 // NewRepository initializes the database connection using environment variables and migrates the schema.
 func NewRepository(metrics *telemetry.Metrics) (*Repository, error) {
 	driver := os.Getenv("DB_DRIVER")
@@ -308,14 +345,19 @@ func (r *Repository) RecentLogs(ctx context.Context, limit int) ([]Log, error) {
 
 // SearchLogs searches for logs based on query, scoped to the tenant carried on ctx.
 //
-// On SQLite the search routes through the FTS5 virtual table (`logs_fts`) and
-// orders by BM25 score for relevance. On Postgres / MySQL / others it falls
-// back to LIKE/ILIKE against logs.body and logs.service_name (Postgres uses
-// the pg_trgm GIN indexes built in AutoMigrateModels).
+// With LOG_FTS_ENABLED on, SQLite routes the search through the FTS5 virtual
+// table (`logs_fts`, BM25 order) and Postgres through the tsvector GIN index
+// (ts_rank order). Otherwise — and on MySQL / SQL Server — it uses LIKE/ILIKE
+// against logs.body and logs.service_name (Postgres uses the pg_trgm GIN
+// indexes built in AutoMigrateModels).
 func (r *Repository) SearchLogs(ctx context.Context, query string, limit int) ([]Log, error) {
 	tenant := TenantFromContext(ctx)
-	if query != "" && fts5Available(r.driver) {
-		return r.searchLogsFTS5(ctx, tenant, query, limit)
+	// The FTS index only covers main.logs; sharded search goes through LIKE
+	// on the view so older days are not silently missed.
+	if query != "" && !r.LogsSharded() {
+		if fts, ok := r.logFTS(query); ok {
+			return r.searchLogsFTS(ctx, tenant, query, fts, limit)
+		}
 	}
 	var logs []Log
 	db := r.db.WithContext(ctx).Where(sqlWhereTenantID, tenant).Order(sqlOrderTimestampDesc).Limit(limit)
@@ -329,34 +371,29 @@ func (r *Repository) SearchLogs(ctx context.Context, query string, limit int) ([
 	return logs, nil
 }
 
-// searchLogsFTS5 runs the FTS5 + BM25 path. The MATCH expression is built via
-// fts5MatchExpr to keep user input safe from FTS5 query syntax. Results are
-// returned ordered by BM25 score (lower = more relevant in SQLite's
-// implementation, which returns negative scores).
-func (r *Repository) searchLogsFTS5(ctx context.Context, tenant, query string, limit int) ([]Log, error) {
-	matchExpr := fts5MatchExpr(query)
-	if matchExpr == "" {
-		var logs []Log
-		err := r.db.WithContext(ctx).Where(sqlWhereTenantID, tenant).Order(sqlOrderTimestampDesc).Limit(limit).Find(&logs).Error
-		return logs, err
-	}
+// searchLogsFTS runs the indexed full-text path built by logFTS. User input
+// only reaches the index through fts5MatchExpr / pgTSQueryExpr, which keep it
+// safe from the query syntax. Results are ordered by relevance.
+func (r *Repository) searchLogsFTS(ctx context.Context, tenant, query string, fts logFTSQuery, limit int) ([]Log, error) {
 	var logs []Log
-	err := r.db.WithContext(ctx).
-		Table("logs").
-		Joins("JOIN "+fts5LogsTable+" ON logs.id = "+fts5LogsTable+".rowid").
-		Where("logs.tenant_id = ? AND "+fts5LogsTable+" MATCH ?", tenant, matchExpr).
-		Order("bm25(" + fts5LogsTable + ") ASC").
+	db := r.db.WithContext(ctx).Table("logs")
+	if fts.join != "" {
+		db = db.Joins(fts.join)
+	}
+	err := db.
+		Where("logs.tenant_id = ? AND "+fts.where, tenant, fts.arg).
+		Order(fts.order).
 		Limit(limit).
 		Find(&logs).Error
 	if err != nil {
-		// FTS5 setup is provisioned at migrate-time and the trigger keeps
-		// it in sync — a query error here means something genuinely went
+		// The index is provisioned at migrate-time and kept in sync on
+		// insert — a query error here means something genuinely went
 		// wrong (corrupt index, missing table on a half-migrated DB). We
 		// fall back to LIKE so the API stays available, but log loudly
 		// so the operator notices and can rebuild the index. CLAUDE.md
 		// "fix root cause" rule: the fallback is the seatbelt, the log is
 		// the dashboard light.
-		slog.Warn("FTS5 search failed, falling back to LIKE", "tenant", tenant, "query", query, "error", err)
+		slog.Warn("full-text search failed, falling back to LIKE", "driver", r.driver, "tenant", tenant, "query", query, "error", err)
 		return r.searchLogsLikeFallback(ctx, tenant, query, limit)
 	}
 	return logs, nil
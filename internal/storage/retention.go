@@ -4,23 +4,86 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// purgeProgressEvery is how many batches a long purge runs between progress
+// log lines.
+const purgeProgressEvery = 10
+
+// logPurgeProgress reports a long-running batched purge so operators can tell
+// a slow backlog drain from a stuck one.
+func logPurgeProgress(table string, batches int, total int64) {
+	if batches%purgeProgressEvery == 0 {
+		slog.Info("retention purge in progress", "table", table, "batches", batches, "rows_deleted", total)
+	}
+}
+
+// RetentionPolicy overrides the hot retention window per signal. A zero field
+// falls back to the scheduler's retentionDays.
+type RetentionPolicy struct {
+	Logs    time.Duration
+	Traces  time.Duration // traces and their spans
+	Metrics time.Duration // metric_buckets
+}
+
+// purgeScope narrows a retention purge by tenant. The zero value purges
+// every tenant.
+type purgeScope struct {
+	tenant  string   // only this tenant
+	exclude []string // every tenant except these
+}
+
+// clause returns the SQL fragment (with a leading " AND") and its args.
+func (s purgeScope) clause() (string, []any) {
+	switch {
+	case s.tenant != "":
+		return " AND tenant_id = ?", []any{s.tenant}
+	case len(s.exclude) > 0:
+		return " AND tenant_id NOT IN ?", []any{s.exclude}
+	}
+	return "", nil
+}
+
+// args assembles the bind args of a batched DELETE: cutoff, tenant args,
+// batch size.
+func (s purgeScope) args(olderThan time.Time, tenantArgs []any, batchSize int) []any {
+	out := append([]any{olderThan}, tenantArgs...)
+	return append(out, batchSize)
+}
+
+// retentionCutoffs is one purge pass's per-table deletion boundary.
+type retentionCutoffs struct {
+	logs, traces, metrics time.Time
+}
+
 // RetentionScheduler periodically enforces hot-DB retention and runs DB maintenance.
-// On startup and hourly thereafter it deletes rows older than retentionDays.
+// On startup and hourly thereafter it deletes rows older than retentionDays
+// (or the per-signal RetentionPolicy window).
 // Daily it runs driver-appropriate maintenance (VACUUM ANALYZE / OPTIMIZE / VACUUM).
+// Every purge is recorded as DeletionRecords.
 type RetentionScheduler struct {
 	repo            *Repository
-	retentionDays   int
 	purgeInterval   time.Duration
 	vacuumInterval  time.Duration
 	purgeBatchSize  int
 	purgeBatchSleep time.Duration
 
+	// confMu guards the windows below, which Reconfigure may replace while
+	// the scheduler runs. Each takes effect from the next purge pass.
+	confMu        sync.RWMutex
+	retentionDays int
+	policy        RetentionPolicy
+
+	// tenantWindows overrides every signal's window for individual tenants.
+	// Those tenants are excluded from the global pass and purged in their
+	// own scoped pass. Replaced whole, never mutated.
+	tenantWindows map[string]time.Duration
+
 	// started is an atomic so a fast-path Stop() before Start() is lock-free.
 	// mu serializes the Start/Stop transition itself (protects cancel + done).
 	started atomic.Bool
@@ -58,6 +121,137 @@ func NewRetentionScheduler(repo *Repository, retentionDays, batchSize int, batch
 	}
 }
 
+// SetPolicy installs per-signal retention windows.
+func (r *RetentionScheduler) SetPolicy(p RetentionPolicy) {
+	r.confMu.Lock()
+	r.policy = p
+	r.confMu.Unlock()
+}
+
+// SetTenantWindows installs per-tenant retention windows that replace the
+// global policy for those tenants, for every signal. Longer or shorter than
+// the default both work, except that logs older than the partition window
+// are gone once DB_POSTGRES_PARTITIONING drops their partition.
+func (r *RetentionScheduler) SetTenantWindows(windows map[string]time.Duration) {
+	r.confMu.Lock()
+	r.tenantWindows = windows
+	r.confMu.Unlock()
+}
+
+// Reconfigure replaces the default window, the per-signal policy and the
+// per-tenant windows at once, e.g. on config file reload. The running
+// scheduler applies them from its next purge pass.
+func (r *RetentionScheduler) Reconfigure(retentionDays int, p RetentionPolicy, windows map[string]time.Duration) {
+	r.confMu.Lock()
+	r.retentionDays, r.policy, r.tenantWindows = retentionDays, p, windows
+	r.confMu.Unlock()
+}
+
+func (r *RetentionScheduler) tenantWindowsSnapshot() map[string]time.Duration {
+	r.confMu.RLock()
+	defer r.confMu.RUnlock()
+	return r.tenantWindows
+}
+
+// globalScope excludes the tenants that have their own window.
+func (r *RetentionScheduler) globalScope() purgeScope {
+	windows := r.tenantWindowsSnapshot()
+	if len(windows) == 0 {
+		return purgeScope{}
+	}
+	exclude := make([]string, 0, len(windows))
+	for t := range windows {
+		exclude = append(exclude, t)
+	}
+	sort.Strings(exclude)
+	return purgeScope{exclude: exclude}
+}
+
+// runTenantPurges purges each tenant with its own window, serially — these
+// passes are small next to the global one. Returns whether any failed.
+func (r *RetentionScheduler) runTenantPurges(ctx context.Context, now time.Time, driver string) bool {
+	windows := r.tenantWindowsSnapshot()
+	tenants := make([]string, 0, len(windows))
+	for t := range windows {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+
+	metrics := r.repo.metrics
+	failed := false
+	for _, tenant := range tenants {
+		cutoff := now.Add(-windows[tenant])
+		scope := purgeScope{tenant: tenant}
+		passes := []struct {
+			kind string
+			fn   func() (int64, error)
+		}{
+			{"logs", func() (int64, error) {
+				return r.purge(ctx, DeletionSignalLogs, DeletionReasonTenantRetention, cutoff, scope)
+			}},
+			{"traces", func() (int64, error) {
+				return r.purge(ctx, DeletionSignalTraces, DeletionReasonTenantRetention, cutoff, scope)
+			}},
+			{"metric_buckets", func() (int64, error) {
+				return r.purge(ctx, DeletionSignalMetrics, DeletionReasonTenantRetention, cutoff, scope)
+			}},
+		}
+		deleted := make(map[string]int64, len(passes))
+		for _, p := range passes {
+			n, err := p.fn()
+			if err != nil {
+				slog.Error("retention: tenant purge failed", "tenant", tenant, "kind", p.kind, "error", err)
+				failed = true
+			}
+			deleted[p.kind] = n
+			if metrics != nil && n > 0 {
+				metrics.RetentionRowsPurgedTotal.WithLabelValues(p.kind, driver).Add(float64(n))
+			}
+		}
+		slog.Info("retention tenant purge complete",
+			"tenant", tenant,
+			"cutoff", cutoff.Format(time.RFC3339),
+			"logs_deleted", deleted["logs"],
+			"traces_deleted", deleted["traces"],
+			"metrics_deleted", deleted["metric_buckets"],
+		)
+	}
+	return failed
+}
+
+// purge deletes one signal's rows older than cutoff within scope, recording
+// the deletion with reason.
+func (r *RetentionScheduler) purge(ctx context.Context, signal, reason string, cutoff time.Time, scope purgeScope) (int64, error) {
+	return r.repo.auditedPurge(ctx, signal, reason, cutoff, scope, func() (int64, error) {
+		switch signal {
+		case DeletionSignalLogs:
+			return r.repo.purgeLogsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep, scope)
+		case DeletionSignalTraces:
+			return r.repo.purgeTracesBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep, scope)
+		}
+		return r.repo.purgeMetricBucketsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep, scope)
+	})
+}
+
+// cutoffs resolves the per-table deletion boundaries relative to now.
+func (r *RetentionScheduler) cutoffs(now time.Time) retentionCutoffs {
+	r.confMu.RLock()
+	def := time.Duration(r.retentionDays) * 24 * time.Hour
+	policy := r.policy
+	r.confMu.RUnlock()
+	window := func(d time.Duration) time.Time {
+		if d <= 0 {
+			d = def
+		}
+		return now.Add(-d)
+	}
+	return retentionCutoffs{
+		logs:    window(policy.Logs),
+		traces:  window(policy.Traces),
+		metrics: window(policy.Metrics),
+	}
+}
+
 // SkippedRuns returns the number of purge/maintenance ticks that were dropped
 // because a previous run was still executing. Intended for tests and telemetry.
 func (r *RetentionScheduler) SkippedRuns() int64 { return r.skippedRuns.Load() }
@@ -131,11 +325,13 @@ func (r *RetentionScheduler) runPurge(ctx context.Context) {
 	if driver == "" {
 		driver = "sqlite"
 	}
-	cutoff := time.Now().UTC().Add(-time.Duration(r.retentionDays) * 24 * time.Hour)
+	now := time.Now().UTC()
+	cutoff := r.cutoffs(now)
+	scope := r.globalScope()
 
 	// SQLite: single-writer, parallel purges would just contend on the DB lock.
 	if driver == "sqlite" {
-		r.runPurgeSerial(ctx, cutoff, driver)
+		r.runPurgeSerial(ctx, now, cutoff, scope, driver)
 		return
 	}
 
@@ -172,20 +368,21 @@ func (r *RetentionScheduler) runPurge(ctx context.Context) {
 	// When DB_POSTGRES_PARTITIONING=daily is active, retention for `logs` is
 	// handled by PartitionScheduler via DROP PARTITION (orders of magnitude
 	// faster than DELETE). Skip the logs DELETE here so we don't pay for two
-	// retention paths against the same table.
-	logsHandledByPartition := r.repo.LogsPartitioned()
+	// retention paths against the same table. DB_SQLITE_SHARDING=daily is
+	// the SQLite equivalent: expired shard files are deleted whole.
+	logsHandledByPartition := r.repo.LogsPartitioned() || r.repo.LogsSharded()
 	logsExpected := 0
 	if !logsHandledByPartition {
 		logsExpected = 1
 		runGuarded("logs", func() (int64, error) {
-			return r.repo.PurgeLogsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+			return r.purge(ctx, DeletionSignalLogs, DeletionReasonRetention, cutoff.logs, scope)
 		})
 	}
 	runGuarded("traces", func() (int64, error) {
-		return r.repo.PurgeTracesBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+		return r.purge(ctx, DeletionSignalTraces, DeletionReasonRetention, cutoff.traces, scope)
 	})
 	runGuarded("metric_buckets", func() (int64, error) {
-		return r.repo.PurgeMetricBucketsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+		return r.purge(ctx, DeletionSignalMetrics, DeletionReasonRetention, cutoff.metrics, scope)
 	})
 
 	purgeFailed := false
@@ -202,6 +399,12 @@ func (r *RetentionScheduler) runPurge(ctx context.Context) {
 			metrics.RetentionRowsPurgedTotal.WithLabelValues(res.kind, driver).Add(float64(res.n))
 		}
 	}
+	if r.runTenantPurges(ctx, now, driver) {
+		purgeFailed = true
+	}
+	for kind, n := range totals {
+		metrics.RecordRetentionCycle(kind, n)
+	}
 
 	if metrics != nil {
 		metrics.RetentionPurgeDurationSeconds.WithLabelValues(driver).Observe(time.Since(start).Seconds())
@@ -276,12 +479,12 @@ func (r *RetentionScheduler) adaptPurgeSleep(elapsed time.Duration) {
 // runPurgeSerial is the SQLite path: running the three purges concurrently buys
 // nothing because the driver holds a single writer lock, so we serialize them
 // to keep the "running" gauge accurate and avoid goroutine launch cost.
-func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Time, driver string) {
+func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, now time.Time, cutoff retentionCutoffs, scope purgeScope, driver string) {
 	metrics := r.repo.metrics
 	start := time.Now()
 	purgeFailed := false
 
-	logs, err := r.repo.PurgeLogsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+	logs, err := r.purge(ctx, DeletionSignalLogs, DeletionReasonRetention, cutoff.logs, scope)
 	if err != nil {
 		slog.Error("retention: purge logs failed", "error", err)
 		purgeFailed = true
@@ -290,7 +493,7 @@ func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Tim
 		metrics.RetentionRowsPurgedTotal.WithLabelValues("logs", driver).Add(float64(logs))
 	}
 
-	traces, err := r.repo.PurgeTracesBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+	traces, err := r.purge(ctx, DeletionSignalTraces, DeletionReasonRetention, cutoff.traces, scope)
 	if err != nil {
 		slog.Error("retention: purge traces failed", "error", err)
 		purgeFailed = true
@@ -299,7 +502,7 @@ func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Tim
 		metrics.RetentionRowsPurgedTotal.WithLabelValues("traces", driver).Add(float64(traces))
 	}
 
-	metricsPurged, err := r.repo.PurgeMetricBucketsBatched(ctx, cutoff, r.purgeBatchSize, r.purgeBatchSleep)
+	metricsPurged, err := r.purge(ctx, DeletionSignalMetrics, DeletionReasonRetention, cutoff.metrics, scope)
 	if err != nil {
 		slog.Error("retention: purge metrics failed", "error", err)
 		purgeFailed = true
@@ -308,6 +511,14 @@ func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Tim
 		metrics.RetentionRowsPurgedTotal.WithLabelValues("metric_buckets", driver).Add(float64(metricsPurged))
 	}
 
+	if r.runTenantPurges(ctx, now, driver) {
+		purgeFailed = true
+	}
+
+	metrics.RecordRetentionCycle("logs", logs)
+	metrics.RecordRetentionCycle("traces", traces)
+	metrics.RecordRetentionCycle("metric_buckets", metricsPurged)
+
 	if metrics != nil {
 		metrics.RetentionPurgeDurationSeconds.WithLabelValues(driver).Observe(time.Since(start).Seconds())
 		if purgeFailed {
@@ -322,7 +533,9 @@ func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Tim
 
 	slog.Info("retention purge complete",
 		"driver", driver,
-		"cutoff", cutoff.Format(time.RFC3339),
+		"logs_cutoff", cutoff.logs.Format(time.RFC3339),
+		"traces_cutoff", cutoff.traces.Format(time.RFC3339),
+		"metrics_cutoff", cutoff.metrics.Format(time.RFC3339),
 		"logs_deleted", logs,
 		"traces_deleted", traces,
 		"metrics_deleted", metricsPurged,
@@ -334,7 +547,7 @@ func (r *RetentionScheduler) runPurgeSerial(ctx context.Context, cutoff time.Tim
 // observeRowsBehind populates RetentionRowsBehindGauge so operators can see
 // when ingest is outrunning purge. Best-effort — a failed COUNT is logged and
 // skipped rather than failing the purge.
-func (r *RetentionScheduler) observeRowsBehind(ctx context.Context, driver string, cutoff time.Time) {
+func (r *RetentionScheduler) observeRowsBehind(ctx context.Context, driver string, cutoff retentionCutoffs) {
 	metrics := r.repo.metrics
 	if metrics == nil || metrics.RetentionRowsBehindGauge == nil {
 		return
@@ -343,14 +556,15 @@ func (r *RetentionScheduler) observeRowsBehind(ctx context.Context, driver strin
 		table    string
 		model    any
 		tsColumn string
+		cutoff   time.Time
 	}{
-		{"logs", &Log{}, "timestamp"},
-		{"traces", &Trace{}, "timestamp"},
-		{"metric_buckets", &MetricBucket{}, "time_bucket"},
+		{"logs", &Log{}, "timestamp", cutoff.logs},
+		{"traces", &Trace{}, "timestamp", cutoff.traces},
+		{"metric_buckets", &MetricBucket{}, "time_bucket", cutoff.metrics},
 	}
 	for _, p := range probes {
 		var n int64
-		if err := r.repo.db.WithContext(ctx).Model(p.model).Where(p.tsColumn+" < ?", cutoff).Count(&n).Error; err != nil {
+		if err := r.repo.db.WithContext(ctx).Model(p.model).Where(p.tsColumn+" < ?", p.cutoff).Count(&n).Error; err != nil {
 			continue // count failure is non-fatal; skip this label
 		}
 		metrics.RetentionRowsBehindGauge.WithLabelValues(p.table, driver).Set(float64(n))
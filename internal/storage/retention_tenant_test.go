@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunPurge_TenantWindows verifies tenants with their own window are
+// purged by it in both directions and skipped by the global pass.
+func TestRunPurge_TenantWindows(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	threeDays := now.Add(-3 * 24 * time.Hour)
+	tenDays := now.Add(-10 * 24 * time.Hour)
+
+	for i, tenant := range []string{"default", "short", "long"} {
+		for j, ts := range []time.Time{threeDays, tenDays} {
+			id := string(rune('a'+i)) + string(rune('0'+j))
+			rows := []any{
+				&Log{TenantID: tenant, ServiceName: "svc", Body: "b", Severity: "INFO", Timestamp: ts},
+				&Trace{TenantID: tenant, TraceID: id, ServiceName: "svc", Timestamp: ts},
+				&Span{TenantID: tenant, TraceID: id, SpanID: id, ServiceName: "svc", StartTime: ts, EndTime: ts},
+				&MetricBucket{TenantID: tenant, Name: "m", ServiceName: "svc", TimeBucket: ts, Count: 1},
+			}
+			for _, row := range rows {
+				if err := repo.db.Create(row).Error; err != nil {
+					t.Fatalf("seed: %v", err)
+				}
+			}
+		}
+	}
+
+	sched := NewRetentionScheduler(repo, 7, 100, 0)
+	sched.SetTenantWindows(map[string]time.Duration{"short": 24 * time.Hour, "long": 30 * 24 * time.Hour})
+	sched.runPurge(context.Background())
+
+	want := map[string]int64{"default": 1, "short": 0, "long": 2}
+	for tenant, n := range want {
+		for _, model := range []any{&Log{}, &Trace{}, &Span{}, &MetricBucket{}} {
+			var got int64
+			if err := repo.db.Model(model).Where("tenant_id = ?", tenant).Count(&got).Error; err != nil {
+				t.Fatalf("count: %v", err)
+			}
+			if got != n {
+				t.Errorf("%s %T: %d rows left, want %d", tenant, model, got, n)
+			}
+		}
+	}
+}
@@ -223,3 +223,28 @@ func TestRetentionScheduler_ConcurrentStopCallers(t *testing.T) {
 		t.Fatal("concurrent Stop() callers deadlocked")
 	}
 }
+
+// TestRetentionScheduler_PerSignalPolicy verifies each signal is purged
+// against its own window: 3-day-old traces expire under a 72h trace policy
+// while 3-day-old logs survive the 7-day default.
+func TestRetentionScheduler_PerSignalPolicy(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	seedLogs(t, repo.db, 10, now.Add(-4*24*time.Hour), "svc")
+	seedTrace(t, repo.db, "old-trace", now.Add(-4*24*time.Hour), []time.Time{now.Add(-4 * 24 * time.Hour)})
+	seedTrace(t, repo.db, "new-trace", now.Add(-time.Hour), []time.Time{now.Add(-time.Hour)})
+
+	r := NewRetentionScheduler(repo, 7, 10_000, 5*time.Millisecond)
+	r.SetPolicy(RetentionPolicy{Traces: 72 * time.Hour})
+	r.runPurge(context.Background())
+
+	if got := mustCount(t, repo.db, &Log{}); got != 10 {
+		t.Errorf("logs = %d, want 10 (default 7d window)", got)
+	}
+	if got := mustCount(t, repo.db, &Trace{}); got != 1 {
+		t.Errorf("traces = %d, want 1 (72h window)", got)
+	}
+	if got := mustCount(t, repo.db, &Span{}); got != 1 {
+		t.Errorf("spans = %d, want 1", got)
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// RuntimeSettings returns the persisted runtime settings, keyed by
+// environment variable name.
+func (r *Repository) RuntimeSettings(ctx context.Context) (map[string]string, error) {
+	var rows []RuntimeSetting
+	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load runtime settings: %w", err)
+	}
+	settings := make(map[string]string, len(rows))
+	for _, row := range rows {
+		settings[row.Key] = row.Value
+	}
+	return settings, nil
+}
+
+// ReplaceRuntimeSettings makes the persisted runtime settings exactly
+// settings, in one transaction.
+func (r *Repository) ReplaceRuntimeSettings(ctx context.Context, settings map[string]string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&RuntimeSetting{}).Error; err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			return nil
+		}
+		rows := make([]RuntimeSetting, 0, len(settings))
+		for k, v := range settings {
+			rows = append(rows, RuntimeSetting{Key: k, Value: v})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save runtime settings: %w", err)
+	}
+	return nil
+}
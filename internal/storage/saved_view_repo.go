@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Saved view kinds.
+const (
+	SavedViewKindFilter    = "filter"
+	SavedViewKindDashboard = "dashboard"
+)
+
+// ErrSavedViewSlugTaken is returned when a view is created with a slug the
+// tenant already uses.
+var ErrSavedViewSlugTaken = errors.New("saved view slug already in use")
+
+// maxSlugSuffix bounds the "-2", "-3", … suffixes tried for a derived slug.
+const maxSlugSuffix = 100
+
+// ListSavedViews returns the saved views of the tenant on ctx, by name. kind
+// filters to "filter" or "dashboard"; empty returns both.
+func (r *Repository) ListSavedViews(ctx context.Context, kind string) ([]SavedView, error) {
+	q := r.db.WithContext(ctx).Where(sqlWhereTenantID, TenantFromContext(ctx))
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	var views []SavedView
+	if err := q.Order("name ASC, id ASC").Find(&views).Error; err != nil {
+		return nil, fmt.Errorf("failed to list saved views: %w", err)
+	}
+	return views, nil
+}
+
+// GetSavedView returns the tenant's view with slug. Returns
+// gorm.ErrRecordNotFound when there is none.
+func (r *Repository) GetSavedView(ctx context.Context, slug string) (*SavedView, error) {
+	var v SavedView
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND slug = ?", TenantFromContext(ctx), slug).
+		First(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to load saved view: %w", err)
+	}
+	return &v, nil
+}
+
+// CreateSavedView inserts v under the tenant on ctx. With derived set, v.Slug
+// is a base that gets a "-2", "-3", … suffix until it is free; otherwise a
+// slug already in use is ErrSavedViewSlugTaken.
+func (r *Repository) CreateSavedView(ctx context.Context, v *SavedView, derived bool) error {
+	v.ID = 0
+	v.TenantID = TenantFromContext(ctx)
+	base := v.Slug
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for n := 1; ; n++ {
+			if n > 1 {
+				suffix := "-" + strconv.Itoa(n)
+				v.Slug = base[:min(len(base), 128-len(suffix))] + suffix
+			}
+			var count int64
+			if err := tx.Model(&SavedView{}).Where("tenant_id = ? AND slug = ?", v.TenantID, v.Slug).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to check saved view slug: %w", err)
+			}
+			if count == 0 {
+				break
+			}
+			if !derived || n == maxSlugSuffix {
+				return ErrSavedViewSlugTaken
+			}
+		}
+		if err := tx.Create(v).Error; err != nil {
+			return fmt.Errorf("failed to create saved view: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateSavedView replaces the name, description, filters and layout of the
+// tenant's view with slug; the slug and kind never change, so shared links
+// keep working. Returns the updated view, or gorm.ErrRecordNotFound.
+func (r *Repository) UpdateSavedView(ctx context.Context, slug string, v *SavedView) (*SavedView, error) {
+	res := r.db.WithContext(ctx).Model(&SavedView{}).
+		Where("tenant_id = ? AND slug = ?", TenantFromContext(ctx), slug).
+		Select("name", "description", "filters", "layout", "updated_at").
+		Updates(v)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to update saved view: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetSavedView(ctx, slug)
+}
+
+// DeleteSavedView removes the tenant's view with slug. Returns
+// gorm.ErrRecordNotFound when there is none.
+func (r *Repository) DeleteSavedView(ctx context.Context, slug string) error {
+	res := r.db.WithContext(ctx).Where("tenant_id = ? AND slug = ?", TenantFromContext(ctx), slug).Delete(&SavedView{})
+	if res.Error != nil {
+		return fmt.Errorf("failed to delete saved view: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,668 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/argusql"
+)
+
+const (
+	// searchScanCap bounds the rows an ArgusQL search evaluates. Attribute
+	// predicates and negations cannot be pushed into SQL, so candidates are
+	// filtered in Go; newest rows win and SearchResult.Truncated reports a
+	// hit.
+	searchScanCap = 20_000
+
+	defaultSearchLimit = 50
+
+	SearchSignalTraces  = "traces"
+	SearchSignalLogs    = "logs"
+	SearchSignalMetrics = "metrics"
+)
+
+// ErrInvalidSearchQuery wraps every query the caller got wrong — syntax,
+// unknown fields, operators a field does not support — as opposed to a
+// storage failure.
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// SearchQuery is one ArgusQL search over traces, logs or metrics. The
+// query is Query text or, when set, the already-parsed Filter (see
+// argusql.ParseFilter); the same expression compiles for every signal.
+type SearchQuery struct {
+	Query  string       // ArgusQL, see package argusql
+	Filter argusql.Expr // overrides Query
+	Signal string       // SearchSignalTraces (default), SearchSignalLogs or SearchSignalMetrics
+	Start  time.Time
+	End    time.Time
+	Limit  int // results returned (default 50)
+}
+
+// SearchResult holds the newest Limit matches. Matched counts every match
+// among the Scanned candidate rows; for traces it counts traces, not spans,
+// and for metrics metric_buckets windows.
+type SearchResult struct {
+	Signal    string         `json:"signal"`
+	Query     string         `json:"query"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Traces    []Trace        `json:"traces,omitempty"`
+	Logs      []Log          `json:"logs,omitempty"`
+	Metrics   []MetricBucket `json:"metrics,omitempty"`
+	Matched   int            `json:"matched"`
+	Scanned   int            `json:"scanned"`
+	Truncated bool           `json:"truncated"` // searchScanCap hit
+}
+
+type searchFieldKind int
+
+const (
+	searchText searchFieldKind = iota
+	searchDuration
+	searchStatus
+	searchSeverity
+	searchNumber
+	searchAttr
+)
+
+type searchField struct {
+	column string // attribute key for searchAttr
+	kind   searchFieldKind
+}
+
+// A trace matches when any one of its spans matches the whole query.
+var spanSearchFields = map[string]searchField{
+	"service":        {"service_name", searchText},
+	"service.name":   {"service_name", searchText},
+	"name":           {"operation_name", searchText},
+	"operation":      {"operation_name", searchText},
+	"duration":       {"duration_ns", searchDuration},
+	"status":         {"status", searchStatus},
+	"trace_id":       {"trace_id", searchText},
+	"span_id":        {"span_id", searchText},
+	"parent_span_id": {"parent_span_id", searchText},
+	"region":         {"region", searchText},
+}
+
+var logSearchFields = map[string]searchField{
+	"service":      {"service_name", searchText},
+	"service.name": {"service_name", searchText},
+	"severity":     {"severity", searchSeverity},
+	"body":         {"body", searchText},
+	"trace_id":     {"trace_id", searchText},
+	"span_id":      {"span_id", searchText},
+	"region":       {"region", searchText},
+}
+
+// metricSearchFields cover metric_buckets windows. min, max, sum and count
+// are the window's aggregates.
+var metricSearchFields = map[string]searchField{
+	"service":      {"service_name", searchText},
+	"service.name": {"service_name", searchText},
+	"name":         {"name", searchText},
+	"region":       {"region", searchText},
+	"min":          {"min", searchNumber},
+	"max":          {"max", searchNumber},
+	"sum":          {"sum", searchNumber},
+	"count":        {"count", searchNumber},
+}
+
+// searchRow is what a compiled query sees of one span, log or metric
+// window.
+type searchRow struct {
+	value    func(column string) string
+	number   func(column string) float64 // metrics only
+	duration int64                       // ns, spans only
+	attrs    string
+}
+
+// compiledSearch is an ArgusQL expression compiled for one signal: a SQL
+// prefilter that selects a superset of the matches, and the exact match.
+// Attribute predicates and negated text predicates contribute no SQL —
+// attributes live in compressed blobs, and collation differences would
+// make a negated prefilter drop real matches.
+type compiledSearch struct {
+	where    string
+	args     []any
+	match    func(searchRow) bool
+	attrs    bool     // match reads AttributesJSON
+	eq       []string // columns under top-level equality, for the index advisor
+	attrKeys []string
+}
+
+func compileSearch(expr argusql.Expr, fields map[string]searchField, likeOp string) (*compiledSearch, error) {
+	c := &compiledSearch{}
+	where, args, match, err := c.compile(expr, fields, likeOp)
+	if err != nil {
+		return nil, err
+	}
+	c.where, c.args, c.match = where, args, match
+	c.collectEq(expr, fields)
+	return c, nil
+}
+
+func (c *compiledSearch) compile(e argusql.Expr, fields map[string]searchField, likeOp string) (string, []any, func(searchRow) bool, error) {
+	switch n := e.(type) {
+	case *argusql.And:
+		lw, la, lm, err := c.compile(n.Left, fields, likeOp)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		rw, ra, rm, err := c.compile(n.Right, fields, likeOp)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		where, args := lw, la
+		switch {
+		case lw == "":
+			where, args = rw, ra
+		case rw != "":
+			where, args = "("+lw+") AND ("+rw+")", append(la, ra...)
+		}
+		return where, args, func(row searchRow) bool { return lm(row) && rm(row) }, nil
+	case *argusql.Or:
+		lw, la, lm, err := c.compile(n.Left, fields, likeOp)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		rw, ra, rm, err := c.compile(n.Right, fields, likeOp)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		where, args := "", []any(nil)
+		if lw != "" && rw != "" {
+			where, args = "("+lw+") OR ("+rw+")", append(la, ra...)
+		}
+		return where, args, func(row searchRow) bool { return lm(row) || rm(row) }, nil
+	case *argusql.Not:
+		_, _, m, err := c.compile(n.X, fields, likeOp)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return "", nil, func(row searchRow) bool { return !m(row) }, nil
+	case *argusql.Compare:
+		return c.compileCompare(n, fields, likeOp)
+	}
+	return "", nil, nil, fmt.Errorf("%w: unsupported expression %T", ErrInvalidSearchQuery, e)
+}
+
+func (c *compiledSearch) compileCompare(n *argusql.Compare, fields map[string]searchField, likeOp string) (string, []any, func(searchRow) bool, error) {
+	invalid := func(format string, a ...any) (string, []any, func(searchRow) bool, error) {
+		if n.Pos < 0 { // from a Filter: no query text to point into
+			return "", nil, nil, fmt.Errorf("%w: %s", ErrInvalidSearchQuery, fmt.Sprintf(format, a...))
+		}
+		return "", nil, nil, fmt.Errorf("%w: %s at position %d", ErrInvalidSearchQuery, fmt.Sprintf(format, a...), n.Pos)
+	}
+	f, ok := fields[n.Field]
+	if key, isAttr := strings.CutPrefix(n.Field, "attr."); isAttr {
+		if key == "" {
+			return invalid("attr. needs a key, e.g. attr.http.route")
+		}
+		f, ok = searchField{column: key, kind: searchAttr}, true
+	}
+	if !ok {
+		return invalid("unknown field %q (fields: %s, attr.<key>)", n.Field, searchFieldNames(fields))
+	}
+	op, v := n.Op, n.Value
+
+	switch f.kind {
+	case searchText:
+		if op.Ordered() {
+			return invalid("%s does not support %s", n.Field, op)
+		}
+		col, want := f.column, v.Text
+		match := func(row searchRow) bool { return matchText(op, row.value(col), want) }
+		switch op {
+		case argusql.OpEq:
+			return col + " = ?", []any{want}, match, nil
+		case argusql.OpContains:
+			return fmt.Sprintf("%s %s ?", col, likeOp), []any{"%" + want + "%"}, match, nil
+		}
+		return "", nil, match, nil
+
+	case searchDuration:
+		if op == argusql.OpContains || op == argusql.OpNotContains {
+			return invalid("%s does not support %s", n.Field, op)
+		}
+		if v.Kind != argusql.KindDuration {
+			return invalid("%s needs a duration with a unit, e.g. 500ms", n.Field)
+		}
+		want := v.Duration.Nanoseconds()
+		return f.column + " " + sqlCompareOp(op) + " ?", []any{want},
+			func(row searchRow) bool { return compareOrdered(op, row.duration, want) }, nil
+
+	case searchStatus:
+		if op != argusql.OpEq && op != argusql.OpNe {
+			return invalid("%s supports only = and !=", n.Field)
+		}
+		want, ok := normalizeSpanStatus(v.Text)
+		if !ok {
+			return invalid("unknown status %q (error, ok, unset)", v.Text)
+		}
+		col := f.column
+		return col + " " + sqlCompareOp(op) + " ?", []any{want},
+			func(row searchRow) bool { return (row.value(col) == want) == (op == argusql.OpEq) }, nil
+
+	case searchSeverity:
+		if op == argusql.OpContains || op == argusql.OpNotContains {
+			return invalid("%s does not support %s", n.Field, op)
+		}
+		want := searchSeverityRank(v.Text)
+		if want == 0 {
+			return invalid("unknown severity %q (trace, debug, info, warn, error, fatal)", v.Text)
+		}
+		col := f.column
+		match := func(row searchRow) bool {
+			got := searchSeverityRank(row.value(col))
+			if op == argusql.OpNe {
+				return got != want
+			}
+			return got != 0 && compareOrdered(op, int64(got), int64(want))
+		}
+		if op == argusql.OpNe {
+			return "", nil, match, nil
+		}
+		// Severity text is stored as sent (INFO, Information, warn, ...), so
+		// the prefilter matches the rank keywords rather than exact values.
+		var ors []string
+		var args []any
+		for rank, words := range severityRankWords {
+			if rank == 0 || !compareOrdered(op, int64(rank), int64(want)) {
+				continue
+			}
+			for _, w := range words {
+				ors = append(ors, fmt.Sprintf("%s %s ?", col, likeOp))
+				args = append(args, "%"+w+"%")
+			}
+		}
+		return "(" + strings.Join(ors, " OR ") + ")", args, match, nil
+
+	case searchNumber:
+		if op == argusql.OpContains || op == argusql.OpNotContains {
+			return invalid("%s does not support %s", n.Field, op)
+		}
+		if v.Kind != argusql.KindNumber {
+			return invalid("%s needs a number", n.Field)
+		}
+		col, want := f.column, v.Number
+		return col + " " + sqlCompareOp(op) + " ?", []any{want},
+			func(row searchRow) bool { return compareOrderedFloat(op, row.number(col), want) }, nil
+
+	default: // searchAttr
+		c.attrs = true
+		c.attrKeys = append(c.attrKeys, f.column)
+		key := f.column
+		if op.Ordered() {
+			if v.Kind != argusql.KindNumber {
+				return invalid("%s %s needs a number", n.Field, op)
+			}
+			want := v.Number
+			return "", nil, func(row searchRow) bool {
+				s, ok := attributeValue(row.attrs, key)
+				if !ok {
+					return false
+				}
+				got, err := strconv.ParseFloat(s, 64)
+				return err == nil && compareOrderedFloat(op, got, want)
+			}, nil
+		}
+		want := v.Text
+		return "", nil, func(row searchRow) bool {
+			s, ok := attributeValue(row.attrs, key)
+			return ok && matchText(op, s, want)
+		}, nil
+	}
+}
+
+// collectEq records the columns pinned by equality at the top level of the
+// query — the shape an index would serve.
+func (c *compiledSearch) collectEq(e argusql.Expr, fields map[string]searchField) {
+	switch n := e.(type) {
+	case *argusql.And:
+		c.collectEq(n.Left, fields)
+		c.collectEq(n.Right, fields)
+	case *argusql.Compare:
+		if f, ok := fields[n.Field]; ok && n.Op == argusql.OpEq && f.kind != searchSeverity {
+			c.eq = append(c.eq, f.column)
+		}
+	}
+}
+
+func searchFieldNames(fields map[string]searchField) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// matchText applies a text operator. Equality is exact; ~ and !~ are
+// case-insensitive substring tests, like the LIKE/ILIKE prefilter.
+func matchText(op argusql.Op, got, want string) bool {
+	switch op {
+	case argusql.OpEq:
+		return got == want
+	case argusql.OpNe:
+		return got != want
+	case argusql.OpContains:
+		return strings.Contains(strings.ToLower(got), strings.ToLower(want))
+	case argusql.OpNotContains:
+		return !strings.Contains(strings.ToLower(got), strings.ToLower(want))
+	}
+	return false
+}
+
+func compareOrdered(op argusql.Op, got, want int64) bool {
+	return compareOrderedFloat(op, float64(got), float64(want))
+}
+
+func compareOrderedFloat(op argusql.Op, got, want float64) bool {
+	switch op {
+	case argusql.OpEq:
+		return got == want
+	case argusql.OpNe:
+		return got != want
+	case argusql.OpGt:
+		return got > want
+	case argusql.OpGe:
+		return got >= want
+	case argusql.OpLt:
+		return got < want
+	case argusql.OpLe:
+		return got <= want
+	}
+	return false
+}
+
+func sqlCompareOp(op argusql.Op) string {
+	if op == argusql.OpNe {
+		return "<>"
+	}
+	return string(op)
+}
+
+func normalizeSpanStatus(s string) (string, bool) {
+	switch strings.ToUpper(strings.TrimPrefix(strings.ToUpper(s), "STATUS_CODE_")) {
+	case "ERROR":
+		return spanStatusError, true
+	case "OK":
+		return spanStatusOK, true
+	case "UNSET":
+		return spanStatusUnset, true
+	}
+	return "", false
+}
+
+// severityRankWords maps a severity rank (1 = TRACE … 6 = FATAL) to the
+// words that identify it in free-form severity text.
+var severityRankWords = [...][]string{
+	1: {"TRACE"},
+	2: {"DEBUG"},
+	3: {"INFO"},
+	4: {"WARN"},
+	5: {"ERR"},
+	6: {"FATAL", "CRIT"},
+}
+
+// searchSeverityRank classifies severity text — INFO, Information,
+// SEVERITY_NUMBER_WARN, err — most severe word first; 0 if unrecognised.
+func searchSeverityRank(s string) int {
+	upper := strings.ToUpper(s)
+	for rank := len(severityRankWords) - 1; rank > 0; rank-- {
+		for _, w := range severityRankWords[rank] {
+			if strings.Contains(upper, w) {
+				return rank
+			}
+		}
+	}
+	return 0
+}
+
+// Search runs an ArgusQL query over the spans, logs or metric windows of the
+// tenant on ctx (region-scoped like the list endpoints) and returns the
+// newest matches.
+// The query compiles to a SQL prefilter over indexed columns; the exact
+// match, including attr.* predicates read from AttributesJSON, runs in Go
+// over at most searchScanCap candidates. An attribute predicate never
+// matches a row without that attribute, whatever the operator. The window
+// defaults to the last hour.
+func (r *Repository) Search(ctx context.Context, q SearchQuery) (*SearchResult, error) {
+	if q.Signal == "" {
+		q.Signal = SearchSignalTraces
+	}
+	fields := spanSearchFields
+	switch q.Signal {
+	case SearchSignalTraces:
+	case SearchSignalLogs:
+		fields = logSearchFields
+	case SearchSignalMetrics:
+		fields = metricSearchFields
+	default:
+		return nil, fmt.Errorf("%w: unknown signal %q (traces, logs, metrics)", ErrInvalidSearchQuery, q.Signal)
+	}
+	expr := q.Filter
+	if expr == nil {
+		var err error
+		if expr, err = argusql.Parse(q.Query); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSearchQuery, err)
+		}
+	} else {
+		q.Query = expr.String()
+	}
+	c, err := compileSearch(expr, fields, r.likeOp())
+	if err != nil {
+		return nil, err
+	}
+	if q.End.IsZero() {
+		q.End = time.Now()
+	}
+	if q.Start.IsZero() || !q.Start.Before(q.End) {
+		q.Start = q.End.Add(-1 * time.Hour)
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+	out := &SearchResult{Signal: q.Signal, Query: q.Query, Start: q.Start, End: q.End}
+	switch q.Signal {
+	case SearchSignalLogs:
+		return out, r.searchLogs(ctx, q, c, out)
+	case SearchSignalMetrics:
+		return out, r.searchMetrics(ctx, q, c, out)
+	}
+	return out, r.searchTraces(ctx, q, c, out)
+}
+
+func (r *Repository) searchTraces(ctx context.Context, q SearchQuery, c *compiledSearch, out *SearchResult) error {
+	tenant := TenantFromContext(ctx)
+	defer func(started time.Time) {
+		r.filters.observe(newFilterPattern("spans", c.eq, "start_time", strings.Join(c.attrKeys, ",")), time.Since(started))
+	}(time.Now())
+
+	cols := "trace_id, span_id, parent_span_id, service_name, operation_name, duration, duration_ns, status, region"
+	if c.attrs {
+		cols += ", attributes_json"
+	}
+	query := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Span{}).Select(cols).
+		Where("tenant_id = ? AND start_time BETWEEN ? AND ?", tenant, q.Start, q.End))
+	if c.where != "" {
+		query = query.Where(c.where, c.args...)
+	}
+	var rows []Span
+	if err := query.Order("start_time DESC").Limit(searchScanCap).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to fetch spans for search: %w", err)
+	}
+	out.Scanned = len(rows)
+	out.Truncated = len(rows) == searchScanCap
+
+	var ids []string
+	seen := make(map[string]bool)
+	for i := range rows {
+		sp := &rows[i]
+		if seen[sp.TraceID] {
+			continue
+		}
+		row := searchRow{value: sp.searchValue, duration: sp.DurationNanos(), attrs: string(sp.AttributesJSON)}
+		if c.match(row) {
+			seen[sp.TraceID] = true
+			ids = append(ids, sp.TraceID)
+		}
+	}
+	out.Matched = len(ids)
+	if len(ids) > q.Limit {
+		ids = ids[:q.Limit]
+	}
+	out.Traces = []Trace{}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var traces []Trace
+	if err := r.db.WithContext(ctx).Where("tenant_id = ? AND trace_id IN ?", tenant, ids).Find(&traces).Error; err != nil {
+		return fmt.Errorf("failed to fetch traces for search: %w", err)
+	}
+	r.enrichTraceSummaries(ctx, tenant, traces)
+	byID := make(map[string]Trace, len(traces))
+	for _, t := range traces {
+		byID[t.TraceID] = t
+	}
+	for _, id := range ids { // newest matching span first
+		if t, ok := byID[id]; ok {
+			out.Traces = append(out.Traces, t)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) searchLogs(ctx context.Context, q SearchQuery, c *compiledSearch, out *SearchResult) error {
+	tenant := TenantFromContext(ctx)
+	defer func(started time.Time) {
+		r.filters.observe(newFilterPattern("logs", c.eq, "timestamp", strings.Join(c.attrKeys, ",")), time.Since(started))
+	}(time.Now())
+
+	query := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Log{}).
+		Where("tenant_id = ? AND timestamp BETWEEN ? AND ?", tenant, q.Start, q.End))
+	if c.where != "" {
+		query = query.Where(c.where, c.args...)
+	}
+	var rows []Log
+	if err := query.Order(sqlOrderTimestampDesc).Limit(searchScanCap).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to fetch logs for search: %w", err)
+	}
+	out.Scanned = len(rows)
+	out.Truncated = len(rows) == searchScanCap
+
+	out.Logs = []Log{}
+	for i := range rows {
+		l := &rows[i]
+		if !c.match(searchRow{value: l.searchValue, attrs: string(l.AttributesJSON)}) {
+			continue
+		}
+		out.Matched++
+		if len(out.Logs) < q.Limit {
+			out.Logs = append(out.Logs, *l)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) searchMetrics(ctx context.Context, q SearchQuery, c *compiledSearch, out *SearchResult) error {
+	tenant := TenantFromContext(ctx)
+	defer func(started time.Time) {
+		r.filters.observe(newFilterPattern("metric_buckets", c.eq, "time_bucket", strings.Join(c.attrKeys, ",")), time.Since(started))
+	}(time.Now())
+
+	query := scopeRegion(ctx, r.db.WithContext(ctx).Model(&MetricBucket{}).
+		Where("tenant_id = ? AND time_bucket BETWEEN ? AND ?", tenant, q.Start, q.End))
+	if c.where != "" {
+		query = query.Where(c.where, c.args...)
+	}
+	var rows []MetricBucket
+	if err := query.Order("time_bucket DESC").Limit(searchScanCap).Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to fetch metrics for search: %w", err)
+	}
+	out.Scanned = len(rows)
+	out.Truncated = len(rows) == searchScanCap
+
+	out.Metrics = []MetricBucket{}
+	for i := range rows {
+		m := &rows[i]
+		if !c.match(searchRow{value: m.searchValue, number: m.searchNumber, attrs: string(m.AttributesJSON)}) {
+			continue
+		}
+		out.Matched++
+		if len(out.Metrics) < q.Limit {
+			out.Metrics = append(out.Metrics, *m)
+		}
+	}
+	return nil
+}
+
+func (s *Span) searchValue(column string) string {
+	switch column {
+	case "service_name":
+		return s.ServiceName
+	case "operation_name":
+		return s.OperationName
+	case "status":
+		return s.Status
+	case "trace_id":
+		return s.TraceID
+	case "span_id":
+		return s.SpanID
+	case "parent_span_id":
+		return s.ParentSpanID
+	case "region":
+		return s.Region
+	}
+	return ""
+}
+
+func (l *Log) searchValue(column string) string {
+	switch column {
+	case "service_name":
+		return l.ServiceName
+	case "severity":
+		return l.Severity
+	case "body":
+		return l.Body
+	case "trace_id":
+		return l.TraceID
+	case "span_id":
+		return l.SpanID
+	case "region":
+		return l.Region
+	}
+	return ""
+}
+
+func (m *MetricBucket) searchValue(column string) string {
+	switch column {
+	case "service_name":
+		return m.ServiceName
+	case "name":
+		return m.Name
+	case "region":
+		return m.Region
+	}
+	return ""
+}
+
+func (m *MetricBucket) searchNumber(column string) float64 {
+	switch column {
+	case "min":
+		return m.Min
+	case "max":
+		return m.Max
+	case "sum":
+		return m.Sum
+	case "count":
+		return float64(m.Count)
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/argusql"
+)
+
+func providerAttrs(v string) CompressedText {
+	return CompressedText(fmt.Sprintf(`[{"key":"payment.provider","value":{"Value":{"StringValue":%q}}},{"key":"amount","value":{"Value":{"IntValue":%d}}}]`, v, len(v)*10))
+}
+
+func seedSearchData(t *testing.T, repo *Repository) {
+	t.Helper()
+	now := time.Now()
+	spans := []Span{
+		{TraceID: "t1", SpanID: "a", ServiceName: "payment-service", OperationName: "charge", Duration: 900_000, Status: spanStatusOK, AttributesJSON: providerAttrs("stripe")},
+		{TraceID: "t2", SpanID: "a", ServiceName: "payment-service", OperationName: "charge", Duration: 100_000, Status: spanStatusOK, AttributesJSON: providerAttrs("stripe")},
+		{TraceID: "t3", SpanID: "a", ServiceName: "payment-service", OperationName: "charge", Duration: 700_000, Status: spanStatusError, AttributesJSON: providerAttrs("adyen")},
+		{TraceID: "t3", SpanID: "b", ParentSpanID: "a", ServiceName: "ledger", OperationName: "INSERT", Duration: 600_000, Status: spanStatusError},
+		{TraceID: "t4", SpanID: "a", ServiceName: "checkout", OperationName: "GET /cart", Duration: 800_000, Status: spanStatusUnset},
+	}
+	for i := range spans {
+		spans[i].TenantID = DefaultTenantID
+		spans[i].StartTime = now.Add(-time.Duration(i+1) * time.Minute)
+		if spans[i].ParentSpanID != "" {
+			continue
+		}
+		if err := repo.db.Create(&Trace{TenantID: DefaultTenantID, TraceID: spans[i].TraceID, ServiceName: spans[i].ServiceName, Timestamp: spans[i].StartTime}).Error; err != nil {
+			t.Fatalf("create trace: %v", err)
+		}
+	}
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+	logs := []Log{
+		{ServiceName: "payment-service", Severity: "INFO", Body: "charge accepted", AttributesJSON: providerAttrs("stripe")},
+		{ServiceName: "payment-service", Severity: "Warning", Body: "retrying charge 100%"},
+		{ServiceName: "payment-service", Severity: "SEVERITY_NUMBER_ERROR", Body: "charge declined", AttributesJSON: providerAttrs("adyen")},
+		{ServiceName: "ledger", Severity: "fatal", Body: "disk full"},
+		{ServiceName: "ledger", Severity: "DEBUG", Body: "flush"},
+	}
+	for i := range logs {
+		logs[i].TenantID = DefaultTenantID
+		logs[i].Timestamp = now.Add(-time.Duration(i+1) * time.Minute)
+	}
+	if err := repo.BatchCreateLogs(logs); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+}
+
+func TestSearch_Traces(t *testing.T) {
+	repo := newTestRepo(t)
+	seedSearchData(t, repo)
+	ctx := context.Background()
+
+	cases := map[string][]string{
+		`service="payment-service" AND duration>500ms AND attr.payment.provider="stripe"`: {"t1"},
+		`service=payment-service`:                               {"t1", "t2", "t3"},
+		`status=error`:                                          {"t3"},
+		`status!=error AND duration>=800ms`:                     {"t1", "t4"},
+		`attr.payment.provider!=stripe`:                         {"t3"}, // spans without the key never match
+		`attr.amount>50`:                                        {"t1", "t2"},
+		`name~"get /"`:                                          {"t4"},
+		`service=ledger OR attr.payment.provider=stripe`:        {"t1", "t2", "t3"},
+		`NOT service=payment-service`:                           {"t3", "t4"}, // t3's ledger span
+		`(service=checkout OR status=error) AND duration<650ms`: {"t3"},
+		`attr.missing=x`:                                        {},
+	}
+	for q, want := range cases {
+		res, err := repo.Search(ctx, SearchQuery{Query: q})
+		if err != nil {
+			t.Errorf("Search(%s): %v", q, err)
+			continue
+		}
+		var got []string
+		for _, tr := range res.Traces {
+			got = append(got, tr.TraceID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Search(%s) = %v, want %v", q, got, want)
+		}
+		if res.Matched != len(want) || res.Scanned == 0 {
+			t.Errorf("Search(%s) matched=%d scanned=%d", q, res.Matched, res.Scanned)
+		}
+	}
+
+	res, err := repo.Search(ctx, SearchQuery{Query: "service=payment-service", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Traces) != 1 || res.Matched != 3 || res.Traces[0].SpanCount != 1 {
+		t.Errorf("limited search = %d traces, matched %d, %+v", len(res.Traces), res.Matched, res.Traces)
+	}
+}
+
+func TestSearch_Logs(t *testing.T) {
+	repo := newTestRepo(t)
+	seedSearchData(t, repo)
+	ctx := context.Background()
+
+	cases := map[string][]string{
+		`severity>=warn`: {"retrying charge 100%", "charge declined", "disk full"},
+		`severity=error`: {"charge declined"},
+		`severity<info`:  {"flush"},
+		`severity!=info AND service=payment-service`: {"retrying charge 100%", "charge declined"},
+		`body~CHARGE AND NOT body~declined`:          {"charge accepted", "retrying charge 100%"},
+		`body~"100%"`:                                {"retrying charge 100%"},
+		`body~"1_0"`:                                 {},
+		`attr.payment.provider=adyen`:                {"charge declined"},
+		`service=ledger AND body!~disk`:              {"flush"},
+	}
+	for q, want := range cases {
+		res, err := repo.Search(ctx, SearchQuery{Query: q, Signal: SearchSignalLogs})
+		if err != nil {
+			t.Errorf("Search(%s): %v", q, err)
+			continue
+		}
+		got := []string{}
+		for _, l := range res.Logs {
+			got = append(got, l.Body)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Search(%s) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestSearch_InvalidQueries(t *testing.T) {
+	repo := newTestRepo(t)
+	for _, q := range []SearchQuery{
+		{Query: `service=`},
+		{Query: `nope=1`},
+		{Query: `body~x`}, // logs-only field
+		{Query: `duration>500`},
+		{Query: `status>error`},
+		{Query: `status=broken`},
+		{Query: `service>a`},
+		{Query: `attr.=x`},
+		{Query: `attr.n>abc`},
+		{Query: `severity=loud`, Signal: SearchSignalLogs},
+		{Query: `service=x`, Signal: "events"},
+		{Query: `count>many`, Signal: SearchSignalMetrics},
+		{Query: `max~1`, Signal: SearchSignalMetrics},
+	} {
+		if _, err := repo.Search(context.Background(), q); !errors.Is(err, ErrInvalidSearchQuery) {
+			t.Errorf("Search(%+v) error = %v, want ErrInvalidSearchQuery", q, err)
+		}
+	}
+}
+
+// One filter applies to every signal that has its fields.
+func TestSearch_FilterAcrossSignals(t *testing.T) {
+	repo := newTestRepo(t)
+	seedSearchData(t, repo)
+	now := time.Now()
+	if err := repo.BatchCreateMetrics([]MetricBucket{
+		{TenantID: DefaultTenantID, Name: "http.server.duration", ServiceName: "payment-service", TimeBucket: now.Add(-2 * time.Minute), Max: 950, Count: 4},
+		{TenantID: DefaultTenantID, Name: "http.server.duration", ServiceName: "payment-service", TimeBucket: now.Add(-time.Minute), Max: 120, Count: 9},
+		{TenantID: DefaultTenantID, Name: "http.server.duration", ServiceName: "checkout", TimeBucket: now.Add(-time.Minute), Max: 990, Count: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	filter, err := argusql.ParseFilter([]byte(`{"and":[{"field":"service","op":"=","value":"payment-service"},{"not":{"field":"region","op":"=","value":"eu"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{SearchSignalTraces: 3, SearchSignalLogs: 3, SearchSignalMetrics: 2}
+	for signal, n := range want {
+		res, err := repo.Search(ctx, SearchQuery{Filter: filter, Signal: signal})
+		if err != nil || res.Matched != n {
+			t.Errorf("%s: matched %+v, %v; want %d", signal, res, err, n)
+			continue
+		}
+		if res.Query != `(service="payment-service" AND NOT region="eu")` {
+			t.Errorf("%s: query = %s", signal, res.Query)
+		}
+	}
+
+	res, err := repo.Search(ctx, SearchQuery{Query: `name="http.server.duration" AND max>=900 AND count<5`, Signal: SearchSignalMetrics})
+	if err != nil || len(res.Metrics) != 2 || res.Metrics[0].ServiceName != "checkout" {
+		t.Errorf("metrics search = %+v, %v", res, err)
+	}
+
+	// Filter errors carry no position: there is no query text.
+	filter, _ = argusql.ParseFilter([]byte(`{"field":"duration","op":">","value":500}`))
+	if _, err := repo.Search(ctx, SearchQuery{Filter: filter}); !errors.Is(err, ErrInvalidSearchQuery) || strings.Contains(err.Error(), "position") {
+		t.Errorf("bad filter error = %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Self-history event kinds.
+const (
+	SelfEventRun       = "run"           // one process lifetime: boot to last heartbeat
+	SelfEventStop      = "stop"          // graceful shutdown
+	SelfEventDowntime  = "downtime"      // no process running between two runs
+	SelfEventConfig    = "config_change" // settings differ from the previous run, or a runtime flag flipped
+	SelfEventDBOutage  = "db_outage"     // health pings failing
+	SelfEventIngestGap = "ingest_gap"    // no spans or logs arriving
+)
+
+// SelfEvent is one entry in this instance's own history, explaining holes
+// in the data: restarts, config changes, database outages and ingest gaps.
+// Events are instance-wide, not tenant-scoped. EndedAt is nil for
+// point-in-time events and for ones still in progress.
+type SelfEvent struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Kind      string     `gorm:"size:32;not null;index" json:"kind"`
+	StartedAt time.Time  `gorm:"not null;index" json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Version   string     `gorm:"size:64" json:"version,omitempty"`
+	Detail    string     `gorm:"type:text" json:"detail,omitempty"`
+	Config    string     `gorm:"type:text" json:"-"` // run only: JSON config fingerprint
+}
+
+// CreateSelfEvent stores e.
+func (r *Repository) CreateSelfEvent(ctx context.Context, e *SelfEvent) error {
+	if err := r.db.WithContext(ctx).Create(e).Error; err != nil {
+		return fmt.Errorf("self history: create %s: %w", e.Kind, err)
+	}
+	return nil
+}
+
+// EndSelfEvent sets the ended_at of event id, used as the heartbeat of the
+// current run.
+func (r *Repository) EndSelfEvent(ctx context.Context, id uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&SelfEvent{}).Where("id = ?", id).Update("ended_at", at).Error; err != nil {
+		return fmt.Errorf("self history: end event %d: %w", id, err)
+	}
+	return nil
+}
+
+// LatestSelfEvent returns the most recently started event of kind, or nil
+// when there is none.
+func (r *Repository) LatestSelfEvent(ctx context.Context, kind string) (*SelfEvent, error) {
+	var e SelfEvent
+	err := r.db.WithContext(ctx).Where("kind = ?", kind).Order("started_at DESC, id DESC").First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("self history: latest %s: %w", kind, err)
+	}
+	return &e, nil
+}
+
+// ListSelfEvents returns events overlapping [start, end], newest first.
+// Zero times leave that side open; kind filters when set.
+func (r *Repository) ListSelfEvents(ctx context.Context, kind string, start, end time.Time, limit int) ([]SelfEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+	q := r.db.WithContext(ctx)
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	if !start.IsZero() {
+		q = q.Where("started_at >= ? OR ended_at >= ?", start, start)
+	}
+	if !end.IsZero() {
+		q = q.Where("started_at <= ?", end)
+	}
+	var events []SelfEvent
+	if err := q.Order("started_at DESC, id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("self history: list: %w", err)
+	}
+	return events, nil
+}
+
+// PruneSelfEvents deletes events that started before cutoff, returning how
+// many were removed.
+func (r *Repository) PruneSelfEvents(ctx context.Context, cutoff time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("started_at < ?", cutoff).Delete(&SelfEvent{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("self history: prune: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
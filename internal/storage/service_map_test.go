@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGetServiceMapMetrics_EdgesFollowParentLinks(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	span := func(traceID, spanID, parent, svc string, durUs int64, status string) Span {
+		return Span{
+			TraceID:       traceID,
+			SpanID:        spanID,
+			ParentSpanID:  parent,
+			OperationName: "op",
+			StartTime:     now,
+			EndTime:       now.Add(time.Duration(durUs) * time.Microsecond),
+			Duration:      durUs,
+			ServiceName:   svc,
+			Status:        status,
+		}
+	}
+	spans := []Span{
+		// t1: gateway → orders → db. gateway and db share the trace but
+		// never call each other.
+		span("t1", "a", "", "gateway", 30_000, spanStatusOK),
+		span("t1", "b", "a", "orders", 20_000, spanStatusOK),
+		span("t1", "c", "b", "db", 4_000, spanStatusError),
+		// t2 reuses span ID "a" for a different service. Matching parents
+		// by span ID alone would invent a gateway → billing edge.
+		span("t2", "a", "", "billing", 10_000, spanStatusOK),
+		span("t2", "b", "a", "orders", 10_000, spanStatusError),
+	}
+	if err := repo.db.Create(&spans).Error; err != nil {
+		t.Fatalf("seed spans: %v", err)
+	}
+
+	m, err := repo.GetServiceMapMetrics(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("GetServiceMapMetrics: %v", err)
+	}
+
+	edges := make(map[string]ServiceMapEdge)
+	for _, e := range m.Edges {
+		edges[e.Source+"->"+e.Target] = e
+	}
+	if len(edges) != 3 {
+		t.Fatalf("edges = %+v, want gateway->orders, orders->db, billing->orders", m.Edges)
+	}
+	if e := edges["gateway->orders"]; e.CallCount != 1 || e.AvgLatencyMs != 20 || e.ErrorRate != 0 {
+		t.Errorf("gateway->orders = %+v, want 1 call, 20ms, no errors", e)
+	}
+	if e := edges["orders->db"]; e.CallCount != 1 || e.AvgLatencyMs != 4 || e.ErrorRate != 1 {
+		t.Errorf("orders->db = %+v, want 1 call, 4ms, error rate 1", e)
+	}
+	if e := edges["billing->orders"]; e.CallCount != 1 || e.ErrorRate != 1 {
+		t.Errorf("billing->orders = %+v, want 1 failed call", e)
+	}
+
+	nodes := make(map[string]ServiceMapNode)
+	for _, n := range m.Nodes {
+		nodes[n.Name] = n
+	}
+	if n := nodes["orders"]; n.TotalTraces != 2 || n.ErrorCount != 1 || n.AvgLatencyMs != 15 {
+		t.Errorf("orders node = %+v, want 2 traces, 1 error, 15ms", n)
+	}
+	if n := nodes["db"]; n.TotalTraces != 1 || n.ErrorCount != 1 {
+		t.Errorf("db node = %+v, want 1 trace, 1 error", n)
+	}
+}
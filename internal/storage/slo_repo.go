@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SLO kinds.
+const (
+	SLOKindAvailability = "availability"
+	SLOKindLatency      = "latency"
+)
+
+// SLOCount is the traces an SLO counts over one window.
+type SLOCount struct {
+	Total int64 `json:"total"`
+	Bad   int64 `json:"bad"`
+}
+
+// ListSLOs returns the SLOs of the tenant on ctx, oldest first.
+func (r *Repository) ListSLOs(ctx context.Context) ([]SLO, error) {
+	var slos []SLO
+	if err := r.db.WithContext(ctx).
+		Where(sqlWhereTenantID, TenantFromContext(ctx)).
+		Order("id ASC").
+		Find(&slos).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SLOs: %w", err)
+	}
+	return slos, nil
+}
+
+// ListSLOsAllTenants returns every SLO across tenants.
+//
+// Tenant scope: SYSTEM-WIDE, for the SLO evaluator only. Never expose this
+// on a tenant-scoped API surface.
+func (r *Repository) ListSLOsAllTenants(ctx context.Context) ([]SLO, error) {
+	var slos []SLO
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&slos).Error; err != nil {
+		return nil, fmt.Errorf("failed to list SLOs: %w", err)
+	}
+	return slos, nil
+}
+
+// GetSLO returns one SLO of the tenant on ctx. Returns
+// gorm.ErrRecordNotFound when the tenant has no such SLO.
+func (r *Repository) GetSLO(ctx context.Context, id uint) (*SLO, error) {
+	var slo SLO
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).
+		First(&slo).Error; err != nil {
+		return nil, err
+	}
+	return &slo, nil
+}
+
+// CreateSLO inserts slo under the tenant on ctx. Its status starts empty
+// until the next evaluation.
+func (r *Repository) CreateSLO(ctx context.Context, slo *SLO) error {
+	slo.ID = 0
+	slo.TenantID = TenantFromContext(ctx)
+	slo.Status = SLOStatus{}
+	if err := r.db.WithContext(ctx).Create(slo).Error; err != nil {
+		return fmt.Errorf("failed to create SLO: %w", err)
+	}
+	return nil
+}
+
+// UpdateSLO replaces the definition of an SLO of the tenant on ctx and
+// reloads slo from the stored row. The evaluated status is kept until the
+// next evaluation. Returns gorm.ErrRecordNotFound when the tenant has no
+// such SLO.
+func (r *Repository) UpdateSLO(ctx context.Context, id uint, slo *SLO) error {
+	slo.UpdatedAt = time.Now()
+	res := r.db.WithContext(ctx).Model(&SLO{}).
+		Where("id = ? AND tenant_id = ?", id, TenantFromContext(ctx)).
+		Select("name", "service_name", "kind", "objective", "latency_threshold_ms", "window_days", "updated_at").
+		Updates(slo)
+	if res.Error != nil {
+		return fmt.Errorf("failed to update SLO: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	stored, err := r.GetSLO(ctx, id)
+	if err != nil {
+		return err
+	}
+	*slo = *stored
+	return nil
+}
+
+// DeleteSLO removes an SLO of the tenant on ctx together with its burn-rate
+// alert rules, resolving their open events. Returns gorm.ErrRecordNotFound
+// when the tenant has no such SLO.
+func (r *Repository) DeleteSLO(ctx context.Context, id uint) error {
+	tenant := TenantFromContext(ctx)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND tenant_id = ?", id, tenant).Delete(&SLO{})
+		if res.Error != nil {
+			return fmt.Errorf("failed to delete SLO: %w", res.Error)
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		var ruleIDs []uint
+		if err := tx.Model(&AlertRule{}).
+			Where("slo_id = ? AND tenant_id = ?", id, tenant).
+			Pluck("id", &ruleIDs).Error; err != nil {
+			return fmt.Errorf("failed to find SLO alert rules: %w", err)
+		}
+		if len(ruleIDs) == 0 {
+			return nil
+		}
+		if err := tx.Where("id IN ?", ruleIDs).Delete(&AlertRule{}).Error; err != nil {
+			return fmt.Errorf("failed to delete SLO alert rules: %w", err)
+		}
+		return tx.Model(&AlertEvent{}).
+			Where("rule_id IN ? AND state = ?", ruleIDs, AlertStateFiring).
+			Updates(map[string]any{"state": AlertStateResolved, "resolved_at": time.Now()}).Error
+	})
+}
+
+// SaveSLOStatus stores the evaluated status of an SLO.
+//
+// Tenant scope: SYSTEM-WIDE (keyed by SLO ID), for the SLO evaluator.
+func (r *Repository) SaveSLOStatus(ctx context.Context, id uint, st SLOStatus) error {
+	if err := r.db.WithContext(ctx).Model(&SLO{}).Where("id = ?", id).
+		Select("status_evaluated_at", "status_total", "status_bad", "status_compliance",
+			"status_budget_remaining", "status_burn_rate_1h", "status_burn_rate_6h").
+		Updates(&SLO{Status: st}).Error; err != nil {
+		return fmt.Errorf("failed to save SLO status: %w", err)
+	}
+	return nil
+}
+
+// CountSLOEvents counts the traces of slo's service, for the tenant on ctx,
+// in each window ending at end, all in one scan bounded by the longest
+// window. A trace is bad when it has error status (availability) or is
+// slower than the latency threshold (latency).
+func (r *Repository) CountSLOEvents(ctx context.Context, slo *SLO, end time.Time, windows ...time.Duration) ([]SLOCount, error) {
+	if len(windows) == 0 {
+		return nil, nil
+	}
+	var bad string
+	var badArgs []any
+	switch slo.Kind {
+	case SLOKindAvailability:
+		bad = fmt.Sprintf("status %s '%%ERROR%%'", r.likeOp())
+	case SLOKindLatency:
+		bad = "duration > ?"
+		badArgs = []any{int64(slo.LatencyThresholdMs * 1000)}
+	default:
+		return nil, fmt.Errorf("unknown SLO kind %q", slo.Kind)
+	}
+
+	longest := windows[0]
+	cols := make([]string, 0, 2*len(windows))
+	var args []any
+	for i, w := range windows {
+		longest = max(longest, w)
+		since := end.Add(-w)
+		cols = append(cols,
+			fmt.Sprintf("SUM(CASE WHEN timestamp >= ? THEN 1 ELSE 0 END) AS total_%d", i),
+			fmt.Sprintf("SUM(CASE WHEN timestamp >= ? AND %s THEN 1 ELSE 0 END) AS bad_%d", bad, i))
+		args = append(args, since, since)
+		args = append(args, badArgs...)
+	}
+
+	row := r.db.WithContext(ctx).Model(&Trace{}).
+		Select(strings.Join(cols, ", "), args...).
+		Where(sqlWhereTenantTimeBetween, TenantFromContext(ctx), end.Add(-longest), end).
+		Where("service_name = ?", slo.ServiceName).
+		Row()
+	sums := make([]sql.NullInt64, 2*len(windows))
+	dest := make([]any, len(sums))
+	for i := range sums {
+		dest[i] = &sums[i]
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to count SLO events: %w", err)
+	}
+	out := make([]SLOCount, len(windows))
+	for i := range out {
+		out[i] = SLOCount{Total: sums[2*i].Int64, Bad: sums[2*i+1].Int64}
+	}
+	return out, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// spanAttributeMaxPerSpan bounds the rows one span can add, so a span
+	// carrying hundreds of attributes cannot multiply write volume.
+	spanAttributeMaxPerSpan = 64
+	spanAttributeMaxKey     = 128
+	spanAttributeMaxValue   = 255
+)
+
+// ErrAttributeNotIndexed is returned when a trace filter names an attribute
+// key that SPAN_ATTRIBUTE_INDEX_KEYS does not extract.
+var ErrAttributeNotIndexed = errors.New("attribute is not indexed")
+
+// SpanAttribute is one scalar span attribute copied out of AttributesJSON
+// (a compressed blob SQL cannot look into) so traces can be filtered by
+// attribute with an index. Written in the same statement batch as its span
+// when attribute indexing is enabled; see SetAttributeIndex. Values longer
+// than spanAttributeMaxValue are not indexed.
+type SpanAttribute struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	TenantID  string    `gorm:"size:64;default:'default';not null;uniqueIndex:idx_span_attrs_span_key,priority:1;index:idx_span_attrs_lookup,priority:1" json:"tenant_id"`
+	TraceID   string    `gorm:"size:32;not null;uniqueIndex:idx_span_attrs_span_key,priority:2" json:"trace_id"`
+	SpanID    string    `gorm:"size:16;not null;uniqueIndex:idx_span_attrs_span_key,priority:3" json:"span_id"`
+	Key       string    `gorm:"column:attr_key;size:128;not null;uniqueIndex:idx_span_attrs_span_key,priority:4;index:idx_span_attrs_lookup,priority:2" json:"key"`
+	Value     string    `gorm:"column:attr_value;size:255;not null;index:idx_span_attrs_lookup,priority:3" json:"value"`
+	StartTime time.Time `gorm:"index" json:"start_time"` // the span's; drives retention
+}
+
+// AttributeFilter is an equality predicate on a span attribute. A trace
+// matches when any of its spans carries Key with Value.
+type AttributeFilter struct {
+	Key   string
+	Value string
+}
+
+// attributeIndex is the set of span attribute keys extracted into
+// span_attributes. The zero value indexes nothing.
+type attributeIndex struct {
+	all  bool
+	keys map[string]bool
+}
+
+func (ix attributeIndex) enabled() bool { return ix.all || len(ix.keys) > 0 }
+
+func (ix attributeIndex) has(key string) bool { return ix.all || ix.keys[key] }
+
+// SetAttributeIndex selects the span attribute keys extracted into
+// span_attributes at write time: nil or empty disables indexing, "*" indexes
+// every scalar attribute. Must be called before ingest starts; spans written
+// earlier are not backfilled.
+func (r *Repository) SetAttributeIndex(keys []string) {
+	ix := attributeIndex{keys: make(map[string]bool)}
+	for _, k := range keys {
+		switch k = strings.TrimSpace(k); k {
+		case "":
+		case "*":
+			ix.all = true
+		default:
+			ix.keys[k] = true
+		}
+	}
+	r.attrIndex = ix
+}
+
+// AttributeIndexed reports whether key can be used in an AttributeFilter.
+func (r *Repository) AttributeIndexed(key string) bool { return r.attrIndex.has(key) }
+
+// spanAttributeRows extracts the indexed scalar attributes of spans.
+func (r *Repository) spanAttributeRows(spans []Span) []SpanAttribute {
+	if !r.attrIndex.enabled() {
+		return nil
+	}
+	var rows []SpanAttribute
+	for i := range spans {
+		sp := &spans[i]
+		n := 0
+		for _, kv := range parseAttributes(string(sp.AttributesJSON)) {
+			if n == spanAttributeMaxPerSpan {
+				break
+			}
+			if !r.attrIndex.has(kv.Key) || len(kv.Key) > spanAttributeMaxKey {
+				continue
+			}
+			v, ok := kv.scalar()
+			if !ok || len(v) > spanAttributeMaxValue {
+				continue
+			}
+			rows = append(rows, SpanAttribute{
+				TenantID:  sp.TenantID,
+				TraceID:   sp.TraceID,
+				SpanID:    sp.SpanID,
+				Key:       kv.Key,
+				Value:     v,
+				StartTime: sp.StartTime,
+			})
+			n++
+		}
+	}
+	return rows
+}
+
+// createSpanAttributesIdempotent inserts rows, absorbing duplicates on
+// idx_span_attrs_span_key so replayed spans do not double their attributes.
+func createSpanAttributesIdempotent(db *gorm.DB, driver string, rows []SpanAttribute) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if strings.ToLower(driver) == "mysql" {
+		return db.Clauses(clause.Insert{Modifier: "IGNORE"}).CreateInBatches(rows, 500).Error
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500).Error
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func statusAttrs(code int, route string) CompressedText {
+	return CompressedText(fmt.Sprintf(`[{"key":"http.status_code","value":{"Value":{"IntValue":%d}}},{"key":"http.route","value":{"Value":{"StringValue":%q}}},{"key":"user.id","value":{"Value":{"StringValue":"u1"}}}]`, code, route))
+}
+
+func TestGetTracesFiltered_AttributeFilters(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.SetAttributeIndex([]string{"http.status_code", " http.route ", ""})
+	ctx := context.Background()
+	now := time.Now()
+
+	spans := []Span{
+		{TraceID: "t1", SpanID: "a", AttributesJSON: statusAttrs(500, "/pay")},
+		{TraceID: "t1", SpanID: "b", ParentSpanID: "a", AttributesJSON: statusAttrs(200, "/ledger")},
+		{TraceID: "t2", SpanID: "a", AttributesJSON: statusAttrs(200, "/pay")},
+		{TraceID: "t3", SpanID: "a", AttributesJSON: statusAttrs(500, "/cart")},
+	}
+	for i := range spans {
+		spans[i].TenantID = DefaultTenantID
+		spans[i].ServiceName = "checkout"
+		spans[i].StartTime = now.Add(-time.Duration(i+1) * time.Minute)
+		if spans[i].ParentSpanID != "" {
+			continue
+		}
+		if err := repo.db.Create(&Trace{TenantID: DefaultTenantID, TraceID: spans[i].TraceID, ServiceName: "checkout", Timestamp: spans[i].StartTime}).Error; err != nil {
+			t.Fatalf("create trace: %v", err)
+		}
+	}
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+	// Replayed spans must not duplicate their attribute rows.
+	if err := repo.BatchCreateSpans(spans[:1]); err != nil {
+		t.Fatalf("replay BatchCreateSpans: %v", err)
+	}
+	if got := mustCount(t, repo.db, &SpanAttribute{}); got != 8 {
+		t.Fatalf("span_attributes rows = %d, want 8 (user.id not indexed)", got)
+	}
+
+	cases := []struct {
+		attrs []AttributeFilter
+		want  []string
+	}{
+		{[]AttributeFilter{{Key: "http.status_code", Value: "500"}}, []string{"t1", "t3"}},
+		{[]AttributeFilter{{Key: "http.status_code", Value: "500"}, {Key: "http.route", Value: "/pay"}}, []string{"t1"}},
+		// Predicates may match different spans of the same trace.
+		{[]AttributeFilter{{Key: "http.status_code", Value: "200"}, {Key: "http.route", Value: "/cart"}}, nil},
+		{[]AttributeFilter{{Key: "http.route", Value: "/ledger"}}, []string{"t1"}},
+	}
+	for _, c := range cases {
+		resp, err := repo.GetTracesFiltered(ctx, now.Add(-time.Hour), time.Time{}, nil, "", "", 10, 0, "", "", c.attrs...)
+		if err != nil {
+			t.Fatalf("GetTracesFiltered(%v): %v", c.attrs, err)
+		}
+		var got []string
+		for _, tr := range resp.Traces {
+			got = append(got, tr.TraceID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) || int(resp.Total) != len(c.want) {
+			t.Errorf("GetTracesFiltered(%v) = %v (total %d), want %v", c.attrs, got, resp.Total, c.want)
+		}
+	}
+
+	_, err := repo.GetTracesFiltered(ctx, time.Time{}, time.Time{}, nil, "", "", 10, 0, "", "", AttributeFilter{Key: "user.id", Value: "u1"})
+	if !errors.Is(err, ErrAttributeNotIndexed) {
+		t.Errorf("filter on unindexed key: err = %v, want ErrAttributeNotIndexed", err)
+	}
+}
+
+func TestSetAttributeIndex(t *testing.T) {
+	repo := newTestRepo(t)
+	if repo.AttributeIndexed("http.route") || repo.spanAttributeRows([]Span{{AttributesJSON: statusAttrs(200, "/")}}) != nil {
+		t.Error("zero-value index should index nothing")
+	}
+	repo.SetAttributeIndex([]string{"*"})
+	if !repo.AttributeIndexed("anything") {
+		t.Error(`"*" should index every key`)
+	}
+	if rows := repo.spanAttributeRows([]Span{{AttributesJSON: statusAttrs(200, "/")}}); len(rows) != 3 {
+		t.Errorf("rows = %+v, want 3", rows)
+	}
+}
+
+func TestPurgeTracesBatched_SweepsSpanAttributes(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.SetAttributeIndex([]string{"*"})
+	cutoff := time.Now().UTC().Add(-7 * 24 * time.Hour)
+	for _, id := range []string{"t-old", "t-new"} {
+		ts := time.Now().UTC()
+		if id == "t-old" {
+			ts = cutoff.Add(-time.Hour)
+		}
+		if err := repo.db.Create(&Trace{TenantID: DefaultTenantID, TraceID: id, Timestamp: ts}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.BatchCreateSpans([]Span{{TenantID: DefaultTenantID, TraceID: id, SpanID: "a", StartTime: ts, AttributesJSON: statusAttrs(200, "/")}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := repo.PurgeTracesBatched(context.Background(), cutoff, 10, time.Millisecond); err != nil {
+		t.Fatalf("PurgeTracesBatched: %v", err)
+	}
+	var ids []string
+	repo.db.Model(&SpanAttribute{}).Distinct("trace_id").Pluck("trace_id", &ids)
+	if fmt.Sprint(ids) != "[t-new]" {
+		t.Errorf("span_attributes traces after purge = %v, want [t-new]", ids)
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxSpanEvents caps the events stored per span, the OTel SDKs' default
+	// event count limit.
+	maxSpanEvents = 128
+	// maxSpanEventName bounds a stored event name; longer names are cut on
+	// a rune boundary.
+	maxSpanEventName = 255
+	// maxTraceEvents bounds the events GetSpanEvents returns for a trace.
+	maxTraceEvents            = 1000
+	defaultSpanEventTraceList = 100
+)
+
+// SpanEvent is one OTLP span event (name, time, attributes), e.g.
+// database_lock_contention, copied out of its span at write time so traces
+// can be searched by what happened in them. Exception events are stored
+// here too; span_exceptions adds their parsed type and stack.
+type SpanEvent struct {
+	ID             uint           `gorm:"primaryKey" json:"-"`
+	TenantID       string         `gorm:"size:64;default:'default';not null;uniqueIndex:idx_span_events_event,priority:1;index:idx_span_events_name,priority:1" json:"-"`
+	TraceID        string         `gorm:"size:32;not null;uniqueIndex:idx_span_events_event,priority:2" json:"trace_id"`
+	SpanID         string         `gorm:"size:16;not null;uniqueIndex:idx_span_events_event,priority:3" json:"span_id"`
+	ServiceName    string         `gorm:"size:255" json:"service_name"`
+	Name           string         `gorm:"size:255;not null;uniqueIndex:idx_span_events_event,priority:4;index:idx_span_events_name,priority:2" json:"name"`
+	Timestamp      time.Time      `gorm:"not null;uniqueIndex:idx_span_events_event,priority:5;index:idx_span_events_name,priority:3;index" json:"timestamp"`
+	AttributesJSON CompressedText `json:"attributes_json,omitempty"`
+}
+
+// spanEventRows flattens the Events of spans, at most maxSpanEvents each.
+func spanEventRows(spans []Span) []SpanEvent {
+	var rows []SpanEvent
+	for i := range spans {
+		sp := &spans[i]
+		for _, e := range sp.Events[:min(len(sp.Events), maxSpanEvents)] {
+			if e.Name == "" {
+				continue
+			}
+			e.ID = 0
+			e.Name = TruncateUTF8(e.Name, maxSpanEventName)
+			e.TenantID, e.TraceID, e.SpanID, e.ServiceName = sp.TenantID, sp.TraceID, sp.SpanID, sp.ServiceName
+			if e.Timestamp.IsZero() {
+				e.Timestamp = sp.StartTime
+			}
+			rows = append(rows, e)
+		}
+	}
+	return rows
+}
+
+// createSpanEventsIdempotent inserts rows, absorbing duplicates on
+// idx_span_events_event so a replayed batch is a no-op.
+func createSpanEventsIdempotent(db *gorm.DB, driver string, rows []SpanEvent) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if strings.ToLower(driver) == "mysql" {
+		return db.Clauses(clause.Insert{Modifier: "IGNORE"}).CreateInBatches(rows, 500).Error
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500).Error
+}
+
+// SpanEventQuery selects traces containing an event.
+type SpanEventQuery struct {
+	Name         string // exact event name; required
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string // services that recorded the event; empty = all
+	Limit        int      // max traces returned (default 100)
+}
+
+// SpanEventTrace is a trace that recorded the queried event. ServiceName,
+// Status, DurationNs and Timestamp come from the trace row and are empty
+// when it is not stored (sampled out or expired).
+type SpanEventTrace struct {
+	TraceID     string    `json:"trace_id"`
+	ServiceName string    `json:"service_name"`
+	Status      string    `json:"status"`
+	DurationNs  int64     `json:"duration_ns"`
+	Timestamp   time.Time `json:"timestamp"`
+	EventCount  int64     `json:"event_count"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	// The latest occurrence in the trace.
+	SampleSpanID     string         `json:"sample_span_id"`
+	SampleService    string         `json:"sample_service_name"`
+	SampleAttributes CompressedText `json:"sample_attributes_json,omitempty"`
+}
+
+// SearchSpanEventTraces returns the tenant's traces with at least one event
+// named q.Name in [Start, End] (default the last 24h), most recent
+// occurrence first.
+func (r *Repository) SearchSpanEventTraces(ctx context.Context, q SpanEventQuery) ([]SpanEventTrace, error) {
+	q.Start, q.End = exceptionWindow(q.Start, q.End)
+	if q.Limit <= 0 {
+		q.Limit = defaultSpanEventTraceList
+	}
+	tenant := TenantFromContext(ctx)
+	db := r.db.WithContext(ctx)
+
+	query := db.Model(&SpanEvent{}).
+		Select("trace_id, COUNT(*) AS event_count, MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen, MAX(id) AS sample_id").
+		Where("tenant_id = ? AND name = ? AND timestamp BETWEEN ? AND ?", tenant, q.Name, q.Start, q.End)
+	if len(q.ServiceNames) > 0 {
+		query = query.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+	var rows []struct {
+		TraceID    string
+		EventCount int64
+		FirstSeen  aggregateTime
+		LastSeen   aggregateTime
+		SampleID   uint
+	}
+	if err := query.Group("trace_id").Order("last_seen DESC").Limit(q.Limit).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search span events: %w", err)
+	}
+	if len(rows) == 0 {
+		return []SpanEventTrace{}, nil
+	}
+
+	traceIDs := make([]string, len(rows))
+	sampleIDs := make([]uint, len(rows))
+	for i, row := range rows {
+		traceIDs[i], sampleIDs[i] = row.TraceID, row.SampleID
+	}
+	var samples []SpanEvent
+	if err := db.Where("id IN ?", sampleIDs).Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to load span event samples: %w", err)
+	}
+	byID := make(map[uint]SpanEvent, len(samples))
+	for _, s := range samples {
+		byID[s.ID] = s
+	}
+	var traces []Trace
+	if err := db.Select("trace_id, service_name, status, duration_ns, timestamp").
+		Where("tenant_id = ? AND trace_id IN ?", tenant, traceIDs).Find(&traces).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up span event traces: %w", err)
+	}
+	byTrace := make(map[string]Trace, len(traces))
+	for _, t := range traces {
+		byTrace[t.TraceID] = t
+	}
+
+	out := make([]SpanEventTrace, 0, len(rows))
+	for _, row := range rows {
+		t, s := byTrace[row.TraceID], byID[row.SampleID]
+		out = append(out, SpanEventTrace{
+			TraceID:          row.TraceID,
+			ServiceName:      t.ServiceName,
+			Status:           t.Status,
+			DurationNs:       t.DurationNs,
+			Timestamp:        t.Timestamp,
+			EventCount:       row.EventCount,
+			FirstSeen:        row.FirstSeen.t,
+			LastSeen:         row.LastSeen.t,
+			SampleSpanID:     s.SpanID,
+			SampleService:    s.ServiceName,
+			SampleAttributes: s.AttributesJSON,
+		})
+	}
+	return out, nil
+}
+
+// GetSpanEvents returns the stored events of the tenant's trace traceID in
+// time order, at most maxTraceEvents.
+func (r *Repository) GetSpanEvents(ctx context.Context, traceID string) ([]SpanEvent, error) {
+	var out []SpanEvent
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND trace_id = ?", TenantFromContext(ctx), traceID).
+		Order("timestamp, id").Limit(maxTraceEvents).
+		Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to get span events: %w", err)
+	}
+	return out, nil
+}
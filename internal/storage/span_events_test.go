@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSpanEvents_SearchTracesByEventName(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	t0 := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	locked := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	clean := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	span := func(trace, id, svc string, events ...SpanEvent) Span {
+		sp := Span{TenantID: "acme", TraceID: trace, SpanID: id, ServiceName: svc, OperationName: "op", Events: events}
+		sp.SetTiming(t0, t0.Add(time.Second))
+		return sp
+	}
+	traces := []Trace{
+		{TenantID: "acme", TraceID: locked, ServiceName: "orders", Status: "STATUS_CODE_ERROR", Timestamp: t0},
+		{TenantID: "acme", TraceID: clean, ServiceName: "orders", Timestamp: t0},
+	}
+	spans := []Span{
+		span(locked, "1111111111111111", "orders"),
+		span(locked, "2222222222222222", "inventory",
+			SpanEvent{Name: "database_lock_contention", Timestamp: t0.Add(100 * time.Millisecond), AttributesJSON: `[{"key":"db.wait_ms","value":{"Value":{"IntValue":250}}}]`},
+			SpanEvent{Name: "database_lock_contention", Timestamp: t0.Add(300 * time.Millisecond)},
+			SpanEvent{Name: "cache_miss"}, // no time: the span's start
+		),
+		span(clean, "3333333333333333", "orders", SpanEvent{Name: "cache_miss", Timestamp: t0.Add(time.Millisecond)}),
+	}
+	if err := repo.BatchCreateAll(traces, spans, nil); err != nil {
+		t.Fatalf("BatchCreateAll: %v", err)
+	}
+	// A replay stores nothing twice.
+	if err := repo.BatchCreateSpans(spans[1:2]); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+
+	got, err := repo.SearchSpanEventTraces(ctx, SpanEventQuery{Name: "database_lock_contention"})
+	if err != nil {
+		t.Fatalf("SearchSpanEventTraces: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("traces = %+v, want only the locked one", got)
+	}
+	g := got[0]
+	if g.TraceID != locked || g.ServiceName != "orders" || g.Status != "STATUS_CODE_ERROR" || g.EventCount != 2 ||
+		!g.FirstSeen.Equal(t0.Add(100*time.Millisecond)) || !g.LastSeen.Equal(t0.Add(300*time.Millisecond)) ||
+		g.SampleSpanID != "2222222222222222" || g.SampleService != "inventory" {
+		t.Errorf("trace = %+v", g)
+	}
+
+	if got, _ := repo.SearchSpanEventTraces(ctx, SpanEventQuery{Name: "cache_miss"}); len(got) != 2 {
+		t.Errorf("cache_miss traces = %+v, want 2", got)
+	}
+	if got, _ := repo.SearchSpanEventTraces(ctx, SpanEventQuery{Name: "database_lock_contention", ServiceNames: []string{"orders"}}); len(got) != 0 {
+		t.Errorf("orders-only search = %+v, want none", got)
+	}
+	if got, _ := repo.SearchSpanEventTraces(WithTenantContext(ctx, "other"), SpanEventQuery{Name: "cache_miss"}); len(got) != 0 {
+		t.Errorf("other tenant = %+v", got)
+	}
+
+	events, err := repo.GetSpanEvents(ctx, locked)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("GetSpanEvents = %+v, %v; want 3", events, err)
+	}
+	if events[0].Name != "cache_miss" || !events[0].Timestamp.Equal(t0) || events[1].AttributesJSON == "" {
+		t.Errorf("events = %+v", events)
+	}
+
+	// Retention removes the events with their trace.
+	if _, err := repo.PurgeTracesBatched(context.Background(), time.Now(), 100, 0); err != nil {
+		t.Fatalf("PurgeTracesBatched: %v", err)
+	}
+	var n int64
+	repo.db.Model(&SpanEvent{}).Count(&n)
+	if n != 0 {
+		t.Errorf("%d span events left after purge", n)
+	}
+}
+
+func TestSpanEventRows_NameRuneBoundary(t *testing.T) {
+	name := strings.Repeat("é", 200) // byte 255 is mid-rune
+	rows := spanEventRows([]Span{{TraceID: "t1", SpanID: "s1", Events: []SpanEvent{{Name: name}}}})
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(rows))
+	}
+	if got := rows[0].Name; !utf8.ValidString(got) || len(got) != maxSpanEventName-1 {
+		t.Errorf("name truncated to %d bytes, valid UTF-8 = %v", len(got), utf8.ValidString(got))
+	}
+}
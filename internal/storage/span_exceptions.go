@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// spanExceptionMaxStack caps a stored stack trace; the top frames are
+	// the ones that identify a crash.
+	spanExceptionMaxStack = 64 << 10
+
+	defaultExceptionGroupLimit = 50
+	defaultExceptionListLimit  = 100
+)
+
+// SpanException is one OTel exception event (exception.type,
+// exception.message, exception.stacktrace) copied out of the attributes blob
+// of the log the receiver synthesizes for it, so exceptions can be grouped
+// and listed in SQL. Written in the same statement batch as the log.
+// Fingerprint matches the errors explorer's IssueFingerprint for the same
+// service, type and message, so an exception group and its issue share
+// triage state.
+type SpanException struct {
+	ID          uint           `gorm:"primaryKey" json:"-"`
+	TenantID    string         `gorm:"size:64;default:'default';not null;uniqueIndex:idx_span_exceptions_event,priority:1;index:idx_span_exceptions_group,priority:1" json:"tenant_id"`
+	TraceID     string         `gorm:"size:32;not null;uniqueIndex:idx_span_exceptions_event,priority:2" json:"trace_id"`
+	SpanID      string         `gorm:"size:16;not null;uniqueIndex:idx_span_exceptions_event,priority:3" json:"span_id"`
+	ServiceName string         `gorm:"size:255;not null" json:"service_name"`
+	Type        string         `gorm:"column:exception_type;size:255;not null" json:"type"`
+	Message     string         `gorm:"type:text" json:"message"`
+	Stacktrace  CompressedText `json:"stacktrace,omitempty"`
+	Escaped     bool           `json:"escaped"`
+	Fingerprint string         `gorm:"size:16;not null;index:idx_span_exceptions_group,priority:2" json:"fingerprint"`
+	Timestamp   time.Time      `gorm:"not null;uniqueIndex:idx_span_exceptions_event,priority:4;index" json:"timestamp"`
+}
+
+// spanExceptionRows extracts the exception events among logs: rows tied to
+// a span whose attributes carry exception.type or exception.message.
+func spanExceptionRows(logs []Log) []SpanException {
+	var rows []SpanException
+	for i := range logs {
+		l := &logs[i]
+		attrs := string(l.AttributesJSON)
+		if l.SpanID == "" || l.TraceID == "" || !strings.Contains(attrs, `"exception.`) {
+			continue
+		}
+		var ex SpanException
+		var found bool
+		for _, kv := range parseAttributes(attrs) {
+			v, ok := kv.scalar()
+			if !ok {
+				continue
+			}
+			switch kv.Key {
+			case "exception.type":
+				ex.Type, found = v, true
+			case "exception.message":
+				ex.Message, found = v, true
+			case "exception.stacktrace":
+				ex.Stacktrace = CompressedText(v[:min(len(v), spanExceptionMaxStack)])
+			case "exception.escaped":
+				ex.Escaped = v == "true"
+			}
+		}
+		if !found {
+			continue
+		}
+		if ex.Type == "" {
+			ex.Type = unknownErrorType
+		}
+		ex.TenantID, ex.TraceID, ex.SpanID = l.TenantID, l.TraceID, l.SpanID
+		ex.ServiceName, ex.Timestamp = l.ServiceName, l.Timestamp
+		ex.Fingerprint = IssueFingerprint(ex.ServiceName, ex.Type, NormalizeErrorMessage(ex.Message))
+		rows = append(rows, ex)
+	}
+	return rows
+}
+
+// createSpanExceptionsIdempotent inserts rows, absorbing duplicates on
+// idx_span_exceptions_event so a replayed batch does not double-count.
+func createSpanExceptionsIdempotent(db *gorm.DB, driver string, rows []SpanException) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if strings.ToLower(driver) == "mysql" {
+		return db.Clauses(clause.Insert{Modifier: "IGNORE"}).CreateInBatches(rows, 500).Error
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500).Error
+}
+
+// ExceptionGroupQuery selects exception groups.
+type ExceptionGroupQuery struct {
+	Start        time.Time
+	End          time.Time
+	ServiceNames []string
+	Type         string // exact exception type; empty = all
+	Limit        int    // max groups returned (default 50)
+}
+
+// ExceptionGroup is the exceptions of one fingerprint: same service, type
+// and normalized message. The sample fields come from its latest occurrence.
+type ExceptionGroup struct {
+	Fingerprint      string    `json:"fingerprint"`
+	ServiceName      string    `json:"service_name"`
+	Type             string    `json:"type"`
+	Message          string    `json:"message"` // normalized template
+	Count            int64     `json:"count"`
+	FirstSeen        time.Time `json:"first_seen"`
+	LastSeen         time.Time `json:"last_seen"`
+	SampleMessage    string    `json:"sample_message"`
+	SampleStacktrace string    `json:"sample_stacktrace,omitempty"`
+	SampleTraceID    string    `json:"sample_trace_id"`
+	SampleSpanID     string    `json:"sample_span_id"`
+}
+
+// GetExceptionGroups groups the exceptions in [Start, End] (default the last
+// 24h) of the tenant on ctx by fingerprint in SQL, most frequent first.
+func (r *Repository) GetExceptionGroups(ctx context.Context, q ExceptionGroupQuery) ([]ExceptionGroup, error) {
+	q.Start, q.End = exceptionWindow(q.Start, q.End)
+	if q.Limit <= 0 {
+		q.Limit = defaultExceptionGroupLimit
+	}
+	query := r.db.WithContext(ctx).Model(&SpanException{}).
+		Select("fingerprint, service_name, exception_type, COUNT(*) AS count, MIN(timestamp) AS first_seen, MAX(timestamp) AS last_seen, MAX(id) AS sample_id").
+		Where(sqlWhereTenantTimeBetween, TenantFromContext(ctx), q.Start, q.End)
+	if len(q.ServiceNames) > 0 {
+		query = query.Where(sqlWhereServiceIn, q.ServiceNames)
+	}
+	if q.Type != "" {
+		query = query.Where("exception_type = ?", q.Type)
+	}
+	var rows []struct {
+		Fingerprint   string
+		ServiceName   string
+		ExceptionType string
+		Count         int64
+		FirstSeen     aggregateTime
+		LastSeen      aggregateTime
+		SampleID      uint
+	}
+	if err := query.Group("fingerprint, service_name, exception_type").
+		Order("count DESC, last_seen DESC").
+		Limit(q.Limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to group exceptions: %w", err)
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.SampleID
+	}
+	samples := make(map[uint]SpanException, len(ids))
+	if len(ids) > 0 {
+		var found []SpanException
+		if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("failed to load exception samples: %w", err)
+		}
+		for _, ex := range found {
+			samples[ex.ID] = ex
+		}
+	}
+
+	groups := make([]ExceptionGroup, 0, len(rows))
+	for _, row := range rows {
+		s := samples[row.SampleID]
+		groups = append(groups, ExceptionGroup{
+			Fingerprint:      row.Fingerprint,
+			ServiceName:      row.ServiceName,
+			Type:             row.ExceptionType,
+			Message:          NormalizeErrorMessage(s.Message),
+			Count:            row.Count,
+			FirstSeen:        row.FirstSeen.t,
+			LastSeen:         row.LastSeen.t,
+			SampleMessage:    s.Message,
+			SampleStacktrace: string(s.Stacktrace),
+			SampleTraceID:    s.TraceID,
+			SampleSpanID:     s.SpanID,
+		})
+	}
+	return groups, nil
+}
+
+// GetExceptions returns the occurrences of one exception group in
+// [start, end] (default the last 24h) for the tenant on ctx, newest first.
+func (r *Repository) GetExceptions(ctx context.Context, fingerprint string, start, end time.Time, limit int) ([]SpanException, error) {
+	start, end = exceptionWindow(start, end)
+	if limit <= 0 || limit > 1000 {
+		limit = defaultExceptionListLimit
+	}
+	var out []SpanException
+	if err := r.db.WithContext(ctx).
+		Where(sqlWhereTenantTimeBetween, TenantFromContext(ctx), start, end).
+		Where("fingerprint = ?", fingerprint).
+		Order(sqlOrderTimestampDesc).
+		Limit(limit).
+		Find(&out).Error; err != nil {
+		return nil, fmt.Errorf("failed to list exceptions: %w", err)
+	}
+	return out, nil
+}
+
+// exceptionWindow applies the errors explorer's default range.
+func exceptionWindow(start, end time.Time) (time.Time, time.Time) {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() || !start.Before(end) {
+		start = end.Add(-24 * time.Hour)
+	}
+	return start, end
+}
+
+// aggregateTime scans MIN/MAX over a timestamp column, which SQLite returns
+// as text since the aggregate has no declared type.
+type aggregateTime struct{ t time.Time }
+
+// aggregateTimeLayouts are the text forms the SQLite driver writes.
+var aggregateTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// Value satisfies driver.Valuer, which GORM requires of scanned fields.
+func (a aggregateTime) Value() (driver.Value, error) { return a.t, nil }
+
+func (a *aggregateTime) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		a.t = v
+		return nil
+	case []byte:
+		src = string(v)
+	}
+	text, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("unsupported aggregate time %T", src)
+	}
+	for _, layout := range aggregateTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			a.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unparseable aggregate time %q", text)
+}
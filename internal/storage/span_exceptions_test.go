@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exceptionAttrs(errType, msg, stack string) CompressedText {
+	return CompressedText(fmt.Sprintf(`[{"key":"exception.type","value":{"Value":{"StringValue":%q}}},{"key":"exception.message","value":{"Value":{"StringValue":%q}}},{"key":"exception.stacktrace","value":{"Value":{"StringValue":%q}}},{"key":"exception.escaped","value":{"Value":{"BoolValue":true}}}]`, errType, msg, stack))
+}
+
+func TestSpanExceptions_MaterializedAndGrouped(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	logs := []Log{
+		{TraceID: "t1", SpanID: "a", Body: "timeout after 3012ms", AttributesJSON: exceptionAttrs("TimeoutError", "timeout after 3012ms", "at pay()\nat main()")},
+		{TraceID: "t2", SpanID: "b", Body: "timeout after 998ms", AttributesJSON: exceptionAttrs("TimeoutError", "timeout after 998ms", "at pay()")},
+		{TraceID: "t3", SpanID: "c", Body: "nil map", AttributesJSON: exceptionAttrs("PanicError", "nil map", "")},
+		// Not exceptions: no span, and a span log without exception attributes.
+		{Body: "orphan", AttributesJSON: exceptionAttrs("TimeoutError", "x", "")},
+		{TraceID: "t4", SpanID: "d", Body: "plain", AttributesJSON: statusAttrs(500, "/")},
+	}
+	for i := range logs {
+		logs[i].TenantID = DefaultTenantID
+		logs[i].ServiceName = "checkout"
+		logs[i].Severity = "ERROR"
+		logs[i].Timestamp = now.Add(-time.Duration(len(logs)-i) * time.Minute)
+	}
+	if err := repo.BatchCreateLogs(logs); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+	// A replayed batch must not double the exceptions.
+	replay := logs[0]
+	replay.ID = 0
+	if err := repo.BatchCreateAll(nil, nil, []Log{replay}); err != nil {
+		t.Fatalf("replay BatchCreateAll: %v", err)
+	}
+	if got := mustCount(t, repo.db, &SpanException{}); got != 3 {
+		t.Fatalf("span_exceptions rows = %d, want 3", got)
+	}
+
+	groups, err := repo.GetExceptionGroups(ctx, ExceptionGroupQuery{Start: now.Add(-time.Hour), End: now})
+	if err != nil {
+		t.Fatalf("GetExceptionGroups: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("groups = %+v, want 2", groups)
+	}
+	g := groups[0]
+	if g.Type != "TimeoutError" || g.Count != 2 || g.Message != "timeout after <n>ms" ||
+		g.SampleTraceID != "t2" || g.SampleMessage != "timeout after 998ms" || g.SampleStacktrace != "at pay()" ||
+		!g.FirstSeen.Equal(logs[0].Timestamp) || !g.LastSeen.Equal(logs[1].Timestamp) {
+		t.Errorf("top group = %+v", g)
+	}
+	if want := IssueFingerprint("checkout", "TimeoutError", "timeout after <n>ms"); g.Fingerprint != want {
+		t.Errorf("fingerprint = %s, want the errors explorer's %s", g.Fingerprint, want)
+	}
+
+	if only, _ := repo.GetExceptionGroups(ctx, ExceptionGroupQuery{Start: now.Add(-time.Hour), End: now, Type: "PanicError"}); len(only) != 1 || only[0].Count != 1 {
+		t.Errorf("PanicError groups = %+v", only)
+	}
+
+	occ, err := repo.GetExceptions(ctx, g.Fingerprint, now.Add(-time.Hour), now, 0)
+	if err != nil {
+		t.Fatalf("GetExceptions: %v", err)
+	}
+	if len(occ) != 2 || occ[0].TraceID != "t2" || !occ[0].Escaped || !strings.HasPrefix(string(occ[1].Stacktrace), "at pay()\n") {
+		t.Errorf("occurrences = %+v", occ)
+	}
+	if other, _ := repo.GetExceptions(WithTenantContext(ctx, "acme"), g.Fingerprint, now.Add(-time.Hour), now, 0); len(other) != 0 {
+		t.Errorf("other tenant sees %d exceptions", len(other))
+	}
+}
+
+func TestPurgeTracesBatched_SweepsSpanExceptions(t *testing.T) {
+	repo := newTestRepo(t)
+	cutoff := time.Now().UTC().Add(-7 * 24 * time.Hour)
+	for _, id := range []string{"t-old", "t-new"} {
+		ts := time.Now().UTC()
+		if id == "t-old" {
+			ts = cutoff.Add(-time.Hour)
+		}
+		if err := repo.db.Create(&Trace{TenantID: DefaultTenantID, TraceID: id, Timestamp: ts}).Error; err != nil {
+			t.Fatal(err)
+		}
+		l := Log{TenantID: DefaultTenantID, TraceID: id, SpanID: "a", Timestamp: ts, AttributesJSON: exceptionAttrs("E", "boom", "")}
+		if err := repo.BatchCreateLogs([]Log{l}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := repo.PurgeTracesBatched(context.Background(), cutoff, 10, time.Millisecond); err != nil {
+		t.Fatalf("PurgeTracesBatched: %v", err)
+	}
+	var ids []string
+	repo.db.Model(&SpanException{}).Pluck("trace_id", &ids)
+	if fmt.Sprint(ids) != "[t-new]" {
+		t.Errorf("span_exceptions traces after purge = %v, want [t-new]", ids)
+	}
+}
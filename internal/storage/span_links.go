@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxSpanLinks caps the links stored per span, the OTel SDKs' default
+	// link count limit.
+	maxSpanLinks = 128
+	// maxTraceLinks bounds the links GetTrace returns per direction.
+	maxTraceLinks = 1000
+	// MaxSpanLinkTraceState is the size of SpanLink.TraceState. A longer
+	// W3C tracestate is dropped rather than cut, since a truncated one is
+	// meaningless.
+	MaxSpanLinkTraceState = 512
+)
+
+// Trace link directions.
+const (
+	TraceLinkOutgoing = "outgoing" // a span of this trace links to the other trace
+	TraceLinkIncoming = "incoming" // a span of the other trace links to this one
+)
+
+// SpanLink is one OTLP span link: span (TraceID, SpanID) points at
+// (LinkedTraceID, LinkedSpanID), typically a consumer at the producer of its
+// message or a batch job at each request it processes. Written in the same
+// statement batch as its span; indexed by the linked trace so the link can
+// be followed back.
+type SpanLink struct {
+	ID             uint           `gorm:"primaryKey" json:"-"`
+	TenantID       string         `gorm:"size:64;default:'default';not null;uniqueIndex:idx_span_links_link,priority:1;index:idx_span_links_linked,priority:1" json:"-"`
+	TraceID        string         `gorm:"size:32;not null;uniqueIndex:idx_span_links_link,priority:2" json:"-"`
+	SpanID         string         `gorm:"size:16;not null;uniqueIndex:idx_span_links_link,priority:3" json:"-"`
+	LinkedTraceID  string         `gorm:"size:32;not null;uniqueIndex:idx_span_links_link,priority:4;index:idx_span_links_linked,priority:2" json:"linked_trace_id"`
+	LinkedSpanID   string         `gorm:"size:16;not null;uniqueIndex:idx_span_links_link,priority:5" json:"linked_span_id"`
+	TraceState     string         `gorm:"size:512" json:"trace_state,omitempty"`
+	AttributesJSON CompressedText `json:"attributes_json,omitempty"`
+	StartTime      time.Time      `gorm:"index" json:"-"` // the span's; drives retention
+}
+
+// TraceLink is a span link seen from one trace: SpanID is the span of that
+// trace, LinkedTraceID and LinkedSpanID the span on the other end.
+// LinkedServiceName is the linked trace's service, empty when it is not
+// stored (sampled out, expired, or not sent).
+type TraceLink struct {
+	Direction         string         `json:"direction"` // outgoing | incoming
+	SpanID            string         `json:"span_id"`
+	LinkedTraceID     string         `json:"linked_trace_id"`
+	LinkedSpanID      string         `json:"linked_span_id"`
+	LinkedServiceName string         `json:"linked_service_name,omitempty"`
+	TraceState        string         `json:"trace_state,omitempty"`
+	AttributesJSON    CompressedText `json:"attributes_json,omitempty"`
+}
+
+// spanLinkRows flattens the Links of spans, at most maxSpanLinks each.
+func spanLinkRows(spans []Span) []SpanLink {
+	var rows []SpanLink
+	for i := range spans {
+		sp := &spans[i]
+		for _, l := range sp.Links[:min(len(sp.Links), maxSpanLinks)] {
+			if l.LinkedTraceID == "" {
+				continue
+			}
+			l.ID = 0
+			l.TenantID, l.TraceID, l.SpanID, l.StartTime = sp.TenantID, sp.TraceID, sp.SpanID, sp.StartTime
+			rows = append(rows, l)
+		}
+	}
+	return rows
+}
+
+// createSpanLinksIdempotent inserts rows, absorbing duplicates on
+// idx_span_links_link so a replayed batch is a no-op.
+func createSpanLinksIdempotent(db *gorm.DB, driver string, rows []SpanLink) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if strings.ToLower(driver) == "mysql" {
+		return db.Clauses(clause.Insert{Modifier: "IGNORE"}).CreateInBatches(rows, 500).Error
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500).Error
+}
+
+// GetTraceLinks returns the links of the tenant's trace traceID in both
+// directions: from its spans to other spans, then from spans of other
+// traces to it. Each direction is capped at maxTraceLinks.
+func (r *Repository) GetTraceLinks(ctx context.Context, traceID string) ([]TraceLink, error) {
+	tenant := TenantFromContext(ctx)
+	db := r.db.WithContext(ctx)
+	var outgoing, incoming []SpanLink
+	if err := db.Where("tenant_id = ? AND trace_id = ?", tenant, traceID).
+		Order("id").Limit(maxTraceLinks).Find(&outgoing).Error; err != nil {
+		return nil, fmt.Errorf("failed to get span links: %w", err)
+	}
+	if err := db.Where("tenant_id = ? AND linked_trace_id = ? AND trace_id <> ?", tenant, traceID, traceID).
+		Order("id").Limit(maxTraceLinks).Find(&incoming).Error; err != nil {
+		return nil, fmt.Errorf("failed to get incoming span links: %w", err)
+	}
+
+	out := make([]TraceLink, 0, len(outgoing)+len(incoming))
+	for _, l := range outgoing {
+		out = append(out, TraceLink{
+			Direction: TraceLinkOutgoing, SpanID: l.SpanID,
+			LinkedTraceID: l.LinkedTraceID, LinkedSpanID: l.LinkedSpanID,
+			TraceState: l.TraceState, AttributesJSON: l.AttributesJSON,
+		})
+	}
+	for _, l := range incoming {
+		out = append(out, TraceLink{
+			Direction: TraceLinkIncoming, SpanID: l.LinkedSpanID,
+			LinkedTraceID: l.TraceID, LinkedSpanID: l.SpanID,
+			TraceState: l.TraceState, AttributesJSON: l.AttributesJSON,
+		})
+	}
+	if len(out) == 0 {
+		return out, nil
+	}
+
+	ids := make([]string, 0, len(out))
+	seen := make(map[string]bool, len(out))
+	for _, l := range out {
+		if !seen[l.LinkedTraceID] {
+			seen[l.LinkedTraceID] = true
+			ids = append(ids, l.LinkedTraceID)
+		}
+	}
+	var stored []struct {
+		TraceID     string
+		ServiceName string
+	}
+	if err := db.Model(&Trace{}).Select("trace_id, service_name").
+		Where("tenant_id = ? AND trace_id IN ?", tenant, ids).Scan(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up linked traces: %w", err)
+	}
+	services := make(map[string]string, len(stored))
+	for _, t := range stored {
+		services[t.TraceID] = t.ServiceName
+	}
+	for i := range out {
+		out[i].LinkedServiceName = services[out[i].LinkedTraceID]
+	}
+	return out, nil
+}
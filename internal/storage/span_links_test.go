@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSpanLinks_StoredAndFollowedBothWays(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	t0 := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	producer := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	consumer := "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	missing := "cccccccccccccccccccccccccccccccc"
+
+	span := func(trace, id, svc string, links ...SpanLink) Span {
+		sp := Span{TenantID: "acme", TraceID: trace, SpanID: id, ServiceName: svc, OperationName: "op", Links: links}
+		sp.SetTiming(t0, t0.Add(time.Millisecond))
+		return sp
+	}
+	traces := []Trace{
+		{TenantID: "acme", TraceID: producer, ServiceName: "orders", Timestamp: t0},
+		{TenantID: "acme", TraceID: consumer, ServiceName: "worker", Timestamp: t0},
+	}
+	spans := []Span{
+		span(producer, "1111111111111111", "orders"),
+		span(consumer, "2222222222222222", "worker",
+			SpanLink{LinkedTraceID: producer, LinkedSpanID: "1111111111111111", AttributesJSON: `[{"key":"messaging.operation","value":{"Value":{"StringValue":"process"}}}]`},
+			SpanLink{LinkedTraceID: missing, LinkedSpanID: "3333333333333333", TraceState: "vendor=1"},
+		),
+	}
+	if err := repo.BatchCreateAll(traces, spans, nil); err != nil {
+		t.Fatalf("BatchCreateAll: %v", err)
+	}
+	// A replay stores nothing twice.
+	if err := repo.BatchCreateSpans(spans[1:]); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+
+	got, err := repo.GetTrace(ctx, consumer)
+	if err != nil {
+		t.Fatalf("GetTrace: %v", err)
+	}
+	if len(got.Links) != 2 {
+		t.Fatalf("consumer links = %+v, want 2 outgoing", got.Links)
+	}
+	l := got.Links[0]
+	if l.Direction != TraceLinkOutgoing || l.SpanID != "2222222222222222" || l.LinkedTraceID != producer ||
+		l.LinkedServiceName != "orders" || l.AttributesJSON == "" {
+		t.Errorf("outgoing link = %+v", l)
+	}
+	if l := got.Links[1]; l.LinkedTraceID != missing || l.LinkedServiceName != "" || l.TraceState != "vendor=1" {
+		t.Errorf("link to unstored trace = %+v", l)
+	}
+
+	got, err = repo.GetTrace(ctx, producer)
+	if err != nil {
+		t.Fatalf("GetTrace: %v", err)
+	}
+	if len(got.Links) != 1 {
+		t.Fatalf("producer links = %+v, want 1 incoming", got.Links)
+	}
+	if l := got.Links[0]; l.Direction != TraceLinkIncoming || l.SpanID != "1111111111111111" ||
+		l.LinkedTraceID != consumer || l.LinkedSpanID != "2222222222222222" || l.LinkedServiceName != "worker" {
+		t.Errorf("incoming link = %+v", l)
+	}
+
+	if links, err := repo.GetTraceLinks(WithTenantContext(ctx, "other"), producer); err != nil || len(links) != 0 {
+		t.Errorf("other tenant links = %+v, %v", links, err)
+	}
+
+	// Retention removes the links with their trace.
+	if _, err := repo.PurgeTracesBatched(context.Background(), time.Now(), 100, 0); err != nil {
+		t.Fatalf("PurgeTracesBatched: %v", err)
+	}
+	var n int64
+	repo.db.Model(&SpanLink{}).Count(&n)
+	if n != 0 {
+		t.Errorf("%d span links left after purge", n)
+	}
+}
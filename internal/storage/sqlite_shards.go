@@ -0,0 +1,480 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// shardSchemaPrefix names attached shard databases: shard_20261014.
+	shardSchemaPrefix = "shard_"
+	// shardMoveSchema is the scratch attachment used to fill a shard that
+	// is not currently part of the logs view.
+	shardMoveSchema = "shard_move"
+	// maxAttachedLogShards leaves headroom under SQLite's default limit of
+	// 10 attached databases: one slot for shardMoveSchema, one spare.
+	maxAttachedLogShards = 8
+	shardFileLayout      = "2006-01-02"
+)
+
+// SQLiteShardScheduler shards the SQLite logs table into one database file
+// per UTC day when DB_SQLITE_SHARDING=daily is enabled. Today's logs are
+// written to the main database as usual; each pass moves rows of closed days
+// into <dir>/logs-YYYY-MM-DD.db, deletes shard files past the retention
+// window (retention becomes a file unlink) and keeps the newest shards
+// ATTACHed behind a TEMP VIEW named `logs`, so every read path sees hot and
+// sharded rows without knowing about the split. Writes go to main.logs
+// explicitly — see Repository.logsTable.
+//
+// Only the newest maxAttachedLogShards shards are queryable, so
+// config.Validate caps the logs window at that many days and refuses
+// per-tenant windows, which the whole-day drop cannot honour. Like
+// PartitionScheduler, this replaces the RetentionScheduler DELETE for logs.
+type SQLiteShardScheduler struct {
+	repo          *Repository
+	dir           string
+	retentionDays int
+	interval      time.Duration
+	onShardDrop   func(int) // metric callback: count of shard files deleted
+	onShardKeep   func(int) // metric callback: count of shard files on disk
+
+	started atomic.Bool
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewSQLiteShardScheduler constructs a scheduler writing shards under dir.
+// retentionDays must match the logs retention window.
+func NewSQLiteShardScheduler(repo *Repository, dir string, retentionDays int) *SQLiteShardScheduler {
+	if retentionDays < 1 {
+		retentionDays = 7
+	}
+	return &SQLiteShardScheduler{
+		repo:          repo,
+		dir:           dir,
+		retentionDays: retentionDays,
+		interval:      10 * time.Minute,
+		done:          make(chan struct{}),
+	}
+}
+
+// SetMetrics wires telemetry callbacks. Both arguments may be nil.
+func (s *SQLiteShardScheduler) SetMetrics(onDrop, onKeep func(int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onShardDrop = onDrop
+	s.onShardKeep = onKeep
+}
+
+// Start creates the shard directory, pins the single SQLite connection (the
+// attachments and the view live on it), runs one pass synchronously and
+// then keeps running on a ticker. Same one-shot lifecycle as
+// PartitionScheduler.
+func (s *SQLiteShardScheduler) Start(parent context.Context) error {
+	s.mu.Lock()
+	if s.started.Load() {
+		s.mu.Unlock()
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("sqlite shards: create %s: %w", s.dir, err)
+	}
+	if sqlDB, err := s.repo.db.DB(); err == nil {
+		sqlDB.SetConnMaxLifetime(0)
+	}
+	s.repo.markLogsSharded()
+	ctx, cancel := context.WithCancel(parent)
+	s.cancel = cancel
+	s.started.Store(true)
+	s.mu.Unlock()
+
+	s.run(ctx)
+	go s.loop(ctx)
+	return nil
+}
+
+// Stop cancels the loop and waits for it to exit. Safe to call multiple times.
+func (s *SQLiteShardScheduler) Stop() {
+	if !s.started.Load() {
+		return
+	}
+	s.mu.Lock()
+	cancel := s.cancel
+	done := s.done
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
+
+func (s *SQLiteShardScheduler) loop(ctx context.Context) {
+	defer close(s.done)
+	tick := time.NewTicker(s.interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			s.run(ctx)
+		}
+	}
+}
+
+// run is one pass: move closed days, delete expired shards, refresh the
+// view. The view is rebuilt every pass so a reconnect (which loses temp
+// objects and attachments) heals within one interval.
+func (s *SQLiteShardScheduler) run(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	now := time.Now().UTC()
+	if _, err := s.moveClosedDays(ctx, now); err != nil {
+		slog.Error("sqlite shards: move failed", "err", err)
+	}
+	dropped, err := s.dropExpired(ctx, now)
+	if err != nil {
+		slog.Error("sqlite shards: drop failed", "err", err)
+	}
+	if dropped > 0 && s.onShardDrop != nil {
+		s.onShardDrop(dropped)
+	}
+	if err := s.refreshView(ctx); err != nil {
+		slog.Error("sqlite shards: view refresh failed", "err", err)
+	}
+	if s.onShardKeep != nil {
+		days, _ := s.shardDays()
+		s.onShardKeep(len(days))
+	}
+}
+
+// moveClosedDays moves every main.logs row older than today's UTC midnight
+// into its day's shard, one day per transaction, and returns the rows
+// moved. Late-arriving rows for an already sharded day are appended to it.
+func (s *SQLiteShardScheduler) moveClosedDays(ctx context.Context, now time.Time) (int64, error) {
+	today := now.Truncate(24 * time.Hour)
+	db := s.repo.db.WithContext(ctx)
+	var moved int64
+	for ctx.Err() == nil {
+		var oldest []time.Time
+		if err := db.Raw("SELECT timestamp FROM main.logs WHERE timestamp < ? ORDER BY timestamp LIMIT 1", today).
+			Scan(&oldest).Error; err != nil {
+			return moved, fmt.Errorf("find oldest closed day: %w", err)
+		}
+		if len(oldest) == 0 {
+			return moved, nil
+		}
+		day := oldest[0].UTC().Truncate(24 * time.Hour)
+		n, err := s.moveDay(ctx, day)
+		if err != nil {
+			return moved, fmt.Errorf("move %s: %w", day.Format(shardFileLayout), err)
+		}
+		moved += n
+		slog.Info("📦 SQLite: logs moved to shard", "day", day.Format(shardFileLayout), "rows", n)
+	}
+	return moved, ctx.Err()
+}
+
+func (s *SQLiteShardScheduler) moveDay(ctx context.Context, day time.Time) (int64, error) {
+	db := s.repo.db.WithContext(ctx)
+	attached, err := attachedLogShards(db)
+	if err != nil {
+		return 0, err
+	}
+	schema := shardSchema(day)
+	if !attached[schema] {
+		schema = shardMoveSchema
+		if err := db.Exec("ATTACH DATABASE ? AS "+schema, s.shardPath(day)).Error; err != nil {
+			return 0, fmt.Errorf("attach: %w", err)
+		}
+		defer db.Exec("DETACH DATABASE " + schema)
+	}
+	if err := createShardTable(db, schema); err != nil {
+		return 0, err
+	}
+	cols, err := sharedColumns(db, schema)
+	if err != nil {
+		return 0, err
+	}
+	list := strings.Join(cols, ", ")
+	end := day.Add(24 * time.Hour)
+	var moved int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT OR IGNORE INTO "+schema+".logs ("+list+") SELECT "+list+
+			" FROM main.logs WHERE timestamp >= ? AND timestamp < ?", day, end).Error; err != nil {
+			return fmt.Errorf("copy: %w", err)
+		}
+		res := tx.Exec("DELETE FROM main.logs WHERE timestamp >= ? AND timestamp < ?", day, end)
+		moved = res.RowsAffected
+		return res.Error
+	})
+	return moved, err
+}
+
+// dropExpired deletes shard files whose whole day is older than the
+// retention cutoff, detaching them first, and records each as
+// DeletionRecords.
+func (s *SQLiteShardScheduler) dropExpired(ctx context.Context, now time.Time) (int, error) {
+	days, err := s.shardDays()
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.Add(-time.Duration(s.retentionDays) * 24 * time.Hour)
+	db := s.repo.db.WithContext(ctx)
+	attached, err := attachedLogShards(db)
+	if err != nil {
+		return 0, err
+	}
+	dropped := 0
+	for _, day := range days {
+		if day.Add(24 * time.Hour).After(cutoff) {
+			continue
+		}
+		schema := shardSchema(day)
+		if !attached[schema] {
+			if err := db.Exec("ATTACH DATABASE ? AS "+schema, s.shardPath(day)).Error; err != nil {
+				return dropped, fmt.Errorf("attach %s: %w", schema, err)
+			}
+		}
+		// Count what the shard holds so the drop is recorded; a shard that
+		// cannot be counted is kept for the next pass. An empty file left by
+		// an interrupted move has no logs table and nothing to count.
+		var groups []deletionGroup
+		cols, err := tableColumns(db, schema)
+		if err == nil && len(cols) > 0 {
+			groups, err = countDeletionGroups(db, schema+".logs", "timestamp", "")
+		}
+		if err != nil {
+			if !attached[schema] {
+				_ = db.Exec("DETACH DATABASE " + schema).Error
+			}
+			return dropped, fmt.Errorf("count %s: %w", schema, err)
+		}
+		if attached[schema] {
+			// The view references the shard; it is rebuilt after this pass.
+			if err := db.Exec("DROP VIEW IF EXISTS temp.logs").Error; err != nil {
+				return dropped, err
+			}
+		}
+		if err := db.Exec("DETACH DATABASE " + schema).Error; err != nil {
+			return dropped, fmt.Errorf("detach %s: %w", schema, err)
+		}
+		path := s.shardPath(day)
+		for _, f := range []string{path, path + "-journal", path + "-wal", path + "-shm"} {
+			if err := os.Remove(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return dropped, err
+			}
+		}
+		dropped++
+		slog.Info("🧹 SQLite: expired logs shard deleted", "day", day.Format(shardFileLayout))
+		if err := recordDeletions(db, DeletionSignalLogs, DeletionReasonPartitionDrop, day.Add(24*time.Hour), groups); err != nil {
+			slog.Error("deletion audit: write failed", "day", day.Format(shardFileLayout), "error", err)
+		}
+	}
+	return dropped, nil
+}
+
+// refreshView attaches the newest shards, detaches the rest and recreates
+// temp.logs as main.logs UNION ALL every attached shard. Columns missing
+// from an older shard read as NULL.
+func (s *SQLiteShardScheduler) refreshView(ctx context.Context) error {
+	days, err := s.shardDays()
+	if err != nil {
+		return err
+	}
+	if len(days) > maxAttachedLogShards {
+		days = days[len(days)-maxAttachedLogShards:]
+	}
+	want := make(map[string]time.Time, len(days))
+	for _, day := range days {
+		want[shardSchema(day)] = day
+	}
+
+	db := s.repo.db.WithContext(ctx)
+	if err := db.Exec("DROP VIEW IF EXISTS temp.logs").Error; err != nil {
+		return err
+	}
+	attached, err := attachedLogShards(db)
+	if err != nil {
+		return err
+	}
+	for schema := range attached {
+		if _, ok := want[schema]; !ok {
+			if err := db.Exec("DETACH DATABASE " + schema).Error; err != nil {
+				return fmt.Errorf("detach %s: %w", schema, err)
+			}
+		}
+	}
+	for schema, day := range want {
+		if !attached[schema] {
+			if err := db.Exec("ATTACH DATABASE ? AS "+schema, s.shardPath(day)).Error; err != nil {
+				return fmt.Errorf("attach %s: %w", schema, err)
+			}
+		}
+	}
+
+	mainCols, err := tableColumns(db, "main")
+	if err != nil {
+		return err
+	}
+	selects := []string{"SELECT " + strings.Join(mainCols, ", ") + " FROM main.logs"}
+	for _, day := range days {
+		schema := shardSchema(day)
+		have, err := tableColumns(db, schema)
+		if err != nil {
+			return err
+		}
+		if len(have) == 0 {
+			continue // empty file left by an interrupted move
+		}
+		present := make(map[string]bool, len(have))
+		for _, c := range have {
+			present[c] = true
+		}
+		cols := make([]string, len(mainCols))
+		for i, c := range mainCols {
+			cols[i] = c
+			if !present[c] {
+				cols[i] = "NULL AS " + c
+			}
+		}
+		selects = append(selects, "SELECT "+strings.Join(cols, ", ")+" FROM "+schema+".logs")
+	}
+	return db.Exec("CREATE TEMP VIEW logs AS " + strings.Join(selects, " UNION ALL ")).Error
+}
+
+// shardDays lists the days that have a shard file, oldest first.
+func (s *SQLiteShardScheduler) shardDays() ([]time.Time, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "logs-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	days := make([]time.Time, 0, len(matches))
+	for _, m := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "logs-"), ".db")
+		day, err := time.Parse(shardFileLayout, name)
+		if err != nil {
+			continue // not ours
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+func (s *SQLiteShardScheduler) shardPath(day time.Time) string {
+	return filepath.Join(s.dir, "logs-"+day.Format(shardFileLayout)+".db")
+}
+
+func shardSchema(day time.Time) string {
+	return shardSchemaPrefix + day.Format("20060102")
+}
+
+// attachedLogShards returns the shard schemas attached to the connection.
+func attachedLogShards(db *gorm.DB) (map[string]bool, error) {
+	var rows []struct {
+		Seq  int
+		Name string
+		File string
+	}
+	if err := db.Raw("PRAGMA database_list").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list attached databases: %w", err)
+	}
+	out := make(map[string]bool)
+	for _, r := range rows {
+		if strings.HasPrefix(r.Name, shardSchemaPrefix) && r.Name != shardMoveSchema {
+			out[r.Name] = true
+		}
+	}
+	return out, nil
+}
+
+// createShardTable creates schema.logs and its indexes from main's DDL so a
+// shard has the same primary key (keeping moves idempotent) and indexes.
+func createShardTable(db *gorm.DB, schema string) error {
+	var objs []struct {
+		Type string
+		Name string
+		SQL  string `gorm:"column:sql"`
+	}
+	if err := db.Raw("SELECT type, name, sql FROM main.sqlite_master WHERE tbl_name = 'logs' AND sql IS NOT NULL ORDER BY type DESC").
+		Scan(&objs).Error; err != nil {
+		return fmt.Errorf("read logs schema: %w", err)
+	}
+	for _, o := range objs {
+		var ddl string
+		switch o.Type {
+		case "table":
+			open := strings.Index(o.SQL, "(")
+			if open < 0 {
+				return fmt.Errorf("unexpected logs DDL %q", o.SQL)
+			}
+			ddl = "CREATE TABLE IF NOT EXISTS " + schema + ".logs " + o.SQL[open:]
+		case "index":
+			on := strings.Index(strings.ToUpper(o.SQL), " ON ")
+			if on < 0 {
+				continue
+			}
+			ddl = "CREATE INDEX IF NOT EXISTS " + schema + ".`" + o.Name + "`" + o.SQL[on:]
+		default:
+			continue // FTS triggers stay on the hot table
+		}
+		if err := db.Exec(ddl).Error; err != nil {
+			return fmt.Errorf("create %s %s in %s: %w", o.Type, o.Name, schema, err)
+		}
+	}
+	return nil
+}
+
+// sharedColumns returns the logs columns present in both main and schema.
+func sharedColumns(db *gorm.DB, schema string) ([]string, error) {
+	mainCols, err := tableColumns(db, "main")
+	if err != nil {
+		return nil, err
+	}
+	shardCols, err := tableColumns(db, schema)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool, len(shardCols))
+	for _, c := range shardCols {
+		have[c] = true
+	}
+	out := make([]string, 0, len(mainCols))
+	for _, c := range mainCols {
+		if have[c] {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func tableColumns(db *gorm.DB, schema string) ([]string, error) {
+	var cols []struct {
+		Name string
+	}
+	if err := db.Raw("PRAGMA " + schema + ".table_info(logs)").Scan(&cols).Error; err != nil {
+		return nil, fmt.Errorf("columns of %s.logs: %w", schema, err)
+	}
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = c.Name
+	}
+	return out, nil
+}
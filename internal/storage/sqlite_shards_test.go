@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newShardTestRepo(t *testing.T) (*Repository, string) {
+	t.Helper()
+	t.Setenv("LOG_FTS_ENABLED", "true")
+	dir := t.TempDir()
+	db, err := NewDatabase("sqlite", filepath.Join(dir, "main.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := &Repository{db: db, driver: "sqlite"}
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo, filepath.Join(dir, "shards")
+}
+
+func TestSQLiteShardScheduler_MovesClosedDaysAndExpiresFiles(t *testing.T) {
+	repo, dir := newShardTestRepo(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	seedLogs(t, repo.db, 2, today.Add(-10*24*time.Hour+time.Hour), "expired")
+	seedLogs(t, repo.db, 3, today.Add(-2*24*time.Hour+time.Hour), "two-days-ago")
+	seedLogs(t, repo.db, 4, today.Add(-24*time.Hour+time.Hour), "yesterday")
+	seedLogs(t, repo.db, 5, today.Add(time.Minute), "today")
+
+	s := NewSQLiteShardScheduler(repo, dir, 7)
+	var dropped, active int
+	s.SetMetrics(func(n int) { dropped += n }, func(n int) { active = n })
+	ctx := context.Background()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Stop()
+
+	if dropped != 1 || active != 2 {
+		t.Fatalf("dropped=%d active=%d, want 1 and 2", dropped, active)
+	}
+	expired := today.Add(-10 * 24 * time.Hour)
+	deletions, err := repo.ListDeletions(WithTenantContext(ctx, DefaultTenantID), DeletionQuery{})
+	if err != nil || len(deletions) != 1 || deletions[0].ServiceName != "expired" || deletions[0].RowCount != 2 ||
+		deletions[0].Reason != DeletionReasonPartitionDrop || !deletions[0].RangeEnd.Equal(expired.Add(24*time.Hour)) {
+		t.Errorf("deletions = %+v, %v; want the expired shard's 2 rows", deletions, err)
+	}
+	for day, want := range map[string]bool{
+		today.Add(-10 * 24 * time.Hour).Format(shardFileLayout): false,
+		today.Add(-2 * 24 * time.Hour).Format(shardFileLayout):  true,
+		today.Add(-24 * time.Hour).Format(shardFileLayout):      true,
+		today.Format(shardFileLayout):                           false,
+	} {
+		_, err := os.Stat(filepath.Join(dir, "logs-"+day+".db"))
+		if got := err == nil; got != want {
+			t.Errorf("shard %s exists = %v, want %v", day, got, want)
+		}
+	}
+
+	var hot int64
+	repo.db.Raw("SELECT COUNT(*) FROM main.logs").Scan(&hot)
+	if hot != 5 {
+		t.Errorf("main.logs rows = %d, want 5 (today only)", hot)
+	}
+	tctx := WithTenantContext(ctx, DefaultTenantID)
+	_, total, err := repo.GetLogsV2(tctx, LogFilter{Limit: 100})
+	if err != nil {
+		t.Fatalf("GetLogsV2: %v", err)
+	}
+	if total != 12 {
+		t.Errorf("GetLogsV2 total = %d, want 12 across hot table and shards", total)
+	}
+
+	// Writes still land in main.logs with the view in place.
+	if err := repo.BatchCreateLogs([]Log{{TenantID: DefaultTenantID, Body: "late", ServiceName: "today", Timestamp: today.Add(time.Hour)}}); err != nil {
+		t.Fatalf("BatchCreateLogs: %v", err)
+	}
+
+	found, err := repo.SearchLogs(tctx, "yesterday", 10)
+	if err != nil {
+		t.Fatalf("SearchLogs: %v", err)
+	}
+	if len(found) != 4 {
+		t.Fatalf("SearchLogs(yesterday) = %d rows, want 4 from the shard", len(found))
+	}
+	if err := repo.UpdateLogInsight(tctx, found[0].ID, "sharded insight"); err != nil {
+		t.Fatalf("UpdateLogInsight on sharded row: %v", err)
+	}
+	got, err := repo.GetLog(tctx, found[0].ID)
+	if err != nil {
+		t.Fatalf("GetLog: %v", err)
+	}
+	if string(got.AIInsight) != "sharded insight" {
+		t.Errorf("AIInsight = %q", got.AIInsight)
+	}
+
+	// A second pass is a no-op, and a lost connection state heals.
+	repo.db.Exec("DROP VIEW temp.logs")
+	s.run(ctx)
+	_, total, err = repo.GetLogsV2(tctx, LogFilter{Limit: 100})
+	if err != nil {
+		t.Fatalf("GetLogsV2 after second pass: %v", err)
+	}
+	if total != 13 {
+		t.Errorf("total after second pass = %d, want 13", total)
+	}
+}
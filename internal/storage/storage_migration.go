@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMigrationBatchSize is the rows per batch when
+// StorageMigrationOptions.BatchSize is unset.
+const DefaultMigrationBatchSize = 500
+
+// StorageMigrationOptions tunes MigrateStorage.
+type StorageMigrationOptions struct {
+	// BatchSize is the rows read and written per transaction.
+	BatchSize int
+	// Progress, when set, is called after every batch and once when a table
+	// is finished.
+	Progress func(MigrationProgress)
+}
+
+// MigrationProgress reports how far MigrateStorage is through a table.
+// Copied and Total count this run only: rows a previous run copied are
+// skipped and appear in Resumed.
+type MigrationProgress struct {
+	Table   string
+	Copied  int64
+	Total   int64
+	Resumed int64
+	Done    bool
+}
+
+// MigrateStorage copies every storage table from src to dst, whose
+// schema must already be migrated (AutoMigrateModelsWithOptions). Rows are
+// copied in primary key order with their IDs, one transaction per batch,
+// so an interrupted run leaves dst with a prefix of each table and running
+// it again resumes after the highest ID dst already holds. Rows changed in
+// src after they were copied are not copied again: stop ingestion first.
+// The GraphRAG tables in the same database are copied by
+// graphrag.MigrateStorage.
+func MigrateStorage(ctx context.Context, src, dst *gorm.DB, dstDriver string, opts StorageMigrationOptions) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultMigrationBatchSize
+	}
+	dstDriver = strings.ToLower(dstDriver)
+	if dstDriver == "sqlserver" || dstDriver == "mssql" {
+		// Explicit ids need IDENTITY_INSERT, which is per table and session.
+		return errors.New("sqlserver is not supported as a migration destination")
+	}
+	if src.Dialector.Name() == "sqlite" {
+		var views int64
+		if err := src.WithContext(ctx).Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'view' AND name = 'logs'").Scan(&views).Error; err != nil {
+			return fmt.Errorf("inspect source: %w", err)
+		}
+		if views > 0 {
+			return errors.New("source logs are in daily shards (DB_SQLITE_SHARDING=daily); only unsharded SQLite databases can be migrated")
+		}
+	}
+	m := storageMigration{ctx: ctx, src: src, dst: dst, dstDriver: dstDriver, opts: opts}
+	steps := []func() error{
+		func() error { return copyByID(m, func(r *Trace) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *Span) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SpanAttribute) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SpanException) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SpanLink) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SpanEvent) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *Log) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *MetricBucket) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *AlertRule) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *AlertEvent) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SLO) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *Monitor) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *MonitorResult) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *Deployment) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *APIKey) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *TriageState) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *Issue) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *TraceInsight) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *StorageUsageSample) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SelfEvent) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *DashboardRollup) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *SavedView) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *DeletionRecord) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *AuditEvent) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *ColdSegment) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *RehydrationJob) uint { return r.ID }) },
+		func() error { return copyByID(m, func(r *RehydratedLog) uint { return r.ID }) },
+		func() error { return copyAll[RollupWatermark](m) },
+		func() error { return copyAll[RuntimeSetting](m) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type storageMigration struct {
+	ctx       context.Context
+	src, dst  *gorm.DB
+	dstDriver string
+	opts      StorageMigrationOptions
+}
+
+func (m storageMigration) tableName(model any) (string, error) {
+	stmt := &gorm.Statement{DB: m.dst}
+	if err := stmt.Parse(model); err != nil {
+		return "", fmt.Errorf("parse %T: %w", model, err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (m storageMigration) report(p MigrationProgress) {
+	if m.opts.Progress != nil {
+		m.opts.Progress(p)
+	}
+}
+
+// copyByID copies table in ascending id order, starting after the highest
+// id already in dst.
+func copyByID[T any](m storageMigration, id func(*T) uint) error {
+	table, err := m.tableName(new(T))
+	if err != nil {
+		return err
+	}
+	var last uint
+	if err := m.dst.WithContext(m.ctx).Table(table).Select("COALESCE(MAX(id), 0)").Scan(&last).Error; err != nil {
+		return fmt.Errorf("%s: read resume point: %w", table, err)
+	}
+	p := MigrationProgress{Table: table}
+	if err := m.src.WithContext(m.ctx).Table(table).Where("id <= ?", last).Count(&p.Resumed).Error; err != nil {
+		return fmt.Errorf("%s: count source rows: %w", table, err)
+	}
+	if err := m.src.WithContext(m.ctx).Table(table).Where("id > ?", last).Count(&p.Total).Error; err != nil {
+		return fmt.Errorf("%s: count source rows: %w", table, err)
+	}
+	for {
+		if err := m.ctx.Err(); err != nil {
+			return err
+		}
+		var rows []T
+		if err := m.src.WithContext(m.ctx).Where("id > ?", last).Order("id").Limit(m.opts.BatchSize).Find(&rows).Error; err != nil {
+			return fmt.Errorf("%s: read batch after id %d: %w", table, last, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		if err := m.dst.WithContext(m.ctx).Transaction(func(tx *gorm.DB) error {
+			return tx.Omit(clause.Associations).Create(&rows).Error
+		}); err != nil {
+			return fmt.Errorf("%s: write batch after id %d: %w", table, last, err)
+		}
+		last = id(&rows[len(rows)-1])
+		p.Copied += int64(len(rows))
+		m.report(p)
+	}
+	if err := m.resetSequence(table); err != nil {
+		return err
+	}
+	p.Done = true
+	m.report(p)
+	return nil
+}
+
+// copyAll upserts the whole of a small table keyed by something other
+// than an id.
+func copyAll[T any](m storageMigration) error {
+	table, err := m.tableName(new(T))
+	if err != nil {
+		return err
+	}
+	var rows []T
+	if err := m.src.WithContext(m.ctx).Find(&rows).Error; err != nil {
+		return fmt.Errorf("%s: read: %w", table, err)
+	}
+	if len(rows) > 0 {
+		if err := m.dst.WithContext(m.ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return fmt.Errorf("%s: write: %w", table, err)
+		}
+	}
+	n := int64(len(rows))
+	m.report(MigrationProgress{Table: table, Copied: n, Total: n, Done: true})
+	return nil
+}
+
+// CopyTable upserts every row of T's table from src into dst, which must
+// already have the table, in batches of opts.BatchSize ordered by its
+// primary key. It carries the tables other packages keep in the same
+// database (GraphRAG's) along with MigrateStorage. They are keyed by
+// something other than an id, so there is no resume point: a rerun copies
+// the table again, overwriting the rows an earlier run wrote.
+func CopyTable[T any](ctx context.Context, src, dst *gorm.DB, opts StorageMigrationOptions) error {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultMigrationBatchSize
+	}
+	m := storageMigration{ctx: ctx, src: src, dst: dst, opts: opts}
+	stmt := &gorm.Statement{DB: src}
+	if err := stmt.Parse(new(T)); err != nil {
+		return fmt.Errorf("parse %T: %w", new(T), err)
+	}
+	table := stmt.Schema.Table
+	p := MigrationProgress{Table: table}
+	if err := src.WithContext(ctx).Table(table).Count(&p.Total).Error; err != nil {
+		return fmt.Errorf("%s: count source rows: %w", table, err)
+	}
+	order := strings.Join(stmt.Schema.PrimaryFieldDBNames, ", ")
+	for offset := 0; ; offset += opts.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var rows []T
+		if err := src.WithContext(ctx).Order(order).Offset(offset).Limit(opts.BatchSize).Find(&rows).Error; err != nil {
+			return fmt.Errorf("%s: read batch at offset %d: %w", table, offset, err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		if err := dst.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&rows).Error; err != nil {
+			return fmt.Errorf("%s: write batch at offset %d: %w", table, offset, err)
+		}
+		p.Copied += int64(len(rows))
+		m.report(p)
+	}
+	p.Done = true
+	m.report(p)
+	return nil
+}
+
+// resetSequence moves a Postgres id sequence past the copied ids, which
+// were inserted explicitly and so never advanced it. Other drivers track
+// the next id from the table itself.
+func (m storageMigration) resetSequence(table string) error {
+	if m.dstDriver != "postgres" && m.dstDriver != "postgresql" {
+		return nil
+	}
+	err := m.dst.WithContext(m.ctx).Exec(
+		"SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE((SELECT MAX(id) FROM "+table+"), 0) + 1, false)", table).Error
+	if err != nil {
+		return fmt.Errorf("%s: reset id sequence: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMigrateStorage_CopiesAndResumes(t *testing.T) {
+	src, dst := newTestRepo(t), newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	seedTrace(t, src.db, "t1", now, []time.Time{now, now.Add(time.Millisecond)})
+	seedLogs(t, src.db, 7, now, "checkout")
+	if err := src.ReplaceRuntimeSettings(ctx, map[string]string{"HOT_RETENTION_DAYS": "14"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop after the first logs batch to leave a partial copy.
+	stop, cancel := context.WithCancel(ctx)
+	err := MigrateStorage(stop, src.db, dst.db, "sqlite", StorageMigrationOptions{
+		BatchSize: 3,
+		Progress: func(p MigrationProgress) {
+			if p.Table == "logs" && p.Copied == 3 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted run = %v, want context.Canceled", err)
+	}
+	if n := mustCount(t, dst.db, &Log{}); n != 3 {
+		t.Fatalf("logs after interrupted run = %d, want 3", n)
+	}
+
+	var logs MigrationProgress
+	err = MigrateStorage(ctx, src.db, dst.db, "sqlite", StorageMigrationOptions{
+		BatchSize: 3,
+		Progress: func(p MigrationProgress) {
+			if p.Table == "logs" && p.Done {
+				logs = p
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("resumed run: %v", err)
+	}
+	if logs.Resumed != 3 || logs.Total != 4 || logs.Copied != 4 {
+		t.Errorf("logs progress = %+v, want 3 resumed and 4 copied", logs)
+	}
+	for _, model := range []any{&Trace{}, &Span{}, &Log{}} {
+		if s, d := mustCount(t, src.db, model), mustCount(t, dst.db, model); s != d {
+			t.Errorf("%T: source %d rows, destination %d", model, s, d)
+		}
+	}
+	var span Span
+	if err := dst.db.Where("span_id = ?", "t1-span-1").First(&span).Error; err != nil || !span.StartTime.Equal(now.Add(time.Millisecond)) {
+		t.Errorf("copied span = %+v, %v", span, err)
+	}
+	if got, err := dst.RuntimeSettings(ctx); err != nil || got["HOT_RETENTION_DAYS"] != "14" {
+		t.Errorf("runtime settings = %v, %v", got, err)
+	}
+
+	// A completed migration run again copies nothing.
+	if err := MigrateStorage(ctx, src.db, dst.db, "sqlite", StorageMigrationOptions{}); err != nil {
+		t.Fatalf("repeat run: %v", err)
+	}
+	if n := mustCount(t, dst.db, &Log{}); n != 7 {
+		t.Errorf("logs after repeat run = %d, want 7", n)
+	}
+}
+
+func TestMigrateStorage_RefusesSQLServerDestination(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := MigrateStorage(context.Background(), repo.db, repo.db, "sqlserver", StorageMigrationOptions{}); err == nil {
+		t.Error("sqlserver destination accepted")
+	}
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Signals reported by storage usage sampling.
+const (
+	UsageSignalTraces  = "traces"
+	UsageSignalLogs    = "logs"
+	UsageSignalMetrics = "metrics"
+)
+
+// usageRowOverhead approximates the fixed columns and per-row bookkeeping
+// not covered by the variable-length payload columns measured below.
+const usageRowOverhead = 64
+
+// StorageUsageSample is the estimated on-disk footprint of one
+// (tenant, signal, service) at SampledAt. Samples are written periodically
+// by RecordStorageUsage so growth can be compared over time.
+type StorageUsageSample struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	TenantID    string    `gorm:"size:64;default:'default';not null;index:idx_storage_usage_tenant_time,priority:1" json:"-"`
+	Signal      string    `gorm:"size:16;not null" json:"signal"`
+	ServiceName string    `gorm:"size:255" json:"service_name"`
+	Rows        int64     `gorm:"column:row_count" json:"rows"`
+	Bytes       int64     `json:"bytes"`
+	SampledAt   time.Time `gorm:"not null;index;index:idx_storage_usage_tenant_time,priority:2" json:"sampled_at"`
+}
+
+// usageSource is one signal's measurement: payload is the table whose
+// per-service rows are summed, tables are everything the signal occupies
+// on disk, apportioned by each service's share of the payload.
+type usageSource struct {
+	signal  string
+	payload string
+	columns []string // variable-length columns summed per row
+	tables  []string
+}
+
+var usageSources = []usageSource{
+	{UsageSignalTraces, "spans", []string{"operation_name", "attributes_json"}, []string{"spans", "traces", "span_attributes", "span_exceptions", "span_links", "span_events"}},
+	{UsageSignalLogs, "logs", []string{"body", "attributes_json", "ai_insight"}, []string{"logs"}},
+	{UsageSignalMetrics, "metric_buckets", []string{"name", "attributes_json"}, []string{"metric_buckets"}},
+}
+
+// MeasureStorageUsage estimates bytes per (tenant, signal, service) across
+// all tenants. Each row's variable-length payload is summed per service and
+// the signal's on-disk table size (driver catalog: dbstat, pg_total_relation_size,
+// information_schema, sys.dm_db_partition_stats) is split by those shares.
+// The figures are estimates: compression, page fill and index sharing vary.
+// This scans every signal table once, so call it on a schedule, not per request.
+func (r *Repository) MeasureStorageUsage(ctx context.Context) ([]StorageUsageSample, error) {
+	db := r.db.WithContext(ctx)
+	var out []StorageUsageSample
+	for _, src := range usageSources {
+		parts := make([]string, len(src.columns))
+		for i, c := range src.columns {
+			parts[i] = fmt.Sprintf("COALESCE(%s(%s), 0)", r.byteLengthFunc(), c)
+		}
+		var rows []struct {
+			TenantID    string
+			ServiceName string
+			RowTotal    int64
+			Payload     int64
+		}
+		if err := db.Table(src.payload).
+			Select(fmt.Sprintf("tenant_id, service_name, COUNT(*) AS row_total, SUM(%s + %d) AS payload", strings.Join(parts, " + "), usageRowOverhead)).
+			Group("tenant_id, service_name").
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("storage usage: measure %s: %w", src.payload, err)
+		}
+		var total int64
+		for _, row := range rows {
+			total += row.Payload
+		}
+		var onDisk int64
+		for _, t := range src.tables {
+			onDisk += r.tableBytes(ctx, t)
+		}
+		for _, row := range rows {
+			bytes := row.Payload
+			if onDisk > 0 && total > 0 {
+				bytes = int64(float64(onDisk) * float64(row.Payload) / float64(total))
+			}
+			out = append(out, StorageUsageSample{
+				TenantID:    row.TenantID,
+				Signal:      src.signal,
+				ServiceName: row.ServiceName,
+				Rows:        row.RowTotal,
+				Bytes:       bytes,
+			})
+		}
+	}
+	return out, nil
+}
+
+// RecordStorageUsage measures usage, stores it as one sample stamped now and
+// deletes samples older than keep (0 keeps everything). It returns the
+// number of rows written.
+func (r *Repository) RecordStorageUsage(ctx context.Context, keep time.Duration) (int, error) {
+	samples, err := r.MeasureStorageUsage(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	for i := range samples {
+		samples[i].SampledAt = now
+	}
+	db := r.db.WithContext(ctx)
+	if len(samples) > 0 {
+		if err := db.CreateInBatches(samples, 500).Error; err != nil {
+			return 0, fmt.Errorf("storage usage: record: %w", err)
+		}
+	}
+	if keep > 0 {
+		if err := db.Where("sampled_at < ?", now.Add(-keep)).Delete(&StorageUsageSample{}).Error; err != nil {
+			return len(samples), fmt.Errorf("storage usage: prune: %w", err)
+		}
+	}
+	return len(samples), nil
+}
+
+// StorageUsageHistory returns the tenant on ctx's samples taken at or after
+// since, oldest first.
+func (r *Repository) StorageUsageHistory(ctx context.Context, since time.Time) ([]StorageUsageSample, error) {
+	var samples []StorageUsageSample
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND sampled_at >= ?", TenantFromContext(ctx), since).
+		Order("sampled_at ASC, signal ASC, service_name ASC").
+		Find(&samples).Error; err != nil {
+		return nil, fmt.Errorf("storage usage: history: %w", err)
+	}
+	return samples, nil
+}
+
+// byteLengthFunc is the driver's byte (not character) length function.
+func (r *Repository) byteLengthFunc() string {
+	switch strings.ToLower(r.driver) {
+	case "postgres", "postgresql":
+		return "octet_length"
+	case "sqlserver", "mssql":
+		return "DATALENGTH"
+	default: // sqlite, mysql
+		return "LENGTH"
+	}
+}
+
+// tableBytes returns table's on-disk size including its indexes, or 0 when
+// the driver cannot say. Postgres sums a partitioned parent's partitions.
+func (r *Repository) tableBytes(ctx context.Context, table string) int64 {
+	db := r.db.WithContext(ctx)
+	var size int64
+	switch strings.ToLower(r.driver) {
+	case "sqlite", "":
+		db.Raw(`SELECT COALESCE(SUM(pgsize), 0) FROM dbstat WHERE name = ?
+			OR name IN (SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?)`, table, table).Scan(&size)
+	case "postgres", "postgresql":
+		db.Raw(`SELECT COALESCE(SUM(pg_total_relation_size(c.oid)), 0) FROM pg_class c
+			WHERE c.oid = to_regclass(?) OR c.oid IN (SELECT inhrelid FROM pg_inherits WHERE inhparent = to_regclass(?))`, table, table).Scan(&size)
+	case "mysql":
+		db.Raw(`SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.tables
+			WHERE table_schema = DATABASE() AND table_name = ?`, table).Scan(&size)
+	case "sqlserver", "mssql":
+		db.Raw(`SELECT COALESCE(SUM(reserved_page_count), 0) * 8192 FROM sys.dm_db_partition_stats
+			WHERE object_id = OBJECT_ID(?)`, table).Scan(&size)
+	}
+	return size
+}
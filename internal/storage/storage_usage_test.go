@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordStorageUsage(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now()
+	seedLogs(t, repo.db, 20, now, "checkout")
+	seedLogs(t, repo.db, 2, now, "cart")
+	big := Log{TenantID: "acme", ServiceName: "checkout", Body: strings.Repeat("x", 4096), Timestamp: now}
+	if err := repo.db.Create(&big).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	seedTrace(t, repo.db, "usage", now, []time.Time{now})
+
+	if n, err := repo.RecordStorageUsage(context.Background(), time.Hour); err != nil || n != 4 {
+		t.Fatalf("RecordStorageUsage = %d, %v; want 4 samples", n, err)
+	}
+	got, err := repo.StorageUsageHistory(context.Background(), now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("StorageUsageHistory: %v", err)
+	}
+	bytes := make(map[string]int64)
+	for _, s := range got {
+		bytes[s.Signal+"/"+s.ServiceName] = s.Bytes
+		if s.Signal == UsageSignalLogs && s.ServiceName == "checkout" && s.Rows != 20 {
+			t.Errorf("checkout logs rows = %d, want 20", s.Rows)
+		}
+	}
+	if len(got) != 3 || bytes["logs/checkout"] <= bytes["logs/cart"] || bytes["traces/svc"] <= 0 {
+		t.Errorf("default tenant samples = %v", bytes)
+	}
+
+	// Tenant-scoped, and samples past keep are pruned on the next record.
+	acme, _ := repo.StorageUsageHistory(WithTenantContext(context.Background(), "acme"), time.Time{})
+	if len(acme) != 1 || acme[0].Bytes <= 4096/2 {
+		t.Errorf("acme samples = %+v", acme)
+	}
+	repo.db.Model(&StorageUsageSample{}).Where("1 = 1").Update("sampled_at", now.Add(-2*time.Hour))
+	if _, err := repo.RecordStorageUsage(context.Background(), time.Hour); err != nil {
+		t.Fatalf("second record: %v", err)
+	}
+	if n := mustCount(t, repo.db, &StorageUsageSample{}); n != 4 {
+		t.Errorf("samples after prune = %d, want 4", n)
+	}
+}
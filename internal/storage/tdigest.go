@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"math"
+	"sort"
+)
+
+// tdigestCompression bounds a digest to a few hundred centroids; quantile
+// error is well under 1% of rank at the tails.
+const tdigestCompression = 100
+
+// tdigest is a merging t-digest (Dunning): a sketch of a distribution in
+// centroids that are small near the tails and large in the middle, so
+// extreme quantiles stay accurate in bounded memory. Not safe for
+// concurrent use.
+type tdigest struct {
+	compression float64
+	centroids   []centroid // merged, sorted by mean
+	buffer      []centroid // unmerged additions
+	count       float64
+	min, max    float64
+}
+
+type centroid struct {
+	mean, count float64
+}
+
+func newTDigest(compression float64) *tdigest {
+	return &tdigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+func (t *tdigest) add(x float64) {
+	t.buffer = append(t.buffer, centroid{x, 1})
+	t.count++
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	if len(t.buffer) >= int(t.compression)*8 {
+		t.compress()
+	}
+}
+
+// compress merges the buffer into the centroids, combining neighbours while
+// the result stays under the size bound for its quantile, 4·n·q(1-q)/δ.
+func (t *tdigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	t.buffer = t.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	cur := all[0]
+	var before float64
+	for _, c := range all[1:] {
+		q := (before + (cur.count+c.count)/2) / t.count
+		if cur.count+c.count <= math.Max(4*t.count*q*(1-q)/t.compression, 1) {
+			cur.count += c.count
+			cur.mean += (c.mean - cur.mean) * c.count / cur.count
+			continue
+		}
+		merged = append(merged, cur)
+		before += cur.count
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+}
+
+// quantile estimates the q-th quantile (0..1), interpolating between
+// centroid centres and the observed min and max. 0 when empty.
+func (t *tdigest) quantile(q float64) float64 {
+	t.compress()
+	if len(t.centroids) == 0 {
+		return 0
+	}
+	rank := q * t.count
+	prevCentre, prevMean := 0.0, t.min
+	var seen float64
+	for _, c := range t.centroids {
+		centre := seen + c.count/2
+		if rank < centre {
+			if centre == prevCentre {
+				return c.mean
+			}
+			return prevMean + (rank-prevCentre)/(centre-prevCentre)*(c.mean-prevMean)
+		}
+		prevCentre, prevMean = centre, c.mean
+		seen += c.count
+	}
+	if t.count == prevCentre {
+		return t.max
+	}
+	return prevMean + (rank-prevCentre)/(t.count-prevCentre)*(t.max-prevMean)
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TraceComparison aligns two traces of the same request shape, A the
+// baseline and B the one under investigation. Spans are matched by service
+// and operation name; the n-th such span of A, in start order, pairs with
+// the n-th of B, so repeated calls (a query in a loop) line up one to one.
+type TraceComparison struct {
+	TraceA          string     `json:"trace_a"`
+	TraceB          string     `json:"trace_b"`
+	DurationAUs     int64      `json:"duration_a_us"`
+	DurationBUs     int64      `json:"duration_b_us"`
+	DurationDeltaUs int64      `json:"duration_delta_us"` // B - A
+	Matched         []SpanDiff `json:"matched"`           // largest slowdown first
+	Missing         []SpanDiff `json:"missing"`           // in A only
+	Extra           []SpanDiff `json:"extra"`             // in B only
+}
+
+// SpanDiff is one aligned span pair, or a span present in one trace only;
+// the absent side's fields are empty.
+type SpanDiff struct {
+	ServiceName    string          `json:"service_name"`
+	OperationName  string          `json:"operation_name"`
+	Occurrence     int             `json:"occurrence"` // index among the trace's spans with this service and operation
+	SpanIDA        string          `json:"span_id_a,omitempty"`
+	SpanIDB        string          `json:"span_id_b,omitempty"`
+	StatusA        string          `json:"status_a,omitempty"`
+	StatusB        string          `json:"status_b,omitempty"`
+	DurationAUs    int64           `json:"duration_a_us"`
+	DurationBUs    int64           `json:"duration_b_us"`
+	DeltaUs        int64           `json:"delta_us"`  // B - A
+	DeltaPct       float64         `json:"delta_pct"` // of A; 0 when A took no time
+	AttributeDiffs []AttributeDiff `json:"attribute_diffs,omitempty"`
+}
+
+// AttributeDiff is a span attribute whose value differs between the pair,
+// rendered as strings. A side without the attribute is null.
+type AttributeDiff struct {
+	Key string  `json:"key"`
+	A   *string `json:"a"`
+	B   *string `json:"b"`
+}
+
+// GetTraceComparison loads two traces of the tenant on ctx and compares
+// them. Errors like GetTrace when either does not exist.
+func (r *Repository) GetTraceComparison(ctx context.Context, traceA, traceB string) (*TraceComparison, error) {
+	a, err := r.GetTrace(ctx, traceA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := r.GetTrace(ctx, traceB)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTraceComparison(a, b), nil
+}
+
+// spanKey identifies a span for alignment.
+type spanKey struct {
+	service, operation string
+	occurrence         int
+}
+
+// BuildTraceComparison aligns the spans of a and b.
+func BuildTraceComparison(a, b *Trace) *TraceComparison {
+	ta, tb := BuildTraceTree(a), BuildTraceTree(b)
+	cmp := &TraceComparison{
+		TraceA:          a.TraceID,
+		TraceB:          b.TraceID,
+		DurationAUs:     ta.DurationUs,
+		DurationBUs:     tb.DurationUs,
+		DurationDeltaUs: tb.DurationUs - ta.DurationUs,
+		Matched:         []SpanDiff{},
+		Missing:         []SpanDiff{},
+		Extra:           []SpanDiff{},
+	}
+
+	keysA, spansA := alignSpans(a.Spans)
+	keysB, spansB := alignSpans(b.Spans)
+	for _, k := range keysA {
+		sa := spansA[k]
+		sb, ok := spansB[k]
+		if !ok {
+			d := spanDiff(k)
+			d.SpanIDA, d.StatusA, d.DurationAUs = sa.SpanID, sa.Status, spanDurationUs(sa)
+			d.DeltaUs = -d.DurationAUs
+			d.DeltaPct = -100
+			if d.DurationAUs == 0 {
+				d.DeltaPct = 0
+			}
+			cmp.Missing = append(cmp.Missing, d)
+			continue
+		}
+		d := spanDiff(k)
+		d.SpanIDA, d.StatusA, d.DurationAUs = sa.SpanID, sa.Status, spanDurationUs(sa)
+		d.SpanIDB, d.StatusB, d.DurationBUs = sb.SpanID, sb.Status, spanDurationUs(sb)
+		d.DeltaUs = d.DurationBUs - d.DurationAUs
+		if d.DurationAUs > 0 {
+			d.DeltaPct = 100 * float64(d.DeltaUs) / float64(d.DurationAUs)
+		}
+		d.AttributeDiffs = diffAttributes(string(sa.AttributesJSON), string(sb.AttributesJSON))
+		cmp.Matched = append(cmp.Matched, d)
+	}
+	for _, k := range keysB {
+		if _, ok := spansA[k]; ok {
+			continue
+		}
+		sb := spansB[k]
+		d := spanDiff(k)
+		d.SpanIDB, d.StatusB, d.DurationBUs = sb.SpanID, sb.Status, spanDurationUs(sb)
+		d.DeltaUs = d.DurationBUs
+		cmp.Extra = append(cmp.Extra, d)
+	}
+	sort.SliceStable(cmp.Matched, func(i, j int) bool { return cmp.Matched[i].DeltaUs > cmp.Matched[j].DeltaUs })
+	return cmp
+}
+
+// alignSpans keys spans by service, operation and occurrence, returning
+// the keys in start order.
+func alignSpans(spans []Span) ([]spanKey, map[spanKey]*Span) {
+	ordered := make([]*Span, 0, len(spans))
+	seen := make(map[string]bool, len(spans))
+	for i := range spans {
+		if !seen[spans[i].SpanID] {
+			seen[spans[i].SpanID] = true
+			ordered = append(ordered, &spans[i])
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].StartTime.Equal(ordered[j].StartTime) {
+			return ordered[i].StartTime.Before(ordered[j].StartTime)
+		}
+		return ordered[i].SpanID < ordered[j].SpanID
+	})
+
+	counts := make(map[[2]string]int, len(ordered))
+	keys := make([]spanKey, 0, len(ordered))
+	byKey := make(map[spanKey]*Span, len(ordered))
+	for _, s := range ordered {
+		name := [2]string{s.ServiceName, s.OperationName}
+		k := spanKey{service: s.ServiceName, operation: s.OperationName, occurrence: counts[name]}
+		counts[name]++
+		keys = append(keys, k)
+		byKey[k] = s
+	}
+	return keys, byKey
+}
+
+func spanDiff(k spanKey) SpanDiff {
+	return SpanDiff{ServiceName: k.service, OperationName: k.operation, Occurrence: k.occurrence}
+}
+
+func spanDurationUs(s *Span) int64 {
+	return s.DurationNanos() / int64(time.Microsecond)
+}
+
+// diffAttributes returns the scalar attributes whose values differ
+// between two AttributesJSON blobs, sorted by key. Array, map and bytes
+// values are not compared.
+func diffAttributes(a, b string) []AttributeDiff {
+	ma, mb := scalarAttributes(a), scalarAttributes(b)
+	var out []AttributeDiff
+	for k, va := range ma {
+		if vb, ok := mb[k]; !ok {
+			out = append(out, AttributeDiff{Key: k, A: &va})
+		} else if va != vb {
+			out = append(out, AttributeDiff{Key: k, A: &va, B: &vb})
+		}
+	}
+	for k, vb := range mb {
+		if _, ok := ma[k]; !ok {
+			out = append(out, AttributeDiff{Key: k, B: &vb})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// scalarAttributes maps the scalar attributes of an AttributesJSON blob by
+// key, the first value winning.
+func scalarAttributes(attrs string) map[string]string {
+	kvs := parseAttributes(attrs)
+	m := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		if _, dup := m[kv.Key]; dup {
+			continue
+		}
+		if v, ok := kv.scalar(); ok {
+			m[kv.Key] = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildTraceComparison(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0).UTC()
+	span := func(trace, id, svc, op string, startMs, durMs int, attrs string) Span {
+		st := t0.Add(time.Duration(startMs) * time.Millisecond)
+		return Span{
+			TraceID:        trace,
+			SpanID:         id,
+			ServiceName:    svc,
+			OperationName:  op,
+			StartTime:      st,
+			EndTime:        st.Add(time.Duration(durMs) * time.Millisecond),
+			Duration:       int64(durMs) * 1000,
+			AttributesJSON: CompressedText(attrs),
+		}
+	}
+	// The baseline runs two queries and a cache lookup; the slow trace runs
+	// three slower queries, skips the cache and calls a fallback.
+	a := &Trace{TraceID: "a", Spans: []Span{
+		span("a", "a1", "api", "GET /orders", 0, 50, `[{"key":"http.status_code","value":{"Value":{"IntValue":200}}},{"key":"region","value":{"Value":{"StringValue":"eu"}}}]`),
+		span("a", "a2", "db", "SELECT", 5, 10, ""),
+		span("a", "a3", "db", "SELECT", 20, 10, ""),
+		span("a", "a4", "cache", "GET", 35, 5, ""),
+	}}
+	b := &Trace{TraceID: "b", Spans: []Span{
+		span("b", "b1", "api", "GET /orders", 0, 120, `[{"key":"http.status_code","value":{"Value":{"IntValue":500}}},{"key":"retry","value":{"Value":{"BoolValue":true}}}]`),
+		span("b", "b2", "db", "SELECT", 5, 10, ""),
+		span("b", "b3", "db", "SELECT", 20, 40, ""),
+		span("b", "b4", "db", "SELECT", 65, 20, ""),
+		span("b", "b5", "fallback", "GET", 90, 25, ""),
+	}}
+
+	cmp := BuildTraceComparison(a, b)
+	if cmp.DurationAUs != 50_000 || cmp.DurationBUs != 120_000 || cmp.DurationDeltaUs != 70_000 {
+		t.Errorf("durations = %d → %d (%d)", cmp.DurationAUs, cmp.DurationBUs, cmp.DurationDeltaUs)
+	}
+	if len(cmp.Matched) != 3 {
+		t.Fatalf("matched = %+v, want api and two SELECTs", cmp.Matched)
+	}
+	root, second := cmp.Matched[0], cmp.Matched[1]
+	if root.SpanIDA != "a1" || root.SpanIDB != "b1" || root.DeltaUs != 70_000 || root.DeltaPct != 140 {
+		t.Errorf("largest slowdown = %+v, want the root at +70ms", root)
+	}
+	if second.SpanIDA != "a3" || second.SpanIDB != "b3" || second.Occurrence != 1 || second.DeltaUs != 30_000 {
+		t.Errorf("second = %+v, want the second SELECT at +30ms", second)
+	}
+	if cmp.Matched[2].DeltaUs != 0 || len(cmp.Matched[2].AttributeDiffs) != 0 {
+		t.Errorf("unchanged SELECT = %+v", cmp.Matched[2])
+	}
+
+	diffs := root.AttributeDiffs
+	if len(diffs) != 3 || diffs[0].Key != "http.status_code" || *diffs[0].A != "200" || *diffs[0].B != "500" ||
+		diffs[1].Key != "region" || diffs[1].B != nil || diffs[2].Key != "retry" || diffs[2].A != nil || *diffs[2].B != "true" {
+		t.Errorf("attribute diffs = %+v", diffs)
+	}
+
+	if len(cmp.Missing) != 1 || cmp.Missing[0].ServiceName != "cache" || cmp.Missing[0].DeltaUs != -5_000 {
+		t.Errorf("missing = %+v, want the cache lookup", cmp.Missing)
+	}
+	if len(cmp.Extra) != 2 || cmp.Extra[0].SpanIDB != "b4" || cmp.Extra[0].Occurrence != 2 || cmp.Extra[1].ServiceName != "fallback" {
+		t.Errorf("extra = %+v, want the third SELECT and the fallback", cmp.Extra)
+	}
+}
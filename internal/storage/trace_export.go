@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultExportPageSize is how many traces ForEachTrace loads per round trip.
+const defaultExportPageSize = 200
+
+// TraceExportFilter bounds a bulk trace export.
+type TraceExportFilter struct {
+	Start, End   time.Time
+	ServiceNames []string
+	// Limit caps the number of traces; 0 exports every match.
+	Limit int
+	// PageSize is the number of traces loaded per round trip (default 200).
+	PageSize int
+}
+
+// ForEachTrace calls fn for every trace of the tenant on ctx whose timestamp
+// falls in [f.Start, f.End], oldest row first, with Spans and Logs loaded.
+// Traces are read in id-keyset pages so memory stays bounded however large
+// the range is — callers stream each trace out before the next page loads.
+// Iteration stops at the first error from fn or the database.
+func (r *Repository) ForEachTrace(ctx context.Context, f TraceExportFilter, fn func(*Trace) error) error {
+	tenant := TenantFromContext(ctx)
+	pageSize := f.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultExportPageSize
+	}
+	var lastID uint
+	sent := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := pageSize
+		if f.Limit > 0 {
+			n = min(n, f.Limit-sent)
+		}
+		q := r.db.WithContext(ctx).
+			Preload("Spans", sqlWhereTenantID, tenant).
+			Preload("Logs", sqlWhereTenantID, tenant).
+			Where("tenant_id = ? AND id > ?", tenant, lastID)
+		if !f.Start.IsZero() {
+			q = q.Where(sqlWhereTimestampGTE, f.Start)
+		}
+		if !f.End.IsZero() {
+			q = q.Where(sqlWhereTimestampLTE, f.End)
+		}
+		if len(f.ServiceNames) > 0 {
+			q = q.Where(sqlWhereServiceIn, f.ServiceNames)
+		}
+		var page []Trace
+		if err := q.Order("id").Limit(n).Find(&page).Error; err != nil {
+			return fmt.Errorf("export traces: %w", err)
+		}
+		for i := range page {
+			if err := fn(&page[i]); err != nil {
+				return err
+			}
+		}
+		sent += len(page)
+		if len(page) < n || (f.Limit > 0 && sent >= f.Limit) {
+			return nil
+		}
+		lastID = page[len(page)-1].ID
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestForEachTrace_PagesRangeAndLimit verifies keyset paging across page
+// boundaries, the time range, Limit, and early stop on a callback error.
+func TestForEachTrace_PagesRangeAndLimit(t *testing.T) {
+	repo := newTestRepo(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		ts := base.Add(time.Duration(i) * time.Hour)
+		seedTrace(t, repo.db, fmt.Sprintf("%032x", i+1), ts, []time.Time{ts, ts})
+	}
+	repo.db.Create(&Trace{TenantID: "other", TraceID: fmt.Sprintf("%032x", 99), Timestamp: base})
+	ctx := context.Background()
+
+	collect := func(f TraceExportFilter) []string {
+		t.Helper()
+		var ids []string
+		if err := repo.ForEachTrace(ctx, f, func(tr *Trace) error {
+			if len(tr.Spans) != 2 {
+				t.Errorf("trace %s: %d spans, want 2", tr.TraceID, len(tr.Spans))
+			}
+			ids = append(ids, tr.TraceID)
+			return nil
+		}); err != nil {
+			t.Fatalf("ForEachTrace: %v", err)
+		}
+		return ids
+	}
+
+	if got := collect(TraceExportFilter{PageSize: 2}); len(got) != 5 {
+		t.Errorf("all traces = %v, want 5 in the default tenant", got)
+	}
+	got := collect(TraceExportFilter{Start: base.Add(time.Hour), End: base.Add(3 * time.Hour), PageSize: 1})
+	if len(got) != 3 || got[0] != fmt.Sprintf("%032x", 2) {
+		t.Errorf("range = %v, want traces 2..4", got)
+	}
+	if got := collect(TraceExportFilter{Limit: 3, PageSize: 2}); len(got) != 3 {
+		t.Errorf("limit 3 = %v", got)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := repo.ForEachTrace(ctx, TraceExportFilter{PageSize: 2}, func(*Trace) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("callback error: err=%v calls=%d, want stop after 1", err, calls)
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// spanStatusError / spanStatusOK mirror the OTLP status code strings the
+// receiver stores on spans (Status.Code.String()).
+const (
+	spanStatusError = "STATUS_CODE_ERROR"
+	spanStatusOK    = "STATUS_CODE_OK"
+	spanStatusUnset = "STATUS_CODE_UNSET"
+)
+
+// TraceKey identifies a trace row. Trace IDs are only unique per tenant
+// (idx_traces_tenant_trace_id), so both halves are required.
+type TraceKey struct {
+	TenantID string
+	TraceID  string
+}
+
+// traceSummary is the trace-level view derived from a trace's spans.
+type traceSummary struct {
+	start  time.Time
+	end    time.Time
+	status string
+}
+
+// fold widens the summary to cover span. Error beats OK beats UNSET so a
+// single failed span marks the whole trace as failed.
+func (s *traceSummary) fold(sp Span) {
+	if s.start.IsZero() || sp.StartTime.Before(s.start) {
+		s.start = sp.StartTime
+	}
+	if sp.EndTime.After(s.end) {
+		s.end = sp.EndTime
+	}
+	switch {
+	case sp.Status == spanStatusError:
+		s.status = spanStatusError
+	case sp.Status == spanStatusOK && s.status != spanStatusError:
+		s.status = spanStatusOK
+	case s.status == "":
+		s.status = spanStatusUnset
+	}
+}
+
+// RecomputeTraceSummaries rewrites Duration, Timestamp and Status on the
+// given trace rows from their persisted spans. The receiver stamps a trace
+// row from the first span it sees (first insert wins), so late-arriving
+// spans that widen the real extent — or carry the only error — never reach
+// the row on their own. Rows already in agreement with their spans are left
+// untouched. Returns the number of rows corrected.
+//
+// Tenant scope: each key carries its own tenant; callers must not mix in
+// keys from a request-scoped tenant they have not authorized.
+func (r *Repository) RecomputeTraceSummaries(ctx context.Context, keys []TraceKey) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	byTenant := make(map[string][]string)
+	for _, k := range keys {
+		byTenant[k.TenantID] = append(byTenant[k.TenantID], k.TraceID)
+	}
+
+	var corrected int
+	for tenant, traceIDs := range byTenant {
+		for start := 0; start < len(traceIDs); start += 500 {
+			end := min(start+500, len(traceIDs))
+			n, err := r.recomputeTenantChunk(ctx, tenant, traceIDs[start:end])
+			corrected += n
+			if err != nil {
+				return corrected, err
+			}
+		}
+	}
+	return corrected, nil
+}
+
+// recomputeTenantChunk handles one bounded IN-list for a single tenant.
+func (r *Repository) recomputeTenantChunk(ctx context.Context, tenant string, traceIDs []string) (int, error) {
+	var spans []Span
+	if err := r.db.WithContext(ctx).
+		Select("trace_id", "start_time", "end_time", "start_time_unix_nano", "duration_ns", "status").
+		Where(sqlWhereTenantID+" AND trace_id IN ?", tenant, traceIDs).
+		Find(&spans).Error; err != nil {
+		return 0, fmt.Errorf("recompute traces: load spans: %w", err)
+	}
+	if len(spans) == 0 {
+		return 0, nil
+	}
+	summaries := make(map[string]*traceSummary)
+	for _, sp := range spans {
+		s := summaries[sp.TraceID]
+		if s == nil {
+			s = &traceSummary{}
+			summaries[sp.TraceID] = s
+		}
+		s.fold(sp)
+	}
+
+	var traces []Trace
+	if err := r.db.WithContext(ctx).
+		Select("id", "trace_id", "duration_ns", "status", "timestamp").
+		Where(sqlWhereTenantID+" AND trace_id IN ?", tenant, traceIDs).
+		Find(&traces).Error; err != nil {
+		return 0, fmt.Errorf("recompute traces: load traces: %w", err)
+	}
+
+	var corrected int
+	for _, tr := range traces {
+		s := summaries[tr.TraceID]
+		if s == nil {
+			continue
+		}
+		durationNs := s.end.Sub(s.start).Nanoseconds()
+		// The timestamp column may be stored below nanosecond precision, so
+		// only a gap of a millisecond or more counts as drift.
+		if durationNs == tr.DurationNs && s.status == tr.Status && s.start.Sub(tr.Timestamp).Abs() < time.Millisecond {
+			continue
+		}
+		if err := r.db.WithContext(ctx).Model(&Trace{}).Where("id = ?", tr.ID).Updates(map[string]any{
+			"duration":    durationNs / int64(time.Microsecond),
+			"duration_ns": durationNs,
+			"status":      s.status,
+			"timestamp":   s.start,
+		}).Error; err != nil {
+			return corrected, fmt.Errorf("recompute traces: update %s: %w", tr.TraceID, err)
+		}
+		corrected++
+	}
+	return corrected, nil
+}
+
+// BackfillTraceSummaries walks every trace row in primary-key order and runs
+// RecomputeTraceSummaries over it in chunks of batchSize, sleeping between
+// chunks so the sweep doesn't starve live ingest of DB connections. Used once
+// to correct rows written before trace finalization existed; safe to re-run.
+//
+// Tenant scope: SYSTEM-WIDE, like retention. Never expose on a tenant-scoped
+// API surface.
+func (r *Repository) BackfillTraceSummaries(ctx context.Context, batchSize int, sleep time.Duration) (int, error) {
+	if batchSize <= 0 {
+		batchSize = 1_000
+	}
+	var (
+		lastID    uint
+		corrected int
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return corrected, err
+		}
+		var page []Trace
+		if err := r.db.WithContext(ctx).
+			Select("id", "tenant_id", "trace_id").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&page).Error; err != nil {
+			return corrected, fmt.Errorf("backfill traces: %w", err)
+		}
+		if len(page) == 0 {
+			return corrected, nil
+		}
+		keys := make([]TraceKey, len(page))
+		for i, tr := range page {
+			keys[i] = TraceKey{TenantID: tr.TenantID, TraceID: tr.TraceID}
+		}
+		n, err := r.RecomputeTraceSummaries(ctx, keys)
+		corrected += n
+		if err != nil {
+			return corrected, err
+		}
+		lastID = page[len(page)-1].ID
+		if len(page) < batchSize {
+			return corrected, nil
+		}
+		select {
+		case <-ctx.Done():
+			return corrected, ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
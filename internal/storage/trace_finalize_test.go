@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// seedFinalizeTrace writes a trace row stamped from its first span plus the
+// given spans, mimicking first-insert-wins ingest.
+func seedFinalizeTrace(t *testing.T, repo *Repository, tenant, traceID string, spans []Span) {
+	t.Helper()
+	first := spans[0]
+	if err := repo.BatchCreateTraces([]Trace{{
+		TenantID:    tenant,
+		TraceID:     traceID,
+		ServiceName: first.ServiceName,
+		Timestamp:   first.StartTime,
+		Duration:    first.EndTime.Sub(first.StartTime).Microseconds(),
+		Status:      first.Status,
+	}}); err != nil {
+		t.Fatalf("BatchCreateTraces: %v", err)
+	}
+	for i := range spans {
+		spans[i].TenantID = tenant
+		spans[i].TraceID = traceID
+	}
+	if err := repo.BatchCreateSpans(spans); err != nil {
+		t.Fatalf("BatchCreateSpans: %v", err)
+	}
+}
+
+func loadTrace(t *testing.T, repo *Repository, tenant, traceID string) Trace {
+	t.Helper()
+	var tr Trace
+	if err := repo.db.Where("tenant_id = ? AND trace_id = ?", tenant, traceID).First(&tr).Error; err != nil {
+		t.Fatalf("load trace: %v", err)
+	}
+	return tr
+}
+
+func TestRecomputeTraceSummaries_LateSpanExtendsTrace(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	seedFinalizeTrace(t, repo, "t1", "trace-a", []Span{
+		{SpanID: "root", StartTime: t0, EndTime: t0.Add(100 * time.Millisecond), Status: spanStatusOK, ServiceName: "api"},
+		{SpanID: "early", StartTime: t0.Add(-20 * time.Millisecond), EndTime: t0, Status: spanStatusUnset, ServiceName: "db"},
+		{SpanID: "late", StartTime: t0.Add(50 * time.Millisecond), EndTime: t0.Add(400 * time.Millisecond), Status: spanStatusError, ServiceName: "db"},
+	})
+
+	n, err := repo.RecomputeTraceSummaries(ctx, []TraceKey{{TenantID: "t1", TraceID: "trace-a"}})
+	if err != nil {
+		t.Fatalf("RecomputeTraceSummaries: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("corrected = %d, want 1", n)
+	}
+	tr := loadTrace(t, repo, "t1", "trace-a")
+	if want := (420 * time.Millisecond).Microseconds(); tr.Duration != want {
+		t.Errorf("Duration = %d, want %d", tr.Duration, want)
+	}
+	if tr.Status != spanStatusError {
+		t.Errorf("Status = %q, want %q", tr.Status, spanStatusError)
+	}
+	if !tr.Timestamp.Equal(t0.Add(-20 * time.Millisecond)) {
+		t.Errorf("Timestamp = %v, want earliest span start", tr.Timestamp)
+	}
+
+	// Second pass is a no-op: the row already agrees with its spans.
+	if n, err := repo.RecomputeTraceSummaries(ctx, []TraceKey{{TenantID: "t1", TraceID: "trace-a"}}); err != nil || n != 0 {
+		t.Errorf("second pass corrected=%d err=%v, want 0, nil", n, err)
+	}
+}
+
+// TestRecomputeTraceSummaries_TenantScoped guards against one tenant's spans
+// widening another tenant's trace that happens to share a trace ID.
+func TestRecomputeTraceSummaries_TenantScoped(t *testing.T) {
+	repo := newTestRepo(t)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	seedFinalizeTrace(t, repo, "a", "shared", []Span{
+		{SpanID: "s1", StartTime: t0, EndTime: t0.Add(10 * time.Millisecond), Status: spanStatusOK},
+	})
+	seedFinalizeTrace(t, repo, "b", "shared", []Span{
+		{SpanID: "s1", StartTime: t0, EndTime: t0.Add(10 * time.Millisecond), Status: spanStatusOK},
+		{SpanID: "s2", StartTime: t0, EndTime: t0.Add(time.Second), Status: spanStatusOK},
+	})
+
+	if _, err := repo.RecomputeTraceSummaries(context.Background(), []TraceKey{
+		{TenantID: "a", TraceID: "shared"},
+		{TenantID: "b", TraceID: "shared"},
+	}); err != nil {
+		t.Fatalf("RecomputeTraceSummaries: %v", err)
+	}
+	if got := loadTrace(t, repo, "a", "shared").Duration; got != 10_000 {
+		t.Errorf("tenant a Duration = %d, want 10000", got)
+	}
+	if got := loadTrace(t, repo, "b", "shared").Duration; got != 1_000_000 {
+		t.Errorf("tenant b Duration = %d, want 1000000", got)
+	}
+}
+
+func TestBackfillTraceSummaries_WalksAllPages(t *testing.T) {
+	repo := newTestRepo(t)
+	t0 := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, id := range []string{"t1", "t2", "t3", "t4", "t5"} {
+		seedFinalizeTrace(t, repo, DefaultTenantID, id, []Span{
+			{SpanID: "a", StartTime: t0, EndTime: t0.Add(time.Millisecond), Status: spanStatusOK},
+			{SpanID: "b", StartTime: t0, EndTime: t0.Add(5 * time.Millisecond), Status: spanStatusOK},
+		})
+	}
+
+	n, err := repo.BackfillTraceSummaries(context.Background(), 2, 0)
+	if err != nil {
+		t.Fatalf("BackfillTraceSummaries: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("corrected = %d, want 5", n)
+	}
+	if got := loadTrace(t, repo, DefaultTenantID, "t5").Duration; got != 5_000 {
+		t.Errorf("t5 Duration = %d, want 5000", got)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTraceInsightNotFound is returned when a trace has no AI insight (yet)
+// for the tenant on ctx.
+var ErrTraceInsightNotFound = errors.New("trace insight not found")
+
+// SaveTraceInsight stores in as the insight of its trace for the tenant on
+// ctx, replacing any earlier one. in.TenantID is overwritten with the ctx
+// tenant so a caller cannot write into another tenant.
+func (r *Repository) SaveTraceInsight(ctx context.Context, in *TraceInsight) error {
+	in.TenantID = TenantFromContext(ctx)
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "trace_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"service_name", "summary", "created_at"}),
+	}).Create(in).Error
+	if err != nil {
+		return fmt.Errorf("failed to save trace insight: %w", err)
+	}
+	return nil
+}
+
+// GetTraceInsight returns the insight of traceID for the tenant on ctx, or
+// ErrTraceInsightNotFound. traceID may be given in either 64- or 128-bit
+// form (see traceid.Variants).
+func (r *Repository) GetTraceInsight(ctx context.Context, traceID string) (*TraceInsight, error) {
+	var in TraceInsight
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND trace_id IN ?", TenantFromContext(ctx), traceid.Variants(traceID)).
+		First(&in).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTraceInsightNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trace insight: %w", err)
+	}
+	return &in, nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTraceInsight_SaveReplaceAndTenantScope(t *testing.T) {
+	repo := newTestRepo(t)
+	ctxA := WithTenantContext(context.Background(), "acme")
+	ctxB := WithTenantContext(context.Background(), "globex")
+
+	// TenantID on the row is ignored in favour of ctx.
+	if err := repo.SaveTraceInsight(ctxA, &TraceInsight{TenantID: "globex", TraceID: "t1", ServiceName: "checkout", Summary: "first"}); err != nil {
+		t.Fatalf("SaveTraceInsight: %v", err)
+	}
+	if err := repo.SaveTraceInsight(ctxA, &TraceInsight{TraceID: "t1", ServiceName: "payment", Summary: "second"}); err != nil {
+		t.Fatalf("SaveTraceInsight (replace): %v", err)
+	}
+
+	got, err := repo.GetTraceInsight(ctxA, "t1")
+	if err != nil {
+		t.Fatalf("GetTraceInsight: %v", err)
+	}
+	if got.TenantID != "acme" || got.ServiceName != "payment" || string(got.Summary) != "second" {
+		t.Errorf("insight = %+v", got)
+	}
+	if n := mustCount(t, repo.db, &TraceInsight{}); n != 1 {
+		t.Errorf("rows = %d, want 1", n)
+	}
+	if _, err := repo.GetTraceInsight(ctxB, "t1"); !errors.Is(err, ErrTraceInsightNotFound) {
+		t.Errorf("other tenant: err = %v, want ErrTraceInsightNotFound", err)
+	}
+}
+
+func TestPurgeTracesBatched_SweepsTraceInsights(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	cutoff := time.Now().UTC().Add(-7 * 24 * time.Hour)
+	for _, id := range []string{"t-old", "t-new"} {
+		ts := time.Now().UTC()
+		if id == "t-old" {
+			ts = cutoff.Add(-time.Hour)
+		}
+		if err := repo.db.Create(&Trace{TenantID: DefaultTenantID, TraceID: id, Timestamp: ts}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.db.Create(&TraceInsight{TenantID: DefaultTenantID, TraceID: id, Summary: "x", CreatedAt: ts}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := repo.PurgeTracesBatched(ctx, cutoff, 10, time.Millisecond); err != nil {
+		t.Fatalf("PurgeTracesBatched: %v", err)
+	}
+	if _, err := repo.GetTraceInsight(ctx, "t-old"); !errors.Is(err, ErrTraceInsightNotFound) {
+		t.Errorf("t-old insight survived purge: err = %v", err)
+	}
+	if _, err := repo.GetTraceInsight(ctx, "t-new"); err != nil {
+		t.Errorf("t-new insight: %v", err)
+	}
+}
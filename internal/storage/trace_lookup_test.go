@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestGetTrace_AcceptsEither64BitSpelling covers a legacy 64-bit trace: rows
+// written before normalization hold the 16-char form, and callers may ask
+// with either spelling.
+func TestGetTrace_AcceptsEither64BitSpelling(t *testing.T) {
+	repo := newTestRepo(t)
+	now := time.Now().UTC()
+	seedTrace(t, repo.db, "00000000000000004bf92f3577b34da6", now, []time.Time{now})
+	seedTrace(t, repo.db, "a3ce929d0e0e4736", now, []time.Time{now})
+	ctx := context.Background()
+
+	for _, id := range []string{
+		"4bf92f3577b34da6",
+		"00000000000000004BF92F3577B34DA6",
+		"a3ce929d0e0e4736",
+		"0000000000000000a3ce929d0e0e4736",
+	} {
+		tr, err := repo.GetTrace(ctx, id)
+		if err != nil {
+			t.Errorf("GetTrace(%q): %v", id, err)
+			continue
+		}
+		if len(tr.Spans) != 1 {
+			t.Errorf("GetTrace(%q): want 1 span, got %d", id, len(tr.Spans))
+		}
+	}
+
+	if _, err := repo.GetTrace(ctx, "ffffffffffffffff"); err == nil {
+		t.Error("unknown trace id: want error")
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
 	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -53,9 +55,19 @@ func (r *Repository) BatchCreateSpans(spans []Span) error {
 	if len(spans) == 0 {
 		return nil
 	}
+	stampRegion(spans, r.region, func(s *Span) *string { return &s.Region })
 	if err := createSpansIdempotent(r.db, r.driver, spans); err != nil {
 		return fmt.Errorf("failed to batch create spans: %w", err)
 	}
+	if err := createSpanAttributesIdempotent(r.db, r.driver, r.spanAttributeRows(spans)); err != nil {
+		return fmt.Errorf("failed to index span attributes: %w", err)
+	}
+	if err := createSpanLinksIdempotent(r.db, r.driver, spanLinkRows(spans)); err != nil {
+		return fmt.Errorf("failed to store span links: %w", err)
+	}
+	if err := createSpanEventsIdempotent(r.db, r.driver, spanEventRows(spans)); err != nil {
+		return fmt.Errorf("failed to store span events: %w", err)
+	}
 	return nil
 }
 
@@ -78,6 +90,7 @@ func (r *Repository) BatchCreateTraces(traces []Trace) error {
 	if len(traces) == 0 {
 		return nil
 	}
+	stampRegion(traces, r.region, func(t *Trace) *string { return &t.Region })
 	return createTracesIdempotent(r.db, r.driver, traces)
 }
 
@@ -109,6 +122,9 @@ func (r *Repository) BatchCreateAll(traces []Trace, spans []Span, logs []Log) er
 	if len(traces) == 0 && len(spans) == 0 && len(logs) == 0 {
 		return nil
 	}
+	stampRegion(traces, r.region, func(t *Trace) *string { return &t.Region })
+	stampRegion(spans, r.region, func(s *Span) *string { return &s.Region })
+	stampRegion(logs, r.region, func(l *Log) *string { return &l.Region })
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if len(traces) > 0 {
 			if err := createTracesIdempotent(tx, r.driver, traces); err != nil {
@@ -119,11 +135,23 @@ func (r *Repository) BatchCreateAll(traces []Trace, spans []Span, logs []Log) er
 			if err := createSpansIdempotent(tx, r.driver, spans); err != nil {
 				return fmt.Errorf("BatchCreateAll: spans: %w", err)
 			}
+			if err := createSpanAttributesIdempotent(tx, r.driver, r.spanAttributeRows(spans)); err != nil {
+				return fmt.Errorf("BatchCreateAll: span attributes: %w", err)
+			}
+			if err := createSpanLinksIdempotent(tx, r.driver, spanLinkRows(spans)); err != nil {
+				return fmt.Errorf("BatchCreateAll: span links: %w", err)
+			}
+			if err := createSpanEventsIdempotent(tx, r.driver, spanEventRows(spans)); err != nil {
+				return fmt.Errorf("BatchCreateAll: span events: %w", err)
+			}
 		}
 		if len(logs) > 0 {
-			if err := tx.CreateInBatches(logs, 500).Error; err != nil {
+			if err := r.logsInsert(tx).CreateInBatches(logs, 500).Error; err != nil {
 				return fmt.Errorf("BatchCreateAll: logs: %w", err)
 			}
+			if err := createSpanExceptionsIdempotent(tx, r.driver, spanExceptionRows(logs)); err != nil {
+				return fmt.Errorf("BatchCreateAll: span exceptions: %w", err)
+			}
 		}
 		return nil
 	})
@@ -133,29 +161,55 @@ func (r *Repository) BatchCreateAll(traces []Trace, spans []Span, logs []Log) er
 // Uniqueness is per idx_traces_tenant_trace_id (tenant_id, trace_id), so the
 // same trace_id across tenants is allowed.
 func (r *Repository) CreateTrace(trace Trace) error {
+	if trace.Region == "" {
+		trace.Region = r.region
+	}
 	if strings.ToLower(r.driver) == "mysql" {
 		return r.db.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&trace).Error
 	}
 	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&trace).Error
 }
 
-// GetTrace returns a trace by ID with its spans and logs, scoped to the tenant on ctx.
+// GetTrace returns a trace by ID with its spans, logs and links (see
+// GetTraceLinks), scoped to the tenant on ctx.
 // Trace uniqueness is composite (tenant_id, trace_id), so the same trace_id can
 // legitimately exist in multiple tenants; the Preloaded Spans and Logs are
 // filtered by tenant_id as defense-in-depth against cross-tenant child leakage.
+// traceID may be given in either 64- or 128-bit form (see traceid.Variants).
 func (r *Repository) GetTrace(ctx context.Context, traceID string) (*Trace, error) {
 	tenant := TenantFromContext(ctx)
 	var trace Trace
 	if err := r.db.WithContext(ctx).
 		Preload("Spans", sqlWhereTenantID, tenant).
 		Preload("Logs", sqlWhereTenantID, tenant).
-		Where("tenant_id = ? AND trace_id = ?", tenant, traceID).
+		Where("tenant_id = ? AND trace_id IN ?", tenant, traceid.Variants(traceID)).
 		First(&trace).Error; err != nil {
 		return nil, fmt.Errorf("failed to get trace: %w", err)
 	}
+	links, err := r.GetTraceLinks(ctx, trace.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) > 0 {
+		trace.Links = links
+	}
 	return &trace, nil
 }
 
+// GetTraceSpans returns the spans stored so far for a trace of the tenant on
+// ctx, oldest first. Unlike GetTrace it does not need the trace row, which
+// is written with the first batch and may lag a trace still arriving.
+func (r *Repository) GetTraceSpans(ctx context.Context, traceID string) ([]Span, error) {
+	var spans []Span
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND trace_id IN ?", TenantFromContext(ctx), traceid.Variants(traceID)).
+		Order("start_time_unix_nano ASC").
+		Find(&spans).Error; err != nil {
+		return nil, fmt.Errorf("failed to get trace spans: %w", err)
+	}
+	return spans, nil
+}
+
 // spanSummary is a lightweight struct used to enrich trace list items.
 type spanSummary struct {
 	TraceID       string
@@ -165,13 +219,33 @@ type spanSummary struct {
 
 // GetTracesFiltered retrieves traces with filtering and pagination, scoped to
 // the tenant on ctx. Spans are NOT eagerly loaded — a single batch summary query
-// is used instead.
-func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time, serviceNames []string, status, search string, limit, offset int, sortBy, orderBy string) (*TracesResponse, error) {
+// is used instead. Each of attrs must hold for some span of the trace (not
+// necessarily the same one) and must name an indexed key, else
+// ErrAttributeNotIndexed.
+func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time, serviceNames []string, status, search string, limit, offset int, sortBy, orderBy string, attrs ...AttributeFilter) (*TracesResponse, error) {
+	for _, a := range attrs {
+		if !r.AttributeIndexed(a.Key) {
+			return nil, fmt.Errorf("%w: %q", ErrAttributeNotIndexed, a.Key)
+		}
+	}
 	tenant := TenantFromContext(ctx)
+	explainResolution(ctx, ResolutionRaw, "")
 	var traces []Trace
 	var total int64
 
-	base := r.db.WithContext(ctx).Model(&Trace{}).Where(sqlWhereTenantID, tenant)
+	base := scopeRegion(ctx, r.db.WithContext(ctx).Model(&Trace{}).Where(sqlWhereTenantID, tenant))
+
+	// status and search are substring matches no B-tree serves, so only
+	// service_name and the time range shape the recorded pattern.
+	var eq []string
+	if len(serviceNames) > 0 {
+		eq = append(eq, "service_name")
+	}
+	rng := ""
+	if !start.IsZero() && !end.IsZero() {
+		rng = "timestamp"
+	}
+	defer func(started time.Time) { r.observeFilter("traces", eq, rng, time.Since(started)) }(time.Now())
 
 	if !start.IsZero() && !end.IsZero() {
 		base = base.Where("timestamp BETWEEN ? AND ?", start, end)
@@ -186,6 +260,14 @@ func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time
 	if search != "" {
 		base = base.Where(fmt.Sprintf("trace_id %s ?", op), "%"+search+"%")
 	}
+	for _, a := range attrs {
+		sub := r.db.Model(&SpanAttribute{}).Select("trace_id").
+			Where("tenant_id = ? AND attr_key = ? AND attr_value = ?", tenant, a.Key, a.Value)
+		if !start.IsZero() {
+			sub = sub.Where("start_time >= ?", start) // spans never start before their trace
+		}
+		base = base.Where("trace_id IN (?)", sub)
+	}
 
 	orderClause := "timestamp DESC"
 	if sortBy != "" {
@@ -195,7 +277,7 @@ func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time
 		}
 		validSorts := map[string]string{
 			"timestamp":    "timestamp",
-			"duration":     "duration",
+			"duration":     "duration_ns",
 			"service_name": "service_name",
 			"status":       "status",
 			"trace_id":     "trace_id",
@@ -217,35 +299,7 @@ func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time
 		return nil, fmt.Errorf("failed to fetch traces: %w", err)
 	}
 
-	// Enrich traces with span summary via a single batch query (no N+1, no full span load).
-	if len(traces) > 0 {
-		traceIDs := make([]string, len(traces))
-		for i, t := range traces {
-			traceIDs[i] = t.TraceID
-		}
-
-		var summaries []spanSummary
-		r.db.WithContext(ctx).Raw(
-			`SELECT trace_id, COUNT(*) as span_count, MIN(operation_name) as operation_name
-			 FROM spans WHERE tenant_id = ? AND trace_id IN ? GROUP BY trace_id`, tenant, traceIDs,
-		).Scan(&summaries)
-
-		sm := make(map[string]spanSummary, len(summaries))
-		for _, s := range summaries {
-			sm[s.TraceID] = s
-		}
-
-		for i := range traces {
-			s := sm[traces[i].TraceID]
-			traces[i].SpanCount = s.SpanCount
-			traces[i].DurationMs = float64(traces[i].Duration) / 1000.0
-			if s.OperationName != "" {
-				traces[i].Operation = s.OperationName
-			} else {
-				traces[i].Operation = "Unknown"
-			}
-		}
-	}
+	r.enrichTraceSummaries(ctx, tenant, traces)
 
 	return &TracesResponse{
 		Traces: traces,
@@ -255,14 +309,54 @@ func (r *Repository) GetTracesFiltered(ctx context.Context, start, end time.Time
 	}, nil
 }
 
+// enrichTraceSummaries fills the span count, duration in ms and operation
+// of list items via a single batch query (no N+1, no full span load).
+func (r *Repository) enrichTraceSummaries(ctx context.Context, tenant string, traces []Trace) {
+	if len(traces) == 0 {
+		return
+	}
+	traceIDs := make([]string, len(traces))
+	for i, t := range traces {
+		traceIDs[i] = t.TraceID
+	}
+
+	var summaries []spanSummary
+	r.db.WithContext(ctx).Raw(
+		`SELECT trace_id, COUNT(*) as span_count, MIN(operation_name) as operation_name
+		 FROM spans WHERE tenant_id = ? AND trace_id IN ? GROUP BY trace_id`, tenant, traceIDs,
+	).Scan(&summaries)
+
+	sm := make(map[string]spanSummary, len(summaries))
+	for _, s := range summaries {
+		sm[s.TraceID] = s
+	}
+
+	for i := range traces {
+		s := sm[traces[i].TraceID]
+		traces[i].SpanCount = s.SpanCount
+		traces[i].DurationMs = float64(traces[i].DurationNanos()) / 1e6
+		if s.OperationName != "" {
+			traces[i].Operation = s.OperationName
+		} else {
+			traces[i].Operation = "Unknown"
+		}
+	}
+}
+
 const serviceMapSpanLimit = 500_000
 
 // GetServiceMapMetrics computes topology metrics from spans scoped to the
-// tenant on ctx.
+// tenant on ctx. Edges come from real parent/child links: a span whose parent
+// (same trace) belongs to another service is one caller→callee call, timed
+// and error-counted by the child span. Services that merely share a trace
+// are not connected.
 func (r *Repository) GetServiceMapMetrics(ctx context.Context, start, end time.Time) (*ServiceMapMetrics, error) {
 	tenant := TenantFromContext(ctx)
 	var spans []Span
-	query := r.db.WithContext(ctx).Model(&Span{}).Where(sqlWhereTenantID, tenant)
+	query := r.db.WithContext(ctx).Model(&Span{}).
+		Select("trace_id, span_id, parent_span_id, service_name, duration, duration_ns, status").
+		Where(sqlWhereTenantID, tenant)
+	query = scopeRegion(ctx, query)
 
 	if !start.IsZero() && !end.IsZero() {
 		query = query.Where("start_time BETWEEN ? AND ?", start, end)
@@ -275,68 +369,84 @@ func (r *Repository) GetServiceMapMetrics(ctx context.Context, start, end time.T
 		slog.Warn("GetServiceMapMetrics: span query hit row limit, topology may be incomplete", "limit", serviceMapSpanLimit)
 	}
 
-	spanMap := make(map[string]Span)
+	// Span IDs are only unique within a trace, so parents are keyed by both.
+	type spanKey struct{ traceID, spanID string }
+	serviceOf := make(map[spanKey]string, len(spans))
 	nodeStats := make(map[string]*ServiceMapNode)
-	edgeStats := make(map[string]*ServiceMapEdge)
+	nodeTraces := make(map[string]map[string]struct{})
+	nodeSpans := make(map[string]int64)
 
 	for _, s := range spans {
-		spanMap[s.SpanID] = s
+		serviceOf[spanKey{s.TraceID, s.SpanID}] = s.ServiceName
 
 		if s.ServiceName == "" {
 			continue
 		}
 
-		if _, ok := nodeStats[s.ServiceName]; !ok {
-			nodeStats[s.ServiceName] = &ServiceMapNode{Name: s.ServiceName}
+		ns, ok := nodeStats[s.ServiceName]
+		if !ok {
+			ns = &ServiceMapNode{Name: s.ServiceName}
+			nodeStats[s.ServiceName] = ns
+			nodeTraces[s.ServiceName] = make(map[string]struct{})
+		}
+		nodeTraces[s.ServiceName][s.TraceID] = struct{}{}
+		nodeSpans[s.ServiceName]++
+		ns.AvgLatencyMs += float64(s.DurationNanos())
+		if s.Status == spanStatusError {
+			ns.ErrorCount++
 		}
-		ns := nodeStats[s.ServiceName]
-		ns.TotalTraces++
-		ns.AvgLatencyMs += float64(s.Duration)
 	}
 
-	nodes := make([]ServiceMapNode, 0)
-	for _, ns := range nodeStats {
-		if ns.TotalTraces > 0 {
-			ns.AvgLatencyMs = ns.AvgLatencyMs / float64(ns.TotalTraces) / 1000.0
-			ns.AvgLatencyMs = math.Round(ns.AvgLatencyMs*100) / 100
-		}
+	nodes := make([]ServiceMapNode, 0, len(nodeStats))
+	for name, ns := range nodeStats {
+		ns.TotalTraces = int64(len(nodeTraces[name]))
+		ns.AvgLatencyMs = math.Round(ns.AvgLatencyMs/float64(nodeSpans[name])/1e6*100) / 100
 		nodes = append(nodes, *ns)
 	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
 
+	edgeStats := make(map[[2]string]*ServiceMapEdge)
+	edgeErrors := make(map[[2]string]int64)
 	for _, s := range spans {
 		if s.ParentSpanID == "" || s.ParentSpanID == "0000000000000000" {
 			continue
 		}
 
-		parent, ok := spanMap[s.ParentSpanID]
+		source, ok := serviceOf[spanKey{s.TraceID, s.ParentSpanID}]
 		if !ok {
 			continue
 		}
-
-		source := parent.ServiceName
 		target := s.ServiceName
 
 		if source == "" || target == "" || source == target {
 			continue
 		}
 
-		key := fmt.Sprintf("%s->%s", source, target)
-		if _, ok := edgeStats[key]; !ok {
-			edgeStats[key] = &ServiceMapEdge{Source: source, Target: target}
+		key := [2]string{source, target}
+		es, ok := edgeStats[key]
+		if !ok {
+			es = &ServiceMapEdge{Source: source, Target: target}
+			edgeStats[key] = es
 		}
-		es := edgeStats[key]
 		es.CallCount++
-		es.AvgLatencyMs += float64(s.Duration)
+		es.AvgLatencyMs += float64(s.DurationNanos())
+		if s.Status == spanStatusError {
+			edgeErrors[key]++
+		}
 	}
 
-	edges := make([]ServiceMapEdge, 0)
-	for _, es := range edgeStats {
-		if es.CallCount > 0 {
-			es.AvgLatencyMs = es.AvgLatencyMs / float64(es.CallCount) / 1000.0
-			es.AvgLatencyMs = math.Round(es.AvgLatencyMs*100) / 100
-		}
+	edges := make([]ServiceMapEdge, 0, len(edgeStats))
+	for key, es := range edgeStats {
+		es.AvgLatencyMs = math.Round(es.AvgLatencyMs/float64(es.CallCount)/1e6*100) / 100
+		es.ErrorRate = math.Round(float64(edgeErrors[key])/float64(es.CallCount)*10000) / 10000
 		edges = append(edges, *es)
 	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
 
 	return &ServiceMapMetrics{
 		Nodes: nodes,
@@ -344,16 +454,20 @@ func (r *Repository) GetServiceMapMetrics(ctx context.Context, start, end time.T
 	}, nil
 }
 
-// PurgeTraces deletes traces older than the given timestamp in a single statement.
+// PurgeTraces deletes traces older than the given timestamp in a single statement,
+// recording the deletion as an admin purge.
 // Uses Unscoped() for a hard DELETE (Trace has a soft-delete column that would
 // otherwise leave rows present and block storage reclamation).
-func (r *Repository) PurgeTraces(olderThan time.Time) (int64, error) {
-	result := r.db.Unscoped().Where("timestamp < ?", olderThan).Delete(&Trace{})
-	if result.Error != nil {
-		return 0, fmt.Errorf("failed to purge traces: %w", result.Error)
+func (r *Repository) PurgeTraces(ctx context.Context, olderThan time.Time) (int64, error) {
+	n, err := r.auditedPurge(ctx, DeletionSignalTraces, DeletionReasonAdminPurge, olderThan, purgeScope{}, func() (int64, error) {
+		result := r.db.WithContext(ctx).Unscoped().Where("timestamp < ?", olderThan).Delete(&Trace{})
+		return result.RowsAffected, result.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge traces: %w", err)
 	}
-	slog.Info("Traces purged", "count", result.RowsAffected, "cutoff", olderThan)
-	return result.RowsAffected, nil
+	slog.Info("Traces purged", "count", n, "cutoff", olderThan)
+	return n, nil
 }
 
 // PurgeTracesBatched deletes traces (and their spans) in bounded chunks.
@@ -363,9 +477,17 @@ func (r *Repository) PurgeTraces(olderThan time.Time) (int64, error) {
 // does NOT filter by tenant. Rows are deleted across every tenant. Never
 // expose this on a tenant-scoped API surface.
 func (r *Repository) PurgeTracesBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration) (int64, error) {
+	return r.purgeTracesBatched(ctx, olderThan, batchSize, sleep, purgeScope{})
+}
+
+// purgeTracesBatched is PurgeTracesBatched narrowed to scope. The orphan
+// span sweep is narrowed the same way, so a scoped pass never touches the
+// spans of tenants outside it.
+func (r *Repository) purgeTracesBatched(ctx context.Context, olderThan time.Time, batchSize int, sleep time.Duration, scope purgeScope) (int64, error) {
 	if batchSize <= 0 {
 		batchSize = 10_000
 	}
+	tenantSQL, tenantArgs := scope.clause()
 	driver := strings.ToLower(r.driver)
 
 	// Delete traces older than cutoff, then sweep any spans whose trace_id is no longer
@@ -375,34 +497,57 @@ func (r *Repository) PurgeTracesBatched(ctx context.Context, olderThan time.Time
 	// Constrain the sweep to old spans (start_time < cutoff) so fresh in-flight spans
 	// are never candidates. Clock-skewed historical spans under a still-present trace
 	// are still protected by the trace-existence subquery.
-	deleteOrphanSpansSQL := "DELETE FROM spans WHERE start_time < ? AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanSpansSQL := "DELETE FROM spans WHERE start_time < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanAttrsSQL := "DELETE FROM span_attributes WHERE start_time < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanInsightsSQL := "DELETE FROM trace_insights WHERE created_at < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanExceptionsSQL := "DELETE FROM span_exceptions WHERE timestamp < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanLinksSQL := "DELETE FROM span_links WHERE start_time < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
+	deleteOrphanEventsSQL := "DELETE FROM span_events WHERE timestamp < ?" + tenantSQL + " AND trace_id NOT IN (SELECT trace_id FROM traces)"
 
 	if driver == "sqlite" || driver == "" {
 		// Unscoped() forces a hard DELETE so the orphan-span sweep below sees a
 		// consistent traces table. Retention must reclaim disk, not soft-delete.
-		result := r.db.WithContext(ctx).Unscoped().Where("timestamp < ?", olderThan).Delete(&Trace{})
+		result := r.db.WithContext(ctx).Unscoped().Where("timestamp < ?"+tenantSQL, append([]any{olderThan}, tenantArgs...)...).Delete(&Trace{})
 		if result.Error != nil {
 			return result.RowsAffected, result.Error
 		}
-		if err := r.db.WithContext(ctx).Exec(deleteOrphanSpansSQL, olderThan).Error; err != nil {
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanSpansSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
 			return result.RowsAffected, fmt.Errorf("sweep orphan spans: %w", err)
 		}
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanAttrsSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
+			return result.RowsAffected, fmt.Errorf("sweep orphan span attributes: %w", err)
+		}
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanInsightsSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
+			return result.RowsAffected, fmt.Errorf("sweep orphan trace insights: %w", err)
+		}
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanExceptionsSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
+			return result.RowsAffected, fmt.Errorf("sweep orphan span exceptions: %w", err)
+		}
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanLinksSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
+			return result.RowsAffected, fmt.Errorf("sweep orphan span links: %w", err)
+		}
+		if err := r.db.WithContext(ctx).Exec(deleteOrphanEventsSQL, append([]any{olderThan}, tenantArgs...)...).Error; err != nil {
+			return result.RowsAffected, fmt.Errorf("sweep orphan span events: %w", err)
+		}
 		return result.RowsAffected, nil
 	}
 
 	var total int64
+	var batches int
 	for {
 		if err := ctx.Err(); err != nil {
 			return total, err
 		}
 		result := r.db.WithContext(ctx).Exec(
-			"DELETE FROM traces WHERE id IN (SELECT id FROM traces WHERE timestamp < ? ORDER BY id LIMIT ?)",
-			olderThan, batchSize,
+			"DELETE FROM traces WHERE id IN (SELECT id FROM traces WHERE timestamp < ?"+tenantSQL+" ORDER BY id LIMIT ?)",
+			scope.args(olderThan, tenantArgs, batchSize)...,
 		)
 		if result.Error != nil {
 			return total, fmt.Errorf("batched purge traces: %w", result.Error)
 		}
 		total += result.RowsAffected
+		batches++
+		logPurgeProgress("traces", batches, total)
 		if result.RowsAffected < int64(batchSize) {
 			break
 		}
@@ -413,27 +558,37 @@ func (r *Repository) PurgeTracesBatched(ctx context.Context, olderThan time.Time
 		}
 	}
 
-	// Sweep orphaned spans in batches. The NOT IN subquery is evaluated per batch, which is
-	// O(spans × traces) worst case — acceptable because we bound the scan with LIMIT and the
-	// trace set shrinks on each pass.
-	for {
-		if err := ctx.Err(); err != nil {
-			return total, err
-		}
-		result := r.db.WithContext(ctx).Exec(
-			"DELETE FROM spans WHERE id IN (SELECT id FROM spans WHERE start_time < ? AND trace_id NOT IN (SELECT trace_id FROM traces) ORDER BY id LIMIT ?)",
-			olderThan, batchSize,
-		)
-		if result.Error != nil {
-			return total, fmt.Errorf("sweep orphan spans: %w", result.Error)
-		}
-		if result.RowsAffected < int64(batchSize) {
-			break
-		}
-		select {
-		case <-ctx.Done():
-			return total, ctx.Err()
-		case <-time.After(sleep):
+	// Sweep orphaned spans, then their extracted attributes, exceptions,
+	// links, events and AI insights, in batches. The NOT IN subquery is evaluated per batch, which
+	// is O(spans × traces) worst case — acceptable because we bound the scan
+	// with LIMIT and the trace set shrinks on each pass.
+	for _, sweep := range []struct{ table, timeCol string }{
+		{"spans", "start_time"},
+		{"span_attributes", "start_time"},
+		{"trace_insights", "created_at"},
+		{"span_exceptions", "timestamp"},
+		{"span_links", "start_time"},
+		{"span_events", "timestamp"},
+	} {
+		for {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+			result := r.db.WithContext(ctx).Exec(
+				"DELETE FROM "+sweep.table+" WHERE id IN (SELECT id FROM "+sweep.table+" WHERE "+sweep.timeCol+" < ?"+tenantSQL+" AND trace_id NOT IN (SELECT trace_id FROM traces) ORDER BY id LIMIT ?)",
+				scope.args(olderThan, tenantArgs, batchSize)...,
+			)
+			if result.Error != nil {
+				return total, fmt.Errorf("sweep orphan %s: %w", sweep.table, result.Error)
+			}
+			if result.RowsAffected < int64(batchSize) {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return total, ctx.Err()
+			case <-time.After(sleep):
+			}
 		}
 	}
 
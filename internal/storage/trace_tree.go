@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// TraceTree is one trace shaped for a waterfall or flamegraph: spans nested
+// under their parents, each positioned relative to the trace start.
+type TraceTree struct {
+	TraceID    string           `json:"trace_id"`
+	StartTime  time.Time        `json:"start_time"`
+	DurationUs int64            `json:"duration_us"` // earliest span start → latest span end
+	SpanCount  int              `json:"span_count"`
+	MaxDepth   int              `json:"max_depth"`
+	Services   []string         `json:"services"`
+	Roots      []*TraceTreeNode `json:"roots"`
+}
+
+// TraceTreeNode is one span in a TraceTree.
+type TraceTreeNode struct {
+	SpanID         string           `json:"span_id"`
+	ParentSpanID   string           `json:"parent_span_id,omitempty"`
+	OperationName  string           `json:"operation_name"`
+	ServiceName    string           `json:"service_name"`
+	Status         string           `json:"status"`
+	StartOffsetUs  int64            `json:"start_offset_us"` // from TraceTree.StartTime
+	DurationUs     int64            `json:"duration_us"`
+	StartOffsetNs  int64            `json:"start_offset_ns"` // orders siblings that start within the same microsecond
+	DurationNs     int64            `json:"duration_ns"`
+	SelfTimeUs     int64            `json:"self_time_us"` // duration not covered by any child
+	Depth          int              `json:"depth"`
+	Orphan         bool             `json:"orphan,omitempty"` // parent span was never received
+	AttributesJSON string           `json:"attributes_json"`
+	Events         []TraceTreeEvent `json:"events"`
+	Children       []*TraceTreeNode `json:"children"`
+}
+
+// TraceTreeEvent is a log record attached to a span — span events
+// (exceptions) are stored as logs at ingest, alongside logs emitted inside
+// the span's context.
+type TraceTreeEvent struct {
+	OffsetUs       int64  `json:"offset_us"` // from TraceTree.StartTime
+	Severity       string `json:"severity"`
+	Body           string `json:"body"`
+	AttributesJSON string `json:"attributes_json"`
+}
+
+// GetTraceTree loads a trace of the tenant on ctx and returns it as a span
+// tree. Errors like GetTrace when the trace does not exist.
+func (r *Repository) GetTraceTree(ctx context.Context, traceID string) (*TraceTree, error) {
+	trace, err := r.GetTrace(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTraceTree(trace), nil
+}
+
+// BuildTraceTree nests t.Spans by parent span ID. Spans whose parent is
+// missing (not yet received, dropped by sampling) become extra roots marked
+// Orphan, so nothing is hidden. Siblings are ordered by start time.
+func BuildTraceTree(t *Trace) *TraceTree {
+	tree := &TraceTree{TraceID: t.TraceID, SpanCount: len(t.Spans), Services: []string{}, Roots: []*TraceTreeNode{}}
+	if len(t.Spans) == 0 {
+		tree.StartTime = t.Timestamp
+		tree.DurationUs = t.Duration
+		return tree
+	}
+
+	start, end := t.Spans[0].StartTime, t.Spans[0].EndTime
+	for _, s := range t.Spans[1:] {
+		if s.StartTime.Before(start) {
+			start = s.StartTime
+		}
+		if s.EndTime.After(end) {
+			end = s.EndTime
+		}
+	}
+	tree.StartTime = start
+	tree.DurationUs = end.Sub(start).Microseconds()
+
+	nodes := make(map[string]*TraceTreeNode, len(t.Spans))
+	ordered := make([]*TraceTreeNode, 0, len(t.Spans))
+	services := make(map[string]bool)
+	for _, s := range t.Spans {
+		if _, dup := nodes[s.SpanID]; dup {
+			continue
+		}
+		n := &TraceTreeNode{
+			SpanID:         s.SpanID,
+			ParentSpanID:   s.ParentSpanID,
+			OperationName:  s.OperationName,
+			ServiceName:    s.ServiceName,
+			Status:         s.Status,
+			StartOffsetUs:  s.StartTime.Sub(start).Microseconds(),
+			DurationUs:     s.DurationNanos() / int64(time.Microsecond),
+			StartOffsetNs:  s.StartTime.Sub(start).Nanoseconds(),
+			DurationNs:     s.DurationNanos(),
+			AttributesJSON: string(s.AttributesJSON),
+			Events:         []TraceTreeEvent{},
+			Children:       []*TraceTreeNode{},
+		}
+		nodes[s.SpanID] = n
+		ordered = append(ordered, n)
+		if s.ServiceName != "" && !services[s.ServiceName] {
+			services[s.ServiceName] = true
+			tree.Services = append(tree.Services, s.ServiceName)
+		}
+	}
+	sort.Strings(tree.Services)
+
+	for _, l := range t.Logs {
+		if n, ok := nodes[l.SpanID]; ok {
+			n.Events = append(n.Events, TraceTreeEvent{
+				OffsetUs:       l.Timestamp.Sub(start).Microseconds(),
+				Severity:       l.Severity,
+				Body:           l.Body,
+				AttributesJSON: string(l.AttributesJSON),
+			})
+		}
+	}
+
+	for _, n := range ordered {
+		parent, ok := nodes[n.ParentSpanID]
+		switch {
+		case n.ParentSpanID == "" || n.ParentSpanID == "0000000000000000":
+			tree.Roots = append(tree.Roots, n)
+		case !ok || parent == n:
+			n.Orphan = true
+			tree.Roots = append(tree.Roots, n)
+		default:
+			parent.Children = append(parent.Children, n)
+		}
+	}
+
+	// Walk from the roots assigning depth and self time. Spans caught in a
+	// parent cycle are unreachable from any root; promote them so they
+	// still render.
+	visited := make(map[*TraceTreeNode]bool, len(ordered))
+	for _, root := range tree.Roots {
+		tree.walk(root, visited)
+	}
+	for _, n := range ordered {
+		if !visited[n] {
+			detach(nodes[n.ParentSpanID], n)
+			n.Orphan = true
+			tree.Roots = append(tree.Roots, n)
+			tree.walk(n, visited)
+		}
+	}
+	sortByStart(tree.Roots)
+	return tree
+}
+
+// walk sets Depth and SelfTimeUs below root, iteratively so very deep
+// traces cannot exhaust the stack.
+func (tree *TraceTree) walk(root *TraceTreeNode, visited map[*TraceTreeNode]bool) {
+	stack := []*TraceTreeNode{root}
+	visited[root] = true
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		tree.MaxDepth = max(tree.MaxDepth, n.Depth)
+		sortByStart(n.Children)
+		n.SelfTimeUs = selfTime(n)
+		for _, c := range n.Children {
+			if visited[c] {
+				continue
+			}
+			visited[c] = true
+			c.Depth = n.Depth + 1
+			stack = append(stack, c)
+		}
+	}
+}
+
+// selfTime is n's duration minus the union of its children's intervals,
+// clipped to n. Children run concurrently or outlive their parent (async
+// work), so a plain subtraction would go negative.
+func selfTime(n *TraceTreeNode) int64 {
+	end := n.StartOffsetUs + n.DurationUs
+	var covered int64
+	cursor := n.StartOffsetUs
+	for _, c := range n.Children { // sorted by start
+		cs := max(c.StartOffsetUs, cursor)
+		ce := min(c.StartOffsetUs+c.DurationUs, end)
+		if ce > cs {
+			covered += ce - cs
+			cursor = ce
+		}
+	}
+	return max(n.DurationUs-covered, 0)
+}
+
+func sortByStart(nodes []*TraceTreeNode) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].StartOffsetNs < nodes[j].StartOffsetNs })
+}
+
+func detach(parent, child *TraceTreeNode) {
+	if parent == nil {
+		return
+	}
+	for i, c := range parent.Children {
+		if c == child {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			return
+		}
+	}
+}
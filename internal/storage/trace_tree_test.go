@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBuildTraceTree(t *testing.T) {
+	t0 := time.Unix(1_700_000_000, 0).UTC()
+	span := func(id, parent, svc string, startMs, durMs int) Span {
+		st := t0.Add(time.Duration(startMs) * time.Millisecond)
+		return Span{
+			TraceID:       "t1",
+			SpanID:        id,
+			ParentSpanID:  parent,
+			OperationName: "op-" + id,
+			ServiceName:   svc,
+			StartTime:     st,
+			EndTime:       st.Add(time.Duration(durMs) * time.Millisecond),
+			Duration:      int64(durMs) * 1000,
+		}
+	}
+	trace := &Trace{
+		TraceID: "t1",
+		Spans: []Span{
+			// Listed out of order on purpose.
+			span("c", "a", "db", 40, 30),        // overlaps b by 10ms
+			span("a", "", "gateway", 0, 100),    // root
+			span("b", "a", "orders", 10, 40),    // 10–50
+			span("d", "b", "orders", 20, 5),     // grandchild
+			span("x", "missing", "cache", 5, 2), // parent never arrived
+		},
+		Logs: []Log{
+			{SpanID: "c", Severity: "ERROR", Body: "timeout", Timestamp: t0.Add(65 * time.Millisecond)},
+		},
+	}
+
+	tree := BuildTraceTree(trace)
+	if tree.SpanCount != 5 || tree.DurationUs != 100_000 || tree.MaxDepth != 2 {
+		t.Fatalf("tree = %+v, want 5 spans, 100ms, depth 2", tree)
+	}
+	if len(tree.Roots) != 2 || tree.Roots[0].SpanID != "a" || tree.Roots[1].SpanID != "x" || !tree.Roots[1].Orphan {
+		t.Fatalf("roots = %+v, want a then orphan x", tree.Roots)
+	}
+
+	a := tree.Roots[0]
+	if len(a.Children) != 2 || a.Children[0].SpanID != "b" || a.Children[1].SpanID != "c" {
+		t.Fatalf("a children not ordered by start: %+v", a.Children)
+	}
+	// Children cover 10–70ms of a's 0–100ms.
+	if a.SelfTimeUs != 40_000 {
+		t.Errorf("a self time = %d, want 40000", a.SelfTimeUs)
+	}
+	b := a.Children[0]
+	if b.StartOffsetUs != 10_000 || b.SelfTimeUs != 35_000 || b.Children[0].Depth != 2 {
+		t.Errorf("b = %+v, want offset 10ms, self 35ms, grandchild depth 2", b)
+	}
+	c := a.Children[1]
+	if len(c.Events) != 1 || c.Events[0].OffsetUs != 65_000 || c.Events[0].Body != "timeout" {
+		t.Errorf("c events = %+v, want the timeout log at 65ms", c.Events)
+	}
+	if len(tree.Services) != 4 {
+		t.Errorf("services = %v, want 4", tree.Services)
+	}
+}
+
+func TestBuildTraceTree_ParentCycle(t *testing.T) {
+	now := time.Now()
+	trace := &Trace{Spans: []Span{
+		{SpanID: "a", ParentSpanID: "b", StartTime: now, EndTime: now},
+		{SpanID: "b", ParentSpanID: "a", StartTime: now, EndTime: now},
+	}}
+	tree := BuildTraceTree(trace)
+	if len(tree.Roots) != 1 || !tree.Roots[0].Orphan || len(tree.Roots[0].Children) != 1 {
+		t.Fatalf("cycle not broken into one orphan root: %+v", tree.Roots)
+	}
+}
+
+func TestGetTraceTree_TenantScoped(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := WithTenantContext(context.Background(), "acme")
+	seedTrace(t, repo.db, "tree1", time.Now(), []time.Time{time.Now()})
+
+	if _, err := repo.GetTraceTree(ctx, "tree1"); err == nil {
+		t.Error("trace of another tenant must not be returned")
+	}
+	tree, err := repo.GetTraceTree(context.Background(), "tree1")
+	if err != nil || tree.SpanCount != 1 {
+		t.Fatalf("GetTraceTree = %+v, %v", tree, err)
+	}
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestGetTrafficMetrics_Buckets verifies SQL bucketing at two step sizes,
+// epoch alignment and error counting.
+func TestGetTrafficMetrics_Buckets(t *testing.T) {
+	repo := newTestRepo(t)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	stamps := []time.Time{
+		base.Add(10 * time.Second),
+		base.Add(50 * time.Second),
+		base.Add(3 * time.Minute),
+		base.Add(7 * time.Minute),
+	}
+	for i, ts := range stamps {
+		status := "OK"
+		if i%2 == 1 {
+			status = "STATUS_CODE_ERROR"
+		}
+		tr := Trace{TraceID: fmt.Sprintf("t%d", i), ServiceName: "cart", Duration: 1000, Status: status, Timestamp: ts}
+		if err := repo.db.Create(&tr).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	for step, want := range map[time.Duration][]TrafficPoint{
+		time.Minute: {
+			{Timestamp: base, Count: 2, ErrorCount: 1},
+			{Timestamp: base.Add(3 * time.Minute), Count: 1},
+			{Timestamp: base.Add(7 * time.Minute), Count: 1, ErrorCount: 1},
+		},
+		5 * time.Minute: {
+			{Timestamp: base, Count: 3, ErrorCount: 1},
+			{Timestamp: base.Add(5 * time.Minute), Count: 1, ErrorCount: 1},
+		},
+	} {
+		got, err := repo.GetTrafficMetrics(ctx, base.Add(-time.Hour), base.Add(time.Hour), nil, step)
+		if err != nil {
+			t.Fatalf("GetTrafficMetrics(%s): %v", step, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %+v, want %+v", step, got, want)
+		}
+		for i := range want {
+			if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Count != want[i].Count || got[i].ErrorCount != want[i].ErrorCount {
+				t.Errorf("%s point %d = %+v, want %+v", step, i, got[i], want[i])
+			}
+		}
+	}
+
+	if got, err := repo.GetTrafficMetrics(ctx, base.Add(-time.Hour), base.Add(time.Hour), []string{"other"}, 0); err != nil || len(got) != 0 {
+		t.Errorf("other service = %+v, %v; want no points", got, err)
+	}
+}
+
+// TestAddTrafficExemplars verifies each bucket links its slowest trace and,
+// when it has errors, an error trace.
+func TestAddTrafficExemplars(t *testing.T) {
+	repo := newTestRepo(t)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, tr := range []Trace{
+		{TraceID: "fast-ok", Duration: 100, Status: "OK", Timestamp: base.Add(5 * time.Second)},
+		{TraceID: "slow-ok", Duration: 9000, Status: "OK", Timestamp: base.Add(20 * time.Second)},
+		{TraceID: "mid-err", Duration: 500, Status: "STATUS_CODE_ERROR", Timestamp: base.Add(40 * time.Second)},
+		{TraceID: "only-ok", Duration: 300, Status: "OK", Timestamp: base.Add(2 * time.Minute)},
+	} {
+		tr.ServiceName = "cart"
+		if err := repo.db.Create(&tr).Error; err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	otherTenant := Trace{TenantID: "acme", TraceID: "acme-slow", ServiceName: "cart", Duration: 99999, Status: "STATUS_CODE_ERROR", Timestamp: base.Add(10 * time.Second)}
+	if err := repo.db.Create(&otherTenant).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	ctx := context.Background()
+	start, end := base.Add(-time.Hour), base.Add(time.Hour)
+	points, err := repo.GetTrafficMetrics(ctx, start, end, nil, time.Minute)
+	if err != nil {
+		t.Fatalf("GetTrafficMetrics: %v", err)
+	}
+	if err := repo.AddTrafficExemplars(ctx, points, start, end, nil, time.Minute); err != nil {
+		t.Fatalf("AddTrafficExemplars: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("points = %+v, want 2", points)
+	}
+	if points[0].SlowTraceID != "slow-ok" || points[0].ErrorTraceID != "mid-err" {
+		t.Errorf("first bucket = %+v, want slow-ok and mid-err", points[0])
+	}
+	if points[1].SlowTraceID != "only-ok" || points[1].ErrorTraceID != "" {
+		t.Errorf("second bucket = %+v, want only-ok and no error trace", points[1])
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Triage item kinds, statuses and bulk actions.
+const (
+	TriageKindIssue   = "issue"
+	TriageKindAnomaly = "anomaly"
+
+	TriageStatusOpen     = "open"
+	TriageStatusResolved = "resolved"
+	TriageStatusIgnored  = "ignored"
+
+	TriageActionResolve = "resolve"
+	TriageActionIgnore  = "ignore"
+	TriageActionReopen  = "reopen"
+	TriageActionAssign  = "assign"
+)
+
+// triageKeyChunk bounds the IN list of one triage statement.
+const triageKeyChunk = 500
+
+// ErrInvalidTriageAction is returned for an unknown action or kind.
+var ErrInvalidTriageAction = errors.New("invalid triage action")
+
+// TriageAction is one bulk change: Action is resolve, ignore, reopen or
+// assign; Assignee is the new owner for assign (empty unassigns).
+type TriageAction struct {
+	Action   string
+	Assignee string
+}
+
+// ApplyTriage applies action to every key of kind for the tenant on ctx,
+// creating state rows for keys that have none. Returns the number of keys
+// changed. Resolving stamps ResolvedAt; reopening clears it. Assigning leaves
+// the status alone.
+func (r *Repository) ApplyTriage(ctx context.Context, kind string, keys []string, action TriageAction) (int, error) {
+	if kind != TriageKindIssue && kind != TriageKindAnomaly {
+		return 0, fmt.Errorf("%w: kind %q", ErrInvalidTriageAction, kind)
+	}
+	now := time.Now().UTC()
+	var updates map[string]any
+	switch action.Action {
+	case TriageActionResolve:
+		updates = map[string]any{"status": TriageStatusResolved, "resolved_at": now}
+	case TriageActionIgnore:
+		updates = map[string]any{"status": TriageStatusIgnored}
+	case TriageActionReopen:
+		updates = map[string]any{"status": TriageStatusOpen, "resolved_at": nil}
+	case TriageActionAssign:
+		updates = map[string]any{"assignee": action.Assignee}
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidTriageAction, action.Action)
+	}
+	updates["updated_at"] = now
+
+	tenant := TenantFromContext(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(keys); start += triageKeyChunk {
+			chunk := keys[start:min(start+triageKeyChunk, len(keys))]
+			var existing []string
+			if err := tx.Model(&TriageState{}).
+				Where("tenant_id = ? AND kind = ? AND item_key IN ?", tenant, kind, chunk).
+				Pluck("item_key", &existing).Error; err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				if err := tx.Model(&TriageState{}).
+					Where("tenant_id = ? AND kind = ? AND item_key IN ?", tenant, kind, existing).
+					Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			have := make(map[string]bool, len(existing))
+			for _, k := range existing {
+				have[k] = true
+			}
+			var rows []TriageState
+			for _, k := range chunk {
+				if have[k] {
+					continue
+				}
+				have[k] = true // duplicate keys in the request
+				row := TriageState{TenantID: tenant, Kind: kind, Key: k, Status: TriageStatusOpen, UpdatedAt: now}
+				switch action.Action {
+				case TriageActionResolve:
+					row.Status, row.ResolvedAt = TriageStatusResolved, &now
+				case TriageActionIgnore:
+					row.Status = TriageStatusIgnored
+				case TriageActionAssign:
+					row.Assignee = action.Assignee
+				}
+				rows = append(rows, row)
+			}
+			if len(rows) > 0 {
+				if err := tx.CreateInBatches(rows, triageKeyChunk).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply triage: %w", err)
+	}
+	return countDistinct(keys), nil
+}
+
+// TriageStates returns the stored state of each key of kind for the tenant
+// on ctx. Keys without a row are absent from the map.
+func (r *Repository) TriageStates(ctx context.Context, kind string, keys []string) (map[string]TriageState, error) {
+	tenant := TenantFromContext(ctx)
+	out := make(map[string]TriageState)
+	for start := 0; start < len(keys); start += triageKeyChunk {
+		chunk := keys[start:min(start+triageKeyChunk, len(keys))]
+		var rows []TriageState
+		if err := r.db.WithContext(ctx).
+			Where("tenant_id = ? AND kind = ? AND item_key IN ?", tenant, kind, chunk).
+			Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to load triage states: %w", err)
+		}
+		for _, row := range rows {
+			out[row.Key] = row
+		}
+	}
+	return out, nil
+}
+
+// EffectiveStatus is the status a triaged item reports when it was last seen
+// at lastSeen: a resolved item that occurred again after it was resolved
+// counts as open (regressed). A nil state is open.
+func (s *TriageState) EffectiveStatus(lastSeen time.Time) string {
+	if s == nil || s.Status == "" {
+		return TriageStatusOpen
+	}
+	if s.Status == TriageStatusResolved && s.ResolvedAt != nil && lastSeen.After(*s.ResolvedAt) {
+		return TriageStatusOpen
+	}
+	return s.Status
+}
+
+func countDistinct(keys []string) int {
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		seen[k] = struct{}{}
+	}
+	return len(seen)
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyTriage(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	other := WithTenantContext(ctx, "other")
+
+	n, err := repo.ApplyTriage(ctx, TriageKindIssue, []string{"a", "b", "a"}, TriageAction{Action: TriageActionResolve})
+	if err != nil || n != 2 {
+		t.Fatalf("resolve: n=%d err=%v", n, err)
+	}
+	if _, err := repo.ApplyTriage(ctx, TriageKindIssue, []string{"b", "c"}, TriageAction{Action: TriageActionAssign, Assignee: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ApplyTriage(other, TriageKindIssue, []string{"a"}, TriageAction{Action: TriageActionIgnore}); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := repo.TriageStates(ctx, TriageKindIssue, []string{"a", "b", "c", "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 3 {
+		t.Fatalf("states = %+v", states)
+	}
+	if a := states["a"]; a.Status != TriageStatusResolved || a.ResolvedAt == nil || a.Assignee != "" {
+		t.Errorf("a = %+v", a)
+	}
+	if b := states["b"]; b.Status != TriageStatusResolved || b.Assignee != "alice" {
+		t.Errorf("b = %+v (assign must keep the status)", b)
+	}
+	if c := states["c"]; c.Status != TriageStatusOpen || c.Assignee != "alice" {
+		t.Errorf("c = %+v", c)
+	}
+	if s, _ := repo.TriageStates(other, TriageKindIssue, []string{"a"}); s["a"].Status != TriageStatusIgnored {
+		t.Errorf("other tenant's a = %+v", s["a"])
+	}
+	if s, _ := repo.TriageStates(ctx, TriageKindAnomaly, []string{"a"}); len(s) != 0 {
+		t.Errorf("kinds leak: %+v", s)
+	}
+
+	if _, err := repo.ApplyTriage(ctx, TriageKindIssue, []string{"a"}, TriageAction{Action: TriageActionReopen}); err != nil {
+		t.Fatal(err)
+	}
+	states, _ = repo.TriageStates(ctx, TriageKindIssue, []string{"a"})
+	if a := states["a"]; a.Status != TriageStatusOpen || a.ResolvedAt != nil {
+		t.Errorf("reopened a = %+v", a)
+	}
+
+	if _, err := repo.ApplyTriage(ctx, TriageKindIssue, []string{"a"}, TriageAction{Action: "delete"}); !errors.Is(err, ErrInvalidTriageAction) {
+		t.Errorf("unknown action: err = %v", err)
+	}
+	if _, err := repo.ApplyTriage(ctx, "trace", []string{"a"}, TriageAction{Action: TriageActionResolve}); !errors.Is(err, ErrInvalidTriageAction) {
+		t.Errorf("unknown kind: err = %v", err)
+	}
+}
+
+func TestTriageState_EffectiveStatus(t *testing.T) {
+	resolvedAt := time.Now()
+	var none *TriageState
+	if none.EffectiveStatus(resolvedAt) != TriageStatusOpen {
+		t.Error("nil state should be open")
+	}
+	st := &TriageState{Status: TriageStatusResolved, ResolvedAt: &resolvedAt}
+	if got := st.EffectiveStatus(resolvedAt.Add(-time.Minute)); got != TriageStatusResolved {
+		t.Errorf("before resolution = %s", got)
+	}
+	if got := st.EffectiveStatus(resolvedAt.Add(time.Minute)); got != TriageStatusOpen {
+		t.Errorf("recurred after resolution = %s, want open", got)
+	}
+}
+
+func TestGetErrorGroups_TriageStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	if err := repo.BatchCreateLogs([]Log{
+		{TenantID: DefaultTenantID, ServiceName: "api", Severity: "ERROR", Body: "timeout after 30ms", Timestamp: now.Add(-time.Hour)},
+		{TenantID: DefaultTenantID, ServiceName: "db", Severity: "ERROR", Body: "deadlock", Timestamp: now.Add(-time.Hour)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fp := IssueFingerprint("api", unknownErrorType, "timeout after <n>ms")
+	if _, err := repo.ApplyTriage(ctx, TriageKindIssue, []string{fp}, TriageAction{Action: TriageActionIgnore}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := repo.GetErrorGroups(ctx, ErrorGroupQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range resp.Groups {
+		want := TriageStatusOpen
+		if g.ServiceName == "api" {
+			want = TriageStatusIgnored
+			if g.Fingerprint != fp {
+				t.Errorf("fingerprint = %s, want %s", g.Fingerprint, fp)
+			}
+		}
+		if g.Status != want {
+			t.Errorf("%s status = %s, want %s", g.ServiceName, g.Status, want)
+		}
+	}
+
+	resp, err = repo.GetErrorGroups(ctx, ErrorGroupQuery{Status: TriageStatusOpen})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalGroups != 1 || resp.Groups[0].ServiceName != "db" || resp.TotalErrors != 1 {
+		t.Errorf("open groups = %+v", resp)
+	}
+}
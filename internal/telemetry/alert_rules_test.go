@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Builds unregistered vectors rather than calling New(), which registers
+// against the global registry and may run only once per test binary.
+func newAlertRuleTestMetrics() *Metrics {
+	labels := []string{"tenant", "rule_id", "rule", "kind", "service"}
+	return &Metrics{
+		AlertRuleFiring: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "firing"}, labels),
+		AlertRuleValue:  prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "value"}, labels),
+	}
+}
+
+func TestSetAlertRuleStates(t *testing.T) {
+	m := newAlertRuleTestMetrics()
+	m.SetAlertRuleStates([]AlertRuleState{
+		{Tenant: "acme", RuleID: 1, Rule: "errors", Kind: "error_rate", Service: "api", Firing: true, Value: 12.5},
+		{Tenant: "acme", RuleID: 2, Rule: "slow", Kind: "p99_latency", Value: 80},
+	})
+	if got := testutil.ToFloat64(m.AlertRuleFiring.WithLabelValues("acme", "1", "errors", "error_rate", "api")); got != 1 {
+		t.Errorf("rule 1 firing = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.AlertRuleValue.WithLabelValues("acme", "2", "slow", "p99_latency", "")); got != 80 {
+		t.Errorf("rule 2 value = %v, want 80", got)
+	}
+
+	// Rule 2 deleted, rule 1 renamed: only the new rule 1 series remains.
+	m.SetAlertRuleStates([]AlertRuleState{
+		{Tenant: "acme", RuleID: 1, Rule: "api errors", Kind: "error_rate", Service: "api"},
+	})
+	if n := testutil.CollectAndCount(m.AlertRuleFiring); n != 1 {
+		t.Errorf("firing series = %d, want 1", n)
+	}
+	if n := testutil.CollectAndCount(m.AlertRuleValue); n != 1 {
+		t.Errorf("value series = %d, want 1", n)
+	}
+	if got := testutil.ToFloat64(m.AlertRuleFiring.WithLabelValues("acme", "1", "api errors", "error_rate", "api")); got != 0 {
+		t.Errorf("resolved rule firing = %v, want 0", got)
+	}
+
+	var nilMetrics *Metrics
+	nilMetrics.SetAlertRuleStates(nil)
+	nilMetrics.RecordAnomaly("t", "error_spike", "critical")
+}
+
+func TestSetSLOStates(t *testing.T) {
+	labels := []string{"tenant", "slo_id", "slo", "service"}
+	m := &Metrics{
+		SLOBudgetRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "budget"}, labels),
+		SLOCompliance:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "compliance"}, labels),
+		SLOBurnRate1h:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "burn_1h"}, labels),
+		SLOBurnRate6h:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "burn_6h"}, labels),
+	}
+	m.SetSLOStates([]SLOState{
+		{Tenant: "acme", SLOID: 1, SLO: "checkout availability", Service: "checkout", BudgetRemaining: 0.4, Compliance: 99.7, BurnRate1h: 14.4, BurnRate6h: 6},
+		{Tenant: "acme", SLOID: 2, SLO: "api p95", Service: "api", BudgetRemaining: 1, Compliance: 100},
+	})
+	if got := testutil.ToFloat64(m.SLOBudgetRemaining.WithLabelValues("acme", "1", "checkout availability", "checkout")); got != 0.4 {
+		t.Errorf("budget remaining = %v, want 0.4", got)
+	}
+	if got := testutil.ToFloat64(m.SLOBurnRate1h.WithLabelValues("acme", "1", "checkout availability", "checkout")); got != 14.4 {
+		t.Errorf("1h burn rate = %v, want 14.4", got)
+	}
+	if got := testutil.ToFloat64(m.SLOCompliance.WithLabelValues("acme", "2", "api p95", "api")); got != 100 {
+		t.Errorf("compliance = %v, want 100", got)
+	}
+
+	// SLO 2 deleted: its series go from every gauge.
+	m.SetSLOStates([]SLOState{
+		{Tenant: "acme", SLOID: 1, SLO: "checkout availability", Service: "checkout", BudgetRemaining: 0.3},
+	})
+	for _, g := range []*prometheus.GaugeVec{m.SLOBudgetRemaining, m.SLOCompliance, m.SLOBurnRate1h, m.SLOBurnRate6h} {
+		if n := testutil.CollectAndCount(g); n != 1 {
+			t.Errorf("series = %d, want 1", n)
+		}
+	}
+
+	var nilMetrics *Metrics
+	nilMetrics.SetSLOStates(nil)
+}
@@ -0,0 +1,118 @@
+package telemetry
+
+import (
+	"fmt"
+	"time"
+)
+
+// DLQ alert condition names, used as the otelcontext_dlq_alert_active label.
+const (
+	DLQAlertFiles          = "files"
+	DLQAlertDisk           = "disk"
+	DLQAlertAge            = "age"
+	DLQAlertReplayFailures = "replay_failures"
+)
+
+// DLQAlertThresholds configures the built-in DLQ growth alerts. A zero
+// field disables that condition.
+type DLQAlertThresholds struct {
+	Files         int           // fire when the backlog holds >= Files batch files
+	Bytes         int64         // fire when the backlog is >= Bytes on disk
+	OldestAge     time.Duration // fire when the oldest batch is older than this
+	FailureStreak int64         // fire after this many consecutive failed replays
+}
+
+// SetDLQAlertThresholds installs the thresholds DLQAlerts evaluates against.
+// Nil-safe.
+func (m *Metrics) SetDLQAlertThresholds(t DLQAlertThresholds) {
+	if m == nil {
+		return
+	}
+	m.dlqThresholds.Store(&t)
+}
+
+// RecordDLQStats publishes a DLQ backlog snapshot to Prometheus and the JSON
+// health endpoint, and refreshes otelcontext_dlq_alert_active. Nil-safe.
+func (m *Metrics) RecordDLQStats(files int, bytes int64, oldestAge time.Duration, failureStreak int64) {
+	if m == nil {
+		return
+	}
+	m.dlqFileCount.Store(int64(files))
+	m.dlqDiskBytes.Store(bytes)
+	m.dlqOldestAgeSec.Store(int64(oldestAge / time.Second))
+	m.dlqFailureStreak.Store(failureStreak)
+
+	if m.DLQSize != nil {
+		m.DLQSize.Set(float64(files))
+	}
+	if m.DLQDiskBytes != nil {
+		m.DLQDiskBytes.Set(float64(bytes))
+	}
+	if m.DLQOldestFileAgeSeconds != nil {
+		m.DLQOldestFileAgeSeconds.Set(oldestAge.Seconds())
+	}
+	if m.DLQReplayFailureStreak != nil {
+		m.DLQReplayFailureStreak.Set(float64(failureStreak))
+	}
+	if m.DLQAlertActive != nil {
+		firing := make(map[string]bool)
+		for _, c := range m.dlqConditions() {
+			firing[c.name] = true
+		}
+		for _, name := range []string{DLQAlertFiles, DLQAlertDisk, DLQAlertAge, DLQAlertReplayFailures} {
+			v := 0.0
+			if firing[name] {
+				v = 1
+			}
+			m.DLQAlertActive.WithLabelValues(name).Set(v)
+		}
+	}
+}
+
+// DLQAlerts returns a human-readable line per firing DLQ growth condition,
+// in the same register as the per-service alerts in the service graph.
+// Nil-safe; empty when no thresholds are set or nothing is firing.
+func (m *Metrics) DLQAlerts() []string {
+	if m == nil {
+		return nil
+	}
+	conds := m.dlqConditions()
+	if len(conds) == 0 {
+		return nil
+	}
+	out := make([]string, len(conds))
+	for i, c := range conds {
+		out[i] = c.message
+	}
+	return out
+}
+
+type dlqCondition struct {
+	name    string
+	message string
+}
+
+func (m *Metrics) dlqConditions() []dlqCondition {
+	t := m.dlqThresholds.Load()
+	if t == nil {
+		return nil
+	}
+	var out []dlqCondition
+	if files := m.dlqFileCount.Load(); t.Files > 0 && files >= int64(t.Files) {
+		out = append(out, dlqCondition{DLQAlertFiles,
+			fmt.Sprintf("DLQ backlog: %d batch files (threshold %d)", files, t.Files)})
+	}
+	if bytes := m.dlqDiskBytes.Load(); t.Bytes > 0 && bytes >= t.Bytes {
+		out = append(out, dlqCondition{DLQAlertDisk,
+			fmt.Sprintf("DLQ disk usage: %d MB (threshold %d MB)", bytes>>20, t.Bytes>>20)})
+	}
+	if age := time.Duration(m.dlqOldestAgeSec.Load()) * time.Second; t.OldestAge > 0 && age >= t.OldestAge {
+		out = append(out, dlqCondition{DLQAlertAge,
+			fmt.Sprintf("DLQ oldest batch is %s old (threshold %s)", age, t.OldestAge)})
+	}
+	if streak := m.dlqFailureStreak.Load(); t.FailureStreak > 0 && streak >= t.FailureStreak {
+		out = append(out, dlqCondition{DLQAlertReplayFailures,
+			fmt.Sprintf("DLQ replay failing: %d consecutive failures (threshold %d)", streak, t.FailureStreak)})
+	}
+	return out
+}
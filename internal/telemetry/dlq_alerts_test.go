@@ -0,0 +1,51 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Uses a zero Metrics rather than New(): New() registers against the global
+// Prometheus registry and may only run once per test binary. All DLQ alert
+// paths are nil-safe on the Prometheus collectors.
+func TestDLQAlerts_Thresholds(t *testing.T) {
+	m := &Metrics{}
+	m.RecordDLQStats(500, 450<<20, 2*time.Hour, 12)
+	if alerts := m.DLQAlerts(); len(alerts) != 0 {
+		t.Fatalf("no thresholds set: want no alerts, got %v", alerts)
+	}
+
+	m.SetDLQAlertThresholds(DLQAlertThresholds{
+		Files:         100,
+		Bytes:         400 << 20,
+		OldestAge:     15 * time.Minute,
+		FailureStreak: 5,
+	})
+	alerts := m.DLQAlerts()
+	if len(alerts) != 4 {
+		t.Fatalf("want 4 alerts, got %v", alerts)
+	}
+	for i, want := range []string{"500 batch files", "450 MB", "2h0m0s old", "12 consecutive"} {
+		if !strings.Contains(alerts[i], want) {
+			t.Errorf("alert %d = %q, want it to mention %q", i, alerts[i], want)
+		}
+	}
+
+	m.RecordDLQStats(3, 1<<20, time.Minute, 0)
+	if alerts := m.DLQAlerts(); len(alerts) != 0 {
+		t.Errorf("healthy backlog: want no alerts, got %v", alerts)
+	}
+	if hs := m.GetHealthStats(); hs.DLQSize != 3 || hs.DLQOldestAgeSeconds != 60 || hs.Alerts != nil {
+		t.Errorf("health stats = %+v", hs)
+	}
+}
+
+func TestDLQAlerts_NilSafe(t *testing.T) {
+	var m *Metrics
+	m.SetDLQAlertThresholds(DLQAlertThresholds{Files: 1})
+	m.RecordDLQStats(10, 0, 0, 0)
+	if alerts := m.DLQAlerts(); alerts != nil {
+		t.Errorf("nil metrics: want nil, got %v", alerts)
+	}
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"sort"
+	"sync"
+)
+
+// droppedRecordsMaxKeys bounds the dropped-records ledger. Past it, new
+// (tenant, service, signal, reason) combinations are folded into
+// DroppedRecordsOtherService so a service-name storm cannot grow it.
+const droppedRecordsMaxKeys = 10_000
+
+// DroppedRecordsOtherService is the service name drops are counted under
+// once the ledger is full.
+const DroppedRecordsOtherService = "(other)"
+
+// DroppedRecord is the number of one service's records refused at ingest
+// for one signal and reason since startup.
+type DroppedRecord struct {
+	ServiceName string `json:"service_name"`
+	Signal      string `json:"signal"`
+	Reason      string `json:"reason"`
+	Count       int64  `json:"count"`
+}
+
+type droppedKey struct {
+	tenant, service, signal, reason string
+}
+
+// droppedLedger is the in-memory per-service counterpart of
+// IngestRejectedTotal, which stays per signal and reason to keep its label
+// cardinality fixed. The zero value is ready to use.
+type droppedLedger struct {
+	mu     sync.Mutex
+	counts map[droppedKey]int64
+}
+
+// RecordDroppedRecords attributes n records refused at ingest to tenant's
+// service, for the data quality report. In memory only: counts reset on
+// restart and are per instance. Nil-safe.
+func (m *Metrics) RecordDroppedRecords(tenant, service, signal, reason string, n int64) {
+	if m == nil || n <= 0 {
+		return
+	}
+	l := &m.dropped
+	k := droppedKey{tenant, service, signal, reason}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts == nil {
+		l.counts = make(map[droppedKey]int64)
+	}
+	if _, ok := l.counts[k]; !ok && len(l.counts) >= droppedRecordsMaxKeys {
+		k.service = DroppedRecordsOtherService
+	}
+	l.counts[k] += n
+}
+
+// DroppedRecords returns tenant's records refused at ingest since startup,
+// ordered by service, signal and reason. Nil-safe.
+func (m *Metrics) DroppedRecords(tenant string) []DroppedRecord {
+	if m == nil {
+		return nil
+	}
+	l := &m.dropped
+	l.mu.Lock()
+	var out []DroppedRecord
+	for k, n := range l.counts {
+		if k.tenant == tenant {
+			out = append(out, DroppedRecord{ServiceName: k.service, Signal: k.signal, Reason: k.reason, Count: n})
+		}
+	}
+	l.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.ServiceName != b.ServiceName {
+			return a.ServiceName < b.ServiceName
+		}
+		if a.Signal != b.Signal {
+			return a.Signal < b.Signal
+		}
+		return a.Reason < b.Reason
+	})
+	return out
+}
@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Uses a zero Metrics rather than New(), which registers against the global
+// Prometheus registry.
+func TestDroppedRecords(t *testing.T) {
+	m := &Metrics{}
+	m.RecordDroppedRecords("acme", "worker", "logs", "severity_filtered", 3)
+	m.RecordDroppedRecords("acme", "api", "traces", "health_check", 2)
+	m.RecordDroppedRecords("acme", "api", "logs", "severity_filtered", 1)
+	m.RecordDroppedRecords("acme", "api", "logs", "severity_filtered", 4)
+	m.RecordDroppedRecords("globex", "api", "logs", "severity_filtered", 9)
+	m.RecordDroppedRecords("acme", "api", "logs", "severity_filtered", 0) // no-op
+
+	got := fmt.Sprint(m.DroppedRecords("acme"))
+	if want := "[{api logs severity_filtered 5} {api traces health_check 2} {worker logs severity_filtered 3}]"; got != want {
+		t.Errorf("DroppedRecords = %s, want %s", got, want)
+	}
+	if got := m.DroppedRecords("initech"); got != nil {
+		t.Errorf("unknown tenant = %v", got)
+	}
+
+	var nilMetrics *Metrics
+	nilMetrics.RecordDroppedRecords("acme", "api", "logs", "x", 1)
+	if nilMetrics.DroppedRecords("acme") != nil {
+		t.Error("nil Metrics returned records")
+	}
+}
+
+func TestDroppedRecords_FoldsPastCap(t *testing.T) {
+	m := &Metrics{}
+	for i := range droppedRecordsMaxKeys {
+		m.RecordDroppedRecords("acme", fmt.Sprintf("svc-%d", i), "logs", "severity_filtered", 1)
+	}
+	m.RecordDroppedRecords("acme", "svc-0", "logs", "severity_filtered", 1) // existing key still counts
+	m.RecordDroppedRecords("acme", "late-1", "logs", "severity_filtered", 2)
+	m.RecordDroppedRecords("acme", "late-2", "logs", "severity_filtered", 3)
+
+	got := m.DroppedRecords("acme")
+	if got[0].ServiceName != DroppedRecordsOtherService || got[0].Count != 5 {
+		t.Errorf("first = %+v, want the folded (other) entry with 5", got[0])
+	}
+	for _, r := range got {
+		if r.ServiceName == "svc-0" && r.Count != 2 {
+			t.Errorf("svc-0 = %+v, want 2", r)
+		}
+	}
+}
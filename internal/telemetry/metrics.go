@@ -3,8 +3,11 @@ package telemetry
 import (
 	"database/sql"
 	"encoding/json"
+	"maps"
 	"net/http"
 	"runtime"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -57,6 +60,7 @@ type Metrics struct {
 	// --- WebSocket ---
 	WSMessagesSent       *prometheus.CounterVec
 	WSSlowClientsRemoved prometheus.Counter
+	WSStaleClientsReaped prometheus.Counter
 
 	// --- DLQ ---
 	DLQEnqueuedTotal prometheus.Counter
@@ -72,8 +76,10 @@ type Metrics struct {
 	RetentionPurgeDurationSeconds  *prometheus.HistogramVec
 	RetentionVacuumDurationSeconds *prometheus.HistogramVec
 	RetentionRowsBehindGauge       *prometheus.GaugeVec
+	RetentionRowsPurgedLastCycle   *prometheus.GaugeVec
 
-	// --- Postgres partitioning (DB_POSTGRES_PARTITIONING=daily) ---
+	// --- Postgres partitioning (DB_POSTGRES_PARTITIONING=daily), also fed
+	// by SQLite sharding (DB_SQLITE_SHARDING=daily) ---
 	// PartitionsDropped counts daily logs partitions dropped during the
 	// retention pass. Each drop is a near-instant DDL — alert when this
 	// counter is flat for >1.5 retention periods (indicates a stuck loop).
@@ -97,6 +103,9 @@ type Metrics struct {
 
 	// --- GraphRAG overflow ---
 	GraphRAGEventsDroppedTotal *prometheus.CounterVec
+	// GraphRAGAnomaliesTotal — anomalies detected, by tenant, type
+	// (error_spike|latency_spike|metric_zscore) and severity.
+	GraphRAGAnomaliesTotal *prometheus.CounterVec
 
 	// --- Async ingest pipeline (Phase 1 robustness work) ---
 	// IngestPipelineQueueDepth — current queue depth, sampled on every Submit.
@@ -106,6 +115,143 @@ type Metrics struct {
 	// reason="soft_backpressure" — healthy batch dropped at >=90% fullness.
 	// reason="queue_full"        — batch rejected at 100% capacity (client got 429/RESOURCE_EXHAUSTED).
 	IngestPipelineDroppedTotal *prometheus.CounterVec
+	// IngestPipelineSpilledTotal — batches whose write kept failing and were
+	// handed to the DLQ for replay, by signal.
+	IngestPipelineSpilledTotal *prometheus.CounterVec
+
+	// IngestRejectedTotal — individual records (spans, log records, metric
+	// data points) refused by an OTLP receiver and reported back to the
+	// client in the response's partial_success field. Labeled by signal and
+	// reason (service_filtered|severity_filtered|soft_backpressure|…).
+	IngestRejectedTotal *prometheus.CounterVec
+
+	// IngestAuthFailuresTotal — OTLP exports refused by INGEST_AUTH_FILE
+	// authentication, by transport (grpc|http|loki) and reason
+	// (missing_credentials|bad_scheme|bad_credentials).
+	IngestAuthFailuresTotal *prometheus.CounterVec
+
+	// TracesRecomputedTotal — trace rows whose duration/status/timestamp
+	// were corrected from their spans. source="finalize" for the live
+	// quiescence pass, source="backfill" for the one-off historical sweep.
+	TracesRecomputedTotal *prometheus.CounterVec
+
+	// JaegerAgentPacketsTotal — UDP datagrams received by the legacy Jaeger
+	// agent receiver, by result (ok|decode_error|export_error).
+	JaegerAgentPacketsTotal *prometheus.CounterVec
+
+	// JaegerCollectorBatchesTotal — batches received by the Jaeger collector
+	// receiver, by transport (http|grpc) and result
+	// (ok|decode_error|export_error).
+	JaegerCollectorBatchesTotal *prometheus.CounterVec
+
+	// ZipkinSpansTotal — spans received by the Zipkin v2 receiver, by result
+	// (ok|invalid|export_error).
+	ZipkinSpansTotal *prometheus.CounterVec
+
+	// LokiEntriesTotal — log entries received by the Loki push receiver, by
+	// result (ok|invalid|export_error).
+	LokiEntriesTotal *prometheus.CounterVec
+
+	// GitHubWebhookEventsTotal — GitHub webhook deliveries, by event and
+	// result (ok|ignored|invalid|unauthorized|error).
+	GitHubWebhookEventsTotal *prometheus.CounterVec
+
+	// DeploymentVerdictsTotal — deployments judged against the window before
+	// them, by verdict (ok|regressed|insufficient_data).
+	DeploymentVerdictsTotal *prometheus.CounterVec
+
+	// StatsDLinesTotal — StatsD/DogStatsD lines received over UDP, by result
+	// (ok|parse_error|unsupported|export_error).
+	StatsDLinesTotal *prometheus.CounterVec
+
+	// FirehoseRecordsTotal — Kinesis Firehose records received on
+	// /ingest/firehose, by result (ok|decode_error|control).
+	FirehoseRecordsTotal *prometheus.CounterVec
+
+	// LogDrainLinesTotal — log lines received on the Heroku/Vercel drain
+	// endpoints, by format (heroku|vercel) and result (ok|invalid|export_error).
+	LogDrainLinesTotal *prometheus.CounterVec
+
+	// TailSamplingTracesTotal — tail-sampling decisions by decision
+	// (kept|dropped) and the policy that matched (error|latency|service|
+	// probabilistic|none). TailSamplingBufferedTraces is the number of
+	// traces waiting for their decision window to close.
+	TailSamplingTracesTotal    *prometheus.CounterVec
+	TailSamplingBufferedTraces prometheus.Gauge
+
+	// SpanNamesNormalizedTotal — spans renamed by a span name rule, by rule
+	// (its name, or index in the rules file). SpanNamesDistinct — distinct
+	// service/span-name pairs seen since startup, by stage (before|after
+	// normalization), each capped at 10k.
+	SpanNamesNormalizedTotal *prometheus.CounterVec
+	SpanNamesDistinct        *prometheus.GaugeVec
+
+	// IngestRedactionsTotal — attribute values and pattern matches scrubbed
+	// by a redaction rule, by signal (traces|logs) and rule (its name, or
+	// its attribute, builtin or index in the rules file).
+	IngestRedactionsTotal *prometheus.CounterVec
+
+	// IngestTransformsTotal — spans and log records changed by a transform
+	// processor, by signal (traces|logs) and processor (its name, or its
+	// operation and index in the transform file).
+	IngestTransformsTotal *prometheus.CounterVec
+
+	// ConfigReloadsTotal — config file reloads, by result (ok|error). A
+	// failed reload keeps the running configuration.
+	ConfigReloadsTotal *prometheus.CounterVec
+
+	// --- Span metrics (RED from ingested spans, SPAN_METRICS_ENABLED) ---
+	// SpanCallsTotal — spans by {tenant,service_name,span_name,status_code};
+	// rate() is the request rate, the STATUS_CODE_ERROR share the error
+	// rate. SpanDurationSeconds — their durations, same labels.
+	// SpanMetricsOverflowTotal — spans folded into span_name="(other)"
+	// after SPAN_METRICS_MAX_SERIES was reached (see span_metrics.go).
+	SpanCallsTotal           *prometheus.CounterVec
+	SpanDurationSeconds      *prometheus.HistogramVec
+	SpanMetricsOverflowTotal prometheus.Counter
+
+	// AlertNotificationsTotal — alert notifications sent, by channel
+	// (slack|webhook|email) and result (ok|error).
+	AlertNotificationsTotal *prometheus.CounterVec
+
+	// ColdTierRowsExportedTotal — rows moved from the hot DB to Parquet
+	// segments, by signal (logs|traces|spans). ColdTierRunsTotal counts
+	// tiering passes by result (ok|error); a failed pass leaves its rows in
+	// the hot DB for the next one.
+	ColdTierRowsExportedTotal *prometheus.CounterVec
+	ColdTierRunsTotal         *prometheus.CounterVec
+
+	// ExportRecordsTotal — records republished to the EXPORT_URL sink, by
+	// signal (traces|logs|metrics) and result (ok|error|dropped). dropped
+	// counts records refused because the export queue was full.
+	ExportRecordsTotal *prometheus.CounterVec
+	// AlertRuleFiring — 1 while a user alert rule is firing, 0 otherwise;
+	// AlertRuleValue — the rule's latest measured value. Both labeled
+	// {tenant,rule_id,rule,kind,service} and replaced wholesale after every
+	// evaluation pass, so deleted or disabled rules drop out.
+	AlertRuleFiring *prometheus.GaugeVec
+	AlertRuleValue  *prometheus.GaugeVec
+
+	// SLO* — each SLO's status from the latest evaluation pass: error
+	// budget remaining (fraction, negative when overspent), compliance
+	// percent and 1h/6h burn rates. Labeled {tenant,slo_id,slo,service} and
+	// replaced wholesale like the rule gauges, so deleted SLOs drop out.
+	SLOBudgetRemaining *prometheus.GaugeVec
+	SLOCompliance      *prometheus.GaugeVec
+	SLOBurnRate1h      *prometheus.GaugeVec
+	SLOBurnRate6h      *prometheus.GaugeVec
+
+	// UptimeMonitorUp — 1 when an uptime monitor's last check passed, 0
+	// otherwise; UptimeMonitorAvailability — percent of its checks up over
+	// the last 24h; UptimeCheckDurationSeconds — its last check's duration.
+	// All labeled {tenant,monitor_id,monitor,kind}; series of deleted or
+	// disabled monitors are removed.
+	UptimeMonitorUp            *prometheus.GaugeVec
+	UptimeMonitorAvailability  *prometheus.GaugeVec
+	UptimeCheckDurationSeconds *prometheus.GaugeVec
+	// UptimeChecksTotal — uptime checks run, by kind (http|tcp|icmp) and
+	// result (up|down).
+	UptimeChecksTotal *prometheus.CounterVec
 
 	// HTTPOTLPThrottledTotal — count of HTTP 429s issued by the OTLP HTTP
 	// receiver when the async ingest pipeline is full. Mirrors the gRPC
@@ -123,9 +269,26 @@ type Metrics struct {
 	// --- DLQ eviction (Task 8) ---
 	DLQEvictedTotal      prometheus.Counter
 	DLQEvictedBytesTotal prometheus.Counter
+	// DLQQuarantinedTotal — batches moved to the DLQ quarantine/ directory
+	// after DLQ_MAX_RETRIES failed replays.
+	DLQQuarantinedTotal prometheus.Counter
+	// DLQExpiredTotal — batches removed for exceeding DLQ_MAX_AGE.
+	DLQExpiredTotal prometheus.Counter
+	// DLQRejectedTotal — batches refused by a full DLQ under
+	// DLQ_OVERFLOW_POLICY=reject_new. Each one is lost telemetry.
+	DLQRejectedTotal prometheus.Counter
 
-	// --- Dashboard p99 (Task 10) ---
-	DashboardP99RowCapHitsTotal prometheus.Counter
+	// --- DLQ growth (sampled every 30s from DeadLetterQueue.Stats) ---
+	// DLQOldestFileAgeSeconds — age of the oldest unreplayed batch. Grows
+	// monotonically while the DB is down even when eviction keeps the file
+	// count flat, so it is the earliest "DB is dying" signal.
+	DLQOldestFileAgeSeconds prometheus.Gauge
+	// DLQReplayFailureStreak — consecutive failed replays since the last
+	// success.
+	DLQReplayFailureStreak prometheus.Gauge
+	// DLQAlertActive — 1 while a built-in DLQ alert condition is firing,
+	// labeled {condition=files|disk|age|replay_failures}.
+	DLQAlertActive *prometheus.GaugeVec
 
 	// --- Vectordb persistence ---
 	// VectorSnapshotWritesTotal counts snapshot write attempts, labeled
@@ -153,6 +316,33 @@ type Metrics struct {
 	dlqFileCount   atomic.Int64
 	dbLatencyP99Ms atomic.Int64
 	startTime      time.Time
+
+	// DLQ growth snapshot + thresholds behind DLQAlerts (see dlq_alerts.go).
+	dlqDiskBytes     atomic.Int64
+	dlqOldestAgeSec  atomic.Int64
+	dlqFailureStreak atomic.Int64
+	dlqThresholds    atomic.Pointer[DLQAlertThresholds]
+
+	// Label sets last written to AlertRuleFiring/AlertRuleValue, keyed by
+	// rule ID, so SetAlertRuleStates can delete series for vanished rules.
+	alertMu     sync.Mutex
+	alertSeries map[uint]prometheus.Labels
+
+	// Label sets last written to the SLO* gauges, keyed by SLO ID, so
+	// SetSLOStates can delete series for deleted SLOs.
+	sloMu     sync.Mutex
+	sloSeries map[uint]prometheus.Labels
+
+	// Label sets last written to the UptimeMonitor* gauges, keyed by
+	// monitor ID, so RetainUptimeMonitors can delete removed monitors.
+	uptimeMu     sync.Mutex
+	uptimeSeries map[uint]prometheus.Labels
+
+	// Per-service records refused at ingest (see dropped_records.go).
+	dropped droppedLedger
+
+	// Series behind SpanCallsTotal/SpanDurationSeconds (see span_metrics.go).
+	spanSeries spanMetricsLedger
 }
 
 // New creates and registers all OtelContext internal metrics.
@@ -244,6 +434,10 @@ func New() *Metrics {
 			Name: "OtelContext_ws_slow_clients_removed_total",
 			Help: "WebSocket clients dropped due to slow consumption.",
 		}),
+		WSStaleClientsReaped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "OtelContext_ws_stale_clients_reaped_total",
+			Help: "WebSocket clients closed for not answering a ping within WS_IDLE_TIMEOUT.",
+		}),
 
 		// DLQ
 		DLQEnqueuedTotal: promauto.NewCounter(prometheus.CounterOpts{
@@ -288,15 +482,19 @@ func New() *Metrics {
 			Name: "otelcontext_retention_rows_behind",
 			Help: "Rows older than retention cutoff that have not yet been purged. Climbing means purge cannot keep pace with ingest.",
 		}, []string{"table", "driver"}),
+		RetentionRowsPurgedLastCycle: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_retention_rows_purged_last_cycle",
+			Help: "Rows deleted by the most recent retention purge pass, by table.",
+		}, []string{"table"}),
 
 		// Postgres partitioning
 		PartitionsDropped: promauto.NewCounter(prometheus.CounterOpts{
 			Name: "otelcontext_partitions_dropped_total",
-			Help: "Total daily logs partitions dropped by the partition scheduler. Increments by `n` when n partitions are dropped on a single tick.",
+			Help: "Total daily logs partitions (or SQLite shard files) dropped by retention. Increments by `n` when n partitions are dropped on a single tick.",
 		}),
 		PartitionsActive: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "otelcontext_partitions_active",
-			Help: "Live partitions attached to the logs parent. Steady-state ≈ HOT_RETENTION_DAYS + DB_PARTITION_LOOKAHEAD_DAYS + 1.",
+			Help: "Live partitions attached to the logs parent (or SQLite shard files on disk). Steady-state ≈ HOT_RETENTION_DAYS + DB_PARTITION_LOOKAHEAD_DAYS + 1.",
 		}),
 
 		// Runtime
@@ -320,7 +518,7 @@ func New() *Metrics {
 		}, []string{"tool", "status"}),
 		APIAuthFailuresTotal: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "OtelContext_api_auth_failures_total",
-			Help: "API key auth failures by reason (missing_header|bad_scheme|bad_key).",
+			Help: "API auth failures by reason (missing_header|bad_scheme|bad_key|insufficient_scope|insufficient_role|client_cert|no_session|oidc_*).",
 		}, []string{"reason"}),
 		GraphRAGEventBufferDepth: promauto.NewGauge(prometheus.GaugeOpts{
 			Name: "OtelContext_graphrag_event_buffer_depth",
@@ -343,6 +541,10 @@ func New() *Metrics {
 			Name: "otelcontext_graphrag_events_dropped_total",
 			Help: "Events dropped because the GraphRAG event channel was full.",
 		}, []string{"signal"}),
+		GraphRAGAnomaliesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_graphrag_anomalies_total",
+			Help: "Anomalies detected by GraphRAG, by tenant, type and severity.",
+		}, []string{"tenant", "type", "severity"}),
 
 		IngestPipelineQueueDepth: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "otelcontext_ingest_pipeline_queue_depth",
@@ -354,8 +556,157 @@ func New() *Metrics {
 		}, []string{"signal"}),
 		IngestPipelineDroppedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "otelcontext_ingest_pipeline_dropped_total",
-			Help: "Batches dropped by the async ingest pipeline. reason=soft_backpressure (>=90% queue, healthy) or queue_full (100% queue, rejected to client).",
+			Help: "Batches dropped by the async ingest pipeline. reason=soft_backpressure (>=90% queue, healthy), queue_full (100% queue, rejected to client) or write_failed (write kept failing and the DLQ refused it).",
+		}, []string{"signal", "reason"}),
+		IngestPipelineSpilledTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_ingest_pipeline_spilled_total",
+			Help: "Batches the async ingest pipeline could not write after retries and spilled to the DLQ, by signal type.",
+		}, []string{"signal"}),
+		IngestRejectedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_ingest_rejected_total",
+			Help: "Records rejected by the OTLP receivers and reported to clients via partial_success, by signal and reason.",
 		}, []string{"signal", "reason"}),
+		IngestAuthFailuresTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_ingest_auth_failures_total",
+			Help: "OTLP exports refused by ingest authentication, by transport (grpc|http|loki) and reason.",
+		}, []string{"transport", "reason"}),
+		TracesRecomputedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_traces_recomputed_total",
+			Help: "Trace rows whose duration/status were corrected from their spans, by source (finalize|backfill).",
+		}, []string{"source"}),
+		JaegerAgentPacketsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_jaeger_agent_packets_total",
+			Help: "UDP packets received by the Jaeger agent (compact thrift) receiver, by result (ok|decode_error|export_error).",
+		}, []string{"result"}),
+		JaegerCollectorBatchesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_jaeger_collector_batches_total",
+			Help: "Batches received by the Jaeger collector receiver, by transport (http|grpc) and result (ok|decode_error|export_error).",
+		}, []string{"transport", "result"}),
+		ZipkinSpansTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_zipkin_spans_total",
+			Help: "Spans received by the Zipkin v2 JSON receiver, by result (ok|invalid|export_error).",
+		}, []string{"result"}),
+		LokiEntriesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_loki_entries_total",
+			Help: "Log entries received by the Loki push receiver, by result (ok|invalid|export_error).",
+		}, []string{"result"}),
+		GitHubWebhookEventsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_github_webhook_events_total",
+			Help: "GitHub webhook deliveries, by event and result (ok|ignored|invalid|unauthorized|error).",
+		}, []string{"event", "result"}),
+		DeploymentVerdictsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_deployment_verdicts_total",
+			Help: "Deployments judged against the window before them, by verdict (ok|regressed|insufficient_data).",
+		}, []string{"verdict"}),
+		StatsDLinesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_statsd_lines_total",
+			Help: "StatsD/DogStatsD lines received over UDP, by result (ok|parse_error|unsupported|export_error).",
+		}, []string{"result"}),
+		FirehoseRecordsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_firehose_records_total",
+			Help: "Kinesis Firehose records received, by result (ok|decode_error|control).",
+		}, []string{"result"}),
+		LogDrainLinesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_log_drain_lines_total",
+			Help: "Log lines received on PaaS log drain endpoints, by format (heroku|vercel) and result (ok|invalid|export_error).",
+		}, []string{"format", "result"}),
+		TailSamplingTracesTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_tail_sampling_traces_total",
+			Help: "Tail-sampling decisions, by decision (kept|dropped) and matching policy.",
+		}, []string{"decision", "policy"}),
+		TailSamplingBufferedTraces: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "otelcontext_tail_sampling_buffered_traces",
+			Help: "Traces buffered by the tail sampler awaiting a decision.",
+		}),
+		SpanNamesNormalizedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_span_names_normalized_total",
+			Help: "Spans renamed at ingest by a span name normalization rule, by rule.",
+		}, []string{"rule"}),
+		SpanNamesDistinct: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_span_names_distinct",
+			Help: "Distinct service/span-name pairs seen since startup, by stage (before|after) span name normalization; capped at 10000.",
+		}, []string{"stage"}),
+		IngestRedactionsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_ingest_redactions_total",
+			Help: "Attribute values and pattern matches scrubbed at ingest by a redaction rule, by signal and rule.",
+		}, []string{"signal", "rule"}),
+		IngestTransformsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_ingest_transforms_total",
+			Help: "Spans and log records changed at ingest by an attribute transform processor, by signal and processor.",
+		}, []string{"signal", "processor"}),
+		ConfigReloadsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_config_reloads_total",
+			Help: "Config file reloads, by result (ok|error).",
+		}, []string{"result"}),
+		SpanCallsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_span_calls_total",
+			Help: "Ingested spans by tenant, service, span name and status code. rate() is the request rate; the STATUS_CODE_ERROR share is the error rate.",
+		}, []string{"tenant", "service_name", "span_name", "status_code"}),
+		SpanDurationSeconds: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "otelcontext_span_duration_seconds",
+			Help:    "Durations of ingested spans by tenant, service, span name and status code.",
+			Buckets: spanMetricsBuckets,
+		}, []string{"tenant", "service_name", "span_name", "status_code"}),
+		SpanMetricsOverflowTotal: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "otelcontext_span_metrics_overflow_total",
+			Help: "Spans counted under span_name=\"(other)\" because SPAN_METRICS_MAX_SERIES was reached.",
+		}),
+		AlertNotificationsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_alert_notifications_total",
+			Help: "Alert notifications sent, by channel (slack|webhook|email) and result (ok|error).",
+		}, []string{"channel", "result"}),
+		ColdTierRowsExportedTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_cold_tier_rows_exported_total",
+			Help: "Rows exported from the hot database to cold tier Parquet segments, by signal (logs|traces|spans).",
+		}, []string{"signal"}),
+		ColdTierRunsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_cold_tier_runs_total",
+			Help: "Cold tier export passes, by result (ok|error).",
+		}, []string{"result"}),
+		ExportRecordsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_export_records_total",
+			Help: "Records republished to the export sink, by signal (traces|logs|metrics) and result (ok|error|dropped).",
+		}, []string{"signal", "result"}),
+		AlertRuleFiring: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_alert_rule_firing",
+			Help: "1 while a user-defined alert rule is firing, 0 otherwise.",
+		}, []string{"tenant", "rule_id", "rule", "kind", "service"}),
+		AlertRuleValue: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_alert_rule_value",
+			Help: "Latest measured value of a user-defined alert rule (error_rate %, p99_latency ms, log_match count, slo_burn_rate burn rate, monitor_down failed checks).",
+		}, []string{"tenant", "rule_id", "rule", "kind", "service"}),
+		SLOBudgetRemaining: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_slo_error_budget_remaining",
+			Help: "Fraction of an SLO's error budget left over its window; negative when overspent.",
+		}, []string{"tenant", "slo_id", "slo", "service"}),
+		SLOCompliance: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_slo_compliance_percent",
+			Help: "Percent of an SLO's traces that were good over its window; 100 without traffic.",
+		}, []string{"tenant", "slo_id", "slo", "service"}),
+		SLOBurnRate1h: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_slo_burn_rate_1h",
+			Help: "Error budget burn rate of an SLO over the last hour (1 spends the budget exactly over the window).",
+		}, []string{"tenant", "slo_id", "slo", "service"}),
+		SLOBurnRate6h: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_slo_burn_rate_6h",
+			Help: "Error budget burn rate of an SLO over the last 6 hours (1 spends the budget exactly over the window).",
+		}, []string{"tenant", "slo_id", "slo", "service"}),
+		UptimeMonitorUp: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_uptime_monitor_up",
+			Help: "1 when an uptime monitor's last check passed, 0 otherwise.",
+		}, []string{"tenant", "monitor_id", "monitor", "kind"}),
+		UptimeMonitorAvailability: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_uptime_monitor_availability_percent",
+			Help: "Percent of an uptime monitor's checks that passed over the last 24h.",
+		}, []string{"tenant", "monitor_id", "monitor", "kind"}),
+		UptimeCheckDurationSeconds: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "otelcontext_uptime_check_duration_seconds",
+			Help: "Duration of an uptime monitor's last check.",
+		}, []string{"tenant", "monitor_id", "monitor", "kind"}),
+		UptimeChecksTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "otelcontext_uptime_checks_total",
+			Help: "Uptime checks run, by kind (http|tcp|icmp) and result (up|down).",
+		}, []string{"kind", "result"}),
 
 		// DB pool (Task 7 — visibility for DB_MAX_OPEN_CONNS sizing).
 		DBPoolOpenConnections: promauto.NewGauge(prometheus.GaugeOpts{
@@ -387,10 +738,30 @@ func New() *Metrics {
 		Name: "otelcontext_dlq_evicted_bytes_total",
 		Help: "Total bytes evicted from DLQ. Rate indicates data-loss volume during backlog.",
 	})
-	m.DashboardP99RowCapHitsTotal = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "otelcontext_dashboard_p99_row_cap_hits_total",
-		Help: "Number of dashboard p99 computations that hit the SQLite row cap (200k). Indicates the dataset is too large for in-memory p99 — use Postgres for prod.",
+	m.DLQQuarantinedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "otelcontext_dlq_quarantined_total",
+		Help: "DLQ batches quarantined after DLQ_MAX_RETRIES failed replays. Each one is a poison batch that needs an operator.",
 	})
+	m.DLQExpiredTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "otelcontext_dlq_expired_total",
+		Help: "DLQ batches removed for being older than DLQ_MAX_AGE. Non-zero means the DB stayed down longer than the DLQ is allowed to cover.",
+	})
+	m.DLQRejectedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "otelcontext_dlq_rejected_total",
+		Help: "Batches refused by a full DLQ under DLQ_OVERFLOW_POLICY=reject_new.",
+	})
+	m.DLQOldestFileAgeSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "otelcontext_dlq_oldest_file_age_seconds",
+		Help: "Age of the oldest unreplayed DLQ batch file. Steady growth means the DB is still rejecting writes.",
+	})
+	m.DLQReplayFailureStreak = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "otelcontext_dlq_replay_failure_streak",
+		Help: "Consecutive failed DLQ replay attempts since the last successful replay.",
+	})
+	m.DLQAlertActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "otelcontext_dlq_alert_active",
+		Help: "1 while a built-in DLQ growth alert is firing, by condition (files|disk|age|replay_failures).",
+	}, []string{"condition"})
 	m.VectorSnapshotWritesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "otelcontext_vectordb_snapshot_writes_total",
 		Help: "Vectordb snapshot write attempts by result (success|failure). Alert on rate(...{result=\"failure\"}[10m]) > 0.",
@@ -488,6 +859,12 @@ func (m *Metrics) RecordIngestion(count int) {
 	m.totalIngested.Add(int64(count))
 }
 
+// TotalIngested returns the spans and logs ingested since the process
+// started.
+func (m *Metrics) TotalIngested() int64 {
+	return m.totalIngested.Load()
+}
+
 // ObserveIngestDuration records an end-to-end OTLP Export latency for the
 // given signal. Callers should pass time.Since(start) measured from the very
 // start of the Export handler. Nil-safe so the OTLP servers can be wired
@@ -499,6 +876,386 @@ func (m *Metrics) ObserveIngestDuration(signal string, d time.Duration) {
 	m.IngestDurationSeconds.WithLabelValues(signal).Observe(d.Seconds())
 }
 
+// RecordIngestRejected adds n rejected records for (signal, reason). Nil-safe
+// so the OTLP servers can run without a Metrics instance in tests.
+func (m *Metrics) RecordIngestRejected(signal, reason string, n int64) {
+	if m == nil || m.IngestRejectedTotal == nil || n <= 0 {
+		return
+	}
+	m.IngestRejectedTotal.WithLabelValues(signal, reason).Add(float64(n))
+}
+
+// RecordJaegerAgentPacket counts one Jaeger agent datagram by result. Nil-safe.
+func (m *Metrics) RecordJaegerAgentPacket(result string) {
+	if m == nil || m.JaegerAgentPacketsTotal == nil {
+		return
+	}
+	m.JaegerAgentPacketsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordJaegerCollectorBatch counts one Jaeger collector batch by transport
+// and result. Nil-safe.
+func (m *Metrics) RecordJaegerCollectorBatch(transport, result string) {
+	if m == nil || m.JaegerCollectorBatchesTotal == nil {
+		return
+	}
+	m.JaegerCollectorBatchesTotal.WithLabelValues(transport, result).Inc()
+}
+
+// RecordZipkinSpans counts n Zipkin spans by result. Nil-safe.
+func (m *Metrics) RecordZipkinSpans(result string, n int) {
+	if m == nil || m.ZipkinSpansTotal == nil || n <= 0 {
+		return
+	}
+	m.ZipkinSpansTotal.WithLabelValues(result).Add(float64(n))
+}
+
+// RecordLokiEntries counts n Loki log entries by result. Nil-safe.
+func (m *Metrics) RecordLokiEntries(result string, n int) {
+	if m == nil || m.LokiEntriesTotal == nil || n <= 0 {
+		return
+	}
+	m.LokiEntriesTotal.WithLabelValues(result).Add(float64(n))
+}
+
+// RecordGitHubWebhookEvent counts one GitHub webhook delivery. Event names
+// outside the handled set are folded into "other" to bound cardinality.
+// Nil-safe.
+func (m *Metrics) RecordGitHubWebhookEvent(event, result string) {
+	if m == nil || m.GitHubWebhookEventsTotal == nil {
+		return
+	}
+	switch event {
+	case "deployment", "deployment_status", "workflow_run", "ping":
+	default:
+		event = "other"
+	}
+	m.GitHubWebhookEventsTotal.WithLabelValues(event, result).Inc()
+}
+
+// RecordDeploymentVerdict counts one judged deployment. Nil-safe.
+func (m *Metrics) RecordDeploymentVerdict(verdict string) {
+	if m == nil || m.DeploymentVerdictsTotal == nil {
+		return
+	}
+	m.DeploymentVerdictsTotal.WithLabelValues(verdict).Inc()
+}
+
+// RecordStatsDLines counts n StatsD lines by result. Nil-safe.
+func (m *Metrics) RecordStatsDLines(result string, n int) {
+	if m == nil || m.StatsDLinesTotal == nil || n <= 0 {
+		return
+	}
+	m.StatsDLinesTotal.WithLabelValues(result).Add(float64(n))
+}
+
+// RecordFirehoseRecords counts n Firehose records by result. Nil-safe.
+func (m *Metrics) RecordFirehoseRecords(result string, n int) {
+	if m == nil || m.FirehoseRecordsTotal == nil || n <= 0 {
+		return
+	}
+	m.FirehoseRecordsTotal.WithLabelValues(result).Add(float64(n))
+}
+
+// RecordLogDrainLines counts n log drain lines by format and result. Nil-safe.
+func (m *Metrics) RecordLogDrainLines(format, result string, n int) {
+	if m == nil || m.LogDrainLinesTotal == nil || n <= 0 {
+		return
+	}
+	m.LogDrainLinesTotal.WithLabelValues(format, result).Add(float64(n))
+}
+
+// RecordTailSamplingDecision counts one tail-sampling decision. Nil-safe.
+func (m *Metrics) RecordTailSamplingDecision(kept bool, policy string) {
+	if m == nil || m.TailSamplingTracesTotal == nil {
+		return
+	}
+	decision := "dropped"
+	if kept {
+		decision = "kept"
+	}
+	m.TailSamplingTracesTotal.WithLabelValues(decision, policy).Inc()
+}
+
+// SetTailSamplingBuffered sets the tail-sampling buffer depth. Nil-safe.
+func (m *Metrics) SetTailSamplingBuffered(n int) {
+	if m == nil || m.TailSamplingBufferedTraces == nil {
+		return
+	}
+	m.TailSamplingBufferedTraces.Set(float64(n))
+}
+
+// RecordSpanNameNormalized counts one span renamed by rule. Nil-safe.
+func (m *Metrics) RecordSpanNameNormalized(rule string) {
+	if m == nil || m.SpanNamesNormalizedTotal == nil {
+		return
+	}
+	m.SpanNamesNormalizedTotal.WithLabelValues(rule).Inc()
+}
+
+// SetSpanNamesDistinct sets the distinct span name count for stage
+// (before|after normalization). Nil-safe.
+func (m *Metrics) SetSpanNamesDistinct(stage string, n int) {
+	if m == nil || m.SpanNamesDistinct == nil {
+		return
+	}
+	m.SpanNamesDistinct.WithLabelValues(stage).Set(float64(n))
+}
+
+// RecordRedaction counts one value or match scrubbed by rule. Nil-safe.
+func (m *Metrics) RecordRedaction(signal, rule string) {
+	if m == nil || m.IngestRedactionsTotal == nil {
+		return
+	}
+	m.IngestRedactionsTotal.WithLabelValues(signal, rule).Inc()
+}
+
+// RecordTransform counts one record changed by processor. Nil-safe.
+func (m *Metrics) RecordTransform(signal, processor string) {
+	if m == nil || m.IngestTransformsTotal == nil {
+		return
+	}
+	m.IngestTransformsTotal.WithLabelValues(signal, processor).Inc()
+}
+
+// RecordConfigReload counts one config file reload. Nil-safe.
+func (m *Metrics) RecordConfigReload(err error) {
+	if m == nil || m.ConfigReloadsTotal == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.ConfigReloadsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordAlertNotification counts one alert notification attempt. Nil-safe.
+func (m *Metrics) RecordAlertNotification(channel string, err error) {
+	if m == nil || m.AlertNotificationsTotal == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.AlertNotificationsTotal.WithLabelValues(channel, result).Inc()
+}
+
+// RecordColdTierRun counts one cold tier pass and the rows it exported per
+// signal. Nil-safe.
+func (m *Metrics) RecordColdTierRun(exported map[string]int64, err error) {
+	if m == nil || m.ColdTierRunsTotal == nil {
+		return
+	}
+	for signal, n := range exported {
+		m.ColdTierRowsExportedTotal.WithLabelValues(signal).Add(float64(n))
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.ColdTierRunsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordExport counts n records of signal handed to the export sink with
+// the given result. Nil-safe.
+func (m *Metrics) RecordExport(signal, result string, n int) {
+	if m == nil || m.ExportRecordsTotal == nil || n <= 0 {
+		return
+	}
+	m.ExportRecordsTotal.WithLabelValues(signal, result).Add(float64(n))
+}
+
+// AlertRuleState is one rule's outcome from an alerting evaluation pass.
+type AlertRuleState struct {
+	Tenant  string
+	RuleID  uint
+	Rule    string
+	Kind    string
+	Service string
+	Firing  bool
+	Value   float64
+}
+
+// SetAlertRuleStates publishes the outcome of a full evaluation pass.
+// Series for rules absent from states (deleted, disabled) are removed.
+// Nil-safe.
+func (m *Metrics) SetAlertRuleStates(states []AlertRuleState) {
+	if m == nil || m.AlertRuleFiring == nil || m.AlertRuleValue == nil {
+		return
+	}
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+	next := make(map[uint]prometheus.Labels, len(states))
+	for _, st := range states {
+		labels := prometheus.Labels{
+			"tenant":  st.Tenant,
+			"rule_id": strconv.FormatUint(uint64(st.RuleID), 10),
+			"rule":    st.Rule,
+			"kind":    st.Kind,
+			"service": st.Service,
+		}
+		if prev, ok := m.alertSeries[st.RuleID]; ok && !maps.Equal(prev, labels) {
+			m.AlertRuleFiring.Delete(prev)
+			m.AlertRuleValue.Delete(prev)
+		}
+		firing := 0.0
+		if st.Firing {
+			firing = 1
+		}
+		m.AlertRuleFiring.With(labels).Set(firing)
+		m.AlertRuleValue.With(labels).Set(st.Value)
+		next[st.RuleID] = labels
+	}
+	for id, labels := range m.alertSeries {
+		if _, ok := next[id]; !ok {
+			m.AlertRuleFiring.Delete(labels)
+			m.AlertRuleValue.Delete(labels)
+		}
+	}
+	m.alertSeries = next
+}
+
+// SLOState is one SLO's status from an alerting evaluation pass.
+type SLOState struct {
+	Tenant          string
+	SLOID           uint
+	SLO             string
+	Service         string
+	BudgetRemaining float64
+	Compliance      float64
+	BurnRate1h      float64
+	BurnRate6h      float64
+}
+
+// SetSLOStates publishes the SLO statuses of a full evaluation pass.
+// Series for SLOs absent from states (deleted) are removed. Nil-safe.
+func (m *Metrics) SetSLOStates(states []SLOState) {
+	if m == nil || m.SLOBudgetRemaining == nil || m.SLOCompliance == nil || m.SLOBurnRate1h == nil || m.SLOBurnRate6h == nil {
+		return
+	}
+	gauges := []*prometheus.GaugeVec{m.SLOBudgetRemaining, m.SLOCompliance, m.SLOBurnRate1h, m.SLOBurnRate6h}
+	m.sloMu.Lock()
+	defer m.sloMu.Unlock()
+	next := make(map[uint]prometheus.Labels, len(states))
+	for _, st := range states {
+		labels := prometheus.Labels{
+			"tenant":  st.Tenant,
+			"slo_id":  strconv.FormatUint(uint64(st.SLOID), 10),
+			"slo":     st.SLO,
+			"service": st.Service,
+		}
+		if prev, ok := m.sloSeries[st.SLOID]; ok && !maps.Equal(prev, labels) {
+			for _, g := range gauges {
+				g.Delete(prev)
+			}
+		}
+		m.SLOBudgetRemaining.With(labels).Set(st.BudgetRemaining)
+		m.SLOCompliance.With(labels).Set(st.Compliance)
+		m.SLOBurnRate1h.With(labels).Set(st.BurnRate1h)
+		m.SLOBurnRate6h.With(labels).Set(st.BurnRate6h)
+		next[st.SLOID] = labels
+	}
+	for id, labels := range m.sloSeries {
+		if _, ok := next[id]; !ok {
+			for _, g := range gauges {
+				g.Delete(labels)
+			}
+		}
+	}
+	m.sloSeries = next
+}
+
+// UptimeCheck is the outcome of one uptime monitor check.
+type UptimeCheck struct {
+	Tenant       string
+	MonitorID    uint
+	Monitor      string
+	Kind         string
+	Up           bool
+	Duration     time.Duration
+	Availability float64 // percent over the last 24h
+}
+
+// RecordUptimeCheck publishes one check of a monitor, replacing its
+// series when the monitor was renamed. Nil-safe.
+func (m *Metrics) RecordUptimeCheck(c UptimeCheck) {
+	if m == nil || m.UptimeMonitorUp == nil {
+		return
+	}
+	labels := prometheus.Labels{
+		"tenant":     c.Tenant,
+		"monitor_id": strconv.FormatUint(uint64(c.MonitorID), 10),
+		"monitor":    c.Monitor,
+		"kind":       c.Kind,
+	}
+	result, up := "down", 0.0
+	if c.Up {
+		result, up = "up", 1
+	}
+	m.uptimeMu.Lock()
+	defer m.uptimeMu.Unlock()
+	if prev, ok := m.uptimeSeries[c.MonitorID]; ok && !maps.Equal(prev, labels) {
+		m.deleteUptimeSeries(prev)
+	}
+	if m.uptimeSeries == nil {
+		m.uptimeSeries = make(map[uint]prometheus.Labels)
+	}
+	m.uptimeSeries[c.MonitorID] = labels
+	m.UptimeMonitorUp.With(labels).Set(up)
+	m.UptimeMonitorAvailability.With(labels).Set(c.Availability)
+	m.UptimeCheckDurationSeconds.With(labels).Set(c.Duration.Seconds())
+	m.UptimeChecksTotal.WithLabelValues(c.Kind, result).Inc()
+}
+
+// RetainUptimeMonitors removes the series of monitors not in active
+// (deleted or disabled). Nil-safe.
+func (m *Metrics) RetainUptimeMonitors(active map[uint]bool) {
+	if m == nil || m.UptimeMonitorUp == nil {
+		return
+	}
+	m.uptimeMu.Lock()
+	defer m.uptimeMu.Unlock()
+	for id, labels := range m.uptimeSeries {
+		if !active[id] {
+			m.deleteUptimeSeries(labels)
+			delete(m.uptimeSeries, id)
+		}
+	}
+}
+
+func (m *Metrics) deleteUptimeSeries(labels prometheus.Labels) {
+	m.UptimeMonitorUp.Delete(labels)
+	m.UptimeMonitorAvailability.Delete(labels)
+	m.UptimeCheckDurationSeconds.Delete(labels)
+}
+
+// RecordAnomaly counts one detected GraphRAG anomaly. Nil-safe.
+func (m *Metrics) RecordAnomaly(tenant, kind, severity string) {
+	if m == nil || m.GraphRAGAnomaliesTotal == nil {
+		return
+	}
+	m.GraphRAGAnomaliesTotal.WithLabelValues(tenant, kind, severity).Inc()
+}
+
+// RecordRetentionCycle sets the rows purged from table in the latest
+// retention pass. Nil-safe.
+func (m *Metrics) RecordRetentionCycle(table string, rows int64) {
+	if m == nil || m.RetentionRowsPurgedLastCycle == nil {
+		return
+	}
+	m.RetentionRowsPurgedLastCycle.WithLabelValues(table).Set(float64(rows))
+}
+
+// RecordTracesRecomputed adds n corrected trace rows for source. Nil-safe.
+func (m *Metrics) RecordTracesRecomputed(source string, n int) {
+	if m == nil || m.TracesRecomputedTotal == nil || n <= 0 {
+		return
+	}
+	m.TracesRecomputedTotal.WithLabelValues(source).Add(float64(n))
+}
+
 func (m *Metrics) SetActiveConnections(n int) {
 	m.ActiveConnections.Set(float64(n))
 	m.activeConns.Store(int64(n))
@@ -539,6 +1296,11 @@ type HealthStats struct {
 	Goroutines     int     `json:"goroutines"`
 	HeapAllocMB    float64 `json:"heap_alloc_mb"`
 	UptimeSeconds  float64 `json:"uptime_seconds"`
+
+	DLQDiskBytes           int64    `json:"dlq_disk_bytes"`
+	DLQOldestAgeSeconds    int64    `json:"dlq_oldest_age_seconds"`
+	DLQReplayFailureStreak int64    `json:"dlq_replay_failure_streak"`
+	Alerts                 []string `json:"alerts,omitempty"` // built-in system alert conditions currently firing
 }
 
 func (m *Metrics) GetHealthStats() HealthStats {
@@ -552,6 +1314,11 @@ func (m *Metrics) GetHealthStats() HealthStats {
 		Goroutines:     runtime.NumGoroutine(),
 		HeapAllocMB:    float64(ms.HeapAlloc) / 1024 / 1024,
 		UptimeSeconds:  time.Since(m.startTime).Seconds(),
+
+		DLQDiskBytes:           m.dlqDiskBytes.Load(),
+		DLQOldestAgeSeconds:    m.dlqOldestAgeSec.Load(),
+		DLQReplayFailureStreak: m.dlqFailureStreak.Load(),
+		Alerts:                 m.DLQAlerts(),
 	}
 }
 
@@ -0,0 +1,75 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanMetricsOtherOperation is the span_name that new operations are
+// counted under once the span metrics series cap is reached.
+const SpanMetricsOtherOperation = "(other)"
+
+// spanMetricsBuckets are the OTel Collector spanmetrics connector's default
+// duration buckets, so dashboards built for it read these histograms the
+// same way.
+var spanMetricsBuckets = []float64{.002, .004, .006, .008, .01, .05, .1, .2, .4, .8, 1, 1.4, 2, 5, 10, 15}
+
+type spanMetricsKey struct {
+	tenant, service, operation string
+}
+
+// spanMetricsLedger tracks the (tenant, service, operation) combinations
+// SpanCallsTotal and SpanDurationSeconds carry, so a span name storm
+// cannot grow the scrape without bound. The zero value is off.
+type spanMetricsLedger struct {
+	mu        sync.Mutex
+	maxSeries int // 0 = span metrics disabled
+	seen      map[spanMetricsKey]struct{}
+}
+
+// EnableSpanMetrics turns on RED metrics from ingested spans, tracking at
+// most maxSeries (tenant, service, operation) combinations. maxSeries <= 0
+// turns them off. Nil-safe.
+func (m *Metrics) EnableSpanMetrics(maxSeries int) {
+	if m == nil {
+		return
+	}
+	l := &m.spanSeries
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxSeries = max(maxSeries, 0)
+}
+
+// RecordSpanMetrics counts one ingested span of tenant's service and
+// operation with its OTLP status code and duration. Operations first seen
+// past the series cap are folded into SpanMetricsOtherOperation. No-op
+// until EnableSpanMetrics. Nil-safe.
+func (m *Metrics) RecordSpanMetrics(tenant, service, operation, status string, d time.Duration) {
+	if m == nil || m.SpanCallsTotal == nil || m.SpanDurationSeconds == nil {
+		return
+	}
+	l := &m.spanSeries
+	k := spanMetricsKey{tenant, service, operation}
+	l.mu.Lock()
+	if l.maxSeries == 0 {
+		l.mu.Unlock()
+		return
+	}
+	if l.seen == nil {
+		l.seen = make(map[spanMetricsKey]struct{})
+	}
+	if _, ok := l.seen[k]; !ok {
+		if len(l.seen) >= l.maxSeries {
+			k.operation = SpanMetricsOtherOperation
+			if m.SpanMetricsOverflowTotal != nil {
+				m.SpanMetricsOverflowTotal.Inc()
+			}
+		} else {
+			l.seen[k] = struct{}{}
+		}
+	}
+	l.mu.Unlock()
+
+	m.SpanCallsTotal.WithLabelValues(k.tenant, k.service, k.operation, status).Inc()
+	m.SpanDurationSeconds.WithLabelValues(k.tenant, k.service, k.operation, status).Observe(d.Seconds())
+}
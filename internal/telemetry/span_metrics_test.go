@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Builds unregistered vectors rather than calling New(), which registers
+// against the global registry and may run only once per test binary.
+func newSpanMetricsTestMetrics() *Metrics {
+	labels := []string{"tenant", "service_name", "span_name", "status_code"}
+	return &Metrics{
+		SpanCallsTotal:           prometheus.NewCounterVec(prometheus.CounterOpts{Name: "calls"}, labels),
+		SpanDurationSeconds:      prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "duration", Buckets: spanMetricsBuckets}, labels),
+		SpanMetricsOverflowTotal: prometheus.NewCounter(prometheus.CounterOpts{Name: "overflow"}),
+	}
+}
+
+func TestRecordSpanMetrics(t *testing.T) {
+	m := newSpanMetricsTestMetrics()
+	m.RecordSpanMetrics("acme", "api", "GET /users", "STATUS_CODE_OK", time.Millisecond)
+	if n := testutil.CollectAndCount(m.SpanCallsTotal); n != 0 {
+		t.Fatalf("recorded %d series while disabled", n)
+	}
+
+	m.EnableSpanMetrics(2)
+	m.RecordSpanMetrics("acme", "api", "GET /users", "STATUS_CODE_OK", 30*time.Millisecond)
+	m.RecordSpanMetrics("acme", "api", "GET /users", "STATUS_CODE_ERROR", 2*time.Second)
+	m.RecordSpanMetrics("acme", "api", "GET /users", "STATUS_CODE_OK", 10*time.Millisecond)
+	m.RecordSpanMetrics("acme", "db", "SELECT", "STATUS_CODE_UNSET", time.Millisecond)
+	m.RecordSpanMetrics("acme", "api", "GET /orders", "STATUS_CODE_OK", time.Millisecond) // past the cap
+
+	if got := testutil.ToFloat64(m.SpanCallsTotal.WithLabelValues("acme", "api", "GET /users", "STATUS_CODE_OK")); got != 2 {
+		t.Errorf("ok calls = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.SpanCallsTotal.WithLabelValues("acme", "api", "GET /users", "STATUS_CODE_ERROR")); got != 1 {
+		t.Errorf("error calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.SpanCallsTotal.WithLabelValues("acme", "api", SpanMetricsOtherOperation, "STATUS_CODE_OK")); got != 1 {
+		t.Errorf("folded calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.SpanMetricsOverflowTotal); got != 1 {
+		t.Errorf("overflow = %v, want 1", got)
+	}
+	if n := testutil.CollectAndCount(m.SpanDurationSeconds); n != 4 {
+		t.Errorf("duration series = %d, want 4", n)
+	}
+
+	var nilMetrics *Metrics
+	nilMetrics.EnableSpanMetrics(10)
+	nilMetrics.RecordSpanMetrics("acme", "api", "op", "STATUS_CODE_OK", time.Millisecond)
+}
@@ -0,0 +1,79 @@
+package tlsbootstrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestServerConfig_MutualTLS verifies a client CA makes the server demand a
+// certificate signed by it.
+func TestServerConfig_MutualTLS(t *testing.T) {
+	serverCert, serverKey, err := EnsureSelfSignedCert(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, clientKey, err := EnsureSelfSignedCert(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCert, otherKey, err := EnsureSelfSignedCert(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srvCfg, err := ServerConfig(serverCert, serverKey, clientCert, tls.RequireAndVerifyClientCert)
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(parseCert(t, serverCert))
+	handshake := func(certFile, keyFile string) error {
+		t.Helper()
+		clientCfg := &tls.Config{RootCAs: roots, ServerName: "localhost", MinVersion: tls.VersionTLS12}
+		if certFile != "" {
+			pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			clientCfg.Certificates = []tls.Certificate{pair}
+		}
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+		srvErr := make(chan error, 1)
+		go func() { srvErr <- tls.Server(c1, srvCfg).Handshake() }()
+		cliErr := tls.Client(c2, clientCfg).Handshake()
+		// With TLS 1.3 the client finishes first; closing its end unblocks
+		// the server's alert write when it rejects the certificate.
+		c2.Close()
+		if err := <-srvErr; err != nil {
+			return err
+		}
+		return cliErr
+	}
+
+	if err := handshake(clientCert, clientKey); err != nil {
+		t.Errorf("trusted client cert: %v", err)
+	}
+	if err := handshake("", ""); err == nil {
+		t.Error("handshake without a client cert succeeded")
+	}
+	if err := handshake(otherCert, otherKey); err == nil {
+		t.Error("handshake with an untrusted client cert succeeded")
+	}
+
+	if cfg, err := ServerConfig(serverCert, serverKey, "", tls.RequireAndVerifyClientCert); err != nil || cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("no client CA = %v, %v; want plain TLS", cfg, err)
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ServerConfig(serverCert, serverKey, notPEM, tls.RequireAndVerifyClientCert); err == nil {
+		t.Error("a client CA file without certificates should fail")
+	}
+}
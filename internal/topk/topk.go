@@ -0,0 +1,300 @@
+// Package topk keeps approximate heavy-hitter counts — the noisiest
+// services, routes and error messages — over a sliding window, so "what is
+// spamming us right now" is answered from memory instead of a table scan.
+package topk
+
+import (
+	"container/heap"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Dimension names a heavy-hitter table.
+type Dimension string
+
+// Tracked dimensions.
+const (
+	// Services counts spans, logs and metric points per service.
+	Services Dimension = "services"
+	// Routes counts spans per service and operation.
+	Routes Dimension = "routes"
+	// Errors counts error logs per service and normalized message.
+	Errors Dimension = "errors"
+)
+
+// Dimensions lists every tracked dimension in response order.
+var Dimensions = []Dimension{Services, Routes, Errors}
+
+// Indexes into bucket.dims, matching Dimensions.
+const (
+	idxServices = iota
+	idxRoutes
+	idxErrors
+)
+
+const (
+	// BucketWidth is the granularity of the sliding window.
+	BucketWidth = time.Minute
+	// MaxWindow is the longest window Top can answer.
+	MaxWindow  = 15 * time.Minute
+	numBuckets = int(MaxWindow / BucketWidth)
+
+	// defaultCapacity is the number of counters per dimension per bucket.
+	// Space-Saving guarantees every key with more than 1/capacity of a
+	// bucket's events is kept.
+	defaultCapacity = 64
+	// defaultMaxTenants caps how many tenants are tracked; events of further
+	// tenants are dropped until a tracked one goes idle for MaxWindow.
+	defaultMaxTenants = 256
+	// maxKeyLen truncates keys so one huge operation name or message cannot
+	// pin memory.
+	maxKeyLen = 256
+)
+
+// Item is one heavy hitter. Count may overstate the true count by at most
+// Error (Space-Saving never understates a key it kept).
+type Item struct {
+	Service string `json:"service"`
+	Name    string `json:"name,omitempty"` // operation or message; empty for Services
+	Count   int64  `json:"count"`
+	Error   int64  `json:"error"`
+}
+
+// Tracker maintains per-tenant Space-Saving summaries in one-minute buckets.
+// All methods are safe for concurrent use and nil-safe.
+type Tracker struct {
+	capacity   int
+	maxTenants int
+	now        func() time.Time // injectable clock for tests
+
+	mu      sync.Mutex
+	tenants map[string]*tenantWindow
+}
+
+// tenantWindow is a ring of buckets; bucket i holds minute m where
+// m % numBuckets == i.
+type tenantWindow struct {
+	buckets [numBuckets]bucket
+	last    int64 // newest minute written
+}
+
+type bucket struct {
+	minute int64
+	dims   [3]*summary // idxServices, idxRoutes, idxErrors
+}
+
+// New creates an empty Tracker.
+func New() *Tracker {
+	return &Tracker{
+		capacity:   defaultCapacity,
+		maxTenants: defaultMaxTenants,
+		now:        time.Now,
+		tenants:    make(map[string]*tenantWindow),
+	}
+}
+
+// RecordService counts one span, log or metric point of service.
+func (t *Tracker) RecordService(tenant, service string) {
+	t.record(tenant, idxServices, service, "")
+}
+
+// RecordRoute counts one span of service's operation.
+func (t *Tracker) RecordRoute(tenant, service, operation string) {
+	t.record(tenant, idxRoutes, service, operation)
+}
+
+// RecordLog counts one log toward its service and, for ERROR and FATAL
+// severities (in any spelling containing ERR or FATAL), toward its message.
+func (t *Tracker) RecordLog(tenant, service, severity, body string) {
+	t.RecordService(tenant, service)
+	if upper := strings.ToUpper(severity); strings.Contains(upper, "ERR") || strings.Contains(upper, "FATAL") {
+		t.RecordError(tenant, service, body)
+	}
+}
+
+// RecordError counts one error log of service; message is normalized so
+// messages differing only in IDs and numbers count together.
+func (t *Tracker) RecordError(tenant, service, message string) {
+	if t == nil {
+		return
+	}
+	t.record(tenant, idxErrors, service, storage.NormalizeErrorMessage(message))
+}
+
+func (t *Tracker) record(tenant string, dim int, service, name string) {
+	if t == nil || service == "" {
+		return
+	}
+	if tenant == "" {
+		tenant = storage.DefaultTenantID
+	}
+	key := truncate(service) + "\x00" + truncate(name)
+	minute := t.now().Unix() / int64(BucketWidth/time.Second)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.tenants[tenant]
+	if w == nil {
+		if len(t.tenants) >= t.maxTenants && !t.evictIdle(minute) {
+			return
+		}
+		w = &tenantWindow{}
+		t.tenants[tenant] = w
+	}
+	b := &w.buckets[minute%int64(numBuckets)]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	if b.dims[dim] == nil {
+		b.dims[dim] = newSummary(t.capacity)
+	}
+	b.dims[dim].add(key)
+	w.last = max(w.last, minute)
+}
+
+// evictIdle drops one tenant with nothing inside the window. Caller holds mu.
+func (t *Tracker) evictIdle(minute int64) bool {
+	for tenant, w := range t.tenants {
+		if minute-w.last >= int64(numBuckets) {
+			delete(t.tenants, tenant)
+			return true
+		}
+	}
+	return false
+}
+
+// Top returns the k heaviest hitters of dim for tenant over the window
+// ending now, heaviest first. window is rounded up to whole buckets and
+// clamped to [BucketWidth, MaxWindow].
+func (t *Tracker) Top(tenant string, dim Dimension, window time.Duration, k int) []Item {
+	out := []Item{}
+	if t == nil || k <= 0 {
+		return out
+	}
+	idx := slices.Index(Dimensions, dim)
+	if idx < 0 {
+		return out
+	}
+	if tenant == "" {
+		tenant = storage.DefaultTenantID
+	}
+	n := int64((min(max(window, BucketWidth), MaxWindow) + BucketWidth - 1) / BucketWidth)
+	minute := t.now().Unix() / int64(BucketWidth/time.Second)
+
+	merged := make(map[string]*Item)
+	t.mu.Lock()
+	if w := t.tenants[tenant]; w != nil {
+		for m := minute - n + 1; m <= minute; m++ {
+			b := &w.buckets[m%int64(numBuckets)]
+			if b.minute != m || b.dims[idx] == nil {
+				continue
+			}
+			for _, c := range b.dims[idx].counters {
+				it := merged[c.key]
+				if it == nil {
+					it = &Item{}
+					it.Service, it.Name = splitKey(c.key)
+					merged[c.key] = it
+				}
+				it.Count += c.count
+				it.Error += c.err
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	for _, it := range merged {
+		out = append(out, *it)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+func splitKey(key string) (service, name string) {
+	service, name, _ = strings.Cut(key, "\x00")
+	return service, name
+}
+
+func truncate(s string) string {
+	if len(s) > maxKeyLen {
+		return s[:maxKeyLen]
+	}
+	return s
+}
+
+// summary is a Space-Saving stream summary: at most capacity counters; a
+// new key evicts the smallest counter and inherits its count as error.
+type summary struct {
+	capacity int
+	index    map[string]*counter
+	counters counterHeap // min-heap by count
+}
+
+type counter struct {
+	key   string
+	count int64
+	err   int64
+	pos   int // heap index
+}
+
+func newSummary(capacity int) *summary {
+	return &summary{capacity: capacity, index: make(map[string]*counter, capacity)}
+}
+
+func (s *summary) add(key string) {
+	if c, ok := s.index[key]; ok {
+		c.count++
+		heap.Fix(&s.counters, c.pos)
+		return
+	}
+	if len(s.counters) < s.capacity {
+		c := &counter{key: key, count: 1}
+		s.index[key] = c
+		heap.Push(&s.counters, c)
+		return
+	}
+	c := s.counters[0]
+	delete(s.index, c.key)
+	c.key, c.err = key, c.count
+	c.count++
+	s.index[key] = c
+	heap.Fix(&s.counters, 0)
+}
+
+type counterHeap []*counter
+
+func (h counterHeap) Len() int           { return len(h) }
+func (h counterHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos, h[j].pos = i, j
+}
+
+func (h *counterHeap) Push(x any) {
+	c := x.(*counter)
+	c.pos = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *counterHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
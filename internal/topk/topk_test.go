@@ -0,0 +1,97 @@
+package topk
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTracker_TopWindowAndTenants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 30, 0, time.UTC)
+	tr := New()
+	tr.now = func() time.Time { return now }
+
+	for range 5 {
+		tr.RecordService("acme", "checkout")
+	}
+	tr.RecordService("globex", "search")
+	tr.RecordRoute("acme", "checkout", "POST /cart")
+	tr.RecordError("acme", "payment", "timeout after 3012ms on order 8812")
+	tr.RecordLog("acme", "payment", "error", "timeout after 998ms on order 17")
+	tr.RecordLog("acme", "payment", "INFO", "charged")
+
+	// Ten minutes later checkout is quiet and payment is loud.
+	now = now.Add(10 * time.Minute)
+	for range 3 {
+		tr.RecordService("acme", "payment")
+	}
+
+	got := tr.Top("acme", Services, 5*time.Minute, 10)
+	if len(got) != 1 || got[0].Service != "payment" || got[0].Count != 3 {
+		t.Errorf("5m window = %+v, want payment x3 only", got)
+	}
+	got = tr.Top("acme", Services, MaxWindow, 1)
+	if len(got) != 1 || got[0].Service != "checkout" || got[0].Count != 5 {
+		t.Errorf("15m top 1 = %+v, want checkout x5", got)
+	}
+	if got := tr.Top("acme", Errors, time.Hour, 10); len(got) != 1 || got[0].Count != 2 || got[0].Name != "timeout after <n>ms on order <n>" {
+		t.Errorf("errors = %+v, want one normalized message x2", got)
+	}
+	if got := tr.Top("acme", Routes, time.Hour, 10); len(got) != 1 || got[0].Name != "POST /cart" {
+		t.Errorf("routes = %+v", got)
+	}
+	if got := tr.Top("globex", Services, MaxWindow, 10); len(got) != 1 || got[0].Service != "search" {
+		t.Errorf("globex = %+v", got)
+	}
+	if got := tr.Top("acme", "bogus", MaxWindow, 10); len(got) != 0 {
+		t.Errorf("unknown dimension = %+v", got)
+	}
+
+	// Past the window everything has aged out.
+	now = now.Add(MaxWindow)
+	if got := tr.Top("acme", Services, MaxWindow, 10); len(got) != 0 {
+		t.Errorf("after window = %+v, want none", got)
+	}
+}
+
+func TestSummary_SpaceSaving(t *testing.T) {
+	s := newSummary(4)
+	// A heavy hitter survives a long tail of one-off keys.
+	for i := range 1000 {
+		s.add("hot")
+		s.add("cold-" + strconv.Itoa(i))
+	}
+	if len(s.counters) != 4 || len(s.index) != 4 {
+		t.Fatalf("summary grew past capacity: %d counters", len(s.counters))
+	}
+	c := s.index["hot"]
+	if c == nil || c.count-c.err > 1000 || c.count < 1000 {
+		t.Errorf("hot = %+v, want count >= 1000 >= count-err", c)
+	}
+}
+
+func TestTracker_MaxTenants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := New()
+	tr.now = func() time.Time { return now }
+	tr.maxTenants = 2
+	tr.RecordService("a", "svc")
+	tr.RecordService("b", "svc")
+	tr.RecordService("c", "svc")
+	if got := tr.Top("c", Services, MaxWindow, 10); len(got) != 0 {
+		t.Errorf("tenant past the cap tracked: %+v", got)
+	}
+	// Once a tenant is idle for the whole window its slot is reused.
+	now = now.Add(MaxWindow)
+	tr.RecordService("b", "svc")
+	tr.RecordService("c", "svc")
+	if got := tr.Top("c", Services, MaxWindow, 10); len(got) != 1 {
+		t.Errorf("tenant not admitted after idle eviction: %+v", got)
+	}
+
+	var nilTracker *Tracker
+	nilTracker.RecordService("a", "svc")
+	if got := nilTracker.Top("a", Services, MaxWindow, 10); len(got) != 0 {
+		t.Errorf("nil tracker = %+v", got)
+	}
+}
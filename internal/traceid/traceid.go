@@ -0,0 +1,113 @@
+// Package traceid maps OTLP trace IDs to the canonical string form stored in
+// the database and used for lookups.
+//
+// W3C trace context IDs are 16 bytes. Some older SDKs (Jaeger/Zipkin B3
+// clients) still emit 8-byte IDs; stored verbatim those become 16-char hex
+// strings that never join against the 32-char form another hop propagates.
+// The default codec left-pads 64-bit IDs with zeros — the same rule the W3C
+// and B3 specs use when interoperating — so every signal stores one spelling.
+package traceid
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// Codec turns wire trace IDs into the canonical stored form. Swap the
+// process-wide codec with SetDefault at startup, before any ingest.
+type Codec interface {
+	// Encode returns the canonical string for a wire trace ID and whether
+	// the ID is valid under this codec. The string is returned even when
+	// invalid so lenient ingest can still store the record.
+	Encode(raw []byte) (string, bool)
+	// Parse normalizes a user-supplied trace ID (API path, query filter).
+	Parse(s string) (string, bool)
+	// Variants returns every stored spelling that must match canonical on
+	// lookup, canonical first.
+	Variants(canonical string) []string
+}
+
+// W3C is the default codec: lowercase hex, 128-bit canonical form. With
+// Allow64Bit, 8-byte IDs are accepted and zero-padded to 128 bits;
+// otherwise they are reported invalid and encoded unpadded.
+type W3C struct {
+	Allow64Bit bool
+}
+
+const (
+	len64  = 8
+	len128 = 16
+)
+
+// Encode implements Codec.
+func (c W3C) Encode(raw []byte) (string, bool) {
+	switch {
+	case len(raw) == len128:
+		return hex.EncodeToString(raw), !allZero(raw)
+	case len(raw) == len64 && c.Allow64Bit:
+		return strings.Repeat("0", 2*len64) + hex.EncodeToString(raw), !allZero(raw)
+	}
+	return hex.EncodeToString(raw), false
+}
+
+// Parse implements Codec. Accepts 16 or 32 hex digits in any case.
+func (c W3C) Parse(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return s, false
+	}
+	return c.Encode(raw)
+}
+
+// Variants implements Codec. A padded 64-bit ID also matches its unpadded
+// spelling, so rows stored before normalization stay reachable.
+func (c W3C) Variants(canonical string) []string {
+	if c.Allow64Bit && len(canonical) == 2*len128 && strings.HasPrefix(canonical, strings.Repeat("0", 2*len64)) {
+		return []string{canonical, canonical[2*len64:]}
+	}
+	return []string{canonical}
+}
+
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultCodec atomic.Pointer[Codec]
+
+func init() {
+	SetDefault(W3C{Allow64Bit: true})
+}
+
+// SetDefault installs c as the process-wide codec.
+func SetDefault(c Codec) {
+	defaultCodec.Store(&c)
+}
+
+// Default returns the process-wide codec.
+func Default() Codec {
+	return *defaultCodec.Load()
+}
+
+// Encode encodes raw with the default codec.
+func Encode(raw []byte) (string, bool) { return Default().Encode(raw) }
+
+// Parse normalizes s with the default codec.
+func Parse(s string) (string, bool) { return Default().Parse(s) }
+
+// Variants returns the lookup spellings of s under the default codec. s is
+// normalized first; an unparseable s is returned as its only variant.
+func Variants(s string) []string {
+	c := Default()
+	canonical, ok := c.Parse(s)
+	if !ok {
+		return []string{s}
+	}
+	return c.Variants(canonical)
+}
@@ -0,0 +1,66 @@
+package traceid
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestW3C_Encode(t *testing.T) {
+	c := W3C{Allow64Bit: true}
+	cases := []struct {
+		name  string
+		raw   []byte
+		want  string
+		valid bool
+	}{
+		{"128-bit", bytes.Repeat([]byte{0xAB}, 16), "abababababababababababababababab", true},
+		{"64-bit padded", bytes.Repeat([]byte{0xCD}, 8), "0000000000000000cdcdcdcdcdcdcdcd", true},
+		{"zero", make([]byte, 16), "00000000000000000000000000000000", false},
+		{"empty", nil, "", false},
+		{"odd length", []byte{1, 2, 3}, "010203", false},
+	}
+	for _, tc := range cases {
+		got, ok := c.Encode(tc.raw)
+		if got != tc.want || ok != tc.valid {
+			t.Errorf("%s: Encode = (%q, %v), want (%q, %v)", tc.name, got, ok, tc.want, tc.valid)
+		}
+	}
+
+	strict := W3C{}
+	if got, ok := strict.Encode(bytes.Repeat([]byte{0xCD}, 8)); ok || got != "cdcdcdcdcdcdcdcd" {
+		t.Errorf("strict 64-bit: Encode = (%q, %v), want unpadded and invalid", got, ok)
+	}
+}
+
+func TestW3C_ParseAndVariants(t *testing.T) {
+	c := W3C{Allow64Bit: true}
+	for _, in := range []string{"CDCDCDCDCDCDCDCD", " cdcdcdcdcdcdcdcd ", "0000000000000000CDCDCDCDCDCDCDCD"} {
+		got, ok := c.Parse(in)
+		if !ok || got != "0000000000000000cdcdcdcdcdcdcdcd" {
+			t.Errorf("Parse(%q) = (%q, %v)", in, got, ok)
+		}
+	}
+	if _, ok := c.Parse("not-hex"); ok {
+		t.Error("Parse(not-hex): want invalid")
+	}
+
+	if got := c.Variants("0000000000000000cdcdcdcdcdcdcdcd"); !slices.Equal(got, []string{"0000000000000000cdcdcdcdcdcdcdcd", "cdcdcdcdcdcdcdcd"}) {
+		t.Errorf("padded Variants = %v", got)
+	}
+	if got := c.Variants("abababababababababababababababab"); len(got) != 1 {
+		t.Errorf("128-bit Variants = %v, want just the canonical id", got)
+	}
+}
+
+func TestVariants_UsesDefaultCodec(t *testing.T) {
+	t.Cleanup(func() { SetDefault(W3C{Allow64Bit: true}) })
+
+	if got := Variants("CDCDCDCDCDCDCDCD"); len(got) != 2 {
+		t.Errorf("default codec: Variants = %v, want padded + unpadded", got)
+	}
+	SetDefault(W3C{})
+	if got := Variants("CDCDCDCDCDCDCDCD"); !slices.Equal(got, []string{"CDCDCDCDCDCDCDCD"}) {
+		t.Errorf("strict codec: Variants = %v, want input unchanged", got)
+	}
+}
@@ -22,6 +22,25 @@ type RawMetric struct {
 	// TenantID identifies the owning tenant for this point. When empty the
 	// DB default ("default") applies at persist time.
 	TenantID string
+	// Exemplar links the point to a sampled trace; nil when it has none.
+	Exemplar *Exemplar
+}
+
+// Exemplar is a trace that contributed to a metric point. A window keeps
+// the highest-value exemplar it receives, so latency spikes link to their
+// slowest sampled trace.
+type Exemplar struct {
+	TraceID string
+	SpanID  string
+	Value   float64
+}
+
+// keep stores e as b's exemplar when it beats the current one.
+func (e *Exemplar) keep(b *storage.MetricBucket) {
+	if e == nil || (b.ExemplarTraceID != "" && e.Value <= b.ExemplarValue) {
+		return
+	}
+	b.ExemplarTraceID, b.ExemplarSpanID, b.ExemplarValue = e.TraceID, e.SpanID, e.Value
 }
 
 // Aggregator manages in-memory tumbling windows for metrics.
@@ -242,6 +261,7 @@ func (a *Aggregator) Ingest(m RawMetric) {
 				Count:          1,
 				AttributesJSON: storage.CompressedText(attrJSON),
 			}
+			m.Exemplar.keep(bucket)
 			a.buckets[key] = bucket
 			a.seriesPerTenant[m.TenantID]++
 			a.mu.Unlock()
@@ -257,6 +277,7 @@ func (a *Aggregator) Ingest(m RawMetric) {
 	}
 	bucket.Sum += m.Value
 	bucket.Count++
+	m.Exemplar.keep(bucket)
 	a.mu.Unlock()
 
 	if overflowTenant != "" && a.cardinalityOverflow != nil {
@@ -275,3 +275,31 @@ func TestAggregator_OverflowBucketStatsCorrect(t *testing.T) {
 		t.Fatalf("BucketCount=%d, want 2", got)
 	}
 }
+
+// TestAggregator_KeepsHighestExemplar verifies a window keeps the
+// highest-value exemplar across its points, and that points without one
+// leave it alone.
+func TestAggregator_KeepsHighestExemplar(t *testing.T) {
+	a := NewAggregator(nil, time.Minute)
+	for _, ex := range []*Exemplar{
+		{TraceID: "t-low", SpanID: "s1", Value: 10},
+		{TraceID: "t-high", SpanID: "s2", Value: 90},
+		nil,
+		{TraceID: "t-mid", SpanID: "s3", Value: 50},
+	} {
+		m := newRawMetric("tenant-a", "svc", "latency", 1)
+		m.Exemplar = ex
+		a.Ingest(m)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.buckets) != 1 {
+		t.Fatalf("buckets = %d, want 1", len(a.buckets))
+	}
+	for _, b := range a.buckets {
+		if b.Count != 4 || b.ExemplarTraceID != "t-high" || b.ExemplarSpanID != "s2" || b.ExemplarValue != 90 {
+			t.Errorf("bucket = %+v, want exemplar t-high/s2 at 90", b)
+		}
+	}
+}
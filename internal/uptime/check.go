@@ -0,0 +1,165 @@
+package uptime
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// maxErrorLen caps the error stored with a result, matching the column.
+const maxErrorLen = 512
+
+// userAgent identifies HTTP checks in the target's access logs.
+const userAgent = "OtelContext-Uptime/1.0"
+
+// check runs one check of m and returns its result; CheckedAt is the start.
+func (s *Scheduler) check(ctx context.Context, m *storage.Monitor) storage.MonitorResult {
+	timeout := time.Duration(m.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res := storage.MonitorResult{CheckedAt: s.now().UTC()}
+	start := time.Now()
+	var err error
+	switch m.Kind {
+	case storage.MonitorKindHTTP:
+		res.StatusCode, err = s.checkHTTP(ctx, m)
+	case storage.MonitorKindTCP:
+		err = checkTCP(ctx, m.Target)
+	case storage.MonitorKindICMP:
+		err = checkICMP(ctx, m.Target)
+	default:
+		err = fmt.Errorf("unknown monitor kind %q", m.Kind)
+	}
+	res.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	res.Up = err == nil
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		// The text may echo a remote peer; keep it valid UTF-8 for the column.
+		res.Error = storage.TruncateUTF8(strings.ToValidUTF8(err.Error(), "\uFFFD"), maxErrorLen)
+	}
+	return res
+}
+
+// checkHTTP GETs the target. The check passes on ExpectedStatus, or on any
+// 2xx/3xx when unset; redirects are not followed.
+func (s *Scheduler) checkHTTP(ctx context.Context, m *storage.Monitor) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.Target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// Drain a little so keep-alive connections can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	code := resp.StatusCode
+	switch {
+	case m.ExpectedStatus != 0 && code != m.ExpectedStatus:
+		return code, fmt.Errorf("status %d, want %d", code, m.ExpectedStatus)
+	case m.ExpectedStatus == 0 && (code < 200 || code > 399):
+		return code, fmt.Errorf("status %d", code)
+	}
+	return code, nil
+}
+
+func checkTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkICMP sends one echo request to host and waits for the reply. It uses
+// an unprivileged ICMP datagram socket (Linux net.ipv4.ping_group_range,
+// macOS) and falls back to a raw socket, which needs CAP_NET_RAW.
+func checkICMP(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("no address for %s", host)
+	}
+	ip := ips[0].IP
+	for _, a := range ips {
+		if a.IP.To4() != nil {
+			ip = a.IP
+			break
+		}
+	}
+
+	v4 := ip.To4() != nil
+	dgram, raw, listenAddr, proto := "udp6", "ip6:ipv6-icmp", "::", 58
+	var echo, reply icmp.Type = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	if v4 {
+		dgram, raw, listenAddr, proto = "udp4", "ip4:icmp", "0.0.0.0", 1
+		echo, reply = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	}
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	conn, err := icmp.ListenPacket(dgram, listenAddr)
+	if err != nil {
+		var rerr error
+		if conn, rerr = icmp.ListenPacket(raw, listenAddr); rerr != nil {
+			return fmt.Errorf("open ICMP socket (allow unprivileged ping via net.ipv4.ping_group_range or grant CAP_NET_RAW): %w", err)
+		}
+		dst = &net.IPAddr{IP: ip}
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// Datagram sockets rewrite the echo ID, so replies are matched on the
+	// sequence number and a random payload.
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	seq := int(token[0])<<8 | int(token[1])
+	msg := icmp.Message{Type: echo, Body: &icmp.Echo{ID: seq, Seq: seq, Data: token}}
+	wire, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(wire, dst); err != nil {
+		return err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		got, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || got.Type != reply {
+			continue
+		}
+		if e, ok := got.Body.(*icmp.Echo); ok && e.Seq == seq && bytes.Equal(e.Data, token) {
+			return nil
+		}
+	}
+}
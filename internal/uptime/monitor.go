@@ -0,0 +1,82 @@
+// Package uptime runs synthetic checks — HTTP requests, TCP connects and
+// ICMP echoes — against the monitors defined through /api/monitors, stores
+// every result and keeps each monitor's status, which the alerting engine
+// reads for monitor_down rules.
+package uptime
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Interval and timeout bounds of a monitor.
+const (
+	defaultInterval = time.Minute
+	minInterval     = 10 * time.Second
+	maxInterval     = 24 * time.Hour
+
+	defaultTimeout = 10 * time.Second
+	minTimeout     = 100 * time.Millisecond
+	maxTimeout     = time.Minute
+)
+
+// ValidateMonitor checks a user-supplied monitor and fills defaults.
+func ValidateMonitor(m *storage.Monitor) error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(m.Name, "\r\n") {
+		return errors.New("name must be a single line")
+	}
+	m.Target = strings.TrimSpace(m.Target)
+	if m.Target == "" {
+		return errors.New("target is required")
+	}
+	m.ServiceName = strings.TrimSpace(m.ServiceName)
+	switch m.Kind {
+	case storage.MonitorKindHTTP:
+		u, err := url.Parse(m.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("target must be an http(s) URL for http monitors")
+		}
+		if m.ExpectedStatus != 0 && (m.ExpectedStatus < 100 || m.ExpectedStatus > 599) {
+			return errors.New("expected_status must be an HTTP status code")
+		}
+	case storage.MonitorKindTCP:
+		host, port, err := net.SplitHostPort(m.Target)
+		if n, perr := strconv.Atoi(port); err != nil || host == "" || perr != nil || n < 1 || n > 65535 {
+			return errors.New("target must be host:port for tcp monitors")
+		}
+		m.ExpectedStatus = 0
+	case storage.MonitorKindICMP:
+		if strings.ContainsAny(m.Target, "/:@ ") && net.ParseIP(m.Target) == nil {
+			return errors.New("target must be a host name or IP address for icmp monitors")
+		}
+		m.ExpectedStatus = 0
+	default:
+		return fmt.Errorf("kind must be %s, %s or %s", storage.MonitorKindHTTP, storage.MonitorKindTCP, storage.MonitorKindICMP)
+	}
+	if m.IntervalSecs == 0 {
+		m.IntervalSecs = int(defaultInterval.Seconds())
+	}
+	interval := time.Duration(m.IntervalSecs) * time.Second
+	if interval < minInterval || interval > maxInterval {
+		return fmt.Errorf("interval_seconds must be between %d and %d", int(minInterval.Seconds()), int(maxInterval.Seconds()))
+	}
+	if m.TimeoutMs == 0 {
+		m.TimeoutMs = int(min(defaultTimeout, interval/2).Milliseconds())
+	}
+	timeout := time.Duration(m.TimeoutMs) * time.Millisecond
+	if timeout < minTimeout || timeout > maxTimeout || timeout >= interval {
+		return fmt.Errorf("timeout_ms must be between %d and %d and shorter than the interval", minTimeout.Milliseconds(), maxTimeout.Milliseconds())
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package uptime
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
+)
+
+const (
+	// tick is how often the scheduler looks for due monitors; a check runs
+	// at most this late.
+	tick = 5 * time.Second
+	// maxConcurrentChecks bounds the checks in flight across all monitors.
+	maxConcurrentChecks = 32
+	// pruneEvery is how often results past the retention are deleted.
+	pruneEvery = 24 * time.Hour
+)
+
+// Scheduler runs every enabled monitor at its interval. Checks run
+// concurrently; a monitor never has two checks in flight. Each result is
+// stored with the monitor's new status: up, the consecutive failure count
+// monitor_down rules alert on and the 24h availability.
+type Scheduler struct {
+	repo    *storage.Repository
+	metrics *telemetry.Metrics
+	client  *http.Client
+	keep    time.Duration
+
+	// now is swapped out by tests.
+	now func() time.Time
+
+	sem       chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	next      map[uint]time.Time // next due time per monitor
+	running   map[uint]bool
+	lastPrune time.Time
+
+	started  atomic.Bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates a scheduler. metrics may be nil.
+func New(repo *storage.Repository, metrics *telemetry.Metrics) *Scheduler {
+	return &Scheduler{
+		repo:    repo,
+		metrics: metrics,
+		client: &http.Client{
+			// Report redirects as they are rather than checking the target.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		now:     time.Now,
+		sem:     make(chan struct{}, maxConcurrentChecks),
+		next:    make(map[uint]time.Time),
+		running: make(map[uint]bool),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetRetention prunes results older than keep daily. 0 keeps everything.
+// Must be called before Start.
+func (s *Scheduler) SetRetention(keep time.Duration) {
+	s.keep = keep
+}
+
+// Start runs the scheduling loop.
+func (s *Scheduler) Start() {
+	if s.started.CompareAndSwap(false, true) {
+		go s.loop()
+	}
+}
+
+// Stop ends the loop and waits for checks in flight. Safe without Start.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		if s.started.Load() {
+			<-s.done
+		}
+	})
+}
+
+func (s *Scheduler) loop() {
+	defer close(s.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		s.wg.Wait()
+	}()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	s.RunDue(ctx)
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue starts a check of every enabled monitor that is due and not
+// already being checked, without waiting for them. A monitor seen for the
+// first time is due one interval after its last stored check.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	monitors, err := s.repo.ListEnabledMonitorsAllTenants(ctx)
+	if err != nil {
+		slog.Error("Uptime: failed to load monitors", "error", err)
+		return
+	}
+	now := s.now()
+	active := make(map[uint]bool, len(monitors))
+	s.mu.Lock()
+	for i := range monitors {
+		m := monitors[i]
+		active[m.ID] = true
+		interval := time.Duration(m.IntervalSecs) * time.Second
+		if interval <= 0 {
+			interval = defaultInterval
+		}
+		due, seen := s.next[m.ID]
+		if !seen && m.Status.CheckedAt != nil {
+			due = m.Status.CheckedAt.Add(interval)
+		}
+		if s.running[m.ID] || now.Before(due) {
+			continue
+		}
+		s.next[m.ID] = now.Add(interval)
+		s.running[m.ID] = true
+		s.wg.Add(1)
+		go s.run(ctx, &m)
+	}
+	for id := range s.next {
+		if !active[id] {
+			delete(s.next, id)
+		}
+	}
+	prune := s.keep > 0 && now.Sub(s.lastPrune) >= pruneEvery
+	if prune {
+		s.lastPrune = now
+	}
+	s.mu.Unlock()
+	s.metrics.RetainUptimeMonitors(active)
+
+	if prune {
+		if n, err := s.repo.PruneMonitorResults(ctx, now.Add(-s.keep)); err != nil {
+			slog.Warn("Uptime: failed to prune results", "error", err)
+		} else if n > 0 {
+			slog.Info("Uptime: pruned old results", "deleted", n)
+		}
+	}
+}
+
+// run checks m once and stores the result and the monitor's new status.
+func (s *Scheduler) run(ctx context.Context, m *storage.Monitor) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, m.ID)
+		s.mu.Unlock()
+	}()
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		return
+	}
+
+	res := s.check(ctx, m)
+	if ctx.Err() != nil {
+		// Shutting down: a cancelled check is not an outage.
+		return
+	}
+	avail, err := s.repo.MonitorAvailabilitySince(ctx, m.ID, res.CheckedAt.Add(-24*time.Hour))
+	if err != nil {
+		slog.Warn("Uptime: failed to compute availability", "monitor_id", m.ID, "error", err)
+	}
+	avail.Checks++
+	if res.Up {
+		avail.Up++
+	}
+	failures := 0
+	if !res.Up {
+		failures = m.Status.ConsecutiveFailures + 1
+	}
+	m.Status = storage.MonitorStatus{
+		CheckedAt:           &res.CheckedAt,
+		Up:                  res.Up,
+		LatencyMs:           res.LatencyMs,
+		StatusCode:          res.StatusCode,
+		Error:               res.Error,
+		ConsecutiveFailures: failures,
+		Availability24h:     100 * float64(avail.Up) / float64(avail.Checks),
+	}
+	if err := s.repo.SaveMonitorResult(ctx, m, &res); err != nil {
+		slog.Warn("Uptime: failed to save result", "monitor_id", m.ID, "error", err)
+	}
+	if !res.Up && failures == 1 {
+		slog.Info("📉 Uptime check failed", "tenant", m.TenantID, "monitor", m.Name, "target", m.Target, "error", res.Error)
+	}
+	s.metrics.RecordUptimeCheck(telemetry.UptimeCheck{
+		Tenant:       m.TenantID,
+		MonitorID:    m.ID,
+		Monitor:      m.Name,
+		Kind:         m.Kind,
+		Up:           res.Up,
+		Duration:     time.Duration(res.LatencyMs * float64(time.Millisecond)),
+		Availability: m.Status.Availability24h,
+	})
+}
@@ -0,0 +1,197 @@
+package uptime
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+func newTestRepo(t *testing.T) *storage.Repository {
+	t.Helper()
+	db, err := storage.NewDatabase("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	if err := storage.AutoMigrateModels(db, "sqlite"); err != nil {
+		t.Fatalf("AutoMigrateModels: %v", err)
+	}
+	repo := storage.NewRepositoryFromDB(db, "sqlite")
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func TestScheduler_ChecksAndStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := closed.Addr().String()
+	_ = closed.Close()
+	defer lis.Close()
+
+	monitors := map[string]*storage.Monitor{
+		"http ok":        {Kind: storage.MonitorKindHTTP, Target: srv.URL + "/ok"},
+		"http 500":       {Kind: storage.MonitorKindHTTP, Target: srv.URL + "/fail"},
+		"http redirect":  {Kind: storage.MonitorKindHTTP, Target: srv.URL + "/moved", ExpectedStatus: http.StatusFound},
+		"tcp open":       {Kind: storage.MonitorKindTCP, Target: lis.Addr().String()},
+		"tcp refused":    {Kind: storage.MonitorKindTCP, Target: closedAddr},
+		"disabled check": {Kind: storage.MonitorKindTCP, Target: closedAddr, Disabled: true},
+	}
+	for name, m := range monitors {
+		m.Name = name
+		if err := ValidateMonitor(m); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := repo.CreateMonitor(ctx, m); err != nil {
+			t.Fatalf("CreateMonitor: %v", err)
+		}
+	}
+
+	now := time.Now()
+	s := New(repo, nil)
+	s.now = func() time.Time { return now }
+	runDue := func() {
+		s.RunDue(context.Background())
+		s.wg.Wait()
+	}
+	runDue()
+	runDue() // nothing is due again within the interval
+	now = now.Add(time.Minute)
+	runDue()
+
+	want := map[string]struct {
+		up       bool
+		failures int
+		errPart  string
+	}{
+		"http ok":       {up: true},
+		"http 500":      {failures: 2, errPart: "status 500"},
+		"http redirect": {up: true},
+		"tcp open":      {up: true},
+		"tcp refused":   {failures: 2, errPart: "refused"},
+	}
+	for name, w := range want {
+		m, err := repo.GetMonitor(ctx, monitors[name].ID)
+		if err != nil {
+			t.Fatalf("GetMonitor: %v", err)
+		}
+		st := m.Status
+		if st.CheckedAt == nil || st.Up != w.up || st.ConsecutiveFailures != w.failures || !strings.Contains(st.Error, w.errPart) {
+			t.Errorf("%s: status = %+v, want up=%v failures=%d error~%q", name, st, w.up, w.failures, w.errPart)
+		}
+		if res, _ := repo.ListMonitorResults(ctx, m.ID, 0); len(res) != 2 {
+			t.Errorf("%s: %d results, want 2", name, len(res))
+		}
+	}
+	if m, _ := repo.GetMonitor(ctx, monitors["http ok"].ID); m.Status.Availability24h != 100 || m.Status.StatusCode != 200 {
+		t.Errorf("http ok status = %+v", m.Status)
+	}
+	if m, _ := repo.GetMonitor(ctx, monitors["disabled check"].ID); m.Status.CheckedAt != nil {
+		t.Error("disabled monitor was checked")
+	}
+}
+
+func TestScheduler_FirstCheckFollowsStoredStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := storage.WithTenantContext(context.Background(), "acme")
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	m := &storage.Monitor{Name: "db", Kind: storage.MonitorKindTCP, Target: lis.Addr().String(), IntervalSecs: 60, TimeoutMs: 1000}
+	if err := repo.CreateMonitor(ctx, m); err != nil {
+		t.Fatalf("CreateMonitor: %v", err)
+	}
+	now := time.Now().UTC()
+	checked := now.Add(-30 * time.Second)
+	m.Status = storage.MonitorStatus{CheckedAt: &checked, Up: true}
+	if err := repo.SaveMonitorResult(context.Background(), m, &storage.MonitorResult{CheckedAt: checked, Up: true}); err != nil {
+		t.Fatalf("SaveMonitorResult: %v", err)
+	}
+
+	// After a restart the check is due one interval after the stored one.
+	s := New(repo, nil)
+	s.now = func() time.Time { return now }
+	s.RunDue(context.Background())
+	s.wg.Wait()
+	if res, _ := repo.ListMonitorResults(ctx, m.ID, 0); len(res) != 1 {
+		t.Fatalf("%d results, want the stored check only", len(res))
+	}
+	now = now.Add(30 * time.Second)
+	s.RunDue(context.Background())
+	s.wg.Wait()
+	if res, _ := repo.ListMonitorResults(ctx, m.ID, 0); len(res) != 2 {
+		t.Errorf("%d results, want a new check once due", len(res))
+	}
+}
+
+func TestCheck_ErrorRuneBoundary(t *testing.T) {
+	s := New(newTestRepo(t), nil)
+	// Byte maxErrorLen of "unknown monitor kind \"xéé…" is mid-rune.
+	res := s.check(context.Background(), &storage.Monitor{Kind: "x" + strings.Repeat("é", 300)})
+	if res.Up || !utf8.ValidString(res.Error) || len(res.Error) != maxErrorLen-1 {
+		t.Errorf("error truncated to %d bytes, valid UTF-8 = %v", len(res.Error), utf8.ValidString(res.Error))
+	}
+}
+
+func TestCheckICMP_Loopback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := checkICMP(ctx, "127.0.0.1")
+	if err != nil && strings.Contains(err.Error(), "open ICMP socket") {
+		t.Skipf("ICMP sockets unavailable here: %v", err)
+	}
+	if err != nil {
+		t.Errorf("ping 127.0.0.1: %v", err)
+	}
+}
+
+func TestValidateMonitor(t *testing.T) {
+	ok := storage.Monitor{Name: " api ", Kind: storage.MonitorKindHTTP, Target: "https://api.example.com/health"}
+	if err := ValidateMonitor(&ok); err != nil || ok.Name != "api" || ok.IntervalSecs != 60 || ok.TimeoutMs != 10000 {
+		t.Fatalf("valid monitor: %v (%+v)", err, ok)
+	}
+	short := storage.Monitor{Name: "x", Kind: storage.MonitorKindICMP, Target: "10.0.0.1", IntervalSecs: 10}
+	if err := ValidateMonitor(&short); err != nil || short.TimeoutMs != 5000 {
+		t.Errorf("short interval: %v, timeout %d; want half the interval", err, short.TimeoutMs)
+	}
+	for name, m := range map[string]storage.Monitor{
+		"no name":          {Kind: storage.MonitorKindHTTP, Target: "https://x"},
+		"multiline name":   {Name: "a\nb", Kind: storage.MonitorKindHTTP, Target: "https://x"},
+		"bad kind":         {Name: "x", Kind: "dns", Target: "x"},
+		"no target":        {Name: "x", Kind: storage.MonitorKindTCP},
+		"file url":         {Name: "x", Kind: storage.MonitorKindHTTP, Target: "file:///etc/passwd"},
+		"bad status":       {Name: "x", Kind: storage.MonitorKindHTTP, Target: "https://x", ExpectedStatus: 42},
+		"tcp without port": {Name: "x", Kind: storage.MonitorKindTCP, Target: "db.internal"},
+		"tcp bad port":     {Name: "x", Kind: storage.MonitorKindTCP, Target: "db:99999"},
+		"icmp url":         {Name: "x", Kind: storage.MonitorKindICMP, Target: "https://x"},
+		"fast interval":    {Name: "x", Kind: storage.MonitorKindTCP, Target: "db:5432", IntervalSecs: 1},
+		"timeout too long": {Name: "x", Kind: storage.MonitorKindTCP, Target: "db:5432", IntervalSecs: 10, TimeoutMs: 10000},
+	} {
+		if err := ValidateMonitor(&m); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
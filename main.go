@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -17,19 +19,28 @@ import (
 	"github.com/RandomCodeSpace/central-ops/pkg/version"
 
 	"github.com/RandomCodeSpace/otelcontext/internal/ai"
+	"github.com/RandomCodeSpace/otelcontext/internal/alerting"
 	"github.com/RandomCodeSpace/otelcontext/internal/api"
+	"github.com/RandomCodeSpace/otelcontext/internal/coldtier"
 	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/export"
+	"github.com/RandomCodeSpace/otelcontext/internal/featureflag"
 	"github.com/RandomCodeSpace/otelcontext/internal/graph"
 	"github.com/RandomCodeSpace/otelcontext/internal/graphrag"
 	"github.com/RandomCodeSpace/otelcontext/internal/ingest"
 	"github.com/RandomCodeSpace/otelcontext/internal/mcp"
+	"github.com/RandomCodeSpace/otelcontext/internal/oidc"
 	"github.com/RandomCodeSpace/otelcontext/internal/queue"
 	"github.com/RandomCodeSpace/otelcontext/internal/realtime"
+	"github.com/RandomCodeSpace/otelcontext/internal/selfhistory"
 	"github.com/RandomCodeSpace/otelcontext/internal/storage"
 	"github.com/RandomCodeSpace/otelcontext/internal/telemetry"
 	tlsbootstrap "github.com/RandomCodeSpace/otelcontext/internal/tls"
+	"github.com/RandomCodeSpace/otelcontext/internal/topk"
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
 	"github.com/RandomCodeSpace/otelcontext/internal/tsdb"
 	"github.com/RandomCodeSpace/otelcontext/internal/ui"
+	"github.com/RandomCodeSpace/otelcontext/internal/uptime"
 	"github.com/RandomCodeSpace/otelcontext/internal/vectordb"
 
 	"runtime/debug"
@@ -106,6 +117,10 @@ func fatal(msg string, err error, kv ...any) {
 
 func main() {
 	versionFlag := flag.Bool("version", false, "print version and exit")
+	migrateStorage := flag.Bool("migrate-storage", false, "copy all data from DB_DRIVER/DB_DSN to -migrate-to-driver/-migrate-to-dsn and exit; rerun to resume")
+	migrateToDriver := flag.String("migrate-to-driver", "", "destination driver for -migrate-storage: sqlite, postgres or mysql")
+	migrateToDSN := flag.String("migrate-to-dsn", os.Getenv("MIGRATE_TO_DB_DSN"), "destination DSN for -migrate-storage (default $MIGRATE_TO_DB_DSN)")
+	migrateBatchSize := flag.Int("migrate-batch-size", storage.DefaultMigrationBatchSize, "rows per batch for -migrate-storage")
 	flag.Parse()
 
 	if *versionFlag {
@@ -164,6 +179,13 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
+	if *migrateStorage {
+		if err := runStorageMigration(cfg, *migrateToDriver, *migrateToDSN, *migrateBatchSize); err != nil {
+			fatal("storage migration failed", err)
+		}
+		os.Exit(0)
+	}
+
 	slog.Info("🚀 Starting OtelContext", "version", Version, "env", cfg.Env, "log_level", level)
 
 	// 1. Initialize Internal Telemetry (first — everything registers metrics against this)
@@ -188,7 +210,26 @@ func main() {
 	if err != nil {
 		fatal("Failed to initialize repository", err)
 	}
-	slog.Info("💾 Storage initialized", "driver", cfg.DBDriver)
+	repo.SetRegion(cfg.Region)
+	if cfg.SpanAttributeIndexKeys != "" {
+		repo.SetAttributeIndex(strings.Split(cfg.SpanAttributeIndexKeys, ","))
+		slog.Info("🏷️ Span attribute index enabled", "keys", cfg.SpanAttributeIndexKeys)
+	}
+	slog.Info("💾 Storage initialized", "driver", cfg.DBDriver, "region", cfg.Region)
+
+	// Settings changed through PATCH /api/config survive restarts: they
+	// apply over the environment and config file before anything reads them.
+	if overrides, err := repo.RuntimeSettings(context.Background()); err != nil {
+		slog.Warn("Failed to load runtime config overrides", "error", err)
+	} else if len(overrides) > 0 {
+		next, err := cfg.WithRuntimeOverrides(overrides)
+		if err != nil {
+			slog.Error("Ignoring invalid runtime config overrides", "error", err)
+		} else {
+			cfg = next
+			slog.Info("⚙️ Runtime config overrides applied", "settings", len(overrides))
+		}
+	}
 
 	// 2a. Retention scheduler: hourly batched purge + daily VACUUM/ANALYZE.
 	ctxRetention, cancelRetention := context.WithCancel(context.Background())
@@ -198,8 +239,17 @@ func main() {
 		cfg.RetentionBatchSize,
 		time.Duration(cfg.RetentionBatchSleepMs)*time.Millisecond,
 	)
+	retentionPolicy, tenantWindows := retentionSettings(cfg)
+	retention.SetPolicy(retentionPolicy)
+	retention.SetTenantWindows(tenantWindows)
 	retention.Start(ctxRetention)
-	slog.Info("🧹 Retention scheduler started", "retention_days", cfg.HotRetentionDays)
+	slog.Info("🧹 Retention scheduler started",
+		"retention_days", cfg.HotRetentionDays,
+		"traces", retentionPolicy.Traces,
+		"logs", retentionPolicy.Logs,
+		"metrics", retentionPolicy.Metrics,
+		"tenant_overrides", len(tenantWindows),
+	)
 
 	// 2b. Partition scheduler: only when DB_POSTGRES_PARTITIONING=daily.
 	// Maintains lookahead daily partitions and drops expired ones — DROP
@@ -208,7 +258,13 @@ func main() {
 	var cancelPartitions context.CancelFunc = func() {}
 	if cfg.DBPostgresPartitioning == storage.PartitioningModeDaily {
 		ctxPart, cancelPart := context.WithCancel(context.Background())
-		partitionScheduler = storage.NewPartitionScheduler(repo, cfg.HotRetentionDays, cfg.DBPartitionLookaheadDays)
+		// Partitions are dropped whole days at a time; round a sub-day
+		// RETENTION_LOGS up so no row is dropped early.
+		logsRetentionDays := cfg.HotRetentionDays
+		if retentionPolicy.Logs > 0 {
+			logsRetentionDays = int((retentionPolicy.Logs + 24*time.Hour - 1) / (24 * time.Hour))
+		}
+		partitionScheduler = storage.NewPartitionScheduler(repo, logsRetentionDays, cfg.DBPartitionLookaheadDays)
 		if metrics != nil {
 			partitionScheduler.SetMetrics(
 				func(n int) {
@@ -225,7 +281,80 @@ func main() {
 		}
 		partitionScheduler.Start(ctxPart)
 		cancelPartitions = cancelPart
-		slog.Info("📦 Partition scheduler started", "lookahead_days", cfg.DBPartitionLookaheadDays, "retention_days", cfg.HotRetentionDays)
+		slog.Info("📦 Partition scheduler started", "lookahead_days", cfg.DBPartitionLookaheadDays, "retention_days", logsRetentionDays)
+	}
+
+	// 2c. SQLite shard scheduler: only when DB_SQLITE_SHARDING=daily. Moves
+	// closed days of logs into per-day files and deletes expired files.
+	var shardScheduler *storage.SQLiteShardScheduler
+	if cfg.DBSQLiteSharding == storage.PartitioningModeDaily {
+		logsRetentionDays := cfg.HotRetentionDays
+		if retentionPolicy.Logs > 0 {
+			logsRetentionDays = int((retentionPolicy.Logs + 24*time.Hour - 1) / (24 * time.Hour))
+		}
+		shardScheduler = storage.NewSQLiteShardScheduler(repo, cfg.DBSQLiteShardDir, logsRetentionDays)
+		if metrics != nil {
+			shardScheduler.SetMetrics(
+				func(n int) {
+					if metrics.PartitionsDropped != nil {
+						metrics.PartitionsDropped.Add(float64(n))
+					}
+				},
+				func(n int) {
+					if metrics.PartitionsActive != nil {
+						metrics.PartitionsActive.Set(float64(n))
+					}
+				},
+			)
+		}
+		if err := shardScheduler.Start(context.Background()); err != nil {
+			fatal("failed to start SQLite shard scheduler", err)
+		}
+		slog.Info("📦 SQLite shard scheduler started", "dir", cfg.DBSQLiteShardDir, "retention_days", logsRetentionDays)
+	}
+
+	// 2d. Cold tier: only when COLD_TIER_URL is set. Moves traces and logs
+	// older than COLD_TIER_AFTER to Parquet segments, readable via /api/cold.
+	var coldTier *coldtier.Tier
+	if cfg.ColdTierURL != "" {
+		store, err := coldtier.OpenStore(coldtier.StoreConfig{
+			URL:             cfg.ColdTierURL,
+			Endpoint:        cfg.ColdTierS3Endpoint,
+			Region:          cfg.ColdTierS3Region,
+			PathStyle:       cfg.ColdTierS3PathStyle,
+			AccessKeyID:     cfg.ColdTierAccessKeyID,
+			SecretAccessKey: cfg.ColdTierSecretAccessKey,
+			SessionToken:    cfg.ColdTierSessionToken,
+		})
+		if err != nil {
+			fatal("open cold tier store", err)
+		}
+		after, interval := cfg.ColdTierWindows()
+		coldTier = coldtier.New(repo, store, coldtier.Options{After: after, Interval: interval, Metrics: metrics})
+		coldTier.Start(context.Background())
+		slog.Info("🧊 Cold tier started", "after", after, "interval", interval, "logs", !repo.LogsSharded())
+	}
+
+	// 2e. Export sink: only when EXPORT_URL is set. Republishes ingested
+	// spans, logs and metric points to Kafka or NATS, fed from the ingest
+	// callbacks below. nil otherwise; its methods are nil-safe.
+	var sink *export.Exporter
+	if cfg.ExportURL != "" {
+		pub, err := export.Open(export.SinkConfig{URL: cfg.ExportURL, TLSCAFile: cfg.ExportTLSCAFile})
+		if err != nil {
+			fatal("open export sink", err)
+		}
+		sink = export.New(pub, export.Options{
+			TracesTopic:   cfg.ExportTracesTopic,
+			LogsTopic:     cfg.ExportLogsTopic,
+			MetricsTopic:  cfg.ExportMetricsTopic,
+			BatchSize:     cfg.ExportBatchSize,
+			FlushInterval: cfg.ExportFlushEvery(),
+			QueueSize:     cfg.ExportQueueSize,
+			Metrics:       metrics,
+		})
+		sink.Start(context.Background())
+		slog.Info("📤 Export sink started", "traces_topic", cfg.ExportTracesTopic, "logs_topic", cfg.ExportLogsTopic, "metrics_topic", cfg.ExportMetricsTopic)
 	}
 
 	// 3. Initialize DLQ (Dead Letter Queue)
@@ -235,47 +364,14 @@ func main() {
 	}
 
 	dlq, err := queue.NewDLQWithLimits(cfg.DLQPath, replayInterval, func(data []byte) error {
-		// Replay handler: typed envelope supports logs, spans, traces, and metrics
-		var envelope struct {
-			Type string          `json:"type"`
-			Data json.RawMessage `json:"data"`
-		}
-		if err := json.Unmarshal(data, &envelope); err != nil {
-			// Legacy format: try to deserialize as []storage.Log
-			var logs []storage.Log
-			if json.Unmarshal(data, &logs) != nil {
-				return fmt.Errorf("DLQ replay unmarshal failed: %w", err)
-			}
-			return repo.BatchCreateLogs(logs)
+		b, err := decodeDLQBatch(data)
+		if err != nil {
+			return err
 		}
-		switch envelope.Type {
-		case "logs":
-			var logs []storage.Log
-			if err := json.Unmarshal(envelope.Data, &logs); err != nil {
-				return fmt.Errorf("DLQ replay logs unmarshal failed: %w", err)
-			}
-			return repo.BatchCreateLogs(logs)
-		case "spans":
-			var spans []storage.Span
-			if err := json.Unmarshal(envelope.Data, &spans); err != nil {
-				return fmt.Errorf("DLQ replay spans unmarshal failed: %w", err)
-			}
-			return repo.BatchCreateSpans(spans)
-		case "traces":
-			var traces []storage.Trace
-			if err := json.Unmarshal(envelope.Data, &traces); err != nil {
-				return fmt.Errorf("DLQ replay traces unmarshal failed: %w", err)
-			}
-			return repo.BatchCreateTraces(traces)
-		case "metrics":
-			var metrics []storage.MetricBucket
-			if err := json.Unmarshal(envelope.Data, &metrics); err != nil {
-				return fmt.Errorf("DLQ replay metrics unmarshal failed: %w", err)
-			}
-			return repo.BatchCreateMetrics(metrics)
-		default:
-			return fmt.Errorf("DLQ replay: unknown type %q", envelope.Type)
+		if len(b.Metrics) > 0 {
+			return repo.BatchCreateMetrics(b.Metrics)
 		}
+		return repo.BatchCreateAll(b.Traces, b.Spans, b.Logs)
 	}, cfg.DLQMaxFiles, int64(cfg.DLQMaxDiskMB), cfg.DLQMaxRetries)
 	if err != nil {
 		fatal("Failed to initialize DLQ", err)
@@ -288,8 +384,48 @@ func main() {
 	)
 	dlq.SetTelemetryMetrics(metrics)
 	dlq.SetMaxReplayPerTick(cfg.DLQMaxReplayPerTick)
+	dlq.SetReplayConcurrency(cfg.DLQReplayConcurrency)
+	// Coalesced groups go through BatchCreateAll in one transaction, so a
+	// failed group leaves nothing behind when its files replay alone.
+	dlq.SetCoalesce(func(batches [][]byte) error {
+		var all dlqBatch
+		for _, data := range batches {
+			b, err := decodeDLQBatch(data)
+			if err != nil {
+				return err
+			}
+			if len(b.Metrics) > 0 {
+				return errors.New("DLQ replay: metric batches are not coalesced")
+			}
+			all.Traces = append(all.Traces, b.Traces...)
+			all.Spans = append(all.Spans, b.Spans...)
+			all.Logs = append(all.Logs, b.Logs...)
+		}
+		return repo.BatchCreateAll(all.Traces, all.Spans, all.Logs)
+	}, cfg.DLQCoalesceFiles, int64(cfg.DLQCoalesceMaxBytes))
+	dlq.SetMaxBytes(int64(cfg.DLQMaxBytes))
+	if cfg.DLQMaxAge != "" {
+		maxAge, _ := config.ParseRetentionWindow(cfg.DLQMaxAge) // validated in cfg.Validate()
+		dlq.SetMaxAge(maxAge)
+	}
+	dlq.SetOverflowPolicy(cfg.DLQOverflowPolicy)
+	dlqAlerts := telemetry.DLQAlertThresholds{
+		Files:         cfg.DLQAlertFiles,
+		FailureStreak: int64(cfg.DLQAlertFailureStreak),
+	}
+	if cfg.DLQByteLimit() > 0 && cfg.DLQAlertDiskPct > 0 {
+		dlqAlerts.Bytes = cfg.DLQByteLimit() * int64(cfg.DLQAlertDiskPct) / 100
+	}
+	if age, err := time.ParseDuration(cfg.DLQAlertMaxAge); err == nil {
+		dlqAlerts.OldestAge = age
+	} else {
+		slog.Warn("Invalid DLQ_ALERT_MAX_AGE, age alert disabled", "value", cfg.DLQAlertMaxAge)
+	}
+	metrics.SetDLQAlertThresholds(dlqAlerts)
 	slog.Info("🔁 DLQ initialized", "path", cfg.DLQPath, "interval", replayInterval,
-		"max_replay_per_tick", cfg.DLQMaxReplayPerTick)
+		"max_replay_per_tick", cfg.DLQMaxReplayPerTick, "replay_concurrency", cfg.DLQReplayConcurrency,
+		"coalesce_files", cfg.DLQCoalesceFiles, "max_bytes", cfg.DLQByteLimit(),
+		"max_age", cfg.DLQMaxAge, "overflow_policy", cfg.DLQOverflowPolicy)
 
 	// 4. Initialize Real-Time WebSocket Hub
 	hub := realtime.NewHub(func(count int) {
@@ -297,9 +433,17 @@ func main() {
 	})
 	hub.SetDevMode(cfg.DevMode)
 	hub.SetMaxClients(cfg.WSMaxClients)
+	wsPingInterval, _ := time.ParseDuration(cfg.WSPingInterval) // validated in cfg.Validate()
+	wsIdleTimeout, _ := time.ParseDuration(cfg.WSIdleTimeout)
+	hub.SetKeepalive(wsPingInterval, wsIdleTimeout)
+	hub.SetReadLimit(int64(cfg.WSReadLimitBytes))
+	hub.SetCompression(cfg.WSCompressionEnabled("zstd"), cfg.WSCompressionEnabled("deflate"))
+	hubTuning, _ := cfg.HubTuning() // validated in cfg.Validate()
+	hub.SetFlushTuning(hubTuning.BufferSize, hubTuning.FlushInterval)
 	hub.SetWSMetrics(
 		func(msgType string) { metrics.WSMessagesSent.WithLabelValues(msgType).Inc() },
 		func() { metrics.WSSlowClientsRemoved.Inc() },
+		func() { metrics.WSStaleClientsReaped.Inc() },
 	)
 	go hub.Run()
 	slog.Info("🔌 WebSocket hub started")
@@ -456,12 +600,130 @@ func main() {
 	aiCtx, aiCancel := context.WithCancel(appCtx)
 	aiService := ai.NewService(repo)
 	aiService.SetParentContext(aiCtx)
+	aiService.SetTraceInsightCallback(func(in storage.TraceInsight) {
+		hub.BroadcastTraceInsight(realtime.TraceInsightEntry{
+			TraceID:     in.TraceID,
+			ServiceName: in.ServiceName,
+			Summary:     string(in.Summary),
+			CreatedAt:   in.CreatedAt,
+			TenantID:    in.TenantID,
+		})
+	})
+
+	// Feature flags: a subsystem's own switch first, then FEATURE_FLAGS
+	// (validated in cfg.Validate()) on top.
+	flags := featureflag.New()
+	if cfg.TailSamplingEnabled {
+		_ = flags.Set(featureflag.TailSampling, true, featureflag.SourceConfig)
+	}
+	flagOverrides, _ := featureflag.ParseSpec(cfg.FeatureFlags)
+	for name, on := range flagOverrides {
+		_ = flags.Set(name, on, featureflag.SourceEnv)
+	}
+	slog.Info("🚩 Feature flags", "flags", flags.Values())
 
 	// 6. Initialize API Server
 	apiServer := api.NewServer(repo, hub, eventHub, metrics)
+	apiServer.SetFeatureFlags(flags)
 	apiServer.SetGraph(svcGraph)
 	apiServer.SetGraphRAG(graphRAG)
 	apiServer.SetVectorIndex(vectorIdx)
+	apiServer.SetAIService(aiService)
+	traceStreams := realtime.NewTraceStreams()
+	apiServer.SetTraceStreams(traceStreams)
+	heavyHitters := topk.New()
+	apiServer.SetTopK(heavyHitters)
+	apiServer.SetConfig(cfg)
+	apiServer.SetColdTier(coldTier)
+
+	// Managed API keys (API_KEYS_ENABLED). Legacy API_KEY / tenant-file keys
+	// are folded in as admin keys so existing deployments keep working and
+	// can mint their first managed key.
+	var keyAuth *api.APIKeyAuth
+	if cfg.APIKeysEnabled {
+		keyAuth = api.NewAPIKeyAuth(repo)
+		keyAuth.SetSharedKey(cfg.APIKey)
+		if cfg.APITenantKeysFile != "" {
+			entries, err := api.LoadTenantKeys(cfg.APITenantKeysFile)
+			if err != nil {
+				fatal("load tenant keys file", err, "path", cfg.APITenantKeysFile)
+			}
+			keyAuth.SetTenantKeys(api.NewTenantKeyAuth(entries))
+		}
+		if cfg.APIKey == "" && cfg.APITenantKeysFile == "" {
+			hasAdmin, err := repo.HasAdminAPIKey(context.Background())
+			if err != nil {
+				fatal("check for admin API keys", err)
+			}
+			if !hasAdmin {
+				fatal("API_KEYS_ENABLED needs a bootstrap credential", errors.New("no admin API key exists"),
+					"hint", "set API_KEY or API_TENANT_KEYS_FILE to create the first key via POST /api/keys")
+			}
+		}
+		apiServer.SetAPIKeyAuth(keyAuth)
+	}
+
+	// Ingest credentials (INGEST_AUTH_FILE): bearer or basic auth on the
+	// OTLP gRPC receiver and /v1/*, optionally pinned to a tenant and a
+	// service allowlist. Managed keys with the ingest scope stay valid.
+	var ingestAuth *ingest.Authenticator
+	if cfg.IngestAuthFile != "" {
+		entries, err := ingest.LoadAuthFile(cfg.IngestAuthFile)
+		if err != nil {
+			fatal("load ingest auth file", err, "path", cfg.IngestAuthFile)
+		}
+		ingestAuth = ingest.NewAuthenticator(entries)
+		if keyAuth != nil {
+			ingestAuth.SetBearerFallback(func(ctx context.Context, token string) (string, []string, bool) {
+				p, ok := keyAuth.Authenticate(ctx, token)
+				if !ok || !p.Allows(storage.APIKeyScopeIngest) {
+					return "", nil, false
+				}
+				return p.Tenant, nil, true
+			})
+		}
+		ingestAuth.SetFailureHook(func(transport, reason string) {
+			metrics.IngestAuthFailuresTotal.WithLabelValues(transport, reason).Inc()
+		})
+		slog.Info("🔑 Ingest authentication enabled", "credentials", len(entries))
+	}
+
+	// Browser sign-in (OIDC_ISSUER_URL) or AUTH_DEV_BYPASS. Signed-in sessions
+	// reach the API and /ws without a key; API keys keep working alongside.
+	var oidcAuth *api.OIDCAuth
+	switch {
+	case cfg.AuthDevBypass:
+		oidcAuth = api.NewDevBypassAuth()
+		slog.Warn("AUTH_DEV_BYPASS is on — every UI/API request is treated as a signed-in developer; never use it in production")
+	case cfg.OIDCEnabled():
+		provider, err := oidc.Discover(context.Background(), oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopeList(),
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+		})
+		if err != nil {
+			fatal("OIDC provider discovery", err, "issuer", cfg.OIDCIssuerURL)
+		}
+		roles := api.RoleMapping{Admins: cfg.RBACAdminList(), Editors: cfg.RBACEditorList()}
+		if !strings.EqualFold(cfg.RBACDefaultRole, "none") {
+			roles.Default, _ = api.ParseRole(cfg.RBACDefaultRole)
+		}
+		oidcAuth = api.NewOIDCAuth(provider, api.OIDCOptions{
+			SessionSecret:  cfg.OIDCSessionSecret,
+			SessionTTL:     cfg.OIDCSessionDuration(),
+			AllowedDomains: cfg.OIDCAllowedDomainList(),
+			SecureCookies:  strings.HasPrefix(cfg.OIDCRedirectURL, "https://"),
+			Roles:          roles,
+		})
+		slog.Info("🔐 OIDC sign-in enabled", "issuer", cfg.OIDCIssuerURL)
+	}
+	if oidcAuth != nil {
+		oidcAuth.SetAcceptAPIKeys(keyAuth != nil || cfg.APITenantKeysFile != "" || cfg.APIKey != "")
+		apiServer.SetOIDCAuth(oidcAuth)
+	}
 
 	// 6b. Initialize MCP Server (HTTP Streamable, JSON-RPC 2.0 + SSE)
 	mcpServer := mcp.New(cfg.DefaultTenant, repo, metrics, svcGraph, vectorIdx)
@@ -479,32 +741,151 @@ func main() {
 	)
 
 	// 7. Initialize OTLP Ingestion (gRPC)
+	// Trace ID canonical form must be fixed before the first Export so ingest
+	// and lookups agree on one spelling.
+	traceid.SetDefault(traceid.W3C{Allow64Bit: cfg.TraceIDAccept64Bit})
 	traceServer := ingest.NewTraceServer(repo, metrics, cfg)
 	logsServer := ingest.NewLogsServer(repo, metrics, cfg)
 	metricsServer := ingest.NewMetricsServer(repo, metrics, tsdbAgg, cfg)
 
-	// Wire adaptive sampler (only when rate < 1.0 to avoid unnecessary overhead)
-	if cfg.SamplingRate > 0 && cfg.SamplingRate < 1.0 {
-		sampler := ingest.NewSampler(cfg.SamplingRate, cfg.SamplingAlwaysOnErrors, float64(cfg.SamplingLatencyThresholdMs))
+	// Wire adaptive sampler (only when rate < 1.0 or services have their own
+	// rate, to avoid unnecessary overhead)
+	if sampler := buildSampler(cfg); sampler != nil {
 		traceServer.SetSampler(sampler)
 		slog.Info("🎯 Adaptive trace sampling enabled",
 			"rate", cfg.SamplingRate,
+			"service_rates", cfg.SamplingServiceRates,
 			"always_errors", cfg.SamplingAlwaysOnErrors,
 			"latency_threshold_ms", cfg.SamplingLatencyThresholdMs,
 		)
 	}
 
+	// Wire per-service ingestion rate limits. Records over a service's
+	// limit are rejected as rate_limited via OTLP partial_success.
+	spanLimiter, logLimiter := buildRateLimiters(cfg)
+	if spanLimiter != nil {
+		traceServer.SetRateLimiter(spanLimiter)
+		slog.Info("🚦 Per-service span rate limits enabled", "limits", cfg.IngestSpanRateLimits)
+	}
+	if logLimiter != nil {
+		logsServer.SetRateLimiter(logLimiter)
+		slog.Info("🚦 Per-service log rate limits enabled", "limits", cfg.IngestLogRateLimits)
+	}
+
+	// Wire strict validation (opt-in). Rejects impossible spans/logs at the
+	// receiver so garbage never reaches aggregates; rejects are reported via
+	// OTLP partial_success and otelcontext_ingest_rejected_total{reason}.
+	if cfg.IngestStrictValidation {
+		skew, err := time.ParseDuration(cfg.IngestMaxFutureSkew)
+		if err != nil || skew <= 0 {
+			slog.Warn("Invalid INGEST_MAX_FUTURE_SKEW, using default", "value", cfg.IngestMaxFutureSkew, "default", "10m")
+			skew = 10 * time.Minute
+		}
+		validator := ingest.NewValidator(skew)
+		traceServer.SetValidator(validator)
+		logsServer.SetValidator(validator)
+		slog.Info("🧪 Strict ingest validation enabled", "max_future_skew", skew)
+	}
+
+	// Drop health-check probe traffic (opt-in); counted under
+	// otelcontext_ingest_rejected_total{reason="health_check"}.
+	if cfg.IngestDropHealthChecks {
+		if hc := ingest.NewHealthCheckFilter(cfg.IngestHealthCheckRoutes, cfg.IngestHealthCheckUserAgents); hc != nil {
+			traceServer.SetHealthCheckFilter(hc)
+			logsServer.SetHealthCheckFilter(hc)
+			slog.Info("🩺 Health-check telemetry filter enabled",
+				"routes", cfg.IngestHealthCheckRoutes, "user_agents", cfg.IngestHealthCheckUserAgents)
+		}
+	}
+
+	// Per-service span rewrite rules (status overrides, canonical
+	// error.type). A broken rules file fails startup rather than silently
+	// storing unrewritten spans.
+	if cfg.IngestRewriteRulesFile != "" {
+		rw, err := ingest.LoadSpanRewriter(cfg.IngestRewriteRulesFile)
+		if err != nil {
+			fatal("load span rewrite rules", err, "path", cfg.IngestRewriteRulesFile)
+		}
+		traceServer.SetSpanRewriter(rw)
+		slog.Info("✏️ Span rewrite rules loaded", "path", cfg.IngestRewriteRulesFile, "rules", rw.Rules())
+	}
+
+	// Span name normalization (raw URLs with IDs → templates), so the
+	// operations dimension stays bounded. Fails startup like the rewrite
+	// rules.
+	if cfg.IngestSpanNameRulesFile != "" {
+		sn, err := ingest.LoadSpanNameNormalizer(cfg.IngestSpanNameRulesFile, metrics)
+		if err != nil {
+			fatal("load span name rules", err, "path", cfg.IngestSpanNameRulesFile)
+		}
+		traceServer.SetSpanNameNormalizer(sn)
+		slog.Info("✏️ Span name rules loaded", "path", cfg.IngestSpanNameRulesFile, "rules", sn.Rules())
+	}
+
+	// PII redaction (payment attributes, card numbers, emails) ahead of
+	// every other ingest step. A broken rules file fails startup rather
+	// than storing raw values.
+	if rd, err := buildRedactor(cfg, metrics); err != nil {
+		fatal("load redaction rules", err, "path", cfg.IngestRedactionRulesFile)
+	} else if rd != nil {
+		traceServer.SetRedactor(rd)
+		logsServer.SetRedactor(rd)
+		slog.Info("🔒 Redaction rules loaded", "path", cfg.IngestRedactionRulesFile, "config_file", cfg.RedactionRules != nil, "rules", rd.Rules())
+	}
+
+	// Attribute transform chain (renames, static tags, dropped noise,
+	// derived fields) normalizing heterogeneous services. Fails startup like
+	// the rewrite rules.
+	if cfg.IngestTransformFile != "" {
+		tf, err := ingest.LoadTransformer(cfg.IngestTransformFile, metrics)
+		if err != nil {
+			fatal("load transform file", err, "path", cfg.IngestTransformFile)
+		}
+		traceServer.SetTransformer(tf)
+		logsServer.SetTransformer(tf)
+		slog.Info("🔧 Attribute transforms loaded", "path", cfg.IngestTransformFile, "processors", tf.Processors())
+	}
+
+	// Capture incoming requests as golden test fixtures (debugging aid).
+	if cfg.IngestRecordFixturesDir != "" {
+		rec, err := ingest.NewFixtureRecorder(cfg.IngestRecordFixturesDir, cfg.IngestRecordFixturesMax)
+		if err != nil {
+			fatal("create fixture recorder", err, "dir", cfg.IngestRecordFixturesDir)
+		}
+		traceServer.SetFixtureRecorder(rec)
+		logsServer.SetFixtureRecorder(rec)
+		metricsServer.SetFixtureRecorder(rec)
+		slog.Warn("📼 Recording OTLP requests as test fixtures", "dir", cfg.IngestRecordFixturesDir, "max_files", cfg.IngestRecordFixturesMax)
+	}
+
+	// A new service.version on a resource becomes a deploy annotation.
+	if cfg.DeploymentsFromServiceVersion {
+		traceServer.SetVersionTracker(ingest.NewVersionTracker(repo))
+	}
+
 	// Wire async ingest pipeline. Decouples OTLP Export() from synchronous
 	// DB writes — caller returns as soon as the parsed batch is enqueued.
 	// When disabled (INGEST_ASYNC_ENABLED=false), trace/logs servers fall
 	// back to the inline-write path bit-for-bit.
 	var ingestPipeline *ingest.Pipeline
 	if cfg.IngestAsyncEnabled {
+		flushInterval, _ := time.ParseDuration(cfg.IngestPipelineFlushInterval) // validated in cfg.Validate()
 		ingestPipeline = ingest.NewPipeline(repo, metrics, ingest.PipelineConfig{
-			Capacity: cfg.IngestPipelineQueueSize,
-			Workers:  cfg.IngestPipelineWorkers,
+			Capacity:      cfg.IngestPipelineQueueSize,
+			Workers:       cfg.IngestPipelineWorkers,
+			BatchSize:     cfg.IngestPipelineBatchSize,
+			FlushInterval: flushInterval,
+			WriteRetries:  cfg.IngestPipelineWriteRetries,
 		})
 		ingestPipeline.SetPerTenantCap(cfg.IngestPipelinePerTenantCap)
+		// Batches that still fail after retries go to the DLQ and are
+		// replayed through the "batch" envelope above.
+		ingestPipeline.SetSpill(func(b *ingest.Batch) error {
+			return dlq.Enqueue(map[string]any{
+				"type": "batch",
+				"data": map[string]any{"traces": b.Traces, "spans": b.Spans, "logs": b.Logs},
+			})
+		})
 
 		// Second-tier severity gate. Empty STORE_MIN_SEVERITY means "use the
 		// same threshold as INGEST_MIN_SEVERITY" — i.e. behavior is identical
@@ -538,17 +919,137 @@ func main() {
 			"queue_size", cfg.IngestPipelineQueueSize,
 			"workers", cfg.IngestPipelineWorkers,
 			"per_tenant_cap", cfg.IngestPipelinePerTenantCap,
+			"batch_size", cfg.IngestPipelineBatchSize,
+			"flush_interval", flushInterval,
+			"write_retries", cfg.IngestPipelineWriteRetries,
 		)
 	} else {
 		slog.Warn("🐌 Async ingest pipeline disabled (INGEST_ASYNC_ENABLED=false) — Export() blocks on DB writes")
 	}
 
+	// Wire tail sampling (opt-in). Buffers each trace for the decision
+	// window and persists only traces matching a keep policy; kept traces
+	// go through the pipeline wired above.
+	var tailSampler *ingest.TailSampler
+	if flags.Enabled(featureflag.TailSampling) {
+		wait, _ := time.ParseDuration(cfg.TailSamplingDecisionWait) // validated in cfg.Validate()
+		tailSampler = ingest.NewTailSampler(ingest.TailSamplingPolicy{
+			KeepErrors:       cfg.TailSamplingKeepErrors,
+			LatencyThreshold: time.Duration(cfg.TailSamplingLatencyThresholdMs) * time.Millisecond,
+			Services:         strings.Split(cfg.TailSamplingServices, ","),
+			Rate:             cfg.TailSamplingRate,
+		}, wait, cfg.TailSamplingMaxTraces, metrics)
+		traceServer.SetTailSampler(tailSampler)
+		tailSampler.Start()
+		slog.Info("🪣 Tail sampling enabled",
+			"decision_wait", wait,
+			"keep_errors", cfg.TailSamplingKeepErrors,
+			"latency_threshold_ms", cfg.TailSamplingLatencyThresholdMs,
+			"services", cfg.TailSamplingServices,
+			"rate", cfg.TailSamplingRate,
+			"max_traces", cfg.TailSamplingMaxTraces,
+		)
+	}
+
+	// Alerting engine: evaluates rules from /api/alerts/rules against the
+	// repository and notifies their Slack/webhook/email channels.
+	var alertEngine *alerting.Engine
+	if cfg.AlertingEnabled {
+		interval, _ := time.ParseDuration(cfg.AlertEvalInterval) // validated in cfg.Validate()
+		alertEngine = alerting.NewEngine(repo, interval, metrics)
+		alertEngine.SetSMTP(alerting.SMTPConfig{
+			Addr:     cfg.AlertSMTPAddr,
+			From:     cfg.AlertSMTPFrom,
+			Username: cfg.AlertSMTPUsername,
+			Password: cfg.AlertSMTPPassword,
+		})
+		alertEngine.Start()
+		slog.Info("🚨 Alerting engine started", "interval", interval, "smtp", cfg.AlertSMTPAddr != "")
+	}
+
+	// Uptime scheduler: runs the HTTP/TCP/ICMP monitors from /api/monitors
+	// and records their status for monitor_down alert rules.
+	var uptimeScheduler *uptime.Scheduler
+	if cfg.UptimeEnabled {
+		uptimeScheduler = uptime.New(repo, metrics)
+		if cfg.UptimeResultRetention != "" {
+			keep, _ := config.ParseRetentionWindow(cfg.UptimeResultRetention) // validated in cfg.Validate()
+			uptimeScheduler.SetRetention(keep)
+		}
+		uptimeScheduler.Start()
+		slog.Info("📶 Uptime scheduler started", "result_retention", cfg.UptimeResultRetention)
+	}
+
+	// Config file and runtime overrides: alert rules the file defines are
+	// synced into the rule table, and edits to the file or PATCH
+	// /api/config are applied to the running subsystems. A file that fails
+	// to parse or validate is logged and the running config kept.
+	live := config.NewLive(cfg, func(prev, next *config.Config) error {
+		if err := applyConfig(next, repo, metrics, traceServer, logsServer, metricsServer, retention); err != nil {
+			return err
+		}
+		// Only a changed tuning is pushed, so a file reload does not undo
+		// PUT /api/admin/hub.
+		prevTuning, _ := prev.HubTuning()
+		if tuning, _ := next.HubTuning(); tuning != prevTuning { // validated in next.Validate()
+			hub.SetFlushTuning(tuning.BufferSize, tuning.FlushInterval)
+		}
+		return nil
+	})
+	apiServer.SetLiveConfig(live)
+	ctxConfig, cancelConfig := context.WithCancel(context.Background())
+	if cfg.ConfigFile != "" {
+		if err := syncAlertRules(context.Background(), repo, cfg); err != nil {
+			fatal("sync config file alert rules", err, "path", cfg.ConfigFile)
+		}
+		reload := func() {
+			_, err := live.Reload()
+			metrics.RecordConfigReload(err)
+			if err != nil {
+				slog.Error("Config file reload failed, keeping the running configuration", "path", cfg.ConfigFile, "error", err)
+				return
+			}
+			slog.Info("🔄 Config file reloaded", "path", cfg.ConfigFile)
+		}
+		if interval, _ := time.ParseDuration(cfg.ConfigReloadInterval); interval > 0 { // validated in cfg.Validate()
+			go config.WatchFile(ctxConfig, cfg.ConfigFile, interval, reload)
+			slog.Info("👀 Watching config file", "path", cfg.ConfigFile, "fallback_interval", interval)
+		}
+	}
+
+	// Self-history: restarts, config changes, DB outages and ingest gaps of
+	// this instance behind GET /api/admin/history. Boot records the
+	// downtime since the previous run before any new data arrives.
+	var selfHistory *selfhistory.Recorder
+	if cfg.SelfHistoryEnabled {
+		selfHistory = selfhistory.New(repo, Version, 30*time.Second)
+		if gap, _ := time.ParseDuration(cfg.SelfHistoryIngestGap); gap > 0 { // validated in cfg.Validate()
+			selfHistory.SetIngestGap(gap, metrics.TotalIngested)
+		}
+		if cfg.SelfHistoryRetention != "" {
+			keep, _ := config.ParseRetentionWindow(cfg.SelfHistoryRetention)
+			selfHistory.SetRetention(keep)
+		}
+		if err := selfHistory.Boot(context.Background(), cfg.Fingerprint()); err != nil {
+			slog.Warn("Self history boot record failed", "error", err)
+		}
+		selfHistory.Start()
+		apiServer.SetSelfHistory(selfHistory)
+		slog.Info("📜 Self history enabled", "ingest_gap", cfg.SelfHistoryIngestGap, "retention", cfg.SelfHistoryRetention)
+	}
+
 	// Wire /ready saturation probes. Both probes are nil-tolerant on the
 	// api server side; we additionally guard against unconfigured caps
 	// (DLQ unbounded, async pipeline disabled) by returning 0 — i.e.
 	// "skipped" semantics — rather than dividing by zero.
-	if dlq != nil && cfg.DLQMaxDiskMB > 0 {
-		maxBytes := float64(cfg.DLQMaxDiskMB) * 1024 * 1024
+	apiServer.SetDLQ(dlq)
+	if window, _ := time.ParseDuration(cfg.DLQReadMergeWindow); window > 0 { // validated in cfg.Validate()
+		apiServer.SetDLQReadMerge(window)
+	}
+	deployImpactWindow, _ := time.ParseDuration(cfg.DeploymentImpactWindow) // validated in cfg.Validate()
+	apiServer.SetDeploymentImpactWindow(deployImpactWindow)
+	if dlq != nil && cfg.DLQByteLimit() > 0 {
+		maxBytes := float64(cfg.DLQByteLimit())
 		apiServer.SetDLQSaturationProbe(func() float64 {
 			return float64(dlq.DiskBytes()) / maxBytes
 		})
@@ -576,8 +1077,12 @@ func main() {
 			AttributesJSON: string(l.AttributesJSON),
 			AIInsight:      string(l.AIInsight),
 			Timestamp:      l.Timestamp,
+			TenantID:       l.TenantID,
 		})
-		aiService.EnqueueLog(l)
+		apiServer.BroadcastLog(l)
+		if flags.Enabled(featureflag.AIInsights) {
+			aiService.EnqueueLog(l)
+		}
 		vectorIdx.Add(l.ID, l.TenantID, l.ServiceName, l.Severity, l.Body)
 		eventHub.NotifyRefresh()
 		if time.Since(start) > 100*time.Millisecond {
@@ -587,16 +1092,81 @@ func main() {
 
 	logsServer.SetLogCallback(func(l storage.Log) {
 		logHandler(l)
+		hub.RecordLog(l.ServiceName, l.Severity)
+		heavyHitters.RecordLog(l.TenantID, l.ServiceName, l.Severity, l.Body)
 		graphRAG.OnLogIngested(l)
+		sink.Log(l)
 	})
 	traceServer.SetLogCallback(func(l storage.Log) {
+		// Not counted in hub stats or service volume: these are synthesized
+		// from error spans, which RecordSpan already counts.
 		logHandler(l)
+		heavyHitters.RecordError(l.TenantID, l.ServiceName, l.Body)
 		graphRAG.OnLogIngested(l)
 	})
 
-	// Wire span callbacks for GraphRAG
+	// Trace finalization: recompute trace rows from their spans once the
+	// trace goes quiet, so late-arriving spans correct duration/status.
+	var traceFinalizer *ingest.TraceFinalizer
+	if cfg.TraceFinalizeEnabled {
+		quiet, err := time.ParseDuration(cfg.TraceFinalizeQuietPeriod)
+		if err != nil || quiet <= 0 {
+			slog.Warn("Invalid TRACE_FINALIZE_QUIET_PERIOD, using default", "value", cfg.TraceFinalizeQuietPeriod, "default", "30s")
+			quiet = 30 * time.Second
+		}
+		traceFinalizer = ingest.NewTraceFinalizer(repo, metrics, quiet)
+		traceFinalizer.SetFinalizeCallback(traceStreams.PublishFinalized)
+		traceFinalizer.Start(appCtx)
+		slog.Info("🧮 Trace finalizer started", "quiet_period", quiet)
+	}
+	if cfg.TraceBackfillOnStart {
+		bootWG.Add(1)
+		go func() {
+			defer bootWG.Done()
+			start := time.Now()
+			n, err := repo.BackfillTraceSummaries(appCtx, 0, 10*time.Millisecond)
+			metrics.RecordTracesRecomputed("backfill", n)
+			if err != nil {
+				slog.Warn("Trace summary backfill stopped early", "corrected", n, "error", err)
+				return
+			}
+			slog.Info("🧮 Trace summary backfill complete", "corrected", n, "duration", time.Since(start))
+		}()
+	}
+
+	// RED metrics per service and span name, from the span callback below.
+	if cfg.SpanMetricsEnabled {
+		metrics.EnableSpanMetrics(cfg.SpanMetricsMaxSeries)
+	}
+
+	// Wire span callbacks for GraphRAG, AI trace RCA, the live traces feed,
+	// live trace streams, trace finalization and span metrics
 	traceServer.SetSpanCallback(func(span storage.Span) {
+		metrics.RecordSpanMetrics(span.TenantID, span.ServiceName, span.OperationName, span.Status, time.Duration(span.DurationNs))
+		hub.RecordSpan(span.ServiceName, span.Status == "STATUS_CODE_ERROR")
+		heavyHitters.RecordService(span.TenantID, span.ServiceName)
+		heavyHitters.RecordRoute(span.TenantID, span.ServiceName, span.OperationName)
+		if span.ParentSpanID == "" {
+			// The root span ends the trace for the live traces page.
+			hub.BroadcastTrace(realtime.TraceEntry{
+				TraceID:       span.TraceID,
+				ServiceName:   span.ServiceName,
+				RootOperation: span.OperationName,
+				DurationMs:    float64(span.Duration) / 1000.0,
+				Status:        span.Status,
+				Timestamp:     span.StartTime,
+				TenantID:      span.TenantID,
+			})
+		}
+		traceStreams.PublishSpan(span)
 		graphRAG.OnSpanIngested(span)
+		if flags.Enabled(featureflag.AIInsights) {
+			aiService.ObserveSpan(span)
+		}
+		if traceFinalizer != nil {
+			traceFinalizer.Observe(span.TenantID, span.TraceID)
+		}
+		sink.Span(span)
 	})
 
 	metricsServer.SetMetricCallback(func(m tsdb.RawMetric) {
@@ -606,13 +1176,18 @@ func main() {
 			Value:       m.Value,
 			Timestamp:   m.Timestamp,
 			Attributes:  m.Attributes,
+			TenantID:    m.TenantID,
 		})
+		hub.RecordMetricPoint(m.ServiceName)
+		heavyHitters.RecordService(m.TenantID, m.ServiceName)
 		graphRAG.OnMetricIngested(m)
+		sink.Metric(m)
 	})
 
-	// Update DLQ size metric periodically. Tied to appCtx so the goroutine
-	// exits before dlq.Stop() — otherwise it keeps polling Size()/DiskBytes()
-	// on a stopped DLQ and races with the file-handle close in repo.Close().
+	// Update DLQ growth metrics (and the built-in DLQ alerts derived from
+	// them) periodically. Tied to appCtx so the goroutine exits before
+	// dlq.Stop() — otherwise it keeps polling Stats() on a stopped DLQ and
+	// races with the file-handle close in repo.Close().
 	bootWG.Add(1)
 	go func() {
 		defer bootWG.Done()
@@ -623,22 +1198,143 @@ func main() {
 			case <-appCtx.Done():
 				return
 			case <-ticker.C:
-				metrics.SetDLQSize(dlq.Size())
-				metrics.DLQDiskBytes.Set(float64(dlq.DiskBytes()))
+				st := dlq.Stats()
+				metrics.RecordDLQStats(st.Files, st.Bytes, st.OldestAge, st.FailureStreak)
 			}
 		}
 	}()
 
+	// Sample estimated storage bytes per tenant, signal and service for
+	// GET /api/admin/storage. The first pass runs at boot so the endpoint
+	// has data before the first interval elapses.
+	if cfg.StorageUsageInterval != "0" {
+		interval, err := config.ParseRetentionWindow(cfg.StorageUsageInterval)
+		if err != nil {
+			interval = time.Hour
+		}
+		var keep time.Duration
+		if cfg.StorageUsageHistory != "" {
+			keep, _ = config.ParseRetentionWindow(cfg.StorageUsageHistory)
+		}
+		bootWG.Add(1)
+		go func() {
+			defer bootWG.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if n, err := repo.RecordStorageUsage(appCtx, keep); err != nil {
+					slog.Warn("Storage usage sample failed", "error", err)
+				} else {
+					slog.Debug("Storage usage sampled", "samples", n)
+				}
+				select {
+				case <-appCtx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	// Roll settled traces and log counts into minute/hour buckets so the
+	// dashboard stats stop scanning raw rows. Each pass catches up at most
+	// a few hours, so a first-boot backfill spreads over several minutes.
+	if cfg.DashboardRollupsEnabled {
+		backfill, err := config.ParseRetentionWindow(cfg.DashboardRollupBackfill)
+		if err != nil {
+			backfill = 24 * time.Hour
+		}
+		var keep time.Duration
+		if cfg.DashboardRollupRetention != "" {
+			keep, _ = config.ParseRetentionWindow(cfg.DashboardRollupRetention)
+		}
+		bootWG.Add(1)
+		go func() {
+			defer bootWG.Done()
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for {
+				if n, err := repo.AdvanceDashboardRollups(appCtx, time.Now(), backfill, keep); err != nil {
+					slog.Warn("Dashboard rollup failed", "error", err)
+				} else if n > 0 {
+					slog.Debug("Dashboard rollups advanced", "minutes", n)
+				}
+				select {
+				case <-appCtx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	// Fold settled error logs and failed spans into tracked issues
+	// (/api/issues). Each pass catches up at most a few hours.
+	if cfg.IssuesEnabled {
+		backfill, err := config.ParseRetentionWindow(cfg.IssueBackfill)
+		if err != nil {
+			backfill = 7 * 24 * time.Hour
+		}
+		bootWG.Add(1)
+		go func() {
+			defer bootWG.Done()
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for {
+				if n, err := repo.AdvanceIssues(appCtx, time.Now(), backfill); err != nil {
+					slog.Warn("Issue tracking pass failed", "error", err)
+				} else if n > 0 {
+					slog.Debug("Issues advanced", "occurrences", n)
+				}
+				select {
+				case <-appCtx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
+	// Judge each deployment once its impact window has passed: latency and
+	// error rate after it against before it (/api/deployments/{id}/impact).
+	if deployImpactWindow > 0 {
+		bootWG.Add(1)
+		go func() {
+			defer bootWG.Done()
+			ticker := time.NewTicker(time.Minute)
+			defer ticker.Stop()
+			for {
+				judged, err := repo.EvaluateDeployments(appCtx, time.Now(), deployImpactWindow)
+				if err != nil {
+					slog.Warn("Deployment evaluation pass failed", "error", err)
+				}
+				for _, d := range judged {
+					metrics.RecordDeploymentVerdict(d.Verdict)
+					if d.Verdict == storage.DeploymentVerdictRegressed {
+						slog.Warn("📉 Deployment regressed", "service", d.ServiceName, "version", d.Version, "tenant", d.TenantID, "reasons", d.Impact.Reasons)
+					}
+				}
+				select {
+				case <-appCtx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+
 	// Resolve TLS material once: explicit cert-file > self-signed > plaintext.
-	// Both gRPC and HTTP reuse the same resolved paths below.
+	// Both gRPC and HTTP reuse the same resolved paths below, except that
+	// OTLP_TLS_CERT/OTLP_TLS_KEY take over the gRPC receiver.
 	const (
 		tlsModeCertFile   = "cert-file"
 		tlsModeSelfSigned = "self-signed"
+		tlsModeOTLP       = "otlp-cert"
 	)
 	var (
 		tlsCertPath string
 		tlsKeyPath  string
-		tlsMode     string // tlsModeCertFile, tlsModeSelfSigned, or "" (plaintext)
+		tlsMode     string // tlsModeCertFile, tlsModeSelfSigned, tlsModeOTLP, or "" (plaintext)
 	)
 	switch {
 	case cfg.TLSCertFileMode():
@@ -654,6 +1350,15 @@ func main() {
 		tlsKeyPath = kp
 		tlsMode = tlsModeSelfSigned
 	}
+	grpcCertPath, grpcKeyPath, grpcTLSMode := tlsCertPath, tlsKeyPath, tlsMode
+	if cfg.OTLPTLSCert != "" {
+		grpcCertPath, grpcKeyPath, grpcTLSMode = cfg.OTLPTLSCert, cfg.OTLPTLSKey, tlsModeOTLP
+		// OTLP/HTTP shares the HTTP port, so without other TLS it serves
+		// the OTLP certificate too.
+		if tlsMode == "" {
+			tlsCertPath, tlsKeyPath, tlsMode = grpcCertPath, grpcKeyPath, tlsModeOTLP
+		}
+	}
 
 	// Start gRPC Server
 	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
@@ -689,27 +1394,28 @@ func main() {
 			metricsUnaryInterceptor(metrics),
 		),
 	}
+	// Chained after recovery/metrics so rejected exports are still counted.
+	switch {
+	case ingestAuth != nil:
+		grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(ingestAuth.UnaryServerInterceptor()))
+	case keyAuth != nil:
+		grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(keyAuth.UnaryServerInterceptor()))
+	}
 	slog.Info("📡 gRPC server tuned",
 		"max_recv_mb", recvBytes,
 		"max_concurrent_streams", streams,
 	)
-	switch tlsMode {
-	case tlsModeCertFile:
-		creds, err := credentials.NewServerTLSFromFile(tlsCertPath, tlsKeyPath)
+	if grpcTLSMode != "" {
+		// With OTLP_TLS_CLIENT_CA every gRPC client must present a
+		// certificate signed by one of its CAs.
+		tlsCfg, err := tlsbootstrap.ServerConfig(grpcCertPath, grpcKeyPath, cfg.OTLPTLSClientCA, tls.RequireAndVerifyClientCert)
 		if err != nil {
-			fatal("Failed to load gRPC TLS credentials", err)
+			fatal("Failed to load gRPC TLS credentials", err, "mode", grpcTLSMode)
 		}
-		grpcOpts = append(grpcOpts, grpc.Creds(creds))
-		slog.Info("🔒 gRPC TLS enabled", "mode", tlsModeCertFile)
-	case tlsModeSelfSigned:
-		creds, err := credentials.NewServerTLSFromFile(tlsCertPath, tlsKeyPath)
-		if err != nil {
-			fatal("Failed to load gRPC TLS credentials (self-signed)", err)
-		}
-		grpcOpts = append(grpcOpts, grpc.Creds(creds))
-		slog.Info("🔒 gRPC TLS enabled", "mode", tlsModeSelfSigned, "cache_dir", cfg.TLSCacheDir)
-	default:
-		slog.Info("🔓 gRPC plaintext — not for production; set TLS_CERT_FILE/TLS_KEY_FILE or TLS_AUTO_SELFSIGNED=true")
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		slog.Info("🔒 gRPC TLS enabled", "mode", grpcTLSMode, "mtls", cfg.OTLPTLSClientCA != "")
+	} else {
+		slog.Info("🔓 gRPC plaintext — not for production; set TLS_CERT_FILE/TLS_KEY_FILE, OTLP_TLS_CERT/OTLP_TLS_KEY or TLS_AUTO_SELFSIGNED=true")
 	}
 	grpcServer := grpc.NewServer(grpcOpts...)
 	coltracepb.RegisterTraceServiceServer(grpcServer, traceServer)
@@ -724,6 +1430,65 @@ func main() {
 		}
 	}()
 
+	// Legacy Jaeger agent receiver (opt-in). Feeds the same TraceServer, so
+	// its spans go through the same filters, validation and pipeline as OTLP.
+	var jaegerAgent *ingest.JaegerAgentReceiver
+	if cfg.JaegerAgentAddr != "" {
+		jaegerAgent = ingest.NewJaegerAgentReceiver(traceServer, metrics)
+		if err := jaegerAgent.Start(cfg.JaegerAgentAddr); err != nil {
+			fatal("Failed to start Jaeger agent receiver", err, "addr", cfg.JaegerAgentAddr)
+		}
+	}
+
+	// Jaeger collector-compatible receivers (opt-in): Thrift over HTTP and
+	// api_v2 over gRPC. The gRPC listener shares the OTLP server's limits,
+	// interceptors and TLS.
+	var jaegerCollector *ingest.JaegerCollector
+	if cfg.JaegerCollectorHTTPAddr != "" || cfg.JaegerCollectorGRPCAddr != "" {
+		jaegerCollector = ingest.NewJaegerCollector(traceServer, metrics)
+		if cfg.JaegerCollectorHTTPAddr != "" {
+			if err := jaegerCollector.StartHTTP(cfg.JaegerCollectorHTTPAddr); err != nil {
+				fatal("Failed to start Jaeger collector HTTP receiver", err, "addr", cfg.JaegerCollectorHTTPAddr)
+			}
+		}
+		if cfg.JaegerCollectorGRPCAddr != "" {
+			if err := jaegerCollector.StartGRPC(cfg.JaegerCollectorGRPCAddr, grpcOpts...); err != nil {
+				fatal("Failed to start Jaeger collector gRPC receiver", err, "addr", cfg.JaegerCollectorGRPCAddr)
+			}
+		}
+	}
+
+	// Zipkin v2 JSON receiver (opt-in) for Brave / Sleuth services.
+	var zipkinReceiver *ingest.ZipkinReceiver
+	if cfg.ZipkinAddr != "" {
+		zipkinReceiver = ingest.NewZipkinReceiver(traceServer, metrics)
+		if err := zipkinReceiver.Start(cfg.ZipkinAddr); err != nil {
+			fatal("Failed to start Zipkin receiver", err, "addr", cfg.ZipkinAddr)
+		}
+	}
+
+	// Loki push API receiver (opt-in) for Promtail / Grafana Agent.
+	var lokiReceiver *ingest.LokiReceiver
+	if cfg.LokiAddr != "" {
+		lokiReceiver = ingest.NewLokiReceiver(logsServer, metrics)
+		if ingestAuth != nil {
+			lokiReceiver.SetAuthenticator(ingestAuth)
+		}
+		if err := lokiReceiver.Start(cfg.LokiAddr); err != nil {
+			fatal("Failed to start Loki receiver", err, "addr", cfg.LokiAddr)
+		}
+	}
+
+	// StatsD/DogStatsD listener (opt-in). Lines are converted to OTLP and fed
+	// through MetricsServer into the TSDB aggregator.
+	var statsdReceiver *ingest.StatsDReceiver
+	if cfg.StatsDAddr != "" {
+		statsdReceiver = ingest.NewStatsDReceiver(metricsServer, metrics)
+		if err := statsdReceiver.Start(cfg.StatsDAddr); err != nil {
+			fatal("Failed to start StatsD receiver", err, "addr", cfg.StatsDAddr)
+		}
+	}
+
 	// Start runtime metrics sampling (every 15s)
 	metrics.StartRuntimeMetrics()
 	slog.Info("📊 Runtime metrics sampling started")
@@ -736,9 +1501,56 @@ func main() {
 		})
 	}
 
+	if cfg.OTLPHTTPCORSOrigins != "" {
+		var origins []string
+		for _, o := range strings.Split(cfg.OTLPHTTPCORSOrigins, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				origins = append(origins, o)
+			}
+		}
+		otlpHTTP.SetCORSAllowedOrigins(origins)
+		slog.Info("🌐 OTLP/HTTP CORS enabled", "origins", origins)
+	}
+
 	// 8. Start HTTP Server
 	mux := http.NewServeMux()
 	otlpHTTP.RegisterRoutes(mux)
+
+	// Kinesis Firehose HTTP endpoint (CloudWatch Logs subscriptions).
+	// Opt-in: enabled by FIREHOSE_ACCESS_KEY, which the handler checks
+	// itself since Firehose cannot send a bearer token.
+	if cfg.FirehoseAccessKey != "" {
+		groups, err := ingest.ParseLogGroupServices(cfg.FirehoseLogGroupServices)
+		if err != nil {
+			fatal("Invalid FIREHOSE_LOG_GROUP_SERVICES", err)
+		}
+		firehose := ingest.NewFirehoseHandler(logsServer, cfg.FirehoseAccessKey, metrics)
+		firehose.SetTenant(cfg.FirehoseTenant)
+		firehose.SetLogGroupServices(groups)
+		firehose.RegisterRoutes(mux)
+		slog.Info("🔥 Firehose ingestion endpoint registered", "path", "/ingest/firehose", "log_group_mappings", len(groups))
+	}
+
+	// Heroku/Vercel log drains (opt-in via LOG_DRAINS). Each drain carries
+	// its own token in the drain URL.
+	if cfg.LogDrains != "" {
+		drains, err := ingest.ParseLogDrains(cfg.LogDrains)
+		if err != nil {
+			fatal("Invalid LOG_DRAINS", err)
+		}
+		ingest.NewLogDrainHandler(logsServer, drains, metrics).RegisterRoutes(mux)
+		slog.Info("🚰 Log drain endpoints registered", "paths", []string{"/ingest/drains/heroku", "/ingest/drains/vercel"}, "drains", len(drains))
+	}
+
+	// GitHub deployment/workflow webhooks → deploy annotations (opt-in via
+	// GITHUB_WEBHOOK_SECRET, which signs every delivery).
+	if cfg.GitHubWebhookSecret != "" {
+		github := ingest.NewGitHubWebhookHandler(repo, cfg.GitHubWebhookSecret, metrics)
+		github.SetTenant(cfg.GitHubWebhookTenant)
+		github.SetDeployWorkflows(cfg.GitHubDeployWorkflows)
+		github.RegisterRoutes(mux)
+		slog.Info("🚀 GitHub webhook endpoint registered", "path", "/ingest/github")
+	}
 	apiServer.RegisterRoutes(mux)
 
 	// MCP Server routes (conditionally enabled via MCP_ENABLED)
@@ -764,6 +1576,7 @@ func main() {
 	// Resolve tenant on /api/* read-side requests (passes through OTLP /v1,
 	// MCP, UI assets, and health probes untouched).
 	httpHandler = api.TenantMiddleware(cfg)(httpHandler)
+	preAuth := httpHandler
 
 	// Wire auth-failure metric hook before installing any auth middleware.
 	api.AuthFailureHook = func(reason string) {
@@ -774,6 +1587,9 @@ func main() {
 	// shared API key — they enforce tenant boundaries at the auth layer rather
 	// than trusting a client-supplied X-Tenant-ID header.
 	switch {
+	case keyAuth != nil:
+		httpHandler = keyAuth.Middleware(cfg.MCPPath, httpHandler)
+		slog.Info("🔑 Managed API key authentication enabled (scoped keys)")
 	case cfg.APITenantKeysFile != "":
 		entries, err := api.LoadTenantKeys(cfg.APITenantKeysFile)
 		if err != nil {
@@ -788,11 +1604,31 @@ func main() {
 	default:
 		slog.Warn("API authentication disabled — set API_KEY or API_TENANT_KEYS_FILE for production")
 	}
+	// With INGEST_AUTH_FILE, /v1/* is authenticated by ingest credentials
+	// instead of the API authentication above.
+	if ingestAuth != nil {
+		httpHandler = ingestAuth.Gate(preAuth, httpHandler)
+	}
+	// With OIDC sign-in, session cookies authenticate the UI, API and /ws
+	// and the user's role is enforced per route; other requests fall
+	// through to the chain above.
+	if oidcAuth != nil {
+		httpHandler = oidcAuth.Gate(cfg.MCPPath, preAuth, httpHandler)
+	}
+	// OTLP_TLS_CLIENT_CA: OTLP/HTTP needs a verified client certificate on
+	// top of any key. Checked before authentication.
+	if cfg.OTLPTLSClientCA != "" {
+		httpHandler = api.RequireClientCert(httpHandler)
+		if tlsMode == "" {
+			slog.Warn("OTLP_TLS_CLIENT_CA set but the HTTP port is plaintext; OTLP/HTTP requests will be rejected")
+		}
+	}
 
 	httpHandler = api.MetricsMiddleware(metrics, httpHandler)
 	if cfg.APIRateLimitRPS > 0 {
 		rl := api.NewRateLimiter(float64(cfg.APIRateLimitRPS))
-		// OTLP ingestion paths (/v1/*) are exempt from the per-IP rate limiter.
+		// OTLP ingestion paths (/v1/*) and push-based ingestion endpoints
+		// (/ingest/*) are exempt from the per-IP rate limiter.
 		//
 		// Why: OTLP collectors batch aggressively and a healthy agent routinely
 		// exceeds the API_RATE_LIMIT_RPS default (100 RPS/IP). Throttling the
@@ -814,11 +1650,11 @@ func main() {
 		// higher-ceiling OTLP-specific limiter scoped to /v1/* — tuned for
 		// collector-class RPS — rather than lowering the general API limit.
 		httpHandler = rl.MiddlewareExcept(func(path string) bool {
-			return strings.HasPrefix(path, "/v1/")
+			return strings.HasPrefix(path, "/v1/") || strings.HasPrefix(path, "/ingest/")
 		})(httpHandler)
 		slog.Info("🛡️  API rate limiter enabled",
 			"rps_per_ip", cfg.APIRateLimitRPS,
-			"exempt_prefixes", []string{"/v1/"},
+			"exempt_prefixes", []string{"/v1/", "/ingest/"},
 		)
 	}
 
@@ -827,6 +1663,9 @@ func main() {
 	var dbHealth *api.DBHealth
 	if sqlDB, dbErr := repo.DB().DB(); dbErr == nil && sqlDB != nil {
 		dbHealth = api.NewDBHealth(sqlDB, cfg.DBDriver, metrics)
+		if selfHistory != nil {
+			dbHealth.SetOnChange(selfHistory.SetDBHealthy)
+		}
 		dbHealth.Start(appCtx)
 		httpHandler = api.DBHealthMiddleware(dbHealth)(httpHandler)
 		slog.Info("🩺 DB health middleware enabled", "driver", cfg.DBDriver)
@@ -889,11 +1728,20 @@ func main() {
 		Handler:           httpHandler,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
+	if tlsMode != "" {
+		// Client certificates are verified when offered; RequireClientCert
+		// demands one on /v1/* only.
+		tlsCfg, err := tlsbootstrap.ServerConfig(tlsCertPath, tlsKeyPath, cfg.OTLPTLSClientCA, tls.VerifyClientCertIfGiven)
+		if err != nil {
+			fatal("Failed to load HTTPS TLS credentials", err, "mode", tlsMode)
+		}
+		srv.TLSConfig = tlsCfg
+	}
 
 	go func() {
 		if tlsMode != "" {
-			slog.Info("🔒 HTTPS server started", "port", cfg.HTTPPort, "mode", tlsMode)
-			if err := srv.ListenAndServeTLS(tlsCertPath, tlsKeyPath); err != nil && err != http.ErrServerClosed {
+			slog.Info("🔒 HTTPS server started", "port", cfg.HTTPPort, "mode", tlsMode, "mtls", cfg.OTLPTLSClientCA != "")
+			if err := srv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
 				fatal("HTTPS server failed", err)
 			}
 		} else {
@@ -917,9 +1765,29 @@ func main() {
 	// Ordered shutdown: ingestion → HTTP → hubs/events → processing → DLQ → DB
 	// 1. Stop ingestion paths first (no new data)
 	grpcServer.GracefulStop()
+	if jaegerAgent != nil {
+		jaegerAgent.Stop()
+	}
+	if jaegerCollector != nil {
+		jaegerCollector.Stop(ctx)
+	}
+	if zipkinReceiver != nil {
+		zipkinReceiver.Stop(ctx)
+	}
+	if lokiReceiver != nil {
+		lokiReceiver.Stop(ctx)
+	}
+	if statsdReceiver != nil {
+		statsdReceiver.Stop()
+	}
 	if err := srv.Shutdown(ctx); err != nil {
 		slog.Error("HTTP server forced shutdown", "error", err)
 	}
+	// Decide and flush traces still in the tail-sampling buffer; the
+	// pipeline drain below persists the kept ones.
+	if tailSampler != nil {
+		tailSampler.Stop()
+	}
 
 	// 2. Stop real-time hubs and event processing
 	hub.Stop()
@@ -955,17 +1823,45 @@ func main() {
 	if ingestPipeline != nil {
 		ingestPipeline.Stop()
 	}
+	// Everything the pipeline drained has reached the sink's queue; flush it.
+	sink.Stop()
+
+	// 3b. Final trace finalization sweep — after the pipeline drained so
+	// every persisted span has been observed, before the DB closes.
+	if traceFinalizer != nil {
+		traceFinalizer.Stop()
+	}
+
+	// 3c. Stop alert evaluation and uptime checks before the DB closes.
+	if alertEngine != nil {
+		alertEngine.Stop()
+	}
+	if uptimeScheduler != nil {
+		uptimeScheduler.Stop()
+	}
+
+	// 3d. Record the graceful stop so the next boot reports a clean restart.
+	if selfHistory != nil {
+		selfHistory.Stop()
+	}
 
 	// 4. Stop DLQ (may still be replaying)
 	dlq.Stop()
 
 	// 4a. Stop retention + partition schedulers before closing DB (both issue queries).
+	cancelConfig()
 	cancelRetention()
 	retention.Stop()
 	cancelPartitions()
 	if partitionScheduler != nil {
 		partitionScheduler.Stop()
 	}
+	if shardScheduler != nil {
+		shardScheduler.Stop()
+	}
+	if coldTier != nil {
+		coldTier.Stop()
+	}
 
 	// 4b. Shutdown the OTel tracer provider (flushes pending spans).
 	if shutdownTracer != nil {
@@ -1003,6 +1899,176 @@ func main() {
 // recoveryUnaryInterceptor catches panics inside any unary gRPC handler,
 // logs the stack, increments the panics-recovered metric, and maps the panic
 // to codes.Internal so the connection stays alive.
+// dlqBatch is the rows of one DLQ batch file.
+type dlqBatch struct {
+	Traces  []storage.Trace        `json:"traces"`
+	Spans   []storage.Span         `json:"spans"`
+	Logs    []storage.Log          `json:"logs"`
+	Metrics []storage.MetricBucket `json:"-"`
+}
+
+// decodeDLQBatch decodes a DLQ batch file: a typed envelope of logs, spans,
+// traces, metrics or a whole ingest batch spilled by the pipeline, or a
+// bare log array from older versions.
+// retentionSettings returns the per-signal and per-tenant retention windows
+// of cfg. Both were validated in cfg.Validate(); empty means the
+// HOT_RETENTION_DAYS default.
+func retentionSettings(cfg *config.Config) (storage.RetentionPolicy, map[string]time.Duration) {
+	var p storage.RetentionPolicy
+	p.Traces, _ = config.ParseRetentionWindow(cfg.RetentionTraces)
+	p.Logs, _ = config.ParseRetentionWindow(cfg.RetentionLogs)
+	p.Metrics, _ = config.ParseRetentionWindow(cfg.RetentionMetrics)
+	tenants, _ := config.ParseTenantRetention(cfg.RetentionTenants)
+	return p, tenants
+}
+
+// buildSampler returns the head sampler of cfg, or nil when every trace is
+// kept (SAMPLING_RATE 0 or 1 and no SAMPLING_SERVICE_RATES).
+func buildSampler(cfg *config.Config) *ingest.Sampler {
+	serviceRates, _ := config.ParseServiceRates(cfg.SamplingServiceRates) // validated in cfg.Validate()
+	if (cfg.SamplingRate <= 0 || cfg.SamplingRate >= 1.0) && len(serviceRates) == 0 {
+		return nil
+	}
+	rate := cfg.SamplingRate
+	if rate <= 0 {
+		rate = 1.0
+	}
+	sampler := ingest.NewSampler(rate, cfg.SamplingAlwaysOnErrors, float64(cfg.SamplingLatencyThresholdMs))
+	sampler.SetServiceRates(serviceRates)
+	return sampler
+}
+
+// buildRateLimiters returns the span and log rate limiters of cfg; nil
+// means unlimited.
+func buildRateLimiters(cfg *config.Config) (spans, logs *ingest.ServiceRateLimiter) {
+	spanLimits, _ := config.ParseServiceRateLimits(cfg.IngestSpanRateLimits) // validated in cfg.Validate()
+	logLimits, _ := config.ParseServiceRateLimits(cfg.IngestLogRateLimits)
+	return ingest.NewServiceRateLimiter(spanLimits), ingest.NewServiceRateLimiter(logLimits)
+}
+
+// buildRedactor returns the redactor of cfg: the config file's inline rules
+// when it has any, else INGEST_REDACTION_RULES_FILE. nil = no redaction.
+func buildRedactor(cfg *config.Config, metrics *telemetry.Metrics) (*ingest.Redactor, error) {
+	if cfg.RedactionRules == nil {
+		return ingest.LoadRedactor(cfg.IngestRedactionRulesFile, cfg.IngestRedactionHashKey, metrics)
+	}
+	rules := make([]ingest.RedactionRule, len(cfg.RedactionRules))
+	for i, r := range cfg.RedactionRules {
+		rules[i] = ingest.RedactionRule(r)
+	}
+	return ingest.NewRedactor(rules, cfg.IngestRedactionHashKey, metrics)
+}
+
+// syncAlertRules makes the managed alert rules match the config file's.
+func syncAlertRules(ctx context.Context, repo *storage.Repository, cfg *config.Config) error {
+	rules := make([]storage.AlertRule, 0, len(cfg.AlertRules))
+	for _, spec := range cfg.AlertRules {
+		rule := storage.AlertRule{
+			TenantID:    spec.Tenant,
+			Name:        spec.Name,
+			Kind:        spec.Kind,
+			ServiceName: spec.Service,
+			Keyword:     spec.Keyword,
+			SLOID:       spec.SLOID,
+			MonitorID:   spec.MonitorID,
+			Threshold:   spec.Threshold,
+			Disabled:    spec.Disabled,
+		}
+		if rule.TenantID == "" {
+			rule.TenantID = cfg.DefaultTenant
+		}
+		if spec.Window != "" {
+			window, _ := time.ParseDuration(spec.Window) // validated in cfg.Validate()
+			rule.WindowSecs = int(window / time.Second)
+		}
+		for _, ch := range spec.Channels {
+			rule.Channels = append(rule.Channels, storage.AlertChannel{Type: ch.Type, URL: ch.URL, To: ch.To})
+		}
+		if err := alerting.ValidateRule(&rule); err != nil {
+			return fmt.Errorf("alert rule %q: %w", spec.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+	created, updated, deleted, err := repo.SyncManagedAlertRules(ctx, rules)
+	if err != nil {
+		return err
+	}
+	if created+updated+deleted > 0 {
+		slog.Info("🚨 Config file alert rules synced", "created", created, "updated", updated, "deleted", deleted)
+	}
+	return nil
+}
+
+// applyConfig applies the reloadable settings of a changed cfg to the
+// running subsystems: ingestion filters, sampling, rate limits, redaction,
+// retention and alert rules. The redactor and alert rules are checked
+// before anything is swapped, so an invalid rule leaves every subsystem on
+// the previous config.
+func applyConfig(cfg *config.Config, repo *storage.Repository, metrics *telemetry.Metrics,
+	traces *ingest.TraceServer, logs *ingest.LogsServer, metricsServer *ingest.MetricsServer,
+	retention *storage.RetentionScheduler) error {
+	rd, err := buildRedactor(cfg, metrics)
+	if err != nil {
+		return fmt.Errorf("redaction rules: %w", err)
+	}
+	if err := syncAlertRules(context.Background(), repo, cfg); err != nil {
+		return err
+	}
+	traces.SetFilters(cfg)
+	logs.SetFilters(cfg)
+	metricsServer.SetFilters(cfg)
+	traces.SetSampler(buildSampler(cfg))
+	spanLimiter, logLimiter := buildRateLimiters(cfg)
+	traces.SetRateLimiter(spanLimiter)
+	logs.SetRateLimiter(logLimiter)
+	traces.SetRedactor(rd)
+	logs.SetRedactor(rd)
+	policy, tenants := retentionSettings(cfg)
+	retention.Reconfigure(cfg.HotRetentionDays, policy, tenants)
+	return nil
+}
+
+func decodeDLQBatch(data []byte) (dlqBatch, error) {
+	var b dlqBatch
+	var envelope struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		// Legacy format: try to deserialize as []storage.Log
+		if json.Unmarshal(data, &b.Logs) != nil {
+			return b, fmt.Errorf("DLQ replay unmarshal failed: %w", err)
+		}
+		return b, nil
+	}
+	var err error
+	switch envelope.Type {
+	case "logs":
+		if err = json.Unmarshal(envelope.Data, &b.Logs); err != nil {
+			err = fmt.Errorf("DLQ replay logs unmarshal failed: %w", err)
+		}
+	case "spans":
+		if err = json.Unmarshal(envelope.Data, &b.Spans); err != nil {
+			err = fmt.Errorf("DLQ replay spans unmarshal failed: %w", err)
+		}
+	case "traces":
+		if err = json.Unmarshal(envelope.Data, &b.Traces); err != nil {
+			err = fmt.Errorf("DLQ replay traces unmarshal failed: %w", err)
+		}
+	case "metrics":
+		if err = json.Unmarshal(envelope.Data, &b.Metrics); err != nil {
+			err = fmt.Errorf("DLQ replay metrics unmarshal failed: %w", err)
+		}
+	case "batch":
+		if err = json.Unmarshal(envelope.Data, &b); err != nil {
+			err = fmt.Errorf("DLQ replay batch unmarshal failed: %w", err)
+		}
+	default:
+		err = fmt.Errorf("DLQ replay: unknown type %q", envelope.Type)
+	}
+	return b, err
+}
+
 func recoveryUnaryInterceptor(m *telemetry.Metrics) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -1107,6 +2173,61 @@ func (a vectorReplayAdapter) LogsForVectorReplay(ctx context.Context, sinceID ui
 	return out, nil
 }
 
+// runStorageMigration copies the configured database into another one with
+// storage.MigrateStorage and graphrag.MigrateStorage, logging progress at
+// most every 5s per table. Interrupting it is safe: running it again
+// resumes where it stopped.
+func runStorageMigration(cfg *config.Config, toDriver, toDSN string, batchSize int) error {
+	toDriver = strings.ToLower(strings.TrimSpace(toDriver))
+	if toDriver == "" {
+		return errors.New("-migrate-to-driver is required")
+	}
+	if strings.EqualFold(toDriver, cfg.DBDriver) && toDSN == cfg.DBDSN {
+		return errors.New("source and destination are the same database")
+	}
+	src, err := storage.NewDatabase(cfg.DBDriver, cfg.DBDSN)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	dst, err := storage.NewDatabase(toDriver, toDSN)
+	if err != nil {
+		return fmt.Errorf("open destination: %w", err)
+	}
+	if err := storage.AutoMigrateModelsWithOptions(dst, toDriver, storage.MigrateOptions{
+		PostgresPartitioning:   cfg.DBPostgresPartitioning,
+		PartitionLookaheadDays: cfg.DBPartitionLookaheadDays,
+	}); err != nil {
+		return fmt.Errorf("migrate destination schema: %w", err)
+	}
+	if err := graphrag.AutoMigrateGraphRAG(dst); err != nil {
+		return fmt.Errorf("migrate destination schema: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	slog.Info("📦 Migrating storage", "from", cfg.DBDriver, "to", toDriver, "batch_size", batchSize)
+	started := time.Now()
+	var lastLog time.Time
+	opts := storage.StorageMigrationOptions{
+		BatchSize: batchSize,
+		Progress: func(p storage.MigrationProgress) {
+			if !p.Done && time.Since(lastLog) < 5*time.Second {
+				return
+			}
+			lastLog = time.Now()
+			slog.Info("Migrating table", "table", p.Table, "copied", p.Copied, "total", p.Total, "already_copied", p.Resumed, "done", p.Done)
+		},
+	}
+	if err := storage.MigrateStorage(ctx, src, dst, toDriver, opts); err != nil {
+		return err
+	}
+	if err := graphrag.MigrateStorage(ctx, src, dst, opts); err != nil {
+		return err
+	}
+	slog.Info("✅ Storage migration complete", "elapsed", time.Since(started).Round(time.Second))
+	return nil
+}
+
 func printBanner() {
 	banner := `
   ___ _____ _____ _     
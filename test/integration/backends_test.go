@@ -0,0 +1,152 @@
+//go:build integration
+// +build integration
+
+// Package integration drives the full receiver → pipeline → storage → query
+// path against real database backends.
+//
+// Each test runs once per backend: SQLite in a temp file always, and
+// Postgres and MySQL in throwaway testcontainers. Run with:
+//
+//	go test -race -tags=integration ./test/integration/...
+//
+// Requires a reachable Docker daemon for the container backends. Without
+// one they auto-skip (t.Skip) and only SQLite runs, so CI without Docker
+// is safe. INTEGRATION_BACKENDS (comma-separated, default
+// "sqlite,postgres,mysql") narrows the set.
+//
+// ClickHouse is not a storage backend in this tree, so it has no entry here.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// backend opens a migrated repository on one database and returns a
+// teardown closure. It skips the test when the database cannot be started.
+type backend struct {
+	name  string
+	setup func(t *testing.T) (*storage.Repository, func())
+}
+
+var allBackends = []backend{
+	{name: "sqlite", setup: setupSQLite},
+	{name: "postgres", setup: setupPostgres},
+	{name: "mysql", setup: setupMySQL},
+}
+
+// selectedBackends returns the backends named by INTEGRATION_BACKENDS, or
+// all of them when it is unset.
+func selectedBackends(t *testing.T) []backend {
+	t.Helper()
+	raw := strings.TrimSpace(os.Getenv("INTEGRATION_BACKENDS"))
+	if raw == "" {
+		return allBackends
+	}
+	var out []backend
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, b := range allBackends {
+			if b.name == name {
+				out = append(out, b)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("INTEGRATION_BACKENDS: unknown backend %q", name)
+		}
+	}
+	return out
+}
+
+// openRepo migrates db and wraps it in a Repository. cleanup runs on
+// failure and after the repository is closed on teardown.
+func openRepo(t *testing.T, driver, dsn string, cleanup func()) (*storage.Repository, func()) {
+	t.Helper()
+	db, err := storage.NewDatabase(driver, dsn)
+	if err != nil {
+		cleanup()
+		t.Fatalf("NewDatabase(%s): %v", driver, err)
+	}
+	if err := storage.AutoMigrateModels(db, driver); err != nil {
+		cleanup()
+		t.Fatalf("AutoMigrateModels(%s): %v", driver, err)
+	}
+	repo := storage.NewRepositoryFromDB(db, driver)
+	return repo, func() {
+		_ = repo.Close()
+		cleanup()
+	}
+}
+
+// setupSQLite uses a file rather than :memory: so every pipeline worker's
+// connection sees the same database.
+func setupSQLite(t *testing.T) (*storage.Repository, func()) {
+	t.Helper()
+	return openRepo(t, "sqlite", filepath.Join(t.TempDir(), "otel.db"), func() {})
+}
+
+func setupPostgres(t *testing.T) (*storage.Repository, func()) {
+	t.Helper()
+	ctx := context.Background()
+	c, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("otel_test"),
+		postgres.WithUsername("otel"),
+		postgres.WithPassword("otel"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		t.Skipf("docker unavailable, skipping postgres backend: %v", err)
+	}
+	terminate := func() { _ = c.Terminate(ctx) }
+	dsn, err := c.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		terminate()
+		t.Fatalf("ConnectionString: %v", err)
+	}
+	return openRepo(t, "postgres", dsn, terminate)
+}
+
+func setupMySQL(t *testing.T) (*storage.Repository, func()) {
+	t.Helper()
+	ctx := context.Background()
+	c, err := testcontainers.Run(ctx, "mysql:8.4",
+		testcontainers.WithExposedPorts("3306/tcp"),
+		testcontainers.WithEnv(map[string]string{
+			"MYSQL_ROOT_PASSWORD": "otel",
+			"MYSQL_DATABASE":      "otel_test",
+			"MYSQL_USER":          "otel",
+			"MYSQL_PASSWORD":      "otel",
+		}),
+		// The entrypoint starts a temporary server first; only the final
+		// one listens on 3306.
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("port: 3306  MySQL Community Server").WithStartupTimeout(3*time.Minute),
+		),
+	)
+	if err != nil {
+		if c != nil {
+			_ = c.Terminate(ctx)
+		}
+		t.Skipf("docker unavailable, skipping mysql backend: %v", err)
+	}
+	terminate := func() { _ = c.Terminate(ctx) }
+	endpoint, err := c.PortEndpoint(ctx, "3306/tcp", "")
+	if err != nil {
+		terminate()
+		t.Fatalf("PortEndpoint: %v", err)
+	}
+	dsn := fmt.Sprintf("otel:otel@tcp(%s)/otel_test?charset=utf8mb4&parseTime=True&loc=Local", endpoint)
+	return openRepo(t, "mysql", dsn, terminate)
+}
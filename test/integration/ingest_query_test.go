@@ -0,0 +1,220 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/config"
+	"github.com/RandomCodeSpace/otelcontext/internal/ingest"
+	"github.com/RandomCodeSpace/otelcontext/internal/storage"
+)
+
+// Defaults for INTEGRATION_TRACES and INTEGRATION_MIN_SPANS_PER_SEC, sized
+// to finish in seconds on a laptop. The throughput floor is a regression
+// tripwire, not a benchmark: it only catches order-of-magnitude slowdowns,
+// and is divided by raceFloorDivisor when the race detector is on.
+const (
+	defaultTraces         = 1000
+	defaultMinSpansPerSec = 1000
+	raceFloorDivisor      = 20
+	exportWorkers         = 8
+	landTimeout           = 2 * time.Minute
+)
+
+func envInt(t *testing.T, key string, def int) int {
+	t.Helper()
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		t.Fatalf("%s must be a positive integer, got %q", key, raw)
+	}
+	return n
+}
+
+// TestIngestQueryPath sends a generated workload through the OTLP trace
+// and log receivers and the async pipeline into each backend, then checks
+// the query layer returns exactly what was sent and that ingest kept up
+// with INTEGRATION_MIN_SPANS_PER_SEC.
+func TestIngestQueryPath(t *testing.T) {
+	n := envInt(t, "INTEGRATION_TRACES", defaultTraces)
+	defFloor := defaultMinSpansPerSec
+	if raceEnabled {
+		defFloor /= raceFloorDivisor
+	}
+	floor := envInt(t, "INTEGRATION_MIN_SPANS_PER_SEC", defFloor)
+	start := time.Now().UTC().Add(-20 * time.Minute).Truncate(time.Minute)
+	w := generateWorkload(42, n, start, 10*time.Minute)
+
+	for _, b := range selectedBackends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			repo, teardown := b.setup(t)
+			defer teardown()
+
+			elapsed := ingestWorkload(t, repo, w)
+			rate := float64(w.spans) / elapsed.Seconds()
+			t.Logf("%s: %d traces, %d spans, %d logs landed in %s (%.0f spans/s)",
+				b.name, len(w.traces), w.spans, w.logs, elapsed.Round(time.Millisecond), rate)
+			if rate < float64(floor) {
+				t.Errorf("ingest throughput %.0f spans/s below floor %d", rate, floor)
+			}
+
+			assertQueries(t, repo, w)
+		})
+	}
+}
+
+// ingestWorkload exports every trace and its logs through in-process
+// receivers backed by a started pipeline, and returns how long it took
+// until every span was readable.
+func ingestWorkload(t *testing.T, repo *storage.Repository, w *workload) time.Duration {
+	t.Helper()
+	cfg := &config.Config{
+		IngestMinSeverity:          "DEBUG",
+		SamplingLatencyThresholdMs: 500,
+		DefaultTenant:              storage.DefaultTenantID,
+	}
+	pipeline := ingest.NewPipeline(repo, nil, ingest.PipelineConfig{
+		Capacity:      4 * len(w.traces),
+		Workers:       4,
+		SoftThreshold: 1.0,
+		BatchSize:     500,
+		FlushInterval: 20 * time.Millisecond,
+		WriteRetries:  2,
+	})
+	pipeline.Start(context.Background())
+	defer pipeline.Stop()
+
+	traces := ingest.NewTraceServer(repo, nil, cfg)
+	traces.SetPipeline(pipeline)
+	logs := ingest.NewLogsServer(repo, nil, cfg)
+	logs.SetPipeline(pipeline)
+
+	ctx := context.Background()
+	began := time.Now()
+	work := make(chan genTrace)
+	var wg sync.WaitGroup
+	for range exportWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range work {
+				if _, err := traces.Export(ctx, g.traceReq); err != nil {
+					t.Errorf("trace Export %s: %v", g.id, err)
+				}
+				if _, err := logs.Export(ctx, g.logsReq); err != nil {
+					t.Errorf("logs Export %s: %v", g.id, err)
+				}
+			}
+		}()
+	}
+	for _, g := range w.traces {
+		work <- g
+	}
+	close(work)
+	wg.Wait()
+
+	deadline := time.Now().Add(landTimeout)
+	for {
+		var spans, logRows int64
+		db := repo.DB()
+		if err := db.Model(&storage.Span{}).Count(&spans).Error; err != nil {
+			t.Fatalf("count spans: %v", err)
+		}
+		if err := db.Model(&storage.Log{}).Count(&logRows).Error; err != nil {
+			t.Fatalf("count logs: %v", err)
+		}
+		if spans == int64(w.spans) && logRows == int64(w.logs) {
+			return time.Since(began)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("after %s: %d/%d spans and %d/%d logs landed (pipeline %+v)",
+				landTimeout, spans, w.spans, logRows, w.logs, pipeline.Stats())
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+// assertQueries checks the read APIs the UI and MCP tools are built on
+// against the workload's known totals.
+func assertQueries(t *testing.T, repo *storage.Repository, w *workload) {
+	t.Helper()
+	ctx := context.Background()
+	from, to := w.start.Add(-time.Minute), w.end.Add(time.Minute)
+
+	all, err := repo.GetTracesFiltered(ctx, from, to, nil, "", "", 10, 0, "", "")
+	if err != nil {
+		t.Fatalf("GetTracesFiltered: %v", err)
+	}
+	if all.Total != int64(len(w.traces)) {
+		t.Errorf("GetTracesFiltered total = %d, want %d", all.Total, len(w.traces))
+	}
+	failed, err := repo.GetTracesFiltered(ctx, from, to, nil, "ERROR", "", 10, 0, "", "")
+	if err != nil {
+		t.Fatalf("GetTracesFiltered(ERROR): %v", err)
+	}
+	if failed.Total != int64(w.errorTraces) {
+		t.Errorf("error traces = %d, want %d", failed.Total, w.errorTraces)
+	}
+	for svc, want := range w.tracesByRoot {
+		got, err := repo.GetTracesFiltered(ctx, from, to, []string{svc}, "", "", 1, 0, "", "")
+		if err != nil {
+			t.Fatalf("GetTracesFiltered(%s): %v", svc, err)
+		}
+		if got.Total != int64(want) {
+			t.Errorf("traces rooted in %s = %d, want %d", svc, got.Total, want)
+		}
+	}
+
+	stats, err := repo.GetDashboardStats(ctx, from, to, nil)
+	if err != nil {
+		t.Fatalf("GetDashboardStats: %v", err)
+	}
+	if stats.TotalTraces != int64(len(w.traces)) || stats.TotalErrors != int64(w.errorTraces) ||
+		stats.TotalLogs != int64(w.logs) || stats.ActiveServices != int64(len(w.tracesByRoot)) {
+		t.Errorf("dashboard = traces %d errors %d logs %d services %d, want %d %d %d %d",
+			stats.TotalTraces, stats.TotalErrors, stats.TotalLogs, stats.ActiveServices,
+			len(w.traces), w.errorTraces, w.logs, len(w.tracesByRoot))
+	}
+
+	points, err := repo.GetTrafficMetrics(ctx, from, to, nil, time.Minute)
+	if err != nil {
+		t.Fatalf("GetTrafficMetrics: %v", err)
+	}
+	var count, errCount int64
+	for _, p := range points {
+		count += p.Count
+		errCount += p.ErrorCount
+	}
+	if count != int64(len(w.traces)) || errCount != int64(w.errorTraces) {
+		t.Errorf("traffic = %d requests / %d errors, want %d / %d", count, errCount, len(w.traces), w.errorTraces)
+	}
+
+	// Spot-check whole traces: the first (failed) one and a healthy one.
+	for _, g := range []genTrace{w.traces[0], w.traces[len(w.traces)/2+1]} {
+		tr, err := repo.GetTrace(ctx, g.id)
+		if err != nil {
+			t.Fatalf("GetTrace(%s): %v", g.id, err)
+		}
+		if tr.ServiceName != g.rootService || len(tr.Spans) != g.spans || len(tr.Logs) != g.logs {
+			t.Errorf("trace %s = service %s, %d spans, %d logs; want %s, %d, %d",
+				g.id, tr.ServiceName, len(tr.Spans), len(tr.Logs), g.rootService, g.spans, g.logs)
+		}
+		logRows, total, err := repo.GetLogsV2(ctx, storage.LogFilter{TraceID: g.id, StartTime: from, EndTime: to, Limit: 100})
+		if err != nil {
+			t.Fatalf("GetLogsV2(%s): %v", g.id, err)
+		}
+		if total != int64(g.logs) || len(logRows) != g.logs {
+			t.Errorf("GetLogsV2(%s) = %d rows, total %d; want %d", g.id, len(logRows), total, g.logs)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build integration && !race
+// +build integration,!race
+
+package integration
+
+const raceEnabled = false
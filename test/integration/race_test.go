@@ -0,0 +1,9 @@
+//go:build integration && race
+// +build integration,race
+
+package integration
+
+// raceEnabled reports whether the race detector is on. It slows SQLite's
+// pure-Go driver by well over an order of magnitude, so the default
+// throughput floor is scaled down to match.
+const raceEnabled = true
@@ -0,0 +1,169 @@
+//go:build integration
+// +build integration
+
+package integration
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/RandomCodeSpace/otelcontext/internal/traceid"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+var workloadServices = []string{
+	"frontend", "checkout", "payment", "inventory",
+	"shipping", "auth", "user", "notification",
+}
+
+// errorEvery marks every errorEvery-th trace as failed.
+const errorEvery = 10
+
+// genTrace is one generated trace: its OTLP requests and what the storage
+// layer should hold once they land.
+type genTrace struct {
+	id          string
+	rootService string
+	isError     bool
+	spans       int
+	// logs counts the explicit log records plus the one ERROR log the
+	// receiver synthesizes per failed span.
+	logs int
+
+	traceReq *coltracepb.ExportTraceServiceRequest
+	logsReq  *collogspb.ExportLogsServiceRequest
+}
+
+// workload is a deterministic batch of traces spread evenly over
+// [start, end).
+type workload struct {
+	start, end time.Time
+	traces     []genTrace
+
+	spans, logs, errorTraces int
+	tracesByRoot             map[string]int
+}
+
+// generateWorkload builds n traces across workloadServices. Each trace has
+// a root span and 2–5 children in random services, and one INFO log per
+// span. Every errorEvery-th trace fails: its first child and the root
+// carry an ERROR status, as an error bubbling up the call chain would.
+//
+// The root's service is the first ResourceSpans entry and the root the
+// first span in it, because the receiver derives the trace row from the
+// first span of a trace it stores.
+func generateWorkload(seed int64, n int, start time.Time, window time.Duration) *workload {
+	rng := rand.New(rand.NewSource(seed)) // #nosec G404 -- deterministic test data
+	w := &workload{
+		start:        start,
+		end:          start.Add(window),
+		traces:       make([]genTrace, 0, n),
+		tracesByRoot: make(map[string]int),
+	}
+	step := window / time.Duration(n)
+
+	for i := 0; i < n; i++ {
+		rawTrace := make([]byte, 16)
+		binary.BigEndian.PutUint64(rawTrace[:8], uint64(i+1))  // #nosec G115 -- i is non-negative
+		binary.BigEndian.PutUint64(rawTrace[8:], rng.Uint64()) // unique and non-zero
+		id, _ := traceid.Encode(rawTrace)
+
+		root := workloadServices[i%len(workloadServices)]
+		isErr := i%errorEvery == 0
+		traceStart := start.Add(time.Duration(i) * step)
+		rootDur := time.Duration(50+rng.Intn(350)) * time.Millisecond
+
+		spanID := func() []byte {
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, rng.Uint64()|1)
+			return b
+		}
+
+		rootID := spanID()
+		bySvc := map[string][]*tracepb.Span{}
+		order := []string{root}
+		add := func(svc string, s *tracepb.Span) {
+			if _, ok := bySvc[svc]; !ok && svc != root {
+				order = append(order, svc)
+			}
+			bySvc[svc] = append(bySvc[svc], s)
+		}
+		mkSpan := func(id, parent []byte, name string, kind tracepb.Span_SpanKind, from time.Time, dur time.Duration, failed bool) *tracepb.Span {
+			s := &tracepb.Span{
+				TraceId:           rawTrace,
+				SpanId:            id,
+				ParentSpanId:      parent,
+				Name:              name,
+				Kind:              kind,
+				StartTimeUnixNano: uint64(from.UnixNano()),          // #nosec G115 -- post-1970 timestamps
+				EndTimeUnixNano:   uint64(from.Add(dur).UnixNano()), // #nosec G115 -- post-1970 timestamps
+				Attributes: []*commonpb.KeyValue{
+					strAttr("http.method", "GET"),
+				},
+			}
+			if failed {
+				s.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: name + " failed"}
+			}
+			return s
+		}
+
+		add(root, mkSpan(rootID, nil, "GET /"+root, tracepb.Span_SPAN_KIND_SERVER, traceStart, rootDur, isErr))
+		children := 2 + rng.Intn(4)
+		for c := 0; c < children; c++ {
+			svc := workloadServices[rng.Intn(len(workloadServices))]
+			off := time.Duration(rng.Int63n(int64(rootDur / 2)))
+			dur := time.Duration(rng.Int63n(int64(rootDur-off))) + time.Millisecond
+			add(svc, mkSpan(spanID(), rootID, fmt.Sprintf("%s.op%d", svc, c), tracepb.Span_SPAN_KIND_CLIENT, traceStart.Add(off), dur, isErr && c == 0))
+		}
+
+		g := genTrace{id: id, rootService: root, isError: isErr, spans: children + 1}
+		g.traceReq = &coltracepb.ExportTraceServiceRequest{}
+		g.logsReq = &collogspb.ExportLogsServiceRequest{}
+		for _, svc := range order {
+			spans := bySvc[svc]
+			res := &resourcepb.Resource{Attributes: []*commonpb.KeyValue{strAttr("service.name", svc)}}
+			g.traceReq.ResourceSpans = append(g.traceReq.ResourceSpans, &tracepb.ResourceSpans{
+				Resource:   res,
+				ScopeSpans: []*tracepb.ScopeSpans{{Spans: spans}},
+			})
+			records := make([]*logspb.LogRecord, 0, len(spans))
+			for _, s := range spans {
+				records = append(records, &logspb.LogRecord{
+					TimeUnixNano: s.StartTimeUnixNano,
+					SeverityText: "INFO",
+					Body:         &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "handled " + s.Name}},
+					TraceId:      rawTrace,
+					SpanId:       s.SpanId,
+				})
+				g.logs++
+				if s.Status != nil {
+					g.logs++
+				}
+			}
+			g.logsReq.ResourceLogs = append(g.logsReq.ResourceLogs, &logspb.ResourceLogs{
+				Resource:  res,
+				ScopeLogs: []*logspb.ScopeLogs{{LogRecords: records}},
+			})
+		}
+
+		w.traces = append(w.traces, g)
+		w.spans += g.spans
+		w.logs += g.logs
+		w.tracesByRoot[root]++
+		if isErr {
+			w.errorTraces++
+		}
+	}
+	return w
+}
+
+func strAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}